@@ -0,0 +1,98 @@
+package admin
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/eleme/lindb/broker/api"
+	"github.com/eleme/lindb/broker/middleware"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/service"
+)
+
+// defaultAuditLogLimit is the default number of audit logs listed
+const defaultAuditLogLimit = 100
+
+// AuditAPI represents audit log rest api, lists the recent administrative operations and their initiators
+type AuditAPI struct {
+	auditService service.AuditService
+}
+
+// NewAuditAPI creates audit log api instance
+func NewAuditAPI(auditService service.AuditService) *AuditAPI {
+	return &AuditAPI{
+		auditService: auditService,
+	}
+}
+
+// List lists the newest audit logs ordered by time desc, the number of logs is limited by param limit
+func (a *AuditAPI) List(w http.ResponseWriter, r *http.Request) {
+	limitStr, err := api.GetParamsFromRequest("limit", r, strconv.Itoa(defaultAuditLogLimit), false)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	logs, err := a.auditService.List(limit)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	api.OK(w, logs)
+}
+
+// auditor records the audit log of administrative operations, does nothing if audit log is disabled
+type auditor struct {
+	auditService service.AuditService
+	logger       *logger.Logger
+}
+
+// newAuditor creates the auditor, audit log is disabled if audit service is nil
+func newAuditor(auditService service.AuditService) *auditor {
+	return &auditor{
+		auditService: auditService,
+		logger:       logger.GetLogger("broker/api/admin/audit"),
+	}
+}
+
+// record records the audit log of operation which is done successfully,
+// the failure of recording is logged only, because the operation has been done.
+func (a *auditor) record(r *http.Request, operation, target string, detail interface{}) {
+	if a.auditService == nil {
+		return
+	}
+	log := models.AuditLog{
+		Operation: operation,
+		Target:    target,
+		Initiator: initiator(r),
+	}
+	if detail != nil {
+		data, err := json.Marshal(detail)
+		if err == nil {
+			log.Detail = string(data)
+		}
+	}
+	if err := a.auditService.Record(log); err != nil {
+		a.logger.Error("record audit log error",
+			logger.String("operation", operation), logger.String("target", target), logger.Error(err))
+	}
+}
+
+// initiator returns the user name of request, returns the client's host if user is unknown
+func initiator(r *http.Request) string {
+	if userName := middleware.GetUserName(r); len(userName) > 0 {
+		return userName
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
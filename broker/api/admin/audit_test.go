@@ -0,0 +1,119 @@
+package admin
+
+import (
+	"net/http"
+	"testing"
+
+	"gopkg.in/check.v1"
+
+	"github.com/eleme/lindb/broker/middleware"
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/state"
+	"github.com/eleme/lindb/service"
+)
+
+type testAuditAPISuite struct {
+	mock.RepoTestSuite
+}
+
+func TestAuditAPI(t *testing.T) {
+	check.Suite(&testAuditAPISuite{})
+	test = t
+	check.TestingT(t)
+}
+
+func (ts *testAuditAPISuite) TestListAuditLogs(c *check.C) {
+	repo, _ := state.NewRepo(state.Config{
+		Endpoints: ts.Cluster.Endpoints,
+	})
+	auditService := service.NewAuditService(repo, 0)
+	databaseAPI := NewDatabaseAPI(service.NewDatabaseService(repo), auditService)
+	clusterAPI := NewStorageClusterAPI(service.NewStorageClusterService(repo), auditService)
+	api := NewAuditAPI(auditService)
+
+	db := models.Database{
+		Name: "test",
+		Clusters: []models.DatabaseCluster{
+			{
+				Name:          "test",
+				NumOfShard:    12,
+				ReplicaFactor: 3,
+			},
+		},
+	}
+	for i := 0; i < 2; i++ {
+		mock.DoRequest(test, &mock.HTTPHandler{
+			Method:         http.MethodPost,
+			URL:            "/database",
+			RequestBody:    db,
+			HandlerFunc:    databaseAPI.Save,
+			ExpectHTTPCode: 204,
+		})
+	}
+	// failure operation isn't recorded
+	mock.DoRequest(test, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/database",
+		RequestBody:    models.Database{},
+		HandlerFunc:    databaseAPI.Save,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(test, &mock.HTTPHandler{
+		Method:         http.MethodDelete,
+		URL:            "/storage/cluster?name=test",
+		HandlerFunc:    clusterAPI.DeleteByName,
+		ExpectHTTPCode: 204,
+	})
+
+	logs, err := auditService.List(0)
+	c.Assert(err, check.IsNil)
+	c.Assert(logs, check.HasLen, 3)
+	operations := map[string]bool{}
+	for _, log := range logs {
+		operations[log.Operation] = true
+	}
+	c.Assert(operations, check.DeepEquals, map[string]bool{
+		models.AuditCreateDatabase:       true,
+		models.AuditUpdateDatabase:       true,
+		models.AuditDeleteStorageCluster: true,
+	})
+
+	mock.DoRequest(test, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/audit/list?limit=3",
+		HandlerFunc:    api.List,
+		ExpectHTTPCode: 200,
+		ExpectResponse: logs,
+	})
+	mock.DoRequest(test, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/audit/list?limit=a",
+		HandlerFunc:    api.List,
+		ExpectHTTPCode: 500,
+	})
+	_ = repo.Close()
+	mock.DoRequest(test, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/audit/list",
+		HandlerFunc:    api.List,
+		ExpectHTTPCode: 500,
+	})
+}
+
+func TestInitiator(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodPost, "/database", nil)
+	r.RemoteAddr = "1.1.1.1:1234"
+	if initiator(r) != "1.1.1.1" {
+		t.Fatal("initiator should be the host of client")
+	}
+	r.RemoteAddr = "pipe"
+	if initiator(r) != "pipe" {
+		t.Fatal("initiator should be the address of client")
+	}
+	token, _ := middleware.CreateToken(models.User{UserName: "admin", Password: "admin123"})
+	r.Header.Set("Authorization", token)
+	if initiator(r) != "admin" {
+		t.Fatal("initiator should be the user")
+	}
+}
@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/eleme/lindb/broker/api"
+	"github.com/eleme/lindb/pkg/backup"
+)
+
+// BackupAPI represents backup set rest api, lists/inspects the backup sets in backup target
+type BackupAPI struct {
+	catalog backup.Catalog
+}
+
+// NewBackupAPI creates backup api instance
+func NewBackupAPI(catalog backup.Catalog) *BackupAPI {
+	return &BackupAPI{
+		catalog: catalog,
+	}
+}
+
+// List lists all backup sets ordered by backup id
+func (b *BackupAPI) List(w http.ResponseWriter, r *http.Request) {
+	sets, err := b.catalog.ListSets()
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	api.OK(w, sets)
+}
+
+// GetByID gets the backup set by id, includes the manifests of finished parts
+func (b *BackupAPI) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := api.GetParamsFromRequest("id", r, "", true)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	set, err := b.catalog.GetSet(id)
+	if err == backup.ErrNotExist {
+		api.NotFound(w)
+		return
+	}
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	api.OK(w, set)
+}
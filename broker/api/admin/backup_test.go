@@ -0,0 +1,74 @@
+package admin
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/pkg/backup"
+)
+
+func TestBackupAPI(t *testing.T) {
+	defer func() {
+		_ = os.RemoveAll("test_data")
+	}()
+	target, _ := backup.NewTarget(backup.Config{URL: "test_data"})
+	catalog := backup.NewCatalog(target)
+	api := NewBackupAPI(catalog)
+
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/backup/list",
+		HandlerFunc:    api.List,
+		ExpectHTTPCode: 200,
+	})
+
+	part := backup.Part{Cluster: "test", Database: "db", Node: "1.1.1.1:2080", ShardIDs: []int{1}}
+	_ = catalog.SaveSet(&backup.SetManifest{ID: "20191017000000", CreateTime: 10, Parts: []backup.Part{part}})
+	_ = catalog.SavePart("20191017000000", &backup.PartManifest{Part: part, Size: 10})
+	set, _ := catalog.GetSet("20191017000000")
+
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/backup/list",
+		HandlerFunc:    api.List,
+		ExpectHTTPCode: 200,
+		ExpectResponse: []*backup.SetInfo{set},
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/backup?id=20191017000000",
+		HandlerFunc:    api.GetByID,
+		ExpectHTTPCode: 200,
+		ExpectResponse: set,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/backup?id=20191018000000",
+		HandlerFunc:    api.GetByID,
+		ExpectHTTPCode: 404,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/backup",
+		HandlerFunc:    api.GetByID,
+		ExpectHTTPCode: 500,
+	})
+
+	// bad manifest
+	_ = target.Put("20191018000000/MANIFEST", strings.NewReader("a"), 1)
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/backup?id=20191018000000",
+		HandlerFunc:    api.GetByID,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/backup/list",
+		HandlerFunc:    api.List,
+		ExpectHTTPCode: 500,
+	})
+}
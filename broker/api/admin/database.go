@@ -11,12 +11,14 @@ import (
 // DatabaseAPI represents database admin rest api
 type DatabaseAPI struct {
 	databaseService service.DatabaseService
+	auditor         *auditor
 }
 
-// NewDatabaseAPI creates database api instance
-func NewDatabaseAPI(databaseService service.DatabaseService) *DatabaseAPI {
+// NewDatabaseAPI creates database api instance, the operations are recorded into audit log if audit service isn't nil
+func NewDatabaseAPI(databaseService service.DatabaseService, auditService service.AuditService) *DatabaseAPI {
 	return &DatabaseAPI{
 		databaseService: databaseService,
+		auditor:         newAuditor(auditService),
 	}
 }
 
@@ -29,7 +31,6 @@ func (d *DatabaseAPI) GetByName(w http.ResponseWriter, r *http.Request) {
 	}
 	database, err := d.databaseService.Get(databaseName)
 	if err != nil {
-		//TODO add not found error?????
 		api.Error(w, err)
 		return
 	}
@@ -45,10 +46,15 @@ func (d *DatabaseAPI) Save(w http.ResponseWriter, r *http.Request) {
 		api.Error(w, err)
 		return
 	}
+	operation := models.AuditCreateDatabase
+	if _, err := d.databaseService.Get(database.Name); err == nil {
+		operation = models.AuditUpdateDatabase
+	}
 	err = d.databaseService.Save(database)
 	if err != nil {
 		api.Error(w, err)
 		return
 	}
+	d.auditor.record(r, operation, database.Name, database)
 	api.NoContent(w)
 }
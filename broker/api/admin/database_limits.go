@@ -0,0 +1,56 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/eleme/lindb/broker/api"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/service"
+)
+
+// DatabaseLimitsAPI represents database limits admin rest api, the limits(e.g. max tags of metrics) are
+// adjusted cluster-wide at runtime, storage nodes watch the limits and apply them to memory-database of shards
+type DatabaseLimitsAPI struct {
+	databaseLimitsService service.DatabaseLimitsService
+	auditor               *auditor
+}
+
+// NewDatabaseLimitsAPI creates database limits api instance, the operations are recorded into audit log
+// if audit service isn't nil
+func NewDatabaseLimitsAPI(databaseLimitsService service.DatabaseLimitsService,
+	auditService service.AuditService) *DatabaseLimitsAPI {
+	return &DatabaseLimitsAPI{
+		databaseLimitsService: databaseLimitsService,
+		auditor:               newAuditor(auditService),
+	}
+}
+
+// GetByName gets the limits of database by the name
+func (d *DatabaseLimitsAPI) GetByName(w http.ResponseWriter, r *http.Request) {
+	databaseName, err := api.GetParamsFromRequest("name", r, "", true)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	limits, err := d.databaseLimitsService.Get(databaseName)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	api.OK(w, limits)
+}
+
+// Save saves the limits of database, the limits are removed if empty
+func (d *DatabaseLimitsAPI) Save(w http.ResponseWriter, r *http.Request) {
+	limits := models.DatabaseLimits{}
+	if err := api.GetJSONBodyFromRequest(r, &limits); err != nil {
+		api.Error(w, err)
+		return
+	}
+	if err := d.databaseLimitsService.Save(limits); err != nil {
+		api.Error(w, err)
+		return
+	}
+	d.auditor.record(r, models.AuditSaveDatabaseLimits, limits.Database, limits)
+	api.NoContent(w)
+}
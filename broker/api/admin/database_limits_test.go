@@ -0,0 +1,87 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+)
+
+type mockDatabaseLimitsService struct {
+	limits *models.DatabaseLimits
+	err    error
+}
+
+func (s *mockDatabaseLimitsService) Save(limits models.DatabaseLimits) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.limits = &limits
+	return nil
+}
+
+func (s *mockDatabaseLimitsService) Get(databaseName string) (*models.DatabaseLimits, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.limits, nil
+}
+
+func TestDatabaseLimitsAPI(t *testing.T) {
+	srv := &mockDatabaseLimitsService{}
+	auditService := &mockAuditService{}
+	api := NewDatabaseLimitsAPI(srv, auditService)
+
+	limits := models.DatabaseLimits{Database: "test", MaxTagsLimits: map[string]uint32{"cpu": 100}}
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/database/limits",
+		RequestBody:    limits,
+		HandlerFunc:    api.Save,
+		ExpectHTTPCode: 204,
+	})
+	assert.Equal(t, &limits, srv.limits)
+	assert.Len(t, auditService.logs, 1)
+	assert.Equal(t, models.AuditSaveDatabaseLimits, auditService.logs[0].Operation)
+	assert.Equal(t, "test", auditService.logs[0].Target)
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/database/limits?name=test",
+		HandlerFunc:    api.GetByName,
+		ExpectHTTPCode: 200,
+		ExpectResponse: limits,
+	})
+
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/database/limits",
+		RequestBody:    "bad request",
+		HandlerFunc:    api.Save,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/database/limits",
+		HandlerFunc:    api.GetByName,
+		ExpectHTTPCode: 500,
+	})
+	srv.err = fmt.Errorf("err")
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/database/limits",
+		RequestBody:    limits,
+		HandlerFunc:    api.Save,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/database/limits?name=test",
+		HandlerFunc:    api.GetByName,
+		ExpectHTTPCode: 500,
+	})
+	assert.Len(t, auditService.logs, 1)
+}
@@ -27,7 +27,7 @@ func (ts *testDatabaseAPISuite) TestGetDatabase(c *check.C) {
 		Endpoints: ts.Cluster.Endpoints,
 	})
 
-	api := NewDatabaseAPI(service.NewDatabaseService(repo))
+	api := NewDatabaseAPI(service.NewDatabaseService(repo), nil)
 
 	db := models.Database{
 		Name: "test",
@@ -75,6 +75,6 @@ func (ts *testDatabaseAPISuite) TestGetDatabase(c *check.C) {
 		Method:         http.MethodGet,
 		URL:            "/database?name=test2",
 		HandlerFunc:    api.GetByName,
-		ExpectHTTPCode: 500,
+		ExpectHTTPCode: 404,
 	})
 }
@@ -0,0 +1,36 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/eleme/lindb/broker/api"
+	"github.com/eleme/lindb/service"
+)
+
+// DiskUsageAPI represents the disk usage rest api, reports the on-disk bytes of databases by storage node/shard/family,
+// so that operators can find out which database consumes the disk
+type DiskUsageAPI struct {
+	diskUsageService service.DiskUsageService
+}
+
+// NewDiskUsageAPI creates disk usage api instance
+func NewDiskUsageAPI(diskUsageService service.DiskUsageService) *DiskUsageAPI {
+	return &DiskUsageAPI{
+		diskUsageService: diskUsageService,
+	}
+}
+
+// Get returns the disk usage of the database specified by the optional param 'db', returns all databases' if absent
+func (d *DiskUsageAPI) Get(w http.ResponseWriter, r *http.Request) {
+	databaseName, err := api.GetParamsFromRequest("db", r, "", false)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	report, err := d.diskUsageService.Report(r.Context(), databaseName)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	api.OK(w, report)
+}
@@ -0,0 +1,60 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+)
+
+type mockDiskUsageService struct {
+	database string
+	err      error
+}
+
+func (s *mockDiskUsageService) Report(ctx context.Context, database string) (*models.DiskUsageReport, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.database = database
+	return models.NewDiskUsageReport([]models.DatabaseDiskUsage{{Database: "db", Node: "1.1.1.1:2000", Bytes: 10}}), nil
+}
+
+func TestDiskUsageAPI_Get(t *testing.T) {
+	srv := &mockDiskUsageService{err: fmt.Errorf("err")}
+	api := NewDiskUsageAPI(srv)
+
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/storage/disk-usage",
+		HandlerFunc:    api.Get,
+		ExpectHTTPCode: 500,
+	})
+
+	srv.err = nil
+	expect := &models.DiskUsageReport{
+		Databases: []models.DiskUsageSummary{{Database: "db", Bytes: 10, Nodes: 1}},
+		Details:   []models.DatabaseDiskUsage{{Database: "db", Node: "1.1.1.1:2000", Bytes: 10}},
+	}
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/storage/disk-usage",
+		HandlerFunc:    api.Get,
+		ExpectHTTPCode: 200,
+		ExpectResponse: expect,
+	})
+	assert.Equal(t, "", srv.database)
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/storage/disk-usage?db=db",
+		HandlerFunc:    api.Get,
+		ExpectHTTPCode: 200,
+		ExpectResponse: expect,
+	})
+	assert.Equal(t, "db", srv.database)
+}
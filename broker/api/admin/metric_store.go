@@ -0,0 +1,46 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/eleme/lindb/broker/api"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/service"
+)
+
+// MetricStoreAPI represents metric store admin rest api, resets/drops the metric store in memory-database of shards,
+// so that operators can recover from a cardinality incident without restarting storage nodes
+type MetricStoreAPI struct {
+	metricStoreService service.MetricStoreService
+	auditor            *auditor
+}
+
+// NewMetricStoreAPI creates metric store api instance, the operations are recorded into audit log
+// if audit service isn't nil
+func NewMetricStoreAPI(metricStoreService service.MetricStoreService, auditService service.AuditService) *MetricStoreAPI {
+	return &MetricStoreAPI{
+		metricStoreService: metricStoreService,
+		auditor:            newAuditor(auditService),
+	}
+}
+
+// Manage resets or drops the metric store in all replicas of database's shards,
+// returns the result of each shard replica
+func (m *MetricStoreAPI) Manage(w http.ResponseWriter, r *http.Request) {
+	req := &models.MetricStoreRequest{}
+	if err := api.GetJSONBodyFromRequest(r, req); err != nil {
+		api.Error(w, err)
+		return
+	}
+	results, err := m.metricStoreService.Manage(r.Context(), req)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	operation := models.AuditResetMetricStore
+	if req.Action == models.DropMetricStore {
+		operation = models.AuditDropMetricStore
+	}
+	m.auditor.record(r, operation, req.Database, req)
+	api.OK(w, results)
+}
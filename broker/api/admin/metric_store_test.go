@@ -0,0 +1,86 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+)
+
+type mockMetricStoreService struct {
+	req *models.MetricStoreRequest
+	err error
+}
+
+func (s *mockMetricStoreService) Manage(ctx context.Context,
+	req *models.MetricStoreRequest) ([]models.MetricStoreResult, error) {
+	s.req = req
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []models.MetricStoreResult{{Node: "127.0.0.1:2000", ShardID: 1}}, nil
+}
+
+type mockAuditService struct {
+	logs []models.AuditLog
+}
+
+func (s *mockAuditService) Record(log models.AuditLog) error {
+	s.logs = append(s.logs, log)
+	return nil
+}
+
+func (s *mockAuditService) List(limit int) ([]models.AuditLog, error) {
+	return s.logs, nil
+}
+
+func TestMetricStoreAPI_Manage(t *testing.T) {
+	srv := &mockMetricStoreService{}
+	auditService := &mockAuditService{}
+	api := NewMetricStoreAPI(srv, auditService)
+
+	req := &models.MetricStoreRequest{Database: "test", MetricName: "cpu", Action: models.DropMetricStore}
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/metric/store",
+		RequestBody:    req,
+		HandlerFunc:    api.Manage,
+		ExpectHTTPCode: 200,
+		ExpectResponse: []models.MetricStoreResult{{Node: "127.0.0.1:2000", ShardID: 1}},
+	})
+	assert.Equal(t, req, srv.req)
+	req.Action = models.ResetMetricStore
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/metric/store",
+		RequestBody:    req,
+		HandlerFunc:    api.Manage,
+		ExpectHTTPCode: 200,
+	})
+	assert.Len(t, auditService.logs, 2)
+	assert.Equal(t, models.AuditDropMetricStore, auditService.logs[0].Operation)
+	assert.Equal(t, models.AuditResetMetricStore, auditService.logs[1].Operation)
+	assert.Equal(t, "test", auditService.logs[1].Target)
+
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/metric/store",
+		RequestBody:    "bad request",
+		HandlerFunc:    api.Manage,
+		ExpectHTTPCode: 500,
+	})
+	srv.err = fmt.Errorf("err")
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/metric/store",
+		RequestBody:    req,
+		HandlerFunc:    api.Manage,
+		ExpectHTTPCode: 500,
+	})
+	assert.Len(t, auditService.logs, 2)
+}
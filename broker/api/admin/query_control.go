@@ -0,0 +1,89 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/eleme/lindb/broker/api"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/query"
+	"github.com/eleme/lindb/service"
+)
+
+// QueryControlAPI represents the query control admin rest api, lists/kills the running queries of broker,
+// and manages the blacklist rules rejecting known-pathological queries
+type QueryControlAPI struct {
+	tracker          query.Tracker
+	blacklistService service.QueryBlacklistService
+	auditor          *auditor
+}
+
+// NewQueryControlAPI creates query control api instance, the operations are recorded into audit log
+// if audit service isn't nil
+func NewQueryControlAPI(tracker query.Tracker, blacklistService service.QueryBlacklistService,
+	auditService service.AuditService) *QueryControlAPI {
+	return &QueryControlAPI{
+		tracker:          tracker,
+		blacklistService: blacklistService,
+		auditor:          newAuditor(auditService),
+	}
+}
+
+// ListRunning lists the running queries of current broker
+func (q *QueryControlAPI) ListRunning(w http.ResponseWriter, r *http.Request) {
+	api.OK(w, q.tracker.List())
+}
+
+// Kill kills the running query of current broker by id, responses not found if the query not exist
+func (q *QueryControlAPI) Kill(w http.ResponseWriter, r *http.Request) {
+	id, err := api.GetParamsFromRequest("id", r, "", true)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	if !q.tracker.Kill(id) {
+		api.NotFound(w)
+		return
+	}
+	q.auditor.record(r, models.AuditKillQuery, id, nil)
+	api.NoContent(w)
+}
+
+// ListBlacklist lists all blacklist rules
+func (q *QueryControlAPI) ListBlacklist(w http.ResponseWriter, r *http.Request) {
+	rules, err := q.blacklistService.List()
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	api.OK(w, rules)
+}
+
+// SaveBlacklist saves the blacklist rule, the rule is applied by all brokers
+func (q *QueryControlAPI) SaveBlacklist(w http.ResponseWriter, r *http.Request) {
+	rule := models.QueryBlacklistRule{}
+	if err := api.GetJSONBodyFromRequest(r, &rule); err != nil {
+		api.Error(w, err)
+		return
+	}
+	if err := q.blacklistService.Save(rule); err != nil {
+		api.Error(w, err)
+		return
+	}
+	q.auditor.record(r, models.AuditSaveQueryBlacklist, rule.Name, rule)
+	api.NoContent(w)
+}
+
+// DeleteBlacklist deletes the blacklist rule by name
+func (q *QueryControlAPI) DeleteBlacklist(w http.ResponseWriter, r *http.Request) {
+	name, err := api.GetParamsFromRequest("name", r, "", true)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	if err := q.blacklistService.Delete(name); err != nil {
+		api.Error(w, err)
+		return
+	}
+	q.auditor.record(r, models.AuditDeleteQueryBlacklist, name, nil)
+	api.NoContent(w)
+}
@@ -0,0 +1,159 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/query"
+)
+
+type mockQueryBlacklistService struct {
+	rules map[string]models.QueryBlacklistRule
+	err   error
+}
+
+func (s *mockQueryBlacklistService) Save(rule models.QueryBlacklistRule) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.rules[rule.Name] = rule
+	return nil
+}
+
+func (s *mockQueryBlacklistService) Delete(name string) error {
+	if s.err != nil {
+		return s.err
+	}
+	delete(s.rules, name)
+	return nil
+}
+
+func (s *mockQueryBlacklistService) List() ([]models.QueryBlacklistRule, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	var rules []models.QueryBlacklistRule
+	for _, rule := range s.rules {
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func TestQueryControlAPI_Running(t *testing.T) {
+	tracker := query.NewTracker()
+	auditService := &mockAuditService{}
+	api := NewQueryControlAPI(tracker, &mockQueryBlacklistService{}, auditService)
+
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/query/running",
+		HandlerFunc:    api.ListRunning,
+		ExpectHTTPCode: 200,
+		ExpectResponse: []models.RunningQuery{},
+	})
+	_, q := tracker.Track(context.TODO(), &models.QueryRequest{Database: "db"})
+	defer q.Finish()
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/query/running",
+		HandlerFunc:    api.ListRunning,
+		ExpectHTTPCode: 200,
+	})
+
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodDelete,
+		URL:            "/query/running",
+		HandlerFunc:    api.Kill,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodDelete,
+		URL:            "/query/running?id=not_exist",
+		HandlerFunc:    api.Kill,
+		ExpectHTTPCode: 404,
+	})
+	assert.False(t, q.Killed())
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodDelete,
+		URL:            "/query/running?id=" + q.ID(),
+		HandlerFunc:    api.Kill,
+		ExpectHTTPCode: 204,
+	})
+	assert.True(t, q.Killed())
+	assert.Equal(t, 1, len(auditService.logs))
+	assert.Equal(t, models.AuditKillQuery, auditService.logs[0].Operation)
+	assert.Equal(t, q.ID(), auditService.logs[0].Target)
+}
+
+func TestQueryControlAPI_Blacklist(t *testing.T) {
+	srv := &mockQueryBlacklistService{rules: make(map[string]models.QueryBlacklistRule)}
+	auditService := &mockAuditService{}
+	api := NewQueryControlAPI(query.NewTracker(), srv, auditService)
+
+	rule := models.QueryBlacklistRule{Name: "url", GroupBy: "url", Reason: "too many series"}
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/query/blacklist",
+		RequestBody:    rule,
+		HandlerFunc:    api.SaveBlacklist,
+		ExpectHTTPCode: 204,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/query/blacklist",
+		HandlerFunc:    api.ListBlacklist,
+		ExpectHTTPCode: 200,
+		ExpectResponse: []models.QueryBlacklistRule{rule},
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodDelete,
+		URL:            "/query/blacklist",
+		HandlerFunc:    api.DeleteBlacklist,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodDelete,
+		URL:            "/query/blacklist?name=url",
+		HandlerFunc:    api.DeleteBlacklist,
+		ExpectHTTPCode: 204,
+	})
+	assert.Empty(t, srv.rules)
+	assert.Equal(t, 2, len(auditService.logs))
+	assert.Equal(t, models.AuditSaveQueryBlacklist, auditService.logs[0].Operation)
+	assert.Equal(t, models.AuditDeleteQueryBlacklist, auditService.logs[1].Operation)
+
+	srv.err = fmt.Errorf("err")
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/query/blacklist",
+		RequestBody:    rule,
+		HandlerFunc:    api.SaveBlacklist,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/query/blacklist",
+		RequestBody:    "bad",
+		HandlerFunc:    api.SaveBlacklist,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/query/blacklist",
+		HandlerFunc:    api.ListBlacklist,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodDelete,
+		URL:            "/query/blacklist?name=url",
+		HandlerFunc:    api.DeleteBlacklist,
+		ExpectHTTPCode: 500,
+	})
+	assert.Equal(t, 2, len(auditService.logs))
+}
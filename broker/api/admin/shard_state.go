@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/eleme/lindb/broker/api"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/service"
+)
+
+// ShardStateAPI represents shard state admin rest api, the shards of old time ranges are changed to
+// read-only or frozen, storage nodes watch the states and enforce them to the shards
+type ShardStateAPI struct {
+	shardStateService service.ShardStateService
+	auditor           *auditor
+}
+
+// NewShardStateAPI creates shard state api instance, the operations are recorded into audit log
+// if audit service isn't nil
+func NewShardStateAPI(shardStateService service.ShardStateService, auditService service.AuditService) *ShardStateAPI {
+	return &ShardStateAPI{
+		shardStateService: shardStateService,
+		auditor:           newAuditor(auditService),
+	}
+}
+
+// GetByName gets the states of database's shards by the name of database
+func (s *ShardStateAPI) GetByName(w http.ResponseWriter, r *http.Request) {
+	databaseName, err := api.GetParamsFromRequest("name", r, "", true)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	states, err := s.shardStateService.Get(databaseName)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	api.OK(w, states)
+}
+
+// Save saves the states of database's shards, the shard not in states is hot
+func (s *ShardStateAPI) Save(w http.ResponseWriter, r *http.Request) {
+	states := models.ShardStates{}
+	if err := api.GetJSONBodyFromRequest(r, &states); err != nil {
+		api.Error(w, err)
+		return
+	}
+	if err := s.shardStateService.Save(states); err != nil {
+		api.Error(w, err)
+		return
+	}
+	s.auditor.record(r, models.AuditSaveShardStates, states.Database, states)
+	api.NoContent(w)
+}
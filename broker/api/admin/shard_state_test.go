@@ -0,0 +1,87 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+)
+
+type mockShardStateService struct {
+	states *models.ShardStates
+	err    error
+}
+
+func (s *mockShardStateService) Save(states models.ShardStates) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.states = &states
+	return nil
+}
+
+func (s *mockShardStateService) Get(databaseName string) (*models.ShardStates, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.states, nil
+}
+
+func TestShardStateAPI(t *testing.T) {
+	srv := &mockShardStateService{}
+	auditService := &mockAuditService{}
+	api := NewShardStateAPI(srv, auditService)
+
+	states := models.ShardStates{Database: "test", States: map[int]models.ShardState{1: models.ShardFrozen}}
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/database/shard/state",
+		RequestBody:    states,
+		HandlerFunc:    api.Save,
+		ExpectHTTPCode: 204,
+	})
+	assert.Equal(t, &states, srv.states)
+	assert.Len(t, auditService.logs, 1)
+	assert.Equal(t, models.AuditSaveShardStates, auditService.logs[0].Operation)
+	assert.Equal(t, "test", auditService.logs[0].Target)
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/database/shard/state?name=test",
+		HandlerFunc:    api.GetByName,
+		ExpectHTTPCode: 200,
+		ExpectResponse: states,
+	})
+
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/database/shard/state",
+		RequestBody:    "bad request",
+		HandlerFunc:    api.Save,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/database/shard/state",
+		HandlerFunc:    api.GetByName,
+		ExpectHTTPCode: 500,
+	})
+	srv.err = fmt.Errorf("err")
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/database/shard/state",
+		RequestBody:    states,
+		HandlerFunc:    api.Save,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/database/shard/state?name=test",
+		HandlerFunc:    api.GetByName,
+		ExpectHTTPCode: 500,
+	})
+	assert.Len(t, auditService.logs, 1)
+}
@@ -0,0 +1,52 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/eleme/lindb/broker/api"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/state"
+	"github.com/eleme/lindb/service"
+)
+
+// StatsAPI represents the per-database statistics rest api, the statistics(points/bytes written, queries,
+// scan bytes, p99 latency) of all nodes are aggregated by master, for capacity planning and chargeback
+type StatsAPI struct {
+	statsService service.DatabaseStatsService
+}
+
+// NewStatsAPI creates database statistics api instance
+func NewStatsAPI(statsService service.DatabaseStatsService) *StatsAPI {
+	return &StatsAPI{
+		statsService: statsService,
+	}
+}
+
+// Get returns the statistics aggregated by master, the statistics are filtered by the optional param 'db',
+// responses not found if the statistics aren't aggregated yet
+func (s *StatsAPI) Get(w http.ResponseWriter, r *http.Request) {
+	databaseName, err := api.GetParamsFromRequest("db", r, "", false)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	stats, err := s.statsService.GetClusterStats()
+	if err == state.ErrNotExist {
+		api.NotFound(w)
+		return
+	}
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	if len(databaseName) > 0 {
+		databases := make([]models.DatabaseStats, 0, 1)
+		for _, database := range stats.Databases {
+			if database.Database == databaseName {
+				databases = append(databases, database)
+			}
+		}
+		stats.Databases = databases
+	}
+	api.OK(w, stats)
+}
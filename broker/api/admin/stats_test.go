@@ -0,0 +1,89 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/state"
+)
+
+type mockDatabaseStatsService struct {
+	stats *models.ClusterStats
+	err   error
+}
+
+func (s *mockDatabaseStatsService) Report(stats models.NodeStats) error {
+	return nil
+}
+
+func (s *mockDatabaseStatsService) GetNodeStats(node string) (*models.NodeStats, error) {
+	return nil, state.ErrNotExist
+}
+
+func (s *mockDatabaseStatsService) ListNodeStats() ([]models.NodeStats, error) {
+	return nil, nil
+}
+
+func (s *mockDatabaseStatsService) SaveClusterStats(stats models.ClusterStats) error {
+	s.stats = &stats
+	return nil
+}
+
+func (s *mockDatabaseStatsService) GetClusterStats() (*models.ClusterStats, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	stats := *s.stats
+	return &stats, nil
+}
+
+func TestStatsAPI_Get(t *testing.T) {
+	srv := &mockDatabaseStatsService{err: state.ErrNotExist}
+	api := NewStatsAPI(srv)
+
+	// the stats aren't aggregated
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/api/v1/stats",
+		HandlerFunc:    api.Get,
+		ExpectHTTPCode: 404,
+	})
+	srv.err = fmt.Errorf("err")
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/api/v1/stats",
+		HandlerFunc:    api.Get,
+		ExpectHTTPCode: 500,
+	})
+
+	srv.err = nil
+	stats := models.ClusterStats{Timestamp: 10, Nodes: 3, Databases: []models.DatabaseStats{
+		{Database: "db1", WrittenPoints: 10, WrittenBytes: 100},
+		{Database: "db2", Queries: 1, ScanBytes: 16, P99Latency: 5, QueryLatency: []int64{1}},
+	}}
+	_ = srv.SaveClusterStats(stats)
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/api/v1/stats",
+		HandlerFunc:    api.Get,
+		ExpectHTTPCode: 200,
+		ExpectResponse: stats,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/api/v1/stats?db=db2",
+		HandlerFunc:    api.Get,
+		ExpectHTTPCode: 200,
+		ExpectResponse: models.ClusterStats{Timestamp: 10, Nodes: 3, Databases: stats.Databases[1:]},
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/api/v1/stats?db=not_exist",
+		HandlerFunc:    api.Get,
+		ExpectHTTPCode: 200,
+		ExpectResponse: models.ClusterStats{Timestamp: 10, Nodes: 3, Databases: []models.DatabaseStats{}},
+	})
+}
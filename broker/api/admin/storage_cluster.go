@@ -11,12 +11,15 @@ import (
 // StorageClusterAPI represents storage cluster admin rest api
 type StorageClusterAPI struct {
 	storageClusterService service.StorageClusterService
+	auditor               *auditor
 }
 
-// NewStorageClusterAPI create storage cluster api
-func NewStorageClusterAPI(storageClusterService service.StorageClusterService) *StorageClusterAPI {
+// NewStorageClusterAPI create storage cluster api, the operations are recorded into audit log if audit service isn't nil
+func NewStorageClusterAPI(storageClusterService service.StorageClusterService,
+	auditService service.AuditService) *StorageClusterAPI {
 	return &StorageClusterAPI{
 		storageClusterService: storageClusterService,
+		auditor:               newAuditor(auditService),
 	}
 }
 
@@ -33,6 +36,7 @@ func (s *StorageClusterAPI) Create(w http.ResponseWriter, r *http.Request) {
 		api.Error(w, err)
 		return
 	}
+	s.auditor.record(r, models.AuditSaveStorageCluster, storage.Name, storage)
 	api.NoContent(w)
 }
 
@@ -63,6 +67,7 @@ func (s *StorageClusterAPI) DeleteByName(w http.ResponseWriter, r *http.Request)
 		api.Error(w, err)
 		return
 	}
+	s.auditor.record(r, models.AuditDeleteStorageCluster, name, nil)
 	api.NoContent(w)
 }
 
@@ -29,7 +29,7 @@ func (ts *testStorageClusterAPISuite) TestStorageCluster(c *check.C) {
 		Endpoints: ts.Cluster.Endpoints,
 	})
 
-	api := NewStorageClusterAPI(service.NewStorageClusterService(repo))
+	api := NewStorageClusterAPI(service.NewStorageClusterService(repo), nil)
 
 	cfg := models.StorageCluster{
 		Name: "test1",
@@ -0,0 +1,71 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/eleme/lindb/broker/api"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/service"
+)
+
+// UDFAPI represents the user defined function admin rest api, the functions are registered by database
+// and loaded by all brokers
+type UDFAPI struct {
+	udfService service.UDFService
+	auditor    *auditor
+}
+
+// NewUDFAPI creates user defined function api instance, the operations are recorded into audit log
+// if audit service isn't nil
+func NewUDFAPI(udfService service.UDFService, auditService service.AuditService) *UDFAPI {
+	return &UDFAPI{
+		udfService: udfService,
+		auditor:    newAuditor(auditService),
+	}
+}
+
+// List lists the user defined functions of database, lists all functions if database is empty
+func (u *UDFAPI) List(w http.ResponseWriter, r *http.Request) {
+	db, _ := api.GetParamsFromRequest("db", r, "", false)
+	definitions, err := u.udfService.List(db)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	api.OK(w, definitions)
+}
+
+// Save saves the user defined function, the function of same database and name is replaced
+func (u *UDFAPI) Save(w http.ResponseWriter, r *http.Request) {
+	definition := models.UDFDefinition{}
+	if err := api.GetJSONBodyFromRequest(r, &definition); err != nil {
+		api.Error(w, err)
+		return
+	}
+	if err := u.udfService.Save(definition); err != nil {
+		api.Error(w, err)
+		return
+	}
+	u.auditor.record(r, models.AuditSaveUDF, definition.Database+"/"+definition.Name, definition)
+	api.NoContent(w)
+}
+
+// Delete deletes the user defined function of database by name
+func (u *UDFAPI) Delete(w http.ResponseWriter, r *http.Request) {
+	db, err := api.GetParamsFromRequest("db", r, "", true)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	name, err := api.GetParamsFromRequest("name", r, "", true)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	if err := u.udfService.Delete(db, name); err != nil {
+		api.Error(w, err)
+		return
+	}
+	u.auditor.record(r, models.AuditDeleteUDF, db+"/"+name, nil)
+	api.NoContent(w)
+}
@@ -0,0 +1,121 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+)
+
+type mockUDFService struct {
+	definitions map[string]models.UDFDefinition
+	err         error
+}
+
+func (s *mockUDFService) Save(definition models.UDFDefinition) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.definitions[definition.Database+"/"+definition.Name] = definition
+	return nil
+}
+
+func (s *mockUDFService) Delete(database, name string) error {
+	if s.err != nil {
+		return s.err
+	}
+	delete(s.definitions, database+"/"+name)
+	return nil
+}
+
+func (s *mockUDFService) List(database string) ([]models.UDFDefinition, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	var definitions []models.UDFDefinition
+	for _, definition := range s.definitions {
+		if len(database) == 0 || definition.Database == database {
+			definitions = append(definitions, definition)
+		}
+	}
+	return definitions, nil
+}
+
+func TestUDFAPI(t *testing.T) {
+	srv := &mockUDFService{definitions: make(map[string]models.UDFDefinition)}
+	auditService := &mockAuditService{}
+	api := NewUDFAPI(srv, auditService)
+
+	definition := models.UDFDefinition{Database: "db", Name: "smooth", Plugin: "/plugins/smooth.so",
+		Params: map[string]string{"alpha": "0.5"}}
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/query/udf",
+		RequestBody:    definition,
+		HandlerFunc:    api.Save,
+		ExpectHTTPCode: 204,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/query/udf?db=db",
+		HandlerFunc:    api.List,
+		ExpectHTTPCode: 200,
+		ExpectResponse: []models.UDFDefinition{definition},
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodDelete,
+		URL:            "/query/udf?name=smooth",
+		HandlerFunc:    api.Delete,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodDelete,
+		URL:            "/query/udf?db=db",
+		HandlerFunc:    api.Delete,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodDelete,
+		URL:            "/query/udf?db=db&name=smooth",
+		HandlerFunc:    api.Delete,
+		ExpectHTTPCode: 204,
+	})
+	assert.Empty(t, srv.definitions)
+	assert.Equal(t, 2, len(auditService.logs))
+	assert.Equal(t, models.AuditSaveUDF, auditService.logs[0].Operation)
+	assert.Equal(t, "db/smooth", auditService.logs[0].Target)
+	assert.Equal(t, models.AuditDeleteUDF, auditService.logs[1].Operation)
+
+	srv.err = fmt.Errorf("err")
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/query/udf",
+		RequestBody:    definition,
+		HandlerFunc:    api.Save,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/query/udf",
+		RequestBody:    "bad",
+		HandlerFunc:    api.Save,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/query/udf",
+		HandlerFunc:    api.List,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodDelete,
+		URL:            "/query/udf?db=db&name=smooth",
+		HandlerFunc:    api.Delete,
+		ExpectHTTPCode: 500,
+	})
+	assert.Equal(t, 2, len(auditService.logs))
+}
@@ -0,0 +1,38 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/eleme/lindb/broker/api"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/service"
+)
+
+// WarmUpAPI represents the warm-up rest api, pre-warms the table readers and index blocks of database's shards
+// after storage nodes restarted, so that the first dashboard load after a deploy doesn't read them from disk
+type WarmUpAPI struct {
+	warmUpService service.WarmUpService
+}
+
+// NewWarmUpAPI creates warm-up api instance
+func NewWarmUpAPI(warmUpService service.WarmUpService) *WarmUpAPI {
+	return &WarmUpAPI{
+		warmUpService: warmUpService,
+	}
+}
+
+// WarmUp warms the metadata index and the most recent segments of shards in all replicas of database,
+// returns the result of each storage node's index and shard
+func (w *WarmUpAPI) WarmUp(rw http.ResponseWriter, r *http.Request) {
+	req := &models.WarmUpRequest{}
+	if err := api.GetJSONBodyFromRequest(r, req); err != nil {
+		api.Error(rw, err)
+		return
+	}
+	results, err := w.warmUpService.WarmUp(r.Context(), req)
+	if err != nil {
+		api.Error(rw, err)
+		return
+	}
+	api.OK(rw, results)
+}
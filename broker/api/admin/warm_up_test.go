@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+)
+
+type mockWarmUpService struct {
+	req *models.WarmUpRequest
+	err error
+}
+
+func (s *mockWarmUpService) WarmUp(ctx context.Context, req *models.WarmUpRequest) ([]models.WarmUpResult, error) {
+	s.req = req
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []models.WarmUpResult{{Node: "127.0.0.1:2000", Index: true}}, nil
+}
+
+func TestWarmUpAPI_WarmUp(t *testing.T) {
+	srv := &mockWarmUpService{}
+	api := NewWarmUpAPI(srv)
+
+	req := &models.WarmUpRequest{Database: "test", ShardIDs: []int{1}, Segments: 2}
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/database/warm-up",
+		RequestBody:    req,
+		HandlerFunc:    api.WarmUp,
+		ExpectHTTPCode: 200,
+		ExpectResponse: []models.WarmUpResult{{Node: "127.0.0.1:2000", Index: true}},
+	})
+	assert.Equal(t, req, srv.req)
+
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/database/warm-up",
+		RequestBody:    "bad request",
+		HandlerFunc:    api.WarmUp,
+		ExpectHTTPCode: 500,
+	})
+	srv.err = fmt.Errorf("err")
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/database/warm-up",
+		RequestBody:    req,
+		HandlerFunc:    api.WarmUp,
+		ExpectHTTPCode: 500,
+	})
+}
@@ -0,0 +1,248 @@
+package grafana
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/eleme/lindb/broker/api"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/query"
+	"github.com/eleme/lindb/service"
+)
+
+// dbParam is the path variable of database name, grafana data source url is like '/api/v1/grafana/{db}'
+const dbParam = "db"
+
+// tagSearchRegexp matches the search target of template variable, like tagKeys(cpu) or tagValues(cpu, host)
+var tagSearchRegexp = regexp.MustCompile(`^\s*(tagKeys|tagValues)\(\s*([^,\s)]+)\s*(?:,\s*([^,\s)]+)\s*)?\)\s*$`)
+
+// SearchRequest represents the search request of grafana simple json data source
+type SearchRequest struct {
+	Target string `json:"target"`
+}
+
+// Range represents the time range of grafana panel
+type Range struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// Target represents the query target of grafana panel,
+// target is the select expression, data is the optional payload of query.
+type Target struct {
+	Target string      `json:"target"`
+	RefID  string      `json:"refId"`
+	Type   string      `json:"type"`
+	Data   *TargetData `json:"data,omitempty"`
+}
+
+// TargetData represents the payload of query target, such as default metric, group by and fill policy
+type TargetData struct {
+	Metric  string               `json:"metric,omitempty"`
+	GroupBy []string             `json:"groupBy,omitempty"`
+	Fill    string               `json:"fill,omitempty"`
+	OrderBy *models.QueryOrderBy `json:"orderBy,omitempty"`
+	Limit   int                  `json:"limit,omitempty"`
+}
+
+// QueryRequest represents the query request of grafana simple json data source
+type QueryRequest struct {
+	Range         Range    `json:"range"`
+	IntervalMs    int64    `json:"intervalMs"`
+	MaxDataPoints int      `json:"maxDataPoints"`
+	Targets       []Target `json:"targets"`
+}
+
+// TimeSeries represents the time series response of grafana, each data point is [value, timestamp(ms)]
+type TimeSeries struct {
+	Target     string          `json:"target"`
+	DataPoints [][]interface{} `json:"datapoints"`
+}
+
+// Annotation represents the annotation response of grafana
+type Annotation struct {
+	Annotation interface{} `json:"annotation"`
+	Time       int64       `json:"time"`
+	Title      string      `json:"title"`
+	Tags       []string    `json:"tags,omitempty"`
+	Text       string      `json:"text,omitempty"`
+}
+
+// GrafanaAPI represents the http api which is compatible with grafana simple json data source,
+// translates the requests of grafana panels into internal queries.
+type GrafanaAPI struct {
+	metadataService service.MetadataService
+	executor        query.BrokerExecutor
+}
+
+// NewGrafanaAPI creates grafana api instance
+func NewGrafanaAPI(metadataService service.MetadataService, executor query.BrokerExecutor) *GrafanaAPI {
+	return &GrafanaAPI{
+		metadataService: metadataService,
+		executor:        executor,
+	}
+}
+
+// TestConnection responses ok, which is used by grafana to test the data source
+func (g *GrafanaAPI) TestConnection(w http.ResponseWriter, r *http.Request) {
+	api.OK(w, "ok")
+}
+
+// Search returns metric names which start with the target for metric picker,
+// or returns tag keys/values for template variable if target is tagKeys(metric)/tagValues(metric, tagKey).
+func (g *GrafanaAPI) Search(w http.ResponseWriter, r *http.Request) {
+	db, err := getDatabase(r)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	req := &SearchRequest{}
+	if err := api.GetJSONBodyFromRequest(r, req); err != nil {
+		api.Error(w, err)
+		return
+	}
+	suggestReq := &models.SuggestRequest{Database: db, Type: models.SuggestMetricNames, Prefix: req.Target}
+	if matches := tagSearchRegexp.FindStringSubmatch(req.Target); len(matches) > 0 {
+		suggestReq.Prefix = ""
+		suggestReq.MetricName = matches[2]
+		if matches[1] == "tagKeys" {
+			suggestReq.Type = models.SuggestTagKeys
+		} else {
+			if len(matches[3]) == 0 {
+				api.Error(w, fmt.Errorf("tag key is required in search target:%s", req.Target))
+				return
+			}
+			suggestReq.Type = models.SuggestTagValues
+			suggestReq.TagKey = matches[3]
+		}
+	}
+	values, err := g.metadataService.Suggest(r.Context(), suggestReq)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	if values == nil {
+		values = []string{}
+	}
+	api.OK(w, values)
+}
+
+// Query translates the targets of grafana panel into query DSL, then responses the time series of results
+func (g *GrafanaAPI) Query(w http.ResponseWriter, r *http.Request) {
+	db, err := getDatabase(r)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	req := &QueryRequest{}
+	if err := api.GetJSONBodyFromRequest(r, req); err != nil {
+		api.Error(w, err)
+		return
+	}
+	result := make([]*TimeSeries, 0)
+	for _, target := range req.Targets {
+		if len(strings.TrimSpace(target.Target)) == 0 {
+			continue
+		}
+		if len(target.Type) > 0 && target.Type != "timeserie" {
+			api.Error(w, fmt.Errorf("target type[%s] isn't supported", target.Type))
+			return
+		}
+		rs, err := g.executor.Execute(r.Context(), buildQueryRequest(db, req, &target))
+		if err != nil {
+			api.Error(w, err)
+			return
+		}
+		result = append(result, toTimeSeries(rs)...)
+	}
+	api.OK(w, result)
+}
+
+// Annotations returns empty annotations, because there is no annotation storage yet
+func (g *GrafanaAPI) Annotations(w http.ResponseWriter, r *http.Request) {
+	api.OK(w, []*Annotation{})
+}
+
+// getDatabase returns the database name from path variable
+func getDatabase(r *http.Request) (string, error) {
+	db := mux.Vars(r)[dbParam]
+	if len(db) == 0 {
+		return "", fmt.Errorf("database name is required in url path")
+	}
+	return db, nil
+}
+
+// buildQueryRequest translates the target of grafana panel into query DSL,
+// the interval is enlarged if the point count exceeds max data points of panel.
+func buildQueryRequest(db string, req *QueryRequest, target *Target) *models.QueryRequest {
+	start := req.Range.From.UnixNano() / int64(time.Millisecond)
+	end := req.Range.To.UnixNano() / int64(time.Millisecond)
+	interval := req.IntervalMs
+	if interval <= 0 {
+		interval = int64(time.Second / time.Millisecond)
+	}
+	if req.MaxDataPoints > 0 && end > start {
+		if minInterval := (end - start) / int64(req.MaxDataPoints); minInterval > interval {
+			interval = minInterval
+		}
+	}
+	queryReq := &models.QueryRequest{
+		Database: db,
+		Fields:   []models.QueryField{{Alias: target.Target, Expr: target.Target}},
+		Start:    start,
+		End:      end,
+		Interval: interval,
+	}
+	if target.Data != nil {
+		queryReq.Metric = target.Data.Metric
+		queryReq.GroupBy = target.Data.GroupBy
+		queryReq.Fill = target.Data.Fill
+		queryReq.OrderBy = target.Data.OrderBy
+		queryReq.Limit = target.Data.Limit
+	}
+	return queryReq
+}
+
+// toTimeSeries converts the result set into grafana time series, the name of time series is field(target) with tags,
+// like 'cpu:used{host=a}', NaN value is converted to null.
+func toTimeSeries(rs *models.ResultSet) []*TimeSeries {
+	var result []*TimeSeries
+	for _, series := range rs.Series {
+		for field, values := range series.Fields {
+			ts := &TimeSeries{
+				Target:     seriesName(field, series.Tags),
+				DataPoints: make([][]interface{}, 0, len(values)),
+			}
+			for i, value := range values {
+				timestamp := rs.StartTime + int64(i)*rs.Interval
+				if math.IsNaN(value) || math.IsInf(value, 0) {
+					ts.DataPoints = append(ts.DataPoints, []interface{}{nil, timestamp})
+				} else {
+					ts.DataPoints = append(ts.DataPoints, []interface{}{value, timestamp})
+				}
+			}
+			result = append(result, ts)
+		}
+	}
+	return result
+}
+
+// seriesName returns the name of series which is joined by field and sorted tags
+func seriesName(field string, tags map[string]string) string {
+	if len(tags) == 0 {
+		return field
+	}
+	pairs := make([]string, 0, len(tags))
+	for key, value := range tags {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return field + "{" + strings.Join(pairs, ",") + "}"
+}
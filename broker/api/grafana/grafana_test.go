@@ -0,0 +1,249 @@
+package grafana
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/tsdb/index"
+)
+
+type mockMetadataService struct {
+	req *models.SuggestRequest
+	err error
+}
+
+func (s *mockMetadataService) Suggest(ctx context.Context, req *models.SuggestRequest) ([]string, error) {
+	s.req = req
+	if s.err != nil {
+		return nil, s.err
+	}
+	if req.MetricName == "not-exist" {
+		return nil, nil
+	}
+	return []string{"a", "b"}, nil
+}
+
+func (s *mockMetadataService) Cardinality(ctx context.Context, req *models.CardinalityRequest) (*index.MetricCardinality, error) {
+	return nil, nil
+}
+
+func (s *mockMetadataService) WriteEvents(ctx context.Context, req *models.EventWriteRequest) error {
+	return nil
+}
+
+func (s *mockMetadataService) QueryEvents(ctx context.Context, query *models.EventQuery) ([]models.Event, error) {
+	return nil, nil
+}
+
+type mockExecutor struct {
+	reqs []*models.QueryRequest
+	err  error
+}
+
+func (e *mockExecutor) Execute(ctx context.Context, req *models.QueryRequest) (*models.ResultSet, error) {
+	e.reqs = append(e.reqs, req)
+	if e.err != nil {
+		return nil, e.err
+	}
+	series := models.NewSeries(map[string]string{"host": "a", "disk": "/"})
+	series.Fields[req.Fields[0].Alias] = []float64{1, math.NaN()}
+	return &models.ResultSet{StartTime: 1000, Interval: 10, PointCount: 2, Series: []*models.Series{series}}, nil
+}
+
+// withDB sets the database name as path variable of request
+func withDB(db string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handler(w, mux.SetURLVars(r, map[string]string{dbParam: db}))
+	}
+}
+
+func TestGrafanaAPI_TestConnection(t *testing.T) {
+	api := NewGrafanaAPI(&mockMetadataService{}, &mockExecutor{})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/api/v1/grafana/db/",
+		HandlerFunc:    api.TestConnection,
+		ExpectHTTPCode: 200,
+		ExpectResponse: "ok",
+	})
+}
+
+func TestGrafanaAPI_Search(t *testing.T) {
+	srv := &mockMetadataService{}
+	api := NewGrafanaAPI(srv, &mockExecutor{})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/grafana/db/search",
+		RequestBody:    &SearchRequest{Target: "cpu"},
+		HandlerFunc:    withDB("db", api.Search),
+		ExpectHTTPCode: 200,
+		ExpectResponse: []string{"a", "b"},
+	})
+	assert.Equal(t, &models.SuggestRequest{Database: "db", Type: models.SuggestMetricNames, Prefix: "cpu"}, srv.req)
+
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/grafana/db/search",
+		RequestBody:    &SearchRequest{Target: "tagKeys(cpu)"},
+		HandlerFunc:    withDB("db", api.Search),
+		ExpectHTTPCode: 200,
+		ExpectResponse: []string{"a", "b"},
+	})
+	assert.Equal(t, &models.SuggestRequest{Database: "db", Type: models.SuggestTagKeys, MetricName: "cpu"}, srv.req)
+
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/grafana/db/search",
+		RequestBody:    &SearchRequest{Target: "tagValues(cpu, host)"},
+		HandlerFunc:    withDB("db", api.Search),
+		ExpectHTTPCode: 200,
+		ExpectResponse: []string{"a", "b"},
+	})
+	assert.Equal(t, &models.SuggestRequest{Database: "db", Type: models.SuggestTagValues, MetricName: "cpu", TagKey: "host"}, srv.req)
+
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/grafana/db/search",
+		RequestBody:    &SearchRequest{Target: "tagKeys(not-exist)"},
+		HandlerFunc:    withDB("db", api.Search),
+		ExpectHTTPCode: 200,
+		ExpectResponse: []string{},
+	})
+
+	// tag key not set
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/grafana/db/search",
+		RequestBody:    &SearchRequest{Target: "tagValues(cpu)"},
+		HandlerFunc:    withDB("db", api.Search),
+		ExpectHTTPCode: 500,
+	})
+	// database not set
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/grafana//search",
+		RequestBody:    &SearchRequest{Target: "cpu"},
+		HandlerFunc:    api.Search,
+		ExpectHTTPCode: 500,
+	})
+	// wrong body
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/grafana/db/search",
+		RequestBody:    "abc",
+		HandlerFunc:    withDB("db", api.Search),
+		ExpectHTTPCode: 500,
+	})
+	srv.err = fmt.Errorf("err")
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/grafana/db/search",
+		RequestBody:    &SearchRequest{Target: "cpu"},
+		HandlerFunc:    withDB("db", api.Search),
+		ExpectHTTPCode: 500,
+	})
+}
+
+func TestGrafanaAPI_Query(t *testing.T) {
+	executor := &mockExecutor{}
+	api := NewGrafanaAPI(&mockMetadataService{}, executor)
+	from := time.Unix(1000, 0)
+	req := &QueryRequest{
+		Range:         Range{From: from, To: from.Add(time.Hour)},
+		IntervalMs:    1000,
+		MaxDataPoints: 60,
+		Targets: []Target{
+			{Target: "cpu:used", RefID: "A", Type: "timeserie"},
+			{Target: "", RefID: "B"},
+			{Target: "used/total", RefID: "C", Data: &TargetData{Metric: "cpu", GroupBy: []string{"host"}, Fill: "zero", Limit: 10}},
+		},
+	}
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/grafana/db/query",
+		RequestBody:    req,
+		HandlerFunc:    withDB("db", api.Query),
+		ExpectHTTPCode: 200,
+		ExpectResponse: []*TimeSeries{
+			{Target: "cpu:used{disk=/,host=a}", DataPoints: [][]interface{}{{1, 1000}, {nil, 1010}}},
+			{Target: "used/total{disk=/,host=a}", DataPoints: [][]interface{}{{1, 1000}, {nil, 1010}}},
+		},
+	})
+	assert.Equal(t, 2, len(executor.reqs))
+	assert.Equal(t, &models.QueryRequest{
+		Database: "db",
+		Fields:   []models.QueryField{{Alias: "cpu:used", Expr: "cpu:used"}},
+		Start:    1000 * 1000,
+		End:      4600 * 1000,
+		Interval: 60 * 1000,
+	}, executor.reqs[0])
+	assert.Equal(t, &models.QueryRequest{
+		Database: "db",
+		Metric:   "cpu",
+		Fields:   []models.QueryField{{Alias: "used/total", Expr: "used/total"}},
+		Start:    1000 * 1000,
+		End:      4600 * 1000,
+		Interval: 60 * 1000,
+		GroupBy:  []string{"host"},
+		Fill:     "zero",
+		Limit:    10,
+	}, executor.reqs[1])
+
+	// table isn't supported
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/grafana/db/query",
+		RequestBody:    &QueryRequest{Targets: []Target{{Target: "cpu:used", Type: "table"}}},
+		HandlerFunc:    withDB("db", api.Query),
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/grafana/db/query",
+		RequestBody:    "abc",
+		HandlerFunc:    withDB("db", api.Query),
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/grafana//query",
+		RequestBody:    req,
+		HandlerFunc:    api.Query,
+		ExpectHTTPCode: 500,
+	})
+	executor.err = fmt.Errorf("err")
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/grafana/db/query",
+		RequestBody:    req,
+		HandlerFunc:    withDB("db", api.Query),
+		ExpectHTTPCode: 500,
+	})
+}
+
+func TestGrafanaAPI_Annotations(t *testing.T) {
+	api := NewGrafanaAPI(&mockMetadataService{}, &mockExecutor{})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/grafana/db/annotations",
+		RequestBody:    map[string]interface{}{"annotation": map[string]string{"name": "deploy"}},
+		HandlerFunc:    withDB("db", api.Annotations),
+		ExpectHTTPCode: 200,
+		ExpectResponse: []*Annotation{},
+	})
+}
+
+func TestBuildQueryRequest(t *testing.T) {
+	from := time.Unix(0, 0)
+	req := buildQueryRequest("db", &QueryRequest{Range: Range{From: from, To: from.Add(time.Minute)}}, &Target{Target: "f"})
+	assert.Equal(t, int64(1000), req.Interval)
+}
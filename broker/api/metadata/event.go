@@ -0,0 +1,86 @@
+package metadata
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/eleme/lindb/broker/api"
+	"github.com/eleme/lindb/models"
+)
+
+// WriteEvents writes the annotation events(deploys, incidents) of the json body into database
+func (m *MetadataAPI) WriteEvents(w http.ResponseWriter, r *http.Request) {
+	req := &models.EventWriteRequest{}
+	if err := api.GetJSONBodyFromRequest(r, req); err != nil {
+		api.Error(w, fmt.Errorf("parse event write request error:%s", err))
+		return
+	}
+	if err := m.metadataService.WriteEvents(r.Context(), req); err != nil {
+		api.Error(w, err)
+		return
+	}
+	api.NoContent(w)
+}
+
+// QueryEvents returns the annotation events of database which overlap [start, end](ms) and have all the tags,
+// the tags are separated by comma like key1=value1,key2=value2, the events are sorted by start time.
+func (m *MetadataAPI) QueryEvents(w http.ResponseWriter, r *http.Request) {
+	query := &models.EventQuery{}
+	var err error
+	query.Database, err = api.GetParamsFromRequest("db", r, "", true)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	if query.StartTime, err = getTimeParam("start", r); err != nil {
+		api.Error(w, err)
+		return
+	}
+	if query.EndTime, err = getTimeParam("end", r); err != nil {
+		api.Error(w, err)
+		return
+	}
+	if query.Limit, err = getIntParam("limit", r); err != nil {
+		api.Error(w, err)
+		return
+	}
+	tags, _ := api.GetParamsFromRequest("tags", r, "", false)
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); len(tag) == 0 {
+			continue
+		}
+		pair := strings.SplitN(tag, "=", 2)
+		if len(pair) != 2 || len(pair[0]) == 0 {
+			api.Error(w, fmt.Errorf("tag[%s] must be key=value", tag))
+			return
+		}
+		if query.Tags == nil {
+			query.Tags = make(map[string]string)
+		}
+		query.Tags[pair[0]] = pair[1]
+	}
+	events, err := m.metadataService.QueryEvents(r.Context(), query)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	if events == nil {
+		events = []models.Event{}
+	}
+	api.OK(w, &models.EventResult{Events: events})
+}
+
+// getTimeParam returns the timestamp(ms) of the required param
+func getTimeParam(paramName string, r *http.Request) (int64, error) {
+	value, err := api.GetParamsFromRequest(paramName, r, "", true)
+	if err != nil {
+		return 0, err
+	}
+	result, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("param[%s] must be timestamp(ms)", paramName)
+	}
+	return result, nil
+}
@@ -0,0 +1,93 @@
+package metadata
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+)
+
+func TestMetadataAPI_WriteEvents(t *testing.T) {
+	srv := &mockMetadataService{}
+	api := NewMetadataAPI(srv)
+
+	req := &models.EventWriteRequest{Database: "test", Events: []models.Event{{Title: "deploy", StartTime: 100}}}
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/metadata/events",
+		RequestBody:    req,
+		HandlerFunc:    api.WriteEvents,
+		ExpectHTTPCode: 204,
+	})
+	assert.Equal(t, req, srv.eventReq)
+
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/metadata/events",
+		RequestBody:    "bad request",
+		HandlerFunc:    api.WriteEvents,
+		ExpectHTTPCode: 500,
+	})
+	srv.err = fmt.Errorf("err")
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/metadata/events",
+		RequestBody:    req,
+		HandlerFunc:    api.WriteEvents,
+		ExpectHTTPCode: 500,
+	})
+}
+
+func TestMetadataAPI_QueryEvents(t *testing.T) {
+	srv := &mockMetadataService{}
+	api := NewMetadataAPI(srv)
+
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/metadata/events?db=test&start=100&end=200&tags=app=lindb,%20env=prod&limit=10",
+		HandlerFunc:    api.QueryEvents,
+		ExpectHTTPCode: 200,
+		ExpectResponse: &models.EventResult{Events: []models.Event{{Title: "deploy", StartTime: 100, EndTime: 100}}},
+	})
+	assert.Equal(t, &models.EventQuery{
+		Database:  "test",
+		StartTime: 100,
+		EndTime:   200,
+		Tags:      map[string]string{"app": "lindb", "env": "prod"},
+		Limit:     10,
+	}, srv.eventQuery)
+
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/metadata/events?db=empty&start=100&end=200",
+		HandlerFunc:    api.QueryEvents,
+		ExpectHTTPCode: 200,
+		ExpectResponse: &models.EventResult{Events: []models.Event{}},
+	})
+	for _, url := range []string{
+		"/metadata/events?start=100&end=200",
+		"/metadata/events?db=test&end=200",
+		"/metadata/events?db=test&start=100",
+		"/metadata/events?db=test&start=abc&end=200",
+		"/metadata/events?db=test&start=100&end=200&limit=abc",
+		"/metadata/events?db=test&start=100&end=200&tags=app",
+	} {
+		mock.DoRequest(t, &mock.HTTPHandler{
+			Method:         http.MethodGet,
+			URL:            url,
+			HandlerFunc:    api.QueryEvents,
+			ExpectHTTPCode: 500,
+		})
+	}
+	srv.err = fmt.Errorf("err")
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/metadata/events?db=test&start=100&end=200",
+		HandlerFunc:    api.QueryEvents,
+		ExpectHTTPCode: 500,
+	})
+}
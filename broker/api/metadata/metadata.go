@@ -0,0 +1,148 @@
+package metadata
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/eleme/lindb/broker/api"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/service"
+)
+
+// MetadataAPI represents metric metadata rest api, such as metric names, tag keys and tag values
+type MetadataAPI struct {
+	metadataService service.MetadataService
+}
+
+// NewMetadataAPI creates metric metadata api instance
+func NewMetadataAPI(metadataService service.MetadataService) *MetadataAPI {
+	return &MetadataAPI{
+		metadataService: metadataService,
+	}
+}
+
+// ListMetricNames lists metric names of the database which start with the prefix, paged by offset and limit
+func (m *MetadataAPI) ListMetricNames(w http.ResponseWriter, r *http.Request) {
+	req, err := buildSuggestRequest(r, models.SuggestMetricNames)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	req.Prefix, _ = api.GetParamsFromRequest("prefix", r, "", false)
+	m.suggest(w, r, req)
+}
+
+// ListTagKeys lists tag keys of the metric, limited by limit
+func (m *MetadataAPI) ListTagKeys(w http.ResponseWriter, r *http.Request) {
+	req, err := buildSuggestRequest(r, models.SuggestTagKeys)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	req.MetricName, err = api.GetParamsFromRequest("metric", r, "", true)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	m.suggest(w, r, req)
+}
+
+// ListTagValues lists tag values of the metric's tag key which start with the prefix, limited by limit
+func (m *MetadataAPI) ListTagValues(w http.ResponseWriter, r *http.Request) {
+	req, err := buildSuggestRequest(r, models.SuggestTagValues)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	req.MetricName, err = api.GetParamsFromRequest("metric", r, "", true)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	req.TagKey, err = api.GetParamsFromRequest("tagKey", r, "", true)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	req.Prefix, _ = api.GetParamsFromRequest("prefix", r, "", false)
+	m.suggest(w, r, req)
+}
+
+// GetCardinality estimates the num. of series and tag values of the metric, the tag keys are separated by comma,
+// all tag keys of the metric are estimated if tag keys not set
+func (m *MetadataAPI) GetCardinality(w http.ResponseWriter, r *http.Request) {
+	req := &models.CardinalityRequest{}
+	var err error
+	req.Database, err = api.GetParamsFromRequest("db", r, "", true)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	req.MetricName, err = api.GetParamsFromRequest("metric", r, "", true)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	tagKeys, _ := api.GetParamsFromRequest("tagKeys", r, "", false)
+	for _, tagKey := range strings.Split(tagKeys, ",") {
+		if tagKey = strings.TrimSpace(tagKey); len(tagKey) > 0 {
+			req.TagKeys = append(req.TagKeys, tagKey)
+		}
+	}
+	sketches, err := m.metadataService.Cardinality(r.Context(), req)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	api.OK(w, service.EstimateCardinality(req, sketches))
+}
+
+// suggest queries metric metadata by suggest request, then responses the result
+func (m *MetadataAPI) suggest(w http.ResponseWriter, r *http.Request, req *models.SuggestRequest) {
+	values, err := m.metadataService.Suggest(r.Context(), req)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	if values == nil {
+		values = []string{}
+	}
+	api.OK(w, &models.SuggestResult{Values: values})
+}
+
+// buildSuggestRequest builds suggest request with common params(db/offset/limit) from the request
+func buildSuggestRequest(r *http.Request, suggestType models.SuggestType) (*models.SuggestRequest, error) {
+	db, err := api.GetParamsFromRequest("db", r, "", true)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := getIntParam("offset", r)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := getIntParam("limit", r)
+	if err != nil {
+		return nil, err
+	}
+	return &models.SuggestRequest{
+		Database: db,
+		Type:     suggestType,
+		Offset:   offset,
+		Limit:    limit,
+	}, nil
+}
+
+// getIntParam returns the int value of the param, returns 0 if not exist
+func getIntParam(paramName string, r *http.Request) (int, error) {
+	value, err := api.GetParamsFromRequest(paramName, r, "0", false)
+	if err != nil {
+		return 0, err
+	}
+	result, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("param[%s] must be integer", paramName)
+	}
+	return result, nil
+}
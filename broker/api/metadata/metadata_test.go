@@ -0,0 +1,247 @@
+package metadata
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/tsdb/index"
+)
+
+type mockMetadataService struct {
+	req            *models.SuggestRequest
+	cardinalityReq *models.CardinalityRequest
+	eventReq       *models.EventWriteRequest
+	eventQuery     *models.EventQuery
+	err            error
+}
+
+func (s *mockMetadataService) Suggest(ctx context.Context, req *models.SuggestRequest) ([]string, error) {
+	s.req = req
+	if s.err != nil {
+		return nil, s.err
+	}
+	if req.MetricName == "not-exist" {
+		return nil, nil
+	}
+	return []string{"a", "b"}, nil
+}
+
+func (s *mockMetadataService) Cardinality(ctx context.Context, req *models.CardinalityRequest) (*index.MetricCardinality, error) {
+	s.cardinalityReq = req
+	if s.err != nil {
+		return nil, s.err
+	}
+	if req.MetricName == "not-exist" {
+		return nil, nil
+	}
+	sketches := index.NewMetricCardinality()
+	sketches.AddSeries(map[string]string{"host": "host-1", "ip": "1.1.1.1"})
+	sketches.AddSeries(map[string]string{"host": "host-2", "ip": "1.1.1.1"})
+	return sketches, nil
+}
+
+func (s *mockMetadataService) WriteEvents(ctx context.Context, req *models.EventWriteRequest) error {
+	s.eventReq = req
+	return s.err
+}
+
+func (s *mockMetadataService) QueryEvents(ctx context.Context, query *models.EventQuery) ([]models.Event, error) {
+	s.eventQuery = query
+	if s.err != nil {
+		return nil, s.err
+	}
+	if query.Database == "empty" {
+		return nil, nil
+	}
+	return []models.Event{{Title: "deploy", StartTime: query.StartTime, EndTime: query.StartTime}}, nil
+}
+
+func TestMetadataAPI_ListMetricNames(t *testing.T) {
+	srv := &mockMetadataService{}
+	api := NewMetadataAPI(srv)
+
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/metadata/metric/names?db=test&prefix=cpu&offset=10&limit=20",
+		HandlerFunc:    api.ListMetricNames,
+		ExpectHTTPCode: 200,
+		ExpectResponse: &models.SuggestResult{Values: []string{"a", "b"}},
+	})
+	assert.Equal(t, &models.SuggestRequest{
+		Database: "test",
+		Type:     models.SuggestMetricNames,
+		Prefix:   "cpu",
+		Offset:   10,
+		Limit:    20,
+	}, srv.req)
+
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/metadata/metric/names",
+		HandlerFunc:    api.ListMetricNames,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/metadata/metric/names?db=test&limit=abc",
+		HandlerFunc:    api.ListMetricNames,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/metadata/metric/names?db=test&offset=abc",
+		HandlerFunc:    api.ListMetricNames,
+		ExpectHTTPCode: 500,
+	})
+
+	srv.err = fmt.Errorf("err")
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/metadata/metric/names?db=test",
+		HandlerFunc:    api.ListMetricNames,
+		ExpectHTTPCode: 500,
+	})
+}
+
+func TestMetadataAPI_ListTagKeys(t *testing.T) {
+	srv := &mockMetadataService{}
+	api := NewMetadataAPI(srv)
+
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/metadata/tag/keys?db=test&metric=cpu&limit=10",
+		HandlerFunc:    api.ListTagKeys,
+		ExpectHTTPCode: 200,
+		ExpectResponse: &models.SuggestResult{Values: []string{"a", "b"}},
+	})
+	assert.Equal(t, &models.SuggestRequest{
+		Database:   "test",
+		Type:       models.SuggestTagKeys,
+		MetricName: "cpu",
+		Limit:      10,
+	}, srv.req)
+
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/metadata/tag/keys?db=test&metric=not-exist",
+		HandlerFunc:    api.ListTagKeys,
+		ExpectHTTPCode: 200,
+		ExpectResponse: &models.SuggestResult{Values: []string{}},
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/metadata/tag/keys?db=test",
+		HandlerFunc:    api.ListTagKeys,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/metadata/tag/keys?metric=cpu",
+		HandlerFunc:    api.ListTagKeys,
+		ExpectHTTPCode: 500,
+	})
+}
+
+func TestMetadataAPI_ListTagValues(t *testing.T) {
+	srv := &mockMetadataService{}
+	api := NewMetadataAPI(srv)
+
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/metadata/tag/values?db=test&metric=cpu&tagKey=host&prefix=192&limit=10",
+		HandlerFunc:    api.ListTagValues,
+		ExpectHTTPCode: 200,
+		ExpectResponse: &models.SuggestResult{Values: []string{"a", "b"}},
+	})
+	assert.Equal(t, &models.SuggestRequest{
+		Database:   "test",
+		Type:       models.SuggestTagValues,
+		MetricName: "cpu",
+		TagKey:     "host",
+		Prefix:     "192",
+		Limit:      10,
+	}, srv.req)
+
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/metadata/tag/values?db=test&metric=cpu",
+		HandlerFunc:    api.ListTagValues,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/metadata/tag/values?db=test&tagKey=host",
+		HandlerFunc:    api.ListTagValues,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/metadata/tag/values?metric=cpu&tagKey=host",
+		HandlerFunc:    api.ListTagValues,
+		ExpectHTTPCode: 500,
+	})
+}
+
+func TestMetadataAPI_GetCardinality(t *testing.T) {
+	srv := &mockMetadataService{}
+	api := NewMetadataAPI(srv)
+
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/metadata/cardinality?db=test&metric=cpu",
+		HandlerFunc:    api.GetCardinality,
+		ExpectHTTPCode: 200,
+		ExpectResponse: &models.CardinalityResult{
+			MetricName: "cpu",
+			Series:     2,
+			TagValues:  map[string]uint64{"host": 2, "ip": 1},
+		},
+	})
+	assert.Equal(t, &models.CardinalityRequest{Database: "test", MetricName: "cpu"}, srv.cardinalityReq)
+
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/metadata/cardinality?db=test&metric=cpu&tagKeys=host,%20zone",
+		HandlerFunc:    api.GetCardinality,
+		ExpectHTTPCode: 200,
+		ExpectResponse: &models.CardinalityResult{
+			MetricName: "cpu",
+			Series:     2,
+			TagValues:  map[string]uint64{"host": 2, "zone": 0},
+		},
+	})
+	assert.Equal(t, []string{"host", "zone"}, srv.cardinalityReq.TagKeys)
+
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/metadata/cardinality?db=test&metric=not-exist",
+		HandlerFunc:    api.GetCardinality,
+		ExpectHTTPCode: 200,
+		ExpectResponse: &models.CardinalityResult{MetricName: "not-exist", TagValues: map[string]uint64{}},
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/metadata/cardinality?metric=cpu",
+		HandlerFunc:    api.GetCardinality,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/metadata/cardinality?db=test",
+		HandlerFunc:    api.GetCardinality,
+		ExpectHTTPCode: 500,
+	})
+	srv.err = fmt.Errorf("err")
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/metadata/cardinality?db=test&metric=cpu",
+		HandlerFunc:    api.GetCardinality,
+		ExpectHTTPCode: 500,
+	})
+}
@@ -0,0 +1,98 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/service"
+	"github.com/eleme/lindb/sql"
+)
+
+// cardinalityFunc is the function which estimates the num. of series under the metric without args,
+// or the num. of values of the tag key given as the arg, like cardinality() or cardinality(host)
+const cardinalityFunc = "cardinality"
+
+// cardinalityField represents the select field of cardinality function, empty tag key means series
+type cardinalityField struct {
+	alias  string
+	tagKey string
+}
+
+// parseCardinalityFields returns the cardinality fields if the select fields of query are cardinality functions,
+// returns false if the query doesn't select cardinality, returns error if cardinality is mixed with other fields.
+func parseCardinalityFields(req *models.QueryRequest) ([]cardinalityField, bool, error) {
+	var fields []cardinalityField
+	for _, field := range req.Fields {
+		expr, err := sql.ParseExpr(field.Expr)
+		if err != nil {
+			// the invalid expression is reported by executor
+			return nil, false, nil
+		}
+		call, ok := expr.(*sql.CallExpr)
+		if !ok || call.Name != cardinalityFunc {
+			continue
+		}
+		alias := field.Alias
+		if len(alias) == 0 {
+			alias = field.Expr
+		}
+		switch len(call.Args) {
+		case 0:
+			fields = append(fields, cardinalityField{alias: alias})
+		case 1:
+			tagKey, ok := call.Args[0].(*sql.FieldExpr)
+			if !ok {
+				return nil, false, fmt.Errorf("the arg of cardinality must be tag key:%s", field.Expr)
+			}
+			fields = append(fields, cardinalityField{alias: alias, tagKey: tagKey.Name})
+		default:
+			return nil, false, fmt.Errorf("cardinality accepts at most one tag key:%s", field.Expr)
+		}
+	}
+	if len(fields) == 0 {
+		return nil, false, nil
+	}
+	if len(fields) != len(req.Fields) {
+		return nil, false, fmt.Errorf("cardinality cannot be selected with other fields")
+	}
+	return fields, true, nil
+}
+
+// queryCardinality estimates the cardinality of the query's metric by the sketches of index,
+// returns the result set with one point of each field, the time range of query is ignored.
+func queryCardinality(ctx context.Context, metadataService service.MetadataService, req *models.QueryRequest,
+	fields []cardinalityField,
+) (*models.ResultSet, error) {
+	if len(req.Database) == 0 {
+		return nil, fmt.Errorf("database name cannot be empty")
+	}
+	if len(req.Metric) == 0 {
+		return nil, fmt.Errorf("metric name cannot be empty for cardinality query")
+	}
+	cardinalityReq := &models.CardinalityRequest{Database: req.Database, MetricName: req.Metric}
+	for _, field := range fields {
+		if len(field.tagKey) > 0 {
+			cardinalityReq.TagKeys = append(cardinalityReq.TagKeys, field.tagKey)
+		}
+	}
+	sketches, err := metadataService.Cardinality(ctx, cardinalityReq)
+	if err != nil {
+		return nil, err
+	}
+	result := service.EstimateCardinality(cardinalityReq, sketches)
+	series := models.NewSeries(nil)
+	for _, field := range fields {
+		if len(field.tagKey) == 0 {
+			series.Fields[field.alias] = []float64{float64(result.Series)}
+		} else {
+			series.Fields[field.alias] = []float64{float64(result.TagValues[field.tagKey])}
+		}
+	}
+	return &models.ResultSet{
+		StartTime:  req.Start,
+		Interval:   req.Interval,
+		PointCount: 1,
+		Series:     []*models.Series{series},
+	}, nil
+}
@@ -0,0 +1,165 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/tsdb/index"
+)
+
+type mockMetadataService struct {
+	req *models.CardinalityRequest
+	err error
+}
+
+func (s *mockMetadataService) Suggest(ctx context.Context, req *models.SuggestRequest) ([]string, error) {
+	return nil, nil
+}
+
+func (s *mockMetadataService) Cardinality(ctx context.Context, req *models.CardinalityRequest) (*index.MetricCardinality, error) {
+	s.req = req
+	if s.err != nil {
+		return nil, s.err
+	}
+	sketches := index.NewMetricCardinality()
+	sketches.AddSeries(map[string]string{"host": "host-1", "ip": "1.1.1.1"})
+	sketches.AddSeries(map[string]string{"host": "host-2", "ip": "1.1.1.1"})
+	sketches.AddSeries(map[string]string{"host": "host-3", "ip": "1.1.1.1"})
+	return sketches, nil
+}
+
+func (s *mockMetadataService) WriteEvents(ctx context.Context, req *models.EventWriteRequest) error {
+	return nil
+}
+
+func (s *mockMetadataService) QueryEvents(ctx context.Context, query *models.EventQuery) ([]models.Event, error) {
+	return nil, nil
+}
+
+type mockAuthorizer struct {
+	database string
+}
+
+func (a *mockAuthorizer) Authorize(ctx context.Context, database string) error {
+	if database == a.database {
+		return errors.Wrapf(errors.ErrQueryUnauthorized, "query of database[%s]", database)
+	}
+	return nil
+}
+
+func TestQueryAPI_Query_Cardinality(t *testing.T) {
+	executor := &mockExecutor{}
+	srv := &mockMetadataService{}
+	api := NewQueryAPI(executor, nil, srv, nil)
+	req := &models.QueryRequest{
+		Database: "db",
+		Metric:   "cpu",
+		Fields: []models.QueryField{
+			{Expr: "cardinality()"},
+			{Alias: "hosts", Expr: "cardinality(host)"},
+			{Alias: "zones", Expr: "cardinality(zone)"},
+		},
+		Start:    1000,
+		Interval: 10,
+	}
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/query",
+		RequestBody:    req,
+		HandlerFunc:    api.Query,
+		ExpectHTTPCode: 200,
+		ExpectResponse: &resultSet{
+			StartTime:  1000,
+			Interval:   10,
+			PointCount: 1,
+			Series: []series{{Fields: map[string][]*float64{
+				"cardinality()": {float64Ptr(3)},
+				"hosts":         {float64Ptr(3)},
+				"zones":         {float64Ptr(0)},
+			}}},
+		},
+	})
+	assert.Equal(t, &models.CardinalityRequest{Database: "db", MetricName: "cpu", TagKeys: []string{"host", "zone"}}, srv.req)
+	// cardinality query isn't executed by executor
+	assert.Nil(t, executor.req)
+
+	for _, fields := range [][]models.QueryField{
+		{{Expr: "cardinality()"}, {Expr: "used"}},
+		{{Expr: "cardinality(1)"}},
+		{{Expr: "cardinality(host, ip)"}},
+	} {
+		mock.DoRequest(t, &mock.HTTPHandler{
+			Method:         http.MethodPost,
+			URL:            "/api/v1/query",
+			RequestBody:    &models.QueryRequest{Database: "db", Metric: "cpu", Fields: fields},
+			HandlerFunc:    api.Query,
+			ExpectHTTPCode: 500,
+		})
+	}
+	// database/metric name is required
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/query",
+		RequestBody:    &models.QueryRequest{Database: "db", Fields: req.Fields},
+		HandlerFunc:    api.Query,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/query",
+		RequestBody:    &models.QueryRequest{Metric: "cpu", Fields: req.Fields},
+		HandlerFunc:    api.Query,
+		ExpectHTTPCode: 500,
+	})
+	srv.err = fmt.Errorf("err")
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/query",
+		RequestBody:    req,
+		HandlerFunc:    api.Query,
+		ExpectHTTPCode: 500,
+	})
+
+	// invalid expression is executed by executor
+	executor.err = fmt.Errorf("err")
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/query",
+		RequestBody:    &models.QueryRequest{Database: "db", Fields: []models.QueryField{{Expr: "cardinality("}}},
+		HandlerFunc:    api.Query,
+		ExpectHTTPCode: 500,
+	})
+	assert.NotNil(t, executor.req)
+}
+
+func TestQueryAPI_Query_Cardinality_Unauthorized(t *testing.T) {
+	executor := &mockExecutor{}
+	srv := &mockMetadataService{}
+	api := NewQueryAPI(executor, &mockAuthorizer{database: "private"}, srv, nil)
+	fields := []models.QueryField{{Expr: "cardinality()"}}
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/query",
+		RequestBody:    &models.QueryRequest{Database: "private", Metric: "cpu", Fields: fields},
+		HandlerFunc:    api.Query,
+		ExpectHTTPCode: 403,
+	})
+	// cardinality cannot be queried across databases
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method: http.MethodPost,
+		URL:    "/api/v1/query",
+		RequestBody: &models.QueryRequest{Fields: fields,
+			From: []models.QueryMetric{{Database: "db1", Metric: "cpu"}, {Database: "db2", Metric: "cpu"}}},
+		HandlerFunc:    api.Query,
+		ExpectHTTPCode: 500,
+	})
+	assert.Nil(t, srv.req)
+	assert.Nil(t, executor.req)
+}
@@ -0,0 +1,69 @@
+package query
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const gzipEncoding = "gzip"
+
+// gzipResponseWriter compresses the response by gzip, the compressed data is flushed to client
+// when the response is flushed, so that the chunks of result set are streamed as well.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gw *gzip.Writer
+}
+
+// newGzipResponseWriter creates the gzip response writer, the header should be written before
+func newGzipResponseWriter(w http.ResponseWriter) *gzipResponseWriter {
+	return &gzipResponseWriter{
+		ResponseWriter: w,
+		gw:             gzip.NewWriter(w),
+	}
+}
+
+// Write compresses the data
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	return w.gw.Write(b)
+}
+
+// Flush flushes the compressed data into response, then flushes the response
+func (w *gzipResponseWriter) Flush() {
+	_ = w.gw.Flush()
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Close writes the end of gzip stream
+func (w *gzipResponseWriter) Close() error {
+	return w.gw.Close()
+}
+
+// acceptGzip checks if gzip is accepted by Accept-Encoding header, the coding with q=0 isn't acceptable,
+// the explicit gzip coding takes precedence over the wildcard.
+func acceptGzip(acceptEncoding string) bool {
+	wildcardAccepted := false
+	for _, coding := range strings.Split(acceptEncoding, ",") {
+		params := strings.Split(coding, ";")
+		accepted := true
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			if q, err := strconv.ParseFloat(param[2:], 64); err == nil && q <= 0 {
+				accepted = false
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(params[0])) {
+		case gzipEncoding:
+			return accepted
+		case "*":
+			wildcardAccepted = accepted
+		}
+	}
+	return wildcardAccepted
+}
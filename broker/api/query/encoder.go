@@ -0,0 +1,267 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/tinylib/msgp/msgp"
+
+	"github.com/eleme/lindb/models"
+)
+
+const (
+	jsonContentType    = "application/json; charset=utf-8"
+	msgpackContentType = "application/x-msgpack"
+	// seriesPerChunk is the number of series which are written in one chunk
+	seriesPerChunk = 100
+)
+
+// encoder encodes the result set to bytes, the series are encoded one by one,
+// so that the large result set can be streamed in chunks.
+type encoder interface {
+	// contentType returns the content type of response
+	contentType() string
+	// appendHeader appends the header of result set(time slots, metadata and series count)
+	appendHeader(b []byte, rs *models.ResultSet) []byte
+	// appendSeries appends the series, idx is the index of series in result set
+	appendSeries(b []byte, idx int, series *models.Series) ([]byte, error)
+	// appendFooter appends the end of result set
+	appendFooter(b []byte) []byte
+}
+
+// writeResultSet writes the result set to response in chunks,
+// flushes the response after writing each chunk, which makes http server use chunked transfer encoding.
+func writeResultSet(w http.ResponseWriter, enc encoder, rs *models.ResultSet) error {
+	flusher, _ := w.(http.Flusher)
+	flush := func(b []byte) error {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+	b := enc.appendHeader(nil, rs)
+	var err error
+	for idx, series := range rs.Series {
+		if b, err = enc.appendSeries(b, idx, series); err != nil {
+			return err
+		}
+		if (idx+1)%seriesPerChunk == 0 {
+			if err := flush(b); err != nil {
+				return err
+			}
+			b = b[:0]
+		}
+	}
+	return flush(enc.appendFooter(b))
+}
+
+// jsonEncoder encodes the result set as json, NaN value is encoded as null
+type jsonEncoder struct {
+}
+
+// jsonValue represents the value of time slot which is encoded as null if no data
+type jsonValue float64
+
+// MarshalJSON encodes NaN/Inf as null, because json doesn't support them
+func (v jsonValue) MarshalJSON() ([]byte, error) {
+	f := float64(v)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return []byte("null"), nil
+	}
+	return strconv.AppendFloat(nil, f, 'g', -1, 64), nil
+}
+
+// jsonSeries represents the json format of series
+type jsonSeries struct {
+	Metric   string                 `json:"metric,omitempty"`
+	Database string                 `json:"database,omitempty"`
+	Tags     map[string]string      `json:"tags,omitempty"`
+	Fields   map[string][]jsonValue `json:"fields"`
+}
+
+func (e *jsonEncoder) contentType() string {
+	return jsonContentType
+}
+
+func (e *jsonEncoder) appendHeader(b []byte, rs *models.ResultSet) []byte {
+	b = append(b, fmt.Sprintf(`{"startTime":%d,"interval":%d,"pointCount":%d,`,
+		rs.StartTime, rs.Interval, rs.PointCount)...)
+	// the metadata are small, which are encoded with the header
+	metadata := struct {
+		Columns       []models.Column     `json:"columns,omitempty"`
+		Stats         *models.ResultStats `json:"stats,omitempty"`
+		Partial       bool                `json:"partial,omitempty"`
+		MissingShards []int               `json:"missingShards,omitempty"`
+		Continue      string              `json:"continue,omitempty"`
+	}{rs.Columns, rs.Stats, rs.Partial, rs.MissingShards, rs.Continue}
+	if data, err := json.Marshal(&metadata); err == nil && len(data) > 2 {
+		// appends the fields of metadata object without braces
+		b = append(b, data[1:len(data)-1]...)
+		b = append(b, ',')
+	}
+	return append(b, `"series":[`...)
+}
+
+func (e *jsonEncoder) appendSeries(b []byte, idx int, series *models.Series) ([]byte, error) {
+	s := jsonSeries{
+		Metric:   series.Metric,
+		Database: series.Database,
+		Tags:     series.Tags,
+		Fields:   make(map[string][]jsonValue, len(series.Fields)),
+	}
+	for field, values := range series.Fields {
+		jsonValues := make([]jsonValue, len(values))
+		for i, value := range values {
+			jsonValues[i] = jsonValue(value)
+		}
+		s.Fields[field] = jsonValues
+	}
+	data, err := json.Marshal(&s)
+	if err != nil {
+		return b, err
+	}
+	if idx > 0 {
+		b = append(b, ',')
+	}
+	return append(b, data...), nil
+}
+
+func (e *jsonEncoder) appendFooter(b []byte) []byte {
+	return append(b, "]}"...)
+}
+
+// msgpackEncoder encodes the result set as msgpack with the same structure as json, NaN value is encoded as nil
+type msgpackEncoder struct {
+}
+
+func (e *msgpackEncoder) contentType() string {
+	return msgpackContentType
+}
+
+func (e *msgpackEncoder) appendHeader(b []byte, rs *models.ResultSet) []byte {
+	size := uint32(4)
+	if len(rs.Columns) > 0 {
+		size++
+	}
+	if rs.Stats != nil {
+		size++
+	}
+	if rs.Partial {
+		size++
+	}
+	if len(rs.MissingShards) > 0 {
+		size++
+	}
+	if len(rs.Continue) > 0 {
+		size++
+	}
+	b = msgp.AppendMapHeader(b, size)
+	if len(rs.Columns) > 0 {
+		b = msgp.AppendString(b, "columns")
+		b = msgp.AppendArrayHeader(b, uint32(len(rs.Columns)))
+		for _, column := range rs.Columns {
+			b = msgp.AppendMapHeader(b, 2)
+			b = msgp.AppendString(b, "name")
+			b = msgp.AppendString(b, column.Name)
+			b = msgp.AppendString(b, "type")
+			b = msgp.AppendString(b, string(column.Type))
+		}
+	}
+	if rs.Stats != nil {
+		b = msgp.AppendString(b, "stats")
+		b = msgp.AppendMapHeader(b, 2)
+		b = msgp.AppendString(b, "scannedSeries")
+		b = msgp.AppendInt64(b, rs.Stats.ScannedSeries)
+		b = msgp.AppendString(b, "scannedPoints")
+		b = msgp.AppendInt64(b, rs.Stats.ScannedPoints)
+	}
+	if rs.Partial {
+		b = msgp.AppendString(b, "partial")
+		b = msgp.AppendBool(b, true)
+	}
+	if len(rs.MissingShards) > 0 {
+		b = msgp.AppendString(b, "missingShards")
+		b = msgp.AppendArrayHeader(b, uint32(len(rs.MissingShards)))
+		for _, shardID := range rs.MissingShards {
+			b = msgp.AppendInt(b, shardID)
+		}
+	}
+	if len(rs.Continue) > 0 {
+		b = msgp.AppendString(b, "continue")
+		b = msgp.AppendString(b, rs.Continue)
+	}
+	b = msgp.AppendString(b, "startTime")
+	b = msgp.AppendInt64(b, rs.StartTime)
+	b = msgp.AppendString(b, "interval")
+	b = msgp.AppendInt64(b, rs.Interval)
+	b = msgp.AppendString(b, "pointCount")
+	b = msgp.AppendInt(b, rs.PointCount)
+	b = msgp.AppendString(b, "series")
+	return msgp.AppendArrayHeader(b, uint32(len(rs.Series)))
+}
+
+func (e *msgpackEncoder) appendSeries(b []byte, idx int, series *models.Series) ([]byte, error) {
+	size := uint32(2)
+	if len(series.Metric) > 0 {
+		size++
+	}
+	if len(series.Database) > 0 {
+		size++
+	}
+	b = msgp.AppendMapHeader(b, size)
+	if len(series.Metric) > 0 {
+		b = msgp.AppendString(b, "metric")
+		b = msgp.AppendString(b, series.Metric)
+	}
+	if len(series.Database) > 0 {
+		b = msgp.AppendString(b, "database")
+		b = msgp.AppendString(b, series.Database)
+	}
+	b = msgp.AppendString(b, "tags")
+	b = msgp.AppendMapHeader(b, uint32(len(series.Tags)))
+	for _, key := range sortedKeys(series.Tags) {
+		b = msgp.AppendString(b, key)
+		b = msgp.AppendString(b, series.Tags[key])
+	}
+	fields := make([]string, 0, len(series.Fields))
+	for field := range series.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	b = msgp.AppendString(b, "fields")
+	b = msgp.AppendMapHeader(b, uint32(len(fields)))
+	for _, field := range fields {
+		values := series.Fields[field]
+		b = msgp.AppendString(b, field)
+		b = msgp.AppendArrayHeader(b, uint32(len(values)))
+		for _, value := range values {
+			if math.IsNaN(value) {
+				b = msgp.AppendNil(b)
+			} else {
+				b = msgp.AppendFloat64(b, value)
+			}
+		}
+	}
+	return b, nil
+}
+
+func (e *msgpackEncoder) appendFooter(b []byte) []byte {
+	return b
+}
+
+// sortedKeys returns the sorted keys of tags
+func sortedKeys(tags map[string]string) []string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,139 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/eleme/lindb/broker/api"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/query"
+	"github.com/eleme/lindb/service"
+)
+
+// QueryAPI represents the query rest api which executes the structured query DSL
+type QueryAPI struct {
+	executor        query.BrokerExecutor
+	authorizer      query.Authorizer
+	metadataService service.MetadataService
+	statsRecorder   service.DatabaseStatsRecorder
+}
+
+// NewQueryAPI creates the query api instance, the cardinality query is estimated by metadata service after
+// authorized(nil authorizer means no authorization), the queries are recorded into database statistics
+// if stats recorder isn't nil
+func NewQueryAPI(executor query.BrokerExecutor, authorizer query.Authorizer, metadataService service.MetadataService,
+	statsRecorder service.DatabaseStatsRecorder) *QueryAPI {
+	return &QueryAPI{
+		executor:        executor,
+		authorizer:      authorizer,
+		metadataService: metadataService,
+		statsRecorder:   statsRecorder,
+	}
+}
+
+// Query executes the query DSL, the query is the json body of POST request or the param 'q' of GET request,
+// then responses the result set in the format(json/msgpack) negotiated by Accept header,
+// series of result set are streamed in chunks for large result set.
+// The response is compressed by gzip if it's accepted by Accept-Encoding header.
+func (q *QueryAPI) Query(w http.ResponseWriter, r *http.Request) {
+	req, err := parseQueryRequest(r)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	start := time.Now()
+	rs, err := q.execute(r.Context(), req)
+	q.recordStats(req, rs, time.Since(start))
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	enc := negotiateEncoder(r.Header.Get("Accept"))
+	w.Header().Set("Content-Type", enc.contentType())
+	w.Header().Add("Vary", "Accept-Encoding")
+	if !acceptGzip(r.Header.Get("Accept-Encoding")) {
+		w.WriteHeader(http.StatusOK)
+		_ = writeResultSet(w, enc, rs)
+		return
+	}
+	w.Header().Set("Content-Encoding", gzipEncoding)
+	w.WriteHeader(http.StatusOK)
+	gw := newGzipResponseWriter(w)
+	_ = writeResultSet(gw, enc, rs)
+	_ = gw.Close()
+}
+
+// execute executes the query request before the deadline of context,
+// the query which selects cardinality is estimated by index sketches
+func (q *QueryAPI) execute(ctx context.Context, req *models.QueryRequest) (*models.ResultSet, error) {
+	fields, ok, err := parseCardinalityFields(req)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if len(req.From) > 0 {
+			return nil, fmt.Errorf("cardinality cannot be queried across databases")
+		}
+		if err := query.Authorize(ctx, q.authorizer, req); err != nil {
+			return nil, err
+		}
+		return queryCardinality(ctx, q.metadataService, req, fields)
+	}
+	return q.executor.Execute(ctx, req)
+}
+
+// recordStats records the query into database statistics, the scan bytes are estimated by the points scanned
+func (q *QueryAPI) recordStats(req *models.QueryRequest, rs *models.ResultSet, latency time.Duration) {
+	if q.statsRecorder == nil {
+		return
+	}
+	var scanBytes int64
+	if rs != nil && rs.Stats != nil {
+		scanBytes = rs.Stats.ScannedPoints * models.PointBytes
+	}
+	for _, database := range req.Databases() {
+		if len(database) > 0 {
+			q.statsRecorder.RecordQuery(database, latency, scanBytes)
+		}
+	}
+}
+
+// parseQueryRequest parses the query request from http request
+func parseQueryRequest(r *http.Request) (*models.QueryRequest, error) {
+	req := &models.QueryRequest{}
+	switch r.Method {
+	case http.MethodPost:
+		if err := api.GetJSONBodyFromRequest(r, req); err != nil {
+			return nil, fmt.Errorf("parse query request error:%s", err)
+		}
+	default:
+		q, err := api.GetParamsFromRequest("q", r, "", true)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(q), req); err != nil {
+			return nil, fmt.Errorf("parse query request error:%s", err)
+		}
+	}
+	return req, nil
+}
+
+// negotiateEncoder returns the encoder of result set by Accept header, default is json
+func negotiateEncoder(accept string) encoder {
+	for _, mediaType := range strings.Split(accept, ",") {
+		if idx := strings.Index(mediaType, ";"); idx >= 0 {
+			mediaType = mediaType[:idx]
+		}
+		switch strings.TrimSpace(mediaType) {
+		case msgpackContentType, "application/msgpack":
+			return &msgpackEncoder{}
+		case "application/json":
+			return &jsonEncoder{}
+		}
+	}
+	return &jsonEncoder{}
+}
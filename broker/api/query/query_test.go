@@ -0,0 +1,335 @@
+package query
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinylib/msgp/msgp"
+
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/service"
+)
+
+type mockExecutor struct {
+	req *models.QueryRequest
+	rs  *models.ResultSet
+	err error
+}
+
+func (e *mockExecutor) Execute(ctx context.Context, req *models.QueryRequest) (*models.ResultSet, error) {
+	e.req = req
+	return e.rs, e.err
+}
+
+// resultSet represents the decoded result set, null value is decoded as nil
+type resultSet struct {
+	StartTime  int64    `json:"startTime"`
+	Interval   int64    `json:"interval"`
+	PointCount int      `json:"pointCount"`
+	Partial    bool     `json:"partial,omitempty"`
+	Series     []series `json:"series"`
+}
+
+type series struct {
+	Tags   map[string]string     `json:"tags,omitempty"`
+	Fields map[string][]*float64 `json:"fields"`
+}
+
+func float64Ptr(v float64) *float64 {
+	return &v
+}
+
+func newResultSet(seriesCount int) *models.ResultSet {
+	rs := &models.ResultSet{StartTime: 1000, Interval: 10, PointCount: 2}
+	for i := 0; i < seriesCount; i++ {
+		series := models.NewSeries(map[string]string{"host": fmt.Sprintf("%d", i)})
+		series.Fields["f"] = []float64{float64(i), math.NaN()}
+		rs.Series = append(rs.Series, series)
+	}
+	return rs
+}
+
+func TestQueryAPI_Query_stats(t *testing.T) {
+	rs := newResultSet(1)
+	rs.Stats = &models.ResultStats{ScannedSeries: 1, ScannedPoints: 10}
+	executor := &mockExecutor{rs: rs}
+	recorder := service.NewDatabaseStatsRecorder()
+	api := NewQueryAPI(executor, nil, nil, recorder)
+	req := &models.QueryRequest{Database: "db", Metric: "cpu", Fields: []models.QueryField{{Expr: "used"}}}
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/query",
+		RequestBody:    req,
+		HandlerFunc:    api.Query,
+		ExpectHTTPCode: 200,
+	})
+	// the failed query is also recorded
+	executor.rs = nil
+	executor.err = fmt.Errorf("err")
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/query",
+		RequestBody:    req,
+		HandlerFunc:    api.Query,
+		ExpectHTTPCode: 500,
+	})
+	stats := recorder.Snapshot()
+	assert.Len(t, stats, 1)
+	assert.Equal(t, "db", stats[0].Database)
+	assert.Equal(t, int64(2), stats[0].Queries)
+	assert.Equal(t, int64(10*models.PointBytes), stats[0].ScanBytes)
+	assert.Equal(t, int64(5), stats[0].P99Latency)
+}
+
+func TestQueryAPI_Query_JSON(t *testing.T) {
+	executor := &mockExecutor{rs: newResultSet(2)}
+	api := NewQueryAPI(executor, nil, nil, nil)
+	req := &models.QueryRequest{
+		Database: "db",
+		Metric:   "cpu",
+		Fields:   []models.QueryField{{Alias: "f", Expr: "used"}},
+		Start:    1000,
+		End:      2000,
+		Interval: 10,
+	}
+	expect := &resultSet{
+		StartTime:  1000,
+		Interval:   10,
+		PointCount: 2,
+		Series: []series{
+			{Tags: map[string]string{"host": "0"}, Fields: map[string][]*float64{"f": {float64Ptr(0), nil}}},
+			{Tags: map[string]string{"host": "1"}, Fields: map[string][]*float64{"f": {float64Ptr(1), nil}}},
+		},
+	}
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/query",
+		RequestBody:    req,
+		HandlerFunc:    api.Query,
+		ExpectHTTPCode: 200,
+		ExpectResponse: expect,
+	})
+	assert.Equal(t, req, executor.req)
+
+	q, _ := json.Marshal(req)
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/api/v1/query?q=" + url.QueryEscape(string(q)),
+		HandlerFunc:    api.Query,
+		ExpectHTTPCode: 200,
+		ExpectResponse: expect,
+	})
+	assert.Equal(t, req, executor.req)
+
+	// empty result set
+	executor.rs = &models.ResultSet{}
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/query",
+		RequestBody:    req,
+		HandlerFunc:    api.Query,
+		ExpectHTTPCode: 200,
+		ExpectResponse: &resultSet{Series: []series{}},
+	})
+
+	// partial result set
+	executor.rs = &models.ResultSet{Partial: true}
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/query",
+		RequestBody:    req,
+		HandlerFunc:    api.Query,
+		ExpectHTTPCode: 200,
+		ExpectResponse: &resultSet{Partial: true, Series: []series{}},
+	})
+
+	// the metadata and metric of series are encoded, decoded by the models of result set
+	executor.rs = newResultSetWithMetadata()
+	body, _ := json.Marshal(req)
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/query", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+	api.Query(rr, r)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rs := &models.ResultSet{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), rs))
+	assertResultSetWithMetadata(t, rs)
+}
+
+func newResultSetWithMetadata() *models.ResultSet {
+	rs := newResultSet(1)
+	rs.Series[0].Metric = "cpu"
+	rs.Series[0].Database = "db"
+	rs.Columns = []models.Column{{Name: "f", Type: models.IntegerValue}}
+	rs.Stats = &models.ResultStats{ScannedSeries: 1, ScannedPoints: 2}
+	rs.Partial = true
+	rs.MissingShards = []int{1, 2}
+	rs.Continue = "token"
+	return rs
+}
+
+func assertResultSetWithMetadata(t *testing.T, rs *models.ResultSet) {
+	assert.Equal(t, []models.Column{{Name: "f", Type: models.IntegerValue}}, rs.Columns)
+	assert.Equal(t, &models.ResultStats{ScannedSeries: 1, ScannedPoints: 2}, rs.Stats)
+	assert.True(t, rs.Partial)
+	assert.Equal(t, []int{1, 2}, rs.MissingShards)
+	assert.Equal(t, "token", rs.Continue)
+	assert.Len(t, rs.Series, 1)
+	assert.Equal(t, "cpu", rs.Series[0].Metric)
+	assert.Equal(t, "db", rs.Series[0].Database)
+	assert.Equal(t, 0.0, rs.Series[0].Fields["f"][0])
+	assert.True(t, math.IsNaN(rs.Series[0].Fields["f"][1]))
+}
+
+func TestQueryAPI_Query_Fail(t *testing.T) {
+	executor := &mockExecutor{err: fmt.Errorf("err")}
+	api := NewQueryAPI(executor, nil, nil, nil)
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/query",
+		RequestBody:    &models.QueryRequest{},
+		HandlerFunc:    api.Query,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/query",
+		RequestBody:    "abc",
+		HandlerFunc:    api.Query,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/api/v1/query",
+		HandlerFunc:    api.Query,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodGet,
+		URL:            "/api/v1/query?q=abc",
+		HandlerFunc:    api.Query,
+		ExpectHTTPCode: 500,
+	})
+	// the blacklisted query is forbidden
+	executor.err = errors.Wrapf(errors.ErrQueryBlacklisted, "rule[url]")
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/query",
+		RequestBody:    &models.QueryRequest{},
+		HandlerFunc:    api.Query,
+		ExpectHTTPCode: 403,
+	})
+}
+
+func TestQueryAPI_Query_Msgpack(t *testing.T) {
+	api := NewQueryAPI(&mockExecutor{rs: newResultSet(seriesPerChunk + 1)}, nil, nil, nil)
+	body, _ := json.Marshal(&models.QueryRequest{})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/query", bytes.NewReader(body))
+	req.Header.Set("Accept", "application/x-msgpack;q=0.9, application/json")
+	rr := httptest.NewRecorder()
+	api.Query(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, msgpackContentType, rr.Header().Get("Content-Type"))
+	assert.True(t, rr.Flushed)
+	var buf bytes.Buffer
+	_, err := msgp.UnmarshalAsJSON(&buf, rr.Body.Bytes())
+	assert.Nil(t, err)
+	result := &resultSet{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), result))
+	assert.Equal(t, int64(1000), result.StartTime)
+	assert.Equal(t, int64(10), result.Interval)
+	assert.Equal(t, 2, result.PointCount)
+	assert.Equal(t, seriesPerChunk+1, len(result.Series))
+	assert.Equal(t, map[string]string{"host": "100"}, result.Series[100].Tags)
+	assert.Equal(t, 100.0, *result.Series[100].Fields["f"][0])
+	assert.Nil(t, result.Series[100].Fields["f"][1])
+	assert.False(t, result.Partial)
+
+	// partial result set
+	rs := newResultSet(1)
+	rs.Partial = true
+	api = NewQueryAPI(&mockExecutor{rs: rs}, nil, nil, nil)
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/query", bytes.NewReader(body))
+	req.Header.Set("Accept", msgpackContentType)
+	rr = httptest.NewRecorder()
+	api.Query(rr, req)
+	buf.Reset()
+	_, err = msgp.UnmarshalAsJSON(&buf, rr.Body.Bytes())
+	assert.Nil(t, err)
+	result = &resultSet{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), result))
+	assert.True(t, result.Partial)
+	assert.Equal(t, 1, len(result.Series))
+
+	// the metadata and metric of series are encoded
+	api = NewQueryAPI(&mockExecutor{rs: newResultSetWithMetadata()}, nil, nil, nil)
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/query", bytes.NewReader(body))
+	req.Header.Set("Accept", msgpackContentType)
+	rr = httptest.NewRecorder()
+	api.Query(rr, req)
+	buf.Reset()
+	_, err = msgp.UnmarshalAsJSON(&buf, rr.Body.Bytes())
+	assert.Nil(t, err)
+	rs = &models.ResultSet{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), rs))
+	assertResultSetWithMetadata(t, rs)
+}
+
+func TestQueryAPI_Query_Gzip(t *testing.T) {
+	api := NewQueryAPI(&mockExecutor{rs: newResultSet(seriesPerChunk + 1)}, nil, nil, nil)
+	body, _ := json.Marshal(&models.QueryRequest{})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/query", bytes.NewReader(body))
+	req.Header.Set("Accept-Encoding", "deflate, gzip;q=0.8")
+	rr := httptest.NewRecorder()
+	api.Query(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "gzip", rr.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", rr.Header().Get("Vary"))
+	assert.True(t, rr.Flushed)
+	gr, err := gzip.NewReader(rr.Body)
+	assert.Nil(t, err)
+	rs := &models.ResultSet{}
+	assert.Nil(t, json.NewDecoder(gr).Decode(rs))
+	assert.Equal(t, seriesPerChunk+1, len(rs.Series))
+	assert.Equal(t, 100.0, rs.Series[100].Fields["f"][0])
+
+	// gzip isn't accepted
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/query", bytes.NewReader(body))
+	req.Header.Set("Accept-Encoding", "gzip;q=0")
+	rr = httptest.NewRecorder()
+	api.Query(rr, req)
+	assert.Empty(t, rr.Header().Get("Content-Encoding"))
+	rs = &models.ResultSet{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), rs))
+	assert.Equal(t, seriesPerChunk+1, len(rs.Series))
+}
+
+func TestAcceptGzip(t *testing.T) {
+	assert.False(t, acceptGzip(""))
+	assert.False(t, acceptGzip("deflate, br"))
+	assert.True(t, acceptGzip("gzip"))
+	assert.True(t, acceptGzip("GZIP;q=0.5"))
+	assert.True(t, acceptGzip("*"))
+	assert.False(t, acceptGzip("gzip;q=0, *"))
+	assert.False(t, acceptGzip("*;q=0"))
+	assert.True(t, acceptGzip("gzip;level=1;q=a"))
+}
+
+func TestNegotiateEncoder(t *testing.T) {
+	assert.Equal(t, &jsonEncoder{}, negotiateEncoder(""))
+	assert.Equal(t, &jsonEncoder{}, negotiateEncoder("text/html"))
+	assert.Equal(t, &jsonEncoder{}, negotiateEncoder("application/json, application/x-msgpack"))
+	assert.Equal(t, &msgpackEncoder{}, negotiateEncoder("application/msgpack"))
+}
@@ -0,0 +1,48 @@
+package query
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/eleme/lindb/broker/api"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/service"
+)
+
+// RawQueryAPI represents the raw query rest api, which returns the stored points of series without aggregation
+// or down sampling, for debugging ingestion correctness and exporting, the result size is strictly guarded.
+type RawQueryAPI struct {
+	rawQueryService service.RawQueryService
+	statsRecorder   service.DatabaseStatsRecorder
+}
+
+// NewRawQueryAPI creates the raw query api instance, the queries are recorded into database statistics
+// if stats recorder isn't nil, the scan bytes are recorded by storage nodes
+func NewRawQueryAPI(rawQueryService service.RawQueryService,
+	statsRecorder service.DatabaseStatsRecorder) *RawQueryAPI {
+	return &RawQueryAPI{
+		rawQueryService: rawQueryService,
+		statsRecorder:   statsRecorder,
+	}
+}
+
+// Query returns the raw points of series in all replicas of database's shards, the request is the json body,
+// the series of replicas are merged if 'merge' of request is true,
+// the result is truncated if it exceeds the max series or max points.
+func (q *RawQueryAPI) Query(w http.ResponseWriter, r *http.Request) {
+	req := &models.RawQueryRequest{}
+	if err := api.GetJSONBodyFromRequest(r, req); err != nil {
+		api.Error(w, err)
+		return
+	}
+	start := time.Now()
+	result, err := q.rawQueryService.Query(r.Context(), req)
+	if q.statsRecorder != nil && len(req.Database) > 0 {
+		q.statsRecorder.RecordQuery(req.Database, time.Since(start), 0)
+	}
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	api.OK(w, result)
+}
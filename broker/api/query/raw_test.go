@@ -0,0 +1,73 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/service"
+)
+
+type mockRawQueryService struct {
+	req *models.RawQueryRequest
+	err error
+}
+
+func (s *mockRawQueryService) Query(ctx context.Context, req *models.RawQueryRequest) (*models.RawQueryResult, error) {
+	s.req = req
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.RawQueryResult{Series: []models.RawSeries{{
+		Node:    "127.0.0.1:2000",
+		ShardID: 1,
+		Fields:  map[string][]models.RawPoint{"f1": {{Timestamp: 10, Value: 1}}},
+	}}, Truncated: true}, nil
+}
+
+func TestRawQueryAPI_Query(t *testing.T) {
+	srv := &mockRawQueryService{}
+	recorder := service.NewDatabaseStatsRecorder()
+	api := NewRawQueryAPI(srv, recorder)
+
+	req := &models.RawQueryRequest{Database: "db", MetricName: "cpu", Fields: []string{"f1"}, Start: 10, End: 20}
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/query/raw",
+		RequestBody:    req,
+		HandlerFunc:    api.Query,
+		ExpectHTTPCode: 200,
+		ExpectResponse: &models.RawQueryResult{Series: []models.RawSeries{{
+			Node:    "127.0.0.1:2000",
+			ShardID: 1,
+			Fields:  map[string][]models.RawPoint{"f1": {{Timestamp: 10, Value: 1}}},
+		}}, Truncated: true},
+	})
+	assert.Equal(t, req, srv.req)
+
+	srv.err = fmt.Errorf("err")
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/query/raw",
+		RequestBody:    req,
+		HandlerFunc:    api.Query,
+		ExpectHTTPCode: 500,
+	})
+	mock.DoRequest(t, &mock.HTTPHandler{
+		Method:         http.MethodPost,
+		URL:            "/api/v1/query/raw",
+		RequestBody:    "bad",
+		HandlerFunc:    api.Query,
+		ExpectHTTPCode: 500,
+	})
+	// the queries are recorded into database statistics, the scan bytes are recorded by storage nodes
+	stats := recorder.Snapshot()
+	assert.Len(t, stats, 1)
+	assert.Equal(t, int64(2), stats[0].Queries)
+	assert.Equal(t, int64(0), stats[0].ScanBytes)
+}
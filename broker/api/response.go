@@ -3,6 +3,8 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/eleme/lindb/pkg/errors"
 )
 
 // OK responses with content and set the http status code 200
@@ -28,10 +30,25 @@ func NotFound(w http.ResponseWriter) {
 	w.WriteHeader(http.StatusNotFound)
 }
 
-// Error responses error message and set the http status code 500
+// Error responses error message and set the http status code by the code of error,
+// 404 for not found error, 400 for invalid argument, others 500.
 func Error(w http.ResponseWriter, err error) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.WriteHeader(http.StatusInternalServerError)
+	w.WriteHeader(httpStatus(err))
 	b, _ := json.Marshal(err.Error())
 	_, _ = w.Write(b)
 }
+
+// httpStatus returns the http status code of error
+func httpStatus(err error) int {
+	switch errors.CodeOf(err) {
+	case errors.CodeFamilyNotFound, errors.CodeShardNotFound, errors.CodeMetricNotFound, errors.CodeDatabaseNotFound:
+		return http.StatusNotFound
+	case errors.CodeInvalidArgument:
+		return http.StatusBadRequest
+	case errors.CodeQueryBlacklisted, errors.CodeQueryUnauthorized:
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
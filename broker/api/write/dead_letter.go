@@ -0,0 +1,46 @@
+package write
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/eleme/lindb/broker/api"
+	"github.com/eleme/lindb/broker/ingestion"
+)
+
+// defaultSampleLimit is the default number of rejects sampled
+const defaultSampleLimit = 20
+
+// DeadLetterAPI represents the api which samples the recent rejected points of database,
+// so that producers can find out why their points are rejected then fix the emitters.
+type DeadLetterAPI struct {
+	deadLetter ingestion.DeadLetterQueue
+}
+
+// NewDeadLetterAPI creates the dead letter api
+func NewDeadLetterAPI(deadLetter ingestion.DeadLetterQueue) *DeadLetterAPI {
+	return &DeadLetterAPI{
+		deadLetter: deadLetter,
+	}
+}
+
+// Sample samples the recent rejected points of database ordered by time desc, the url is like
+// '/write/rejects?db=xx&limit=20'
+func (d *DeadLetterAPI) Sample(w http.ResponseWriter, r *http.Request) {
+	db, err := api.GetParamsFromRequest("db", r, "", true)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	limitStr, err := api.GetParamsFromRequest("limit", r, strconv.Itoa(defaultSampleLimit), false)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	api.OK(w, d.deadLetter.Sample(db, limit))
+}
@@ -0,0 +1,47 @@
+package write
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/broker/ingestion"
+	"github.com/eleme/lindb/config"
+)
+
+func TestDeadLetterAPI_Sample(t *testing.T) {
+	deadLetter, err := ingestion.NewDeadLetterQueue(config.DeadLetter{SampleSize: 10})
+	assert.Nil(t, err)
+	writeAPI := NewWriteAPI(&mockWriter{}, deadLetter, nil, nil)
+	rr := doWrite(writeAPI, "/api/v1/write?db=db", strings.NewReader("cpu usage=1\ncpu usage\nmemory"), "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	deadLetterAPI := NewDeadLetterAPI(deadLetter)
+	sample := func(url string) (int, []ingestion.RejectedPoint) {
+		rr := httptest.NewRecorder()
+		deadLetterAPI.Sample(rr, httptest.NewRequest(http.MethodGet, url, nil))
+		var rejects []ingestion.RejectedPoint
+		if rr.Code == http.StatusOK {
+			assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &rejects))
+		}
+		return rr.Code, rejects
+	}
+	code, rejects := sample("/write/rejects?db=db")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, rejects, 2)
+	assert.Equal(t, "memory", rejects[0].Line)
+	assert.Equal(t, "cpu usage", rejects[1].Line)
+	assert.NotEmpty(t, rejects[1].Reason)
+
+	code, rejects = sample("/write/rejects?db=db&limit=1")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, rejects, 1)
+	code, _ = sample("/write/rejects?db=db&limit=a")
+	assert.Equal(t, http.StatusInternalServerError, code)
+	code, _ = sample("/write/rejects")
+	assert.NotEqual(t, http.StatusOK, code)
+}
@@ -0,0 +1,46 @@
+package write
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/eleme/lindb/broker/api"
+	"github.com/eleme/lindb/pkg/tracing"
+)
+
+// defaultTraceLimit is the default number of write traces returned
+const defaultTraceLimit = 20
+
+// TraceAPI represents the api which returns the recent write traces which are sampled or slow,
+// so that the stage causing latency regression of write path can be localized quickly.
+type TraceAPI struct {
+	tracer tracing.Tracer
+}
+
+// NewTraceAPI creates the write trace api
+func NewTraceAPI(tracer tracing.Tracer) *TraceAPI {
+	return &TraceAPI{
+		tracer: tracer,
+	}
+}
+
+// Recent returns the recent write traces of database(all databases if empty) ordered by time desc,
+// the url is like '/write/trace?db=xx&limit=20'
+func (t *TraceAPI) Recent(w http.ResponseWriter, r *http.Request) {
+	db, err := api.GetParamsFromRequest("db", r, "", false)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	limitStr, err := api.GetParamsFromRequest("limit", r, strconv.Itoa(defaultTraceLimit), false)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		api.Error(w, err)
+		return
+	}
+	api.OK(w, t.tracer.Recent(db, limit))
+}
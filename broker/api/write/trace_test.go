@@ -0,0 +1,58 @@
+package write
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/tracing"
+)
+
+func TestTraceAPI_Recent(t *testing.T) {
+	// all writes are sampled
+	tracer := tracing.NewTracer(1, 0)
+	writer := &mockWriter{}
+	writeAPI := NewWriteAPI(writer, nil, nil, tracer)
+	rr := doWrite(writeAPI, "/api/v1/write?db=db", strings.NewReader("cpu usage=1\nmemory used=1"), "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	writer.err = fmt.Errorf("err")
+	rr = doWrite(writeAPI, "/api/v1/write?db=db2", strings.NewReader("cpu usage=1"), "")
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	traceAPI := NewTraceAPI(tracer)
+	recent := func(url string) (int, []models.WriteTrace) {
+		rr := httptest.NewRecorder()
+		traceAPI.Recent(rr, httptest.NewRequest(http.MethodGet, url, nil))
+		var traces []models.WriteTrace
+		if rr.Code == http.StatusOK {
+			assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &traces))
+		}
+		return rr.Code, traces
+	}
+	code, traces := recent("/write/trace")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, traces, 2)
+	assert.Equal(t, "db2", traces[0].Database)
+	assert.Equal(t, "err", traces[0].Error)
+	assert.Equal(t, "db", traces[1].Database)
+	assert.Equal(t, 2, traces[1].Points)
+	assert.Empty(t, traces[1].Error)
+	var stages []string
+	for _, stage := range traces[1].Stages {
+		stages = append(stages, stage.Stage)
+	}
+	assert.Equal(t, []string{tracing.StageReceive, tracing.StageParse, tracing.StageRoute}, stages)
+
+	code, traces = recent("/write/trace?db=db&limit=1")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, traces, 1)
+	assert.Equal(t, "db", traces[0].Database)
+	code, _ = recent("/write/trace?limit=a")
+	assert.Equal(t, http.StatusInternalServerError, code)
+}
@@ -0,0 +1,322 @@
+package write
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/eleme/lindb/broker/api"
+	"github.com/eleme/lindb/broker/ingestion"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/pkg/tracing"
+	"github.com/eleme/lindb/service"
+)
+
+// sequenceHeader is the response header of sequence token for read-your-writes
+const sequenceHeader = "X-Lindb-Sequence"
+
+// backfillParam is the param of write request for re-ingesting the corrected historical points
+const backfillParam = "backfill"
+
+// ackParam is the param of write request for choosing the ack mode of write(see models.WriteAck)
+const ackParam = "ack"
+
+// defaultMaxBodySize is the default max size(after decompressed) of write request body
+const defaultMaxBodySize = 32 * 1024 * 1024
+
+// maxSampledLines is the max number of invalid lines sampled in the response of partial write
+const maxSampledLines = 10
+
+// RejectedLine represents the invalid line with the reason
+type RejectedLine struct {
+	Line   string `json:"line"`
+	Reason string `json:"reason"`
+}
+
+// WriteResult represents the result of partial write, the valid lines are written even if some lines are invalid,
+// the invalid lines are counted by the categories of error(format/metric/tag/field/timestamp) with some samples.
+// Error is the first error like influxdb, which is checked by influxdb clients.
+type WriteResult struct {
+	Error    string         `json:"error"`
+	Written  int            `json:"written"`
+	Rejected int            `json:"rejected"`
+	Errors   map[string]int `json:"errors"`
+	Samples  []RejectedLine `json:"samples"`
+}
+
+// precisions are the precisions of timestamp which are compatible with influxdb write api
+var precisions = map[string]time.Duration{
+	"":   time.Nanosecond,
+	"n":  time.Nanosecond,
+	"ns": time.Nanosecond,
+	"u":  time.Microsecond,
+	"us": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+}
+
+// WriteAPI represents the write api which is compatible with the influxdb write api, so that
+// the influxdb output of telegraf can write into LinDB with urls like 'http://broker:9000/api/v1',
+// skip_database_creation of telegraf should be true because the database is created by admin api.
+type WriteAPI struct {
+	writer        ingestion.Writer
+	deadLetter    ingestion.DeadLetterQueue
+	statsRecorder service.DatabaseStatsRecorder
+	tracer        tracing.Tracer
+	maxBodySize   int64
+}
+
+// NewWriteAPI creates the write api which writes the points by writer,
+// the invalid lines are recorded into dead letter queue if it isn't nil,
+// the writes are recorded into database statistics if stats recorder isn't nil,
+// the stage timings of writes are traced by tracer if it isn't nil.
+func NewWriteAPI(writer ingestion.Writer, deadLetter ingestion.DeadLetterQueue,
+	statsRecorder service.DatabaseStatsRecorder, tracer tracing.Tracer) *WriteAPI {
+	return &WriteAPI{
+		writer:        writer,
+		deadLetter:    deadLetter,
+		statsRecorder: statsRecorder,
+		tracer:        tracer,
+		maxBodySize:   defaultMaxBodySize,
+	}
+}
+
+// Write writes the points of line protocol in request body, the url is like '/api/v1/write?db=xx&precision=ns',
+// precision is the unit of timestamps(n/ns, u/us, ms, s, m, h), default is ns like influxdb.
+// The body can be compressed with header 'Content-Encoding: gzip'.
+// If the param 'sequence' is true, the sequence token of shards after the points applied is responded
+// by header 'X-Lindb-Sequence', the query carrying the token(minSequence) reads the written points.
+// The valid lines are written even if some lines are invalid, then responses 400 with WriteResult which has
+// the first parse error, the counts of invalid lines by error category and the sampled invalid lines,
+// the invalid lines are recorded into dead letter queue with the reasons.
+// If the param 'strings' is true, the string field values are kept as the last value of series(see field.StringField),
+// otherwise they are dropped like before.
+// The numeric fields listed by the param 'summary'(comma separated, like 'summary=latency,size') are written as
+// summary fields, which maintain min/max/sum/count of the raw samples per time slot(see field.SummaryField).
+// If the param 'backfill' is true, the points are written by backfill writer, which bypasses the behind window
+// of storage for re-ingesting the corrected historical data, the backfill is authorized for operators only
+// (see IsBackfill), it cannot be combined with 'sequence' because the backfill doesn't advance the sequences.
+// The param 'ack' chooses when the write is acked(enqueue/durable, see models.WriteAck), the default ack mode
+// of database is used if not chosen, it cannot be combined with 'sequence' or 'backfill'.
+// The elapsed time of receiving, parsing and routing are traced by the stages of write path.
+func (wa *WriteAPI) Write(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	db := params.Get("db")
+	if len(db) == 0 {
+		wa.error(w, errors.Wrapf(errors.ErrInvalidArgument, "database cannot be empty"))
+		return
+	}
+	precision, ok := precisions[params.Get("precision")]
+	if !ok {
+		wa.error(w, errors.Wrapf(errors.ErrInvalidArgument, "unknown precision[%s]", params.Get("precision")))
+		return
+	}
+	mode, err := wa.writeMode(r)
+	if err != nil {
+		wa.error(w, err)
+		return
+	}
+	trace := wa.startTrace(db)
+	data, err := wa.readBody(r)
+	if err != nil {
+		trace.Finish(err)
+		wa.error(w, err)
+		return
+	}
+	trace.Stage(tracing.StageReceive)
+	result := &WriteResult{Errors: make(map[string]int)}
+	reject := func(line string, err error) {
+		result.Rejected++
+		category := ingestion.LineErrFormat
+		if lineErr, ok := err.(*ingestion.LineError); ok {
+			category = lineErr.Category
+		}
+		result.Errors[category]++
+		if len(result.Samples) < maxSampledLines {
+			result.Samples = append(result.Samples, RejectedLine{Line: line, Reason: err.Error()})
+		}
+		if wa.deadLetter != nil {
+			wa.deadLetter.Reject(db, line, err.Error())
+		}
+	}
+	parse := ingestion.ParseLineProtocolWithReject
+	if params.Get("strings") == "true" {
+		parse = ingestion.ParseLineProtocolWithStrings
+	}
+	points, parseErr := parse(data, timeutil.Now(), precision, reject)
+	if summary := params.Get("summary"); len(summary) > 0 {
+		ingestion.ToSummaryFields(points, strings.Split(summary, ","))
+	}
+	trace.Stage(tracing.StageParse)
+	trace.SetPoints(len(points))
+	if len(points) > 0 {
+		err = wa.write(w, mode, db, points)
+		trace.Stage(tracing.StageRoute)
+		if err != nil {
+			trace.Finish(err)
+			wa.recordStats(db, 0, 0, len(points)+result.Rejected)
+			wa.error(w, errors.Wrapf(err, "write points error"))
+			return
+		}
+	}
+	trace.Finish(nil)
+	wa.recordStats(db, len(points), len(data), result.Rejected)
+	if parseErr != nil {
+		result.Written = len(points)
+		wa.partialWrite(w, result, errors.Wrapf(errors.ErrInvalidArgument, "partial write, %s", parseErr))
+		return
+	}
+	wa.noContent(w)
+}
+
+// writeMode represents how the points of write request are written and acked
+type writeMode struct {
+	sequenceWriter ingestion.SequenceWriter
+	backfillWriter ingestion.BackfillWriter
+	ackWriter      ingestion.AckWriter
+	ack            models.WriteAck
+}
+
+// writeMode returns the sequence writer if the param 'sequence' is true, returns the backfill writer
+// if the param 'backfill' is true, returns the ack writer with the ack mode of the param 'ack' otherwise,
+// returns error if the writer doesn't support or the params are combined
+func (wa *WriteAPI) writeMode(r *http.Request) (*writeMode, error) {
+	var ok bool
+	mode := &writeMode{ack: models.WriteAck(r.URL.Query().Get(ackParam))}
+	if err := mode.ack.Validate(); err != nil {
+		return nil, errors.Wrapf(errors.ErrInvalidArgument, "%s", err)
+	}
+	if r.URL.Query().Get("sequence") == "true" {
+		if mode.sequenceWriter, ok = wa.writer.(ingestion.SequenceWriter); !ok {
+			return nil, errors.Wrapf(errors.ErrInvalidArgument, "sequence token isn't supported by writer")
+		}
+	}
+	if IsBackfill(r) {
+		if mode.sequenceWriter != nil {
+			return nil, errors.Wrapf(errors.ErrInvalidArgument, "sequence token isn't supported by backfill")
+		}
+		if mode.backfillWriter, ok = wa.writer.(ingestion.BackfillWriter); !ok {
+			return nil, errors.Wrapf(errors.ErrInvalidArgument, "backfill isn't supported by writer")
+		}
+	}
+	if mode.sequenceWriter != nil || mode.backfillWriter != nil {
+		if len(mode.ack) > 0 {
+			return nil, errors.Wrapf(errors.ErrInvalidArgument, "write ack isn't supported by sequence or backfill")
+		}
+		return mode, nil
+	}
+	mode.ackWriter, ok = wa.writer.(ingestion.AckWriter)
+	if !ok && mode.ack == models.WriteAckDurable {
+		return nil, errors.Wrapf(errors.ErrInvalidArgument, "durable ack isn't supported by writer")
+	}
+	return mode, nil
+}
+
+// write writes the points by the writer of write mode, sets the sequence token into response header
+// if sequence writer is given, the points are written by the ack mode if the writer supports ack
+func (wa *WriteAPI) write(w http.ResponseWriter, mode *writeMode, db string, points []models.Point) error {
+	switch {
+	case mode.backfillWriter != nil:
+		return mode.backfillWriter.WriteBackfill(db, points)
+	case mode.sequenceWriter != nil:
+		token, err := mode.sequenceWriter.WriteWithSequence(db, points)
+		if err != nil {
+			return err
+		}
+		w.Header().Set(sequenceHeader, token.String())
+		return nil
+	case mode.ackWriter != nil:
+		return mode.ackWriter.WriteWithAck(db, points, mode.ack)
+	default:
+		return wa.writer.Write(db, points)
+	}
+}
+
+// IsBackfill returns if the write request is backfill, which is authorized by the middleware of operators
+func IsBackfill(r *http.Request) bool {
+	return r.URL.Query().Get(backfillParam) == "true"
+}
+
+// startTrace starts the trace of write batch by tracer, only the stage histograms are observed if no tracer
+func (wa *WriteAPI) startTrace(db string) *tracing.Trace {
+	if wa.tracer == nil {
+		return tracing.NewTrace(db)
+	}
+	return wa.tracer.Start(db)
+}
+
+// recordStats records the points/bytes written and the points failed into database statistics
+func (wa *WriteAPI) recordStats(db string, points, bytes, failedPoints int) {
+	if wa.statsRecorder == nil {
+		return
+	}
+	wa.statsRecorder.RecordWrite(db, int64(points), int64(bytes), int64(failedPoints))
+}
+
+// Ping responses 204 for the health check of influxdb clients
+func (wa *WriteAPI) Ping(w http.ResponseWriter, r *http.Request) {
+	wa.noContent(w)
+}
+
+// readBody reads the request body, decompresses it if gzip encoded
+func (wa *WriteAPI) readBody(r *http.Request) ([]byte, error) {
+	var reader io.Reader = r.Body
+	switch r.Header.Get("Content-Encoding") {
+	case "", "identity":
+	case "gzip":
+		gr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, errors.Wrapf(errors.ErrInvalidArgument, "read gzip body error:%s", err)
+		}
+		defer gr.Close()
+		reader = gr
+	default:
+		return nil, errors.Wrapf(errors.ErrInvalidArgument, "unsupported content encoding[%s]",
+			r.Header.Get("Content-Encoding"))
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(reader, wa.maxBodySize+1))
+	if err != nil {
+		return nil, errors.Wrapf(errors.ErrInvalidArgument, "read body error:%s", err)
+	}
+	if int64(len(data)) > wa.maxBodySize {
+		return nil, errors.Wrapf(errors.ErrInvalidArgument, "body exceeds max size[%d]", wa.maxBodySize)
+	}
+	return data, nil
+}
+
+// error responses the error like influxdb, {"error":"..."}
+func (wa *WriteAPI) error(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, errors.ErrInvalidArgument) {
+		status = http.StatusBadRequest
+	}
+	w.Header().Set("X-Influxdb-Error", err.Error())
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	b, _ := json.Marshal(map[string]string{"error": err.Error()})
+	_, _ = w.Write(b)
+}
+
+// partialWrite responses 400 with the result of partial write, the error is also set in header like influxdb
+func (wa *WriteAPI) partialWrite(w http.ResponseWriter, result *WriteResult, err error) {
+	result.Error = err.Error()
+	w.Header().Set("X-Influxdb-Error", err.Error())
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusBadRequest)
+	b, _ := json.Marshal(result)
+	_, _ = w.Write(b)
+}
+
+// noContent responses 204 with the influxdb version header which is checked by some clients
+func (wa *WriteAPI) noContent(w http.ResponseWriter) {
+	w.Header().Set("X-Influxdb-Version", "1.7-lindb")
+	api.NoContent(w)
+}
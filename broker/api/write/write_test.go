@@ -0,0 +1,334 @@
+package write
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/broker/ingestion"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/service"
+)
+
+type mockWriter struct {
+	database string
+	points   []models.Point
+	err      error
+}
+
+func (w *mockWriter) Write(database string, points []models.Point) error {
+	w.database = database
+	w.points = append(w.points, points...)
+	return w.err
+}
+
+// doWrite sends the write request, returns the response recorder
+func doWrite(api *WriteAPI, url string, body io.Reader, encoding string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, url, body)
+	if len(encoding) > 0 {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	rr := httptest.NewRecorder()
+	api.Write(rr, req)
+	return rr
+}
+
+// errorOf returns the error message of influxdb error response
+func errorOf(t *testing.T, rr *httptest.ResponseRecorder) string {
+	resp := make(map[string]interface{})
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	return resp["error"].(string)
+}
+
+// gzipped compresses the data like the content_encoding = "gzip" of telegraf
+func gzipped(t *testing.T, data []byte) io.Reader {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	_, err := gw.Write(data)
+	assert.Nil(t, err)
+	assert.Nil(t, gw.Close())
+	return &buf
+}
+
+func TestWriteAPI_Write_telegraf(t *testing.T) {
+	batch, err := ioutil.ReadFile("testdata/telegraf.txt")
+	assert.Nil(t, err)
+
+	for _, encoding := range []string{"", "gzip"} {
+		writer := &mockWriter{}
+		api := NewWriteAPI(writer, nil, nil, nil)
+		body := io.Reader(bytes.NewReader(batch))
+		if encoding == "gzip" {
+			body = gzipped(t, batch)
+		}
+		rr := doWrite(api, "/api/v1/write?db=telegraf", body, encoding)
+		assert.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+		assert.NotEmpty(t, rr.Header().Get("X-Influxdb-Version"))
+		assert.Equal(t, "telegraf", writer.database)
+		// the line with only string field is skipped
+		assert.Len(t, writer.points, 13)
+
+		cpu := writer.points[0]
+		assert.Equal(t, "cpu", cpu.Name())
+		assert.Equal(t, int64(1566300010000), cpu.Timestamp())
+		assert.Equal(t, map[string]string{"cpu": "cpu0", "host": "telegraf-01"}, cpu.TagsMap())
+		assert.Equal(t, 97.59036144578313, cpu.Fields()["usage_idle"].(models.SimpleField).Value())
+
+		netResponse := writer.points[11]
+		assert.Equal(t, "broker 01", netResponse.TagsMap()["server"])
+		assert.Len(t, netResponse.Fields(), 2)
+		docker := writer.points[12]
+		assert.Len(t, docker.Fields(), 3)
+	}
+}
+
+func TestWriteAPI_Write_precision(t *testing.T) {
+	cases := map[string]int64{
+		"":   1566300010000000000,
+		"ns": 1566300010000000000,
+		"u":  1566300010000000,
+		"ms": 1566300010000,
+		"s":  1566300010,
+	}
+	for precision, timestamp := range cases {
+		writer := &mockWriter{}
+		url := "/api/v1/write?db=db&precision=" + precision
+		rr := doWrite(NewWriteAPI(writer, nil, nil, nil), url,
+			strings.NewReader(fmt.Sprintf("cpu usage=1 %d", timestamp)), "")
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Equal(t, int64(1566300010000), writer.points[0].Timestamp(), precision)
+	}
+}
+
+func TestWriteAPI_Write_strings(t *testing.T) {
+	batch, err := ioutil.ReadFile("testdata/telegraf.txt")
+	assert.Nil(t, err)
+	writer := &mockWriter{}
+	rr := doWrite(NewWriteAPI(writer, nil, nil, nil), "/api/v1/write?db=telegraf&strings=true",
+		bytes.NewReader(batch), "")
+	assert.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	// the line with only string field is kept
+	assert.Len(t, writer.points, 14)
+	uptime := writer.points[8]
+	assert.Equal(t, "10 days,  0:23", uptime.Fields()["uptime_format"].(models.StringField).Value())
+	netResponse := writer.points[12]
+	assert.Equal(t, "success", netResponse.Fields()["result_type"].(models.StringField).Value())
+}
+
+func TestWriteAPI_Write_summary(t *testing.T) {
+	writer := &mockWriter{}
+	rr := doWrite(NewWriteAPI(writer, nil, nil, nil), "/api/v1/write?db=db&precision=ms&summary=latency",
+		strings.NewReader("rpc latency=20i,count=1i 1000"), "")
+	assert.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	assert.Len(t, writer.points, 1)
+	assert.Equal(t, field.SummaryField, writer.points[0].Fields()["latency"].Type())
+	assert.Equal(t, field.SumField, writer.points[0].Fields()["count"].Type())
+}
+
+func TestWriteAPI_Write_failure(t *testing.T) {
+	writer := &mockWriter{}
+	recorder := service.NewDatabaseStatsRecorder()
+	api := NewWriteAPI(writer, nil, recorder, nil)
+
+	// database is empty
+	rr := doWrite(api, "/api/v1/write", strings.NewReader("cpu usage=1"), "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, errorOf(t, rr), rr.Header().Get("X-Influxdb-Error"))
+	// unknown precision
+	rr = doWrite(api, "/api/v1/write?db=db&precision=d", strings.NewReader("cpu usage=1"), "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	// unsupported encoding
+	rr = doWrite(api, "/api/v1/write?db=db", strings.NewReader("cpu usage=1"), "br")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	// invalid gzip body
+	rr = doWrite(api, "/api/v1/write?db=db", strings.NewReader("cpu usage=1"), "gzip")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	// body too large
+	api.maxBodySize = 5
+	rr = doWrite(api, "/api/v1/write?db=db", strings.NewReader("cpu usage=1"), "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Empty(t, writer.points)
+	api.maxBodySize = defaultMaxBodySize
+
+	// partial write, the valid lines are written
+	rr = doWrite(api, "/api/v1/write?db=db", strings.NewReader("cpu usage=1\ncpu usage"), "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.True(t, strings.HasPrefix(errorOf(t, rr), "partial write, parse line[2] error"))
+	assert.Len(t, writer.points, 1)
+	result := WriteResult{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &result))
+	assert.Equal(t, 1, result.Written)
+	assert.Equal(t, 1, result.Rejected)
+
+	// write failure
+	writer.err = fmt.Errorf("err")
+	rr = doWrite(api, "/api/v1/write?db=db", strings.NewReader("cpu usage=1"), "")
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, "write points error:err", errorOf(t, rr))
+
+	// the written points and the rejected/failed points are recorded into database statistics
+	assert.Equal(t, []models.DatabaseStats{{Database: "db", WrittenPoints: 1, WrittenBytes: 21, FailedPoints: 2}},
+		recorder.Snapshot())
+}
+
+func TestWriteAPI_Write_partial(t *testing.T) {
+	writer := &mockWriter{}
+	api := NewWriteAPI(writer, nil, nil, nil)
+	var lines []string
+	for i := 0; i < maxSampledLines; i++ {
+		lines = append(lines, "cpu,host usage=1")
+	}
+	lines = append(lines, "cpu usage=1", ",host=1 usage=1", "cpu usage=abc", "cpu usage=1 abc", "cpu")
+	rr := doWrite(api, "/api/v1/write?db=db", strings.NewReader(strings.Join(lines, "\n")), "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Len(t, writer.points, 1)
+
+	result := WriteResult{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &result))
+	assert.Equal(t, rr.Header().Get("X-Influxdb-Error"), result.Error)
+	assert.Equal(t, 1, result.Written)
+	assert.Equal(t, maxSampledLines+4, result.Rejected)
+	assert.Equal(t, map[string]int{
+		ingestion.LineErrTag:       maxSampledLines,
+		ingestion.LineErrMetric:    1,
+		ingestion.LineErrField:     1,
+		ingestion.LineErrTimestamp: 1,
+		ingestion.LineErrFormat:    1,
+	}, result.Errors)
+	assert.Len(t, result.Samples, maxSampledLines)
+	assert.Equal(t, RejectedLine{Line: "cpu,host usage=1", Reason: "invalid tag[host]:must be key=value"},
+		result.Samples[0])
+}
+
+type mockSequenceWriter struct {
+	mockWriter
+}
+
+func (w *mockSequenceWriter) WriteWithSequence(database string, points []models.Point) (models.SequenceToken, error) {
+	if err := w.Write(database, points); err != nil {
+		return nil, err
+	}
+	return models.SequenceToken{1: 10, 2: 5}, nil
+}
+
+func TestWriteAPI_Write_sequence(t *testing.T) {
+	// the writer doesn't support sequence token
+	rr := doWrite(NewWriteAPI(&mockWriter{}, nil, nil, nil), "/api/v1/write?db=db&sequence=true",
+		strings.NewReader("cpu usage=1"), "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = doWrite(NewWriteAPI(ingestion.NewFilterWriter(&mockWriter{}, nil), nil, nil, nil),
+		"/api/v1/write?db=db&sequence=true", strings.NewReader("cpu usage=1"), "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	writer := &mockSequenceWriter{}
+	api := NewWriteAPI(writer, nil, nil, nil)
+	rr = doWrite(api, "/api/v1/write?db=db&sequence=true", strings.NewReader("cpu usage=1"), "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "1:10,2:5", rr.Header().Get(sequenceHeader))
+	assert.Len(t, writer.points, 1)
+
+	// sequence token isn't responded by default
+	rr = doWrite(api, "/api/v1/write?db=db", strings.NewReader("cpu usage=1"), "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Empty(t, rr.Header().Get(sequenceHeader))
+
+	writer.err = fmt.Errorf("err")
+	rr = doWrite(api, "/api/v1/write?db=db&sequence=true", strings.NewReader("cpu usage=1"), "")
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Empty(t, rr.Header().Get(sequenceHeader))
+}
+
+type mockBackfillWriter struct {
+	mockSequenceWriter
+	backfills int
+}
+
+func (w *mockBackfillWriter) WriteBackfill(database string, points []models.Point) error {
+	w.backfills++
+	return w.Write(database, points)
+}
+
+func TestWriteAPI_Write_backfill(t *testing.T) {
+	// the writer doesn't support backfill
+	rr := doWrite(NewWriteAPI(&mockWriter{}, nil, nil, nil), "/api/v1/write?db=db&backfill=true",
+		strings.NewReader("cpu usage=1"), "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = doWrite(NewWriteAPI(ingestion.NewFilterWriter(&mockWriter{}, nil), nil, nil, nil),
+		"/api/v1/write?db=db&backfill=true", strings.NewReader("cpu usage=1"), "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	writer := &mockBackfillWriter{}
+	api := NewWriteAPI(writer, nil, nil, nil)
+	rr = doWrite(api, "/api/v1/write?db=db&backfill=true&precision=s", strings.NewReader("cpu usage=1 1"), "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, 1, writer.backfills)
+	assert.Len(t, writer.points, 1)
+	// sequence token isn't supported by backfill
+	rr = doWrite(api, "/api/v1/write?db=db&backfill=true&sequence=true", strings.NewReader("cpu usage=1"), "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, 1, writer.backfills)
+
+	writer.err = fmt.Errorf("err")
+	rr = doWrite(api, "/api/v1/write?db=db&backfill=true", strings.NewReader("cpu usage=1"), "")
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+type mockAckWriter struct {
+	mockBackfillWriter
+	acks []models.WriteAck
+}
+
+func (w *mockAckWriter) WriteWithAck(database string, points []models.Point, ack models.WriteAck) error {
+	w.acks = append(w.acks, ack)
+	return w.Write(database, points)
+}
+
+func TestWriteAPI_Write_ack(t *testing.T) {
+	// the writer doesn't support durable ack
+	api := NewWriteAPI(&mockWriter{}, nil, nil, nil)
+	rr := doWrite(api, "/api/v1/write?db=db&ack=durable", strings.NewReader("cpu usage=1"), "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = doWrite(api, "/api/v1/write?db=db&ack=enqueue", strings.NewReader("cpu usage=1"), "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	rr = doWrite(NewWriteAPI(ingestion.NewFilterWriter(&mockWriter{}, nil), nil, nil, nil),
+		"/api/v1/write?db=db&ack=durable", strings.NewReader("cpu usage=1"), "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	writer := &mockAckWriter{}
+	api = NewWriteAPI(writer, nil, nil, nil)
+	rr = doWrite(api, "/api/v1/write?db=db&ack=fsync", strings.NewReader("cpu usage=1"), "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	// the default ack of database is used if not chosen
+	rr = doWrite(api, "/api/v1/write?db=db", strings.NewReader("cpu usage=1"), "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	rr = doWrite(api, "/api/v1/write?db=db&ack=durable", strings.NewReader("cpu usage=1"), "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, []models.WriteAck{"", models.WriteAckDurable}, writer.acks)
+	assert.Len(t, writer.points, 2)
+	// ack isn't supported by sequence or backfill
+	rr = doWrite(api, "/api/v1/write?db=db&ack=durable&sequence=true", strings.NewReader("cpu usage=1"), "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = doWrite(api, "/api/v1/write?db=db&ack=enqueue&backfill=true", strings.NewReader("cpu usage=1"), "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Len(t, writer.acks, 2)
+
+	writer.err = fmt.Errorf("err")
+	rr = doWrite(api, "/api/v1/write?db=db&ack=durable", strings.NewReader("cpu usage=1"), "")
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+func TestWriteAPI_Ping(t *testing.T) {
+	rr := httptest.NewRecorder()
+	NewWriteAPI(&mockWriter{}, nil, nil, nil).Ping(rr, httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+}
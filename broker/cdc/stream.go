@@ -0,0 +1,198 @@
+package cdc
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/timeutil"
+)
+
+// defaultBufferSize is the default number of batches buffered for each subscriber
+const defaultBufferSize = 1024
+
+var (
+	droppedBatches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "lindb",
+		Subsystem: "broker_cdc",
+		Name:      "dropped_batches_total",
+		Help:      "The number of change batches dropped because the subscriber is slow.",
+	}, []string{"db"})
+	subscribers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "lindb",
+		Subsystem: "broker_cdc",
+		Name:      "subscribers",
+		Help:      "The number of subscribers of change stream.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(droppedBatches, subscribers)
+}
+
+// Stream represents the change stream(change data capture) of the write batches committed by broker,
+// the batches are published by the writer after committed, then dispatched to the subscribers of database,
+// such as downstream stream processing, real-time dashboards or secondary index builders.
+// Publishing never blocks the write, the batch is dropped for the subscriber whose buffer is full,
+// the subscriber finds out the lost batches by the sequence of batch.
+// NOTICE: the batches are published by each broker, the subscriber needs to subscribe all brokers.
+type Stream interface {
+	// Publish publishes the committed points of database with the sequence token of shards(nil if unknown),
+	// returns the sequence of batch
+	Publish(database string, points []models.Point, token models.SequenceToken) int64
+	// Subscribe subscribes the batches committed after subscribing
+	Subscribe(subscription models.ChangeSubscription) Subscriber
+	// Close closes all subscribers, then the following publishing only advances the sequence
+	Close()
+}
+
+// Subscriber receives the batches of subscription in the order of committing
+type Subscriber interface {
+	// Batches returns the channel of batches, which is closed after the subscriber or stream closed
+	Batches() <-chan *models.ChangeBatch
+	// Dropped returns the number of batches dropped because the subscriber is slow
+	Dropped() int64
+	// Close unsubscribes the stream
+	Close()
+}
+
+// stream implements Stream interface
+type stream struct {
+	bufferSize int
+
+	mutex       sync.Mutex
+	sequences   map[string]int64
+	subscribers map[*subscriber]struct{}
+	closed      bool
+}
+
+// NewStream creates the change stream, buffer size is the number of batches buffered for each subscriber,
+// buffer size <= 0 means the default size.
+func NewStream(bufferSize int) Stream {
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+	return &stream{
+		bufferSize:  bufferSize,
+		sequences:   make(map[string]int64),
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+// Publish publishes the committed points of database, the points are converted only if any subscriber
+// subscribes the database. The batches are dispatched under lock, so that the order of sequences is kept.
+func (s *stream) Publish(database string, points []models.Point, token models.SequenceToken) int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.sequences[database]++
+	sequence := s.sequences[database]
+	var batch *models.ChangeBatch
+	for sub := range s.subscribers {
+		if len(sub.subscription.Database) > 0 && sub.subscription.Database != database {
+			continue
+		}
+		if batch == nil {
+			batch = newChangeBatch(database, sequence, points, token)
+		}
+		select {
+		case sub.batches <- batch:
+		default:
+			atomic.AddInt64(&sub.dropped, 1)
+			droppedBatches.WithLabelValues(database).Inc()
+		}
+	}
+	return sequence
+}
+
+// Subscribe subscribes the batches committed after subscribing, the subscriber is closed if the stream is closed
+func (s *stream) Subscribe(subscription models.ChangeSubscription) Subscriber {
+	sub := &subscriber{
+		stream:       s,
+		subscription: subscription,
+		batches:      make(chan *models.ChangeBatch, s.bufferSize),
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		sub.closed = true
+		close(sub.batches)
+		return sub
+	}
+	s.subscribers[sub] = struct{}{}
+	subscribers.Inc()
+	return sub
+}
+
+// Close closes all subscribers
+func (s *stream) Close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.closed = true
+	for sub := range s.subscribers {
+		s.remove(sub)
+	}
+}
+
+// unsubscribe removes the subscriber from stream, then closes the channel of subscriber
+func (s *stream) unsubscribe(sub *subscriber) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.remove(sub)
+}
+
+// remove removes the subscriber if not closed, must be called under lock
+func (s *stream) remove(sub *subscriber) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(s.subscribers, sub)
+	close(sub.batches)
+	subscribers.Dec()
+}
+
+// subscriber implements Subscriber interface
+type subscriber struct {
+	stream       *stream
+	subscription models.ChangeSubscription
+	batches      chan *models.ChangeBatch
+	dropped      int64
+	// closed is protected by the lock of stream
+	closed bool
+}
+
+// Batches returns the channel of batches
+func (s *subscriber) Batches() <-chan *models.ChangeBatch {
+	return s.batches
+}
+
+// Dropped returns the number of batches dropped
+func (s *subscriber) Dropped() int64 {
+	return atomic.LoadInt64(&s.dropped)
+}
+
+// Close unsubscribes the stream
+func (s *subscriber) Close() {
+	s.stream.unsubscribe(s)
+}
+
+// newChangeBatch creates the change batch of committed points
+func newChangeBatch(database string, sequence int64, points []models.Point,
+	token models.SequenceToken) *models.ChangeBatch {
+	batch := &models.ChangeBatch{
+		Database:   database,
+		Sequence:   sequence,
+		CommitTime: timeutil.Now(),
+		Points:     make([]models.ChangePoint, len(points)),
+	}
+	if len(token) > 0 {
+		batch.SequenceToken = token.String()
+	}
+	for i, point := range points {
+		batch.Points[i] = models.NewChangePoint(point)
+	}
+	return batch
+}
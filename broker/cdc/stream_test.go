@@ -0,0 +1,75 @@
+package cdc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/field"
+)
+
+func newTestPoints() []models.Point {
+	return []models.Point{
+		models.NewPoint("cpu", 1000, map[string]string{"host": "a"}, map[string]models.Field{
+			"count": models.NewSimpleField(field.SumField, field.Integer, int64(10)),
+			"load":  models.NewSimpleField(field.MaxField, field.Float, 1.5),
+		}),
+	}
+}
+
+func TestStream_Publish(t *testing.T) {
+	stream := NewStream(2)
+	// the sequence advances without subscribers
+	assert.Equal(t, int64(1), stream.Publish("db", newTestPoints(), nil))
+
+	all := stream.Subscribe(models.ChangeSubscription{})
+	db := stream.Subscribe(models.ChangeSubscription{Database: "db"})
+	db2 := stream.Subscribe(models.ChangeSubscription{Database: "db2"})
+	assert.Equal(t, int64(2), stream.Publish("db", newTestPoints(), models.SequenceToken{1: 10, 0: 5}))
+	assert.Equal(t, int64(1), stream.Publish("db2", nil, nil))
+
+	batch := <-db.Batches()
+	assert.Equal(t, "db", batch.Database)
+	assert.Equal(t, int64(2), batch.Sequence)
+	assert.True(t, batch.CommitTime > 0)
+	assert.Equal(t, "0:5,1:10", batch.SequenceToken)
+	assert.Equal(t, []models.ChangePoint{{
+		Metric:    "cpu",
+		Timestamp: 1000,
+		Tags:      map[string]string{"host": "a"},
+		Fields:    map[string]float64{"count": 10, "load": 1.5},
+	}}, batch.Points)
+	assert.Equal(t, batch, <-all.Batches())
+	batch = <-all.Batches()
+	assert.Equal(t, "db2", batch.Database)
+	assert.Equal(t, batch, <-db2.Batches())
+
+	// the batches are dropped for the slow subscriber
+	for i := 0; i < 3; i++ {
+		stream.Publish("db", newTestPoints(), nil)
+	}
+	assert.Equal(t, int64(1), db.Dropped())
+	assert.Equal(t, int64(3), (<-db.Batches()).Sequence)
+	assert.Equal(t, int64(4), (<-db.Batches()).Sequence)
+	assert.Equal(t, int64(0), db2.Dropped())
+
+	// the channel is closed after unsubscribed
+	db.Close()
+	db.Close()
+	_, ok := <-db.Batches()
+	assert.False(t, ok)
+	assert.Equal(t, int64(6), stream.Publish("db", newTestPoints(), nil))
+
+	stream.Close()
+	for range all.Batches() {
+	}
+	_, ok = <-db2.Batches()
+	assert.False(t, ok)
+	all.Close()
+	sub := stream.Subscribe(models.ChangeSubscription{})
+	_, ok = <-sub.Batches()
+	assert.False(t, ok)
+	sub.Close()
+	assert.Equal(t, int64(7), stream.Publish("db", newTestPoints(), nil))
+}
@@ -0,0 +1,77 @@
+package cdc
+
+import (
+	"github.com/eleme/lindb/broker/ingestion"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+)
+
+// writer wraps the writer of ingestion, publishes the points into change stream after written durably
+type writer struct {
+	writer ingestion.Writer
+	stream Stream
+}
+
+// NewWriter creates the writer which publishes the points committed by the writer into change stream,
+// the write modes(sequence token, backfill, ack modes) are supported if the writer supports them, the failed write
+// isn't published. The points are written with durable ack if the writer supports ack modes whatever the ack mode
+// of write is, so that the points published are never lost by the writer(e.g. the batch fails to replicate after
+// enqueued), the points are published after the writer returns if the writer doesn't support ack modes.
+func NewWriter(w ingestion.Writer, stream Stream) ingestion.Writer {
+	return &writer{writer: w, stream: stream}
+}
+
+// Write writes the points into database with durable ack, then publishes the points if success
+func (w *writer) Write(database string, points []models.Point) error {
+	if err := w.writeDurable(database, points); err != nil {
+		return err
+	}
+	w.stream.Publish(database, points, nil)
+	return nil
+}
+
+// WriteWithSequence writes the points into database, then publishes the points with sequence token if success,
+// returns error if the writer doesn't support sequence token.
+func (w *writer) WriteWithSequence(database string, points []models.Point) (models.SequenceToken, error) {
+	sequenceWriter, ok := w.writer.(ingestion.SequenceWriter)
+	if !ok {
+		return nil, errors.Wrapf(errors.ErrInvalidArgument, "sequence token isn't supported by writer")
+	}
+	token, err := sequenceWriter.WriteWithSequence(database, points)
+	if err != nil {
+		return nil, err
+	}
+	w.stream.Publish(database, points, token)
+	return token, nil
+}
+
+// WriteBackfill writes the historical points into database, then publishes the points if success,
+// returns error if the writer doesn't support backfill.
+func (w *writer) WriteBackfill(database string, points []models.Point) error {
+	backfillWriter, ok := w.writer.(ingestion.BackfillWriter)
+	if !ok {
+		return errors.Wrapf(errors.ErrInvalidArgument, "backfill isn't supported by writer")
+	}
+	if err := backfillWriter.WriteBackfill(database, points); err != nil {
+		return err
+	}
+	w.stream.Publish(database, points, nil)
+	return nil
+}
+
+// WriteWithAck writes the points into database with durable ack instead of the ack mode, then publishes the points
+// if success, returns error if the ack mode other than enqueue is required but the writer doesn't support ack modes.
+func (w *writer) WriteWithAck(database string, points []models.Point, ack models.WriteAck) error {
+	if _, ok := w.writer.(ingestion.AckWriter); !ok && len(ack) != 0 && ack != models.WriteAckEnqueue {
+		return errors.Wrapf(errors.ErrInvalidArgument, "write ack[%s] isn't supported by writer", ack)
+	}
+	return w.Write(database, points)
+}
+
+// writeDurable writes the points with durable ack if the writer supports ack modes, otherwise writes the points
+func (w *writer) writeDurable(database string, points []models.Point) error {
+	if ackWriter, ok := w.writer.(ingestion.AckWriter); ok {
+		return ackWriter.WriteWithAck(database, points, models.WriteAckDurable)
+	}
+	return w.writer.Write(database, points)
+}
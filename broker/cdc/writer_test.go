@@ -0,0 +1,117 @@
+package cdc
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/broker/ingestion"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+)
+
+type mockWriter struct {
+	err error
+}
+
+func (w *mockWriter) Write(database string, points []models.Point) error {
+	return w.err
+}
+
+type mockSequenceWriter struct {
+	mockWriter
+	token models.SequenceToken
+}
+
+func (w *mockSequenceWriter) WriteWithSequence(database string, points []models.Point) (models.SequenceToken, error) {
+	return w.token, w.err
+}
+
+type mockBackfillWriter struct {
+	mockWriter
+}
+
+func (w *mockBackfillWriter) WriteBackfill(database string, points []models.Point) error {
+	return w.err
+}
+
+type mockAckWriter struct {
+	mockWriter
+	ack models.WriteAck
+}
+
+func (w *mockAckWriter) WriteWithAck(database string, points []models.Point, ack models.WriteAck) error {
+	w.ack = ack
+	return w.err
+}
+
+func TestWriter(t *testing.T) {
+	stream := NewStream(10)
+	sub := stream.Subscribe(models.ChangeSubscription{})
+	defer sub.Close()
+
+	inner := &mockWriter{}
+	writer := NewWriter(inner, stream)
+	assert.Nil(t, writer.Write("db", newTestPoints()))
+	batch := <-sub.Batches()
+	assert.Equal(t, int64(1), batch.Sequence)
+	assert.Empty(t, batch.SequenceToken)
+	// the failed write isn't published
+	inner.err = fmt.Errorf("err")
+	assert.NotNil(t, writer.Write("db", newTestPoints()))
+	assert.Empty(t, sub.Batches())
+	// the write modes not supported by the writer are rejected as invalid argument
+	_, err := writer.(ingestion.SequenceWriter).WriteWithSequence("db", newTestPoints())
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+	err = writer.(ingestion.BackfillWriter).WriteBackfill("db", newTestPoints())
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+	err = writer.(ingestion.AckWriter).WriteWithAck("db", newTestPoints(), models.WriteAckDurable)
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+	// the enqueue ack falls back to write
+	inner.err = nil
+	assert.Nil(t, writer.(ingestion.AckWriter).WriteWithAck("db", newTestPoints(), models.WriteAckEnqueue))
+	assert.Equal(t, int64(2), (<-sub.Batches()).Sequence)
+
+	innerSequence := &mockSequenceWriter{token: models.SequenceToken{1: 10}}
+	writer = NewWriter(innerSequence, stream)
+	sequenceWriter, ok := writer.(ingestion.SequenceWriter)
+	assert.True(t, ok)
+	token, err := sequenceWriter.WriteWithSequence("db", newTestPoints())
+	assert.Nil(t, err)
+	assert.Equal(t, innerSequence.token, token)
+	batch = <-sub.Batches()
+	assert.Equal(t, int64(3), batch.Sequence)
+	assert.Equal(t, "1:10", batch.SequenceToken)
+	assert.Nil(t, sequenceWriter.Write("db", newTestPoints()))
+	assert.Equal(t, int64(4), (<-sub.Batches()).Sequence)
+
+	innerSequence.err = fmt.Errorf("err")
+	_, err = sequenceWriter.WriteWithSequence("db", newTestPoints())
+	assert.NotNil(t, err)
+	assert.Empty(t, sub.Batches())
+
+	innerBackfill := &mockBackfillWriter{}
+	writer = NewWriter(innerBackfill, stream)
+	assert.Nil(t, writer.(ingestion.BackfillWriter).WriteBackfill("db", newTestPoints()))
+	assert.Equal(t, int64(5), (<-sub.Batches()).Sequence)
+	innerBackfill.err = fmt.Errorf("err")
+	assert.NotNil(t, writer.(ingestion.BackfillWriter).WriteBackfill("db", newTestPoints()))
+	assert.Empty(t, sub.Batches())
+
+	innerAck := &mockAckWriter{}
+	writer = NewWriter(innerAck, stream)
+	ackWriter, ok := writer.(ingestion.AckWriter)
+	assert.True(t, ok)
+	// the points are written durably before published whatever the ack mode is
+	assert.Nil(t, ackWriter.Write("db", newTestPoints()))
+	assert.Equal(t, models.WriteAckDurable, innerAck.ack)
+	assert.Equal(t, int64(6), (<-sub.Batches()).Sequence)
+	innerAck.ack = ""
+	assert.Nil(t, ackWriter.WriteWithAck("db", newTestPoints(), models.WriteAckEnqueue))
+	assert.Equal(t, models.WriteAckDurable, innerAck.ack)
+	assert.Equal(t, int64(7), (<-sub.Batches()).Sequence)
+	innerAck.err = fmt.Errorf("err")
+	assert.NotNil(t, ackWriter.WriteWithAck("db", newTestPoints(), models.WriteAckEnqueue))
+	assert.Empty(t, sub.Batches())
+}
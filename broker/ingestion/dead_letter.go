@@ -0,0 +1,162 @@
+package ingestion
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/timeutil"
+)
+
+// maxRejectedLineLength is the max length of rejected line kept, the longer line is truncated
+const maxRejectedLineLength = 1024
+
+var rejectedPoints = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "lindb",
+	Subsystem: "broker_write",
+	Name:      "rejected_points_total",
+	Help:      "The number of points rejected by validation, by database.",
+}, []string{"database"})
+
+func init() {
+	prometheus.MustRegister(rejectedPoints)
+}
+
+// RejectedPoint represents the point(line) which fails validation with the reason
+type RejectedPoint struct {
+	Database  string `json:"database"`
+	Line      string `json:"line"`
+	Reason    string `json:"reason"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// DeadLetterQueue keeps the points which fail validation instead of silently dropping them,
+// the recent rejects of each database can be sampled, so that producers can fix their emitters.
+type DeadLetterQueue interface {
+	// Reject records the line of database which is rejected with the reason
+	Reject(database, line, reason string)
+	// Sample returns the recent rejects of database ordered by time desc, returns all kept if limit <= 0
+	Sample(database string, limit int) []RejectedPoint
+	// Close closes the sink of dead letter queue
+	Close() error
+}
+
+// deadLetterQueue implements DeadLetterQueue
+type deadLetterQueue struct {
+	sampleSize int
+
+	mutex   sync.Mutex
+	samples map[string]*rejectRing
+	sink    *os.File
+
+	logger *logger.Logger
+}
+
+// NewDeadLetterQueue creates the dead letter queue, the rejects are appended into the file of path as json lines
+// if path isn't empty, the number of rejects is counted by metric anyway.
+func NewDeadLetterQueue(cfg config.DeadLetter) (DeadLetterQueue, error) {
+	q := &deadLetterQueue{
+		sampleSize: cfg.SampleSize,
+		samples:    make(map[string]*rejectRing),
+		logger:     logger.GetLogger("broker/ingestion/dead-letter"),
+	}
+	if len(cfg.Path) > 0 {
+		if err := os.MkdirAll(filepath.Dir(cfg.Path), os.ModePerm); err != nil {
+			return nil, err
+		}
+		f, err := os.OpenFile(cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		q.sink = f
+	}
+	return q, nil
+}
+
+// Reject records the line of database which is rejected with the reason
+func (q *deadLetterQueue) Reject(database, line, reason string) {
+	rejectedPoints.WithLabelValues(database).Inc()
+	if len(line) > maxRejectedLineLength {
+		line = line[:maxRejectedLineLength]
+	}
+	p := RejectedPoint{
+		Database:  database,
+		Line:      line,
+		Reason:    reason,
+		Timestamp: timeutil.Now(),
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.sampleSize > 0 {
+		ring, ok := q.samples[database]
+		if !ok {
+			ring = &rejectRing{points: make([]RejectedPoint, q.sampleSize)}
+			q.samples[database] = ring
+		}
+		ring.add(p)
+	}
+	if q.sink != nil {
+		data, _ := json.Marshal(p)
+		if _, err := q.sink.Write(append(data, '\n')); err != nil {
+			q.logger.Error("write rejected point into dead letter file error", logger.Error(err))
+		}
+	}
+}
+
+// Sample returns the recent rejects of database ordered by time desc, returns all kept if limit <= 0
+func (q *deadLetterQueue) Sample(database string, limit int) []RejectedPoint {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	ring, ok := q.samples[database]
+	if !ok {
+		return nil
+	}
+	return ring.recent(limit)
+}
+
+// Close closes the dead letter file
+func (q *deadLetterQueue) Close() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.sink == nil {
+		return nil
+	}
+	err := q.sink.Close()
+	q.sink = nil
+	return err
+}
+
+// rejectRing keeps the recent rejects, the oldest is overwritten when full
+type rejectRing struct {
+	points []RejectedPoint
+	next   int
+	size   int
+}
+
+// add adds the reject, overwrites the oldest if full
+func (r *rejectRing) add(p RejectedPoint) {
+	r.points[r.next] = p
+	r.next = (r.next + 1) % len(r.points)
+	if r.size < len(r.points) {
+		r.size++
+	}
+}
+
+// recent returns the recent rejects ordered by time desc
+func (r *rejectRing) recent(limit int) []RejectedPoint {
+	n := r.size
+	if limit > 0 && limit < n {
+		n = limit
+	}
+	result := make([]RejectedPoint, 0, n)
+	for i := 1; i <= n; i++ {
+		result = append(result, r.points[(r.next-i+len(r.points))%len(r.points)])
+	}
+	return result
+}
@@ -0,0 +1,67 @@
+package ingestion
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/pkg/util"
+)
+
+func TestDeadLetterQueue_Sample(t *testing.T) {
+	q, err := NewDeadLetterQueue(config.DeadLetter{SampleSize: 2})
+	assert.Nil(t, err)
+	assert.Nil(t, q.Sample("db", 0))
+
+	q.Reject("db", "a", "err1")
+	q.Reject("db", "b", "err2")
+	q.Reject("db", "c", "err3")
+	q.Reject("db2", strings.Repeat("x", maxRejectedLineLength+10), "err")
+
+	rejects := q.Sample("db", 0)
+	assert.Len(t, rejects, 2)
+	assert.Equal(t, "c", rejects[0].Line)
+	assert.Equal(t, "err3", rejects[0].Reason)
+	assert.Equal(t, "b", rejects[1].Line)
+	assert.Len(t, q.Sample("db", 1), 1)
+	assert.Len(t, q.Sample("db2", 0)[0].Line, maxRejectedLineLength)
+
+	metric := &dto.Metric{}
+	assert.Nil(t, rejectedPoints.WithLabelValues("db").Write(metric))
+	assert.True(t, metric.GetCounter().GetValue() >= 3)
+	assert.Nil(t, q.Close())
+}
+
+func TestDeadLetterQueue_File(t *testing.T) {
+	testPath := "dead_letter_test"
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	path := filepath.Join(testPath, "rejects.log")
+	q, err := NewDeadLetterQueue(config.DeadLetter{Path: path})
+	assert.Nil(t, err)
+	q.Reject("db", "a", "err1")
+	q.Reject("db", "b", "err2")
+	assert.Nil(t, q.Sample("db", 0))
+	assert.Nil(t, q.Close())
+	assert.Nil(t, q.Close())
+
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, lines, 2)
+	p := RejectedPoint{}
+	assert.Nil(t, json.Unmarshal([]byte(lines[1]), &p))
+	assert.Equal(t, "b", p.Line)
+	assert.Equal(t, "err2", p.Reason)
+
+	// cannot create the file
+	_, err = NewDeadLetterQueue(config.DeadLetter{Path: filepath.Join(path, "rejects.log")})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,336 @@
+package ingestion
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/field"
+)
+
+// Defines the categories of the errors of invalid lines
+const (
+	LineErrFormat    = "format"
+	LineErrMetric    = "metric"
+	LineErrTag       = "tag"
+	LineErrField     = "field"
+	LineErrTimestamp = "timestamp"
+)
+
+// LineError represents the error of invalid line with the category of error
+type LineError struct {
+	Category string
+	msg      string
+}
+
+// newLineError creates the error of invalid line with category
+func newLineError(category, format string, args ...interface{}) error {
+	return &LineError{Category: category, msg: fmt.Sprintf(format, args...)}
+}
+
+// Error returns the message of error
+func (e *LineError) Error() string {
+	return e.msg
+}
+
+// ParseLineProtocol parses the points of line protocol, each line is a point with format:
+//
+//	<metric>[,<tag>=<value>...] <field>=<value>[,<field>=<value>...] [timestamp(ms)]
+//
+// The field value is float, or integer with suffix 'i'(like 10i) or 'u'(like 10u), the field type is sum.
+// The string(double quoted) and boolean field values are dropped because they cannot be aggregated,
+// the line without numeric fields is skipped, see ParseLineProtocolWithStrings for keeping the string values.
+// The space/comma/equal sign in metric, tag and field can be escaped by backslash.
+// The empty line and comment line(starts with '#') are skipped, now is used if timestamp is missing.
+// The invalid lines are skipped, returns the points of valid lines with the error of first invalid line.
+func ParseLineProtocol(data []byte, now int64) ([]models.Point, error) {
+	return ParseLineProtocolWithPrecision(data, now, time.Millisecond)
+}
+
+// ParseLineProtocolWithPrecision parses the points of line protocol like ParseLineProtocol,
+// the timestamps of lines are in the unit of precision(like time.Nanosecond), which are converted into ms.
+func ParseLineProtocolWithPrecision(data []byte, now int64, precision time.Duration) ([]models.Point, error) {
+	return ParseLineProtocolWithReject(data, now, precision, nil)
+}
+
+// ParseLineProtocolWithReject parses the points of line protocol like ParseLineProtocolWithPrecision,
+// the invalid lines are passed to reject with the parse error(*LineError) if reject isn't nil.
+func ParseLineProtocolWithReject(data []byte, now int64, precision time.Duration,
+	reject func(line string, err error)) ([]models.Point, error) {
+	return parseLines(data, now, precision, false, reject)
+}
+
+// ParseLineProtocolWithStrings parses the points of line protocol like ParseLineProtocolWithReject,
+// the string field values are kept as string fields(the last value of series), such as build version.
+func ParseLineProtocolWithStrings(data []byte, now int64, precision time.Duration,
+	reject func(line string, err error)) ([]models.Point, error) {
+	return parseLines(data, now, precision, true, reject)
+}
+
+// parseLines parses the points of lines, the string field values are dropped if not keepStrings
+func parseLines(data []byte, now int64, precision time.Duration, keepStrings bool,
+	reject func(line string, err error)) ([]models.Point, error) {
+	var (
+		points   []models.Point
+		firstErr error
+	)
+	for lineNum, line := range bytes.Split(data, []byte{'\n'}) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		point, err := parseLine(string(line), now, precision, keepStrings)
+		if err != nil {
+			if reject != nil {
+				reject(string(line), err)
+			}
+			if firstErr == nil {
+				firstErr = fmt.Errorf("parse line[%d] error:%s", lineNum+1, err)
+			}
+			continue
+		}
+		if point != nil {
+			points = append(points, point)
+		}
+	}
+	return points, firstErr
+}
+
+// parseLine parses a point from line, returns nil if the line has no numeric(or string if keepStrings) fields
+func parseLine(line string, now int64, precision time.Duration, keepStrings bool) (models.Point, error) {
+	sections := splitSections(line)
+	if len(sections) < 2 || len(sections) > 3 {
+		return nil, newLineError(LineErrFormat, "line must have metric and fields, with optional timestamp")
+	}
+	keys := splitUnescaped(sections[0], ',', false)
+	name := unescape(keys[0])
+	if len(name) == 0 {
+		return nil, newLineError(LineErrMetric, "metric name cannot be empty")
+	}
+	tags := make(map[string]string)
+	for _, tag := range keys[1:] {
+		key, value, err := splitPair(tag)
+		if err != nil {
+			return nil, newLineError(LineErrTag, "invalid tag[%s]:%s", tag, err)
+		}
+		tags[key] = value
+	}
+
+	fields := make(map[string]models.Field)
+	for _, pair := range splitUnescaped(sections[1], ',', true) {
+		key, value, err := splitPair(pair)
+		if err != nil {
+			return nil, newLineError(LineErrField, "invalid field[%s]:%s", pair, err)
+		}
+		f, err := parseFieldValue(value, keepStrings)
+		if err != nil {
+			return nil, newLineError(LineErrField, "invalid value of field[%s]:%s", key, err)
+		}
+		if f != nil {
+			fields[key] = f
+		}
+	}
+
+	timestamp := now
+	if len(sections) == 3 {
+		t, err := strconv.ParseInt(sections[2], 10, 64)
+		if err != nil {
+			return nil, newLineError(LineErrTimestamp, "invalid timestamp[%s]", sections[2])
+		}
+		timestamp = toMillis(t, precision)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return models.NewPoint(name, timestamp, tags, fields), nil
+}
+
+// toMillis converts the timestamp in the unit of precision into ms
+func toMillis(timestamp int64, precision time.Duration) int64 {
+	if precision >= time.Millisecond {
+		return timestamp * int64(precision/time.Millisecond)
+	}
+	return timestamp / int64(time.Millisecond/precision)
+}
+
+// parseFieldValue parses the field value, integer has suffix 'i' and unsigned integer has suffix 'u',
+// returns nil for boolean value, and string value if not keepStrings.
+func parseFieldValue(value string, keepStrings bool) (models.Field, error) {
+	switch {
+	case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+		if keepStrings {
+			return models.NewStringField(value[1 : len(value)-1]), nil
+		}
+		return nil, nil
+	case value == "t" || value == "T" || value == "true" || value == "True" || value == "TRUE" ||
+		value == "f" || value == "F" || value == "false" || value == "False" || value == "FALSE":
+		return nil, nil
+	case strings.HasSuffix(value, "i"):
+		v, err := strconv.ParseInt(value[:len(value)-1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return models.NewSimpleField(field.SumField, field.Integer, v), nil
+	case strings.HasSuffix(value, "u"):
+		v, err := strconv.ParseUint(value[:len(value)-1], 10, 63)
+		if err != nil {
+			return nil, err
+		}
+		return models.NewSimpleField(field.SumField, field.Integer, int64(v)), nil
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewSimpleField(field.SumField, field.Float, v), nil
+}
+
+// splitPair splits the key=value pair, key and value cannot be empty
+func splitPair(pair string) (key, value string, err error) {
+	idx := indexUnescaped(pair, '=')
+	if idx <= 0 || idx == len(pair)-1 {
+		return "", "", fmt.Errorf("must be key=value")
+	}
+	return unescape(pair[:idx]), unescape(pair[idx+1:]), nil
+}
+
+// splitSections splits the line into metric with tags, fields and timestamp by the spaces,
+// the spaces in metric/tags are escaped, the spaces in fields are escaped or double quoted(string value).
+func splitSections(line string) []string {
+	idx := indexUnescaped(line, ' ')
+	if idx < 0 {
+		return []string{line}
+	}
+	return append([]string{line[:idx]}, splitUnescaped(line[idx+1:], ' ', true)...)
+}
+
+// indexUnescaped returns the index of first separator which isn't escaped by backslash, returns -1 if not found
+func indexUnescaped(s string, sep byte) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case sep:
+			return i
+		}
+	}
+	return -1
+}
+
+// splitUnescaped splits the string by separator which isn't escaped by backslash or in double quotes if quoted,
+// the continuous spaces are treated as one separator
+func splitUnescaped(s string, sep byte, quoted bool) []string {
+	var parts []string
+	start := 0
+	inQuotes := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case quoted && s[i] == '"':
+			inQuotes = !inQuotes
+		case s[i] == sep && !inQuotes:
+			if sep == ' ' && i == start {
+				start = i + 1
+				continue
+			}
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	if start < len(s) || sep != ' ' {
+		parts = append(parts, s[start:])
+	}
+	return parts
+}
+
+// FormatLine formats the point as a line of line protocol(see ParseLineProtocol),
+// the tags and fields are sorted by key, the timestamp is in milliseconds.
+func FormatLine(point models.Point) string {
+	var b strings.Builder
+	b.WriteString(escape(point.Name(), " ,"))
+	tags := point.TagsMap()
+	tagKeys := make([]string, 0, len(tags))
+	for key := range tags {
+		tagKeys = append(tagKeys, key)
+	}
+	sort.Strings(tagKeys)
+	for _, key := range tagKeys {
+		b.WriteString(",")
+		b.WriteString(escape(key, " ,="))
+		b.WriteString("=")
+		b.WriteString(escape(tags[key], " ,="))
+	}
+	fields := point.Fields()
+	fieldNames := make([]string, 0, len(fields))
+	for name := range fields {
+		fieldNames = append(fieldNames, name)
+	}
+	sort.Strings(fieldNames)
+	for idx, name := range fieldNames {
+		if idx == 0 {
+			b.WriteString(" ")
+		} else {
+			b.WriteString(",")
+		}
+		b.WriteString(escape(name, " ,="))
+		b.WriteString("=")
+		b.WriteString(formatFieldValue(fields[name]))
+	}
+	b.WriteString(" ")
+	b.WriteString(strconv.FormatInt(point.Timestamp(), 10))
+	return b.String()
+}
+
+// formatFieldValue formats the value of field, the integer has suffix 'i', the string is double quoted
+func formatFieldValue(f models.Field) string {
+	switch v := f.(type) {
+	case models.StringField:
+		return strconv.Quote(v.Value())
+	case models.SimpleField:
+		switch value := v.Value().(type) {
+		case int64:
+			return strconv.FormatInt(value, 10) + "i"
+		case float64:
+			return strconv.FormatFloat(value, 'f', -1, 64)
+		default:
+			return fmt.Sprintf("%v", value)
+		}
+	default:
+		return ""
+	}
+}
+
+// escape escapes the characters of chars by backslash
+func escape(s, chars string) string {
+	if !strings.ContainsAny(s, chars+"\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' || strings.IndexByte(chars, s[i]) >= 0 {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// unescape removes the backslash of escaped characters
+func unescape(s string) string {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,142 @@
+package ingestion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/field"
+)
+
+func TestParseLineProtocol(t *testing.T) {
+	data := []byte(`
+# comment line
+cpu,host=1.1.1.1,zone=sh usage=10.5,load=3i 1000
+memory  used=100
+disk\ io,path=/data\,1 read\=bytes=1
+`)
+	points, err := ParseLineProtocol(data, 2000)
+	assert.Nil(t, err)
+	assert.Len(t, points, 3)
+
+	cpu := points[0]
+	assert.Equal(t, "cpu", cpu.Name())
+	assert.Equal(t, int64(1000), cpu.Timestamp())
+	assert.Equal(t, map[string]string{"host": "1.1.1.1", "zone": "sh"}, cpu.TagsMap())
+	assert.Equal(t, `{"host":"1.1.1.1","zone":"sh"}`, cpu.Tags())
+	usage := cpu.Fields()["usage"].(models.SimpleField)
+	assert.Equal(t, field.SumField, usage.Type())
+	assert.Equal(t, field.Float, usage.ValueType())
+	assert.Equal(t, 10.5, usage.Value())
+	load := cpu.Fields()["load"].(models.SimpleField)
+	assert.Equal(t, field.Integer, load.ValueType())
+	assert.Equal(t, int64(3), load.Value())
+
+	memory := points[1]
+	assert.Equal(t, "memory", memory.Name())
+	assert.Equal(t, int64(2000), memory.Timestamp())
+	assert.Empty(t, memory.TagsMap())
+
+	disk := points[2]
+	assert.Equal(t, "disk io", disk.Name())
+	assert.Equal(t, map[string]string{"path": "/data,1"}, disk.TagsMap())
+	assert.NotNil(t, disk.Fields()["read=bytes"])
+}
+
+func TestParseLineProtocol_invalid(t *testing.T) {
+	lines := []string{
+		"cpu",
+		",host=1 usage=1",
+		"cpu,host usage=1",
+		"cpu usage",
+		"cpu usage=abc",
+		"cpu usage=1.5i",
+		"cpu usage=1 abc",
+		"cpu usage=1 1000 extra",
+	}
+	for _, line := range lines {
+		points, err := ParseLineProtocol([]byte(line), 0)
+		assert.NotNil(t, err, line)
+		assert.Empty(t, points, line)
+	}
+
+	// the valid lines are kept
+	points, err := ParseLineProtocol([]byte("cpu usage=1\ncpu usage\nmemory used=1"), 0)
+	assert.Equal(t, "parse line[2] error:invalid field[usage]:must be key=value", err.Error())
+	assert.Len(t, points, 2)
+}
+
+func TestParseLineProtocolWithReject(t *testing.T) {
+	var rejects []string
+	points, err := ParseLineProtocolWithReject([]byte("cpu usage=1\ncpu usage\nmemory\n"), 0, time.Millisecond,
+		func(line string, err error) {
+			rejects = append(rejects, line+":"+err.Error())
+		})
+	assert.NotNil(t, err)
+	assert.Len(t, points, 1)
+	assert.Equal(t, []string{"cpu usage:invalid field[usage]:must be key=value", "memory:line must have metric and fields, with optional timestamp"}, rejects)
+}
+
+func TestParseLineProtocolWithPrecision(t *testing.T) {
+	data := []byte(`system,host=alpha load1=0.5,n_cpus=4i,uptime=3600u,uptime_format="1:00, 0 days" 1566300000000000000
+system,host=alpha uptime_format="1 day" 1566300000000000000
+net_response,server=a\ b result_type="success",up=true,response_time=0.01 1566300000000000000`)
+	points, err := ParseLineProtocolWithPrecision(data, 0, time.Nanosecond)
+	assert.Nil(t, err)
+	// the line without numeric fields is skipped
+	assert.Len(t, points, 2)
+
+	system := points[0]
+	assert.Equal(t, int64(1566300000000), system.Timestamp())
+	assert.Len(t, system.Fields(), 3)
+	assert.Equal(t, int64(3600), system.Fields()["uptime"].(models.SimpleField).Value())
+
+	response := points[1]
+	assert.Equal(t, map[string]string{"server": "a b"}, response.TagsMap())
+	assert.Len(t, response.Fields(), 1)
+	assert.Equal(t, 0.01, response.Fields()["response_time"].(models.SimpleField).Value())
+
+	for _, precision := range []time.Duration{time.Second, time.Microsecond} {
+		points, err = ParseLineProtocolWithPrecision([]byte("cpu usage=1 1566300000"), 0, precision)
+		assert.Nil(t, err)
+		assert.Equal(t, toMillis(1566300000, precision), points[0].Timestamp())
+	}
+	assert.Equal(t, int64(1566300000000), toMillis(1566300000, time.Second))
+	assert.Equal(t, int64(1566300), toMillis(1566300000, time.Microsecond))
+
+	_, err = ParseLineProtocolWithPrecision([]byte("cpu usage=-1u"), 0, time.Nanosecond)
+	assert.NotNil(t, err)
+}
+
+func TestParseLineProtocolWithStrings(t *testing.T) {
+	data := []byte(`system,host=alpha load1=0.5,uptime_format="1:00, 0 days",version="v\"1\"" 1566300000000
+system,host=alpha up=true 1566300000000
+cpu usage`)
+	var rejects []string
+	points, err := ParseLineProtocolWithStrings(data, 0, time.Millisecond, func(line string, err error) {
+		rejects = append(rejects, line)
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"cpu usage"}, rejects)
+	// the line with only boolean fields is skipped
+	assert.Len(t, points, 1)
+	fields := points[0].Fields()
+	assert.Len(t, fields, 3)
+	assert.Equal(t, "1:00, 0 days", fields["uptime_format"].(models.StringField).Value())
+	assert.Equal(t, `v"1"`, fields["version"].(models.StringField).Value())
+	assert.Equal(t, 0.5, fields["load1"].(models.SimpleField).Value())
+}
+
+func TestFormatLine(t *testing.T) {
+	data := []byte(`cpu\ load,region=nj,host=a\,b count=10i,usage=1.5,state="running" 1566300000000`)
+	points, err := ParseLineProtocolWithStrings(data, 0, time.Millisecond, nil)
+	assert.Nil(t, err)
+	line := FormatLine(points[0])
+	assert.Equal(t, `cpu\ load,host=a\,b,region=nj count=10i,state="running",usage=1.5 1566300000000`, line)
+	// the formatted line is parsed as the same point
+	parsed, err := ParseLineProtocolWithStrings([]byte(line), 0, time.Millisecond, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, points, parsed)
+}
@@ -0,0 +1,151 @@
+package ingestion
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/eleme/lindb/models"
+)
+
+// Defines the rules of naming policy which the points violate
+const (
+	NamingRulePattern  = "pattern"
+	NamingRuleDepth    = "depth"
+	NamingRuleTags     = "tags"
+	NamingRuleTemplate = "template"
+)
+
+var (
+	namingViolations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "lindb",
+		Subsystem: "broker_naming_policy",
+		Name:      "violations_total",
+		Help:      "The number of points violating the naming policy of database.",
+	}, []string{"database", "rule"})
+)
+
+func init() {
+	prometheus.MustRegister(namingViolations)
+}
+
+// templateSegment represents the segment of naming template, which is literal, wildcard or tag key
+type templateSegment struct {
+	literal  string
+	tagKey   string
+	wildcard bool
+}
+
+// NamingChecker checks the points by the naming policy of database
+type NamingChecker struct {
+	metricPattern *regexp.Regexp
+	maxDepth      int
+	requiredTags  []string
+	template      []templateSegment
+	dryRun        bool
+}
+
+// NewNamingChecker compiles the naming policy, returns nil if no policy
+func NewNamingChecker(policy *models.NamingPolicy) (*NamingChecker, error) {
+	if policy == nil {
+		return nil, nil
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	c := &NamingChecker{
+		maxDepth:     policy.MaxDepth,
+		requiredTags: policy.RequiredTags,
+		dryRun:       policy.DryRun,
+	}
+	if len(policy.MetricPattern) > 0 {
+		c.metricPattern = regexp.MustCompile("^(?:" + policy.MetricPattern + ")$")
+	}
+	if len(policy.Template) > 0 {
+		for _, segment := range strings.Split(policy.Template, ".") {
+			switch {
+			case segment == "*":
+				c.template = append(c.template, templateSegment{wildcard: true})
+			case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+				c.template = append(c.template, templateSegment{tagKey: segment[1 : len(segment)-1]})
+			default:
+				c.template = append(c.template, templateSegment{literal: segment})
+			}
+		}
+	}
+	return c, nil
+}
+
+// Check returns the first rule which the point violates, returns empty if the point is valid
+func (c *NamingChecker) Check(point models.Point) string {
+	name := point.Name()
+	if c.metricPattern != nil && !c.metricPattern.MatchString(name) {
+		return NamingRulePattern
+	}
+	segments := strings.Split(name, ".")
+	if c.maxDepth > 0 && len(segments) > c.maxDepth {
+		return NamingRuleDepth
+	}
+	tags := point.TagsMap()
+	for _, tagKey := range c.requiredTags {
+		if len(tags[tagKey]) == 0 {
+			return NamingRuleTags
+		}
+	}
+	if len(c.template) > 0 && !c.matchTemplate(segments, tags) {
+		return NamingRuleTemplate
+	}
+	return ""
+}
+
+// matchTemplate checks if the prefix of metric name matches the template
+func (c *NamingChecker) matchTemplate(segments []string, tags map[string]string) bool {
+	if len(segments) < len(c.template) {
+		return false
+	}
+	for idx, t := range c.template {
+		segment := segments[idx]
+		switch {
+		case t.wildcard:
+			if len(segment) == 0 {
+				return false
+			}
+		case len(t.tagKey) > 0:
+			if value := tags[t.tagKey]; len(value) == 0 || value != segment {
+				return false
+			}
+		default:
+			if t.literal != segment {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Apply drops the points violating the naming policy(kept if dry run), the dropped point is passed to
+// reject with the rule violated if reject isn't nil, returns the points left and the number of violations of each rule.
+func (c *NamingChecker) Apply(points []models.Point,
+	reject func(point models.Point, rule string)) (result []models.Point, violations map[string]int) {
+	if c == nil {
+		return points, nil
+	}
+	result = make([]models.Point, 0, len(points))
+	for _, point := range points {
+		if rule := c.Check(point); len(rule) > 0 {
+			if violations == nil {
+				violations = make(map[string]int)
+			}
+			violations[rule]++
+			if !c.dryRun {
+				if reject != nil {
+					reject(point, rule)
+				}
+				continue
+			}
+		}
+		result = append(result, point)
+	}
+	return result, violations
+}
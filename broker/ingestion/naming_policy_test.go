@@ -0,0 +1,137 @@
+package ingestion
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/models"
+)
+
+func testNamingPolicy() *models.NamingPolicy {
+	return &models.NamingPolicy{
+		MetricPattern: "[a-z0-9_.]+",
+		MaxDepth:      4,
+		RequiredTags:  []string{"app"},
+		Template:      "{app}.*",
+	}
+}
+
+func TestNamingChecker_Check(t *testing.T) {
+	checker, err := NewNamingChecker(testNamingPolicy())
+	assert.Nil(t, err)
+	app := map[string]string{"app": "order"}
+	cases := []struct {
+		point models.Point
+		rule  string
+	}{
+		{models.NewPoint("order.http.count", 0, app, nil), ""},
+		{models.NewPoint("order.HTTP", 0, app, nil), NamingRulePattern},
+		{models.NewPoint("order.a.b.c.d", 0, app, nil), NamingRuleDepth},
+		{models.NewPoint("order.http", 0, nil, nil), NamingRuleTags},
+		{models.NewPoint("order", 0, app, nil), NamingRuleTemplate},
+		{models.NewPoint("pay.http", 0, app, nil), NamingRuleTemplate},
+		{models.NewPoint("order..http", 0, app, nil), NamingRuleTemplate},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.rule, checker.Check(c.point), c.point.Name())
+	}
+
+	checker, err = NewNamingChecker(&models.NamingPolicy{Template: "biz.{app}"})
+	assert.Nil(t, err)
+	assert.Empty(t, checker.Check(models.NewPoint("biz.order.count", 0, app, nil)))
+	assert.Equal(t, NamingRuleTemplate, checker.Check(models.NewPoint("sys.order", 0, app, nil)))
+
+	checker, err = NewNamingChecker(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, checker)
+	_, err = NewNamingChecker(&models.NamingPolicy{MetricPattern: "("})
+	assert.NotNil(t, err)
+}
+
+func TestNamingChecker_Apply(t *testing.T) {
+	points := []models.Point{
+		models.NewPoint("order.http", 0, map[string]string{"app": "order"}, nil),
+		models.NewPoint("order.http", 0, nil, nil),
+		models.NewPoint("Order", 0, nil, nil),
+	}
+	checker, _ := NewNamingChecker(testNamingPolicy())
+	var rejected []string
+	result, violations := checker.Apply(points, func(point models.Point, rule string) {
+		rejected = append(rejected, rule)
+	})
+	assert.Equal(t, points[:1], result)
+	assert.Equal(t, []string{NamingRuleTags, NamingRulePattern}, rejected)
+	assert.Equal(t, map[string]int{NamingRuleTags: 1, NamingRulePattern: 1}, violations)
+
+	// dry run only counts the violations
+	policy := testNamingPolicy()
+	policy.DryRun = true
+	checker, _ = NewNamingChecker(policy)
+	result, violations = checker.Apply(points, nil)
+	assert.Equal(t, points, result)
+	assert.Len(t, violations, 2)
+
+	checker = nil
+	result, violations = checker.Apply(points, nil)
+	assert.Equal(t, points, result)
+	assert.Nil(t, violations)
+}
+
+func TestFilterWriter_NamingPolicy(t *testing.T) {
+	writer := newMemoryWriter()
+	fw := NewFilterWriter(writer, nil)
+	violations := counterValue(t, namingViolations.WithLabelValues("db", NamingRuleTags))
+
+	cfg, _ := json.Marshal(models.Database{
+		Name:         "db",
+		WriteRules:   &models.WriteRules{AddTags: map[string]string{"app": "order"}},
+		NamingPolicy: &models.NamingPolicy{RequiredTags: []string{"app", "host"}},
+	})
+	fw.OnCreate("/database/config/db", cfg)
+	assert.Len(t, fw.checkers, 1)
+	points := []models.Point{
+		// the required tag is added by write rules
+		models.NewPoint("cpu", 0, map[string]string{"host": "a"}, nil),
+		models.NewPoint("cpu", 0, nil, nil),
+	}
+	assert.Nil(t, fw.Write("db", points))
+	assert.Equal(t, 1, writer.numOfPoints("db"))
+	assert.Equal(t, violations+1, counterValue(t, namingViolations.WithLabelValues("db", NamingRuleTags)))
+
+	// invalid policy keeps the policy
+	cfg, _ = json.Marshal(models.Database{Name: "db", NamingPolicy: &models.NamingPolicy{MaxDepth: -1}})
+	fw.OnCreate("/database/config/db", cfg)
+	assert.Len(t, fw.checkers, 1)
+	assert.NotNil(t, fw.SetNamingPolicy("db", &models.NamingPolicy{MetricPattern: "("}))
+
+	fw.OnDelete("/database/config/db")
+	assert.Empty(t, fw.checkers)
+	assert.Nil(t, fw.SetNamingPolicy("db", testNamingPolicy()))
+	fw.Cleanup()
+	assert.Empty(t, fw.checkers)
+	assert.Nil(t, fw.SetNamingPolicy("db", testNamingPolicy()))
+	assert.Nil(t, fw.SetNamingPolicy("db", nil))
+	assert.Empty(t, fw.checkers)
+}
+
+func TestFilterWriter_NamingPolicy_deadLetter(t *testing.T) {
+	deadLetter, err := NewDeadLetterQueue(config.DeadLetter{SampleSize: 10})
+	assert.Nil(t, err)
+	writer := newMemoryWriter()
+	fw := NewFilterWriter(writer, deadLetter)
+	assert.Nil(t, fw.SetNamingPolicy("db", &models.NamingPolicy{RequiredTags: []string{"host"}}))
+
+	assert.Nil(t, fw.Write("db", []models.Point{
+		models.NewPoint("cpu", 1000, map[string]string{"host": "a"}, nil),
+		models.NewPoint("cpu", 1000, map[string]string{"app": "order"}, nil),
+	}))
+	assert.Equal(t, 1, writer.numOfPoints("db"))
+	// the point violating the naming policy is recorded into dead letter queue
+	rejects := deadLetter.Sample("db", 0)
+	assert.Len(t, rejects, 1)
+	assert.Equal(t, "cpu,app=order 1000", rejects[0].Line)
+	assert.Equal(t, "violate naming rule: "+NamingRuleTags, rejects[0].Reason)
+}
@@ -0,0 +1,268 @@
+package ingestion
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/field"
+)
+
+// Defines all metric types of statsd
+const (
+	statsdCounter = "c"
+	statsdGauge   = "g"
+	statsdTimer   = "ms"
+	statsdHisto   = "h"
+	statsdSet     = "s"
+)
+
+// statsdMetric represents a metric line of statsd, format: <name>:<value>|<type>[|@<sample rate>][|#<tag>:<value>,...]
+type statsdMetric struct {
+	name       string
+	value      string
+	metricType string
+	sampleRate float64
+	tags       map[string]string
+}
+
+// parseStatsDLine parses the metric line of statsd, the tags are the extension of dogstatsd
+func parseStatsDLine(line string) (*statsdMetric, error) {
+	idx := strings.IndexByte(line, ':')
+	if idx <= 0 {
+		return nil, fmt.Errorf("metric name not found")
+	}
+	m := &statsdMetric{name: line[:idx], sampleRate: 1, tags: make(map[string]string)}
+	sections := strings.Split(line[idx+1:], "|")
+	if len(sections) < 2 || len(sections[0]) == 0 {
+		return nil, fmt.Errorf("metric value or type not found")
+	}
+	m.value = sections[0]
+	m.metricType = sections[1]
+	switch m.metricType {
+	case statsdCounter, statsdGauge, statsdTimer, statsdHisto, statsdSet:
+	default:
+		return nil, fmt.Errorf("unknown metric type[%s]", m.metricType)
+	}
+	for _, section := range sections[2:] {
+		switch {
+		case strings.HasPrefix(section, "@"):
+			rate, err := strconv.ParseFloat(section[1:], 64)
+			if err != nil || rate <= 0 || rate > 1 {
+				return nil, fmt.Errorf("invalid sample rate[%s]", section)
+			}
+			m.sampleRate = rate
+		case strings.HasPrefix(section, "#"):
+			for _, tag := range strings.Split(section[1:], ",") {
+				if len(tag) == 0 {
+					continue
+				}
+				kv := strings.SplitN(tag, ":", 2)
+				if len(kv) == 2 {
+					m.tags[kv[0]] = kv[1]
+				} else {
+					m.tags[kv[0]] = ""
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+// statsdSeries represents the aggregated values of a series in a flush interval
+type statsdSeries struct {
+	name string
+	tags map[string]string
+
+	// counter/set/timer count
+	count float64
+	// gauge value or timer sum
+	value    float64
+	min, max float64
+	buckets  []float64
+	set      map[string]struct{}
+	// updated is false if the gauge isn't updated after last flush
+	updated bool
+}
+
+// StatsDAggregator aggregates the statsd metrics in memory, the aggregated values are converted
+// into points when flushing, it's safe for concurrent use:
+// 1) counter => field count(sum)
+// 2) gauge => field value(max), the gauge is kept after flushed for the relative changes(+/-)
+// 3) timer/histogram => fields count/sum(sum), min(min), max(max), le_<bound>(sum, cumulative bucket count)
+// 4) set => field count(sum), the number of unique values
+type StatsDAggregator struct {
+	buckets []float64
+
+	mutex    sync.Mutex
+	counters map[string]*statsdSeries
+	gauges   map[string]*statsdSeries
+	timers   map[string]*statsdSeries
+	sets     map[string]*statsdSeries
+}
+
+// NewStatsDAggregator creates the statsd aggregator with the upper bounds of timer histogram buckets
+func NewStatsDAggregator(buckets []float64) *StatsDAggregator {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return &StatsDAggregator{
+		buckets:  sorted,
+		counters: make(map[string]*statsdSeries),
+		gauges:   make(map[string]*statsdSeries),
+		timers:   make(map[string]*statsdSeries),
+		sets:     make(map[string]*statsdSeries),
+	}
+}
+
+// Handle parses the lines of statsd packet then aggregates the metrics,
+// returns the number of metrics aggregated and the error of first invalid line.
+func (a *StatsDAggregator) Handle(data []byte) (int, error) {
+	var (
+		count    int
+		firstErr error
+	)
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if err := a.Add(string(line)); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("handle statsd line[%s] error:%s", line, err)
+			}
+			continue
+		}
+		count++
+	}
+	return count, firstErr
+}
+
+// Add parses the metric line of statsd then aggregates it
+func (a *StatsDAggregator) Add(line string) error {
+	m, err := parseStatsDLine(line)
+	if err != nil {
+		return err
+	}
+	if m.metricType == statsdSet {
+		a.mutex.Lock()
+		s := a.getOrCreateSeries(a.sets, m)
+		if s.set == nil {
+			s.set = make(map[string]struct{})
+		}
+		s.set[m.value] = struct{}{}
+		a.mutex.Unlock()
+		return nil
+	}
+
+	// the relative change of gauge has sign
+	relative := m.metricType == statsdGauge && (m.value[0] == '+' || m.value[0] == '-')
+	value, err := strconv.ParseFloat(m.value, 64)
+	if err != nil {
+		return fmt.Errorf("invalid value[%s]", m.value)
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	switch m.metricType {
+	case statsdCounter:
+		s := a.getOrCreateSeries(a.counters, m)
+		s.count += value / m.sampleRate
+	case statsdGauge:
+		s := a.getOrCreateSeries(a.gauges, m)
+		if relative {
+			s.value += value
+		} else {
+			s.value = value
+		}
+		s.updated = true
+	default:
+		s := a.getOrCreateSeries(a.timers, m)
+		weight := 1 / m.sampleRate
+		if s.count == 0 || value < s.min {
+			s.min = value
+		}
+		if s.count == 0 || value > s.max {
+			s.max = value
+		}
+		s.count += weight
+		s.value += value * weight
+		if s.buckets == nil {
+			s.buckets = make([]float64, len(a.buckets))
+		}
+		for i, bound := range a.buckets {
+			if value <= bound {
+				s.buckets[i] += weight
+			}
+		}
+	}
+	return nil
+}
+
+// Flush converts the aggregated metrics into points with timestamp, then resets the aggregated values
+func (a *StatsDAggregator) Flush(timestamp int64) []models.Point {
+	a.mutex.Lock()
+	counters, timers, sets := a.counters, a.timers, a.sets
+	a.counters = make(map[string]*statsdSeries)
+	a.timers = make(map[string]*statsdSeries)
+	a.sets = make(map[string]*statsdSeries)
+	var gauges []*statsdSeries
+	for _, s := range a.gauges {
+		if s.updated {
+			gauges = append(gauges, &statsdSeries{name: s.name, tags: s.tags, value: s.value})
+			s.updated = false
+		}
+	}
+	a.mutex.Unlock()
+
+	var points []models.Point
+	for _, s := range counters {
+		points = append(points, models.NewPoint(s.name, timestamp, s.tags, map[string]models.Field{
+			"count": floatField(field.SumField, s.count),
+		}))
+	}
+	for _, s := range gauges {
+		points = append(points, models.NewPoint(s.name, timestamp, s.tags, map[string]models.Field{
+			"value": floatField(field.MaxField, s.value),
+		}))
+	}
+	for _, s := range timers {
+		fields := map[string]models.Field{
+			"count": floatField(field.SumField, s.count),
+			"sum":   floatField(field.SumField, s.value),
+			"min":   floatField(field.MinField, s.min),
+			"max":   floatField(field.MaxField, s.max),
+		}
+		for i, bound := range a.buckets {
+			fields["le_"+strconv.FormatFloat(bound, 'g', -1, 64)] = floatField(field.SumField, s.buckets[i])
+		}
+		fields["le_inf"] = floatField(field.SumField, s.count)
+		points = append(points, models.NewPoint(s.name, timestamp, s.tags, fields))
+	}
+	for _, s := range sets {
+		points = append(points, models.NewPoint(s.name, timestamp, s.tags, map[string]models.Field{
+			"count": floatField(field.SumField, float64(len(s.set))),
+		}))
+	}
+	return points
+}
+
+// getOrCreateSeries returns the series of metric by name and tags, creates it if not exist
+func (a *StatsDAggregator) getOrCreateSeries(series map[string]*statsdSeries, m *statsdMetric) *statsdSeries {
+	key := m.name + "|" + models.NewPoint(m.name, 0, m.tags, nil).Tags()
+	s, ok := series[key]
+	if !ok {
+		s = &statsdSeries{name: m.name, tags: m.tags, min: math.NaN(), max: math.NaN()}
+		series[key] = s
+	}
+	return s
+}
+
+// floatField returns the simple field of float value
+func floatField(fieldType field.Type, value float64) models.Field {
+	return models.NewSimpleField(fieldType, field.Float, value)
+}
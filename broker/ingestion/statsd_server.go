@@ -0,0 +1,283 @@
+package ingestion
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/timeutil"
+)
+
+var (
+	statsdMetrics = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "lindb",
+		Subsystem: "broker_statsd",
+		Name:      "metrics_total",
+		Help:      "The number of statsd metrics aggregated.",
+	})
+	statsdInvalidMetrics = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "lindb",
+		Subsystem: "broker_statsd",
+		Name:      "invalid_metrics_total",
+		Help:      "The number of statsd packets(udp) or lines(tcp) with invalid metrics.",
+	})
+	statsdFlushErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "lindb",
+		Subsystem: "broker_statsd",
+		Name:      "flush_errors_total",
+		Help:      "The number of failures writing the aggregated points.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(statsdMetrics, statsdInvalidMetrics, statsdFlushErrors)
+}
+
+// StatsDServer receives the metrics of statsd protocol by udp/tcp, aggregates them in memory,
+// then writes the aggregated points into database every flush interval.
+type StatsDServer interface {
+	// Start listens on the udp/tcp addresses, then receives and flushes the metrics in background
+	Start() error
+	// UDPAddr returns the local udp address, returns nil if not listening
+	UDPAddr() net.Addr
+	// TCPAddr returns the local tcp address, returns nil if not listening
+	TCPAddr() net.Addr
+	// Close stops receiving metrics, then flushes the aggregated metrics
+	Close() error
+}
+
+// statsdServer implements StatsDServer
+type statsdServer struct {
+	cfg        config.StatsD
+	writer     Writer
+	aggregator *StatsDAggregator
+
+	udpConn     *net.UDPConn
+	tcpListener net.Listener
+	started     bool
+	closed      chan struct{}
+	wg          sync.WaitGroup
+
+	mutex sync.Mutex
+	conns map[net.Conn]struct{}
+
+	logger *logger.Logger
+}
+
+// NewStatsDServer creates the statsd server which writes the points into database of config by writer
+func NewStatsDServer(cfg config.StatsD, writer Writer) StatsDServer {
+	return &statsdServer{
+		cfg:        cfg,
+		writer:     writer,
+		aggregator: NewStatsDAggregator(cfg.TimerBuckets),
+		closed:     make(chan struct{}),
+		conns:      make(map[net.Conn]struct{}),
+		logger:     logger.GetLogger("broker/ingestion"),
+	}
+}
+
+// Start listens on the udp/tcp addresses, then receives and flushes the metrics in background
+func (s *statsdServer) Start() error {
+	if len(s.cfg.Database) == 0 {
+		return fmt.Errorf("database of statsd server cannot be empty")
+	}
+	if s.cfg.FlushInterval <= 0 {
+		return fmt.Errorf("flush interval of statsd server must be positive")
+	}
+	if len(s.cfg.UDPAddress) == 0 && len(s.cfg.TCPAddress) == 0 {
+		return fmt.Errorf("statsd server must listen on udp or tcp address")
+	}
+	if len(s.cfg.UDPAddress) > 0 {
+		addr, err := net.ResolveUDPAddr("udp", s.cfg.UDPAddress)
+		if err != nil {
+			return fmt.Errorf("resolve udp address[%s] error:%s", s.cfg.UDPAddress, err)
+		}
+		conn, err := net.ListenUDP("udp", addr)
+		if err != nil {
+			return fmt.Errorf("listen udp address[%s] error:%s", s.cfg.UDPAddress, err)
+		}
+		s.udpConn = conn
+	}
+	if len(s.cfg.TCPAddress) > 0 {
+		listener, err := net.Listen("tcp", s.cfg.TCPAddress)
+		if err != nil {
+			if s.udpConn != nil {
+				_ = s.udpConn.Close()
+				s.udpConn = nil
+			}
+			return fmt.Errorf("listen tcp address[%s] error:%s", s.cfg.TCPAddress, err)
+		}
+		s.tcpListener = listener
+	}
+	s.started = true
+
+	if s.udpConn != nil {
+		s.wg.Add(1)
+		go s.readUDP()
+		s.logger.Info("statsd udp listener started", logger.String("address", s.udpConn.LocalAddr().String()))
+	}
+	if s.tcpListener != nil {
+		s.wg.Add(1)
+		go s.acceptTCP()
+		s.logger.Info("statsd tcp listener started", logger.String("address", s.tcpListener.Addr().String()))
+	}
+	s.wg.Add(1)
+	go s.flushLoop()
+	return nil
+}
+
+// UDPAddr returns the local udp address, returns nil if not listening
+func (s *statsdServer) UDPAddr() net.Addr {
+	if s.udpConn == nil {
+		return nil
+	}
+	return s.udpConn.LocalAddr()
+}
+
+// TCPAddr returns the local tcp address, returns nil if not listening
+func (s *statsdServer) TCPAddr() net.Addr {
+	if s.tcpListener == nil {
+		return nil
+	}
+	return s.tcpListener.Addr()
+}
+
+// Close stops receiving metrics, then flushes the aggregated metrics
+func (s *statsdServer) Close() error {
+	if !s.started {
+		return nil
+	}
+	close(s.closed)
+	var err error
+	if s.udpConn != nil {
+		err = s.udpConn.Close()
+	}
+	if s.tcpListener != nil {
+		if e := s.tcpListener.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	s.mutex.Lock()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mutex.Unlock()
+	s.wg.Wait()
+	// flush the metrics received before closed
+	s.flush()
+	return err
+}
+
+// readUDP receives the udp packets until closed, each packet may contain multi-lines
+func (s *statsdServer) readUDP() {
+	defer s.wg.Done()
+	buf := make([]byte, maxUDPPacketSize)
+	for {
+		n, _, err := s.udpConn.ReadFromUDP(buf)
+		if err != nil {
+			if s.isClosed() {
+				return
+			}
+			s.logger.Error("read statsd udp packet error", logger.Error(err))
+			continue
+		}
+		s.handle(buf[:n])
+	}
+}
+
+// acceptTCP accepts the tcp connections until closed
+func (s *statsdServer) acceptTCP() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.tcpListener.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return
+			}
+			s.logger.Error("accept statsd tcp connection error", logger.Error(err))
+			continue
+		}
+		s.mutex.Lock()
+		if s.isClosed() {
+			s.mutex.Unlock()
+			_ = conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mutex.Unlock()
+
+		s.wg.Add(1)
+		go s.readTCP(conn)
+	}
+}
+
+// readTCP receives the newline-delimited metrics of tcp connection until the connection closed
+func (s *statsdServer) readTCP(conn net.Conn) {
+	defer func() {
+		s.mutex.Lock()
+		delete(s.conns, conn)
+		s.mutex.Unlock()
+		_ = conn.Close()
+		s.wg.Done()
+	}()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		s.handle(scanner.Bytes())
+	}
+	if err := scanner.Err(); err != nil && !s.isClosed() {
+		s.logger.Error("read statsd tcp connection error", logger.Error(err))
+	}
+}
+
+// handle aggregates the metrics of data
+func (s *statsdServer) handle(data []byte) {
+	n, err := s.aggregator.Handle(data)
+	statsdMetrics.Add(float64(n))
+	if err != nil {
+		statsdInvalidMetrics.Inc()
+		s.logger.Warn("invalid statsd metrics", logger.Error(err))
+	}
+}
+
+// flushLoop flushes the aggregated metrics every flush interval until closed
+func (s *statsdServer) flushLoop() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(time.Duration(s.cfg.FlushInterval) * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-s.closed:
+			return
+		}
+	}
+}
+
+// flush writes the aggregated points into database
+func (s *statsdServer) flush() {
+	points := s.aggregator.Flush(timeutil.Now())
+	if len(points) == 0 {
+		return
+	}
+	if err := s.writer.Write(s.cfg.Database, points); err != nil {
+		statsdFlushErrors.Inc()
+		s.logger.Error("write statsd points error", logger.Error(err))
+	}
+}
+
+// isClosed checks if the server is closed
+func (s *statsdServer) isClosed() bool {
+	select {
+	case <-s.closed:
+		return true
+	default:
+		return false
+	}
+}
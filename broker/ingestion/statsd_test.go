@@ -0,0 +1,188 @@
+package ingestion
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/field"
+)
+
+func TestParseStatsDLine(t *testing.T) {
+	m, err := parseStatsDLine("api.latency:12.5|ms|@0.5|#host:alpha,canary")
+	assert.Nil(t, err)
+	assert.Equal(t, "api.latency", m.name)
+	assert.Equal(t, "12.5", m.value)
+	assert.Equal(t, statsdTimer, m.metricType)
+	assert.Equal(t, 0.5, m.sampleRate)
+	assert.Equal(t, map[string]string{"host": "alpha", "canary": ""}, m.tags)
+
+	lines := []string{
+		"api.latency",
+		":1|c",
+		"api.latency:|c",
+		"api.latency:1",
+		"api.latency:1|x",
+		"api.latency:1|c|@abc",
+		"api.latency:1|c|@2",
+	}
+	for _, line := range lines {
+		_, err := parseStatsDLine(line)
+		assert.NotNil(t, err, line)
+	}
+}
+
+func TestStatsDAggregator(t *testing.T) {
+	a := NewStatsDAggregator([]float64{100, 10})
+	n, err := a.Handle([]byte(`
+requests:1|c|#host:alpha
+requests:2|c|@0.5|#host:alpha
+requests:1|c|#host:beta
+temperature:10|g
+temperature:+5|g
+temperature:-3|g
+latency:5|ms
+latency:50|h
+latency:500|ms
+users:alice|s
+users:bob|s
+users:alice|s
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, 12, n)
+
+	points := pointsByKey(a.Flush(1000))
+	assert.Len(t, points, 5)
+
+	alpha := points[`requests{"host":"alpha"}`]
+	assert.Equal(t, int64(1000), alpha.Timestamp())
+	assertField(t, alpha, "count", field.SumField, 5)
+	assertField(t, points[`requests{"host":"beta"}`], "count", field.SumField, 1)
+	assertField(t, points["temperature{}"], "value", field.MaxField, 12)
+
+	latency := points["latency{}"]
+	assert.Len(t, latency.Fields(), 7)
+	assertField(t, latency, "count", field.SumField, 3)
+	assertField(t, latency, "sum", field.SumField, 555)
+	assertField(t, latency, "min", field.MinField, 5)
+	assertField(t, latency, "max", field.MaxField, 500)
+	assertField(t, latency, "le_10", field.SumField, 1)
+	assertField(t, latency, "le_100", field.SumField, 2)
+	assertField(t, latency, "le_inf", field.SumField, 3)
+
+	assertField(t, points["users{}"], "count", field.SumField, 2)
+
+	// only the updated gauges are flushed, the relative changes are based on last value
+	assert.Empty(t, a.Flush(2000))
+	assert.Nil(t, a.Add("temperature:-2|g"))
+	points = pointsByKey(a.Flush(3000))
+	assert.Len(t, points, 1)
+	assertField(t, points["temperature{}"], "value", field.MaxField, 10)
+}
+
+func TestStatsDAggregator_invalid(t *testing.T) {
+	a := NewStatsDAggregator(nil)
+	n, err := a.Handle([]byte("requests:1|c\nrequests:abc|c\nrequests|c"))
+	assert.Equal(t, 1, n)
+	assert.Equal(t, "handle statsd line[requests:abc|c] error:invalid value[abc]", err.Error())
+	assert.Len(t, a.Flush(0), 1)
+}
+
+func TestStatsDServer(t *testing.T) {
+	writer := newMemoryWriter()
+	server := NewStatsDServer(config.StatsD{
+		UDPAddress:    "127.0.0.1:0",
+		TCPAddress:    "127.0.0.1:0",
+		Database:      "db",
+		FlushInterval: 50,
+	}, writer)
+	assert.Nil(t, server.UDPAddr())
+	assert.Nil(t, server.TCPAddr())
+	assert.Nil(t, server.Start())
+	invalid := counterValue(t, statsdInvalidMetrics)
+
+	udpConn, err := net.Dial("udp", server.UDPAddr().String())
+	assert.Nil(t, err)
+	_, err = udpConn.Write([]byte("udp.requests:1|c\nudp.requests:abc|c"))
+	assert.Nil(t, err)
+	_ = udpConn.Close()
+
+	tcpConn, err := net.Dial("tcp", server.TCPAddr().String())
+	assert.Nil(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = fmt.Fprintf(tcpConn, "tcp.latency:%d|ms|#host:alpha\n", i)
+		assert.Nil(t, err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for writer.numOfPoints("db") < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// the tcp connection is closed by server
+	assert.Nil(t, server.Close())
+	assert.Equal(t, 2, writer.numOfPoints("db"))
+	assert.Equal(t, invalid+1, counterValue(t, statsdInvalidMetrics))
+}
+
+func TestStatsDServer_Start(t *testing.T) {
+	cfgs := []config.StatsD{
+		{UDPAddress: ":0", FlushInterval: 10},
+		{UDPAddress: ":0", Database: "db"},
+		{Database: "db", FlushInterval: 10},
+		{UDPAddress: "abc", Database: "db", FlushInterval: 10},
+	}
+	for _, cfg := range cfgs {
+		server := NewStatsDServer(cfg, newMemoryWriter())
+		assert.NotNil(t, server.Start())
+		assert.Nil(t, server.Close())
+	}
+
+	// address is used
+	server := NewStatsDServer(config.StatsD{UDPAddress: "127.0.0.1:0", Database: "db", FlushInterval: 10}, newMemoryWriter())
+	assert.Nil(t, server.Start())
+	server2 := NewStatsDServer(config.StatsD{UDPAddress: server.UDPAddr().String(), Database: "db", FlushInterval: 10},
+		newMemoryWriter())
+	assert.NotNil(t, server2.Start())
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	server3 := NewStatsDServer(config.StatsD{UDPAddress: "127.0.0.1:0", TCPAddress: listener.Addr().String(),
+		Database: "db", FlushInterval: 10}, newMemoryWriter())
+	assert.NotNil(t, server3.Start())
+	assert.Nil(t, server3.UDPAddr())
+	_ = listener.Close()
+	assert.Nil(t, server.Close())
+}
+
+func TestStatsDServer_flush_failure(t *testing.T) {
+	writer := newMemoryWriter()
+	writer.err = fmt.Errorf("err")
+	s := NewStatsDServer(config.StatsD{Database: "db"}, writer).(*statsdServer)
+	flushErrors := counterValue(t, statsdFlushErrors)
+	s.flush()
+	assert.Equal(t, flushErrors, counterValue(t, statsdFlushErrors))
+	s.handle([]byte("requests:1|c"))
+	s.flush()
+	assert.Equal(t, flushErrors+1, counterValue(t, statsdFlushErrors))
+}
+
+func pointsByKey(points []models.Point) map[string]models.Point {
+	result := make(map[string]models.Point)
+	for _, p := range points {
+		result[p.Name()+p.Tags()] = p
+	}
+	return result
+}
+
+func assertField(t *testing.T, point models.Point, name string, fieldType field.Type, value float64) {
+	f, ok := point.Fields()[name].(models.SimpleField)
+	assert.True(t, ok, name)
+	if ok {
+		assert.Equal(t, fieldType, f.Type(), name)
+		assert.Equal(t, value, f.Value(), name)
+	}
+}
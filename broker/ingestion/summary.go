@@ -0,0 +1,25 @@
+package ingestion
+
+import (
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/field"
+)
+
+// ToSummaryFields converts the simple fields with the given names into summary fields(see field.SummaryField),
+// so that the raw samples like latency are aggregated into min/max/sum/count per time slot on write,
+// the fields of other types(string etc.) are kept.
+func ToSummaryFields(points []models.Point, fieldNames []string) {
+	if len(fieldNames) == 0 {
+		return
+	}
+	for _, point := range points {
+		fields := point.Fields()
+		for _, fieldName := range fieldNames {
+			sf, ok := fields[fieldName].(models.SimpleField)
+			if !ok {
+				continue
+			}
+			fields[fieldName] = models.NewSimpleField(field.SummaryField, sf.ValueType(), sf.Value())
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package ingestion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/field"
+)
+
+func TestToSummaryFields(t *testing.T) {
+	points, err := ParseLineProtocolWithStrings([]byte(`rpc,host=a latency=20i,size=1.5,code="ok" 1000`),
+		0, time.Millisecond, nil)
+	assert.Nil(t, err)
+	ToSummaryFields(points, nil)
+	assert.Equal(t, field.SumField, points[0].Fields()["latency"].Type())
+
+	ToSummaryFields(points, []string{"latency", "size", "code", "not_exist"})
+	fields := points[0].Fields()
+	assert.Equal(t, models.NewSimpleField(field.SummaryField, field.Integer, int64(20)), fields["latency"])
+	assert.Equal(t, models.NewSimpleField(field.SummaryField, field.Float, 1.5), fields["size"])
+	assert.Equal(t, models.NewStringField("ok"), fields["code"])
+	assert.Len(t, fields, 3)
+}
@@ -0,0 +1,230 @@
+package ingestion
+
+import (
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/timeutil"
+)
+
+// maxUDPPacketSize is the max size of udp packet
+const maxUDPPacketSize = 64 * 1024
+
+// Defines the reasons of dropping udp packets
+const (
+	dropByQueueFull  = "queue_full"
+	dropByParseError = "parse_error"
+	dropByWriteError = "write_error"
+)
+
+var (
+	udpPackets = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "lindb",
+		Subsystem: "broker_udp",
+		Name:      "packets_total",
+		Help:      "The number of udp packets received.",
+	})
+	udpPoints = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "lindb",
+		Subsystem: "broker_udp",
+		Name:      "points_total",
+		Help:      "The number of points parsed from udp packets.",
+	})
+	udpDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "lindb",
+		Subsystem: "broker_udp",
+		Name:      "packets_dropped_total",
+		Help:      "The number of udp packets dropped(partially for parse error), by the reason of dropping.",
+	}, []string{"reason"})
+)
+
+func init() {
+	prometheus.MustRegister(udpPackets, udpPoints, udpDropped)
+}
+
+var packetPool = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, maxUDPPacketSize)
+		return &buf
+	},
+}
+
+// Writer writes the points received by ingestion protocols into database
+type Writer interface {
+	// Write writes the points into database, returns the error if fail
+	Write(database string, points []models.Point) error
+}
+
+// SequenceWriter is the writer which returns the sequence token of shards after the points written,
+// so that the client can read its own writes by the query carrying the token(read-your-writes).
+type SequenceWriter interface {
+	Writer
+	// WriteWithSequence writes the points into database, returns the sequence token after the points applied
+	WriteWithSequence(database string, points []models.Point) (models.SequenceToken, error)
+}
+
+// BackfillWriter is the writer which re-ingests the corrected historical points, the points bypass the
+// behind window of storage, then are staged and flushed into the historical families directly.
+type BackfillWriter interface {
+	Writer
+	// WriteBackfill writes the historical points into database, returns the error if fail
+	WriteBackfill(database string, points []models.Point) error
+}
+
+// AckWriter is the writer which acknowledges the write by the ack mode(see models.WriteAck),
+// so that the client chooses the tradeoff between the latency and durability per request.
+type AckWriter interface {
+	Writer
+	// WriteWithAck writes the points into database, returns after the points acked by the ack mode,
+	// empty ack mode means the default ack mode of database
+	WriteWithAck(database string, points []models.Point, ack models.WriteAck) error
+}
+
+// UDPListener receives the fire-and-forget metrics of line protocol by udp, for the clients which prefer
+// low overhead to delivery guarantee. The packets are parsed and written by a pool of workers,
+// the packets are dropped if the workers cannot keep up with the receiving.
+type UDPListener interface {
+	// Start listens on the udp port, then receives and parses the packets in background
+	Start() error
+	// Addr returns the local address of listener, returns nil if not started
+	Addr() net.Addr
+	// Close stops receiving packets, then waits for the queued packets processed
+	Close() error
+}
+
+// packet represents the udp packet waiting for processing, the buffer is put back into pool after processed
+type packet struct {
+	buf *[]byte
+	n   int
+}
+
+// udpListener implements UDPListener
+type udpListener struct {
+	cfg    config.UDP
+	writer Writer
+
+	conn    *net.UDPConn
+	packets chan packet
+	closed  chan struct{}
+	readWG  sync.WaitGroup
+	workWG  sync.WaitGroup
+
+	logger *logger.Logger
+}
+
+// NewUDPListener creates the udp listener which writes the points into database of config by writer
+func NewUDPListener(cfg config.UDP, writer Writer) UDPListener {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1
+	}
+	return &udpListener{
+		cfg:     cfg,
+		writer:  writer,
+		packets: make(chan packet, cfg.QueueSize),
+		closed:  make(chan struct{}),
+		logger:  logger.GetLogger("broker/ingestion"),
+	}
+}
+
+// Start listens on the udp port, then receives and parses the packets in background
+func (l *udpListener) Start() error {
+	if len(l.cfg.Database) == 0 {
+		return fmt.Errorf("database of udp listener cannot be empty")
+	}
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{Port: int(l.cfg.Port)})
+	if err != nil {
+		return fmt.Errorf("listen udp port[%d] error:%s", l.cfg.Port, err)
+	}
+	if l.cfg.ReadBuffer > 0 {
+		if err := conn.SetReadBuffer(l.cfg.ReadBuffer); err != nil {
+			_ = conn.Close()
+			return fmt.Errorf("set read buffer of udp socket error:%s", err)
+		}
+	}
+	l.conn = conn
+
+	for i := 0; i < l.cfg.Workers; i++ {
+		l.workWG.Add(1)
+		go l.work()
+	}
+	l.readWG.Add(1)
+	go l.read()
+	l.logger.Info("udp listener started", logger.String("address", conn.LocalAddr().String()))
+	return nil
+}
+
+// Addr returns the local address of listener, returns nil if not started
+func (l *udpListener) Addr() net.Addr {
+	if l.conn == nil {
+		return nil
+	}
+	return l.conn.LocalAddr()
+}
+
+// Close stops receiving packets, then waits for the queued packets processed
+func (l *udpListener) Close() error {
+	if l.conn == nil {
+		return nil
+	}
+	close(l.closed)
+	err := l.conn.Close()
+	l.readWG.Wait()
+	close(l.packets)
+	l.workWG.Wait()
+	return err
+}
+
+// read receives the packets into queue until closed, drops the packet if queue is full
+func (l *udpListener) read() {
+	defer l.readWG.Done()
+	for {
+		buf := packetPool.Get().(*[]byte)
+		n, _, err := l.conn.ReadFromUDP(*buf)
+		if err != nil {
+			packetPool.Put(buf)
+			select {
+			case <-l.closed:
+				return
+			default:
+				l.logger.Error("read udp packet error", logger.Error(err))
+				continue
+			}
+		}
+		udpPackets.Inc()
+		select {
+		case l.packets <- packet{buf: buf, n: n}:
+		default:
+			packetPool.Put(buf)
+			udpDropped.WithLabelValues(dropByQueueFull).Inc()
+		}
+	}
+}
+
+// work parses the packets of queue, then writes the points into database
+func (l *udpListener) work() {
+	defer l.workWG.Done()
+	for p := range l.packets {
+		points, err := ParseLineProtocol((*p.buf)[:p.n], timeutil.Now())
+		packetPool.Put(p.buf)
+		if err != nil {
+			udpDropped.WithLabelValues(dropByParseError).Inc()
+		}
+		if len(points) == 0 {
+			continue
+		}
+		udpPoints.Add(float64(len(points)))
+		if err := l.writer.Write(l.cfg.Database, points); err != nil {
+			udpDropped.WithLabelValues(dropByWriteError).Inc()
+			l.logger.Error("write points of udp packet error", logger.Error(err))
+		}
+	}
+}
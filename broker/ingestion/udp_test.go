@@ -0,0 +1,102 @@
+package ingestion
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/models"
+)
+
+// memoryWriter keeps the written points in memory
+type memoryWriter struct {
+	mutex  sync.Mutex
+	points map[string][]models.Point
+	err    error
+}
+
+func newMemoryWriter() *memoryWriter {
+	return &memoryWriter{points: make(map[string][]models.Point)}
+}
+
+func (w *memoryWriter) Write(database string, points []models.Point) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.points[database] = append(w.points[database], points...)
+	return w.err
+}
+
+func (w *memoryWriter) numOfPoints(database string) int {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return len(w.points[database])
+}
+
+func counterValue(t *testing.T, counter prometheus.Counter) float64 {
+	metric := &dto.Metric{}
+	assert.Nil(t, counter.Write(metric))
+	return metric.GetCounter().GetValue()
+}
+
+func TestUDPListener(t *testing.T) {
+	writer := newMemoryWriter()
+	listener := NewUDPListener(config.UDP{Database: "db", ReadBuffer: 1024 * 1024, Workers: 2, QueueSize: 10}, writer)
+	assert.Nil(t, listener.Addr())
+	assert.Nil(t, listener.Start())
+	parseErrors := counterValue(t, udpDropped.WithLabelValues(dropByParseError))
+
+	conn, err := net.Dial("udp", listener.Addr().String())
+	assert.Nil(t, err)
+	for i := 0; i < 5; i++ {
+		_, err = conn.Write([]byte(fmt.Sprintf("cpu,host=%d usage=1\nmemory used=2i", i)))
+		assert.Nil(t, err)
+	}
+	_, err = conn.Write([]byte("cpu usage"))
+	assert.Nil(t, err)
+	_ = conn.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for writer.numOfPoints("db") < 10 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Nil(t, listener.Close())
+	assert.Equal(t, 10, writer.numOfPoints("db"))
+	assert.Equal(t, parseErrors+1, counterValue(t, udpDropped.WithLabelValues(dropByParseError)))
+}
+
+func TestUDPListener_Start(t *testing.T) {
+	// database is empty
+	listener := NewUDPListener(config.UDP{}, newMemoryWriter())
+	assert.NotNil(t, listener.Start())
+	assert.Nil(t, listener.Close())
+
+	// port is used
+	listener = NewUDPListener(config.UDP{Database: "db"}, newMemoryWriter())
+	assert.Nil(t, listener.Start())
+	port := listener.Addr().(*net.UDPAddr).Port
+	listener2 := NewUDPListener(config.UDP{Database: "db", Port: uint16(port)}, newMemoryWriter())
+	assert.NotNil(t, listener2.Start())
+	assert.Nil(t, listener.Close())
+}
+
+func TestUDPListener_work(t *testing.T) {
+	writer := newMemoryWriter()
+	writer.err = fmt.Errorf("err")
+	l := NewUDPListener(config.UDP{Database: "db"}, writer).(*udpListener)
+	writeErrors := counterValue(t, udpDropped.WithLabelValues(dropByWriteError))
+
+	buf := packetPool.Get().(*[]byte)
+	n := copy(*buf, "cpu usage=1")
+	l.packets <- packet{buf: buf, n: n}
+	close(l.packets)
+	l.workWG.Add(1)
+	l.work()
+	assert.Equal(t, writeErrors+1, counterValue(t, udpDropped.WithLabelValues(dropByWriteError)))
+}
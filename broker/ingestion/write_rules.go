@@ -0,0 +1,340 @@
+package ingestion
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/logger"
+)
+
+var (
+	rulesDroppedPoints = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "lindb",
+		Subsystem: "broker_write_rules",
+		Name:      "dropped_points_total",
+		Help:      "The number of points dropped by the write rules of database.",
+	}, []string{"database"})
+	rulesRelabeledPoints = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "lindb",
+		Subsystem: "broker_write_rules",
+		Name:      "relabeled_points_total",
+		Help:      "The number of points whose tags are changed by the write rules of database.",
+	}, []string{"database"})
+)
+
+func init() {
+	prometheus.MustRegister(rulesDroppedPoints, rulesRelabeledPoints)
+}
+
+// WriteFilter applies the write rules of database to the points
+type WriteFilter struct {
+	dropMetrics *regexp.Regexp
+	dropTags    map[string]struct{}
+	renameTags  map[string]string
+	addTags     map[string]string
+}
+
+// NewWriteFilter compiles the write rules, returns nil if no rules
+func NewWriteFilter(rules *models.WriteRules) (*WriteFilter, error) {
+	if rules == nil {
+		return nil, nil
+	}
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+	f := &WriteFilter{
+		renameTags: rules.RenameTags,
+		addTags:    rules.AddTags,
+	}
+	if len(rules.DropMetrics) > 0 {
+		patterns := make([]string, len(rules.DropMetrics))
+		for i, pattern := range rules.DropMetrics {
+			patterns[i] = "(?:" + pattern + ")"
+		}
+		// full match any of the patterns
+		f.dropMetrics = regexp.MustCompile("^(?:" + strings.Join(patterns, "|") + ")$")
+	}
+	if len(rules.DropTags) > 0 {
+		f.dropTags = make(map[string]struct{}, len(rules.DropTags))
+		for _, tagKey := range rules.DropTags {
+			f.dropTags[tagKey] = struct{}{}
+		}
+	}
+	return f, nil
+}
+
+// Apply drops the points of matched metrics, then relabels the tags of points,
+// returns the points after filtering and the number of relabeled points.
+func (f *WriteFilter) Apply(points []models.Point) (result []models.Point, relabeled int) {
+	if f == nil {
+		return points, 0
+	}
+	result = make([]models.Point, 0, len(points))
+	for _, point := range points {
+		if f.dropMetrics != nil && f.dropMetrics.MatchString(point.Name()) {
+			continue
+		}
+		if tags, changed := f.relabel(point.TagsMap()); changed {
+			point = models.NewPoint(point.Name(), point.Timestamp(), tags, point.Fields())
+			relabeled++
+		}
+		result = append(result, point)
+	}
+	return result, relabeled
+}
+
+// relabel drops, renames and adds tags, the tags are copied if changed
+func (f *WriteFilter) relabel(tags map[string]string) (map[string]string, bool) {
+	if len(f.dropTags) == 0 && len(f.renameTags) == 0 && len(f.addTags) == 0 {
+		return tags, false
+	}
+	result := make(map[string]string, len(tags)+len(f.addTags))
+	changed := false
+	for key, value := range tags {
+		if _, ok := f.dropTags[key]; ok {
+			changed = true
+			continue
+		}
+		if _, ok := f.renameTags[key]; ok {
+			changed = true
+			continue
+		}
+		result[key] = value
+	}
+	// the renamed tag overrides the tag with same key
+	for oldKey, newKey := range f.renameTags {
+		if value, ok := tags[oldKey]; ok {
+			if _, dropped := f.dropTags[oldKey]; !dropped {
+				result[newKey] = value
+			}
+		}
+	}
+	for key, value := range f.addTags {
+		if _, ok := result[key]; !ok {
+			result[key] = value
+			changed = true
+		}
+	}
+	if !changed {
+		return tags, false
+	}
+	return result, true
+}
+
+// FilterWriter applies the write rules and naming policy of database before writing the points by the underlying writer.
+// The rules and default write ack are watched from the database configs, FilterWriter implements the listener of
+// database config discovery(OnCreate/OnDelete/Cleanup), so the rules take effect without restarting.
+// The points violating the naming policy are recorded into the dead letter queue instead of silently dropping.
+type FilterWriter struct {
+	writer     Writer
+	deadLetter DeadLetterQueue
+
+	mutex    sync.RWMutex
+	filters  map[string]*WriteFilter
+	checkers map[string]*NamingChecker
+	acks     map[string]models.WriteAck
+
+	logger *logger.Logger
+}
+
+// NewFilterWriter creates the filter writer which writes the filtered points by writer,
+// the rejected points are recorded into the dead letter queue if it isn't nil.
+func NewFilterWriter(writer Writer, deadLetter DeadLetterQueue) *FilterWriter {
+	return &FilterWriter{
+		writer:     writer,
+		deadLetter: deadLetter,
+		filters:    make(map[string]*WriteFilter),
+		checkers:   make(map[string]*NamingChecker),
+		acks:       make(map[string]models.WriteAck),
+		logger:     logger.GetLogger("broker/ingestion"),
+	}
+}
+
+// Write applies the write rules of database to the points, then checks the naming policy
+// after relabeling(e.g. the required tags may be added by rules), writes the points left.
+func (w *FilterWriter) Write(database string, points []models.Point) error {
+	result := w.filter(database, points)
+	if len(result) == 0 {
+		return nil
+	}
+	return w.writer.Write(database, result)
+}
+
+// WriteWithSequence is same as Write, but returns the sequence token after the points left applied,
+// returns empty token if all points are dropped, returns error if the underlying writer doesn't support it.
+func (w *FilterWriter) WriteWithSequence(database string, points []models.Point) (models.SequenceToken, error) {
+	writer, ok := w.writer.(SequenceWriter)
+	if !ok {
+		return nil, errors.Wrapf(errors.ErrInvalidArgument, "sequence token isn't supported by writer")
+	}
+	result := w.filter(database, points)
+	if len(result) == 0 {
+		return models.SequenceToken{}, nil
+	}
+	return writer.WriteWithSequence(database, result)
+}
+
+// WriteBackfill is same as Write, but writes the points left as backfill,
+// returns error if the underlying writer doesn't support it.
+func (w *FilterWriter) WriteBackfill(database string, points []models.Point) error {
+	writer, ok := w.writer.(BackfillWriter)
+	if !ok {
+		return errors.Wrapf(errors.ErrInvalidArgument, "backfill isn't supported by writer")
+	}
+	result := w.filter(database, points)
+	if len(result) == 0 {
+		return nil
+	}
+	return writer.WriteBackfill(database, result)
+}
+
+// WriteWithAck is same as Write, but returns after the points left acked by the ack mode,
+// the default ack mode of database is used if ack is empty, enqueue if the database has no default.
+// Returns error if durable ack is required but the underlying writer doesn't support it.
+func (w *FilterWriter) WriteWithAck(database string, points []models.Point, ack models.WriteAck) error {
+	if len(ack) == 0 {
+		ack = w.WriteAck(database)
+	}
+	writer, ok := w.writer.(AckWriter)
+	if !ok && ack != models.WriteAckEnqueue {
+		return errors.Wrapf(errors.ErrInvalidArgument, "write ack[%s] isn't supported by writer", ack)
+	}
+	result := w.filter(database, points)
+	if len(result) == 0 {
+		return nil
+	}
+	if !ok {
+		return w.writer.Write(database, result)
+	}
+	return writer.WriteWithAck(database, result, ack)
+}
+
+// filter applies the write rules and naming policy of database, returns the points left
+func (w *FilterWriter) filter(database string, points []models.Point) []models.Point {
+	w.mutex.RLock()
+	filter := w.filters[database]
+	checker := w.checkers[database]
+	w.mutex.RUnlock()
+
+	result, relabeled := filter.Apply(points)
+	if dropped := len(points) - len(result); dropped > 0 {
+		rulesDroppedPoints.WithLabelValues(database).Add(float64(dropped))
+	}
+	if relabeled > 0 {
+		rulesRelabeledPoints.WithLabelValues(database).Add(float64(relabeled))
+	}
+	var reject func(point models.Point, rule string)
+	if w.deadLetter != nil {
+		reject = func(point models.Point, rule string) {
+			w.deadLetter.Reject(database, FormatLine(point), fmt.Sprintf("violate naming rule: %s", rule))
+		}
+	}
+	result, violations := checker.Apply(result, reject)
+	for rule, count := range violations {
+		namingViolations.WithLabelValues(database, rule).Add(float64(count))
+	}
+	return result
+}
+
+// SetRules sets the write rules of database, removes the rules if rules is nil
+func (w *FilterWriter) SetRules(database string, rules *models.WriteRules) error {
+	filter, err := NewWriteFilter(rules)
+	if err != nil {
+		return fmt.Errorf("compile write rules of database[%s] error:%s", database, err)
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if filter == nil {
+		delete(w.filters, database)
+	} else {
+		w.filters[database] = filter
+	}
+	return nil
+}
+
+// SetNamingPolicy sets the naming policy of database, removes the policy if policy is nil
+func (w *FilterWriter) SetNamingPolicy(database string, policy *models.NamingPolicy) error {
+	checker, err := NewNamingChecker(policy)
+	if err != nil {
+		return fmt.Errorf("compile naming policy of database[%s] error:%s", database, err)
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if checker == nil {
+		delete(w.checkers, database)
+	} else {
+		w.checkers[database] = checker
+	}
+	return nil
+}
+
+// SetWriteAck sets the default write ack of database, removes the default if ack is empty
+func (w *FilterWriter) SetWriteAck(database string, ack models.WriteAck) error {
+	if err := ack.Validate(); err != nil {
+		return fmt.Errorf("set write ack of database[%s] error:%s", database, err)
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if len(ack) == 0 {
+		delete(w.acks, database)
+	} else {
+		w.acks[database] = ack
+	}
+	return nil
+}
+
+// WriteAck returns the default write ack of database, returns enqueue if not set
+func (w *FilterWriter) WriteAck(database string) models.WriteAck {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	if ack, ok := w.acks[database]; ok {
+		return ack
+	}
+	return models.WriteAckEnqueue
+}
+
+// OnCreate updates the write rules, naming policy and default write ack when the database config is created or modified
+func (w *FilterWriter) OnCreate(key string, resource []byte) {
+	cfg := models.Database{}
+	if err := json.Unmarshal(resource, &cfg); err != nil {
+		w.logger.Error("unmarshal database config error",
+			logger.String("data", string(resource)), logger.Error(err))
+		return
+	}
+	if err := w.SetRules(cfg.Name, cfg.WriteRules); err != nil {
+		w.logger.Error("set write rules error", logger.Error(err))
+	}
+	if err := w.SetNamingPolicy(cfg.Name, cfg.NamingPolicy); err != nil {
+		w.logger.Error("set naming policy error", logger.Error(err))
+	}
+	if err := w.SetWriteAck(cfg.Name, cfg.WriteAck); err != nil {
+		w.logger.Error("set write ack error", logger.Error(err))
+	}
+}
+
+// OnDelete removes the write rules, naming policy and default write ack when the database config is deleted,
+// the key is like '/database/config/{name}'
+func (w *FilterWriter) OnDelete(key string) {
+	name := key[strings.LastIndex(key, "/")+1:]
+	w.mutex.Lock()
+	delete(w.filters, name)
+	delete(w.checkers, name)
+	delete(w.acks, name)
+	w.mutex.Unlock()
+}
+
+// Cleanup removes all write rules, naming policies and default write acks
+func (w *FilterWriter) Cleanup() {
+	w.mutex.Lock()
+	w.filters = make(map[string]*WriteFilter)
+	w.checkers = make(map[string]*NamingChecker)
+	w.acks = make(map[string]models.WriteAck)
+	w.mutex.Unlock()
+}
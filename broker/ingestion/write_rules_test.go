@@ -0,0 +1,213 @@
+package ingestion
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/coordinator/discovery"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+)
+
+// FilterWriter must be the listener of database config discovery
+var _ discovery.Listener = (*FilterWriter)(nil)
+
+func testRules() *models.WriteRules {
+	return &models.WriteRules{
+		DropMetrics: []string{"debug_.*", "tmp"},
+		DropTags:    []string{"pid"},
+		RenameTags:  map[string]string{"hostname": "host"},
+		AddTags:     map[string]string{"zone": "sh"},
+	}
+}
+
+func TestWriteFilter_Apply(t *testing.T) {
+	filter, err := NewWriteFilter(testRules())
+	assert.Nil(t, err)
+	points := []models.Point{
+		models.NewPoint("debug_cpu", 0, nil, nil),
+		models.NewPoint("tmp", 0, nil, nil),
+		models.NewPoint("tmp_cpu", 0, map[string]string{"zone": "bj"}, nil),
+		models.NewPoint("cpu", 0, map[string]string{"hostname": "a", "host": "b", "pid": "1"}, nil),
+	}
+	result, relabeled := filter.Apply(points)
+	assert.Equal(t, 1, relabeled)
+	assert.Len(t, result, 2)
+	// the point whose tags are not changed is kept
+	assert.Equal(t, points[2], result[0])
+	assert.Equal(t, map[string]string{"host": "a", "zone": "sh"}, result[1].TagsMap())
+
+	// no rules
+	filter, err = NewWriteFilter(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, filter)
+	result, relabeled = filter.Apply(points)
+	assert.Equal(t, points, result)
+	assert.Equal(t, 0, relabeled)
+	filter, err = NewWriteFilter(&models.WriteRules{})
+	assert.Nil(t, err)
+	result, _ = filter.Apply(points)
+	assert.Equal(t, points, result)
+
+	_, err = NewWriteFilter(&models.WriteRules{DropMetrics: []string{"("}})
+	assert.NotNil(t, err)
+}
+
+func TestFilterWriter(t *testing.T) {
+	writer := newMemoryWriter()
+	fw := NewFilterWriter(writer, nil)
+	dropped := counterValue(t, rulesDroppedPoints.WithLabelValues("db"))
+	relabeled := counterValue(t, rulesRelabeledPoints.WithLabelValues("db"))
+
+	points := []models.Point{
+		models.NewPoint("debug_cpu", 0, nil, nil),
+		models.NewPoint("cpu", 0, map[string]string{"hostname": "a"}, nil),
+	}
+	// no rules
+	assert.Nil(t, fw.Write("db", points))
+	assert.Equal(t, 2, writer.numOfPoints("db"))
+
+	cfg, _ := json.Marshal(models.Database{Name: "db", WriteRules: testRules()})
+	fw.OnCreate("/database/config/db", cfg)
+	assert.Nil(t, fw.Write("db", points))
+	assert.Equal(t, 3, writer.numOfPoints("db"))
+	assert.Equal(t, map[string]string{"host": "a", "zone": "sh"}, writer.points["db"][2].TagsMap())
+	assert.Equal(t, dropped+1, counterValue(t, rulesDroppedPoints.WithLabelValues("db")))
+	assert.Equal(t, relabeled+1, counterValue(t, rulesRelabeledPoints.WithLabelValues("db")))
+	// all points are dropped
+	assert.Nil(t, fw.Write("db", points[:1]))
+	assert.Equal(t, 3, writer.numOfPoints("db"))
+
+	// the rules of other database are not applied
+	assert.Nil(t, fw.Write("db2", points))
+	assert.Equal(t, 2, writer.numOfPoints("db2"))
+
+	// invalid config keeps the rules
+	fw.OnCreate("/database/config/db", []byte("abc"))
+	cfg, _ = json.Marshal(models.Database{Name: "db", WriteRules: &models.WriteRules{DropTags: []string{""}}})
+	fw.OnCreate("/database/config/db", cfg)
+	assert.Len(t, fw.filters, 1)
+
+	fw.OnDelete("/database/config/db")
+	assert.Empty(t, fw.filters)
+	assert.Nil(t, fw.SetRules("db", testRules()))
+	fw.Cleanup()
+	assert.Empty(t, fw.filters)
+	assert.Nil(t, fw.SetRules("db", testRules()))
+	assert.Nil(t, fw.SetRules("db", nil))
+	assert.Empty(t, fw.filters)
+
+	writer.err = fmt.Errorf("err")
+	assert.NotNil(t, fw.Write("db", points))
+}
+
+type sequenceWriter struct {
+	*memoryWriter
+}
+
+func (w *sequenceWriter) WriteWithSequence(database string, points []models.Point) (models.SequenceToken, error) {
+	if err := w.Write(database, points); err != nil {
+		return nil, err
+	}
+	return models.SequenceToken{1: int64(w.numOfPoints(database))}, nil
+}
+
+func TestFilterWriter_WriteWithSequence(t *testing.T) {
+	points := []models.Point{
+		models.NewPoint("debug_cpu", 0, nil, nil),
+		models.NewPoint("cpu", 0, map[string]string{"hostname": "a"}, nil),
+	}
+	_, err := NewFilterWriter(newMemoryWriter(), nil).WriteWithSequence("db", points)
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+
+	fw := NewFilterWriter(&sequenceWriter{memoryWriter: newMemoryWriter()}, nil)
+	assert.Nil(t, fw.SetRules("db", testRules()))
+	token, err := fw.WriteWithSequence("db", points)
+	assert.Nil(t, err)
+	assert.Equal(t, models.SequenceToken{1: 1}, token)
+	// all points are dropped
+	token, err = fw.WriteWithSequence("db", points[:1])
+	assert.Nil(t, err)
+	assert.Empty(t, token)
+}
+
+type backfillWriter struct {
+	*memoryWriter
+	backfills int
+}
+
+func (w *backfillWriter) WriteBackfill(database string, points []models.Point) error {
+	w.backfills++
+	return w.Write(database, points)
+}
+
+func TestFilterWriter_WriteBackfill(t *testing.T) {
+	points := []models.Point{
+		models.NewPoint("debug_cpu", 0, nil, nil),
+		models.NewPoint("cpu", 0, map[string]string{"hostname": "a"}, nil),
+	}
+	assert.True(t, errors.Is(NewFilterWriter(newMemoryWriter(), nil).WriteBackfill("db", points),
+		errors.ErrInvalidArgument))
+
+	writer := &backfillWriter{memoryWriter: newMemoryWriter()}
+	fw := NewFilterWriter(writer, nil)
+	assert.Nil(t, fw.SetRules("db", testRules()))
+	assert.Nil(t, fw.WriteBackfill("db", points))
+	assert.Equal(t, 1, writer.numOfPoints("db"))
+	// all points are dropped
+	assert.Nil(t, fw.WriteBackfill("db", points[:1]))
+	assert.Equal(t, 1, writer.backfills)
+}
+
+type ackWriter struct {
+	*memoryWriter
+	acks []models.WriteAck
+}
+
+func (w *ackWriter) WriteWithAck(database string, points []models.Point, ack models.WriteAck) error {
+	w.acks = append(w.acks, ack)
+	return w.Write(database, points)
+}
+
+func TestFilterWriter_WriteWithAck(t *testing.T) {
+	points := []models.Point{
+		models.NewPoint("debug_cpu", 0, nil, nil),
+		models.NewPoint("cpu", 0, map[string]string{"hostname": "a"}, nil),
+	}
+	// enqueue ack is same as write if the underlying writer doesn't support ack
+	memWriter := newMemoryWriter()
+	fw := NewFilterWriter(memWriter, nil)
+	assert.Nil(t, fw.WriteWithAck("db", points, ""))
+	assert.Equal(t, 2, memWriter.numOfPoints("db"))
+	assert.True(t, errors.Is(fw.WriteWithAck("db", points, models.WriteAckDurable), errors.ErrInvalidArgument))
+
+	writer := &ackWriter{memoryWriter: newMemoryWriter()}
+	fw = NewFilterWriter(writer, nil)
+	assert.Nil(t, fw.SetRules("db", testRules()))
+	assert.NotNil(t, fw.SetWriteAck("db", "fsync"))
+	assert.Equal(t, models.WriteAckEnqueue, fw.WriteAck("db"))
+	assert.Nil(t, fw.SetWriteAck("db", models.WriteAckDurable))
+	// the default ack of database is used if not chosen
+	assert.Nil(t, fw.WriteWithAck("db", points, ""))
+	assert.Nil(t, fw.WriteWithAck("db", points, models.WriteAckEnqueue))
+	assert.Equal(t, []models.WriteAck{models.WriteAckDurable, models.WriteAckEnqueue}, writer.acks)
+	assert.Equal(t, 2, writer.numOfPoints("db"))
+	// all points are dropped
+	assert.Nil(t, fw.WriteWithAck("db", points[:1], ""))
+	assert.Len(t, writer.acks, 2)
+
+	// default ack is watched from database config
+	data, _ := json.Marshal(&models.Database{Name: "db2", WriteAck: models.WriteAckDurable})
+	fw.OnCreate("/database/config/db2", data)
+	assert.Equal(t, models.WriteAckDurable, fw.WriteAck("db2"))
+	fw.OnDelete("/database/config/db2")
+	assert.Equal(t, models.WriteAckEnqueue, fw.WriteAck("db2"))
+	fw.Cleanup()
+	assert.Equal(t, models.WriteAckEnqueue, fw.WriteAck("db"))
+	assert.Nil(t, fw.SetWriteAck("db", models.WriteAckDurable))
+	assert.Nil(t, fw.SetWriteAck("db", ""))
+	assert.Equal(t, models.WriteAckEnqueue, fw.WriteAck("db"))
+}
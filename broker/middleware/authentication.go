@@ -11,6 +11,7 @@ import (
 	"github.com/eleme/lindb/models"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
 )
 
 // UserAuthentication represents authentication param
@@ -59,6 +60,37 @@ func (u *UserAuthentication) ValidateMiddleware(next http.Handler) http.Handler
 	})
 }
 
+// IdentifyMiddleware creates middleware which carries the authenticated user in the context of request,
+// so that the user can be authorized by the resource of request(e.g. the readers of database),
+// the request without valid token performs the next action as an anonymous request.
+func (u *UserAuthentication) IdentifyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("Authorization")
+		if len(token) > 0 {
+			claims, _ := ParseToken(token, u.user)
+			if claims.UserName == u.user.UserName && claims.Password == u.user.Password {
+				r = r.WithContext(models.WithUser(r.Context(), claims.UserName))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ValidateMiddlewareIf creates middleware which validates the user permissions only for the request
+// matched by the condition, such as the write request of backfill, other requests perform the next action directly
+func (u *UserAuthentication) ValidateMiddlewareIf(condition func(r *http.Request) bool) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		validate := u.ValidateMiddleware(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if condition(r) {
+				validate.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // ParseToken returns jwt claims by token
 // get secret key use Md5Encrypt method with username and password
 // then jwt parse token by secret key
@@ -71,6 +103,20 @@ func ParseToken(tokenString string, user models.User) (*CustomClaims, error) {
 	return &claims, nil
 }
 
+// GetUserName returns the user name in the token of request header Authorization, returns empty if no token.
+// NOTICE: the token isn't verified, so the user name is only used for identifying the initiator, like audit log.
+func GetUserName(r *http.Request) string {
+	token := r.Header.Get("Authorization")
+	if len(token) == 0 {
+		return ""
+	}
+	claims := CustomClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(token, &claims); err != nil {
+		return ""
+	}
+	return claims.UserName
+}
+
 // CreateLToken returns token use jwt with custom claims
 func CreateToken(user models.User) (string, error) {
 	claims := CustomClaims{
@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/magiconair/properties/assert"
@@ -26,3 +28,57 @@ func Test_CreateToken(t *testing.T) {
 		"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJ1c2VybmFtZSI6ImFkbWluIiwicGF"+
 			"zc3dvcmQiOiJhZG1pbjEyMyJ9.YbNGN0V-U5Y3xOIGNXcgbQkK2VV30UDDEZV19FN62hk", token)
 }
+
+func Test_GetUserName(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodGet, "/database", nil)
+	assert.Equal(t, "", GetUserName(r))
+	r.Header.Set("Authorization", "invalid")
+	assert.Equal(t, "", GetUserName(r))
+	token, _ := CreateToken(models.User{UserName: "admin", Password: "admin123"})
+	r.Header.Set("Authorization", token)
+	assert.Equal(t, "admin", GetUserName(r))
+}
+
+func Test_ValidateMiddlewareIf(t *testing.T) {
+	user := models.User{UserName: "admin", Password: "admin123"}
+	called := 0
+	handler := NewUserAuthentication(user).ValidateMiddlewareIf(func(r *http.Request) bool {
+		return r.URL.Query().Get("backfill") == "true"
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called++
+	}))
+	// the request not matched isn't validated
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/write", nil))
+	assert.Equal(t, 1, called)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/write?backfill=true", nil))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, 1, called)
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/write?backfill=true", nil)
+	token, _ := CreateToken(user)
+	r.Header.Set("Authorization", token)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, 2, called)
+}
+
+func Test_IdentifyMiddleware(t *testing.T) {
+	user := models.User{UserName: "admin", Password: "admin123"}
+	userName := ""
+	handler := NewUserAuthentication(user).IdentifyMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userName = models.UserFromContext(r.Context())
+		}))
+	// the request without token is anonymous
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/query", nil))
+	assert.Equal(t, "", userName)
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/query", nil)
+	token, _ := CreateToken(models.User{UserName: "admin", Password: "wrong"})
+	r.Header.Set("Authorization", token)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, "", userName)
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/query", nil)
+	token, _ = CreateToken(user)
+	r.Header.Set("Authorization", token)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, "admin", userName)
+}
@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/pkg/errors"
+)
+
+// QueryPriorityHeader is the request header which selects the priority class of query
+const QueryPriorityHeader = "X-LinDB-Query-Priority"
+
+// Defines the priority classes of query
+const (
+	// QueryInteractive is the class of ad-hoc queries by users
+	QueryInteractive = "interactive"
+	// QueryDashboard is the class of queries refreshed by dashboards, like grafana
+	QueryDashboard = "dashboard"
+	// QueryBatch is the class of heavy queries, like exports and reports
+	QueryBatch = "batch"
+)
+
+var (
+	queryQueueTime = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "lindb",
+		Subsystem: "broker_query",
+		Name:      "queue_time_ms",
+		Help:      "The time(ms) of query waiting in the admission queue, by priority class.",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 10),
+	}, []string{"class"})
+	queryQueueWaiting = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "lindb",
+		Subsystem: "broker_query",
+		Name:      "queue_waiting",
+		Help:      "The number of queries waiting in the admission queue, by priority class.",
+	}, []string{"class"})
+	queryQueueRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "lindb",
+		Subsystem: "broker_query",
+		Name:      "queue_rejected_total",
+		Help:      "The number of queries rejected after waiting too long in the admission queue, by priority class.",
+	}, []string{"class"})
+)
+
+func init() {
+	prometheus.MustRegister(queryQueueTime, queryQueueWaiting, queryQueueRejected)
+}
+
+// queryClass represents a priority class of queries with bounded concurrency
+type queryClass struct {
+	name         string
+	slots        chan struct{}
+	maxQueueTime time.Duration
+}
+
+// acquire waits for a slot of class, returns ErrQueryRejected if waits longer than max queue time
+func (c *queryClass) acquire(ctx context.Context) (release func(), err error) {
+	if c.slots == nil {
+		return func() {}, nil
+	}
+	start := time.Now()
+	queryQueueWaiting.WithLabelValues(c.name).Inc()
+	defer func() {
+		queryQueueWaiting.WithLabelValues(c.name).Dec()
+		queryQueueTime.WithLabelValues(c.name).Observe(float64(time.Since(start) / time.Millisecond))
+	}()
+
+	var timeout <-chan time.Time
+	if c.maxQueueTime > 0 {
+		timer := time.NewTimer(c.maxQueueTime)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case c.slots <- struct{}{}:
+		return func() { <-c.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timeout:
+		queryQueueRejected.WithLabelValues(c.name).Inc()
+		return nil, errors.Wrapf(errors.ErrQueryRejected,
+			"query of class[%s] waits in queue more than %s", c.name, c.maxQueueTime)
+	}
+}
+
+// QueryQueue represents the admission queue of queries with priority classes(interactive, dashboard, batch),
+// the class of query is selected by request header, each class has separate bounded concurrency,
+// so that the heavy batch queries don't starve the interactive queries and dashboards.
+type QueryQueue struct {
+	classes map[string]*queryClass
+}
+
+// NewQueryQueue creates the query admission queue
+func NewQueryQueue(cfg config.QueryQueue) *QueryQueue {
+	return &QueryQueue{
+		classes: map[string]*queryClass{
+			QueryInteractive: newQueryClass(QueryInteractive, cfg.Interactive),
+			QueryDashboard:   newQueryClass(QueryDashboard, cfg.Dashboard),
+			QueryBatch:       newQueryClass(QueryBatch, cfg.Batch),
+		},
+	}
+}
+
+// newQueryClass creates the priority class, the concurrency is unlimited if zero
+func newQueryClass(name string, cfg config.QueryClass) *queryClass {
+	c := &queryClass{
+		name:         name,
+		maxQueueTime: time.Duration(cfg.MaxQueueTime) * time.Millisecond,
+	}
+	if cfg.Concurrency > 0 {
+		c.slots = make(chan struct{}, cfg.Concurrency)
+	}
+	return c
+}
+
+// Admit creates the middleware which admits the query after getting a slot of its class,
+// the class is selected by request header, default class is used if the header is absent.
+func (q *QueryQueue) Admit(defaultClass string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name := strings.ToLower(strings.TrimSpace(r.Header.Get(QueryPriorityHeader)))
+			if len(name) == 0 {
+				name = defaultClass
+			}
+			class, ok := q.classes[name]
+			if !ok {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("unknown query priority class[%s]", name))
+				return
+			}
+			release, err := class.acquire(r.Context())
+			if err != nil {
+				writeError(w, http.StatusServiceUnavailable, err)
+				return
+			}
+			defer release()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// writeError responses the error message with http status code
+func writeError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	b, _ := json.Marshal(err.Error())
+	_, _ = w.Write(b)
+}
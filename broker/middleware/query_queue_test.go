@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/pkg/errors"
+)
+
+func TestQueryQueue_Admit(t *testing.T) {
+	queue := NewQueryQueue(config.QueryQueue{
+		Interactive: config.QueryClass{Concurrency: 1, MaxQueueTime: 10},
+		Batch:       config.QueryClass{Concurrency: 1},
+	})
+	running := make(chan struct{})
+	finish := make(chan struct{})
+	handler := queue.Admit(QueryInteractive)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(QueryPriorityHeader) == "batch" {
+			running <- struct{}{}
+			<-finish
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(class string) int {
+		r, _ := http.NewRequest(http.MethodGet, "/api/v1/query", nil)
+		r.Header.Set(QueryPriorityHeader, class)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+		return rr.Code
+	}
+
+	// the batch query holds the only slot of batch class
+	go func() {
+		_ = serve(QueryBatch)
+	}()
+	<-running
+	// the interactive and dashboard queries aren't blocked by batch query
+	assert.Equal(t, http.StatusOK, serve(""))
+	assert.Equal(t, http.StatusOK, serve("Dashboard"))
+	assert.Equal(t, http.StatusBadRequest, serve("unknown"))
+	close(finish)
+}
+
+func TestQueryClass_acquire(t *testing.T) {
+	class := newQueryClass(QueryInteractive, config.QueryClass{Concurrency: 1, MaxQueueTime: 10})
+	release, err := class.acquire(context.TODO())
+	assert.Nil(t, err)
+	// the class is full, rejected after max queue time
+	_, err = class.acquire(context.TODO())
+	assert.True(t, errors.Is(err, errors.ErrQueryRejected))
+	// canceled by client
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	_, err = class.acquire(ctx)
+	assert.Equal(t, context.Canceled, err)
+
+	release()
+	release, err = class.acquire(context.TODO())
+	assert.Nil(t, err)
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		release()
+	}()
+	// no max queue time, waits until slot released
+	class.maxQueueTime = 0
+	release, err = class.acquire(context.TODO())
+	assert.Nil(t, err)
+	release()
+
+	// unlimited concurrency
+	class = newQueryClass(QueryBatch, config.QueryClass{})
+	release, err = class.acquire(context.TODO())
+	assert.Nil(t, err)
+	release()
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// TimeoutHeader is the request header which shortens the default timeout(ms) of api
+const TimeoutHeader = "X-LinDB-Timeout"
+
+// Timeout creates the middleware which sets the deadline of request context by the timeout, zero means no timeout.
+// The timeout of request header only takes effect if shorter than the default timeout, so that the request
+// cannot extend or remove the deadline configured.
+// The deadline is propagated to storage nodes by the context of rpc.
+func Timeout(defaultTimeout time.Duration) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := defaultTimeout
+			if value := r.Header.Get(TimeoutHeader); len(value) > 0 {
+				ms, err := strconv.ParseInt(value, 10, 64)
+				if err != nil || ms < 0 {
+					writeError(w, http.StatusBadRequest, fmt.Errorf("invalid timeout[%s] of request", value))
+					return
+				}
+				if requestTimeout := time.Duration(ms) * time.Millisecond; requestTimeout > 0 &&
+					(defaultTimeout <= 0 || requestTimeout < defaultTimeout) {
+					timeout = requestTimeout
+				}
+			}
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeout(t *testing.T) {
+	var deadline time.Time
+	var hasDeadline bool
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, hasDeadline = r.Context().Deadline()
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := Timeout(time.Minute)(next)
+	serve := func(timeout string) int {
+		r, _ := http.NewRequest(http.MethodGet, "/api/v1/query", nil)
+		if len(timeout) > 0 {
+			r.Header.Set(TimeoutHeader, timeout)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+		return rr.Code
+	}
+
+	now := time.Now()
+	assert.Equal(t, http.StatusOK, serve(""))
+	assert.True(t, hasDeadline)
+	assert.True(t, deadline.Sub(now) > 50*time.Second)
+	// shortened by request
+	assert.Equal(t, http.StatusOK, serve("100"))
+	assert.True(t, hasDeadline)
+	assert.True(t, deadline.Sub(now) < time.Second)
+	// cannot be extended by request
+	assert.Equal(t, http.StatusOK, serve("3600000"))
+	assert.True(t, hasDeadline)
+	assert.True(t, deadline.Sub(now) < 2*time.Minute)
+	// cannot be removed by request
+	assert.Equal(t, http.StatusOK, serve("0"))
+	assert.True(t, hasDeadline)
+	assert.True(t, deadline.Sub(now) > 50*time.Second)
+
+	assert.Equal(t, http.StatusBadRequest, serve("abc"))
+	assert.Equal(t, http.StatusBadRequest, serve("-1"))
+
+	// no default timeout, the timeout is set by request
+	handler = Timeout(0)(next)
+	assert.Equal(t, http.StatusOK, serve(""))
+	assert.False(t, hasDeadline)
+	assert.Equal(t, http.StatusOK, serve("100"))
+	assert.True(t, hasDeadline)
+	assert.True(t, deadline.Sub(now) < time.Second)
+}
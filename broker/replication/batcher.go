@@ -0,0 +1,249 @@
+package replication
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/tracing"
+)
+
+// Defines the reasons of flushing batch
+const (
+	flushByPoints = "points"
+	flushByBytes  = "bytes"
+	flushByLinger = "linger"
+	flushByManual = "manual"
+	flushByEpoch  = "epoch"
+)
+
+var (
+	batchPoints = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "lindb",
+		Subsystem: "broker_write",
+		Name:      "batch_points",
+		Help:      "The number of points in the write batch replicated to storage.",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 10),
+	})
+	batchBytes = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "lindb",
+		Subsystem: "broker_write",
+		Name:      "batch_bytes",
+		Help:      "The bytes of the write batch replicated to storage.",
+		Buckets:   prometheus.ExponentialBuckets(64, 4, 10),
+	})
+	batchWrites = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "lindb",
+		Subsystem: "broker_write",
+		Name:      "batch_writes",
+		Help:      "The number of client writes coalesced into the write batch.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	})
+	batchFlushes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "lindb",
+		Subsystem: "broker_write",
+		Name:      "batch_flushes_total",
+		Help:      "The number of write batches flushed, by the reason of flushing.",
+	}, []string{"reason"})
+)
+
+func init() {
+	prometheus.MustRegister(batchPoints, batchBytes, batchWrites, batchFlushes)
+}
+
+// Batch represents the writes of clients which are coalesced into one replication request
+type Batch struct {
+	Writes [][]byte
+	Points int
+	Bytes  int
+	// Epoch is the routing epoch of shard assignment which all writes of batch are routed by
+	Epoch int64
+}
+
+// batchResult is the result of flushing a batch, which is waited by the durable writes of the batch
+type batchResult struct {
+	done chan struct{}
+	err  error
+}
+
+// Flusher sends the batch of writes to storage nodes
+type Flusher interface {
+	// Flush sends the batch tagged with its routing epoch(see rpc.WithRoutingEpoch), returns the error if fail,
+	// the batch is rejected by storage with ErrEpochMismatch if the shard assignment is changed.
+	Flush(batch *Batch) error
+}
+
+// Batcher coalesces the small writes of clients into batches, so that the writes are replicated to storage
+// by efficient requests. The batch is flushed when the number of points or bytes reaches the limit,
+// or the first write of batch has waited for max linger time. The batches are flushed in order.
+type Batcher interface {
+	// Write appends the data with the number of points routed by the epoch into current batch,
+	// flushes the batch if it's full, returns the error of flushing.
+	// The pending batch is flushed first if the epoch is changed, so that a batch is never routed by mixed epochs.
+	Write(data []byte, points int, epoch int64) error
+	// WriteDurable is same as Write, but waits until the batch including the data is flushed,
+	// returns the error of flushing the batch, so that the write is acked after replicated.
+	WriteDurable(data []byte, points int, epoch int64) error
+	// Flush flushes the pending writes immediately
+	Flush() error
+	// Close flushes the pending writes, then rejects the following writes
+	Close() error
+}
+
+// batcher implements Batcher
+type batcher struct {
+	cfg     config.Write
+	flusher Flusher
+
+	// flushMutex keeps the order of flushing batches
+	flushMutex sync.Mutex
+	mutex      sync.Mutex
+	batch      *Batch
+	// seq is the sequence of current batch, used by linger timer to check if the batch is flushed already
+	seq int64
+	// result is the result of current batch, created by the first durable write of the batch
+	result *batchResult
+	timer  *time.Timer
+	closed bool
+
+	logger *logger.Logger
+}
+
+// NewBatcher creates the batcher with the limits of batch, zero batch points/bytes means no limit,
+// max linger <= 0 means each write is flushed immediately.
+func NewBatcher(cfg config.Write, flusher Flusher) Batcher {
+	return &batcher{
+		cfg:     cfg,
+		flusher: flusher,
+		batch:   &Batch{},
+		logger:  logger.GetLogger("broker/replication"),
+	}
+}
+
+// Write appends the data with the number of points routed by the epoch into current batch,
+// flushes the batch if it's full, returns the error of flushing
+func (b *batcher) Write(data []byte, points int, epoch int64) error {
+	_, err := b.write(data, points, epoch, false)
+	return err
+}
+
+// WriteDurable is same as Write, but waits until the batch including the data is flushed,
+// returns the error of flushing the batch
+func (b *batcher) WriteDurable(data []byte, points int, epoch int64) error {
+	result, err := b.write(data, points, epoch, true)
+	if err != nil {
+		return err
+	}
+	<-result.done
+	return result.err
+}
+
+// write appends the data into current batch, flushes the batch if it's full,
+// returns the result of the batch including the data if durable
+func (b *batcher) write(data []byte, points int, epoch int64, durable bool) (*batchResult, error) {
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return nil, fmt.Errorf("write batcher is closed")
+	}
+	if len(b.batch.Writes) > 0 && b.batch.Epoch != epoch {
+		// flushes the batch of previous epoch before switching routing
+		seq := b.seq
+		b.mutex.Unlock()
+		if err := b.flush(flushByEpoch, seq); err != nil {
+			return nil, err
+		}
+		return b.write(data, points, epoch, durable)
+	}
+	b.batch.Epoch = epoch
+	b.batch.Writes = append(b.batch.Writes, data)
+	b.batch.Points += points
+	b.batch.Bytes += len(data)
+	var result *batchResult
+	if durable {
+		if b.result == nil {
+			b.result = &batchResult{done: make(chan struct{})}
+		}
+		result = b.result
+	}
+
+	var reason string
+	switch {
+	case b.cfg.BatchPoints > 0 && b.batch.Points >= b.cfg.BatchPoints:
+		reason = flushByPoints
+	case b.cfg.BatchBytes > 0 && b.batch.Bytes >= b.cfg.BatchBytes:
+		reason = flushByBytes
+	case b.cfg.MaxLinger <= 0:
+		reason = flushByLinger
+	case len(b.batch.Writes) == 1:
+		// starts the linger timer of batch by the first write
+		seq := b.seq
+		b.timer = time.AfterFunc(time.Duration(b.cfg.MaxLinger)*time.Millisecond, func() {
+			if err := b.flush(flushByLinger, seq); err != nil {
+				b.logger.Error("flush write batch error after linger", logger.Error(err))
+			}
+		})
+	}
+	seq := b.seq
+	b.mutex.Unlock()
+
+	if len(reason) == 0 {
+		return result, nil
+	}
+	if err := b.flush(reason, seq); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Flush flushes the pending writes immediately
+func (b *batcher) Flush() error {
+	return b.flush(flushByManual, -1)
+}
+
+// Close flushes the pending writes, then rejects the following writes
+func (b *batcher) Close() error {
+	b.mutex.Lock()
+	b.closed = true
+	b.mutex.Unlock()
+	return b.Flush()
+}
+
+// flush flushes the batch of sequence(-1 means current batch), does nothing if the batch is flushed already
+func (b *batcher) flush(reason string, seq int64) error {
+	b.flushMutex.Lock()
+	defer b.flushMutex.Unlock()
+
+	b.mutex.Lock()
+	if (seq >= 0 && seq != b.seq) || len(b.batch.Writes) == 0 {
+		b.mutex.Unlock()
+		return nil
+	}
+	batch := b.batch
+	result := b.result
+	b.batch = &Batch{}
+	b.result = nil
+	b.seq++
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.mutex.Unlock()
+
+	batchPoints.Observe(float64(batch.Points))
+	batchBytes.Observe(float64(batch.Bytes))
+	batchWrites.Observe(float64(len(batch.Writes)))
+	batchFlushes.WithLabelValues(reason).Inc()
+	defer tracing.ObserveStage(tracing.StageReplicaAppend, time.Now())
+	err := b.flusher.Flush(batch)
+	if result != nil {
+		// notifies the durable writes of batch
+		result.err = err
+		close(result.done)
+	}
+	return err
+}
@@ -0,0 +1,163 @@
+package replication
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/config"
+)
+
+// memoryFlusher keeps the flushed batches in memory
+type memoryFlusher struct {
+	mutex   sync.Mutex
+	batches []*Batch
+	err     error
+}
+
+func (f *memoryFlusher) Flush(batch *Batch) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.batches = append(f.batches, batch)
+	return f.err
+}
+
+func (f *memoryFlusher) numOfBatches() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.batches)
+}
+
+func TestBatcher_Write(t *testing.T) {
+	flusher := &memoryFlusher{}
+	b := NewBatcher(config.Write{BatchPoints: 10, BatchBytes: 100, MaxLinger: 60 * 1000}, flusher)
+
+	// flush by points
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, b.Write([]byte("ab"), 2, 0))
+	}
+	assert.Equal(t, 1, flusher.numOfBatches())
+	assert.Equal(t, &Batch{Writes: [][]byte{[]byte("ab"), []byte("ab"), []byte("ab"), []byte("ab"), []byte("ab")},
+		Points: 10, Bytes: 10}, flusher.batches[0])
+
+	// flush by bytes
+	assert.Nil(t, b.Write(make([]byte, 60), 1, 0))
+	assert.Nil(t, b.Write(make([]byte, 60), 1, 0))
+	assert.Equal(t, 2, flusher.numOfBatches())
+	assert.Equal(t, 120, flusher.batches[1].Bytes)
+
+	// flush manually
+	assert.Nil(t, b.Flush())
+	assert.Equal(t, 2, flusher.numOfBatches())
+	assert.Nil(t, b.Write([]byte("a"), 1, 0))
+	assert.Nil(t, b.Flush())
+	assert.Equal(t, 3, flusher.numOfBatches())
+
+	// flush error
+	flusher.err = fmt.Errorf("err")
+	assert.NotNil(t, b.Write(make([]byte, 100), 1, 0))
+
+	// closed
+	flusher.err = nil
+	assert.Nil(t, b.Write([]byte("a"), 1, 0))
+	assert.Nil(t, b.Close())
+	assert.Equal(t, 5, flusher.numOfBatches())
+	assert.NotNil(t, b.Write([]byte("a"), 1, 0))
+}
+
+func TestBatcher_Linger(t *testing.T) {
+	flusher := &memoryFlusher{}
+	b := NewBatcher(config.Write{BatchPoints: 1000, MaxLinger: 10}, flusher)
+	assert.Nil(t, b.Write([]byte("a"), 1, 0))
+	assert.Nil(t, b.Write([]byte("b"), 1, 0))
+	assert.Equal(t, 0, flusher.numOfBatches())
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 1, flusher.numOfBatches())
+	assert.Equal(t, 2, flusher.batches[0].Points)
+
+	// the timer of flushed batch doesn't flush the next batch
+	b = NewBatcher(config.Write{BatchPoints: 1, MaxLinger: 10}, flusher)
+	bb := b.(*batcher)
+	bb.timer = nil
+	assert.Nil(t, bb.flush(flushByLinger, 100))
+
+	// no linger, each write is flushed immediately
+	flusher = &memoryFlusher{}
+	b = NewBatcher(config.Write{}, flusher)
+	assert.Nil(t, b.Write([]byte("a"), 1, 0))
+	assert.Nil(t, b.Write([]byte("b"), 1, 0))
+	assert.Equal(t, 2, flusher.numOfBatches())
+}
+
+func TestBatcher_Metrics(t *testing.T) {
+	metric := &dto.Metric{}
+	assert.Nil(t, batchPoints.Write(metric))
+	count := metric.GetHistogram().GetSampleCount()
+
+	b := NewBatcher(config.Write{BatchPoints: 2, MaxLinger: 1000}, &memoryFlusher{})
+	assert.Nil(t, b.Write([]byte("a"), 2, 0))
+	assert.Nil(t, batchPoints.Write(metric))
+	assert.Equal(t, count+1, metric.GetHistogram().GetSampleCount())
+	assert.Nil(t, batchFlushes.WithLabelValues(flushByPoints).Write(metric))
+	assert.True(t, metric.GetCounter().GetValue() >= 1)
+}
+
+func TestBatcher_Epoch(t *testing.T) {
+	flusher := &memoryFlusher{}
+	b := NewBatcher(config.Write{BatchPoints: 1000, MaxLinger: 60 * 1000}, flusher)
+	assert.Nil(t, b.Write([]byte("a"), 1, 1))
+	assert.Nil(t, b.Write([]byte("b"), 1, 1))
+	assert.Equal(t, 0, flusher.numOfBatches())
+
+	// the batch of previous epoch is flushed when routing is switched
+	assert.Nil(t, b.Write([]byte("c"), 1, 2))
+	assert.Equal(t, 1, flusher.numOfBatches())
+	assert.Equal(t, &Batch{Writes: [][]byte{[]byte("a"), []byte("b")}, Points: 2, Bytes: 2, Epoch: 1},
+		flusher.batches[0])
+	assert.Nil(t, b.Flush())
+	assert.Equal(t, 2, flusher.numOfBatches())
+	assert.Equal(t, &Batch{Writes: [][]byte{[]byte("c")}, Points: 1, Bytes: 1, Epoch: 2}, flusher.batches[1])
+
+	// flush error of previous epoch
+	flusher.err = fmt.Errorf("err")
+	assert.Nil(t, b.Write([]byte("d"), 1, 2))
+	assert.NotNil(t, b.Write([]byte("e"), 1, 3))
+}
+
+func TestBatcher_WriteDurable(t *testing.T) {
+	flusher := &memoryFlusher{}
+	b := NewBatcher(config.Write{BatchPoints: 1000, MaxLinger: 10}, flusher)
+
+	// the durable writes wait until the batch is flushed after linger
+	var wait sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			assert.Nil(t, b.WriteDurable([]byte("a"), 1, 0))
+		}()
+	}
+	wait.Wait()
+	assert.Equal(t, 1, flusher.numOfBatches())
+	assert.Equal(t, 3, flusher.batches[0].Points)
+
+	// the error of flushing is returned to durable write
+	flusher.mutex.Lock()
+	flusher.err = fmt.Errorf("err")
+	flusher.mutex.Unlock()
+	assert.NotNil(t, b.WriteDurable([]byte("b"), 1, 0))
+	assert.Equal(t, 2, flusher.numOfBatches())
+
+	// the batch is flushed by the durable write if it's full
+	b = NewBatcher(config.Write{BatchPoints: 2, MaxLinger: 60 * 1000}, flusher)
+	assert.Nil(t, b.Write([]byte("c"), 1, 0))
+	assert.NotNil(t, b.WriteDurable([]byte("d"), 1, 0))
+	assert.Equal(t, 3, flusher.numOfBatches())
+
+	assert.Nil(t, b.Close())
+	assert.NotNil(t, b.WriteDurable([]byte("e"), 1, 0))
+}
@@ -0,0 +1,342 @@
+package replication
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	brokerrpc "github.com/eleme/lindb/broker/rpc"
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/stream"
+)
+
+// spillFileSuffix is the suffix of the file of spilled batch, the file name is the sequence of batch
+const spillFileSuffix = ".batch"
+
+var (
+	spilledBatches = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "lindb",
+		Subsystem: "broker_write",
+		Name:      "spilled_batches_total",
+		Help:      "The number of write batches spilled to disk when storage nodes are unreachable.",
+	})
+	drainedBatches = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "lindb",
+		Subsystem: "broker_write",
+		Name:      "drained_batches_total",
+		Help:      "The number of spilled write batches replicated to storage after recovered.",
+	})
+	spillDroppedBatches = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "lindb",
+		Subsystem: "broker_write",
+		Name:      "spill_dropped_batches_total",
+		Help:      "The number of spilled write batches dropped because they are rejected by storage or corrupted.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(spilledBatches, drainedBatches, spillDroppedBatches)
+}
+
+// SpillFlusher is the flusher of shard which spills the batches into a bounded on-disk queue when storage nodes
+// are unreachable(see rpc.IsNodeFailure), then drains the queue in background after storage recovers.
+// The batches are replicated in order, the following batches are spilled until the queue is drained.
+// The batch spilled is acked(e.g. the durable writes) after persisted on disk of broker.
+type SpillFlusher interface {
+	Flusher
+	// Pending returns the number and bytes of the batches spilled but not drained
+	Pending() (batches int, bytes int64)
+	// Drain replicates the spilled batches in order until the queue is empty or storage is still unreachable
+	Drain()
+	// Close stops draining in background, the batches left are kept on disk and drained after restarted
+	Close() error
+}
+
+// spillFlusher implements SpillFlusher
+type spillFlusher struct {
+	flusher Flusher
+	// mutex keeps the order of flushing, spilling and draining batches
+	mutex  sync.Mutex
+	queue  *spillQueue
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	logger *logger.Logger
+}
+
+// NewSpillFlusher creates the spill flusher of shard which spills the batches into the dir when
+// the flusher fails by unreachable storage, the spilled batches left in dir(e.g. before restarted) are drained first.
+func NewSpillFlusher(cfg config.Spill, dir string, flusher Flusher) (SpillFlusher, error) {
+	queue, err := newSpillQueue(dir, cfg.MaxBytes)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &spillFlusher{
+		flusher: flusher,
+		queue:   queue,
+		ctx:     ctx,
+		cancel:  cancel,
+		logger:  logger.GetLogger("broker/replication"),
+	}
+	if cfg.DrainInterval > 0 {
+		go f.drainLoop(time.Duration(cfg.DrainInterval) * time.Millisecond)
+	}
+	return f, nil
+}
+
+// Flush sends the batch if no batch is spilled, spills the batch if storage is unreachable
+// or the spilled batches aren't drained, returns error if the queue is full.
+func (f *spillFlusher) Flush(batch *Batch) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.queue.len() == 0 {
+		err := f.flusher.Flush(batch)
+		if !brokerrpc.IsNodeFailure(err) {
+			return err
+		}
+		f.logger.Warn("storage is unreachable, spill write batch to disk", logger.Error(err))
+	}
+	if err := f.queue.put(batch); err != nil {
+		return err
+	}
+	spilledBatches.Inc()
+	return nil
+}
+
+// Pending returns the number and bytes of the batches spilled but not drained
+func (f *spillFlusher) Pending() (batches int, bytes int64) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.queue.len(), f.queue.size
+}
+
+// Drain replicates the spilled batches in order until the queue is empty or storage is still unreachable,
+// the batch rejected by storage(e.g. the routing epoch is changed) or corrupted is dropped.
+func (f *spillFlusher) Drain() {
+	for f.drainOne() {
+	}
+}
+
+// drainOne replicates the oldest spilled batch, returns if the next batch can be drained,
+// the lock is released between batches so that the flushing of new batches isn't blocked by draining.
+func (f *spillFlusher) drainOne() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.queue.len() == 0 {
+		return false
+	}
+	batch, err := f.queue.peek()
+	if err == nil {
+		err = f.flusher.Flush(batch)
+		if brokerrpc.IsNodeFailure(err) {
+			return false
+		}
+	}
+	if err != nil {
+		spillDroppedBatches.Inc()
+		f.logger.Error("drop spilled write batch", logger.Error(err))
+	} else {
+		drainedBatches.Inc()
+	}
+	if err := f.queue.pop(); err != nil {
+		f.logger.Error("remove spilled write batch error", logger.Error(err))
+		return false
+	}
+	return true
+}
+
+// drainLoop drains the spilled batches every interval until closed
+func (f *spillFlusher) drainLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.ctx.Done():
+			return
+		case <-ticker.C:
+			f.Drain()
+		}
+	}
+}
+
+// Close stops draining in background
+func (f *spillFlusher) Close() error {
+	f.cancel()
+	return nil
+}
+
+// spillQueue is the bounded on-disk queue of batches, each batch is a file named by its sequence
+type spillQueue struct {
+	dir      string
+	maxBytes int64
+	// seqs are the sequences of spilled batches in order, sizes are the file sizes of them
+	seqs  []int64
+	sizes []int64
+	size  int64
+	next  int64
+}
+
+// newSpillQueue creates the queue in dir, loads the batches spilled before, max bytes <= 0 means no limit
+func newSpillQueue(dir string, maxBytes int64) (*spillQueue, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("create spill dir[%s] error:%s", dir, err)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("list spill dir[%s] error:%s", dir, err)
+	}
+	q := &spillQueue{dir: dir, maxBytes: maxBytes}
+	sizes := make(map[int64]int64)
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), spillFileSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseInt(strings.TrimSuffix(file.Name(), spillFileSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		q.seqs = append(q.seqs, seq)
+		sizes[seq] = file.Size()
+	}
+	sort.Slice(q.seqs, func(i, j int) bool { return q.seqs[i] < q.seqs[j] })
+	for _, seq := range q.seqs {
+		q.sizes = append(q.sizes, sizes[seq])
+		q.size += sizes[seq]
+		q.next = seq + 1
+	}
+	return q, nil
+}
+
+// len returns the number of batches in queue
+func (q *spillQueue) len() int {
+	return len(q.seqs)
+}
+
+// put writes the batch into a temp file then renames it, so that the spilled batch is never partial,
+// the file and the directory are synced, so that the spilled batch survives the crash of machine.
+// Returns error if the queue is full
+func (q *spillQueue) put(batch *Batch) error {
+	data, err := encodeBatch(batch)
+	if err != nil {
+		return err
+	}
+	if q.maxBytes > 0 && q.size+int64(len(data)) > q.maxBytes {
+		return fmt.Errorf("spill queue[%s] is full, size:%d", q.dir, q.size)
+	}
+	path := q.path(q.next)
+	tmp := path + ".tmp"
+	if err := writeFileSync(tmp, data); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("write spilled batch error:%s", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("rename spilled batch error:%s", err)
+	}
+	if err := syncDir(q.dir); err != nil {
+		return fmt.Errorf("sync spill dir error:%s", err)
+	}
+	q.seqs = append(q.seqs, q.next)
+	q.sizes = append(q.sizes, int64(len(data)))
+	q.size += int64(len(data))
+	q.next++
+	return nil
+}
+
+// writeFileSync writes the data into file, then syncs the file before closed
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir syncs the directory, so that the entry of file renamed is persisted
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	if err := d.Sync(); err != nil {
+		_ = d.Close()
+		return err
+	}
+	return d.Close()
+}
+
+// peek reads the oldest batch, the queue must not be empty
+func (q *spillQueue) peek() (*Batch, error) {
+	data, err := ioutil.ReadFile(q.path(q.seqs[0]))
+	if err != nil {
+		return nil, fmt.Errorf("read spilled batch error:%s", err)
+	}
+	return decodeBatch(data)
+}
+
+// pop removes the oldest batch, the queue must not be empty
+func (q *spillQueue) pop() error {
+	if err := os.Remove(q.path(q.seqs[0])); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	q.size -= q.sizes[0]
+	q.seqs = q.seqs[1:]
+	q.sizes = q.sizes[1:]
+	return nil
+}
+
+// path returns the file path of batch of sequence
+func (q *spillQueue) path(seq int64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", seq, spillFileSuffix))
+}
+
+// encodeBatch encodes the batch as epoch, points, number of writes, then the writes with length
+func encodeBatch(batch *Batch) ([]byte, error) {
+	writer := stream.BinaryWriter()
+	writer.PutInt64(batch.Epoch)
+	writer.PutUvarint64(uint64(batch.Points))
+	writer.PutUvarint64(uint64(len(batch.Writes)))
+	for _, data := range batch.Writes {
+		writer.PutKey(data)
+	}
+	return writer.Bytes()
+}
+
+// decodeBatch decodes the batch encoded by encodeBatch, returns error if the data is corrupted
+func decodeBatch(data []byte) (*Batch, error) {
+	reader := stream.BinaryReader(data)
+	batch := &Batch{Epoch: reader.ReadInt64(), Points: int(reader.ReadUvarint64())}
+	count := int(reader.ReadUvarint64())
+	for i := 0; i < count && reader.Error() == nil; i++ {
+		length := int(reader.ReadUvarint64())
+		write := reader.ReadBytes(length)
+		if len(write) != length {
+			return nil, fmt.Errorf("spilled batch is corrupted")
+		}
+		batch.Writes = append(batch.Writes, write)
+		batch.Bytes += length
+	}
+	if reader.Error() != nil || !reader.Empty() {
+		return nil, fmt.Errorf("spilled batch is corrupted")
+	}
+	return batch, nil
+}
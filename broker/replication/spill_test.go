@@ -0,0 +1,161 @@
+package replication
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/pkg/util"
+)
+
+func (f *memoryFlusher) setErr(err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.err = err
+}
+
+func TestSpillFlusher_Flush(t *testing.T) {
+	testPath := "test_data"
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	batch := func(data string) *Batch {
+		return &Batch{Writes: [][]byte{[]byte(data)}, Points: 1, Bytes: len(data), Epoch: 2}
+	}
+	flusher := &memoryFlusher{}
+	f, err := NewSpillFlusher(config.Spill{MaxBytes: 30}, testPath, flusher)
+	assert.Nil(t, err)
+
+	assert.Nil(t, f.Flush(batch("a")))
+	assert.Equal(t, 1, flusher.numOfBatches())
+	// the error returned by storage isn't spilled
+	flusher.setErr(fmt.Errorf("err"))
+	assert.NotNil(t, f.Flush(batch("b")))
+	batches, _ := f.Pending()
+	assert.Equal(t, 0, batches)
+
+	// spill when storage is unreachable
+	flusher.setErr(status.Error(codes.Unavailable, "unavailable"))
+	assert.Nil(t, f.Flush(batch("c")))
+	assert.Equal(t, 3, flusher.numOfBatches())
+	// the following batches are spilled until drained, so that the batches are in order
+	flusher.setErr(nil)
+	assert.Nil(t, f.Flush(batch("d")))
+	assert.Equal(t, 3, flusher.numOfBatches())
+	batches, bytes := f.Pending()
+	assert.Equal(t, 2, batches)
+	assert.True(t, bytes > 0)
+	// the queue is full
+	assert.NotNil(t, f.Flush(&Batch{Writes: [][]byte{make([]byte, 30)}}))
+	assert.Nil(t, f.Close())
+
+	// the batches spilled before are drained after restarted
+	f, err = NewSpillFlusher(config.Spill{MaxBytes: 30}, testPath, flusher)
+	assert.Nil(t, err)
+	batches, _ = f.Pending()
+	assert.Equal(t, 2, batches)
+	flusher.setErr(status.Error(codes.Unavailable, "unavailable"))
+	f.Drain()
+	batches, _ = f.Pending()
+	assert.Equal(t, 2, batches)
+	flusher.setErr(nil)
+	f.Drain()
+	batches, bytes = f.Pending()
+	assert.Equal(t, 0, batches)
+	assert.Equal(t, int64(0), bytes)
+	assert.Equal(t, batch("c"), flusher.batches[4])
+	assert.Equal(t, batch("d"), flusher.batches[5])
+	assert.Nil(t, f.Close())
+}
+
+func TestSpillFlusher_Drain(t *testing.T) {
+	testPath := "test_data"
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	flusher := &memoryFlusher{err: status.Error(codes.Unavailable, "unavailable")}
+	f, err := NewSpillFlusher(config.Spill{}, testPath, flusher)
+	assert.Nil(t, err)
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, f.Flush(&Batch{Writes: [][]byte{[]byte("a")}, Points: 1, Bytes: 1}))
+	}
+	// the corrupted batch is dropped
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(testPath, fmt.Sprintf("%020d%s", 0, spillFileSuffix)),
+		[]byte("err"), 0644))
+	f.Drain()
+	batches, _ := f.Pending()
+	assert.Equal(t, 2, batches)
+	// the batches rejected by storage are dropped
+	flusher.setErr(fmt.Errorf("epoch mismatch"))
+	f.Drain()
+	batches, _ = f.Pending()
+	assert.Equal(t, 0, batches)
+	assert.Nil(t, f.Close())
+
+	// drained in background
+	f, err = NewSpillFlusher(config.Spill{DrainInterval: 10}, testPath, flusher)
+	assert.Nil(t, err)
+	flusher.setErr(status.Error(codes.Unavailable, "unavailable"))
+	assert.Nil(t, f.Flush(&Batch{Writes: [][]byte{[]byte("b")}, Points: 1, Bytes: 1}))
+	flusher.setErr(nil)
+	time.Sleep(100 * time.Millisecond)
+	batches, _ = f.Pending()
+	assert.Equal(t, 0, batches)
+	assert.Nil(t, f.Close())
+}
+
+func TestSpillFlusher_NewError(t *testing.T) {
+	testPath := "test_data"
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	assert.Nil(t, ioutil.WriteFile(testPath, []byte("file"), 0644))
+	_, err := NewSpillFlusher(config.Spill{}, testPath, &memoryFlusher{})
+	assert.NotNil(t, err)
+}
+
+func TestDecodeBatch(t *testing.T) {
+	batch := &Batch{Writes: [][]byte{[]byte("ab"), []byte("c")}, Points: 3, Bytes: 3, Epoch: 5}
+	data, err := encodeBatch(batch)
+	assert.Nil(t, err)
+	decoded, err := decodeBatch(data)
+	assert.Nil(t, err)
+	assert.Equal(t, batch, decoded)
+	_, err = decodeBatch(data[:len(data)-1])
+	assert.NotNil(t, err)
+	_, err = decodeBatch(append(data, 1))
+	assert.NotNil(t, err)
+}
+
+func TestSpillQueue_put(t *testing.T) {
+	testPath := "test_data"
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	assert.Nil(t, util.MkDirIfNotExist(testPath))
+	q, err := newSpillQueue(testPath, 0)
+	assert.Nil(t, err)
+	batch := &Batch{Writes: [][]byte{[]byte("ab")}, Points: 1, Bytes: 2}
+	assert.Nil(t, q.put(batch))
+	peeked, err := q.peek()
+	assert.Nil(t, err)
+	assert.Equal(t, batch, peeked)
+
+	// the temp file isn't left if the batch cannot be renamed
+	assert.Nil(t, util.MkDirIfNotExist(filepath.Join(q.path(q.next), "blocked")))
+	assert.NotNil(t, q.put(batch))
+	assert.False(t, util.Exist(q.path(q.next)+".tmp"))
+	assert.Equal(t, 1, q.len())
+
+	// the file and directory cannot be synced after the directory removed
+	assert.Nil(t, util.RemoveDir(testPath))
+	assert.NotNil(t, writeFileSync(filepath.Join(testPath, "file"), []byte("a")))
+	assert.NotNil(t, syncDir(testPath))
+}
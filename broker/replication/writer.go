@@ -0,0 +1,462 @@
+package replication
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/eleme/lindb/broker/ingestion"
+	brokerrpc "github.com/eleme/lindb/broker/rpc"
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/rpc"
+	"github.com/eleme/lindb/service"
+)
+
+// defaultWriteTimeout is the timeout of replicating the write of shard to storage node if not configured
+const defaultWriteTimeout = 5 * time.Second
+
+// maxRerouteRetries is the max times of re-routing the write of shard which is rejected by storage node
+// because of routing epoch mismatch, the routing cache is refreshed before each retry
+const maxRerouteRetries = 3
+
+// Writer routes the points of database to the shards, then replicates the points of each shard to storage nodes,
+// the write is acked after enqueued into the batches of shards, or after the batches replicated if durable.
+// The write with sequence or backfill is replicated directly, the write with sequence returns the sequence token
+// of shards after applied, the backfill returns after flushed into the historical families of shards.
+type Writer interface {
+	ingestion.AckWriter
+	// WriteWithSequence writes the points into database, returns the sequence token after the points applied
+	WriteWithSequence(database string, points []models.Point) (models.SequenceToken, error)
+	// WriteBackfill writes the historical points into database, returns the error if fail
+	WriteBackfill(database string, points []models.Point) error
+	// Close flushes the pending batches of shards, then rejects the following writes
+	Close() error
+}
+
+// shardKey identifies the shard of database in storage cluster, each shard has its own batcher
+type shardKey struct {
+	cluster  string
+	database string
+	shardID  int
+}
+
+// writer implements Writer, routes the points of database to the shards by the shard assignments
+// in routing cache, then coalesces the writes of each shard into batches which are replicated to all replicas
+// of the shard. The database placed in multiple storage clusters is written into each of them.
+// The batches are tagged with the routing epoch of shard assignment, the batch routed by stale routing
+// (e.g. the shard is split or moved) is re-routed by the refreshed assignment, the batch rejected by storage
+// because the routing cache is stale is re-routed after the routing cache is refreshed from state repo.
+type writer struct {
+	cfg             config.Write
+	spill           config.Spill
+	routingCache    service.RoutingCache
+	circuitBreakers brokerrpc.CircuitBreakers
+	newClient       func(node models.Node) brokerrpc.WriteClient
+	timeout         time.Duration
+
+	mutex    sync.Mutex
+	batchers map[shardKey]Batcher
+	// spillFlushers are the flushers of shards which spill the batches when storage is unreachable
+	spillFlushers map[shardKey]SpillFlusher
+	closed        bool
+
+	logger *logger.Logger
+}
+
+// NewWriter creates the writer which replicates the batches of shards to storage nodes by the shared connections
+// of pool, the timeout is the timeout of replicating the batch of shard, <= 0 means the default timeout.
+// If spill is enabled, the batches of shard are spilled into the sub dir of shard when storage is unreachable,
+// the batches spilled before restarted are drained after the shard is written again.
+func NewWriter(cfg config.Write, spill config.Spill, routingCache service.RoutingCache, circuitBreakers brokerrpc.CircuitBreakers,
+	connPool brokerrpc.ConnPool, timeout time.Duration) Writer {
+	if timeout <= 0 {
+		timeout = defaultWriteTimeout
+	}
+	return &writer{
+		cfg:             cfg,
+		spill:           spill,
+		routingCache:    routingCache,
+		circuitBreakers: circuitBreakers,
+		newClient: func(node models.Node) brokerrpc.WriteClient {
+			return brokerrpc.NewPooledWriteClient(connPool, fmt.Sprintf("%s:%d", node.IP, node.Port))
+		},
+		timeout:       timeout,
+		batchers:      make(map[shardKey]Batcher),
+		spillFlushers: make(map[shardKey]SpillFlusher),
+		logger:        logger.GetLogger("broker/replication"),
+	}
+}
+
+// Write routes the points to the shards of database, then appends the points of each shard into its batch,
+// returns after the points enqueued, same as WriteWithAck with enqueue ack.
+func (w *writer) Write(database string, points []models.Point) error {
+	return w.WriteWithAck(database, points, models.WriteAckEnqueue)
+}
+
+// WriteWithAck routes the points to the shards of database, then appends the points of each shard into its batch,
+// returns after the batches replicated with the first error of replicating if the ack is durable,
+// otherwise returns after enqueued, the points of other shards are written continually.
+func (w *writer) WriteWithAck(database string, points []models.Point, ack models.WriteAck) error {
+	shardAssigns, ok := w.routingCache.ShardAssignments(database)
+	if !ok {
+		return errors.Wrapf(errors.ErrDatabaseNotFound, "no shard routing of database: %s", database)
+	}
+	durable := ack == models.WriteAckDurable
+	var result error
+	for _, shardAssign := range shardAssigns {
+		if err := w.writeCluster(shardAssign, database, points, durable); err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}
+
+// WriteWithSequence routes the points to the shards of database, then replicates the points of each shard
+// to the replicas directly instead of batching, returns the sequence token of shards after the points applied
+// by all replicas. The first error of replicating is returned, the points of other shards are written continually.
+func (w *writer) WriteWithSequence(database string, points []models.Point) (models.SequenceToken, error) {
+	return w.writeDirect(context.Background(), database, points)
+}
+
+// WriteBackfill routes the points to the shards of database, then replicates the points of each shard
+// to the replicas directly as backfill, returns after the points flushed into the historical families by all replicas.
+func (w *writer) WriteBackfill(database string, points []models.Point) error {
+	_, err := w.writeDirect(rpc.WithBackfill(context.Background()), database, points)
+	return err
+}
+
+// Close flushes the pending batches of shards, then rejects the following writes,
+// the spilled batches not drained are kept on disk.
+func (w *writer) Close() error {
+	w.mutex.Lock()
+	w.closed = true
+	batchers := w.batchers
+	spillFlushers := w.spillFlushers
+	w.batchers = make(map[shardKey]Batcher)
+	w.spillFlushers = make(map[shardKey]SpillFlusher)
+	w.mutex.Unlock()
+
+	var result error
+	for key, b := range batchers {
+		if err := b.Close(); err != nil {
+			w.logger.Error("close write batcher of shard error", logger.String("cluster", key.cluster),
+				logger.String("database", key.database), logger.Any("shardID", key.shardID), logger.Error(err))
+			if result == nil {
+				result = err
+			}
+		}
+	}
+	for _, f := range spillFlushers {
+		_ = f.Close()
+	}
+	return result
+}
+
+// writeCluster routes the points by the shard assignment of storage cluster, then appends the points of each shard
+// into the batch of shard concurrently, waits until the batches are flushed if durable. Returns before any shard
+// is written if the points cannot be encoded or the batcher of any shard cannot be created.
+func (w *writer) writeCluster(shardAssign *models.ShardAssignment, database string, points []models.Point,
+	durable bool) error {
+	shards, err := routePoints(shardAssign, points)
+	if err != nil {
+		return err
+	}
+	// the points of all shards are encoded and the batchers are resolved before any write starts,
+	// so that no shard is written if the write fails early
+	type shardWrite struct {
+		batcher Batcher
+		data    []byte
+		count   int
+	}
+	writes := make([]shardWrite, 0, len(shards))
+	for shardID, shardPoints := range shards {
+		data, err := models.EncodePoints(shardPoints)
+		if err != nil {
+			return fmt.Errorf("encode points of shard[%d] error:%s", shardID, err)
+		}
+		b, err := w.getBatcher(shardKey{cluster: shardAssign.Config.Name, database: database, shardID: shardID})
+		if err != nil {
+			return err
+		}
+		writes = append(writes, shardWrite{batcher: b, data: data, count: len(shardPoints)})
+	}
+	var (
+		wg     sync.WaitGroup
+		mutex  sync.Mutex
+		result error
+	)
+	for _, sw := range writes {
+		wg.Add(1)
+		go func(sw shardWrite) {
+			defer wg.Done()
+			write := sw.batcher.Write
+			if durable {
+				write = sw.batcher.WriteDurable
+			}
+			if err := write(sw.data, sw.count, shardAssign.Epoch); err != nil {
+				mutex.Lock()
+				if result == nil {
+					result = err
+				}
+				mutex.Unlock()
+			}
+		}(sw)
+	}
+	wg.Wait()
+	return result
+}
+
+// writeDirect routes the points to the shards of database, then replicates the points of each shard
+// to the replicas without batching by the context, returns the sequence token of shards after applied.
+func (w *writer) writeDirect(ctx context.Context, database string, points []models.Point) (models.SequenceToken, error) {
+	shardAssigns, ok := w.routingCache.ShardAssignments(database)
+	if !ok {
+		return nil, errors.Wrapf(errors.ErrDatabaseNotFound, "no shard routing of database: %s", database)
+	}
+	w.mutex.Lock()
+	closed := w.closed
+	w.mutex.Unlock()
+	if closed {
+		return nil, fmt.Errorf("replication writer is closed")
+	}
+	token := make(models.SequenceToken)
+	var result error
+	for _, shardAssign := range shardAssigns {
+		shards, err := routePoints(shardAssign, points)
+		if err != nil {
+			return nil, err
+		}
+		for shardID, shardPoints := range shards {
+			data, err := models.EncodePoints(shardPoints)
+			if err != nil {
+				return nil, fmt.Errorf("encode points of shard[%d] error:%s", shardID, err)
+			}
+			key := shardKey{cluster: shardAssign.Config.Name, database: database, shardID: shardID}
+			shardToken, err := w.writeShard(ctx, key, shardAssign.Epoch, [][]byte{data}, maxRerouteRetries)
+			if err != nil {
+				if result == nil {
+					result = err
+				}
+				continue
+			}
+			token.Merge(shardToken)
+		}
+	}
+	if result != nil {
+		return nil, result
+	}
+	return token, nil
+}
+
+// getBatcher returns the batcher of shard, creates it if not exist,
+// the batches are flushed by the spill flusher of shard if spill is enabled
+func (w *writer) getBatcher(key shardKey) (Batcher, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.closed {
+		return nil, fmt.Errorf("replication writer is closed")
+	}
+	if b, ok := w.batchers[key]; ok {
+		return b, nil
+	}
+	var flusher Flusher = &shardFlusher{writer: w, key: key}
+	if w.spill.Enabled {
+		dir := filepath.Join(w.spill.Dir, key.cluster, key.database, strconv.Itoa(key.shardID))
+		spillFlusher, err := NewSpillFlusher(w.spill, dir, flusher)
+		if err != nil {
+			return nil, fmt.Errorf("create spill flusher of shard[%d] of database[%s] error:%s",
+				key.shardID, key.database, err)
+		}
+		w.spillFlushers[key] = spillFlusher
+		flusher = spillFlusher
+	}
+	b := NewBatcher(w.cfg, flusher)
+	w.batchers[key] = b
+	return b, nil
+}
+
+// shardAssignment returns the current shard assignment of database in the storage cluster
+func (w *writer) shardAssignment(cluster, database string) (*models.ShardAssignment, bool) {
+	shardAssigns, _ := w.routingCache.ShardAssignments(database)
+	for _, shardAssign := range shardAssigns {
+		if shardAssign.Config.Name == cluster {
+			return shardAssign, true
+		}
+	}
+	return nil, false
+}
+
+// shardFlusher implements Flusher, replicates the batch of shard to all replicas of the shard
+type shardFlusher struct {
+	writer *writer
+	key    shardKey
+}
+
+// Flush replicates the batch to the replicas of shard if the batch is routed by current routing,
+// otherwise re-routes the points of batch by the refreshed shard assignment.
+func (f *shardFlusher) Flush(batch *Batch) error {
+	_, err := f.writer.writeShard(context.Background(), f.key, batch.Epoch, batch.Writes, maxRerouteRetries)
+	return err
+}
+
+// writeShard replicates the writes of shard routed by the routing epoch to the replicas of shard, the writes routed
+// by stale routing are re-routed by the current shard assignment. If the writes are rejected by storage because
+// the routing cache is stale, the routing cache is refreshed then the writes are re-routed, at most retries times.
+func (w *writer) writeShard(ctx context.Context, key shardKey, epoch int64, writes [][]byte,
+	retries int) (models.SequenceToken, error) {
+	shardAssign, ok := w.shardAssignment(key.cluster, key.database)
+	if !ok {
+		return nil, errors.Wrapf(errors.ErrDatabaseNotFound, "no shard routing of database[%s] in cluster[%s]",
+			key.database, key.cluster)
+	}
+	if shardAssign.Epoch != epoch {
+		return w.reroute(ctx, shardAssign, key, epoch, writes, retries)
+	}
+	token, err := w.replicate(ctx, shardAssign, &models.ShardWrite{
+		Database: key.database,
+		ShardID:  key.shardID,
+		Writes:   writes,
+	})
+	if retries <= 0 || !errors.Is(err, errors.ErrEpochMismatch) {
+		return token, err
+	}
+	w.logger.Warn("write is rejected by routing epoch of storage, refresh the routing then re-route",
+		logger.String("database", key.database), logger.String("cluster", key.cluster),
+		logger.Any("shardID", key.shardID), logger.Any("epoch", epoch), logger.Error(err))
+	refreshCtx, cancel := context.WithTimeout(context.Background(), w.timeout)
+	defer cancel()
+	if refreshErr := w.routingCache.Refresh(refreshCtx, key.cluster, key.database); refreshErr != nil {
+		w.logger.Error("refresh the routing of database error",
+			logger.String("database", key.database), logger.String("cluster", key.cluster), logger.Error(refreshErr))
+		return token, err
+	}
+	return w.writeShard(ctx, key, epoch, writes, retries-1)
+}
+
+// reroute writes the points routed by stale routing with the refreshed shard assignment,
+// the points are replicated directly instead of batching again, so that the order of the writes is kept.
+func (w *writer) reroute(ctx context.Context, shardAssign *models.ShardAssignment, key shardKey, epoch int64,
+	writes [][]byte, retries int) (models.SequenceToken, error) {
+	w.logger.Info("re-route the write batch routed by stale routing",
+		logger.String("database", key.database), logger.String("cluster", key.cluster),
+		logger.Any("shardID", key.shardID), logger.Any("staleEpoch", epoch),
+		logger.Any("epoch", shardAssign.Epoch))
+	var points []models.Point
+	for _, data := range writes {
+		decoded, err := models.DecodePoints(data)
+		if err != nil {
+			return nil, fmt.Errorf("decode points of shard[%d] error:%s", key.shardID, err)
+		}
+		points = append(points, decoded...)
+	}
+	shards, err := routePoints(shardAssign, points)
+	if err != nil {
+		return nil, err
+	}
+	token := make(models.SequenceToken)
+	var result error
+	for shardID, shardPoints := range shards {
+		data, err := models.EncodePoints(shardPoints)
+		if err != nil {
+			return nil, fmt.Errorf("encode points of shard[%d] error:%s", shardID, err)
+		}
+		shardToken, err := w.writeShard(ctx, shardKey{cluster: key.cluster, database: key.database, shardID: shardID},
+			shardAssign.Epoch, [][]byte{data}, retries)
+		if err != nil {
+			if result == nil {
+				result = err
+			}
+			continue
+		}
+		token.Merge(shardToken)
+	}
+	return token, result
+}
+
+// replicate sends the write of shard tagged with the routing epoch of assignment to all replicas of the shard
+// by the context(e.g. marked as backfill), returns the first error of replicas, the replica node not found in
+// assignment is an error too. The sequence token is the min
+// sequence of the replicas, so that the query carrying the token isn't blocked by the replica whose sequence
+// is behind of others.
+func (w *writer) replicate(ctx context.Context, shardAssign *models.ShardAssignment,
+	write *models.ShardWrite) (models.SequenceToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+	ctx = rpc.WithRoutingEpoch(ctx, write.Database, shardAssign.Epoch)
+	var (
+		token  models.SequenceToken
+		result error
+	)
+	replicas := shardAssign.Shards[write.ShardID].Replicas
+	if len(replicas) == 0 {
+		return nil, fmt.Errorf("shard[%d] of database[%s] has no replica", write.ShardID, write.Database)
+	}
+	for _, nodeID := range replicas {
+		node, ok := shardAssign.Nodes[nodeID]
+		if !ok {
+			// the write isn't acknowledged if any replica is unknown, the others are still written
+			if result == nil {
+				result = fmt.Errorf("replica node[%d] of shard[%d] of database[%s] not found in shard assignment",
+					nodeID, write.ShardID, write.Database)
+			}
+			continue
+		}
+		nodeToken, err := w.writeNode(ctx, node, write)
+		if err != nil {
+			if result == nil {
+				result = errors.Wrapf(err, "replicate shard[%d] of database[%s] to storage node[%s] error",
+					write.ShardID, write.Database, node.String())
+			}
+			continue
+		}
+		sequence, ok := nodeToken[write.ShardID]
+		if !ok {
+			continue
+		}
+		if token == nil {
+			token = models.SequenceToken{write.ShardID: sequence}
+		} else if sequence < token[write.ShardID] {
+			token[write.ShardID] = sequence
+		}
+	}
+	return token, result
+}
+
+// writeNode sends the write of shard to the storage node, records the result into the circuit breaker of node,
+// returns the sequence token of shard in storage node
+func (w *writer) writeNode(ctx context.Context, node models.Node, write *models.ShardWrite) (models.SequenceToken, error) {
+	client := w.newClient(node)
+	if err := client.Init(); err != nil {
+		w.circuitBreakers.Failure(node.Key())
+		return nil, err
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	token, err := client.WritePoints(ctx, write)
+	if brokerrpc.IsNodeFailure(err) {
+		w.circuitBreakers.Failure(node.Key())
+	} else {
+		w.circuitBreakers.Success(node.Key())
+	}
+	return token, err
+}
+
+// routePoints groups the points by the shards which the series of points are routed to
+func routePoints(shardAssign *models.ShardAssignment, points []models.Point) (map[int][]models.Point, error) {
+	shards := make(map[int][]models.Point)
+	for _, point := range points {
+		shardID, err := shardAssign.RouteSeries(point.Name(), point.TagsMap())
+		if err != nil {
+			return nil, fmt.Errorf("route series of metric[%s] error:%s", point.Name(), err)
+		}
+		shards[shardID] = append(shards[shardID], point)
+	}
+	return shards, nil
+}
@@ -0,0 +1,631 @@
+package replication
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/eleme/lindb/broker/cdc"
+	"github.com/eleme/lindb/broker/ingestion"
+	brokerrpc "github.com/eleme/lindb/broker/rpc"
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/pkg/interval"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/option"
+	"github.com/eleme/lindb/pkg/state"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/pkg/util"
+	"github.com/eleme/lindb/rpc"
+	aggregationpb "github.com/eleme/lindb/rpc/proto/aggregation"
+	"github.com/eleme/lindb/rpc/proto/common"
+	"github.com/eleme/lindb/rpc/proto/storage"
+	"github.com/eleme/lindb/service"
+	"github.com/eleme/lindb/storage/handler"
+)
+
+const storageAddress = "127.0.0.1:9006"
+
+// fakeRoutingCache returns the shard assignments of databases, the assignments of refreshes replace
+// the cached ones after refreshed
+type fakeRoutingCache struct {
+	mutex        sync.Mutex
+	shardAssigns map[string][]*models.ShardAssignment
+	refreshes    map[string][]*models.ShardAssignment
+	refreshed    int
+}
+
+func (c *fakeRoutingCache) Load() error {
+	return nil
+}
+
+func (c *fakeRoutingCache) Watch(ctx context.Context, repo state.Repository) {}
+
+func (c *fakeRoutingCache) Refresh(ctx context.Context, clusterName, databaseName string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	shardAssigns, ok := c.refreshes[databaseName]
+	if !ok {
+		return fmt.Errorf("refresh error")
+	}
+	c.refreshed++
+	c.shardAssigns[databaseName] = shardAssigns
+	return nil
+}
+
+func (c *fakeRoutingCache) Shards(databaseName string) ([][]models.Node, bool) {
+	return nil, false
+}
+
+func (c *fakeRoutingCache) ShardAssignments(databaseName string) ([]*models.ShardAssignment, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	shardAssigns, ok := c.shardAssigns[databaseName]
+	return shardAssigns, ok
+}
+
+// fakeWriteClient records the writes of storage node, returns error if the node is failed
+type fakeWriteClient struct {
+	node  string
+	nodes *fakeNodes
+}
+
+func (c *fakeWriteClient) Init() error {
+	return nil
+}
+
+func (c *fakeWriteClient) WritePoints(ctx context.Context, write *models.ShardWrite) (models.SequenceToken, error) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	if _, epoch, ok := rpc.GetRoutingEpoch(metadata.NewIncomingContext(ctx, md)); ok && epoch != c.nodes.epoch {
+		return nil, errors.Wrapf(errors.ErrEpochMismatch, "epoch:%d", epoch)
+	}
+	return c.nodes.write(c.node, write)
+}
+
+func (c *fakeWriteClient) Close() error {
+	return nil
+}
+
+// fakeNodes keeps the writes received by storage nodes, the writes routed by other epoch are rejected,
+// the sequence of shard in node is increased by the writes of shard
+type fakeNodes struct {
+	mutex     sync.Mutex
+	writes    map[string][]*models.ShardWrite
+	errs      map[string]error
+	sequences map[string]models.SequenceToken
+	epoch     int64
+}
+
+func newFakeNodes() *fakeNodes {
+	return &fakeNodes{
+		writes:    make(map[string][]*models.ShardWrite),
+		errs:      make(map[string]error),
+		sequences: make(map[string]models.SequenceToken),
+	}
+}
+
+func (n *fakeNodes) write(node string, write *models.ShardWrite) (models.SequenceToken, error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if err := n.errs[node]; err != nil {
+		return nil, err
+	}
+	n.writes[node] = append(n.writes[node], write)
+	if _, ok := n.sequences[node]; !ok {
+		n.sequences[node] = make(models.SequenceToken)
+	}
+	n.sequences[node][write.ShardID]++
+	return models.SequenceToken{write.ShardID: n.sequences[node][write.ShardID]}, nil
+}
+
+func (n *fakeNodes) points(t *testing.T, node string) map[int]int {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	result := make(map[int]int)
+	for _, write := range n.writes[node] {
+		for _, data := range write.Writes {
+			points, err := models.DecodePoints(data)
+			assert.Nil(t, err)
+			result[write.ShardID] += len(points)
+		}
+	}
+	return result
+}
+
+// newTestShardAssign creates the assignment of 2 shards, shard 1 on node 1/2, shard 2 on node 2/3
+func newTestShardAssign() *models.ShardAssignment {
+	shardAssign := models.NewShardAssignment()
+	for nodeID := 1; nodeID <= 3; nodeID++ {
+		shardAssign.Nodes[nodeID] = models.Node{IP: "127.0.0.1", Port: uint16(2000 + nodeID)}
+	}
+	shardAssign.Shards[1] = models.Replica{Replicas: []int{1, 2}}
+	shardAssign.Shards[2] = models.Replica{Replicas: []int{2, 3}}
+	shardAssign.InitRanges()
+	return shardAssign
+}
+
+func newTestWriter(shardAssign *models.ShardAssignment, nodes *fakeNodes) *writer {
+	return &writer{
+		routingCache: &fakeRoutingCache{shardAssigns: map[string][]*models.ShardAssignment{
+			"db": {shardAssign},
+		}},
+		circuitBreakers: brokerrpc.NewCircuitBreakers(config.CircuitBreaker{}),
+		newClient: func(node models.Node) brokerrpc.WriteClient {
+			return &fakeWriteClient{node: node.String(), nodes: nodes}
+		},
+		timeout:       defaultWriteTimeout,
+		batchers:      make(map[shardKey]Batcher),
+		spillFlushers: make(map[shardKey]SpillFlusher),
+		logger:        logger.GetLogger("broker/replication/test"),
+	}
+}
+
+func newTestPoints(n int) []models.Point {
+	var points []models.Point
+	for i := 0; i < n; i++ {
+		points = append(points, models.NewPoint("cpu", 1000, map[string]string{"host": fmt.Sprintf("host-%d", i)},
+			map[string]models.Field{"usage": models.NewSimpleField(field.SumField, field.Float, 1.0)}))
+	}
+	return points
+}
+
+// testStorage is the storage node which serves the writes of shards by the real write handler
+type testStorage struct {
+	path           string
+	server         rpc.TCPServer
+	storageService service.StorageService
+	query          *handler.Query
+}
+
+// newTestStorage starts the storage node on storage address, which has shard 1 of database db
+func newTestStorage(t *testing.T, path string) *testStorage {
+	storageService := service.NewStorageService(config.Engine{Path: path})
+	assert.Nil(t, storageService.CreateShards("db", option.ShardOption{Interval: time.Second * 10,
+		IntervalType: interval.Day, TimeWindow: 32, Behind: timeutil.OneHour, Ahead: timeutil.OneHour}, 1))
+	server := rpc.NewTCPServer(storageAddress)
+	storage.RegisterWriteServiceServer(server.GetServer(), handler.NewWriter(storageService, handler.NewRoutingEpochs()))
+	go func() {
+		_ = server.Start()
+	}()
+	time.Sleep(100 * time.Millisecond)
+	return &testStorage{
+		path:           path,
+		server:         server,
+		storageService: storageService,
+		query:          handler.NewQuery(service.NewQueryService(storageService, config.Query{}, nil)),
+	}
+}
+
+// newStorageShardAssign creates the assignment of shard 1 on the test storage node
+func newStorageShardAssign() *models.ShardAssignment {
+	shardAssign := models.NewShardAssignment()
+	shardAssign.Nodes[1] = models.Node{IP: "127.0.0.1", Port: 9006}
+	shardAssign.Shards[1] = models.Replica{Replicas: []int{1}}
+	shardAssign.InitRanges()
+	return shardAssign
+}
+
+// newStoragePoints creates the points of n hosts at the timestamp, the value of host-i is i+1
+func newStoragePoints(n int, timestamp int64) []models.Point {
+	var points []models.Point
+	for i := 0; i < n; i++ {
+		points = append(points, models.NewPoint("cpu", timestamp, map[string]string{"host": fmt.Sprintf("host-%d", i)},
+			map[string]models.Field{"usage": models.NewSimpleField(field.SumField, field.Integer, int64(i+1))}))
+	}
+	return points
+}
+
+// queryUsage queries the field usage grouped by host at the timestamp from shard 1 by the query handler,
+// the query waits until the sequences of token are visible if token isn't empty
+func (s *testStorage) queryUsage(t *testing.T, timestamp int64, token models.SequenceToken) map[string]int64 {
+	data, err := json.Marshal(&models.StorageQueryRequest{Database: "db", ShardIDs: []int{1}, MetricName: "cpu",
+		Fields: []string{"usage"}, GroupBy: []string{"host"}, Start: timestamp, End: timestamp, Interval: 10 * 1000,
+		MinSequences: token})
+	assert.Nil(t, err)
+	resp, err := s.query.Query(context.TODO(), &common.Request{Data: data})
+	assert.Nil(t, err)
+	assert.Nil(t, rpc.ResponseToError(resp))
+	result := &aggregationpb.PartialResult{}
+	assert.Nil(t, proto.Unmarshal(resp.Data, result))
+	assert.True(t, result.Complete)
+	usage := make(map[string]int64)
+	for _, series := range result.Series {
+		for _, value := range series.Columns[0].IntValues {
+			usage[series.TagValues[0]] += value
+		}
+	}
+	return usage
+}
+
+func (s *testStorage) close() {
+	s.server.Stop()
+	_ = s.storageService.GetEngine("db").Close()
+	_ = util.RemoveDir(s.path)
+}
+
+func TestWriter_Write(t *testing.T) {
+	shardAssign := newTestShardAssign()
+	nodes := newFakeNodes()
+	w := newTestWriter(shardAssign, nodes)
+
+	points := newTestPoints(100)
+	assert.Nil(t, w.Write("db", points))
+	expected := make(map[int]int)
+	for _, point := range points {
+		shardID, err := shardAssign.RouteSeries(point.Name(), point.TagsMap())
+		assert.Nil(t, err)
+		expected[shardID]++
+	}
+	assert.Equal(t, map[int]int{1: expected[1]}, nodes.points(t, "127.0.0.1:2001"))
+	assert.Equal(t, expected, nodes.points(t, "127.0.0.1:2002"))
+	assert.Equal(t, map[int]int{2: expected[2]}, nodes.points(t, "127.0.0.1:2003"))
+
+	// database not found
+	assert.True(t, errors.Is(w.Write("not_exist", points), errors.ErrDatabaseNotFound))
+	// replica failure
+	nodes.errs["127.0.0.1:2003"] = fmt.Errorf("write error")
+	assert.NotNil(t, w.Write("db", points))
+}
+
+func TestWriter_Write_storage(t *testing.T) {
+	s := newTestStorage(t, "test_data")
+	defer s.close()
+	connPool := brokerrpc.NewConnPool()
+	defer func() {
+		_ = connPool.Close()
+	}()
+	w := NewWriter(config.Write{}, config.Spill{}, &fakeRoutingCache{shardAssigns: map[string][]*models.ShardAssignment{
+		"db": {newStorageShardAssign()},
+	}}, brokerrpc.NewCircuitBreakers(config.CircuitBreaker{}), connPool, 0)
+	now := timeutil.Now()
+	now -= now % (10 * 1000)
+
+	// the points replicated are applied into the shard by write handler, then are queried
+	assert.Nil(t, w.WriteWithAck("db", newStoragePoints(10, now), models.WriteAckDurable))
+	assert.Nil(t, w.Write("db", newStoragePoints(10, now)))
+	assert.Nil(t, w.Close())
+	assert.Equal(t, int64(20), s.storageService.GetShard("db", 1).Sequence())
+	usage := s.queryUsage(t, now, nil)
+	assert.Len(t, usage, 10)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, int64(2*(i+1)), usage[fmt.Sprintf("host-%d", i)])
+	}
+}
+
+func TestWriter_WriteWithSequence_storage(t *testing.T) {
+	s := newTestStorage(t, "test_data")
+	defer s.close()
+	connPool := brokerrpc.NewConnPool()
+	defer func() {
+		_ = connPool.Close()
+	}()
+	w := NewWriter(config.Write{}, config.Spill{}, &fakeRoutingCache{shardAssigns: map[string][]*models.ShardAssignment{
+		"db": {newStorageShardAssign()},
+	}}, brokerrpc.NewCircuitBreakers(config.CircuitBreaker{}), connPool, 0)
+	defer func() {
+		_ = w.Close()
+	}()
+	stream := cdc.NewStream(10)
+	sub := stream.Subscribe(models.ChangeSubscription{})
+	defer sub.Close()
+	// the writer chain of broker: filter writer => cdc writer => replication writer
+	fw := ingestion.NewFilterWriter(cdc.NewWriter(w, stream), nil)
+	now := timeutil.Now()
+	now -= now % (10 * 1000)
+
+	token, err := fw.WriteWithSequence("db", newStoragePoints(10, now))
+	assert.Nil(t, err)
+	assert.Equal(t, models.SequenceToken{1: 10}, token)
+	assert.Equal(t, token.String(), (<-sub.Batches()).SequenceToken)
+	token, err = fw.WriteWithSequence("db", newStoragePoints(5, now))
+	assert.Nil(t, err)
+	assert.Equal(t, models.SequenceToken{1: 15}, token)
+	assert.Equal(t, token.String(), (<-sub.Batches()).SequenceToken)
+	// the writes of token are visible
+	usage := s.queryUsage(t, now, token)
+	assert.Len(t, usage, 10)
+	assert.Equal(t, int64(2), usage["host-0"])
+	assert.Equal(t, int64(10), usage["host-9"])
+
+	// the database without shard routing
+	_, err = fw.WriteWithSequence("not_exist", newStoragePoints(1, now))
+	assert.True(t, errors.Is(err, errors.ErrDatabaseNotFound))
+}
+
+func TestWriter_WriteBackfill_storage(t *testing.T) {
+	s := newTestStorage(t, "test_data")
+	defer s.close()
+	connPool := brokerrpc.NewConnPool()
+	defer func() {
+		_ = connPool.Close()
+	}()
+	w := NewWriter(config.Write{}, config.Spill{}, &fakeRoutingCache{shardAssigns: map[string][]*models.ShardAssignment{
+		"db": {newStorageShardAssign()},
+	}}, brokerrpc.NewCircuitBreakers(config.CircuitBreaker{}), connPool, 0)
+	defer func() {
+		_ = w.Close()
+	}()
+	fw := ingestion.NewFilterWriter(cdc.NewWriter(w, cdc.NewStream(10)), nil)
+	timestamp := timeutil.Now() - 3*timeutil.OneDay
+	timestamp -= timestamp % (10 * 1000)
+
+	// the historical points are dropped by the live write, but are written into the historical family by backfill
+	assert.Nil(t, fw.Write("db", newStoragePoints(10, timestamp)))
+	assert.Empty(t, s.queryUsage(t, timestamp, nil))
+	assert.Nil(t, fw.WriteBackfill("db", newStoragePoints(10, timestamp)))
+	usage := s.queryUsage(t, timestamp, nil)
+	assert.Len(t, usage, 10)
+	assert.Equal(t, int64(10), usage["host-9"])
+
+	// the backfill of read-only shard is rejected
+	assert.Nil(t, s.storageService.GetShard("db", 1).SetState(context.TODO(), models.ShardReadOnly))
+	err := fw.WriteBackfill("db", newStoragePoints(10, timestamp))
+	assert.True(t, errors.Is(err, errors.ErrShardReadOnly))
+}
+
+func TestWriter_Write_route_error(t *testing.T) {
+	shardAssign := models.NewShardAssignment()
+	shardAssign.Shards[1] = models.Replica{Replicas: []int{1}}
+	w := newTestWriter(shardAssign, newFakeNodes())
+	// the shard has no hash range
+	assert.NotNil(t, w.Write("db", newTestPoints(1)))
+}
+
+func TestWriter_Write_routingTags(t *testing.T) {
+	shardAssign := newTestShardAssign()
+	shardAssign.Config.RoutingTags = []string{"region"}
+	nodes := newFakeNodes()
+	w := newTestWriter(shardAssign, nodes)
+
+	var points []models.Point
+	for _, point := range newTestPoints(100) {
+		tags := point.TagsMap()
+		tags["region"] = "nj"
+		points = append(points, models.NewPoint(point.Name(), point.Timestamp(), tags, point.Fields()))
+	}
+	assert.Nil(t, w.Write("db", points))
+	// the series of same region are routed to the same shard
+	shardID, err := shardAssign.RouteSeries("cpu", map[string]string{"region": "nj"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[int]int{shardID: 100}, nodes.points(t, "127.0.0.1:2002"))
+}
+
+func TestWriter_Write_reroute(t *testing.T) {
+	stale := newTestShardAssign()
+	fresh := newTestShardAssign()
+	_, err := fresh.SplitShard(1)
+	assert.Nil(t, err)
+	nodes := newFakeNodes()
+	nodes.epoch = fresh.Epoch
+	w := newTestWriter(stale, nodes)
+
+	// the routing cache cannot be refreshed
+	err = w.Write("db", newTestPoints(100))
+	assert.True(t, errors.Is(err, errors.ErrEpochMismatch))
+	assert.Empty(t, nodes.writes)
+
+	// the routing cache is refreshed after rejected by storage, then the points are re-routed
+	cache := w.routingCache.(*fakeRoutingCache)
+	cache.refreshes = map[string][]*models.ShardAssignment{"db": {fresh}}
+	token, err := w.WriteWithSequence("db", newTestPoints(100))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, cache.refreshed)
+	assert.Contains(t, token, 3)
+	total := 0
+	for _, count := range nodes.points(t, "127.0.0.1:2002") {
+		total += count
+	}
+	assert.Equal(t, 100, total)
+
+	// the batch routed by stale routing is re-routed by the refreshed assignment
+	data, err := models.EncodePoints(newTestPoints(100))
+	assert.Nil(t, err)
+	flusher := &shardFlusher{writer: w, key: shardKey{database: "db", shardID: 1}}
+	assert.Nil(t, flusher.Flush(&Batch{Writes: [][]byte{data}, Points: 100, Epoch: stale.Epoch}))
+	total = 0
+	for _, count := range nodes.points(t, "127.0.0.1:2002") {
+		total += count
+	}
+	assert.Equal(t, 200, total)
+	assert.Contains(t, nodes.points(t, "127.0.0.1:2001"), 3)
+	assert.Equal(t, 1, cache.refreshed)
+	// bad batch
+	assert.NotNil(t, flusher.Flush(&Batch{Writes: [][]byte{{1}}, Epoch: stale.Epoch}))
+	// cluster not found
+	flusher = &shardFlusher{writer: w, key: shardKey{cluster: "other", database: "db", shardID: 1}}
+	assert.True(t, errors.Is(flusher.Flush(&Batch{Epoch: fresh.Epoch}), errors.ErrDatabaseNotFound))
+
+	// the re-routing is retried at most max times if the refreshed routing is still stale
+	nodes.epoch++
+	cache.refreshed = 0
+	err = w.WriteWithAck("db", newTestPoints(100), models.WriteAckDurable)
+	assert.True(t, errors.Is(err, errors.ErrEpochMismatch))
+	// each shard of fresh routing is retried
+	assert.Equal(t, maxRerouteRetries*len(fresh.Shards), cache.refreshed)
+}
+
+func TestWriter_batch(t *testing.T) {
+	shardAssign := newTestShardAssign()
+	nodes := newFakeNodes()
+	w := newTestWriter(shardAssign, nodes)
+	w.cfg = config.Write{MaxLinger: 60 * 1000}
+
+	// the writes of shard are coalesced into one batch until the batcher is closed
+	b, err := w.getBatcher(shardKey{database: "db", shardID: 1})
+	assert.Nil(t, err)
+	for i := 0; i < 2; i++ {
+		data, err := models.EncodePoints(newTestPoints(10))
+		assert.Nil(t, err)
+		assert.Nil(t, b.Write(data, 10, shardAssign.Epoch))
+	}
+	assert.Empty(t, nodes.points(t, "127.0.0.1:2001"))
+	assert.Nil(t, w.Close())
+	assert.Len(t, nodes.writes["127.0.0.1:2001"], 1)
+	assert.Len(t, nodes.writes["127.0.0.1:2001"][0].Writes, 2)
+	assert.Equal(t, map[int]int{1: 20}, nodes.points(t, "127.0.0.1:2001"))
+
+	// closed writer rejects the writes
+	assert.NotNil(t, w.Write("db", newTestPoints(1)))
+}
+
+func TestWriter_WriteWithAck(t *testing.T) {
+	shardAssign := newTestShardAssign()
+	nodes := newFakeNodes()
+	w := newTestWriter(shardAssign, nodes)
+	w.cfg = config.Write{MaxLinger: 200}
+	nodes.errs["127.0.0.1:2003"] = fmt.Errorf("write error")
+
+	// the enqueued write is acked before replicated
+	assert.Nil(t, w.WriteWithAck("db", newTestPoints(100), models.WriteAckEnqueue))
+	assert.Empty(t, nodes.points(t, "127.0.0.1:2002"))
+	// the durable write waits until the batches including the enqueued writes are replicated after linger
+	assert.NotNil(t, w.WriteWithAck("db", newTestPoints(100), models.WriteAckDurable))
+	total := 0
+	for _, count := range nodes.points(t, "127.0.0.1:2002") {
+		total += count
+	}
+	assert.Equal(t, 200, total)
+	assert.Nil(t, w.Close())
+}
+
+func TestWriter_WriteWithAck_cdc(t *testing.T) {
+	shardAssign := newTestShardAssign()
+	nodes := newFakeNodes()
+	w := newTestWriter(shardAssign, nodes)
+	w.cfg = config.Write{MaxLinger: 200}
+	stream := cdc.NewStream(10)
+	sub := stream.Subscribe(models.ChangeSubscription{})
+	defer sub.Close()
+	writer := cdc.NewWriter(w, stream)
+
+	// the points failed to replicate aren't published even if enqueued ack required
+	nodes.errs["127.0.0.1:2003"] = fmt.Errorf("write error")
+	assert.NotNil(t, writer.Write("db", newTestPoints(100)))
+	assert.NotNil(t, writer.(ingestion.AckWriter).WriteWithAck("db", newTestPoints(100), models.WriteAckEnqueue))
+	assert.Empty(t, sub.Batches())
+	// the points are published after replicated
+	delete(nodes.errs, "127.0.0.1:2003")
+	assert.Nil(t, writer.Write("db", newTestPoints(100)))
+	assert.Equal(t, int64(1), (<-sub.Batches()).Sequence)
+	assert.Nil(t, w.Close())
+}
+
+func TestWriter_WriteWithSequence(t *testing.T) {
+	shardAssign := newTestShardAssign()
+	nodes := newFakeNodes()
+	w := newTestWriter(shardAssign, nodes)
+
+	// the token of shard is the min sequence of replicas
+	nodes.sequences["127.0.0.1:2002"] = models.SequenceToken{1: 10, 2: 10}
+	points := newTestPoints(100)
+	token, err := w.WriteWithSequence("db", points)
+	assert.Nil(t, err)
+	assert.Equal(t, models.SequenceToken{1: 1, 2: 1}, token)
+	// the points are replicated without batching
+	expected := make(map[int]int)
+	for _, point := range points {
+		shardID, err := shardAssign.RouteSeries(point.Name(), point.TagsMap())
+		assert.Nil(t, err)
+		expected[shardID]++
+	}
+	assert.Equal(t, expected, nodes.points(t, "127.0.0.1:2002"))
+	assert.Empty(t, w.batchers)
+
+	// database not found
+	_, err = w.WriteWithSequence("not_exist", points)
+	assert.True(t, errors.Is(err, errors.ErrDatabaseNotFound))
+	// replica failure
+	nodes.errs["127.0.0.1:2003"] = fmt.Errorf("write error")
+	_, err = w.WriteWithSequence("db", points)
+	assert.NotNil(t, err)
+	// the shard has no hash range
+	_, err = newTestWriter(models.NewShardAssignment(), nodes).WriteWithSequence("db", points)
+	assert.NotNil(t, err)
+	// closed writer rejects the writes
+	assert.Nil(t, w.Close())
+	_, err = w.WriteWithSequence("db", points)
+	assert.NotNil(t, err)
+}
+
+func TestWriter_replicate_unknownReplica(t *testing.T) {
+	shardAssign := newTestShardAssign()
+	nodes := newFakeNodes()
+	w := newTestWriter(shardAssign, nodes)
+	write := &models.ShardWrite{Database: "db", ShardID: 2}
+
+	// the replica node not found in assignment isn't acknowledged, the others are still written
+	delete(shardAssign.Nodes, 3)
+	_, err := w.replicate(context.TODO(), shardAssign, write)
+	assert.NotNil(t, err)
+	assert.Len(t, nodes.writes["127.0.0.1:2002"], 1)
+	// no replica of shard is written
+	delete(shardAssign.Nodes, 2)
+	_, err = w.replicate(context.TODO(), shardAssign, write)
+	assert.NotNil(t, err)
+	assert.Len(t, nodes.writes["127.0.0.1:2002"], 1)
+	// the shard has no replica
+	shardAssign.Shards[2] = models.Replica{}
+	_, err = w.replicate(context.TODO(), shardAssign, write)
+	assert.NotNil(t, err)
+}
+
+func TestWriter_spill(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "writer_spill_test")
+	_ = os.RemoveAll(dir)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	shardAssign := newTestShardAssign()
+	nodes := newFakeNodes()
+	w := newTestWriter(shardAssign, nodes)
+	w.spill = config.Spill{Enabled: true, Dir: dir}
+
+	// the batch is spilled when storage is unreachable, the error of node is wrapped by replicating
+	nodes.errs["127.0.0.1:2003"] = status.Error(codes.Unavailable, "unreachable")
+	points := newTestPoints(100)
+	assert.Nil(t, w.Write("db", points))
+	f := w.spillFlushers[shardKey{database: "db", shardID: 2}]
+	batches, _ := f.Pending()
+	assert.Equal(t, 1, batches)
+
+	// the spilled batch is drained after storage recovers
+	delete(nodes.errs, "127.0.0.1:2003")
+	f.Drain()
+	batches, _ = f.Pending()
+	assert.Equal(t, 0, batches)
+	assert.Contains(t, nodes.points(t, "127.0.0.1:2003"), 2)
+	assert.Nil(t, w.Close())
+
+	// the spill dir cannot be created
+	w = newTestWriter(shardAssign, nodes)
+	w.spill = config.Spill{Enabled: true, Dir: "/proc/not_exist"}
+	assert.NotNil(t, w.Write("db", points))
+
+	// no shard is written if the spill dir of any shard cannot be created
+	nodes = newFakeNodes()
+	w = newTestWriter(shardAssign, nodes)
+	w.spill = config.Spill{Enabled: true, Dir: dir}
+	assert.Nil(t, os.RemoveAll(dir))
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "db"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "db", "2"), []byte("file"), 0644))
+	assert.NotNil(t, w.Write("db", points))
+	// the write started in background would be appended into batch, then flushed when closed
+	time.Sleep(10 * time.Millisecond)
+	assert.Nil(t, w.Close())
+	assert.Empty(t, nodes.points(t, "127.0.0.1:2001"))
+	assert.Empty(t, nodes.points(t, "127.0.0.1:2002"))
+}
@@ -0,0 +1,182 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/grpc"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/rpc"
+	"github.com/eleme/lindb/rpc/proto/common"
+	"github.com/eleme/lindb/rpc/proto/storage"
+)
+
+//go:generate mockgen -source ./admin_client.go -destination=./admin_client_mock.go -package rpc
+
+// AdminClient represents the client for the administration of storage node
+type AdminClient interface {
+	Init() error
+	ManageMetricStore(ctx context.Context, req *models.MetricStoreRequest) ([]models.MetricStoreResult, error)
+	QueryRawPoints(ctx context.Context, req *models.RawQueryRequest) (*models.RawQueryResult, error)
+	GetDiskUsage(ctx context.Context, req *models.DiskUsageRequest) ([]models.DatabaseDiskUsage, error)
+	WarmUp(ctx context.Context, req *models.WarmUpRequest) ([]models.WarmUpResult, error)
+	ExportFamily(ctx context.Context, req *models.FamilyExportRequest) (*models.FamilyExport, error)
+	Close() error
+}
+
+type adminClient struct {
+	conn     *grpc.ClientConn
+	client   storage.AdminServiceClient
+	address  string
+	connPool ConnPool // shared connections, the connection of pool isn't closed by client
+}
+
+// NewAdminClient creates the admin client for given storage node's address
+func NewAdminClient(address string) AdminClient {
+	return &adminClient{
+		address: address,
+	}
+}
+
+// NewPooledAdminClient creates the admin client for given storage node's address,
+// which uses the shared connection of pool
+func NewPooledAdminClient(connPool ConnPool, address string) AdminClient {
+	return &adminClient{
+		address:  address,
+		connPool: connPool,
+	}
+}
+
+func (ac *adminClient) Init() error {
+	var (
+		conn *grpc.ClientConn
+		err  error
+	)
+	if ac.connPool != nil {
+		conn, err = ac.connPool.Get(ac.address)
+	} else {
+		conn, err = grpc.Dial(ac.address, grpc.WithInsecure(), rpc.DefaultProtocol.DialOption())
+	}
+	if err != nil {
+		return err
+	}
+	ac.conn = conn
+
+	ac.client = storage.NewAdminServiceClient(conn)
+
+	return nil
+}
+
+// ManageMetricStore sends the request of resetting/dropping metric store to storage node,
+// returns the results of the shards in storage node
+func (ac *adminClient) ManageMetricStore(ctx context.Context,
+	req *models.MetricStoreRequest) ([]models.MetricStoreResult, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal metric store request error:%s", err)
+	}
+	resp, err := ac.client.ManageMetricStore(ctx, &common.Request{Data: data})
+	if err != nil {
+		return nil, err
+	}
+	if err := rpc.ResponseToError(resp); err != nil {
+		return nil, errors.Wrapf(err, "manage metric store of storage node[%s] error", ac.address)
+	}
+	var results []models.MetricStoreResult
+	if err := json.Unmarshal(resp.Data, &results); err != nil {
+		return nil, fmt.Errorf("unmarshal metric store results error:%s", err)
+	}
+	return results, nil
+}
+
+// QueryRawPoints sends the raw query request to storage node, returns the raw points of the shards in storage node
+func (ac *adminClient) QueryRawPoints(ctx context.Context,
+	req *models.RawQueryRequest) (*models.RawQueryResult, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal raw query request error:%s", err)
+	}
+	resp, err := ac.client.QueryRawPoints(ctx, &common.Request{Data: data})
+	if err != nil {
+		return nil, err
+	}
+	if err := rpc.ResponseToError(resp); err != nil {
+		return nil, errors.Wrapf(err, "query raw points of storage node[%s] error", ac.address)
+	}
+	result := &models.RawQueryResult{}
+	if err := json.Unmarshal(resp.Data, result); err != nil {
+		return nil, fmt.Errorf("unmarshal raw query result error:%s", err)
+	}
+	return result, nil
+}
+
+// GetDiskUsage sends the disk usage request to storage node, returns the disk usage of databases in storage node
+func (ac *adminClient) GetDiskUsage(ctx context.Context,
+	req *models.DiskUsageRequest) ([]models.DatabaseDiskUsage, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal disk usage request error:%s", err)
+	}
+	resp, err := ac.client.GetDiskUsage(ctx, &common.Request{Data: data})
+	if err != nil {
+		return nil, err
+	}
+	if err := rpc.ResponseToError(resp); err != nil {
+		return nil, errors.Wrapf(err, "get disk usage of storage node[%s] error", ac.address)
+	}
+	var usages []models.DatabaseDiskUsage
+	if err := json.Unmarshal(resp.Data, &usages); err != nil {
+		return nil, fmt.Errorf("unmarshal disk usage error:%s", err)
+	}
+	return usages, nil
+}
+
+// WarmUp sends the warm-up request to storage node, returns the result of database's index and shards in storage node
+func (ac *adminClient) WarmUp(ctx context.Context, req *models.WarmUpRequest) ([]models.WarmUpResult, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal warm up request error:%s", err)
+	}
+	resp, err := ac.client.WarmUp(ctx, &common.Request{Data: data})
+	if err != nil {
+		return nil, err
+	}
+	if err := rpc.ResponseToError(resp); err != nil {
+		return nil, errors.Wrapf(err, "warm up storage node[%s] error", ac.address)
+	}
+	var results []models.WarmUpResult
+	if err := json.Unmarshal(resp.Data, &results); err != nil {
+		return nil, fmt.Errorf("unmarshal warm up results error:%s", err)
+	}
+	return results, nil
+}
+
+// ExportFamily sends the family export request to storage node, returns a page of the exported family
+func (ac *adminClient) ExportFamily(ctx context.Context, req *models.FamilyExportRequest) (*models.FamilyExport, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal family export request error:%s", err)
+	}
+	resp, err := ac.client.ExportFamily(ctx, &common.Request{Data: data})
+	if err != nil {
+		return nil, err
+	}
+	if err := rpc.ResponseToError(resp); err != nil {
+		return nil, errors.Wrapf(err, "export family of storage node[%s] error", ac.address)
+	}
+	result := &models.FamilyExport{}
+	if err := json.Unmarshal(resp.Data, result); err != nil {
+		return nil, fmt.Errorf("unmarshal exported family error:%s", err)
+	}
+	return result, nil
+}
+
+func (ac *adminClient) Close() error {
+	if ac.conn != nil && ac.connPool == nil {
+		return ac.conn.Close()
+	}
+	return nil
+}
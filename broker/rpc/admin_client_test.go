@@ -0,0 +1,138 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/rpc"
+	"github.com/eleme/lindb/rpc/proto/common"
+	"github.com/eleme/lindb/rpc/proto/storage"
+)
+
+const adminAddress = ":9003"
+
+type mockAdminServer struct {
+}
+
+func (s *mockAdminServer) PrepareShutdown(ctx context.Context, request *common.Request) (*common.Response, error) {
+	return rpc.ResponseOK(), nil
+}
+
+func (s *mockAdminServer) ListTasks(ctx context.Context, request *common.Request) (*common.Response, error) {
+	return rpc.ResponseOK(), nil
+}
+
+func (s *mockAdminServer) ManageMetricStore(ctx context.Context, request *common.Request) (*common.Response, error) {
+	return mockAdminResponse(request, []models.MetricStoreResult{{ShardID: 1}})
+}
+
+func (s *mockAdminServer) QueryRawPoints(ctx context.Context, request *common.Request) (*common.Response, error) {
+	return mockAdminResponse(request, &models.RawQueryResult{Series: []models.RawSeries{{ShardID: 1}}})
+}
+
+func (s *mockAdminServer) GetDiskUsage(ctx context.Context, request *common.Request) (*common.Response, error) {
+	return mockAdminResponse(request, []models.DatabaseDiskUsage{{Database: "db", Bytes: 100}})
+}
+
+func (s *mockAdminServer) WarmUp(ctx context.Context, request *common.Request) (*common.Response, error) {
+	return mockAdminResponse(request, []models.WarmUpResult{{Index: true}, {ShardID: 1}})
+}
+
+func (s *mockAdminServer) ExportFamily(ctx context.Context, request *common.Request) (*common.Response, error) {
+	return mockAdminResponse(request, &models.FamilyExport{Next: 10})
+}
+
+// mockAdminResponse returns the error response if the database of request is "err",
+// the bad data if "bad", otherwise the result
+func mockAdminResponse(request *common.Request, result interface{}) (*common.Response, error) {
+	req := &struct {
+		Database string `json:"database"`
+	}{}
+	_ = json.Unmarshal(request.Data, req)
+	switch req.Database {
+	case "err":
+		return rpc.ResponseError("admin error"), nil
+	case "bad":
+		return rpc.ResponseOKWithData([]byte("bad data")), nil
+	default:
+		data, _ := json.Marshal(result)
+		return rpc.ResponseOKWithData(data), nil
+	}
+}
+
+func TestAdminClient(t *testing.T) {
+	server := rpc.NewTCPServer(adminAddress)
+	storage.RegisterAdminServiceServer(server.GetServer(), &mockAdminServer{})
+	go func() {
+		_ = server.Start()
+	}()
+	defer server.Stop()
+	time.Sleep(100 * time.Millisecond)
+
+	cli := NewAdminClient(adminAddress)
+	assert.Nil(t, cli.Init())
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	ctx := context.TODO()
+	cases := []struct {
+		name   string
+		call   func(database string) (interface{}, error)
+		result interface{}
+	}{
+		{
+			name: "ManageMetricStore",
+			call: func(database string) (interface{}, error) {
+				return cli.ManageMetricStore(ctx, &models.MetricStoreRequest{Database: database})
+			},
+			result: []models.MetricStoreResult{{ShardID: 1}},
+		},
+		{
+			name: "QueryRawPoints",
+			call: func(database string) (interface{}, error) {
+				return cli.QueryRawPoints(ctx, &models.RawQueryRequest{Database: database})
+			},
+			result: &models.RawQueryResult{Series: []models.RawSeries{{ShardID: 1}}},
+		},
+		{
+			name: "GetDiskUsage",
+			call: func(database string) (interface{}, error) {
+				return cli.GetDiskUsage(ctx, &models.DiskUsageRequest{Database: database})
+			},
+			result: []models.DatabaseDiskUsage{{Database: "db", Bytes: 100}},
+		},
+		{
+			name: "WarmUp",
+			call: func(database string) (interface{}, error) {
+				return cli.WarmUp(ctx, &models.WarmUpRequest{Database: database})
+			},
+			result: []models.WarmUpResult{{Index: true}, {ShardID: 1}},
+		},
+		{
+			name: "ExportFamily",
+			call: func(database string) (interface{}, error) {
+				return cli.ExportFamily(ctx, &models.FamilyExportRequest{Database: database})
+			},
+			result: &models.FamilyExport{Next: 10},
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := tc.call("db")
+			assert.Nil(t, err)
+			assert.Equal(t, tc.result, result)
+
+			_, err = tc.call("err")
+			assert.NotNil(t, err)
+			_, err = tc.call("bad")
+			assert.NotNil(t, err)
+		})
+	}
+}
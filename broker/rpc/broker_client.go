@@ -2,36 +2,46 @@ package rpc
 
 import (
 	"context"
-	"time"
+	"encoding/json"
+	"fmt"
 
 	"google.golang.org/grpc"
 
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/rpc"
 	"github.com/eleme/lindb/rpc/proto/broker"
 	"github.com/eleme/lindb/rpc/proto/common"
 )
 
 type BrokerClient interface {
 	Init() error
-	WritePoints(request *common.Request) (*common.Response, error)
+	// WritePoints writes the points to broker, the timeout of request is the deadline of context
+	WritePoints(ctx context.Context, request *common.Request) (*common.Response, error)
+	// SubscribeChanges subscribes the change stream of broker, the subscription is canceled by the context
+	SubscribeChanges(ctx context.Context, subscription models.ChangeSubscription) (ChangeReceiver, error)
 	Close() error
 }
 
+// ChangeReceiver receives the batches from the change stream of broker
+type ChangeReceiver interface {
+	// Recv blocks until the next batch is received, returns io.EOF if the stream is closed by broker
+	Recv() (*models.ChangeBatch, error)
+}
+
 type brokerClient struct {
 	conn    *grpc.ClientConn
 	client  broker.BrokerServiceClient
 	address string
-	timeout time.Duration
 }
 
-func NewBrokerClient(address string, timeout time.Duration) BrokerClient {
+func NewBrokerClient(address string) BrokerClient {
 	return &brokerClient{
 		address: address,
-		timeout: timeout,
 	}
 }
 
 func (bc *brokerClient) Init() error {
-	conn, err := grpc.Dial(bc.address, grpc.WithInsecure())
+	conn, err := grpc.Dial(bc.address, grpc.WithInsecure(), rpc.DefaultProtocol.DialOption())
 	if err != nil {
 		return err
 	}
@@ -42,15 +52,44 @@ func (bc *brokerClient) Init() error {
 	return nil
 }
 
-func (bc *brokerClient) WritePoints(request *common.Request) (*common.Response, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), bc.timeout)
-	defer cancel()
+func (bc *brokerClient) WritePoints(ctx context.Context, request *common.Request) (*common.Response, error) {
 	return bc.client.WritePoints(ctx, request)
 }
 
+func (bc *brokerClient) SubscribeChanges(ctx context.Context,
+	subscription models.ChangeSubscription) (ChangeReceiver, error) {
+	data, err := json.Marshal(subscription)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := bc.client.SubscribeChanges(ctx, &common.Request{Data: data})
+	if err != nil {
+		return nil, err
+	}
+	return &changeReceiver{stream: stream}, nil
+}
+
 func (bc *brokerClient) Close() error {
 	if bc.conn != nil {
 		return bc.conn.Close()
 	}
 	return nil
 }
+
+// changeReceiver implements ChangeReceiver, decodes the batches from grpc stream
+type changeReceiver struct {
+	stream broker.BrokerService_SubscribeChangesClient
+}
+
+// Recv receives the next batch from grpc stream
+func (r *changeReceiver) Recv() (*models.ChangeBatch, error) {
+	resp, err := r.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	batch := &models.ChangeBatch{}
+	if err := json.Unmarshal(resp.Data, batch); err != nil {
+		return nil, fmt.Errorf("unmarshal change batch error:%s", err)
+	}
+	return batch, nil
+}
@@ -2,10 +2,14 @@ package rpc
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net"
 
 	"google.golang.org/grpc"
 
+	"github.com/eleme/lindb/broker/cdc"
+	"github.com/eleme/lindb/models"
 	"github.com/eleme/lindb/pkg/logger"
 	"github.com/eleme/lindb/rpc"
 	"github.com/eleme/lindb/rpc/proto/broker"
@@ -20,12 +24,16 @@ type BrokerServer interface {
 type brokerSever struct {
 	bindAddress string
 	gs          *grpc.Server
-	logger      *logger.Logger
+	// changes is the change stream served to the external subscribers, nil means not supported
+	changes cdc.Stream
+	logger  *logger.Logger
 }
 
-func NewBrokerServer(bindAddress string) BrokerServer {
+// NewBrokerServer creates the broker grpc server, the change stream is served if it isn't nil
+func NewBrokerServer(bindAddress string, changes cdc.Stream) BrokerServer {
 	return &brokerSever{
 		bindAddress: bindAddress,
+		changes:     changes,
 		logger:      logger.GetLogger("broker/rpc"),
 	}
 }
@@ -50,6 +58,43 @@ func (bs *brokerSever) WritePoints(ctx context.Context, request *common.Request)
 	return rpc.ResponseOK(), nil
 }
 
+// SubscribeChanges subscribes the change stream by the subscription of request,
+// then sends the committed batches to the subscriber until the subscriber disconnected or the stream closed
+func (bs *brokerSever) SubscribeChanges(request *common.Request,
+	srv broker.BrokerService_SubscribeChangesServer) error {
+	if bs.changes == nil {
+		return fmt.Errorf("change stream isn't enabled")
+	}
+	subscription := models.ChangeSubscription{}
+	if len(request.Data) > 0 {
+		if err := json.Unmarshal(request.Data, &subscription); err != nil {
+			return fmt.Errorf("unmarshal change subscription error:%s", err)
+		}
+	}
+	subscriber := bs.changes.Subscribe(subscription)
+	defer subscriber.Close()
+	bs.logger.Info("change stream subscribed", logger.Any("subscription", subscription))
+	for {
+		select {
+		case batch, ok := <-subscriber.Batches():
+			if !ok {
+				return nil
+			}
+			data, err := json.Marshal(batch)
+			if err != nil {
+				return fmt.Errorf("marshal change batch error:%s", err)
+			}
+			if err := srv.Send(&common.Response{Data: data}); err != nil {
+				return err
+			}
+		case <-srv.Context().Done():
+			bs.logger.Info("change stream unsubscribed", logger.Any("subscription", subscription),
+				logger.Any("dropped", subscriber.Dropped()))
+			return nil
+		}
+	}
+}
+
 func (bs *brokerSever) Close() {
 	if bs.gs != nil {
 		bs.gs.Stop()
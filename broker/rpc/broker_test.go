@@ -1,11 +1,14 @@
 package rpc
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"gopkg.in/check.v1"
 
+	"github.com/eleme/lindb/broker/cdc"
+	"github.com/eleme/lindb/models"
 	"github.com/eleme/lindb/pkg/logger"
 	"github.com/eleme/lindb/rpc"
 	"github.com/eleme/lindb/rpc/proto/common"
@@ -17,11 +20,15 @@ const (
 )
 
 type brokerTestSuite struct {
-	bs BrokerServer
+	bs      BrokerServer
+	changes cdc.Stream
 }
 
+var changes = cdc.NewStream(0)
+
 var _ = check.Suite(&brokerTestSuite{
-	bs: NewBrokerServer(bindAddress),
+	bs:      NewBrokerServer(bindAddress, changes),
+	changes: changes,
 })
 
 func Test(t *testing.T) {
@@ -44,13 +51,15 @@ func (ts *brokerTestSuite) TearDownSuite(c *check.C) {
 }
 
 func (ts *brokerTestSuite) TestWritePoints(c *check.C) {
-	cli := NewBrokerClient(bindAddress, timeout)
+	cli := NewBrokerClient(bindAddress)
 
 	err := cli.Init()
 
 	c.Assert(err, check.IsNil)
 
-	resp, err := cli.WritePoints(&common.Request{
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	resp, err := cli.WritePoints(ctx, &common.Request{
 		Data: []byte("hello"),
 	})
 
@@ -62,3 +71,44 @@ func (ts *brokerTestSuite) TestWritePoints(c *check.C) {
 	c.Assert(err, check.IsNil)
 
 }
+
+func (ts *brokerTestSuite) TestSubscribeChanges(c *check.C) {
+	cli := NewBrokerClient(bindAddress)
+	c.Assert(cli.Init(), check.IsNil)
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	receiver, err := cli.SubscribeChanges(ctx, models.ChangeSubscription{Database: "db"})
+	c.Assert(err, check.IsNil)
+	// publishes until the subscription is registered by server
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+				ts.changes.Publish("db2", nil, nil)
+				ts.changes.Publish("db", nil, nil)
+			}
+		}
+	}()
+	batch, err := receiver.Recv()
+	c.Assert(err, check.IsNil)
+	c.Assert(batch.Database, check.Equals, "db")
+	c.Assert(batch.Sequence > 0, check.Equals, true)
+	cancel()
+	_, err = receiver.Recv()
+	c.Assert(err, check.NotNil)
+
+	// bad subscription
+	bs := NewBrokerServer(bindAddress, changes).(*brokerSever)
+	c.Assert(bs.SubscribeChanges(&common.Request{Data: []byte("bad")}, nil), check.NotNil)
+	// change stream isn't enabled
+	bs = NewBrokerServer(bindAddress, nil).(*brokerSever)
+	c.Assert(bs.SubscribeChanges(&common.Request{}, nil), check.NotNil)
+}
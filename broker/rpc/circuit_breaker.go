@@ -0,0 +1,204 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/pkg/logger"
+)
+
+// CircuitState represents the state of circuit breaker of storage node
+type CircuitState int
+
+// Defines the states of circuit breaker
+const (
+	// CircuitClosed means the node is healthy, the requests are sent to it
+	CircuitClosed CircuitState = iota
+	// CircuitOpen means the node is suspect after consecutive failures, the requests are shipped to other replicas
+	CircuitOpen
+	// CircuitHalfOpen means the open timeout passes, a probe request is sent to check if the node recovers
+	CircuitHalfOpen
+)
+
+// String returns the name of state
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+var circuitOpened = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "lindb",
+	Subsystem: "broker_rpc",
+	Name:      "circuit_opened_total",
+	Help:      "The number of times the circuit breaker of storage node is opened, by node.",
+}, []string{"node"})
+
+func init() {
+	prometheus.MustRegister(circuitOpened)
+}
+
+// CircuitBreakers represents the circuit breakers of storage nodes which broker fans out requests to,
+// the node is marked suspect after consecutive failures, then the requests are shipped to other replicas
+// instead of waiting for timeout of the dead node. After open timeout, a probe request re-closes the circuit
+// if succeeds, otherwise the circuit is open again.
+type CircuitBreakers interface {
+	// Allow returns if the request can be sent to the node, a probe is allowed if the open timeout passes
+	Allow(node string) bool
+	// Success records the success of request to the node, closes the circuit
+	Success(node string)
+	// Failure records the failure of request to the node, opens the circuit after consecutive failures
+	Failure(node string)
+	// State returns the circuit state of node
+	State(node string) CircuitState
+}
+
+// circuit represents the circuit breaker of a storage node
+type circuit struct {
+	state    CircuitState
+	failures int
+	openedAt time.Time
+}
+
+// circuitBreakers implements CircuitBreakers
+type circuitBreakers struct {
+	failureThreshold int
+	openTimeout      time.Duration
+
+	mutex    sync.Mutex
+	circuits map[string]*circuit
+
+	logger *logger.Logger
+}
+
+// NewCircuitBreakers creates the circuit breakers of storage nodes, the circuit is never opened if threshold <= 0
+func NewCircuitBreakers(cfg config.CircuitBreaker) CircuitBreakers {
+	return &circuitBreakers{
+		failureThreshold: cfg.FailureThreshold,
+		openTimeout:      time.Duration(cfg.OpenTimeout) * time.Millisecond,
+		circuits:         make(map[string]*circuit),
+		logger:           logger.GetLogger("broker/rpc/circuit"),
+	}
+}
+
+// Allow returns if the request can be sent to the node, only one probe is allowed in open timeout when half open
+func (b *circuitBreakers) Allow(node string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	c, ok := b.circuits[node]
+	if !ok {
+		return true
+	}
+	switch c.state {
+	case CircuitOpen:
+		if time.Since(c.openedAt) < b.openTimeout {
+			return false
+		}
+		c.state = CircuitHalfOpen
+		c.openedAt = time.Now()
+		b.logger.Info("probe suspect storage node", logger.String("node", node))
+		return true
+	case CircuitHalfOpen:
+		// the probe is in flight, allows another probe if the result of probe isn't recorded in open timeout
+		if time.Since(c.openedAt) < b.openTimeout {
+			return false
+		}
+		c.openedAt = time.Now()
+		return true
+	default:
+		return true
+	}
+}
+
+// Success records the success of request to the node, closes the circuit
+func (b *circuitBreakers) Success(node string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	c, ok := b.circuits[node]
+	if !ok {
+		return
+	}
+	if c.state != CircuitClosed {
+		b.logger.Info("storage node recovers, close circuit", logger.String("node", node))
+	}
+	delete(b.circuits, node)
+}
+
+// Failure records the failure of request to the node, opens the circuit after consecutive failures
+// or the failure of probe
+func (b *circuitBreakers) Failure(node string) {
+	if b.failureThreshold <= 0 {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	c, ok := b.circuits[node]
+	if !ok {
+		c = &circuit{}
+		b.circuits[node] = c
+	}
+	c.failures++
+	if c.state == CircuitHalfOpen || (c.state == CircuitClosed && c.failures >= b.failureThreshold) {
+		c.state = CircuitOpen
+		c.openedAt = time.Now()
+		circuitOpened.WithLabelValues(node).Inc()
+		b.logger.Warn("storage node is suspect, open circuit",
+			logger.String("node", node), logger.Any("failures", c.failures))
+	}
+}
+
+// State returns the circuit state of node
+func (b *circuitBreakers) State(node string) CircuitState {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	c, ok := b.circuits[node]
+	if !ok {
+		return CircuitClosed
+	}
+	return c.state
+}
+
+// IsNodeFailure checks if the error means the storage node cannot serve(e.g. unreachable, timeout),
+// the errors returned by storage node for the request don't count as the failures of node.
+// The wrapped errors(see errors.Wrapf) are unwrapped until the cause is found.
+func IsNodeFailure(err error) bool {
+	for err != nil {
+		if isNodeFailure(err) {
+			return true
+		}
+		wrapped, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			return false
+		}
+		err = wrapped.Unwrap()
+	}
+	return false
+}
+
+// isNodeFailure checks if the error itself is the failure of node
+func isNodeFailure(err error) bool {
+	if err == context.DeadlineExceeded {
+		return true
+	}
+	s, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	switch s.Code() {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,80 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/pkg/errors"
+)
+
+func TestCircuitBreakers(t *testing.T) {
+	b := NewCircuitBreakers(config.CircuitBreaker{FailureThreshold: 2, OpenTimeout: 50})
+	assert.True(t, b.Allow("node1"))
+	b.Success("node1")
+
+	// opens after consecutive failures
+	b.Failure("node1")
+	assert.Equal(t, CircuitClosed, b.State("node1"))
+	assert.True(t, b.Allow("node1"))
+	b.Failure("node1")
+	assert.Equal(t, CircuitOpen, b.State("node1"))
+	assert.False(t, b.Allow("node1"))
+	assert.True(t, b.Allow("node2"))
+
+	// one probe after open timeout, the failure of probe opens circuit again
+	time.Sleep(60 * time.Millisecond)
+	assert.True(t, b.Allow("node1"))
+	assert.Equal(t, CircuitHalfOpen, b.State("node1"))
+	assert.False(t, b.Allow("node1"))
+	b.Failure("node1")
+	assert.Equal(t, CircuitOpen, b.State("node1"))
+	assert.False(t, b.Allow("node1"))
+
+	// the success of probe closes circuit
+	time.Sleep(60 * time.Millisecond)
+	assert.True(t, b.Allow("node1"))
+	b.Success("node1")
+	assert.Equal(t, CircuitClosed, b.State("node1"))
+	assert.True(t, b.Allow("node1"))
+
+	// another probe if the result of probe is lost
+	b.Failure("node1")
+	b.Failure("node1")
+	time.Sleep(60 * time.Millisecond)
+	assert.True(t, b.Allow("node1"))
+	time.Sleep(60 * time.Millisecond)
+	assert.True(t, b.Allow("node1"))
+}
+
+func TestCircuitBreakers_disabled(t *testing.T) {
+	b := NewCircuitBreakers(config.CircuitBreaker{})
+	for i := 0; i < 10; i++ {
+		b.Failure("node1")
+	}
+	assert.True(t, b.Allow("node1"))
+	assert.Equal(t, CircuitClosed, b.State("node1"))
+}
+
+func TestCircuitState_String(t *testing.T) {
+	assert.Equal(t, "closed", CircuitClosed.String())
+	assert.Equal(t, "open", CircuitOpen.String())
+	assert.Equal(t, "half-open", CircuitHalfOpen.String())
+}
+
+func TestIsNodeFailure(t *testing.T) {
+	assert.False(t, IsNodeFailure(nil))
+	assert.False(t, IsNodeFailure(fmt.Errorf("err")))
+	assert.True(t, IsNodeFailure(context.DeadlineExceeded))
+	assert.True(t, IsNodeFailure(status.Error(codes.Unavailable, "err")))
+	assert.True(t, IsNodeFailure(status.Error(codes.DeadlineExceeded, "err")))
+	assert.False(t, IsNodeFailure(status.Error(codes.InvalidArgument, "err")))
+	assert.True(t, IsNodeFailure(errors.Wrapf(status.Error(codes.Unavailable, "err"), "write error")))
+	assert.False(t, IsNodeFailure(errors.Wrapf(errors.ErrEpochMismatch, "write error")))
+}
@@ -0,0 +1,61 @@
+package rpc
+
+import (
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+
+	"github.com/eleme/lindb/rpc"
+)
+
+// ConnPool caches the client connections of storage nodes keyed by address, the connection of node is shared
+// by the clients of the node, so that broker doesn't dial storage node per request.
+type ConnPool interface {
+	// Get returns the shared connection of address, dials it if not exist or has been shut down
+	Get(address string) (*grpc.ClientConn, error)
+	// Close closes all connections of pool
+	Close() error
+}
+
+// connPool implements ConnPool interface
+type connPool struct {
+	conns map[string]*grpc.ClientConn
+	mutex sync.Mutex
+}
+
+// NewConnPool creates the pool of client connections
+func NewConnPool() ConnPool {
+	return &connPool{
+		conns: make(map[string]*grpc.ClientConn),
+	}
+}
+
+// Get returns the shared connection of address, the connection reconnects automatically after transient failure
+func (p *connPool) Get(address string) (*grpc.ClientConn, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if conn, ok := p.conns[address]; ok && conn.GetState() != connectivity.Shutdown {
+		return conn, nil
+	}
+	conn, err := grpc.Dial(address, grpc.WithInsecure(), rpc.DefaultProtocol.DialOption())
+	if err != nil {
+		return nil, err
+	}
+	p.conns[address] = conn
+	return conn, nil
+}
+
+// Close closes all connections of pool
+func (p *connPool) Close() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	var err error
+	for address, conn := range p.conns {
+		if e := conn.Close(); e != nil {
+			err = e
+		}
+		delete(p.conns, address)
+	}
+	return err
+}
@@ -0,0 +1,33 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnPool(t *testing.T) {
+	pool := NewConnPool()
+	conn1, err := pool.Get(adminAddress)
+	assert.Nil(t, err)
+	conn2, err := pool.Get(adminAddress)
+	assert.Nil(t, err)
+	assert.True(t, conn1 == conn2)
+
+	// the pooled client doesn't close the shared connection
+	cli := NewPooledAdminClient(pool, adminAddress)
+	assert.Nil(t, cli.Init())
+	assert.Nil(t, cli.Close())
+	conn3, err := pool.Get(adminAddress)
+	assert.Nil(t, err)
+	assert.True(t, conn1 == conn3)
+
+	// re-dial after the connection shut down
+	_ = conn1.Close()
+	conn4, err := pool.Get(adminAddress)
+	assert.Nil(t, err)
+	assert.False(t, conn1 == conn4)
+
+	assert.Nil(t, pool.Close())
+	assert.Empty(t, pool.(*connPool).conns)
+}
@@ -0,0 +1,157 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/grpc"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/rpc"
+	"github.com/eleme/lindb/rpc/proto/common"
+	"github.com/eleme/lindb/rpc/proto/storage"
+	"github.com/eleme/lindb/tsdb/index"
+)
+
+// MetadataClient represents the client for querying metric metadata from storage node
+type MetadataClient interface {
+	Init() error
+	Suggest(ctx context.Context, req *models.SuggestRequest) ([]string, error)
+	Cardinality(ctx context.Context, req *models.CardinalityRequest) (*index.MetricCardinality, error)
+	WriteEvents(ctx context.Context, req *models.EventWriteRequest) error
+	QueryEvents(ctx context.Context, query *models.EventQuery) ([]models.Event, error)
+	Close() error
+}
+
+type metadataClient struct {
+	conn     *grpc.ClientConn
+	client   storage.MetadataServiceClient
+	address  string
+	connPool ConnPool // shared connections, the connection of pool isn't closed by client
+}
+
+// NewMetadataClient creates the metadata client for given storage node's address,
+// the timeout of request is the deadline of context which is propagated to storage node
+func NewMetadataClient(address string) MetadataClient {
+	return &metadataClient{
+		address: address,
+	}
+}
+
+// NewPooledMetadataClient creates the metadata client for given storage node's address,
+// which uses the shared connection of pool
+func NewPooledMetadataClient(connPool ConnPool, address string) MetadataClient {
+	return &metadataClient{
+		address:  address,
+		connPool: connPool,
+	}
+}
+
+func (mc *metadataClient) Init() error {
+	var (
+		conn *grpc.ClientConn
+		err  error
+	)
+	if mc.connPool != nil {
+		conn, err = mc.connPool.Get(mc.address)
+	} else {
+		conn, err = grpc.Dial(mc.address, grpc.WithInsecure(), rpc.DefaultProtocol.DialOption())
+	}
+	if err != nil {
+		return err
+	}
+	mc.conn = conn
+
+	mc.client = storage.NewMetadataServiceClient(conn)
+
+	return nil
+}
+
+// Suggest sends suggest request to storage node, returns the values of suggest result
+func (mc *metadataClient) Suggest(ctx context.Context, req *models.SuggestRequest) ([]string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal suggest request error:%s", err)
+	}
+	resp, err := mc.client.Suggest(ctx, &common.Request{Data: data})
+	if err != nil {
+		return nil, err
+	}
+	if err := rpc.ResponseToError(resp); err != nil {
+		return nil, errors.Wrapf(err, "suggest from storage node[%s] error", mc.address)
+	}
+	result := &models.SuggestResult{}
+	if err := json.Unmarshal(resp.Data, result); err != nil {
+		return nil, fmt.Errorf("unmarshal suggest result error:%s", err)
+	}
+	return result.Values, nil
+}
+
+// Cardinality sends cardinality request to storage node, returns the sketches of metric,
+// returns nil if metric not exist in storage node
+func (mc *metadataClient) Cardinality(ctx context.Context, req *models.CardinalityRequest) (*index.MetricCardinality, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal cardinality request error:%s", err)
+	}
+	resp, err := mc.client.Cardinality(ctx, &common.Request{Data: data})
+	if err != nil {
+		return nil, err
+	}
+	if err := rpc.ResponseToError(resp); err != nil {
+		return nil, errors.Wrapf(err, "get cardinality from storage node[%s] error", mc.address)
+	}
+	if len(resp.Data) == 0 {
+		return nil, nil
+	}
+	result := index.NewMetricCardinality()
+	if err := result.UnmarshalBinary(resp.Data); err != nil {
+		return nil, fmt.Errorf("unmarshal cardinality sketches error:%s", err)
+	}
+	return result, nil
+}
+
+// WriteEvents sends the annotation events to storage node
+func (mc *metadataClient) WriteEvents(ctx context.Context, req *models.EventWriteRequest) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("marshal event write request error:%s", err)
+	}
+	resp, err := mc.client.WriteEvents(ctx, &common.Request{Data: data})
+	if err != nil {
+		return err
+	}
+	if err := rpc.ResponseToError(resp); err != nil {
+		return errors.Wrapf(err, "write events into storage node[%s] error", mc.address)
+	}
+	return nil
+}
+
+// QueryEvents sends event query to storage node, returns the annotation events of query result
+func (mc *metadataClient) QueryEvents(ctx context.Context, query *models.EventQuery) ([]models.Event, error) {
+	data, err := json.Marshal(query)
+	if err != nil {
+		return nil, fmt.Errorf("marshal event query error:%s", err)
+	}
+	resp, err := mc.client.QueryEvents(ctx, &common.Request{Data: data})
+	if err != nil {
+		return nil, err
+	}
+	if err := rpc.ResponseToError(resp); err != nil {
+		return nil, errors.Wrapf(err, "query events from storage node[%s] error", mc.address)
+	}
+	result := &models.EventResult{}
+	if err := json.Unmarshal(resp.Data, result); err != nil {
+		return nil, fmt.Errorf("unmarshal event result error:%s", err)
+	}
+	return result.Events, nil
+}
+
+func (mc *metadataClient) Close() error {
+	if mc.conn != nil && mc.connPool == nil {
+		return mc.conn.Close()
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/rpc"
+	"github.com/eleme/lindb/rpc/proto/common"
+	"github.com/eleme/lindb/rpc/proto/storage"
+	"github.com/eleme/lindb/tsdb/index"
+)
+
+const metadataAddress = ":9002"
+
+type mockMetadataServer struct {
+}
+
+func (s *mockMetadataServer) Suggest(ctx context.Context, request *common.Request) (*common.Response, error) {
+	req := &models.SuggestRequest{}
+	_ = json.Unmarshal(request.Data, req)
+	switch req.Database {
+	case "err":
+		return rpc.ResponseError("suggest error"), nil
+	case "bad":
+		return rpc.ResponseOKWithData([]byte("bad data")), nil
+	case "not-found":
+		return rpc.ResponseErr(errors.Wrapf(errors.ErrMetricNotFound, "metric: %s", req.MetricName)), nil
+	default:
+		data, _ := json.Marshal(&models.SuggestResult{Values: []string{req.Prefix + "1", req.Prefix + "2"}})
+		return rpc.ResponseOKWithData(data), nil
+	}
+}
+
+func (s *mockMetadataServer) Cardinality(ctx context.Context, request *common.Request) (*common.Response, error) {
+	req := &models.CardinalityRequest{}
+	_ = json.Unmarshal(request.Data, req)
+	switch req.Database {
+	case "err":
+		return rpc.ResponseError("cardinality error"), nil
+	case "bad":
+		return rpc.ResponseOKWithData([]byte{0}), nil
+	case "not-found":
+		return rpc.ResponseOK(), nil
+	default:
+		sketches := index.NewMetricCardinality()
+		sketches.AddSeries(map[string]string{"host": "host-1"})
+		data, _ := sketches.MarshalBinary()
+		return rpc.ResponseOKWithData(data), nil
+	}
+}
+
+func (s *mockMetadataServer) WriteEvents(ctx context.Context, request *common.Request) (*common.Response, error) {
+	req := &models.EventWriteRequest{}
+	_ = json.Unmarshal(request.Data, req)
+	if req.Database == "err" {
+		return rpc.ResponseError("write events error"), nil
+	}
+	return rpc.ResponseOK(), nil
+}
+
+func (s *mockMetadataServer) QueryEvents(ctx context.Context, request *common.Request) (*common.Response, error) {
+	query := &models.EventQuery{}
+	_ = json.Unmarshal(request.Data, query)
+	switch query.Database {
+	case "err":
+		return rpc.ResponseError("query events error"), nil
+	case "bad":
+		return rpc.ResponseOKWithData([]byte("bad data")), nil
+	default:
+		data, _ := json.Marshal(&models.EventResult{Events: []models.Event{{Title: "deploy", StartTime: query.StartTime}}})
+		return rpc.ResponseOKWithData(data), nil
+	}
+}
+
+func TestMetadataClient_Suggest(t *testing.T) {
+	server := rpc.NewTCPServer(metadataAddress)
+	storage.RegisterMetadataServiceServer(server.GetServer(), &mockMetadataServer{})
+	go func() {
+		_ = server.Start()
+	}()
+	defer server.Stop()
+	time.Sleep(100 * time.Millisecond)
+
+	cli := NewMetadataClient(metadataAddress)
+	assert.Nil(t, cli.Init())
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	values, err := cli.Suggest(context.TODO(), &models.SuggestRequest{Database: "db", Prefix: "cpu"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"cpu1", "cpu2"}, values)
+
+	_, err = cli.Suggest(context.TODO(), &models.SuggestRequest{Database: "err"})
+	assert.NotNil(t, err)
+	_, err = cli.Suggest(context.TODO(), &models.SuggestRequest{Database: "bad"})
+	assert.NotNil(t, err)
+	_, err = cli.Suggest(context.TODO(), &models.SuggestRequest{Database: "not-found", MetricName: "cpu"})
+	assert.True(t, errors.Is(err, errors.ErrMetricNotFound))
+	assert.Equal(t, "suggest from storage node[:9002] error:metric: cpu:metric not found", err.Error())
+}
+
+func TestMetadataClient_Cardinality(t *testing.T) {
+	server := rpc.NewTCPServer(metadataAddress)
+	storage.RegisterMetadataServiceServer(server.GetServer(), &mockMetadataServer{})
+	go func() {
+		_ = server.Start()
+	}()
+	defer server.Stop()
+	time.Sleep(100 * time.Millisecond)
+
+	cli := NewMetadataClient(metadataAddress)
+	assert.Nil(t, cli.Init())
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	sketches, err := cli.Cardinality(context.TODO(), &models.CardinalityRequest{Database: "db", MetricName: "cpu"})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), sketches.Series.Count())
+	assert.Equal(t, uint64(1), sketches.TagValues["host"].Count())
+
+	sketches, err = cli.Cardinality(context.TODO(), &models.CardinalityRequest{Database: "not-found", MetricName: "cpu"})
+	assert.Nil(t, err)
+	assert.Nil(t, sketches)
+	_, err = cli.Cardinality(context.TODO(), &models.CardinalityRequest{Database: "err"})
+	assert.NotNil(t, err)
+	_, err = cli.Cardinality(context.TODO(), &models.CardinalityRequest{Database: "bad"})
+	assert.NotNil(t, err)
+}
+
+func TestMetadataClient_Events(t *testing.T) {
+	server := rpc.NewTCPServer(metadataAddress)
+	storage.RegisterMetadataServiceServer(server.GetServer(), &mockMetadataServer{})
+	go func() {
+		_ = server.Start()
+	}()
+	defer server.Stop()
+	time.Sleep(100 * time.Millisecond)
+
+	cli := NewMetadataClient(metadataAddress)
+	assert.Nil(t, cli.Init())
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	assert.Nil(t, cli.WriteEvents(context.TODO(), &models.EventWriteRequest{Database: "db"}))
+	assert.NotNil(t, cli.WriteEvents(context.TODO(), &models.EventWriteRequest{Database: "err"}))
+
+	events, err := cli.QueryEvents(context.TODO(), &models.EventQuery{Database: "db", StartTime: 100})
+	assert.Nil(t, err)
+	assert.Equal(t, []models.Event{{Title: "deploy", StartTime: 100}}, events)
+	_, err = cli.QueryEvents(context.TODO(), &models.EventQuery{Database: "err"})
+	assert.NotNil(t, err)
+	_, err = cli.QueryEvents(context.TODO(), &models.EventQuery{Database: "bad"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,97 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/rpc"
+	aggregationpb "github.com/eleme/lindb/rpc/proto/aggregation"
+	"github.com/eleme/lindb/rpc/proto/common"
+	"github.com/eleme/lindb/rpc/proto/storage"
+)
+
+//go:generate mockgen -source ./query_client.go -destination=./query_client_mock.go -package rpc
+
+// QueryClient represents the client for querying metric data from storage node
+type QueryClient interface {
+	Init() error
+	Query(ctx context.Context, req *models.StorageQueryRequest) (*aggregationpb.PartialResult, error)
+	Close() error
+}
+
+type queryClient struct {
+	conn     *grpc.ClientConn
+	client   storage.QueryServiceClient
+	address  string
+	connPool ConnPool // shared connections, the connection of pool isn't closed by client
+}
+
+// NewQueryClient creates the query client for given storage node's address,
+// the timeout of request is the deadline of context which is propagated to storage node
+func NewQueryClient(address string) QueryClient {
+	return &queryClient{
+		address: address,
+	}
+}
+
+// NewPooledQueryClient creates the query client for given storage node's address,
+// which uses the shared connection of pool
+func NewPooledQueryClient(connPool ConnPool, address string) QueryClient {
+	return &queryClient{
+		address:  address,
+		connPool: connPool,
+	}
+}
+
+func (qc *queryClient) Init() error {
+	var (
+		conn *grpc.ClientConn
+		err  error
+	)
+	if qc.connPool != nil {
+		conn, err = qc.connPool.Get(qc.address)
+	} else {
+		conn, err = grpc.Dial(qc.address, grpc.WithInsecure(), rpc.DefaultProtocol.DialOption())
+	}
+	if err != nil {
+		return err
+	}
+	qc.conn = conn
+
+	qc.client = storage.NewQueryServiceClient(conn)
+
+	return nil
+}
+
+// Query sends the sub query of metric to storage node, returns the partial aggregation result of storage node
+func (qc *queryClient) Query(ctx context.Context, req *models.StorageQueryRequest) (*aggregationpb.PartialResult, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal query request error:%s", err)
+	}
+	resp, err := qc.client.Query(ctx, &common.Request{Data: data})
+	if err != nil {
+		return nil, err
+	}
+	if err := rpc.ResponseToError(resp); err != nil {
+		return nil, errors.Wrapf(err, "query metric from storage node[%s] error", qc.address)
+	}
+	result := &aggregationpb.PartialResult{}
+	if err := proto.Unmarshal(resp.Data, result); err != nil {
+		return nil, fmt.Errorf("unmarshal partial result error:%s", err)
+	}
+	return result, nil
+}
+
+func (qc *queryClient) Close() error {
+	if qc.conn != nil && qc.connPool == nil {
+		return qc.conn.Close()
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/rpc"
+	aggregationpb "github.com/eleme/lindb/rpc/proto/aggregation"
+	"github.com/eleme/lindb/rpc/proto/common"
+	"github.com/eleme/lindb/rpc/proto/storage"
+)
+
+const queryAddress = ":9004"
+
+type mockQueryServer struct {
+}
+
+func (s *mockQueryServer) Query(ctx context.Context, request *common.Request) (*common.Response, error) {
+	req := &models.StorageQueryRequest{}
+	_ = json.Unmarshal(request.Data, req)
+	switch req.Database {
+	case "err":
+		return rpc.ResponseError("query error"), nil
+	case "bad":
+		return rpc.ResponseOKWithData([]byte("bad data")), nil
+	default:
+		data, _ := proto.Marshal(&aggregationpb.PartialResult{MetricName: req.MetricName, Complete: true})
+		return rpc.ResponseOKWithData(data), nil
+	}
+}
+
+func TestQueryClient_Query(t *testing.T) {
+	server := rpc.NewTCPServer(queryAddress)
+	storage.RegisterQueryServiceServer(server.GetServer(), &mockQueryServer{})
+	go func() {
+		_ = server.Start()
+	}()
+	defer server.Stop()
+	time.Sleep(100 * time.Millisecond)
+
+	cli := NewQueryClient(queryAddress)
+	assert.Nil(t, cli.Init())
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	result, err := cli.Query(context.TODO(), &models.StorageQueryRequest{Database: "db", MetricName: "cpu"})
+	assert.Nil(t, err)
+	assert.Equal(t, "cpu", result.MetricName)
+	assert.True(t, result.Complete)
+
+	_, err = cli.Query(context.TODO(), &models.StorageQueryRequest{Database: "err"})
+	assert.NotNil(t, err)
+	_, err = cli.Query(context.TODO(), &models.StorageQueryRequest{Database: "bad"})
+	assert.NotNil(t, err)
+}
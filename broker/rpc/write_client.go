@@ -0,0 +1,78 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/rpc"
+	"github.com/eleme/lindb/rpc/proto/common"
+	"github.com/eleme/lindb/rpc/proto/storage"
+)
+
+//go:generate mockgen -source ./write_client.go -destination=./write_client_mock.go -package rpc
+
+// WriteClient represents the client which replicates the writes of shard to storage node
+type WriteClient interface {
+	Init() error
+	// WritePoints sends the write of shard to storage node, the timeout of request is the deadline of context,
+	// returns the sequence token of shard after the write applied by storage node,
+	// returns the error of storage node if the write is rejected(e.g. routing epoch mismatch)
+	WritePoints(ctx context.Context, write *models.ShardWrite) (models.SequenceToken, error)
+	Close() error
+}
+
+type writeClient struct {
+	conn     *grpc.ClientConn
+	client   storage.WriteServiceClient
+	address  string
+	connPool ConnPool // shared connections, the connection of pool isn't closed by client
+}
+
+// NewPooledWriteClient creates the write client for given storage node's address,
+// which uses the shared connection of pool
+func NewPooledWriteClient(connPool ConnPool, address string) WriteClient {
+	return &writeClient{
+		address:  address,
+		connPool: connPool,
+	}
+}
+
+func (wc *writeClient) Init() error {
+	conn, err := wc.connPool.Get(wc.address)
+	if err != nil {
+		return err
+	}
+	wc.conn = conn
+
+	wc.client = storage.NewWriteServiceClient(conn)
+
+	return nil
+}
+
+// WritePoints sends the write of shard to storage node, the sequence token is the data of response
+func (wc *writeClient) WritePoints(ctx context.Context, write *models.ShardWrite) (models.SequenceToken, error) {
+	data, err := write.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("marshal shard write error:%s", err)
+	}
+	resp, err := wc.client.WritePoints(ctx, &common.Request{Data: data})
+	if err != nil {
+		return nil, err
+	}
+	if err := rpc.ResponseToError(resp); err != nil {
+		return nil, errors.Wrapf(err, "write points to storage node[%s] error", wc.address)
+	}
+	token, err := models.ParseSequenceToken(string(resp.Data))
+	if err != nil {
+		return nil, fmt.Errorf("parse sequence token of storage node[%s] error:%s", wc.address, err)
+	}
+	return token, nil
+}
+
+func (wc *writeClient) Close() error {
+	return nil
+}
@@ -0,0 +1,66 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/rpc"
+	"github.com/eleme/lindb/rpc/proto/common"
+	"github.com/eleme/lindb/rpc/proto/storage"
+)
+
+const writeAddress = ":9005"
+
+type mockWriteServer struct {
+	writes []*models.ShardWrite
+}
+
+func (s *mockWriteServer) WritePoints(ctx context.Context, request *common.Request) (*common.Response, error) {
+	write, err := models.UnmarshalShardWrite(request.Data)
+	if err != nil {
+		return rpc.ResponseErr(err), nil
+	}
+	if write.Database == "err" {
+		return rpc.ResponseError("write error"), nil
+	}
+	if write.Database == "bad_token" {
+		return rpc.ResponseOKWithData([]byte("bad")), nil
+	}
+	s.writes = append(s.writes, write)
+	return rpc.ResponseOKWithData([]byte(models.SequenceToken{write.ShardID: int64(len(s.writes))}.String())), nil
+}
+
+func TestWriteClient(t *testing.T) {
+	server := rpc.NewTCPServer(writeAddress)
+	writeServer := &mockWriteServer{}
+	storage.RegisterWriteServiceServer(server.GetServer(), writeServer)
+	go func() {
+		_ = server.Start()
+	}()
+	defer server.Stop()
+	time.Sleep(100 * time.Millisecond)
+
+	connPool := NewConnPool()
+	defer func() {
+		_ = connPool.Close()
+	}()
+	cli := NewPooledWriteClient(connPool, writeAddress)
+	assert.Nil(t, cli.Init())
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	write := &models.ShardWrite{Database: "db", ShardID: 1, Writes: [][]byte{[]byte("points")}}
+	token, err := cli.WritePoints(context.TODO(), write)
+	assert.Nil(t, err)
+	assert.Equal(t, models.SequenceToken{1: 1}, token)
+	assert.Equal(t, []*models.ShardWrite{write}, writeServer.writes)
+	_, err = cli.WritePoints(context.TODO(), &models.ShardWrite{Database: "err"})
+	assert.NotNil(t, err)
+	_, err = cli.WritePoints(context.TODO(), &models.ShardWrite{Database: "bad_token"})
+	assert.NotNil(t, err)
+}
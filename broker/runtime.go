@@ -5,20 +5,35 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"sync/atomic"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
 	"github.com/eleme/lindb/broker/api"
 	"github.com/eleme/lindb/broker/api/admin"
+	"github.com/eleme/lindb/broker/api/grafana"
+	"github.com/eleme/lindb/broker/api/metadata"
+	brokerQuery "github.com/eleme/lindb/broker/api/query"
+	"github.com/eleme/lindb/broker/api/write"
+	"github.com/eleme/lindb/broker/cdc"
+	"github.com/eleme/lindb/broker/ingestion"
 	"github.com/eleme/lindb/broker/middleware"
+	"github.com/eleme/lindb/broker/replication"
+	"github.com/eleme/lindb/broker/rpc"
 	"github.com/eleme/lindb/config"
-	"github.com/eleme/lindb/constants"
 	"github.com/eleme/lindb/coordinator"
 	"github.com/eleme/lindb/coordinator/discovery"
 	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/backup"
 	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/pathutil"
+	"github.com/eleme/lindb/pkg/resource"
 	"github.com/eleme/lindb/pkg/server"
 	"github.com/eleme/lindb/pkg/state"
+	"github.com/eleme/lindb/pkg/tracing"
 	"github.com/eleme/lindb/pkg/util"
+	"github.com/eleme/lindb/query"
 	"github.com/eleme/lindb/service"
 )
 
@@ -26,21 +41,68 @@ const (
 	cfgName = "broker.toml"
 	// DefaultBrokerCfgFile defines broker default config file path
 	DefaultBrokerCfgFile = "./" + cfgName
+	// healthCheckTimeout is the timeout of checking the connection of state repository
+	healthCheckTimeout = 2 * time.Second
 )
 
 type srv struct {
 	storageClusterService service.StorageClusterService
+	storageClusterRepos   service.StorageClusterRepos
+	connPool              rpc.ConnPool
 	databaseService       service.DatabaseService
+	metadataService       service.MetadataService
+	metricStoreService    service.MetricStoreService
+	databaseLimitsService service.DatabaseLimitsService
+	shardStateService     service.ShardStateService
+	rawQueryService       service.RawQueryService
+	fetcher               query.Fetcher
+	diskUsageService      service.DiskUsageService
+	warmUpService         service.WarmUpService
+	queryBlacklistService service.QueryBlacklistService
+	queryBlacklist        *service.QueryBlacklist
+	queryTracker          query.Tracker
+	queryAuthorizer       query.Authorizer
+	udfService            service.UDFService
+	udfRegistry           *service.UDFRegistry
+	auditService          service.AuditService
+	statsService          service.DatabaseStatsService
+	statsRecorder         service.DatabaseStatsRecorder
+	deadLetter            ingestion.DeadLetterQueue
+	writeTracer           tracing.Tracer
+	// replicationWriter replicates the batches of shards to storage nodes, closed before the connections
+	replicationWriter replication.Writer
+	// writer applies the write rules of databases, then replicates the points to storage nodes
+	writer *ingestion.FilterWriter
+	// changeStream publishes the committed write batches to subscribers, nil if change data capture is disabled
+	changeStream cdc.Stream
 }
 
 type apiHandler struct {
 	storageClusterAPI *admin.StorageClusterAPI
 	databaseAPI       *admin.DatabaseAPI
 	loginAPI          *api.LoginAPI
+	metadataAPI       *metadata.MetadataAPI
+	queryAPI          *brokerQuery.QueryAPI
+	rawQueryAPI       *brokerQuery.RawQueryAPI
+	grafanaAPI        *grafana.GrafanaAPI
+	backupAPI         *admin.BackupAPI
+	auditAPI          *admin.AuditAPI
+	metricStoreAPI    *admin.MetricStoreAPI
+	databaseLimitsAPI *admin.DatabaseLimitsAPI
+	shardStateAPI     *admin.ShardStateAPI
+	statsAPI          *admin.StatsAPI
+	diskUsageAPI      *admin.DiskUsageAPI
+	warmUpAPI         *admin.WarmUpAPI
+	queryControlAPI   *admin.QueryControlAPI
+	udfAPI            *admin.UDFAPI
+	deadLetterAPI     *write.DeadLetterAPI
+	writeTraceAPI     *write.TraceAPI
+	writeAPI          *write.WriteAPI
 }
 
 type middlewareHandler struct {
 	authentication *middleware.UserAuthentication
+	queryQueue     *middleware.QueryQueue
 }
 
 // runtime represents broker runtime dependency
@@ -53,8 +115,20 @@ type runtime struct {
 	repo       state.Repository
 	srv        srv
 	httpServer *http.Server
+	pprof      *server.PProfServer
 	master     coordinator.Master
 	registry   discovery.Registry
+	health     *server.Health
+	// databaseConfigs watches the database configs, the write rules of databases are applied by writer
+	databaseConfigs discovery.Discovery
+	// rpcServer serves the change stream to external subscribers
+	rpcServer rpc.BrokerServer
+	// udpListener receives the points of line protocol by udp if enabled
+	udpListener ingestion.UDPListener
+	// statsdServer receives the statsd metrics if enabled, the aggregated metrics are flushed when closed
+	statsdServer ingestion.StatsDServer
+	// ready is 1 after the broker completes startup, 0 when stopping
+	ready int32
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -89,7 +163,15 @@ func (r *runtime) Run() error {
 		r.state = server.Failed
 		return fmt.Errorf("decode config file error:%s", err)
 	}
+	if err := logger.InitLogger(r.config.Logging); err != nil {
+		r.state = server.Failed
+		return fmt.Errorf("init logger error:%s", err)
+	}
 	r.log.Info("load broker config from file successfully", logger.String("config", r.cfgPath))
+	// adjust GOMAXPROCS by the cpu quota of container
+	limits := resource.DetectLimits()
+	r.log.Info("apply resource limits", logger.Any("cpu", limits.CPU), logger.Any("memory", limits.Memory),
+		logger.Any("gomaxprocs", resource.AdjustMaxProcs(limits)))
 
 	ip, err := util.GetHostIP()
 	if err != nil {
@@ -112,20 +194,48 @@ func (r *runtime) Run() error {
 	// start http server
 	r.startHTTPServer()
 
+	// start pprof server if enabled
+	r.startPProfServer()
+
+	// start grpc server of change stream if enabled
+	r.startRPCServer()
+
+	// watch the write rules of databases
+	r.databaseConfigs = discovery.NewDiscovery(r.repo, pathutil.Keys.Databases.Configs.Prefix(), r.srv.writer)
+	if err := r.databaseConfigs.Discovery(); err != nil {
+		return fmt.Errorf("watch database configs error:%s", err)
+	}
+
+	// start udp listener of line protocol if enabled
+	if err := r.startUDPListener(); err != nil {
+		return err
+	}
+	// start statsd server if enabled
+	if err := r.startStatsDServer(); err != nil {
+		return err
+	}
+
 	// register storage node info
 	//TODO TTL default value???
-	r.registry = discovery.NewRegistry(r.repo, constants.ActiveNodesPath, 1)
+	r.registry = discovery.NewRegistry(r.repo, string(pathutil.Keys.Nodes.Active), 1)
 	if err := r.registry.Register(r.node); err != nil {
 		return fmt.Errorf("register storage node error:%s", err)
 	}
 
+	// report the database statistics of node if enabled, which are aggregated by master
+	if r.srv.statsRecorder != nil {
+		go service.ReportDatabaseStats(r.ctx, r.node.Key(), r.srv.statsRecorder, r.srv.statsService,
+			time.Duration(r.config.DatabaseStats.ReportInterval)*time.Millisecond, nil)
+	}
+
 	//TODO config ttl
-	r.master = coordinator.NewMaster(r.repo, r.node, 1)
+	r.master = coordinator.NewMaster(r.repo, r.node, 1, r.config.Backup, r.config.DatabaseStats)
 	if err := r.master.Start(); err != nil {
 		return fmt.Errorf("start master error:%s", err)
 	}
 
 	r.state = server.Running
+	atomic.StoreInt32(&r.ready, 1)
 	return nil
 }
 
@@ -138,6 +248,8 @@ func (r *runtime) State() server.State {
 func (r *runtime) Stop() error {
 	r.log.Info("stopping broker server.....")
 	defer r.cancel()
+	// not ready first, so that orchestrators stop routing requests to this node
+	atomic.StoreInt32(&r.ready, 0)
 
 	if r.master != nil {
 		r.master.Stop()
@@ -150,6 +262,56 @@ func (r *runtime) Stop() error {
 		}
 	}
 
+	if r.udpListener != nil {
+		r.log.Info("stopping udp listener")
+		if err := r.udpListener.Close(); err != nil {
+			r.log.Error("stop udp listener error", logger.Error(err))
+		}
+	}
+
+	if r.statsdServer != nil {
+		r.log.Info("stopping statsd server")
+		if err := r.statsdServer.Close(); err != nil {
+			r.log.Error("stop statsd server error", logger.Error(err))
+		}
+	}
+
+	if r.rpcServer != nil {
+		r.log.Info("stopping grpc server")
+		r.rpcServer.Close()
+	}
+	if r.srv.changeStream != nil {
+		r.srv.changeStream.Close()
+	}
+
+	if r.pprof != nil {
+		r.log.Info("stopping pprof server")
+		if err := r.pprof.Stop(); err != nil {
+			r.log.Error("stop pprof server error", logger.Error(err))
+		}
+	}
+
+	if r.srv.replicationWriter != nil {
+		r.log.Info("flushing pending write batches")
+		if err := r.srv.replicationWriter.Close(); err != nil {
+			r.log.Error("flush pending write batches error", logger.Error(err))
+		}
+	}
+
+	if r.srv.connPool != nil {
+		r.log.Info("closing connections of storage nodes")
+		if err := r.srv.connPool.Close(); err != nil {
+			r.log.Error("close connections of storage nodes error", logger.Error(err))
+		}
+	}
+
+	if r.srv.storageClusterRepos != nil {
+		r.log.Info("closing state repos of storage clusters")
+		if err := r.srv.storageClusterRepos.Close(); err != nil {
+			r.log.Error("close state repos of storage clusters error", logger.Error(err))
+		}
+	}
+
 	if r.repo != nil {
 		r.log.Info("closing state repo")
 		if err := r.repo.Close(); err != nil {
@@ -157,11 +319,31 @@ func (r *runtime) Stop() error {
 		}
 	}
 
+	if r.srv.deadLetter != nil {
+		if err := r.srv.deadLetter.Close(); err != nil {
+			r.log.Error("close dead letter queue error", logger.Error(err))
+		}
+	}
+
 	r.log.Info("broker server stop complete")
 	r.state = server.Terminated
 	return nil
 }
 
+// startRPCServer starts the grpc server serving the change stream to external subscribers if enabled
+func (r *runtime) startRPCServer() {
+	if r.srv.changeStream == nil || r.config.CDC.Port == 0 {
+		return
+	}
+	r.rpcServer = rpc.NewBrokerServer(fmt.Sprintf(":%d", r.config.CDC.Port), r.srv.changeStream)
+	r.log.Info("starting grpc server of change stream", logger.Uint16("port", r.config.CDC.Port))
+	go func() {
+		if err := r.rpcServer.Start(); err != nil {
+			r.log.Error("start grpc server of change stream error", logger.Error(err))
+		}
+	}()
+}
+
 // startHTTPServer starts http server for api handler
 func (r *runtime) startHTTPServer() {
 	port := r.config.HTTP.Port
@@ -192,24 +374,122 @@ func (r *runtime) startStateRepo() error {
 	}
 	r.repo = repo
 	r.log.Info("start broker state repository successfully")
+	clusterInfo, err := service.NewClusterInfoService(repo).Bootstrap(r.config.Coordinator.ClusterID)
+	if err != nil {
+		return fmt.Errorf("bootstrap cluster error:%s", err)
+	}
+	r.log.Info("join cluster successfully", logger.Any("cluster", clusterInfo))
 	return nil
 }
 
 // buildServiceDependency builds broker service dependency
 func (r *runtime) buildServiceDependency() {
+	storageClusterService := service.NewStorageClusterService(r.repo)
+	databaseService := service.NewDatabaseService(r.repo)
+	// the state repos of storage clusters are connected once, then shared by the requests
+	storageClusterRepos := service.NewStorageClusterRepos()
+	// the connections of storage nodes are shared by the requests fanned out by broker
+	connPool := rpc.NewConnPool()
+	// the circuit breakers of storage nodes which broker fans out requests to
+	circuitBreakers := rpc.NewCircuitBreakers(r.config.CircuitBreaker)
+	// the routing cache is filled by the watches of state repos, the requests are routed by it,
+	// the snapshot serves the shard routing while the watches are re-established if enabled
+	snapshotPath := ""
+	if r.config.RoutingCache.Enabled {
+		snapshotPath = r.config.RoutingCache.Path
+	}
+	routingCache := service.NewRoutingCache(snapshotPath)
+	if err := routingCache.Load(); err != nil {
+		r.log.Error("load routing snapshot error", logger.Error(err))
+	}
+	go routingCache.Watch(r.ctx, r.repo)
+	// the points are coalesced into the batches of shards, then replicated to storage nodes
+	replicationWriter := replication.NewWriter(r.config.Write, r.config.Spill, routingCache, circuitBreakers,
+		connPool, time.Duration(r.config.Timeout.Write)*time.Millisecond)
 	srv := srv{
-		storageClusterService: service.NewStorageClusterService(r.repo),
-		databaseService:       service.NewDatabaseService(r.repo),
+		storageClusterService: storageClusterService,
+		storageClusterRepos:   storageClusterRepos,
+		connPool:              connPool,
+		databaseService:       databaseService,
+		metadataService:       service.NewBrokerMetadataService(routingCache, circuitBreakers, connPool),
+		metricStoreService:    service.NewBrokerMetricStoreService(routingCache, circuitBreakers, connPool),
+		databaseLimitsService: service.NewDatabaseLimitsService(databaseService, storageClusterService,
+			storageClusterRepos),
+		shardStateService: service.NewShardStateService(databaseService, storageClusterService,
+			storageClusterRepos),
+		rawQueryService: service.NewBrokerRawQueryService(routingCache, circuitBreakers, connPool),
+		fetcher:         service.NewStorageFetcher(routingCache, circuitBreakers, connPool),
+		diskUsageService: service.NewBrokerDiskUsageService(storageClusterService, storageClusterRepos,
+			routingCache, circuitBreakers, connPool),
+		warmUpService:         service.NewBrokerWarmUpService(routingCache, circuitBreakers, connPool),
+		statsService:          service.NewDatabaseStatsService(r.repo),
+		queryBlacklistService: service.NewQueryBlacklistService(r.repo),
+		queryBlacklist:        service.NewQueryBlacklist(),
+		queryTracker:          query.NewTracker(),
+		queryAuthorizer:       service.NewQueryAuthorizer(databaseService),
+		udfService:            service.NewUDFService(r.repo),
+		udfRegistry:           service.NewUDFRegistry(service.NewUDFLoader(r.config.UDF.AllowPlugin)),
+		writeTracer:           tracing.NewTracer(r.config.Write.TraceSampling, r.config.Write.SlowWrite),
+		replicationWriter:     replicationWriter,
+	}
+	// the blacklist rules are watched, so that the rules saved by any broker are applied by all brokers
+	go srv.queryBlacklist.Watch(r.ctx, r.repo)
+	// the user defined functions are watched, then loaded from plugins or wasm modules by each broker
+	go srv.udfRegistry.Watch(r.ctx, r.repo)
+	if r.config.DatabaseStats.ReportInterval > 0 {
+		srv.statsRecorder = service.NewDatabaseStatsRecorder()
+	}
+	if r.config.Audit.Enabled {
+		srv.auditService = service.NewAuditService(r.repo, r.config.Audit.MaxLogs)
 	}
+	if r.config.CDC.Enabled {
+		srv.changeStream = cdc.NewStream(r.config.CDC.BufferSize)
+	}
+	if r.config.DeadLetter.Enabled {
+		deadLetter, err := ingestion.NewDeadLetterQueue(r.config.DeadLetter)
+		if err != nil {
+			r.log.Error("create dead letter queue error", logger.Error(err))
+		} else {
+			srv.deadLetter = deadLetter
+		}
+	}
+	// the points replicated durably are published into the change stream if change data capture is enabled,
+	// the points violating the naming policy are recorded into the dead letter queue if enabled
+	var writer ingestion.Writer = replicationWriter
+	if srv.changeStream != nil {
+		writer = cdc.NewWriter(writer, srv.changeStream)
+	}
+	srv.writer = ingestion.NewFilterWriter(writer, srv.deadLetter)
 	r.srv = srv
 }
 
 // buildAPIDependency builds broker api dependency
 func (r *runtime) buildAPIDependency() {
+	// the queries are authorized by the readers of each database, checked by blacklist rules and tracked,
+	// so that the running query can be killed
+	executor := query.NewControlledExecutor(query.NewBrokerExecutor(r.srv.fetcher, r.srv.udfRegistry),
+		r.srv.queryTracker, r.srv.queryBlacklist, r.srv.queryAuthorizer)
 	handler := apiHandler{
-		storageClusterAPI: admin.NewStorageClusterAPI(r.srv.storageClusterService),
-		databaseAPI:       admin.NewDatabaseAPI(r.srv.databaseService),
+		storageClusterAPI: admin.NewStorageClusterAPI(r.srv.storageClusterService, r.srv.auditService),
+		databaseAPI:       admin.NewDatabaseAPI(r.srv.databaseService, r.srv.auditService),
 		loginAPI:          api.NewLoginAPI(r.config.User),
+		metadataAPI:       metadata.NewMetadataAPI(r.srv.metadataService),
+		queryAPI: brokerQuery.NewQueryAPI(executor, r.srv.queryAuthorizer, r.srv.metadataService,
+			r.srv.statsRecorder),
+		rawQueryAPI:       brokerQuery.NewRawQueryAPI(r.srv.rawQueryService, r.srv.statsRecorder),
+		grafanaAPI:        grafana.NewGrafanaAPI(r.srv.metadataService, executor),
+		metricStoreAPI:    admin.NewMetricStoreAPI(r.srv.metricStoreService, r.srv.auditService),
+		databaseLimitsAPI: admin.NewDatabaseLimitsAPI(r.srv.databaseLimitsService, r.srv.auditService),
+		shardStateAPI:     admin.NewShardStateAPI(r.srv.shardStateService, r.srv.auditService),
+		statsAPI:          admin.NewStatsAPI(r.srv.statsService),
+		diskUsageAPI:      admin.NewDiskUsageAPI(r.srv.diskUsageService),
+		warmUpAPI:         admin.NewWarmUpAPI(r.srv.warmUpService),
+		queryControlAPI: admin.NewQueryControlAPI(r.srv.queryTracker, r.srv.queryBlacklistService,
+			r.srv.auditService),
+		udfAPI:        admin.NewUDFAPI(r.srv.udfService, r.srv.auditService),
+		writeTraceAPI: write.NewTraceAPI(r.srv.writeTracer),
+		writeAPI: write.NewWriteAPI(r.srv.writer, r.srv.deadLetter, r.srv.statsRecorder,
+			r.srv.writeTracer),
 	}
 
 	api.AddRoutes("Login", http.MethodPost, "/login", handler.loginAPI.Login)
@@ -219,9 +499,84 @@ func (r *runtime) buildAPIDependency() {
 	api.AddRoutes("GetStorageCluster", http.MethodGet, "/storage/cluster", handler.storageClusterAPI.GetByName)
 	api.AddRoutes("DeleteStorageCluster", http.MethodDelete, "/storage/cluster", handler.storageClusterAPI.DeleteByName)
 	api.AddRoutes("ListStorageClusters", http.MethodGet, "/storage/cluster/list", handler.storageClusterAPI.List)
+	api.AddRoutes("GetDiskUsage", http.MethodGet, "/storage/disk-usage", handler.diskUsageAPI.Get)
 
 	api.AddRoutes("CreateOrUpdateDatabase", http.MethodPost, "/database", handler.databaseAPI.Save)
 	api.AddRoutes("GetDatabase", http.MethodGet, "/database", handler.databaseAPI.GetByName)
+	api.AddRoutes("SaveDatabaseLimits", http.MethodPost, "/database/limits", handler.databaseLimitsAPI.Save)
+	api.AddRoutes("GetDatabaseLimits", http.MethodGet, "/database/limits", handler.databaseLimitsAPI.GetByName)
+	api.AddRoutes("SaveShardStates", http.MethodPost, "/database/shard/state", handler.shardStateAPI.Save)
+	api.AddRoutes("GetShardStates", http.MethodGet, "/database/shard/state", handler.shardStateAPI.GetByName)
+	api.AddRoutes("WarmUpDatabase", http.MethodPost, "/database/warm-up", handler.warmUpAPI.WarmUp)
+
+	api.AddRoutes("ManageMetricStore", http.MethodPost, "/metric/store", handler.metricStoreAPI.Manage)
+
+	api.AddRoutes("ListRunningQueries", http.MethodGet, "/query/running", handler.queryControlAPI.ListRunning)
+	api.AddRoutes("KillQuery", http.MethodDelete, "/query/running", handler.queryControlAPI.Kill)
+	api.AddRoutes("ListQueryBlacklist", http.MethodGet, "/query/blacklist", handler.queryControlAPI.ListBlacklist)
+	api.AddRoutes("SaveQueryBlacklist", http.MethodPost, "/query/blacklist", handler.queryControlAPI.SaveBlacklist)
+	api.AddRoutes("DeleteQueryBlacklist", http.MethodDelete, "/query/blacklist", handler.queryControlAPI.DeleteBlacklist)
+	api.AddRoutes("ListUDFs", http.MethodGet, "/query/udf", handler.udfAPI.List)
+	api.AddRoutes("SaveUDF", http.MethodPost, "/query/udf", handler.udfAPI.Save)
+	api.AddRoutes("DeleteUDF", http.MethodDelete, "/query/udf", handler.udfAPI.Delete)
+
+	api.AddRoutes("ListMetricNames", http.MethodGet, "/metadata/metric/names", handler.metadataAPI.ListMetricNames)
+	api.AddRoutes("ListTagKeys", http.MethodGet, "/metadata/tag/keys", handler.metadataAPI.ListTagKeys)
+	api.AddRoutes("ListTagValues", http.MethodGet, "/metadata/tag/values", handler.metadataAPI.ListTagValues)
+	api.AddRoutes("GetCardinality", http.MethodGet, "/metadata/cardinality", handler.metadataAPI.GetCardinality)
+	api.AddRoutes("WriteEvents", http.MethodPost, "/metadata/events", handler.metadataAPI.WriteEvents)
+	api.AddRoutes("QueryEvents", http.MethodGet, "/metadata/events", handler.metadataAPI.QueryEvents)
+
+	api.AddRoutes("Query", http.MethodGet, "/api/v1/query", handler.queryAPI.Query)
+	api.AddRoutes("QueryByDSL", http.MethodPost, "/api/v1/query", handler.queryAPI.Query)
+	api.AddRoutes("QueryRawPoints", http.MethodPost, "/api/v1/query/raw", handler.rawQueryAPI.Query)
+
+	api.AddRoutes("GetDatabaseStats", http.MethodGet, "/api/v1/stats", handler.statsAPI.Get)
+
+	// the write api is compatible with influxdb, so that the influxdb clients(e.g. telegraf) write by urls
+	// like 'http://broker:9000/api/v1' or 'http://broker:9000'
+	api.AddRoutes("Write", http.MethodPost, "/api/v1/write", handler.writeAPI.Write)
+	api.AddRoutes("WriteCompatible", http.MethodPost, "/write", handler.writeAPI.Write)
+	api.AddRoutes("Ping", http.MethodGet, "/api/v1/ping", handler.writeAPI.Ping)
+	api.AddRoutes("PingCompatible", http.MethodGet, "/ping", handler.writeAPI.Ping)
+	api.AddRoutes("ListWriteTraces", http.MethodGet, "/write/trace", handler.writeTraceAPI.Recent)
+
+	api.AddRoutes("GrafanaTestConnection", http.MethodGet, "/api/v1/grafana/{db}/", handler.grafanaAPI.TestConnection)
+	api.AddRoutes("GrafanaSearch", http.MethodPost, "/api/v1/grafana/{db}/search", handler.grafanaAPI.Search)
+	api.AddRoutes("GrafanaQuery", http.MethodPost, "/api/v1/grafana/{db}/query", handler.grafanaAPI.Query)
+	api.AddRoutes("GrafanaAnnotations", http.MethodPost, "/api/v1/grafana/{db}/annotations", handler.grafanaAPI.Annotations)
+
+	api.AddRoutes("Metrics", http.MethodGet, "/metrics", promhttp.Handler().ServeHTTP)
+
+	r.health = server.NewHealth()
+	r.health.AddReadinessCheck("startup", r.checkStartup)
+	r.health.AddReadinessCheck("etcd", r.checkStateRepo)
+	api.AddRoutes("HealthLive", http.MethodGet, "/health/live", r.health.Live)
+	api.AddRoutes("HealthReady", http.MethodGet, "/health/ready", r.health.Ready)
+
+	// audit log api is available if audit log is enabled
+	if r.srv.auditService != nil {
+		handler.auditAPI = admin.NewAuditAPI(r.srv.auditService)
+		api.AddRoutes("ListAuditLogs", http.MethodGet, "/audit/list", handler.auditAPI.List)
+	}
+
+	// the rejects of dead letter queue can be sampled if dead letter queue is enabled
+	if r.srv.deadLetter != nil {
+		handler.deadLetterAPI = write.NewDeadLetterAPI(r.srv.deadLetter)
+		api.AddRoutes("SampleRejectedPoints", http.MethodGet, "/write/rejects", handler.deadLetterAPI.Sample)
+	}
+
+	// backup set api is available if backup is enabled
+	if r.config.Backup.Enabled {
+		target, err := backup.NewTarget(r.config.Backup.Target)
+		if err != nil {
+			r.log.Error("create backup target error", logger.Error(err))
+			return
+		}
+		handler.backupAPI = admin.NewBackupAPI(backup.NewCatalog(target))
+		api.AddRoutes("ListBackupSets", http.MethodGet, "/backup/list", handler.backupAPI.List)
+		api.AddRoutes("GetBackupSet", http.MethodGet, "/backup", handler.backupAPI.GetByID)
+	}
 }
 
 // buildMiddlewareDependency builds middleware dependency
@@ -229,10 +584,97 @@ func (r *runtime) buildAPIDependency() {
 func (r *runtime) buildMiddlewareDependency() {
 	middlewareHandler := middlewareHandler{
 		authentication: middleware.NewUserAuthentication(r.config.User),
+		queryQueue:     middleware.NewQueryQueue(r.config.QueryQueue),
 	}
 	validate, err := regexp.Compile("/check/*")
 	if err == nil {
 		api.AddMiddleware(middlewareHandler.authentication.ValidateMiddleware, validate)
 	}
+	// the metric store of memory-database is reset/dropped by authenticated operators only
+	api.AddMiddleware(middlewareHandler.authentication.ValidateMiddleware, regexp.MustCompile("^/metric/store$"))
+	// the limits of database are adjusted by authenticated operators only
+	api.AddMiddleware(middlewareHandler.authentication.ValidateMiddleware, regexp.MustCompile("^/database/limits$"))
+	// the states of shards are changed and the shards are warmed by authenticated operators only
+	api.AddMiddleware(middlewareHandler.authentication.ValidateMiddleware,
+		regexp.MustCompile("^/database/(shard/state|warm-up)$"))
+	// the running queries are killed, the query blacklist and the udfs are managed by authenticated operators only
+	api.AddMiddleware(middlewareHandler.authentication.ValidateMiddleware,
+		regexp.MustCompile("^/query/(running|blacklist|udf)$"))
+	// the audit logs of administrative operations are listed by authenticated operators only
+	api.AddMiddleware(middlewareHandler.authentication.ValidateMiddleware, regexp.MustCompile("^/audit/list$"))
+	// the rejected points with the raw lines of users are sampled by authenticated operators only
+	api.AddMiddleware(middlewareHandler.authentication.ValidateMiddleware, regexp.MustCompile("^/write/rejects$"))
+	// the traces of recent writes are listed by authenticated operators only
+	api.AddMiddleware(middlewareHandler.authentication.ValidateMiddleware, regexp.MustCompile("^/write/trace$"))
+	// the raw points are exported by authenticated operators only
+	api.AddMiddleware(middlewareHandler.authentication.ValidateMiddleware, regexp.MustCompile("^/api/v1/query/raw$"))
+	// the historical points are backfilled by authenticated operators only
+	api.AddMiddleware(middlewareHandler.authentication.ValidateMiddlewareIf(write.IsBackfill),
+		regexp.MustCompile("^/(api/v1/)?write$"))
+	// the authenticated user of query is authorized by the readers of database
+	api.AddMiddleware(middlewareHandler.authentication.IdentifyMiddleware,
+		regexp.MustCompile("^/api/v1/(query|grafana/.+/query)$"))
+	// the queries of grafana are dashboard class by default, others are interactive class
+	api.AddMiddleware(middlewareHandler.queryQueue.Admit(middleware.QueryInteractive),
+		regexp.MustCompile("^/api/v1/query(/raw)?$"))
+	api.AddMiddleware(middlewareHandler.queryQueue.Admit(middleware.QueryDashboard),
+		regexp.MustCompile("^/api/v1/grafana/.+/query$"))
+	// the deadline of request context, the timeout middleware is added after query queue so that it wraps the queue,
+	// then the time waiting in the queue is counted in the timeout
+	timeout := r.config.Timeout
+	api.AddMiddleware(middleware.Timeout(time.Duration(timeout.Query)*time.Millisecond),
+		regexp.MustCompile("^/(api/v1/query(/raw)?|api/v1/grafana/.+/(query|search)|metadata/.+)$"))
+	api.AddMiddleware(middleware.Timeout(time.Duration(timeout.Write)*time.Millisecond),
+		regexp.MustCompile("^/(api/v1/)?write$"))
+	api.AddMiddleware(middleware.Timeout(time.Duration(timeout.Admin)*time.Millisecond),
+		regexp.MustCompile("^/(database|storage/cluster|storage/disk-usage|backup|audit|metric/store|query|api/v1/stats)(/.*)?$"))
+
+}
+
+// startPProfServer starts pprof http server for production profiling if enabled
+func (r *runtime) startPProfServer() {
+	if !r.config.PProf.Enabled {
+		return
+	}
+	r.pprof = server.NewPProfServer(fmt.Sprintf(":%d", r.config.PProf.Port))
+	r.pprof.Start()
+}
+
+// startUDPListener starts the udp listener which writes the points of line protocol into the database of config
+func (r *runtime) startUDPListener() error {
+	if !r.config.UDP.Enabled {
+		return nil
+	}
+	r.udpListener = ingestion.NewUDPListener(r.config.UDP, r.srv.writer)
+	if err := r.udpListener.Start(); err != nil {
+		return fmt.Errorf("start udp listener error:%s", err)
+	}
+	return nil
+}
+
+// startStatsDServer starts the statsd server which writes the aggregated metrics into the database of config
+func (r *runtime) startStatsDServer() error {
+	if !r.config.StatsD.Enabled {
+		return nil
+	}
+	r.statsdServer = ingestion.NewStatsDServer(r.config.StatsD, r.srv.writer)
+	if err := r.statsdServer.Start(); err != nil {
+		return fmt.Errorf("start statsd server error:%s", err)
+	}
+	return nil
+}
+
+// checkStartup checks whether the broker completes startup
+func (r *runtime) checkStartup() error {
+	if atomic.LoadInt32(&r.ready) == 0 {
+		return fmt.Errorf("broker is starting or stopping")
+	}
+	return nil
+}
 
+// checkStateRepo checks whether the state repository is reachable
+func (r *runtime) checkStateRepo() error {
+	ctx, cancel := context.WithTimeout(r.ctx, healthCheckTimeout)
+	defer cancel()
+	return state.Ping(ctx, r.repo)
 }
@@ -1,6 +1,10 @@
 package broker
 
 import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -45,6 +49,18 @@ func (ts *testBrokerRuntimeSuite) TestBrokerRun(c *check.C) {
 			Namespace: "/test/broker",
 			Endpoints: ts.Cluster.Endpoints,
 		},
+		UDP: config.UDP{
+			Enabled:  true,
+			Database: "db",
+		},
+		StatsD: config.StatsD{
+			Enabled:       true,
+			UDPAddress:    "127.0.0.1:0",
+			Database:      "db",
+			FlushInterval: 1000,
+		},
+		Audit:      config.Audit{Enabled: true},
+		DeadLetter: config.DeadLetter{Enabled: true},
 	}
 	_ = util.EncodeToml(brokerCfgPath, &cfg)
 	broker = NewBrokerRuntime(brokerCfgPath)
@@ -57,6 +73,52 @@ func (ts *testBrokerRuntimeSuite) TestBrokerRun(c *check.C) {
 
 	c.Assert(server.Running, check.Equals, broker.State())
 
+	// broker is ready after startup
+	resp, err := http.Get("http://127.0.0.1:9999/health/ready")
+	if err != nil {
+		c.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	c.Assert(resp.StatusCode, check.Equals, http.StatusOK)
+	resp, err = http.Get("http://127.0.0.1:9999/health/live")
+	if err != nil {
+		c.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	c.Assert(resp.StatusCode, check.Equals, http.StatusOK)
+	// the ping of influxdb clients
+	resp, err = http.Get("http://127.0.0.1:9999/ping")
+	if err != nil {
+		c.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	c.Assert(resp.StatusCode, check.Equals, http.StatusNoContent)
+	assertUnauthorized := func(method, url string, reqBody io.Reader) {
+		req, _ := http.NewRequest(method, url, reqBody)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			c.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		c.Assert(resp.StatusCode, check.Equals, http.StatusInternalServerError)
+		c.Assert(string(body), check.Matches, ".*authorization.*")
+	}
+	// the udfs are registered by authenticated operators only
+	assertUnauthorized(http.MethodPost, "http://127.0.0.1:9999/query/udf",
+		strings.NewReader(`{"name":"f","plugin":"/tmp/f.so"}`))
+	// the audit logs are listed by authenticated operators only
+	assertUnauthorized(http.MethodGet, "http://127.0.0.1:9999/audit/list", nil)
+	// the rejected points are sampled by authenticated operators only
+	assertUnauthorized(http.MethodGet, "http://127.0.0.1:9999/write/rejects?db=db", nil)
+	// the write traces are listed by authenticated operators only
+	assertUnauthorized(http.MethodGet, "http://127.0.0.1:9999/write/trace?db=db", nil)
+
+	// the udp listener and statsd server are started with the random ports
+	c.Assert(broker.(*runtime).udpListener.Addr(), check.NotNil)
+	c.Assert(broker.(*runtime).statsdServer.UDPAddr(), check.NotNil)
+
 	_ = broker.Stop()
 	c.Assert(server.Terminated, check.Equals, broker.State())
+	c.Assert(broker.(*runtime).checkStartup(), check.NotNil)
 }
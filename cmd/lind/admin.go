@@ -0,0 +1,169 @@
+package lind
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eleme/lindb/broker"
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/state"
+	"github.com/eleme/lindb/pkg/util"
+	"github.com/eleme/lindb/service"
+)
+
+var (
+	adminCfgPath              = ""
+	metadataFile              = ""
+	importOverwrite           = false
+	importSkipShardAssignment = false
+)
+
+// newAdminCmd returns a new admin-cmd
+func newAdminCmd() *cobra.Command {
+	adminCmd := &cobra.Command{
+		Use:   "admin",
+		Short: "The admin tools of LinDB cluster",
+	}
+	adminCmd.PersistentFlags().StringVar(&adminCfgPath, "config", "",
+		fmt.Sprintf("broker config file path, default is %s", broker.DefaultBrokerCfgFile))
+	adminCmd.PersistentFlags().StringVar(&metadataFile, "file", "metadata.json",
+		"the json bundle file of cluster metadata")
+	importMetadataCmd.Flags().BoolVar(&importOverwrite, "overwrite", false,
+		"overwrite the existing metadata")
+	importMetadataCmd.Flags().BoolVar(&importSkipShardAssignment, "skip-shard-assignment", false,
+		"skip shard assignments, master creates new shard assignments based on active nodes")
+	adminCmd.AddCommand(
+		exportMetadataCmd,
+		importMetadataCmd,
+		migrateMetadataCmd,
+	)
+	return adminCmd
+}
+
+// export cluster metadata to json bundle
+var exportMetadataCmd = &cobra.Command{
+	Use:   "export-metadata",
+	Short: "export all cluster metadata(storage clusters/databases/shard assignments) to a json bundle",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withClusterMetadataService(func(srv service.ClusterMetadataService) error {
+			metadata, err := srv.Export()
+			if err != nil {
+				return err
+			}
+			data, err := json.MarshalIndent(metadata, "", "  ")
+			if err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(metadataFile, data, 0644); err != nil {
+				return fmt.Errorf("write metadata file error:%s", err)
+			}
+			fmt.Printf("export cluster metadata to %s successfully\n", metadataFile)
+			return nil
+		})
+	},
+}
+
+// import cluster metadata from json bundle
+var importMetadataCmd = &cobra.Command{
+	Use:   "import-metadata",
+	Short: "import cluster metadata from a json bundle",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := ioutil.ReadFile(metadataFile)
+		if err != nil {
+			return fmt.Errorf("read metadata file error:%s", err)
+		}
+		metadata := &models.ClusterMetadata{}
+		if err := json.Unmarshal(data, metadata); err != nil {
+			return fmt.Errorf("unmarshal metadata file error:%s", err)
+		}
+		return withClusterMetadataService(func(srv service.ClusterMetadataService) error {
+			if err := srv.Import(metadata, service.ImportOption{
+				Overwrite:           importOverwrite,
+				SkipShardAssignment: importSkipShardAssignment,
+			}); err != nil {
+				return err
+			}
+			fmt.Printf("import cluster metadata from %s successfully\n", metadataFile)
+			return nil
+		})
+	},
+}
+
+// migrate the metadata schema of broker and storage clusters to the version of current lind
+var migrateMetadataCmd = &cobra.Command{
+	Use:   "migrate-metadata",
+	Short: "migrate the metadata schema of broker and storage clusters to the version of current lind",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withBrokerRepo(func(repo state.Repository) error {
+			if err := migrateMetadata("broker", repo); err != nil {
+				return err
+			}
+			storageClusters, err := service.NewStorageClusterService(repo).List()
+			if err != nil {
+				return fmt.Errorf("list storage clusters error:%s", err)
+			}
+			for _, storageCluster := range storageClusters {
+				storageRepo, err := state.NewRepo(storageCluster.Config)
+				if err != nil {
+					return fmt.Errorf("new state repository of storage cluster[%s] error:%s", storageCluster.Name, err)
+				}
+				err = migrateMetadata(fmt.Sprintf("storage cluster[%s]", storageCluster.Name), storageRepo)
+				_ = storageRepo.Close()
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	},
+}
+
+// migrateMetadata migrates the metadata schema in the state repository of cluster
+func migrateMetadata(name string, repo state.Repository) error {
+	info, err := service.NewClusterInfoService(repo).Migrate()
+	if err == state.ErrNotExist {
+		fmt.Printf("%s isn't bootstrapped, skip migrating\n", name)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("migrate metadata of %s error:%s", name, err)
+	}
+	fmt.Printf("migrate metadata of %s to schema version %d successfully\n", name, info.SchemaVersion)
+	return nil
+}
+
+// withClusterMetadataService connects broker's state repository based on broker config,
+// then invokes fn with cluster metadata service
+func withClusterMetadataService(fn func(srv service.ClusterMetadataService) error) error {
+	return withBrokerRepo(func(repo state.Repository) error {
+		storageClusterRepos := service.NewStorageClusterRepos()
+		defer func() {
+			_ = storageClusterRepos.Close()
+		}()
+		return fn(service.NewClusterMetadataService(repo, storageClusterRepos))
+	})
+}
+
+// withBrokerRepo connects broker's state repository based on broker config, then invokes fn with the repository
+func withBrokerRepo(fn func(repo state.Repository) error) error {
+	path := adminCfgPath
+	if len(path) == 0 {
+		path = broker.DefaultBrokerCfgFile
+	}
+	cfg := config.Broker{}
+	if err := util.DecodeToml(path, &cfg); err != nil {
+		return fmt.Errorf("decode config file error:%s", err)
+	}
+	repo, err := state.NewRepo(cfg.Coordinator)
+	if err != nil {
+		return fmt.Errorf("new broker state repository error:%s", err)
+	}
+	defer func() {
+		_ = repo.Close()
+	}()
+	return fn(repo)
+}
@@ -0,0 +1,90 @@
+package lind
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eleme/lindb/pkg/bench"
+	"github.com/eleme/lindb/pkg/util"
+)
+
+const defaultBenchCfgFile = "./bench.toml"
+
+var (
+	benchCfgPath  = ""
+	benchDuration = int64(0)
+	benchTimeout  = 5 * time.Second
+)
+
+// newBenchCmd returns a new bench-cmd
+func newBenchCmd() *cobra.Command {
+	benchCmd := &cobra.Command{
+		Use:   "bench",
+		Short: "The load generator of LinDB, writes and queries simulated time series against broker",
+	}
+	benchCmd.PersistentFlags().StringVar(&benchCfgPath, "config", "",
+		fmt.Sprintf("bench workload config file path, default is %s", defaultBenchCfgFile))
+	runBenchCmd.Flags().Int64Var(&benchDuration, "duration", 0,
+		"seconds of running benchmark, overwrite the duration of config")
+	runBenchCmd.Flags().DurationVar(&benchTimeout, "timeout", 5*time.Second,
+		"timeout of each write/query request")
+	benchCmd.AddCommand(
+		runBenchCmd,
+		initializeBenchConfigCmd,
+	)
+	return benchCmd
+}
+
+var runBenchCmd = &cobra.Command{
+	Use:   "run",
+	Short: "runs the workload and reports throughput and latency percentiles",
+	RunE:  runBench,
+}
+
+// initialize workload config for bench
+var initializeBenchConfigCmd = &cobra.Command{
+	Use:   "initialize-config",
+	Short: "initialize a new bench workload config",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		defaultCfg := bench.NewDefaultConfig()
+		return util.EncodeToml(benchCfgFile(), &defaultCfg)
+	},
+}
+
+// runBench runs the workload until duration elapsed or exit signal received
+func runBench(cmd *cobra.Command, args []string) error {
+	cfg := bench.NewDefaultConfig()
+	if len(benchCfgPath) > 0 || util.Exist(defaultBenchCfgFile) {
+		if err := util.DecodeToml(benchCfgFile(), &cfg); err != nil {
+			return fmt.Errorf("decode bench config file error:%s", err)
+		}
+	}
+	if benchDuration > 0 {
+		cfg.Duration = benchDuration
+	}
+	writer, err := bench.NewRPCWriter(cfg.BrokerRPC, benchTimeout)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = writer.Close()
+	}()
+	runner, err := bench.NewRunner(cfg, writer, bench.NewHTTPQuerier(cfg.BrokerHTTP, benchTimeout))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("running benchmark against broker[%s] for %ds\n", cfg.BrokerRPC, cfg.Duration)
+	report := runner.Run(newCtxWithSignals())
+	fmt.Print(report.String())
+	return nil
+}
+
+// benchCfgFile returns the path of bench workload config file
+func benchCfgFile() string {
+	if len(benchCfgPath) == 0 {
+		return defaultBenchCfgFile
+	}
+	return benchCfgPath
+}
@@ -0,0 +1,158 @@
+package lind
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eleme/lindb/kv"
+	"github.com/eleme/lindb/kv/table"
+	kvversion "github.com/eleme/lindb/kv/version"
+)
+
+var (
+	inspectVerify = false
+)
+
+// newInspectCmd returns a new inspect-cmd
+func newInspectCmd() *cobra.Command {
+	inspectCmd := &cobra.Command{
+		Use:   "inspect",
+		Short: "Inspect the sst/manifest files of kv store offline, for debugging storage issues",
+	}
+	inspectCmd.PersistentFlags().BoolVar(&inspectVerify, "verify", false,
+		"verify the checksums of all values in sst files, and compute the content digests of them")
+	inspectCmd.AddCommand(
+		inspectSSTCmd,
+		inspectManifestCmd,
+		inspectStoreCmd,
+	)
+	return inspectCmd
+}
+
+// dump the footers, index blocks, key ranges and sizes of sst files
+var inspectSSTCmd = &cobra.Command{
+	Use:   "sst [file...]",
+	Short: "dump the footers, index blocks, key ranges and sizes of sst files",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for _, path := range args {
+			info, err := table.Inspect(path, inspectVerify)
+			if err != nil {
+				return fmt.Errorf("inspect sst file[%s] error:%s", path, err)
+			}
+			printFileInfo(info)
+		}
+		return nil
+	},
+}
+
+// pretty-print the edit logs of manifest file in store directory
+var inspectManifestCmd = &cobra.Command{
+	Use:   "manifest [store directory]",
+	Short: "pretty-print the edit logs of current manifest file in store directory",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return printManifest(args[0])
+	},
+}
+
+// dump the families, manifest and summary of sst files in store directory
+var inspectStoreCmd = &cobra.Command{
+	Use:   "store [store directory]",
+	Short: "dump the families, edit logs of manifest and summary of sst files in store directory",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		storePath := args[0]
+		families, err := kv.LoadFamilyOptions(storePath)
+		if err != nil {
+			return err
+		}
+		if err := printManifest(storePath); err != nil {
+			return err
+		}
+		names := make([]string, 0, len(families))
+		for name := range families {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("family: %s(%d)\n", name, families[name].ID)
+			files, err := ioutil.ReadDir(filepath.Join(storePath, name))
+			if err != nil {
+				return fmt.Errorf("list files of family[%s] error:%s", name, err)
+			}
+			for _, file := range files {
+				if _, ok := kvversion.ParseTable(file.Name()); !ok {
+					continue
+				}
+				info, err := table.Inspect(filepath.Join(storePath, name, file.Name()), inspectVerify)
+				if err != nil {
+					fmt.Printf("  %s error:%s\n", file.Name(), err)
+					continue
+				}
+				fmt.Printf("  %s size:%d version:%d keys:%d range:[%d,%d] index blocks:%d%s\n",
+					file.Name(), info.Size, info.Version, info.NumOfKeys, info.MinKey, info.MaxKey,
+					len(info.IndexBlocks), corruptionOf(info))
+			}
+		}
+		return nil
+	},
+}
+
+// printFileInfo prints the layout of sst file
+func printFileInfo(info *table.FileInfo) {
+	fmt.Printf("sst: %s\n", info.Path)
+	fmt.Printf("  size: %d, version: %d, checksum: %t\n", info.Size, info.Version, info.Checksum)
+	fmt.Printf("  keys: %d, range: [%d,%d]\n", info.NumOfKeys, info.MinKey, info.MaxKey)
+	if info.Top != nil {
+		fmt.Printf("  top index: %s\n", info.Top)
+	}
+	fmt.Printf("  index blocks: %d\n", len(info.IndexBlocks))
+	for idx, block := range info.IndexBlocks {
+		fmt.Printf("    #%d %s\n", idx, block)
+	}
+	if inspectVerify {
+		fmt.Printf("  verify:%s\n", corruptionOf(info))
+	}
+}
+
+// corruptionOf returns the corruption of sst file found by verifying, or the content digest if not corrupted,
+// which is compared with the file digest in manifest
+func corruptionOf(info *table.FileInfo) string {
+	if len(info.Corruption) > 0 {
+		return " corrupted:" + info.Corruption
+	}
+	if inspectVerify {
+		return fmt.Sprintf(" ok digest:%016x", info.Digest)
+	}
+	return ""
+}
+
+// printManifest prints the edit logs of current manifest file in store directory
+func printManifest(storePath string) error {
+	manifest, editLogs, err := kvversion.ReadManifest(storePath)
+	if err != nil {
+		return fmt.Errorf("read manifest of store[%s] error:%s", storePath, err)
+	}
+	families, err := kv.LoadFamilyOptions(storePath)
+	if err != nil {
+		return err
+	}
+	names := map[int]string{kvversion.StoreFamilyID: "store"}
+	for name, option := range families {
+		names[option.ID] = name
+	}
+	fmt.Printf("manifest: %s, edit logs: %d\n", manifest, len(editLogs))
+	for idx, editLog := range editLogs {
+		fmt.Printf("  #%d family:%s(%d) sequence:%d\n",
+			idx, names[editLog.FamilyID()], editLog.FamilyID(), editLog.Sequence())
+		for _, log := range editLog.Logs() {
+			fmt.Printf("    %s\n", log)
+		}
+	}
+	return nil
+}
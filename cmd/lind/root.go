@@ -30,5 +30,8 @@ func init() {
 		versionCmd,
 		newStorageCmd(),
 		newBrokerCmd(),
+		newAdminCmd(),
+		newBenchCmd(),
+		newInspectCmd(),
 	)
 }
@@ -1,19 +1,30 @@
 package lind
 
 import (
+	"context"
 	"fmt"
 	_ "net/http/pprof" // for profiling
+	"time"
 
 	"github.com/eleme/lindb/config"
 	"github.com/eleme/lindb/pkg/util"
+	"github.com/eleme/lindb/rpc"
+	"github.com/eleme/lindb/rpc/proto/common"
+	rpcstorage "github.com/eleme/lindb/rpc/proto/storage"
 	"github.com/eleme/lindb/storage"
 
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
 )
 
 var (
 	storageCfgPath = ""
 	storageDebug   = false
+
+	prepareShutdownAddress = ""
+	prepareShutdownTimeout = time.Minute
+
+	listTasksAddress = ""
 )
 
 // newStorageCmd returns a new storage-cmd
@@ -27,11 +38,19 @@ func newStorageCmd() *cobra.Command {
 		fmt.Sprintf("storage config file path, default is %s", storage.DefaultStorageCfgFile))
 	runStorageCmd.PersistentFlags().BoolVar(&storageDebug, "debug", false,
 		"profiling Go programs with pprof")
+	prepareShutdownCmd.Flags().StringVar(&prepareShutdownAddress, "addr", "127.0.0.1:2891",
+		"the grpc address of storage node")
+	prepareShutdownCmd.Flags().DurationVar(&prepareShutdownTimeout, "timeout", time.Minute,
+		"the timeout of flushing data")
+	listTasksCmd.Flags().StringVar(&listTasksAddress, "addr", "127.0.0.1:2891",
+		"the grpc address of storage node")
 
 	storageCmd.AddCommand(
 		runStorageCmd,
 		initializeStorageConfigCmd,
 		databaseCmd,
+		prepareShutdownCmd,
+		listTasksCmd,
 	)
 	return storageCmd
 }
@@ -59,7 +78,7 @@ func serveStorage(cmd *cobra.Command, args []string) error {
 	ctx := newCtxWithSignals()
 
 	// start storage server
-	storageRuntime := storage.NewStorageRuntime(storageCfgPath)
+	storageRuntime := storage.NewStorageRuntime(storageCfgPath, rpc.DefaultProtocol)
 	if err := storageRuntime.Run(); err != nil {
 		return fmt.Errorf("run storage server error:%s", err)
 	}
@@ -74,6 +93,59 @@ func serveStorage(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// prepareShutdownCmd prepares the storage node for a planned restart, used as the preStop hook of Kubernetes,
+// returns after the in-memory data of storage node is flushed into disk.
+var prepareShutdownCmd = &cobra.Command{
+	Use:   "prepare-shutdown",
+	Short: "reject writes and flush in-memory data of storage node before restarting",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := grpc.Dial(prepareShutdownAddress, grpc.WithInsecure(), rpc.DefaultProtocol.DialOption())
+		if err != nil {
+			return fmt.Errorf("dial storage node[%s] error:%s", prepareShutdownAddress, err)
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), prepareShutdownTimeout)
+		defer cancel()
+		resp, err := rpcstorage.NewAdminServiceClient(conn).PrepareShutdown(ctx, &common.Request{})
+		if err != nil {
+			return fmt.Errorf("prepare shutdown of storage node[%s] error:%s", prepareShutdownAddress, err)
+		}
+		if err := rpc.ResponseToError(resp); err != nil {
+			return fmt.Errorf("prepare shutdown of storage node[%s] error:%s", prepareShutdownAddress, err)
+		}
+		fmt.Printf("storage node[%s] is ready for shutdown\n", prepareShutdownAddress)
+		return nil
+	},
+}
+
+// listTasksCmd prints the progress of running flush/compaction tasks of storage node
+var listTasksCmd = &cobra.Command{
+	Use:   "tasks",
+	Short: "list the running flush/compaction tasks of storage node",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := grpc.Dial(listTasksAddress, grpc.WithInsecure(), rpc.DefaultProtocol.DialOption())
+		if err != nil {
+			return fmt.Errorf("dial storage node[%s] error:%s", listTasksAddress, err)
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		resp, err := rpcstorage.NewAdminServiceClient(conn).ListTasks(ctx, &common.Request{})
+		if err != nil {
+			return fmt.Errorf("list tasks of storage node[%s] error:%s", listTasksAddress, err)
+		}
+		if err := rpc.ResponseToError(resp); err != nil {
+			return fmt.Errorf("list tasks of storage node[%s] error:%s", listTasksAddress, err)
+		}
+		fmt.Println(string(resp.Data))
+		return nil
+	},
+}
+
 // databaseCmd provides the ability to control the database of storage
 var databaseCmd = &cobra.Command{
 	Use:   "database",
@@ -0,0 +1,15 @@
+package config
+
+import "github.com/eleme/lindb/pkg/backup"
+
+// Backup represents the config of backup scheduler, master backups all shards periodically,
+// keeps the newest complete backup sets by retention, the scheduler is disabled by default.
+// If incremental is enabled, the backup is based on latest complete backup set, only the new sst files are uploaded.
+type Backup struct {
+	Enabled     bool `toml:"enabled"`
+	Incremental bool `toml:"incremental"`
+	// Interval is the interval of backup in seconds
+	Interval  int64         `toml:"interval"`
+	Retention int           `toml:"retention"`
+	Target    backup.Config `toml:"target"`
+}
@@ -2,17 +2,174 @@ package config
 
 import (
 	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/backup"
+	"github.com/eleme/lindb/pkg/logger"
 	"github.com/eleme/lindb/pkg/state"
 )
 
 // Broker represents a broker configuration
 type Broker struct {
-	HTTP        HTTP         `toml:"HTTP"`
-	Coordinator state.Config `toml:"coordinator"`
-	User        models.User  `toml:"user"`
+	HTTP           HTTP           `toml:"HTTP"`
+	Coordinator    state.Config   `toml:"coordinator"`
+	User           models.User    `toml:"user"`
+	PProf          PProf          `toml:"pprof"`
+	Backup         Backup         `toml:"backup"`
+	Write          Write          `toml:"write"`
+	Spill          Spill          `toml:"spill"`
+	UDP            UDP            `toml:"udp"`
+	StatsD         StatsD         `toml:"statsd"`
+	Audit          Audit          `toml:"audit"`
+	QueryQueue     QueryQueue     `toml:"query-queue"`
+	Timeout        Timeout        `toml:"timeout"`
+	DeadLetter     DeadLetter     `toml:"dead-letter"`
+	CircuitBreaker CircuitBreaker `toml:"circuit-breaker"`
+	RoutingCache   RoutingCache   `toml:"routing-cache"`
+	DatabaseStats  DatabaseStats  `toml:"database-stats"`
+	CDC            CDC            `toml:"cdc"`
+	UDF            UDF            `toml:"udf"`
+	// Logging is the logging config of broker, e.g. json format for log pipelines
+	Logging logger.Config `toml:"logging"`
 }
 
-// HTTP represents an HTTP level configuration of broker.
+// UDF represents the loading of user defined functions, the functions are loaded from WebAssembly modules
+// which run in sandbox. The Go plugin runs in the process of broker without isolation,
+// so that the functions of Go plugin are rejected unless allowed explicitly.
+type UDF struct {
+	// AllowPlugin allows loading the functions from Go plugins, only for the trusted plugins
+	AllowPlugin bool `toml:"allow-plugin"`
+}
+
+// CDC represents the change data capture of broker, the write batches replicated durably by broker are published
+// to the subscribers of change stream, so the writes are acked after replicated when enabled, the external subscribers receive the batches by the grpc streaming
+// of broker server. The change stream is disabled by default.
+type CDC struct {
+	Enabled bool `toml:"enabled"`
+	// Port is the port of broker grpc server serving the change stream, 0 means only internal subscribers
+	Port uint16 `toml:"port"`
+	// BufferSize is the number of batches buffered for each subscriber, the batches are dropped if the buffer is full
+	BufferSize int `toml:"buffer-size"`
+}
+
+// RoutingCache represents the local cache of the shard routing of databases, which is always filled by the watches
+// of state repository. If enabled, the routing is persisted as snapshot after changed, then the broker routes requests
+// by the snapshot at startup while the watches are re-established, so that the requests aren't rejected if etcd is
+// slow. The snapshot is disabled by default.
+type RoutingCache struct {
+	Enabled bool `toml:"enabled"`
+	// Path is the snapshot file of routing cache, empty means the routing isn't persisted
+	Path string `toml:"path"`
+}
+
+// CircuitBreaker represents the circuit breakers of storage nodes which broker fans out requests to,
+// the node is suspect after consecutive failures, then the requests are shipped to other replicas.
+type CircuitBreaker struct {
+	// FailureThreshold is the number of consecutive failures which opens the circuit, zero means never opens
+	FailureThreshold int `toml:"failure-threshold"`
+	// OpenTimeout is the time(ms) the circuit keeps open before probing the node
+	OpenTimeout int64 `toml:"open-timeout"`
+}
+
+// DeadLetter represents the dead letter queue of the points which fail validation, the rejects are counted by metric,
+// the recent rejects of each database are kept for sampling by api, the dead letter queue is disabled by default.
+type DeadLetter struct {
+	Enabled bool `toml:"enabled"`
+	// Path is the file which the rejects are appended into as json lines, empty means not writing file
+	Path string `toml:"path"`
+	// SampleSize is the number of recent rejects kept for each database
+	SampleSize int `toml:"sample-size"`
+}
+
+// Timeout represents the default timeouts(ms) of broker apis, which are enforced by the deadline of request context
+// and propagated to storage nodes, the timeout can be shortened per request by header, zero means no timeout.
+type Timeout struct {
+	Write int64 `toml:"write"`
+	Query int64 `toml:"query"`
+	Admin int64 `toml:"admin"`
+}
+
+// QueryQueue represents the admission queue of broker queries with priority classes, the class of query is selected
+// by request header, each class has bounded concurrency, so that heavy batch queries don't starve dashboards.
+type QueryQueue struct {
+	Interactive QueryClass `toml:"interactive"`
+	Dashboard   QueryClass `toml:"dashboard"`
+	Batch       QueryClass `toml:"batch"`
+}
+
+// QueryClass represents the limits of a priority class of queries
+type QueryClass struct {
+	// Concurrency is the max number of queries executed concurrently, zero means no limit
+	Concurrency int `toml:"concurrency"`
+	// MaxQueueTime is the max time(ms) a query waits in the queue before rejected, zero means waiting until executed
+	MaxQueueTime int64 `toml:"max-queue-time"`
+}
+
+// Audit represents the audit log of administrative operations(database create/update, storage cluster save/delete),
+// the logs are stored in state repository and listed by api, the audit log is disabled by default.
+type Audit struct {
+	Enabled bool `toml:"enabled"`
+	// MaxLogs is the max number of the newest logs kept, zero means keeping all
+	MaxLogs int `toml:"max-logs"`
+}
+
+// UDP represents the udp ingestion listener of broker for the fire-and-forget metrics of line protocol,
+// the packets are dropped if the parsing queue is full, the listener is disabled by default.
+type UDP struct {
+	Enabled bool   `toml:"enabled"`
+	Port    uint16 `toml:"port"`
+	// Database is the database which the points are written into
+	Database string `toml:"database"`
+	// ReadBuffer is the read buffer size(bytes) of socket, zero means the default of os
+	ReadBuffer int `toml:"read-buffer"`
+	// Workers is the number of workers parsing packets
+	Workers int `toml:"workers"`
+	// QueueSize is the max number of packets waiting for parsing
+	QueueSize int `toml:"queue-size"`
+}
+
+// StatsD represents the statsd compatible listener of broker, the metrics are aggregated in memory,
+// then written into database as points every flush interval, the listener is disabled by default.
+type StatsD struct {
+	Enabled bool `toml:"enabled"`
+	// UDPAddress/TCPAddress are the listen addresses(like :8125), empty means not listening
+	UDPAddress string `toml:"udp-address"`
+	TCPAddress string `toml:"tcp-address"`
+	// Database is the database which the points are written into
+	Database string `toml:"database"`
+	// FlushInterval is the interval(ms) of flushing the aggregated metrics
+	FlushInterval int64 `toml:"flush-interval"`
+	// TimerBuckets are the upper bounds(ms) of histogram buckets of timer
+	TimerBuckets []float64 `toml:"timer-buckets"`
+}
+
+// Write represents the write batching configuration of broker, the small writes of clients are coalesced
+// into a batch before replicated to storage, the batch is flushed when any of the limits is reached.
+type Write struct {
+	// BatchPoints is the max number of points in a batch
+	BatchPoints int `toml:"batch-points"`
+	// BatchBytes is the max bytes of a batch
+	BatchBytes int `toml:"batch-bytes"`
+	// MaxLinger is the max time(ms) a write waits in the batch before flushed
+	MaxLinger int64 `toml:"max-linger"`
+	// TraceSampling means the stage timings of 1 of every n write batches are traced, 0 means no sampling
+	TraceSampling int `toml:"trace-sampling"`
+	// SlowWrite is the threshold(ms) of slow write batch which is always traced, 0 means no threshold
+	SlowWrite int64 `toml:"slow-write"`
+}
+
+// Spill represents the bounded on-disk queue of write batches, the broker spills the pending batches of shard
+// into the queue when storage nodes are unreachable, then drains the queue in order after storage recovers,
+// so that the writes aren't rejected during a short storage outage. The spill is disabled by default.
+type Spill struct {
+	Enabled bool `toml:"enabled"`
+	// Dir is the dir of spilled batches, each shard has its own sub dir
+	Dir string `toml:"dir"`
+	// MaxBytes is the max bytes of spilled batches of each shard, the writes are rejected if the queue is full
+	MaxBytes int64 `toml:"max-bytes"`
+	// DrainInterval is the interval(ms) of retrying to drain the spilled batches
+	DrainInterval int64 `toml:"drain-interval"`
+}
+
+// HTTP represents an HTTP level configuration of broker/storage.
 type HTTP struct {
 	Port uint16 `toml:"port"`
 }
@@ -23,10 +180,77 @@ func NewDefaultBrokerCfg() Broker {
 		HTTP: HTTP{
 			Port: 9000,
 		},
+		Logging: logger.NewConfig(),
 		Coordinator: state.Config{
-			Namespace:   "/lindb/broker",
-			Endpoints:   []string{"http://localhost:2379"},
-			DialTimeout: 5,
+			Namespace:      "/lindb/broker",
+			Endpoints:      []string{"http://localhost:2379"},
+			DialTimeout:    5,
+			RequestTimeout: 5,
+			MaxRetries:     3,
+		},
+		PProf: PProf{
+			Port: 6060,
+		},
+		Backup: Backup{
+			Interval:  24 * 60 * 60,
+			Retention: 7,
+			Target: backup.Config{
+				URL: "/tmp/lindb/backup",
+			},
+		},
+		Write: Write{
+			BatchPoints:   5000,
+			BatchBytes:    1024 * 1024,
+			MaxLinger:     100,
+			TraceSampling: 1000,
+			SlowWrite:     1000,
+		},
+		Spill: Spill{
+			Dir:           "/tmp/lindb/broker/spill",
+			MaxBytes:      256 * 1024 * 1024,
+			DrainInterval: 1000,
+		},
+		UDP: UDP{
+			Port:       8089,
+			ReadBuffer: 8 * 1024 * 1024,
+			Workers:    4,
+			QueueSize:  1024,
+		},
+		StatsD: StatsD{
+			UDPAddress:    ":8125",
+			TCPAddress:    ":8125",
+			FlushInterval: 10 * 1000,
+			TimerBuckets:  []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000},
+		},
+		Audit: Audit{
+			MaxLogs: 1000,
+		},
+		QueryQueue: QueryQueue{
+			Interactive: QueryClass{Concurrency: 32, MaxQueueTime: 10 * 1000},
+			Dashboard:   QueryClass{Concurrency: 16, MaxQueueTime: 30 * 1000},
+			Batch:       QueryClass{Concurrency: 2, MaxQueueTime: 60 * 1000},
+		},
+		Timeout: Timeout{
+			Write: 5 * 1000,
+			Query: 30 * 1000,
+			Admin: 10 * 1000,
+		},
+		DeadLetter: DeadLetter{
+			SampleSize: 100,
+		},
+		CircuitBreaker: CircuitBreaker{
+			FailureThreshold: 5,
+			OpenTimeout:      10 * 1000,
+		},
+		RoutingCache: RoutingCache{
+			Path: "/tmp/lindb/broker/routing.json",
+		},
+		DatabaseStats: DatabaseStats{
+			ReportInterval: 30 * 1000,
+		},
+		CDC: CDC{
+			Port:       9001,
+			BufferSize: 1024,
 		},
 	}
 }
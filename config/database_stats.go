@@ -0,0 +1,9 @@
+package config
+
+// DatabaseStats represents the config of per-database write/query statistics, each node reports the statistics
+// into state repository periodically, then master aggregates the statistics of all nodes for capacity planning
+// and chargeback.
+type DatabaseStats struct {
+	// ReportInterval is the interval(ms) of reporting/aggregating the statistics, zero disables the statistics
+	ReportInterval int64 `toml:"report-interval"`
+}
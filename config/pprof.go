@@ -0,0 +1,8 @@
+package config
+
+// PProf represents the config of http server which exposes runtime profiling data,
+// the server is disabled by default.
+type PProf struct {
+	Enabled bool   `toml:"enabled"`
+	Port    uint16 `toml:"port"`
+}
@@ -1,13 +1,27 @@
 package config
 
-import "github.com/eleme/lindb/pkg/state"
+import (
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/state"
+)
 
 // Storage represents a storage configuration
 type Storage struct {
 	Coordinator state.Config `toml:"coordinator"`
 	Server      Server       `toml:"server"`
+	// HTTP is the http server exposing the health of storage node(/health/live and /health/ready), zero port disables it
+	HTTP HTTP `toml:"http"`
 
 	Engine Engine `toml:"engine"`
+	Query  Query  `toml:"query"`
+	Flush  Flush  `toml:"flush"`
+	PProf  PProf  `toml:"pprof"`
+	// DatabaseStats is the per-database statistics of storage node, which is aggregated by master of broker
+	DatabaseStats DatabaseStats `toml:"database-stats"`
+	// Labels are the labels of storage node(e.g. rack/zone/disk), used by shard placement constraints
+	Labels map[string]string `toml:"labels"`
+	// Logging is the logging config of storage node, e.g. json format for log pipelines
+	Logging logger.Config `toml:"logging"`
 }
 
 // Server represents tcp server config
@@ -16,25 +30,97 @@ type Server struct {
 	TTL  int64  `toml:"ttl"`
 }
 
+// Query represents the limits of query execution in storage node, zero value means no limit,
+// the query returns partial result when exceeds any of them.
+type Query struct {
+	MaxSeries        int64 `toml:"max-series"`
+	MaxPoints        int64 `toml:"max-points"`
+	MaxResponseBytes int64 `toml:"max-response-bytes"`
+	// Concurrency is the max number of segments scanned concurrently by one query, zero means GOMAXPROCS
+	Concurrency int `toml:"concurrency"`
+}
+
+// Flush represents the flush coordination of storage node, the memory databases of shards are flushed
+// by the coordinator based on the total memory and disk bandwidth, instead of flushing independently.
+type Flush struct {
+	// CheckInterval is the interval(ms) of checking whether flush is needed
+	CheckInterval int64 `toml:"check-interval"`
+	// HighWaterMark/LowWaterMark are the total memory(bytes) of memory databases,
+	// the largest ones are flushed when exceeds high water mark until below low water mark
+	HighWaterMark int64 `toml:"high-water-mark"`
+	LowWaterMark  int64 `toml:"low-water-mark"`
+	// HighWaterMarkRatio/LowWaterMarkRatio are the water marks as the ratios of memory limit of container(cgroup),
+	// which override the absolute water marks if set and the memory limit is detected
+	HighWaterMarkRatio float64 `toml:"high-water-mark-ratio"`
+	LowWaterMarkRatio  float64 `toml:"low-water-mark-ratio"`
+	// MaxInterval is the max interval(ms) between two flushes of a memory database, zero means no limit
+	MaxInterval int64 `toml:"max-interval"`
+	// DiskBandwidth is the budget of disk bandwidth(bytes/s) for flushing, zero means no limit
+	DiskBandwidth int64 `toml:"disk-bandwidth"`
+	// Concurrency is the max number of memory databases flushed at the same time
+	Concurrency int `toml:"concurrency"`
+}
+
 // Engine represents a tsdb engine level configuration
 type Engine struct {
 	Path string `toml:"path"`
+	// DataPaths are the directories storing shards(JBOD, each directory on a disk), the new shard is placed on
+	// the directory with most available space, empty means the shards are stored under path
+	DataPaths []string `toml:"data-paths"`
+	// WarmUpSegments is the number of most recent segments of each interval warmed automatically after the shards
+	// opened by storage node, the metadata index is warmed too, 0 means no automatic warm-up
+	WarmUpSegments int `toml:"warm-up-segments"`
+	// BlockCacheSize is the capacity(bytes) of the cache for leaf index blocks shared by all kv stores of node,
+	// 0 means each kv store uses its own cache with default capacity
+	BlockCacheSize int64 `toml:"block-cache-size"`
+	// BlockCacheRatio is the capacity of block cache as the ratio of memory limit of container(cgroup),
+	// which overrides block cache size if set and the memory limit is detected
+	BlockCacheRatio float64 `toml:"block-cache-ratio"`
 }
 
 // NewDefaultStorageCfg creates storage define config
 func NewDefaultStorageCfg() Storage {
 	return Storage{
 		Coordinator: state.Config{
-			Namespace:   "/lindb/storage",
-			Endpoints:   []string{"http://localhost:2379"},
-			DialTimeout: 5,
+			Namespace:         "/lindb/storage",
+			Endpoints:         []string{"http://localhost:2379"},
+			DialTimeout:       5,
+			RequestTimeout:    5,
+			MaxRetries:        3,
+			DegradedTolerance: 60,
 		},
 		Server: Server{
 			Port: 2891,
 			TTL:  1,
 		},
+		HTTP: HTTP{
+			Port: 2892,
+		},
 		Engine: Engine{
-			Path: "/tmp",
+			Path:            "/tmp",
+			BlockCacheRatio: 0.05,
+		},
+		Query: Query{
+			MaxSeries:        100000,
+			MaxPoints:        100000000,
+			MaxResponseBytes: 256 * 1024 * 1024,
+		},
+		Flush: Flush{
+			CheckInterval:      1000,
+			HighWaterMark:      2 * 1024 * 1024 * 1024,
+			LowWaterMark:       1536 * 1024 * 1024,
+			HighWaterMarkRatio: 0.5,
+			LowWaterMarkRatio:  0.375,
+			MaxInterval:        30 * 60 * 1000,
+			DiskBandwidth:      100 * 1024 * 1024,
+			Concurrency:        1,
+		},
+		PProf: PProf{
+			Port: 6061,
+		},
+		Logging: logger.NewConfig(),
+		DatabaseStats: DatabaseStats{
+			ReportInterval: 30 * 1000,
 		},
 	}
 }
@@ -12,10 +12,38 @@ const (
 	DatabaseConfigPath = "/database/config"
 	// DatabaseAssignPath represents database shard assignment
 	DatabaseAssignPath = "/database/assign"
+	// DatabaseLimitsPath represents the runtime limits(e.g. max tags of metrics) of database
+	DatabaseLimitsPath = "/database/limits"
+	// MasterPath represents master elect path
+	MasterPath = "/master/node"
+	// ClusterInfoPath represents the identity(id/metadata schema version) of cluster
+	ClusterInfoPath = "/cluster/info"
+	// AuditLogPath represents the audit log of administrative operations
+	AuditLogPath = "/audit/log"
+	// BackupLockPath represents the lock of backup scheduling, so that one backup runs at a time
+	BackupLockPath = "/lock/backup"
+	// ShardLockPath represents the lock prefix of database's shards, so that the shards are split/moved one at a time
+	ShardLockPath = "/lock/shard"
+	// ShardStatePath represents the states(hot/read-only/frozen) of database's shards
+	ShardStatePath = "/shard/state"
+	// ShardCorruptionPath represents the corrupted files of shards reported by storage nodes
+	ShardCorruptionPath = "/shard/corruption"
+	// StatsNodesPath represents the database statistics reported by each node
+	StatsNodesPath = "/stats/nodes"
+	// StatsDatabasesPath represents the database statistics aggregated by master
+	StatsDatabasesPath = "/stats/databases"
+	// QueryBlacklistPath represents the blacklist rules rejecting known-pathological queries
+	QueryBlacklistPath = "/query/blacklist"
+	// QueryUDFPath represents the user defined functions of databases, the key is /query/udf/{database}/{name}
+	QueryUDFPath = "/query/udf"
 )
 
 // defines all task kinds
 const (
 	// CreateShard represents task kind which is create shard for storage node
 	CreateShard task.Kind = "create-shard"
+	// Backup represents task kind which is backup database's shards for storage node
+	Backup task.Kind = "backup"
+	// SplitShard represents task kind which is split shard for storage node
+	SplitShard task.Kind = "split-shard"
 )
@@ -10,6 +10,12 @@ import (
 type StateMachine struct {
 	StorageCluster storage.ClusterStateMachine
 	DatabaseAdmin  database.AdminStateMachine
+	// BackupScheduler is nil if backup is disabled
+	BackupScheduler storage.BackupScheduler
+	// StatsAggregator is nil if database stats is disabled
+	StatsAggregator storage.StatsAggregator
+	// ShardSplitter is nil if database stats is disabled, because the hot shards are found by the shard stats
+	ShardSplitter database.ShardSplitter
 }
 
 // MasterContext represents master context, creates it after node elect master
@@ -33,4 +39,13 @@ func (m *MasterContext) Close() {
 	if err := m.stateMachine.DatabaseAdmin.Close(); err != nil {
 		log.Error("close database admin state machine error", logger.Error(err), logger.Stack())
 	}
+	if m.stateMachine.BackupScheduler != nil {
+		m.stateMachine.BackupScheduler.Close()
+	}
+	if m.stateMachine.StatsAggregator != nil {
+		m.stateMachine.StatsAggregator.Close()
+	}
+	if m.stateMachine.ShardSplitter != nil {
+		m.stateMachine.ShardSplitter.Close()
+	}
 }
@@ -9,8 +9,10 @@ import (
 	"github.com/eleme/lindb/constants"
 	"github.com/eleme/lindb/coordinator/discovery"
 	"github.com/eleme/lindb/coordinator/storage"
+	"github.com/eleme/lindb/coordinator/task"
 	"github.com/eleme/lindb/models"
 	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/pathutil"
 	"github.com/eleme/lindb/pkg/state"
 )
 
@@ -20,6 +22,14 @@ import (
 type AdminStateMachine interface {
 	discovery.Listener
 
+	// SplitShard splits the hash range of database's shard in storage cluster into two shards,
+	// returns the id of new shard
+	SplitShard(databaseName, clusterName string, shardID int) (int, error)
+	// SplitHotShards splits the shards which exceed the split thresholds of database config by shard stats,
+	// returns the ids of split shards
+	SplitHotShards(databaseName, clusterName string, stats []models.ShardStat) ([]int, error)
+	// ReassignShard moves database's shard in storage cluster to the active nodes, increases the routing epoch
+	ReassignShard(databaseName, clusterName string, shardID int, nodes []models.Node) error
 	// Close closes admin state machine, stops watch change event
 	Close() error
 }
@@ -51,7 +61,7 @@ func NewAdminStateMachine(ctx context.Context, repo state.Repository,
 		log:            logger.GetLogger("database/admin/state/machine"),
 	}
 	// new database config discovery
-	stateMachine.discovery = discovery.NewDiscovery(repo, constants.DatabaseConfigPath, stateMachine)
+	stateMachine.discovery = discovery.NewDiscovery(repo, pathutil.Keys.Databases.Configs.Prefix(), stateMachine)
 	if err := stateMachine.discovery.Discovery(); err != nil {
 		return nil, fmt.Errorf("discovery database config error:%s", err)
 	}
@@ -134,27 +144,200 @@ func (sm *adminStateMachine) createShardAssignment(databaseName string,
 		nodes[idx] = node
 	}
 
-	var nodeIDs []int
-	for idx := range nodes {
-		nodeIDs = append(nodeIDs, idx)
-	}
-
-	// generate shard assignment based on node ids and config
-	shardAssign, err := ShardAssignment(nodeIDs, clusterCfg)
+	// generate shard assignment based on nodes and config, the placement constraints of config are honored
+	shardAssign, err := PlacementShardAssignment(nodes, clusterCfg)
 	if err != nil {
 		return err
 	}
 	// set nodes and config, storage node will use it when execute create shard task
 	shardAssign.Nodes = nodes
 	shardAssign.Config = clusterCfg
+	shardAssign.InitRanges()
 
-	// save shard assignment into related storage cluster
-	if err := cluster.SaveShardAssign(databaseName, shardAssign); err != nil {
+	// save shard assignment into related storage cluster, fails if created by others
+	if err := cluster.SaveShardAssign(databaseName, shardAssign, 0); err != nil {
 		return err
 	}
 	return nil
 }
 
+// SplitShard splits the hash range of shard into two shards, the new shard is placed on the same replicas.
+// Publishes the shard assignment with new routing epoch and split shard coordinator task atomically,
+// brokers switch routing when receive it, storage nodes create the new shard.
+func (sm *adminStateMachine) SplitShard(databaseName, clusterName string, shardID int) (int, error) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	lock, err := sm.lockShards(databaseName, "split")
+	if err != nil {
+		return 0, err
+	}
+	defer sm.unlockShards(lock)
+
+	return sm.splitShard(databaseName, clusterName, shardID)
+}
+
+// SplitHotShards splits the shards which exceed the split thresholds of database config
+func (sm *adminStateMachine) SplitHotShards(databaseName, clusterName string,
+	stats []models.ShardStat) ([]int, error) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	lock, err := sm.lockShards(databaseName, "split")
+	if err != nil {
+		return nil, err
+	}
+	defer sm.unlockShards(lock)
+
+	cluster := sm.storageCluster.GetCluster(clusterName)
+	if cluster == nil {
+		return nil, fmt.Errorf("storage cluster[%s] not exist", clusterName)
+	}
+	shardAssign, err := cluster.GetShardAssign(databaseName)
+	if err != nil {
+		return nil, err
+	}
+	var splitShardIDs []int
+	for _, stat := range stats {
+		if !shardAssign.Config.SplitOption.NeedSplit(stat) {
+			continue
+		}
+		if _, err := sm.splitShard(databaseName, clusterName, stat.ShardID); err != nil {
+			return splitShardIDs, err
+		}
+		splitShardIDs = append(splitShardIDs, stat.ShardID)
+	}
+	return splitShardIDs, nil
+}
+
+// splitShard splits the shard, saves shard assignment then submits split shard tasks
+func (sm *adminStateMachine) splitShard(databaseName, clusterName string, shardID int) (int, error) {
+	cluster := sm.storageCluster.GetCluster(clusterName)
+	if cluster == nil {
+		return 0, fmt.Errorf("storage cluster[%s] not exist", clusterName)
+	}
+	shardAssign, revision, err := cluster.GetShardAssignWithRevision(databaseName)
+	if err != nil {
+		return 0, err
+	}
+	newShardID, err := shardAssign.SplitShard(shardID)
+	if err != nil {
+		return 0, err
+	}
+	replica := shardAssign.Shards[newShardID]
+	var params []task.ControllerTaskParam
+	for _, replicaID := range replica.Replicas {
+		node := shardAssign.Nodes[replicaID]
+		params = append(params, task.ControllerTaskParam{
+			NodeID: (&node).Key(),
+			Params: models.SplitShardTask{
+				Database:    databaseName,
+				ShardID:     shardID,
+				NewShardID:  newShardID,
+				Range:       *replica.Range,
+				Epoch:       shardAssign.Epoch,
+				ShardOption: shardAssign.Config.ShardOption,
+				RoutingTags: shardAssign.Config.RoutingTags,
+			},
+		})
+	}
+	name := fmt.Sprintf("%s-split-%d", databaseName, shardAssign.Epoch)
+	// publish shard assignment with split shard tasks atomically
+	if err := cluster.PublishShardAssign(databaseName, shardAssign, revision,
+		constants.SplitShard, name, params); err != nil {
+		return 0, err
+	}
+	sm.log.Info("split shard", logger.String("database", databaseName), logger.Any("shard", shardID),
+		logger.Any("newShard", newShardID), logger.Any("epoch", shardAssign.Epoch))
+	return newShardID, nil
+}
+
+// ReassignShard moves the shard to the active nodes, the reassignment is rolled out by routing epoch.
+// Publishes the shard assignment with new routing epoch and the replicas of shard, and the create shard
+// coordinator tasks atomically, the new replicas create the shard.
+// Storage nodes reject the writes routed by old epoch, then brokers switch to new replicas without losing writes.
+// The new replicas must satisfy the placement constraints of database.
+func (sm *adminStateMachine) ReassignShard(databaseName, clusterName string, shardID int, nodes []models.Node) error {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	lock, err := sm.lockShards(databaseName, "reassign")
+	if err != nil {
+		return err
+	}
+	defer sm.unlockShards(lock)
+
+	cluster := sm.storageCluster.GetCluster(clusterName)
+	if cluster == nil {
+		return fmt.Errorf("storage cluster[%s] not exist", clusterName)
+	}
+	shardAssign, revision, err := cluster.GetShardAssignWithRevision(databaseName)
+	if err != nil {
+		return err
+	}
+	// the active nodes have the labels which the placement constraints are checked by
+	activeNodes := make(map[string]models.Node)
+	for _, node := range cluster.GetActiveNodes() {
+		activeNodes[(&node).Key()] = node
+	}
+	var replicas []int
+	for _, node := range nodes {
+		activeNode, ok := activeNodes[(&node).Key()]
+		if !ok {
+			return fmt.Errorf("node[%s] isn't active in storage cluster[%s]", (&node).Key(), clusterName)
+		}
+		replicas = append(replicas, assignNode(shardAssign, activeNode))
+	}
+	if err := shardAssign.ReassignShard(shardID, replicas); err != nil {
+		return err
+	}
+	if err := CheckPlacement(shardAssign, shardAssign.Config, shardID); err != nil {
+		return fmt.Errorf("reassign shard[%d] of database[%s] error:%s", shardID, databaseName, err)
+	}
+	if err := cluster.SaveShardAssign(databaseName, shardAssign, revision); err != nil {
+		return err
+	}
+	sm.log.Info("reassign shard", logger.String("database", databaseName), logger.Any("shard", shardID),
+		logger.Any("replicas", replicas), logger.Any("epoch", shardAssign.Epoch))
+	return nil
+}
+
+// lockShards acquires the distributed lock of database's shards, so that the shards are split/moved
+// by one broker at a time, the value of lock is the operation.
+func (sm *adminStateMachine) lockShards(databaseName, operation string) (state.Mutex, error) {
+	lock := sm.repo.NewMutex(pathutil.GetShardLockPath(databaseName), []byte(operation), 0)
+	acquired, _, err := lock.TryLock(sm.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire shard lock of database[%s] error:%s", databaseName, err)
+	}
+	if !acquired {
+		return nil, fmt.Errorf("shards of database[%s] are being changed by others", databaseName)
+	}
+	return lock, nil
+}
+
+// unlockShards releases the distributed lock of database's shards
+func (sm *adminStateMachine) unlockShards(lock state.Mutex) {
+	if err := lock.Unlock(sm.ctx); err != nil {
+		sm.log.Error("release shard lock error", logger.String("lock", lock.Key()), logger.Error(err))
+	}
+}
+
+// assignNode returns the id of node in shard assignment, adds the node with a new id if not exist
+func assignNode(shardAssign *models.ShardAssignment, node models.Node) int {
+	nextID := 0
+	for id, assigned := range shardAssign.Nodes {
+		if (&assigned).Key() == (&node).Key() {
+			return id
+		}
+		if id >= nextID {
+			nextID = id + 1
+		}
+	}
+	shardAssign.Nodes[nextID] = node
+	return nextID
+}
+
 // getNodes returns all active nodes by cluster name
 func (sm *adminStateMachine) getNodes(clusterName string) (map[int]models.Node, error) {
 	cluster := sm.storageCluster.GetCluster(clusterName)
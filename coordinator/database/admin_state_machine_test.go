@@ -94,10 +94,72 @@ func (ts *testAdminStateMachineSuite) TestDatabaseShardAssign(c *check.C) {
 		NumOfShard:    10,
 		ReplicaFactor: 3,
 	},
-		check.Equals,
+		check.DeepEquals,
 		shardAssign.Config)
 
 	c.Assert(true, check.Equals, util.Exist(filepath.Join(testPath, "test", "shard")))
+
+	// split shard
+	newShardID, err := stateMachine.SplitShard("test", "storage1", 0)
+	if err != nil {
+		c.Fatal(err)
+	}
+	c.Assert(newShardID, check.Equals, 10)
+	shardAssign, _ = cluster.GetShardAssign("test")
+	c.Assert(shardAssign.Epoch, check.Equals, int64(1))
+	c.Assert(shardAssign.Shards[newShardID].Replicas, check.DeepEquals, shardAssign.Shards[0].Replicas)
+	c.Assert(shardAssign.Shards[0].Range.End+1, check.Equals, shardAssign.Shards[newShardID].Range.Start)
+	_, err = stateMachine.SplitShard("test", "storage1", 100)
+	c.Assert(err, check.NotNil)
+	_, err = stateMachine.SplitShard("test", "storage_not_exist", 0)
+	c.Assert(err, check.NotNil)
+
+	// reassign shard, increases routing epoch
+	nodes := []models.Node{{IP: "127.0.0.1", Port: 2080}, {IP: "127.0.0.5", Port: 2080}}
+	err = stateMachine.ReassignShard("test", "storage1", 1, nodes)
+	c.Assert(err, check.IsNil)
+	shardAssign, _ = cluster.GetShardAssign("test")
+	c.Assert(shardAssign.Epoch, check.Equals, int64(2))
+	var replicas []models.Node
+	for _, nodeID := range shardAssign.Shards[1].Replicas {
+		replicas = append(replicas, shardAssign.Nodes[nodeID])
+	}
+	c.Assert(replicas, check.DeepEquals, nodes)
+	err = stateMachine.ReassignShard("test", "storage1", 1, []models.Node{{IP: "127.0.0.9", Port: 2080}})
+	c.Assert(err, check.NotNil)
+	err = stateMachine.ReassignShard("test", "storage1", 100, nodes)
+	c.Assert(err, check.NotNil)
+	err = stateMachine.ReassignShard("test", "storage_not_exist", 1, nodes)
+	c.Assert(err, check.NotNil)
+	// the replicas violate the placement constraints, the nodes without label aren't spread
+	shardAssign, revision, _ := cluster.GetShardAssignWithRevision("test")
+	shardAssign.Config.Placement = &models.Placement{SpreadBy: "zone"}
+	c.Assert(cluster.SaveShardAssign("test", shardAssign, revision), check.IsNil)
+	err = stateMachine.ReassignShard("test", "storage1", 1, nodes)
+	c.Assert(err, check.NotNil)
+	shardAssign, _ = cluster.GetShardAssign("test")
+	c.Assert(shardAssign.Epoch, check.Equals, int64(2))
+	shardAssign, revision, _ = cluster.GetShardAssignWithRevision("test")
+	shardAssign.Config.Placement = nil
+	c.Assert(cluster.SaveShardAssign("test", shardAssign, revision), check.IsNil)
+
+	// shards are being changed by others
+	lock := repo.NewMutex(pathutil.GetShardLockPath("test"), []byte("other"), 1)
+	_, _ = lock.Lock(context.TODO())
+	_, err = stateMachine.SplitShard("test", "storage1", 0)
+	c.Assert(err, check.NotNil)
+	err = stateMachine.ReassignShard("test", "storage1", 1, nodes)
+	c.Assert(err, check.NotNil)
+	_, err = stateMachine.SplitHotShards("test", "storage1", nil)
+	c.Assert(err, check.NotNil)
+	_ = lock.Unlock(context.TODO())
+
+	// split hot shards, no split option
+	shardIDs, err := stateMachine.SplitHotShards("test", "storage1", []models.ShardStat{{ShardID: 1, NumOfSeries: 100}})
+	c.Assert(err, check.IsNil)
+	c.Assert(shardIDs, check.IsNil)
+	_, err = stateMachine.SplitHotShards("test", "storage_not_exist", nil)
+	c.Assert(err, check.NotNil)
 }
 
 func (ts *testAdminStateMachineSuite) TestWrongCfg(c *check.C) {
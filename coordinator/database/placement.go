@@ -0,0 +1,121 @@
+package database
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/eleme/lindb/models"
+)
+
+// PlacementShardAssignment assigns replica list for each shard of database based on the placement constraints,
+// only the storage nodes which match the require labels are selected.
+// If spread by label is set, the replicas of each shard are placed on the nodes with different label values:
+// 1. Group the selected nodes by label value, the groups are ordered by label value.
+// 2. The groups of shard's replicas are consecutive groups, starting from the shard id with a random shift.
+// 3. The node of each group is picked by round-robin in the group.
+// If no spread by label, assigns replica list for the selected nodes same as ShardAssignment.
+func PlacementShardAssignment(nodes map[int]models.Node, cluster models.DatabaseCluster) (*models.ShardAssignment, error) {
+	placement := cluster.Placement
+	var nodeIDs []int
+	for nodeID, node := range nodes {
+		if placement.Match(node.Labels) {
+			nodeIDs = append(nodeIDs, nodeID)
+		}
+	}
+	sort.Ints(nodeIDs)
+	if len(nodeIDs) == 0 {
+		return nil, fmt.Errorf("shard assign error for cluster[%s], because no storage node matches placement",
+			cluster.Name)
+	}
+	if placement == nil || len(placement.SpreadBy) == 0 {
+		return ShardAssignment(nodeIDs, cluster)
+	}
+	if cluster.NumOfShard <= 0 {
+		return nil, fmt.Errorf("shard assign error for cluster[%s], because num. of shard <=0", cluster.Name)
+	}
+	if cluster.ReplicaFactor <= 0 {
+		return nil, fmt.Errorf("shard assign error for cluster[%s], bacause replica factor <=0", cluster.Name)
+	}
+
+	groups := groupNodesByLabel(nodes, nodeIDs, placement.SpreadBy)
+	numOfGroup := len(groups)
+	if cluster.ReplicaFactor > numOfGroup {
+		return nil,
+			fmt.Errorf("shard assign error for cluster[%s], bacause replica factor > num. of label[%s] values",
+				cluster.Name, placement.SpreadBy)
+	}
+	shardAssignment := models.NewShardAssignment()
+	startIndex := rand.Intn(numOfGroup)
+	// next node index of each group
+	nextNodes := make([]int, numOfGroup)
+	for shardID := 0; shardID < cluster.NumOfShard; shardID++ {
+		for j := 0; j < cluster.ReplicaFactor; j++ {
+			groupIndex := (shardID + startIndex + j) % numOfGroup
+			group := groups[groupIndex]
+			shardAssignment.AddReplica(shardID, group[nextNodes[groupIndex]%len(group)])
+			nextNodes[groupIndex]++
+		}
+	}
+	return shardAssignment, nil
+}
+
+// CheckPlacement checks if the shards of assignment satisfy the placement constraints of database,
+// all shards are checked if no shard ids, returns the error of the first replica which violates the constraints.
+// It is used to validate the assignment before changing it, e.g. moving replicas when rebalancing.
+func CheckPlacement(shardAssign *models.ShardAssignment, cluster models.DatabaseCluster, shardIDs ...int) error {
+	placement := cluster.Placement
+	if placement == nil {
+		return nil
+	}
+	if len(shardIDs) == 0 {
+		for shardID := range shardAssign.Shards {
+			shardIDs = append(shardIDs, shardID)
+		}
+	}
+	for _, shardID := range shardIDs {
+		replica, ok := shardAssign.Shards[shardID]
+		if !ok {
+			return fmt.Errorf("shard[%d] not exist", shardID)
+		}
+		values := make(map[string]bool)
+		for _, nodeID := range replica.Replicas {
+			node, ok := shardAssign.Nodes[nodeID]
+			if !ok {
+				return fmt.Errorf("node[%d] of shard[%d] not exist", nodeID, shardID)
+			}
+			if !placement.Match(node.Labels) {
+				return fmt.Errorf("node[%s] of shard[%d] doesn't match require labels", (&node).String(), shardID)
+			}
+			if len(placement.SpreadBy) == 0 {
+				continue
+			}
+			value := node.Labels[placement.SpreadBy]
+			if values[value] {
+				return fmt.Errorf("replicas of shard[%d] aren't spread by label[%s]", shardID, placement.SpreadBy)
+			}
+			values[value] = true
+		}
+	}
+	return nil
+}
+
+// groupNodesByLabel groups the nodes by the value of label, the groups are ordered by label value,
+// the node without the label is grouped with empty value.
+func groupNodesByLabel(nodes map[int]models.Node, nodeIDs []int, label string) [][]int {
+	groupMap := make(map[string][]int)
+	var values []string
+	for _, nodeID := range nodeIDs {
+		value := nodes[nodeID].Labels[label]
+		if _, ok := groupMap[value]; !ok {
+			values = append(values, value)
+		}
+		groupMap[value] = append(groupMap[value], nodeID)
+	}
+	sort.Strings(values)
+	groups := make([][]int, len(values))
+	for idx, value := range values {
+		groups[idx] = groupMap[value]
+	}
+	return groups
+}
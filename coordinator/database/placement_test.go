@@ -0,0 +1,111 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+)
+
+func buildPlacementNodes() map[int]models.Node {
+	return map[int]models.Node{
+		0: {IP: "1.1.1.1", Port: 2000, Labels: map[string]string{"zone": "a", "disk": "ssd"}},
+		1: {IP: "1.1.1.2", Port: 2000, Labels: map[string]string{"zone": "a", "disk": "hdd"}},
+		2: {IP: "1.1.1.3", Port: 2000, Labels: map[string]string{"zone": "b", "disk": "ssd"}},
+		3: {IP: "1.1.1.4", Port: 2000, Labels: map[string]string{"zone": "b", "disk": "ssd"}},
+		4: {IP: "1.1.1.5", Port: 2000, Labels: map[string]string{"zone": "c", "disk": "ssd"}},
+	}
+}
+
+func TestPlacementShardAssignment(t *testing.T) {
+	nodes := buildPlacementNodes()
+	// no placement
+	cluster := models.DatabaseCluster{Name: "test", NumOfShard: 10, ReplicaFactor: 3}
+	shardAssign, err := PlacementShardAssignment(nodes, cluster)
+	assert.Nil(t, err)
+	checkShardAssignResult(shardAssign, t)
+
+	// spread by zone
+	cluster.Placement = &models.Placement{SpreadBy: "zone"}
+	shardAssign, err = PlacementShardAssignment(nodes, cluster)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, len(shardAssign.Shards))
+	shardAssign.Nodes = nodes
+	assert.Nil(t, CheckPlacement(shardAssign, cluster))
+	// all nodes are used
+	used := make(map[int]bool)
+	for _, replica := range shardAssign.Shards {
+		assert.Equal(t, 3, len(replica.Replicas))
+		for _, nodeID := range replica.Replicas {
+			used[nodeID] = true
+		}
+	}
+	assert.Equal(t, 5, len(used))
+
+	// require ssd and spread by zone
+	cluster.Placement = &models.Placement{Require: map[string]string{"disk": "ssd"}, SpreadBy: "zone"}
+	shardAssign, err = PlacementShardAssignment(nodes, cluster)
+	assert.Nil(t, err)
+	shardAssign.Nodes = nodes
+	assert.Nil(t, CheckPlacement(shardAssign, cluster))
+	for _, replica := range shardAssign.Shards {
+		for _, nodeID := range replica.Replicas {
+			assert.NotEqual(t, 1, nodeID)
+		}
+	}
+
+	// not enough zones
+	cluster.ReplicaFactor = 4
+	_, err = PlacementShardAssignment(nodes, cluster)
+	assert.NotNil(t, err)
+
+	// require ssd without spread
+	cluster.ReplicaFactor = 3
+	cluster.Placement = &models.Placement{Require: map[string]string{"disk": "ssd"}}
+	shardAssign, err = PlacementShardAssignment(nodes, cluster)
+	assert.Nil(t, err)
+	shardAssign.Nodes = nodes
+	assert.Nil(t, CheckPlacement(shardAssign, cluster))
+
+	// no node matches
+	cluster.Placement = &models.Placement{Require: map[string]string{"disk": "nvme"}}
+	_, err = PlacementShardAssignment(nodes, cluster)
+	assert.NotNil(t, err)
+
+	// bad config
+	cluster.Placement = &models.Placement{SpreadBy: "zone"}
+	cluster.NumOfShard = 0
+	_, err = PlacementShardAssignment(nodes, cluster)
+	assert.NotNil(t, err)
+	cluster.NumOfShard = 10
+	cluster.ReplicaFactor = 0
+	_, err = PlacementShardAssignment(nodes, cluster)
+	assert.NotNil(t, err)
+}
+
+func TestCheckPlacement(t *testing.T) {
+	nodes := buildPlacementNodes()
+	cluster := models.DatabaseCluster{Name: "test", NumOfShard: 1, ReplicaFactor: 2}
+	shardAssign := models.NewShardAssignment()
+	shardAssign.Nodes = nodes
+	shardAssign.AddReplica(0, 0)
+	shardAssign.AddReplica(0, 1)
+	assert.Nil(t, CheckPlacement(shardAssign, cluster))
+
+	cluster.Placement = &models.Placement{SpreadBy: "zone"}
+	assert.NotNil(t, CheckPlacement(shardAssign, cluster))
+
+	cluster.Placement = &models.Placement{Require: map[string]string{"disk": "ssd"}}
+	assert.NotNil(t, CheckPlacement(shardAssign, cluster))
+	assert.NotNil(t, CheckPlacement(shardAssign, cluster, 0))
+	// only the shards of ids are checked
+	shardAssign.AddReplica(1, 2)
+	assert.Nil(t, CheckPlacement(shardAssign, cluster, 1))
+	assert.NotNil(t, CheckPlacement(shardAssign, cluster, 1, 0))
+	assert.NotNil(t, CheckPlacement(shardAssign, cluster, 100))
+
+	shardAssign.AddReplica(0, 10)
+	cluster.Placement = &models.Placement{}
+	assert.NotNil(t, CheckPlacement(shardAssign, cluster))
+}
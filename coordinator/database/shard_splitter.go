@@ -0,0 +1,173 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/eleme/lindb/coordinator/storage"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/state"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/service"
+)
+
+const (
+	// splitCooldownIntervals is the number of intervals which the shards of database aren't split again after split,
+	// because the statistics of split shard include the moved series until they are migrated and evicted
+	splitCooldownIntervals = 10
+	// staleStatsIntervals is the number of intervals after which the statistics reported by node are stale,
+	// e.g. the node is down
+	staleStatsIntervals = 3
+)
+
+// ShardSplitter splits the hot shards of databases periodically when node is master, the shard is hot if
+// the statistics reported by storage nodes(see models.NodeStats) exceed the split option of database cluster.
+type ShardSplitter interface {
+	// SplitHotShards splits the hot shards of all databases, returns the ids of split shards keyed by database
+	SplitHotShards() (map[string][]int, error)
+	// Close stops the splitter
+	Close()
+}
+
+// shardSplitter implements ShardSplitter interface
+type shardSplitter struct {
+	databaseService service.DatabaseService
+	storageCluster  storage.ClusterStateMachine
+	admin           AdminStateMachine
+	interval        time.Duration
+	// lastSplit is the time(ms) of last split of database, which is only accessed by the goroutine of splitter
+	lastSplit map[string]int64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	log *logger.Logger
+}
+
+// NewShardSplitter creates shard splitter, runs splitting periodically in background
+func NewShardSplitter(ctx context.Context, repo state.Repository, storageCluster storage.ClusterStateMachine,
+	admin AdminStateMachine, interval time.Duration) (ShardSplitter, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("shard split interval must be positive")
+	}
+	c, cancel := context.WithCancel(ctx)
+	s := &shardSplitter{
+		databaseService: service.NewDatabaseService(repo),
+		storageCluster:  storageCluster,
+		admin:           admin,
+		interval:        interval,
+		lastSplit:       make(map[string]int64),
+		ctx:             c,
+		cancel:          cancel,
+		log:             logger.GetLogger("coordinator/shard/splitter"),
+	}
+	go s.run()
+	s.log.Info("shard splitter started", logger.String("interval", interval.String()))
+	return s, nil
+}
+
+// SplitHotShards splits the hot shards of the databases which have split option,
+// the storage cluster whose statistics cannot be listed is skipped.
+func (s *shardSplitter) SplitHotShards() (map[string][]int, error) {
+	databases, err := s.databaseService.List()
+	if err != nil {
+		return nil, fmt.Errorf("list databases error:%s", err)
+	}
+	now := timeutil.Now()
+	intervalMillis := s.interval.Nanoseconds() / int64(time.Millisecond)
+	result := make(map[string][]int)
+	for _, database := range databases {
+		if lastSplit, ok := s.lastSplit[database.Name]; ok && now-lastSplit < splitCooldownIntervals*intervalMillis {
+			continue
+		}
+		for _, databaseCluster := range database.Clusters {
+			if databaseCluster.SplitOption == nil {
+				continue
+			}
+			cluster := s.storageCluster.GetCluster(databaseCluster.Name)
+			if cluster == nil {
+				continue
+			}
+			nodes, err := service.NewDatabaseStatsService(cluster.GetRepo()).ListNodeStats()
+			if err != nil {
+				s.log.Error("list stats of storage nodes error", logger.String("cluster", databaseCluster.Name),
+					logger.Error(err))
+				continue
+			}
+			stats := mergeShardStats(database.Name, nodes, now-staleStatsIntervals*intervalMillis)
+			if len(stats) == 0 {
+				continue
+			}
+			shardIDs, err := s.admin.SplitHotShards(database.Name, databaseCluster.Name, stats)
+			if err != nil {
+				s.log.Error("split hot shards error", logger.String("database", database.Name),
+					logger.String("cluster", databaseCluster.Name), logger.Error(err))
+			}
+			if len(shardIDs) > 0 {
+				result[database.Name] = append(result[database.Name], shardIDs...)
+				s.lastSplit[database.Name] = now
+			}
+		}
+	}
+	return result, nil
+}
+
+// Close stops the splitter
+func (s *shardSplitter) Close() {
+	s.cancel()
+}
+
+// run runs splitting periodically until splitter closed
+func (s *shardSplitter) run() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.SplitHotShards(); err != nil {
+				s.log.Error("split hot shards error", logger.Error(err))
+			}
+		}
+	}
+}
+
+// mergeShardStats merges the statistics of database's shards reported by the storage nodes after the time(ms),
+// the replicas of shard receive the same writes, so the max statistics of replicas are taken.
+// The result is sorted by shard id.
+func mergeShardStats(databaseName string, nodes []models.NodeStats, after int64) []models.ShardStat {
+	shards := make(map[int]*models.ShardStat)
+	for _, node := range nodes {
+		if node.Timestamp < after {
+			continue
+		}
+		for _, stat := range node.Shards {
+			if stat.Database != databaseName {
+				continue
+			}
+			merged, ok := shards[stat.ShardID]
+			if !ok {
+				merged = &models.ShardStat{Database: databaseName, ShardID: stat.ShardID}
+				shards[stat.ShardID] = merged
+			}
+			if stat.NumOfSeries > merged.NumOfSeries {
+				merged.NumOfSeries = stat.NumOfSeries
+			}
+			if stat.WriteRate > merged.WriteRate {
+				merged.WriteRate = stat.WriteRate
+			}
+		}
+	}
+	stats := make([]models.ShardStat, 0, len(shards))
+	for _, stat := range shards {
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].ShardID < stats[j].ShardID
+	})
+	return stats
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/eleme/lindb/constants"
+	"github.com/eleme/lindb/coordinator/storage"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/state"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/service"
+)
+
+// mockSplitAdmin records the shard stats of splitting hot shards
+type mockSplitAdmin struct {
+	AdminStateMachine
+	databases []string
+	stats     [][]models.ShardStat
+}
+
+func (a *mockSplitAdmin) SplitHotShards(databaseName, clusterName string,
+	stats []models.ShardStat) ([]int, error) {
+	a.databases = append(a.databases, databaseName+"/"+clusterName)
+	a.stats = append(a.stats, stats)
+	return []int{stats[len(stats)-1].ShardID}, nil
+}
+
+func (ts *testAdminStateMachineSuite) TestShardSplitter(c *check.C) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	repo, _ := state.NewRepo(state.Config{Namespace: "/shard/splitter", Endpoints: ts.Cluster.Endpoints})
+	storageCfg := state.Config{Namespace: "/shard/splitter/storage1", Endpoints: ts.Cluster.Endpoints}
+	data, _ := json.Marshal(models.StorageCluster{Name: "storage1", Config: storageCfg})
+	_ = repo.Put(context.TODO(), constants.StorageClusterConfigPath+"/storage1", data)
+	clusterStateMachine, _ := storage.NewClusterStateMachine(ctx, repo)
+	defer func() {
+		_ = clusterStateMachine.Close()
+	}()
+	for i := 0; i < 100 && clusterStateMachine.GetCluster("storage1") == nil; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cluster := clusterStateMachine.GetCluster("storage1")
+	c.Assert(cluster, check.NotNil)
+
+	databaseSRV := service.NewDatabaseService(repo)
+	_ = databaseSRV.Save(models.Database{Name: "db1", Clusters: []models.DatabaseCluster{
+		{Name: "storage1", NumOfShard: 3, ReplicaFactor: 2, ShardOption: validOption,
+			SplitOption: &models.SplitOption{MaxSeries: 10}},
+	}})
+	_ = databaseSRV.Save(models.Database{Name: "db2", Clusters: []models.DatabaseCluster{
+		{Name: "storage1", NumOfShard: 3, ReplicaFactor: 2, ShardOption: validOption},
+	}})
+	statsService := service.NewDatabaseStatsService(cluster.GetRepo())
+	now := timeutil.Now()
+	_ = statsService.Report(models.NodeStats{Node: "node-1", Timestamp: now, Shards: []models.ShardStat{
+		{Database: "db1", ShardID: 1, NumOfSeries: 5, WriteRate: 100},
+		{Database: "db1", ShardID: 2, NumOfSeries: 20},
+		{Database: "db2", ShardID: 1, NumOfSeries: 20},
+	}})
+	_ = statsService.Report(models.NodeStats{Node: "node-2", Timestamp: now, Shards: []models.ShardStat{
+		{Database: "db1", ShardID: 1, NumOfSeries: 15, WriteRate: 10},
+	}})
+	// the stats of node are stale
+	_ = statsService.Report(models.NodeStats{Node: "node-3", Timestamp: 1, Shards: []models.ShardStat{
+		{Database: "db1", ShardID: 3, NumOfSeries: 100},
+	}})
+
+	admin := &mockSplitAdmin{}
+	_, err := NewShardSplitter(ctx, repo, clusterStateMachine, admin, 0)
+	c.Assert(err, check.NotNil)
+	splitter, err := NewShardSplitter(ctx, repo, clusterStateMachine, admin, time.Hour)
+	c.Assert(err, check.IsNil)
+	defer splitter.Close()
+
+	// the max stats of replicas are taken
+	result, err := splitter.SplitHotShards()
+	c.Assert(err, check.IsNil)
+	c.Assert(result, check.DeepEquals, map[string][]int{"db1": {2}})
+	c.Assert(admin.databases, check.DeepEquals, []string{"db1/storage1"})
+	c.Assert(admin.stats, check.DeepEquals, [][]models.ShardStat{{
+		{Database: "db1", ShardID: 1, NumOfSeries: 15, WriteRate: 100},
+		{Database: "db1", ShardID: 2, NumOfSeries: 20},
+	}})
+
+	// the database isn't split again in cooldown
+	result, err = splitter.SplitHotShards()
+	c.Assert(err, check.IsNil)
+	c.Assert(result, check.HasLen, 0)
+	c.Assert(admin.databases, check.HasLen, 1)
+}
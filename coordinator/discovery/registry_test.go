@@ -44,7 +44,7 @@ func (ts *testRegistrySuite) TestRegister(c *check.C) {
 	nodeBytes, _ := repo.Get(context.TODO(), nodePath)
 	nodeInfo := models.Node{}
 	_ = json.Unmarshal(nodeBytes, &nodeInfo)
-	c.Assert(node, check.Equals, nodeInfo)
+	c.Assert(node, check.DeepEquals, nodeInfo)
 
 	// test re-register
 	_ = repo.Delete(context.TODO(), nodePath)
@@ -54,7 +54,7 @@ func (ts *testRegistrySuite) TestRegister(c *check.C) {
 	time.Sleep(500 * time.Millisecond)
 	nodeBytes, _ = repo.Get(context.TODO(), nodePath)
 	_ = json.Unmarshal(nodeBytes, &nodeInfo)
-	c.Assert(node, check.Equals, nodeInfo)
+	c.Assert(node, check.DeepEquals, nodeInfo)
 
 	_ = registry.Close()
 	time.Sleep(time.Second)
@@ -84,7 +84,7 @@ func (ts *testRegistrySuite) TestDeregister(c *check.C) {
 	nodeBytes, _ := repo.Get(context.TODO(), nodePath)
 	nodeInfo := models.Node{}
 	_ = json.Unmarshal(nodeBytes, &nodeInfo)
-	c.Assert(node, check.Equals, nodeInfo)
+	c.Assert(node, check.DeepEquals, nodeInfo)
 
 	_ = registry.Deregister(node)
 	time.Sleep(500 * time.Millisecond)
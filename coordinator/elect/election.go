@@ -3,10 +3,12 @@ package elect
 import (
 	"context"
 	"encoding/json"
+	"sync"
 	"time"
 
 	"github.com/eleme/lindb/models"
 	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/pathutil"
 	"github.com/eleme/lindb/pkg/state"
 	"github.com/eleme/lindb/pkg/timeutil"
 
@@ -14,9 +16,6 @@ import (
 	"go.uber.org/zap"
 )
 
-// masterPath represents master elect path
-const masterPath = "/master/node"
-
 // Listener represent master change callback interface
 type Listener interface {
 	// OnFailOver triggers master fail-over, current node become master
@@ -46,6 +45,10 @@ type election struct {
 
 	listener Listener
 
+	// lock is the distributed lock of master path held by current node
+	lock      state.Mutex
+	lockMutex sync.Mutex
+
 	ctx    context.Context
 	cancel context.CancelFunc
 
@@ -73,7 +76,7 @@ func NewElection(repo state.Repository, node models.Node, ttl int64, listener Li
 // Initialize initializes election, such as master change watch
 func (e *election) Initialize() {
 	// watch master change event
-	watchEventChan := e.repo.Watch(e.ctx, masterPath)
+	watchEventChan := e.repo.Watch(e.ctx, pathutil.Keys.Masters.Node)
 
 	go func() {
 		e.handlerMasterChange(watchEventChan)
@@ -108,7 +111,13 @@ func (e *election) elect() {
 		masterBytes, err := json.Marshal(master)
 		var result bool
 		if err == nil {
-			result, _, err = e.repo.PutIfNotExist(e.ctx, masterPath, masterBytes, e.ttl)
+			lock := e.repo.NewMutex(pathutil.Keys.Masters.Node, masterBytes, e.ttl)
+			result, _, err = lock.TryLock(e.ctx)
+			if result {
+				e.lockMutex.Lock()
+				e.lock = lock
+				e.lockMutex.Unlock()
+			}
 		}
 		if err != nil {
 			log.Warn("got an error when master elect, sleep 500ms then retry",
@@ -134,14 +143,19 @@ func (e *election) Close() {
 	e.cancel()
 }
 
-// resign resigns master role, delete master elect node
+// resign resigns master role, releases the lock of master path, then master elect node is deleted
 func (e *election) resign() {
-	if e.isMaster.Load() {
-		if err := e.repo.Delete(e.ctx, masterPath); err != nil {
-			e.log.Error("delete master path failed", zap.Error(err))
+	e.lockMutex.Lock()
+	lock := e.lock
+	e.lock = nil
+	e.lockMutex.Unlock()
+
+	if lock != nil {
+		if err := lock.Unlock(e.ctx); err != nil {
+			e.log.Error("release master lock failed", zap.Error(err))
 		}
-		e.isMaster.Store(false)
 	}
+	e.isMaster.Store(false)
 }
 
 // handlerMasterChange handles the event of master change,
@@ -156,12 +170,14 @@ func (e *election) handlerMasterChange(eventChan state.WatchEventChan) {
 		switch event.Type {
 		case state.EventTypeDelete:
 			log.Info("master node lost, retry elect new master")
+			// NOTICE: the delete event of old master may be received after current node acquired the lock,
+			// so only resign after current node became master, otherwise the lock of new master is released.
 			if e.isMaster.Load() {
 				// current node is master, do resignation when master delete is deleted
 				log.Info("current node is master, do resig when master node is deleted")
 				e.listener.OnResignation()
+				e.resign()
 			}
-			e.resign()
 			// notify try elect master
 			e.retryCh <- 1
 		case state.EventTypeAll:
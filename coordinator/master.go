@@ -3,7 +3,9 @@ package coordinator
 import (
 	"context"
 	"sync"
+	"time"
 
+	"github.com/eleme/lindb/config"
 	coCtx "github.com/eleme/lindb/coordinator/context"
 	"github.com/eleme/lindb/coordinator/database"
 	"github.com/eleme/lindb/coordinator/elect"
@@ -27,8 +29,10 @@ type Master interface {
 
 // master implements master interface
 type master struct {
-	node models.Node
-	repo state.Repository
+	node      models.Node
+	repo      state.Repository
+	backupCfg config.Backup
+	statsCfg  config.DatabaseStats
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -41,15 +45,19 @@ type master struct {
 	log *logger.Logger
 }
 
-// NewMaster create master for current node
-func NewMaster(repo state.Repository, node models.Node, ttl int64) Master {
+// NewMaster create master for current node, master runs backup scheduler if backup is enabled,
+// and runs stats aggregator if database stats is enabled
+func NewMaster(repo state.Repository, node models.Node, ttl int64,
+	backupCfg config.Backup, statsCfg config.DatabaseStats) Master {
 	ctx, cancel := context.WithCancel(context.Background())
 	m := &master{
-		repo:   repo,
-		node:   node,
-		ctx:    ctx,
-		cancel: cancel,
-		log:    logger.GetLogger("coordinator/master"),
+		repo:      repo,
+		node:      node,
+		backupCfg: backupCfg,
+		statsCfg:  statsCfg,
+		ctx:       ctx,
+		cancel:    cancel,
+		log:       logger.GetLogger("coordinator/master"),
 	}
 	// create master election
 	m.elect = elect.NewElection(repo, node, ttl, m)
@@ -76,6 +84,33 @@ func (m *master) OnFailOver() {
 	}
 	stateMachine.DatabaseAdmin = databaseAdmin
 
+	if m.backupCfg.Enabled {
+		backupScheduler, err := storage.NewBackupScheduler(m.ctx, m.repo, storageCluster, m.backupCfg)
+		if err != nil {
+			m.log.Error("start backup scheduler error", logger.Error(err))
+		} else {
+			stateMachine.BackupScheduler = backupScheduler
+		}
+	}
+
+	if m.statsCfg.ReportInterval > 0 {
+		statsAggregator, err := storage.NewStatsAggregator(m.ctx, m.repo, storageCluster,
+			time.Duration(m.statsCfg.ReportInterval)*time.Millisecond)
+		if err != nil {
+			m.log.Error("start stats aggregator error", logger.Error(err))
+		} else {
+			stateMachine.StatsAggregator = statsAggregator
+		}
+		// split the hot shards by the shard stats reported by storage nodes
+		shardSplitter, err := database.NewShardSplitter(m.ctx, m.repo, storageCluster, databaseAdmin,
+			time.Duration(m.statsCfg.ReportInterval)*time.Millisecond)
+		if err != nil {
+			m.log.Error("start shard splitter error", logger.Error(err))
+		} else {
+			stateMachine.ShardSplitter = shardSplitter
+		}
+	}
+
 	m.masterCtx = coCtx.NewMasterContext(stateMachine)
 
 	//FIXME resign if init master context
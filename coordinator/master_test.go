@@ -6,6 +6,7 @@ import (
 
 	"gopkg.in/check.v1"
 
+	"github.com/eleme/lindb/config"
 	"github.com/eleme/lindb/mock"
 	"github.com/eleme/lindb/models"
 	"github.com/eleme/lindb/pkg/state"
@@ -26,7 +27,7 @@ func (ts *testMasterSuite) TestMasterElect(c *check.C) {
 		Endpoints: ts.Cluster.Endpoints,
 	})
 	node1 := models.Node{IP: "1.1.1.1", Port: 8000}
-	master1 := NewMaster(repo, node1, 1)
+	master1 := NewMaster(repo, node1, 1, config.Backup{}, config.DatabaseStats{})
 	_ = master1.Start()
 	time.Sleep(400 * time.Millisecond)
 	c.Assert(true, check.Equals, master1.IsMaster())
@@ -36,7 +37,7 @@ func (ts *testMasterSuite) TestMasterElect(c *check.C) {
 		Endpoints: ts.Cluster.Endpoints,
 	})
 	node2 := models.Node{IP: "1.1.1.2", Port: 8000}
-	master2 := NewMaster(repo2, node2, 1)
+	master2 := NewMaster(repo2, node2, 1, config.Backup{}, config.DatabaseStats{})
 	_ = master2.Start()
 	time.Sleep(400 * time.Millisecond)
 	c.Assert(false, check.Equals, master2.IsMaster())
@@ -0,0 +1,239 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/constants"
+	"github.com/eleme/lindb/coordinator/task"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/backup"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/pathutil"
+	"github.com/eleme/lindb/pkg/state"
+	"github.com/eleme/lindb/pkg/timeutil"
+)
+
+// backupIDLayout is the time layout of backup set id, which is ordered by create time
+const backupIDLayout = "20060102150405"
+
+// BackupScheduler represents the scheduler which backups all databases' shards periodically when node is master,
+// after backup, deletes the expired backup sets by retention.
+// If incremental is enabled, the new backup set is based on the latest complete backup set.
+type BackupScheduler interface {
+	// Backup creates backup set, then submits backup tasks of all databases into related storage cluster
+	Backup() (*backup.SetManifest, error)
+	// Close stops the scheduler
+	Close()
+}
+
+// backupScheduler implements backup scheduler interface
+type backupScheduler struct {
+	repo           state.Repository
+	storageCluster ClusterStateMachine
+	catalog        backup.Catalog
+	cfg            config.Backup
+	now            func() int64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	log *logger.Logger
+}
+
+// NewBackupScheduler creates backup scheduler, runs backup periodically in background
+func NewBackupScheduler(ctx context.Context, repo state.Repository,
+	storageCluster ClusterStateMachine, cfg config.Backup) (BackupScheduler, error) {
+	if cfg.Interval <= 0 {
+		return nil, fmt.Errorf("backup interval must be positive")
+	}
+	target, err := backup.NewTarget(cfg.Target)
+	if err != nil {
+		return nil, fmt.Errorf("create backup target error:%s", err)
+	}
+	c, cancel := context.WithCancel(ctx)
+	s := &backupScheduler{
+		repo:           repo,
+		storageCluster: storageCluster,
+		catalog:        backup.NewCatalog(target),
+		cfg:            cfg,
+		now:            timeutil.Now,
+		ctx:            c,
+		cancel:         cancel,
+		log:            logger.GetLogger("coordinator/backup/scheduler"),
+	}
+	go s.run()
+	s.log.Info("backup scheduler started", logger.Any("config", cfg))
+	return s, nil
+}
+
+// Backup creates backup set, then submits backup tasks of all databases into related storage cluster.
+// For each shard, picks one active replica for backup, the part of backup set is the shards in one node.
+// The backup lock is held while creating backup set, so that the old and new master don't backup at the same time.
+func (s *backupScheduler) Backup() (*backup.SetManifest, error) {
+	now := s.now()
+	lock := s.repo.NewMutex(pathutil.Keys.Masters.BackupLock, []byte(timeutil.FormatTimestamp(now, backupIDLayout)), 0)
+	acquired, _, err := lock.TryLock(s.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire backup lock error:%s", err)
+	}
+	if !acquired {
+		return nil, fmt.Errorf("backup is running by others")
+	}
+	defer func() {
+		if err := lock.Unlock(s.ctx); err != nil {
+			s.log.Error("release backup lock error", logger.Error(err))
+		}
+	}()
+	databases, err := s.repo.List(s.ctx, pathutil.Keys.Databases.Configs.Prefix())
+	if err != nil {
+		return nil, fmt.Errorf("get database list error:%s", err)
+	}
+	set := &backup.SetManifest{
+		ID:         timeutil.FormatTimestamp(now, backupIDLayout),
+		CreateTime: now,
+	}
+	if _, err := s.catalog.GetSet(set.ID); err != backup.ErrNotExist {
+		return nil, fmt.Errorf("backup set[%s] already exist", set.ID)
+	}
+	if s.cfg.Incremental {
+		sets, err := s.catalog.ListSets()
+		if err != nil {
+			return nil, fmt.Errorf("list backup sets error:%s", err)
+		}
+		if latest := backup.LatestComplete(sets); latest != nil {
+			set.Base = latest.ID
+		}
+	}
+	var tasks = make(map[Cluster][]task.ControllerTaskParam)
+	for _, data := range databases {
+		database := models.Database{}
+		if err := json.Unmarshal(data, &database); err != nil {
+			s.log.Error("unmarshal database config error", logger.String("data", string(data)), logger.Error(err))
+			continue
+		}
+		for _, clusterCfg := range database.Clusters {
+			cluster := s.storageCluster.GetCluster(clusterCfg.Name)
+			if cluster == nil {
+				s.log.Error("storage cluster not exist", logger.String("cluster", clusterCfg.Name))
+				continue
+			}
+			shardAssign, err := cluster.GetShardAssign(database.Name)
+			if err != nil {
+				s.log.Error("get shard assign error",
+					logger.String("database", database.Name), logger.Error(err))
+				continue
+			}
+			parts := s.buildParts(clusterCfg.Name, database.Name, shardAssign, cluster.GetActiveNodes())
+			for _, part := range parts {
+				tasks[cluster] = append(tasks[cluster], task.ControllerTaskParam{
+					NodeID: part.Node,
+					Params: models.BackupTask{BackupID: set.ID, Base: set.Base, Part: part, Target: s.cfg.Target},
+				})
+			}
+			set.Parts = append(set.Parts, parts...)
+		}
+	}
+	if len(set.Parts) == 0 {
+		return nil, fmt.Errorf("there is no shard need backup")
+	}
+	// save backup set before submitting tasks, then the progress of backup set can be inspected
+	if err := s.catalog.SaveSet(set); err != nil {
+		return nil, fmt.Errorf("save backup set error:%s", err)
+	}
+	for cluster, params := range tasks {
+		if err := cluster.SubmitTask(constants.Backup, fmt.Sprintf("backup-%s", set.ID), params); err != nil {
+			s.log.Error("submit backup task error", logger.String("backup", set.ID), logger.Error(err))
+		}
+	}
+	return set, nil
+}
+
+// Close stops the scheduler
+func (s *backupScheduler) Close() {
+	s.cancel()
+}
+
+// run runs backup periodically until scheduler closed
+func (s *backupScheduler) run() {
+	ticker := time.NewTicker(time.Duration(s.cfg.Interval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			set, err := s.Backup()
+			if err != nil {
+				s.log.Error("backup error", logger.Error(err))
+			} else {
+				s.log.Info("backup set created", logger.String("backup", set.ID))
+			}
+			s.deleteExpiredSets()
+		}
+	}
+}
+
+// deleteExpiredSets deletes the backup sets which exceed retention
+func (s *backupScheduler) deleteExpiredSets() {
+	sets, err := s.catalog.ListSets()
+	if err != nil {
+		s.log.Error("list backup sets error", logger.Error(err))
+		return
+	}
+	for _, set := range backup.ExpiredSets(sets, s.cfg.Retention) {
+		if err := s.catalog.DeleteSet(set.ID); err != nil {
+			s.log.Error("delete expired backup set error", logger.String("backup", set.ID), logger.Error(err))
+			continue
+		}
+		s.log.Info("delete expired backup set", logger.String("backup", set.ID))
+	}
+}
+
+// buildParts builds the backup parts of database, picks the first active replica of each shard.
+func (s *backupScheduler) buildParts(clusterName, databaseName string,
+	shardAssign *models.ShardAssignment, activeNodes []models.Node) []backup.Part {
+	actives := make(map[string]bool)
+	for _, node := range activeNodes {
+		actives[(&node).Key()] = true
+	}
+	var shardIDs []int
+	for shardID := range shardAssign.Shards {
+		shardIDs = append(shardIDs, shardID)
+	}
+	sort.Ints(shardIDs)
+
+	parts := make(map[string]*backup.Part)
+	var nodes []string
+	for _, shardID := range shardIDs {
+		picked := ""
+		for _, replicaID := range shardAssign.Shards[shardID].Replicas {
+			node, ok := shardAssign.Nodes[replicaID]
+			if ok && actives[(&node).Key()] {
+				picked = (&node).Key()
+				break
+			}
+		}
+		if len(picked) == 0 {
+			s.log.Error("there is no active replica for backup",
+				logger.String("database", databaseName), logger.Any("shard", shardID))
+			continue
+		}
+		part, ok := parts[picked]
+		if !ok {
+			part = &backup.Part{Cluster: clusterName, Database: databaseName, Node: picked}
+			parts[picked] = part
+			nodes = append(nodes, picked)
+		}
+		part.ShardIDs = append(part.ShardIDs, shardID)
+	}
+	var result []backup.Part
+	for _, node := range nodes {
+		result = append(result, *parts[node])
+	}
+	return result
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/constants"
+	"github.com/eleme/lindb/coordinator/discovery"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/backup"
+	"github.com/eleme/lindb/pkg/interval"
+	"github.com/eleme/lindb/pkg/pathutil"
+	"github.com/eleme/lindb/pkg/state"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/pkg/util"
+	"github.com/eleme/lindb/service"
+	"github.com/eleme/lindb/tsdb"
+)
+
+// mockClusterStateMachine returns the storage cluster by name for testing
+type mockClusterStateMachine struct {
+	clusters map[string]Cluster
+}
+
+func (sm *mockClusterStateMachine) OnCreate(key string, resource []byte) {}
+func (sm *mockClusterStateMachine) OnDelete(key string)                  {}
+func (sm *mockClusterStateMachine) Cleanup()                             {}
+func (sm *mockClusterStateMachine) GetCluster(name string) Cluster       { return sm.clusters[name] }
+func (sm *mockClusterStateMachine) Close() error                         { return nil }
+func (sm *mockClusterStateMachine) GetAllCluster() []Cluster {
+	var clusters []Cluster
+	for _, cluster := range sm.clusters {
+		clusters = append(clusters, cluster)
+	}
+	return clusters
+}
+
+func (ts *testTaskExecutorSuite) TestBackup(c *check.C) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	storageService := service.NewStorageService(config.Engine{Path: filepath.Join(testPath, "data")})
+	storageCfg := state.Config{Namespace: "/backup/storage", Endpoints: ts.Cluster.Endpoints}
+	repo, _ := state.NewRepo(storageCfg)
+	node := models.Node{IP: "127.0.0.6", Port: 2080}
+	taskExecutor := NewTaskExecutor(ctx, &node, repo, storageService)
+	taskExecutor.Run()
+	defer func() {
+		_ = taskExecutor.Close()
+	}()
+	_ = discovery.NewRegistry(repo, constants.ActiveNodesPath, 100).Register(node)
+
+	cluster, _ := newCluster(ctx, models.StorageCluster{Name: "test", Config: storageCfg})
+	defer cluster.Close()
+	shardAssign := models.NewShardAssignment()
+	shardAssign.Nodes[1] = node
+	shardAssign.Nodes[2] = models.Node{IP: "127.0.0.7", Port: 2080}
+	shardAssign.AddReplica(1, 2)
+	shardAssign.AddReplica(1, 1)
+	shardAssign.AddReplica(2, 1)
+	shardAssign.AddReplica(3, 2)
+	shardAssign.Config = models.DatabaseCluster{ShardOption: validOption}
+	_ = cluster.SaveShardAssign("test", shardAssign, service.AnyRevision)
+	time.Sleep(200 * time.Millisecond)
+
+	brokerRepo, _ := state.NewRepo(state.Config{Namespace: "/backup/broker", Endpoints: ts.Cluster.Endpoints})
+	database, _ := json.Marshal(models.Database{Name: "test", Clusters: []models.DatabaseCluster{{Name: "test"}, {Name: "not_exist"}}})
+	_ = brokerRepo.Put(ctx, pathutil.GetDatabaseConfigPath("test"), database)
+	_ = brokerRepo.Put(ctx, pathutil.GetDatabaseConfigPath("bad"), []byte("bad"))
+
+	stateMachine := &mockClusterStateMachine{clusters: map[string]Cluster{"test": cluster}}
+	cfg := config.Backup{
+		Interval:  3600,
+		Retention: 1,
+		Target:    backup.Config{URL: filepath.Join(testPath, "backup")},
+	}
+	_, err := NewBackupScheduler(ctx, brokerRepo, stateMachine, config.Backup{Target: cfg.Target})
+	c.Assert(err, check.NotNil)
+	_, err = NewBackupScheduler(ctx, brokerRepo, stateMachine, config.Backup{Interval: 10})
+	c.Assert(err, check.NotNil)
+
+	scheduler, err := NewBackupScheduler(ctx, brokerRepo, stateMachine, cfg)
+	c.Assert(err, check.IsNil)
+	defer scheduler.Close()
+
+	// expired backup set
+	catalog := backup.NewCatalog(scheduler.(*backupScheduler).catalog.Target())
+	_ = catalog.SaveSet(&backup.SetManifest{ID: "20000101000000"})
+
+	set, err := scheduler.Backup()
+	c.Assert(err, check.IsNil)
+	// shard 3 hasn't active replica
+	c.Assert(set.Parts, check.DeepEquals, []backup.Part{
+		{Cluster: "test", Database: "test", Node: node.String(), ShardIDs: []int{1, 2}},
+	})
+	time.Sleep(300 * time.Millisecond)
+
+	info, err := catalog.GetSet(set.ID)
+	c.Assert(err, check.IsNil)
+	c.Assert(info.Complete, check.Equals, true)
+	c.Assert(len(info.Finished[0].Files) > 0, check.Equals, true)
+
+	// backup set already exist
+	scheduler.(*backupScheduler).now = func() int64 { return set.CreateTime }
+	_, err = scheduler.Backup()
+	c.Assert(err, check.NotNil)
+
+	// backup lock is held by others
+	lock := brokerRepo.NewMutex(constants.BackupLockPath, []byte("other"), 1)
+	_, _ = lock.Lock(ctx)
+	_, err = scheduler.Backup()
+	c.Assert(err, check.NotNil)
+	_ = lock.Unlock(ctx)
+
+	// incremental backup, only the new sst files are uploaded
+	engine := storageService.GetEngine("test")
+	flushTestFile(c, engine, 1)
+	incremental := cfg
+	incremental.Incremental = true
+	scheduler2, _ := NewBackupScheduler(ctx, brokerRepo, stateMachine, incremental)
+	defer scheduler2.Close()
+	scheduler2.(*backupScheduler).now = func() int64 { return set.CreateTime + 1000 }
+	set2, err := scheduler2.Backup()
+	c.Assert(err, check.IsNil)
+	c.Assert(set2.Base, check.Equals, set.ID)
+	time.Sleep(300 * time.Millisecond)
+	info2, _ := catalog.GetSet(set2.ID)
+	c.Assert(info2.Complete, check.Equals, true)
+	c.Assert(info2.Depends, check.IsNil)
+	c.Assert(info2.Uploaded, check.Equals, info2.Size)
+	var sstSize int64
+	for _, f := range info2.Finished[0].Files {
+		if strings.HasSuffix(f.Name, ".sst") {
+			sstSize += f.Size
+		}
+	}
+	c.Assert(sstSize > 0, check.Equals, true)
+
+	flushTestFile(c, engine, 2)
+	scheduler2.(*backupScheduler).now = func() int64 { return set.CreateTime + 2000 }
+	set3, err := scheduler2.Backup()
+	c.Assert(err, check.IsNil)
+	c.Assert(set3.Base, check.Equals, set2.ID)
+	time.Sleep(300 * time.Millisecond)
+	info3, _ := catalog.GetSet(set3.ID)
+	c.Assert(info3.Complete, check.Equals, true)
+	c.Assert(info3.Depends, check.DeepEquals, []string{set2.ID})
+	c.Assert(info3.Uploaded, check.Equals, info3.Size-sstSize)
+
+	// keeps the latest set and the set it depends on
+	scheduler.(*backupScheduler).deleteExpiredSets()
+	sets, _ := catalog.ListSets()
+	c.Assert(len(sets), check.Equals, 2)
+	c.Assert(sets[0].ID, check.Equals, set2.ID)
+	c.Assert(sets[1].ID, check.Equals, set3.ID)
+
+	// no database need backup
+	emptyRepo, _ := state.NewRepo(state.Config{Namespace: "/backup/empty", Endpoints: ts.Cluster.Endpoints})
+	scheduler, _ = NewBackupScheduler(ctx, emptyRepo, stateMachine, cfg)
+	_, err = scheduler.Backup()
+	c.Assert(err, check.NotNil)
+	scheduler.Close()
+}
+
+// flushTestFile flushes a sst file into the family of engine's shard
+func flushTestFile(c *check.C, engine tsdb.Engine, shardID int) {
+	intervalSegment, _ := engine.GetShard(shardID).GetIntervalSegment(interval.Day)
+	segment, err := intervalSegment.GetOrCreateSegmentOf(timeutil.Now())
+	c.Assert(err, check.IsNil)
+	family, err := segment.GetOrCreateFamily(timeutil.Now())
+	c.Assert(err, check.IsNil)
+	flusher := family.NewFlusher()
+	c.Assert(flusher.Add(1, []byte("sst")), check.IsNil)
+	c.Assert(flusher.Commit(), check.IsNil)
+}
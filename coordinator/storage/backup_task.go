@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/eleme/lindb/constants"
+	"github.com/eleme/lindb/coordinator/task"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/backup"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/service"
+)
+
+// backupProcessor represents backup database's shards when receive task,
+// uploads the files into backup target, then saves the manifest of backup part.
+// For incremental backup, the sst files which exist in the part of base backup aren't uploaded.
+type backupProcessor struct {
+	storageService service.StorageService
+}
+
+// newBackupProcessor returns backup processor instance
+func newBackupProcessor(storageService service.StorageService) task.Processor {
+	return &backupProcessor{
+		storageService: storageService,
+	}
+}
+
+func (p *backupProcessor) Kind() task.Kind             { return constants.Backup }
+func (p *backupProcessor) RetryCount() int             { return 3 }
+func (p *backupProcessor) RetryBackOff() time.Duration { return time.Second }
+func (p *backupProcessor) Concurrency() int            { return 1 }
+
+// Process uploads the files of database's shards into backup target
+func (p *backupProcessor) Process(ctx context.Context, task task.Task) error {
+	param := models.BackupTask{}
+	if err := json.Unmarshal(task.Params, &param); err != nil {
+		return err
+	}
+	logger.GetLogger("backup/task").
+		Info("process backup task", logger.String("params", string(task.Params)))
+	engine := p.storageService.GetEngine(param.Part.Database)
+	if engine == nil {
+		return fmt.Errorf("database[%s] not exist", param.Part.Database)
+	}
+	target, err := backup.NewTarget(param.Target)
+	if err != nil {
+		return err
+	}
+	catalog := backup.NewCatalog(target)
+	part := &backup.PartManifest{
+		Part:      param.Part,
+		StartTime: timeutil.Now(),
+	}
+	var base backup.PartFiles
+	if len(param.Base) > 0 {
+		baseFiles, err := catalog.GetPartFiles(param.Base, param.Part)
+		switch {
+		case err == backup.ErrNotExist:
+			// the part of database in this node doesn't exist in base backup, do full backup
+		case err != nil:
+			return fmt.Errorf("get base backup part error:%s", err)
+		default:
+			base = baseFiles
+		}
+	}
+	files, err := engine.Backup(target, catalog.PartPath(param.BackupID, param.Part), base, param.Part.ShardIDs...)
+	if err != nil {
+		return err
+	}
+	part.Files = files
+	for _, f := range files {
+		part.Size += f.Size
+		if len(f.BackupID) == 0 {
+			part.Uploaded += f.Size
+		}
+	}
+	part.EndTime = timeutil.Now()
+	return catalog.SavePart(param.BackupID, part)
+}
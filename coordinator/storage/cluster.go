@@ -26,8 +26,16 @@ type Cluster interface {
 	GetActiveNodes() []models.Node
 	// GetShardAssign returns shard assignment by database name, return not exist err if it not exist
 	GetShardAssign(databaseName string) (*models.ShardAssignment, error)
-	// SaveShardAssign saves shard assignment
-	SaveShardAssign(databaseName string, shardAssign *models.ShardAssignment) error
+	// GetShardAssignWithRevision returns shard assignment with the revision of it by database name,
+	// the revision is used for publishing the modified shard assignment
+	GetShardAssignWithRevision(databaseName string) (*models.ShardAssignment, int64, error)
+	// SaveShardAssign saves shard assignment with create shard tasks atomically,
+	// fails if the shard assignment is changed since the revision read(service.AnyRevision skips the check)
+	SaveShardAssign(databaseName string, shardAssign *models.ShardAssignment, revision int64) error
+	// PublishShardAssign saves shard assignment with the coordinator tasks atomically,
+	// fails if the shard assignment is changed since the revision read(service.AnyRevision skips the check)
+	PublishShardAssign(databaseName string, shardAssign *models.ShardAssignment, revision int64,
+		kind task.Kind, name string, params []task.ControllerTaskParam) error
 	// SubmitTask generates coordinator task
 	SubmitTask(kind task.Kind, name string, params []task.ControllerTaskParam) error
 	// GetRepo returns current storage cluster's state repo
@@ -66,7 +74,7 @@ func newCluster(ctx context.Context, cfg models.StorageCluster) (Cluster, error)
 		log:                logger.GetLogger("coordinator/storage/cluster"),
 	}
 	// init active nodes if exist
-	nodeList, err := repo.List(ctx, constants.ActiveNodesPath)
+	nodeList, err := repo.List(ctx, pathutil.Keys.Nodes.Active.Prefix())
 	if err != nil {
 		return nil, fmt.Errorf("get active nodes error:%s", err)
 	}
@@ -75,7 +83,7 @@ func newCluster(ctx context.Context, cfg models.StorageCluster) (Cluster, error)
 	}
 
 	// new storage active node discovery
-	cluster.discovery = discovery.NewDiscovery(repo, constants.ActiveNodesPath, cluster)
+	cluster.discovery = discovery.NewDiscovery(repo, pathutil.Keys.Nodes.Active.Prefix(), cluster)
 	if err := cluster.discovery.Discovery(); err != nil {
 		return nil, fmt.Errorf("discovery active storage nodes error:%s", err)
 	}
@@ -120,11 +128,14 @@ func (c *cluster) GetShardAssign(databaseName string) (*models.ShardAssignment,
 	return c.shardAssignService.Get(databaseName)
 }
 
-// SaveShardAssign saves shard assignment, generates create shard task after saving successfully
-func (c *cluster) SaveShardAssign(databaseName string, shardAssign *models.ShardAssignment) error {
-	if err := c.shardAssignService.Save(databaseName, shardAssign); err != nil {
-		return err
-	}
+// GetShardAssignWithRevision returns shard assignment with the revision of it by database name
+func (c *cluster) GetShardAssignWithRevision(databaseName string) (*models.ShardAssignment, int64, error) {
+	return c.shardAssignService.GetWithRevision(databaseName)
+}
+
+// SaveShardAssign saves shard assignment with create shard tasks of all replicas atomically,
+// so that storage nodes never observe the shard assignment without the tasks creating its shards.
+func (c *cluster) SaveShardAssign(databaseName string, shardAssign *models.ShardAssignment, revision int64) error {
 	var tasks = make(map[int]*models.CreateShardTask)
 
 	for ID, shard := range shardAssign.Shards {
@@ -142,15 +153,23 @@ func (c *cluster) SaveShardAssign(databaseName string, shardAssign *models.Shard
 	for nodeID, taskParam := range tasks {
 		node := shardAssign.Nodes[nodeID]
 		params = append(params, task.ControllerTaskParam{
-			NodeID: (&node).String(),
+			NodeID: (&node).Key(),
 			Params: taskParam,
 		})
 	}
-	// create create shard coordinator tasks
-	if err := c.SubmitTask(constants.CreateShard, databaseName, params); err != nil {
+	// create create shard coordinator tasks with shard assignment
+	return c.PublishShardAssign(databaseName, shardAssign, revision, constants.CreateShard, databaseName, params)
+}
+
+// PublishShardAssign saves shard assignment with the coordinator tasks in one transaction of repository,
+// so that the routing of brokers and the shards of storage nodes are never changed half-updated.
+func (c *cluster) PublishShardAssign(databaseName string, shardAssign *models.ShardAssignment, revision int64,
+	kind task.Kind, name string, params []task.ControllerTaskParam) error {
+	kvs, err := c.controller.TaskKVs(kind, name, params)
+	if err != nil {
 		return err
 	}
-	return nil
+	return c.shardAssignService.Publish(databaseName, shardAssign, revision, kvs)
 }
 
 // SubmitTask submits coordinator task based on kind and params into related storage cluster,
@@ -183,6 +202,6 @@ func (c *cluster) addNode(resource []byte) {
 	}
 
 	c.mutex.Lock()
-	c.nodes[node.String()] = node
+	c.nodes[node.Key()] = node
 	c.mutex.Unlock()
 }
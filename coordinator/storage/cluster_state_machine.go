@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"sync"
 
-	"github.com/eleme/lindb/constants"
 	"github.com/eleme/lindb/coordinator/discovery"
 	"github.com/eleme/lindb/models"
 	"github.com/eleme/lindb/pkg/logger"
@@ -51,7 +50,7 @@ func NewClusterStateMachine(ctx context.Context, repo state.Repository) (Cluster
 		clusters: make(map[string]Cluster),
 		log:      log,
 	}
-	clusterList, err := repo.List(c, constants.StorageClusterConfigPath)
+	clusterList, err := repo.List(c, pathutil.Keys.Cluster.StorageClusters.Prefix())
 	if err != nil {
 		return nil, fmt.Errorf("get storage cluster list error:%s", err)
 	}
@@ -61,7 +60,7 @@ func NewClusterStateMachine(ctx context.Context, repo state.Repository) (Cluster
 		stateMachine.addCluster(cluster)
 	}
 	// new storage config discovery
-	stateMachine.discovery = discovery.NewDiscovery(repo, constants.StorageClusterConfigPath, stateMachine)
+	stateMachine.discovery = discovery.NewDiscovery(repo, pathutil.Keys.Cluster.StorageClusters.Prefix(), stateMachine)
 	if err := stateMachine.discovery.Discovery(); err != nil {
 		return nil, fmt.Errorf("discovery storage cluster config error:%s", err)
 	}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/eleme/lindb/constants"
+	"github.com/eleme/lindb/coordinator/task"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/service"
+)
+
+// splitShardProcessor represents split shard when receive task.
+// The new shard is created on the same node as the split shard, so the series of new shard's hash range
+// are migrated within the node: the series written after the routing epoch go into new shard,
+// the data written before is moved from the split shard into new shard.
+type splitShardProcessor struct {
+	storageService service.StorageService
+}
+
+// newSplitShardProcessor returns split shard processor instance
+func newSplitShardProcessor(storageService service.StorageService) task.Processor {
+	return &splitShardProcessor{
+		storageService: storageService,
+	}
+}
+
+func (p *splitShardProcessor) Kind() task.Kind             { return constants.SplitShard }
+func (p *splitShardProcessor) RetryCount() int             { return 3 }
+func (p *splitShardProcessor) RetryBackOff() time.Duration { return time.Second }
+func (p *splitShardProcessor) Concurrency() int            { return 1 }
+
+// Process creates the new shard of split shard, then migrates the series of new shard's hash range into it
+func (p *splitShardProcessor) Process(ctx context.Context, task task.Task) error {
+	param := models.SplitShardTask{}
+	if err := json.Unmarshal(task.Params, &param); err != nil {
+		return err
+	}
+	logger.GetLogger("split_shard/task").
+		Info("process split shard task", logger.String("params", string(task.Params)))
+	if err := p.storageService.CreateShards(param.Database, param.ShardOption, param.NewShardID); err != nil {
+		return err
+	}
+	engine := p.storageService.GetEngine(param.Database)
+	if engine == nil {
+		return fmt.Errorf("database[%s] not found", param.Database)
+	}
+	return engine.MigrateSeries(ctx, param.ShardID, param.NewShardID, param.Range, param.RoutingTags)
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/state"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/service"
+)
+
+// StatsAggregator represents the aggregator of database statistics when node is master, which aggregates
+// the statistics reported by broker nodes and the nodes of all storage clusters periodically.
+type StatsAggregator interface {
+	// Aggregate aggregates the statistics of all nodes, then saves the result into the state repository of broker
+	Aggregate() (*models.ClusterStats, error)
+	// Close stops the aggregator
+	Close()
+}
+
+// statsAggregator implements stats aggregator interface
+type statsAggregator struct {
+	statsService   service.DatabaseStatsService
+	storageCluster ClusterStateMachine
+	interval       time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	log *logger.Logger
+}
+
+// NewStatsAggregator creates stats aggregator, runs aggregation periodically in background
+func NewStatsAggregator(ctx context.Context, repo state.Repository,
+	storageCluster ClusterStateMachine, interval time.Duration) (StatsAggregator, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("stats aggregate interval must be positive")
+	}
+	c, cancel := context.WithCancel(ctx)
+	a := &statsAggregator{
+		statsService:   service.NewDatabaseStatsService(repo),
+		storageCluster: storageCluster,
+		interval:       interval,
+		ctx:            c,
+		cancel:         cancel,
+		log:            logger.GetLogger("coordinator/stats/aggregator"),
+	}
+	go a.run()
+	a.log.Info("stats aggregator started", logger.String("interval", interval.String()))
+	return a, nil
+}
+
+// Aggregate aggregates the statistics of broker nodes and storage nodes by database,
+// the storage cluster whose statistics cannot be listed is skipped.
+func (a *statsAggregator) Aggregate() (*models.ClusterStats, error) {
+	nodes, err := a.statsService.ListNodeStats()
+	if err != nil {
+		return nil, fmt.Errorf("list stats of broker nodes error:%s", err)
+	}
+	for _, cluster := range a.storageCluster.GetAllCluster() {
+		storageNodes, err := service.NewDatabaseStatsService(cluster.GetRepo()).ListNodeStats()
+		if err != nil {
+			a.log.Error("list stats of storage nodes error", logger.Error(err))
+			continue
+		}
+		nodes = append(nodes, storageNodes...)
+	}
+	stats := &models.ClusterStats{
+		Timestamp: timeutil.Now(),
+		Nodes:     len(nodes),
+		Databases: models.AggregateDatabaseStats(nodes),
+	}
+	if err := a.statsService.SaveClusterStats(*stats); err != nil {
+		return nil, fmt.Errorf("save aggregated stats error:%s", err)
+	}
+	return stats, nil
+}
+
+// Close stops the aggregator
+func (a *statsAggregator) Close() {
+	a.cancel()
+}
+
+// run runs aggregation periodically until aggregator closed
+func (a *statsAggregator) run() {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := a.Aggregate(); err != nil {
+				a.log.Error("aggregate database stats error", logger.Error(err))
+			}
+		}
+	}
+}
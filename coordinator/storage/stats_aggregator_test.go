@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/state"
+	"github.com/eleme/lindb/service"
+)
+
+func (ts *testTaskExecutorSuite) TestStatsAggregator(c *check.C) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	storageCfg := state.Config{Namespace: "/stats/storage", Endpoints: ts.Cluster.Endpoints}
+	cluster, _ := newCluster(ctx, models.StorageCluster{Name: "test", Config: storageCfg})
+	defer cluster.Close()
+	storageStats := models.DatabaseStats{Database: "db1", WrittenPoints: 10, ScanBytes: 32}
+	_ = service.NewDatabaseStatsService(cluster.GetRepo()).Report(models.NodeStats{
+		Node: "storage-1", Databases: []models.DatabaseStats{storageStats}})
+
+	brokerRepo, _ := state.NewRepo(state.Config{Namespace: "/stats/broker", Endpoints: ts.Cluster.Endpoints})
+	brokerStats := models.DatabaseStats{Database: "db1", WrittenPoints: 5, Queries: 1}
+	brokerStats.ObserveLatency(20)
+	statsService := service.NewDatabaseStatsService(brokerRepo)
+	_ = statsService.Report(models.NodeStats{
+		Node: "1.1.1.1:9000", Databases: []models.DatabaseStats{brokerStats, {Database: "db2", FailedPoints: 1}}})
+
+	_, err := NewStatsAggregator(ctx, brokerRepo, &mockClusterStateMachine{}, 0)
+	c.Assert(err, check.NotNil)
+
+	stateMachine := &mockClusterStateMachine{clusters: map[string]Cluster{"test": cluster}}
+	aggregator, err := NewStatsAggregator(ctx, brokerRepo, stateMachine, 20*time.Millisecond)
+	c.Assert(err, check.IsNil)
+	defer aggregator.Close()
+	stats, err := aggregator.Aggregate()
+	c.Assert(err, check.IsNil)
+	c.Assert(stats.Nodes, check.Equals, 2)
+	c.Assert(stats.Databases, check.HasLen, 2)
+	c.Assert(stats.Databases[0].WrittenPoints, check.Equals, int64(15))
+	c.Assert(stats.Databases[0].ScanBytes, check.Equals, int64(32))
+	c.Assert(stats.Databases[0].P99Latency, check.Equals, int64(25))
+	c.Assert(stats.Databases[1].FailedPoints, check.Equals, int64(1))
+
+	// the stats are aggregated periodically, then saved into the state repository of broker
+	_ = statsService.Report(models.NodeStats{Node: "1.1.1.2:9000", Databases: []models.DatabaseStats{brokerStats}})
+	for i := 0; i < 100; i++ {
+		stats, err = statsService.GetClusterStats()
+		if err == nil && stats.Nodes == 3 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(stats.Nodes, check.Equals, 3)
+	c.Assert(stats.Databases[0].WrittenPoints, check.Equals, int64(20))
+}
@@ -30,6 +30,8 @@ func NewTaskExecutor(ctx context.Context,
 
 	// register task processor
 	executor.Register(newCreateShardProcessor(storageService))
+	executor.Register(newBackupProcessor(storageService))
+	executor.Register(newSplitShardProcessor(storageService))
 	return &TaskExecutor{
 		ctx:            ctx,
 		repo:           repo,
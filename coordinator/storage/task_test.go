@@ -9,6 +9,7 @@ import (
 	"gopkg.in/check.v1"
 
 	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/coordinator/task"
 	"github.com/eleme/lindb/mock"
 	"github.com/eleme/lindb/models"
 	"github.com/eleme/lindb/pkg/interval"
@@ -64,8 +65,42 @@ func (ts *testTaskExecutorSuite) TestCreateShard(c *check.C) {
 				ShardOption: validOption,
 			},
 		},
+		0,
 	)
 	time.Sleep(100 * time.Millisecond)
 
 	c.Assert(true, check.Equals, util.Exist(filepath.Join(testPath, "test", "shard")))
+
+	// the shard assignment is changed since the revision read
+	shardAssign, revision, err := cluster.GetShardAssignWithRevision("test")
+	c.Assert(err, check.IsNil)
+	c.Assert(cluster.SaveShardAssign("test", shardAssign, revision), check.IsNil)
+	c.Assert(cluster.SaveShardAssign("test", shardAssign, revision), check.NotNil)
+	c.Assert(cluster.SaveShardAssign("test", shardAssign, 0), check.NotNil)
+}
+
+func (ts *testTaskExecutorSuite) TestSplitShard(c *check.C) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	storageService := service.NewStorageService(config.Engine{Path: testPath})
+	processor := newSplitShardProcessor(storageService)
+	param := models.SplitShardTask{
+		Database:    "split_db",
+		ShardID:     1,
+		NewShardID:  2,
+		Range:       models.HashRange{Start: 100, End: 200},
+		ShardOption: validOption,
+	}
+	// split shard not found
+	err := processor.Process(context.TODO(), task.Task{Params: param.Bytes()})
+	c.Assert(err, check.NotNil)
+
+	c.Assert(storageService.CreateShards("split_db", validOption, 1), check.IsNil)
+	err = processor.Process(context.TODO(), task.Task{Params: param.Bytes()})
+	c.Assert(err, check.IsNil)
+	c.Assert(storageService.GetShard("split_db", 2), check.NotNil)
+
+	err = processor.Process(context.TODO(), task.Task{Params: []byte("bad")})
+	c.Assert(err, check.NotNil)
 }
@@ -64,19 +64,40 @@ func NewController(ctx context.Context, cli state.Repository) *Controller {
 // Submit submits a task with params and node ids, a readable name with
 // context information is recommended.
 func (c *Controller) Submit(kind Kind, name string, params []ControllerTaskParam) error {
+	kvs, err := c.TaskKVs(kind, name, params)
+	if err != nil {
+		return err
+	}
+	if len(kvs) == 0 {
+		return nil
+	}
+	resp, err := c.cli.Batch(c.ctx, state.Batch{KVs: kvs})
+	if err != nil {
+		return err
+	}
+	if !resp {
+		//TODO need modify error type
+		return ErrTaskNameAlreadyExisted
+	}
+	return nil
+}
+
+// TaskKVs returns the key-value pairs of the task with params and node ids, which are put into repository
+// for submitting the task, so that the task can be submitted with other keys(e.g. shard assignment) atomically.
+func (c *Controller) TaskKVs(kind Kind, name string, params []ControllerTaskParam) ([]state.KeyValue, error) {
 	if atomic.LoadInt32(&c.closed) == 1 {
-		return ErrControllerClosed
+		return nil, ErrControllerClosed
 	}
 	if len(params) >= maxTasksLimit {
-		return ErrMaxTasksLimitExceeded
+		return nil, ErrMaxTasksLimitExceeded
 	}
 	if len(params) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	// TODO(damnever): kinds validation
 	grp := groupedTasks{State: StateRunning}
-	batch := state.Batch{KVs: []state.KeyValue{{}}}
+	kvs := []state.KeyValue{{}}
 	for _, param := range params {
 		task := Task{
 			Kind:     kind,
@@ -86,25 +107,16 @@ func (c *Controller) Submit(kind Kind, name string, params []ControllerTaskParam
 			State:    StateCreated,
 		}
 		grp.Tasks = append(grp.Tasks, task)
-		batch.KVs = append(batch.KVs, state.KeyValue{
+		kvs = append(kvs, state.KeyValue{
 			Key:   c.taskKey(kind, name, param.NodeID),
 			Value: task.UnsafeMarshal()})
 	}
 	key := c.statusKey(kind, name)
-	batch.KVs[0] = state.KeyValue{
+	kvs[0] = state.KeyValue{
 		Key:   key,
 		Value: grp.UnsafeMarshal(),
 	}
-
-	resp, err := c.cli.Batch(c.ctx, batch)
-	if err != nil {
-		return err
-	}
-	if !resp {
-		//TODO need modify error type
-		return ErrTaskNameAlreadyExisted
-	}
-	return nil
+	return kvs, nil
 }
 
 // Close shutdown Controller.
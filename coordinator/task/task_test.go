@@ -96,3 +96,31 @@ func (ts *testTaskSuite) Test_tasks(c *check.C) {
 	//}
 	//c.Assert(false, check.Equals, fail)
 }
+
+func (ts *testTaskSuite) Test_TaskKVs(c *check.C) {
+	repo, _ := state.NewRepo(state.Config{
+		Namespace: "/coordinator/test/task/kvs",
+		Endpoints: ts.Cluster.Endpoints,
+	})
+	controller := NewController(context.TODO(), repo)
+	node1 := &models.Node{IP: "1.1.1.1", Port: 8000}
+	kvs, err := controller.TaskKVs(kindDummy, "kvs", []ControllerTaskParam{
+		{NodeID: node1.String(), Params: dummyParams{}},
+	})
+	c.Assert(err, check.IsNil)
+	// status key and task key
+	c.Assert(kvs, check.HasLen, 2)
+	c.Assert(kvs[0].Key, check.Equals, controller.statusKey(kindDummy, "kvs"))
+	c.Assert(kvs[1].Key, check.Equals, controller.taskKey(kindDummy, "kvs", node1.String()))
+
+	kvs, err = controller.TaskKVs(kindDummy, "kvs", nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(kvs, check.IsNil)
+	_, err = controller.TaskKVs(kindDummy, "kvs", make([]ControllerTaskParam, maxTasksLimit))
+	c.Assert(err, check.Equals, ErrMaxTasksLimitExceeded)
+
+	_ = controller.Close()
+	_, err = controller.TaskKVs(kindDummy, "kvs", nil)
+	c.Assert(err, check.Equals, ErrControllerClosed)
+	c.Assert(controller.Submit(kindDummy, "kvs", nil), check.Equals, ErrControllerClosed)
+}
@@ -0,0 +1,115 @@
+package integration
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCluster_KillStorage(t *testing.T) {
+	c := NewCluster(t, 1, 3)
+	defer c.Terminate()
+
+	assert.True(t, c.WaitFor(activeStorageNodes(c, 3), waitTimeout))
+	for idx := range c.Storages {
+		assert.Nil(t, c.Write(idx, fmt.Sprintf("before-kill-%d", idx)))
+	}
+
+	// kill storage node, which is removed from active nodes after heartbeat ttl
+	assert.Nil(t, c.KillStorage(1))
+	assert.True(t, c.WaitFor(activeStorageNodes(c, 2), waitTimeout))
+	assert.NotNil(t, c.Write(1, "after-kill"))
+	assert.Nil(t, c.Write(0, "after-kill"))
+
+	// restart storage node with same data path
+	assert.Nil(t, c.RestartStorage(1))
+	assert.True(t, c.WaitFor(activeStorageNodes(c, 3), waitTimeout))
+	assert.True(t, c.WaitFor(func() bool {
+		return c.Write(1, "after-restart") == nil
+	}, waitTimeout))
+
+	// un-acknowledged writes aren't recorded into ledger
+	assert.Equal(t, []string{"after-kill", "before-kill-0"}, c.Ledger.Acked(c.Storages[0].Address))
+	assert.Equal(t, []string{"after-restart", "before-kill-1"}, c.Ledger.Acked(c.Storages[1].Address))
+	// no acknowledged write is lost, including the writes before the node killed
+	lost, err := c.Ledger.Verify(c.Read)
+	assert.Nil(t, err)
+	assert.Empty(t, lost)
+	// the un-acknowledged write isn't read
+	ok, err := c.Read(c.Storages[1].Address, "after-kill")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestCluster_Partition(t *testing.T) {
+	c := NewCluster(t, 0, 2)
+	defer c.Terminate()
+
+	assert.True(t, c.WaitFor(activeStorageNodes(c, 2), waitTimeout))
+	c.Partition(0)
+	err := c.Write(0, "partitioned")
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Nil(t, c.Write(1, "partitioned"))
+
+	// delay exceeds the timeout of write
+	c.Heal(0)
+	c.Faults.Delay(c.Storages[0].Address, 2*writeTimeout)
+	err = c.Write(0, "delayed")
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	c.Faults.HealAll()
+	assert.Nil(t, c.Write(0, "healed"))
+	assert.Equal(t, []string{"healed"}, c.Ledger.Acked(c.Storages[0].Address))
+	lost, err := c.Ledger.Verify(c.Read)
+	assert.Nil(t, err)
+	assert.Empty(t, lost)
+}
+
+func TestCluster_MasterFailover(t *testing.T) {
+	c := NewCluster(t, 2, 0)
+	defer c.Terminate()
+
+	masterIdx := -1
+	assert.True(t, c.WaitFor(func() bool {
+		masterIdx = c.masterIdx()
+		return masterIdx >= 0
+	}, waitTimeout))
+	if masterIdx < 0 {
+		t.FailNow()
+	}
+
+	// kill master, another broker becomes master
+	assert.Nil(t, c.KillBroker(masterIdx))
+	assert.True(t, c.WaitFor(func() bool {
+		idx := c.masterIdx()
+		return idx >= 0 && idx != masterIdx
+	}, waitTimeout))
+
+	assert.Nil(t, c.RestartBroker(masterIdx))
+	assert.True(t, c.Brokers[masterIdx].Running())
+}
+
+// activeStorageNodes returns the condition which checks the num. of active storage nodes
+func activeStorageNodes(c *Cluster, expect int) func() bool {
+	return func() bool {
+		nodes, err := c.ActiveStorageNodes()
+		return err == nil && len(nodes) == expect
+	}
+}
+
+// masterIdx returns the index of master broker, returns -1 if no master
+func (c *Cluster) masterIdx() int {
+	master, err := c.Master()
+	if err != nil {
+		return -1
+	}
+	for idx, node := range c.Brokers {
+		if node.Port == master.Node.Port {
+			return idx
+		}
+	}
+	return -1
+}
@@ -0,0 +1,443 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+
+	"github.com/eleme/lindb/broker"
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/constants"
+	"github.com/eleme/lindb/coordinator/task"
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/pkg/interval"
+	"github.com/eleme/lindb/pkg/option"
+	"github.com/eleme/lindb/pkg/pathutil"
+	"github.com/eleme/lindb/pkg/server"
+	"github.com/eleme/lindb/pkg/state"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/pkg/util"
+	"github.com/eleme/lindb/rpc"
+	aggregationpb "github.com/eleme/lindb/rpc/proto/aggregation"
+	"github.com/eleme/lindb/rpc/proto/common"
+	storagepb "github.com/eleme/lindb/rpc/proto/storage"
+	"github.com/eleme/lindb/storage"
+)
+
+const (
+	brokerNamespace  = "/integration/broker"
+	storageNamespace = "/integration/storage"
+	writeTimeout     = time.Second
+	waitTimeout      = 10 * time.Second
+	// the writes of cluster are written into the shard of database, which is created on all storage nodes
+	database = "integration"
+	shardID  = 1
+	// metricName is the metric of writes, the key of write is the tag value of keyTag
+	metricName = "chaos"
+	keyTag     = "key"
+)
+
+// shardOption is the option of the shard which the writes of cluster are written into
+var shardOption = option.ShardOption{Interval: 10 * time.Second, IntervalType: interval.Day, TimeWindow: 32,
+	Behind: timeutil.OneHour, Ahead: timeutil.OneHour}
+
+// Node represents a broker/storage node of cluster running in process
+type Node struct {
+	Port    uint16
+	Address string // rpc address of storage node, http address of broker node
+	cfgPath string
+	// Protocol is the rpc protocol of the binary which the node runs, used by the storage node
+	Protocol *rpc.Protocol
+	service  server.Service
+	newFn    func() server.Service
+}
+
+// Running returns if the node is running
+func (n *Node) Running() bool {
+	return n.service != nil && n.service.State() == server.Running
+}
+
+// Cluster represents the cluster running in process for integration/chaos testing,
+// which includes embedded etcd, brokers and storage nodes. Faults can be injected into the rpc calls
+// of storage nodes, such as network partition and delay.
+// NOTE: http api routes of broker are process global, so the http api of first broker serves all brokers.
+type Cluster struct {
+	t    *testing.T
+	etcd *mock.EtcdCluster
+	dir  string
+	ip   string
+
+	Faults   *rpc.FaultInjector
+	Ledger   *Ledger
+	Brokers  []*Node
+	Storages []*Node
+	// started is the time which cluster started, the writes of cluster are queried from it
+	started int64
+
+	brokerRepo  state.Repository // observes the state of brokers
+	storageRepo state.Repository // observes the state of storage nodes
+	mutex       sync.Mutex
+}
+
+// NewCluster creates and starts the cluster with num. of brokers and storage nodes
+func NewCluster(t *testing.T, numOfBrokers, numOfStorages int) *Cluster {
+	ip, err := util.GetHostIP()
+	if err != nil {
+		t.Fatalf("get host ip error:%s", err)
+	}
+	dir, err := ioutil.TempDir("", "lindb-integration")
+	if err != nil {
+		t.Fatalf("create cluster dir error:%s", err)
+	}
+	c := &Cluster{
+		t:       t,
+		etcd:    mock.StartEtcdCluster(t),
+		dir:     dir,
+		ip:      ip,
+		Faults:  rpc.NewFaultInjector(),
+		Ledger:  NewLedger(),
+		started: timeutil.Now(),
+	}
+	if c.brokerRepo, err = c.newRepo(brokerNamespace); err != nil {
+		c.Terminate()
+		t.Fatal(err)
+	}
+	if c.storageRepo, err = c.newRepo(storageNamespace); err != nil {
+		c.Terminate()
+		t.Fatal(err)
+	}
+	for i := 0; i < numOfStorages; i++ {
+		c.Storages = append(c.Storages, c.newStorage(i))
+	}
+	for i := 0; i < numOfBrokers; i++ {
+		c.Brokers = append(c.Brokers, c.newBroker(i))
+	}
+	for _, node := range append(c.Storages, c.Brokers...) {
+		if err := c.start(node); err != nil {
+			c.Terminate()
+			t.Fatal(err)
+		}
+	}
+	if err := c.createShards(); err != nil {
+		c.Terminate()
+		t.Fatal(err)
+	}
+	return c
+}
+
+// KillStorage kills the storage node, the node is removed from active nodes after heartbeat ttl
+func (c *Cluster) KillStorage(idx int) error {
+	return c.stop(c.Storages[idx])
+}
+
+// RestartStorage restarts the killed storage node, which recovers the data from same path
+func (c *Cluster) RestartStorage(idx int) error {
+	return c.start(c.Storages[idx])
+}
+
+// UpgradeStorage restarts the storage node with the binary of rpc protocol, which simulates the rolling upgrade
+// (or downgrade) of storage node, the node recovers the data from same path
+func (c *Cluster) UpgradeStorage(idx int, protocol *rpc.Protocol) error {
+	node := c.Storages[idx]
+	if err := c.stop(node); err != nil {
+		return err
+	}
+	node.Protocol = protocol
+	return c.start(node)
+}
+
+// KillBroker kills the broker node
+func (c *Cluster) KillBroker(idx int) error {
+	return c.stop(c.Brokers[idx])
+}
+
+// RestartBroker restarts the killed broker node
+func (c *Cluster) RestartBroker(idx int) error {
+	return c.start(c.Brokers[idx])
+}
+
+// Partition isolates the storage node from network, all rpc calls from/to it fail
+func (c *Cluster) Partition(idx int) {
+	c.Faults.Partition(c.Storages[idx].Address)
+}
+
+// Heal heals the network partition of the storage node
+func (c *Cluster) Heal(idx int) {
+	c.Faults.Heal(c.Storages[idx].Address)
+}
+
+// Write writes the point of key into the shard of storage node, the write is recorded into ledger by key if acknowledged
+func (c *Cluster) Write(idx int, key string) error {
+	return c.WriteWithProtocol(rpc.DefaultProtocol, idx, key)
+}
+
+// WriteWithProtocol writes the point of key into the shard of storage node by the client of rpc protocol,
+// which simulates the client of old/new binary, the write is recorded into ledger by key if acknowledged
+func (c *Cluster) WriteWithProtocol(protocol *rpc.Protocol, idx int, key string) error {
+	address := c.Storages[idx].Address
+	if err := c.write(protocol, address, key); err != nil {
+		return err
+	}
+	c.Ledger.Ack(address, key)
+	return nil
+}
+
+// write writes the point of key into the shard of storage node
+func (c *Cluster) write(protocol *rpc.Protocol, address, key string) error {
+	point := models.NewPoint(metricName, timeutil.Now(), map[string]string{keyTag: key},
+		map[string]models.Field{"count": models.NewSimpleField(field.SumField, field.Integer, int64(1))})
+	data, err := models.EncodePoints([]models.Point{point})
+	if err != nil {
+		return err
+	}
+	write := &models.ShardWrite{Database: database, ShardID: shardID, Writes: [][]byte{data}}
+	if data, err = write.Marshal(); err != nil {
+		return err
+	}
+	conn, err := grpc.Dial(address, grpc.WithInsecure(),
+		grpc.WithChainUnaryInterceptor(c.Faults.UnaryClientInterceptor(), protocol.UnaryClientInterceptor()))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	resp, err := storagepb.NewWriteServiceClient(conn).WritePoints(ctx, &common.Request{Data: data})
+	if err != nil {
+		return err
+	}
+	return rpc.ResponseToError(resp)
+}
+
+// Read checks if the write of key can be read from the storage node by the query rpc, which scans the shard
+// of database written since the cluster started, implements the Reader of ledger
+func (c *Cluster) Read(address, key string) (bool, error) {
+	data, err := json.Marshal(&models.StorageQueryRequest{Database: database, ShardIDs: []int{shardID},
+		MetricName: metricName, Fields: []string{"count"}, GroupBy: []string{keyTag},
+		Start: c.started - timeutil.OneHour, End: timeutil.Now() + timeutil.OneHour, Interval: timeutil.OneHour})
+	if err != nil {
+		return false, err
+	}
+	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithChainUnaryInterceptor(
+		c.Faults.UnaryClientInterceptor(), rpc.DefaultProtocol.UnaryClientInterceptor()))
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	resp, err := storagepb.NewQueryServiceClient(conn).Query(ctx, &common.Request{Data: data})
+	if err != nil {
+		return false, err
+	}
+	if err := rpc.ResponseToError(resp); err != nil {
+		return false, err
+	}
+	result := &aggregationpb.PartialResult{}
+	if err := proto.Unmarshal(resp.Data, result); err != nil {
+		return false, err
+	}
+	for _, series := range result.Series {
+		if len(series.TagValues) > 0 && series.TagValues[0] == key {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// createShards creates the shard of database on all storage nodes by the create shard tasks like master,
+// then waits until the shard is written on all storage nodes
+func (c *Cluster) createShards() error {
+	if len(c.Storages) == 0 {
+		return nil
+	}
+	var nodes []models.Node
+	if !c.WaitFor(func() bool {
+		var err error
+		nodes, err = c.ActiveStorageNodes()
+		return err == nil && len(nodes) == len(c.Storages)
+	}, waitTimeout) {
+		return fmt.Errorf("wait for active storage nodes timeout")
+	}
+	controller := task.NewController(context.TODO(), c.storageRepo)
+	defer func() {
+		_ = controller.Close()
+	}()
+	var params []task.ControllerTaskParam
+	for idx := range nodes {
+		params = append(params, task.ControllerTaskParam{
+			NodeID: nodes[idx].Key(),
+			Params: models.CreateShardTask{Database: database, ShardIDs: []int{shardID}, ShardOption: shardOption},
+		})
+	}
+	if err := controller.Submit(constants.CreateShard, database, params); err != nil {
+		return fmt.Errorf("submit create shard tasks error:%s", err)
+	}
+	for _, node := range c.Storages {
+		if !c.WaitFor(func() bool {
+			return c.write(rpc.DefaultProtocol, node.Address, "create-shard") == nil
+		}, waitTimeout) {
+			return fmt.Errorf("wait for shard created on storage node[%s] timeout", node.Address)
+		}
+	}
+	return nil
+}
+
+// ActiveStorageNodes returns the active storage nodes registered in etcd
+func (c *Cluster) ActiveStorageNodes() ([]models.Node, error) {
+	values, err := c.storageRepo.List(context.TODO(), pathutil.Keys.Nodes.Active.Prefix())
+	if err != nil {
+		return nil, err
+	}
+	var nodes []models.Node
+	for _, value := range values {
+		node := models.Node{}
+		if err := json.Unmarshal(value, &node); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// Master returns the master elected by brokers
+func (c *Cluster) Master() (*models.Master, error) {
+	value, err := c.brokerRepo.Get(context.TODO(), pathutil.Keys.Masters.Node)
+	if err != nil {
+		return nil, err
+	}
+	master := &models.Master{}
+	if err := json.Unmarshal(value, master); err != nil {
+		return nil, err
+	}
+	return master, nil
+}
+
+// WaitFor waits until the condition is satisfied, returns false if timeout
+func (c *Cluster) WaitFor(condition func() bool, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return condition()
+}
+
+// Terminate stops all the nodes and etcd, removes the data of cluster
+func (c *Cluster) Terminate() {
+	for _, node := range append(c.Brokers, c.Storages...) {
+		_ = c.stop(node)
+	}
+	for _, repo := range []state.Repository{c.brokerRepo, c.storageRepo} {
+		if repo != nil {
+			_ = repo.Close()
+		}
+	}
+	c.etcd.Terminate(c.t)
+	_ = util.RemoveDir(c.dir)
+}
+
+// newStorage creates the storage node with the data path under cluster dir
+func (c *Cluster) newStorage(idx int) *Node {
+	port := c.freePort()
+	address := fmt.Sprintf("%s:%d", c.ip, port)
+	cfg := config.NewDefaultStorageCfg()
+	cfg.Coordinator = state.Config{Namespace: storageNamespace, Endpoints: c.etcd.Endpoints}
+	cfg.Server.Port = port
+	cfg.HTTP.Port = c.freePort()
+	cfg.Engine.Path = filepath.Join(c.dir, fmt.Sprintf("storage-%d", idx), "data")
+	node := &Node{
+		Port:     port,
+		Address:  address,
+		cfgPath:  filepath.Join(c.dir, fmt.Sprintf("storage-%d.toml", idx)),
+		Protocol: rpc.DefaultProtocol,
+	}
+	node.newFn = func() server.Service {
+		return storage.NewStorageRuntime(node.cfgPath, node.Protocol, c.Faults.UnaryServerInterceptor(address))
+	}
+	c.encodeCfg(node.cfgPath, &cfg)
+	return node
+}
+
+// newBroker creates the broker node
+func (c *Cluster) newBroker(idx int) *Node {
+	port := c.freePort()
+	cfg := config.NewDefaultBrokerCfg()
+	cfg.Coordinator = state.Config{Namespace: brokerNamespace, Endpoints: c.etcd.Endpoints}
+	cfg.HTTP.Port = port
+	node := &Node{
+		Port:    port,
+		Address: fmt.Sprintf("http://%s:%d", c.ip, port),
+		cfgPath: filepath.Join(c.dir, fmt.Sprintf("broker-%d.toml", idx)),
+	}
+	node.newFn = func() server.Service {
+		return broker.NewBrokerRuntime(node.cfgPath)
+	}
+	c.encodeCfg(node.cfgPath, &cfg)
+	return node
+}
+
+// start starts the node with new runtime, does nothing if node is running
+func (c *Cluster) start(node *Node) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if node.Running() {
+		return nil
+	}
+	node.service = node.newFn()
+	if err := node.service.Run(); err != nil {
+		return fmt.Errorf("start node[%s] error:%s", node.Address, err)
+	}
+	return nil
+}
+
+// stop stops the node, does nothing if node isn't running
+func (c *Cluster) stop(node *Node) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !node.Running() {
+		return nil
+	}
+	return node.service.Stop()
+}
+
+// newRepo creates the state repository of namespace
+func (c *Cluster) newRepo(namespace string) (state.Repository, error) {
+	return state.NewRepo(state.Config{Namespace: namespace, Endpoints: c.etcd.Endpoints, DialTimeout: 5})
+}
+
+// encodeCfg writes the config file of node
+func (c *Cluster) encodeCfg(cfgPath string, cfg interface{}) {
+	if err := util.EncodeToml(cfgPath, cfg); err != nil {
+		c.t.Fatalf("write config file error:%s", err)
+	}
+}
+
+// freePort returns a free tcp port of local host
+func (c *Cluster) freePort() uint16 {
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		c.t.Fatalf("get free port error:%s", err)
+	}
+	defer func() {
+		_ = listener.Close()
+	}()
+	return uint16(listener.Addr().(*net.TCPAddr).Port)
+}
@@ -0,0 +1,69 @@
+package integration
+
+import (
+	"sort"
+	"sync"
+)
+
+// Reader checks if the write of key can be read from the node
+type Reader func(address, key string) (bool, error)
+
+// Ledger records the acknowledged writes of each node, so that chaos testing can verify
+// no acknowledged write is lost after faults injected.
+type Ledger struct {
+	mutex sync.Mutex
+	acked map[string]map[string]struct{} // address => keys
+}
+
+// NewLedger creates the ledger of acknowledged writes
+func NewLedger() *Ledger {
+	return &Ledger{acked: make(map[string]map[string]struct{})}
+}
+
+// Ack records the acknowledged write of key by the node
+func (l *Ledger) Ack(address, key string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	keys, ok := l.acked[address]
+	if !ok {
+		keys = make(map[string]struct{})
+		l.acked[address] = keys
+	}
+	keys[key] = struct{}{}
+}
+
+// Acked returns the sorted keys of acknowledged writes by the node
+func (l *Ledger) Acked(address string) []string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	keys := make([]string, 0, len(l.acked[address]))
+	for key := range l.acked[address] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Verify reads all the acknowledged writes by reader, returns the keys of lost writes
+func (l *Ledger) Verify(reader Reader) (lost map[string][]string, err error) {
+	l.mutex.Lock()
+	addresses := make([]string, 0, len(l.acked))
+	for address := range l.acked {
+		addresses = append(addresses, address)
+	}
+	l.mutex.Unlock()
+
+	lost = make(map[string][]string)
+	for _, address := range addresses {
+		for _, key := range l.Acked(address) {
+			ok, err := reader(address, key)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				lost[address] = append(lost[address], key)
+			}
+		}
+	}
+	return lost, nil
+}
@@ -0,0 +1,29 @@
+package integration
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLedger_Verify(t *testing.T) {
+	ledger := NewLedger()
+	ledger.Ack("node1", "b")
+	ledger.Ack("node1", "a")
+	ledger.Ack("node1", "a")
+	ledger.Ack("node2", "c")
+	assert.Equal(t, []string{"a", "b"}, ledger.Acked("node1"))
+	assert.Empty(t, ledger.Acked("node3"))
+
+	lost, err := ledger.Verify(func(address, key string) (bool, error) {
+		return key != "b", nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]string{"node1": {"b"}}, lost)
+
+	_, err = ledger.Verify(func(address, key string) (bool, error) {
+		return false, fmt.Errorf("err")
+	})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,85 @@
+package integration
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/eleme/lindb/rpc"
+)
+
+func TestCluster_RollingUpgrade(t *testing.T) {
+	legacy := rpc.NewProtocol(rpc.ProtocolV1, rpc.ProtocolV1)
+	clients := []*rpc.Protocol{legacy, rpc.DefaultProtocol}
+
+	c := NewCluster(t, 0, 2)
+	defer c.Terminate()
+
+	// all storage nodes run the binary of old version before upgrade
+	for idx := range c.Storages {
+		assert.Nil(t, c.UpgradeStorage(idx, legacy))
+	}
+	assert.True(t, c.WaitFor(activeStorageNodes(c, 2), waitTimeout))
+	for idx := range c.Storages {
+		assert.True(t, c.WaitFor(func() bool {
+			return c.Write(idx, "before-upgrade") == nil
+		}, waitTimeout))
+	}
+	// the client which requires newer version is rejected by old node
+	err := c.WriteWithProtocol(rpc.NewProtocol(3, 3), 0, "incompatible")
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// upgrade node-by-node, the old/new clients keep writing into the node which isn't upgrading
+	for idx := range c.Storages {
+		other := (idx + 1) % len(c.Storages)
+		var (
+			wg     sync.WaitGroup
+			stop   = make(chan struct{})
+			failed []error
+		)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for seq := 0; ; seq++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				for version, client := range clients {
+					key := fmt.Sprintf("upgrading-%d-v%d-%d", idx, version+1, seq)
+					if err := c.WriteWithProtocol(client, other, key); err != nil {
+						failed = append(failed, err)
+					}
+				}
+			}
+		}()
+		assert.Nil(t, c.UpgradeStorage(idx, rpc.DefaultProtocol))
+		// the upgraded node accepts the writes of old/new clients
+		for version, client := range clients {
+			key := fmt.Sprintf("upgraded-%d-v%d", idx, version+1)
+			assert.True(t, c.WaitFor(func() bool {
+				return c.WriteWithProtocol(client, idx, key) == nil
+			}, waitTimeout))
+		}
+		close(stop)
+		wg.Wait()
+		assert.Empty(t, failed)
+	}
+	assert.True(t, c.WaitFor(activeStorageNodes(c, 2), waitTimeout))
+
+	for idx, node := range c.Storages {
+		acked := c.Ledger.Acked(node.Address)
+		assert.Contains(t, acked, "before-upgrade")
+		assert.Contains(t, acked, fmt.Sprintf("upgraded-%d-v1", idx))
+		assert.Contains(t, acked, fmt.Sprintf("upgraded-%d-v2", idx))
+	}
+	// the writes acknowledged before/during the upgrade are recovered by the upgraded nodes
+	lost, err := c.Ledger.Verify(c.Read)
+	assert.Nil(t, err)
+	assert.Empty(t, lost)
+}
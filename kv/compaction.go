@@ -0,0 +1,305 @@
+package kv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/RoaringBitmap/roaring"
+
+	"github.com/eleme/lindb/kv/table"
+	"github.com/eleme/lindb/kv/version"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/timeutil"
+)
+
+// Downsampler downsamples the merged value of key in compaction for the rollup family
+type Downsampler interface {
+	// Downsample returns the downsampled value of key, returns nil if the key has no value for rollup
+	Downsample(key uint32, value []byte) ([]byte, error)
+}
+
+// CompactionHook emits the downsampled values into the rollup family in the same pass of compaction
+// when the input files are entirely older than the threshold age, so that the cold data isn't read again
+// for rollup. The rollup family should merge the duplicate downsampled values, because the values may be
+// emitted again if the compaction fails after the rollup file committed.
+type CompactionHook struct {
+	// Rollup is the family which the downsampled values are written into, must be the family of kv store
+	Rollup Family
+	// Age is the threshold age(ms), the hook is applied if the max time of all input files < now - age,
+	// the input file without time range is never cold
+	Age int64
+	// Downsampler downsamples the merged value of each key
+	Downsampler Downsampler
+}
+
+// SetCompactionHook sets the hook of compaction, removes the hook if nil
+func (f *family) SetCompactionHook(hook *CompactionHook) {
+	f.compactMutex.Lock()
+	f.hook = hook
+	f.compactMutex.Unlock()
+}
+
+// compactionInput represents the file of level which is compacted
+type compactionInput struct {
+	level int
+	file  *version.FileMeta
+}
+
+// Compact merges all the files of family into a new file of last level, the values of same key
+// in different files are merged by merger, so that the reader reads one value for each key.
+// The input files are deleted after no version uses them. Does nothing if family has less than 2 files.
+// If the compaction hook is set and the input files are cold, the downsampled values are written into
+// a new file of rollup family, which is committed before the compacted file.
+func (f *family) Compact(merger Merger) error {
+	f.compactMutex.Lock()
+	defer f.compactMutex.Unlock()
+
+	current := f.familyVersion.GetCurrent()
+	released := false
+	defer func() {
+		if !released {
+			current.Release()
+		}
+	}()
+
+	var inputs []compactionInput
+	for level := 0; level < current.NumOfLevels(); level++ {
+		for _, file := range current.GetLevelFiles(level) {
+			// the bad file is kept until it's replaced, e.g. re-replicated from healthy replica
+			if f.familyVersion.IsBadFile(file.GetFileNumber()) {
+				continue
+			}
+			inputs = append(inputs, compactionInput{level: level, file: file})
+		}
+	}
+	if len(inputs) < 2 {
+		return nil
+	}
+
+	var total int64
+	for _, input := range inputs {
+		total += int64(input.file.GetFileSize())
+	}
+	task := DefaultTaskManager.Start(TaskCompaction, f.familyPath, total)
+	defer task.Done()
+
+	readers := make([]table.Reader, len(inputs))
+	keys := roaring.New()
+	for idx, input := range inputs {
+		reader, err := f.store.cache.GetReader(f.name, input.file.GetFileNumber())
+		if err != nil {
+			f.checkCorruption(err)
+			return fmt.Errorf("get reader of file[%d] error when compact:%s", input.file.GetFileNumber(), err)
+		}
+		readers[idx] = reader
+		it := reader.Iterator()
+		for it.Next() {
+			keys.Add(it.Key())
+		}
+		if err := it.Err(); err != nil {
+			f.checkCorruption(err)
+			return fmt.Errorf("iterate file[%d] error when compact:%s", input.file.GetFileNumber(), err)
+		}
+	}
+
+	rollup, err := f.newRollup(inputs)
+	if err != nil {
+		return err
+	}
+	builder, err := f.newTableBuilder()
+	if err != nil {
+		rollup.abort()
+		return fmt.Errorf("create table build error when compact:%s", err)
+	}
+	if err := f.mergeTo(builder, keys, readers, merger, rollup, task); err != nil {
+		f.checkCorruption(err)
+		rollup.abort()
+		f.abortCompaction(builder)
+		return err
+	}
+	if err := builder.Close(); err != nil {
+		rollup.abort()
+		f.abortCompaction(builder)
+		return fmt.Errorf("close table builder error when compact:%s", err)
+	}
+	if err := rollup.commit(); err != nil {
+		f.abortCompaction(builder)
+		return err
+	}
+
+	editLog := version.NewEditLog(f.option.ID)
+	editLog.AddNewFile(int32(current.NumOfLevels()-1), compactedFileMeta(builder, inputs))
+	for _, input := range inputs {
+		editLog.Add(version.NewDeleteFile(int32(input.level), input.file.GetFileNumber()))
+	}
+	// releases the version before committing, so that the input files can be deleted after committed
+	current.Release()
+	released = true
+	if !f.commitEditLog(editLog) {
+		f.abortCompaction(builder)
+		return fmt.Errorf("commit edit log failure when compact")
+	}
+	f.logger.Info("compact family successfully",
+		logger.Any("inputs", len(inputs)), logger.Any("keys", keys.GetCardinality()))
+	return nil
+}
+
+// mergeTo writes the merged value of each key into builder in key order, the downsampled value is written into
+// rollup if rollup isn't nil, the bytes read are reported to task
+func (f *family) mergeTo(builder table.Builder, keys *roaring.Bitmap, readers []table.Reader,
+	merger Merger, rollup *rollupWriter, task *Task) error {
+	it := keys.Iterator()
+	for it.HasNext() {
+		key := it.Next()
+		var values [][]byte
+		for _, reader := range readers {
+			// always verifies the values of inputs, so that the corrupted data isn't compacted into new file
+			value, err := reader.Read(key, true)
+			if err != nil {
+				return err
+			}
+			if value != nil {
+				values = append(values, value)
+				task.Add(len(value))
+			}
+		}
+		value := values[0]
+		if len(values) > 1 {
+			merged, err := merger.Merge(key, values)
+			if err != nil {
+				return fmt.Errorf("merge values of key[%d] error when compact:%s", key, err)
+			}
+			value = merged
+		}
+		if err := builder.Add(key, value); err != nil {
+			return fmt.Errorf("add key[%d] error when compact:%s", key, err)
+		}
+		if err := rollup.add(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newRollup creates the writer of rollup family if the compaction hook is set and the input files are cold,
+// returns nil if the downsampled values needn't be emitted
+func (f *family) newRollup(inputs []compactionInput) (*rollupWriter, error) {
+	hook := f.hook
+	if hook == nil {
+		return nil, nil
+	}
+	minTime, maxTime, ok := inputsTimeRange(inputs)
+	if !ok || maxTime >= timeutil.Now()-hook.Age {
+		return nil, nil
+	}
+	rollup, ok := hook.Rollup.(*family)
+	if !ok {
+		return nil, fmt.Errorf("rollup family of compaction hook must be the family of kv store")
+	}
+	builder, err := rollup.newTableBuilder()
+	if err != nil {
+		return nil, fmt.Errorf("create table build of rollup family[%s] error when compact:%s", rollup.name, err)
+	}
+	builder.UpdateTimeRange(minTime, maxTime)
+	return &rollupWriter{family: rollup, builder: builder, downsampler: hook.Downsampler}, nil
+}
+
+// rollupWriter writes the downsampled values of compaction into a new file of rollup family,
+// does nothing if the writer is nil
+type rollupWriter struct {
+	family      *family
+	builder     table.Builder
+	downsampler Downsampler
+}
+
+// add downsamples the merged value of key, then adds the downsampled value into the file of rollup family
+func (w *rollupWriter) add(key uint32, value []byte) error {
+	if w == nil {
+		return nil
+	}
+	downsampled, err := w.downsampler.Downsample(key, value)
+	if err != nil {
+		return fmt.Errorf("downsample value of key[%d] error when compact:%s", key, err)
+	}
+	if downsampled == nil {
+		return nil
+	}
+	if err := w.builder.Add(key, downsampled); err != nil {
+		return fmt.Errorf("add key[%d] into rollup family[%s] error when compact:%s", key, w.family.name, err)
+	}
+	return nil
+}
+
+// commit commits the file of rollup family, the empty file is removed
+func (w *rollupWriter) commit() error {
+	if w == nil {
+		return nil
+	}
+	if w.builder.Count() == 0 {
+		w.abort()
+		return nil
+	}
+	if err := w.builder.Close(); err != nil {
+		w.abort()
+		return fmt.Errorf("close table builder of rollup family[%s] error when compact:%s", w.family.name, err)
+	}
+	minTime, maxTime, _ := w.builder.TimeRange()
+	editLog := version.NewEditLog(w.family.option.ID)
+	fileMeta := version.NewFileMetaWithTimeRange(w.builder.FileNumber(),
+		w.builder.MinKey(), w.builder.MaxKey(), w.builder.Size(), minTime, maxTime)
+	fileMeta.SetDigest(w.builder.Digest())
+	editLog.AddNewFile(0, fileMeta)
+	if !w.family.commitEditLog(editLog) {
+		w.abort()
+		return fmt.Errorf("commit edit log of rollup family[%s] failure when compact", w.family.name)
+	}
+	w.family.logger.Info("write downsampled values of compaction successfully",
+		logger.Any("keys", w.builder.Count()))
+	return nil
+}
+
+// abort removes the file of rollup family which isn't committed
+func (w *rollupWriter) abort() {
+	if w != nil {
+		w.family.abortCompaction(w.builder)
+	}
+}
+
+// abortCompaction closes the table builder, then removes the file which isn't committed
+func (f *family) abortCompaction(builder table.Builder) {
+	_ = builder.Close()
+	filePath := filepath.Join(f.familyPath, version.Table(builder.FileNumber()))
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		f.logger.Error("remove file error when abort compaction", logger.String("file", filePath), logger.Error(err))
+	}
+}
+
+// compactedFileMeta returns the file meta of compacted file with the digest of builder,
+// the time range is the union of input files, the time range is unknown if any input file hasn't time range.
+func compactedFileMeta(builder table.Builder, inputs []compactionInput) *version.FileMeta {
+	fileMeta := version.NewFileMeta(builder.FileNumber(), builder.MinKey(), builder.MaxKey(), builder.Size())
+	if minTime, maxTime, ok := inputsTimeRange(inputs); ok {
+		fileMeta = version.NewFileMetaWithTimeRange(builder.FileNumber(), builder.MinKey(), builder.MaxKey(),
+			builder.Size(), minTime, maxTime)
+	}
+	fileMeta.SetDigest(builder.Digest())
+	return fileMeta
+}
+
+// inputsTimeRange returns the union time range of input files, ok is false if any input file hasn't time range
+func inputsTimeRange(inputs []compactionInput) (minTime, maxTime int64, ok bool) {
+	for idx, input := range inputs {
+		file := input.file
+		if !file.HasTimeRange() {
+			return 0, 0, false
+		}
+		if idx == 0 || file.GetMinTime() < minTime {
+			minTime = file.GetMinTime()
+		}
+		if idx == 0 || file.GetMaxTime() > maxTime {
+			maxTime = file.GetMaxTime()
+		}
+	}
+	return minTime, maxTime, true
+}
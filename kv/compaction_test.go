@@ -0,0 +1,265 @@
+package kv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/kv/version"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/pkg/util"
+)
+
+// concatMerger concats the values in sorted order
+type concatMerger struct {
+	err error
+}
+
+func (m *concatMerger) Merge(key uint32, values [][]byte) ([]byte, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return bytes.Compare(values[i], values[j]) < 0
+	})
+	return bytes.Join(values, []byte(",")), nil
+}
+
+func TestFamily_Compact(t *testing.T) {
+	option := DefaultStoreOption(testKVPath)
+	defer util.RemoveDir(testKVPath)
+
+	var kv, err = NewStore("test_kv", option)
+	assert.Nil(t, err, "cannot create kv store")
+	defer kv.Close()
+
+	f, err := kv.CreateFamily("f", FamilyOption{})
+	assert.Nil(t, err, "cannot create family")
+	// less than 2 files
+	assert.Nil(t, f.Compact(&concatMerger{}))
+
+	flusher := f.NewFlusher()
+	_ = flusher.Add(1, []byte("a"))
+	_ = flusher.Add(2, []byte("b"))
+	flusher.(*storeFlusher).builder.UpdateTimeRange(0, 100)
+	assert.Nil(t, flusher.Commit())
+	flusher = f.NewFlusher()
+	_ = flusher.Add(1, []byte("c"))
+	_ = flusher.Add(3, []byte("d"))
+	flusher.(*storeFlusher).builder.UpdateTimeRange(50, 200)
+	assert.Nil(t, flusher.Commit())
+
+	// merge error, keeps the input files
+	assert.NotNil(t, f.Compact(&concatMerger{err: fmt.Errorf("err")}))
+	snapshot, _ := f.GetSnapshot(1)
+	assert.Equal(t, 2, len(snapshot.Readers()))
+	snapshot.Close()
+
+	assert.Nil(t, f.Compact(&concatMerger{}))
+	snapshot, _ = f.GetSnapshot(1)
+	readers := snapshot.Readers()
+	assert.Equal(t, 1, len(readers))
+	assert.Equal(t, []byte("a,c"), readers[0].Get(1))
+	assert.Equal(t, []byte("b"), readers[0].Get(2))
+	assert.Equal(t, []byte("d"), readers[0].Get(3))
+	snapshot.Close()
+
+	// time range of compacted file is the union of input files
+	snapshot, _ = f.GetSnapshotInTimeRange(1, 150, 160)
+	assert.Equal(t, 1, len(snapshot.Readers()))
+	snapshot.Close()
+	snapshot, _ = f.GetSnapshotInTimeRange(1, 300, 400)
+	assert.Equal(t, 0, len(snapshot.Readers()))
+	snapshot.Close()
+
+	// obsolete input files are deleted
+	files, _ := ioutil.ReadDir(f.(*family).familyPath)
+	assert.Equal(t, 1, len(files))
+}
+
+// prefixDownsampler prefixes the values, the key 3 has no value for rollup
+type prefixDownsampler struct {
+	err error
+}
+
+func (d *prefixDownsampler) Downsample(key uint32, value []byte) ([]byte, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	if key == 3 {
+		return nil, nil
+	}
+	return append([]byte("d:"), value...), nil
+}
+
+// otherFamily is the family not created by kv store
+type otherFamily struct {
+	Family
+}
+
+func TestFamily_Compact_hook(t *testing.T) {
+	option := DefaultStoreOption(testKVPath)
+	defer util.RemoveDir(testKVPath)
+
+	var kv, err = NewStore("test_kv", option)
+	assert.Nil(t, err, "cannot create kv store")
+	defer kv.Close()
+
+	f, _ := kv.CreateFamily("f", FamilyOption{})
+	rollup, _ := kv.CreateFamily("rollup", FamilyOption{})
+	flush := func(minTime, maxTime int64, kvs ...string) {
+		flusher := f.NewFlusher()
+		for i := 0; i < len(kvs); i += 2 {
+			key, _ := strconv.Atoi(kvs[i])
+			_ = flusher.Add(uint32(key), []byte(kvs[i+1]))
+		}
+		flusher.(*storeFlusher).builder.UpdateTimeRange(minTime, maxTime)
+		assert.Nil(t, flusher.Commit())
+	}
+	numOfRollupFiles := func() int {
+		snapshot, _ := rollup.GetSnapshot(1)
+		defer snapshot.Close()
+		return len(snapshot.Readers())
+	}
+	hook := &CompactionHook{Rollup: rollup, Age: timeutil.OneHour, Downsampler: &prefixDownsampler{}}
+	f.SetCompactionHook(hook)
+
+	// rollup family isn't the family of kv store
+	flush(0, 100, "1", "a", "2", "b")
+	flush(50, 200, "1", "c", "3", "d")
+	f.SetCompactionHook(&CompactionHook{Rollup: &otherFamily{Family: rollup}, Downsampler: &prefixDownsampler{}})
+	assert.NotNil(t, f.Compact(&concatMerger{}))
+	// downsample error, keeps the input files
+	f.SetCompactionHook(&CompactionHook{Rollup: rollup, Downsampler: &prefixDownsampler{err: fmt.Errorf("err")}})
+	assert.NotNil(t, f.Compact(&concatMerger{}))
+	assert.Equal(t, 0, numOfRollupFiles())
+	files, _ := ioutil.ReadDir(rollup.(*family).familyPath)
+	assert.Equal(t, 0, len(files))
+
+	// the cold files are downsampled into rollup family
+	f.SetCompactionHook(hook)
+	assert.Nil(t, f.Compact(&concatMerger{}))
+	snapshot, _ := rollup.GetSnapshotInTimeRange(1, 150, 160)
+	readers := snapshot.Readers()
+	assert.Equal(t, 1, len(readers))
+	assert.Equal(t, []byte("d:a,c"), readers[0].Get(1))
+	assert.Equal(t, []byte("d:b"), readers[0].Get(2))
+	assert.Nil(t, readers[0].Get(3))
+	snapshot.Close()
+	snapshot, _ = f.GetSnapshot(1)
+	assert.Equal(t, []byte("a,c"), snapshot.Readers()[0].Get(1))
+	snapshot.Close()
+
+	// the hot files aren't downsampled
+	now := timeutil.Now()
+	flush(now, now, "1", "e")
+	assert.Nil(t, f.Compact(&concatMerger{}))
+	assert.Equal(t, 1, numOfRollupFiles())
+
+	// no value for rollup, empty file isn't committed
+	f, _ = kv.CreateFamily("g", FamilyOption{})
+	f.SetCompactionHook(hook)
+	flush(0, 100, "3", "f")
+	flush(0, 100, "3", "g")
+	assert.Nil(t, f.Compact(&concatMerger{}))
+	assert.Equal(t, 1, numOfRollupFiles())
+	files, _ = ioutil.ReadDir(rollup.(*family).familyPath)
+	assert.Equal(t, 1, len(files))
+}
+
+// corruptFile flips the byte at offset of sst file
+func corruptFile(t *testing.T, f Family, fileNumber int64, offset int) {
+	fileName := filepath.Join(f.(*family).familyPath, version.Table(fileNumber))
+	data, err := ioutil.ReadFile(fileName)
+	assert.Nil(t, err)
+	data[offset] ^= 0xff
+	assert.Nil(t, ioutil.WriteFile(fileName, data, 0644))
+}
+
+func TestFamily_Compact_corruption(t *testing.T) {
+	option := DefaultStoreOption(testKVPath)
+	defer util.RemoveDir(testKVPath)
+
+	kv, err := NewStore("test_kv", option)
+	assert.Nil(t, err, "cannot create kv store")
+	defer kv.Close()
+	var corrupted []int64
+	kv.SetCorruptionHandler(func(family string, fileNumber int64, err error) {
+		assert.Equal(t, "f", family)
+		assert.True(t, errors.Is(err, errors.ErrCorruption))
+		corrupted = append(corrupted, fileNumber)
+	})
+
+	f, _ := kv.CreateFamily("f", FamilyOption{})
+	var fileNumbers []int64
+	for _, value := range []string{"a", "b", "c"} {
+		flusher := f.NewFlusher()
+		_ = flusher.Add(1, []byte(value))
+		fileNumbers = append(fileNumbers, flusher.(*storeFlusher).builder.FileNumber())
+		assert.Nil(t, flusher.Commit())
+	}
+	// corrupt the value of first file, skip the length of value
+	corruptFile(t, f, fileNumbers[0], 1)
+
+	// checksum isn't verified on read by default
+	assert.Nil(t, f.LookupWithContext(context.TODO(), 1, func(value []byte) bool { return false }))
+	// the values of compaction inputs are always verified
+	err = f.Compact(&concatMerger{})
+	assert.True(t, errors.Is(err, errors.ErrCorruption))
+	assert.Equal(t, []int64{fileNumbers[0]}, corrupted)
+	assert.Contains(t, f.(*family).familyVersion.BadFiles(), fileNumbers[0])
+
+	// the bad file is skipped by compaction
+	assert.Nil(t, f.Compact(&concatMerger{}))
+	snapshot, _ := f.GetSnapshot(1)
+	assert.Equal(t, 2, len(snapshot.Readers()))
+	snapshot.Close()
+	assert.Equal(t, []int64{fileNumbers[0]}, corrupted)
+}
+
+func TestFamily_Lookup_verifyChecksum(t *testing.T) {
+	option := DefaultStoreOption(testKVPath)
+	option.VerifyChecksum = true
+	defer util.RemoveDir(testKVPath)
+
+	kv, _ := NewStore("test_kv", option)
+	defer kv.Close()
+	handled := 0
+	kv.SetCorruptionHandler(func(family string, fileNumber int64, err error) {
+		handled++
+	})
+
+	f, _ := kv.CreateFamily("f", FamilyOption{})
+	flusher := f.NewFlusher()
+	_ = flusher.Add(1, []byte("a"))
+	_ = flusher.Add(2, []byte("b"))
+	fileNumber := flusher.(*storeFlusher).builder.FileNumber()
+	assert.Nil(t, flusher.Commit())
+	corruptFile(t, f, fileNumber, 1)
+
+	var values []string
+	extractor := func(value []byte) bool {
+		values = append(values, string(value))
+		return false
+	}
+	assert.Nil(t, f.LookupWithContext(context.TODO(), 2, extractor))
+	assert.Equal(t, []string{"b"}, values)
+	err := f.LookupLatest(context.TODO(), 1, extractor)
+	assert.True(t, errors.Is(err, errors.ErrCorruption))
+	assert.Equal(t, []string{"b"}, values)
+	assert.True(t, f.(*family).familyVersion.IsBadFile(fileNumber))
+	assert.Equal(t, 1, handled)
+
+	// handler is invoked once for each bad file
+	assert.NotNil(t, f.LookupWithContext(context.TODO(), 1, extractor))
+	assert.Equal(t, 1, handled)
+	kv.SetCorruptionHandler(nil)
+}
@@ -1,11 +1,15 @@
 package kv
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/eleme/lindb/kv/table"
 	"github.com/eleme/lindb/kv/version"
+	"github.com/eleme/lindb/models"
 	"github.com/eleme/lindb/pkg/logger"
 	"github.com/eleme/lindb/pkg/util"
 )
@@ -16,10 +20,43 @@ type Family interface {
 	Name() string
 	// NewFlusher creates flusher for saving data to family.
 	NewFlusher() Flusher
+	// NewFlusherWithContext creates flusher for saving data to family,
+	// the flushing is aborted and the uncommitted file is removed after context canceled.
+	NewFlusherWithContext(ctx context.Context) Flusher
+	// NewReplaceFlusher creates flusher which replaces the current files of family with the files flushed,
+	// e.g. the family is rewritten from healthy replica after its file corrupted. The current files are captured
+	// when created, the files flushed after that are kept, the commit fails if any captured file is compacted.
+	NewReplaceFlusher(ctx context.Context) ReplaceFlusher
 	// GetSnapshot returns current version for given key, includes sst files
 	GetSnapshot(key uint32) (Snapshot, error)
+	// GetSnapshotInTimeRange returns current version for given key, includes the sst files
+	// which time range overlaps [startTime, endTime]
+	GetSnapshotInTimeRange(key uint32, startTime, endTime int64) (Snapshot, error)
+	// GetSnapshotOfAllFiles returns current version with the readers of all files except bad files,
+	// which is used to scan all keys of family, e.g. exporting the family to replica
+	GetSnapshotOfAllFiles() (Snapshot, error)
+	// GetFamilySnapshot returns the snapshot of current version for all keys, the files of version are read
+	// by the snapshot until closed, even if they are compacted
+	GetFamilySnapshot() FamilySnapshot
 	// Lookup represents lookup value associated with the given key, by the extractor-function filter
 	Lookup(key uint32, extractorFunc func([]byte) bool)
+	// LookupWithContext is same as Lookup, but stops reading the files after context canceled,
+	// returns the error of getting snapshot or context.
+	LookupWithContext(ctx context.Context, key uint32, extractorFunc func([]byte) bool) error
+	// LookupLatest is same as LookupWithContext, but reads the files in descending order of max timestamp,
+	// so that the latest value query stops after the newest file including key, the older files are skipped.
+	LookupLatest(ctx context.Context, key uint32, extractorFunc func([]byte) bool) error
+	// Compact merges all the files of family into one file, the values of same key are merged by merger.
+	Compact(merger Merger) error
+	// SetCompactionHook sets the hook of compaction which emits the downsampled values into rollup family,
+	// removes the hook if nil
+	SetCompactionHook(hook *CompactionHook)
+	// DiskUsage returns the bytes of sst files of family by level, which is computed from the file metas
+	// of current version, with the content digest of files for comparing replicas
+	DiskUsage() models.FamilyDiskUsage
+	// WarmUp opens the readers of current files and loads their index blocks into block cache,
+	// so that the first reads after store opened don't read them from disk
+	WarmUp() (models.WarmUpStats, error)
 }
 
 // family implements Family interface
@@ -29,7 +66,10 @@ type family struct {
 	familyPath    string
 	option        FamilyOption
 	familyVersion *version.FamilyVersion
-	logger        *logger.Logger
+	compactMutex  sync.Mutex
+	// hook is the compaction hook of family, guarded by compactMutex
+	hook   *CompactionHook
+	logger *logger.Logger
 }
 
 // newFamily creates new family or open existed family.
@@ -58,6 +98,58 @@ func newFamily(store *store, option FamilyOption) (Family, error) {
 	return f, nil
 }
 
+// DiskUsage returns the bytes of sst files of family by level, the empty level is skipped,
+// the content digest of files is included if all files have digest
+func (f *family) DiskUsage() models.FamilyDiskUsage {
+	current := f.familyVersion.GetCurrent()
+	defer current.Release()
+	usage := models.FamilyDiskUsage{Name: f.name}
+	for level := 0; level < current.NumOfLevels(); level++ {
+		files := current.GetLevelFiles(level)
+		if len(files) == 0 {
+			continue
+		}
+		levelUsage := models.LevelDiskUsage{Level: level, Files: len(files)}
+		for _, file := range files {
+			levelUsage.Bytes += int64(file.GetFileSize())
+		}
+		usage.Bytes += levelUsage.Bytes
+		usage.Levels = append(usage.Levels, levelUsage)
+	}
+	if digest, ok := current.Digest(); ok {
+		usage.Digest = fmt.Sprintf("%016x", digest)
+	}
+	return usage
+}
+
+// WarmUp opens the readers of current files and loads their index blocks into block cache,
+// stops at the first file which fails, the bad files are skipped
+func (f *family) WarmUp() (models.WarmUpStats, error) {
+	current := f.familyVersion.GetCurrent()
+	defer current.Release()
+	var stats models.WarmUpStats
+	for level := 0; level < current.NumOfLevels(); level++ {
+		for _, fileMeta := range current.GetLevelFiles(level) {
+			if f.familyVersion.IsBadFile(fileMeta.GetFileNumber()) {
+				continue
+			}
+			reader, err := f.store.cache.GetReader(f.name, fileMeta.GetFileNumber())
+			if err == nil {
+				var blocks int
+				blocks, err = reader.WarmUp()
+				stats.Blocks += blocks
+			}
+			if err != nil {
+				f.checkCorruption(err)
+				return stats, fmt.Errorf("warm up file[%d] of family[%s] error:%s",
+					fileMeta.GetFileNumber(), f.name, err)
+			}
+			stats.Files++
+		}
+	}
+	return stats, nil
+}
+
 // Name return family's name
 func (f *family) Name() string {
 	return f.name
@@ -65,17 +157,69 @@ func (f *family) Name() string {
 
 // NewFlusher creates flusher for saving data to family.
 func (f *family) NewFlusher() Flusher {
-	return newStoreFlusher(f)
+	return newStoreFlusher(context.Background(), f)
+}
+
+// NewFlusherWithContext creates flusher for saving data to family, the flushing is aborted after context canceled.
+func (f *family) NewFlusherWithContext(ctx context.Context) Flusher {
+	return newStoreFlusher(ctx, f)
+}
+
+// NewReplaceFlusher creates flusher which replaces the current files of family with the files flushed on commit
+func (f *family) NewReplaceFlusher(ctx context.Context) ReplaceFlusher {
+	current := f.familyVersion.GetCurrent()
+	defer current.Release()
+	flusher := newStoreFlusher(ctx, f).(*storeFlusher)
+	flusher.replace = true
+	for level := 0; level < current.NumOfLevels(); level++ {
+		for _, file := range current.GetLevelFiles(level) {
+			flusher.replaced = append(flusher.replaced, compactionInput{level: level, file: file})
+		}
+	}
+	return flusher
 }
 
 // GetSnapshot returns current version for given key, includes sst files
 func (f *family) GetSnapshot(key uint32) (Snapshot, error) {
 	v, files := f.familyVersion.FindFiles(key)
+	return f.newSnapshot(v, files)
+}
+
+// GetSnapshotInTimeRange returns current version for given key, includes sst files
+// which time range overlaps [startTime, endTime], so that the files out of time range are never opened
+func (f *family) GetSnapshotInTimeRange(key uint32, startTime, endTime int64) (Snapshot, error) {
+	v, files := f.familyVersion.FindFilesInTimeRange(key, startTime, endTime)
+	return f.newSnapshot(v, files)
+}
+
+// GetSnapshotOfAllFiles returns current version with the readers of all files, the bad files are skipped
+func (f *family) GetSnapshotOfAllFiles() (Snapshot, error) {
+	current := f.familyVersion.GetCurrent()
+	var files []*version.FileMeta
+	for level := 0; level < current.NumOfLevels(); level++ {
+		for _, fileMeta := range current.GetLevelFiles(level) {
+			if !f.familyVersion.IsBadFile(fileMeta.GetFileNumber()) {
+				files = append(files, fileMeta)
+			}
+		}
+	}
+	return f.newSnapshot(current, files)
+}
+
+// GetFamilySnapshot returns the snapshot of current version for all keys
+func (f *family) GetFamilySnapshot() FamilySnapshot {
+	return newFamilySnapshot(f, f.familyVersion.GetCurrent())
+}
+
+// newSnapshot creates snapshot of version with the readers of files
+func (f *family) newSnapshot(v *version.Version, files []*version.FileMeta) (Snapshot, error) {
 	var readers []table.Reader
 	for _, fileMeta := range files {
 		// get store reader from cache
 		reader, err := f.store.cache.GetReader(f.name, fileMeta.GetFileNumber())
 		if err != nil {
+			v.Release()
+			f.checkCorruption(err)
 			return nil, err
 		}
 		readers = append(readers, reader)
@@ -85,20 +229,72 @@ func (f *family) GetSnapshot(key uint32) (Snapshot, error) {
 
 //Lookup represents lookup value associated with the given key, by the extractor-function filter
 func (f *family) Lookup(key uint32, extractorFunc func([]byte) bool) {
+	if err := f.LookupWithContext(context.Background(), key, extractorFunc); err != nil {
+		f.logger.Error("lookup error:", logger.Error(err))
+	}
+}
+
+// LookupWithContext represents lookup value associated with the given key, by the extractor-function filter,
+// checks the cancellation of context before reading each file.
+func (f *family) LookupWithContext(ctx context.Context, key uint32, extractorFunc func([]byte) bool) error {
 	snapshot, err := f.GetSnapshot(key)
 	if nil != err {
-		f.logger.Error("lookup error:", logger.Error(err))
-		return
+		return err
 	}
-	readers := snapshot.Readers()
+	defer snapshot.Close()
+	err = lookupReaders(ctx, snapshot.Readers(), key, f.store.option.VerifyChecksum, extractorFunc)
+	f.checkCorruption(err)
+	return err
+}
+
+// LookupLatest represents lookup value associated with the given key from the newest file,
+// stops reading the older files after the extractor-function returns true.
+func (f *family) LookupLatest(ctx context.Context, key uint32, extractorFunc func([]byte) bool) error {
+	v, files := f.familyVersion.FindLatestFiles(key)
+	snapshot, err := f.newSnapshot(v, files)
+	if err != nil {
+		return err
+	}
+	defer snapshot.Close()
+	err = lookupReaders(ctx, snapshot.Readers(), key, f.store.option.VerifyChecksum, extractorFunc)
+	f.checkCorruption(err)
+	return err
+}
+
+// lookupReaders reads the value of key from readers in order until the extractor-function returns true,
+// checks the cancellation of context before reading each file, verifies the checksum of value if verify is true.
+func lookupReaders(ctx context.Context, readers []table.Reader, key uint32, verify bool,
+	extractorFunc func([]byte) bool) error {
 	for _, reader := range readers {
-		byteArray := reader.Get(key)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		byteArray, err := reader.Read(key, verify)
+		if err != nil {
+			return err
+		}
 		if nil != byteArray {
 			if extractorFunc(byteArray) {
-				return
+				return nil
 			}
 		}
 	}
+	return nil
+}
+
+// checkCorruption marks the file bad in family version if the error is corruption of file,
+// then invokes the corruption handler of store
+func (f *family) checkCorruption(err error) {
+	corruption, ok := err.(*table.CorruptionError)
+	if !ok {
+		return
+	}
+	fileNumber, ok := version.ParseTable(filepath.Base(corruption.Path))
+	if !ok || !f.familyVersion.MarkBadFile(fileNumber, err) {
+		return
+	}
+	f.logger.Error("file of family is corrupted", logger.Any("file", fileNumber), logger.Error(err))
+	f.store.onCorruption(f.name, fileNumber, err)
 }
 
 // newTableBuilder creates table builder instance for storing kv data.
@@ -118,5 +314,20 @@ func (f *family) commitEditLog(editLog *version.EditLog) bool {
 		f.logger.Error("commit edit log error:", logger.Error(err))
 		return false
 	}
+	f.deleteObsoleteFiles()
 	return true
 }
+
+// deleteObsoleteFiles deletes the files which are not referenced by any active version of family
+func (f *family) deleteObsoleteFiles() {
+	for _, file := range f.familyVersion.GetObsoleteFiles() {
+		fileNumber := file.GetFileNumber()
+		f.store.cache.Evict(f.name, fileNumber)
+		filePath := filepath.Join(f.familyPath, version.Table(fileNumber))
+		if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+			f.logger.Error("delete obsolete file error:", logger.String("file", filePath), logger.Error(err))
+			continue
+		}
+		f.logger.Info("delete obsolete file", logger.String("file", filePath))
+	}
+}
@@ -1,9 +1,14 @@
 package kv
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
 	"github.com/eleme/lindb/kv/version"
+	"github.com/eleme/lindb/models"
 	"github.com/eleme/lindb/pkg/util"
 
 	"github.com/stretchr/testify/assert"
@@ -33,6 +38,119 @@ func Test_Data_Write_Read(t *testing.T) {
 	snapshot.Close()
 }
 
+func TestFamily_GetSnapshotInTimeRange(t *testing.T) {
+	option := DefaultStoreOption(testKVPath)
+	defer util.RemoveDir(testKVPath)
+
+	var kv, err = NewStore("test_kv", option)
+	defer kv.Close()
+	assert.Nil(t, err, "cannot create kv store")
+
+	f, err := kv.CreateFamily("f", FamilyOption{})
+	assert.Nil(t, err, "cannot create family")
+	// file with time range
+	flusher := f.NewFlusher()
+	_ = flusher.Add(1, []byte("old"))
+	flusher.(*storeFlusher).builder.UpdateTimeRange(0, 100)
+	flusher.(*storeFlusher).builder.UpdateTimeRange(50, 200)
+	assert.Nil(t, flusher.Commit())
+	// file without time range
+	flusher = f.NewFlusher()
+	_ = flusher.Add(1, []byte("new"))
+	assert.Nil(t, flusher.Commit())
+
+	snapshot, _ := f.GetSnapshot(1)
+	assert.Equal(t, 2, len(snapshot.Readers()))
+	snapshot.Close()
+
+	snapshot, _ = f.GetSnapshotInTimeRange(1, 300, 400)
+	readers := snapshot.Readers()
+	assert.Equal(t, 1, len(readers))
+	assert.Equal(t, []byte("new"), readers[0].Get(1))
+	snapshot.Close()
+
+	snapshot, _ = f.GetSnapshotInTimeRange(1, 200, 400)
+	assert.Equal(t, 2, len(snapshot.Readers()))
+	snapshot.Close()
+}
+
+func TestFamily_GetSnapshotOfAllFiles(t *testing.T) {
+	option := DefaultStoreOption(testKVPath)
+	defer util.RemoveDir(testKVPath)
+
+	var kv, err = NewStore("test_kv", option)
+	defer kv.Close()
+	assert.Nil(t, err, "cannot create kv store")
+
+	f, err := kv.CreateFamily("f", FamilyOption{})
+	assert.Nil(t, err, "cannot create family")
+	flusher := f.NewFlusher()
+	_ = flusher.Add(1, []byte("a"))
+	assert.Nil(t, flusher.Commit())
+	flusher = f.NewFlusher()
+	_ = flusher.Add(5, []byte("b"))
+	assert.Nil(t, flusher.Commit())
+
+	snapshot, err := f.GetSnapshotOfAllFiles()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(snapshot.Readers()))
+	snapshot.Close()
+
+	// the bad file is skipped
+	fv := f.(*family).familyVersion
+	current := fv.GetCurrent()
+	fileNumber := current.GetLevelFiles(0)[0].GetFileNumber()
+	current.Release()
+	fv.MarkBadFile(fileNumber, fmt.Errorf("corrupted"))
+	snapshot, err = f.GetSnapshotOfAllFiles()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(snapshot.Readers()))
+	snapshot.Close()
+}
+
+func TestFamily_LookupLatest(t *testing.T) {
+	option := DefaultStoreOption(testKVPath)
+	defer util.RemoveDir(testKVPath)
+
+	var kv, err = NewStore("test_kv", option)
+	defer kv.Close()
+	assert.Nil(t, err, "cannot create kv store")
+
+	f, err := kv.CreateFamily("f", FamilyOption{})
+	assert.Nil(t, err, "cannot create family")
+	for _, data := range []struct {
+		value            string
+		minTime, maxTime int64
+	}{{"middle", 100, 200}, {"latest", 150, 300}, {"oldest", 0, 100}} {
+		flusher := f.NewFlusher()
+		_ = flusher.Add(1, []byte(data.value))
+		flusher.(*storeFlusher).builder.UpdateTimeRange(data.minTime, data.maxTime)
+		assert.Nil(t, flusher.Commit())
+	}
+
+	var values []string
+	assert.Nil(t, f.LookupLatest(context.Background(), 1, func(byteArray []byte) bool {
+		values = append(values, string(byteArray))
+		return true
+	}))
+	assert.Equal(t, []string{"latest"}, values)
+	values = nil
+	assert.Nil(t, f.LookupLatest(context.Background(), 1, func(byteArray []byte) bool {
+		values = append(values, string(byteArray))
+		return false
+	}))
+	assert.Equal(t, []string{"latest", "middle", "oldest"}, values)
+	// snapshot is closed after lookup
+	assert.Equal(t, 1, f.(*family).familyVersion.NumOfActiveVersions())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, f.LookupLatest(ctx, 1, func(byteArray []byte) bool {
+		t.Fatal("should not read file after canceled")
+		return true
+	}))
+}
+
 func TestCommitEditLog(t *testing.T) {
 	option := DefaultStoreOption(testKVPath)
 	defer util.RemoveDir(testKVPath)
@@ -55,6 +173,40 @@ func TestCommitEditLog(t *testing.T) {
 	assert.True(t, ok)
 }
 
+func TestFamily_deleteObsoleteFiles(t *testing.T) {
+	option := DefaultStoreOption(testKVPath)
+	defer util.RemoveDir(testKVPath)
+
+	var kv, _ = NewStore("test_kv", option)
+	defer kv.Close()
+
+	f, _ := kv.CreateFamily("f", FamilyOption{})
+	flusher := f.NewFlusher()
+	_ = flusher.Add(1, []byte("test"))
+	assert.Nil(t, flusher.Commit())
+
+	snapshot, _ := f.GetSnapshot(1)
+	assert.Equal(t, 1, len(snapshot.Readers()))
+	family := f.(*family)
+	fileNumber := family.familyVersion.GetAllFiles()[0].GetFileNumber()
+	filePath := filepath.Join(family.familyPath, version.Table(fileNumber))
+
+	editLog := version.NewEditLog(family.option.ID)
+	editLog.Add(version.NewDeleteFile(0, fileNumber))
+	assert.True(t, family.commitEditLog(editLog))
+	// file is retained by snapshot
+	assert.True(t, util.Exist(filePath))
+	assert.Equal(t, 2, family.familyVersion.NumOfActiveVersions())
+
+	snapshot.Close()
+	assert.Equal(t, 1, family.familyVersion.NumOfActiveVersions())
+	// obsolete file is deleted after next committing
+	flusher = f.NewFlusher()
+	_ = flusher.Add(2, []byte("test"))
+	assert.Nil(t, flusher.Commit())
+	assert.False(t, util.Exist(filePath))
+}
+
 func TestFamily_Lookup(t *testing.T) {
 	option := DefaultStoreOption(testKVPath)
 	defer util.RemoveDir(testKVPath)
@@ -85,5 +237,100 @@ func TestFamily_Lookup(t *testing.T) {
 			return true
 		})
 	}
+	// snapshot is closed after lookup
+	assert.Equal(t, 1, f.(*family).familyVersion.NumOfActiveVersions())
+
+	assert.Nil(t, f.LookupWithContext(context.Background(), 1, func(byteArray []byte) bool {
+		assert.Equal(t, []byte("orange"), byteArray)
+		return true
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, f.LookupWithContext(ctx, 1, func(byteArray []byte) bool {
+		t.Fatal("should not read file after canceled")
+		return true
+	}))
+
+}
+
+func TestFamily_DiskUsage(t *testing.T) {
+	option := DefaultStoreOption(testKVPath)
+	defer util.RemoveDir(testKVPath)
+
+	var kv, err = NewStore("test_kv", option)
+	defer kv.Close()
+	assert.Nil(t, err, "cannot create kv store")
+
+	f, err := kv.CreateFamily("f", FamilyOption{})
+	assert.Nil(t, err, "cannot create family")
+	// empty family
+	assert.Equal(t, models.FamilyDiskUsage{Name: "f"}, f.DiskUsage())
+
+	for i := 0; i < 2; i++ {
+		flusher := f.NewFlusher()
+		_ = flusher.Add(1, []byte("test"))
+		assert.Nil(t, flusher.Commit())
+	}
+	usage := f.DiskUsage()
+	assert.Equal(t, "f", usage.Name)
+	assert.Equal(t, 1, len(usage.Levels))
+	assert.Equal(t, 0, usage.Levels[0].Level)
+	assert.Equal(t, 2, usage.Levels[0].Files)
+	// the bytes are the sizes of sst files
+	var fileBytes int64
+	files, _ := ioutil.ReadDir(filepath.Join(testKVPath, "f"))
+	for _, file := range files {
+		if filepath.Ext(file.Name()) == ".sst" {
+			fileBytes += file.Size()
+		}
+	}
+	assert.True(t, fileBytes > 0)
+	assert.Equal(t, fileBytes, usage.Levels[0].Bytes)
+	assert.Equal(t, fileBytes, usage.Bytes)
+
+	// the families with same data have same digest
+	assert.Len(t, usage.Digest, 16)
+	f2, err := kv.CreateFamily("f2", FamilyOption{})
+	assert.Nil(t, err)
+	for i := 0; i < 2; i++ {
+		flusher := f2.NewFlusher()
+		_ = flusher.Add(1, []byte("test"))
+		assert.Nil(t, flusher.Commit())
+	}
+	assert.Equal(t, usage.Digest, f2.DiskUsage().Digest)
+	flusher := f2.NewFlusher()
+	_ = flusher.Add(2, []byte("test"))
+	assert.Nil(t, flusher.Commit())
+	assert.NotEqual(t, usage.Digest, f2.DiskUsage().Digest)
+}
+
+func TestFamily_WarmUp(t *testing.T) {
+	option := DefaultStoreOption(testKVPath)
+	defer util.RemoveDir(testKVPath)
+
+	var kv, err = NewStore("test_kv", option)
+	defer kv.Close()
+	assert.Nil(t, err, "cannot create kv store")
+
+	f, err := kv.CreateFamily("f", FamilyOption{})
+	assert.Nil(t, err, "cannot create family")
+	// empty family
+	stats, err := f.WarmUp()
+	assert.Nil(t, err)
+	assert.Equal(t, models.WarmUpStats{}, stats)
+
+	flusher := f.NewFlusher()
+	_ = flusher.Add(1, []byte("test"))
+	assert.Nil(t, flusher.Commit())
+	// the index of file with many keys is partitioned into blocks
+	flusher = f.NewFlusher()
+	for i := uint32(0); i < 20000; i++ {
+		_ = flusher.Add(i, []byte("test"))
+	}
+	assert.Nil(t, flusher.Commit())
 
+	stats, err = f.WarmUp()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, stats.Files)
+	assert.Equal(t, 2, stats.Blocks)
 }
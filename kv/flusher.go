@@ -1,10 +1,14 @@
 package kv
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/eleme/lindb/kv/table"
 	"github.com/eleme/lindb/kv/version"
+	"github.com/eleme/lindb/pkg/logger"
 )
 
 // Flusher flushes data into kv store, for big data will be split into many sstable
@@ -15,16 +19,35 @@ type Flusher interface {
 	Commit() error
 }
 
+// ReplaceFlusher flushes the data which replaces the current files of family, see Family.NewReplaceFlusher
+type ReplaceFlusher interface {
+	Flusher
+	// NextFile closes the file being flushed, the k/v pairs added after that are flushed into a new file,
+	// so that the values of same key which aren't merged by reads are kept in different files
+	NextFile() error
+	// Abort closes the file being flushed, then removes the files flushed which aren't committed
+	Abort()
+}
+
 // storeFlusher is family level store flusher
 type storeFlusher struct {
+	ctx     context.Context
 	family  *family
 	builder table.Builder
 	editLog *version.EditLog
+	// task tracks the progress of flushing, starts when the first k/v pair is added
+	task *Task
+	// replace is true if the replaced files are deleted on commit, see Family.NewReplaceFlusher
+	replace  bool
+	replaced []compactionInput
+	// closed are the builders of files closed by NextFile, which are committed with the last file
+	closed []table.Builder
 }
 
-// newStoreFlusher create family store flusher
-func newStoreFlusher(family *family) Flusher {
+// newStoreFlusher create family store flusher, flushing is aborted after context canceled
+func newStoreFlusher(ctx context.Context, family *family) Flusher {
 	return &storeFlusher{
+		ctx:     ctx,
 		family:  family,
 		editLog: version.NewEditLog(family.option.ID),
 	}
@@ -33,27 +56,38 @@ func newStoreFlusher(family *family) Flusher {
 // Add adds puts k/v pair.
 // NOTICE: key must key in sort by desc
 func (sf *storeFlusher) Add(key uint32, value []byte) error {
+	if err := sf.ctx.Err(); err != nil {
+		sf.abort()
+		return err
+	}
 	if sf.builder == nil {
 		builder, err := sf.family.newTableBuilder()
 		if err != nil {
 			return fmt.Errorf("create table build error:%s", err)
 		}
 		sf.builder = builder
+		sf.task = DefaultTaskManager.Start(TaskFlush, sf.family.familyPath, 0)
 	}
+	sf.task.Add(len(value))
 	//TODO add file size limit
 	return sf.builder.Add(key, value)
 }
 
 // Commit flushes data and commits metadata
 func (sf *storeFlusher) Commit() error {
-	builder := sf.builder
-	if builder != nil {
-		if err := builder.Close(); err != nil {
-			return fmt.Errorf("close table builder error when flush commit, error:%s", err)
-		}
-
-		fileMeta := version.NewFileMeta(builder.FileNumber(), builder.MinKey(), builder.MaxKey(), builder.Size())
-		sf.editLog.Add(version.CreateNewFile(0, fileMeta))
+	if sf.task != nil {
+		defer sf.task.Done()
+	}
+	if err := sf.ctx.Err(); err != nil {
+		sf.abort()
+		return err
+	}
+	if err := sf.closeFile(); err != nil {
+		sf.removeFile()
+		return err
+	}
+	if sf.replace {
+		return sf.commitReplace()
 	}
 
 	if flag := sf.family.commitEditLog(sf.editLog); !flag {
@@ -61,3 +95,114 @@ func (sf *storeFlusher) Commit() error {
 	}
 	return nil
 }
+
+// NextFile closes the file being flushed, the k/v pairs added after that are flushed into a new file
+func (sf *storeFlusher) NextFile() error {
+	if err := sf.ctx.Err(); err != nil {
+		sf.abort()
+		return err
+	}
+	if err := sf.closeFile(); err != nil {
+		sf.abort()
+		return err
+	}
+	if sf.builder != nil {
+		sf.closed = append(sf.closed, sf.builder)
+		sf.builder = nil
+	}
+	return nil
+}
+
+// Abort closes the file being flushed, then removes the files flushed which aren't committed
+func (sf *storeFlusher) Abort() {
+	sf.abort()
+}
+
+// closeFile closes the table builder, then adds the file into edit log
+func (sf *storeFlusher) closeFile() error {
+	builder := sf.builder
+	if builder == nil {
+		return nil
+	}
+	if err := builder.Close(); err != nil {
+		return fmt.Errorf("close table builder error when flush commit, error:%s", err)
+	}
+	fileMeta := version.NewFileMeta(builder.FileNumber(), builder.MinKey(), builder.MaxKey(), builder.Size())
+	if minTime, maxTime, ok := builder.TimeRange(); ok {
+		fileMeta = version.NewFileMetaWithTimeRange(builder.FileNumber(), builder.MinKey(), builder.MaxKey(),
+			builder.Size(), minTime, maxTime)
+	}
+	fileMeta.SetDigest(builder.Digest())
+	sf.editLog.AddNewFile(0, fileMeta)
+	return nil
+}
+
+// commitReplace commits the new files with deleting the replaced files, holds the lock of compaction,
+// so that the replaced files aren't compacted during committing.
+func (sf *storeFlusher) commitReplace() error {
+	f := sf.family
+	f.compactMutex.Lock()
+	defer f.compactMutex.Unlock()
+
+	current := f.familyVersion.GetCurrent()
+	for _, input := range sf.replaced {
+		if !containsFile(current.GetLevelFiles(input.level), input.file.GetFileNumber()) {
+			current.Release()
+			sf.removeFile()
+			return fmt.Errorf("file[%d] of family[%s] is compacted when replace", input.file.GetFileNumber(), f.name)
+		}
+		sf.editLog.Add(version.NewDeleteFile(int32(input.level), input.file.GetFileNumber()))
+	}
+	// releases the version before committing, so that the replaced files can be deleted after committed
+	current.Release()
+	if !f.commitEditLog(sf.editLog) {
+		sf.removeFile()
+		return fmt.Errorf("commit edit log failure when replace")
+	}
+	f.logger.Info("replace files of family successfully", logger.Any("replaced", len(sf.replaced)))
+	return nil
+}
+
+// removeFile removes the files of closed builders which aren't committed
+func (sf *storeFlusher) removeFile() {
+	for _, builder := range sf.closed {
+		sf.family.abortCompaction(builder)
+	}
+	sf.closed = nil
+	if sf.builder != nil {
+		sf.family.abortCompaction(sf.builder)
+	}
+}
+
+// containsFile checks if the file of number is in the files
+func containsFile(files []*version.FileMeta, fileNumber int64) bool {
+	for _, file := range files {
+		if file.GetFileNumber() == fileNumber {
+			return true
+		}
+	}
+	return false
+}
+
+// abort closes the table builder, then removes the file which isn't committed
+func (sf *storeFlusher) abort() {
+	if sf.task != nil {
+		sf.task.Done()
+	}
+	for _, closed := range sf.closed {
+		sf.family.abortCompaction(closed)
+	}
+	sf.closed = nil
+	builder := sf.builder
+	if builder == nil {
+		return
+	}
+	sf.builder = nil
+	if err := builder.Close(); err != nil {
+		sf.family.logger.Error("close table builder error when abort flush", logger.Error(err))
+	}
+	filePath := filepath.Join(sf.family.familyPath, version.Table(builder.FileNumber()))
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		sf.family.logger.Error("remove file error when abort flush", logger.String("file", filePath), logger.Error(err))
+	}
+}
@@ -0,0 +1,119 @@
+package kv
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/kv/version"
+	"github.com/eleme/lindb/pkg/util"
+)
+
+func Benchmark_storeFlusher_Commit(b *testing.B) {
+	option := DefaultStoreOption(testKVPath)
+	defer util.RemoveDir(testKVPath)
+
+	kv, err := NewStore("bench_kv", option)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer kv.Close()
+	f, err := kv.CreateFamily("f", FamilyOption{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	value := make([]byte, 128)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		flusher := f.NewFlusher()
+		for key := uint32(0); key < 1000; key++ {
+			if err := flusher.Add(key, value); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if err := flusher.Commit(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestStoreFlusher_Canceled(t *testing.T) {
+	option := DefaultStoreOption(testKVPath)
+	defer util.RemoveDir(testKVPath)
+
+	kv, _ := NewStore("test_kv", option)
+	defer kv.Close()
+	f, _ := kv.CreateFamily("f", FamilyOption{})
+
+	// cancel before committing
+	ctx, cancel := context.WithCancel(context.Background())
+	flusher := f.NewFlusherWithContext(ctx)
+	assert.Nil(t, flusher.Add(1, []byte("test")))
+	filePath := filepath.Join(f.(*family).familyPath,
+		version.Table(flusher.(*storeFlusher).builder.FileNumber()))
+	assert.True(t, util.Exist(filePath))
+	cancel()
+	assert.Equal(t, context.Canceled, flusher.Commit())
+	assert.False(t, util.Exist(filePath))
+	assert.Equal(t, 0, len(f.(*family).familyVersion.GetAllFiles()))
+
+	// cancel when adding
+	ctx, cancel = context.WithCancel(context.Background())
+	flusher = f.NewFlusherWithContext(ctx)
+	assert.Nil(t, flusher.Add(1, []byte("test")))
+	cancel()
+	assert.Equal(t, context.Canceled, flusher.Add(2, []byte("test")))
+	assert.Nil(t, flusher.(*storeFlusher).builder)
+}
+
+func TestStoreFlusher_Replace(t *testing.T) {
+	option := DefaultStoreOption(testKVPath)
+	defer util.RemoveDir(testKVPath)
+
+	kv, _ := NewStore("test_kv", option)
+	defer kv.Close()
+	f, _ := kv.CreateFamily("f", FamilyOption{})
+	for _, value := range []string{"a", "b"} {
+		flusher := f.NewFlusher()
+		_ = flusher.Add(1, []byte(value))
+		assert.Nil(t, flusher.Commit())
+	}
+
+	replacer := f.NewReplaceFlusher(context.TODO())
+	// the file flushed after replace flusher created is kept
+	flusher := f.NewFlusher()
+	_ = flusher.Add(1, []byte("c"))
+	assert.Nil(t, flusher.Commit())
+	assert.Nil(t, replacer.Add(1, []byte("x")))
+	// the values of same key are flushed into different files
+	assert.Nil(t, replacer.NextFile())
+	assert.Nil(t, replacer.Add(1, []byte("z")))
+	assert.Nil(t, replacer.Commit())
+
+	var values []string
+	f.Lookup(1, func(value []byte) bool {
+		values = append(values, string(value))
+		return false
+	})
+	assert.ElementsMatch(t, []string{"c", "x", "z"}, values)
+	assert.Equal(t, 3, len(f.(*family).familyVersion.GetAllFiles()))
+
+	// fails if the replaced file is compacted
+	replacer = f.NewReplaceFlusher(context.TODO())
+	assert.Nil(t, replacer.Add(1, []byte("y")))
+	assert.Nil(t, replacer.NextFile())
+	fileNumber := replacer.(*storeFlusher).closed[0].FileNumber()
+	assert.Nil(t, f.Compact(&concatMerger{}))
+	assert.NotNil(t, replacer.Commit())
+	assert.Equal(t, 1, len(f.(*family).familyVersion.GetAllFiles()))
+	assert.False(t, util.Exist(filepath.Join(f.(*family).familyPath, version.Table(fileNumber))))
+
+	// replaces all files without new file
+	replacer = f.NewReplaceFlusher(context.TODO())
+	assert.Nil(t, replacer.Commit())
+	assert.Equal(t, 0, len(f.(*family).familyVersion.GetAllFiles()))
+}
@@ -0,0 +1,19 @@
+package kv
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/eleme/lindb/kv/version"
+	"github.com/eleme/lindb/pkg/util"
+)
+
+// LoadFamilyOptions loads the options of families keyed by family name from the INFO file of store,
+// which is used for debugging storage offline without opening the store.
+func LoadFamilyOptions(path string) (map[string]FamilyOption, error) {
+	info := &storeInfo{}
+	if err := util.DecodeToml(filepath.Join(path, version.Options), info); err != nil {
+		return nil, fmt.Errorf("load store info error:%s", err)
+	}
+	return info.Families, nil
+}
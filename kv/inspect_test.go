@@ -0,0 +1,23 @@
+package kv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/pkg/util"
+)
+
+func TestLoadFamilyOptions(t *testing.T) {
+	defer util.RemoveDir(testKVPath)
+
+	_, err := LoadFamilyOptions(testKVPath)
+	assert.NotNil(t, err)
+
+	kv, _ := NewStore("test_kv", DefaultStoreOption(testKVPath))
+	_, _ = kv.CreateFamily("f", FamilyOption{})
+	_ = kv.Close()
+	families, err := LoadFamilyOptions(testKVPath)
+	assert.Nil(t, err)
+	assert.Equal(t, "f", families["f"].Name)
+}
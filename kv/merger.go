@@ -2,7 +2,7 @@ package kv
 
 // Merger does merge job(compact/rollup etc.)
 type Merger interface {
-
-	// Merge values for same key
-	Merge(key uint32, value [][]byte)
+	// Merge merges the values of same key in different files into one value,
+	// the order of values isn't guaranteed.
+	Merge(key uint32, values [][]byte) ([]byte, error)
 }
@@ -1,5 +1,11 @@
 package kv
 
+import (
+	"sync"
+
+	"github.com/eleme/lindb/kv/table"
+)
+
 // FamilyOption defines config items for family level
 type FamilyOption struct {
 	ID   int    `toml:"id"`
@@ -10,6 +16,11 @@ type FamilyOption struct {
 type StoreOption struct {
 	Path   string `toml:"-"` // ignore path field for INFO file
 	Levels int    `toml:"levels"`
+	// capacity(bytes) of cache for leaf index blocks, uses default capacity if not set
+	BlockCacheSize int `toml:"blockCacheSize"`
+	// VerifyChecksum verifies the checksum of values when reading, the values of compaction inputs
+	// are always verified
+	VerifyChecksum bool `toml:"verifyChecksum"`
 }
 
 // DefaultStoreOption builds default store option
@@ -33,3 +44,35 @@ func newStoreInfo(storeOption StoreOption) *storeInfo {
 		Families:    make(map[string]FamilyOption),
 	}
 }
+
+// sharedBlockCache is the cache of leaf index blocks shared by the stores which don't set the capacity of cache,
+// so that the memory of block caches is bounded by the budget of node instead of the number of stores
+var (
+	sharedBlockCache      table.BlockCache
+	sharedBlockCacheMutex sync.RWMutex
+)
+
+// SetSharedBlockCacheSize creates the block cache with capacity(bytes) shared by the stores opened after,
+// whose option doesn't set block cache size, removes the shared cache if capacity <= 0
+func SetSharedBlockCacheSize(capacity int) {
+	sharedBlockCacheMutex.Lock()
+	defer sharedBlockCacheMutex.Unlock()
+	if capacity <= 0 {
+		sharedBlockCache = nil
+		return
+	}
+	sharedBlockCache = table.NewBlockCache(capacity)
+}
+
+// newBlockCache returns the block cache with capacity if set, else returns the shared cache if exist,
+// otherwise creates the cache with default capacity
+func newBlockCache(capacity int) table.BlockCache {
+	if capacity <= 0 {
+		sharedBlockCacheMutex.RLock()
+		defer sharedBlockCacheMutex.RUnlock()
+		if sharedBlockCache != nil {
+			return sharedBlockCache
+		}
+	}
+	return table.NewBlockCache(capacity)
+}
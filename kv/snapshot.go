@@ -1,10 +1,12 @@
 package kv
 
 import (
+	"context"
 	"sync/atomic"
 
 	"github.com/eleme/lindb/kv/table"
 	"github.com/eleme/lindb/kv/version"
+	"github.com/eleme/lindb/pkg/logger"
 )
 
 // Snapshot represents a current family version by given key, for reading data.
@@ -43,3 +45,63 @@ func (s *snapshot) Close() {
 		s.version.Release()
 	}
 }
+
+// FamilySnapshot represents the version of family for all keys, for reading data of a consistent view
+type FamilySnapshot interface {
+	// Lookup represents lookup value associated with the given key from the files of version,
+	// by the extractor-function filter
+	Lookup(key uint32, extractorFunc func([]byte) bool)
+	// LookupWithContext is same as Lookup, but stops reading the files after context canceled,
+	// returns the error of reading files or context.
+	LookupWithContext(ctx context.Context, key uint32, extractorFunc func([]byte) bool) error
+	// Close releases the version, the files are removed after compacted if not referenced by other versions
+	Close()
+}
+
+// familySnapshot implements FamilySnapshot interface
+type familySnapshot struct {
+	family  *family
+	version *version.Version
+	closed  int32
+}
+
+// newFamilySnapshot creates the snapshot of the version retained
+func newFamilySnapshot(family *family, version *version.Version) FamilySnapshot {
+	return &familySnapshot{
+		family:  family,
+		version: version,
+	}
+}
+
+// Lookup represents lookup value associated with the given key from the files of version
+func (s *familySnapshot) Lookup(key uint32, extractorFunc func([]byte) bool) {
+	if err := s.LookupWithContext(context.Background(), key, extractorFunc); err != nil {
+		s.family.logger.Error("lookup snapshot error:", logger.Error(err))
+	}
+}
+
+// LookupWithContext represents lookup value associated with the given key from the files of version,
+// checks the cancellation of context before reading each file.
+func (s *familySnapshot) LookupWithContext(ctx context.Context, key uint32, extractorFunc func([]byte) bool) error {
+	f := s.family
+	var readers []table.Reader
+	for _, fileMeta := range s.version.FindFiles(key) {
+		reader, err := f.store.cache.GetReader(f.name, fileMeta.GetFileNumber())
+		if err != nil {
+			f.checkCorruption(err)
+			return err
+		}
+		readers = append(readers, reader)
+	}
+	err := lookupReaders(ctx, readers, key, f.store.option.VerifyChecksum, extractorFunc)
+	f.checkCorruption(err)
+	return err
+}
+
+// Close releases the version
+func (s *familySnapshot) Close() {
+	// atomic set closed status, make sure only release once
+	if atomic.CompareAndSwapInt32(&s.closed, 0, 1) {
+		s.version.Release()
+	}
+}
@@ -0,0 +1,56 @@
+package kv
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/pkg/util"
+)
+
+func TestFamilySnapshot_Lookup(t *testing.T) {
+	option := DefaultStoreOption(testKVPath)
+	defer util.RemoveDir(testKVPath)
+
+	var kv, err = NewStore("test_kv", option)
+	assert.Nil(t, err, "cannot create kv store")
+	defer kv.Close()
+
+	f, err := kv.CreateFamily("f", FamilyOption{})
+	assert.Nil(t, err, "cannot create family")
+	for _, value := range []string{"a", "b"} {
+		flusher := f.NewFlusher()
+		_ = flusher.Add(1, []byte(value))
+		assert.Nil(t, flusher.Commit())
+	}
+	lookup := func(snapshot FamilySnapshot) []string {
+		var values []string
+		snapshot.Lookup(1, func(byteArray []byte) bool {
+			values = append(values, string(byteArray))
+			return false
+		})
+		return values
+	}
+	snapshot := f.GetFamilySnapshot()
+	// the files compacted and flushed after acquired aren't visible to the snapshot
+	assert.Nil(t, f.Compact(&concatMerger{}))
+	flusher := f.NewFlusher()
+	_ = flusher.Add(1, []byte("c"))
+	assert.Nil(t, flusher.Commit())
+	assert.ElementsMatch(t, []string{"a", "b"}, lookup(snapshot))
+	current := f.GetFamilySnapshot()
+	assert.ElementsMatch(t, []string{"a,b", "c"}, lookup(current))
+	current.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, snapshot.LookupWithContext(ctx, 1, func(byteArray []byte) bool {
+		t.Fatal("should not read file after canceled")
+		return true
+	}))
+	assert.Equal(t, 2, f.(*family).familyVersion.NumOfActiveVersions())
+	snapshot.Close()
+	snapshot.Close()
+	assert.Equal(t, 1, f.(*family).familyVersion.NumOfActiveVersions())
+}
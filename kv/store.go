@@ -3,10 +3,12 @@ package kv
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
 	"sync"
 
 	"github.com/eleme/lindb/kv/table"
 	"github.com/eleme/lindb/kv/version"
+	"github.com/eleme/lindb/models"
 	"github.com/eleme/lindb/pkg/lockers"
 	"github.com/eleme/lindb/pkg/logger"
 	"github.com/eleme/lindb/pkg/util"
@@ -19,10 +21,31 @@ type Store interface {
 	CreateFamily(familyName string, option FamilyOption) (Family, error)
 	// GetFamily gets family based on name, return nil if not exist.
 	GetFamily(familyName string) Family
+	// GetFamilies returns all families sorted by family name
+	GetFamilies() []Family
+	// DiskUsage returns the disk usage of families sorted by family name
+	DiskUsage() []models.FamilyDiskUsage
+	// WarmUp opens the readers of all families' files and loads their index blocks into block cache
+	WarmUp() (models.WarmUpStats, error)
+	// SetCorruptionHandler sets the handler which is invoked when a file of family is found corrupted,
+	// e.g. the owner re-replicates the data from healthy replica, removes the handler if nil
+	SetCorruptionHandler(handler CorruptionHandler)
+	// SetSnapshotBarrier sets the barrier shared by stores, the new versions of families are installed
+	// exclusively with the family snapshots acquired in the barrier
+	SetSnapshotBarrier(barrier *version.Barrier)
+	// Checkpoint pins the current versions of all families for copying the files of store, e.g. backup,
+	// the checkpoints of the stores sharing the snapshot barrier are consistent if created in the acquiring of barrier
+	Checkpoint() *version.Checkpoint
+	// ActiveVersions returns the num. of active versions of each family keyed by family name, the versions pinned
+	// by snapshots or checkpoints are active until released
+	ActiveVersions() map[string]int
 	// Close closes store, then release some resource
 	Close() error
 }
 
+// CorruptionHandler handles the corrupted file of family, the file has been marked bad in family version
+type CorruptionHandler func(family string, fileNumber int64, err error)
+
 // store implements Store interface
 type store struct {
 	name   string
@@ -39,6 +62,8 @@ type store struct {
 	storeInfo *storeInfo
 	cache     table.Cache
 
+	corruptionHandler CorruptionHandler
+
 	logger *logger.Logger
 }
 
@@ -115,10 +140,42 @@ func NewStore(name string, option StoreOption) (Store, error) {
 	}
 
 	// build store reader cache
-	store.cache = table.NewCache(store.option.Path)
+	store.cache = table.NewCache(store.option.Path, newBlockCache(store.option.BlockCacheSize))
 	return store, nil
 }
 
+// SetCorruptionHandler sets the handler which is invoked when a file of family is found corrupted
+func (s *store) SetCorruptionHandler(handler CorruptionHandler) {
+	s.rwMutex.Lock()
+	s.corruptionHandler = handler
+	s.rwMutex.Unlock()
+}
+
+// SetSnapshotBarrier sets the barrier shared by stores
+func (s *store) SetSnapshotBarrier(barrier *version.Barrier) {
+	s.versions.SetBarrier(barrier)
+}
+
+// Checkpoint pins the current versions of all families
+func (s *store) Checkpoint() *version.Checkpoint {
+	return s.versions.Checkpoint()
+}
+
+// ActiveVersions returns the num. of active versions of each family keyed by family name
+func (s *store) ActiveVersions() map[string]int {
+	return s.versions.ActiveVersions()
+}
+
+// onCorruption invokes the corruption handler if set
+func (s *store) onCorruption(family string, fileNumber int64, err error) {
+	s.rwMutex.RLock()
+	handler := s.corruptionHandler
+	s.rwMutex.RUnlock()
+	if handler != nil {
+		handler(family, fileNumber, err)
+	}
+}
+
 // CreateFamily create/load column family.
 func (s *store) CreateFamily(familyName string, option FamilyOption) (Family, error) {
 	s.rwMutex.RLock()
@@ -164,6 +221,44 @@ func (s *store) GetFamily(familyName string) Family {
 	return family
 }
 
+// GetFamilies returns all families sorted by family name
+func (s *store) GetFamilies() []Family {
+	s.rwMutex.RLock()
+	families := make([]Family, 0, len(s.families))
+	for _, family := range s.families {
+		families = append(families, family)
+	}
+	s.rwMutex.RUnlock()
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].Name() < families[j].Name()
+	})
+	return families
+}
+
+// DiskUsage returns the disk usage of families sorted by family name
+func (s *store) DiskUsage() []models.FamilyDiskUsage {
+	families := s.GetFamilies()
+	usages := make([]models.FamilyDiskUsage, 0, len(families))
+	for _, family := range families {
+		usages = append(usages, family.DiskUsage())
+	}
+	return usages
+}
+
+// WarmUp opens the readers of all families' files and loads their index blocks into block cache,
+// stops at the first family which fails
+func (s *store) WarmUp() (models.WarmUpStats, error) {
+	var stats models.WarmUpStats
+	for _, family := range s.GetFamilies() {
+		familyStats, err := family.WarmUp()
+		stats.Add(familyStats)
+		if err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
 // Close closes store, then release some resource
 func (s *store) Close() error {
 	if err := s.cache.Close(); err != nil {
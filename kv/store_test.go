@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/eleme/lindb/models"
 	"github.com/eleme/lindb/pkg/util"
 )
 
@@ -71,3 +72,92 @@ func TestCreateFamily(t *testing.T) {
 	_, e := NewStore("test_kv", option)
 	assert.NotNil(t, e, "store re-open not allow")
 }
+
+func TestStore_DiskUsage(t *testing.T) {
+	option := DefaultStoreOption(testKVPath)
+	defer util.RemoveDir(testKVPath)
+
+	var kv, err = NewStore("test_kv", option)
+	defer kv.Close()
+	assert.Nil(t, err, "cannot create kv store")
+	assert.Empty(t, kv.DiskUsage())
+
+	_, _ = kv.CreateFamily("f2", FamilyOption{})
+	f1, _ := kv.CreateFamily("f1", FamilyOption{})
+	flusher := f1.NewFlusher()
+	_ = flusher.Add(1, []byte("test"))
+	assert.Nil(t, flusher.Commit())
+
+	// sorted by family name
+	usages := kv.DiskUsage()
+	assert.Equal(t, 2, len(usages))
+	assert.Equal(t, f1.DiskUsage(), usages[0])
+	assert.True(t, usages[0].Bytes > 0)
+	assert.Equal(t, models.FamilyDiskUsage{Name: "f2"}, usages[1])
+}
+
+func TestStore_Checkpoint(t *testing.T) {
+	option := DefaultStoreOption(testKVPath)
+	defer util.RemoveDir(testKVPath)
+
+	var kv, err = NewStore("test_kv", option)
+	defer kv.Close()
+	assert.Nil(t, err, "cannot create kv store")
+	f, _ := kv.CreateFamily("f", FamilyOption{})
+	checkpoint := kv.Checkpoint()
+	flusher := f.NewFlusher()
+	_ = flusher.Add(1, []byte("test"))
+	assert.Nil(t, flusher.Commit())
+	// the version pinned by checkpoint is active until released
+	assert.Equal(t, map[string]int{"f": 2}, kv.ActiveVersions())
+	checkpoint.Release()
+	assert.Equal(t, map[string]int{"f": 1}, kv.ActiveVersions())
+}
+
+func TestStore_WarmUp(t *testing.T) {
+	option := DefaultStoreOption(testKVPath)
+	defer util.RemoveDir(testKVPath)
+
+	var kv, err = NewStore("test_kv", option)
+	defer kv.Close()
+	assert.Nil(t, err, "cannot create kv store")
+
+	for _, name := range []string{"f1", "f2"} {
+		f, _ := kv.CreateFamily(name, FamilyOption{})
+		flusher := f.NewFlusher()
+		_ = flusher.Add(1, []byte("test"))
+		assert.Nil(t, flusher.Commit())
+	}
+	stats, err := kv.WarmUp()
+	assert.Nil(t, err)
+	assert.Equal(t, models.WarmUpStats{Files: 2}, stats)
+}
+
+func TestStore_SharedBlockCache(t *testing.T) {
+	defer util.RemoveDir(testKVPath)
+	// the stores without the capacity of block cache use own cache if no shared cache
+	assert.False(t, newBlockCache(0) == newBlockCache(0))
+
+	SetSharedBlockCacheSize(1024)
+	defer SetSharedBlockCacheSize(0)
+	// the stores without the capacity of block cache share the cache
+	assert.True(t, newBlockCache(0) == newBlockCache(0))
+	assert.False(t, newBlockCache(0) == newBlockCache(1024))
+
+	kv, err := NewStore("test_kv", DefaultStoreOption(testKVPath))
+	assert.Nil(t, err)
+	f, _ := kv.CreateFamily("f", FamilyOption{})
+	flusher := f.NewFlusher()
+	_ = flusher.Add(1, []byte("test"))
+	assert.Nil(t, flusher.Commit())
+	var values []string
+	f.Lookup(1, func(value []byte) bool {
+		values = append(values, string(value))
+		return true
+	})
+	assert.Equal(t, []string{"test"}, values)
+	assert.Nil(t, kv.Close())
+
+	SetSharedBlockCacheSize(0)
+	assert.False(t, newBlockCache(0) == newBlockCache(0))
+}
@@ -0,0 +1,117 @@
+package table
+
+import (
+	"container/list"
+	"sync"
+)
+
+// defaultBlockCacheSize is the default capacity(bytes) of block cache
+const defaultBlockCacheSize = 8 * 1024 * 1024
+
+// Block represents the block loaded from store file, like leaf index block
+type Block interface {
+	// Size returns the memory size of block in bytes
+	Size() int
+}
+
+// BlockCache caches the blocks loaded from store files, evicts the least recently used blocks
+// if the total size of blocks exceeds the capacity
+type BlockCache interface {
+	// Get returns the cached block of file at given position
+	Get(file string, pos int) (Block, bool)
+	// Put caches the block of file at given position
+	Put(file string, pos int, block Block)
+	// Evict removes all blocks of file from cache
+	Evict(file string)
+	// Size returns the total size of cached blocks in bytes
+	Size() int
+}
+
+// blockKey represents the key of block in cache
+type blockKey struct {
+	file string
+	pos  int
+}
+
+// blockEntry represents the cached block
+type blockEntry struct {
+	key   blockKey
+	block Block
+}
+
+// lruBlockCache implements BlockCache based on lru list
+type lruBlockCache struct {
+	capacity int
+	size     int
+	lru      *list.List
+	blocks   map[blockKey]*list.Element
+	mutex    sync.Mutex
+}
+
+// NewBlockCache creates block cache with capacity in bytes, uses default capacity if capacity <= 0
+func NewBlockCache(capacity int) BlockCache {
+	if capacity <= 0 {
+		capacity = defaultBlockCacheSize
+	}
+	return &lruBlockCache{
+		capacity: capacity,
+		lru:      list.New(),
+		blocks:   make(map[blockKey]*list.Element),
+	}
+}
+
+// Get returns the cached block of file at given position
+func (c *lruBlockCache) Get(file string, pos int) (Block, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	elem, ok := c.blocks[blockKey{file: file, pos: pos}]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*blockEntry).block, true
+}
+
+// Put caches the block of file at given position, evicts the least recently used blocks if exceeds the capacity
+func (c *lruBlockCache) Put(file string, pos int, block Block) {
+	key := blockKey{file: file, pos: pos}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if elem, ok := c.blocks[key]; ok {
+		c.remove(elem)
+	}
+	c.blocks[key] = c.lru.PushFront(&blockEntry{key: key, block: block})
+	c.size += block.Size()
+	// keeps the latest block at least
+	for c.size > c.capacity && c.lru.Len() > 1 {
+		c.remove(c.lru.Back())
+	}
+}
+
+// Evict removes all blocks of file from cache
+func (c *lruBlockCache) Evict(file string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for key, elem := range c.blocks {
+		if key.file == file {
+			c.remove(elem)
+		}
+	}
+}
+
+// Size returns the total size of cached blocks in bytes
+func (c *lruBlockCache) Size() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.size
+}
+
+// remove removes the block element from lru list and map
+func (c *lruBlockCache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*blockEntry)
+	delete(c.blocks, entry.key)
+	c.size -= entry.block.Size()
+}
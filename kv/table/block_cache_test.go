@@ -0,0 +1,48 @@
+package table
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testBlock int
+
+func (b testBlock) Size() int {
+	return int(b)
+}
+
+func TestBlockCache(t *testing.T) {
+	cache := NewBlockCache(100)
+	cache.Put("a", 1, testBlock(40))
+	cache.Put("a", 2, testBlock(40))
+	block, ok := cache.Get("a", 1)
+	assert.True(t, ok)
+	assert.Equal(t, testBlock(40), block)
+	assert.Equal(t, 80, cache.Size())
+
+	// evict the least recently used block
+	cache.Put("b", 1, testBlock(40))
+	_, ok = cache.Get("a", 2)
+	assert.False(t, ok)
+	_, ok = cache.Get("a", 1)
+	assert.True(t, ok)
+	assert.Equal(t, 80, cache.Size())
+
+	// replace the cached block
+	cache.Put("b", 1, testBlock(20))
+	assert.Equal(t, 60, cache.Size())
+
+	cache.Evict("a")
+	_, ok = cache.Get("a", 1)
+	assert.False(t, ok)
+	assert.Equal(t, 20, cache.Size())
+
+	// keep the latest block even if exceeds capacity
+	cache.Put("c", 1, testBlock(200))
+	_, ok = cache.Get("c", 1)
+	assert.True(t, ok)
+	assert.Equal(t, 200, cache.Size())
+
+	assert.Equal(t, defaultBlockCacheSize, NewBlockCache(0).(*lruBlockCache).capacity)
+}
@@ -7,10 +7,7 @@ import (
 
 	"github.com/eleme/lindb/kv/version"
 	"github.com/eleme/lindb/pkg/bufioutil"
-	"github.com/eleme/lindb/pkg/encoding"
 	"github.com/eleme/lindb/pkg/logger"
-
-	"github.com/RoaringBitmap/roaring"
 )
 
 //go:generate mockgen -source ./builder.go -destination=./builder_mock.go -package table
@@ -18,8 +15,14 @@ import (
 const (
 	// magic-number in the footer of sst file
 	magicNumberOffsetFile uint64 = 7308327815838786409
-	// current file layout version
+	// file layout version with keys bitmap and offsets index
 	version0 = 0
+	// file layout version with shared-prefix key compression index
+	version1 = 1
+	// file layout version with two-level index, the leaf index blocks are partitioned by number of keys
+	version2 = 2
+	// max number of keys in single index block, the index is partitioned if exceeds it
+	defaultPartitionKeys = 16 * 1024
 )
 
 // Builder builds sst file
@@ -37,6 +40,12 @@ type Builder interface {
 	Size() int32
 	// Count returns the number of k/v pairs contained in the store
 	Count() uint64
+	// UpdateTimeRange extends the time range of data in store by the time range of data added
+	UpdateTimeRange(minTime, maxTime int64)
+	// TimeRange returns the time range of data in store, ok is false if time range is never updated
+	TimeRange() (minTime, maxTime int64, ok bool)
+	// Digest returns the merkle-style content digest of k/v pairs in store(see Digest)
+	Digest() uint64
 	// Close closes sst file write buffer
 	Close() error
 }
@@ -46,14 +55,24 @@ type storeBuilder struct {
 	fileNumber int64
 	fileName   string
 	writer     bufioutil.BufioWriter
-	offset     *encoding.DeltaBitPackingEncoder
+	index      prefixIndexBuilder
+	// top index of partitioned index blocks
+	top           prefixIndexBuilder
+	partitionKeys int
+	count         uint64
 
-	// see paper of roaring bitmap: https://arxiv.org/pdf/1603.06549.pdf
-	keys   *roaring.Bitmap
 	minKey uint32
 	maxKey uint32
 
+	hasTimeRange bool
+	minTime      int64
+	maxTime      int64
+
+	digest *Digest
+
 	first bool
+	// buffer of value with checksum
+	buf []byte
 
 	logger *logger.Logger
 }
@@ -61,7 +80,6 @@ type storeBuilder struct {
 // NewStoreBuilder creates store builder instance for building store file
 func NewStoreBuilder(path string, fileNumber int64) (Builder, error) {
 	fileName := filepath.Join(path, version.Table(fileNumber))
-	keys := roaring.New()
 	log := logger.GetLogger(fmt.Sprintf("kv/builder[%s]", fileName))
 	writer, err := bufioutil.NewBufioWriter(fileName)
 	if err != nil {
@@ -70,11 +88,12 @@ func NewStoreBuilder(path string, fileNumber int64) (Builder, error) {
 	return &storeBuilder{
 		fileNumber: fileNumber,
 		fileName:   fileName,
-		keys:       keys,
 		logger:     log,
 		writer:     writer,
 		first:      true,
-		offset:     encoding.NewDeltaBitPackingEncoder(),
+		digest:     NewDigest(),
+
+		partitionKeys: defaultPartitionKeys,
 	}, nil
 }
 
@@ -93,13 +112,21 @@ func (b *storeBuilder) Add(key uint32, value []byte) error {
 
 	// get write offset
 	offset := b.writer.Size()
-	if _, err := b.writer.Write(value); err != nil {
+	// value block: value+checksum(4)
+	b.buf = appendChecksum(append(b.buf[:0], value...), value)
+	if _, err := b.writer.Write(b.buf); err != nil {
 		return fmt.Errorf("write data into store file error:%s", err)
 	}
-	// add offset into offset buffer
-	b.offset.Add(int32(offset))
-	// add key into index block
-	b.keys.Add(key)
+	b.digest.add(key, binary.BigEndian.Uint32(b.buf[len(value):]))
+	// flush the full index block as leaf block of partitioned index
+	if b.index.keys >= b.partitionKeys {
+		if err := b.flushPartition(); err != nil {
+			return fmt.Errorf("write index block into store file error:%s", err)
+		}
+	}
+	// add key and offset into index block
+	b.index.add(key, int(offset))
+	b.count++
 
 	if b.first {
 		b.minKey = key
@@ -128,38 +155,88 @@ func (b *storeBuilder) Size() int32 {
 
 // Count returns the number of k/v pairs contained in the store
 func (b *storeBuilder) Count() uint64 {
-	return b.keys.GetCardinality()
+	return b.count
 }
 
-// Close writes file footer before closing resources
-func (b *storeBuilder) Close() error {
-	posOfOffset := b.writer.Size()
-	offset, err := b.offset.Bytes()
-	if err != nil {
-		return fmt.Errorf("marshal store table offsets error:%s", err)
+// UpdateTimeRange extends the time range of data in store
+func (b *storeBuilder) UpdateTimeRange(minTime, maxTime int64) {
+	if !b.hasTimeRange {
+		b.minTime, b.maxTime = minTime, maxTime
+		b.hasTimeRange = true
+		return
 	}
-	if _, err = b.writer.Write(offset); err != nil {
-		return err
+	if minTime < b.minTime {
+		b.minTime = minTime
+	}
+	if maxTime > b.maxTime {
+		b.maxTime = maxTime
 	}
+}
 
-	b.keys.RunOptimize()
-	keys, err := b.keys.MarshalBinary()
-	if err != nil {
-		return err
+// TimeRange returns the time range of data in store
+func (b *storeBuilder) TimeRange() (minTime, maxTime int64, ok bool) {
+	return b.minTime, b.maxTime, b.hasTimeRange
+}
+
+// Digest returns the merkle-style content digest of k/v pairs in store
+func (b *storeBuilder) Digest() uint64 {
+	return b.digest.Sum()
+}
+
+// Close writes file footer before closing resources
+func (b *storeBuilder) Close() error {
+	// write single index block if not partitioned
+	index := &b.index
+	layoutVersion := byte(version1)
+	if b.top.keys > 0 {
+		if b.index.keys > 0 {
+			if err := b.flushPartition(); err != nil {
+				return err
+			}
+		}
+		index = &b.top
+		layoutVersion = version2
 	}
-	posOfKeys := b.writer.Size()
-	if _, err = b.writer.Write(keys); err != nil {
+	posOfEntries, posOfRestarts, err := b.writeIndex(index)
+	if err != nil {
 		return err
 	}
 
-	// for file footer for offsets/keys index, length=1+4+4+8
+	// for file footer for entries/restarts index, length=4+4+1+8
 	var buf [17]byte
-	binary.BigEndian.PutUint32(buf[:4], uint32(posOfOffset))
-	binary.BigEndian.PutUint32(buf[4:8], uint32(posOfKeys))
-	buf[8] = version0
+	binary.BigEndian.PutUint32(buf[:4], uint32(posOfEntries))
+	binary.BigEndian.PutUint32(buf[4:8], uint32(posOfRestarts))
+	buf[8] = layoutVersion | checksumFlag
 	binary.BigEndian.PutUint64(buf[9:], magicNumberOffsetFile)
 	if _, err = b.writer.Write(buf[:]); err != nil {
 		return err
 	}
 	return b.writer.Close()
 }
+
+// flushPartition writes the index block as leaf block, then adds the first key and position of it into top index
+func (b *storeBuilder) flushPartition() error {
+	pos, _, err := b.writeIndex(&b.index)
+	if err != nil {
+		return err
+	}
+	b.top.add(b.index.firstKey, int(pos))
+	b.index.reset()
+	return nil
+}
+
+// writeIndex writes the entries/restarts blocks of index, the checksum of both is appended to restarts block,
+// returns the positions of entries/restarts blocks
+func (b *storeBuilder) writeIndex(index *prefixIndexBuilder) (posOfEntries, posOfRestarts int64, err error) {
+	entries := index.entriesBytes()
+	posOfEntries = b.writer.Size()
+	if _, err = b.writer.Write(entries); err != nil {
+		return 0, 0, err
+	}
+	restarts := index.restartsBytes()
+	posOfRestarts = b.writer.Size()
+	if _, err = b.writer.Write(appendChecksum(restarts, entries, restarts)); err != nil {
+		return 0, 0, err
+	}
+	return posOfEntries, posOfRestarts, nil
+}
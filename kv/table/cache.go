@@ -15,25 +15,29 @@ import (
 type Cache interface {
 	// GetReader returns store reader from cache, create new reader if not exist.
 	GetReader(family string, fileNumber int64) (Reader, error)
+	// Evict closes the reader of file and removes it from cache, if exist.
+	Evict(family string, fileNumber int64)
 	// Close cleans cache data after closing reader resource firstly
 	Close() error
 }
 
 // Cache caches table readers based on map
 type mapCache struct {
-	storePath string
-	readers   map[string]Reader
-	mutex     sync.Mutex
+	storePath  string
+	blockCache BlockCache
+	readers    map[string]Reader
+	mutex      sync.Mutex
 
 	log *logger.Logger
 }
 
-// NewCache creates cache for store readers
-func NewCache(storePath string) Cache {
+// NewCache creates cache for store readers, the readers share the block cache
+func NewCache(storePath string, blockCache BlockCache) Cache {
 	return &mapCache{
-		storePath: storePath,
-		readers:   make(map[string]Reader),
-		log:       logger.GetLogger(fmt.Sprintf("kv/cache[%s]", storePath)),
+		storePath:  storePath,
+		blockCache: blockCache,
+		readers:    make(map[string]Reader),
+		log:        logger.GetLogger(fmt.Sprintf("kv/cache[%s]", storePath)),
 	}
 }
 
@@ -51,7 +55,7 @@ func (c *mapCache) GetReader(family string, fileNumber int64) (Reader, error) {
 
 	// create new reader
 	path := filepath.Join(c.storePath, filePath)
-	newReader, err := newMMapStoreReader(path)
+	newReader, err := newMMapStoreReader(path, c.blockCache)
 	if err != nil {
 		return nil, err
 	}
@@ -59,6 +63,23 @@ func (c *mapCache) GetReader(family string, fileNumber int64) (Reader, error) {
 	return newReader, nil
 }
 
+// Evict closes the reader of file and removes it from cache, if exist.
+func (c *mapCache) Evict(family string, fileNumber int64) {
+	filePath := filepath.Join(family, version.Table(fileNumber))
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	reader, ok := c.readers[filePath]
+	if !ok {
+		return
+	}
+	delete(c.readers, filePath)
+	if err := reader.Close(); err != nil {
+		c.log.Error("close store reader error",
+			logger.String("file", filePath), logger.Error(err))
+	}
+}
+
 // Close closes reader resource and cleans cache data.
 func (c *mapCache) Close() error {
 	for k, v := range c.readers {
@@ -0,0 +1,71 @@
+package table
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/eleme/lindb/pkg/errors"
+)
+
+const (
+	// checksumFlag is set in the version of footer if the blocks of file have checksums
+	checksumFlag = 0x80
+	// length of checksum in bytes
+	checksumLength = 4
+)
+
+// crcTable is the crc32 table using castagnoli polynomial, which is hardware accelerated
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// CorruptionError is the error returned when the data of sst file is corrupted, e.g. checksum mismatch,
+// which is checked by errors.Is(err, errors.ErrCorruption).
+type CorruptionError struct {
+	// Path is the path of corrupted sst file
+	Path string
+	msg  string
+}
+
+// newCorruptionError creates the corruption error of sst file with detail message
+func newCorruptionError(path string, format string, args ...interface{}) *CorruptionError {
+	return &CorruptionError{Path: path, msg: fmt.Sprintf(format, args...)}
+}
+
+// Error returns the detail message with the path of sst file
+func (e *CorruptionError) Error() string {
+	return fmt.Sprintf("sstfile:%s corrupted:%s", e.Path, e.msg)
+}
+
+// Unwrap returns errors.ErrCorruption
+func (e *CorruptionError) Unwrap() error {
+	return errors.ErrCorruption
+}
+
+// appendChecksum appends the checksum of blocks to dst
+func appendChecksum(dst []byte, blocks ...[]byte) []byte {
+	var crc uint32
+	for _, block := range blocks {
+		crc = crc32.Update(crc, crcTable, block)
+	}
+	var buf [checksumLength]byte
+	binary.BigEndian.PutUint32(buf[:], crc)
+	return append(dst, buf[:]...)
+}
+
+// verifyChecksum verifies the checksum at the end of last block, which covers all blocks,
+// returns the last block without checksum.
+func verifyChecksum(blocks ...[]byte) ([]byte, error) {
+	last := blocks[len(blocks)-1]
+	if len(last) < checksumLength {
+		return nil, fmt.Errorf("block is too short for checksum")
+	}
+	var crc uint32
+	for _, block := range blocks[:len(blocks)-1] {
+		crc = crc32.Update(crc, crcTable, block)
+	}
+	data, expect := last[:len(last)-checksumLength], binary.BigEndian.Uint32(last[len(last)-checksumLength:])
+	if crc = crc32.Update(crc, crcTable, data); crc != expect {
+		return nil, fmt.Errorf("checksum mismatch, expect:%d, actual:%d", expect, crc)
+	}
+	return data, nil
+}
@@ -0,0 +1,27 @@
+package table
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/pkg/errors"
+)
+
+func TestChecksum(t *testing.T) {
+	block := appendChecksum([]byte("b"), []byte("a"), []byte("b"))
+	data, err := verifyChecksum([]byte("a"), block)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("b"), data)
+
+	_, err = verifyChecksum([]byte("c"), block)
+	assert.NotNil(t, err)
+	_, err = verifyChecksum([]byte{1, 2})
+	assert.NotNil(t, err)
+}
+
+func TestCorruptionError(t *testing.T) {
+	err := newCorruptionError("000001.sst", "checksum mismatch at:%d", 10)
+	assert.Equal(t, "sstfile:000001.sst corrupted:checksum mismatch at:10", err.Error())
+	assert.True(t, errors.Is(err, errors.ErrCorruption))
+}
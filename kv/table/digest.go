@@ -0,0 +1,59 @@
+package table
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"hash/fnv"
+)
+
+// digestBlockKeys is the number of k/v pairs hashed into a block of digest
+const digestBlockKeys = 1024
+
+// Digest computes the merkle-style content digest of the k/v pairs added in key order, the leaves are the keys
+// with the checksums of values, the leaves are hashed by blocks of keys, then the root hashes the digests of blocks.
+// The stores with same k/v pairs have same digest, so that the replicas are compared without full scans.
+type Digest struct {
+	block  hash.Hash64
+	keys   int
+	blocks []uint64
+	buf    [8]byte
+}
+
+// NewDigest creates the digest of k/v pairs
+func NewDigest() *Digest {
+	return &Digest{block: fnv.New64a()}
+}
+
+// Add adds the k/v pair into digest, the keys must be added in order
+func (d *Digest) Add(key uint32, value []byte) {
+	d.add(key, crc32.Checksum(value, crcTable))
+}
+
+// add adds the key with the checksum of value into current block, starts a new block if it's full
+func (d *Digest) add(key uint32, checksum uint32) {
+	binary.BigEndian.PutUint32(d.buf[:4], key)
+	binary.BigEndian.PutUint32(d.buf[4:], checksum)
+	_, _ = d.block.Write(d.buf[:])
+	d.keys++
+	if d.keys >= digestBlockKeys {
+		d.blocks = append(d.blocks, d.block.Sum64())
+		d.block.Reset()
+		d.keys = 0
+	}
+}
+
+// Sum returns the root digest of the blocks, the k/v pairs can be added after summed
+func (d *Digest) Sum() uint64 {
+	root := fnv.New64a()
+	var buf [8]byte
+	for _, block := range d.blocks {
+		binary.BigEndian.PutUint64(buf[:], block)
+		_, _ = root.Write(buf[:])
+	}
+	if d.keys > 0 {
+		binary.BigEndian.PutUint64(buf[:], d.block.Sum64())
+		_, _ = root.Write(buf[:])
+	}
+	return root.Sum64()
+}
@@ -0,0 +1,29 @@
+package table
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDigest(t *testing.T) {
+	newDigest := func(n uint32, value string) *Digest {
+		d := NewDigest()
+		for key := uint32(0); key < n; key++ {
+			d.Add(key, []byte(value))
+		}
+		return d
+	}
+	assert.Equal(t, NewDigest().Sum(), NewDigest().Sum())
+	d := newDigest(10, "a")
+	assert.Equal(t, d.Sum(), d.Sum())
+	assert.Equal(t, d.Sum(), newDigest(10, "a").Sum())
+	assert.NotEqual(t, d.Sum(), newDigest(10, "b").Sum())
+	assert.NotEqual(t, d.Sum(), newDigest(11, "a").Sum())
+
+	// multi blocks
+	d = newDigest(digestBlockKeys*2+1, "a")
+	assert.Len(t, d.blocks, 2)
+	assert.Equal(t, d.Sum(), newDigest(digestBlockKeys*2+1, "a").Sum())
+	assert.NotEqual(t, d.Sum(), newDigest(digestBlockKeys*2, "a").Sum())
+}
@@ -0,0 +1,384 @@
+package table
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/RoaringBitmap/roaring"
+
+	"github.com/eleme/lindb/pkg/encoding"
+)
+
+const (
+	// number of keys between restart points of prefix index
+	restartInterval = 16
+	// length of key in bytes
+	keyLength = 4
+)
+
+// keyIndex represents the index block of store file, which maps key => offset of value
+type keyIndex interface {
+	// find returns the offset of value for giving key, ok is false if not exist,
+	// returns error if the index block cannot be loaded
+	find(key uint32) (offset int, ok bool, err error)
+	// iterator returns the key iterator in key order
+	iterator() keyIterator
+}
+
+// keyIterator iterates over the keys of index in key order
+type keyIterator interface {
+	// next returns the next key and offset of its value, ok is false if the iterator is exhausted
+	next() (key uint32, offset int, ok bool)
+	// err returns the error if the iterator is exhausted because of corrupted index block
+	err() error
+}
+
+// prefixIndexBuilder builds the index block with shared-prefix key compression(like leveldb block),
+// the key is encoded in big-endian, so that the adjacent series-ID-prefixed keys share the long prefix.
+//
+// entry: shared(1)+unshared key bytes+offset of value(uvarint)
+// the offset is delta of previous entry's offset, except the restart point which keeps whole key and offset.
+// restarts: number of keys(4)+offset of each restart point in entries(4*n)
+type prefixIndexBuilder struct {
+	entries  bytes.Buffer
+	restarts []uint32
+	keys     int
+	firstKey uint32
+
+	lastKey    uint32
+	lastOffset int
+	scratch    [binary.MaxVarintLen64]byte
+}
+
+// add adds key and the offset of its value into index, key must be larger than last key
+func (b *prefixIndexBuilder) add(key uint32, offset int) {
+	shared := 0
+	delta := offset
+	if b.keys == 0 {
+		b.firstKey = key
+	}
+	if b.keys%restartInterval == 0 {
+		b.restarts = append(b.restarts, uint32(b.entries.Len()))
+	} else {
+		shared = sharedPrefixLen(b.lastKey, key)
+		delta = offset - b.lastOffset
+	}
+	var buf [keyLength]byte
+	binary.BigEndian.PutUint32(buf[:], key)
+	b.entries.WriteByte(byte(shared))
+	b.entries.Write(buf[shared:])
+	n := binary.PutUvarint(b.scratch[:], uint64(delta))
+	b.entries.Write(b.scratch[:n])
+
+	b.lastKey = key
+	b.lastOffset = offset
+	b.keys++
+}
+
+// reset resets the builder for building next index block
+func (b *prefixIndexBuilder) reset() {
+	b.entries.Reset()
+	b.restarts = b.restarts[:0]
+	b.keys = 0
+	b.lastKey, b.lastOffset = 0, 0
+}
+
+// entriesBytes returns the encoded entries of index
+func (b *prefixIndexBuilder) entriesBytes() []byte {
+	return b.entries.Bytes()
+}
+
+// restartsBytes returns the encoded number of keys and restart points
+func (b *prefixIndexBuilder) restartsBytes() []byte {
+	buf := make([]byte, 4+4*len(b.restarts))
+	binary.BigEndian.PutUint32(buf, uint32(b.keys))
+	for idx, restart := range b.restarts {
+		binary.BigEndian.PutUint32(buf[4+4*idx:], restart)
+	}
+	return buf
+}
+
+// prefixIndex is the index with shared-prefix key compression, finds the key by binary search over restart points
+type prefixIndex struct {
+	entries  []byte
+	restarts []int
+	keys     int
+}
+
+// newPrefixIndex creates the prefix index based on the entries/restarts block
+func newPrefixIndex(entries, restarts []byte) (*prefixIndex, error) {
+	if len(restarts) < 4 || len(restarts)%4 != 0 {
+		return nil, fmt.Errorf("invalid length of restarts:%d", len(restarts))
+	}
+	idx := &prefixIndex{
+		entries: entries,
+		keys:    int(binary.BigEndian.Uint32(restarts)),
+	}
+	for pos := 4; pos < len(restarts); pos += 4 {
+		restart := int(binary.BigEndian.Uint32(restarts[pos:]))
+		// each restart point keeps whole key at least
+		if restart+1+keyLength > len(entries) {
+			return nil, fmt.Errorf("restart point:%d out of entries:%d", restart, len(entries))
+		}
+		idx.restarts = append(idx.restarts, restart)
+	}
+	if len(idx.restarts) != (idx.keys+restartInterval-1)/restartInterval {
+		return nil, fmt.Errorf("num. of restarts:%d mismatch num. of keys:%d", len(idx.restarts), idx.keys)
+	}
+	return idx, nil
+}
+
+// find returns the offset of value for giving key, ok is false if not exist
+func (idx *prefixIndex) find(key uint32) (offset int, ok bool, err error) {
+	floorKey, offset, ok := idx.floor(key)
+	if !ok || floorKey != key {
+		return 0, false, nil
+	}
+	return offset, true, nil
+}
+
+// floor returns the largest key <= giving key and its offset, ok is false if not exist
+func (idx *prefixIndex) floor(key uint32) (floorKey uint32, offset int, ok bool) {
+	// find the last restart point which key <= giving key
+	i := sort.Search(len(idx.restarts), func(i int) bool {
+		return idx.restartKey(i) > key
+	}) - 1
+	if i < 0 {
+		return 0, 0, false
+	}
+	end := len(idx.entries)
+	if i+1 < len(idx.restarts) {
+		end = idx.restarts[i+1]
+	}
+	var (
+		k     uint32
+		off   int
+		valid bool
+		found bool
+	)
+	pos := idx.restarts[i]
+	for pos < end {
+		if k, off, pos, valid = decodeEntry(idx.entries, pos, k, off); !valid || k > key {
+			break
+		}
+		floorKey, offset, found = k, off, true
+	}
+	return floorKey, offset, found
+}
+
+// Size returns the memory size of index in bytes
+func (idx *prefixIndex) Size() int {
+	return len(idx.entries) + 8*len(idx.restarts)
+}
+
+// iterator returns the key iterator in key order
+func (idx *prefixIndex) iterator() keyIterator {
+	return &prefixIndexIterator{index: idx}
+}
+
+// restartKey returns the whole key at restart point
+func (idx *prefixIndex) restartKey(i int) uint32 {
+	pos := idx.restarts[i] + 1
+	return binary.BigEndian.Uint32(idx.entries[pos : pos+keyLength])
+}
+
+// prefixIndexIterator iterates over the entries of prefix index
+type prefixIndexIterator struct {
+	index   *prefixIndex
+	pos     int
+	restart int
+
+	key    uint32
+	offset int
+	failed bool
+}
+
+// next returns the next key and offset of its value, ok is false if the iterator is exhausted
+func (it *prefixIndexIterator) next() (key uint32, offset int, ok bool) {
+	if it.failed {
+		return 0, 0, false
+	}
+	// restart point keeps whole key and offset
+	if it.restart < len(it.index.restarts) && it.pos == it.index.restarts[it.restart] {
+		it.key, it.offset = 0, 0
+		it.restart++
+	}
+	if it.key, it.offset, it.pos, ok = decodeEntry(it.index.entries, it.pos, it.key, it.offset); !ok {
+		// the entries are exhausted if decoding at the end
+		it.failed = it.pos < len(it.index.entries)
+		return 0, 0, false
+	}
+	return it.key, it.offset, true
+}
+
+// err returns the error if the entries cannot be decoded
+func (it *prefixIndexIterator) err() error {
+	if it.failed {
+		return fmt.Errorf("decode index entry at:%d failure", it.pos)
+	}
+	return nil
+}
+
+// partitionedIndex is the two-level index, the top index maps the first key of each leaf index block
+// to the position of leaf block, which is loaded lazily
+type partitionedIndex struct {
+	top  *prefixIndex
+	leaf func(pos int) (*prefixIndex, error)
+}
+
+// find returns the offset of value for giving key, ok is false if not exist,
+// returns error if the leaf index block cannot be loaded
+func (idx *partitionedIndex) find(key uint32) (offset int, ok bool, err error) {
+	_, pos, ok := idx.top.floor(key)
+	if !ok {
+		return 0, false, nil
+	}
+	leaf, err := idx.leaf(pos)
+	if err != nil {
+		return 0, false, err
+	}
+	return leaf.find(key)
+}
+
+// iterator returns the key iterator in key order
+func (idx *partitionedIndex) iterator() keyIterator {
+	return &partitionedIndexIterator{index: idx, topIt: idx.top.iterator()}
+}
+
+// partitionedIndexIterator iterates over the keys of leaf index blocks in order
+type partitionedIndexIterator struct {
+	index   *partitionedIndex
+	topIt   keyIterator
+	leafIt  keyIterator
+	leafErr error
+}
+
+// next returns the next key and offset of its value, ok is false if the iterator is exhausted
+func (it *partitionedIndexIterator) next() (key uint32, offset int, ok bool) {
+	for it.leafErr == nil {
+		if it.leafIt != nil {
+			if key, offset, ok = it.leafIt.next(); ok {
+				return key, offset, true
+			}
+			if it.leafErr = it.leafIt.err(); it.leafErr != nil {
+				break
+			}
+		}
+		_, pos, hasNext := it.topIt.next()
+		if !hasNext {
+			break
+		}
+		leaf, err := it.index.leaf(pos)
+		if err != nil {
+			it.leafErr = err
+			break
+		}
+		it.leafIt = leaf.iterator()
+	}
+	return 0, 0, false
+}
+
+// err returns the error if the top/leaf index blocks cannot be loaded or decoded
+func (it *partitionedIndexIterator) err() error {
+	if it.leafErr != nil {
+		return it.leafErr
+	}
+	return it.topIt.err()
+}
+
+// decodeEntry decodes the entry at pos based on previous key/offset, returns the position of next entry
+func decodeEntry(entries []byte, pos int, prevKey uint32, prevOffset int) (key uint32, offset, next int, ok bool) {
+	if pos >= len(entries) {
+		return 0, 0, pos, false
+	}
+	shared := int(entries[pos])
+	if shared > keyLength {
+		return 0, 0, pos, false
+	}
+	pos++
+	end := pos + keyLength - shared
+	if end > len(entries) {
+		return 0, 0, pos, false
+	}
+	var buf [keyLength]byte
+	binary.BigEndian.PutUint32(buf[:], prevKey)
+	copy(buf[shared:], entries[pos:end])
+	delta, n := binary.Uvarint(entries[end:])
+	if n <= 0 {
+		return 0, 0, pos, false
+	}
+	return binary.BigEndian.Uint32(buf[:]), prevOffset + int(delta), end + n, true
+}
+
+// sharedPrefixLen returns the length of shared prefix of keys in big-endian
+func sharedPrefixLen(a, b uint32) int {
+	shared := 0
+	for shift := uint(24); shared < keyLength; shift -= 8 {
+		if byte(a>>shift) != byte(b>>shift) {
+			break
+		}
+		shared++
+	}
+	return shared
+}
+
+// bitmapIndex is the index of file layout version0, which keeps keys in bitmap and offsets in delta encoding
+type bitmapIndex struct {
+	keys    *roaring.Bitmap
+	offsets []int32
+}
+
+// newBitmapIndex creates the bitmap index based on the keys/offsets block
+func newBitmapIndex(keys, offsets []byte) (keyIndex, error) {
+	idx := &bitmapIndex{keys: roaring.New()}
+	if err := idx.keys.UnmarshalBinary(keys); err != nil {
+		return nil, fmt.Errorf("unmarshal keys data error:%s", err)
+	}
+	d := encoding.NewDeltaBitPackingDecoder(&offsets)
+	for d.HasNext() {
+		idx.offsets = append(idx.offsets, d.Next())
+	}
+	if len(idx.offsets) != int(idx.keys.GetCardinality()) {
+		return nil, fmt.Errorf("num. of keys != num. of offsets")
+	}
+	return idx, nil
+}
+
+// find returns the offset of value for giving key, ok is false if not exist
+func (idx *bitmapIndex) find(key uint32) (offset int, ok bool, err error) {
+	if !idx.keys.Contains(key) {
+		return 0, false, nil
+	}
+	// bitmap data's index from 1, so idx=get index -1
+	return int(idx.offsets[idx.keys.Rank(key)-1]), true, nil
+}
+
+// iterator returns the key iterator in key order
+func (idx *bitmapIndex) iterator() keyIterator {
+	return &bitmapIndexIterator{index: idx, keyIt: idx.keys.Iterator()}
+}
+
+// bitmapIndexIterator iterates over the keys of bitmap index
+type bitmapIndexIterator struct {
+	index *bitmapIndex
+	keyIt roaring.IntIterable
+	idx   int
+}
+
+// next returns the next key and offset of its value, ok is false if the iterator is exhausted
+func (it *bitmapIndexIterator) next() (key uint32, offset int, ok bool) {
+	if !it.keyIt.HasNext() {
+		return 0, 0, false
+	}
+	key = it.keyIt.Next()
+	offset = int(it.index.offsets[it.idx])
+	it.idx++
+	return key, offset, true
+}
+
+// err returns nil, because the bitmap is decoded when creating index
+func (it *bitmapIndexIterator) err() error {
+	return nil
+}
@@ -0,0 +1,139 @@
+package table
+
+import (
+	"testing"
+
+	"github.com/RoaringBitmap/roaring"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/pkg/encoding"
+)
+
+func TestPrefixIndex(t *testing.T) {
+	b := &prefixIndexBuilder{}
+	var keys []uint32
+	for i := 0; i < 100; i++ {
+		key := uint32(1<<20 + i*3)
+		keys = append(keys, key)
+		b.add(key, i*10)
+	}
+	idx, err := newPrefixIndex(b.entriesBytes(), b.restartsBytes())
+	assert.Nil(t, err)
+	assert.Equal(t, 100, idx.keys)
+	// 1 restart point for each 16 keys
+	assert.Len(t, idx.restarts, 7)
+
+	for i, key := range keys {
+		offset, ok, _ := idx.find(key)
+		assert.True(t, ok)
+		assert.Equal(t, i*10, offset)
+		_, ok, _ = idx.find(key + 1)
+		assert.False(t, ok)
+	}
+	_, ok, _ := idx.find(0)
+	assert.False(t, ok)
+	_, ok, _ = idx.find(1 << 30)
+	assert.False(t, ok)
+
+	it := idx.iterator()
+	for i, key := range keys {
+		k, offset, ok := it.next()
+		assert.True(t, ok)
+		assert.Equal(t, key, k)
+		assert.Equal(t, i*10, offset)
+	}
+	_, _, ok = it.next()
+	assert.False(t, ok)
+}
+
+func TestPrefixIndex_compression(t *testing.T) {
+	b := &prefixIndexBuilder{}
+	for i := 0; i < 100; i++ {
+		b.add(uint32(0x01020300+i), i)
+	}
+	// restart point keeps whole key(1+4+1), others keep the last byte of key(1+1+1)
+	assert.Equal(t, 7*6+(100-7)*3, len(b.entriesBytes()))
+}
+
+func TestPrefixIndex_corrupt(t *testing.T) {
+	_, err := newPrefixIndex(nil, []byte{1, 2})
+	assert.NotNil(t, err)
+	// restart point out of entries
+	_, err = newPrefixIndex(nil, []byte{0, 0, 0, 1, 0, 0, 0, 0})
+	assert.NotNil(t, err)
+	// num. of keys mismatch
+	b := &prefixIndexBuilder{}
+	b.add(1, 0)
+	restarts := b.restartsBytes()
+	restarts[3] = 20
+	_, err = newPrefixIndex(b.entriesBytes(), restarts)
+	assert.NotNil(t, err)
+
+	_, _, _, ok := decodeEntry([]byte{5}, 0, 0, 0)
+	assert.False(t, ok)
+	_, _, _, ok = decodeEntry([]byte{0, 1}, 0, 0, 0)
+	assert.False(t, ok)
+	_, _, _, ok = decodeEntry([]byte{3, 1}, 0, 0, 0)
+	assert.False(t, ok)
+}
+
+func TestSharedPrefixLen(t *testing.T) {
+	assert.Equal(t, 0, sharedPrefixLen(0x01000000, 0x02000000))
+	assert.Equal(t, 2, sharedPrefixLen(0x01020304, 0x01020404))
+	assert.Equal(t, 3, sharedPrefixLen(0x01020304, 0x01020305))
+	assert.Equal(t, 4, sharedPrefixLen(10, 10))
+}
+
+func TestBitmapIndex(t *testing.T) {
+	keys := roaring.BitmapOf(1, 10, 100)
+	keysData, _ := keys.MarshalBinary()
+	encoder := encoding.NewDeltaBitPackingEncoder()
+	encoder.Add(0)
+	encoder.Add(5)
+	encoder.Add(20)
+	offsets, _ := encoder.Bytes()
+
+	idx, err := newBitmapIndex(keysData, offsets)
+	assert.Nil(t, err)
+	assert.Len(t, idx.(*bitmapIndex).offsets, 3)
+	offset, ok, _ := idx.find(10)
+	assert.True(t, ok)
+	assert.Equal(t, 5, offset)
+	_, ok, _ = idx.find(2)
+	assert.False(t, ok)
+
+	it := idx.iterator()
+	key, offset, ok := it.next()
+	assert.True(t, ok)
+	assert.Equal(t, uint32(1), key)
+	assert.Equal(t, 0, offset)
+
+	_, err = newBitmapIndex([]byte{1, 2, 3}, offsets)
+	assert.NotNil(t, err)
+	_, err = newBitmapIndex(keysData, nil)
+	assert.NotNil(t, err)
+}
+
+func TestPrefixIndex_floor(t *testing.T) {
+	b := &prefixIndexBuilder{}
+	for i := 1; i <= 40; i++ {
+		b.add(uint32(i*10), i)
+	}
+	assert.Equal(t, uint32(10), b.firstKey)
+	idx, _ := newPrefixIndex(b.entriesBytes(), b.restartsBytes())
+	key, offset, ok := idx.floor(175)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(170), key)
+	assert.Equal(t, 17, offset)
+	key, _, ok = idx.floor(1000)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(400), key)
+	_, _, ok = idx.floor(9)
+	assert.False(t, ok)
+
+	b.reset()
+	b.add(5, 1)
+	assert.Equal(t, uint32(5), b.firstKey)
+	idx, _ = newPrefixIndex(b.entriesBytes(), b.restartsBytes())
+	assert.Equal(t, 1, idx.keys)
+}
@@ -0,0 +1,149 @@
+package table
+
+import (
+	"fmt"
+
+	"github.com/eleme/lindb/pkg/bufioutil"
+)
+
+// FileInfo represents the layout of sst file, which is used for debugging storage offline
+type FileInfo struct {
+	Path      string
+	Size      int
+	Version   int
+	Checksum  bool
+	NumOfKeys int
+	MinKey    uint32
+	MaxKey    uint32
+	// Top is the top index block of two-level index, nil if the index isn't partitioned
+	Top *BlockInfo
+	// IndexBlocks are the single index block or the leaf index blocks of two-level index
+	IndexBlocks []BlockInfo
+	// Corruption is the first corruption found when verifying the checksums of values
+	Corruption string
+	// Digest is the content digest of k/v pairs computed when verifying(see Digest),
+	// which is compared with the digest of file in manifest
+	Digest uint64
+}
+
+// BlockInfo represents the index block of sst file
+type BlockInfo struct {
+	Position  int
+	Size      int
+	NumOfKeys int
+	FirstKey  uint32
+	LastKey   uint32
+}
+
+// Inspect reads the footer and index blocks of sst file, verifies the checksums of all values if verify is true
+func Inspect(path string, verify bool) (*FileInfo, error) {
+	reader, err := newMMapStoreReader(path, NewBlockCache(0))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	r := reader.(*storeMMapReader)
+	info := &FileInfo{
+		Path:     path,
+		Size:     r.len,
+		Version:  int(r.version),
+		Checksum: r.checksum,
+	}
+	switch index := r.index.(type) {
+	case *bitmapIndex:
+		block := BlockInfo{
+			Position:  r.indexPos[0],
+			Size:      r.recordSize(r.indexPos[0]) + r.recordSize(r.indexPos[1]),
+			NumOfKeys: len(index.offsets),
+		}
+		if !index.keys.IsEmpty() {
+			block.FirstKey, block.LastKey = index.keys.Minimum(), index.keys.Maximum()
+		}
+		info.IndexBlocks = append(info.IndexBlocks, block)
+	case *prefixIndex:
+		info.IndexBlocks = append(info.IndexBlocks, r.inspectIndex(r.indexPos[0], index))
+	case *partitionedIndex:
+		top := r.inspectIndex(r.indexPos[0], index.top)
+		info.Top = &top
+		it := index.top.iterator()
+		for {
+			_, pos, ok := it.next()
+			if !ok {
+				break
+			}
+			leaf, err := r.loadLeaf(pos)
+			if err != nil {
+				return nil, err
+			}
+			info.IndexBlocks = append(info.IndexBlocks, r.inspectIndex(pos, leaf))
+		}
+	}
+	for _, block := range info.IndexBlocks {
+		if block.NumOfKeys == 0 {
+			continue
+		}
+		if info.NumOfKeys == 0 {
+			info.MinKey = block.FirstKey
+		}
+		info.MaxKey = block.LastKey
+		info.NumOfKeys += block.NumOfKeys
+	}
+	if verify {
+		info.Corruption, info.Digest = r.verifyValues()
+	}
+	return info, nil
+}
+
+// inspectIndex returns the block info of prefix index at position, the restarts block follows the entries block
+func (r *storeMMapReader) inspectIndex(pos int, index *prefixIndex) BlockInfo {
+	size := r.recordSize(pos)
+	block := BlockInfo{
+		Position:  pos,
+		Size:      size + r.recordSize(pos+size),
+		NumOfKeys: index.keys,
+	}
+	it := index.iterator()
+	for idx := 0; ; idx++ {
+		key, _, ok := it.next()
+		if !ok {
+			break
+		}
+		if idx == 0 {
+			block.FirstKey = key
+		}
+		block.LastKey = key
+	}
+	return block
+}
+
+// verifyValues verifies the checksums of all values, returns the message of first corruption,
+// and the content digest of k/v pairs
+func (r *storeMMapReader) verifyValues() (corruption string, digest uint64) {
+	d := NewDigest()
+	it := r.Iterator()
+	for it.Next() {
+		value, err := r.Read(it.Key(), true)
+		if err != nil {
+			return err.Error(), 0
+		}
+		d.Add(it.Key(), value)
+	}
+	if err := it.Err(); err != nil {
+		return err.Error(), 0
+	}
+	return "", d.Sum()
+}
+
+// recordSize returns the size of record at offset written by bufioutil, including the length of record
+func (r *storeMMapReader) recordSize(offset int) int {
+	data := r.readBytes(offset)
+	return int(bufioutil.GetVariantLength(uint64(len(data)))) + len(data)
+}
+
+// String returns the summary of index block
+func (b BlockInfo) String() string {
+	return fmt.Sprintf("position:%d size:%d keys:%d range:[%d,%d]",
+		b.Position, b.Size, b.NumOfKeys, b.FirstKey, b.LastKey)
+}
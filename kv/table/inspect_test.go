@@ -0,0 +1,69 @@
+package table
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/kv/version"
+	"github.com/eleme/lindb/pkg/util"
+)
+
+func TestInspect(t *testing.T) {
+	_ = util.MkDirIfNotExist(testKVPath)
+	defer os.RemoveAll(testKVPath)
+	fileName := filepath.Join(testKVPath, version.Table(10))
+
+	builder, _ := NewStoreBuilder(testKVPath, 10)
+	_ = builder.Add(1, []byte("test"))
+	_ = builder.Add(10, []byte("test10"))
+	assert.Nil(t, builder.Close())
+	info, err := Inspect(fileName, true)
+	assert.Nil(t, err)
+	assert.Equal(t, int(builder.Size()), info.Size)
+	assert.Equal(t, version1, info.Version)
+	assert.True(t, info.Checksum)
+	assert.Equal(t, 2, info.NumOfKeys)
+	assert.Equal(t, uint32(1), info.MinKey)
+	assert.Equal(t, uint32(10), info.MaxKey)
+	assert.Nil(t, info.Top)
+	assert.Len(t, info.IndexBlocks, 1)
+	assert.Equal(t, "position:20 size:23 keys:2 range:[1,10]", info.IndexBlocks[0].String())
+	assert.Empty(t, info.Corruption)
+	// the digest computed when verifying is same as the digest of builder
+	assert.Equal(t, builder.Digest(), info.Digest)
+	digest := NewDigest()
+	digest.Add(1, []byte("test"))
+	digest.Add(10, []byte("test10"))
+	assert.Equal(t, digest.Sum(), info.Digest)
+
+	// partitioned index
+	builder, _ = NewStoreBuilder(testKVPath, 11)
+	builder.(*storeBuilder).partitionKeys = 2
+	for key := uint32(1); key <= 5; key++ {
+		_ = builder.Add(key, []byte{byte(key)})
+	}
+	assert.Nil(t, builder.Close())
+	info, err = Inspect(filepath.Join(testKVPath, version.Table(11)), false)
+	assert.Nil(t, err)
+	assert.Equal(t, version2, info.Version)
+	assert.Equal(t, 5, info.NumOfKeys)
+	assert.Equal(t, uint32(5), info.MaxKey)
+	assert.Equal(t, 3, info.Top.NumOfKeys)
+	assert.Len(t, info.IndexBlocks, 3)
+	assert.Equal(t, uint32(3), info.IndexBlocks[1].FirstKey)
+
+	// corrupted value
+	data, _ := ioutil.ReadFile(fileName)
+	data[1] ^= 0xff
+	assert.Nil(t, ioutil.WriteFile(fileName, data, 0644))
+	info, err = Inspect(fileName, true)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, info.Corruption)
+
+	_, err = Inspect(filepath.Join(testKVPath, version.Table(12)), false)
+	assert.NotNil(t, err)
+}
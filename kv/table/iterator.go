@@ -9,6 +9,8 @@ type Iterator interface {
 	Key() uint32
 	// Value returns the value of the current key/value pair
 	Value() []byte
+	// Err returns the error if the iterator is exhausted because of corrupted data
+	Err() error
 }
 
 // mergedIterator iteratores over some iterator in key order
@@ -5,44 +5,50 @@ import (
 	"encoding/binary"
 	"fmt"
 
-	"github.com/RoaringBitmap/roaring"
-
 	"github.com/eleme/lindb/pkg/bufioutil"
-	"github.com/eleme/lindb/pkg/encoding"
 	"github.com/eleme/lindb/pkg/mmap"
 )
 
 const (
 	sstFileFooterSize = 1 + // entry length wrote by bufioutil
-		4 + // posOfOffset(4)
-		4 + // posOfKeys(4)
+		4 + // posOfOffset(4) for version0, posOfEntries(4) of (top) index for version1/2
+		4 + // posOfKeys(4) for version0, posOfRestarts(4) of (top) index for version1/2
 		1 + // version(1)
 		8 // magicNumber(8)
-	// footer-size, offset(1), keys(1)
+	// footer-size, two blocks of index(1+1)
 	sstFileMinLength = sstFileFooterSize + 2
 )
 
 // Reader reads k/v pair from store file
 type Reader interface {
-	// Get returns value for giving key
+	// Get returns value for giving key, returns nil if not exist or cannot be read
 	Get(key uint32) []byte
+	// Read returns value for giving key, verifies the checksum of value if verify is true,
+	// returns *CorruptionError if the data of file is corrupted
+	Read(key uint32, verify bool) ([]byte, error)
 	// Iterator iterates over a store's key/value pairs in key order.
 	Iterator() Iterator
+	// WarmUp loads all leaf index blocks of file into block cache, so that the first reads after opened
+	// don't read them from disk, returns the number of blocks loaded
+	WarmUp() (int, error)
 	// Close closes reader, release related resources
 	Close() error
 }
 
 // storeMMapReader is mmap store file reader
 type storeMMapReader struct {
-	path    string          // path of sst-file
-	data    []byte          // mmaped  file content
-	len     int             // length of the file
-	keys    *roaring.Bitmap // bitmap of keys
-	offsets []int32         // offset of values
+	path       string     // path of sst-file
+	data       []byte     // mmaped  file content
+	len        int        // length of the file
+	index      keyIndex   // index of keys => offset of values
+	version    byte       // layout version of file
+	checksum   bool       // if the blocks have checksums
+	indexPos   [2]int     // positions of (top) index blocks in footer
+	blockCache BlockCache // cache of leaf index blocks
 }
 
 // newMMapStoreReader creates mmap store file reader
-func newMMapStoreReader(path string) (Reader, error) {
+func newMMapStoreReader(path string, blockCache BlockCache) (Reader, error) {
 	data, err := mmap.Map(path)
 	if err != nil {
 		return nil, fmt.Errorf("create mmap store reader error:%s", err)
@@ -54,53 +60,163 @@ func newMMapStoreReader(path string) (Reader, error) {
 		path: path,
 		data: data,
 		len:  len(data),
-		keys: roaring.New(),
+
+		blockCache: blockCache,
 	}
 
 	if err := r.initialize(); err != nil {
+		_ = mmap.Unmap(data)
 		return nil, err
 	}
 
 	return r, nil
 }
 
-// initialize initializes store reader, reads index block(keys,offset etc.), then caches it
+// initialize initializes store reader, reads index block(keys,offset etc.), then caches it,
+// the checksum of index block is always verified
 func (r *storeMMapReader) initialize() error {
 	buf := r.readBytes(r.len - sstFileFooterSize)
 	if (len(buf)) != sstFileFooterSize-1 {
-		return fmt.Errorf("read sstfile:%s footer error", r.path)
+		return newCorruptionError(r.path, "read footer failure")
 	}
 	// validate magic-number
 	if binary.BigEndian.Uint64(buf[9:]) != magicNumberOffsetFile {
-		return fmt.Errorf("verify magic-number of sstfile:%s failure", r.path)
+		return newCorruptionError(r.path, "verify magic-number failure")
 	}
-	posOfOffset := int(binary.BigEndian.Uint32(buf[:4]))
-	posOfKeys := int(binary.BigEndian.Uint32(buf[4:8]))
-	if err := r.keys.UnmarshalBinary(r.readBytes(posOfKeys)); err != nil {
-		return fmt.Errorf("unmarshal keys data from file[%s] error:%s", r.path, err)
+	pos1 := int(binary.BigEndian.Uint32(buf[:4]))
+	pos2 := int(binary.BigEndian.Uint32(buf[4:8]))
+	if pos1 >= r.len || pos2 >= r.len {
+		return newCorruptionError(r.path, "position of index block out of file")
 	}
-	offset := r.readBytes(posOfOffset)
-	d := encoding.NewDeltaBitPackingDecoder(&offset)
-
-	for d.HasNext() {
-		r.offsets = append(r.offsets, d.Next())
+	r.checksum = buf[8]&checksumFlag != 0
+	r.version = buf[8] &^ checksumFlag
+	r.indexPos = [2]int{pos1, pos2}
+	var err error
+	switch version := r.version; version {
+	case version0:
+		r.index, err = newBitmapIndex(r.readBytes(pos2), r.readBytes(pos1))
+	case version1:
+		r.index, err = r.readIndex(pos1, pos2)
+	case version2:
+		var top *prefixIndex
+		if top, err = r.readIndex(pos1, pos2); err == nil {
+			r.index = &partitionedIndex{top: top, leaf: r.loadLeaf}
+		}
+	default:
+		return fmt.Errorf("unknown layout version:%d of sstfile:%s", version, r.path)
 	}
-
-	if len(r.offsets) != int(r.keys.GetCardinality()) {
-		return fmt.Errorf("num. of keys != num. of offsets in file[%s]", r.path)
+	if err != nil {
+		return newCorruptionError(r.path, "read index block error:%s", err)
 	}
 	return nil
 }
 
-// Get return value for key, if not exist return nil
+// Get return value for key, if not exist or cannot be read return nil
 func (r *storeMMapReader) Get(key uint32) []byte {
-	if !r.keys.Contains(key) {
-		return nil
+	value, _ := r.Read(key, false)
+	return value
+}
+
+// Read returns value for giving key, verifies the checksum of value if verify is true,
+// returns *CorruptionError if the data of file is corrupted
+func (r *storeMMapReader) Read(key uint32, verify bool) ([]byte, error) {
+	offset, ok, err := r.index.find(key)
+	if err != nil {
+		return nil, r.corrupted(err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	return r.readValue(offset, verify)
+}
+
+// readValue reads the value at offset, strips the checksum of value if exist
+func (r *storeMMapReader) readValue(offset int, verify bool) ([]byte, error) {
+	value := r.readBytes(offset)
+	if value == nil {
+		return nil, newCorruptionError(r.path, "read value at:%d failure", offset)
+	}
+	if !r.checksum {
+		return value, nil
+	}
+	if verify {
+		data, err := verifyChecksum(value)
+		if err != nil {
+			return nil, newCorruptionError(r.path, "verify value at:%d error:%s", offset, err)
+		}
+		return data, nil
+	}
+	if len(value) < checksumLength {
+		return nil, newCorruptionError(r.path, "value at:%d is too short", offset)
+	}
+	return value[:len(value)-checksumLength], nil
+}
+
+// readIndex reads the entries/restarts blocks of index at positions, verifies the checksum if exist
+func (r *storeMMapReader) readIndex(posOfEntries, posOfRestarts int) (*prefixIndex, error) {
+	entries, restarts := r.readBytes(posOfEntries), r.readBytes(posOfRestarts)
+	if r.checksum {
+		var err error
+		if restarts, err = verifyChecksum(entries, restarts); err != nil {
+			return nil, err
+		}
+	}
+	return newPrefixIndex(entries, restarts)
+}
+
+// corrupted returns the corruption error of file if the error isn't
+func (r *storeMMapReader) corrupted(err error) error {
+	if _, ok := err.(*CorruptionError); ok {
+		return err
+	}
+	return newCorruptionError(r.path, err.Error())
+}
+
+// loadLeaf loads the leaf index block at position from block cache,
+// reads it from file and puts it into cache if not cached
+func (r *storeMMapReader) loadLeaf(pos int) (*prefixIndex, error) {
+	if block, ok := r.blockCache.Get(r.path, pos); ok {
+		return block.(*prefixIndex), nil
+	}
+	entries := r.readBytes(pos)
+	if entries == nil {
+		return nil, newCorruptionError(r.path, "read leaf index block at:%d failure", pos)
+	}
+	// restarts block is followed by entries block
+	posOfRestarts := pos + int(bufioutil.GetVariantLength(uint64(len(entries)))) + len(entries)
+	leaf, err := r.readIndex(pos, posOfRestarts)
+	if err != nil {
+		return nil, newCorruptionError(r.path, "read leaf index block at:%d error:%s", pos, err)
+	}
+	// copy entries from mmap data, which is released after unmapping file
+	leaf.entries = append([]byte(nil), leaf.entries...)
+	r.blockCache.Put(r.path, pos, leaf)
+	return leaf, nil
+}
+
+// WarmUp loads all leaf index blocks of file into block cache, the index of file without leaf blocks
+// is loaded after opened already.
+func (r *storeMMapReader) WarmUp() (int, error) {
+	index, ok := r.index.(*partitionedIndex)
+	if !ok {
+		return 0, nil
+	}
+	blocks := 0
+	it := index.top.iterator()
+	for {
+		_, pos, ok := it.next()
+		if !ok {
+			break
+		}
+		if _, err := r.loadLeaf(pos); err != nil {
+			return blocks, err
+		}
+		blocks++
+	}
+	if err := it.err(); err != nil {
+		return blocks, r.corrupted(err)
 	}
-	// bitmap data's index from 1, so idx=get index -1
-	idx := r.keys.Rank(key)
-	offset := r.offsets[idx-1]
-	return r.readBytes(int(offset))
+	return blocks, nil
 }
 
 // Iterator iterates over a store's key/value pairs in key order.
@@ -110,11 +226,15 @@ func (r *storeMMapReader) Iterator() Iterator {
 
 // close store reader, release resource
 func (r *storeMMapReader) Close() error {
+	r.blockCache.Evict(r.path)
 	return mmap.Unmap(r.data)
 }
 
 // readBytes reads bytes from buffer, read length+data format
 func (r *storeMMapReader) readBytes(offset int) []byte {
+	if offset < 0 || offset >= len(r.data) {
+		return nil
+	}
 	length, err := binary.ReadUvarint(bytes.NewReader(r.data[offset:]))
 	if err != nil {
 		return nil
@@ -131,34 +251,46 @@ func (r *storeMMapReader) readBytes(offset int) []byte {
 // storeMMapIterator iterates k/v pair using mmap store reader
 type storeMMapIterator struct {
 	reader *storeMMapReader
-	keyIt  roaring.IntIterable
+	keyIt  keyIterator
 
-	idx int
+	key    uint32
+	offset int
 }
 
 // newMMapIterator creates store iterator using mmap store reader
 func newMMapIterator(reader *storeMMapReader) Iterator {
 	return &storeMMapIterator{
 		reader: reader,
-		keyIt:  reader.keys.Iterator(),
+		keyIt:  reader.index.iterator(),
 	}
 }
 
 // Next moves the iterator to the next key/value pair.
 // It returns false if the iterator is exhausted.
 func (it *storeMMapIterator) Next() bool {
-	return it.keyIt.HasNext()
+	key, offset, ok := it.keyIt.next()
+	if !ok {
+		return false
+	}
+	it.key, it.offset = key, offset
+	return true
 }
 
 // Key returns the key of the current key/value pair
 func (it *storeMMapIterator) Key() uint32 {
-	key := it.keyIt.Next()
-	return key
+	return it.key
 }
 
-// Value returns the value of the current key/value pair
+// Value returns the value of the current key/value pair, the checksum of value isn't verified
 func (it *storeMMapIterator) Value() []byte {
-	offset := it.reader.offsets[it.idx]
-	it.idx++
-	return it.reader.readBytes(int(offset))
+	value, _ := it.reader.readValue(it.offset, false)
+	return value
+}
+
+// Err returns *CorruptionError if the iterator is exhausted because of corrupted index block
+func (it *storeMMapIterator) Err() error {
+	if err := it.keyIt.err(); err != nil {
+		return it.reader.corrupted(err)
+	}
+	return nil
 }
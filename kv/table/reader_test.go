@@ -1,11 +1,18 @@
 package table
 
 import (
+	"encoding/binary"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/RoaringBitmap/roaring"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/eleme/lindb/kv/version"
+	"github.com/eleme/lindb/pkg/bufioutil"
+	"github.com/eleme/lindb/pkg/encoding"
 	"github.com/eleme/lindb/pkg/util"
 )
 
@@ -20,7 +27,7 @@ func TestReader(t *testing.T) {
 	err = builder.Close()
 	assert.Nil(t, err)
 
-	cache := NewCache(testKVPath)
+	cache := NewCache(testKVPath, NewBlockCache(0))
 
 	var reader, err2 = cache.GetReader("", 10)
 	if err2 != nil {
@@ -44,7 +51,7 @@ func TestStoreIterator(t *testing.T) {
 	err = builder.Close()
 	assert.Nil(t, err)
 
-	cache := NewCache(testKVPath)
+	cache := NewCache(testKVPath, NewBlockCache(0))
 	var reader, err2 = cache.GetReader("", 10)
 	if err2 != nil {
 		t.Error(err2)
@@ -61,3 +68,263 @@ func TestStoreIterator(t *testing.T) {
 
 	assert.False(t, it.Next())
 }
+
+func TestReader_restarts(t *testing.T) {
+	_ = util.MkDirIfNotExist(testKVPath)
+	defer os.RemoveAll(testKVPath)
+	builder, _ := NewStoreBuilder(testKVPath, 10)
+	// series-ID-prefixed keys across some restart points
+	var keys []uint32
+	for i := 0; i < 50; i++ {
+		key := uint32(100<<16 | i<<8 | i)
+		keys = append(keys, key)
+		assert.Nil(t, builder.Add(key, []byte{byte(i)}))
+	}
+	assert.Nil(t, builder.Close())
+
+	reader, err := newMMapStoreReader(filepath.Join(testKVPath, version.Table(10)), NewBlockCache(0))
+	assert.Nil(t, err)
+	defer reader.Close()
+	for i, key := range keys {
+		assert.Equal(t, []byte{byte(i)}, reader.Get(key))
+	}
+	assert.Nil(t, reader.Get(1))
+	assert.Nil(t, reader.Get(101<<16))
+
+	it := reader.Iterator()
+	for i, key := range keys {
+		assert.True(t, it.Next())
+		assert.Equal(t, key, it.Key())
+		assert.Equal(t, []byte{byte(i)}, it.Value())
+	}
+	assert.False(t, it.Next())
+}
+
+func TestReader_version0(t *testing.T) {
+	_ = util.MkDirIfNotExist(testKVPath)
+	defer os.RemoveAll(testKVPath)
+	fileName := filepath.Join(testKVPath, version.Table(10))
+	// build store file of layout version0
+	writer, _ := bufioutil.NewBufioWriter(fileName)
+	keys := roaring.New()
+	offsets := encoding.NewDeltaBitPackingEncoder()
+	for _, key := range []uint32{1, 10} {
+		offsets.Add(int32(writer.Size()))
+		keys.Add(key)
+		_, _ = writer.Write([]byte{byte(key)})
+	}
+	posOfOffset := writer.Size()
+	offsetData, _ := offsets.Bytes()
+	_, _ = writer.Write(offsetData)
+	posOfKeys := writer.Size()
+	keysData, _ := keys.MarshalBinary()
+	_, _ = writer.Write(keysData)
+	var footer [17]byte
+	binary.BigEndian.PutUint32(footer[:4], uint32(posOfOffset))
+	binary.BigEndian.PutUint32(footer[4:8], uint32(posOfKeys))
+	footer[8] = version0
+	binary.BigEndian.PutUint64(footer[9:], magicNumberOffsetFile)
+	_, _ = writer.Write(footer[:])
+	assert.Nil(t, writer.Close())
+
+	reader, err := newMMapStoreReader(fileName, NewBlockCache(0))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{10}, reader.Get(10))
+	assert.Nil(t, reader.Get(2))
+	it := reader.Iterator()
+	assert.True(t, it.Next())
+	assert.Equal(t, uint32(1), it.Key())
+	assert.Equal(t, []byte{1}, it.Value())
+	assert.True(t, it.Next())
+	assert.False(t, it.Next())
+	assert.Nil(t, reader.Close())
+
+	// unknown layout version
+	data, _ := ioutil.ReadFile(fileName)
+	data[len(data)-9] = 9
+	assert.Nil(t, ioutil.WriteFile(fileName, data, 0644))
+	_, err = newMMapStoreReader(fileName, NewBlockCache(0))
+	assert.NotNil(t, err)
+}
+
+func TestReader_partitioned(t *testing.T) {
+	_ = util.MkDirIfNotExist(testKVPath)
+	defer os.RemoveAll(testKVPath)
+	builder, _ := NewStoreBuilder(testKVPath, 10)
+	builder.(*storeBuilder).partitionKeys = 16
+	var keys []uint32
+	for i := 0; i < 100; i++ {
+		key := uint32(100<<16 | i*5)
+		keys = append(keys, key)
+		assert.Nil(t, builder.Add(key, []byte{byte(i)}))
+	}
+	assert.Equal(t, uint64(100), builder.Count())
+	assert.Nil(t, builder.Close())
+
+	blockCache := NewBlockCache(0)
+	reader, err := newMMapStoreReader(filepath.Join(testKVPath, version.Table(10)), blockCache)
+	assert.Nil(t, err)
+	_, ok := reader.(*storeMMapReader).index.(*partitionedIndex)
+	assert.True(t, ok)
+	// leaf index blocks are loaded lazily
+	assert.Equal(t, 0, blockCache.Size())
+	assert.Equal(t, []byte{byte(20)}, reader.Get(keys[20]))
+	assert.True(t, blockCache.Size() > 0)
+
+	for i, key := range keys {
+		assert.Equal(t, []byte{byte(i)}, reader.Get(key))
+		assert.Nil(t, reader.Get(key+1))
+	}
+	assert.Nil(t, reader.Get(1))
+
+	it := reader.Iterator()
+	for i, key := range keys {
+		assert.True(t, it.Next())
+		assert.Equal(t, key, it.Key())
+		assert.Equal(t, []byte{byte(i)}, it.Value())
+	}
+	assert.False(t, it.Next())
+
+	// leaf index blocks of file are evicted after closing reader
+	assert.Nil(t, reader.Close())
+	assert.Equal(t, 0, blockCache.Size())
+}
+
+func TestReader_partitioned_corrupt(t *testing.T) {
+	r := &storeMMapReader{path: "test", data: []byte{1, 2, 3}, blockCache: NewBlockCache(0)}
+	_, err := r.loadLeaf(10)
+	assert.NotNil(t, err)
+	_, err = r.loadLeaf(0)
+	assert.NotNil(t, err)
+
+	idx := &partitionedIndex{
+		top:  &prefixIndex{entries: []byte{0, 0, 0, 0, 1, 10}, restarts: []int{0}, keys: 1},
+		leaf: r.loadLeaf,
+	}
+	_, ok, err := idx.find(1)
+	assert.False(t, ok)
+	assert.NotNil(t, err)
+	_, ok, err = idx.find(0)
+	assert.False(t, ok)
+	assert.Nil(t, err)
+	it := idx.iterator()
+	_, _, ok = it.next()
+	assert.False(t, ok)
+	assert.NotNil(t, it.err())
+}
+
+func TestReader_WarmUp(t *testing.T) {
+	_ = util.MkDirIfNotExist(testKVPath)
+	defer os.RemoveAll(testKVPath)
+	builder, _ := NewStoreBuilder(testKVPath, 10)
+	builder.(*storeBuilder).partitionKeys = 16
+	for i := 0; i < 100; i++ {
+		assert.Nil(t, builder.Add(uint32(i), []byte{byte(i)}))
+	}
+	assert.Nil(t, builder.Close())
+	builder, _ = NewStoreBuilder(testKVPath, 11)
+	assert.Nil(t, builder.Add(1, []byte{1}))
+	assert.Nil(t, builder.Close())
+
+	blockCache := NewBlockCache(0)
+	reader, err := newMMapStoreReader(filepath.Join(testKVPath, version.Table(10)), blockCache)
+	assert.Nil(t, err)
+	// all leaf index blocks are loaded into block cache
+	blocks, err := reader.WarmUp()
+	assert.Nil(t, err)
+	assert.Equal(t, 7, blocks)
+	size := blockCache.Size()
+	assert.True(t, size > 0)
+	assert.Equal(t, []byte{byte(20)}, reader.Get(20))
+	assert.Equal(t, size, blockCache.Size())
+	assert.Nil(t, reader.Close())
+
+	// the index without leaf blocks is loaded after opened
+	reader, err = newMMapStoreReader(filepath.Join(testKVPath, version.Table(11)), blockCache)
+	assert.Nil(t, err)
+	blocks, err = reader.WarmUp()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, blocks)
+	assert.Nil(t, reader.Close())
+
+	r := &storeMMapReader{path: "test", data: []byte{1, 2, 3}, blockCache: NewBlockCache(0)}
+	r.index = &partitionedIndex{
+		top:  &prefixIndex{entries: []byte{0, 0, 0, 0, 1, 10}, restarts: []int{0}, keys: 1},
+		leaf: r.loadLeaf,
+	}
+	_, err = r.WarmUp()
+	assert.NotNil(t, err)
+}
+
+func TestReader_checksum(t *testing.T) {
+	_ = util.MkDirIfNotExist(testKVPath)
+	defer os.RemoveAll(testKVPath)
+	fileName := filepath.Join(testKVPath, version.Table(10))
+	builder, _ := NewStoreBuilder(testKVPath, 10)
+	_ = builder.Add(1, []byte("test"))
+	_ = builder.Add(10, []byte("test10"))
+	assert.Nil(t, builder.Close())
+
+	// corrupt the value of key 1, skip the length of value
+	data, _ := ioutil.ReadFile(fileName)
+	data[1] ^= 0xff
+	assert.Nil(t, ioutil.WriteFile(fileName, data, 0644))
+	reader, err := newMMapStoreReader(fileName, NewBlockCache(0))
+	assert.Nil(t, err)
+	value, err := reader.Read(1, false)
+	assert.Nil(t, err)
+	assert.Len(t, value, 4)
+	_, err = reader.Read(1, true)
+	_, ok := err.(*CorruptionError)
+	assert.True(t, ok)
+	value, err = reader.Read(10, true)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("test10"), value)
+	value, err = reader.Read(2, true)
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+	it := reader.Iterator()
+	for it.Next() {
+	}
+	assert.Nil(t, it.Err())
+	assert.Nil(t, reader.Close())
+
+	// corrupt the index block
+	data[len(data)-sstFileFooterSize-2] ^= 0xff
+	assert.Nil(t, ioutil.WriteFile(fileName, data, 0644))
+	_, err = newMMapStoreReader(fileName, NewBlockCache(0))
+	_, ok = err.(*CorruptionError)
+	assert.True(t, ok)
+}
+
+func TestReader_checksum_leaf(t *testing.T) {
+	_ = util.MkDirIfNotExist(testKVPath)
+	defer os.RemoveAll(testKVPath)
+	fileName := filepath.Join(testKVPath, version.Table(10))
+	builder, _ := NewStoreBuilder(testKVPath, 10)
+	builder.(*storeBuilder).partitionKeys = 2
+	for key := uint32(1); key <= 4; key++ {
+		_ = builder.Add(key, []byte{byte(key)})
+	}
+	assert.Nil(t, builder.Close())
+
+	// corrupt the entries of first leaf index block, which is flushed after writing the value of key 3
+	data, _ := ioutil.ReadFile(fileName)
+	data[3*(1+1+checksumLength)+1] ^= 0xff
+	assert.Nil(t, ioutil.WriteFile(fileName, data, 0644))
+	reader, err := newMMapStoreReader(fileName, NewBlockCache(0))
+	assert.Nil(t, err)
+	defer reader.Close()
+	_, err = reader.Read(1, false)
+	_, ok := err.(*CorruptionError)
+	assert.True(t, ok)
+	assert.Nil(t, reader.Get(1))
+	value, err := reader.Read(3, true)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{3}, value)
+
+	it := reader.Iterator()
+	assert.False(t, it.Next())
+	_, ok = it.Err().(*CorruptionError)
+	assert.True(t, ok)
+}
@@ -0,0 +1,143 @@
+package kv
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/eleme/lindb/pkg/timeutil"
+)
+
+// Defines the types of background task of kv store
+const (
+	TaskFlush      = "flush"
+	TaskCompaction = "compaction"
+)
+
+// TaskProgress represents the progress of running flush/compaction task
+type TaskProgress struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"`
+	// Family is the path of family
+	Family    string `json:"family"`
+	StartTime int64  `json:"startTime"`
+	// Elapsed is the running time(ms) of task
+	Elapsed        int64 `json:"elapsed"`
+	BytesProcessed int64 `json:"bytesProcessed"`
+	// BytesTotal is the total bytes need process, 0 means unknown(e.g. flush)
+	BytesTotal int64 `json:"bytesTotal,omitempty"`
+	// EstimatedRemaining is the estimated remaining time(ms) by the processing speed, -1 means unknown
+	EstimatedRemaining int64 `json:"estimatedRemaining"`
+}
+
+// TaskManager tracks the running flush/compaction tasks of kv stores, so that operators can see
+// why disk IO is high and whether a task is stuck.
+type TaskManager interface {
+	// Start starts tracking the task of family, total is the bytes need process, 0 means unknown
+	Start(taskType, family string, total int64) *Task
+	// Running returns the progress of running tasks ordered by start time
+	Running() []TaskProgress
+}
+
+// DefaultTaskManager is the task manager which tracks the tasks of all kv stores in process
+var DefaultTaskManager = NewTaskManager()
+
+// taskManager implements TaskManager
+type taskManager struct {
+	seq   int64
+	mutex sync.RWMutex
+	tasks map[int64]*Task
+}
+
+// NewTaskManager creates the task manager
+func NewTaskManager() TaskManager {
+	return &taskManager{
+		tasks: make(map[int64]*Task),
+	}
+}
+
+// Start starts tracking the task of family, total is the bytes need process, 0 means unknown
+func (m *taskManager) Start(taskType, family string, total int64) *Task {
+	t := &Task{
+		id:        atomic.AddInt64(&m.seq, 1),
+		taskType:  taskType,
+		family:    family,
+		startTime: timeutil.Now(),
+		total:     total,
+		manager:   m,
+	}
+	m.mutex.Lock()
+	m.tasks[t.id] = t
+	m.mutex.Unlock()
+	return t
+}
+
+// Running returns the progress of running tasks ordered by start time
+func (m *taskManager) Running() []TaskProgress {
+	now := timeutil.Now()
+	m.mutex.RLock()
+	result := make([]TaskProgress, 0, len(m.tasks))
+	for _, t := range m.tasks {
+		result = append(result, t.progress(now))
+	}
+	m.mutex.RUnlock()
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// remove stops tracking the task
+func (m *taskManager) remove(id int64) {
+	m.mutex.Lock()
+	delete(m.tasks, id)
+	m.mutex.Unlock()
+}
+
+// Task represents a running flush/compaction task
+type Task struct {
+	id        int64
+	taskType  string
+	family    string
+	startTime int64
+	total     int64
+	processed int64
+	done      int32
+	manager   *taskManager
+}
+
+// Add adds the bytes processed
+func (t *Task) Add(bytes int) {
+	atomic.AddInt64(&t.processed, int64(bytes))
+}
+
+// Done stops tracking the task when it completes or fails, it can be called repeatedly
+func (t *Task) Done() {
+	if atomic.CompareAndSwapInt32(&t.done, 0, 1) {
+		t.manager.remove(t.id)
+	}
+}
+
+// progress returns the progress of task, the remaining time is estimated by the processing speed
+func (t *Task) progress(now int64) TaskProgress {
+	processed := atomic.LoadInt64(&t.processed)
+	elapsed := now - t.startTime
+	p := TaskProgress{
+		ID:                 t.id,
+		Type:               t.taskType,
+		Family:             t.family,
+		StartTime:          t.startTime,
+		Elapsed:            elapsed,
+		BytesProcessed:     processed,
+		BytesTotal:         t.total,
+		EstimatedRemaining: -1,
+	}
+	if t.total > 0 && processed > 0 {
+		remaining := t.total - processed
+		if remaining < 0 {
+			remaining = 0
+		}
+		p.EstimatedRemaining = elapsed * remaining / processed
+	}
+	return p
+}
@@ -0,0 +1,75 @@
+package kv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/pkg/util"
+)
+
+func TestTaskManager(t *testing.T) {
+	manager := NewTaskManager()
+	assert.Empty(t, manager.Running())
+
+	compaction := manager.Start(TaskCompaction, "f1", 100)
+	flush := manager.Start(TaskFlush, "f2", 0)
+	tasks := manager.Running()
+	assert.Len(t, tasks, 2)
+	assert.Equal(t, TaskCompaction, tasks[0].Type)
+	assert.Equal(t, "f1", tasks[0].Family)
+	assert.Equal(t, int64(100), tasks[0].BytesTotal)
+	// unknown remaining time before processing
+	assert.Equal(t, int64(-1), tasks[0].EstimatedRemaining)
+	assert.Equal(t, TaskFlush, tasks[1].Type)
+
+	compaction.Add(25)
+	flush.Add(10)
+	tasks = manager.Running()
+	assert.Equal(t, int64(25), tasks[0].BytesProcessed)
+	assert.True(t, tasks[0].EstimatedRemaining >= 0)
+	assert.Equal(t, int64(10), tasks[1].BytesProcessed)
+	// unknown total bytes of flush
+	assert.Equal(t, int64(-1), tasks[1].EstimatedRemaining)
+
+	compaction.Done()
+	compaction.Done()
+	tasks = manager.Running()
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, TaskFlush, tasks[0].Type)
+	flush.Done()
+	assert.Empty(t, manager.Running())
+}
+
+func TestTask_progress(t *testing.T) {
+	task := &Task{startTime: 1000, total: 100, processed: 25}
+	assert.Equal(t, int64(30), task.progress(1010).EstimatedRemaining)
+	task.processed = 120
+	assert.Equal(t, int64(0), task.progress(1010).EstimatedRemaining)
+}
+
+func TestTaskManager_flush(t *testing.T) {
+	option := DefaultStoreOption(testKVPath)
+	defer util.RemoveDir(testKVPath)
+
+	kv, _ := NewStore("test_kv", option)
+	defer kv.Close()
+	f, _ := kv.CreateFamily("f", FamilyOption{})
+
+	flusher := f.NewFlusher()
+	assert.Nil(t, flusher.Add(1, []byte("test")))
+	task := flusher.(*storeFlusher).task
+	assert.NotNil(t, task)
+	assert.True(t, isRunning(task.id))
+	assert.Nil(t, flusher.Commit())
+	assert.False(t, isRunning(task.id))
+}
+
+func isRunning(id int64) bool {
+	for _, p := range DefaultTaskManager.Running() {
+		if p.ID == id {
+			return p.BytesProcessed > 0
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package version
+
+import "sync"
+
+// Barrier makes the versions of families across stores acquired atomically. The stores sharing the barrier install
+// the new versions of committed edit logs exclusively with the acquiring, so that the versions acquired together
+// are never in the middle of a flush or compaction. The nil barrier doesn't synchronize anything.
+type Barrier struct {
+	mutex sync.RWMutex
+}
+
+// NewBarrier creates the barrier shared by stores
+func NewBarrier() *Barrier {
+	return &Barrier{}
+}
+
+// Acquire runs fn which acquires the versions of families, the new versions aren't installed until fn returns
+func (b *Barrier) Acquire(fn func()) {
+	if b == nil {
+		fn()
+		return
+	}
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	fn()
+}
+
+// install runs fn which installs the new versions exclusively with the acquiring
+func (b *Barrier) install(fn func()) {
+	if b == nil {
+		fn()
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	fn()
+}
@@ -0,0 +1,179 @@
+package version
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+
+	"github.com/eleme/lindb/pkg/bufioutil"
+)
+
+// checkpointManifestNumber is the file number of the manifest written by checkpoint, which is less than
+// the next file number of any store, so that the manifest isn't overwritten by the store recovered from it.
+const checkpointManifestNumber = 1
+
+// Checkpoint represents the versions of all families of store pinned at a time, for copying the files of store
+// as a consistent view, e.g. backup. The files of pinned versions aren't removed until the checkpoint released,
+// even if they are compacted. The store copied with the files of checkpoint and the manifest written by
+// checkpoint is recovered as the pinned versions.
+type Checkpoint struct {
+	storePath      string
+	nextFileNumber int64
+	familyIDs      map[int]string
+	versions       map[int]*Version // keyed by family id
+	released       int32
+}
+
+// Checkpoint pins the current versions of all families, the checkpoints of the stores sharing the barrier
+// are consistent if they are created in the acquiring of barrier.
+func (vs *StoreVersionSet) Checkpoint() *Checkpoint {
+	vs.mutex.RLock()
+	defer vs.mutex.RUnlock()
+	c := &Checkpoint{
+		storePath:      vs.storePath,
+		nextFileNumber: atomic.LoadInt64(&vs.nextFileNumber),
+		familyIDs:      make(map[int]string),
+		versions:       make(map[int]*Version),
+	}
+	for familyID, familyName := range vs.familyIDs {
+		c.familyIDs[familyID] = familyName
+		c.versions[familyID] = vs.familyVersions[familyName].GetCurrent()
+	}
+	return c
+}
+
+// StorePath returns the path of store
+func (c *Checkpoint) StorePath() string {
+	return c.storePath
+}
+
+// Files returns the files of pinned versions keyed by family name,
+// the file is stored under the family's path which is the family name under store path.
+func (c *Checkpoint) Files() map[string][]*FileMeta {
+	files := make(map[string][]*FileMeta)
+	for familyID, v := range c.versions {
+		files[c.familyIDs[familyID]] = v.getAllFiles()
+	}
+	return files
+}
+
+// UnchangedFiles returns the files of pinned versions which are in the base files with the same meta
+// keyed by family name, the base files are keyed by family id(see ReadManifestFiles), e.g. the files of previous
+// checkpoint of store. The file number is never reused in a store as recorded by version edit log(next file number),
+// the meta(size/key range/time range/digest) is also compared in case the store is dropped and created again.
+func (c *Checkpoint) UnchangedFiles(base map[int][]*FileMeta) map[string][]*FileMeta {
+	result := make(map[string][]*FileMeta)
+	for familyID, v := range c.versions {
+		baseFiles := make(map[int64]*FileMeta)
+		for _, file := range base[familyID] {
+			baseFiles[file.fileNumber] = file
+		}
+		for _, file := range v.getAllFiles() {
+			if baseFile, ok := baseFiles[file.fileNumber]; ok && *baseFile == *file {
+				familyName := c.familyIDs[familyID]
+				result[familyName] = append(result[familyName], file)
+			}
+		}
+	}
+	return result
+}
+
+// WriteManifest writes the manifest which builds the pinned versions and the CURRENT file referencing it
+// into dir, returns the names of files written in order of manifest, CURRENT.
+func (c *Checkpoint) WriteManifest(dir string) ([]string, error) {
+	manifestName := manifestFileName(checkpointManifestNumber)
+	writer, err := bufioutil.NewBufioWriter(filepath.Join(dir, manifestName))
+	if err != nil {
+		return nil, err
+	}
+	vs := &StoreVersionSet{storePath: dir}
+	if err := vs.peresistEditLogs(writer, c.editLogs()); err != nil {
+		_ = writer.Close()
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(vs.getCurrentPath(), []byte(manifestName), 0666); err != nil {
+		return nil, fmt.Errorf("write manifest file name into current file error:%s", err)
+	}
+	return []string{manifestName, current()}, nil
+}
+
+// Release releases the pinned versions, the checkpoint cannot be used after released
+func (c *Checkpoint) Release() {
+	// atomic set released status, make sure only release once
+	if atomic.CompareAndSwapInt32(&c.released, 0, 1) {
+		for _, v := range c.versions {
+			v.Release()
+		}
+	}
+}
+
+// editLogs builds the edit logs of pinned versions ordered by family id, then the store level edit log
+func (c *Checkpoint) editLogs() []*EditLog {
+	familyIDs := make([]int, 0, len(c.versions))
+	for familyID := range c.versions {
+		familyIDs = append(familyIDs, familyID)
+	}
+	sort.Ints(familyIDs)
+	var editLogs []*EditLog
+	for _, familyID := range familyIDs {
+		editLog := NewEditLog(familyID)
+		v := c.versions[familyID]
+		for level := 0; level < v.NumOfLevels(); level++ {
+			for _, file := range v.GetLevelFiles(level) {
+				editLog.AddNewFile(int32(level), file)
+			}
+		}
+		editLogs = append(editLogs, editLog)
+	}
+	storeEditLog := NewEditLog(StoreFamilyID)
+	storeEditLog.Add(NewNextFileNumber(c.nextFileNumber))
+	return append(editLogs, storeEditLog)
+}
+
+// ReadManifestFiles reads the edit logs from manifest file in order, returns the files of the versions built by
+// the edit logs keyed by family id, e.g. the manifest written by checkpoint. The edit logs which have been applied
+// are skipped as recovering.
+func ReadManifestFiles(manifestPath string) (map[int][]*FileMeta, error) {
+	vs := &StoreVersionSet{appliedSequences: make(map[int]map[int64]struct{})}
+	families := make(map[int]map[int64]*FileMeta)
+	err := readEditLogs(manifestPath, func(editLog *EditLog) error {
+		if !vs.markApplied(editLog) || editLog.familyID == StoreFamilyID {
+			return nil
+		}
+		files, ok := families[editLog.familyID]
+		if !ok {
+			files = make(map[int64]*FileMeta)
+			families[editLog.familyID] = files
+		}
+		for _, log := range editLog.logs {
+			switch l := log.(type) {
+			case *NewFile:
+				files[l.file.fileNumber] = l.file
+			case *DeleteFile:
+				delete(files, l.fileNumber)
+			case *FileDigest:
+				if file, ok := files[l.fileNumber]; ok {
+					withDigest := *file
+					withDigest.SetDigest(l.digest)
+					files[l.fileNumber] = &withDigest
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[int][]*FileMeta)
+	for familyID, files := range families {
+		for _, file := range files {
+			result[familyID] = append(result[familyID], file)
+		}
+	}
+	return result, nil
+}
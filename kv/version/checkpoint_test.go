@@ -0,0 +1,75 @@
+package version
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/pkg/util"
+)
+
+func TestStoreVersionSet_Checkpoint(t *testing.T) {
+	initVersionSetTestData()
+	defer destoryVersionTestData()
+
+	vs := NewStoreVersionSet(vsTestPath, 2)
+	assert.Nil(t, vs.Recover())
+	vs.CreateFamilyVersion("f", 1)
+	vs.CreateFamilyVersion("g", 2)
+	file := NewFileMeta(12, 1, 100, 2014)
+	file.SetDigest(100)
+	editLog := NewEditLog(1)
+	editLog.AddNewFile(1, file)
+	assert.Nil(t, vs.CommitFamilyEditLog("f", editLog))
+
+	checkpoint := vs.Checkpoint()
+	assert.Equal(t, vsTestPath, checkpoint.StorePath())
+	// the files committed after checkpoint aren't included
+	editLog = NewEditLog(1)
+	editLog.Add(NewDeleteFile(1, 12))
+	editLog.Add(CreateNewFile(0, NewFileMeta(13, 1, 100, 2014)))
+	assert.Nil(t, vs.CommitFamilyEditLog("f", editLog))
+	assert.Equal(t, map[string][]*FileMeta{"f": {file}, "g": nil}, checkpoint.Files())
+	// the pinned version is active until released
+	assert.Equal(t, map[string]int{"f": 2, "g": 1}, vs.ActiveVersions())
+
+	dir := filepath.Join(vsTestPath, "checkpoint")
+	assert.Nil(t, util.MkDirIfNotExist(dir))
+	names, err := checkpoint.WriteManifest(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{manifestFileName(checkpointManifestNumber), current()}, names)
+	_, err = checkpoint.WriteManifest(filepath.Join(vsTestPath, "not_exist"))
+	assert.NotNil(t, err)
+
+	// the files of manifest written by checkpoint are the same files of checkpoint
+	base, err := ReadManifestFiles(filepath.Join(dir, names[0]))
+	assert.Nil(t, err)
+	assert.Equal(t, map[int][]*FileMeta{1: {file}}, base)
+	assert.Equal(t, map[string][]*FileMeta{"f": {file}}, checkpoint.UnchangedFiles(base))
+	// the file with same number but different meta is changed
+	assert.Empty(t, checkpoint.UnchangedFiles(map[int][]*FileMeta{1: {NewFileMeta(12, 1, 100, 2015)}}))
+	assert.Empty(t, checkpoint.UnchangedFiles(map[int][]*FileMeta{2: {file}}))
+	// the file deleted after checkpoint is changed
+	latest := vs.Checkpoint()
+	assert.Empty(t, latest.UnchangedFiles(base))
+	latest.Release()
+	_, err = ReadManifestFiles(filepath.Join(dir, "not_exist"))
+	assert.NotNil(t, err)
+	checkpoint.Release()
+	checkpoint.Release()
+	assert.Equal(t, map[string]int{"f": 1, "g": 1}, vs.ActiveVersions())
+	nextFileNumber := vs.NextFileNumber()
+	vs.Destroy()
+
+	// the store recovered from the manifest of checkpoint has the pinned versions
+	vs = NewStoreVersionSet(dir, 2)
+	vs.CreateFamilyVersion("f", 1)
+	vs.CreateFamilyVersion("g", 2)
+	assert.Nil(t, vs.Recover())
+	current := vs.GetFamilyVersion("f").GetCurrent()
+	assert.Equal(t, []*FileMeta{file}, current.getAllFiles())
+	current.Release()
+	assert.True(t, vs.NextFileNumber() >= nextFileNumber)
+	vs.Destroy()
+}
@@ -16,6 +16,9 @@ const StoreFamilyID = 0
 type EditLog struct {
 	logs     []Log
 	familyID int
+	// sequence is assigned when committing, increasing in store level, zero means no sequence(old version).
+	// it's used for skipping the edit log which has been applied when recovering.
+	sequence int64
 }
 
 // NewEditLog new EditLog instance
@@ -30,6 +33,29 @@ func (el *EditLog) Add(log Log) {
 	el.logs = append(el.logs, log)
 }
 
+// AddNewFile adds the new file log into log list, with the digest log if the file has digest
+func (el *EditLog) AddNewFile(level int32, file *FileMeta) {
+	el.Add(CreateNewFile(level, file))
+	if file.HasDigest() {
+		el.Add(NewFileDigest(level, file.GetFileNumber(), file.GetDigest()))
+	}
+}
+
+// FamilyID returns the id of family which edit log belongs to, StoreFamilyID for store level edit log
+func (el *EditLog) FamilyID() int {
+	return el.familyID
+}
+
+// Sequence returns the sequence assigned when committing, zero means no sequence
+func (el *EditLog) Sequence() int64 {
+	return el.sequence
+}
+
+// Logs returns the logs of edit log
+func (el *EditLog) Logs() []Log {
+	return el.logs
+}
+
 // IsEmpty returns edit logs is empty or not.
 func (el *EditLog) IsEmpty() bool {
 	return len(el.logs) == 0
@@ -53,6 +79,8 @@ func (el *EditLog) marshal() ([]byte, error) {
 		stream.PutUvarint32(uint32(len(value))) // write log bytes length
 		stream.PutBytes(value)                  // write log bytes data
 	}
+	// write sequence at the end, compatible with the edit log without sequence
+	stream.PutInt64(el.sequence)
 	return stream.Bytes()
 }
 
@@ -77,6 +105,10 @@ func (el *EditLog) unmarshal(buf []byte) error {
 		}
 		el.Add(l)
 	}
+	// read sequence if exist
+	if !stream.Empty() {
+		el.sequence = stream.ReadInt64()
+	}
 	return stream.Error()
 }
 
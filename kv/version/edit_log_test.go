@@ -24,6 +24,20 @@ func TestEditLogCodec(t *testing.T) {
 	assert.Nil(t, err2, "unmarshal error")
 
 	assert.Equal(t, editLog, editLog2, "edit log not eqauls")
+
+	// with sequence
+	editLog.sequence = 10
+	v, _ = editLog.marshal()
+	editLog3 := NewEditLog(1)
+	assert.Nil(t, editLog3.unmarshal(v))
+	assert.Equal(t, editLog, editLog3)
+
+	// old edit log without sequence
+	editLog4 := NewEditLog(1)
+	// sequence 10 is encoded as one byte varint
+	assert.Nil(t, editLog4.unmarshal(v[:len(v)-1]))
+	assert.Equal(t, int64(0), editLog4.sequence)
+	assert.Equal(t, editLog.logs, editLog4.logs)
 }
 
 func TestApply(t *testing.T) {
@@ -45,3 +59,48 @@ func TestApply(t *testing.T) {
 	editLog2.apply(version)
 	assert.Equal(t, 0, len(version.getAllFiles()), "cannot delete file from version")
 }
+
+func TestEditLog_AddNewFile(t *testing.T) {
+	initVersionSetTestData()
+	defer destoryVersionTestData()
+
+	var vs = NewStoreVersionSet(vsTestPath, 2)
+	familyVersion := vs.CreateFamilyVersion("family", 1)
+	file := NewFileMeta(12, 1, 100, 2014)
+	file.SetDigest(100)
+	editLog := NewEditLog(1)
+	editLog.AddNewFile(1, file)
+	editLog.AddNewFile(1, NewFileMeta(13, 1, 100, 2014))
+	assert.Len(t, editLog.Logs(), 3)
+
+	// the digest log is decoded after the new file log
+	data, err := editLog.marshal()
+	assert.Nil(t, err)
+	editLog2 := &EditLog{}
+	assert.Nil(t, editLog2.unmarshal(data))
+	version := newVersion(1, familyVersion)
+	editLog2.apply(version)
+	files := version.GetLevelFiles(1)
+	assert.Len(t, files, 2)
+	_, ok := version.Digest()
+	assert.False(t, ok)
+
+	// the version has digest if all files have digest
+	editLog3 := NewEditLog(1)
+	editLog3.Add(NewFileDigest(1, 13, 200))
+	editLog3.Add(NewFileDigest(1, 14, 300))
+	newVersion := version.cloneVersion()
+	editLog3.apply(newVersion)
+	digest, ok := newVersion.Digest()
+	assert.True(t, ok)
+	// the file of old version isn't changed
+	_, ok = version.Digest()
+	assert.False(t, ok)
+
+	// same files in different levels have same digest
+	other := newVersion.cloneVersion()
+	other.deleteFile(1, 12)
+	other.addFile(0, file)
+	digest2, _ := other.Digest()
+	assert.Equal(t, digest, digest2)
+}
@@ -8,6 +8,8 @@ type FamilyVersion struct {
 
 	current        *Version           // current mutable version
 	activeVersions map[int64]*Version // all active versions include mutable/immutable versions
+	obsoleteFiles  []*FileMeta        // files not referenced by any active version, wait for deleting
+	badFiles       map[int64]error    // files which data is corrupted, keyed by file number with the error
 
 	mutex sync.RWMutex
 }
@@ -17,9 +19,12 @@ func newFamilyVersion(versionSet *StoreVersionSet) *FamilyVersion {
 	fv := &FamilyVersion{
 		versionSet:     versionSet,
 		activeVersions: make(map[int64]*Version),
+		badFiles:       make(map[int64]error),
 	}
 	// create new version for current mutable version
 	current := newVersion(fv.versionSet.newVersionID(), fv)
+	// family version holds the ref of current version, released when current version is replaced
+	current.retain()
 	fv.activeVersions[current.id] = current
 	fv.current = current
 	return fv
@@ -47,8 +52,34 @@ func (fv *FamilyVersion) FindFiles(key uint32) (*Version, []*FileMeta) {
 	return current, files
 }
 
+// FindFilesInTimeRange finds all files include key from current's level, the files which time range
+// doesn't overlap [startTime, endTime] are pruned, must release the returned version after read data.
+func (fv *FamilyVersion) FindFilesInTimeRange(key uint32, startTime, endTime int64) (*Version, []*FileMeta) {
+	fv.mutex.RLock()
+	current := fv.current
+	// must retain it, don't release util finish read, release it during snapshot's closing.
+	current.retain()
+	files := current.findFilesInTimeRange(key, startTime, endTime)
+	fv.mutex.RUnlock()
+	return current, files
+}
+
+// FindLatestFiles finds all files include key from current's level, the files are sorted by max timestamp
+// in descending, must release the returned version after read data.
+func (fv *FamilyVersion) FindLatestFiles(key uint32) (*Version, []*FileMeta) {
+	fv.mutex.RLock()
+	current := fv.current
+	// must retain it, don't release util finish read, release it during snapshot's closing.
+	current.retain()
+	files := current.findLatestFiles(key)
+	fv.mutex.RUnlock()
+	return current, files
+}
+
 // GetAllFiles returns all files based on all active versions
 func (fv *FamilyVersion) GetAllFiles() []*FileMeta {
+	fv.mutex.RLock()
+	defer fv.mutex.RUnlock()
 	var files []*FileMeta
 	var fileNumbers = make(map[int64]int64)
 	for _, version := range fv.activeVersions {
@@ -66,16 +97,84 @@ func (fv *FamilyVersion) GetAllFiles() []*FileMeta {
 	return files
 }
 
-// removeVersion removes version from active versions
+// NumOfActiveVersions returns the num. of active versions, which is the gauge of versions retained by snapshots
+func (fv *FamilyVersion) NumOfActiveVersions() int {
+	fv.mutex.RLock()
+	defer fv.mutex.RUnlock()
+	return len(fv.activeVersions)
+}
+
+// GetObsoleteFiles returns the files which are not referenced by any active version,
+// the returned files are removed from pending list, invoker must delete them.
+func (fv *FamilyVersion) GetObsoleteFiles() []*FileMeta {
+	fv.mutex.Lock()
+	defer fv.mutex.Unlock()
+	files := fv.obsoleteFiles
+	fv.obsoleteFiles = nil
+	for _, file := range files {
+		delete(fv.badFiles, file.fileNumber)
+	}
+	return files
+}
+
+// MarkBadFile marks the file bad because its data is corrupted, returns false if the file is marked before
+func (fv *FamilyVersion) MarkBadFile(fileNumber int64, err error) bool {
+	fv.mutex.Lock()
+	defer fv.mutex.Unlock()
+	if _, ok := fv.badFiles[fileNumber]; ok {
+		return false
+	}
+	fv.badFiles[fileNumber] = err
+	return true
+}
+
+// IsBadFile returns if the file is marked bad
+func (fv *FamilyVersion) IsBadFile(fileNumber int64) bool {
+	fv.mutex.RLock()
+	defer fv.mutex.RUnlock()
+	_, ok := fv.badFiles[fileNumber]
+	return ok
+}
+
+// BadFiles returns the bad files keyed by file number with the error, which are removed after the file deleted
+func (fv *FamilyVersion) BadFiles() map[int64]error {
+	fv.mutex.RLock()
+	defer fv.mutex.RUnlock()
+	result := make(map[int64]error, len(fv.badFiles))
+	for fileNumber, err := range fv.badFiles {
+		result[fileNumber] = err
+	}
+	return result
+}
+
+// removeVersion removes version from active versions when no one retains it,
+// then schedules the files which only exist in this version for deleting.
 func (fv *FamilyVersion) removeVersion(v *Version) {
 	fv.mutex.Lock()
+	defer fv.mutex.Unlock()
+	if _, ok := fv.activeVersions[v.id]; !ok {
+		return
+	}
 	delete(fv.activeVersions, v.id)
-	fv.mutex.Unlock()
+
+	activeFiles := make(map[int64]struct{})
+	for _, version := range fv.activeVersions {
+		for _, file := range version.getAllFiles() {
+			activeFiles[file.fileNumber] = struct{}{}
+		}
+	}
+	for _, file := range v.getAllFiles() {
+		if _, ok := activeFiles[file.fileNumber]; !ok {
+			fv.obsoleteFiles = append(fv.obsoleteFiles, file)
+		}
+	}
 }
 
 // appendVersion swaps family's current version, then releases previous version
 func (fv *FamilyVersion) appendVersion(v *Version) {
 	previous := fv.current
+	// family version holds the ref of new current version
+	v.retain()
 
 	fv.mutex.Lock()
 	fv.activeVersions[v.id] = v
@@ -1,6 +1,7 @@
 package version
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -14,9 +15,9 @@ func TestFamilyVersion(t *testing.T) {
 
 	assert.Equal(t, 0, len(familyVersion.GetAllFiles()), "file list not empty")
 
+	// family version holds the ref of current version
+	assert.Equal(t, 1, familyVersion.NumOfActiveVersions())
 	version1 := familyVersion.GetCurrent()
-	// add mock version ref
-	version1.retain()
 	file1 := NewFileMeta(12, 1, 50, 2014)
 	version1.addFile(1, file1)
 	file2 := NewFileMeta(13, 1, 10, 2014)
@@ -28,8 +29,9 @@ func TestFamilyVersion(t *testing.T) {
 
 	version2 := version1.cloneVersion()
 	familyVersion.appendVersion(version2)
-	assert.Equal(t, 2, len(familyVersion.activeVersions), "version list !=2")
+	assert.Equal(t, 2, familyVersion.NumOfActiveVersions(), "version list !=2")
 	assert.Equal(t, version2, familyVersion.GetCurrent(), "get wrong current version")
+	version2.Release()
 	assert.Equal(t, 3, len(familyVersion.GetAllFiles()), "file list != 3")
 
 	// delete file1
@@ -37,10 +39,19 @@ func TestFamilyVersion(t *testing.T) {
 	// can get file from version1
 	assert.Equal(t, 3, len(familyVersion.GetAllFiles()), "file list != 3")
 
+	assert.Equal(t, 0, len(familyVersion.GetObsoleteFiles()))
 	// release version1
 	version1.Release()
 	// cannot get file from version1
 	assert.Equal(t, 2, len(familyVersion.GetAllFiles()), "file list != 2")
+	assert.Equal(t, 1, familyVersion.NumOfActiveVersions())
+	// file1 only exists in version1, schedule it for deleting
+	assert.Equal(t, []*FileMeta{file1}, familyVersion.GetObsoleteFiles())
+	assert.Equal(t, 0, len(familyVersion.GetObsoleteFiles()))
+	// release removed version again, nothing happen
+	version1.retain()
+	version1.Release()
+	assert.Equal(t, 0, len(familyVersion.GetObsoleteFiles()))
 
 	file4 := NewFileMeta(40, 70, 100, 1024)
 	// add invalid version
@@ -55,4 +66,52 @@ func TestFamilyVersion(t *testing.T) {
 
 	_, findFile2 := familyVersion.FindFiles(5)
 	assert.Equal(t, 2, len(findFile2))
+
+	// prune files by time range
+	file5 := NewFileMetaWithTimeRange(50, 1, 100, 1024, 0, 100)
+	file6 := NewFileMetaWithTimeRange(51, 1, 100, 1024, 1000, 2000)
+	version2.addFiles(0, []*FileMeta{file5, file6})
+	_, findFile3 := familyVersion.FindFilesInTimeRange(5, 1500, 3000)
+	assert.ElementsMatch(t, []*FileMeta{file6, file2, file3}, findFile3)
+	_, findFile4 := familyVersion.FindFilesInTimeRange(5, 0, 10)
+	assert.ElementsMatch(t, []*FileMeta{file5, file2, file3}, findFile4)
+	// sorted by max time in descending, files without time range are in the front
+	_, findFile5 := familyVersion.FindLatestFiles(5)
+	assert.Len(t, findFile5, 4)
+	assert.False(t, findFile5[0].HasTimeRange())
+	assert.False(t, findFile5[1].HasTimeRange())
+	assert.Equal(t, []*FileMeta{file6, file5}, findFile5[2:])
+}
+
+func TestFamilyVersion_badFiles(t *testing.T) {
+	initVersionSetTestData()
+	defer destoryVersionTestData()
+	var vs = NewStoreVersionSet(vsTestPath, 2)
+	familyVersion := vs.CreateFamilyVersion("f", 1)
+
+	current := familyVersion.GetCurrent()
+	current.addFile(1, NewFileMeta(12, 1, 50, 2014))
+	assert.True(t, familyVersion.MarkBadFile(12, fmt.Errorf("err")))
+	assert.False(t, familyVersion.MarkBadFile(12, fmt.Errorf("err")))
+	assert.True(t, familyVersion.IsBadFile(12))
+	assert.False(t, familyVersion.IsBadFile(13))
+	assert.Len(t, familyVersion.BadFiles(), 1)
+
+	// bad file is removed after deleting
+	next := current.cloneVersion()
+	next.deleteFile(1, 12)
+	familyVersion.appendVersion(next)
+	current.Release()
+	assert.Len(t, familyVersion.GetObsoleteFiles(), 1)
+	assert.Empty(t, familyVersion.BadFiles())
+}
+
+func TestParseTable(t *testing.T) {
+	fileNumber, ok := ParseTable(Table(12))
+	assert.True(t, ok)
+	assert.Equal(t, int64(12), fileNumber)
+	_, ok = ParseTable("MANIFEST-000001")
+	assert.False(t, ok)
+	_, ok = ParseTable("a.sst")
+	assert.False(t, ok)
 }
@@ -1,11 +1,20 @@
 package version
 
-// FileMeta is the metadata for sst file
+// FileMeta is the metadata for sst file,
+// the time range is the min/max timestamp of data in sst file, which is used for pruning files when query,
+// the file without time range(e.g. index data) is never pruned.
+// The digest is the content digest of k/v pairs computed when building the sst file, which is used for
+// comparing the replicas without full scans, the file written by old version has no digest.
 type FileMeta struct {
-	fileNumber int64  // file number
-	minKey     uint32 // min key
-	maxKey     uint32 // max key
-	fileSize   int32  // file size
+	fileNumber   int64  // file number
+	minKey       uint32 // min key
+	maxKey       uint32 // max key
+	fileSize     int32  // file size
+	hasTimeRange bool   // if has time range
+	minTime      int64  // min timestamp
+	maxTime      int64  // max timestamp
+	hasDigest    bool   // if has digest
+	digest       uint64 // content digest
 }
 
 // NewFileMeta new FileMeta instance
@@ -18,6 +27,16 @@ func NewFileMeta(fileNumber int64, minKey uint32, maxKey uint32, fileSize int32)
 	}
 }
 
+// NewFileMetaWithTimeRange new FileMeta instance with the time range of data
+func NewFileMetaWithTimeRange(fileNumber int64, minKey uint32, maxKey uint32, fileSize int32,
+	minTime, maxTime int64) *FileMeta {
+	f := NewFileMeta(fileNumber, minKey, maxKey, fileSize)
+	f.hasTimeRange = true
+	f.minTime = minTime
+	f.maxTime = maxTime
+	return f
+}
+
 // GetFileNumber gets file number for sst file
 func (f *FileMeta) GetFileNumber() int64 {
 	return f.fileNumber
@@ -37,3 +56,43 @@ func (f *FileMeta) GetMaxKey() uint32 {
 func (f *FileMeta) GetFileSize() int32 {
 	return f.fileSize
 }
+
+// HasTimeRange returns if the time range of data in sst file is known
+func (f *FileMeta) HasTimeRange() bool {
+	return f.hasTimeRange
+}
+
+// GetMinTime gets min timestamp in sst file
+func (f *FileMeta) GetMinTime() int64 {
+	return f.minTime
+}
+
+// GetMaxTime gets max timestamp in sst file
+func (f *FileMeta) GetMaxTime() int64 {
+	return f.maxTime
+}
+
+// SetDigest sets the content digest of sst file
+func (f *FileMeta) SetDigest(digest uint64) {
+	f.digest = digest
+	f.hasDigest = true
+}
+
+// HasDigest returns if the content digest of sst file is known
+func (f *FileMeta) HasDigest() bool {
+	return f.hasDigest
+}
+
+// GetDigest gets the content digest of sst file
+func (f *FileMeta) GetDigest() uint64 {
+	return f.digest
+}
+
+// OverlapsTimeRange checks if the time range of sst file overlaps [startTime, endTime],
+// returns true if the time range of sst file is unknown.
+func (f *FileMeta) OverlapsTimeRange(startTime, endTime int64) bool {
+	if !f.hasTimeRange {
+		return true
+	}
+	return f.minTime <= endTime && f.maxTime >= startTime
+}
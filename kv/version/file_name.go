@@ -2,6 +2,8 @@ package version
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 )
 
 const sstSuffix = "sst"
@@ -9,6 +11,7 @@ const TmpSuffix = "tmp"
 
 const Lock = "LOCK"
 const Options = "OPTIONS"
+const Current = "CURRENT"
 const manifestPrefix = "MANIFEST-"
 
 // FileType represent a file type.
@@ -31,7 +34,7 @@ type FileDesc struct {
 
 // current return current file name for saving manifest file name
 func current() string {
-	return "CURRENT"
+	return Current
 }
 
 // Table file name
@@ -39,6 +42,15 @@ func Table(fileNumber int64) string {
 	return fmt.Sprintf("%06d.%s", fileNumber, sstSuffix)
 }
 
+// ParseTable parses the file number from table file name, ok is false if the name isn't table file
+func ParseTable(name string) (fileNumber int64, ok bool) {
+	if !strings.HasSuffix(name, "."+sstSuffix) {
+		return 0, false
+	}
+	fileNumber, err := strconv.ParseInt(strings.TrimSuffix(name, "."+sstSuffix), 10, 64)
+	return fileNumber, err == nil
+}
+
 // manifestFileName return manifeset file name
 func manifestFileName(fileNumber int64) string {
 	return fmt.Sprintf("%s%06d", manifestPrefix, fileNumber)
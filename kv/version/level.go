@@ -29,6 +29,17 @@ func (l *level) deleteFile(fileNumber int64) {
 	delete(l.files, fileNumber)
 }
 
+// setFileDigest replaces the file with the copy of it which has the digest, the file meta may be shared by old versions
+func (l *level) setFileDigest(fileNumber int64, digest uint64) {
+	file, ok := l.files[fileNumber]
+	if !ok {
+		return
+	}
+	withDigest := *file
+	withDigest.SetDigest(digest)
+	l.files[fileNumber] = &withDigest
+}
+
 // getFiles returns all files in current level
 func (l *level) getFiles() []*FileMeta {
 	var values []*FileMeta
@@ -20,6 +20,10 @@ func init() {
 	RegisterLogType(3, func() Log {
 		return &NextFileNumber{}
 	})
+	// register file digest
+	RegisterLogType(4, func() Log {
+		return &FileDigest{}
+	})
 }
 
 // NewLogFunc create specific edit log instance
@@ -81,6 +85,11 @@ func (n *NewFile) Encode() ([]byte, error) {
 	stream.PutUvarint32(n.file.GetMinKey()) // min key
 	stream.PutUvarint32(n.file.GetMaxKey()) // max key
 	stream.PutInt32(n.file.GetFileSize())   // file size
+	// time range is optional, the log written by old version doesn't include it
+	if n.file.HasTimeRange() {
+		stream.PutInt64(n.file.GetMinTime()) // min time
+		stream.PutInt64(n.file.GetMaxTime()) // max time
+	}
 
 	return stream.Bytes()
 }
@@ -92,15 +101,79 @@ func (n *NewFile) Decode(v []byte) error {
 	n.level = stream.ReadInt32()
 	// read file meta
 	n.file = NewFileMeta(stream.ReadInt64(), stream.ReadUvarint32(), stream.ReadUvarint32(), stream.ReadInt32())
+	// read time range if exist
+	if stream.Error() == nil && !stream.Empty() {
+		n.file = NewFileMetaWithTimeRange(n.file.fileNumber, n.file.minKey, n.file.maxKey, n.file.fileSize,
+			stream.ReadInt64(), stream.ReadInt64())
+	}
 	// if error, return it
 	return stream.Error()
 }
 
+// String returns the readable string of new file
+func (n *NewFile) String() string {
+	s := fmt.Sprintf("new file:%d level:%d keys:[%d,%d] size:%d",
+		n.file.fileNumber, n.level, n.file.minKey, n.file.maxKey, n.file.fileSize)
+	if n.file.hasTimeRange {
+		s += fmt.Sprintf(" time:[%d,%d]", n.file.minTime, n.file.maxTime)
+	}
+	return s
+}
+
 // Apply new file edit log to version
 func (n *NewFile) apply(version *Version) {
 	version.addFile(int(n.level), n.file)
 }
 
+// FileDigest sets the content digest of the new file, which follows the new file log in same edit log,
+// the digest is kept apart from new file log, so that the new file log written by old version is compatible.
+type FileDigest struct {
+	level      int32
+	fileNumber int64
+	digest     uint64
+}
+
+// NewFileDigest creates FileDigest instance
+func NewFileDigest(level int32, fileNumber int64, digest uint64) *FileDigest {
+	return &FileDigest{
+		level:      level,
+		fileNumber: fileNumber,
+		digest:     digest,
+	}
+}
+
+// Encode writes file digest data into binary
+func (d *FileDigest) Encode() ([]byte, error) {
+	var stream = strm.BinaryWriter()
+
+	stream.PutInt32(d.level)
+	stream.PutInt64(d.fileNumber)
+	stream.PutUvarint64(d.digest)
+
+	return stream.Bytes()
+}
+
+// Decode reads file digest data from binary
+func (d *FileDigest) Decode(v []byte) error {
+	var stream = strm.BinaryReader(v)
+
+	d.level = stream.ReadInt32()
+	d.fileNumber = stream.ReadInt64()
+	d.digest = stream.ReadUvarint64()
+
+	return stream.Error()
+}
+
+// String returns the readable string of file digest
+func (d *FileDigest) String() string {
+	return fmt.Sprintf("file digest:%d level:%d digest:%016x", d.fileNumber, d.level, d.digest)
+}
+
+// Apply sets the digest of file in version
+func (d *FileDigest) apply(version *Version) {
+	version.setFileDigest(int(d.level), d.fileNumber, d.digest)
+}
+
 // DeleteFile remove file from metadata
 type DeleteFile struct {
 	level      int32
@@ -135,6 +208,11 @@ func (d *DeleteFile) Decode(v []byte) error {
 	return stream.Error()
 }
 
+// String returns the readable string of delete file
+func (d *DeleteFile) String() string {
+	return fmt.Sprintf("delete file:%d level:%d", d.fileNumber, d.level)
+}
+
 // Apply removes file from version
 func (d *DeleteFile) apply(version *Version) {
 	version.deleteFile(int(d.level), d.fileNumber)
@@ -166,6 +244,11 @@ func (n *NextFileNumber) Decode(v []byte) error {
 	return stream.Error()
 }
 
+// String returns the readable string of next file number
+func (n *NextFileNumber) String() string {
+	return fmt.Sprintf("next file number:%d", n.fileNumber)
+}
+
 // Apply do nothing for next file number
 func (n *NextFileNumber) apply(version *Version) {
 	// do nothing
@@ -16,6 +16,48 @@ func TestNewFile(t *testing.T) {
 	assert.Nil(t, err2, "new file decode error")
 
 	assert.Equal(t, newFile, newFile2, "file1 not eqals files")
+
+	// with time range
+	newFile = CreateNewFile(1, NewFileMetaWithTimeRange(12, 1, 100, 2014, -10, 3600))
+	bytes, err = newFile.Encode()
+	assert.Nil(t, err, "new file encode error")
+	newFile2 = &NewFile{}
+	err2 = newFile2.Decode(bytes)
+	assert.Nil(t, err2, "new file decode error")
+	assert.Equal(t, newFile, newFile2, "file1 not eqals files")
+	assert.True(t, newFile2.file.HasTimeRange())
+	assert.Equal(t, int64(-10), newFile2.file.GetMinTime())
+	assert.Equal(t, int64(3600), newFile2.file.GetMaxTime())
+}
+
+func TestFileMeta_OverlapsTimeRange(t *testing.T) {
+	file := NewFileMeta(12, 1, 100, 2014)
+	assert.False(t, file.HasTimeRange())
+	assert.True(t, file.OverlapsTimeRange(10, 20))
+
+	file = NewFileMetaWithTimeRange(12, 1, 100, 2014, 10, 20)
+	assert.True(t, file.OverlapsTimeRange(0, 10))
+	assert.True(t, file.OverlapsTimeRange(20, 30))
+	assert.True(t, file.OverlapsTimeRange(12, 15))
+	assert.True(t, file.OverlapsTimeRange(0, 30))
+	assert.False(t, file.OverlapsTimeRange(0, 9))
+	assert.False(t, file.OverlapsTimeRange(21, 30))
+}
+
+func TestFileDigest(t *testing.T) {
+	fileDigest := NewFileDigest(1, 12, 1<<63+1)
+	bytes, err := fileDigest.Encode()
+	assert.Nil(t, err)
+	fileDigest2 := &FileDigest{}
+	assert.Nil(t, fileDigest2.Decode(bytes))
+	assert.Equal(t, fileDigest, fileDigest2)
+	assert.Equal(t, "file digest:12 level:1 digest:8000000000000001", fileDigest2.String())
+
+	file := NewFileMeta(12, 1, 100, 2014)
+	assert.False(t, file.HasDigest())
+	file.SetDigest(10)
+	assert.True(t, file.HasDigest())
+	assert.Equal(t, uint64(10), file.GetDigest())
 }
 
 func TestDeleteFile(t *testing.T) {
@@ -41,3 +83,12 @@ func TestNextFileNumber(t *testing.T) {
 	assert.Nil(t, err2, "next file nubmer decode error")
 	assert.Equal(t, nextFileNumber, nextFileNumber2, "next file number 1 != next file number 2")
 }
+
+func TestLog_String(t *testing.T) {
+	assert.Equal(t, "new file:12 level:1 keys:[1,100] size:2014",
+		CreateNewFile(1, NewFileMeta(12, 1, 100, 2014)).String())
+	assert.Equal(t, "new file:12 level:1 keys:[1,100] size:2014 time:[-10,3600]",
+		CreateNewFile(1, NewFileMetaWithTimeRange(12, 1, 100, 2014, -10, 3600)).String())
+	assert.Equal(t, "delete file:120 level:1", NewDeleteFile(1, 120).String())
+	assert.Equal(t, "next file number:12", NewNextFileNumber(12).String())
+}
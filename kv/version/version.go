@@ -1,6 +1,9 @@
 package version
 
 import (
+	"encoding/binary"
+	"hash/fnv"
+	"sort"
 	"sync/atomic"
 )
 
@@ -43,6 +46,11 @@ func (v *Version) Release() {
 	}
 }
 
+// FindFiles finds all files include key from each level, the version must be retained until the files are read
+func (v *Version) FindFiles(key uint32) []*FileMeta {
+	return v.findFiles(key)
+}
+
 // findFiles finds all files include key from each level
 func (v *Version) findFiles(key uint32) []*FileMeta {
 	var files []*FileMeta
@@ -56,6 +64,30 @@ func (v *Version) findFiles(key uint32) []*FileMeta {
 	return files
 }
 
+// findFilesInTimeRange finds all files include key from each level, which time range overlaps [startTime, endTime]
+func (v *Version) findFilesInTimeRange(key uint32, startTime, endTime int64) []*FileMeta {
+	var files []*FileMeta
+	for _, file := range v.findFiles(key) {
+		if file.OverlapsTimeRange(startTime, endTime) {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// findLatestFiles finds all files include key from each level, the files are sorted by max timestamp in descending,
+// the files without time range are in the front, because their data may be the latest.
+func (v *Version) findLatestFiles(key uint32) []*FileMeta {
+	files := v.findFiles(key)
+	sort.SliceStable(files, func(i, j int) bool {
+		if !files[i].hasTimeRange || !files[j].hasTimeRange {
+			return !files[i].hasTimeRange && files[j].hasTimeRange
+		}
+		return files[i].maxTime > files[j].maxTime
+	})
+	return files
+}
+
 // getAllFilesetAllFiles returns all ative files of each level
 func (v *Version) getAllFiles() []*FileMeta {
 	var files []*FileMeta
@@ -65,6 +97,44 @@ func (v *Version) getAllFiles() []*FileMeta {
 	return files
 }
 
+// Digest returns the content digest of all active files, which hashes the sorted digests of files,
+// so that the versions with same files have same digest regardless of the levels and file numbers.
+// ok is false if no file or any file has no digest(e.g. written by old version).
+func (v *Version) Digest() (digest uint64, ok bool) {
+	files := v.getAllFiles()
+	if len(files) == 0 {
+		return 0, false
+	}
+	digests := make([]uint64, 0, len(files))
+	for _, file := range files {
+		if !file.HasDigest() {
+			return 0, false
+		}
+		digests = append(digests, file.GetDigest())
+	}
+	sort.Slice(digests, func(i, j int) bool { return digests[i] < digests[j] })
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, d := range digests {
+		binary.BigEndian.PutUint64(buf[:], d)
+		_, _ = h.Write(buf[:])
+	}
+	return h.Sum64(), true
+}
+
+// NumOfLevels returns the num. of levels
+func (v *Version) NumOfLevels() int {
+	return v.numOfLevels
+}
+
+// GetLevelFiles returns the files of level
+func (v *Version) GetLevelFiles(level int) []*FileMeta {
+	if level < 0 || level >= v.numOfLevels {
+		return nil
+	}
+	return v.levels[level].getFiles()
+}
+
 // retain increments version ref count
 func (v *Version) retain() {
 	atomic.AddInt32(&v.ref, 1)
@@ -91,6 +161,11 @@ func (v *Version) addFile(level int, file *FileMeta) {
 	v.levels[level].addFile(file)
 }
 
+// setFileDigest sets the content digest of file in spec level
+func (v *Version) setFileDigest(level int, fileNumber int64, digest uint64) {
+	v.levels[level].setFileDigest(fileNumber, digest)
+}
+
 // deleteFile delete file from spec level file list
 func (v *Version) deleteFile(level int, fileNumber int64) {
 	v.levels[level].deleteFile(fileNumber)
@@ -9,10 +9,21 @@ import (
 	"sync/atomic"
 
 	"github.com/eleme/lindb/pkg/bufioutil"
+	"github.com/eleme/lindb/pkg/errors"
 	"github.com/eleme/lindb/pkg/logger"
 	"github.com/eleme/lindb/pkg/util"
 )
 
+// maxCommitBatch is the max num. of edit logs persisted by one group commit
+const maxCommitBatch = 128
+
+// commitRequest represents the request of committing family's edit log
+type commitRequest struct {
+	familyVersion *FamilyVersion
+	editLog       *EditLog
+	done          chan error
+}
+
 // StoreVersionSet maintains all metadata for kv store
 type StoreVersionSet struct {
 	manifestFileNumber int64
@@ -21,12 +32,21 @@ type StoreVersionSet struct {
 	familyVersions     map[string]*FamilyVersion
 	familyIDs          map[int]string
 	versionID          int64 // unique in for increasing version id
+	sequence           int64 // the max sequence of edit logs, accessed by recovering/journal writer
+	// the sequences of edit logs applied when recovering, keyed by family id(include store family)
+	appliedSequences map[int]map[int64]struct{}
 
 	numOfLevels int // num of levels
 
 	manifest bufioutil.BufioWriter
 	mutex    sync.RWMutex
 
+	commitCh    chan *commitRequest // pending commit requests, consumed by manifest journal writer
+	journalDone chan struct{}       // closed after journal writer exits
+	// barrier synchronizes the installing of new versions with the acquiring of snapshots across stores,
+	// stores *Barrier, read by journal writer without holding mutex
+	barrier atomic.Value
+
 	logger *logger.Logger
 }
 
@@ -45,9 +65,21 @@ func NewStoreVersionSet(storePath string, numOfLevels int) *StoreVersionSet {
 
 // Destroy closes version set, release resource, such as journal writer etc.
 func (vs *StoreVersionSet) Destroy() error {
+	// stop manifest journal writer, no more commit requests are accepted after commit channel closed
 	vs.mutex.Lock()
-	defer vs.mutex.Unlock()
+	commitCh := vs.commitCh
+	journalDone := vs.journalDone
+	vs.commitCh = nil
+	if commitCh != nil {
+		close(commitCh)
+	}
+	vs.mutex.Unlock()
 
+	// wait pending commit requests completed without holding mutex,
+	// because the journal writer may acquire it when applying edit logs
+	if commitCh != nil {
+		<-journalDone
+	}
 	// close manifest journal writer if it exist
 	if vs.manifest != nil {
 		if err := vs.manifest.Close(); err != nil {
@@ -63,34 +95,105 @@ func (vs *StoreVersionSet) NextFileNumber() int64 {
 	return nextNumber - 1
 }
 
-// CommitFamilyEditLog persists edit logs to manifest file, then apply new version to family version
+// CommitFamilyEditLog persists edit logs to manifest file, then apply new version to family version.
+// The commit requests of all families are queued, the manifest journal writer persists the pending
+// edit logs in batch with one fsync(group commit), so that families flushing concurrently don't convoy.
+// The edit logs of same family are applied in commit order.
 func (vs *StoreVersionSet) CommitFamilyEditLog(family string, editLog *EditLog) error {
 	// get family version based on family name
 	familyVersion := vs.GetFamilyVersion(family)
 	if familyVersion == nil {
-		return fmt.Errorf("cannot find family version for name: %s", family)
+		return errors.Wrapf(errors.ErrFamilyNotFound, "cannot find family version for name: %s", family)
 	}
-
-	vs.mutex.Lock()
-	defer vs.mutex.Unlock()
-
-	// add next file number init edit log for each delta edit log
-	editLog.Add(NewNextFileNumber(vs.nextFileNumber))
-	// persist edit log
-	if err := vs.peresistEditLogs(vs.manifest, []*EditLog{editLog}); err != nil {
-		return err
+	request := &commitRequest{
+		familyVersion: familyVersion,
+		editLog:       editLog,
+		done:          make(chan error, 1),
+	}
+	vs.mutex.RLock()
+	if vs.commitCh == nil {
+		vs.mutex.RUnlock()
+		return errors.Wrapf(errors.ErrStoreClosed, "manifest journal of version set is not running")
 	}
+	vs.commitCh <- request
+	vs.mutex.RUnlock()
+	return <-request.done
+}
 
-	newVersion := familyVersion.GetCurrent().cloneVersion()
+// SetBarrier sets the barrier shared by stores, the new versions are installed exclusively
+// with the acquiring of versions by the barrier
+func (vs *StoreVersionSet) SetBarrier(barrier *Barrier) {
+	vs.barrier.Store(barrier)
+}
 
-	// apply delta edit to new version
-	editLog.apply(newVersion)
+// getBarrier returns the barrier of version set, nil if not set.
+// Invoked by journal writer, must not acquire the mutex which may be held by Destroy waiting journal writer exit.
+func (vs *StoreVersionSet) getBarrier() *Barrier {
+	barrier, _ := vs.barrier.Load().(*Barrier)
+	return barrier
+}
 
-	// Install the new version for family level version edit log
-	familyVersion.appendVersion(newVersion)
+// runJournal consumes the commit requests until commit channel closed,
+// drains all pending requests as one batch for group commit.
+func (vs *StoreVersionSet) runJournal(commitCh <-chan *commitRequest) {
+	defer close(vs.journalDone)
+	for request := range commitCh {
+		batch := []*commitRequest{request}
+	Drain:
+		for len(batch) < maxCommitBatch {
+			select {
+			case next, ok := <-commitCh:
+				if !ok {
+					break Drain
+				}
+				batch = append(batch, next)
+			default:
+				break Drain
+			}
+		}
+		vs.commitBatch(batch)
+	}
+}
 
-	vs.logger.Info("log and apply new version edit", logger.Any("log", editLog))
-	return nil
+// commitBatch persists edit logs of batch with one fsync, then applies them to family versions in order
+func (vs *StoreVersionSet) commitBatch(batch []*commitRequest) {
+	editLogs := make([]*EditLog, len(batch))
+	for idx, request := range batch {
+		// assign sequence once before the first persisting, the edit log committed again after
+		// persisting failure(maybe partial writes) keeps same content, so that it's applied once when recovering
+		if request.editLog.sequence == 0 {
+			// add next file number init edit log for each delta edit log
+			request.editLog.Add(NewNextFileNumber(atomic.LoadInt64(&vs.nextFileNumber)))
+			vs.sequence++
+			request.editLog.sequence = vs.sequence
+		}
+		editLogs[idx] = request.editLog
+	}
+	// persist edit logs
+	if err := vs.peresistEditLogs(vs.manifest, editLogs); err != nil {
+		for _, request := range batch {
+			request.done <- err
+		}
+		return
+	}
+	vs.getBarrier().install(func() {
+		for _, request := range batch {
+			familyVersion := request.familyVersion
+			current := familyVersion.GetCurrent()
+			newVersion := current.cloneVersion()
+			current.Release()
+
+			// apply delta edit to new version
+			request.editLog.apply(newVersion)
+
+			// Install the new version for family level version edit log
+			familyVersion.appendVersion(newVersion)
+		}
+	})
+	for _, request := range batch {
+		vs.logger.Info("log and apply new version edit", logger.Any("log", request.editLog))
+		request.done <- nil
+	}
 }
 
 // CreateFamilyVersion creates family version using family name,
@@ -120,6 +223,17 @@ func (vs *StoreVersionSet) GetFamilyVersion(family string) *FamilyVersion {
 	return nil
 }
 
+// ActiveVersions returns the num. of active versions of each family, keyed by family name
+func (vs *StoreVersionSet) ActiveVersions() map[string]int {
+	vs.mutex.RLock()
+	defer vs.mutex.RUnlock()
+	result := make(map[string]int)
+	for family, familyVersion := range vs.familyVersions {
+		result[family] = familyVersion.NumOfActiveVersions()
+	}
+	return result
+}
+
 // Recover recover version set if exist, recover been invoked when kv store init.
 // Initialize if version file not exists, else recover old data then init journal writer.
 func (vs *StoreVersionSet) Recover() error {
@@ -147,48 +261,115 @@ func (vs *StoreVersionSet) recover() error {
 		return err
 	}
 	manifestPath := vs.getManifestFilePath(manifestFileName)
+	vs.appliedSequences = make(map[int]map[int64]struct{})
+	defer func() {
+		vs.appliedSequences = nil
+	}()
+	return readEditLogs(manifestPath, func(editLog *EditLog) error {
+		if !vs.markApplied(editLog) {
+			vs.logger.Warn("skip edit log which has been applied",
+				logger.Any("family", editLog.familyID), logger.Any("sequence", editLog.sequence))
+			return nil
+		}
+		familyID := editLog.familyID
+		if familyID == StoreFamilyID {
+			editLog.applyVersionSet(vs)
+			return nil
+		}
+		// find releted family version
+		familyVersion := vs.getFamilyVersion(familyID)
+		if familyVersion == nil {
+			return errors.Wrapf(errors.ErrFamilyNotFound, "cannot get family version by id:%d", familyID)
+		}
+		// apply edit log to family current family
+		current := familyVersion.GetCurrent()
+		editLog.apply(current)
+		current.Release()
+		return nil
+	})
+}
+
+// ReadManifest reads all edit logs from the current manifest file of store in order, which is used for
+// debugging storage offline, the edit logs which have been applied(retried writes) are included.
+func ReadManifest(storePath string) (manifestFileName string, editLogs []*EditLog, err error) {
+	vs := &StoreVersionSet{storePath: storePath}
+	if manifestFileName, err = vs.readManifestFileName(); err != nil {
+		return "", nil, err
+	}
+	err = readEditLogs(vs.getManifestFilePath(manifestFileName), func(editLog *EditLog) error {
+		editLogs = append(editLogs, editLog)
+		return nil
+	})
+	return manifestFileName, editLogs, err
+}
+
+// readEditLogs reads the edit logs from manifest file in order, invokes fn for each edit log
+func readEditLogs(manifestPath string, fn func(editLog *EditLog) error) error {
 	reader, err := bufioutil.NewBufioReader(manifestPath)
+	if err != nil {
+		return fmt.Errorf("create journal reader error:%s", err)
+	}
 	defer func() {
 		if e := reader.Close(); e != nil {
-			vs.logger.Error("close manifest reader error",
+			logger.GetLogger("kv/version").Error("close manifest reader error",
 				logger.String("manifest", manifestPath))
 		}
 	}()
-	if err != nil {
-		return fmt.Errorf("create journal reader error:%s", err)
-	}
 	// read edit log
 	for reader.Next() {
 		record, err := reader.Read()
 		if err != nil {
-			return fmt.Errorf("recover data from manifest file error:%s", err)
+			return errors.Wrapf(errors.ErrManifestCorrupted, "recover data from manifest file error:%s", err)
 		}
 		editLog := &EditLog{}
 		unmalshalErr := editLog.unmarshal(record)
 		if unmalshalErr != nil {
-			return fmt.Errorf("unmarshal edit log data from manifest file error:%s", unmalshalErr)
+			return errors.Wrapf(errors.ErrManifestCorrupted,
+				"unmarshal edit log data from manifest file error:%s", unmalshalErr)
 		}
-
-		familyID := editLog.familyID
-		if familyID == StoreFamilyID {
-			editLog.applyVersionSet(vs)
-		} else {
-			// find releted family version
-			familyVersion := vs.getFamilyVersion(familyID)
-			if familyVersion == nil {
-				return fmt.Errorf("cannot get family version by id:%d", familyID)
-			}
-			// apply edit log to family current family
-			editLog.apply(familyVersion.GetCurrent())
+		if err := fn(editLog); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
-// setNextFileNumberWithoutLock set next file number, invoker must add lock
+// markApplied marks the edit log applied, returns false if the edit log has been applied,
+// e.g. the edit log is written twice into manifest file because of retrying after partial writes.
+// The retried edit log may be written after the edit logs with greater sequence, so checks the exact sequence.
+// The edit log without sequence is always applied.
+func (vs *StoreVersionSet) markApplied(editLog *EditLog) bool {
+	sequence := editLog.sequence
+	if sequence == 0 {
+		return true
+	}
+	sequences, ok := vs.appliedSequences[editLog.familyID]
+	if !ok {
+		sequences = make(map[int64]struct{})
+		vs.appliedSequences[editLog.familyID] = sequences
+	}
+	if _, ok := sequences[sequence]; ok {
+		return false
+	}
+	sequences[sequence] = struct{}{}
+	if sequence > vs.sequence {
+		vs.sequence = sequence
+	}
+	return true
+}
+
+// setNextFileNumberWithoutLock set next file number, invoker must add lock.
+// Next file number never goes back, because the file numbers may be allocated concurrently
+// after the edit log recorded next file number.
 func (vs *StoreVersionSet) setNextFileNumberWithoutLock(newNextFileNumber int64) {
 	vs.manifestFileNumber = newNextFileNumber
-	vs.nextFileNumber = newNextFileNumber + 1
+	for {
+		nextFileNumber := atomic.LoadInt64(&vs.nextFileNumber)
+		if newNextFileNumber+1 <= nextFileNumber ||
+			atomic.CompareAndSwapInt64(&vs.nextFileNumber, nextFileNumber, newNextFileNumber+1) {
+			return
+		}
+	}
 }
 
 // readManifestFileName reads manifest file name from current file
@@ -225,6 +406,13 @@ func (vs *StoreVersionSet) initJournal() error {
 		}
 		// finally set version set's namifest writer
 		vs.manifest = writer
+		// start manifest journal writer for committing edit logs
+		commitCh := make(chan *commitRequest, maxCommitBatch)
+		vs.journalDone = make(chan struct{})
+		vs.mutex.Lock()
+		vs.commitCh = commitCh
+		vs.mutex.Unlock()
+		go vs.runJournal(commitCh)
 	}
 	return nil
 }
@@ -288,14 +476,17 @@ func (vs *StoreVersionSet) createSnapshot() []*EditLog {
 // createFamilySnapshot creates snapshot of eidt log for family level
 func (vs *StoreVersionSet) createFamilySnapshot(familyID int, familyVersion *FamilyVersion) *EditLog {
 	editLog := NewEditLog(familyID)
+	// the edit logs committed after snapshot have greater sequence
+	editLog.sequence = vs.sequence
 	// save current version all active files
-	levels := familyVersion.GetCurrent().levels
+	current := familyVersion.GetCurrent()
+	defer current.Release()
+	levels := current.levels
 	for numOfLevel, level := range levels {
 		files := level.getFiles()
 		for _, file := range files {
 			// level -> file meta
-			newFile := CreateNewFile(int32(numOfLevel), file)
-			editLog.Add(newFile)
+			editLog.AddNewFile(int32(numOfLevel), file)
 		}
 	}
 	return editLog
@@ -304,12 +495,13 @@ func (vs *StoreVersionSet) createFamilySnapshot(familyID int, familyVersion *Fam
 // createStoreSnapshot creates snapshot of eidt log for store level
 func (vs *StoreVersionSet) createStoreSnapshot() *EditLog {
 	editLog := NewEditLog(StoreFamilyID)
+	editLog.sequence = vs.sequence
 	// save next file number
 	editLog.Add(NewNextFileNumber(vs.nextFileNumber))
 	return editLog
 }
 
-// peresistEditLogs peresists eidt logs into manifest file
+// peresistEditLogs peresists eidt logs into manifest file, syncs once after writing all edit logs
 func (vs *StoreVersionSet) peresistEditLogs(writer bufioutil.BufioWriter, editLogs []*EditLog) error {
 	for _, editLog := range editLogs {
 		v, err := editLog.marshal()
@@ -319,9 +511,9 @@ func (vs *StoreVersionSet) peresistEditLogs(writer bufioutil.BufioWriter, editLo
 		if _, err := writer.Write(v); err != nil {
 			return fmt.Errorf("write edit log error:%s", err)
 		}
-		if err := writer.Sync(); err != nil {
-			return fmt.Errorf("sync edit log error:%s", err)
-		}
+	}
+	if err := writer.Sync(); err != nil {
+		return fmt.Errorf("sync edit log error:%s", err)
 	}
 	return nil
 }
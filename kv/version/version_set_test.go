@@ -2,10 +2,14 @@ package version
 
 import (
 	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/eleme/lindb/pkg/bufioutil"
+	"github.com/eleme/lindb/pkg/errors"
 	"github.com/eleme/lindb/pkg/util"
 )
 
@@ -57,6 +61,7 @@ func TestCommitFamilyEditLog(t *testing.T) {
 
 	err = vs.CommitFamilyEditLog("f", nil)
 	assert.NotNil(t, err, "commit not exist family version")
+	assert.True(t, errors.Is(err, errors.ErrFamilyNotFound))
 
 	familyID := 1
 	vs.CreateFamilyVersion("f", familyID)
@@ -66,6 +71,8 @@ func TestCommitFamilyEditLog(t *testing.T) {
 	editLog.Add(NewDeleteFile(1, 123))
 	err = vs.CommitFamilyEditLog("f", editLog)
 	assert.Nil(t, err, "commit family edit log error")
+	// previous version without ref is released
+	assert.Equal(t, map[string]int{"f": 1}, vs.ActiveVersions())
 
 	vs.Destroy()
 
@@ -84,6 +91,255 @@ func TestCommitFamilyEditLog(t *testing.T) {
 	}
 }
 
+func TestCommitFamilyEditLog_Digest(t *testing.T) {
+	initVersionSetTestData()
+	defer destoryVersionTestData()
+
+	vs := NewStoreVersionSet(vsTestPath, 2)
+	assert.Nil(t, vs.Recover())
+	vs.CreateFamilyVersion("f", 1)
+	file := NewFileMeta(12, 1, 100, 2014)
+	file.SetDigest(100)
+	editLog := NewEditLog(1)
+	editLog.AddNewFile(1, file)
+	assert.Nil(t, vs.CommitFamilyEditLog("f", editLog))
+	vs.Destroy()
+
+	// the digest is kept by the snapshot of manifest after recovered many times
+	for i := 0; i < 2; i++ {
+		vs = NewStoreVersionSet(vsTestPath, 2)
+		vs.CreateFamilyVersion("f", 1)
+		assert.Nil(t, vs.Recover())
+		current := vs.GetFamilyVersion("f").GetCurrent()
+		assert.Equal(t, file, current.getAllFiles()[0])
+		current.Release()
+		vs.Destroy()
+	}
+}
+
+func TestReadManifest(t *testing.T) {
+	initVersionSetTestData()
+	defer destoryVersionTestData()
+
+	_, _, err := ReadManifest(vsTestPath)
+	assert.NotNil(t, err)
+
+	var vs = NewStoreVersionSet(vsTestPath, 2)
+	assert.Nil(t, vs.Recover())
+	vs.CreateFamilyVersion("f", 1)
+	editLog := NewEditLog(1)
+	editLog.Add(CreateNewFile(1, NewFileMeta(12, 1, 100, 2014)))
+	assert.Nil(t, vs.CommitFamilyEditLog("f", editLog))
+	vs.Destroy()
+
+	manifest, editLogs, err := ReadManifest(vsTestPath)
+	assert.Nil(t, err)
+	assert.Equal(t, manifestFileName(1), manifest)
+	// snapshot of store and family, then committed edit log
+	last := editLogs[len(editLogs)-1]
+	assert.Equal(t, 1, last.FamilyID())
+	assert.True(t, last.Sequence() > 0)
+	assert.Equal(t, editLog.Logs(), last.Logs())
+}
+
+func TestCommitFamilyEditLog_Duplicate(t *testing.T) {
+	initVersionSetTestData()
+	defer destoryVersionTestData()
+
+	var vs = NewStoreVersionSet(vsTestPath, 2)
+	vs.CreateFamilyVersion("f", 1)
+	assert.Nil(t, vs.Recover())
+
+	editLog1 := NewEditLog(1)
+	editLog1.Add(CreateNewFile(1, NewFileMeta(12, 1, 100, 2014)))
+	assert.Nil(t, vs.CommitFamilyEditLog("f", editLog1))
+	assert.Equal(t, int64(1), editLog1.sequence)
+	editLog2 := NewEditLog(1)
+	editLog2.Add(NewDeleteFile(1, 12))
+	assert.Nil(t, vs.CommitFamilyEditLog("f", editLog2))
+	assert.Equal(t, int64(2), editLog2.sequence)
+	nextFileNumber := vs.nextFileNumber
+	// mock edit logs written twice
+	assert.Nil(t, vs.peresistEditLogs(vs.manifest, []*EditLog{editLog1, editLog2}))
+	assert.Nil(t, vs.Destroy())
+
+	vs = NewStoreVersionSet(vsTestPath, 2)
+	vs.CreateFamilyVersion("f", 1)
+	assert.Nil(t, vs.Recover())
+	assert.Equal(t, 0, len(vs.GetFamilyVersion("f").GetAllFiles()))
+	assert.Equal(t, nextFileNumber, vs.nextFileNumber)
+	assert.Equal(t, int64(2), vs.sequence)
+
+	// the sequence of new edit log is greater than recovered edit logs
+	editLog3 := NewEditLog(1)
+	editLog3.Add(CreateNewFile(1, NewFileMeta(13, 1, 100, 2014)))
+	assert.Nil(t, vs.CommitFamilyEditLog("f", editLog3))
+	assert.Equal(t, int64(3), editLog3.sequence)
+	assert.Nil(t, vs.Destroy())
+
+	vs = NewStoreVersionSet(vsTestPath, 2)
+	vs.CreateFamilyVersion("f", 1)
+	assert.Nil(t, vs.Recover())
+	assert.Equal(t, 1, len(vs.GetFamilyVersion("f").GetAllFiles()))
+	assert.Nil(t, vs.Destroy())
+}
+
+// failSyncWriter fails the first syncing after data written
+type failSyncWriter struct {
+	bufioutil.BufioWriter
+	failed bool
+}
+
+func (w *failSyncWriter) Sync() error {
+	if !w.failed {
+		w.failed = true
+		return fmt.Errorf("sync failure")
+	}
+	return w.BufioWriter.Sync()
+}
+
+func TestCommitFamilyEditLog_Retry(t *testing.T) {
+	initVersionSetTestData()
+	defer destoryVersionTestData()
+
+	var vs = NewStoreVersionSet(vsTestPath, 2)
+	vs.CreateFamilyVersion("f", 1)
+	assert.Nil(t, vs.Recover())
+	vs.manifest = &failSyncWriter{BufioWriter: vs.manifest}
+
+	editLog1 := NewEditLog(1)
+	editLog1.Add(CreateNewFile(1, NewFileMeta(12, 1, 100, 2014)))
+	assert.NotNil(t, vs.CommitFamilyEditLog("f", editLog1))
+	assert.Equal(t, int64(1), editLog1.sequence)
+	numOfLogs := len(editLog1.Logs())
+	// commit other edit log before retrying
+	editLog2 := NewEditLog(1)
+	editLog2.Add(CreateNewFile(1, NewFileMeta(13, 1, 100, 2014)))
+	assert.Nil(t, vs.CommitFamilyEditLog("f", editLog2))
+	assert.Equal(t, int64(2), editLog2.sequence)
+	// retry doesn't re-assign sequence and append next file number again
+	assert.Nil(t, vs.CommitFamilyEditLog("f", editLog1))
+	assert.Equal(t, int64(1), editLog1.sequence)
+	assert.Equal(t, numOfLogs, len(editLog1.Logs()))
+	assert.Equal(t, 2, len(vs.GetFamilyVersion("f").GetAllFiles()))
+	assert.Nil(t, vs.Destroy())
+
+	_, editLogs, err := ReadManifest(vsTestPath)
+	assert.Nil(t, err)
+	var sequences []int64
+	for _, editLog := range editLogs {
+		if editLog.Sequence() > 0 {
+			sequences = append(sequences, editLog.Sequence())
+		}
+	}
+	// edit log 1 is written twice, the retried one is after edit log 2
+	assert.Equal(t, []int64{1, 2, 1}, sequences)
+
+	vs = NewStoreVersionSet(vsTestPath, 2)
+	vs.CreateFamilyVersion("f", 1)
+	assert.Nil(t, vs.Recover())
+	assert.Equal(t, 2, len(vs.GetFamilyVersion("f").GetAllFiles()))
+	assert.Equal(t, int64(2), vs.sequence)
+	assert.Nil(t, vs.Destroy())
+}
+
+func TestCommitFamilyEditLog_Concurrent(t *testing.T) {
+	initVersionSetTestData()
+	defer destoryVersionTestData()
+
+	var vs = NewStoreVersionSet(vsTestPath, 2)
+	// journal not running before recovering
+	vs.CreateFamilyVersion("f", 1)
+	assert.NotNil(t, vs.CommitFamilyEditLog("f", NewEditLog(1)))
+	assert.Nil(t, vs.Recover())
+
+	numOfFamily := 5
+	numOfCommit := 20
+	for i := 2; i <= numOfFamily; i++ {
+		vs.CreateFamilyVersion(fmt.Sprintf("f%d", i), i)
+	}
+	var wg sync.WaitGroup
+	for i := 1; i <= numOfFamily; i++ {
+		family := "f"
+		if i > 1 {
+			family = fmt.Sprintf("f%d", i)
+		}
+		wg.Add(1)
+		go func(familyID int, family string) {
+			defer wg.Done()
+			for j := 0; j < numOfCommit; j++ {
+				editLog := NewEditLog(familyID)
+				editLog.Add(CreateNewFile(0, NewFileMeta(vs.NextFileNumber(), 1, 100, 2014)))
+				assert.Nil(t, vs.CommitFamilyEditLog(family, editLog))
+			}
+		}(i, family)
+	}
+	wg.Wait()
+	assert.Nil(t, vs.Destroy())
+	// commit after destroy
+	assert.True(t, errors.Is(vs.CommitFamilyEditLog("f", NewEditLog(1)), errors.ErrStoreClosed))
+
+	// recover all edit logs
+	vs = NewStoreVersionSet(vsTestPath, 2)
+	vs.CreateFamilyVersion("f", 1)
+	for i := 2; i <= numOfFamily; i++ {
+		vs.CreateFamilyVersion(fmt.Sprintf("f%d", i), i)
+	}
+	assert.Nil(t, vs.Recover())
+	assert.Equal(t, numOfCommit, len(vs.GetFamilyVersion("f").GetAllFiles()))
+	assert.Equal(t, numOfCommit, len(vs.GetFamilyVersion("f3").GetAllFiles()))
+	// file numbers are unique, next file number is greater than all allocated file numbers
+	var maxFileNumber int64
+	fileNumbers := make(map[int64]struct{})
+	for _, familyVersion := range vs.familyVersions {
+		for _, file := range familyVersion.GetAllFiles() {
+			fileNumbers[file.GetFileNumber()] = struct{}{}
+			if file.GetFileNumber() > maxFileNumber {
+				maxFileNumber = file.GetFileNumber()
+			}
+		}
+	}
+	assert.Equal(t, numOfFamily*numOfCommit, len(fileNumbers))
+	assert.True(t, vs.nextFileNumber > maxFileNumber)
+	assert.Nil(t, vs.Destroy())
+}
+
+func TestDestroy_CommitsQueued(t *testing.T) {
+	initVersionSetTestData()
+	defer destoryVersionTestData()
+
+	var vs = NewStoreVersionSet(vsTestPath, 2)
+	vs.SetBarrier(NewBarrier())
+	vs.CreateFamilyVersion("f", 1)
+	assert.Nil(t, vs.Recover())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				editLog := NewEditLog(1)
+				editLog.Add(CreateNewFile(0, NewFileMeta(vs.NextFileNumber(), 1, 100, 2014)))
+				if err := vs.CommitFamilyEditLog("f", editLog); err != nil {
+					assert.True(t, errors.Is(err, errors.ErrStoreClosed))
+				}
+			}
+		}()
+	}
+	destroyed := make(chan error, 1)
+	go func() {
+		destroyed <- vs.Destroy()
+	}()
+	select {
+	case err := <-destroyed:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("destroy version set with queued commits deadlock")
+	}
+	wg.Wait()
+}
+
 func TestCreateFamily(t *testing.T) {
 	initVersionSetTestData()
 	defer destoryVersionTestData()
@@ -110,3 +366,37 @@ func destoryVersionTestData() {
 		fmt.Println("delete test path error")
 	}
 }
+
+func TestCommitFamilyEditLog_Barrier(t *testing.T) {
+	initVersionSetTestData()
+	defer destoryVersionTestData()
+
+	vs := NewStoreVersionSet(vsTestPath, 2)
+	assert.Nil(t, vs.Recover())
+	defer vs.Destroy()
+	vs.CreateFamilyVersion("f", 1)
+	barrier := NewBarrier()
+	vs.SetBarrier(barrier)
+
+	committed := make(chan error)
+	barrier.Acquire(func() {
+		go func() {
+			editLog := NewEditLog(1)
+			editLog.Add(CreateNewFile(0, NewFileMeta(12, 1, 100, 2014)))
+			committed <- vs.CommitFamilyEditLog("f", editLog)
+		}()
+		// the new version isn't installed until the acquiring completed
+		select {
+		case <-committed:
+			t.Fatal("should not install new version in barrier")
+		case <-time.After(50 * time.Millisecond):
+		}
+		current := vs.GetFamilyVersion("f").GetCurrent()
+		assert.Empty(t, current.getAllFiles())
+		current.Release()
+	})
+	assert.Nil(t, <-committed)
+	current := vs.GetFamilyVersion("f").GetCurrent()
+	assert.Len(t, current.getAllFiles(), 1)
+	current.Release()
+}
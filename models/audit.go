@@ -0,0 +1,32 @@
+package models
+
+// Defines the administrative operations recorded by audit log
+const (
+	AuditCreateDatabase       = "create-database"
+	AuditUpdateDatabase       = "update-database"
+	AuditSaveStorageCluster   = "save-storage-cluster"
+	AuditDeleteStorageCluster = "delete-storage-cluster"
+	AuditResetMetricStore     = "reset-metric-store"
+	AuditDropMetricStore      = "drop-metric-store"
+	AuditSaveDatabaseLimits   = "save-database-limits"
+	AuditSaveShardStates      = "save-shard-states"
+	AuditKillQuery            = "kill-query"
+	AuditSaveQueryBlacklist   = "save-query-blacklist"
+	AuditDeleteQueryBlacklist = "delete-query-blacklist"
+	AuditSaveUDF              = "save-udf"
+	AuditDeleteUDF            = "delete-udf"
+)
+
+// AuditLog represents the summary of an administrative operation, like database create/update,
+// which tells operators what changed, when and by whom.
+type AuditLog struct {
+	ID        string `json:"id"`
+	Operation string `json:"operation"`
+	// Target is the name of database/storage cluster operated
+	Target string `json:"target"`
+	// Initiator is the user who initiates the operation, or the address of client if user is unknown
+	Initiator string `json:"initiator"`
+	// Detail is the content of operation, like the config of database
+	Detail    string `json:"detail,omitempty"`
+	Timestamp int64  `json:"timestamp"`
+}
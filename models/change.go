@@ -0,0 +1,53 @@
+package models
+
+// ChangePoint represents the point of committed write in change stream, the values of simple fields are float
+type ChangePoint struct {
+	Metric    string             `json:"metric"`
+	Timestamp int64              `json:"timestamp"`
+	Tags      map[string]string  `json:"tags,omitempty"`
+	Fields    map[string]float64 `json:"fields"`
+}
+
+// ChangeBatch represents the write batch committed into database, which is published to the subscribers
+// of change stream(change data capture) in the order of committing.
+type ChangeBatch struct {
+	Database string `json:"database"`
+	// Sequence increases by one for each batch of database, so that the subscriber can find out the lost batches
+	Sequence int64 `json:"sequence"`
+	// CommitTime is the time(ms) when the batch is committed
+	CommitTime int64 `json:"commitTime"`
+	// SequenceToken is the sequences of shards after the batch applied, empty if the writer doesn't return it
+	SequenceToken string        `json:"sequenceToken,omitempty"`
+	Points        []ChangePoint `json:"points"`
+}
+
+// ChangeSubscription represents the subscription of change stream
+type ChangeSubscription struct {
+	// Database is the database subscribed, empty means all databases
+	Database string `json:"database,omitempty"`
+}
+
+// NewChangePoint creates the change point by point, only the simple fields are kept
+func NewChangePoint(point Point) ChangePoint {
+	changePoint := ChangePoint{
+		Metric:    point.Name(),
+		Timestamp: point.Timestamp(),
+		Tags:      point.TagsMap(),
+		Fields:    make(map[string]float64),
+	}
+	for name, f := range point.Fields() {
+		simpleField, ok := f.(SimpleField)
+		if !ok {
+			continue
+		}
+		switch value := simpleField.Value().(type) {
+		case float64:
+			changePoint.Fields[name] = value
+		case int64:
+			changePoint.Fields[name] = float64(value)
+		case int:
+			changePoint.Fields[name] = float64(value)
+		}
+	}
+	return changePoint
+}
@@ -0,0 +1,13 @@
+package models
+
+// MetadataSchemaVersion represents the schema version of metadata in state repository supported by node,
+// which is increased when the layout/format of metadata is changed incompatibly.
+const MetadataSchemaVersion = 1
+
+// ClusterInfo represents the identity of cluster, which is created in the namespace of state repository
+// on the first start of cluster, and validated by every node when joining the cluster.
+type ClusterInfo struct {
+	ID            string `json:"id"`
+	SchemaVersion int    `json:"schemaVersion"`
+	CreateTime    int64  `json:"createTime"`
+}
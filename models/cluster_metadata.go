@@ -0,0 +1,32 @@
+package models
+
+// ClusterMetadataVersion represents the version of cluster metadata bundle format
+const ClusterMetadataVersion = 1
+
+// ClusterMetadata represents all metadata of cluster which is stored in state repository,
+// it is exported as a json bundle for disaster recovery and environment cloning.
+// NOTICE: users are defined in broker config, so they aren't included.
+type ClusterMetadata struct {
+	Version          int                       `json:"version"`
+	ExportTime       int64                     `json:"exportTime"`
+	StorageClusters  []StorageCluster          `json:"storageClusters"`
+	Databases        []Database                `json:"databases"`
+	ShardAssignments []DatabaseShardAssignment `json:"shardAssignments"`
+	// Entries are the other metadata(e.g. udfs/query blacklist/database limits/shard states) kept as key/value pairs
+	Entries []MetadataEntry `json:"entries,omitempty"`
+}
+
+// MetadataEntry represents the key/value pair of metadata in state repository,
+// the entry is stored in broker's state repository if cluster is empty, else in the storage cluster's.
+type MetadataEntry struct {
+	Cluster string `json:"cluster,omitempty"`
+	Key     string `json:"key"`
+	Value   []byte `json:"value"`
+}
+
+// DatabaseShardAssignment represents the shard assignment of database in storage cluster
+type DatabaseShardAssignment struct {
+	Cluster         string           `json:"cluster"`
+	Database        string           `json:"database"`
+	ShardAssignment *ShardAssignment `json:"shardAssignment"`
+}
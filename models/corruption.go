@@ -0,0 +1,77 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/pkg/interval"
+)
+
+// Corruption represents the corrupted file found in the kv family of storage node, which has been marked bad
+// in family version. It is reported into state repository, then the family is re-replicated from a healthy replica.
+type Corruption struct {
+	Node     string `json:"node,omitempty"`
+	Database string `json:"database"`
+	// Index is true if the file belongs to the metadata index of database, which isn't re-replicated
+	// because the ids in index are allocated by each storage node.
+	Index        bool          `json:"index,omitempty"`
+	ShardID      int           `json:"shardId"`
+	IntervalType interval.Type `json:"intervalType"`
+	Segment      string        `json:"segment,omitempty"`
+	Family       string        `json:"family"`
+	FileNumber   int64         `json:"fileNumber"`
+	Error        string        `json:"error,omitempty"`
+	// ReportTime is the timestamp(ms) when the corruption is found
+	ReportTime int64 `json:"reportTime,omitempty"`
+}
+
+// ID returns the unique id of the family with corrupted file in storage node
+func (c *Corruption) ID() string {
+	if c.Index {
+		return fmt.Sprintf("%s_%s_index_%s", c.Node, c.Database, c.Family)
+	}
+	return fmt.Sprintf("%s_%s_%d_%s_%s_%s", c.Node, c.Database, c.ShardID, c.IntervalType.String(), c.Segment, c.Family)
+}
+
+// FamilyExportRequest represents the request of exporting the data of a family from healthy replica,
+// the metric blocks are exported in pages by metric id of the replica, the page starts after the metric id.
+type FamilyExportRequest struct {
+	Database     string        `json:"database"`
+	ShardID      int           `json:"shardId"`
+	IntervalType interval.Type `json:"intervalType"`
+	Segment      string        `json:"segment"`
+	Family       string        `json:"family"`
+	After        uint32        `json:"after,omitempty"`
+	// Limit is the max bytes of blocks in page
+	Limit int `json:"limit,omitempty"`
+}
+
+// FamilyExport represents a page of the exported family, the series and fields are referenced by name,
+// because the ids are allocated by each storage node.
+type FamilyExport struct {
+	Blocks []ExportedBlock `json:"blocks,omitempty"`
+	// Next is the metric id which the next page starts after, there is no more page if done
+	Next uint32 `json:"next,omitempty"`
+	Done bool   `json:"done"`
+}
+
+// ExportedBlock represents the metric block of family
+type ExportedBlock struct {
+	Metric    string          `json:"metric"`
+	Fields    []ExportedField `json:"fields"`
+	StartTime int64           `json:"startTime"`
+	EndTime   int64           `json:"endTime"`
+	Series    []ExportedEntry `json:"series"`
+}
+
+// ExportedField represents the field of metric block
+type ExportedField struct {
+	Name string     `json:"name"`
+	Type field.Type `json:"type"`
+}
+
+// ExportedEntry represents the data of series in metric block, which is referenced by the tags
+type ExportedEntry struct {
+	Tags string `json:"tags"`
+	Data []byte `json:"data"`
+}
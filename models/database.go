@@ -1,11 +1,135 @@
 package models
 
-import "github.com/eleme/lindb/pkg/option"
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/eleme/lindb/pkg/option"
+)
 
 // Database defines database config, database can include multi-cluster
 type Database struct {
-	Name     string            `json:"name"`
-	Clusters []DatabaseCluster `json:"clusters"`
+	Name       string            `json:"name"`
+	Clusters   []DatabaseCluster `json:"clusters"`
+	WriteRules *WriteRules       `json:"writeRules,omitempty"`
+	// NamingPolicy is the naming rules of metrics which are enforced by broker at write
+	NamingPolicy *NamingPolicy `json:"namingPolicy,omitempty"`
+	// WriteAck is the default ack mode of the writes which don't choose the ack mode, enqueue if empty
+	WriteAck WriteAck `json:"writeAck,omitempty"`
+	// Readers are the users who are allowed to query the database, all users can query the database if empty
+	Readers []string `json:"readers,omitempty"`
+}
+
+// WriteAck represents when the broker acknowledges the write of client,
+// which is the tradeoff between the latency and durability of write.
+type WriteAck string
+
+// Defines all ack modes of write
+const (
+	// WriteAckEnqueue acks after the points are enqueued into the write batch(fire-and-forget), it's low latency,
+	// but the points may be lost if the broker crashes or the replication fails after acked
+	WriteAckEnqueue WriteAck = "enqueue"
+	// WriteAckDurable acks after the batch of points is replicated and applied by storage nodes,
+	// the replication error is returned to client
+	WriteAckDurable WriteAck = "durable"
+)
+
+// Validate checks if the ack mode is one of the defined modes, empty means the default mode
+func (a WriteAck) Validate() error {
+	switch a {
+	case "", WriteAckEnqueue, WriteAckDurable:
+		return nil
+	default:
+		return fmt.Errorf("unknown write ack[%s], should be %s or %s", a, WriteAckEnqueue, WriteAckDurable)
+	}
+}
+
+// WriteRules represents the write-time filtering/relabeling rules of database, which are applied by broker
+// before writing in order: drop metrics, drop tags, rename tags, add tags.
+// dropMetrics are the regexp patterns of metric names(full match), dropTags are the tag keys,
+// renameTags maps the old tag key to new key, addTags are the static tags which don't override the tags of point.
+type WriteRules struct {
+	DropMetrics []string          `json:"dropMetrics,omitempty"`
+	DropTags    []string          `json:"dropTags,omitempty"`
+	RenameTags  map[string]string `json:"renameTags,omitempty"`
+	AddTags     map[string]string `json:"addTags,omitempty"`
+}
+
+// Validate checks if the patterns of metric names are valid regexp, and the tag keys are not empty
+func (r *WriteRules) Validate() error {
+	if r == nil {
+		return nil
+	}
+	for _, pattern := range r.DropMetrics {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid pattern of drop metric[%s]:%s", pattern, err)
+		}
+	}
+	for _, tagKey := range r.DropTags {
+		if len(tagKey) == 0 {
+			return fmt.Errorf("tag key of drop tags cannot be empty")
+		}
+	}
+	for oldKey, newKey := range r.RenameTags {
+		if len(oldKey) == 0 || len(newKey) == 0 {
+			return fmt.Errorf("tag key of rename tags cannot be empty")
+		}
+	}
+	for tagKey, tagValue := range r.AddTags {
+		if len(tagKey) == 0 || len(tagValue) == 0 {
+			return fmt.Errorf("tag key/value of add tags cannot be empty")
+		}
+	}
+	return nil
+}
+
+// NamingPolicy represents the naming rules of the metrics of database, which are enforced by broker at write,
+// the points violating any rule are dropped(only counted if dry run).
+// metricPattern is the regexp(full match) of allowed metric names(e.g. [a-z0-9_.]+),
+// maxDepth is the max number of the segments of metric name separated by '.',
+// requiredTags are the tag keys(e.g. app) which every point must have,
+// template is the prefix of metric name separated by '.', each segment is literal, '*' matches any segment
+// or '{tagKey}' matches the value of tag, e.g. {app}.* requires the metric name starts with the app of point.
+type NamingPolicy struct {
+	MetricPattern string   `json:"metricPattern,omitempty"`
+	MaxDepth      int      `json:"maxDepth,omitempty"`
+	RequiredTags  []string `json:"requiredTags,omitempty"`
+	Template      string   `json:"template,omitempty"`
+	DryRun        bool     `json:"dryRun,omitempty"`
+}
+
+// Validate checks if the pattern of metric name is valid regexp, the max depth is not negative,
+// and the required tags and the segments of template are not empty
+func (p *NamingPolicy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	if len(p.MetricPattern) > 0 {
+		if _, err := regexp.Compile(p.MetricPattern); err != nil {
+			return fmt.Errorf("invalid pattern of metric name[%s]:%s", p.MetricPattern, err)
+		}
+	}
+	if p.MaxDepth < 0 {
+		return fmt.Errorf("max depth of metric name must be >= 0")
+	}
+	for _, tagKey := range p.RequiredTags {
+		if len(tagKey) == 0 {
+			return fmt.Errorf("tag key of required tags cannot be empty")
+		}
+	}
+	if len(p.Template) > 0 {
+		segments := strings.Split(p.Template, ".")
+		if p.MaxDepth > 0 && len(segments) > p.MaxDepth {
+			return fmt.Errorf("segments of template[%s] exceed max depth", p.Template)
+		}
+		for _, segment := range segments {
+			if len(segment) == 0 || segment == "{}" {
+				return fmt.Errorf("segment of template[%s] cannot be empty", p.Template)
+			}
+		}
+	}
+	return nil
 }
 
 // DatabaseCluster represents database's storage cluster config
@@ -14,18 +138,50 @@ type DatabaseCluster struct {
 	NumOfShard    int                `json:"numOfShard"`
 	ReplicaFactor int                `json:"replicaFactor"`
 	ShardOption   option.ShardOption `json:"shardOption"`
+	Placement     *Placement         `json:"placement,omitempty"`
+	SplitOption   *SplitOption       `json:"splitOption,omitempty"`
+	// RoutingTags are the tag keys(e.g. region) which the shard routing hashes on with metric name,
+	// instead of the whole series, see SeriesHash
+	RoutingTags []string `json:"routingTags,omitempty"`
+}
+
+// Placement represents the placement constraints of database's shard replicas,
+// require selects the storage nodes whose labels match all of them(e.g. disk=ssd),
+// spreadBy is the label key(e.g. zone), the replicas of each shard are placed on the nodes
+// with different values of the label.
+type Placement struct {
+	Require  map[string]string `json:"require,omitempty"`
+	SpreadBy string            `json:"spreadBy,omitempty"`
 }
 
-// Replica defines replica list for spec shard of database
+// Match checks if the labels satisfy the require constraints
+func (p *Placement) Match(labels map[string]string) bool {
+	if p == nil {
+		return true
+	}
+	for key, value := range p.Require {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Replica defines replica list for spec shard of database,
+// range is the hash range of series which is routed to the shard.
 type Replica struct {
-	Replicas []int `json:"replicas"`
+	Replicas []int      `json:"replicas"`
+	Range    *HashRange `json:"range,omitempty"`
 }
 
-// ShardAssignment defines shard assignment for database
+// ShardAssignment defines shard assignment for database,
+// epoch is the routing epoch which is increased when the hash ranges of shards are changed(e.g. split shard),
+// brokers switch routing atomically when receive the shard assignment with new epoch.
 type ShardAssignment struct {
 	Config DatabaseCluster `json:"cluster"`
 	Nodes  map[int]Node    `json:"nodes"`
 	Shards map[int]Replica `json:"shards"`
+	Epoch  int64           `json:"epoch"`
 }
 
 // NewShardAssignment returns empty shard assignment instance
@@ -36,6 +192,27 @@ func NewShardAssignment() *ShardAssignment {
 	}
 }
 
+// ResolveNodes updates the addresses of assigned nodes by the active nodes with the same key,
+// so that the assignment survives the address changes of nodes with persistent id, returns if any node is changed.
+func (s *ShardAssignment) ResolveNodes(activeNodes []Node) bool {
+	actives := make(map[string]Node, len(activeNodes))
+	for _, node := range activeNodes {
+		actives[node.Key()] = node
+	}
+	changed := false
+	for idx, node := range s.Nodes {
+		active, ok := actives[node.Key()]
+		if !ok || (active.IP == node.IP && active.Port == node.Port) {
+			continue
+		}
+		node.IP = active.IP
+		node.Port = active.Port
+		s.Nodes[idx] = node
+		changed = true
+	}
+	return changed
+}
+
 // AddReplica adds replica id to replica list of spec shard
 func (s *ShardAssignment) AddReplica(shardID int, replicaID int) {
 	replica := s.Shards[shardID]
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"sort"
+)
+
+// PointBytes is the bytes of a raw point(timestamp and value), which estimates the bytes scanned by query
+const PointBytes = 16
+
+// QueryLatencyBuckets are the upper bounds(ms) of the histogram of query latency,
+// the latency exceeding the last bound is counted in the overflow bucket.
+var QueryLatencyBuckets = []int64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000}
+
+// DatabaseStats represents the statistics of database's writes and queries since the node started,
+// which is recorded by each node, then aggregated by master for capacity planning and chargeback.
+type DatabaseStats struct {
+	Database      string `json:"database"`
+	WrittenPoints int64  `json:"writtenPoints"`
+	WrittenBytes  int64  `json:"writtenBytes"`
+	FailedPoints  int64  `json:"failedPoints"`
+	Queries       int64  `json:"queries"`
+	// ScanBytes is the bytes of raw points scanned by queries
+	ScanBytes int64 `json:"scanBytes"`
+	// P99Latency is the p99 latency(ms) of queries, which is estimated by the latency histogram
+	P99Latency int64 `json:"p99Latency"`
+	// QueryLatency is the histogram of query latency by QueryLatencyBuckets, the last one is the overflow bucket
+	QueryLatency []int64 `json:"queryLatency,omitempty"`
+}
+
+// ObserveLatency counts the latency(ms) of query into the latency histogram
+func (s *DatabaseStats) ObserveLatency(latency int64) {
+	if len(s.QueryLatency) != len(QueryLatencyBuckets)+1 {
+		s.QueryLatency = make([]int64, len(QueryLatencyBuckets)+1)
+	}
+	idx := sort.Search(len(QueryLatencyBuckets), func(i int) bool {
+		return latency <= QueryLatencyBuckets[i]
+	})
+	s.QueryLatency[idx]++
+	s.P99Latency = s.Percentile(0.99)
+}
+
+// Merge adds the statistics of other into this one, the p99 latency is estimated by the merged histogram
+func (s *DatabaseStats) Merge(other *DatabaseStats) {
+	s.WrittenPoints += other.WrittenPoints
+	s.WrittenBytes += other.WrittenBytes
+	s.FailedPoints += other.FailedPoints
+	s.Queries += other.Queries
+	s.ScanBytes += other.ScanBytes
+	if len(other.QueryLatency) != len(QueryLatencyBuckets)+1 {
+		return
+	}
+	if len(s.QueryLatency) != len(QueryLatencyBuckets)+1 {
+		s.QueryLatency = make([]int64, len(QueryLatencyBuckets)+1)
+	}
+	for idx, count := range other.QueryLatency {
+		s.QueryLatency[idx] += count
+	}
+	s.P99Latency = s.Percentile(0.99)
+}
+
+// Percentile returns the upper bound(ms) of the bucket which the percentile of query latency falls in,
+// returns the last bound if the percentile falls in the overflow bucket, returns 0 if no query.
+func (s *DatabaseStats) Percentile(percentile float64) int64 {
+	var total int64
+	for _, count := range s.QueryLatency {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := int64(float64(total)*percentile + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	var cumulative int64
+	for idx, count := range s.QueryLatency {
+		cumulative += count
+		if cumulative >= rank && idx < len(QueryLatencyBuckets) {
+			return QueryLatencyBuckets[idx]
+		}
+	}
+	return QueryLatencyBuckets[len(QueryLatencyBuckets)-1]
+}
+
+// NodeStats represents the database statistics of node, which is reported into state repository periodically
+type NodeStats struct {
+	Node      string          `json:"node"`
+	Timestamp int64           `json:"timestamp"`
+	Databases []DatabaseStats `json:"databases"`
+	// Shards are the statistics of shards reported by storage node, which are used for splitting hot shards
+	Shards []ShardStat `json:"shards,omitempty"`
+}
+
+// ClusterStats represents the database statistics aggregated from the nodes of broker and storage clusters
+type ClusterStats struct {
+	// Timestamp is the time(ms) of aggregation
+	Timestamp int64 `json:"timestamp"`
+	// Nodes is the number of nodes aggregated
+	Nodes     int             `json:"nodes"`
+	Databases []DatabaseStats `json:"databases"`
+}
+
+// AggregateDatabaseStats merges the database statistics of nodes by database, the result is sorted by database
+func AggregateDatabaseStats(nodes []NodeStats) []DatabaseStats {
+	merged := make(map[string]*DatabaseStats)
+	for _, node := range nodes {
+		for idx := range node.Databases {
+			stats := &node.Databases[idx]
+			if result, ok := merged[stats.Database]; ok {
+				result.Merge(stats)
+				continue
+			}
+			result := &DatabaseStats{Database: stats.Database}
+			result.Merge(stats)
+			merged[stats.Database] = result
+		}
+	}
+	result := make([]DatabaseStats, 0, len(merged))
+	for _, stats := range merged {
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Database < result[j].Database
+	})
+	return result
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabaseStats_ObserveLatency(t *testing.T) {
+	stats := &DatabaseStats{Database: "db"}
+	assert.Equal(t, int64(0), stats.Percentile(0.99))
+	for i := 0; i < 98; i++ {
+		stats.ObserveLatency(3)
+	}
+	stats.ObserveLatency(40)
+	assert.Equal(t, int64(5), stats.P99Latency)
+	stats.ObserveLatency(200)
+	assert.Equal(t, int64(50), stats.P99Latency)
+	stats.ObserveLatency(100000)
+	stats.ObserveLatency(100000)
+	// the latency in overflow bucket is estimated by the last bound
+	assert.Equal(t, int64(30000), stats.P99Latency)
+	assert.Equal(t, int64(5), stats.Percentile(0.5))
+	assert.Len(t, stats.QueryLatency, len(QueryLatencyBuckets)+1)
+}
+
+func TestAggregateDatabaseStats(t *testing.T) {
+	stats1 := DatabaseStats{Database: "db1", WrittenPoints: 10, WrittenBytes: 100, FailedPoints: 1}
+	stats2 := DatabaseStats{Database: "db2", Queries: 2, ScanBytes: 32}
+	stats2.ObserveLatency(8)
+	stats2.ObserveLatency(8)
+	stats3 := DatabaseStats{Database: "db2", Queries: 1, ScanBytes: 16}
+	stats3.ObserveLatency(2000)
+	// the histogram of old version is ignored
+	stats4 := DatabaseStats{Database: "db1", WrittenPoints: 5, WrittenBytes: 50, QueryLatency: []int64{1}}
+
+	result := AggregateDatabaseStats([]NodeStats{
+		{Node: "node1", Databases: []DatabaseStats{stats2, stats1}},
+		{Node: "node2", Databases: []DatabaseStats{stats3, stats4}},
+	})
+	assert.Len(t, result, 2)
+	assert.Equal(t, DatabaseStats{Database: "db1", WrittenPoints: 15, WrittenBytes: 150, FailedPoints: 1}, result[0])
+	assert.Equal(t, "db2", result[1].Database)
+	assert.Equal(t, int64(3), result[1].Queries)
+	assert.Equal(t, int64(48), result[1].ScanBytes)
+	assert.Equal(t, int64(2500), result[1].P99Latency)
+	// the stats of nodes aren't changed
+	assert.Equal(t, int64(2), stats2.Queries)
+	assert.Equal(t, int64(10), stats2.P99Latency)
+
+	assert.Empty(t, AggregateDatabaseStats(nil))
+}
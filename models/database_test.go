@@ -0,0 +1,78 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteRules_Validate(t *testing.T) {
+	var rules *WriteRules
+	assert.Nil(t, rules.Validate())
+	rules = &WriteRules{
+		DropMetrics: []string{"debug_.*"},
+		DropTags:    []string{"pid"},
+		RenameTags:  map[string]string{"hostname": "host"},
+		AddTags:     map[string]string{"zone": "sh"},
+	}
+	assert.Nil(t, rules.Validate())
+
+	invalids := []*WriteRules{
+		{DropMetrics: []string{"debug_("}},
+		{DropTags: []string{""}},
+		{RenameTags: map[string]string{"hostname": ""}},
+		{AddTags: map[string]string{"zone": ""}},
+	}
+	for _, r := range invalids {
+		assert.NotNil(t, r.Validate())
+	}
+}
+
+func TestNamingPolicy_Validate(t *testing.T) {
+	var policy *NamingPolicy
+	assert.Nil(t, policy.Validate())
+	policy = &NamingPolicy{
+		MetricPattern: "[a-z0-9_.]+",
+		MaxDepth:      4,
+		RequiredTags:  []string{"app"},
+		Template:      "{app}.*",
+	}
+	assert.Nil(t, policy.Validate())
+
+	invalids := []*NamingPolicy{
+		{MetricPattern: "[a-z"},
+		{MaxDepth: -1},
+		{RequiredTags: []string{""}},
+		{Template: "{app}..*"},
+		{Template: "{}.cpu"},
+		{Template: "a.b.c", MaxDepth: 2},
+	}
+	for _, p := range invalids {
+		assert.NotNil(t, p.Validate())
+	}
+}
+
+func TestWriteAck_Validate(t *testing.T) {
+	assert.Nil(t, WriteAck("").Validate())
+	assert.Nil(t, WriteAckEnqueue.Validate())
+	assert.Nil(t, WriteAckDurable.Validate())
+	assert.NotNil(t, WriteAck("fsync").Validate())
+}
+
+func TestShardAssignment_ResolveNodes(t *testing.T) {
+	shardAssign := NewShardAssignment()
+	shardAssign.Nodes[1] = Node{IP: "127.0.0.1", Port: 2000}
+	shardAssign.Nodes[2] = Node{ID: "node-2", IP: "127.0.0.1", Port: 2001}
+	shardAssign.Nodes[3] = Node{ID: "node-3", IP: "127.0.0.1", Port: 2002}
+	node1, node2 := shardAssign.Nodes[1], shardAssign.Nodes[2]
+	assert.Equal(t, "127.0.0.1:2000", node1.Key())
+	assert.Equal(t, "node-2", node2.Key())
+
+	actives := []Node{{IP: "127.0.0.1", Port: 2000}, {ID: "node-2", IP: "127.0.0.2", Port: 2001}}
+	assert.True(t, shardAssign.ResolveNodes(actives))
+	assert.Equal(t, Node{IP: "127.0.0.1", Port: 2000}, shardAssign.Nodes[1])
+	assert.Equal(t, Node{ID: "node-2", IP: "127.0.0.2", Port: 2001}, shardAssign.Nodes[2])
+	// the inactive node is kept
+	assert.Equal(t, Node{ID: "node-3", IP: "127.0.0.1", Port: 2002}, shardAssign.Nodes[3])
+	assert.False(t, shardAssign.ResolveNodes(actives))
+}
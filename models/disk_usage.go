@@ -0,0 +1,96 @@
+package models
+
+import (
+	"sort"
+)
+
+// LevelDiskUsage represents the sst files of a level in kv family
+type LevelDiskUsage struct {
+	Level int   `json:"level"`
+	Files int   `json:"files"`
+	Bytes int64 `json:"bytes"`
+}
+
+// FamilyDiskUsage represents the disk usage of kv family by level, which is computed from the file metas of
+// current version instead of walking the filesystem, so the obsolete files waiting for deleting aren't counted.
+type FamilyDiskUsage struct {
+	// Name is the name of family, which is prefixed by the interval type and segment for data family,
+	// e.g. day/20191010/family
+	Name   string           `json:"name"`
+	Bytes  int64            `json:"bytes"`
+	Levels []LevelDiskUsage `json:"levels,omitempty"`
+	// Digest is the content digest(hex) of family's files, which is compared between replicas without full scans,
+	// empty if any file has no digest
+	Digest string `json:"digest,omitempty"`
+}
+
+// ShardDiskUsage represents the disk usage of shard by family
+type ShardDiskUsage struct {
+	ShardID  int               `json:"shardId"`
+	Bytes    int64             `json:"bytes"`
+	Families []FamilyDiskUsage `json:"families,omitempty"`
+}
+
+// DatabaseDiskUsage represents the disk usage of database in storage node, includes the metadata index and shards
+type DatabaseDiskUsage struct {
+	Database string `json:"database"`
+	// Node is the storage node which the database's data is stored in, set by broker
+	Node string `json:"node,omitempty"`
+	// Bytes is the total bytes of index and shards
+	Bytes      int64             `json:"bytes"`
+	IndexBytes int64             `json:"indexBytes"`
+	Index      []FamilyDiskUsage `json:"index,omitempty"`
+	Shards     []ShardDiskUsage  `json:"shards,omitempty"`
+}
+
+// DiskUsageRequest represents the request of disk usage, all databases are returned if database is empty
+type DiskUsageRequest struct {
+	Database string `json:"database,omitempty"`
+}
+
+// DiskUsageSummary represents the total disk usage of database in all storage nodes
+type DiskUsageSummary struct {
+	Database string `json:"database"`
+	Bytes    int64  `json:"bytes"`
+	// Nodes is the number of storage nodes which store the data of database
+	Nodes int `json:"nodes"`
+}
+
+// DiskUsageReport represents the disk usage of databases in storage clusters
+type DiskUsageReport struct {
+	// Databases are the total disk usage of databases, sorted by bytes in descending
+	Databases []DiskUsageSummary `json:"databases"`
+	// Details are the disk usage of databases in each storage node
+	Details []DatabaseDiskUsage `json:"details"`
+}
+
+// NewDiskUsageReport creates the report of disk usage, summarizes the disk usage of databases in storage nodes
+func NewDiskUsageReport(details []DatabaseDiskUsage) *DiskUsageReport {
+	summaries := make(map[string]*DiskUsageSummary)
+	for _, usage := range details {
+		summary, ok := summaries[usage.Database]
+		if !ok {
+			summary = &DiskUsageSummary{Database: usage.Database}
+			summaries[usage.Database] = summary
+		}
+		summary.Bytes += usage.Bytes
+		summary.Nodes++
+	}
+	report := &DiskUsageReport{
+		Databases: make([]DiskUsageSummary, 0, len(summaries)),
+		Details:   details,
+	}
+	for _, summary := range summaries {
+		report.Databases = append(report.Databases, *summary)
+	}
+	sort.Slice(report.Databases, func(i, j int) bool {
+		if report.Databases[i].Bytes != report.Databases[j].Bytes {
+			return report.Databases[i].Bytes > report.Databases[j].Bytes
+		}
+		return report.Databases[i].Database < report.Databases[j].Database
+	})
+	if report.Details == nil {
+		report.Details = []DatabaseDiskUsage{}
+	}
+	return report
+}
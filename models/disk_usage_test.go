@@ -0,0 +1,27 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDiskUsageReport(t *testing.T) {
+	report := NewDiskUsageReport(nil)
+	assert.Equal(t, &DiskUsageReport{Databases: []DiskUsageSummary{}, Details: []DatabaseDiskUsage{}}, report)
+
+	details := []DatabaseDiskUsage{
+		{Database: "db1", Node: "1.1.1.1:2000", Bytes: 10},
+		{Database: "db2", Node: "1.1.1.1:2000", Bytes: 100},
+		{Database: "db1", Node: "1.1.1.2:2000", Bytes: 20},
+		{Database: "db3", Node: "1.1.1.2:2000", Bytes: 30},
+	}
+	report = NewDiskUsageReport(details)
+	assert.Equal(t, details, report.Details)
+	// sorted by bytes in descending, then by database
+	assert.Equal(t, []DiskUsageSummary{
+		{Database: "db2", Bytes: 100, Nodes: 1},
+		{Database: "db1", Bytes: 30, Nodes: 2},
+		{Database: "db3", Bytes: 30, Nodes: 1},
+	}, report.Databases)
+}
@@ -1,17 +1,17 @@
 package models
 
 import (
-	"errors"
+	"github.com/eleme/lindb/pkg/errors"
 )
 
 // ErrTooManyTags is the error returned by memory-database when
 // writes exceed the max limit of tag identifiers.
-var ErrTooManyTags = errors.New("too many tags")
+var ErrTooManyTags = errors.ErrTooManyTags
 
 // ErrTooManyFields is the error returned by memory-database when
 // writes exceed the max limit of fields.
-var ErrTooManyFields = errors.New("too many fields")
+var ErrTooManyFields = errors.ErrTooManyFields
 
 // ErrWrongFieldType is the error returned by memory-database when
 // field-type of new point is different from the type before.
-var ErrWrongFieldType = errors.New("field type is wrong")
+var ErrWrongFieldType = errors.ErrWrongFieldType
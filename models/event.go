@@ -0,0 +1,76 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxEventDuration is the max duration(ms) of annotation event, the events overlapping a window are looked up
+// from the window's start time minus max duration, so that the long events are never scanned.
+const MaxEventDuration = int64(7 * 24 * time.Hour / time.Millisecond)
+
+// Event represents the annotation event(e.g. deploy, incident) with tags and time range(ms),
+// which is overlaid on dashboards, the event without end time is a point in time.
+type Event struct {
+	Title     string            `json:"title"`
+	Text      string            `json:"text,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	StartTime int64             `json:"startTime"`
+	EndTime   int64             `json:"endTime"`
+}
+
+// Validate checks if the event is valid, the end time is set to start time if not set
+func (e *Event) Validate() error {
+	if len(e.Title) == 0 {
+		return fmt.Errorf("title of event cannot be empty")
+	}
+	if e.StartTime <= 0 {
+		return fmt.Errorf("start time of event must be > 0")
+	}
+	if e.EndTime == 0 {
+		e.EndTime = e.StartTime
+	}
+	if e.EndTime < e.StartTime {
+		return fmt.Errorf("end time of event must be >= start time")
+	}
+	if e.EndTime-e.StartTime > MaxEventDuration {
+		return fmt.Errorf("duration of event must be <= %dms", MaxEventDuration)
+	}
+	return nil
+}
+
+// Overlaps checks if the time range of event overlaps [startTime, endTime]
+func (e *Event) Overlaps(startTime, endTime int64) bool {
+	return e.StartTime <= endTime && e.EndTime >= startTime
+}
+
+// MatchTags checks if the event has all the tags
+func (e *Event) MatchTags(tags map[string]string) bool {
+	for key, value := range tags {
+		if v, ok := e.Tags[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// EventWriteRequest represents the request of writing annotation events into database
+type EventWriteRequest struct {
+	Database string  `json:"database"`
+	Events   []Event `json:"events"`
+}
+
+// EventQuery represents the query of the annotation events which overlap [startTime, endTime] and
+// have all the tags, the events are sorted by start time.
+type EventQuery struct {
+	Database  string            `json:"database"`
+	StartTime int64             `json:"startTime"`
+	EndTime   int64             `json:"endTime"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	Limit     int               `json:"limit,omitempty"`
+}
+
+// EventResult represents the annotation events of event query
+type EventResult struct {
+	Events []Event `json:"events"`
+}
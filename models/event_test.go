@@ -0,0 +1,35 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvent_Validate(t *testing.T) {
+	assert.NotNil(t, (&Event{StartTime: 100}).Validate())
+	assert.NotNil(t, (&Event{Title: "deploy"}).Validate())
+	assert.NotNil(t, (&Event{Title: "deploy", StartTime: 100, EndTime: 50}).Validate())
+	assert.NotNil(t, (&Event{Title: "deploy", StartTime: 100, EndTime: 101 + MaxEventDuration}).Validate())
+
+	event := &Event{Title: "deploy", StartTime: 100}
+	assert.Nil(t, event.Validate())
+	assert.Equal(t, int64(100), event.EndTime)
+}
+
+func TestEvent_Overlaps(t *testing.T) {
+	event := &Event{Title: "incident", StartTime: 100, EndTime: 200}
+	assert.True(t, event.Overlaps(50, 100))
+	assert.True(t, event.Overlaps(150, 160))
+	assert.True(t, event.Overlaps(200, 300))
+	assert.False(t, event.Overlaps(0, 99))
+	assert.False(t, event.Overlaps(201, 300))
+}
+
+func TestEvent_MatchTags(t *testing.T) {
+	event := &Event{Title: "deploy", Tags: map[string]string{"app": "lindb", "env": "prod"}}
+	assert.True(t, event.MatchTags(nil))
+	assert.True(t, event.MatchTags(map[string]string{"app": "lindb"}))
+	assert.False(t, event.MatchTags(map[string]string{"app": "other"}))
+	assert.False(t, event.MatchTags(map[string]string{"zone": "sh"}))
+}
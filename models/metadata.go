@@ -0,0 +1,46 @@
+package models
+
+// SuggestType represents the kind of metric metadata for suggesting
+type SuggestType int
+
+// Defines all suggest types of metric metadata
+const (
+	// SuggestMetricNames suggests metric names by prefix
+	SuggestMetricNames SuggestType = iota + 1
+	// SuggestTagKeys suggests tag keys of metric
+	SuggestTagKeys
+	// SuggestTagValues suggests tag values of metric's tag key by prefix
+	SuggestTagValues
+)
+
+// SuggestRequest represents the request of suggesting metric metadata,
+// offset only works for metric names, tag keys/values are limited by limit.
+type SuggestRequest struct {
+	Database   string      `json:"database"`
+	Type       SuggestType `json:"type"`
+	MetricName string      `json:"metricName,omitempty"`
+	TagKey     string      `json:"tagKey,omitempty"`
+	Prefix     string      `json:"prefix,omitempty"`
+	Offset     int         `json:"offset,omitempty"`
+	Limit      int         `json:"limit"`
+}
+
+// SuggestResult represents the result of suggesting metric metadata
+type SuggestResult struct {
+	Values []string `json:"values"`
+}
+
+// CardinalityRequest represents the request of estimating the num. of series under the metric
+// and the num. of values of the metric's tag keys, all tag keys are estimated if tag keys not set.
+type CardinalityRequest struct {
+	Database   string   `json:"database"`
+	MetricName string   `json:"metricName"`
+	TagKeys    []string `json:"tagKeys,omitempty"`
+}
+
+// CardinalityResult represents the estimated num. of series and tag values of the metric
+type CardinalityResult struct {
+	MetricName string            `json:"metricName"`
+	Series     uint64            `json:"series"`
+	TagValues  map[string]uint64 `json:"tagValues"`
+}
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"encoding/json"
+
+	"github.com/eleme/lindb/pkg/field"
+)
+
+// metricPoint implements Point, which is built by the write protocols of broker(line protocol etc.)
+type metricPoint struct {
+	name      string
+	timestamp int64
+	tags      map[string]string
+	fields    map[string]Field
+}
+
+// NewPoint creates the point with metric name, timestamp(ms), tags and fields
+func NewPoint(name string, timestamp int64, tags map[string]string, fields map[string]Field) Point {
+	return &metricPoint{
+		name:      name,
+		timestamp: timestamp,
+		tags:      tags,
+		fields:    fields,
+	}
+}
+
+// Name returns the metric name
+func (p *metricPoint) Name() string {
+	return p.name
+}
+
+// Timestamp returns the timestamp(ms) of point
+func (p *metricPoint) Timestamp() int64 {
+	return p.timestamp
+}
+
+// Tags returns the tags string which is same as the tags key of index, the keys are sorted
+func (p *metricPoint) Tags() string {
+	b, _ := json.Marshal(p.tags)
+	return string(b)
+}
+
+// Fields returns the fields of point
+func (p *metricPoint) Fields() map[string]Field {
+	return p.fields
+}
+
+// TagsMap returns the tags of point
+func (p *metricPoint) TagsMap() map[string]string {
+	return p.tags
+}
+
+// simpleField implements SimpleField
+type simpleField struct {
+	fieldType field.Type
+	valueType field.ValueType
+	value     interface{}
+}
+
+// NewSimpleField creates the simple field of sum/min/max/summary field type,
+// the value is int64 for integer value type, float64 for float value type.
+func NewSimpleField(fieldType field.Type, valueType field.ValueType, value interface{}) SimpleField {
+	return &simpleField{
+		fieldType: fieldType,
+		valueType: valueType,
+		value:     value,
+	}
+}
+
+// Type returns the field type
+func (f *simpleField) Type() field.Type {
+	return f.fieldType
+}
+
+// IsComplex returns false, simple field has only one value
+func (f *simpleField) IsComplex() bool {
+	return false
+}
+
+// ValueType returns the value type
+func (f *simpleField) ValueType() field.ValueType {
+	return f.valueType
+}
+
+// AggType returns the aggregator type by field type
+func (f *simpleField) AggType() field.AggType {
+	return f.fieldType.AggType()
+}
+
+// Value returns the value of field
+func (f *simpleField) Value() interface{} {
+	return f.value
+}
+
+// ExpandSummaryField expands the raw sample of summary field into the min/max/sum/count simple fields
+// named with the summary suffixes, the count of each sample is 1.
+func ExpandSummaryField(fieldName string, f SimpleField) map[string]Field {
+	return map[string]Field{
+		fieldName + field.SummaryMinSuffix:   NewSimpleField(field.MinField, f.ValueType(), f.Value()),
+		fieldName + field.SummaryMaxSuffix:   NewSimpleField(field.MaxField, f.ValueType(), f.Value()),
+		fieldName + field.SummarySumSuffix:   NewSimpleField(field.SumField, f.ValueType(), f.Value()),
+		fieldName + field.SummaryCountSuffix: NewSimpleField(field.SumField, field.Integer, int64(1)),
+	}
+}
+
+// stringField implements StringField
+type stringField struct {
+	value string
+}
+
+// NewStringField creates the string field
+func NewStringField(value string) StringField {
+	return &stringField{value: value}
+}
+
+// Type returns the string field type
+func (f *stringField) Type() field.Type {
+	return field.StringField
+}
+
+// IsComplex returns false, string field has only one value
+func (f *stringField) IsComplex() bool {
+	return false
+}
+
+// Value returns the string value
+func (f *stringField) Value() string {
+	return f.value
+}
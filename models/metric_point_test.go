@@ -0,0 +1,49 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/pkg/field"
+)
+
+func TestNewPoint(t *testing.T) {
+	fields := map[string]Field{
+		"count": NewSimpleField(field.SumField, field.Integer, int64(10)),
+	}
+	point := NewPoint("cpu", 1000, map[string]string{"ip": "1.1.1.1", "host": "alpha"}, fields)
+	assert.Equal(t, "cpu", point.Name())
+	assert.Equal(t, int64(1000), point.Timestamp())
+	assert.Equal(t, `{"host":"alpha","ip":"1.1.1.1"}`, point.Tags())
+	assert.Equal(t, map[string]string{"ip": "1.1.1.1", "host": "alpha"}, point.TagsMap())
+	assert.Equal(t, fields, point.Fields())
+}
+
+func TestNewSimpleField(t *testing.T) {
+	f := NewSimpleField(field.SumField, field.Float, 1.5)
+	assert.Equal(t, field.SumField, f.Type())
+	assert.False(t, f.IsComplex())
+	assert.Equal(t, field.Float, f.ValueType())
+	assert.Equal(t, field.Sum, f.AggType())
+	assert.Equal(t, 1.5, f.Value())
+	assert.Equal(t, field.Min, NewSimpleField(field.MinField, field.Float, 1.5).AggType())
+	assert.Equal(t, field.Max, NewSimpleField(field.MaxField, field.Float, 1.5).AggType())
+}
+
+func TestNewStringField(t *testing.T) {
+	f := NewStringField("v1.0.0")
+	assert.Equal(t, field.StringField, f.Type())
+	assert.False(t, f.IsComplex())
+	assert.Equal(t, "v1.0.0", f.Value())
+}
+
+func TestExpandSummaryField(t *testing.T) {
+	fields := ExpandSummaryField("latency", NewSimpleField(field.SummaryField, field.Integer, int64(20)))
+	assert.Equal(t, map[string]Field{
+		"latency_min":   NewSimpleField(field.MinField, field.Integer, int64(20)),
+		"latency_max":   NewSimpleField(field.MaxField, field.Integer, int64(20)),
+		"latency_sum":   NewSimpleField(field.SumField, field.Integer, int64(20)),
+		"latency_count": NewSimpleField(field.SumField, field.Integer, int64(1)),
+	}, fields)
+}
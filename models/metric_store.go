@@ -0,0 +1,41 @@
+package models
+
+// MetricStoreAction represents the administrative action on the metric store in memory-database of shard
+type MetricStoreAction string
+
+// Defines all actions on the metric store
+const (
+	// ResetMetricStore moves the series of metric store into immutable part which is flushed later,
+	// so that the new series can be written after the limit of series is exceeded
+	ResetMetricStore MetricStoreAction = "reset"
+	// DropMetricStore removes the metric store from memory-database, the data not flushed yet is discarded
+	DropMetricStore MetricStoreAction = "drop"
+)
+
+// MetricStoreRequest represents the request of resetting/dropping the metric store in memory-database,
+// which is used for recovering from a cardinality incident without restarting storage nodes.
+// All shards of database are operated if shard ids not set.
+type MetricStoreRequest struct {
+	Database   string            `json:"database"`
+	MetricName string            `json:"metricName"`
+	Action     MetricStoreAction `json:"action"`
+	ShardIDs   []int             `json:"shardIDs,omitempty"`
+}
+
+// MetricStoreResult represents the result of operating the metric store of a shard replica in storage node,
+// error is empty if done successfully.
+type MetricStoreResult struct {
+	Node    string `json:"node,omitempty"`
+	ShardID int    `json:"shardID"`
+	Error   string `json:"error,omitempty"`
+}
+
+// DatabaseLimits represents the runtime limits of database's memory-database in storage nodes,
+// which is stored in state repository of storage clusters and watched by storage nodes,
+// so that the limits can be adjusted cluster-wide without restarting storage nodes.
+type DatabaseLimits struct {
+	Database string `json:"database"`
+	// MaxTagsLimits is the max count of tags combinations(series) of metrics,
+	// key: metric name, value: max-limit, the metric not in it uses the default limit
+	MaxTagsLimits map[string]uint32 `json:"maxTagsLimits,omitempty"`
+}
@@ -4,10 +4,14 @@ import (
 	"fmt"
 )
 
-// Node represents the basic info of server
+// Node represents the basic info of server,
+// labels describe the topology/hardware of node(e.g. rack/zone/disk), used by shard placement.
+// ID is the persistent id of storage node, which doesn't change when the ip/port of node changes(e.g. pod restart).
 type Node struct {
-	IP   string `json:"ip"`
-	Port uint16 `json:"port"`
+	ID     string            `json:"id,omitempty"`
+	IP     string            `json:"ip"`
+	Port   uint16            `json:"port"`
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 // String returns node info string
@@ -15,6 +19,15 @@ func (n *Node) String() string {
 	return fmt.Sprintf("%s:%d", n.IP, n.Port)
 }
 
+// Key returns the identity of node used for registration and task dispatching,
+// which is the persistent id if exists, otherwise the address for the node without id.
+func (n *Node) Key() string {
+	if len(n.ID) > 0 {
+		return n.ID
+	}
+	return n.String()
+}
+
 // Master represents master basic info
 type Master struct {
 	Node      Node  `json:"node"`
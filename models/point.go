@@ -21,6 +21,12 @@ type Field interface {
 	IsComplex() bool
 }
 
+// StringField is the field of string value, only the last value of series is kept
+type StringField interface {
+	Field
+	Value() string
+}
+
 // SimpleField is simple field for single value
 type SimpleField interface {
 	Field
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/eleme/lindb/pkg/interval"
+)
 
 // Query represents search condition
 type Query interface {
@@ -22,3 +26,99 @@ type TagFilter struct {
 	TagName  string
 	TagValue string
 }
+
+// QueryRequest represents the structured query DSL which is used by http query api
+type QueryRequest struct {
+	Database string `json:"database"`
+	// Metric is the default metric of the field which isn't prefixed by metric name in select expression
+	Metric string `json:"metric,omitempty"`
+	// From are the metrics qualified by database for the query across databases, like FROM db1.cpu, db2.cpu,
+	// the query is planned in each database with the metric as default metric, then the series are unioned,
+	// the database and metric of request aren't used if it's set.
+	From []QueryMetric `json:"from,omitempty"`
+	// Fields are the select expressions with alias, like {"alias":"ratio", "expr":"errors:count/requests:count"}
+	Fields []QueryField `json:"fields"`
+	// Start/End are the time range of query(ms)
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	// Interval is the time interval of result(ms)
+	Interval int64         `json:"interval"`
+	GroupBy  []string      `json:"groupBy,omitempty"`
+	Fill     string        `json:"fill,omitempty"`
+	OrderBy  *QueryOrderBy `json:"orderBy,omitempty"`
+	Offset   int           `json:"offset,omitempty"`
+	Limit    int           `json:"limit,omitempty"`
+	// MinSequence is the sequence token returned by write(see SequenceToken), the query waits until
+	// the writes of token are visible in shards, so that the client reads its own writes
+	MinSequence string `json:"minSequence,omitempty"`
+	// PageSize is the max number of series in one page of result, zero means no pagination,
+	// the continue token of result set is carried by the query of next page
+	PageSize int    `json:"pageSize,omitempty"`
+	Continue string `json:"continue,omitempty"`
+}
+
+// Sources returns the metrics qualified by database which are queried by the request,
+// which are the from clause if set, otherwise the database and metric of request
+func (r *QueryRequest) Sources() []QueryMetric {
+	if len(r.From) == 0 {
+		return []QueryMetric{{Database: r.Database, Metric: r.Metric}}
+	}
+	return r.From
+}
+
+// Databases returns the distinct databases queried by the request
+func (r *QueryRequest) Databases() []string {
+	var databases []string
+	seen := make(map[string]struct{})
+	for _, from := range r.Sources() {
+		if _, ok := seen[from.Database]; ok {
+			continue
+		}
+		seen[from.Database] = struct{}{}
+		databases = append(databases, from.Database)
+	}
+	return databases
+}
+
+// QueryMetric represents the metric qualified by database in the from clause of query
+type QueryMetric struct {
+	Database string `json:"database"`
+	Metric   string `json:"metric"`
+}
+
+// QueryField represents the select expression with alias
+type QueryField struct {
+	Alias string `json:"alias"`
+	Expr  string `json:"expr"`
+}
+
+// QueryOrderBy represents ordering series by the reduced value(sum/avg/max/min/last) of field
+type QueryOrderBy struct {
+	Field string `json:"field"`
+	Func  string `json:"func"`
+	Desc  bool   `json:"desc,omitempty"`
+}
+
+// StorageQueryRequest represents the sub query of one metric which is sent by broker to storage node,
+// the storage node scans the requested shards which it owns, then returns the partial aggregation result
+// grouped by the tag values of group by, the requested shards not owned by the node are reported missing.
+type StorageQueryRequest struct {
+	Database   string   `json:"database"`
+	ShardIDs   []int    `json:"shardIDs"`
+	MetricName string   `json:"metricName"`
+	Fields     []string `json:"fields"`
+	GroupBy    []string `json:"groupBy,omitempty"`
+	// Start/End are the time range of query(ms), the start time is aligned by interval
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	// Interval is the time interval of result(ms)
+	Interval int64 `json:"interval"`
+	// IntervalType selects the segments of rollup interval, the segments of day interval are queried if not set
+	IntervalType interval.Type `json:"intervalType,omitempty"`
+	// OrderBy/Limit are the top n stage pushed down by broker, the storage node only returns the top limit groups,
+	// the groups are ordered by tag values if order by isn't set
+	OrderBy *QueryOrderBy `json:"orderBy,omitempty"`
+	Limit   int           `json:"limit,omitempty"`
+	// MinSequences are the sequences of shards which the storage node waits for before scanning(read-your-writes)
+	MinSequences SequenceToken `json:"minSequences,omitempty"`
+}
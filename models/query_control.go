@@ -0,0 +1,30 @@
+package models
+
+// RunningQuery represents the query executing in broker, which can be killed by id
+type RunningQuery struct {
+	ID       string        `json:"id"`
+	Database string        `json:"database"`
+	Query    *QueryRequest `json:"query"`
+	// StartTime is the time(ms) when the query starts
+	StartTime int64 `json:"startTime"`
+	// Elapsed is the elapsed time(ms) of the query
+	Elapsed int64 `json:"elapsed"`
+	// ScannedBytes is the bytes of raw points scanned by the sub queries fetched so far
+	ScannedBytes int64 `json:"scannedBytes"`
+}
+
+// QueryBlacklistRule represents the rule rejecting known-pathological queries before executing,
+// the query is rejected if it matches all non-empty conditions of the rule.
+type QueryBlacklistRule struct {
+	Name string `json:"name"`
+	// Database is the database of query, empty means all databases
+	Database string `json:"database,omitempty"`
+	// Metric is the regular expression of the default metric of query
+	Metric string `json:"metric,omitempty"`
+	// Expr is the regular expression of the select expressions, the query matches if any expression matches
+	Expr string `json:"expr,omitempty"`
+	// GroupBy is the tag key which the query groups by
+	GroupBy string `json:"groupBy,omitempty"`
+	// Reason tells the client why the query is rejected
+	Reason string `json:"reason,omitempty"`
+}
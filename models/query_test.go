@@ -0,0 +1,24 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryRequest_Sources(t *testing.T) {
+	req := &QueryRequest{Database: "db", Metric: "cpu"}
+	assert.Equal(t, []QueryMetric{{Database: "db", Metric: "cpu"}}, req.Sources())
+	assert.Equal(t, []string{"db"}, req.Databases())
+
+	req.From = []QueryMetric{{Database: "db1", Metric: "cpu"}, {Database: "db2", Metric: "cpu"},
+		{Database: "db1", Metric: "mem"}}
+	assert.Equal(t, req.From, req.Sources())
+	assert.Equal(t, []string{"db1", "db2"}, req.Databases())
+}
+
+func TestUserFromContext(t *testing.T) {
+	assert.Equal(t, "", UserFromContext(context.TODO()))
+	assert.Equal(t, "admin", UserFromContext(WithUser(context.TODO(), "admin")))
+}
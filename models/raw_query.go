@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/pkg/interval"
+)
+
+// Defines the guards of raw query, because the raw points aren't aggregated or down sampled
+const (
+	// MaxRawQueryTimeRange is the max time range of raw query
+	MaxRawQueryTimeRange = int64(time.Hour / time.Millisecond)
+	// DefaultRawQuerySeries is the max series of raw query if not set
+	DefaultRawQuerySeries = 100
+	// MaxRawQuerySeries is the upper bound of the max series of raw query
+	MaxRawQuerySeries = 1000
+	// MaxRawQueryPoints is the max points of raw query result, the result is truncated if exceeded
+	MaxRawQueryPoints = 100000
+)
+
+// RawQueryRequest represents the query of raw points which are stored in memory-database of shards,
+// the points are returned per series and shard replica without aggregation or down sampling,
+// which is used for debugging ingestion correctness and exporting.
+// All shards of database are queried if shard ids not set.
+type RawQueryRequest struct {
+	Database   string   `json:"database"`
+	MetricName string   `json:"metricName"`
+	Fields     []string `json:"fields"`
+	// Start/End are the time range of query(ms), which cannot exceed MaxRawQueryTimeRange
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	// MaxSeries is the max series of result, DefaultRawQuerySeries if not set
+	MaxSeries int   `json:"maxSeries,omitempty"`
+	ShardIDs  []int `json:"shardIDs,omitempty"`
+	// IntervalType selects the memory-database of rollup interval, the interval of shard is queried if not set
+	IntervalType interval.Type `json:"intervalType,omitempty"`
+	// StringFields are the string fields whose last values are attached to the series as dimensions,
+	// so that the series can be grouped by them, like the build version of service
+	StringFields []string `json:"stringFields,omitempty"`
+	// Merge merges the series of all replicas of shard into one series by broker, the duplicate points
+	// of replicas are last-write-wins by the sequence of replica, instead of returning the points per replica
+	Merge bool `json:"merge,omitempty"`
+}
+
+// RawQueryResult represents the raw points of series, truncated is true if the series or points exceed the limits.
+type RawQueryResult struct {
+	Series    []RawSeries `json:"series"`
+	Truncated bool        `json:"truncated,omitempty"`
+}
+
+// PointCount returns the count of points of all series
+func (r *RawQueryResult) PointCount() int {
+	count := 0
+	for _, series := range r.Series {
+		count += series.PointCount()
+	}
+	return count
+}
+
+// RawSeries represents the raw points of fields of a series in shard replica
+type RawSeries struct {
+	Node    string                `json:"node,omitempty"`
+	ShardID int                   `json:"shardID"`
+	Tags    map[string]string     `json:"tags,omitempty"`
+	Fields  map[string][]RawPoint `json:"fields"`
+	// FieldTypes are the types of the fields, by which the duplicate points of replicas are merged
+	FieldTypes map[string]field.Type `json:"fieldTypes,omitempty"`
+	// Strings are the last values of the string fields of request
+	Strings map[string]string `json:"strings,omitempty"`
+	// Sequence is the sequence of latest write of shard replica which is visible when queried
+	Sequence int64 `json:"sequence,omitempty"`
+}
+
+// PointCount returns the count of points of all fields
+func (s *RawSeries) PointCount() int {
+	count := 0
+	for _, points := range s.Fields {
+		count += len(points)
+	}
+	return count
+}
+
+// RawPoint represents the stored value of a time slot
+type RawPoint struct {
+	Timestamp int64 `json:"timestamp"`
+	Value     int64 `json:"value"`
+}
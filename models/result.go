@@ -0,0 +1,161 @@
+package models
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+)
+
+// ValueType represents the value type of field column in result set
+type ValueType string
+
+// Defines all value types of field column
+const (
+	// IntegerValue represents the values of column are integers, which are stored as float in series
+	IntegerValue ValueType = "integer"
+	// FloatValue represents the values of column are floats
+	FloatValue ValueType = "float"
+)
+
+// Column represents the typed column of field in result set, the values of column are stored in the fields
+// of series by column name.
+type Column struct {
+	Name string    `json:"name"`
+	Type ValueType `json:"type"`
+}
+
+// ResultStats represents the statistics of query execution on storage nodes
+type ResultStats struct {
+	ScannedSeries int64 `json:"scannedSeries"`
+	ScannedPoints int64 `json:"scannedPoints"`
+}
+
+// ResultSet represents the query result which is shared by storage responses, broker merging,
+// http serialization and the client, all series share the same time slots,
+// the timestamp of time slot i is StartTime + i*Interval.
+type ResultSet struct {
+	StartTime  int64     `json:"startTime"`
+	Interval   int64     `json:"interval"`
+	PointCount int       `json:"pointCount"`
+	Series     []*Series `json:"series"`
+	// Columns are the typed columns of fields in series, sorted by column name
+	Columns []Column `json:"columns,omitempty"`
+	// Stats is the statistics of query execution, nil if not collected
+	Stats *ResultStats `json:"stats,omitempty"`
+	// Partial is true if the result set is truncated because the query exceeds the limits of storage node
+	Partial bool `json:"partial,omitempty"`
+	// MissingShards are the shards which aren't queried, e.g. the storage node of shard is down
+	MissingShards []int `json:"missingShards,omitempty"`
+	// Continue is the token for querying the next page of series, empty if it's the last page
+	Continue string `json:"continue,omitempty"`
+}
+
+// Timestamps returns the timestamps of time slots
+func (rs *ResultSet) Timestamps() []int64 {
+	timestamps := make([]int64, rs.PointCount)
+	for i := range timestamps {
+		timestamps[i] = rs.StartTime + int64(i)*rs.Interval
+	}
+	return timestamps
+}
+
+// AddColumn adds the typed column of field, keeps the columns sorted by name,
+// the column is float if the value types of same column are different.
+func (rs *ResultSet) AddColumn(name string, valueType ValueType) {
+	idx := sort.Search(len(rs.Columns), func(i int) bool {
+		return rs.Columns[i].Name >= name
+	})
+	if idx < len(rs.Columns) && rs.Columns[idx].Name == name {
+		if rs.Columns[idx].Type != valueType {
+			rs.Columns[idx].Type = FloatValue
+		}
+		return
+	}
+	rs.Columns = append(rs.Columns, Column{})
+	copy(rs.Columns[idx+1:], rs.Columns[idx:])
+	rs.Columns[idx] = Column{Name: name, Type: valueType}
+}
+
+// ColumnType returns the value type of column, returns float if the column not exist
+func (rs *ResultSet) ColumnType(name string) ValueType {
+	idx := sort.Search(len(rs.Columns), func(i int) bool {
+		return rs.Columns[i].Name >= name
+	})
+	if idx < len(rs.Columns) && rs.Columns[idx].Name == name {
+		return rs.Columns[idx].Type
+	}
+	return FloatValue
+}
+
+// MergeMetadata merges the metadata(stats, partial flags) of other result set into this one,
+// the columns and series aren't merged.
+func (rs *ResultSet) MergeMetadata(other *ResultSet) {
+	if other == nil {
+		return
+	}
+	rs.Partial = rs.Partial || other.Partial
+	if other.Stats != nil {
+		if rs.Stats == nil {
+			rs.Stats = &ResultStats{}
+		}
+		rs.Stats.ScannedSeries += other.Stats.ScannedSeries
+		rs.Stats.ScannedPoints += other.Stats.ScannedPoints
+	}
+	for _, shardID := range other.MissingShards {
+		idx := sort.SearchInts(rs.MissingShards, shardID)
+		if idx < len(rs.MissingShards) && rs.MissingShards[idx] == shardID {
+			continue
+		}
+		rs.MissingShards = append(rs.MissingShards, 0)
+		copy(rs.MissingShards[idx+1:], rs.MissingShards[idx:])
+		rs.MissingShards[idx] = shardID
+	}
+}
+
+// Series represents a group of query result, the values of field are indexed by time slot,
+// NaN value means no data in the time slot.
+type Series struct {
+	// Metric is the metric name of series, empty if the fields are evaluated from multiple metrics
+	Metric string `json:"metric,omitempty"`
+	// Database is the database of series in the query across databases, empty for the query of one database
+	Database string               `json:"database,omitempty"`
+	Tags     map[string]string    `json:"tags,omitempty"`
+	Fields   map[string][]float64 `json:"fields"`
+}
+
+// NewSeries creates a series with given tags
+func NewSeries(tags map[string]string) *Series {
+	return &Series{
+		Tags:   tags,
+		Fields: make(map[string][]float64),
+	}
+}
+
+// UnmarshalJSON decodes the series, the null value which means no data in the time slot is decoded as NaN
+func (s *Series) UnmarshalJSON(data []byte) error {
+	decoded := struct {
+		Metric   string                `json:"metric"`
+		Database string                `json:"database"`
+		Tags     map[string]string     `json:"tags"`
+		Fields   map[string][]*float64 `json:"fields"`
+	}{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	s.Metric = decoded.Metric
+	s.Database = decoded.Database
+	s.Tags = decoded.Tags
+	s.Fields = make(map[string][]float64, len(decoded.Fields))
+	for field, values := range decoded.Fields {
+		fieldValues := make([]float64, len(values))
+		for i, value := range values {
+			if value == nil {
+				fieldValues[i] = math.NaN()
+			} else {
+				fieldValues[i] = *value
+			}
+		}
+		s.Fields[field] = fieldValues
+	}
+	return nil
+}
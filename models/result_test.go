@@ -0,0 +1,63 @@
+package models
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultSet_Timestamps(t *testing.T) {
+	rs := &ResultSet{StartTime: 1000, Interval: 10, PointCount: 3}
+	assert.Equal(t, []int64{1000, 1010, 1020}, rs.Timestamps())
+	assert.Empty(t, (&ResultSet{}).Timestamps())
+}
+
+func TestResultSet_AddColumn(t *testing.T) {
+	rs := &ResultSet{}
+	rs.AddColumn("min", FloatValue)
+	rs.AddColumn("count", IntegerValue)
+	rs.AddColumn("max", IntegerValue)
+	rs.AddColumn("count", IntegerValue)
+	// the value types of same column are different
+	rs.AddColumn("max", FloatValue)
+	assert.Equal(t, []Column{
+		{Name: "count", Type: IntegerValue},
+		{Name: "max", Type: FloatValue},
+		{Name: "min", Type: FloatValue},
+	}, rs.Columns)
+	assert.Equal(t, IntegerValue, rs.ColumnType("count"))
+	assert.Equal(t, FloatValue, rs.ColumnType("max"))
+	assert.Equal(t, FloatValue, rs.ColumnType("not_exist"))
+}
+
+func TestResultSet_MergeMetadata(t *testing.T) {
+	rs := &ResultSet{MissingShards: []int{2}}
+	rs.MergeMetadata(nil)
+	rs.MergeMetadata(&ResultSet{})
+	assert.False(t, rs.Partial)
+	assert.Nil(t, rs.Stats)
+
+	rs.MergeMetadata(&ResultSet{Partial: true, MissingShards: []int{3, 1, 2},
+		Stats: &ResultStats{ScannedSeries: 1, ScannedPoints: 10}})
+	rs.MergeMetadata(&ResultSet{Stats: &ResultStats{ScannedSeries: 2, ScannedPoints: 20}})
+	assert.True(t, rs.Partial)
+	assert.Equal(t, []int{1, 2, 3}, rs.MissingShards)
+	assert.Equal(t, &ResultStats{ScannedSeries: 3, ScannedPoints: 30}, rs.Stats)
+}
+
+func TestSeries_UnmarshalJSON(t *testing.T) {
+	rs := &ResultSet{}
+	err := json.Unmarshal([]byte(`{"startTime":1000,"interval":10,"pointCount":2,"partial":true,`+
+		`"series":[{"metric":"cpu","tags":{"host":"1.1.1.1"},"fields":{"f":[1,null]}}]}`), rs)
+	assert.Nil(t, err)
+	assert.True(t, rs.Partial)
+	assert.Len(t, rs.Series, 1)
+	assert.Equal(t, "cpu", rs.Series[0].Metric)
+	assert.Equal(t, map[string]string{"host": "1.1.1.1"}, rs.Series[0].Tags)
+	assert.Equal(t, 1.0, rs.Series[0].Fields["f"][0])
+	assert.True(t, math.IsNaN(rs.Series[0].Fields["f"][1]))
+
+	assert.NotNil(t, json.Unmarshal([]byte(`{"fields":{"f":["a"]}}`), &Series{}))
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SequenceToken represents the sequences of shards(shard id => sequence) after the writes applied,
+// which is returned by write for read-your-writes, the query carrying the token waits until the writes
+// of sequences are visible in shards. The format of token is like 1:100,2:20.
+type SequenceToken map[int]int64
+
+// String returns the string of token which is sorted by shard id, returns empty if no sequence
+func (t SequenceToken) String() string {
+	shardIDs := make([]int, 0, len(t))
+	for shardID := range t {
+		shardIDs = append(shardIDs, shardID)
+	}
+	sort.Ints(shardIDs)
+	pairs := make([]string, 0, len(shardIDs))
+	for _, shardID := range shardIDs {
+		pairs = append(pairs, fmt.Sprintf("%d:%d", shardID, t[shardID]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Merge merges the sequences of other token, keeps the max sequence of each shard
+func (t SequenceToken) Merge(other SequenceToken) {
+	for shardID, sequence := range other {
+		if sequence > t[shardID] {
+			t[shardID] = sequence
+		}
+	}
+}
+
+// ParseSequenceToken parses the sequence token like 1:100,2:20, returns nil if the token is empty
+func ParseSequenceToken(token string) (SequenceToken, error) {
+	if len(strings.TrimSpace(token)) == 0 {
+		return nil, nil
+	}
+	result := make(SequenceToken)
+	for _, pair := range strings.Split(token, ",") {
+		parts := strings.Split(strings.TrimSpace(pair), ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("sequence token[%s] must be like shardID:sequence,...", token)
+		}
+		shardID, err := strconv.Atoi(parts[0])
+		if err != nil || shardID < 0 {
+			return nil, fmt.Errorf("shard id of sequence token[%s] must be >= 0", token)
+		}
+		sequence, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || sequence < 0 {
+			return nil, fmt.Errorf("sequence of sequence token[%s] must be >= 0", token)
+		}
+		result.Merge(SequenceToken{shardID: sequence})
+	}
+	return result, nil
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSequenceToken(t *testing.T) {
+	token, err := ParseSequenceToken("")
+	assert.Nil(t, err)
+	assert.Nil(t, token)
+	assert.Equal(t, "", SequenceToken{}.String())
+
+	token, err = ParseSequenceToken("2:20, 1:100,2:10")
+	assert.Nil(t, err)
+	assert.Equal(t, SequenceToken{1: 100, 2: 20}, token)
+	assert.Equal(t, "1:100,2:20", token.String())
+
+	token.Merge(SequenceToken{1: 99, 3: 1})
+	assert.Equal(t, SequenceToken{1: 100, 2: 20, 3: 1}, token)
+
+	for _, invalid := range []string{"1", "a:1", "-1:1", "1:a", "1:-1", "1:2:3"} {
+		_, err = ParseSequenceToken(invalid)
+		assert.NotNil(t, err, invalid)
+	}
+}
@@ -0,0 +1,224 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/eleme/lindb/pkg/hashers"
+)
+
+// HashRange represents the range of series hash which is routed to the shard, includes start and end
+type HashRange struct {
+	Start uint32 `json:"start"`
+	End   uint32 `json:"end"`
+}
+
+// Contains checks if the hash is in the range
+func (r HashRange) Contains(hash uint32) bool {
+	return hash >= r.Start && hash <= r.End
+}
+
+// Split splits the range into two halves, returns error if the range cannot be split
+func (r HashRange) Split() (lower, upper HashRange, err error) {
+	if r.Start >= r.End {
+		return lower, upper, fmt.Errorf("hash range[%d,%d] cannot be split", r.Start, r.End)
+	}
+	mid := r.Start + (r.End-r.Start)/2
+	return HashRange{Start: r.Start, End: mid}, HashRange{Start: mid + 1, End: r.End}, nil
+}
+
+// SplitOption represents the thresholds of splitting shard, the shard is hot if exceeds any of them,
+// zero value means no limit.
+type SplitOption struct {
+	MaxSeries    int64 `json:"maxSeries,omitempty"`
+	MaxWriteRate int64 `json:"maxWriteRate,omitempty"` // points per second
+}
+
+// ShardStat represents the statistics of shard which is used for deciding to split shard
+type ShardStat struct {
+	Database    string `json:"database,omitempty"`
+	ShardID     int    `json:"shardID"`
+	NumOfSeries int64  `json:"numOfSeries"`
+	WriteRate   int64  `json:"writeRate"` // points per second
+	// WrittenPoints is the number of points written into shard since the node started
+	WrittenPoints int64 `json:"writtenPoints,omitempty"`
+}
+
+// NeedSplit checks if the shard exceeds the split thresholds
+func (o *SplitOption) NeedSplit(stat ShardStat) bool {
+	if o == nil {
+		return false
+	}
+	return (o.MaxSeries > 0 && stat.NumOfSeries > o.MaxSeries) ||
+		(o.MaxWriteRate > 0 && stat.WriteRate > o.MaxWriteRate)
+}
+
+// InitRanges divides the whole hash space into the shards evenly ordered by shard id,
+// if any shard has hash range, does nothing.
+func (s *ShardAssignment) InitRanges() {
+	shardIDs := s.shardIDs()
+	if len(shardIDs) == 0 {
+		return
+	}
+	for _, shardID := range shardIDs {
+		if s.Shards[shardID].Range != nil {
+			return
+		}
+	}
+	numOfShard := uint64(len(shardIDs))
+	space := uint64(math.MaxUint32) + 1
+	for idx, shardID := range shardIDs {
+		replica := s.Shards[shardID]
+		replica.Range = &HashRange{
+			Start: uint32(space * uint64(idx) / numOfShard),
+			End:   uint32(space*uint64(idx+1)/numOfShard - 1),
+		}
+		s.Shards[shardID] = replica
+	}
+}
+
+// Route returns the shard id whose hash range contains the hash of series
+func (s *ShardAssignment) Route(hash uint32) (int, error) {
+	for shardID, replica := range s.Shards {
+		if replica.Range != nil && replica.Range.Contains(hash) {
+			return shardID, nil
+		}
+	}
+	return 0, fmt.Errorf("shard not found for hash[%d]", hash)
+}
+
+// SeriesHash returns the hash of series for shard routing, which hashes on metric name and all tags by default.
+// If routing tags are set, only the values of routing tags are hashed with metric name, so that the related series
+// (e.g. same region) are kept on the same shard, and group by the routing tags is shard-local.
+func SeriesHash(metricName string, tags map[string]string, routingTags []string) uint32 {
+	var b strings.Builder
+	b.WriteString(metricName)
+	if len(routingTags) > 0 {
+		for _, tagKey := range routingTags {
+			b.WriteByte(',')
+			b.WriteString(tagKey)
+			b.WriteByte('=')
+			b.WriteString(tags[tagKey])
+		}
+		return hashers.Fnv32a(b.String())
+	}
+	tagKeys := make([]string, 0, len(tags))
+	for tagKey := range tags {
+		tagKeys = append(tagKeys, tagKey)
+	}
+	sort.Strings(tagKeys)
+	for _, tagKey := range tagKeys {
+		b.WriteByte(',')
+		b.WriteString(tagKey)
+		b.WriteByte('=')
+		b.WriteString(tags[tagKey])
+	}
+	return hashers.Fnv32a(b.String())
+}
+
+// RouteSeries returns the shard id of series by the routing tags of database cluster
+func (s *ShardAssignment) RouteSeries(metricName string, tags map[string]string) (int, error) {
+	return s.Route(SeriesHash(metricName, tags, s.Config.RoutingTags))
+}
+
+// IsShardLocal checks if the series of each group are on the same shard, which is true when
+// grouping by all the routing tags, then the broker doesn't need to merge the groups across shards.
+func (c *DatabaseCluster) IsShardLocal(groupBy []string) bool {
+	if len(c.RoutingTags) == 0 {
+		return false
+	}
+	for _, tagKey := range c.RoutingTags {
+		found := false
+		for _, groupByKey := range groupBy {
+			if groupByKey == tagKey {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateRoutingTags checks if the routing tags are not empty and not duplicated
+func (c *DatabaseCluster) ValidateRoutingTags() error {
+	tagKeys := make(map[string]struct{}, len(c.RoutingTags))
+	for _, tagKey := range c.RoutingTags {
+		if len(tagKey) == 0 {
+			return fmt.Errorf("routing tag key cannot be empty")
+		}
+		if _, ok := tagKeys[tagKey]; ok {
+			return fmt.Errorf("routing tag key[%s] is duplicated", tagKey)
+		}
+		tagKeys[tagKey] = struct{}{}
+	}
+	return nil
+}
+
+// SplitShard splits the hash range of shard into two halves, the shard keeps the lower half,
+// the new shard takes the upper half and is placed on the same replicas, so that the series of
+// upper half are migrated within the storage node, then increases the routing epoch.
+// Returns the id of new shard.
+func (s *ShardAssignment) SplitShard(shardID int) (int, error) {
+	replica, ok := s.Shards[shardID]
+	if !ok {
+		return 0, fmt.Errorf("shard[%d] not exist", shardID)
+	}
+	s.InitRanges()
+	replica = s.Shards[shardID]
+	lower, upper, err := replica.Range.Split()
+	if err != nil {
+		return 0, err
+	}
+	shardIDs := s.shardIDs()
+	newShardID := shardIDs[len(shardIDs)-1] + 1
+
+	replica.Range = &lower
+	s.Shards[shardID] = replica
+	s.Shards[newShardID] = Replica{
+		Replicas: append([]int(nil), replica.Replicas...),
+		Range:    &upper,
+	}
+	s.Epoch++
+	return newShardID, nil
+}
+
+// ReassignShard moves the shard to the replicas(the ids of nodes), then increases the routing epoch,
+// so that the writes routed by the old assignment are rejected by storage nodes instead of being lost.
+func (s *ShardAssignment) ReassignShard(shardID int, replicas []int) error {
+	replica, ok := s.Shards[shardID]
+	if !ok {
+		return fmt.Errorf("shard[%d] not exist", shardID)
+	}
+	if len(replicas) == 0 {
+		return fmt.Errorf("replicas of shard[%d] cannot be empty", shardID)
+	}
+	nodeIDs := make(map[int]struct{}, len(replicas))
+	for _, nodeID := range replicas {
+		if _, ok := s.Nodes[nodeID]; !ok {
+			return fmt.Errorf("node[%d] of shard[%d] not exist", nodeID, shardID)
+		}
+		if _, ok := nodeIDs[nodeID]; ok {
+			return fmt.Errorf("node[%d] of shard[%d] is duplicated", nodeID, shardID)
+		}
+		nodeIDs[nodeID] = struct{}{}
+	}
+	replica.Replicas = append([]int(nil), replicas...)
+	s.Shards[shardID] = replica
+	s.Epoch++
+	return nil
+}
+
+// shardIDs returns the sorted shard ids
+func (s *ShardAssignment) shardIDs() []int {
+	var shardIDs []int
+	for shardID := range s.Shards {
+		shardIDs = append(shardIDs, shardID)
+	}
+	sort.Ints(shardIDs)
+	return shardIDs
+}
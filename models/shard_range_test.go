@@ -0,0 +1,131 @@
+package models
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShardAssignment_Ranges(t *testing.T) {
+	shardAssign := NewShardAssignment()
+	_, err := shardAssign.Route(10)
+	assert.NotNil(t, err)
+	shardAssign.InitRanges()
+
+	for i := 0; i < 3; i++ {
+		shardAssign.AddReplica(i, 1)
+		shardAssign.AddReplica(i, 2)
+	}
+	shardAssign.InitRanges()
+	assert.Equal(t, HashRange{Start: 0, End: 1431655764}, *shardAssign.Shards[0].Range)
+	assert.Equal(t, uint32(math.MaxUint32), shardAssign.Shards[2].Range.End)
+	for _, hash := range []uint32{0, 1431655764, 1431655765, math.MaxUint32} {
+		shardID, err := shardAssign.Route(hash)
+		assert.Nil(t, err)
+		assert.True(t, shardAssign.Shards[shardID].Range.Contains(hash))
+	}
+
+	newShardID, err := shardAssign.SplitShard(2)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, newShardID)
+	assert.Equal(t, int64(1), shardAssign.Epoch)
+	assert.Equal(t, []int{1, 2}, shardAssign.Shards[3].Replicas)
+	assert.Equal(t, shardAssign.Shards[2].Range.End+1, shardAssign.Shards[3].Range.Start)
+	shardID, _ := shardAssign.Route(math.MaxUint32)
+	assert.Equal(t, 3, shardID)
+	// ranges aren't reset after split
+	shardAssign.InitRanges()
+	shardID, _ = shardAssign.Route(math.MaxUint32)
+	assert.Equal(t, 3, shardID)
+
+	_, err = shardAssign.SplitShard(100)
+	assert.NotNil(t, err)
+	shardAssign.Shards[4] = Replica{Range: &HashRange{Start: 5, End: 5}}
+	_, err = shardAssign.SplitShard(4)
+	assert.NotNil(t, err)
+}
+
+func TestShardAssignment_ReassignShard(t *testing.T) {
+	shardAssign := NewShardAssignment()
+	shardAssign.Nodes[1] = Node{IP: "127.0.0.1", Port: 2000}
+	shardAssign.Nodes[2] = Node{IP: "127.0.0.2", Port: 2000}
+	shardAssign.Nodes[3] = Node{IP: "127.0.0.3", Port: 2000}
+	shardAssign.Shards[0] = Replica{Replicas: []int{1, 2}}
+
+	assert.Nil(t, shardAssign.ReassignShard(0, []int{2, 3}))
+	assert.Equal(t, []int{2, 3}, shardAssign.Shards[0].Replicas)
+	assert.Equal(t, int64(1), shardAssign.Epoch)
+
+	assert.NotNil(t, shardAssign.ReassignShard(1, []int{2, 3}))
+	assert.NotNil(t, shardAssign.ReassignShard(0, nil))
+	assert.NotNil(t, shardAssign.ReassignShard(0, []int{4}))
+	assert.NotNil(t, shardAssign.ReassignShard(0, []int{2, 2}))
+	assert.Equal(t, int64(1), shardAssign.Epoch)
+}
+
+func TestSplitOption_NeedSplit(t *testing.T) {
+	var opt *SplitOption
+	assert.False(t, opt.NeedSplit(ShardStat{NumOfSeries: 100}))
+	opt = &SplitOption{MaxSeries: 10, MaxWriteRate: 100}
+	assert.False(t, opt.NeedSplit(ShardStat{NumOfSeries: 10, WriteRate: 100}))
+	assert.True(t, opt.NeedSplit(ShardStat{NumOfSeries: 11}))
+	assert.True(t, opt.NeedSplit(ShardStat{WriteRate: 101}))
+	opt = &SplitOption{}
+	assert.False(t, opt.NeedSplit(ShardStat{NumOfSeries: 11, WriteRate: 101}))
+}
+
+func TestSeriesHash(t *testing.T) {
+	tags := map[string]string{"region": "sh", "host": "1.1.1.1"}
+	// all tags are hashed by default
+	assert.Equal(t, SeriesHash("cpu", tags, nil),
+		SeriesHash("cpu", map[string]string{"host": "1.1.1.1", "region": "sh"}, nil))
+	assert.NotEqual(t, SeriesHash("cpu", tags, nil),
+		SeriesHash("cpu", map[string]string{"region": "sh", "host": "2.2.2.2"}, nil))
+	assert.NotEqual(t, SeriesHash("cpu", tags, nil), SeriesHash("memory", tags, nil))
+
+	// only routing tags are hashed
+	routingTags := []string{"region"}
+	assert.Equal(t, SeriesHash("cpu", tags, routingTags),
+		SeriesHash("cpu", map[string]string{"region": "sh", "host": "2.2.2.2"}, routingTags))
+	assert.NotEqual(t, SeriesHash("cpu", tags, routingTags),
+		SeriesHash("cpu", map[string]string{"region": "bj", "host": "1.1.1.1"}, routingTags))
+	assert.NotEqual(t, SeriesHash("cpu", tags, routingTags), SeriesHash("memory", tags, routingTags))
+}
+
+func TestShardAssignment_RouteSeries(t *testing.T) {
+	shardAssign := NewShardAssignment()
+	shardAssign.Config.RoutingTags = []string{"region"}
+	for i := 0; i < 8; i++ {
+		shardAssign.AddReplica(i, 1)
+	}
+	shardAssign.InitRanges()
+	shardID, err := shardAssign.RouteSeries("cpu", map[string]string{"region": "sh", "host": "1.1.1.1"})
+	assert.Nil(t, err)
+	// the series of same region are on the same shard
+	for _, host := range []string{"2.2.2.2", "3.3.3.3", "4.4.4.4"} {
+		id, err := shardAssign.RouteSeries("cpu", map[string]string{"region": "sh", "host": host})
+		assert.Nil(t, err)
+		assert.Equal(t, shardID, id)
+	}
+}
+
+func TestDatabaseCluster_IsShardLocal(t *testing.T) {
+	cluster := &DatabaseCluster{}
+	assert.False(t, cluster.IsShardLocal([]string{"region"}))
+	cluster.RoutingTags = []string{"region", "zone"}
+	assert.True(t, cluster.IsShardLocal([]string{"host", "zone", "region"}))
+	assert.False(t, cluster.IsShardLocal([]string{"region"}))
+	assert.False(t, cluster.IsShardLocal(nil))
+}
+
+func TestDatabaseCluster_ValidateRoutingTags(t *testing.T) {
+	cluster := &DatabaseCluster{}
+	assert.Nil(t, cluster.ValidateRoutingTags())
+	cluster.RoutingTags = []string{"region", "zone"}
+	assert.Nil(t, cluster.ValidateRoutingTags())
+	cluster.RoutingTags = []string{"region", ""}
+	assert.NotNil(t, cluster.ValidateRoutingTags())
+	cluster.RoutingTags = []string{"region", "region"}
+	assert.NotNil(t, cluster.ValidateRoutingTags())
+}
@@ -0,0 +1,47 @@
+package models
+
+// ShardState represents the state of shard in storage nodes, which is changed by operators,
+// so that the shards of old time ranges stop accepting writes or release the memory.
+type ShardState string
+
+// Defines all states of shard
+const (
+	// ShardHot is the default state, the shard accepts writes, the data not flushed is kept in memory-database
+	ShardHot ShardState = "hot"
+	// ShardReadOnly rejects writes, the data in memory-database is still queryable
+	ShardReadOnly ShardState = "read-only"
+	// ShardFrozen rejects writes, the memory-databases are flushed into segments then released,
+	// the queries are served from disk only
+	ShardFrozen ShardState = "frozen"
+)
+
+// IsValid returns if the state is one of the defined states
+func (s ShardState) IsValid() bool {
+	switch s {
+	case ShardHot, ShardReadOnly, ShardFrozen:
+		return true
+	default:
+		return false
+	}
+}
+
+// Writable returns if the shard of the state accepts writes
+func (s ShardState) Writable() bool {
+	return s == ShardHot
+}
+
+// ShardStates represents the states of database's shards, which is stored in state repository
+// of storage clusters and watched by storage nodes, the shard not in states is hot.
+type ShardStates struct {
+	Database string `json:"database"`
+	// States is the states of shards, key: shard id
+	States map[int]ShardState `json:"states,omitempty"`
+}
+
+// StateOf returns the state of shard, returns hot if not set
+func (s *ShardStates) StateOf(shardID int) ShardState {
+	if state, ok := s.States[shardID]; ok {
+		return state
+	}
+	return ShardHot
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShardState(t *testing.T) {
+	assert.True(t, ShardHot.IsValid())
+	assert.True(t, ShardReadOnly.IsValid())
+	assert.True(t, ShardFrozen.IsValid())
+	assert.False(t, ShardState("archived").IsValid())
+	assert.True(t, ShardHot.Writable())
+	assert.False(t, ShardReadOnly.Writable())
+	assert.False(t, ShardFrozen.Writable())
+
+	states := &ShardStates{Database: "db", States: map[int]ShardState{1: ShardFrozen}}
+	assert.Equal(t, ShardFrozen, states.StateOf(1))
+	assert.Equal(t, ShardHot, states.StateOf(2))
+}
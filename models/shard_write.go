@@ -0,0 +1,176 @@
+package models
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/pkg/stream"
+)
+
+// Defines the kinds of field value encoded by EncodePoints
+const (
+	fieldValueInteger byte = iota + 1
+	fieldValueFloat
+	fieldValueString
+)
+
+// ShardWrite represents the write request replicated by broker to the replicas of shard,
+// each write is the points of a client write encoded by EncodePoints.
+type ShardWrite struct {
+	Database string
+	ShardID  int
+	Writes   [][]byte
+}
+
+// Marshal encodes the shard write as database, shard id, number of writes, then the writes with length
+func (w *ShardWrite) Marshal() ([]byte, error) {
+	writer := stream.BinaryWriter()
+	writer.PutKey([]byte(w.Database))
+	writer.PutUvarint64(uint64(w.ShardID))
+	writer.PutUvarint64(uint64(len(w.Writes)))
+	for _, data := range w.Writes {
+		writer.PutKey(data)
+	}
+	return writer.Bytes()
+}
+
+// UnmarshalShardWrite decodes the shard write encoded by Marshal, returns error if the data is corrupted
+func UnmarshalShardWrite(data []byte) (*ShardWrite, error) {
+	reader := stream.BinaryReader(data)
+	database, ok := readKey(reader)
+	if !ok {
+		return nil, fmt.Errorf("shard write is corrupted")
+	}
+	w := &ShardWrite{Database: string(database), ShardID: int(reader.ReadUvarint64())}
+	count := int(reader.ReadUvarint64())
+	for i := 0; i < count && reader.Error() == nil; i++ {
+		write, ok := readKey(reader)
+		if !ok {
+			return nil, fmt.Errorf("shard write is corrupted")
+		}
+		w.Writes = append(w.Writes, write)
+	}
+	if reader.Error() != nil || !reader.Empty() {
+		return nil, fmt.Errorf("shard write is corrupted")
+	}
+	return w, nil
+}
+
+// EncodePoints encodes the points as the payload of shard write, each point is encoded as
+// metric name, timestamp, tags, then fields with field type and value, only the simple fields
+// of int64/float64 value and the string fields are supported.
+func EncodePoints(points []Point) ([]byte, error) {
+	writer := stream.BinaryWriter()
+	writer.PutUvarint64(uint64(len(points)))
+	for _, point := range points {
+		writer.PutKey([]byte(point.Name()))
+		writer.PutInt64(point.Timestamp())
+		tags := point.TagsMap()
+		writer.PutUvarint64(uint64(len(tags)))
+		for tagKey, tagValue := range tags {
+			writer.PutKey([]byte(tagKey))
+			writer.PutKey([]byte(tagValue))
+		}
+		fields := point.Fields()
+		writer.PutUvarint64(uint64(len(fields)))
+		for name, f := range fields {
+			writer.PutKey([]byte(name))
+			writer.PutUvarint64(uint64(f.Type()))
+			switch v := f.(type) {
+			case StringField:
+				writer.PutByte(fieldValueString)
+				writer.PutKey([]byte(v.Value()))
+			case SimpleField:
+				switch value := v.Value().(type) {
+				case int64:
+					writer.PutByte(fieldValueInteger)
+					writer.PutInt64(value)
+				case float64:
+					writer.PutByte(fieldValueFloat)
+					writer.PutUvarint64(math.Float64bits(value))
+				default:
+					return nil, fmt.Errorf("unsupported value[%T] of field[%s]", value, name)
+				}
+			default:
+				return nil, fmt.Errorf("unsupported field[%s]", name)
+			}
+		}
+	}
+	return writer.Bytes()
+}
+
+// DecodePoints decodes the points encoded by EncodePoints, returns error if the data is corrupted
+func DecodePoints(data []byte) ([]Point, error) {
+	reader := stream.BinaryReader(data)
+	count := int(reader.ReadUvarint64())
+	var points []Point
+	for i := 0; i < count && reader.Error() == nil; i++ {
+		name, ok := readKey(reader)
+		if !ok {
+			return nil, fmt.Errorf("points are corrupted")
+		}
+		timestamp := reader.ReadInt64()
+		numOfTags := int(reader.ReadUvarint64())
+		tags := make(map[string]string, numOfTags)
+		for j := 0; j < numOfTags && reader.Error() == nil; j++ {
+			tagKey, ok1 := readKey(reader)
+			tagValue, ok2 := readKey(reader)
+			if !ok1 || !ok2 {
+				return nil, fmt.Errorf("tags of points are corrupted")
+			}
+			tags[string(tagKey)] = string(tagValue)
+		}
+		numOfFields := int(reader.ReadUvarint64())
+		fields := make(map[string]Field, numOfFields)
+		for j := 0; j < numOfFields && reader.Error() == nil; j++ {
+			f, fieldName, err := readField(reader)
+			if err != nil {
+				return nil, err
+			}
+			fields[fieldName] = f
+		}
+		points = append(points, NewPoint(string(name), timestamp, tags, fields))
+	}
+	if reader.Error() != nil || !reader.Empty() {
+		return nil, fmt.Errorf("points are corrupted")
+	}
+	return points, nil
+}
+
+// readField reads the field encoded by EncodePoints, returns the field with its name
+func readField(reader *stream.Binary) (Field, string, error) {
+	name, ok := readKey(reader)
+	if !ok {
+		return nil, "", fmt.Errorf("fields of points are corrupted")
+	}
+	fieldType := field.Type(reader.ReadUvarint64())
+	kind := reader.ReadBytes(1)
+	if len(kind) != 1 {
+		return nil, "", fmt.Errorf("fields of points are corrupted")
+	}
+	switch kind[0] {
+	case fieldValueInteger:
+		return NewSimpleField(fieldType, field.Integer, reader.ReadInt64()), string(name), nil
+	case fieldValueFloat:
+		return NewSimpleField(fieldType, field.Float, math.Float64frombits(reader.ReadUvarint64())), string(name), nil
+	case fieldValueString:
+		value, ok := readKey(reader)
+		if !ok {
+			return nil, "", fmt.Errorf("fields of points are corrupted")
+		}
+		return NewStringField(string(value)), string(name), nil
+	default:
+		return nil, "", fmt.Errorf("unknown value kind[%d] of field[%s]", kind[0], name)
+	}
+}
+
+// readKey reads the bytes with length encoded by PutKey, returns false if the data is short
+func readKey(reader *stream.Binary) ([]byte, bool) {
+	length := int(reader.ReadUvarint64())
+	if reader.Error() != nil {
+		return nil, false
+	}
+	key := reader.ReadBytes(length)
+	return key, len(key) == length
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/pkg/field"
+)
+
+func TestEncodePoints(t *testing.T) {
+	points := []Point{
+		NewPoint("cpu", 1000, map[string]string{"host": "alpha", "ip": "1.1.1.1"}, map[string]Field{
+			"count": NewSimpleField(field.SumField, field.Integer, int64(-10)),
+			"usage": NewSimpleField(field.MaxField, field.Float, 1.5),
+			"state": NewStringField("running"),
+		}),
+		NewPoint("mem", 2000, map[string]string{}, map[string]Field{
+			"used": NewSimpleField(field.SumField, field.Float, 0.0),
+		}),
+	}
+	data, err := EncodePoints(points)
+	assert.Nil(t, err)
+	decoded, err := DecodePoints(data)
+	assert.Nil(t, err)
+	assert.Equal(t, points, decoded)
+
+	_, err = DecodePoints(data[:len(data)-1])
+	assert.NotNil(t, err)
+	_, err = DecodePoints(append(data, 1))
+	assert.NotNil(t, err)
+
+	_, err = EncodePoints([]Point{NewPoint("cpu", 1000, nil, map[string]Field{
+		"count": NewSimpleField(field.SumField, field.Integer, 10),
+	})})
+	assert.NotNil(t, err)
+}
+
+func TestShardWrite_Marshal(t *testing.T) {
+	w := &ShardWrite{Database: "db", ShardID: 3, Writes: [][]byte{[]byte("a"), []byte("bc")}}
+	data, err := w.Marshal()
+	assert.Nil(t, err)
+	decoded, err := UnmarshalShardWrite(data)
+	assert.Nil(t, err)
+	assert.Equal(t, w, decoded)
+
+	_, err = UnmarshalShardWrite(data[:len(data)-1])
+	assert.NotNil(t, err)
+	_, err = UnmarshalShardWrite(nil)
+	assert.NotNil(t, err)
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"encoding/json"
 
+	"github.com/eleme/lindb/pkg/backup"
 	"github.com/eleme/lindb/pkg/logger"
 	"github.com/eleme/lindb/pkg/option"
 )
@@ -24,3 +25,48 @@ func (t CreateShardTask) Bytes() []byte {
 	}
 	return data
 }
+
+// BackupTask represents backup task param, storage node uploads the files of database's shards into backup target,
+// base is the backup id of base backup set for incremental backup.
+type BackupTask struct {
+	BackupID string        `json:"backupID"`
+	Base     string        `json:"base,omitempty"`
+	Part     backup.Part   `json:"part"`
+	Target   backup.Config `json:"target"`
+}
+
+// Bytes returns backup task binary data using json
+func (t BackupTask) Bytes() []byte {
+	data, err := json.Marshal(t)
+	if err != nil {
+		logger.GetLogger("model/task").Error("marshal backup task error",
+			logger.Error(err))
+		return nil
+	}
+	return data
+}
+
+// SplitShardTask represents split shard task param, storage node creates the new shard,
+// then the series whose hash in range of new shard are written into new shard from the epoch,
+// the data of them written before is migrated from the split shard into new shard.
+type SplitShardTask struct {
+	Database    string             `json:"database"`
+	ShardID     int                `json:"shardID"`
+	NewShardID  int                `json:"newShardID"`
+	Range       HashRange          `json:"range"`
+	Epoch       int64              `json:"epoch"`
+	ShardOption option.ShardOption `json:"shardOption"`
+	// RoutingTags are the routing tags of database cluster, which the hash of series is computed by
+	RoutingTags []string `json:"routingTags,omitempty"`
+}
+
+// Bytes returns split shard task binary data using json
+func (t SplitShardTask) Bytes() []byte {
+	data, err := json.Marshal(t)
+	if err != nil {
+		logger.GetLogger("model/task").Error("marshal split shard task error",
+			logger.Error(err))
+		return nil
+	}
+	return data
+}
@@ -0,0 +1,19 @@
+package models
+
+// UDFDefinition represents the user defined function registered by database, which is loaded from Go plugin
+// or WebAssembly module on broker nodes, then used in the select expressions of the database's queries like builtin functions.
+type UDFDefinition struct {
+	Database string `json:"database"`
+	Name     string `json:"name"`
+	// Plugin is the path of Go plugin(.so) on broker nodes, which exports the factory of function
+	Plugin string `json:"plugin,omitempty"`
+	// WASM is the path of WebAssembly module(.wasm) on broker nodes, which is executed in sandbox,
+	// either plugin or wasm must be set
+	WASM string `json:"wasm,omitempty"`
+	// Symbol is the name of factory exported by plugin, default is NewUDF,
+	// or the name of eval function exported by wasm module, default is eval
+	Symbol string `json:"symbol,omitempty"`
+	// Params are passed to the factory of plugin when the function is created
+	Params map[string]string `json:"params,omitempty"`
+	Desc   string            `json:"desc,omitempty"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "context"
+
 // User user system model
 type User struct {
 	UserName string `toml:"username" json:"UserName"`
@@ -9,3 +11,17 @@ type User struct {
 type JwtToken struct {
 	Token string
 }
+
+// userKey is the key of authenticated user name in context
+type userKey struct{}
+
+// WithUser returns the context which carries the name of authenticated user
+func WithUser(ctx context.Context, userName string) context.Context {
+	return context.WithValue(ctx, userKey{}, userName)
+}
+
+// UserFromContext returns the name of authenticated user in context, returns empty if not authenticated
+func UserFromContext(ctx context.Context) string {
+	userName, _ := ctx.Value(userKey{}).(string)
+	return userName
+}
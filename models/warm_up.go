@@ -0,0 +1,34 @@
+package models
+
+// WarmUpStats represents the number of table readers opened and index blocks loaded into block cache by warm-up
+type WarmUpStats struct {
+	Files  int `json:"files"`
+	Blocks int `json:"blocks"`
+}
+
+// Add adds the stats of other warm-up
+func (s *WarmUpStats) Add(other WarmUpStats) {
+	s.Files += other.Files
+	s.Blocks += other.Blocks
+}
+
+// WarmUpRequest represents the request of pre-warming the table readers and index blocks of database,
+// which is used after storage nodes restarted, so that the first queries don't read them from disk.
+// All shards of database are warmed if shard ids not set, the metadata index of database is always warmed.
+type WarmUpRequest struct {
+	Database string `json:"database"`
+	ShardIDs []int  `json:"shardIDs,omitempty"`
+	// Segments is the number of most recent segments of each interval warmed in shards, all segments if <= 0
+	Segments int `json:"segments,omitempty"`
+}
+
+// WarmUpResult represents the result of warming the metadata index or a shard replica in storage node,
+// error is empty if done successfully.
+type WarmUpResult struct {
+	Node string `json:"node,omitempty"`
+	// Index is true if the result is of the metadata index of database, otherwise of the shard
+	Index   bool `json:"index,omitempty"`
+	ShardID int  `json:"shardID"`
+	WarmUpStats
+	Error string `json:"error,omitempty"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarmUpStats_Add(t *testing.T) {
+	stats := WarmUpStats{Files: 1, Blocks: 2}
+	stats.Add(WarmUpStats{Files: 3, Blocks: 4})
+	assert.Equal(t, WarmUpStats{Files: 4, Blocks: 6}, stats)
+}
+
+func TestWarmUpResult_JSON(t *testing.T) {
+	data, _ := json.Marshal(WarmUpResult{Node: "127.0.0.1:2000", ShardID: 1, WarmUpStats: WarmUpStats{Files: 1}})
+	assert.Equal(t, `{"node":"127.0.0.1:2000","shardID":1,"files":1,"blocks":0}`, string(data))
+}
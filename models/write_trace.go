@@ -0,0 +1,23 @@
+package models
+
+// StageTiming represents the elapsed time of a stage in write path
+type StageTiming struct {
+	Stage string `json:"stage"`
+	// Cost is the elapsed time(µs) of the stage
+	Cost int64 `json:"cost"`
+}
+
+// WriteTrace represents the stage timings of a write batch, which is sampled or slow,
+// so that the stage causing latency regression can be localized.
+type WriteTrace struct {
+	Database string `json:"database"`
+	Points   int    `json:"points"`
+	// StartTime is the time(ms) when the batch is received
+	StartTime int64 `json:"startTime"`
+	// Cost is the elapsed time(µs) of all stages
+	Cost   int64         `json:"cost"`
+	Stages []StageTiming `json:"stages"`
+	// Slow is true if the cost exceeds the threshold of slow write
+	Slow  bool   `json:"slow"`
+	Error string `json:"error,omitempty"`
+}
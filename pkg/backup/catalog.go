@@ -0,0 +1,317 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+)
+
+// manifestName is the object name of backup set/part manifest
+const manifestName = "MANIFEST"
+
+// Part represents a part of backup set, which is the data of database in a storage node
+type Part struct {
+	Cluster  string `json:"cluster"`
+	Database string `json:"database"`
+	Node     string `json:"node"`
+	ShardIDs []int  `json:"shardIDs"`
+}
+
+// SetManifest represents the manifest of backup set, which is written before the backup tasks are submitted.
+// The backup set is incremental if base is set, the sst files which exist in base backup set aren't uploaded again.
+type SetManifest struct {
+	ID         string `json:"id"`
+	Base       string `json:"base,omitempty"`
+	CreateTime int64  `json:"createTime"`
+	Parts      []Part `json:"parts"`
+}
+
+// FileInfo represents a backup file, backup id is the backup set which the file is stored in,
+// empty backup id means the file is stored in the backup set of manifest.
+type FileInfo struct {
+	Name     string `json:"name"`
+	Size     int64  `json:"size"`
+	BackupID string `json:"backupID,omitempty"`
+}
+
+// PartManifest represents the manifest of backup part, which is written after all files of part are uploaded,
+// size is the total size of all files, uploaded is the size of files uploaded by this backup.
+type PartManifest struct {
+	Part
+	Files     []FileInfo `json:"files"`
+	Size      int64      `json:"size"`
+	Uploaded  int64      `json:"uploaded"`
+	StartTime int64      `json:"startTime"`
+	EndTime   int64      `json:"endTime"`
+}
+
+// Resolve returns the files of part with the backup set id which the file is stored in
+func (p *PartManifest) Resolve(setID string) []FileInfo {
+	files := make([]FileInfo, len(p.Files))
+	for idx, f := range p.Files {
+		if len(f.BackupID) == 0 {
+			f.BackupID = setID
+		}
+		files[idx] = f
+	}
+	return files
+}
+
+// PartFiles represents the files of a finished backup part which can be read from backup target,
+// e.g. the files of base backup part for incremental backup.
+type PartFiles interface {
+	// Files returns the files of part with the backup set id which the file is stored in
+	Files() []FileInfo
+	// Open opens the file of part for reading from the backup set which the file is stored in
+	Open(file FileInfo) (io.ReadCloser, error)
+}
+
+// partFiles implements PartFiles interface
+type partFiles struct {
+	catalog *catalog
+	part    Part
+	files   []FileInfo
+}
+
+// Files returns the files of part with the backup set id which the file is stored in
+func (p *partFiles) Files() []FileInfo {
+	return p.files
+}
+
+// Open opens the file of part for reading from the backup set which the file is stored in
+func (p *partFiles) Open(file FileInfo) (io.ReadCloser, error) {
+	return p.catalog.target.Get(joinName(p.catalog.PartPath(file.BackupID, p.part), file.Name))
+}
+
+// SetInfo represents the backup set with the finished parts,
+// depends are the backup sets which store the files referenced by this backup set.
+type SetInfo struct {
+	SetManifest
+	Complete bool            `json:"complete"`
+	Size     int64           `json:"size"`
+	Uploaded int64           `json:"uploaded"`
+	Depends  []string        `json:"depends,omitempty"`
+	Finished []*PartManifest `json:"finished"`
+}
+
+// Catalog represents the catalog of backup sets, all backup sets are stored in backup target:
+// <set id>/MANIFEST, <set id>/<database>/<node>/MANIFEST and <set id>/<database>/<node>/<files>
+type Catalog interface {
+	// Target returns the backup target
+	Target() Target
+	// PartPath returns the path of backup part
+	PartPath(setID string, part Part) string
+	// SaveSet saves the manifest of backup set
+	SaveSet(set *SetManifest) error
+	// SavePart saves the manifest of finished backup part
+	SavePart(setID string, part *PartManifest) error
+	// GetPart returns the manifest of finished backup part, return ErrNotExist if not exist
+	GetPart(setID string, part Part) (*PartManifest, error)
+	// GetPartFiles returns the files of finished backup part, return ErrNotExist if not exist
+	GetPartFiles(setID string, part Part) (PartFiles, error)
+	// ListSets returns all backup sets ordered by id
+	ListSets() ([]*SetInfo, error)
+	// GetSet returns the backup set by id, return ErrNotExist if not exist
+	GetSet(setID string) (*SetInfo, error)
+	// DeleteSet deletes the backup set and all files of it
+	DeleteSet(setID string) error
+}
+
+// catalog implements Catalog interface
+type catalog struct {
+	target Target
+}
+
+// NewCatalog creates the catalog of backup sets in target
+func NewCatalog(target Target) Catalog {
+	return &catalog{target: target}
+}
+
+// Target returns the backup target
+func (c *catalog) Target() Target {
+	return c.target
+}
+
+// PartPath returns the path of backup part
+func (c *catalog) PartPath(setID string, part Part) string {
+	return joinName(setID, part.Database, part.Node)
+}
+
+// SaveSet saves the manifest of backup set
+func (c *catalog) SaveSet(set *SetManifest) error {
+	return c.putJSON(joinName(set.ID, manifestName), set)
+}
+
+// SavePart saves the manifest of finished backup part
+func (c *catalog) SavePart(setID string, part *PartManifest) error {
+	return c.putJSON(joinName(c.PartPath(setID, part.Part), manifestName), part)
+}
+
+// GetPart returns the manifest of finished backup part
+func (c *catalog) GetPart(setID string, part Part) (*PartManifest, error) {
+	partManifest := &PartManifest{}
+	if err := c.getJSON(joinName(c.PartPath(setID, part), manifestName), partManifest); err != nil {
+		return nil, err
+	}
+	return partManifest, nil
+}
+
+// GetPartFiles returns the files of finished backup part, which are resolved with the backup set id
+func (c *catalog) GetPartFiles(setID string, part Part) (PartFiles, error) {
+	partManifest, err := c.GetPart(setID, part)
+	if err != nil {
+		return nil, err
+	}
+	return &partFiles{catalog: c, part: part, files: partManifest.Resolve(setID)}, nil
+}
+
+// ListSets returns all backup sets ordered by id, the dir without manifest is ignored
+func (c *catalog) ListSets() ([]*SetInfo, error) {
+	ids, err := c.target.List("")
+	if err != nil {
+		return nil, fmt.Errorf("list backup sets error:%s", err)
+	}
+	var sets []*SetInfo
+	for _, id := range ids {
+		set, err := c.GetSet(id)
+		if err == ErrNotExist {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, set)
+	}
+	sort.Slice(sets, func(i, j int) bool {
+		return sets[i].ID < sets[j].ID
+	})
+	return sets, nil
+}
+
+// GetSet returns the backup set with the finished parts
+func (c *catalog) GetSet(setID string) (*SetInfo, error) {
+	set := &SetInfo{}
+	if err := c.getJSON(joinName(setID, manifestName), &set.SetManifest); err != nil {
+		return nil, err
+	}
+	depends := make(map[string]bool)
+	for _, part := range set.Parts {
+		partManifest, err := c.GetPart(setID, part)
+		if err == ErrNotExist {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		set.Size += partManifest.Size
+		set.Uploaded += partManifest.Uploaded
+		set.Finished = append(set.Finished, partManifest)
+		for _, f := range partManifest.Files {
+			if len(f.BackupID) > 0 && f.BackupID != setID && !depends[f.BackupID] {
+				depends[f.BackupID] = true
+				set.Depends = append(set.Depends, f.BackupID)
+			}
+		}
+	}
+	sort.Strings(set.Depends)
+	set.Complete = len(set.Finished) == len(set.Parts)
+	return set, nil
+}
+
+// DeleteSet deletes the backup set, deletes the manifest at last so that the set isn't listed before
+// all files are deleted.
+func (c *catalog) DeleteSet(setID string) error {
+	names, err := c.target.List(setID)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if name == manifestName {
+			continue
+		}
+		if err := c.target.Delete(joinName(setID, name)); err != nil {
+			return fmt.Errorf("delete backup set[%s] error:%s", setID, err)
+		}
+	}
+	return c.target.Delete(setID)
+}
+
+// putJSON writes the json data of value into target
+func (c *catalog) putJSON(name string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.target.Put(name, bytes.NewReader(data), int64(len(data)))
+}
+
+// getJSON reads the json data from target then unmarshal it into value
+func (c *catalog) getJSON(name string, value interface{}) error {
+	reader, err := c.target.Get(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("unmarshal backup manifest[%s] error:%s", name, err)
+	}
+	return nil
+}
+
+// ExpiredSets returns the backup sets which should be deleted for keeping the newest complete sets by retention,
+// the incomplete sets newer than the oldest kept set are kept because they may be still running,
+// the sets which the kept sets depend on(directly or indirectly) are also kept.
+// Sets must be ordered by id.
+func ExpiredSets(sets []*SetInfo, retention int) []*SetInfo {
+	if retention <= 0 {
+		return nil
+	}
+	kept := 0
+	for i := len(sets) - 1; i >= 0; i-- {
+		if !sets[i].Complete {
+			continue
+		}
+		kept++
+		if kept == retention {
+			depends := make(map[string]bool)
+			for _, set := range sets[i:] {
+				for _, id := range set.Depends {
+					depends[id] = true
+				}
+			}
+			// depends always point to older sets, so the kept sets can be found from newest to oldest
+			var expired []*SetInfo
+			for j := i - 1; j >= 0; j-- {
+				if !depends[sets[j].ID] {
+					expired = append([]*SetInfo{sets[j]}, expired...)
+					continue
+				}
+				for _, id := range sets[j].Depends {
+					depends[id] = true
+				}
+			}
+			return expired
+		}
+	}
+	return nil
+}
+
+// LatestComplete returns the latest complete backup set, returns nil if not exist.
+// Sets must be ordered by id.
+func LatestComplete(sets []*SetInfo) *SetInfo {
+	for i := len(sets) - 1; i >= 0; i-- {
+		if sets[i].Complete {
+			return sets[i]
+		}
+	}
+	return nil
+}
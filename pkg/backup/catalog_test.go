@@ -0,0 +1,169 @@
+package backup
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalog(t *testing.T) {
+	defer func() {
+		_ = os.RemoveAll(testPath)
+	}()
+	target, _ := NewTarget(Config{URL: testPath})
+	catalog := NewCatalog(target)
+	assert.Equal(t, target, catalog.Target())
+
+	part1 := Part{Cluster: "test", Database: "db", Node: "1.1.1.1:2080", ShardIDs: []int{1, 3}}
+	part2 := Part{Cluster: "test", Database: "db", Node: "1.1.1.2:2080", ShardIDs: []int{2}}
+	assert.Equal(t, "20191017000000/db/1.1.1.1:2080", catalog.PartPath("20191017000000", part1))
+
+	sets, err := catalog.ListSets()
+	assert.Nil(t, err)
+	assert.Empty(t, sets)
+
+	assert.Nil(t, catalog.SaveSet(&SetManifest{ID: "20191017000000", Parts: []Part{part1, part2}}))
+	assert.Nil(t, catalog.SaveSet(&SetManifest{ID: "20191016000000"}))
+	// dir without manifest is ignored
+	_ = target.Put("tmp/a", strings.NewReader("a"), 1)
+
+	set, err := catalog.GetSet("20191017000000")
+	assert.Nil(t, err)
+	assert.False(t, set.Complete)
+	assert.Empty(t, set.Finished)
+
+	assert.Nil(t, catalog.SavePart("20191017000000", &PartManifest{Part: part1, Size: 10}))
+	_ = target.Put(catalog.PartPath("20191017000000", part1)+"/OPTIONS", strings.NewReader("a"), 1)
+	set, _ = catalog.GetSet("20191017000000")
+	assert.False(t, set.Complete)
+	assert.Equal(t, int64(10), set.Size)
+	assert.Equal(t, 1, len(set.Finished))
+	assert.Nil(t, catalog.SavePart("20191017000000", &PartManifest{Part: part2, Size: 5}))
+	set, _ = catalog.GetSet("20191017000000")
+	assert.True(t, set.Complete)
+	assert.Equal(t, int64(15), set.Size)
+
+	sets, err = catalog.ListSets()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(sets))
+	assert.Equal(t, "20191016000000", sets[0].ID)
+	assert.Equal(t, "20191017000000", sets[1].ID)
+
+	_, err = catalog.GetSet("20191018000000")
+	assert.Equal(t, ErrNotExist, err)
+	// bad manifest
+	_ = target.Put("20191018000000/MANIFEST", strings.NewReader("a"), 1)
+	_, err = catalog.GetSet("20191018000000")
+	assert.NotNil(t, err)
+	_, err = catalog.ListSets()
+	assert.NotNil(t, err)
+
+	assert.Nil(t, catalog.DeleteSet("20191018000000"))
+	assert.Nil(t, catalog.DeleteSet("20191017000000"))
+	sets, _ = catalog.ListSets()
+	assert.Equal(t, 1, len(sets))
+	names, _ := target.List("20191017000000")
+	assert.Empty(t, names)
+}
+
+func TestExpiredSets(t *testing.T) {
+	sets := []*SetInfo{
+		{SetManifest: SetManifest{ID: "1"}, Complete: true},
+		{SetManifest: SetManifest{ID: "2"}, Complete: false},
+		{SetManifest: SetManifest{ID: "3"}, Complete: true},
+		{SetManifest: SetManifest{ID: "4"}, Complete: true},
+		{SetManifest: SetManifest{ID: "5"}, Complete: false},
+	}
+	ids := func(sets []*SetInfo) []string {
+		var result []string
+		for _, set := range sets {
+			result = append(result, set.ID)
+		}
+		return result
+	}
+	assert.Nil(t, ExpiredSets(sets, 0))
+	assert.Equal(t, []string{"1", "2", "3"}, ids(ExpiredSets(sets, 1)))
+	assert.Equal(t, []string{"1", "2"}, ids(ExpiredSets(sets, 2)))
+	assert.Empty(t, ExpiredSets(sets, 3))
+	assert.Nil(t, ExpiredSets(sets, 10))
+}
+
+func TestExpiredSets_depends(t *testing.T) {
+	sets := []*SetInfo{
+		{SetManifest: SetManifest{ID: "1"}, Complete: true},
+		{SetManifest: SetManifest{ID: "2"}, Complete: true, Depends: []string{"1"}},
+		{SetManifest: SetManifest{ID: "3"}, Complete: true, Depends: []string{"1", "2"}},
+		{SetManifest: SetManifest{ID: "4"}, Complete: true, Depends: []string{"2"}},
+	}
+	expired := ExpiredSets(sets, 1)
+	assert.Equal(t, 1, len(expired))
+	assert.Equal(t, "3", expired[0].ID)
+	sets[3].Depends = nil
+	expired = ExpiredSets(sets, 1)
+	assert.Equal(t, 3, len(expired))
+	assert.Equal(t, "1", expired[0].ID)
+	assert.Equal(t, "3", expired[2].ID)
+
+	assert.Equal(t, "4", LatestComplete(sets).ID)
+	sets[3].Complete = false
+	assert.Equal(t, "3", LatestComplete(sets).ID)
+	assert.Nil(t, LatestComplete(nil))
+}
+
+func TestCatalog_incremental(t *testing.T) {
+	defer func() {
+		_ = os.RemoveAll(testPath)
+	}()
+	target, _ := NewTarget(Config{URL: testPath})
+	catalog := NewCatalog(target)
+	part := Part{Database: "db", Node: "1.1.1.1:2080", ShardIDs: []int{1}}
+
+	_, err := catalog.GetPart("1", part)
+	assert.Equal(t, ErrNotExist, err)
+
+	_ = catalog.SaveSet(&SetManifest{ID: "2", Base: "1", Parts: []Part{part}})
+	_ = catalog.SavePart("2", &PartManifest{
+		Part: part,
+		Files: []FileInfo{
+			{Name: "OPTIONS", Size: 1},
+			{Name: "000001.sst", Size: 10, BackupID: "1"},
+			{Name: "000002.sst", Size: 5},
+		},
+		Size:     16,
+		Uploaded: 6,
+	})
+	partManifest, err := catalog.GetPart("2", part)
+	assert.Nil(t, err)
+	assert.Equal(t, []FileInfo{
+		{Name: "OPTIONS", Size: 1, BackupID: "2"},
+		{Name: "000001.sst", Size: 10, BackupID: "1"},
+		{Name: "000002.sst", Size: 5, BackupID: "2"},
+	}, partManifest.Resolve("2"))
+
+	// the files of part are read from the backup set which they are stored in
+	_ = target.Put(joinName("1", "db", "1.1.1.1:2080", "000001.sst"), strings.NewReader("sst1"), 4)
+	_ = target.Put(joinName("2", "db", "1.1.1.1:2080", "000002.sst"), strings.NewReader("sst2"), 4)
+	partFiles, err := catalog.GetPartFiles("2", part)
+	assert.Nil(t, err)
+	assert.Equal(t, partManifest.Resolve("2"), partFiles.Files())
+	for idx, expect := range []string{"sst1", "sst2"} {
+		reader, err := partFiles.Open(partFiles.Files()[idx+1])
+		assert.Nil(t, err)
+		data, _ := ioutil.ReadAll(reader)
+		_ = reader.Close()
+		assert.Equal(t, expect, string(data))
+	}
+	_, err = partFiles.Open(partFiles.Files()[0])
+	assert.NotNil(t, err)
+	_, err = catalog.GetPartFiles("1", part)
+	assert.Equal(t, ErrNotExist, err)
+
+	set, _ := catalog.GetSet("2")
+	assert.Equal(t, "1", set.Base)
+	assert.Equal(t, []string{"1"}, set.Depends)
+	assert.Equal(t, int64(16), set.Size)
+	assert.Equal(t, int64(6), set.Uploaded)
+}
@@ -0,0 +1,89 @@
+package backup
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const tmpSuffix = ".tmp"
+
+// localTarget implements Target interface, stores the backup files in local file system,
+// the path can be a mounted shared file system such as nfs.
+type localTarget struct {
+	root string
+}
+
+// newLocalTarget creates backup target under the root path
+func newLocalTarget(root string) Target {
+	return &localTarget{root: root}
+}
+
+// Put writes the object into a temp file, then renames it for making sure the object is complete
+func (t *localTarget) Put(name string, reader io.Reader, size int64) error {
+	path := t.path(name)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return fmt.Errorf("create backup dir error:%s", err)
+	}
+	tmp := path + tmpSuffix
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(f, reader)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err == nil && n != size {
+		err = fmt.Errorf("write backup file[%s] size mismatch, expect:%d, actual:%d", name, size, n)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Get returns the reader of object, return ErrNotExist if not exist
+func (t *localTarget) Get(name string) (io.ReadCloser, error) {
+	f, err := os.Open(t.path(name))
+	if os.IsNotExist(err) {
+		return nil, ErrNotExist
+	}
+	return f, err
+}
+
+// List returns the names of direct children under prefix
+func (t *localTarget) List(prefix string) ([]string, error) {
+	files, err := ioutil.ReadDir(t.path(prefix))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, f := range files {
+		// skip the file which is writing
+		if strings.HasSuffix(f.Name(), tmpSuffix) {
+			continue
+		}
+		names = append(names, f.Name())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Delete deletes the file or dir
+func (t *localTarget) Delete(name string) error {
+	return os.RemoveAll(t.path(name))
+}
+
+// path returns the local file path of object
+func (t *localTarget) path(name string) string {
+	return filepath.Join(t.root, filepath.FromSlash(name))
+}
@@ -0,0 +1,340 @@
+package backup
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	emptyPayload    = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	signAlgorithm   = "AWS4-HMAC-SHA256"
+	defaultRegion   = "us-east-1"
+	// defaultTimeout is the default timeout of each request, which includes uploading the sst file,
+	// so that the versions pinned by backup are released after the hanging upload fails
+	defaultTimeout = 10 * time.Minute
+)
+
+// s3Option represents the options of s3 target
+type s3Option struct {
+	endpoint  string
+	region    string
+	bucket    string
+	prefix    string
+	accessKey string
+	secretKey string
+	timeout   time.Duration
+}
+
+// s3Target implements Target interface, stores the backup files in s3 compatible object storage,
+// requests are path-style and signed by signature version 4.
+type s3Target struct {
+	option s3Option
+	client *http.Client
+	now    func() time.Time
+}
+
+// newS3Target creates s3 backup target
+func newS3Target(option s3Option) Target {
+	if len(option.region) == 0 {
+		option.region = defaultRegion
+	}
+	if len(option.endpoint) == 0 {
+		option.endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", option.region)
+	}
+	if option.timeout <= 0 {
+		option.timeout = defaultTimeout
+	}
+	option.endpoint = strings.TrimRight(option.endpoint, "/")
+	return &s3Target{
+		option: option,
+		client: &http.Client{Timeout: option.timeout},
+		now:    time.Now,
+	}
+}
+
+// Put uploads the object, the payload isn't signed for streaming the reader
+func (t *s3Target) Put(name string, reader io.Reader, size int64) error {
+	req, err := t.newRequest(http.MethodPut, t.key(name), nil, reader)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		// make sure empty object isn't sent as chunked body
+		req.Body = http.NoBody
+	}
+	resp, err := t.do(req, unsignedPayload)
+	if err != nil {
+		return err
+	}
+	return closeResponse(resp)
+}
+
+// Get returns the body of object, return ErrNotExist if not found
+func (t *s3Target) Get(name string) (io.ReadCloser, error) {
+	req, err := t.newRequest(http.MethodGet, t.key(name), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.do(req, emptyPayload)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// List returns the names of direct children under prefix, which are the objects and common prefixes
+func (t *s3Target) List(prefix string) ([]string, error) {
+	keyPrefix := t.key(prefix)
+	if len(keyPrefix) > 0 {
+		keyPrefix += "/"
+	}
+	var names []string
+	err := t.listObjects(keyPrefix, "/", func(result *listBucketResult) {
+		for _, p := range result.CommonPrefixes {
+			names = append(names, strings.TrimSuffix(strings.TrimPrefix(p.Prefix, keyPrefix), "/"))
+		}
+		for _, c := range result.Contents {
+			names = append(names, strings.TrimPrefix(c.Key, keyPrefix))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Delete deletes the object and all objects under it one by one
+func (t *s3Target) Delete(name string) error {
+	key := t.key(name)
+	keys := []string{key}
+	err := t.listObjects(key+"/", "", func(result *listBucketResult) {
+		for _, c := range result.Contents {
+			keys = append(keys, c.Key)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		req, err := t.newRequest(http.MethodDelete, k, nil, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := t.do(req, emptyPayload)
+		if err != nil {
+			return err
+		}
+		if err := closeResponse(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listBucketResult represents the response of list objects v2
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+}
+
+// listObjects lists all objects by prefix/delimiter page by page
+func (t *s3Target) listObjects(prefix, delimiter string, fn func(result *listBucketResult)) error {
+	token := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if len(delimiter) > 0 {
+			query.Set("delimiter", delimiter)
+		}
+		if len(token) > 0 {
+			query.Set("continuation-token", token)
+		}
+		req, err := t.newRequest(http.MethodGet, "", query, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := t.do(req, emptyPayload)
+		if err != nil {
+			return err
+		}
+		result := &listBucketResult{}
+		err = xml.NewDecoder(resp.Body).Decode(result)
+		_ = resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("decode list objects result error:%s", err)
+		}
+		fn(result)
+		if !result.IsTruncated || len(result.NextContinuationToken) == 0 {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// key returns the object key with the prefix of target
+func (t *s3Target) key(name string) string {
+	return joinName(t.option.prefix, name)
+}
+
+// newRequest creates the path-style request of bucket/key
+func (t *s3Target) newRequest(method, key string, query url.Values, body io.Reader) (*http.Request, error) {
+	path := "/" + uriEncode(t.option.bucket, false)
+	if len(key) > 0 {
+		path += "/" + uriEncode(key, true)
+	}
+	rawURL := t.option.endpoint + path
+	if len(query) > 0 {
+		rawURL += "?" + canonicalQuery(query)
+	}
+	return http.NewRequest(method, rawURL, body)
+}
+
+// do signs the request then sends it, returns error if the status code isn't 2xx
+func (t *s3Target) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	t.sign(req, payloadHash)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound && req.Method != http.MethodDelete {
+		_ = resp.Body.Close()
+		return nil, ErrNotExist
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("s3 request[%s %s] error, status:%d, message:%s",
+			req.Method, req.URL.Path, resp.StatusCode, string(msg))
+	}
+	return resp, nil
+}
+
+// sign signs the request using aws signature version 4
+func (t *s3Target) sign(req *http.Request, payloadHash string) {
+	now := t.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	signedHeaders, headers := canonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		headers,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := strings.Join([]string{date, t.option.region, "s3", "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{signAlgorithm, amzDate, scope, sha256Hex(canonicalRequest)}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+t.option.secretKey), date)
+	key = hmacSHA256(key, t.option.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signAlgorithm, t.option.accessKey, scope, signedHeaders, signature))
+}
+
+// canonicalHeaders returns the signed header names and canonical headers, includes host and all request headers
+func canonicalHeaders(req *http.Request) (signedHeaders string, headers string) {
+	values := map[string]string{"host": req.URL.Host}
+	if len(req.Host) > 0 {
+		values["host"] = req.Host
+	}
+	for name, value := range req.Header {
+		if strings.EqualFold(name, "Authorization") {
+			continue
+		}
+		values[strings.ToLower(name)] = strings.TrimSpace(strings.Join(value, ","))
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteString(":")
+		b.WriteString(values[name])
+		b.WriteString("\n")
+	}
+	return strings.Join(names, ";"), b.String()
+}
+
+// canonicalQuery returns the query string sorted by key and encoded
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, key := range keys {
+		values := query[key]
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(key, false)+"="+uriEncode(value, false))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode encodes all characters except unreserved characters, keeps '/' if keepSlash
+func uriEncode(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// closeResponse drains and closes the response body
+func closeResponse(resp *http.Response) error {
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+func sha256Hex(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
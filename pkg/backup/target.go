@@ -0,0 +1,82 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrNotExist represents the object of backup target not exist
+var ErrNotExist = errors.New("backup: not exist")
+
+// Config represents backup target config, the url is local path(/data/backup or file:///data/backup)
+// or s3 bucket with optional prefix(s3://bucket/prefix).
+// The credentials of s3 are read from env AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY.
+type Config struct {
+	URL      string `toml:"url" json:"url"`
+	Region   string `toml:"region" json:"region"`
+	Endpoint string `toml:"endpoint" json:"endpoint"`
+	// Timeout is the timeout(s) of each request of s3 target including transferring the object,
+	// the default timeout is used if not set
+	Timeout int64 `toml:"timeout" json:"timeout"`
+}
+
+// Target represents the storage of backup files, object name is separated by '/'
+type Target interface {
+	// Put writes the object with given name, the size is the number of bytes which reader returns
+	Put(name string, reader io.Reader, size int64) error
+	// Get returns the reader of object, return ErrNotExist if not exist, caller must close reader
+	Get(name string) (io.ReadCloser, error)
+	// List returns the names of direct children under prefix, return empty if not exist
+	List(prefix string) ([]string, error)
+	// Delete deletes the object and all objects under it
+	Delete(name string) error
+}
+
+// NewTarget creates backup target based on config
+func NewTarget(cfg Config) (Target, error) {
+	if len(cfg.URL) == 0 {
+		return nil, fmt.Errorf("backup target url cannot be empty")
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("parse backup target url error:%s", err)
+	}
+	switch u.Scheme {
+	case "", "file":
+		return newLocalTarget(u.Path), nil
+	case "s3":
+		accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+		secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+		if len(accessKey) == 0 || len(secretKey) == 0 {
+			return nil, fmt.Errorf("s3 backup target need credentials from env AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY")
+		}
+		return newS3Target(s3Option{
+			endpoint:  cfg.Endpoint,
+			region:    cfg.Region,
+			bucket:    u.Host,
+			prefix:    strings.Trim(u.Path, "/"),
+			accessKey: accessKey,
+			secretKey: secretKey,
+			timeout:   time.Duration(cfg.Timeout) * time.Second,
+		}), nil
+	default:
+		return nil, fmt.Errorf("not support backup target:%s", u.Scheme)
+	}
+}
+
+// joinName joins the parts of object name using '/'
+func joinName(parts ...string) string {
+	var names []string
+	for _, part := range parts {
+		part = strings.Trim(part, "/")
+		if len(part) > 0 {
+			names = append(names, part)
+		}
+	}
+	return strings.Join(names, "/")
+}
@@ -0,0 +1,97 @@
+package backup
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testPath = "test_data"
+
+func TestNewTarget(t *testing.T) {
+	_, err := NewTarget(Config{})
+	assert.NotNil(t, err)
+	_, err = NewTarget(Config{URL: "hdfs://backup"})
+	assert.NotNil(t, err)
+	_, err = NewTarget(Config{URL: "%zz"})
+	assert.NotNil(t, err)
+
+	target, err := NewTarget(Config{URL: "file:///tmp/backup"})
+	assert.Nil(t, err)
+	assert.Equal(t, "/tmp/backup", target.(*localTarget).root)
+
+	_ = os.Setenv("AWS_ACCESS_KEY_ID", "")
+	_, err = NewTarget(Config{URL: "s3://bucket/prefix"})
+	assert.NotNil(t, err)
+	_ = os.Setenv("AWS_ACCESS_KEY_ID", "ak")
+	_ = os.Setenv("AWS_SECRET_ACCESS_KEY", "sk")
+	defer func() {
+		_ = os.Unsetenv("AWS_ACCESS_KEY_ID")
+		_ = os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+	}()
+	target, err = NewTarget(Config{URL: "s3://bucket/prefix/"})
+	assert.Nil(t, err)
+	s3 := target.(*s3Target)
+	assert.Equal(t, "bucket", s3.option.bucket)
+	assert.Equal(t, "prefix", s3.option.prefix)
+	assert.Equal(t, "https://s3.us-east-1.amazonaws.com", s3.option.endpoint)
+	assert.Equal(t, defaultTimeout, s3.client.Timeout)
+	target, err = NewTarget(Config{URL: "s3://bucket", Timeout: 30})
+	assert.Nil(t, err)
+	assert.Equal(t, 30*time.Second, target.(*s3Target).client.Timeout)
+}
+
+func TestLocalTarget(t *testing.T) {
+	defer func() {
+		_ = os.RemoveAll(testPath)
+	}()
+	target, _ := NewTarget(Config{URL: testPath})
+	testTarget(t, target)
+
+	// size mismatch
+	err := target.Put("a/b", bytes.NewReader([]byte("abc")), 10)
+	assert.NotNil(t, err)
+	assert.False(t, exist(filepath.Join(testPath, "a", "b.tmp")))
+}
+
+// testTarget tests the common behaviors of target
+func testTarget(t *testing.T, target Target) {
+	names, err := target.List("")
+	assert.Nil(t, err)
+	assert.Empty(t, names)
+
+	assert.Nil(t, target.Put("set1/MANIFEST", bytes.NewReader([]byte("manifest")), 8))
+	assert.Nil(t, target.Put("set1/db/node/000001.sst", bytes.NewReader([]byte("sst")), 3))
+	assert.Nil(t, target.Put("set2/MANIFEST", bytes.NewReader(nil), 0))
+
+	names, err = target.List("")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"set1", "set2"}, names)
+	names, err = target.List("set1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"MANIFEST", "db"}, names)
+
+	reader, err := target.Get("set1/MANIFEST")
+	assert.Nil(t, err)
+	data, _ := ioutil.ReadAll(reader)
+	_ = reader.Close()
+	assert.Equal(t, "manifest", string(data))
+	_, err = target.Get("set3/MANIFEST")
+	assert.Equal(t, ErrNotExist, err)
+
+	assert.Nil(t, target.Delete("set1"))
+	names, _ = target.List("")
+	assert.Equal(t, []string{"set2"}, names)
+	names, _ = target.List("set1")
+	assert.Empty(t, names)
+}
+
+func exist(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,112 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	brokerrpc "github.com/eleme/lindb/broker/rpc"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/rpc"
+	"github.com/eleme/lindb/rpc/proto/common"
+)
+
+// Writer writes a batch of points into broker
+type Writer interface {
+	// Write writes the points into database
+	Write(database string, points []Point) error
+	// Close closes the connection of broker
+	Close() error
+}
+
+// Querier executes the query against broker
+type Querier interface {
+	// Query executes the query, returns the result set, returns err if fail
+	Query(request *models.QueryRequest) (*models.ResultSet, error)
+}
+
+// WriteRequest represents the batch of points sent by rpc writer, which is encoded as json
+type WriteRequest struct {
+	Database string  `json:"database"`
+	Points   []Point `json:"points"`
+}
+
+// rpcWriter writes the points by broker rpc client
+type rpcWriter struct {
+	client  brokerrpc.BrokerClient
+	timeout time.Duration
+}
+
+// NewRPCWriter creates the writer which writes points by broker rpc service
+func NewRPCWriter(address string, timeout time.Duration) (Writer, error) {
+	client := brokerrpc.NewBrokerClient(address)
+	if err := client.Init(); err != nil {
+		return nil, fmt.Errorf("connect broker[%s] error:%s", address, err)
+	}
+	return &rpcWriter{client: client, timeout: timeout}, nil
+}
+
+// Write writes the points into database, the batch of points is encoded as json
+func (w *rpcWriter) Write(database string, points []Point) error {
+	data, err := json.Marshal(&WriteRequest{Database: database, Points: points})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), w.timeout)
+	defer cancel()
+	resp, err := w.client.WritePoints(ctx, &common.Request{Data: data})
+	if err != nil {
+		return err
+	}
+	return rpc.ResponseToError(resp)
+}
+
+// Close closes the connection of broker
+func (w *rpcWriter) Close() error {
+	return w.client.Close()
+}
+
+// httpQuerier executes the query by broker http query api
+type httpQuerier struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPQuerier creates the querier which executes query by broker http api
+func NewHTTPQuerier(address string, timeout time.Duration) Querier {
+	return &httpQuerier{
+		url:    strings.TrimSuffix(address, "/") + "/api/v1/query",
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Query posts the query to broker, decodes the result set from json response,
+// returns err if status code isn't 200
+func (q *httpQuerier) Query(request *models.QueryRequest) (*models.ResultSet, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := q.client.Post(q.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("query error, status:%d, msg:%s", resp.StatusCode, msg)
+	}
+	rs := &models.ResultSet{}
+	if err := json.NewDecoder(resp.Body).Decode(rs); err != nil {
+		return nil, fmt.Errorf("decode result set error:%s", err)
+	}
+	return rs, nil
+}
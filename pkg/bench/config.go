@@ -0,0 +1,119 @@
+package bench
+
+import (
+	"fmt"
+)
+
+// Defines the distributions of tag values
+const (
+	// Uniform picks each tag value with same probability
+	Uniform = "uniform"
+	// Zipf picks tag values by zipf distribution, a few tag values are hot, which is common in production
+	Zipf = "zipf"
+)
+
+// Defines the field types of generated points
+const (
+	SumField = "sum"
+	MinField = "min"
+	MaxField = "max"
+)
+
+// Config represents the workload of benchmark
+type Config struct {
+	BrokerRPC  string `toml:"brokerRPC"`  // rpc address of broker for writing, e.g. localhost:9001
+	BrokerHTTP string `toml:"brokerHTTP"` // http address of broker for querying, e.g. http://localhost:9000
+	Database   string `toml:"database"`
+	Duration   int64  `toml:"duration"` // seconds of running benchmark
+
+	NumOfMetrics int         `toml:"numOfMetrics"`
+	NumOfFields  int         `toml:"numOfFields"` // num. of fields of each metric
+	FieldTypes   []string    `toml:"fieldTypes"`  // types of fields, picked by the index of field
+	Tags         []TagOption `toml:"tags"`
+	Distribution string      `toml:"distribution"` // distribution of tag values, uniform or zipf
+
+	WriteRate int `toml:"writeRate"` // points per second of all writers, zero means no limit
+	BatchSize int `toml:"batchSize"` // num. of points in a write request
+	Writers   int `toml:"writers"`   // num. of concurrent writers
+
+	QueryRate int           `toml:"queryRate"` // queries per second, zero means no query
+	Queries   []QueryOption `toml:"queries"`   // query mix, each query is picked by weight
+}
+
+// TagOption represents the tag key and the cardinality of tag values
+type TagOption struct {
+	Key         string `toml:"key"`
+	Cardinality int    `toml:"cardinality"`
+}
+
+// QueryOption represents a kind of query in query mix
+type QueryOption struct {
+	Weight      int      `toml:"weight"`
+	NumOfFields int      `toml:"numOfFields"` // num. of fields selected
+	GroupBy     []string `toml:"groupBy"`     // tag keys of group by
+	TimeRange   int64    `toml:"timeRange"`   // seconds of time range, end time is now
+	Interval    int64    `toml:"interval"`    // seconds of interval
+}
+
+// NewDefaultConfig returns the default workload of benchmark
+func NewDefaultConfig() Config {
+	return Config{
+		BrokerRPC:    "localhost:9001",
+		BrokerHTTP:   "http://localhost:9000",
+		Database:     "bench",
+		Duration:     60,
+		NumOfMetrics: 10,
+		NumOfFields:  2,
+		FieldTypes:   []string{SumField, MaxField},
+		Tags: []TagOption{
+			{Key: "host", Cardinality: 1000},
+			{Key: "zone", Cardinality: 3},
+		},
+		Distribution: Zipf,
+		WriteRate:    10000,
+		BatchSize:    100,
+		Writers:      4,
+		QueryRate:    10,
+		Queries: []QueryOption{
+			{Weight: 8, NumOfFields: 1, TimeRange: 3600, Interval: 10},
+			{Weight: 2, NumOfFields: 2, GroupBy: []string{"zone"}, TimeRange: 86400, Interval: 300},
+		},
+	}
+}
+
+// validate checks the workload of benchmark
+func (c *Config) validate() error {
+	if len(c.Database) == 0 {
+		return fmt.Errorf("database is required")
+	}
+	if c.Duration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+	if c.NumOfMetrics <= 0 || c.NumOfFields <= 0 {
+		return fmt.Errorf("num. of metrics/fields must be positive")
+	}
+	for _, fieldType := range c.FieldTypes {
+		switch fieldType {
+		case SumField, MinField, MaxField:
+		default:
+			return fmt.Errorf("field type[%s] isn't supported", fieldType)
+		}
+	}
+	for _, tag := range c.Tags {
+		if tag.Cardinality <= 0 {
+			return fmt.Errorf("cardinality of tag[%s] must be positive", tag.Key)
+		}
+	}
+	switch c.Distribution {
+	case "", Uniform, Zipf:
+	default:
+		return fmt.Errorf("distribution[%s] isn't supported", c.Distribution)
+	}
+	if c.BatchSize <= 0 || c.Writers <= 0 {
+		return fmt.Errorf("batch size/writers must be positive")
+	}
+	if c.QueryRate > 0 && len(c.Queries) == 0 {
+		return fmt.Errorf("query mix is required when query rate is positive")
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package bench
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/eleme/lindb/models"
+)
+
+// Point represents the metric point written into broker, which is encoded as json
+type Point struct {
+	Name      string            `json:"name"`
+	Timestamp int64             `json:"timestamp"`
+	Tags      map[string]string `json:"tags"`
+	Fields    []Field           `json:"fields"`
+}
+
+// Field represents the field of metric point
+type Field struct {
+	Name  string  `json:"name"`
+	Type  string  `json:"type"`
+	Value float64 `json:"value"`
+}
+
+// Generator generates the points and queries of workload, it's not safe for concurrent use.
+type Generator struct {
+	cfg        Config
+	rand       *rand.Rand
+	tagValues  []func() int // picks the index of tag value for each tag
+	weightSum  int
+	metricName func(idx int) string
+}
+
+// NewGenerator creates the generator of workload with the seed of random
+func NewGenerator(cfg Config, seed int64) *Generator {
+	r := rand.New(rand.NewSource(seed))
+	g := &Generator{
+		cfg:  cfg,
+		rand: r,
+		metricName: func(idx int) string {
+			return fmt.Sprintf("bench_metric_%d", idx)
+		},
+	}
+	for _, tag := range cfg.Tags {
+		cardinality := tag.Cardinality
+		if cfg.Distribution == Zipf && cardinality > 1 {
+			zipf := rand.NewZipf(r, 1.1, 1, uint64(cardinality-1))
+			g.tagValues = append(g.tagValues, func() int { return int(zipf.Uint64()) })
+		} else {
+			g.tagValues = append(g.tagValues, func() int { return r.Intn(cardinality) })
+		}
+	}
+	for _, query := range cfg.Queries {
+		g.weightSum += query.Weight
+	}
+	return g
+}
+
+// NextPoints generates n points at timestamp
+func (g *Generator) NextPoints(n int, timestamp int64) []Point {
+	points := make([]Point, n)
+	for i := range points {
+		points[i] = g.nextPoint(timestamp)
+	}
+	return points
+}
+
+// nextPoint generates a point of random metric with the tags picked by distribution
+func (g *Generator) nextPoint(timestamp int64) Point {
+	tags := make(map[string]string, len(g.cfg.Tags))
+	for idx, tag := range g.cfg.Tags {
+		tags[tag.Key] = fmt.Sprintf("%s_%d", tag.Key, g.tagValues[idx]())
+	}
+	fields := make([]Field, g.cfg.NumOfFields)
+	for idx := range fields {
+		fields[idx] = Field{
+			Name:  fieldName(idx),
+			Type:  g.fieldType(idx),
+			Value: g.rand.Float64() * 100,
+		}
+	}
+	return Point{
+		Name:      g.metricName(g.rand.Intn(g.cfg.NumOfMetrics)),
+		Timestamp: timestamp,
+		Tags:      tags,
+		Fields:    fields,
+	}
+}
+
+// NextQuery generates the query picked from query mix by weight, the end of time range is now,
+// returns nil if no query in query mix.
+func (g *Generator) NextQuery(now int64) *models.QueryRequest {
+	if g.weightSum <= 0 {
+		return nil
+	}
+	n := g.rand.Intn(g.weightSum)
+	var option QueryOption
+	for _, option = range g.cfg.Queries {
+		if n < option.Weight {
+			break
+		}
+		n -= option.Weight
+	}
+	numOfFields := option.NumOfFields
+	if numOfFields <= 0 || numOfFields > g.cfg.NumOfFields {
+		numOfFields = g.cfg.NumOfFields
+	}
+	fields := make([]models.QueryField, numOfFields)
+	for idx := range fields {
+		fields[idx] = models.QueryField{Alias: fieldName(idx), Expr: fieldName(idx)}
+	}
+	return &models.QueryRequest{
+		Database: g.cfg.Database,
+		Metric:   g.metricName(g.rand.Intn(g.cfg.NumOfMetrics)),
+		Fields:   fields,
+		Start:    now - option.TimeRange*1000,
+		End:      now,
+		Interval: option.Interval * 1000,
+		GroupBy:  option.GroupBy,
+	}
+}
+
+// fieldType returns the type of field by the index of field, default is sum
+func (g *Generator) fieldType(idx int) string {
+	if len(g.cfg.FieldTypes) == 0 {
+		return SumField
+	}
+	return g.cfg.FieldTypes[idx%len(g.cfg.FieldTypes)]
+}
+
+// fieldName returns the name of field by the index of field
+func fieldName(idx int) string {
+	return fmt.Sprintf("f%d", idx)
+}
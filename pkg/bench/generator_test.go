@@ -0,0 +1,84 @@
+package bench
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerator_NextPoints(t *testing.T) {
+	cfg := NewDefaultConfig()
+	g := NewGenerator(cfg, 1)
+	points := g.NextPoints(1000, 100)
+	assert.Len(t, points, 1000)
+	hosts := make(map[string]int)
+	for _, point := range points {
+		assert.Equal(t, int64(100), point.Timestamp)
+		assert.Len(t, point.Tags, 2)
+		assert.Len(t, point.Fields, 2)
+		assert.Equal(t, SumField, point.Fields[0].Type)
+		assert.Equal(t, MaxField, point.Fields[1].Type)
+		hosts[point.Tags["host"]]++
+	}
+	// zipf distribution, first tag value is the hottest
+	assert.True(t, hosts["host_0"] > hosts["host_1"])
+	assert.True(t, len(hosts) <= 1000)
+
+	// same seed generates same points
+	assert.Equal(t, points, NewGenerator(cfg, 1).NextPoints(1000, 100))
+
+	cfg.Distribution = Uniform
+	cfg.FieldTypes = nil
+	cfg.Tags = []TagOption{{Key: "host", Cardinality: 1}}
+	points = NewGenerator(cfg, 1).NextPoints(10, 100)
+	for _, point := range points {
+		assert.Equal(t, "host_0", point.Tags["host"])
+		assert.Equal(t, SumField, point.Fields[0].Type)
+	}
+}
+
+func TestGenerator_NextQuery(t *testing.T) {
+	cfg := NewDefaultConfig()
+	g := NewGenerator(cfg, 1)
+	groupBy := 0
+	for i := 0; i < 1000; i++ {
+		query := g.NextQuery(100000000)
+		assert.Equal(t, "bench", query.Database)
+		assert.Equal(t, int64(100000000), query.End)
+		if len(query.GroupBy) > 0 {
+			groupBy++
+			assert.Len(t, query.Fields, 2)
+			assert.Equal(t, int64(100000000-86400*1000), query.Start)
+			assert.Equal(t, int64(300*1000), query.Interval)
+		} else {
+			assert.Len(t, query.Fields, 1)
+			assert.Equal(t, int64(100000000-3600*1000), query.Start)
+		}
+	}
+	// picked by weight 8:2
+	assert.True(t, groupBy > 100 && groupBy < 300)
+
+	cfg.Queries = nil
+	assert.Nil(t, NewGenerator(cfg, 1).NextQuery(100))
+}
+
+func TestConfig_validate(t *testing.T) {
+	cfg := NewDefaultConfig()
+	assert.Nil(t, cfg.validate())
+
+	cases := []func(cfg *Config){
+		func(cfg *Config) { cfg.Database = "" },
+		func(cfg *Config) { cfg.Duration = 0 },
+		func(cfg *Config) { cfg.NumOfMetrics = 0 },
+		func(cfg *Config) { cfg.FieldTypes = []string{"histogram"} },
+		func(cfg *Config) { cfg.Tags[0].Cardinality = 0 },
+		func(cfg *Config) { cfg.Distribution = "normal" },
+		func(cfg *Config) { cfg.BatchSize = 0 },
+		func(cfg *Config) { cfg.Queries = nil },
+	}
+	for _, c := range cases {
+		cfg := NewDefaultConfig()
+		c(&cfg)
+		assert.NotNil(t, cfg.validate())
+	}
+}
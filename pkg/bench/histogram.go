@@ -0,0 +1,96 @@
+package bench
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxSamples is the max num. of latency samples kept by histogram, samples are replaced randomly after full
+const maxSamples = 100000
+
+// Latency represents the percentiles of latency
+type Latency struct {
+	Count int64         `json:"count"`
+	Mean  time.Duration `json:"mean"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P99   time.Duration `json:"p99"`
+	Max   time.Duration `json:"max"`
+}
+
+// Histogram records the latency samples, it's safe for concurrent use
+type Histogram struct {
+	mutex   sync.Mutex
+	samples []time.Duration
+	count   int64
+	sum     time.Duration
+	max     time.Duration
+	next    uint64 // state of xorshift random for reservoir sampling
+}
+
+// NewHistogram creates latency histogram
+func NewHistogram() *Histogram {
+	return &Histogram{next: 88172645463325252}
+}
+
+// Record records a latency sample
+func (h *Histogram) Record(latency time.Duration) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.count++
+	h.sum += latency
+	if latency > h.max {
+		h.max = latency
+	}
+	if len(h.samples) < maxSamples {
+		h.samples = append(h.samples, latency)
+		return
+	}
+	// reservoir sampling, keeps each sample with same probability
+	if idx := h.random() % uint64(h.count); idx < maxSamples {
+		h.samples[idx] = latency
+	}
+}
+
+// Latency returns the percentiles of recorded latency
+func (h *Histogram) Latency() Latency {
+	h.mutex.Lock()
+	samples := append([]time.Duration(nil), h.samples...)
+	result := Latency{Count: h.count, Max: h.max}
+	if h.count > 0 {
+		result.Mean = h.sum / time.Duration(h.count)
+	}
+	h.mutex.Unlock()
+
+	if len(samples) == 0 {
+		return result
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i] < samples[j]
+	})
+	result.P50 = percentile(samples, 0.5)
+	result.P90 = percentile(samples, 0.9)
+	result.P99 = percentile(samples, 0.99)
+	return result
+}
+
+// random returns next pseudo random number by xorshift
+func (h *Histogram) random() uint64 {
+	h.next ^= h.next << 13
+	h.next ^= h.next >> 7
+	h.next ^= h.next << 17
+	return h.next
+}
+
+// percentile returns the percentile of sorted samples
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	idx := int(float64(len(sorted))*p+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
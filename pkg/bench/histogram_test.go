@@ -0,0 +1,36 @@
+package bench
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogram_Latency(t *testing.T) {
+	h := NewHistogram()
+	assert.Equal(t, Latency{}, h.Latency())
+
+	for i := 1; i <= 100; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+	latency := h.Latency()
+	assert.Equal(t, int64(100), latency.Count)
+	assert.Equal(t, 50*time.Millisecond+500*time.Microsecond, latency.Mean)
+	assert.Equal(t, 50*time.Millisecond, latency.P50)
+	assert.Equal(t, 90*time.Millisecond, latency.P90)
+	assert.Equal(t, 99*time.Millisecond, latency.P99)
+	assert.Equal(t, 100*time.Millisecond, latency.Max)
+}
+
+func TestHistogram_Sampling(t *testing.T) {
+	h := NewHistogram()
+	for i := 0; i < maxSamples*2; i++ {
+		h.Record(time.Duration(i % 100))
+	}
+	latency := h.Latency()
+	assert.Equal(t, int64(maxSamples*2), latency.Count)
+	assert.Len(t, h.samples, maxSamples)
+	assert.Equal(t, time.Duration(99), latency.Max)
+	assert.InDelta(t, 50, int64(latency.P50), 2)
+}
@@ -0,0 +1,181 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/timeutil"
+)
+
+var log = logger.GetLogger("pkg/bench")
+
+// Report represents the result of benchmark
+type Report struct {
+	Duration time.Duration `json:"duration"`
+
+	Points          int64   `json:"points"`
+	WriteErrors     int64   `json:"writeErrors"`
+	WriteThroughput float64 `json:"writeThroughput"` // points per second
+	WriteLatency    Latency `json:"writeLatency"`    // latency of write request
+
+	Queries         int64   `json:"queries"`
+	QueryErrors     int64   `json:"queryErrors"`
+	QueryThroughput float64 `json:"queryThroughput"` // queries per second
+	QueryLatency    Latency `json:"queryLatency"`
+}
+
+// String returns the readable report
+func (r *Report) String() string {
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "duration: %s\n", r.Duration)
+	_, _ = fmt.Fprintf(&sb, "write: points=%d errors=%d throughput=%.2f points/s\n",
+		r.Points, r.WriteErrors, r.WriteThroughput)
+	_, _ = fmt.Fprintf(&sb, "  latency: %s\n", latencyString(r.WriteLatency))
+	_, _ = fmt.Fprintf(&sb, "query: queries=%d errors=%d throughput=%.2f queries/s\n",
+		r.Queries, r.QueryErrors, r.QueryThroughput)
+	_, _ = fmt.Fprintf(&sb, "  latency: %s\n", latencyString(r.QueryLatency))
+	return sb.String()
+}
+
+// Runner runs the workload against broker and reports throughput and latency percentiles
+type Runner struct {
+	cfg     Config
+	writer  Writer
+	querier Querier
+
+	points       int64
+	writeErrors  int64
+	queries      int64
+	queryErrors  int64
+	writeLatency *Histogram
+	queryLatency *Histogram
+}
+
+// NewRunner creates the runner of workload, querier can be nil if query rate is zero
+func NewRunner(cfg Config, writer Writer, querier Querier) (*Runner, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if cfg.QueryRate > 0 && querier == nil {
+		return nil, fmt.Errorf("querier is required when query rate is positive")
+	}
+	return &Runner{
+		cfg:          cfg,
+		writer:       writer,
+		querier:      querier,
+		writeLatency: NewHistogram(),
+		queryLatency: NewHistogram(),
+	}, nil
+}
+
+// Run runs the workload until duration elapsed or ctx is canceled
+func (r *Runner) Run(ctx context.Context) *Report {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.cfg.Duration)*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < r.cfg.Writers; i++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			r.runWriter(ctx, NewGenerator(r.cfg, start.UnixNano()+int64(idx)))
+		}(i)
+	}
+	if r.cfg.QueryRate > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.runQuerier(ctx, NewGenerator(r.cfg, start.UnixNano()-1))
+		}()
+	}
+	wg.Wait()
+	return r.report(time.Since(start))
+}
+
+// runWriter writes the batch of points at the rate shared by all writers
+func (r *Runner) runWriter(ctx context.Context, generator *Generator) {
+	var ticker *time.Ticker
+	if r.cfg.WriteRate > 0 {
+		interval := time.Second * time.Duration(r.cfg.BatchSize*r.cfg.Writers) / time.Duration(r.cfg.WriteRate)
+		if interval > 0 {
+			ticker = time.NewTicker(interval)
+			defer ticker.Stop()
+		}
+	}
+	for {
+		if ticker != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		} else if ctx.Err() != nil {
+			return
+		}
+		points := generator.NextPoints(r.cfg.BatchSize, timeutil.Now())
+		begin := time.Now()
+		err := r.writer.Write(r.cfg.Database, points)
+		r.writeLatency.Record(time.Since(begin))
+		if err != nil {
+			atomic.AddInt64(&r.writeErrors, 1)
+			log.Error("write points error", logger.Error(err))
+			continue
+		}
+		atomic.AddInt64(&r.points, int64(len(points)))
+	}
+}
+
+// runQuerier executes the query picked from query mix at query rate
+func (r *Runner) runQuerier(ctx context.Context, generator *Generator) {
+	interval := time.Second / time.Duration(r.cfg.QueryRate)
+	if interval <= 0 {
+		interval = time.Nanosecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		begin := time.Now()
+		_, err := r.querier.Query(generator.NextQuery(timeutil.Now()))
+		r.queryLatency.Record(time.Since(begin))
+		atomic.AddInt64(&r.queries, 1)
+		if err != nil {
+			atomic.AddInt64(&r.queryErrors, 1)
+			log.Error("query error", logger.Error(err))
+		}
+	}
+}
+
+// report builds the report of benchmark
+func (r *Runner) report(elapsed time.Duration) *Report {
+	report := &Report{
+		Duration:     elapsed,
+		Points:       atomic.LoadInt64(&r.points),
+		WriteErrors:  atomic.LoadInt64(&r.writeErrors),
+		WriteLatency: r.writeLatency.Latency(),
+		Queries:      atomic.LoadInt64(&r.queries),
+		QueryErrors:  atomic.LoadInt64(&r.queryErrors),
+		QueryLatency: r.queryLatency.Latency(),
+	}
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		report.WriteThroughput = float64(report.Points) / seconds
+		report.QueryThroughput = float64(report.Queries) / seconds
+	}
+	return report
+}
+
+// latencyString returns the readable percentiles of latency
+func latencyString(latency Latency) string {
+	return fmt.Sprintf("count=%d mean=%s p50=%s p90=%s p99=%s max=%s",
+		latency.Count, latency.Mean, latency.P50, latency.P90, latency.P99, latency.Max)
+}
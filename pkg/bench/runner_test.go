@@ -0,0 +1,123 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+)
+
+type fakeWriter struct {
+	batches int64
+	fail    bool
+}
+
+func (w *fakeWriter) Write(database string, points []Point) error {
+	atomic.AddInt64(&w.batches, 1)
+	if w.fail {
+		return fmt.Errorf("write error")
+	}
+	return nil
+}
+
+func (w *fakeWriter) Close() error {
+	return nil
+}
+
+type fakeQuerier struct {
+	fail bool
+}
+
+func (q *fakeQuerier) Query(request *models.QueryRequest) (*models.ResultSet, error) {
+	if q.fail {
+		return nil, fmt.Errorf("query error")
+	}
+	return &models.ResultSet{}, nil
+}
+
+func newTestConfig() Config {
+	cfg := NewDefaultConfig()
+	cfg.Duration = 1
+	cfg.WriteRate = 2000
+	cfg.BatchSize = 10
+	cfg.Writers = 2
+	cfg.QueryRate = 20
+	return cfg
+}
+
+func TestRunner_Run(t *testing.T) {
+	writer := &fakeWriter{}
+	runner, err := NewRunner(newTestConfig(), writer, &fakeQuerier{})
+	assert.Nil(t, err)
+	report := runner.Run(context.TODO())
+	assert.True(t, report.Duration >= time.Second)
+	assert.True(t, report.Points > 0)
+	assert.Equal(t, atomic.LoadInt64(&writer.batches)*10, report.Points)
+	assert.True(t, report.Points <= 2100)
+	assert.Equal(t, int64(0), report.WriteErrors)
+	assert.True(t, report.WriteThroughput > 0)
+	assert.True(t, report.Queries > 0)
+	assert.Equal(t, report.Queries, report.QueryLatency.Count)
+	assert.Equal(t, int64(0), report.QueryErrors)
+	assert.NotEmpty(t, report.String())
+}
+
+func TestRunner_Run_fail(t *testing.T) {
+	runner, err := NewRunner(newTestConfig(), &fakeWriter{fail: true}, &fakeQuerier{fail: true})
+	assert.Nil(t, err)
+	ctx, cancel := context.WithTimeout(context.TODO(), 200*time.Millisecond)
+	defer cancel()
+	report := runner.Run(ctx)
+	assert.True(t, report.Duration < time.Second)
+	assert.Equal(t, int64(0), report.Points)
+	assert.True(t, report.WriteErrors > 0)
+	assert.Equal(t, report.Queries, report.QueryErrors)
+}
+
+func TestNewRunner(t *testing.T) {
+	cfg := newTestConfig()
+	_, err := NewRunner(cfg, &fakeWriter{}, nil)
+	assert.NotNil(t, err)
+	cfg.Database = ""
+	_, err = NewRunner(cfg, &fakeWriter{}, &fakeQuerier{})
+	assert.NotNil(t, err)
+}
+
+func TestHTTPQuerier_Query(t *testing.T) {
+	status := http.StatusOK
+	body := `{"startTime":1000,"interval":10,"pointCount":2,"columns":[{"name":"f","type":"integer"}],` +
+		`"series":[{"metric":"cpu","fields":{"f":[1,null]}}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/query", r.URL.Path)
+		assert.Equal(t, http.MethodPost, r.Method)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	querier := NewHTTPQuerier(server.URL+"/", time.Second)
+	query := &models.QueryRequest{Database: "bench", Metric: "cpu"}
+	rs, err := querier.Query(query)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1000), rs.StartTime)
+	assert.Equal(t, []models.Column{{Name: "f", Type: models.IntegerValue}}, rs.Columns)
+	assert.Len(t, rs.Series, 1)
+	assert.Equal(t, "cpu", rs.Series[0].Metric)
+	assert.Equal(t, 1.0, rs.Series[0].Fields["f"][0])
+	assert.True(t, math.IsNaN(rs.Series[0].Fields["f"][1]))
+
+	body = "bad"
+	_, err = querier.Query(query)
+	assert.NotNil(t, err)
+	status = http.StatusNotFound
+	_, err = querier.Query(query)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,267 @@
+package encoding
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// defaultBulkSize is the initial capacity of the buffers of block decoder
+const defaultBulkSize = 256
+
+var blockDecoderPool = sync.Pool{
+	New: func() interface{} {
+		return &BlockDecoder{
+			uints:  make([]uint64, 0, defaultBulkSize),
+			floats: make([]float64, 0, defaultBulkSize),
+			ints:   make([]int64, 0, defaultBulkSize),
+			exists: make([]bool, 0, defaultBulkSize),
+		}
+	},
+}
+
+// GetBlockDecoder gets a block decoder from pool, puts it back by PutBlockDecoder after the values are consumed
+func GetBlockDecoder() *BlockDecoder {
+	return blockDecoderPool.Get().(*BlockDecoder)
+}
+
+// PutBlockDecoder puts the block decoder back into pool, the values decoded by it cannot be used any more
+func PutBlockDecoder(d *BlockDecoder) {
+	if d == nil {
+		return
+	}
+	blockDecoderPool.Put(d)
+}
+
+// BlockDecoder decodes all values of a compressed block in one call, unlike XORDecoder/TSDDecoder which
+// decode value by value, it reads the bit stream by words without per-bit function calls.
+// The decoded values are stored in the reusable buffers of decoder, which are overwritten by next decoding,
+// so that there is no allocation after the buffers are large enough. It's not safe for concurrent use.
+type BlockDecoder struct {
+	uints  []uint64
+	floats []float64
+	ints   []int64
+	exists []bool
+}
+
+// DecodeXOR decodes count values from the xor compressed data which is built by XOREncoder
+func (d *BlockDecoder) DecodeXOR(data []byte, count int) ([]uint64, error) {
+	values, err := decodeXOR(data, count, d.uints[:0])
+	d.uints = values
+	return values, err
+}
+
+// DecodeFloats decodes count float values from the xor compressed data which is built by FloatEncoder
+func (d *BlockDecoder) DecodeFloats(data []byte, count int) ([]float64, error) {
+	values, err := d.DecodeXOR(data, count)
+	if err != nil {
+		return nil, err
+	}
+	floats := growFloats(d.floats, len(values))
+	for i, v := range values {
+		floats[i] = math.Float64frombits(v)
+	}
+	d.floats = floats
+	return floats, nil
+}
+
+// DecodeInt64s decodes count int64 values from the xor compressed data which is built by XOREncoder
+func (d *BlockDecoder) DecodeInt64s(data []byte, count int) ([]int64, error) {
+	values, err := d.DecodeXOR(data, count)
+	if err != nil {
+		return nil, err
+	}
+	ints := growInts(d.ints, len(values))
+	for i, v := range values {
+		ints[i] = int64(v)
+	}
+	d.ints = ints
+	return ints, nil
+}
+
+// DecodeTSD decodes the time series block which is built by TSDEncoder, returns the start time slot,
+// the values indexed by time slot and if the time slot has value, the value of empty time slot is 0.
+func (d *BlockDecoder) DecodeTSD(data []byte) (startTime int, values []uint64, exists []bool, err error) {
+	startTime, count, timeSlots, valueBuf, err := readTSDBlock(data)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	exists = growBools(d.exists, count)
+	numOfValues := 0
+	for i := 0; i < count; i++ {
+		has := timeSlots[i>>3]&(0x80>>uint(i&7)) != 0
+		exists[i] = has
+		if has {
+			numOfValues++
+		}
+	}
+	d.exists = exists
+
+	// decodes the values of time slots which have value, then expands them to the time slots backward
+	values, err = decodeXOR(valueBuf, numOfValues, d.uints[:0])
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if cap(values) < count {
+		values = append(values, make([]uint64, count-len(values))...)
+	}
+	values = values[:count]
+	d.uints = values
+	j := numOfValues - 1
+	for i := count - 1; i >= 0 && i > j; i-- {
+		if exists[i] {
+			values[i] = values[j]
+			j--
+		} else {
+			values[i] = 0
+		}
+	}
+	return startTime, values, exists, nil
+}
+
+// DecodeTSDFloats decodes the time series block of float values which is built by TSDEncoder,
+// returns the start time slot and the values indexed by time slot, the value of empty time slot is NaN.
+func (d *BlockDecoder) DecodeTSDFloats(data []byte) (startTime int, values []float64, err error) {
+	startTime, uints, exists, err := d.DecodeTSD(data)
+	if err != nil {
+		return 0, nil, err
+	}
+	floats := growFloats(d.floats, len(uints))
+	for i, v := range uints {
+		if exists[i] {
+			floats[i] = math.Float64frombits(v)
+		} else {
+			floats[i] = math.NaN()
+		}
+	}
+	d.floats = floats
+	return startTime, floats, nil
+}
+
+// readTSDBlock reads the header of tsd block, returns the start time slot, number of time slots,
+// the bitmap of time slots and the xor compressed values
+func readTSDBlock(data []byte) (startTime, count int, timeSlots, values []byte, err error) {
+	var fields [3]uint64
+	pos := 0
+	for i := range fields {
+		v, n := binary.Uvarint(data[pos:])
+		if n <= 0 {
+			return 0, 0, nil, nil, fmt.Errorf("read tsd block header error")
+		}
+		fields[i] = v
+		pos += n
+	}
+	startTime, count = int(int32(fields[0])), int(int32(fields[1]))
+	slotsLen := int(fields[2])
+	if count < 0 || pos+slotsLen > len(data) || slotsLen*8 < count {
+		return 0, 0, nil, nil, fmt.Errorf("read tsd block time slots error")
+	}
+	timeSlots = data[pos : pos+slotsLen]
+	pos += slotsLen
+	valuesLen, n := binary.Uvarint(data[pos:])
+	if n <= 0 || pos+n+int(valuesLen) > len(data) {
+		return 0, 0, nil, nil, fmt.Errorf("read tsd block values error")
+	}
+	pos += n
+	return startTime, count, timeSlots, data[pos : pos+int(valuesLen)], nil
+}
+
+// decodeXOR decodes count values from xor compressed data, appends them to dst.
+// The data is read by 64 bits word, reference XORDecoder for the data format.
+func decodeXOR(data []byte, count int, dst []uint64) ([]uint64, error) {
+	if count <= 0 {
+		return dst, nil
+	}
+	totalBits := len(data) * 8
+	pos := 0
+	if totalBits < firstValueLen {
+		return dst, fmt.Errorf("decode xor block error, no first value")
+	}
+	val := readBits(data, pos, firstValueLen)
+	pos += firstValueLen
+	dst = append(dst, val)
+	var leading, trailing int
+	for i := 1; i < count; i++ {
+		if pos >= totalBits {
+			return dst, fmt.Errorf("decode xor block error, expect %d values but %d", count, i)
+		}
+		// read delta control bit
+		if data[pos>>3]&(0x80>>uint(pos&7)) == 0 {
+			// same as previous
+			pos++
+			dst = append(dst, val)
+			continue
+		}
+		pos++
+		if pos >= totalBits {
+			return dst, fmt.Errorf("decode xor block error, expect %d values but %d", count, i)
+		}
+		// read control bit of block information
+		reuse := data[pos>>3]&(0x80>>uint(pos&7)) != 0
+		pos++
+		if !reuse {
+			if pos+12 > totalBits {
+				return dst, fmt.Errorf("decode xor block error, expect %d values but %d", count, i)
+			}
+			header := readBits(data, pos, 12)
+			pos += 12
+			leading = int(header >> 6)
+			trailing = 64 - leading - int(header&0x3f) - blockSizeAdjustment
+			if trailing < 0 {
+				return dst, fmt.Errorf("decode xor block error, invalid block size")
+			}
+		}
+		blockSize := 64 - leading - trailing
+		if blockSize <= 0 || pos+blockSize > totalBits {
+			return dst, fmt.Errorf("decode xor block error, expect %d values but %d", count, i)
+		}
+		val ^= readBits(data, pos, blockSize) << uint(trailing)
+		pos += blockSize
+		dst = append(dst, val)
+	}
+	return dst, nil
+}
+
+// readBits reads n(1~64) bits of data at bit position pos, the caller checks the bounds
+func readBits(data []byte, pos, n int) uint64 {
+	idx := pos >> 3
+	offset := uint(pos & 7)
+	var word uint64
+	if idx+8 <= len(data) {
+		word = binary.BigEndian.Uint64(data[idx:])
+	} else {
+		for i := idx; i < len(data); i++ {
+			word |= uint64(data[i]) << uint(56-8*(i-idx))
+		}
+	}
+	word <<= offset
+	if n > 64-int(offset) {
+		word |= uint64(data[idx+8]) >> (8 - offset)
+	}
+	return word >> uint(64-n)
+}
+
+// growFloats returns the float slice with length n, reuses the buffer if its capacity is enough
+func growFloats(buf []float64, n int) []float64 {
+	if cap(buf) < n {
+		return make([]float64, n)
+	}
+	return buf[:n]
+}
+
+// growInts returns the int64 slice with length n, reuses the buffer if its capacity is enough
+func growInts(buf []int64, n int) []int64 {
+	if cap(buf) < n {
+		return make([]int64, n)
+	}
+	return buf[:n]
+}
+
+// growBools returns the bool slice with length n, reuses the buffer if its capacity is enough
+func growBools(buf []bool, n int) []bool {
+	if cap(buf) < n {
+		return make([]bool, n)
+	}
+	return buf[:n]
+}
@@ -0,0 +1,160 @@
+package encoding
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/pkg/bit"
+)
+
+func newTestFloats(n int) []float64 {
+	r := rand.New(rand.NewSource(1))
+	values := make([]float64, n)
+	v := 100.0
+	for i := range values {
+		switch r.Intn(3) {
+		case 0:
+			// same as previous
+		case 1:
+			v += float64(r.Intn(10))
+		default:
+			v = r.Float64() * 1000
+		}
+		values[i] = v
+	}
+	return values
+}
+
+func encodeTestFloats(t testing.TB, values []float64) []byte {
+	encoder := NewFloatEncoder()
+	for _, v := range values {
+		assert.Nil(t, encoder.Write(v))
+	}
+	data, err := encoder.Bytes()
+	assert.Nil(t, err)
+	return data
+}
+
+func TestBlockDecoder_DecodeFloats(t *testing.T) {
+	values := newTestFloats(1000)
+	data := encodeTestFloats(t, values)
+
+	decoder := GetBlockDecoder()
+	defer PutBlockDecoder(decoder)
+	for i := 0; i < 2; i++ {
+		decoded, err := decoder.DecodeFloats(data, len(values))
+		assert.Nil(t, err)
+		assert.Equal(t, values, decoded)
+	}
+	// decode part of values
+	decoded, err := decoder.DecodeFloats(data, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, values[:10], decoded)
+
+	// same as value by value decoding
+	floatDecoder := NewFloatDecoder(data)
+	for _, v := range values {
+		assert.True(t, floatDecoder.Next())
+		assert.Equal(t, v, floatDecoder.Value())
+	}
+
+	// not enough data
+	_, err = decoder.DecodeFloats(data, 100000)
+	assert.NotNil(t, err)
+	_, err = decoder.DecodeFloats(data[:4], 1)
+	assert.NotNil(t, err)
+	values, err = decoder.DecodeFloats(nil, 0)
+	assert.Nil(t, err)
+	assert.Empty(t, values)
+	PutBlockDecoder(nil)
+}
+
+func TestBlockDecoder_DecodeInt64s(t *testing.T) {
+	values := []int64{76, 50, 50, 999999999, 100, -1, 0, math.MaxInt64, math.MinInt64}
+	encoder := NewXOREncoder()
+	for _, v := range values {
+		assert.Nil(t, encoder.Write(uint64(v)))
+	}
+	data, err := encoder.Bytes()
+	assert.Nil(t, err)
+
+	decoder := GetBlockDecoder()
+	defer PutBlockDecoder(decoder)
+	decoded, err := decoder.DecodeInt64s(data, len(values))
+	assert.Nil(t, err)
+	assert.Equal(t, values, decoded)
+	_, err = decoder.DecodeInt64s(data[:8], len(values))
+	assert.NotNil(t, err)
+}
+
+func TestBlockDecoder_DecodeTSD(t *testing.T) {
+	encoder := NewTSDEncoder(10)
+	encoder.AppendTime(bit.One)
+	encoder.AppendValue(math.Float64bits(10))
+	encoder.AppendTime(bit.One)
+	encoder.AppendValue(math.Float64bits(100))
+	encoder.AppendTime(bit.Zero)
+	encoder.AppendTime(bit.One)
+	encoder.AppendValue(math.Float64bits(50))
+	encoder.AppendTime(bit.Zero)
+	data, err := encoder.Bytes()
+	assert.Nil(t, err)
+
+	decoder := GetBlockDecoder()
+	defer PutBlockDecoder(decoder)
+	startTime, values, exists, err := decoder.DecodeTSD(data)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, startTime)
+	assert.Equal(t, []bool{true, true, false, true, false}, exists)
+	assert.Equal(t, []uint64{math.Float64bits(10), math.Float64bits(100), 0, math.Float64bits(50), 0}, values)
+
+	startTime, floats, err := decoder.DecodeTSDFloats(data)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, startTime)
+	assert.Equal(t, []float64{10, 100}, floats[:2])
+	assert.True(t, math.IsNaN(floats[2]))
+	assert.Equal(t, float64(50), floats[3])
+	assert.True(t, math.IsNaN(floats[4]))
+
+	// corrupted block
+	_, _, _, err = decoder.DecodeTSD(nil)
+	assert.NotNil(t, err)
+	_, _, _, err = decoder.DecodeTSD(data[:4])
+	assert.NotNil(t, err)
+	_, _, err = decoder.DecodeTSDFloats(data[:len(data)-2])
+	assert.NotNil(t, err)
+}
+
+func BenchmarkBlockDecoder_DecodeFloats(b *testing.B) {
+	values := newTestFloats(4096)
+	data := encodeTestFloats(b, values)
+	decoder := GetBlockDecoder()
+	defer PutBlockDecoder(decoder)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = decoder.DecodeFloats(data, len(values))
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(b.N)*float64(len(values))/b.Elapsed().Seconds()/1e6, "Mpoints/s")
+}
+
+func BenchmarkFloatDecoder(b *testing.B) {
+	values := newTestFloats(4096)
+	data := encodeTestFloats(b, values)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		decoder := NewFloatDecoder(data)
+		for j := 0; j < len(values) && decoder.Next(); j++ {
+			_ = decoder.Value()
+		}
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(b.N)*float64(len(values))/b.Elapsed().Seconds()/1e6, "Mpoints/s")
+}
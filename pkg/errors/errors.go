@@ -0,0 +1,234 @@
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"reflect"
+)
+
+// Code represents the code of error, which is transferred by rpc response,
+// so that the error can be identified after crossing node.
+type Code int32
+
+// Defines all codes of error, OK/Unknown are same as rpc.OK/rpc.ERR.
+const (
+	CodeOK Code = iota
+	CodeUnknown
+	CodeFamilyNotFound
+	CodeManifestCorrupted
+	CodeStoreClosed
+	CodeTooManyTags
+	CodeTooManyFields
+	CodeWrongFieldType
+	CodeShardNotFound
+	CodeMetricNotFound
+	CodeDatabaseNotFound
+	CodeInvalidArgument
+	CodeShardOffline
+	CodeQueryRejected
+	CodeEpochMismatch
+	CodeCorruption
+	CodeQueryBlacklisted
+	CodeQueryKilled
+	CodeShardReadOnly
+	CodeQueryUnauthorized
+)
+
+// Defines all storage engine errors, check error by Is, because the error may be wrapped.
+var (
+	// ErrFamilyNotFound is the error returned when kv family not exist
+	ErrFamilyNotFound = newError(CodeFamilyNotFound, "family not found")
+	// ErrManifestCorrupted is the error returned when the manifest file of kv store cannot be recovered
+	ErrManifestCorrupted = newError(CodeManifestCorrupted, "manifest corrupted")
+	// ErrStoreClosed is the error returned when using a closed kv store
+	ErrStoreClosed = newError(CodeStoreClosed, "store closed")
+	// ErrTooManyTags is the error returned by memory-database when
+	// writes exceed the max limit of tag identifiers.
+	ErrTooManyTags = newError(CodeTooManyTags, "too many tags")
+	// ErrTooManyFields is the error returned by memory-database when
+	// writes exceed the max limit of fields.
+	ErrTooManyFields = newError(CodeTooManyFields, "too many fields")
+	// ErrWrongFieldType is the error returned by memory-database when
+	// field-type of new point is different from the type before.
+	ErrWrongFieldType = newError(CodeWrongFieldType, "field type is wrong")
+	// ErrShardNotFound is the error returned when shard not exist in engine
+	ErrShardNotFound = newError(CodeShardNotFound, "shard not found")
+	// ErrMetricNotFound is the error returned when metric not exist
+	ErrMetricNotFound = newError(CodeMetricNotFound, "metric not found")
+	// ErrDatabaseNotFound is the error returned when database not exist
+	ErrDatabaseNotFound = newError(CodeDatabaseNotFound, "database not found")
+	// ErrInvalidArgument is the error returned when the argument of request is invalid
+	ErrInvalidArgument = newError(CodeInvalidArgument, "invalid argument")
+	// ErrShardOffline is the error returned when shard cannot be accessed, e.g. the disk of shard fails
+	ErrShardOffline = newError(CodeShardOffline, "shard offline")
+	// ErrQueryRejected is the error returned when query waits too long in the admission queue
+	ErrQueryRejected = newError(CodeQueryRejected, "query rejected")
+	// ErrEpochMismatch is the error returned when the routing epoch of write doesn't match the shard assignment
+	ErrEpochMismatch = newError(CodeEpochMismatch, "routing epoch mismatch")
+	// ErrCorruption is the error returned when the data of file is corrupted, e.g. checksum mismatch
+	ErrCorruption = newError(CodeCorruption, "data corruption")
+	// ErrQueryBlacklisted is the error returned when query matches the blacklist rule of known-pathological queries
+	ErrQueryBlacklisted = newError(CodeQueryBlacklisted, "query blacklisted")
+	// ErrQueryKilled is the error returned when running query is killed by operator
+	ErrQueryKilled = newError(CodeQueryKilled, "query killed")
+	// ErrShardReadOnly is the error returned when writing into the shard which is read-only or frozen
+	ErrShardReadOnly = newError(CodeShardReadOnly, "shard read-only")
+	// ErrQueryUnauthorized is the error returned when the user of query isn't allowed to query the database
+	ErrQueryUnauthorized = newError(CodeQueryUnauthorized, "query unauthorized")
+)
+
+// codeErrors is the registry of errors keyed by code
+var codeErrors = make(map[Code]*codeError)
+
+// codeError represents the error with code
+type codeError struct {
+	code Code
+	msg  string
+}
+
+// newError creates the error with code, then registers it
+func newError(code Code, msg string) *codeError {
+	err := &codeError{code: code, msg: msg}
+	codeErrors[code] = err
+	return err
+}
+
+// Error returns the error message
+func (e *codeError) Error() string {
+	return e.msg
+}
+
+// Code returns the code of error
+func (e *codeError) Code() Code {
+	return e.code
+}
+
+// wrapError represents the error with detail message, which wraps the cause error
+type wrapError struct {
+	msg   string
+	cause error
+}
+
+// Error returns the detail message with the message of cause error
+func (e *wrapError) Error() string {
+	return fmt.Sprintf("%s:%s", e.msg, e.cause)
+}
+
+// Unwrap returns the cause error
+func (e *wrapError) Unwrap() error {
+	return e.cause
+}
+
+// Wrapf wraps the error with detail message, the wrapped error can be checked by Is/As.
+func Wrapf(err error, format string, args ...interface{}) error {
+	return &wrapError{msg: fmt.Sprintf(format, args...), cause: err}
+}
+
+// New returns an error that formats as the given text
+func New(text string) error {
+	return stderrors.New(text)
+}
+
+// Is reports whether any error in err's chain matches target.
+// The chain is walked by Unwrap like errors.Is of go 1.13, which isn't available in go 1.12.
+func Is(err, target error) bool {
+	if target == nil {
+		return err == target
+	}
+	comparable := reflect.TypeOf(target).Comparable()
+	for err != nil {
+		if comparable && err == target {
+			return true
+		}
+		if x, ok := err.(interface{ Is(error) bool }); ok && x.Is(target) {
+			return true
+		}
+		err = Unwrap(err)
+	}
+	return false
+}
+
+// As finds the first error in err's chain that matches target, and if so, sets target to that error value
+// and returns true. It panics if target isn't a non-nil pointer to an interface or a type implementing error.
+func As(err error, target interface{}) bool {
+	if target == nil {
+		panic("errors: target cannot be nil")
+	}
+	val := reflect.ValueOf(target)
+	typ := val.Type()
+	if typ.Kind() != reflect.Ptr || val.IsNil() {
+		panic("errors: target must be a non-nil pointer")
+	}
+	targetType := typ.Elem()
+	if targetType.Kind() != reflect.Interface && !targetType.Implements(errorType) {
+		panic("errors: *target must be interface or implement error")
+	}
+	for err != nil {
+		if reflect.TypeOf(err).AssignableTo(targetType) {
+			val.Elem().Set(reflect.ValueOf(err))
+			return true
+		}
+		if x, ok := err.(interface{ As(interface{}) bool }); ok && x.As(target) {
+			return true
+		}
+		err = Unwrap(err)
+	}
+	return false
+}
+
+// Unwrap returns the result of calling the Unwrap method on err, returns nil if err has no Unwrap method
+func Unwrap(err error) error {
+	u, ok := err.(interface{ Unwrap() error })
+	if !ok {
+		return nil
+	}
+	return u.Unwrap()
+}
+
+// errorType is the reflect type of error interface
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// CodeOf returns the code of error, returns CodeOK if error is nil,
+// returns CodeUnknown if no error in err's chain has code.
+func CodeOf(err error) Code {
+	if err == nil {
+		return CodeOK
+	}
+	var e interface{ Code() Code }
+	if As(err, &e) {
+		return e.Code()
+	}
+	return CodeUnknown
+}
+
+// FromCode builds the error by code and message which are transferred by rpc response,
+// the error with known code can be checked by Is, returns nil if code is CodeOK.
+func FromCode(code Code, msg string) error {
+	if code == CodeOK {
+		return nil
+	}
+	err, ok := codeErrors[code]
+	if !ok {
+		return New(msg)
+	}
+	if msg == err.msg {
+		return err
+	}
+	return &remoteError{msg: msg, cause: err}
+}
+
+// remoteError represents the error returned by remote node, the message includes the message of cause error
+type remoteError struct {
+	msg   string
+	cause error
+}
+
+// Error returns the message returned by remote node
+func (e *remoteError) Error() string {
+	return e.msg
+}
+
+// Unwrap returns the cause error
+func (e *remoteError) Unwrap() error {
+	return e.cause
+}
@@ -0,0 +1,74 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapf(t *testing.T) {
+	err := Wrapf(ErrFamilyNotFound, "cannot find family[%s]", "f")
+	assert.Equal(t, "cannot find family[f]:family not found", err.Error())
+	assert.True(t, Is(err, ErrFamilyNotFound))
+	assert.False(t, Is(err, ErrShardNotFound))
+
+	err = Wrapf(err, "flush error")
+	assert.Equal(t, "flush error:cannot find family[f]:family not found", err.Error())
+	assert.True(t, Is(err, ErrFamilyNotFound))
+
+	var e *codeError
+	assert.True(t, As(err, &e))
+	assert.Equal(t, CodeFamilyNotFound, e.Code())
+}
+
+func TestCodeOf(t *testing.T) {
+	assert.Equal(t, CodeOK, CodeOf(nil))
+	assert.Equal(t, CodeUnknown, CodeOf(New("err")))
+	assert.Equal(t, CodeUnknown, CodeOf(fmt.Errorf("err")))
+	assert.Equal(t, CodeStoreClosed, CodeOf(ErrStoreClosed))
+	assert.Equal(t, CodeTooManyTags, CodeOf(Wrapf(ErrTooManyTags, "write error")))
+}
+
+func TestFromCode(t *testing.T) {
+	assert.Nil(t, FromCode(CodeOK, ""))
+	err := FromCode(CodeUnknown, "err")
+	assert.Equal(t, "err", err.Error())
+	assert.Equal(t, CodeUnknown, CodeOf(err))
+	err = FromCode(Code(10000), "unknown code")
+	assert.Equal(t, "unknown code", err.Error())
+
+	assert.Equal(t, ErrShardNotFound, FromCode(CodeShardNotFound, ErrShardNotFound.Error()))
+
+	// transfer error by code and message
+	err = Wrapf(ErrManifestCorrupted, "recover error")
+	err = FromCode(CodeOf(err), err.Error())
+	assert.Equal(t, "recover error:manifest corrupted", err.Error())
+	assert.True(t, Is(err, ErrManifestCorrupted))
+	assert.Equal(t, CodeManifestCorrupted, CodeOf(err))
+}
+
+type matchAllError struct{}
+
+func (e *matchAllError) Error() string              { return "match all" }
+func (e *matchAllError) Is(target error) bool       { return true }
+func (e *matchAllError) As(target interface{}) bool { return false }
+
+func TestIs_As(t *testing.T) {
+	assert.True(t, Is(nil, nil))
+	assert.False(t, Is(ErrStoreClosed, nil))
+	assert.False(t, Is(nil, ErrStoreClosed))
+	assert.True(t, Is(Wrapf(&matchAllError{}, "wrap"), ErrStoreClosed))
+	assert.Nil(t, Unwrap(ErrStoreClosed))
+	assert.Equal(t, ErrStoreClosed, Unwrap(Wrapf(ErrStoreClosed, "wrap")))
+
+	var e *matchAllError
+	assert.True(t, As(Wrapf(&matchAllError{}, "wrap"), &e))
+	var ce *codeError
+	assert.False(t, As(Wrapf(&matchAllError{}, "wrap"), &ce))
+	assert.False(t, As(nil, &ce))
+
+	assert.Panics(t, func() { As(ErrStoreClosed, nil) })
+	assert.Panics(t, func() { As(ErrStoreClosed, ce) })
+	assert.Panics(t, func() { As(ErrStoreClosed, &struct{}{}) })
+}
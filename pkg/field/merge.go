@@ -0,0 +1,161 @@
+package field
+
+// Point represents the value of a time slot in a source, with the write sequence which the value reflects
+type Point struct {
+	Timestamp int64
+	Value     int64
+	// Sequence is the write sequence of source when the value is read, the value of greater sequence is newer
+	Sequence int64
+}
+
+// PointIterator iterates the points of a series field in timestamp order
+type PointIterator interface {
+	// Next moves to the next point, returns false if no more points
+	Next() bool
+	// Point returns the current point
+	Point() Point
+}
+
+// MergePolicy represents how the duplicate points(same series and timestamp) from multiple sources
+// (e.g. memory-database, L0 files and replicas) are merged into one
+type MergePolicy int
+
+// Defines all merge policies of duplicate points
+const (
+	// LastWrite keeps the value of the greatest sequence, the value of the first source wins if the sequences
+	// are same, so that the duplicates are never summed
+	LastWrite MergePolicy = iota + 1
+	// MinValue keeps the min value, which is idempotent for the duplicates
+	MinValue
+	// MaxValue keeps the max value, which is idempotent for the duplicates
+	MaxValue
+)
+
+// MergePolicyOf returns the merge policy of duplicate points by field type, the min/max fields are aggregated
+// because they are idempotent, the others(gauges, and the sum fields as counters) are last-write-wins,
+// because summing the duplicates of the same writes counts them twice.
+func MergePolicyOf(fieldType Type) MergePolicy {
+	switch fieldType {
+	case MinField:
+		return MinValue
+	case MaxField:
+		return MaxValue
+	default:
+		return LastWrite
+	}
+}
+
+// merge merges the duplicate point into the point by the policy, the sequence of result is the greatest one
+func (p MergePolicy) merge(point, duplicate Point) Point {
+	switch p {
+	case MinValue:
+		if duplicate.Value < point.Value {
+			point.Value = duplicate.Value
+		}
+	case MaxValue:
+		if duplicate.Value > point.Value {
+			point.Value = duplicate.Value
+		}
+	default:
+		if duplicate.Sequence > point.Sequence {
+			return duplicate
+		}
+	}
+	if duplicate.Sequence > point.Sequence {
+		point.Sequence = duplicate.Sequence
+	}
+	return point
+}
+
+// pointsIterator iterates the points in slice
+type pointsIterator struct {
+	points []Point
+	idx    int
+}
+
+// NewPointsIterator creates the iterator of points sorted by timestamp
+func NewPointsIterator(points []Point) PointIterator {
+	return &pointsIterator{points: points, idx: -1}
+}
+
+// Next moves to the next point, returns false if no more points
+func (it *pointsIterator) Next() bool {
+	if it.idx+1 >= len(it.points) {
+		return false
+	}
+	it.idx++
+	return true
+}
+
+// Point returns the current point
+func (it *pointsIterator) Point() Point {
+	return it.points[it.idx]
+}
+
+// mergedIterator merges the points of sources in timestamp order
+type mergedIterator struct {
+	policy  MergePolicy
+	sources []PointIterator
+	// heads are the current points of sources, valid is false if the source is exhausted
+	heads []Point
+	valid []bool
+	point Point
+}
+
+// NewMergedIterator creates the iterator which merges the points of sources in priority order(e.g. memory-database,
+// L0 files, replicas), each timestamp is returned once, the duplicate points of the timestamp in all sources are
+// merged by the policy deterministically regardless of the timing of reading.
+func NewMergedIterator(policy MergePolicy, sources ...PointIterator) PointIterator {
+	it := &mergedIterator{
+		policy:  policy,
+		sources: sources,
+		heads:   make([]Point, len(sources)),
+		valid:   make([]bool, len(sources)),
+	}
+	for idx := range sources {
+		it.advance(idx)
+	}
+	return it
+}
+
+// Next moves to the next timestamp of all sources, merges the duplicate points of the timestamp
+func (it *mergedIterator) Next() bool {
+	found := false
+	var timestamp int64
+	for idx, valid := range it.valid {
+		if valid && (!found || it.heads[idx].Timestamp < timestamp) {
+			timestamp = it.heads[idx].Timestamp
+			found = true
+		}
+	}
+	if !found {
+		return false
+	}
+	merged := false
+	for idx := range it.sources {
+		// the duplicate points in the same source are merged too
+		for it.valid[idx] && it.heads[idx].Timestamp == timestamp {
+			if merged {
+				it.point = it.policy.merge(it.point, it.heads[idx])
+			} else {
+				it.point = it.heads[idx]
+				merged = true
+			}
+			it.advance(idx)
+		}
+	}
+	return true
+}
+
+// Point returns the merged point of current timestamp
+func (it *mergedIterator) Point() Point {
+	return it.point
+}
+
+// advance moves the source to the next point
+func (it *mergedIterator) advance(idx int) {
+	it.valid[idx] = it.sources[idx].Next()
+	if it.valid[idx] {
+		it.heads[idx] = it.sources[idx].Point()
+	}
+}
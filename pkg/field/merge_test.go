@@ -0,0 +1,57 @@
+package field
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// collect returns all points of iterator
+func collect(it PointIterator) []Point {
+	var points []Point
+	for it.Next() {
+		points = append(points, it.Point())
+	}
+	return points
+}
+
+func TestMergePolicyOf(t *testing.T) {
+	assert.Equal(t, LastWrite, MergePolicyOf(SumField))
+	assert.Equal(t, MinValue, MergePolicyOf(MinField))
+	assert.Equal(t, MaxValue, MergePolicyOf(MaxField))
+	assert.Equal(t, LastWrite, MergePolicyOf(SummaryField))
+}
+
+func TestNewMergedIterator(t *testing.T) {
+	memDB := []Point{{Timestamp: 10, Value: 5, Sequence: 20}, {Timestamp: 30, Value: 1, Sequence: 20}}
+	l0 := []Point{{Timestamp: 10, Value: 3, Sequence: 10}, {Timestamp: 20, Value: 2, Sequence: 10}}
+	replica := []Point{{Timestamp: 10, Value: 8, Sequence: 20}, {Timestamp: 20, Value: 9, Sequence: 15},
+		{Timestamp: 20, Value: 7, Sequence: 15}}
+	merge := func(policy MergePolicy) []Point {
+		return collect(NewMergedIterator(policy, NewPointsIterator(memDB), NewPointsIterator(l0),
+			NewPointsIterator(replica)))
+	}
+	// the value of greatest sequence wins, the first source wins if same sequence, the counters are never summed
+	assert.Equal(t, []Point{
+		{Timestamp: 10, Value: 5, Sequence: 20},
+		{Timestamp: 20, Value: 9, Sequence: 15},
+		{Timestamp: 30, Value: 1, Sequence: 20},
+	}, merge(LastWrite))
+	assert.Equal(t, []Point{
+		{Timestamp: 10, Value: 3, Sequence: 20},
+		{Timestamp: 20, Value: 2, Sequence: 15},
+		{Timestamp: 30, Value: 1, Sequence: 20},
+	}, merge(MinValue))
+	assert.Equal(t, []Point{
+		{Timestamp: 10, Value: 8, Sequence: 20},
+		{Timestamp: 20, Value: 9, Sequence: 15},
+		{Timestamp: 30, Value: 1, Sequence: 20},
+	}, merge(MaxValue))
+
+	// the result is same regardless of the order of sources if the sequences are different
+	assert.Equal(t, collect(NewMergedIterator(LastWrite, NewPointsIterator(l0), NewPointsIterator(memDB))),
+		collect(NewMergedIterator(LastWrite, NewPointsIterator(memDB), NewPointsIterator(l0))))
+
+	assert.Empty(t, collect(NewMergedIterator(LastWrite)))
+	assert.Empty(t, collect(NewMergedIterator(LastWrite, NewPointsIterator(nil))))
+}
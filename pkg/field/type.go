@@ -28,4 +28,33 @@ const (
 	MinField
 	MaxField
 	HistogramField
+	// StringField stores the last value of string, such as build version or status message,
+	// which isn't aggregated but used as the dimension of series
+	StringField
+	// SummaryField maintains the min/max/sum/count of the raw samples per time slot on write,
+	// which are stored as the simple fields with summary suffixes(see SummaryMinSuffix etc.),
+	// so that the avg/max of raw samples like latency are queried cheaply without histogram
+	SummaryField
+)
+
+// AggType returns the aggregator type of the values of field type, the values of same time slot
+// are aggregated by it when written or down sampled
+func (t Type) AggType() AggType {
+	switch t {
+	case MinField:
+		return Min
+	case MaxField:
+		return Max
+	default:
+		return Sum
+	}
+}
+
+// Defines the suffixes of the simple fields maintained by summary field, such as the summary field latency
+// is stored as latency_min/latency_max/latency_sum/latency_count, the avg is latency_sum/latency_count
+const (
+	SummaryMinSuffix   = "_min"
+	SummaryMaxSuffix   = "_max"
+	SummarySumSuffix   = "_sum"
+	SummaryCountSuffix = "_count"
 )
@@ -0,0 +1,96 @@
+package hashers
+
+import (
+	"math/bits"
+)
+
+// primes of XXH64 algorithm(seed 0), refer to github.com/cespare/xxhash
+const (
+	xxPrime1 uint64 = 11400714785074694791
+	xxPrime2 uint64 = 14029467366897019727
+	xxPrime3 uint64 = 1609587929392839161
+	xxPrime4 uint64 = 9650029242287828579
+	xxPrime5 uint64 = 2870177450012600261
+)
+
+// XXHash64 returns a 64-bit xxHash of a string,
+// which is faster than FNV for strings longer than a few bytes and has better distribution.
+func XXHash64(s string) uint64 {
+	b := s
+	n := len(b)
+	var h uint64
+	if n >= 32 {
+		// wrap around on purpose, the constant expressions overflow
+		v1 := xxPrime1
+		v1 += xxPrime2
+		v2 := xxPrime2
+		v3 := uint64(0)
+		v4 := uint64(0)
+		v4 -= xxPrime1
+		for len(b) >= 32 {
+			v1 = xxRound(v1, readUint64(b[0:8]))
+			v2 = xxRound(v2, readUint64(b[8:16]))
+			v3 = xxRound(v3, readUint64(b[16:24]))
+			v4 = xxRound(v4, readUint64(b[24:32]))
+			b = b[32:]
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxMergeRound(h, v1)
+		h = xxMergeRound(h, v2)
+		h = xxMergeRound(h, v3)
+		h = xxMergeRound(h, v4)
+	} else {
+		h = xxPrime5
+	}
+	h += uint64(n)
+
+	for ; len(b) >= 8; b = b[8:] {
+		h ^= xxRound(0, readUint64(b[:8]))
+		h = bits.RotateLeft64(h, 27)*xxPrime1 + xxPrime4
+	}
+	if len(b) >= 4 {
+		h ^= uint64(readUint32(b[:4])) * xxPrime1
+		h = bits.RotateLeft64(h, 23)*xxPrime2 + xxPrime3
+		b = b[4:]
+	}
+	for i := 0; i < len(b); i++ {
+		h ^= uint64(b[i]) * xxPrime5
+		h = bits.RotateLeft64(h, 11) * xxPrime1
+	}
+
+	h ^= h >> 33
+	h *= xxPrime2
+	h ^= h >> 29
+	h *= xxPrime3
+	h ^= h >> 32
+	return h
+}
+
+// xxRound mixes the 8 bytes input into the accumulator
+func xxRound(acc, input uint64) uint64 {
+	acc += input * xxPrime2
+	acc = bits.RotateLeft64(acc, 31)
+	acc *= xxPrime1
+	return acc
+}
+
+// xxMergeRound merges the accumulator of lane into the hash
+func xxMergeRound(acc, val uint64) uint64 {
+	val = xxRound(0, val)
+	acc ^= val
+	acc = acc*xxPrime1 + xxPrime4
+	return acc
+}
+
+// readUint64 reads the little endian uint64 from the string, avoids converting string to bytes
+func readUint64(b string) uint64 {
+	_ = b[7] // bounds check hint to compiler
+	return uint64(b[0]) | uint64(b[1])<<8 | uint64(b[2])<<16 | uint64(b[3])<<24 |
+		uint64(b[4])<<32 | uint64(b[5])<<40 | uint64(b[6])<<48 | uint64(b[7])<<56
+}
+
+// readUint32 reads the little endian uint32 from the string, avoids converting string to bytes
+func readUint32(b string) uint32 {
+	_ = b[3] // bounds check hint to compiler
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+}
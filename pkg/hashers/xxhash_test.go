@@ -0,0 +1,75 @@
+package hashers
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// realistic metric names and sorted tags on the write path
+var (
+	_metricName = "system.cpu.load"
+	_sortedTags = "host=alpha-dev-server-001,idc=sh,ip=192.168.100.101,role=web,zone=cn-east-1a"
+)
+
+func Test_XXHash64(t *testing.T) {
+	cases := []struct {
+		input string
+		hash  uint64
+	}{
+		{"", 0xef46db3751d8e999},
+		{"a", 0xd24ec4f1a98c6e5b},
+		{"as", 0x1c330fb2d66be179},
+		{"asd", 0x631c37ce72a97393},
+		{"asdf", 0x415872f599cea71e},
+		{"Call me Ishmael. Some years ago--never mind how long precisely-", 0x02a2e85470d6fd96},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.hash, XXHash64(c.input), c.input)
+	}
+}
+
+func Test_XXHash64_distribution(t *testing.T) {
+	hashes := make(map[uint64]struct{})
+	for i := 0; i < 100000; i++ {
+		hashes[XXHash64("host=alpha-"+strconv.Itoa(i))] = struct{}{}
+	}
+	assert.Len(t, hashes, 100000)
+}
+
+func Benchmark_Fnv32a_metric(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Fnv32a(_metricName)
+	}
+}
+
+func Benchmark_Fnv64a_metric(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Fnv64a(_metricName)
+	}
+}
+
+func Benchmark_XXHash64_metric(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		XXHash64(_metricName)
+	}
+}
+
+func Benchmark_Fnv32a_tags(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Fnv32a(_sortedTags)
+	}
+}
+
+func Benchmark_Fnv64a_tags(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Fnv64a(_sortedTags)
+	}
+}
+
+func Benchmark_XXHash64_tags(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		XXHash64(_sortedTags)
+	}
+}
@@ -0,0 +1,139 @@
+package hll
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/eleme/lindb/pkg/hashers"
+)
+
+const (
+	// precision is the num. of hash bits which index the register,
+	// the standard error is 1.04/sqrt(2^precision), about 1.6%
+	precision = 12
+	// numOfRegisters is the num. of registers, 2^precision
+	numOfRegisters = 1 << precision
+
+	// sparseFormat stores the index and value of non-zero registers
+	sparseFormat byte = 1
+	// denseFormat stores all registers
+	denseFormat byte = 2
+)
+
+// HyperLogLog estimates the num. of distinct values with fixed memory(one byte per register)
+type HyperLogLog struct {
+	registers []uint8
+}
+
+// New creates an empty HyperLogLog sketch
+func New() *HyperLogLog {
+	return &HyperLogLog{
+		registers: make([]uint8, numOfRegisters),
+	}
+}
+
+// AddString adds the hash of string value into sketch
+func (h *HyperLogLog) AddString(value string) {
+	h.Add(hashers.XXHash64(value))
+}
+
+// Add adds the hash of value into sketch, the hash must be uniformly distributed
+func (h *HyperLogLog) Add(hash uint64) {
+	idx := hash >> (64 - precision)
+	// the sentinel bit limits the rank to 64-precision+1
+	rank := uint8(bits.LeadingZeros64(hash<<precision|1<<(precision-1))) + 1
+	if rank > h.registers[idx] {
+		h.registers[idx] = rank
+	}
+}
+
+// Merge merges other sketch into this sketch, the result estimates the union of values
+func (h *HyperLogLog) Merge(other *HyperLogLog) {
+	for idx, rank := range other.registers {
+		if rank > h.registers[idx] {
+			h.registers[idx] = rank
+		}
+	}
+}
+
+// Count returns the estimated num. of distinct values
+func (h *HyperLogLog) Count() uint64 {
+	m := float64(numOfRegisters)
+	sum := 0.0
+	zeros := 0
+	for _, rank := range h.registers {
+		sum += 1.0 / float64(uint64(1)<<rank)
+		if rank == 0 {
+			zeros++
+		}
+	}
+	alpha := 0.7213 / (1 + 1.079/m)
+	estimate := alpha * m * m / sum
+	// uses linear counting for small cardinality
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+// MarshalBinary encodes the sketch, only the non-zero registers are stored if the sketch is sparse
+func (h *HyperLogLog) MarshalBinary() ([]byte, error) {
+	nonZeros := 0
+	for _, rank := range h.registers {
+		if rank > 0 {
+			nonZeros++
+		}
+	}
+	// each sparse register takes 3 bytes(2 bytes index + 1 byte rank)
+	if nonZeros*3 >= numOfRegisters {
+		return append([]byte{denseFormat}, h.registers...), nil
+	}
+	data := make([]byte, 3+nonZeros*3)
+	data[0] = sparseFormat
+	binary.BigEndian.PutUint16(data[1:], uint16(nonZeros))
+	pos := 3
+	for idx, rank := range h.registers {
+		if rank > 0 {
+			binary.BigEndian.PutUint16(data[pos:], uint16(idx))
+			data[pos+2] = rank
+			pos += 3
+		}
+	}
+	return data, nil
+}
+
+// UnmarshalBinary decodes the sketch encoded by MarshalBinary
+func (h *HyperLogLog) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("hyperloglog data is empty")
+	}
+	registers := make([]uint8, numOfRegisters)
+	switch data[0] {
+	case denseFormat:
+		if len(data) != 1+numOfRegisters {
+			return fmt.Errorf("invalid length of dense hyperloglog data:%d", len(data))
+		}
+		copy(registers, data[1:])
+	case sparseFormat:
+		if len(data) < 3 {
+			return fmt.Errorf("invalid length of sparse hyperloglog data:%d", len(data))
+		}
+		nonZeros := int(binary.BigEndian.Uint16(data[1:]))
+		if len(data) != 3+nonZeros*3 {
+			return fmt.Errorf("invalid length of sparse hyperloglog data:%d", len(data))
+		}
+		for pos := 3; pos < len(data); pos += 3 {
+			idx := binary.BigEndian.Uint16(data[pos:])
+			if int(idx) >= numOfRegisters {
+				return fmt.Errorf("invalid register index of hyperloglog data:%d", idx)
+			}
+			registers[idx] = data[pos+2]
+		}
+	default:
+		return fmt.Errorf("unknown format of hyperloglog data:%d", data[0])
+	}
+	h.registers = registers
+	return nil
+}
@@ -0,0 +1,74 @@
+package hll
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func assertEstimate(t *testing.T, expect int, h *HyperLogLog) {
+	errorRate := math.Abs(float64(h.Count())-float64(expect)) / float64(expect)
+	assert.True(t, errorRate < 0.05, fmt.Sprintf("expect:%d, estimate:%d", expect, h.Count()))
+}
+
+func TestHyperLogLog_Count(t *testing.T) {
+	h := New()
+	assert.Equal(t, uint64(0), h.Count())
+	for i := 0; i < 10; i++ {
+		h.AddString("host-1")
+	}
+	assert.Equal(t, uint64(1), h.Count())
+
+	for _, n := range []int{100, 10000, 1000000} {
+		h := New()
+		for i := 0; i < n; i++ {
+			h.AddString(fmt.Sprintf("host-%d", i))
+		}
+		assertEstimate(t, n, h)
+	}
+}
+
+func TestHyperLogLog_Merge(t *testing.T) {
+	h1 := New()
+	h2 := New()
+	for i := 0; i < 20000; i++ {
+		h1.AddString(fmt.Sprintf("host-%d", i))
+	}
+	for i := 10000; i < 30000; i++ {
+		h2.AddString(fmt.Sprintf("host-%d", i))
+	}
+	h1.Merge(h2)
+	assertEstimate(t, 30000, h1)
+}
+
+func TestHyperLogLog_Marshal(t *testing.T) {
+	for _, n := range []int{0, 100, 100000} {
+		h := New()
+		for i := 0; i < n; i++ {
+			h.AddString(fmt.Sprintf("host-%d", i))
+		}
+		data, err := h.MarshalBinary()
+		assert.Nil(t, err)
+		h2 := New()
+		assert.Nil(t, h2.UnmarshalBinary(data))
+		assert.Equal(t, h.registers, h2.registers)
+		assert.Equal(t, h.Count(), h2.Count())
+	}
+	// sparse sketch is smaller than dense one
+	h := New()
+	h.AddString("host-1")
+	data, _ := h.MarshalBinary()
+	assert.Equal(t, 6, len(data))
+}
+
+func TestHyperLogLog_Unmarshal_Error(t *testing.T) {
+	h := New()
+	assert.NotNil(t, h.UnmarshalBinary(nil))
+	assert.NotNil(t, h.UnmarshalBinary([]byte{3}))
+	assert.NotNil(t, h.UnmarshalBinary([]byte{denseFormat, 1, 2}))
+	assert.NotNil(t, h.UnmarshalBinary([]byte{sparseFormat, 1}))
+	assert.NotNil(t, h.UnmarshalBinary([]byte{sparseFormat, 0, 2, 0, 1, 1}))
+	assert.NotNil(t, h.UnmarshalBinary([]byte{sparseFormat, 0, 1, 0xff, 0xff, 1}))
+}
@@ -48,6 +48,27 @@ func ParseType(s string) (Type, error) {
 	}
 }
 
+// MaxSlotsPerFamily is the max number of time slots in a family, so that the sub-second intervals are supported
+// for high-frequency monitoring, e.g. 100ms interval of day interval type has 36000 slots in the family of hour.
+const MaxSlotsPerFamily = 1 << 16
+
+// ValidateInterval checks if the interval(ms) is valid for the calculator of interval type, the sub-second interval
+// must be the divisor of 1s(like 100ms/250ms), so that the slots are aligned with seconds,
+// and the time slots of family cannot exceed MaxSlotsPerFamily.
+func ValidateInterval(calc Calculator, interval int64) error {
+	if interval <= 0 {
+		return fmt.Errorf("interval[%dms] must be > 0", interval)
+	}
+	if interval < timeutil.OneSecond && timeutil.OneSecond%interval != 0 {
+		return fmt.Errorf("sub-second interval[%dms] must be divisor of 1s", interval)
+	}
+	if slots := calc.CalSlotsPerFamily(interval); slots > MaxSlotsPerFamily {
+		return fmt.Errorf("interval[%dms] is too small, %d slots per family exceed %d",
+			interval, slots, MaxSlotsPerFamily)
+	}
+	return nil
+}
+
 // intervalTypes defines calculator for interval type
 var intervalTypes = make(map[Type]Calculator)
 
@@ -70,9 +91,64 @@ func GetCalculator(intervalType Type) (Calculator, error) {
 
 // init register interval types when system init
 func init() {
-	register(Day, &day{})
-	register(Month, &month{})
-	register(Year, &year{})
+	register(Day, &day{familyDuration: timeutil.OneHour, location: time.Local})
+	register(Month, &month{location: time.Local})
+	register(Year, &year{location: time.Local})
+}
+
+// Alignment represents how the segments and families are aligned, such as the midnight of local time zone or UTC
+type Alignment string
+
+// Defines all alignments of segments and families
+const (
+	// LocalAlignment aligns the segments and families with the time zone of server, which is the default alignment
+	LocalAlignment Alignment = "local"
+	// UTCAlignment aligns the segments and families with UTC, so that the servers in different time zones
+	// have the same segments and families
+	UTCAlignment Alignment = "utc"
+)
+
+// location returns the time zone of alignment, empty alignment means local alignment
+func (a Alignment) location() (*time.Location, error) {
+	switch a {
+	case "", LocalAlignment:
+		return time.Local, nil
+	case UTCAlignment:
+		return time.UTC, nil
+	default:
+		return nil, fmt.Errorf("unknown alignment[%s]", a)
+	}
+}
+
+// NewCalculator creates the calculator of interval type with the time window of family(ms) and the alignment,
+// family duration <= 0 means the default family of interval type(hour for day, day for month, month for year).
+// Only the family duration of day interval type is configurable, which must be the divisor of 1 day(like 2h),
+// so that the flush granularity of memory database matches the retention granularity.
+func NewCalculator(intervalType Type, familyDuration int64, alignment Alignment) (Calculator, error) {
+	location, err := alignment.location()
+	if err != nil {
+		return nil, err
+	}
+	switch intervalType {
+	case Day:
+		if familyDuration <= 0 {
+			familyDuration = timeutil.OneHour
+		}
+		if familyDuration > timeutil.OneDay || timeutil.OneDay%familyDuration != 0 {
+			return nil, fmt.Errorf("family duration[%dms] must be divisor of 1 day", familyDuration)
+		}
+		return &day{familyDuration: familyDuration, location: location}, nil
+	case Month, Year:
+		if familyDuration > 0 {
+			return nil, fmt.Errorf("family duration of interval type[%s] isn't configurable", intervalType)
+		}
+		if intervalType == Month {
+			return &month{location: location}, nil
+		}
+		return &year{location: location}, nil
+	default:
+		return nil, fmt.Errorf("cannot found interval calculator by type:%d", intervalType)
+	}
 }
 
 // Calculator represents calculate timestamp for each interval type
@@ -87,48 +163,65 @@ type Calculator interface {
 	CalFamily(timestamp int64, segmentTime int64) int
 	// CalFamilyStartTime calculates family start time based on segment time and family
 	CalFamilyStartTime(segmentTime int64, family int) int64
+	// CalFamilyEndTime calculates family end time(exclusive) based on family start time
+	CalFamilyEndTime(familyStartTime int64) int64
 	// CalSlot calculates field store slot index based on given timestamp and base time
 	CalSlot(timestamp, baseTime, interval int64) int
+	// CalSlotsPerFamily calculates the max number of time slots in a family based on given interval
+	CalSlotsPerFamily(interval int64) int
 }
 
 // day implements Calculator interface for day interval type
 type day struct {
+	familyDuration int64
+	location       *time.Location
 }
 
 // CalSlot calculates field store slot index based on given timestamp and base time for day interval type
 func (d *day) CalSlot(timestamp, baseTime, interval int64) int {
-	return int(((timestamp - baseTime) % timeutil.OneHour) / interval)
+	return int(((timestamp - baseTime) % d.familyDuration) / interval)
+}
+
+// CalSlotsPerFamily calculates the number of time slots in the family(hour by default) for day interval type
+func (d *day) CalSlotsPerFamily(interval int64) int {
+	return int((d.familyDuration + interval - 1) / interval)
 }
 
 // GetSegment returns segment name by given timestamp for day interval type
 func (d *day) GetSegment(timestamp int64) string {
-	return timeutil.FormatTimestamp(timestamp, "20060102")
+	return formatTimestamp(timestamp, "20060102", d.location)
 }
 
 // ParseSegmentTime parses segment base time based on given segment name for day interval type
 func (d *day) ParseSegmentTime(segmentName string) (int64, error) {
-	return timeutil.ParseTimestamp(segmentName, "20060102")
+	return parseTimestamp(segmentName, "20060102", d.location)
 }
 
 // CalSegmentTime calculates segment base time based on given segment name for day interval type
 func (d *day) CalSegmentTime(timestamp int64) int64 {
-	t := time.Unix(timestamp/1000, 0)
-	t2 := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	t := time.Unix(timestamp/1000, 0).In(d.location)
+	t2 := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, d.location)
 	return t2.UnixNano() / 1000000
 }
 
 // CalFamily calculates family base time based on given timestamp for day interval type
 func (d *day) CalFamily(timestamp int64, segmentTime int64) int {
-	return int((timestamp - segmentTime) / timeutil.OneHour)
+	return int((timestamp - segmentTime) / d.familyDuration)
 }
 
 // CalFamilyStartTime calculates family start time based on segment time and family for day interval type
 func (d *day) CalFamilyStartTime(segmentTime int64, family int) int64 {
-	return segmentTime + int64(family)*timeutil.OneHour
+	return segmentTime + int64(family)*d.familyDuration
+}
+
+// CalFamilyEndTime calculates family end time based on family start time for day interval type
+func (d *day) CalFamilyEndTime(familyStartTime int64) int64 {
+	return familyStartTime + d.familyDuration
 }
 
 // month implements Calculator interface for month interval type
 type month struct {
+	location *time.Location
 }
 
 // CalSlot calculates field store slot index based on given timestamp and base time for month interval type
@@ -136,38 +229,50 @@ func (m *month) CalSlot(timestamp, baseTime, interval int64) int {
 	return int(((timestamp - baseTime) % timeutil.OneDay) / interval)
 }
 
+// CalSlotsPerFamily calculates the number of time slots in the family of day for month interval type
+func (m *month) CalSlotsPerFamily(interval int64) int {
+	return int((timeutil.OneDay + interval - 1) / interval)
+}
+
 // GetSegment returns segment name by given timestamp for month interval type
 func (m *month) GetSegment(timestamp int64) string {
-	return timeutil.FormatTimestamp(timestamp, "200601")
+	return formatTimestamp(timestamp, "200601", m.location)
 }
 
 // ParseSegmentTime parses segment base time based on given segment name for month interval type
 func (m *month) ParseSegmentTime(segmentName string) (int64, error) {
-	return timeutil.ParseTimestamp(segmentName, "200601")
+	return parseTimestamp(segmentName, "200601", m.location)
 }
 
 // CalSegmentTime calculates segment base time based on given segment name for month interval type
 func (m *month) CalSegmentTime(timestamp int64) int64 {
-	t := time.Unix(timestamp/1000, 0)
-	t2 := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.Local)
+	t := time.Unix(timestamp/1000, 0).In(m.location)
+	t2 := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, m.location)
 	return t2.UnixNano() / 1000000
 }
 
 // CalFamily calculates family base time based on given timestamp for month interval type
 func (m *month) CalFamily(timestamp int64, segmentTime int64) int {
-	t := time.Unix(timestamp/1000, 0)
+	t := time.Unix(timestamp/1000, 0).In(m.location)
 	return t.Day()
 }
 
 // CalFamilyStartTime calculates famliy start time based on segment time and family for month interval type
 func (m *month) CalFamilyStartTime(segmentTime int64, family int) int64 {
-	t := time.Unix(segmentTime/1000, 0)
-	t2 := time.Date(t.Year(), t.Month(), family, 0, 0, 0, 0, time.Local)
+	t := time.Unix(segmentTime/1000, 0).In(m.location)
+	t2 := time.Date(t.Year(), t.Month(), family, 0, 0, 0, 0, m.location)
 	return t2.UnixNano() / 1000000
 }
 
+// CalFamilyEndTime calculates family end time based on family start time for month interval type
+func (m *month) CalFamilyEndTime(familyStartTime int64) int64 {
+	t := time.Unix(familyStartTime/1000, 0).In(m.location)
+	return t.AddDate(0, 0, 1).UnixNano() / 1000000
+}
+
 // year implements Calculator interface for year interval type
 type year struct {
+	location *time.Location
 }
 
 // CalSlot calculates field store slot index based on given timestamp and base time for year interval type
@@ -175,32 +280,57 @@ func (y *year) CalSlot(timestamp, baseTime, interval int64) int {
 	return int((timestamp - baseTime) / interval)
 }
 
+// CalSlotsPerFamily calculates the number of time slots in the family of month(31 days) for year interval type
+func (y *year) CalSlotsPerFamily(interval int64) int {
+	return int((31*timeutil.OneDay + interval - 1) / interval)
+}
+
 // GetSegment returns segment name by given timestamp for day interval type
 func (y *year) GetSegment(timestamp int64) string {
-	return timeutil.FormatTimestamp(timestamp, "2006")
+	return formatTimestamp(timestamp, "2006", y.location)
 }
 
 // ParseSegmentTime parses segment base time based on given segment name for year interval type
 func (y *year) ParseSegmentTime(segmentName string) (int64, error) {
-	return timeutil.ParseTimestamp(segmentName, "2006")
+	return parseTimestamp(segmentName, "2006", y.location)
 }
 
 // CalSegmentTime calculates segment base time based on given segment name for year interval type
 func (y *year) CalSegmentTime(timestamp int64) int64 {
-	t := time.Unix(timestamp/1000, 0)
-	t2 := time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, time.Local)
+	t := time.Unix(timestamp/1000, 0).In(y.location)
+	t2 := time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, y.location)
 	return t2.UnixNano() / 1000000
 }
 
 // CalFamily calculates family base time based on given timestamp for year interval type
 func (y *year) CalFamily(timestamp int64, segmentTime int64) int {
-	t := time.Unix(timestamp/1000, 0)
+	t := time.Unix(timestamp/1000, 0).In(y.location)
 	return int(t.Month())
 }
 
 // CalFamilyStartTime calculates famliy start time based on segment time and family for year interval type
 func (y *year) CalFamilyStartTime(segmentTime int64, family int) int64 {
-	t := time.Unix(segmentTime/1000, 0)
-	t2 := time.Date(t.Year(), time.Month(family), 1, 0, 0, 0, 0, time.Local)
+	t := time.Unix(segmentTime/1000, 0).In(y.location)
+	t2 := time.Date(t.Year(), time.Month(family), 1, 0, 0, 0, 0, y.location)
 	return t2.UnixNano() / 1000000
 }
+
+// CalFamilyEndTime calculates family end time based on family start time for year interval type
+func (y *year) CalFamilyEndTime(familyStartTime int64) int64 {
+	t := time.Unix(familyStartTime/1000, 0).In(y.location)
+	return t.AddDate(0, 1, 0).UnixNano() / 1000000
+}
+
+// formatTimestamp formats timestamp(ms) with layout in the time zone
+func formatTimestamp(timestamp int64, layout string, location *time.Location) string {
+	return time.Unix(timestamp/1000, 0).In(location).Format(layout)
+}
+
+// parseTimestamp parses the timestamp(ms) with layout in the time zone
+func parseTimestamp(timestampStr, layout string, location *time.Location) (int64, error) {
+	t, err := time.ParseInLocation(layout, timestampStr, location)
+	if err != nil {
+		return 0, err
+	}
+	return t.UnixNano() / 1000000, nil
+}
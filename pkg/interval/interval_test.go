@@ -2,6 +2,7 @@ package interval
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -142,3 +143,94 @@ func TestCalFamilyStartTime(t *testing.T) {
 	t2, _ = calc.ParseSegmentTime("2019")
 	assert.Equal(t, t1, calc.CalFamilyStartTime(t2, 10))
 }
+
+func TestCalSlotsPerFamily(t *testing.T) {
+	calc, _ := GetCalculator(Day)
+	assert.Equal(t, 360, calc.CalSlotsPerFamily(10*timeutil.OneSecond))
+	assert.Equal(t, 36000, calc.CalSlotsPerFamily(100))
+	assert.Equal(t, 2, calc.CalSlotsPerFamily(35*timeutil.OneMinute))
+	calc, _ = GetCalculator(Month)
+	assert.Equal(t, 288, calc.CalSlotsPerFamily(5*timeutil.OneMinute))
+	calc, _ = GetCalculator(Year)
+	assert.Equal(t, 31, calc.CalSlotsPerFamily(timeutil.OneDay))
+}
+
+func TestValidateInterval(t *testing.T) {
+	day, _ := GetCalculator(Day)
+	month, _ := GetCalculator(Month)
+	year, _ := GetCalculator(Year)
+	assert.Nil(t, ValidateInterval(day, 10*timeutil.OneSecond))
+	assert.Nil(t, ValidateInterval(day, 100))
+	assert.Nil(t, ValidateInterval(day, 250))
+	assert.Nil(t, ValidateInterval(month, 5*timeutil.OneSecond))
+	assert.Nil(t, ValidateInterval(year, timeutil.OneHour))
+
+	assert.NotNil(t, ValidateInterval(day, 0))
+	// not divisor of 1s
+	assert.NotNil(t, ValidateInterval(day, 300))
+	// too many slots per family
+	assert.NotNil(t, ValidateInterval(day, 50))
+	assert.NotNil(t, ValidateInterval(month, 100))
+	assert.NotNil(t, ValidateInterval(year, timeutil.OneSecond))
+	// the slots of larger family
+	twoHours, _ := NewCalculator(Day, 2*timeutil.OneHour, "")
+	assert.NotNil(t, ValidateInterval(twoHours, 100))
+}
+
+func TestNewCalculator(t *testing.T) {
+	now, _ := timeutil.ParseTimestamp("20190702 19:10:48", "20060102 15:04:05")
+	// the default calculators are same as registered
+	for _, intervalType := range []Type{Day, Month, Year} {
+		calc, err := NewCalculator(intervalType, 0, LocalAlignment)
+		assert.Nil(t, err)
+		defaultCalc, _ := GetCalculator(intervalType)
+		segmentTime := calc.CalSegmentTime(now)
+		assert.Equal(t, defaultCalc.CalSegmentTime(now), segmentTime)
+		family := calc.CalFamily(now, segmentTime)
+		assert.Equal(t, defaultCalc.CalFamilyStartTime(segmentTime, family), calc.CalFamilyStartTime(segmentTime, family))
+	}
+
+	// the family of 6 hours aligned with UTC
+	calc, err := NewCalculator(Day, 6*timeutil.OneHour, UTCAlignment)
+	assert.Nil(t, err)
+	utcNow := time.Date(2019, 7, 2, 19, 10, 48, 0, time.UTC).UnixNano() / 1e6
+	segmentTime := calc.CalSegmentTime(utcNow)
+	assert.Equal(t, time.Date(2019, 7, 2, 0, 0, 0, 0, time.UTC).UnixNano()/1e6, segmentTime)
+	assert.Equal(t, "20190702", calc.GetSegment(utcNow))
+	parsed, _ := calc.ParseSegmentTime("20190702")
+	assert.Equal(t, segmentTime, parsed)
+	family := calc.CalFamily(utcNow, segmentTime)
+	assert.Equal(t, 3, family)
+	familyStartTime := calc.CalFamilyStartTime(segmentTime, family)
+	assert.Equal(t, segmentTime+18*timeutil.OneHour, familyStartTime)
+	assert.Equal(t, segmentTime+24*timeutil.OneHour, calc.CalFamilyEndTime(familyStartTime))
+	assert.Equal(t, 2160, calc.CalSlotsPerFamily(10*timeutil.OneSecond))
+	assert.Equal(t, (timeutil.OneHour+10*timeutil.OneMinute+48*timeutil.OneSecond)/(10*timeutil.OneSecond),
+		calc.CalSlot(utcNow, familyStartTime, 10*timeutil.OneSecond))
+
+	calc, _ = NewCalculator(Month, 0, UTCAlignment)
+	segmentTime = calc.CalSegmentTime(utcNow)
+	assert.Equal(t, time.Date(2019, 7, 1, 0, 0, 0, 0, time.UTC).UnixNano()/1e6, segmentTime)
+	familyStartTime = calc.CalFamilyStartTime(segmentTime, calc.CalFamily(utcNow, segmentTime))
+	assert.Equal(t, time.Date(2019, 7, 2, 0, 0, 0, 0, time.UTC).UnixNano()/1e6, familyStartTime)
+	assert.Equal(t, familyStartTime+timeutil.OneDay, calc.CalFamilyEndTime(familyStartTime))
+
+	calc, _ = NewCalculator(Year, 0, UTCAlignment)
+	segmentTime = calc.CalSegmentTime(utcNow)
+	assert.Equal(t, "2019", calc.GetSegment(utcNow))
+	familyStartTime = calc.CalFamilyStartTime(segmentTime, calc.CalFamily(utcNow, segmentTime))
+	assert.Equal(t, time.Date(2019, 7, 1, 0, 0, 0, 0, time.UTC).UnixNano()/1e6, familyStartTime)
+	assert.Equal(t, time.Date(2019, 8, 1, 0, 0, 0, 0, time.UTC).UnixNano()/1e6, calc.CalFamilyEndTime(familyStartTime))
+
+	// invalid options
+	_, err = NewCalculator(Day, 5*timeutil.OneHour, UTCAlignment)
+	assert.NotNil(t, err)
+	_, err = NewCalculator(Day, 2*timeutil.OneDay, UTCAlignment)
+	assert.NotNil(t, err)
+	_, err = NewCalculator(Month, timeutil.OneHour, UTCAlignment)
+	assert.NotNil(t, err)
+	_, err = NewCalculator(Unknown, 0, UTCAlignment)
+	assert.NotNil(t, err)
+	_, err = NewCalculator(Day, 0, "rolling")
+	assert.NotNil(t, err)
+}
@@ -4,16 +4,34 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
+const (
+	// FormatAuto is the default format, same as console for compatibility
+	FormatAuto = "auto"
+	// FormatConsole outputs the human-readable logs, the message is prefixed by module, like "[module]:message"
+	FormatConsole = "console"
+	// FormatJSON outputs one json object per line with stable field keys, which is parsed by log pipelines(ELK/Loki)
+	FormatJSON = "json"
+)
+
+// Config represents the logging config of runtime
 type Config struct {
-	Path         string        `toml:"path"`
+	// Path is the file which logs are appended to, empty means stdout
+	Path string `toml:"path"`
+	// Format is the output format of logs, auto/console/json
 	Format       string        `toml:"format"`
 	Level        zapcore.Level `toml:"level"`
 	SuppressLogo bool          `toml:"suppress-logo"`
+	// Sampling limits the high-frequency messages(e.g. per-point errors), the messages with same level and text
+	// are logged the first N times each second, then every Mth time, 0 means no sampling
+	SamplingInitial    int `toml:"sampling-initial"`
+	SamplingThereafter int `toml:"sampling-thereafter"`
 }
 
 // NewConfig returns a new instance of Config with defaults.
 func NewConfig() Config {
 	return Config{
-		Format: "auto",
+		Format:             FormatAuto,
+		SamplingInitial:    100,
+		SamplingThereafter: 100,
 	}
 }
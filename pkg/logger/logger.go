@@ -4,69 +4,106 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/mattn/go-isatty"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-var (
-	logger *zap.Logger
-	once   sync.Once
+// The stable keys of the fields in json format
+const (
+	TimeKey       = "time"
+	LevelKey      = "level"
+	ModuleKey     = "module"
+	MessageKey    = "msg"
+	StacktraceKey = "stacktrace"
 )
 
+// root is the zap logger of process shared by module loggers, replaced by InitLogger with the config of runtime
+var root atomic.Value
+
+// rootLogger represents the zap logger of process with its format
+type rootLogger struct {
+	log  *zap.Logger
+	json bool
+}
+
+func init() {
+	config := NewConfig()
+	if err := InitLogger(config); err != nil {
+		panic(err)
+	}
+}
+
 // Logger is wrapper for zap logger with module, it is singleton.
 type Logger struct {
 	module string
-	log    *zap.Logger
 }
 
 // GetLogger return logger with module name
 func GetLogger(module string) *Logger {
 	return &Logger{
 		module: module,
-		log:    getLogger(),
 	}
 }
 
-func getLogger() *zap.Logger {
-	once.Do(func() {
-		logger = New()
-	})
-	return logger
+// InitLogger replaces the zap logger of process by config, the module loggers created before use it too
+func InitLogger(config Config) error {
+	l, err := config.New()
+	if err != nil {
+		return err
+	}
+	root.Store(&rootLogger{log: l, json: config.Format == FormatJSON})
+	return nil
+}
+
+// getLogger returns the root logger of process
+func getLogger() *rootLogger {
+	return root.Load().(*rootLogger)
 }
 
+// New returns the zap logger of process
 func New() *zap.Logger {
-	config := NewConfig()
-	l, _ := config.New()
-	return l
+	return getLogger().log
 }
 
+// New creates the zap logger by config, the messages are sampled if sampling is set
 func (c *Config) New() (*zap.Logger, error) {
-	//TODO ?????
-	//w := zapcore.AddSync(&lumberjack.Logger{
-	//	Filename:   "/var/log/myapp/foo.log",
-	//	MaxSize:    500, // megabytes
-	//	MaxBackups: 3,
-	//	MaxAge:     28, // days
-	//})
-	//core := zapcore.NewCore(
-	//	zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
-	//	w,
-	//	zap.InfoLevel,
-	//)
-	//logger := zap.New(core)
-	encoderConfig := zap.NewProductionEncoderConfig()
-	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-	core := zapcore.NewCore(
-		zapcore.NewConsoleEncoder(encoderConfig),
-		os.Stdout,
-		c.Level,
-	)
-
-	logger := zap.New(core)
-	return logger, nil
+	var encoder zapcore.Encoder
+	switch c.Format {
+	case "", FormatAuto, FormatConsole:
+		encoderConfig := zap.NewProductionEncoderConfig()
+		encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	case FormatJSON:
+		encoder = zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+			TimeKey:        TimeKey,
+			LevelKey:       LevelKey,
+			MessageKey:     MessageKey,
+			StacktraceKey:  StacktraceKey,
+			LineEnding:     zapcore.DefaultLineEnding,
+			EncodeLevel:    zapcore.LowercaseLevelEncoder,
+			EncodeTime:     zapcore.ISO8601TimeEncoder,
+			EncodeDuration: zapcore.StringDurationEncoder,
+		})
+	default:
+		return nil, fmt.Errorf("unknown log format:%s", c.Format)
+	}
+	var output zapcore.WriteSyncer = os.Stdout
+	if len(c.Path) > 0 {
+		f, err := os.OpenFile(c.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("open log file[%s] error:%s", c.Path, err)
+		}
+		output = f
+	}
+	core := zapcore.NewCore(encoder, output, c.Level)
+	if c.SamplingInitial > 0 && c.SamplingThereafter > 0 {
+		core = zapcore.NewSampler(core, time.Second, c.SamplingInitial, c.SamplingThereafter)
+	}
+	return zap.New(core), nil
 }
 
 // IsTerminal checks if w is a file and whether it is an interactive terminal session.
@@ -82,25 +119,42 @@ func IsTerminal(w io.Writer) bool {
 // Debug logs a message at DebugLevel. The message includes any fields passed
 // at the log site, as well as any fields accumulated on the logger.
 func (l *Logger) Debug(msg string, fields ...zap.Field) {
-	l.log.Debug(l.formatMsg(msg), fields...)
+	l.write(zapcore.DebugLevel, msg, fields)
 }
 
 // Info logs a message at InfoLevel. The message includes any fields passed
 // at the log site, as well as any fields accumulated on the logger.
 func (l *Logger) Info(msg string, fields ...zap.Field) {
-	l.log.Info(l.formatMsg(msg), fields...)
+	l.write(zapcore.InfoLevel, msg, fields)
 }
 
 // Warn logs a message at WarnLevel. The message includes any fields passed
 // at the log site, as well as any fields accumulated on the logger.
 func (l *Logger) Warn(msg string, fields ...zap.Field) {
-	l.log.Warn(l.formatMsg(msg), fields...)
+	l.write(zapcore.WarnLevel, msg, fields)
 }
 
 // Error logs a message at ErrorLevel. The message includes any fields passed
 // at the log site, as well as any fields accumulated on the logger.
 func (l *Logger) Error(msg string, fields ...zap.Field) {
-	l.log.Error(l.formatMsg(msg), fields...)
+	l.write(zapcore.ErrorLevel, msg, fields)
+}
+
+// write logs the message with module, the module is the field of json, otherwise is the prefix of message
+func (l *Logger) write(level zapcore.Level, msg string, fields []zap.Field) {
+	r := getLogger()
+	if !r.json {
+		msg = l.formatMsg(msg)
+	}
+	ce := r.log.Check(level, msg)
+	if ce == nil {
+		return
+	}
+	if r.json {
+		// copies the fields, so that the array of caller isn't modified
+		fields = append(fields[:len(fields):len(fields)], String(ModuleKey, l.module))
+	}
+	ce.Write(fields...)
 }
 
 // formatMsg formats msg using module name
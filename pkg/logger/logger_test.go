@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readLines(t *testing.T, path string) []string {
+	f, err := os.Open(path)
+	assert.Nil(t, err)
+	defer func() {
+		_ = f.Close()
+	}()
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
+
+func TestInitLogger_json(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "logger")
+	defer func() {
+		_ = os.RemoveAll(dir)
+		_ = InitLogger(NewConfig())
+	}()
+	path := filepath.Join(dir, "lind.log")
+
+	// the logger created before init uses the new config
+	log := GetLogger("test/module")
+	assert.Nil(t, InitLogger(Config{Path: path, Format: FormatJSON}))
+	log.Info("hello", String("db", "test"), Error(fmt.Errorf("err")))
+	log.Debug("not logged")
+
+	lines := readLines(t, path)
+	assert.Len(t, lines, 1)
+	entry := make(map[string]interface{})
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "info", entry[LevelKey])
+	assert.Equal(t, "hello", entry[MessageKey])
+	assert.Equal(t, "test/module", entry[ModuleKey])
+	assert.Equal(t, "test", entry["db"])
+	assert.Equal(t, "err", entry["error"])
+	assert.NotEmpty(t, entry[TimeKey])
+}
+
+func TestInitLogger_console(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "logger")
+	defer func() {
+		_ = os.RemoveAll(dir)
+		_ = InitLogger(NewConfig())
+	}()
+	path := filepath.Join(dir, "lind.log")
+
+	assert.Nil(t, InitLogger(Config{Path: path}))
+	GetLogger("test/module").Warn("hello")
+	lines := readLines(t, path)
+	assert.Len(t, lines, 1)
+	assert.True(t, strings.Contains(lines[0], "[test/module]:hello"))
+}
+
+func TestInitLogger_sampling(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "logger")
+	defer func() {
+		_ = os.RemoveAll(dir)
+		_ = InitLogger(NewConfig())
+	}()
+	path := filepath.Join(dir, "lind.log")
+
+	// the first 2 messages, then every 5th
+	assert.Nil(t, InitLogger(Config{Path: path, Format: FormatJSON, SamplingInitial: 2, SamplingThereafter: 5}))
+	log := GetLogger("test/module")
+	for i := 0; i < 12; i++ {
+		log.Error("write point error")
+	}
+	log.Error("other error")
+	assert.Len(t, readLines(t, path), 5)
+}
+
+func TestInitLogger_error(t *testing.T) {
+	assert.NotNil(t, InitLogger(Config{Format: "xml"}))
+	assert.NotNil(t, InitLogger(Config{Path: "/not/exist/dir/lind.log"}))
+	// the logger isn't replaced if fail
+	assert.NotNil(t, New())
+}
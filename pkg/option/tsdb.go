@@ -1,6 +1,7 @@
 package option
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/eleme/lindb/pkg/interval"
@@ -19,4 +20,99 @@ type ShardOption struct {
 	Ahead        int64         `toml:"ahead" json:"ahead"`               // allowed timestamp write ahead
 	Interval     time.Duration `toml:"interval" json:"interval"`         // interval duration
 	IntervalType interval.Type `toml:"intervalType" json:"intervalType"` // interval type
+	// type of storage engine, empty means default engine based on memory database and kv store
+	EngineType string `toml:"engineType" json:"engineType,omitempty"`
+	// high-cardinality tags removed at write time(metric name => tag keys), the colliding series are aggregated
+	BannedTags map[string][]string `toml:"bannedTags" json:"bannedTags,omitempty"`
+	// coarser intervals which the points are aggregated into at write time besides the interval,
+	// so that the rollups are available immediately without background rollup job
+	Rollups []RollupOption `toml:"rollups" json:"rollups,omitempty"`
+	// time window and alignment of family, which is the flush granularity of memory database
+	Family FamilyOption `toml:"family" json:"family,omitempty"`
+}
+
+// FamilyOption represents the time window of family and how the segments and families are aligned,
+// the family is the flush granularity of memory database, which should match the retention granularity.
+type FamilyOption struct {
+	// time window of family, which is only configurable for day interval type(divisor of 1 day like 2h),
+	// 0 means the default family of interval type(hour for day, day for month, month for year)
+	Duration time.Duration `toml:"duration" json:"duration,omitempty"`
+	// local(the midnight of server's time zone, default) or utc(the midnight of UTC),
+	// which is also used by the rollup intervals
+	Alignment interval.Alignment `toml:"alignment" json:"alignment,omitempty"`
+}
+
+// RollupOption represents a coarser interval of shard, which is stored by the segments of interval type
+type RollupOption struct {
+	Interval     time.Duration `toml:"interval" json:"interval"`         // interval duration
+	IntervalType interval.Type `toml:"intervalType" json:"intervalType"` // interval type
+}
+
+// Validate checks if the intervals are valid, the interval(if set) and rollup intervals must be
+// the multiple of 1ms and valid for the calculators of their interval types(see interval.ValidateInterval),
+// so that the sub-second intervals like 100ms are supported, then validates the rollups.
+func (o ShardOption) Validate() error {
+	if o.Family.Duration%time.Millisecond != 0 {
+		return fmt.Errorf("family duration[%s] must be multiple of 1ms", o.Family.Duration)
+	}
+	if o.Interval != 0 || o.Family != (FamilyOption{}) {
+		calc, err := o.Calculator()
+		if err != nil {
+			return err
+		}
+		if err = validateInterval(o.Interval, calc); err != nil {
+			return err
+		}
+	}
+	for _, rollup := range o.Rollups {
+		calc, err := o.RollupCalculator(rollup)
+		if err != nil {
+			return err
+		}
+		if err = validateInterval(rollup.Interval, calc); err != nil {
+			return err
+		}
+	}
+	return o.ValidateRollups()
+}
+
+// Calculator returns the calculator of the interval type with the time window and alignment of family
+func (o ShardOption) Calculator() (interval.Calculator, error) {
+	return interval.NewCalculator(o.IntervalType, int64(o.Family.Duration/time.Millisecond), o.Family.Alignment)
+}
+
+// RollupCalculator returns the calculator of the rollup interval type, which has the default family
+// of interval type and the same alignment as the interval.
+func (o ShardOption) RollupCalculator(rollup RollupOption) (interval.Calculator, error) {
+	return interval.NewCalculator(rollup.IntervalType, 0, o.Family.Alignment)
+}
+
+// validateInterval checks if the interval is valid for the calculator of interval type
+func validateInterval(intervalValue time.Duration, calc interval.Calculator) error {
+	if intervalValue%time.Millisecond != 0 {
+		return fmt.Errorf("interval[%s] must be multiple of 1ms", intervalValue)
+	}
+	return interval.ValidateInterval(calc, int64(intervalValue/time.Millisecond))
+}
+
+// ValidateRollups checks if the rollup intervals are valid, each of them must be multiple of the interval,
+// the interval types must be different, because the segments of shard are separated by interval type.
+func (o ShardOption) ValidateRollups() error {
+	if len(o.Rollups) > 0 && o.Interval <= 0 {
+		return fmt.Errorf("interval must be set if rollup intervals are set")
+	}
+	intervalTypes := map[interval.Type]struct{}{o.IntervalType: {}}
+	for _, rollup := range o.Rollups {
+		if rollup.Interval <= o.Interval || rollup.Interval%o.Interval != 0 {
+			return fmt.Errorf("rollup interval[%s] must be multiple of interval[%s]", rollup.Interval, o.Interval)
+		}
+		if _, err := interval.GetCalculator(rollup.IntervalType); err != nil {
+			return fmt.Errorf("interval type[%d] of rollup interval[%s] not define", rollup.IntervalType, rollup.Interval)
+		}
+		if _, ok := intervalTypes[rollup.IntervalType]; ok {
+			return fmt.Errorf("interval type[%s] of rollup interval[%s] is duplicated", rollup.IntervalType, rollup.Interval)
+		}
+		intervalTypes[rollup.IntervalType] = struct{}{}
+	}
+	return nil
 }
@@ -0,0 +1,79 @@
+package option
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/pkg/interval"
+)
+
+func TestShardOption_ValidateRollups(t *testing.T) {
+	option := ShardOption{Interval: 10 * time.Second, IntervalType: interval.Day}
+	assert.Nil(t, option.ValidateRollups())
+
+	option.Rollups = []RollupOption{
+		{Interval: 5 * time.Minute, IntervalType: interval.Month},
+		{Interval: time.Hour, IntervalType: interval.Year},
+	}
+	assert.Nil(t, option.ValidateRollups())
+
+	option.Rollups = []RollupOption{{Interval: 15 * time.Second, IntervalType: interval.Month}}
+	assert.NotNil(t, option.ValidateRollups())
+	option.Rollups = []RollupOption{{Interval: 10 * time.Second, IntervalType: interval.Month}}
+	assert.NotNil(t, option.ValidateRollups())
+	option.Rollups = []RollupOption{{Interval: 5 * time.Minute, IntervalType: interval.Unknown}}
+	assert.NotNil(t, option.ValidateRollups())
+	option.Rollups = []RollupOption{{Interval: 5 * time.Minute, IntervalType: interval.Day}}
+	assert.NotNil(t, option.ValidateRollups())
+	option.Rollups = []RollupOption{
+		{Interval: 5 * time.Minute, IntervalType: interval.Month},
+		{Interval: time.Hour, IntervalType: interval.Month},
+	}
+	assert.NotNil(t, option.ValidateRollups())
+	option.Interval = 0
+	assert.NotNil(t, option.ValidateRollups())
+}
+
+func TestShardOption_Validate(t *testing.T) {
+	assert.Nil(t, ShardOption{}.Validate())
+	option := ShardOption{Interval: 100 * time.Millisecond, IntervalType: interval.Day,
+		Rollups: []RollupOption{{Interval: 10 * time.Second, IntervalType: interval.Month}}}
+	assert.Nil(t, option.Validate())
+
+	assert.NotNil(t, ShardOption{Interval: 100 * time.Microsecond, IntervalType: interval.Day}.Validate())
+	assert.NotNil(t, ShardOption{Interval: 1500 * time.Microsecond, IntervalType: interval.Day}.Validate())
+	assert.NotNil(t, ShardOption{Interval: 10 * time.Millisecond, IntervalType: interval.Day}.Validate())
+	assert.NotNil(t, ShardOption{Interval: 100 * time.Millisecond, IntervalType: interval.Unknown}.Validate())
+	option.Rollups = []RollupOption{{Interval: 200 * time.Millisecond, IntervalType: interval.Month}}
+	assert.NotNil(t, option.Validate())
+	option.Rollups = []RollupOption{{Interval: 10 * time.Second, IntervalType: interval.Day}}
+	assert.NotNil(t, option.Validate())
+
+	// family option
+	option = ShardOption{Interval: 10 * time.Second, IntervalType: interval.Day,
+		Family:  FamilyOption{Duration: 2 * time.Hour, Alignment: interval.UTCAlignment},
+		Rollups: []RollupOption{{Interval: 5 * time.Minute, IntervalType: interval.Month}}}
+	assert.Nil(t, option.Validate())
+	calc, err := option.Calculator()
+	assert.Nil(t, err)
+	assert.Equal(t, 720, calc.CalSlotsPerFamily(10*1000))
+	calc, err = option.RollupCalculator(option.Rollups[0])
+	assert.Nil(t, err)
+	assert.Equal(t, 288, calc.CalSlotsPerFamily(5*60*1000))
+	option.Family.Duration = 5 * time.Hour
+	assert.NotNil(t, option.Validate())
+	option.Family.Duration = 1500 * time.Microsecond
+	assert.NotNil(t, option.Validate())
+	option.Family = FamilyOption{Alignment: "rolling"}
+	assert.NotNil(t, option.Validate())
+	// the family of 2 hours has too many slots of 100ms interval
+	option = ShardOption{Interval: 100 * time.Millisecond, IntervalType: interval.Day,
+		Family: FamilyOption{Duration: 2 * time.Hour}}
+	assert.NotNil(t, option.Validate())
+	// the family duration of month isn't configurable
+	option = ShardOption{Interval: 5 * time.Minute, IntervalType: interval.Month,
+		Family: FamilyOption{Duration: time.Hour}}
+	assert.NotNil(t, option.Validate())
+}
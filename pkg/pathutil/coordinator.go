@@ -3,23 +3,36 @@ package pathutil
 import (
 	"fmt"
 	"path/filepath"
-
-	"github.com/eleme/lindb/constants"
 )
 
 // GetStorageClusterPath returns path which storing config of storage cluster
 func GetStorageClusterPath(name string) string {
-	return fmt.Sprintf("%s/%s", constants.StorageClusterConfigPath, name)
+	return Keys.Cluster.StorageClusters.Key(name)
 }
 
 // GetDatabaseConfigPath returns path which storing config of database
 func GetDatabaseConfigPath(name string) string {
-	return constants.DatabaseConfigPath + "/" + name
+	return Keys.Databases.Configs.Key(name)
 }
 
 // GetDatabaseAssignPath returns path which storing shard assignment of database
 func GetDatabaseAssignPath(name string) string {
-	return constants.DatabaseAssignPath + "/" + name
+	return Keys.Databases.Assignments.Key(name)
+}
+
+// GetDatabaseLimitsPath returns path which storing runtime limits of database
+func GetDatabaseLimitsPath(name string) string {
+	return Keys.Databases.Limits.Key(name)
+}
+
+// GetShardLockPath returns the lock path of database's shards
+func GetShardLockPath(name string) string {
+	return Keys.Shards.Locks.Key(name)
+}
+
+// GetShardStatePath returns path which storing the states of database's shards
+func GetShardStatePath(name string) string {
+	return Keys.Shards.States.Key(name)
 }
 
 // GetNodePath returns node register path
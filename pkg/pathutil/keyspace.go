@@ -0,0 +1,196 @@
+package pathutil
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/eleme/lindb/constants"
+)
+
+// keySeparator is the separator of the elements of key in state repository
+const keySeparator = "/"
+
+// KeyRoot represents the root of keys of the same kind in state repository, the key of name is root/name
+type KeyRoot string
+
+// Key returns the key of name under root
+func (r KeyRoot) Key(name string) string {
+	return string(r) + keySeparator + name
+}
+
+// Prefix returns the prefix for listing/watching the keys under root, the prefix ends with separator,
+// so that the keys of other root sharing the same prefix(e.g. /database/config2) aren't matched.
+func (r KeyRoot) Prefix() string {
+	return string(r) + keySeparator
+}
+
+// Name returns the name of key under root, returns false if the key isn't directly under root
+func (r KeyRoot) Name(key string) (string, bool) {
+	if !strings.HasPrefix(key, r.Prefix()) {
+		return "", false
+	}
+	name := key[len(r.Prefix()):]
+	if ValidateName(name) != nil {
+		return "", false
+	}
+	return name, true
+}
+
+// ClusterKeys represents the keys of cluster level state
+type ClusterKeys struct {
+	// Info is the identity(id/metadata schema version) of cluster
+	Info string
+	// StorageClusters are the configs of storage clusters
+	StorageClusters KeyRoot
+	// AuditLogs are the audit logs of administrative operations
+	AuditLogs KeyRoot
+}
+
+// DatabaseKeys represents the keys of databases
+type DatabaseKeys struct {
+	// Configs are the configs of databases
+	Configs KeyRoot
+	// Assignments are the shard assignments of databases
+	Assignments KeyRoot
+	// Limits are the runtime limits of databases
+	Limits KeyRoot
+}
+
+// NodeKeys represents the keys of nodes
+type NodeKeys struct {
+	// Active are the active nodes registered with lease
+	Active KeyRoot
+}
+
+// ShardKeys represents the keys of shards
+type ShardKeys struct {
+	// Locks are the locks of database's shards, so that the shards are split/moved one at a time
+	Locks KeyRoot
+	// States are the states(hot/read-only/frozen) of database's shards
+	States KeyRoot
+	// Corruptions are the corrupted files of shards reported by storage nodes, which are removed after repaired
+	Corruptions KeyRoot
+}
+
+// MasterKeys represents the keys of master
+type MasterKeys struct {
+	// Node is the elected master node
+	Node string
+	// BackupLock is the lock of backup scheduling of master
+	BackupLock string
+}
+
+// StatsKeys represents the keys of database statistics
+type StatsKeys struct {
+	// Nodes are the database statistics reported by each node
+	Nodes KeyRoot
+	// Databases is the database statistics aggregated by master
+	Databases string
+}
+
+// QueryKeys represents the keys of query control
+type QueryKeys struct {
+	// Blacklist are the blacklist rules of queries
+	Blacklist KeyRoot
+	// UDFs are the user defined functions, which are grouped by database
+	UDFs KeyRoot
+}
+
+// Keyspace represents the typed layout of keys in state repository, the keys are grouped by kind
+// (cluster/databases/nodes/shards/masters/stats/queries) under distinct roots. The keys are relative to the namespace
+// of state repository, which prefixes all keys, so that multiple clusters can share an etcd cluster.
+type Keyspace struct {
+	Cluster   ClusterKeys
+	Databases DatabaseKeys
+	Nodes     NodeKeys
+	Shards    ShardKeys
+	Masters   MasterKeys
+	Stats     StatsKeys
+	Queries   QueryKeys
+}
+
+// Keys is the keyspace of cluster
+var Keys = Keyspace{
+	Cluster: ClusterKeys{
+		Info:            constants.ClusterInfoPath,
+		StorageClusters: KeyRoot(constants.StorageClusterConfigPath),
+		AuditLogs:       KeyRoot(constants.AuditLogPath),
+	},
+	Databases: DatabaseKeys{
+		Configs:     KeyRoot(constants.DatabaseConfigPath),
+		Assignments: KeyRoot(constants.DatabaseAssignPath),
+		Limits:      KeyRoot(constants.DatabaseLimitsPath),
+	},
+	Nodes: NodeKeys{
+		Active: KeyRoot(constants.ActiveNodesPath),
+	},
+	Shards: ShardKeys{
+		Locks:       KeyRoot(constants.ShardLockPath),
+		States:      KeyRoot(constants.ShardStatePath),
+		Corruptions: KeyRoot(constants.ShardCorruptionPath),
+	},
+	Masters: MasterKeys{
+		Node:       constants.MasterPath,
+		BackupLock: constants.BackupLockPath,
+	},
+	Stats: StatsKeys{
+		Nodes:     KeyRoot(constants.StatsNodesPath),
+		Databases: constants.StatsDatabasesPath,
+	},
+	Queries: QueryKeys{
+		Blacklist: KeyRoot(constants.QueryBlacklistPath),
+		UDFs:      KeyRoot(constants.QueryUDFPath),
+	},
+}
+
+// Roots returns all roots and keys of keyspace
+func (k Keyspace) Roots() []string {
+	return []string{
+		k.Cluster.Info,
+		string(k.Cluster.StorageClusters),
+		string(k.Cluster.AuditLogs),
+		string(k.Databases.Configs),
+		string(k.Databases.Assignments),
+		string(k.Databases.Limits),
+		string(k.Nodes.Active),
+		string(k.Shards.Locks),
+		string(k.Shards.States),
+		string(k.Shards.Corruptions),
+		k.Masters.Node,
+		k.Masters.BackupLock,
+		string(k.Stats.Nodes),
+		k.Stats.Databases,
+		string(k.Queries.Blacklist),
+		string(k.Queries.UDFs),
+	}
+}
+
+// StateRoots returns the roots and keys of state which isn't the metadata of cluster,
+// e.g. the identity of cluster, the runtime state maintained by running nodes and the history of operations.
+func (k Keyspace) StateRoots() []string {
+	return []string{
+		k.Cluster.Info,
+		string(k.Cluster.AuditLogs),
+		string(k.Nodes.Active),
+		string(k.Shards.Locks),
+		k.Masters.Node,
+		k.Masters.BackupLock,
+		string(k.Stats.Nodes),
+		k.Stats.Databases,
+	}
+}
+
+// ValidateName checks if the name can be an element of key, the name with separator or relative element
+// is invalid, because the key of name would collide with the keys of other names or kinds.
+func ValidateName(name string) error {
+	if len(name) == 0 {
+		return fmt.Errorf("name cannot be empty")
+	}
+	if strings.Contains(name, keySeparator) {
+		return fmt.Errorf("name[%s] cannot contain '%s'", name, keySeparator)
+	}
+	if name == "." || name == ".." {
+		return fmt.Errorf("name[%s] cannot be relative element", name)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package pathutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyRoot(t *testing.T) {
+	root := KeyRoot("/database/config")
+	assert.Equal(t, "/database/config/db", root.Key("db"))
+	assert.Equal(t, "/database/config/", root.Prefix())
+	name, ok := root.Name(root.Key("db"))
+	assert.True(t, ok)
+	assert.Equal(t, "db", name)
+	// the key of other root sharing the same prefix
+	_, ok = root.Name("/database/config2/db")
+	assert.False(t, ok)
+	assert.False(t, strings.HasPrefix("/database/config2/db", root.Prefix()))
+	// the key isn't directly under root
+	_, ok = root.Name("/database/config/db/shard")
+	assert.False(t, ok)
+	_, ok = root.Name(root.Prefix())
+	assert.False(t, ok)
+}
+
+func TestKeyspace_Roots(t *testing.T) {
+	roots := Keys.Roots()
+	for i, root := range roots {
+		assert.True(t, strings.HasPrefix(root, "/"), root)
+		for j, other := range roots {
+			if i == j {
+				continue
+			}
+			// the keys of different kinds never collide
+			assert.NotEqual(t, root, other)
+			assert.False(t, strings.HasPrefix(other+"/", root+"/"), "%s contains %s", root, other)
+		}
+	}
+	assert.Equal(t, GetDatabaseConfigPath("db"), Keys.Databases.Configs.Key("db"))
+	assert.Equal(t, GetDatabaseAssignPath("db"), Keys.Databases.Assignments.Key("db"))
+	assert.Equal(t, GetDatabaseLimitsPath("db"), Keys.Databases.Limits.Key("db"))
+	assert.Equal(t, GetStorageClusterPath("cluster"), Keys.Cluster.StorageClusters.Key("cluster"))
+	assert.Equal(t, GetShardLockPath("db"), Keys.Shards.Locks.Key("db"))
+	assert.Equal(t, GetShardStatePath("db"), Keys.Shards.States.Key("db"))
+}
+
+func TestKeyspace_StateRoots(t *testing.T) {
+	roots := Keys.Roots()
+	for _, root := range Keys.StateRoots() {
+		assert.Contains(t, roots, root)
+	}
+	assert.NotContains(t, Keys.StateRoots(), string(Keys.Databases.Configs))
+}
+
+func TestValidateName(t *testing.T) {
+	assert.Nil(t, ValidateName("db"))
+	assert.Nil(t, ValidateName("db.1"))
+	assert.NotNil(t, ValidateName(""))
+	assert.NotNil(t, ValidateName("db/1"))
+	assert.NotNil(t, ValidateName("."))
+	assert.NotNil(t, ValidateName(".."))
+}
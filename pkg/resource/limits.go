@@ -0,0 +1,125 @@
+package resource
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// cgroupRoot is the mount point of cgroup file system, the cgroup of container is mounted as root
+// in its cgroup namespace
+var cgroupRoot = "/sys/fs/cgroup"
+
+// unlimitedMemory is the threshold of unlimited memory, cgroup v1 reports a page-aligned max int64 if no limit
+const unlimitedMemory = int64(1) << 62
+
+// Limits represents the cpu and memory limits of process applied by cgroup(v1 or v2),
+// zero means unlimited or unknown(e.g. not running in container).
+type Limits struct {
+	// CPU is the cpu quota in cores, like 1.5 cores
+	CPU float64
+	// Memory is the memory limit in bytes
+	Memory int64
+}
+
+// DetectLimits detects the cpu and memory limits from cgroup file system, cgroup v2 first then v1
+func DetectLimits() Limits {
+	return Limits{
+		CPU:    cpuQuota(),
+		Memory: memoryLimit(),
+	}
+}
+
+// MaxProcs returns the GOMAXPROCS matching the cpu quota, which is rounded up and bounded by number of cpu cores,
+// returns number of cpu cores if no cpu quota
+func (l Limits) MaxProcs(numCPU int) int {
+	if l.CPU <= 0 {
+		return numCPU
+	}
+	procs := int(math.Ceil(l.CPU))
+	if procs > numCPU {
+		procs = numCPU
+	}
+	if procs < 1 {
+		procs = 1
+	}
+	return procs
+}
+
+// ResolveBytes returns the bytes as the ratio of memory limit if both ratio and memory limit are set,
+// otherwise returns the absolute bytes, so that the budgets follow the memory limit of container.
+func (l Limits) ResolveBytes(bytes int64, ratio float64) int64 {
+	if ratio <= 0 || l.Memory <= 0 {
+		return bytes
+	}
+	return int64(float64(l.Memory) * ratio)
+}
+
+// AdjustMaxProcs sets GOMAXPROCS by the cpu quota of limits, the GOMAXPROCS environment variable takes precedence,
+// returns the GOMAXPROCS after adjusted. The go runtime uses the number of cpu cores of host by default,
+// which causes the cpu throttling of container with a small quota.
+func AdjustMaxProcs(limits Limits) int {
+	if _, ok := os.LookupEnv("GOMAXPROCS"); ok {
+		return runtime.GOMAXPROCS(0)
+	}
+	procs := limits.MaxProcs(runtime.NumCPU())
+	runtime.GOMAXPROCS(procs)
+	return procs
+}
+
+// cpuQuota returns the cpu quota in cores, returns 0 if unlimited
+func cpuQuota() float64 {
+	// cgroup v2: "$MAX $PERIOD", MAX is "max" if unlimited
+	if fields := strings.Fields(readFile(filepath.Join(cgroupRoot, "cpu.max"))); len(fields) == 2 {
+		return quotaOf(fields[0], fields[1])
+	}
+	// cgroup v1: quota is -1 if unlimited
+	for _, dir := range []string{"cpu", "cpu,cpuacct", "cpuacct,cpu"} {
+		quota := readFile(filepath.Join(cgroupRoot, dir, "cpu.cfs_quota_us"))
+		if len(quota) > 0 {
+			return quotaOf(quota, readFile(filepath.Join(cgroupRoot, dir, "cpu.cfs_period_us")))
+		}
+	}
+	return 0
+}
+
+// quotaOf returns the cores of quota and period, returns 0 if invalid
+func quotaOf(quota, period string) float64 {
+	q, err := strconv.ParseFloat(quota, 64)
+	if err != nil || q <= 0 {
+		return 0
+	}
+	p, err := strconv.ParseFloat(period, 64)
+	if err != nil || p <= 0 {
+		return 0
+	}
+	return q / p
+}
+
+// memoryLimit returns the memory limit in bytes, returns 0 if unlimited
+func memoryLimit() int64 {
+	// cgroup v2: "max" if unlimited
+	limit := readFile(filepath.Join(cgroupRoot, "memory.max"))
+	if len(limit) == 0 {
+		// cgroup v1
+		limit = readFile(filepath.Join(cgroupRoot, "memory", "memory.limit_in_bytes"))
+	}
+	bytes, err := strconv.ParseInt(limit, 10, 64)
+	if err != nil || bytes <= 0 || bytes >= unlimitedMemory {
+		return 0
+	}
+	return bytes
+}
+
+// readFile returns the trimmed content of file, returns empty if not exist
+func readFile(path string) string {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,96 @@
+package resource
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mockCgroup(t *testing.T, files map[string]string) func() {
+	dir, err := ioutil.TempDir("", "cgroup")
+	assert.Nil(t, err)
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+	old := cgroupRoot
+	cgroupRoot = dir
+	return func() {
+		cgroupRoot = old
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func TestDetectLimits_v2(t *testing.T) {
+	reset := mockCgroup(t, map[string]string{
+		"cpu.max":    "150000 100000\n",
+		"memory.max": "1073741824\n",
+	})
+	assert.Equal(t, Limits{CPU: 1.5, Memory: 1024 * 1024 * 1024}, DetectLimits())
+	reset()
+
+	reset = mockCgroup(t, map[string]string{
+		"cpu.max":    "max 100000\n",
+		"memory.max": "max\n",
+	})
+	assert.Equal(t, Limits{}, DetectLimits())
+	reset()
+}
+
+func TestDetectLimits_v1(t *testing.T) {
+	reset := mockCgroup(t, map[string]string{
+		"cpu,cpuacct/cpu.cfs_quota_us":  "200000\n",
+		"cpu,cpuacct/cpu.cfs_period_us": "100000\n",
+		"memory/memory.limit_in_bytes":  "2147483648\n",
+	})
+	assert.Equal(t, Limits{CPU: 2, Memory: 2 * 1024 * 1024 * 1024}, DetectLimits())
+	reset()
+
+	reset = mockCgroup(t, map[string]string{
+		"cpu/cpu.cfs_quota_us":         "-1\n",
+		"cpu/cpu.cfs_period_us":        "100000\n",
+		"memory/memory.limit_in_bytes": "9223372036854771712\n",
+	})
+	assert.Equal(t, Limits{}, DetectLimits())
+	reset()
+}
+
+func TestDetectLimits_notExist(t *testing.T) {
+	reset := mockCgroup(t, nil)
+	defer reset()
+	assert.Equal(t, Limits{}, DetectLimits())
+}
+
+func TestLimits_MaxProcs(t *testing.T) {
+	assert.Equal(t, 8, Limits{}.MaxProcs(8))
+	assert.Equal(t, 2, Limits{CPU: 1.5}.MaxProcs(8))
+	assert.Equal(t, 1, Limits{CPU: 0.2}.MaxProcs(8))
+	assert.Equal(t, 8, Limits{CPU: 16}.MaxProcs(8))
+}
+
+func TestLimits_ResolveBytes(t *testing.T) {
+	assert.Equal(t, int64(100), Limits{}.ResolveBytes(100, 0.5))
+	assert.Equal(t, int64(100), Limits{Memory: 1000}.ResolveBytes(100, 0))
+	assert.Equal(t, int64(500), Limits{Memory: 1000}.ResolveBytes(100, 0.5))
+}
+
+func TestAdjustMaxProcs(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+
+	_ = os.Unsetenv("GOMAXPROCS")
+	assert.Equal(t, 1, AdjustMaxProcs(Limits{CPU: 0.5}))
+	assert.Equal(t, 1, runtime.GOMAXPROCS(0))
+	assert.Equal(t, runtime.NumCPU(), AdjustMaxProcs(Limits{}))
+
+	// environment variable takes precedence
+	_ = os.Setenv("GOMAXPROCS", "3")
+	defer func() {
+		_ = os.Unsetenv("GOMAXPROCS")
+	}()
+	assert.Equal(t, runtime.NumCPU(), AdjustMaxProcs(Limits{CPU: 0.5}))
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/eleme/lindb/pkg/logger"
+)
+
+// Defines the status of health check
+const (
+	HealthOK   = "ok"
+	HealthFail = "fail"
+)
+
+// HealthCheck checks an aspect of server health, returns the error if unhealthy
+type HealthCheck func() error
+
+// HealthStatus represents the result of health checks
+type HealthStatus struct {
+	Status string `json:"status"`
+	// Checks are the results of each check, the value is ok or the error message
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// namedCheck represents the health check with name
+type namedCheck struct {
+	name  string
+	check HealthCheck
+}
+
+// Health exposes the liveness and readiness of server for orchestrators(like the probes of Kubernetes),
+// liveness means the process works and needn't restart, readiness means the server can serve requests.
+// The status code is 200 if all checks are ok, otherwise 503.
+type Health struct {
+	mutex     sync.RWMutex
+	liveness  []namedCheck
+	readiness []namedCheck
+}
+
+// NewHealth creates the health without any checks, which is healthy
+func NewHealth() *Health {
+	return &Health{}
+}
+
+// AddLivenessCheck adds the check of liveness
+func (h *Health) AddLivenessCheck(name string, check HealthCheck) {
+	h.mutex.Lock()
+	h.liveness = append(h.liveness, namedCheck{name: name, check: check})
+	h.mutex.Unlock()
+}
+
+// AddReadinessCheck adds the check of readiness, the server isn't ready if any check fails
+func (h *Health) AddReadinessCheck(name string, check HealthCheck) {
+	h.mutex.Lock()
+	h.readiness = append(h.readiness, namedCheck{name: name, check: check})
+	h.mutex.Unlock()
+}
+
+// Live handles the liveness probe
+func (h *Health) Live(w http.ResponseWriter, r *http.Request) {
+	h.mutex.RLock()
+	checks := h.liveness
+	h.mutex.RUnlock()
+	writeHealthStatus(w, runHealthChecks(checks))
+}
+
+// Ready handles the readiness probe
+func (h *Health) Ready(w http.ResponseWriter, r *http.Request) {
+	h.mutex.RLock()
+	checks := h.readiness
+	h.mutex.RUnlock()
+	writeHealthStatus(w, runHealthChecks(checks))
+}
+
+// runHealthChecks runs all checks, the status fails if any check fails
+func runHealthChecks(checks []namedCheck) *HealthStatus {
+	status := &HealthStatus{Status: HealthOK}
+	if len(checks) == 0 {
+		return status
+	}
+	status.Checks = make(map[string]string, len(checks))
+	for _, c := range checks {
+		if err := c.check(); err != nil {
+			status.Status = HealthFail
+			status.Checks[c.name] = err.Error()
+			continue
+		}
+		status.Checks[c.name] = HealthOK
+	}
+	return status
+}
+
+// writeHealthStatus writes the status as json, the status code is 503 if unhealthy
+func writeHealthStatus(w http.ResponseWriter, status *HealthStatus) {
+	data, _ := json.Marshal(status)
+	w.Header().Set("Content-Type", "application/json")
+	if status.Status == HealthOK {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_, _ = w.Write(data)
+}
+
+// HealthServer represents the http server which exposes the health under /health/live and /health/ready,
+// and the self-monitoring metrics under /metrics, used by the server without http api(like storage).
+type HealthServer struct {
+	server *http.Server
+	log    *logger.Logger
+}
+
+// NewHealthServer creates the health server which listens on the address
+func NewHealthServer(addr string, health *Health) *HealthServer {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health/live", health.Live)
+	mux.HandleFunc("/health/ready", health.Ready)
+	mux.Handle("/metrics", promhttp.Handler())
+	return &HealthServer{
+		server: &http.Server{Addr: addr, Handler: mux},
+		log:    logger.GetLogger("pkg/server/health"),
+	}
+}
+
+// Start starts the health server in background
+func (s *HealthServer) Start() {
+	go func() {
+		s.log.Info("starting health server", logger.String("addr", s.server.Addr))
+		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			s.log.Error("health server error", logger.Error(err))
+		}
+	}()
+}
+
+// Stop shutdowns the health server gracefully, closes the connections forcibly if not finished in time
+func (s *HealthServer) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		s.log.Warn("shutdown health server timeout, close it", logger.Error(err))
+		return s.server.Close()
+	}
+	return nil
+}
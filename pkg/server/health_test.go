@@ -0,0 +1,94 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealth(t *testing.T) {
+	h := NewHealth()
+	// no checks
+	assertHealth(t, h.Live, http.StatusOK, &HealthStatus{Status: HealthOK})
+	assertHealth(t, h.Ready, http.StatusOK, &HealthStatus{Status: HealthOK})
+
+	ready := false
+	h.AddLivenessCheck("process", func() error { return nil })
+	h.AddReadinessCheck("etcd", func() error { return nil })
+	h.AddReadinessCheck("recovery", func() error {
+		if !ready {
+			return fmt.Errorf("recovering")
+		}
+		return nil
+	})
+	assertHealth(t, h.Live, http.StatusOK,
+		&HealthStatus{Status: HealthOK, Checks: map[string]string{"process": HealthOK}})
+	assertHealth(t, h.Ready, http.StatusServiceUnavailable,
+		&HealthStatus{Status: HealthFail, Checks: map[string]string{"etcd": HealthOK, "recovery": "recovering"}})
+	ready = true
+	assertHealth(t, h.Ready, http.StatusOK,
+		&HealthStatus{Status: HealthOK, Checks: map[string]string{"etcd": HealthOK, "recovery": HealthOK}})
+}
+
+func assertHealth(t *testing.T, handler http.HandlerFunc, code int, status *HealthStatus) {
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, code, w.Code)
+	result := &HealthStatus{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), result))
+	assert.Equal(t, status, result)
+}
+
+func TestHealthServer(t *testing.T) {
+	h := NewHealth()
+	h.AddReadinessCheck("recovery", func() error { return fmt.Errorf("recovering") })
+	addr := freeAddr(t)
+	s := NewHealthServer(addr, h)
+	s.Start()
+
+	var resp *http.Response
+	var err error
+	for i := 0; i < 50; i++ {
+		resp, err = http.Get("http://" + addr + "/health/live")
+		if err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Nil(t, err)
+	if resp != nil {
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		_ = resp.Body.Close()
+	}
+	resp, err = http.Get("http://" + addr + "/health/ready")
+	assert.Nil(t, err)
+	if resp != nil {
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		body, _ := ioutil.ReadAll(resp.Body)
+		assert.Contains(t, string(body), "recovering")
+		_ = resp.Body.Close()
+	}
+	resp, err = http.Get("http://" + addr + "/metrics")
+	assert.Nil(t, err)
+	if resp != nil {
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		_ = resp.Body.Close()
+	}
+	assert.Nil(t, s.Stop())
+}
+
+// freeAddr returns the local address with a free port
+func freeAddr(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	addr := listener.Addr().String()
+	assert.Nil(t, listener.Close())
+	return addr
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/eleme/lindb/pkg/logger"
+)
+
+// PProfServer represents the http server which exposes runtime profiling data by net/http/pprof,
+// such as cpu/heap/goroutine profiles under /debug/pprof/.
+type PProfServer struct {
+	server *http.Server
+	log    *logger.Logger
+}
+
+// NewPProfServer creates the pprof server which listens on the address
+func NewPProfServer(addr string) *PProfServer {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return &PProfServer{
+		server: &http.Server{Addr: addr, Handler: mux},
+		log:    logger.GetLogger("pkg/server/pprof"),
+	}
+}
+
+// Start starts the pprof server in background
+func (s *PProfServer) Start() {
+	go func() {
+		s.log.Info("starting pprof server", logger.String("addr", s.server.Addr))
+		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			s.log.Error("pprof server error", logger.Error(err))
+		}
+	}()
+}
+
+// Stop shutdowns the pprof server
+func (s *PProfServer) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
@@ -2,7 +2,19 @@ package state
 
 // Config represents state repository config
 type Config struct {
+	// Namespace prefixes all keys of cluster, the clusters sharing an etcd cluster must use distinct namespaces
 	Namespace   string   `toml:"namespace" json:"namespace"`
 	Endpoints   []string `toml:"endpoints" json:"endpoints"`
 	DialTimeout int64    `toml:"dialTimeout" json:"dialTimeout"`
+	// RequestTimeout is the timeout(seconds) of each request to repository if the context has no earlier deadline,
+	// zero means no timeout
+	RequestTimeout int64 `toml:"request-timeout" json:"requestTimeout,omitempty"`
+	// MaxRetries is the max retries of the idempotent request(get/list/put/delete) if repository is unavailable
+	MaxRetries int `toml:"max-retries" json:"maxRetries,omitempty"`
+	// DegradedTolerance is the time(seconds) the node keeps serving with the last-known cluster state
+	// after repository becomes unavailable, zero means not tolerated
+	DegradedTolerance int64 `toml:"degraded-tolerance" json:"degradedTolerance,omitempty"`
+	// ClusterID is the id of cluster which node joins, the node refuses to start if the namespace is used by
+	// another cluster, empty means joining the cluster in namespace(the id is generated on first start).
+	ClusterID string `toml:"cluster-id" json:"clusterId,omitempty"`
 }
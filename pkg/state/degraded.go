@@ -0,0 +1,94 @@
+package state
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/eleme/lindb/pkg/logger"
+)
+
+var (
+	degradedGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "lindb",
+		Subsystem: "state",
+		Name:      "degraded",
+		Help:      "Whether the node serves with the last-known cluster state because state repository is unavailable.",
+	})
+	unavailableCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "lindb",
+		Subsystem: "state",
+		Name:      "unavailable_total",
+		Help:      "The number of failed availability checks of state repository.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(degradedGauge, unavailableCounter)
+}
+
+// Degraded tracks the availability of state repository. The node keeps serving reads/writes with the last-known
+// cluster state(e.g. the routing and limits watched before) if repository is unavailable briefly, the unavailability
+// is alerted by log and metric instead of failing the checks, until the tolerance passes.
+type Degraded struct {
+	repo      Repository
+	tolerance time.Duration
+	// unavailableSince is the time when repository becomes unavailable, zero if available
+	unavailableSince time.Time
+	mutex            sync.Mutex
+	now              func() time.Time
+
+	logger *logger.Logger
+}
+
+// NewDegraded creates the degraded mode of state repository with the tolerance of unavailability
+func NewDegraded(repo Repository, tolerance time.Duration) *Degraded {
+	return &Degraded{
+		repo:      repo,
+		tolerance: tolerance,
+		now:       time.Now,
+		logger:    logger.GetLogger("pkg/state/degraded"),
+	}
+}
+
+// Check pings state repository, returns nil if repository is available or the unavailability is within tolerance,
+// returns the error of ping if the tolerance passes.
+func (d *Degraded) Check(ctx context.Context) error {
+	err := Ping(ctx, d.repo)
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if err == nil {
+		if !d.unavailableSince.IsZero() {
+			d.logger.Info("state repository is available, leave degraded mode",
+				logger.String("unavailable", d.now().Sub(d.unavailableSince).String()))
+			d.unavailableSince = time.Time{}
+			degradedGauge.Set(0)
+		}
+		return nil
+	}
+	unavailableCounter.Inc()
+	now := d.now()
+	if d.unavailableSince.IsZero() {
+		d.unavailableSince = now
+	}
+	unavailable := now.Sub(d.unavailableSince)
+	if unavailable >= d.tolerance {
+		degradedGauge.Set(0)
+		d.logger.Error("state repository is unavailable over the tolerance",
+			logger.String("unavailable", unavailable.String()), logger.Error(err))
+		return err
+	}
+	degradedGauge.Set(1)
+	d.logger.Error("state repository is unavailable, serve with the last-known cluster state",
+		logger.String("unavailable", unavailable.String()), logger.Error(err))
+	return nil
+}
+
+// IsDegraded returns if the node serves with the last-known cluster state within tolerance
+func (d *Degraded) IsDegraded() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return !d.unavailableSince.IsZero() && d.now().Sub(d.unavailableSince) < d.tolerance
+}
@@ -0,0 +1,45 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/mock"
+)
+
+func TestDegraded_Check(t *testing.T) {
+	cluster := mock.StartEtcdCluster(t)
+	defer cluster.Terminate(t)
+	repo, err := NewRepo(Config{Endpoints: cluster.Endpoints})
+	assert.Nil(t, err)
+
+	degraded := NewDegraded(repo, time.Minute)
+	now := time.Now()
+	degraded.now = func() time.Time { return now }
+	assert.Nil(t, degraded.Check(context.TODO()))
+	assert.False(t, degraded.IsDegraded())
+
+	// repository is available again, leaves degraded mode
+	degraded.unavailableSince = now.Add(-time.Second)
+	assert.True(t, degraded.IsDegraded())
+	assert.Nil(t, degraded.Check(context.TODO()))
+	assert.False(t, degraded.IsDegraded())
+
+	// repository is unavailable within tolerance
+	_ = repo.Close()
+	assert.Nil(t, degraded.Check(context.TODO()))
+	assert.True(t, degraded.IsDegraded())
+	now = now.Add(30 * time.Second)
+	assert.Nil(t, degraded.Check(context.TODO()))
+	assert.True(t, degraded.IsDegraded())
+	// the tolerance passes
+	now = now.Add(30 * time.Second)
+	assert.NotNil(t, degraded.Check(context.TODO()))
+	assert.False(t, degraded.IsDegraded())
+
+	// unavailability isn't tolerated
+	assert.NotNil(t, NewDegraded(repo, 0).Check(context.TODO()))
+}
@@ -3,17 +3,29 @@ package state
 import (
 	"context"
 	"fmt"
-	"path/filepath"
+	"path"
+	"strings"
+	"time"
 
 	etcdcliv3 "github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/eleme/lindb/pkg/logger"
 )
 
+// maxRetryBackoff is the max backoff between the retries of request
+const maxRetryBackoff = time.Second
+
 // etcdRepository is repository based on etcd storage
 type etcdRepository struct {
 	namespace string
 	client    *etcdcliv3.Client
+	// requestTimeout is the timeout of each request, zero means no timeout
+	requestTimeout time.Duration
+	// maxRetries is the max retries of idempotent request if etcd is unavailable
+	maxRetries int
 }
 
 // newEtedRepository creates a new repository based on etcd storage
@@ -28,8 +40,10 @@ func newEtedRepository(config Config) (Repository, error) {
 	}
 	logger.GetLogger("state").Info("new etcd client successfully", logger.Any("endpoints", config.Endpoints))
 	return &etcdRepository{
-		namespace: config.Namespace,
-		client:    cli,
+		namespace:      normalizeNamespace(config.Namespace),
+		client:         cli,
+		requestTimeout: time.Duration(config.RequestTimeout) * time.Second,
+		maxRetries:     config.MaxRetries,
 	}, nil
 }
 
@@ -42,9 +56,26 @@ func (r *etcdRepository) Get(ctx context.Context, key string) ([]byte, error) {
 	return r.getValue(key, resp)
 }
 
+// GetWithRevision retrieves value and mod revision for given key from etcd
+func (r *etcdRepository) GetWithRevision(ctx context.Context, key string) ([]byte, int64, error) {
+	resp, err := r.get(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	value, err := r.getValue(key, resp)
+	if err != nil {
+		return nil, 0, err
+	}
+	return value, resp.Kvs[0].ModRevision, nil
+}
+
 // List retrieves list for given prefix from etcd
 func (r *etcdRepository) List(ctx context.Context, prefix string) ([][]byte, error) {
-	resp, err := r.client.Get(ctx, r.keyPath(prefix), etcdcliv3.WithPrefix())
+	var resp *etcdcliv3.GetResponse
+	err := r.retry(ctx, func(ctx context.Context) (err error) {
+		resp, err = r.client.Get(ctx, r.keyPath(prefix), etcdcliv3.WithPrefix())
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -60,16 +91,39 @@ func (r *etcdRepository) List(ctx context.Context, prefix string) ([][]byte, err
 	return result, nil
 }
 
+// ListKV returns key/value pairs for given prefix, the pairs with empty value are ignored as List
+func (r *etcdRepository) ListKV(ctx context.Context, prefix string) ([]KeyValue, error) {
+	var resp *etcdcliv3.GetResponse
+	err := r.retry(ctx, func(ctx context.Context) (err error) {
+		resp, err = r.client.Get(ctx, r.keyPath(prefix), etcdcliv3.WithPrefix())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	var result []KeyValue
+	for _, kv := range resp.Kvs {
+		if len(kv.Value) > 0 {
+			result = append(result, KeyValue{Key: r.parseKey(string(kv.Key)), Value: kv.Value})
+		}
+	}
+	return result, nil
+}
+
 // Put puts a key-value pair into etcd
 func (r *etcdRepository) Put(ctx context.Context, key string, val []byte) error {
-	_, err := r.client.Put(ctx, r.keyPath(key), string(val))
-	return err
+	return r.retry(ctx, func(ctx context.Context) error {
+		_, err := r.client.Put(ctx, r.keyPath(key), string(val))
+		return err
+	})
 }
 
 // Delete deletes value for given key from etcd
 func (r *etcdRepository) Delete(ctx context.Context, key string) error {
-	_, err := r.client.Delete(ctx, r.keyPath(key))
-	return err
+	return r.retry(ctx, func(ctx context.Context) error {
+		_, err := r.client.Delete(ctx, r.keyPath(key))
+		return err
+	})
 }
 
 // Close closes etcd client
@@ -120,7 +174,11 @@ func (r *etcdRepository) PutIfNotExist(ctx context.Context, key string,
 
 // get returns response of get operator
 func (r *etcdRepository) get(ctx context.Context, key string) (*etcdcliv3.GetResponse, error) {
-	resp, err := r.client.Get(ctx, r.keyPath(key))
+	var resp *etcdcliv3.GetResponse
+	err := r.retry(ctx, func(ctx context.Context) (err error) {
+		resp, err = r.client.Get(ctx, r.keyPath(key))
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("get value failure for key[%s], error:%s", key, err)
 	}
@@ -169,6 +227,8 @@ func (r *etcdRepository) Batch(ctx context.Context, batch Batch) (bool, error) {
 		))
 	}
 
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
 	resp, err := r.client.Txn(ctx).Then(ops...).Commit()
 	if err != nil {
 		return false, err
@@ -176,10 +236,112 @@ func (r *etcdRepository) Batch(ctx context.Context, batch Batch) (bool, error) {
 	return resp.Succeeded, nil
 }
 
-// keyPath return new key path with namespace prefix
+// Txn applies the operations of transaction atomically by etcd txn if all the comparisons are true
+func (r *etcdRepository) Txn(ctx context.Context, txn Txn) (bool, error) {
+	cmps := make([]etcdcliv3.Cmp, len(txn.Cmps))
+	for idx, cmp := range txn.Cmps {
+		cmps[idx] = cmp.etcdCmp(r.keyPath(cmp.Key))
+	}
+	ops := make([]etcdcliv3.Op, len(txn.Ops))
+	for idx, op := range txn.Ops {
+		ops[idx] = op.etcdOp(r.keyPath(op.Key))
+	}
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	resp, err := r.client.Txn(ctx).If(cmps...).Then(ops...).Commit()
+	if err != nil {
+		return false, fmt.Errorf("commit txn error:%s", err)
+	}
+	return resp.Succeeded, nil
+}
+
+// Grant grants an etcd lease with ttl
+func (r *etcdRepository) Grant(ctx context.Context, ttl int64) (Lease, error) {
+	return grantLease(ctx, r.client, ttl)
+}
+
+// PutWithLease puts a key-value pair attached to the etcd lease
+func (r *etcdRepository) PutWithLease(ctx context.Context, key string, val []byte, lease LeaseID) error {
+	_, err := r.client.Put(ctx, r.keyPath(key), string(val), etcdcliv3.WithLease(etcdcliv3.LeaseID(lease)))
+	return err
+}
+
+// NewMutex creates the distributed lock on the key based on etcd txn and lease
+func (r *etcdRepository) NewMutex(key string, value []byte, ttl int64) Mutex {
+	return newMutex(r.client, r.keyPath(key), value, ttl)
+}
+
+// keyPath return new key path with namespace prefix, the key isn't cleaned, so that the key cannot escape
+// the namespace by relative elements, and the separator suffix of prefix is kept.
 func (r *etcdRepository) keyPath(key string) string {
-	if len(r.namespace) > 0 {
-		return filepath.Join(r.namespace, key)
+	if len(r.namespace) == 0 {
+		return key
+	}
+	return r.namespace + "/" + strings.TrimPrefix(key, "/")
+}
+
+// parseKey returns the key relative to namespace
+func (r *etcdRepository) parseKey(key string) string {
+	if len(r.namespace) == 0 {
+		return key
+	}
+	return strings.TrimPrefix(key, r.namespace)
+}
+
+// normalizeNamespace cleans the namespace without the separator suffix, so that the namespace "/lindb/"
+// is the same as "/lindb", the root namespace means no namespace.
+func normalizeNamespace(namespace string) string {
+	if len(namespace) == 0 {
+		return namespace
+	}
+	namespace = path.Clean(namespace)
+	if namespace == "/" || namespace == "." {
+		return ""
+	}
+	return namespace
+}
+
+// withTimeout returns the context with request timeout, the earlier deadline of parent context is kept
+func (r *etcdRepository) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.requestTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, r.requestTimeout)
+}
+
+// retry executes the idempotent request with request timeout, retries the request with backoff
+// if etcd is unavailable temporarily, until max retries reached or the context is done.
+func (r *etcdRepository) retry(ctx context.Context, request func(ctx context.Context) error) error {
+	backoff := defaultRetryInterval
+	for attempt := 0; ; attempt++ {
+		reqCtx, cancel := r.withTimeout(ctx)
+		err := request(reqCtx)
+		cancel()
+		if err == nil || attempt >= r.maxRetries || ctx.Err() != nil || !isRetryable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+// isRetryable returns if the error is caused by the temporary unavailability of etcd,
+// e.g. request timeout, no leader or endpoints unreachable.
+func isRetryable(err error) bool {
+	if err == context.DeadlineExceeded {
+		return true
+	}
+	var code codes.Code
+	if etcdErr, ok := err.(rpctypes.EtcdError); ok {
+		code = etcdErr.Code()
+	} else {
+		code = status.Code(err)
 	}
-	return key
+	return code == codes.Unavailable || code == codes.DeadlineExceeded
 }
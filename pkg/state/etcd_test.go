@@ -8,6 +8,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
 	"github.com/eleme/lindb/mock"
 	"github.com/eleme/lindb/pkg/util"
 
@@ -297,3 +302,120 @@ func (ts *testEtcdRepoSuite) TestBatch(c *check.C) {
 	list, _ := b.List(context.TODO(), "key")
 	c.Assert(3, check.Equals, len(list))
 }
+
+func (ts *testEtcdRepoSuite) TestNamespace(c *check.C) {
+	newRepo := func(namespace string) Repository {
+		repo, err := newEtedRepository(Config{Namespace: namespace, Endpoints: ts.Cluster.Endpoints})
+		c.Assert(err, check.IsNil)
+		return repo
+	}
+	repo1 := newRepo("/ns/c1")
+	repo10 := newRepo("/ns/c10")
+	root := newRepo("")
+	defer func() {
+		_ = repo1.Close()
+		_ = repo10.Close()
+		_ = root.Close()
+	}()
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	eventCh := repo1.WatchPrefix(ctx, "/database/config/")
+	event := <-eventCh
+	c.Assert(event.Type, check.Equals, EventTypeAll)
+	c.Assert(event.KeyValues, check.HasLen, 0)
+
+	_ = repo10.Put(context.TODO(), "/database/config/db", []byte("c10"))
+	_ = repo1.Put(context.TODO(), "/database/config2/db", []byte("c1-config2"))
+	_ = repo1.Put(context.TODO(), "/database/config/db", []byte("c1"))
+	// the keys of other namespace sharing the same prefix and the keys of other root aren't watched
+	event = <-eventCh
+	c.Assert(event.KeyValues, check.HasLen, 1)
+	c.Assert(event.KeyValues[0].Key, check.Equals, "/database/config/db")
+	c.Assert(string(event.KeyValues[0].Value), check.Equals, "c1")
+
+	values, err := repo1.List(context.TODO(), "/database/config/")
+	c.Assert(err, check.IsNil)
+	c.Assert(values, check.DeepEquals, [][]byte{[]byte("c1")})
+	values, err = root.List(context.TODO(), "/ns/c1/")
+	c.Assert(err, check.IsNil)
+	c.Assert(values, check.HasLen, 2)
+	// the keys are relative to namespace
+	kvs, err := repo1.ListKV(context.TODO(), "/database/config/")
+	c.Assert(err, check.IsNil)
+	c.Assert(kvs, check.DeepEquals, []KeyValue{{Key: "/database/config/db", Value: []byte("c1")}})
+	kvs, err = root.ListKV(context.TODO(), "/ns/c10/")
+	c.Assert(err, check.IsNil)
+	c.Assert(kvs, check.DeepEquals, []KeyValue{{Key: "/ns/c10/database/config/db", Value: []byte("c10")}})
+
+	// the separator suffix of namespace is ignored
+	value, err := newRepo("/ns/c1/").Get(context.TODO(), "/database/config/db")
+	c.Assert(err, check.IsNil)
+	c.Assert(string(value), check.Equals, "c1")
+	// the key cannot escape the namespace by relative elements
+	_ = repo1.Put(context.TODO(), "/database/config/../../../c10/database/config/db", []byte("escaped"))
+	value, _ = repo10.Get(context.TODO(), "/database/config/db")
+	c.Assert(string(value), check.Equals, "c10")
+}
+
+func TestNormalizeNamespace(t *testing.T) {
+	assert.Equal(t, "", normalizeNamespace(""))
+	assert.Equal(t, "", normalizeNamespace("/"))
+	assert.Equal(t, "/lindb", normalizeNamespace("/lindb/"))
+	assert.Equal(t, "/lindb/broker", normalizeNamespace("/lindb//broker"))
+	assert.Equal(t, "lindb", normalizeNamespace("lindb"))
+}
+
+func TestEtcdRepository_retry(t *testing.T) {
+	repo := &etcdRepository{maxRetries: 2}
+	attempts := 0
+	err := repo.retry(context.TODO(), func(ctx context.Context) error {
+		attempts++
+		return status.Error(codes.Unavailable, "unavailable")
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, 3, attempts)
+
+	// the error isn't retryable
+	attempts = 0
+	err = repo.retry(context.TODO(), func(ctx context.Context) error {
+		attempts++
+		return fmt.Errorf("err")
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, attempts)
+
+	// succeeds after retry, each attempt has request timeout
+	repo.requestTimeout = time.Second
+	attempts = 0
+	err = repo.retry(context.TODO(), func(ctx context.Context) error {
+		_, ok := ctx.Deadline()
+		assert.True(t, ok)
+		attempts++
+		if attempts == 1 {
+			return context.DeadlineExceeded
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, attempts)
+
+	// context is done
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	attempts = 0
+	err = repo.retry(ctx, func(ctx context.Context) error {
+		attempts++
+		return ctx.Err()
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, isRetryable(context.DeadlineExceeded))
+	assert.True(t, isRetryable(rpctypes.ErrNoLeader))
+	assert.True(t, isRetryable(status.Error(codes.Unavailable, "unavailable")))
+	assert.False(t, isRetryable(rpctypes.ErrKeyNotFound))
+	assert.False(t, isRetryable(context.Canceled))
+	assert.False(t, isRetryable(fmt.Errorf("err")))
+}
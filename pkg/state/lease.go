@@ -0,0 +1,82 @@
+package state
+
+import (
+	"context"
+	"fmt"
+
+	etcdcliv3 "github.com/coreos/etcd/clientv3"
+)
+
+// LeaseID represents the id of lease granted by repository
+type LeaseID int64
+
+// Lease represents the lease with ttl(seconds), the keys attached to lease are deleted
+// after the lease expired or revoked, so the keys live as long as the owner keeps the lease alive.
+type Lease interface {
+	// ID returns the id of lease, which is used for attaching keys to lease
+	ID() LeaseID
+	// TTL returns the ttl(seconds) of lease
+	TTL() int64
+	// KeepAlive keeps the lease alive in background until ctx done or the lease is lost,
+	// the returned channel is closed when the keepalive stopped.
+	KeepAlive(ctx context.Context) (<-chan Closed, error)
+	// Revoke revokes the lease, the keys attached to lease are deleted
+	Revoke(ctx context.Context) error
+}
+
+// etcdLease implements Lease based on etcd lease
+type etcdLease struct {
+	client *etcdcliv3.Client
+	id     etcdcliv3.LeaseID
+	ttl    int64
+}
+
+// grantLease grants the etcd lease with ttl, the default ttl is used if ttl <= 0
+func grantLease(ctx context.Context, client *etcdcliv3.Client, ttl int64) (*etcdLease, error) {
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	resp, err := client.Grant(ctx, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("grant lease error:%s", err)
+	}
+	return &etcdLease{
+		client: client,
+		id:     resp.ID,
+		ttl:    ttl,
+	}, nil
+}
+
+// ID returns the id of lease
+func (l *etcdLease) ID() LeaseID {
+	return LeaseID(l.id)
+}
+
+// TTL returns the ttl(seconds) of lease
+func (l *etcdLease) TTL() int64 {
+	return l.ttl
+}
+
+// KeepAlive keeps the lease alive in background, the keepalive responses are drained,
+// the returned channel is closed after the keepalive channel of etcd closed(ctx done or lease lost).
+func (l *etcdLease) KeepAlive(ctx context.Context) (<-chan Closed, error) {
+	keepaliveCh, err := l.client.KeepAlive(ctx, l.id)
+	if err != nil {
+		return nil, fmt.Errorf("keepalive lease[%d] error:%s", l.id, err)
+	}
+	ch := make(chan Closed)
+	go func() {
+		defer close(ch)
+		for range keepaliveCh {
+		}
+	}()
+	return ch, nil
+}
+
+// Revoke revokes the lease, the keys attached to lease are deleted
+func (l *etcdLease) Revoke(ctx context.Context) error {
+	if _, err := l.client.Revoke(ctx, l.id); err != nil {
+		return fmt.Errorf("revoke lease[%d] error:%s", l.id, err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package state
+
+import (
+	"context"
+	"time"
+
+	"gopkg.in/check.v1"
+)
+
+func (ts *testEtcdRepoSuite) TestLease(c *check.C) {
+	repo, _ := newEtedRepository(Config{
+		Namespace: "/test/lease",
+		Endpoints: ts.Cluster.Endpoints,
+	})
+	defer func() {
+		_ = repo.Close()
+	}()
+	lease, err := repo.Grant(context.TODO(), 0)
+	c.Assert(err, check.IsNil)
+	c.Assert(lease.TTL(), check.Equals, int64(defaultTTL))
+	c.Assert(repo.PutWithLease(context.TODO(), "/key", []byte("value"), lease.ID()), check.IsNil)
+	value, _ := repo.Get(context.TODO(), "/key")
+	c.Assert(string(value), check.Equals, "value")
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	closed, err := lease.KeepAlive(ctx)
+	c.Assert(err, check.IsNil)
+	cancel()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		c.Fatal("stop keepalive timeout")
+	}
+	// the keys attached to lease are deleted after revoked
+	c.Assert(lease.Revoke(context.TODO()), check.IsNil)
+	_, err = repo.Get(context.TODO(), "/key")
+	c.Assert(err, check.Equals, ErrNotExist)
+	c.Assert(lease.Revoke(context.TODO()), check.NotNil)
+	// keepalive stops if lease not found
+	closed, err = lease.KeepAlive(context.TODO())
+	c.Assert(err, check.IsNil)
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		c.Fatal("stop keepalive timeout")
+	}
+	c.Assert(repo.PutWithLease(context.TODO(), "/key", []byte("value"), lease.ID()), check.NotNil)
+
+	// the lease expires without keepalive
+	lease, _ = repo.Grant(context.TODO(), 1)
+	_ = repo.PutWithLease(context.TODO(), "/key", []byte("value"), lease.ID())
+	time.Sleep(3 * time.Second)
+	_, err = repo.Get(context.TODO(), "/key")
+	c.Assert(err, check.Equals, ErrNotExist)
+}
@@ -0,0 +1,144 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	etcdcliv3 "github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+)
+
+// Mutex represents the distributed lock on a key, the key is attached to the lease of holder with the value
+// of holder, so the lock is released after holder unlocked or the lease expired(e.g. holder crashed).
+type Mutex interface {
+	// Lock acquires the lock, blocks until the lock acquired or ctx done,
+	// the returned channel is closed when the lock is released or lost.
+	Lock(ctx context.Context) (<-chan Closed, error)
+	// TryLock acquires the lock without blocking, returns false if the lock is held by others,
+	// the returned channel is closed when the lock is released or lost.
+	TryLock(ctx context.Context) (bool, <-chan Closed, error)
+	// Unlock releases the lock by revoking the lease, the key of lock is deleted
+	Unlock(ctx context.Context) error
+	// Key returns the key of lock
+	Key() string
+}
+
+// etcdMutex implements Mutex based on etcd txn and lease
+type etcdMutex struct {
+	client *etcdcliv3.Client
+	key    string
+	value  []byte
+	ttl    int64
+
+	mutex  sync.Mutex
+	lease  *etcdLease
+	cancel context.CancelFunc
+}
+
+// newMutex creates the distributed lock on the key with the value of holder and the ttl of lease
+func newMutex(client *etcdcliv3.Client, key string, value []byte, ttl int64) Mutex {
+	return &etcdMutex{
+		client: client,
+		key:    key,
+		value:  value,
+		ttl:    ttl,
+	}
+}
+
+// Lock acquires the lock, waits the deletion of key if the lock is held by others, then retries
+func (m *etcdMutex) Lock(ctx context.Context) (<-chan Closed, error) {
+	for {
+		acquired, rev, closed, err := m.tryLock(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			return closed, nil
+		}
+		if err := m.waitRelease(ctx, rev); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// TryLock acquires the lock without blocking
+func (m *etcdMutex) TryLock(ctx context.Context) (bool, <-chan Closed, error) {
+	acquired, _, closed, err := m.tryLock(ctx)
+	return acquired, closed, err
+}
+
+// Unlock releases the lock, does nothing if the lock isn't held
+func (m *etcdMutex) Unlock(ctx context.Context) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.lease == nil {
+		return nil
+	}
+	lease := m.lease
+	m.cancel()
+	m.lease = nil
+	m.cancel = nil
+	return lease.Revoke(ctx)
+}
+
+// Key returns the key of lock
+func (m *etcdMutex) Key() string {
+	return m.key
+}
+
+// tryLock puts the key with a new lease if the key doesn't exist, then keeps the lease alive until unlocked,
+// returns the revision of repository if the lock is held by others.
+func (m *etcdMutex) tryLock(ctx context.Context) (acquired bool, rev int64, closed <-chan Closed, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.lease != nil {
+		return false, 0, nil, fmt.Errorf("lock[%s] is already held", m.key)
+	}
+	lease, err := grantLease(ctx, m.client, m.ttl)
+	if err != nil {
+		return false, 0, nil, err
+	}
+	resp, err := m.client.Txn(ctx).
+		If(etcdcliv3.Compare(etcdcliv3.CreateRevision(m.key), "=", 0)).
+		Then(etcdcliv3.OpPut(m.key, string(m.value), etcdcliv3.WithLease(lease.id))).
+		Commit()
+	if err != nil || !resp.Succeeded {
+		// the lease is useless, expires after ttl if revoke failed
+		_ = lease.Revoke(context.Background())
+		if err != nil {
+			return false, 0, nil, fmt.Errorf("acquire lock[%s] error:%s", m.key, err)
+		}
+		return false, resp.Header.Revision, nil, nil
+	}
+	// the keepalive lives longer than the ctx of acquiring, stops after unlocked
+	keepaliveCtx, cancel := context.WithCancel(context.Background())
+	closed, err = lease.KeepAlive(keepaliveCtx)
+	if err != nil {
+		cancel()
+		_ = lease.Revoke(context.Background())
+		return false, 0, nil, err
+	}
+	m.lease = lease
+	m.cancel = cancel
+	return true, 0, closed, nil
+}
+
+// waitRelease waits the key of lock deleted after the revision
+func (m *etcdMutex) waitRelease(ctx context.Context, rev int64) error {
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for resp := range m.client.Watch(watchCtx, m.key, etcdcliv3.WithRev(rev+1)) {
+		if err := resp.Err(); err != nil {
+			return fmt.Errorf("watch lock[%s] error:%s", m.key, err)
+		}
+		for _, event := range resp.Events {
+			if event.Type == mvccpb.DELETE {
+				return nil
+			}
+		}
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,70 @@
+package state
+
+import (
+	"context"
+	"time"
+
+	"gopkg.in/check.v1"
+)
+
+func (ts *testEtcdRepoSuite) TestMutex(c *check.C) {
+	repo, _ := newEtedRepository(Config{
+		Namespace: "/test/mutex",
+		Endpoints: ts.Cluster.Endpoints,
+	})
+	defer func() {
+		_ = repo.Close()
+	}()
+	m1 := repo.NewMutex("/lock", []byte("node1"), 1)
+	m2 := repo.NewMutex("/lock", []byte("node2"), 1)
+	c.Assert(m1.Key(), check.Equals, "/test/mutex/lock")
+
+	closed1, err := m1.Lock(context.TODO())
+	c.Assert(err, check.IsNil)
+	// keepalive the lease longer than ttl
+	time.Sleep(2 * time.Second)
+	value, _ := repo.Get(context.TODO(), "/lock")
+	c.Assert(string(value), check.Equals, "node1")
+	_, _, err = m1.TryLock(context.TODO())
+	c.Assert(err, check.NotNil)
+
+	acquired, _, err := m2.TryLock(context.TODO())
+	c.Assert(err, check.IsNil)
+	c.Assert(acquired, check.Equals, false)
+	ctx, cancel := context.WithTimeout(context.TODO(), 200*time.Millisecond)
+	_, err = m2.Lock(ctx)
+	cancel()
+	c.Assert(err, check.NotNil)
+
+	// m2 acquires the lock after m1 unlocked
+	locked := make(chan struct{})
+	go func() {
+		if _, err := m2.Lock(context.TODO()); err == nil {
+			close(locked)
+		}
+	}()
+	time.Sleep(100 * time.Millisecond)
+	c.Assert(m1.Unlock(context.TODO()), check.IsNil)
+	c.Assert(m1.Unlock(context.TODO()), check.IsNil)
+	select {
+	case <-closed1:
+	case <-time.After(time.Second):
+		c.Fatal("release lock timeout")
+	}
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		c.Fatal("acquire lock timeout")
+	}
+	value, _ = repo.Get(context.TODO(), "/lock")
+	c.Assert(string(value), check.Equals, "node2")
+	c.Assert(m2.Unlock(context.TODO()), check.IsNil)
+
+	acquired, closed1, err = m1.TryLock(context.TODO())
+	c.Assert(err, check.IsNil)
+	c.Assert(acquired, check.Equals, true)
+	c.Assert(m1.Unlock(context.TODO()), check.IsNil)
+	<-closed1
+	_, err = repo.Get(context.TODO(), "/lock")
+	c.Assert(err, check.Equals, ErrNotExist)
+}
@@ -10,12 +10,20 @@ var (
 	ErrNotExist = errors.New("not exist")
 )
 
+// pingKey is the key read for checking the connection of repository, it isn't required to exist
+const pingKey = "/ping"
+
 // Repository stores state data, such as metadata/config/status/task etc.
 type Repository interface {
 	// Get retrieves value for given key from repository
 	Get(ctx context.Context, key string) ([]byte, error)
+	// GetWithRevision retrieves value and mod revision for given key from repository,
+	// the revision is used for the comparison of transaction(compare-and-swap)
+	GetWithRevision(ctx context.Context, key string) ([]byte, int64, error)
 	// List retrieves list for given prefix from repository
 	List(ctx context.Context, prefix string) ([][]byte, error)
+	// ListKV retrieves key/value pairs for given prefix from repository, the keys are relative to namespace
+	ListKV(ctx context.Context, prefix string) ([]KeyValue, error)
 	// Put puts a key-value pair into repository
 	Put(ctx context.Context, key string, val []byte) error
 	// Delete deletes value for given key from repository
@@ -34,6 +42,16 @@ type Repository interface {
 	WatchPrefix(ctx context.Context, prefixKey string) WatchEventChan
 	// Batch puts k/v list, this operation is atomic
 	Batch(ctx context.Context, batch Batch) (bool, error)
+	// Txn applies the operations of transaction atomically if all the comparisons are true,
+	// returns false if any comparison is false
+	Txn(ctx context.Context, txn Txn) (bool, error)
+	// Grant grants a lease with ttl(seconds), the lease must be kept alive or revoked by caller
+	Grant(ctx context.Context, ttl int64) (Lease, error)
+	// PutWithLease puts a key-value pair attached to the lease, the key is deleted after lease expired or revoked
+	PutWithLease(ctx context.Context, key string, val []byte, lease LeaseID) error
+	// NewMutex creates the distributed lock on the key, the value identifies the holder of lock,
+	// the lock is released after the lease with ttl(seconds) expired if holder crashed.
+	NewMutex(key string, value []byte, ttl int64) Mutex
 	// Close closes repository and release resources
 	Close() error
 }
@@ -84,3 +102,11 @@ type WatchEventChan <-chan *Event
 func NewRepo(config Config) (Repository, error) {
 	return newEtedRepository(config)
 }
+
+// Ping checks whether the repository is reachable by reading a key which isn't required to exist
+func Ping(ctx context.Context, repo Repository) error {
+	if _, err := repo.Get(ctx, pingKey); err != nil && err != ErrNotExist {
+		return err
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package state
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -18,4 +19,9 @@ func TestNewRepo(t *testing.T) {
 	repo, err := NewRepo(cfg)
 	assert.Nil(t, err)
 	assert.NotNil(t, repo)
+	assert.Nil(t, Ping(context.TODO(), repo))
+
+	// cannot connect after closed
+	_ = repo.Close()
+	assert.NotNil(t, Ping(context.TODO(), repo))
 }
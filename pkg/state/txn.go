@@ -0,0 +1,67 @@
+package state
+
+import (
+	etcdcliv3 "github.com/coreos/etcd/clientv3"
+)
+
+// Txn represents the transaction of repository across multiple keys,
+// the operations are applied atomically only if all the comparisons are true(compare-and-swap),
+// so that the watchers never observe the keys half-updated.
+type Txn struct {
+	Cmps []Cmp
+	Ops  []Op
+}
+
+// Cmp represents the comparison of transaction on a key
+type Cmp struct {
+	Key string
+	// ModRevision is the expected mod revision of key, 0 means the key doesn't exist
+	ModRevision int64
+	// Value is the expected value of key if byValue
+	Value   []byte
+	byValue bool
+}
+
+// CmpRevision returns the comparison which is true if the mod revision of key equals rev,
+// the rev returned by GetWithRevision is used for compare-and-swap, 0 means the key doesn't exist.
+func CmpRevision(key string, rev int64) Cmp {
+	return Cmp{Key: key, ModRevision: rev}
+}
+
+// CmpValue returns the comparison which is true if the value of key equals value
+func CmpValue(key string, value []byte) Cmp {
+	return Cmp{Key: key, Value: value, byValue: true}
+}
+
+// Op represents the put/delete operation of transaction
+type Op struct {
+	Key    string
+	Value  []byte
+	Delete bool
+}
+
+// OpPut returns the operation which puts the key-value pair
+func OpPut(key string, value []byte) Op {
+	return Op{Key: key, Value: value}
+}
+
+// OpDelete returns the operation which deletes the key
+func OpDelete(key string) Op {
+	return Op{Key: key, Delete: true}
+}
+
+// etcdCmp converts the comparison into etcd comparison with the key path
+func (c Cmp) etcdCmp(keyPath string) etcdcliv3.Cmp {
+	if c.byValue {
+		return etcdcliv3.Compare(etcdcliv3.Value(keyPath), "=", string(c.Value))
+	}
+	return etcdcliv3.Compare(etcdcliv3.ModRevision(keyPath), "=", c.ModRevision)
+}
+
+// etcdOp converts the operation into etcd operation with the key path
+func (o Op) etcdOp(keyPath string) etcdcliv3.Op {
+	if o.Delete {
+		return etcdcliv3.OpDelete(keyPath)
+	}
+	return etcdcliv3.OpPut(keyPath, string(o.Value))
+}
@@ -0,0 +1,69 @@
+package state
+
+import (
+	"context"
+
+	"gopkg.in/check.v1"
+)
+
+func (ts *testEtcdRepoSuite) TestTxn(c *check.C) {
+	repo, _ := newEtedRepository(Config{
+		Namespace: "/test/txn",
+		Endpoints: ts.Cluster.Endpoints,
+	})
+	defer func() {
+		_ = repo.Close()
+	}()
+	_, _, err := repo.GetWithRevision(context.TODO(), "/key1")
+	c.Assert(err, check.Equals, ErrNotExist)
+
+	// the keys don't exist
+	success, err := repo.Txn(context.TODO(), Txn{
+		Cmps: []Cmp{CmpRevision("/key1", 0), CmpRevision("/key2", 0)},
+		Ops:  []Op{OpPut("/key1", []byte("v1")), OpPut("/key2", []byte("v2"))},
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(success, check.Equals, true)
+	value, rev, err := repo.GetWithRevision(context.TODO(), "/key1")
+	c.Assert(err, check.IsNil)
+	c.Assert(string(value), check.Equals, "v1")
+	c.Assert(rev > 0, check.Equals, true)
+
+	// compare-and-swap with the revision
+	success, _ = repo.Txn(context.TODO(), Txn{
+		Cmps: []Cmp{CmpRevision("/key1", rev)},
+		Ops:  []Op{OpPut("/key1", []byte("v11")), OpDelete("/key2")},
+	})
+	c.Assert(success, check.Equals, true)
+	value, _ = repo.Get(context.TODO(), "/key1")
+	c.Assert(string(value), check.Equals, "v11")
+	_, err = repo.Get(context.TODO(), "/key2")
+	c.Assert(err, check.Equals, ErrNotExist)
+
+	// the revision is changed, nothing is applied
+	success, _ = repo.Txn(context.TODO(), Txn{
+		Cmps: []Cmp{CmpRevision("/key1", rev)},
+		Ops:  []Op{OpPut("/key1", []byte("v12")), OpPut("/key2", []byte("v2"))},
+	})
+	c.Assert(success, check.Equals, false)
+	value, _ = repo.Get(context.TODO(), "/key1")
+	c.Assert(string(value), check.Equals, "v11")
+	_, err = repo.Get(context.TODO(), "/key2")
+	c.Assert(err, check.Equals, ErrNotExist)
+
+	// compare with value
+	success, _ = repo.Txn(context.TODO(), Txn{
+		Cmps: []Cmp{CmpValue("/key1", []byte("v11"))},
+		Ops:  []Op{OpPut("/key2", []byte("v2"))},
+	})
+	c.Assert(success, check.Equals, true)
+	success, _ = repo.Txn(context.TODO(), Txn{
+		Cmps: []Cmp{CmpValue("/key1", []byte("v1"))},
+		Ops:  []Op{OpDelete("/key2")},
+	})
+	c.Assert(success, check.Equals, false)
+
+	_ = repo.Close()
+	_, err = repo.Txn(context.TODO(), Txn{Ops: []Op{OpDelete("/key2")}})
+	c.Assert(err, check.NotNil)
+}
@@ -2,7 +2,6 @@ package state
 
 import (
 	"context"
-	"strings"
 	"time"
 
 	etcdcliv3 "github.com/coreos/etcd/clientv3"
@@ -88,10 +87,7 @@ func (w *watcher) watch(eventc chan<- *Event) {
 }
 
 func (w *watcher) parseKey(key string) string {
-	if len(w.cli.namespace) == 0 {
-		return key
-	}
-	return strings.Replace(key, w.cli.namespace, "", 1)
+	return w.cli.parseKey(key)
 }
 
 func (w *watcher) packWatchEvent(watchEvent *etcdcliv3.Event) *Event {
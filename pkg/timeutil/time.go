@@ -3,10 +3,16 @@ package timeutil
 import "time"
 
 const (
+	// OneSecond is the number of millisecond for a second
+	OneSecond = 1000
+	// OneMinute is the number of millisecond for a minute
+	OneMinute = 60 * 1000
 	// OneHour is the number of millisecond for an hour
 	OneHour = 60 * 60 * 1000
 	// OneDay is the number of millisecond for an day
 	OneDay = 24 * 60 * 60 * 1000
+	// OneWeek is the number of millisecond for a week
+	OneWeek = 7 * 24 * 60 * 60 * 1000
 )
 
 // FormatTimestamp returns timestamp format based on layout
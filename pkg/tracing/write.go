@@ -0,0 +1,183 @@
+package tracing
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/eleme/lindb/models"
+)
+
+// Defines the stages of write path in the order of processing
+const (
+	// StageReceive reads(and decompresses) the request body of write
+	StageReceive = "receive"
+	// StageParse parses the points of line protocol
+	StageParse = "parse"
+	// StageRoute routes the points to the shards, then hands over to the replica batcher
+	StageRoute = "route"
+	// StageReplicaAppend sends the batch of writes to the replicas of storage
+	StageReplicaAppend = "replica_append"
+	// StageStorageApply decodes then writes the points of shard write into the shard in storage node
+	StageStorageApply = "storage_apply"
+	// StageMemDBWrite writes the point into memory-databases of shard
+	StageMemDBWrite = "memdb_write"
+)
+
+// defaultCapacity is the default number of write traces kept by tracer
+const defaultCapacity = 256
+
+var (
+	stageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "lindb",
+		Subsystem: "write",
+		Name:      "stage_duration_seconds",
+		Help:      "The elapsed time of each stage in write path.",
+		Buckets:   prometheus.ExponentialBuckets(0.00001, 4, 10),
+	}, []string{"stage"})
+)
+
+func init() {
+	prometheus.MustRegister(stageDuration)
+}
+
+// ObserveStage observes the elapsed time since start into the histogram of stage
+func ObserveStage(stage string, start time.Time) {
+	stageDuration.WithLabelValues(stage).Observe(time.Since(start).Seconds())
+}
+
+// Tracer starts the trace of write batch, keeps the recent traces which are sampled or slow
+type Tracer interface {
+	// Start starts the trace of write batch for database
+	Start(database string) *Trace
+	// Recent returns the recent traces of database(all databases if empty) ordered by time desc,
+	// limit <= 0 means no limit
+	Recent(database string, limit int) []models.WriteTrace
+}
+
+// tracer implements Tracer interface
+type tracer struct {
+	// sampling means 1 of sampling batches is kept, <= 0 means no sampling
+	sampling int64
+	// slowThreshold is the threshold of slow write, <= 0 means no threshold
+	slowThreshold time.Duration
+	count         int64
+
+	mutex  sync.Mutex
+	traces []models.WriteTrace
+	next   int
+	full   bool
+}
+
+// NewTracer creates the tracer which keeps 1 of sampling batches(<= 0 means no sampling) and
+// all batches slower than the threshold(ms, <= 0 means no threshold)
+func NewTracer(sampling int, slowThreshold int64) Tracer {
+	return &tracer{
+		sampling:      int64(sampling),
+		slowThreshold: time.Duration(slowThreshold) * time.Millisecond,
+		traces:        make([]models.WriteTrace, defaultCapacity),
+	}
+}
+
+// Start starts the trace of write batch for database
+func (t *tracer) Start(database string) *Trace {
+	trace := NewTrace(database)
+	trace.tracer = t
+	if t.sampling > 0 {
+		trace.sampled = atomic.AddInt64(&t.count, 1)%t.sampling == 0
+	}
+	return trace
+}
+
+// Recent returns the recent traces of database ordered by time desc
+func (t *tracer) Recent(database string, limit int) []models.WriteTrace {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	size := t.next
+	if t.full {
+		size = len(t.traces)
+	}
+	result := make([]models.WriteTrace, 0)
+	for i := 1; i <= size; i++ {
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+		trace := t.traces[(t.next-i+len(t.traces))%len(t.traces)]
+		if len(database) > 0 && trace.Database != database {
+			continue
+		}
+		result = append(result, trace)
+	}
+	return result
+}
+
+// add adds the finished trace, overwrites the oldest one if full
+func (t *tracer) add(trace models.WriteTrace) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.traces[t.next] = trace
+	t.next++
+	if t.next == len(t.traces) {
+		t.next = 0
+		t.full = true
+	}
+}
+
+// Trace records the stage timings of a write batch, it isn't thread-safe
+type Trace struct {
+	tracer  *tracer
+	sampled bool
+	record  models.WriteTrace
+	start   time.Time
+	last    time.Time
+}
+
+// NewTrace creates the trace of write batch which only observes the stage histograms
+func NewTrace(database string) *Trace {
+	now := time.Now()
+	return &Trace{
+		record: models.WriteTrace{
+			Database:  database,
+			StartTime: now.UnixNano() / int64(time.Millisecond),
+		},
+		start: now,
+		last:  now,
+	}
+}
+
+// Stage records the elapsed time since the previous stage(or start) as the time of stage
+func (t *Trace) Stage(stage string) {
+	now := time.Now()
+	cost := now.Sub(t.last)
+	t.last = now
+	stageDuration.WithLabelValues(stage).Observe(cost.Seconds())
+	t.record.Stages = append(t.record.Stages, models.StageTiming{Stage: stage, Cost: int64(cost / time.Microsecond)})
+}
+
+// SetPoints sets the number of points in the batch
+func (t *Trace) SetPoints(points int) {
+	t.record.Points = points
+}
+
+// Finish finishes the trace with the error of write(nil if success),
+// the trace is kept by tracer if it's sampled or slow
+func (t *Trace) Finish(err error) {
+	if t.tracer == nil {
+		return
+	}
+	cost := time.Since(t.start)
+	slow := t.tracer.slowThreshold > 0 && cost >= t.tracer.slowThreshold
+	if !t.sampled && !slow {
+		return
+	}
+	t.record.Cost = int64(cost / time.Microsecond)
+	t.record.Slow = slow
+	if err != nil {
+		t.record.Error = err.Error()
+	}
+	t.tracer.add(t.record)
+}
@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracer_sampling(t *testing.T) {
+	tracer := NewTracer(2, 0)
+	for i := 0; i < 4; i++ {
+		trace := tracer.Start(fmt.Sprintf("db-%d", i))
+		trace.SetPoints(i)
+		trace.Stage(StageReceive)
+		trace.Stage(StageParse)
+		trace.Finish(nil)
+	}
+	// 1 of 2 batches is kept
+	traces := tracer.Recent("", 0)
+	assert.Len(t, traces, 2)
+	assert.Equal(t, "db-3", traces[0].Database)
+	assert.Equal(t, 3, traces[0].Points)
+	assert.Len(t, traces[0].Stages, 2)
+	assert.Equal(t, StageParse, traces[0].Stages[1].Stage)
+	assert.False(t, traces[0].Slow)
+	assert.Equal(t, "db-1", traces[1].Database)
+	assert.Len(t, tracer.Recent("db-1", 0), 1)
+	assert.Len(t, tracer.Recent("", 1), 1)
+	assert.Empty(t, tracer.Recent("db-0", 0))
+
+	// no sampling
+	tracer = NewTracer(0, 0)
+	tracer.Start("db").Finish(nil)
+	assert.Empty(t, tracer.Recent("", 0))
+}
+
+func TestTracer_slow(t *testing.T) {
+	tracer := NewTracer(0, 1)
+	trace := tracer.Start("db")
+	tracer.Start("db").Finish(nil)
+	time.Sleep(2 * time.Millisecond)
+	trace.Stage(StageRoute)
+	trace.Finish(fmt.Errorf("err"))
+	traces := tracer.Recent("", 0)
+	assert.Len(t, traces, 1)
+	assert.True(t, traces[0].Slow)
+	assert.Equal(t, "err", traces[0].Error)
+	assert.True(t, traces[0].Cost >= 2000)
+	assert.True(t, traces[0].Stages[0].Cost >= 2000)
+}
+
+func TestTracer_overwrite(t *testing.T) {
+	tracer := NewTracer(1, 0)
+	for i := 0; i < defaultCapacity+10; i++ {
+		trace := tracer.Start("db")
+		trace.SetPoints(i)
+		trace.Finish(nil)
+	}
+	traces := tracer.Recent("db", 0)
+	assert.Len(t, traces, defaultCapacity)
+	assert.Equal(t, defaultCapacity+9, traces[0].Points)
+	assert.Equal(t, 10, traces[defaultCapacity-1].Points)
+}
+
+func TestNewTrace(t *testing.T) {
+	trace := NewTrace("db")
+	trace.Stage(StageReceive)
+	// the trace without tracer is dropped
+	trace.Finish(nil)
+	ObserveStage(StageMemDBWrite, time.Now())
+}
@@ -244,7 +244,11 @@ func (r *Reader) Range(startKey, endKey []byte) Iterator {
 		startKey: startKey,
 		endKey:   endKey,
 	}
-	return r.seekLeafNodes(rangeFilter)
+	it := r.seekLeafNodes(rangeFilter)
+	if it == nil {
+		return nil
+	}
+	return it
 }
 
 //SeekFirst returns an Iterator positioned on the first K-V pair in the tree
@@ -271,7 +275,11 @@ func (r *Reader) Seek(prefix []byte) Iterator {
 		prefix: prefix,
 	}
 
-	return r.seekLeafNodes(seekFilter)
+	it := r.seekLeafNodes(seekFilter)
+	if it == nil {
+		return nil
+	}
+	return it
 }
 
 //seekLeafNodes return a ReaderIterator.
@@ -81,3 +81,23 @@ func TestTree(t *testing.T) {
 
 	tree.Close()
 }
+
+func TestBTree_Range(t *testing.T) {
+	tree := NewBTree()
+	tree.Range(func(key []byte, value int) bool {
+		t.Fatal("empty tree")
+		return true
+	})
+	tree.Put([]byte("b"), 2)
+	tree.Put([]byte("a"), 1)
+	tree.Put([]byte("c"), 3)
+	var keys []string
+	var values []int
+	tree.Range(func(key []byte, value int) bool {
+		keys = append(keys, string(key))
+		values = append(values, value)
+		return value < 2
+	})
+	assert.Equal(t, []string{"a", "b"}, keys)
+	assert.Equal(t, []int{1, 2}, values)
+}
@@ -50,6 +50,21 @@ func (b *BTree) Len() int {
 	return b.tree.Len()
 }
 
+//Range calls fn with the key and value of each item in ascending order of key, stops if fn returns false.
+func (b *BTree) Range(fn func(key []byte, value int) bool) {
+	it, err := b.tree.SeekFirst()
+	if err != nil {
+		return
+	}
+	defer it.Close()
+	for {
+		key, value, err := it.Next()
+		if err != nil || !fn(key.([]byte), value.(int)) {
+			return
+		}
+	}
+}
+
 //Writer represents encoding the B+tree into the encoder
 type Writer struct {
 	t       *Tree                  // B+Tree
@@ -0,0 +1,437 @@
+package wasm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Defines the opcodes of instructions supported by interpreter, the numeric instructions in
+// [i32.eqz, f64.reinterpret_i64] are supported if they operate on i32/i64/f64(see numericSignature)
+const (
+	opUnreachable       = 0x00
+	opNop               = 0x01
+	opBlock             = 0x02
+	opLoop              = 0x03
+	opIf                = 0x04
+	opElse              = 0x05
+	opEnd               = 0x0b
+	opBr                = 0x0c
+	opBrIf              = 0x0d
+	opBrTable           = 0x0e
+	opReturn            = 0x0f
+	opCall              = 0x10
+	opDrop              = 0x1a
+	opSelect            = 0x1b
+	opLocalGet          = 0x20
+	opLocalSet          = 0x21
+	opLocalTee          = 0x22
+	opGlobalGet         = 0x23
+	opGlobalSet         = 0x24
+	opI32Load           = 0x28
+	opI64Load           = 0x29
+	opF64Load           = 0x2b
+	opI32Store          = 0x36
+	opI64Store          = 0x37
+	opF64Store          = 0x39
+	opMemorySize        = 0x3f
+	opMemoryGrow        = 0x40
+	opI32Const          = 0x41
+	opI64Const          = 0x42
+	opF64Const          = 0x44
+	opI32Eqz            = 0x45
+	opF64ReinterpretI64 = 0xbf
+)
+
+// instruction represents the decoded instruction, the immediates are decoded and the targets of blocks are
+// resolved when compiling, so that the interpreter doesn't parse the code when executing.
+type instruction struct {
+	op byte
+	// a/b are the immediates, e.g. const value, index of local/global/function, offset of memory
+	a, b uint64
+	// params/results are the arity of block/loop/if
+	params, results int
+	// elseAt/endAt are the indexes of else/end instruction of block/loop/if, elseAt is -1 if no else
+	elseAt, endAt int
+	// targets are the label depths of br_table, the last one is the default
+	targets []uint32
+}
+
+// compileFunction decodes the locals and code of function body, validates the types of operands
+func compileFunction(m *Module, fn *Function, body []byte) error {
+	r := &reader{buf: body}
+	groups, err := r.u32()
+	if err != nil {
+		return err
+	}
+	total := 0
+	for i := uint32(0); i < groups; i++ {
+		n, err := r.u32()
+		if err != nil {
+			return err
+		}
+		t, err := r.valueType()
+		if err != nil {
+			return err
+		}
+		total += int(n)
+		if total > maxLocals {
+			return fmt.Errorf("number of locals exceeds limit %d", maxLocals)
+		}
+		for j := uint32(0); j < n; j++ {
+			fn.Locals = append(fn.Locals, t)
+		}
+	}
+	c := &compiler{module: m, fn: fn, reader: r}
+	if err := c.compile(); err != nil {
+		return fmt.Errorf("%s at offset %d", err, r.pos)
+	}
+	fn.maxStack = c.validator.maxHeight
+	return nil
+}
+
+// compiler compiles the code of function into instructions, the code is validated when compiling
+type compiler struct {
+	module *Module
+	fn     *Function
+	reader *reader
+	// blocks are the indexes of block/loop/if instructions not ended
+	blocks    []int
+	validator validator
+}
+
+func (c *compiler) compile() error {
+	r := c.reader
+	v := &c.validator
+	locals := append(append([]ValueType{}, c.fn.Type.Params...), c.fn.Locals...)
+	v.pushCtrl(opBlock, nil, c.fn.Type.Results)
+	for {
+		op, err := r.byte()
+		if err != nil {
+			return err
+		}
+		ins := instruction{op: op, elseAt: -1}
+		idx := len(c.fn.code)
+		switch {
+		case op == opUnreachable:
+			v.setUnreachable()
+		case op == opNop:
+		case op == opReturn:
+			if err := v.pops(c.fn.Type.Results); err != nil {
+				return err
+			}
+			v.setUnreachable()
+		case op == opDrop:
+			if _, err := v.pop(); err != nil {
+				return err
+			}
+		case op == opSelect:
+			if err := c.selectOperands(unknownType); err != nil {
+				return err
+			}
+		case op >= opI32Eqz && op <= opF64ReinterpretI64:
+			params, result, ok := numericSignature(op)
+			if !ok {
+				return fmt.Errorf("unsupported opcode 0x%x", op)
+			}
+			if err := v.operate(params, result); err != nil {
+				return err
+			}
+		case op == opBlock, op == opLoop, op == opIf:
+			t, err := c.blockType()
+			if err != nil {
+				return err
+			}
+			if op == opIf {
+				if _, err := v.popExpect(I32); err != nil {
+					return err
+				}
+			}
+			if err := v.pops(t.Params); err != nil {
+				return err
+			}
+			v.pushCtrl(op, t.Params, t.Results)
+			ins.params, ins.results = len(t.Params), len(t.Results)
+			c.blocks = append(c.blocks, idx)
+		case op == opElse:
+			if len(c.blocks) == 0 || v.ctrls[len(v.ctrls)-1].op != opIf {
+				return errors.New("else without if")
+			}
+			frame, err := v.popCtrl()
+			if err != nil {
+				return err
+			}
+			v.pushCtrl(opElse, frame.params, frame.results)
+			c.fn.code[c.blocks[len(c.blocks)-1]].elseAt = idx
+		case op == opEnd:
+			frame, err := v.popCtrl()
+			if err != nil {
+				return err
+			}
+			if frame.op == opIf && !(FuncType{Params: frame.params}).Equal(FuncType{Params: frame.results}) {
+				return errors.New("if without else must have the same params and results")
+			}
+			if len(c.blocks) == 0 {
+				c.fn.code = append(c.fn.code, ins)
+				if !r.eof() {
+					return errors.New("code after the end of function")
+				}
+				return nil
+			}
+			v.pushes(frame.results)
+			block := &c.fn.code[c.blocks[len(c.blocks)-1]]
+			block.endAt = idx
+			if block.elseAt >= 0 {
+				// the then branch skips the else branch
+				c.fn.code[block.elseAt].endAt = idx
+			}
+			c.blocks = c.blocks[:len(c.blocks)-1]
+		case op == opBr, op == opBrIf:
+			if ins.a, err = c.labelDepth(); err != nil {
+				return err
+			}
+			if op == opBrIf {
+				if _, err := v.popExpect(I32); err != nil {
+					return err
+				}
+			}
+			types := v.labelTypes(ins.a)
+			if err := v.pops(types); err != nil {
+				return err
+			}
+			if op == opBr {
+				v.setUnreachable()
+			} else {
+				v.pushes(types)
+			}
+		case op == opBrTable:
+			if err := c.brTable(&ins); err != nil {
+				return err
+			}
+		case op == opCall:
+			if ins.a, err = c.index(len(c.module.Functions), "function"); err != nil {
+				return err
+			}
+			if err := c.callOperands(c.module.Functions[ins.a].Type); err != nil {
+				return err
+			}
+		case op >= opLocalGet && op <= opLocalTee:
+			if ins.a, err = c.index(len(locals), "local"); err != nil {
+				return err
+			}
+			if err := c.variableOperands(op, locals[ins.a]); err != nil {
+				return err
+			}
+		case op == opGlobalGet, op == opGlobalSet:
+			if ins.a, err = c.index(len(c.module.Globals), "global"); err != nil {
+				return err
+			}
+			global := c.module.Globals[ins.a]
+			if op == opGlobalSet && !global.Mutable {
+				return fmt.Errorf("global %d is immutable", ins.a)
+			}
+			if err := c.variableOperands(op, global.Type); err != nil {
+				return err
+			}
+		case op == opI32Load, op == opI64Load, op == opF64Load, op == opI32Store, op == opI64Store, op == opF64Store:
+			if c.module.Memory == nil {
+				return errors.New("memory instruction without memory")
+			}
+			if _, err := r.u32(); err != nil { // alignment is only a hint
+				return err
+			}
+			offset, err := r.u32()
+			if err != nil {
+				return err
+			}
+			ins.a = uint64(offset)
+			if err := v.operate(memorySignature(op)); err != nil {
+				return err
+			}
+		case op == opMemorySize, op == opMemoryGrow:
+			if c.module.Memory == nil {
+				return errors.New("memory instruction without memory")
+			}
+			if err := c.zero(); err != nil {
+				return err
+			}
+			if op == opMemoryGrow {
+				if _, err := v.popExpect(I32); err != nil {
+					return err
+				}
+			}
+			v.push(I32)
+		case op == opI32Const:
+			value, err := r.signed(32)
+			if err != nil {
+				return err
+			}
+			ins.a = uint64(uint32(value))
+			v.push(I32)
+		case op == opI64Const:
+			value, err := r.signed(64)
+			if err != nil {
+				return err
+			}
+			ins.a = uint64(value)
+			v.push(I64)
+		case op == opF64Const:
+			if ins.a, err = r.f64(); err != nil {
+				return err
+			}
+			v.push(F64)
+		default:
+			return fmt.Errorf("unsupported opcode 0x%x", op)
+		}
+		c.fn.code = append(c.fn.code, ins)
+	}
+}
+
+// brTable decodes the targets of br_table, the targets must pass the same number and types of operands
+func (c *compiler) brTable(ins *instruction) error {
+	r := c.reader
+	v := &c.validator
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	if int(n) > len(r.buf)-r.pos {
+		return errUnexpectedEOF
+	}
+	for i := uint32(0); i <= n; i++ {
+		depth, err := c.labelDepth()
+		if err != nil {
+			return err
+		}
+		ins.targets = append(ins.targets, uint32(depth))
+	}
+	if _, err := v.popExpect(I32); err != nil {
+		return err
+	}
+	defaultTypes := v.labelTypes(uint64(ins.targets[n]))
+	for _, depth := range ins.targets[:n] {
+		types := v.labelTypes(uint64(depth))
+		if len(types) != len(defaultTypes) {
+			return errors.New("targets of br_table have different arity")
+		}
+		if err := v.pops(types); err != nil {
+			return err
+		}
+		v.pushes(types)
+	}
+	if err := v.pops(defaultTypes); err != nil {
+		return err
+	}
+	v.setUnreachable()
+	return nil
+}
+
+// callOperands pops the params of callee, then pushes the results
+func (c *compiler) callOperands(t FuncType) error {
+	v := &c.validator
+	if err := v.pops(t.Params); err != nil {
+		return err
+	}
+	v.pushes(t.Results)
+	return nil
+}
+
+// selectOperands pops the condition and the operands of same type, then pushes the selected one,
+// the type of operands is inferred if unknown
+func (c *compiler) selectOperands(t ValueType) error {
+	v := &c.validator
+	if _, err := v.popExpect(I32); err != nil {
+		return err
+	}
+	t1, err := v.popExpect(t)
+	if err != nil {
+		return err
+	}
+	t2, err := v.popExpect(t1)
+	if err != nil {
+		return err
+	}
+	if t1 == unknownType {
+		t1 = t2
+	}
+	v.push(t1)
+	return nil
+}
+
+// variableOperands checks the operands of local/global get/set/tee of the variable type
+func (c *compiler) variableOperands(op byte, t ValueType) error {
+	v := &c.validator
+	switch op {
+	case opLocalGet, opGlobalGet:
+		v.push(t)
+	case opLocalSet, opGlobalSet:
+		if _, err := v.popExpect(t); err != nil {
+			return err
+		}
+	default: // local.tee
+		if _, err := v.popExpect(t); err != nil {
+			return err
+		}
+		v.push(t)
+	}
+	return nil
+}
+
+// blockType returns the params and results of block
+func (c *compiler) blockType() (FuncType, error) {
+	r := c.reader
+	if r.eof() {
+		return FuncType{}, errUnexpectedEOF
+	}
+	switch b := ValueType(r.buf[r.pos]); {
+	case b == 0x40:
+		r.pos++
+		return FuncType{}, nil
+	case b == I32, b == I64, b == F64:
+		r.pos++
+		return FuncType{Results: []ValueType{b}}, nil
+	}
+	idx, err := r.signed(33)
+	if err != nil {
+		return FuncType{}, err
+	}
+	if idx < 0 || int(idx) >= len(c.module.Types) {
+		return FuncType{}, fmt.Errorf("invalid block type %d", idx)
+	}
+	return c.module.Types[idx], nil
+}
+
+// labelDepth reads the depth of branch target, the function body is the outermost label
+func (c *compiler) labelDepth() (uint64, error) {
+	depth, err := c.reader.u32()
+	if err != nil {
+		return 0, err
+	}
+	if int(depth) > len(c.blocks) {
+		return 0, fmt.Errorf("label depth %d out of range", depth)
+	}
+	return uint64(depth), nil
+}
+
+// index reads the index of item, returns error if out of range
+func (c *compiler) index(count int, item string) (uint64, error) {
+	idx, err := c.reader.u32()
+	if err != nil {
+		return 0, err
+	}
+	if int(idx) >= count {
+		return 0, fmt.Errorf("%s index %d out of range", item, idx)
+	}
+	return uint64(idx), nil
+}
+
+// zero reads the reserved byte of memory index, which must be zero
+func (c *compiler) zero() error {
+	b, err := c.reader.byte()
+	if err != nil {
+		return err
+	}
+	if b != 0 {
+		return errors.New("only memory 0 is supported")
+	}
+	return nil
+}
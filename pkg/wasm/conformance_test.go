@@ -0,0 +1,413 @@
+package wasm
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// specCase represents the assert_return/assert_trap of WebAssembly spec test suite on the numeric instruction,
+// the instruction is executed by the exported function which passes its params as the operands.
+type specCase struct {
+	op   byte
+	args []uint64
+	want uint64
+	// nan expects any NaN of f64 as result, the bits of NaN aren't specified by spec
+	nan  bool
+	trap error
+}
+
+func s64(v int64) uint64 {
+	return uint64(v)
+}
+
+var (
+	negZero  = math.Copysign(0, -1)
+	inf      = math.Inf(1)
+	nan      = math.NaN()
+	nanBits  = uint64(0x7ff8000000000001)
+	negNaN   = uint64(0xfff8000000000001)
+	f64Cases = func(op byte, args []float64, want float64) specCase {
+		c := f64Compare(op, args, fromF64(want))
+		c.nan = math.IsNaN(want)
+		return c
+	}
+	f64Compare = func(op byte, args []float64, want uint64) specCase {
+		c := specCase{op: op, want: want}
+		for _, arg := range args {
+			c.args = append(c.args, fromF64(arg))
+		}
+		return c
+	}
+)
+
+// specCases are the assertions picked from i32.wast, i64.wast, f64.wast, f64_cmp.wast, f64_bitwise.wast
+// and conversions.wast of spec test suite, which cover all numeric instructions accepted by interpreter
+var specCases = []specCase{
+	// i32
+	{op: 0x45, args: []uint64{0}, want: 1},
+	{op: 0x45, args: []uint64{0x80000000}, want: 0},
+	{op: 0x46, args: []uint64{neg(-1), neg(-1)}, want: 1},
+	{op: 0x46, args: []uint64{0x80000000, 0x7fffffff}, want: 0},
+	{op: 0x47, args: []uint64{0x80000000, 0x7fffffff}, want: 1},
+	{op: 0x48, args: []uint64{0x80000000, 0x7fffffff}, want: 1},
+	{op: 0x48, args: []uint64{1, neg(-1)}, want: 0},
+	{op: 0x49, args: []uint64{neg(-1), 1}, want: 0},
+	{op: 0x49, args: []uint64{0x7fffffff, 0x80000000}, want: 1},
+	{op: 0x4a, args: []uint64{1, neg(-1)}, want: 1},
+	{op: 0x4b, args: []uint64{1, neg(-1)}, want: 0},
+	{op: 0x4c, args: []uint64{neg(-1), neg(-1)}, want: 1},
+	{op: 0x4c, args: []uint64{0x7fffffff, 0x80000000}, want: 0},
+	{op: 0x4d, args: []uint64{0x7fffffff, 0x80000000}, want: 1},
+	{op: 0x4e, args: []uint64{0x80000000, 0x7fffffff}, want: 0},
+	{op: 0x4f, args: []uint64{0x80000000, 0x7fffffff}, want: 1},
+	{op: 0x67, args: []uint64{0xffffffff}, want: 0},
+	{op: 0x67, args: []uint64{0}, want: 32},
+	{op: 0x67, args: []uint64{0x00008000}, want: 16},
+	{op: 0x68, args: []uint64{0}, want: 32},
+	{op: 0x68, args: []uint64{0x00008000}, want: 15},
+	{op: 0x68, args: []uint64{0x80000000}, want: 31},
+	{op: 0x69, args: []uint64{0xffffffff}, want: 32},
+	{op: 0x69, args: []uint64{0x55555555}, want: 16},
+	{op: 0x6a, args: []uint64{0x7fffffff, 1}, want: 0x80000000},
+	{op: 0x6a, args: []uint64{0x80000000, neg(-1)}, want: 0x7fffffff},
+	{op: 0x6a, args: []uint64{neg(-1), 1}, want: 0},
+	{op: 0x6b, args: []uint64{0x80000000, 1}, want: 0x7fffffff},
+	{op: 0x6b, args: []uint64{0x7fffffff, neg(-1)}, want: 0x80000000},
+	{op: 0x6c, args: []uint64{0x01234567, 0x76543210}, want: 0x358e7470},
+	{op: 0x6c, args: []uint64{0x7fffffff, neg(-1)}, want: 0x80000001},
+	{op: 0x6d, args: []uint64{1, 0}, trap: ErrDivideByZero},
+	{op: 0x6d, args: []uint64{0x80000000, neg(-1)}, trap: ErrIntegerOverflow},
+	{op: 0x6d, args: []uint64{0x80000000, 2}, want: 0xc0000000},
+	{op: 0x6d, args: []uint64{7, neg(-2)}, want: neg(-3)},
+	{op: 0x6d, args: []uint64{neg(-7), 2}, want: neg(-3)},
+	{op: 0x6e, args: []uint64{1, 0}, trap: ErrDivideByZero},
+	{op: 0x6e, args: []uint64{0x80000000, neg(-1)}, want: 0},
+	{op: 0x6e, args: []uint64{neg(-5), 2}, want: 0x7ffffffd},
+	{op: 0x6f, args: []uint64{1, 0}, trap: ErrDivideByZero},
+	{op: 0x6f, args: []uint64{0x80000000, neg(-1)}, want: 0},
+	{op: 0x6f, args: []uint64{neg(-7), 2}, want: neg(-1)},
+	{op: 0x6f, args: []uint64{7, neg(-3)}, want: 1},
+	{op: 0x70, args: []uint64{1, 0}, trap: ErrDivideByZero},
+	{op: 0x70, args: []uint64{0x80000000, neg(-1)}, want: 0x80000000},
+	{op: 0x70, args: []uint64{neg(-5), 2}, want: 1},
+	{op: 0x71, args: []uint64{0xf0f0ffff, 0xfffff0f0}, want: 0xf0f0f0f0},
+	{op: 0x72, args: []uint64{0xf0f0ffff, 0xfffff0f0}, want: 0xffffffff},
+	{op: 0x73, args: []uint64{0xf0f0ffff, 0xfffff0f0}, want: 0x0f0f0f0f},
+	{op: 0x74, args: []uint64{1, 31}, want: 0x80000000},
+	{op: 0x74, args: []uint64{1, 32}, want: 1},
+	{op: 0x74, args: []uint64{0x40000000, 1}, want: 0x80000000},
+	{op: 0x75, args: []uint64{0x80000000, 31}, want: neg(-1)},
+	{op: 0x75, args: []uint64{0x7fffffff, 1}, want: 0x3fffffff},
+	{op: 0x75, args: []uint64{1, 32}, want: 1},
+	{op: 0x76, args: []uint64{neg(-1), 1}, want: 0x7fffffff},
+	{op: 0x76, args: []uint64{0x80000000, 31}, want: 1},
+	{op: 0x76, args: []uint64{1, 32}, want: 1},
+	{op: 0x77, args: []uint64{0xabcd9876, 1}, want: 0x579b30ed},
+	{op: 0x77, args: []uint64{0x80000000, 1}, want: 1},
+	{op: 0x77, args: []uint64{1, 32}, want: 1},
+	{op: 0x78, args: []uint64{0xff00cc00, 1}, want: 0x7f806600},
+	{op: 0x78, args: []uint64{1, 1}, want: 0x80000000},
+	{op: 0x78, args: []uint64{1, 32}, want: 1},
+	// i64
+	{op: 0x50, args: []uint64{0}, want: 1},
+	{op: 0x50, args: []uint64{0x8000000000000000}, want: 0},
+	{op: 0x51, args: []uint64{0x8000000000000000, 0x8000000000000000}, want: 1},
+	{op: 0x51, args: []uint64{0x8000000000000000, 0x7fffffffffffffff}, want: 0},
+	{op: 0x52, args: []uint64{0x8000000000000000, 0x7fffffffffffffff}, want: 1},
+	{op: 0x53, args: []uint64{0x8000000000000000, 0x7fffffffffffffff}, want: 1},
+	{op: 0x54, args: []uint64{0x8000000000000000, 0x7fffffffffffffff}, want: 0},
+	{op: 0x55, args: []uint64{1, s64(-1)}, want: 1},
+	{op: 0x56, args: []uint64{1, s64(-1)}, want: 0},
+	{op: 0x57, args: []uint64{s64(-1), s64(-1)}, want: 1},
+	{op: 0x58, args: []uint64{1, s64(-1)}, want: 1},
+	{op: 0x59, args: []uint64{s64(-1), 1}, want: 0},
+	{op: 0x5a, args: []uint64{s64(-1), 1}, want: 1},
+	{op: 0x79, args: []uint64{0}, want: 64},
+	{op: 0x79, args: []uint64{0x8000000000000000}, want: 0},
+	{op: 0x79, args: []uint64{0x00008000}, want: 48},
+	{op: 0x7a, args: []uint64{0}, want: 64},
+	{op: 0x7a, args: []uint64{0x8000000000000000}, want: 63},
+	{op: 0x7b, args: []uint64{s64(-1)}, want: 64},
+	{op: 0x7b, args: []uint64{0x8000800080008000}, want: 4},
+	{op: 0x7c, args: []uint64{0x7fffffffffffffff, 1}, want: 0x8000000000000000},
+	{op: 0x7c, args: []uint64{0x8000000000000000, s64(-1)}, want: 0x7fffffffffffffff},
+	{op: 0x7d, args: []uint64{0x8000000000000000, 1}, want: 0x7fffffffffffffff},
+	{op: 0x7e, args: []uint64{0x0123456789abcdef, 0xfedcba9876543210}, want: 0x2236d88fe5618cf0},
+	{op: 0x7e, args: []uint64{0x7fffffffffffffff, s64(-1)}, want: 0x8000000000000001},
+	{op: 0x7f, args: []uint64{1, 0}, trap: ErrDivideByZero},
+	{op: 0x7f, args: []uint64{0x8000000000000000, s64(-1)}, trap: ErrIntegerOverflow},
+	{op: 0x7f, args: []uint64{s64(-7), 2}, want: s64(-3)},
+	{op: 0x80, args: []uint64{1, 0}, trap: ErrDivideByZero},
+	{op: 0x80, args: []uint64{s64(-5), 2}, want: 0x7ffffffffffffffd},
+	{op: 0x81, args: []uint64{1, 0}, trap: ErrDivideByZero},
+	{op: 0x81, args: []uint64{0x8000000000000000, s64(-1)}, want: 0},
+	{op: 0x81, args: []uint64{s64(-7), 2}, want: s64(-1)},
+	{op: 0x82, args: []uint64{1, 0}, trap: ErrDivideByZero},
+	{op: 0x82, args: []uint64{s64(-5), 2}, want: 1},
+	{op: 0x83, args: []uint64{0xf0f0ffff, 0xfffff0f0}, want: 0xf0f0f0f0},
+	{op: 0x84, args: []uint64{0xf0f0ffff, 0xfffff0f0}, want: 0xffffffff},
+	{op: 0x85, args: []uint64{0xf0f0ffff, 0xfffff0f0}, want: 0x0f0f0f0f},
+	{op: 0x86, args: []uint64{1, 63}, want: 0x8000000000000000},
+	{op: 0x86, args: []uint64{1, 64}, want: 1},
+	{op: 0x87, args: []uint64{0x8000000000000000, 63}, want: s64(-1)},
+	{op: 0x87, args: []uint64{1, 64}, want: 1},
+	{op: 0x88, args: []uint64{0x8000000000000000, 63}, want: 1},
+	{op: 0x88, args: []uint64{s64(-1), 1}, want: 0x7fffffffffffffff},
+	{op: 0x89, args: []uint64{0xabcd987602468ace, 1}, want: 0x579b30ec048d159d},
+	{op: 0x89, args: []uint64{1, 64}, want: 1},
+	{op: 0x8a, args: []uint64{0xabcd987602468ace, 1}, want: 0x55e6cc3b01234567},
+	{op: 0x8a, args: []uint64{1, 1}, want: 0x8000000000000000},
+	// f64 comparison
+	f64Compare(0x61, []float64{negZero, 0}, 1),
+	f64Compare(0x61, []float64{nan, nan}, 0),
+	f64Compare(0x62, []float64{nan, nan}, 1),
+	f64Compare(0x62, []float64{1, 1}, 0),
+	f64Compare(0x63, []float64{negZero, 0}, 0),
+	f64Compare(0x63, []float64{-inf, inf}, 1),
+	f64Compare(0x63, []float64{nan, 1}, 0),
+	f64Compare(0x64, []float64{inf, 1}, 1),
+	f64Compare(0x64, []float64{1, nan}, 0),
+	f64Compare(0x65, []float64{negZero, 0}, 1),
+	f64Compare(0x65, []float64{nan, nan}, 0),
+	f64Compare(0x66, []float64{0, negZero}, 1),
+	f64Compare(0x66, []float64{nan, 1}, 0),
+	// f64 unary
+	{op: 0x99, args: []uint64{fromF64(negZero)}, want: fromF64(0)},
+	{op: 0x99, args: []uint64{fromF64(-inf)}, want: fromF64(inf)},
+	{op: 0x99, args: []uint64{negNaN}, want: nanBits},
+	{op: 0x9a, args: []uint64{fromF64(0)}, want: fromF64(negZero)},
+	{op: 0x9a, args: []uint64{nanBits}, want: negNaN},
+	f64Cases(0x9b, []float64{-0.5}, negZero),
+	f64Cases(0x9b, []float64{1.5}, 2),
+	f64Cases(0x9b, []float64{-inf}, -inf),
+	f64Cases(0x9b, []float64{nan}, nan),
+	f64Cases(0x9c, []float64{-0.5}, -1),
+	f64Cases(0x9c, []float64{0.5}, 0),
+	f64Cases(0x9c, []float64{negZero}, negZero),
+	f64Cases(0x9d, []float64{-1.5}, -1),
+	f64Cases(0x9d, []float64{-0.5}, negZero),
+	f64Cases(0x9d, []float64{inf}, inf),
+	f64Cases(0x9e, []float64{0.5}, 0),
+	f64Cases(0x9e, []float64{-0.5}, negZero),
+	f64Cases(0x9e, []float64{1.5}, 2),
+	f64Cases(0x9e, []float64{2.5}, 2),
+	f64Cases(0x9e, []float64{-3.5}, -4),
+	f64Cases(0x9f, []float64{4}, 2),
+	f64Cases(0x9f, []float64{negZero}, negZero),
+	f64Cases(0x9f, []float64{-1}, nan),
+	f64Cases(0x9f, []float64{inf}, inf),
+	// f64 binary
+	f64Cases(0xa0, []float64{1, 2}, 3),
+	f64Cases(0xa0, []float64{negZero, negZero}, negZero),
+	f64Cases(0xa0, []float64{inf, -inf}, nan),
+	f64Cases(0xa0, []float64{math.MaxFloat64, math.MaxFloat64}, inf),
+	f64Cases(0xa1, []float64{0, 0}, 0),
+	f64Cases(0xa1, []float64{inf, inf}, nan),
+	f64Cases(0xa1, []float64{negZero, 0}, negZero),
+	f64Cases(0xa2, []float64{negZero, 1}, negZero),
+	f64Cases(0xa2, []float64{inf, 0}, nan),
+	f64Cases(0xa2, []float64{1e308, 10}, inf),
+	f64Cases(0xa3, []float64{1, 0}, inf),
+	f64Cases(0xa3, []float64{1, negZero}, -inf),
+	f64Cases(0xa3, []float64{0, 0}, nan),
+	f64Cases(0xa3, []float64{1, 4}, 0.25),
+	f64Cases(0xa4, []float64{negZero, 0}, negZero),
+	f64Cases(0xa4, []float64{0, negZero}, negZero),
+	f64Cases(0xa4, []float64{nan, 1}, nan),
+	f64Cases(0xa4, []float64{1, nan}, nan),
+	f64Cases(0xa4, []float64{-inf, 1}, -inf),
+	f64Cases(0xa5, []float64{negZero, 0}, 0),
+	f64Cases(0xa5, []float64{0, negZero}, 0),
+	f64Cases(0xa5, []float64{nan, 1}, nan),
+	f64Cases(0xa5, []float64{inf, 1}, inf),
+	f64Cases(0xa6, []float64{1, negZero}, -1),
+	f64Cases(0xa6, []float64{-1, 0}, 1),
+	{op: 0xa6, args: []uint64{nanBits, fromF64(-1)}, want: negNaN},
+	// conversions
+	{op: 0xa7, args: []uint64{s64(-1)}, want: 0xffffffff},
+	{op: 0xa7, args: []uint64{0x100000000}, want: 0},
+	{op: 0xa7, args: []uint64{0xffffffff00000001}, want: 1},
+	{op: 0xaa, args: []uint64{fromF64(negZero)}, want: 0},
+	{op: 0xaa, args: []uint64{fromF64(-1.9)}, want: neg(-1)},
+	{op: 0xaa, args: []uint64{fromF64(2147483647.9)}, want: 0x7fffffff},
+	{op: 0xaa, args: []uint64{fromF64(-2147483648.9)}, want: 0x80000000},
+	{op: 0xaa, args: []uint64{fromF64(2147483648)}, trap: ErrIntegerOverflow},
+	{op: 0xaa, args: []uint64{fromF64(-2147483649)}, trap: ErrIntegerOverflow},
+	{op: 0xaa, args: []uint64{fromF64(inf)}, trap: ErrIntegerOverflow},
+	{op: 0xaa, args: []uint64{fromF64(nan)}, trap: ErrInvalidConversion},
+	{op: 0xab, args: []uint64{fromF64(-0.9)}, want: 0},
+	{op: 0xab, args: []uint64{fromF64(4294967295.9)}, want: 0xffffffff},
+	{op: 0xab, args: []uint64{fromF64(4294967296)}, trap: ErrIntegerOverflow},
+	{op: 0xab, args: []uint64{fromF64(-1)}, trap: ErrIntegerOverflow},
+	{op: 0xab, args: []uint64{fromF64(nan)}, trap: ErrInvalidConversion},
+	{op: 0xac, args: []uint64{0x7fffffff}, want: 0x7fffffff},
+	{op: 0xac, args: []uint64{0x80000000}, want: 0xffffffff80000000},
+	{op: 0xad, args: []uint64{0x80000000}, want: 0x80000000},
+	{op: 0xad, args: []uint64{neg(-1)}, want: 0xffffffff},
+	{op: 0xb0, args: []uint64{fromF64(-1.9)}, want: s64(-1)},
+	{op: 0xb0, args: []uint64{fromF64(9223372036854774784)}, want: 9223372036854774784},
+	{op: 0xb0, args: []uint64{fromF64(-9223372036854775808)}, want: 0x8000000000000000},
+	{op: 0xb0, args: []uint64{fromF64(9223372036854775808)}, trap: ErrIntegerOverflow},
+	{op: 0xb0, args: []uint64{fromF64(-9223372036854777856)}, trap: ErrIntegerOverflow},
+	{op: 0xb0, args: []uint64{fromF64(nan)}, trap: ErrInvalidConversion},
+	{op: 0xb1, args: []uint64{fromF64(-0.9)}, want: 0},
+	{op: 0xb1, args: []uint64{fromF64(1e16)}, want: 10000000000000000},
+	{op: 0xb1, args: []uint64{fromF64(18446744073709549568)}, want: 0xfffffffffffff800},
+	{op: 0xb1, args: []uint64{fromF64(18446744073709551616)}, trap: ErrIntegerOverflow},
+	{op: 0xb1, args: []uint64{fromF64(-1)}, trap: ErrIntegerOverflow},
+	{op: 0xb1, args: []uint64{fromF64(nan)}, trap: ErrInvalidConversion},
+	{op: 0xb7, args: []uint64{neg(-1)}, want: fromF64(-1)},
+	{op: 0xb7, args: []uint64{0x80000000}, want: fromF64(-2147483648)},
+	{op: 0xb8, args: []uint64{0xffffffff}, want: fromF64(4294967295)},
+	{op: 0xb8, args: []uint64{0x80000000}, want: fromF64(2147483648)},
+	{op: 0xb9, args: []uint64{s64(-1)}, want: fromF64(-1)},
+	{op: 0xb9, args: []uint64{0x8000000000000000}, want: fromF64(-9223372036854775808)},
+	{op: 0xb9, args: []uint64{9007199254740993}, want: fromF64(9007199254740992)},
+	{op: 0xba, args: []uint64{s64(-1)}, want: fromF64(18446744073709551616)},
+	{op: 0xba, args: []uint64{0x8000000000000000}, want: fromF64(9223372036854775808)},
+	{op: 0xba, args: []uint64{9007199254740993}, want: fromF64(9007199254740992)},
+	{op: 0xbd, args: []uint64{fromF64(negZero)}, want: 0x8000000000000000},
+	{op: 0xbd, args: []uint64{nanBits}, want: nanBits},
+	{op: 0xbf, args: []uint64{0x8000000000000000}, want: fromF64(negZero)},
+	{op: 0xbf, args: []uint64{0x3ff0000000000000}, want: fromF64(1)},
+}
+
+// numericFuncName returns the name of function which executes the numeric instruction
+func numericFuncName(op byte) string {
+	return fmt.Sprintf("op_%02x", op)
+}
+
+// newNumericInstance returns the instance which exports a function for each numeric instruction accepted
+func newNumericInstance(t *testing.T) *Instance {
+	var funcs []testFunc
+	for op := opI32Eqz; op <= opF64ReinterpretI64; op++ {
+		params, result, ok := numericSignature(byte(op))
+		if !ok {
+			continue
+		}
+		var body []byte
+		for i := range params {
+			body = append(body, opLocalGet, byte(i))
+		}
+		funcs = append(funcs, testFunc{name: numericFuncName(byte(op)), params: params,
+			results: []ValueType{result}, body: append(body, byte(op), opEnd)})
+	}
+	m, err := Decode(testModule{funcs: funcs}.bytes())
+	assert.NoError(t, err)
+	vm, err := Instantiate(m, Config{})
+	assert.NoError(t, err)
+	return vm
+}
+
+func TestConformance_numeric(t *testing.T) {
+	vm := newNumericInstance(t)
+	for _, c := range specCases {
+		results, err := vm.Call(numericFuncName(c.op), c.args...)
+		if c.trap != nil {
+			assert.Equal(t, c.trap, err, "op 0x%x %v", c.op, c.args)
+			continue
+		}
+		if !assert.NoError(t, err, "op 0x%x %v", c.op, c.args) {
+			continue
+		}
+		if c.nan {
+			assert.True(t, math.IsNaN(f64(results[0])), "op 0x%x %v", c.op, c.args)
+		} else {
+			assert.Equal(t, c.want, results[0], "op 0x%x %v", c.op, c.args)
+		}
+	}
+}
+
+func TestConformance_opcodes(t *testing.T) {
+	covered := make(map[byte]bool)
+	for _, c := range specCases {
+		covered[c.op] = true
+	}
+	accepted := map[byte]bool{
+		opUnreachable: true, opNop: true, opBlock: true, opLoop: true, opIf: true, opElse: true, opEnd: true,
+		opBr: true, opBrIf: true, opBrTable: true, opReturn: true, opCall: true, opDrop: true, opSelect: true,
+		opLocalGet: true, opLocalSet: true, opLocalTee: true, opGlobalGet: true, opGlobalSet: true,
+		opI32Load: true, opI64Load: true, opF64Load: true, opI32Store: true, opI64Store: true, opF64Store: true,
+		opMemorySize: true, opMemoryGrow: true, opI32Const: true, opI64Const: true, opF64Const: true,
+	}
+	for op := 0; op <= 0xff; op++ {
+		if _, _, ok := numericSignature(byte(op)); ok && op >= opI32Eqz && op <= opF64ReinterpretI64 {
+			// each numeric instruction accepted is covered by spec cases
+			assert.True(t, covered[byte(op)], "op 0x%x isn't covered", op)
+			continue
+		}
+		if accepted[byte(op)] {
+			continue
+		}
+		// the instructions out of the subset are rejected when decoding
+		_, err := Decode(testModule{funcs: []testFunc{{body: []byte{byte(op), opEnd}}}, memory: []byte{0x00, 0x01}}.bytes())
+		if assert.Error(t, err, "op 0x%x", op) {
+			assert.Contains(t, err.Error(), fmt.Sprintf("unsupported opcode 0x%x", op))
+		}
+	}
+}
+
+func TestConformance_memory(t *testing.T) {
+	// the functions load/store at addr with the static offset, see address.wast/memory_trap.wast of spec test suite
+	funcs := []testFunc{
+		{name: "i32.load", params: i32, results: i32, body: []byte{opLocalGet, 0, opI32Load, 2, 1, opEnd}},
+		{name: "i64.load", params: i32, results: i64, body: []byte{opLocalGet, 0, opI64Load, 3, 1, opEnd}},
+		{name: "f64.load", params: i32, results: f64x1, body: []byte{opLocalGet, 0, opF64Load, 3, 0, opEnd}},
+		{name: "i32.store", params: i32x2, body: []byte{opLocalGet, 0, opLocalGet, 1, opI32Store, 2, 1, opEnd}},
+		{name: "i64.store", params: []ValueType{I32, I64},
+			body: []byte{opLocalGet, 0, opLocalGet, 1, opI64Store, 3, 1, opEnd}},
+		{name: "f64.store", params: []ValueType{I32, F64},
+			body: []byte{opLocalGet, 0, opLocalGet, 1, opF64Store, 3, 0, opEnd}},
+		{name: "memory.grow", params: i32, results: i32, body: []byte{opLocalGet, 0, opMemoryGrow, 0, opEnd}},
+		{name: "memory.size", results: i32, body: []byte{opMemorySize, 0, opEnd}},
+	}
+	m, err := Decode(testModule{funcs: funcs, memory: []byte{0x01, 0x01, 0x02},
+		data: []byte("abcdefghijklmnopqrstuvwxyz")}.bytes())
+	assert.NoError(t, err)
+	vm, err := Instantiate(m, Config{})
+	assert.NoError(t, err)
+
+	// little endian with the static offset
+	assert.Equal(t, uint64(0x65646362), call(t, vm, "i32.load", 0))
+	assert.Equal(t, uint64(0x7a797877), call(t, vm, "i32.load", 21))
+	assert.Equal(t, uint64(0x6968676665646362), call(t, vm, "i64.load", 0))
+	call(t, vm, "i32.store", 0, 0xffffffff)
+	assert.Equal(t, uint64(0x61), uint64(vm.Memory()[0]))
+	assert.Equal(t, uint64(0xffffffff), call(t, vm, "i32.load", 0))
+	call(t, vm, "i64.store", 8, 0x0123456789abcdef)
+	assert.Equal(t, uint64(0x0123456789abcdef), call(t, vm, "i64.load", 8))
+	call(t, vm, "f64.store", 16, fromF64(negZero))
+	assert.Equal(t, fromF64(negZero), call(t, vm, "f64.load", 16))
+
+	// the access ends at the boundary is in bounds, the effective address doesn't wrap around
+	assert.Equal(t, uint64(0), call(t, vm, "i32.load", PageSize-5))
+	assert.Equal(t, uint64(0), call(t, vm, "f64.load", PageSize-8))
+	traps := []struct {
+		name string
+		args []uint64
+	}{
+		{"i32.load", []uint64{PageSize - 4}},
+		{"i64.load", []uint64{PageSize - 8}},
+		{"f64.load", []uint64{PageSize - 7}},
+		{"i32.load", []uint64{math.MaxUint32}},
+		{"i32.store", []uint64{PageSize - 4, 0}},
+		{"i64.store", []uint64{math.MaxUint32, 0}},
+		{"f64.store", []uint64{PageSize, 0}},
+	}
+	for _, c := range traps {
+		_, err := vm.Call(c.name, c.args...)
+		assert.Equal(t, ErrMemoryOutOfBounds, err, "%s %v", c.name, c.args)
+	}
+
+	// the memory grows up to the max of limits, returns -1 if exceeds
+	assert.Equal(t, uint64(1), call(t, vm, "memory.size"))
+	assert.Equal(t, uint64(1), call(t, vm, "memory.grow", 0))
+	assert.Equal(t, uint64(1), call(t, vm, "memory.grow", 1))
+	assert.Equal(t, neg(-1), call(t, vm, "memory.grow", 1))
+	assert.Equal(t, uint64(2), call(t, vm, "memory.size"))
+	assert.Equal(t, uint64(0), call(t, vm, "i32.load", PageSize-5))
+	assert.Equal(t, uint64(0), call(t, vm, "i32.load", 2*PageSize-5))
+}
@@ -0,0 +1,562 @@
+package wasm
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+)
+
+// label represents the branch target of block/loop/if when executing
+type label struct {
+	// height is the height of stack when entering the block, excludes the params of block
+	height int
+	// arity is the number of values passed by branch, params for loop, results for block/if
+	arity int
+	// target is the index of instruction continued after branch, the loop continues after itself,
+	// the block/if continues after its end.
+	target int
+	loop   bool
+}
+
+// call calls the function with the args on top of stack, the results are left on the stack.
+// The locals are kept on the stack below the operands of function.
+func (vm *Instance) call(idx uint32) {
+	vm.depth++
+	if vm.depth > vm.config.MaxCallDepth {
+		throw(ErrCallStackExhausted)
+	}
+	fn := &vm.module.Functions[idx]
+	locals := len(vm.stack) - len(fn.Type.Params)
+	// the operand stack of validated function never exceeds its max height
+	if len(vm.stack)+len(fn.Locals)+fn.maxStack > vm.config.MaxStackSize {
+		throw(ErrCallStackExhausted)
+	}
+	for range fn.Locals {
+		vm.stack = append(vm.stack, 0)
+	}
+	vm.execute(fn, locals)
+	// drops the locals and the operands left, keeps the results
+	n := len(fn.Type.Results)
+	copy(vm.stack[locals:], vm.stack[len(vm.stack)-n:])
+	vm.stack = vm.stack[:locals+n]
+	vm.depth--
+}
+
+// execute executes the instructions of function until return
+func (vm *Instance) execute(fn *Function, locals int) {
+	code := fn.code
+	var labels []label
+	// br branches to the label by depth, returns false if branches out of function
+	br := func(pc *int, depth int) bool {
+		if depth >= len(labels) {
+			return false
+		}
+		l := labels[len(labels)-1-depth]
+		copy(vm.stack[l.height:], vm.stack[len(vm.stack)-l.arity:])
+		vm.stack = vm.stack[:l.height+l.arity]
+		if l.loop {
+			labels = labels[:len(labels)-depth]
+		} else {
+			labels = labels[:len(labels)-1-depth]
+		}
+		*pc = l.target
+		return true
+	}
+	for pc := 0; pc < len(code); pc++ {
+		vm.fuel--
+		if vm.fuel < 0 {
+			throw(ErrOutOfFuel)
+		}
+		ins := &code[pc]
+		switch op := ins.op; {
+		case op == opUnreachable:
+			throw(ErrUnreachable)
+		case op == opNop:
+		case op == opBlock:
+			labels = append(labels, label{height: len(vm.stack) - ins.params, arity: ins.results, target: ins.endAt})
+		case op == opLoop:
+			labels = append(labels, label{height: len(vm.stack) - ins.params, arity: ins.params, target: pc, loop: true})
+		case op == opIf:
+			cond := vm.pop()
+			switch {
+			case cond != 0:
+				labels = append(labels, label{height: len(vm.stack) - ins.params, arity: ins.results, target: ins.endAt})
+			case ins.elseAt >= 0:
+				labels = append(labels, label{height: len(vm.stack) - ins.params, arity: ins.results, target: ins.endAt})
+				pc = ins.elseAt
+			default:
+				// the if without else keeps the params as results
+				pc = ins.endAt
+			}
+		case op == opElse:
+			// the end of then branch
+			labels = labels[:len(labels)-1]
+			pc = ins.endAt
+		case op == opEnd:
+			if len(labels) == 0 {
+				return
+			}
+			labels = labels[:len(labels)-1]
+		case op == opBr:
+			if !br(&pc, int(ins.a)) {
+				return
+			}
+		case op == opBrIf:
+			if vm.pop() != 0 && !br(&pc, int(ins.a)) {
+				return
+			}
+		case op == opBrTable:
+			i := uint32(vm.pop())
+			depth := ins.targets[len(ins.targets)-1]
+			if int(i) < len(ins.targets)-1 {
+				depth = ins.targets[i]
+			}
+			if !br(&pc, int(depth)) {
+				return
+			}
+		case op == opReturn:
+			return
+		case op == opCall:
+			vm.call(uint32(ins.a))
+		case op == opDrop:
+			vm.pop()
+		case op == opSelect:
+			cond := vm.pop()
+			b := vm.pop()
+			if cond == 0 {
+				vm.stack[len(vm.stack)-1] = b
+			}
+		case op == opLocalGet:
+			vm.push(vm.stack[locals+int(ins.a)])
+		case op == opLocalSet:
+			vm.stack[locals+int(ins.a)] = vm.pop()
+		case op == opLocalTee:
+			vm.stack[locals+int(ins.a)] = vm.stack[len(vm.stack)-1]
+		case op == opGlobalGet:
+			vm.push(vm.globals[ins.a])
+		case op == opGlobalSet:
+			vm.globals[ins.a] = vm.pop()
+		case op >= opI32Load && op <= opF64Store:
+			vm.memoryAccess(ins)
+		case op == opMemorySize:
+			vm.push(uint64(len(vm.memory) / PageSize))
+		case op == opMemoryGrow:
+			vm.push(vm.grow(uint32(vm.pop())))
+		case op >= opI32Const && op <= opF64Const:
+			vm.push(ins.a)
+		default:
+			vm.numeric(op)
+		}
+	}
+}
+
+// push pushes the value on top of stack, traps if the stack exceeds limit
+func (vm *Instance) push(v uint64) {
+	if len(vm.stack) >= vm.config.MaxStackSize {
+		throw(ErrCallStackExhausted)
+	}
+	vm.stack = append(vm.stack, v)
+}
+
+// pop pops the value on top of stack
+func (vm *Instance) pop() uint64 {
+	v := vm.stack[len(vm.stack)-1]
+	vm.stack = vm.stack[:len(vm.stack)-1]
+	return v
+}
+
+// grow grows the memory by pages, returns the old pages, returns -1 if the memory exceeds limit
+func (vm *Instance) grow(pages uint32) uint64 {
+	old := uint32(len(vm.memory) / PageSize)
+	if uint64(old)+uint64(pages) > uint64(vm.maxPages) {
+		return uint64(math.MaxUint32)
+	}
+	if pages > 0 {
+		memory := make([]byte, (int(old)+int(pages))*PageSize)
+		copy(memory, vm.memory)
+		vm.memory = memory
+	}
+	return uint64(old)
+}
+
+// effectiveAddress returns the memory of load/store by the address on top of stack and the offset of instruction
+func (vm *Instance) effectiveAddress(offset uint64, size int) []byte {
+	addr := uint64(uint32(vm.pop())) + offset
+	if addr+uint64(size) > uint64(len(vm.memory)) {
+		throw(ErrMemoryOutOfBounds)
+	}
+	return vm.memory[addr : addr+uint64(size)]
+}
+
+// memoryAccess executes the load/store instruction
+func (vm *Instance) memoryAccess(ins *instruction) {
+	le := binary.LittleEndian
+	switch ins.op {
+	case opI32Load:
+		vm.push(uint64(le.Uint32(vm.effectiveAddress(ins.a, 4))))
+	case opI64Load, opF64Load:
+		vm.push(le.Uint64(vm.effectiveAddress(ins.a, 8)))
+	case opI32Store:
+		v := vm.pop()
+		le.PutUint32(vm.effectiveAddress(ins.a, 4), uint32(v))
+	default: // i64.store, f64.store
+		v := vm.pop()
+		le.PutUint64(vm.effectiveAddress(ins.a, 8), v)
+	}
+}
+
+// trunc truncates the float to integer, traps if NaN or the integer is out of (lower, upper)
+func trunc(f, lower, upper float64) float64 {
+	if f != f {
+		throw(ErrInvalidConversion)
+	}
+	if f <= lower || f >= upper {
+		throw(ErrIntegerOverflow)
+	}
+	return math.Trunc(f)
+}
+
+// f64SignBit is the sign bit of float64
+const f64SignBit = uint64(1) << 63
+
+// numeric executes the numeric instruction(comparison, arithmetic and conversion)
+func (vm *Instance) numeric(op byte) {
+	switch {
+	case op == 0x45: // i32.eqz
+		vm.push(b2u(uint32(vm.pop()) == 0))
+	case op >= 0x46 && op <= 0x4f:
+		y := uint32(vm.pop())
+		x := uint32(vm.pop())
+		vm.push(b2u(i32Compare(op, x, y)))
+	case op == 0x50: // i64.eqz
+		vm.push(b2u(vm.pop() == 0))
+	case op >= 0x51 && op <= 0x5a:
+		y := vm.pop()
+		x := vm.pop()
+		vm.push(b2u(i64Compare(op, x, y)))
+	case op >= 0x61 && op <= 0x66:
+		y := f64(vm.pop())
+		x := f64(vm.pop())
+		vm.push(b2u(floatCompare(op-0x61, x, y)))
+	case op >= 0x67 && op <= 0x69:
+		x := uint32(vm.pop())
+		switch op {
+		case 0x67: // i32.clz
+			vm.push(uint64(bits.LeadingZeros32(x)))
+		case 0x68: // i32.ctz
+			vm.push(uint64(bits.TrailingZeros32(x)))
+		default: // i32.popcnt
+			vm.push(uint64(bits.OnesCount32(x)))
+		}
+	case op >= 0x6a && op <= 0x78:
+		y := uint32(vm.pop())
+		x := uint32(vm.pop())
+		vm.push(uint64(i32Binary(op, x, y)))
+	case op >= 0x79 && op <= 0x7b:
+		x := vm.pop()
+		switch op {
+		case 0x79: // i64.clz
+			vm.push(uint64(bits.LeadingZeros64(x)))
+		case 0x7a: // i64.ctz
+			vm.push(uint64(bits.TrailingZeros64(x)))
+		default: // i64.popcnt
+			vm.push(uint64(bits.OnesCount64(x)))
+		}
+	case op >= 0x7c && op <= 0x8a:
+		y := vm.pop()
+		x := vm.pop()
+		vm.push(i64Binary(op, x, y))
+	case op >= 0x99 && op <= 0x9f:
+		x := vm.pop()
+		switch op {
+		case 0x99: // f64.abs
+			vm.push(x &^ f64SignBit)
+		case 0x9a: // f64.neg
+			vm.push(x ^ f64SignBit)
+		default:
+			vm.push(fromF64(floatUnary(op-0x99, f64(x))))
+		}
+	case op >= 0xa0 && op <= 0xa6:
+		y := vm.pop()
+		x := vm.pop()
+		if op == 0xa6 { // f64.copysign
+			vm.push(x&^f64SignBit | y&f64SignBit)
+		} else {
+			vm.push(fromF64(f64Binary(op, f64(x), f64(y))))
+		}
+	default:
+		vm.push(convert(op, vm.pop()))
+	}
+}
+
+// b2u converts the bool to i32
+func b2u(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// i32Compare compares the i32 values, op is in [i32.eq, i32.ge_u]
+func i32Compare(op byte, x, y uint32) bool {
+	switch op {
+	case 0x46:
+		return x == y
+	case 0x47:
+		return x != y
+	case 0x48:
+		return int32(x) < int32(y)
+	case 0x49:
+		return x < y
+	case 0x4a:
+		return int32(x) > int32(y)
+	case 0x4b:
+		return x > y
+	case 0x4c:
+		return int32(x) <= int32(y)
+	case 0x4d:
+		return x <= y
+	case 0x4e:
+		return int32(x) >= int32(y)
+	default:
+		return x >= y
+	}
+}
+
+// i64Compare compares the i64 values, op is in [i64.eq, i64.ge_u]
+func i64Compare(op byte, x, y uint64) bool {
+	switch op {
+	case 0x51:
+		return x == y
+	case 0x52:
+		return x != y
+	case 0x53:
+		return int64(x) < int64(y)
+	case 0x54:
+		return x < y
+	case 0x55:
+		return int64(x) > int64(y)
+	case 0x56:
+		return x > y
+	case 0x57:
+		return int64(x) <= int64(y)
+	case 0x58:
+		return x <= y
+	case 0x59:
+		return int64(x) >= int64(y)
+	default:
+		return x >= y
+	}
+}
+
+// floatCompare compares the f64 values, kind is the offset of op to f64.eq
+func floatCompare(kind byte, x, y float64) bool {
+	switch kind {
+	case 0:
+		return x == y
+	case 1:
+		return x != y
+	case 2:
+		return x < y
+	case 3:
+		return x > y
+	case 4:
+		return x <= y
+	default:
+		return x >= y
+	}
+}
+
+// i32Binary executes the binary i32 operation, op is in [i32.add, i32.rotr]
+func i32Binary(op byte, x, y uint32) uint32 {
+	switch op {
+	case 0x6a:
+		return x + y
+	case 0x6b:
+		return x - y
+	case 0x6c:
+		return x * y
+	case 0x6d: // div_s
+		if y == 0 {
+			throw(ErrDivideByZero)
+		}
+		if int32(x) == math.MinInt32 && int32(y) == -1 {
+			throw(ErrIntegerOverflow)
+		}
+		return uint32(int32(x) / int32(y))
+	case 0x6e: // div_u
+		if y == 0 {
+			throw(ErrDivideByZero)
+		}
+		return x / y
+	case 0x6f: // rem_s
+		if y == 0 {
+			throw(ErrDivideByZero)
+		}
+		if int32(y) == -1 {
+			return 0
+		}
+		return uint32(int32(x) % int32(y))
+	case 0x70: // rem_u
+		if y == 0 {
+			throw(ErrDivideByZero)
+		}
+		return x % y
+	case 0x71:
+		return x & y
+	case 0x72:
+		return x | y
+	case 0x73:
+		return x ^ y
+	case 0x74:
+		return x << (y & 31)
+	case 0x75:
+		return uint32(int32(x) >> (y & 31))
+	case 0x76:
+		return x >> (y & 31)
+	case 0x77:
+		return bits.RotateLeft32(x, int(y&31))
+	default:
+		return bits.RotateLeft32(x, -int(y&31))
+	}
+}
+
+// i64Binary executes the binary i64 operation, op is in [i64.add, i64.rotr]
+func i64Binary(op byte, x, y uint64) uint64 {
+	switch op {
+	case 0x7c:
+		return x + y
+	case 0x7d:
+		return x - y
+	case 0x7e:
+		return x * y
+	case 0x7f: // div_s
+		if y == 0 {
+			throw(ErrDivideByZero)
+		}
+		if int64(x) == math.MinInt64 && int64(y) == -1 {
+			throw(ErrIntegerOverflow)
+		}
+		return uint64(int64(x) / int64(y))
+	case 0x80: // div_u
+		if y == 0 {
+			throw(ErrDivideByZero)
+		}
+		return x / y
+	case 0x81: // rem_s
+		if y == 0 {
+			throw(ErrDivideByZero)
+		}
+		if int64(y) == -1 {
+			return 0
+		}
+		return uint64(int64(x) % int64(y))
+	case 0x82: // rem_u
+		if y == 0 {
+			throw(ErrDivideByZero)
+		}
+		return x % y
+	case 0x83:
+		return x & y
+	case 0x84:
+		return x | y
+	case 0x85:
+		return x ^ y
+	case 0x86:
+		return x << (y & 63)
+	case 0x87:
+		return uint64(int64(x) >> (y & 63))
+	case 0x88:
+		return x >> (y & 63)
+	case 0x89:
+		return bits.RotateLeft64(x, int(y&63))
+	default:
+		return bits.RotateLeft64(x, -int(y&63))
+	}
+}
+
+// floatUnary executes the unary f64 operation, kind is the offset of op to f64.abs
+func floatUnary(kind byte, x float64) float64 {
+	switch kind {
+	case 2:
+		return math.Ceil(x)
+	case 3:
+		return math.Floor(x)
+	case 4:
+		return math.Trunc(x)
+	case 5:
+		return math.RoundToEven(x)
+	default:
+		return math.Sqrt(x)
+	}
+}
+
+// f64Binary executes the binary f64 operation, op is in [f64.add, f64.max]
+func f64Binary(op byte, x, y float64) float64 {
+	switch op {
+	case 0xa0:
+		return x + y
+	case 0xa1:
+		return x - y
+	case 0xa2:
+		return x * y
+	case 0xa3:
+		return x / y
+	case 0xa4:
+		return math.Min(x, y)
+	default:
+		return math.Max(x, y)
+	}
+}
+
+// convert executes the conversion, op is the conversion between i32/i64/f64 in [i32.wrap_i64, f64.reinterpret_i64]
+func convert(op byte, x uint64) uint64 {
+	switch op {
+	case 0xa7: // i32.wrap_i64
+		return uint64(uint32(x))
+	case 0xaa: // i32.trunc_f64_s
+		return uint64(uint32(int32(trunc(f64(x), math.MinInt32-1, math.MaxInt32+1))))
+	case 0xab: // i32.trunc_f64_u
+		return uint64(uint32(trunc(f64(x), -1, math.MaxUint32+1)))
+	case 0xac: // i64.extend_i32_s
+		return uint64(int32(x))
+	case 0xad: // i64.extend_i32_u
+		return uint64(uint32(x))
+	case 0xb0: // i64.trunc_f64_s
+		return truncI64(f64(x))
+	case 0xb1: // i64.trunc_f64_u
+		return truncU64(f64(x))
+	case 0xb7: // f64.convert_i32_s
+		return fromF64(float64(int32(x)))
+	case 0xb8: // f64.convert_i32_u
+		return fromF64(float64(uint32(x)))
+	case 0xb9: // f64.convert_i64_s
+		return fromF64(float64(int64(x)))
+	case 0xba: // f64.convert_i64_u
+		return fromF64(float64(x))
+	default: // i64.reinterpret_f64, f64.reinterpret_i64
+		return x
+	}
+}
+
+// truncI64 truncates the float to i64, traps if NaN or out of range
+func truncI64(f float64) uint64 {
+	if f != f {
+		throw(ErrInvalidConversion)
+	}
+	if f < math.MinInt64 || f >= 1<<63 {
+		throw(ErrIntegerOverflow)
+	}
+	return uint64(int64(f))
+}
+
+// truncU64 truncates the float to u64, traps if NaN or out of range
+func truncU64(f float64) uint64 {
+	if f != f {
+		throw(ErrInvalidConversion)
+	}
+	if f <= -1 || f >= 1<<64 {
+		throw(ErrIntegerOverflow)
+	}
+	return uint64(math.Trunc(f))
+}
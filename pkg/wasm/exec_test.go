@@ -0,0 +1,275 @@
+package wasm
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func i32Const(v int32) []byte {
+	return append([]byte{opI32Const}, sleb(int64(v))...)
+}
+
+func concat(parts ...[]byte) []byte {
+	var out []byte
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+var (
+	i32    = []ValueType{I32}
+	i32x2  = []ValueType{I32, I32}
+	i64    = []ValueType{I64}
+	f64x1  = []ValueType{F64}
+	f64x2  = []ValueType{F64, F64}
+	blockV = byte(0x40)
+)
+
+// testFuncs are the functions of test module, the index of function is the order
+var testFuncs = []testFunc{
+	// 0: add(a, b i32) i32
+	{name: "add", params: i32x2, results: i32, body: []byte{opLocalGet, 0, opLocalGet, 1, 0x6a, opEnd}},
+	// 1: fib(n i32) i32, recursive
+	{name: "fib", params: i32, results: i32, body: concat(
+		[]byte{opLocalGet, 0}, i32Const(2), []byte{0x48, opIf, byte(I32)},
+		[]byte{opLocalGet, 0},
+		[]byte{opElse},
+		[]byte{opLocalGet, 0}, i32Const(1), []byte{0x6b, opCall, 1},
+		[]byte{opLocalGet, 0}, i32Const(2), []byte{0x6b, opCall, 1, 0x6a},
+		[]byte{opEnd, opEnd})},
+	// 2: sum(n i64) i64, sums 1..n by loop
+	{name: "sum", params: i64, results: i64, locals: i64, body: []byte{
+		opBlock, blockV, opLoop, blockV,
+		opLocalGet, 0, 0x50, opBrIf, 1,
+		opLocalGet, 1, opLocalGet, 0, 0x7c, opLocalSet, 1,
+		opLocalGet, 0, opI64Const, 1, 0x7d, opLocalSet, 0,
+		opBr, 0,
+		opEnd, opEnd,
+		opLocalGet, 1, opEnd}},
+	// 3: classify(i i32) i32, returns 10/11 for 0/1, otherwise 12
+	{name: "classify", params: i32, results: i32, body: concat(
+		[]byte{opBlock, blockV, opBlock, blockV, opBlock, blockV},
+		[]byte{opLocalGet, 0, opBrTable, 2, 0, 1, 2},
+		[]byte{opEnd}, i32Const(10), []byte{opReturn},
+		[]byte{opEnd}, i32Const(11), []byte{opReturn},
+		[]byte{opEnd}, i32Const(12), []byte{opEnd})},
+	// 4: scale(ptr i32, n i32, k f64), multiplies n float64 values in memory by k
+	{name: "scale", params: []ValueType{I32, I32, F64}, body: concat(
+		[]byte{opBlock, blockV, opLoop, blockV},
+		[]byte{opLocalGet, 1, opI32Eqz, opBrIf, 1},
+		[]byte{opLocalGet, 0, opLocalGet, 0, 0x2b, 3, 0, opLocalGet, 2, 0xa2, 0x39, 3, 0},
+		[]byte{opLocalGet, 0}, i32Const(8), []byte{0x6a, opLocalSet, 0},
+		[]byte{opLocalGet, 1}, i32Const(1), []byte{0x6b, opLocalSet, 1},
+		[]byte{opBr, 0, opEnd, opEnd, opEnd})},
+	// 5: grow(pages i32) i32
+	{name: "grow", params: i32, results: i32, body: []byte{opLocalGet, 0, opMemoryGrow, 0, opEnd}},
+	// 6: size() i32
+	{name: "size", results: i32, body: []byte{opMemorySize, 0, opEnd}},
+	// 7: div(a, b i32) i32
+	{name: "div", params: i32x2, results: i32, body: []byte{opLocalGet, 0, opLocalGet, 1, 0x6d, opEnd}},
+	// 8: spin(), loops forever
+	{name: "spin", body: []byte{opLoop, blockV, opBr, 0, opEnd, opEnd}},
+	// 9: recurse(), calls itself forever
+	{name: "recurse", body: []byte{opCall, 9, opEnd}},
+	// 10: trap()
+	{name: "trap", body: []byte{opUnreachable, opEnd}},
+	// 11: load(addr i32) i32
+	{name: "load", params: i32, results: i32, body: []byte{opLocalGet, 0, opI32Load, 2, 0, opEnd}},
+	// 12: trunc(f f64) i32
+	{name: "trunc", params: f64x1, results: i32, body: []byte{opLocalGet, 0, 0xaa, opEnd}},
+	// 13: min(a, b f64) f64
+	{name: "min", params: f64x2, results: f64x1, body: []byte{opLocalGet, 0, opLocalGet, 1, 0xa4, opEnd}},
+	// 14: nearest(f f64) f64
+	{name: "nearest", params: f64x1, results: f64x1, body: []byte{opLocalGet, 0, 0x9e, opEnd}},
+	// 15: abs(a i32) i32 by select
+	{name: "abs", params: i32, results: i32, body: concat(
+		i32Const(0), []byte{opLocalGet, 0, 0x6b, opLocalGet, 0, opLocalGet, 0}, i32Const(0),
+		[]byte{0x48, opSelect, opEnd})},
+	// 16: store(addr, v i32)
+	{name: "store", params: i32x2, body: []byte{opLocalGet, 0, opLocalGet, 1, opI32Store, 2, 0, opEnd}},
+	// 17: counter() i32, increments the global
+	{name: "counter", results: i32, body: []byte{
+		opGlobalGet, 0, opI32Const, 1, 0x6a, opGlobalSet, 0, opGlobalGet, 0, opEnd}},
+}
+
+func newTestInstance(t *testing.T, cfg Config) *Instance {
+	data := testModule{
+		funcs:  testFuncs,
+		memory: []byte{0x00, 0x01},
+		data:   []byte{0x2a},
+	}.bytes()
+	// appends the mutable i32 global with init value 100 before the export section
+	m, err := Decode(insertSection(data, 7, section(6, concat([]byte{0x01, byte(I32), 0x01}, i32Const(100), []byte{opEnd}))))
+	assert.NoError(t, err)
+	vm, err := Instantiate(m, cfg)
+	assert.NoError(t, err)
+	return vm
+}
+
+// insertSection inserts the section before the section of id
+func insertSection(data []byte, id byte, s []byte) []byte {
+	r := &reader{buf: data, pos: 8}
+	for !r.eof() {
+		start := r.pos
+		sid, _ := r.byte()
+		size, _ := r.u32()
+		if sid == id {
+			return concat(data[:start], s, data[start:])
+		}
+		r.pos += int(size)
+	}
+	return append(data, s...)
+}
+
+func call(t *testing.T, vm *Instance, name string, args ...uint64) uint64 {
+	results, err := vm.Call(name, args...)
+	assert.NoError(t, err, name)
+	if len(results) == 0 {
+		return 0
+	}
+	return results[0]
+}
+
+func neg(v int32) uint64 {
+	return uint64(uint32(v))
+}
+
+func TestInstance_Call(t *testing.T) {
+	vm := newTestInstance(t, Config{MaxMemoryPages: 2, MaxInstructions: 100000})
+	assert.Equal(t, uint64(5), call(t, vm, "add", 2, 3))
+	assert.Equal(t, neg(-1), call(t, vm, "add", neg(-3), 2))
+	assert.Equal(t, uint64(610), call(t, vm, "fib", 15))
+	assert.Equal(t, uint64(5050), call(t, vm, "sum", 100))
+	assert.Equal(t, uint64(10), call(t, vm, "classify", 0))
+	assert.Equal(t, uint64(11), call(t, vm, "classify", 1))
+	assert.Equal(t, uint64(12), call(t, vm, "classify", 5))
+	assert.Equal(t, uint64(7), call(t, vm, "abs", neg(-7)))
+	assert.Equal(t, uint64(7), call(t, vm, "abs", 7))
+	assert.Equal(t, neg(-3), call(t, vm, "div", neg(-7), 2))
+	assert.Equal(t, uint64(101), call(t, vm, "counter"))
+	assert.Equal(t, uint64(102), call(t, vm, "counter"))
+
+	// memory initialized by data segment
+	assert.Equal(t, uint64(0x2a), call(t, vm, "load", 0))
+	assert.NoError(t, vm.WriteFloat64s(16, []float64{1, 2.5, -3}))
+	call(t, vm, "scale", 16, 3, fromF64(2))
+	values, err := vm.ReadFloat64s(16, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{2, 5, -6}, values)
+	call(t, vm, "store", 100, neg(-1))
+	assert.Equal(t, neg(-1), call(t, vm, "load", 100))
+
+	assert.Equal(t, uint64(1), call(t, vm, "size"))
+	assert.Equal(t, uint64(1), call(t, vm, "grow", 1))
+	assert.Equal(t, uint64(2), call(t, vm, "size"))
+	assert.Equal(t, neg(-1), call(t, vm, "grow", 1))
+	assert.Len(t, vm.Memory(), 2*PageSize)
+
+	assert.Equal(t, neg(-3), call(t, vm, "trunc", fromF64(-3.7)))
+	assert.Equal(t, 2.0, f64(call(t, vm, "nearest", fromF64(2.5))))
+	assert.Equal(t, -2.0, f64(call(t, vm, "min", fromF64(1), fromF64(-2))))
+	assert.True(t, math.IsNaN(f64(call(t, vm, "min", fromF64(1), fromF64(math.NaN())))))
+}
+
+func TestInstance_Call_trap(t *testing.T) {
+	vm := newTestInstance(t, Config{MaxInstructions: 10000, MaxCallDepth: 100})
+	cases := []struct {
+		name string
+		args []uint64
+		err  error
+	}{
+		{"div", []uint64{1, 0}, ErrDivideByZero},
+		{"div", []uint64{neg(math.MinInt32), neg(-1)}, ErrIntegerOverflow},
+		{"spin", nil, ErrOutOfFuel},
+		{"recurse", nil, ErrCallStackExhausted},
+		{"trap", nil, ErrUnreachable},
+		{"load", []uint64{PageSize - 2}, ErrMemoryOutOfBounds},
+		{"load", []uint64{math.MaxUint32}, ErrMemoryOutOfBounds},
+		{"store", []uint64{PageSize - 2, 0}, ErrMemoryOutOfBounds},
+		{"trunc", []uint64{fromF64(math.NaN())}, ErrInvalidConversion},
+		{"trunc", []uint64{fromF64(3e9)}, ErrIntegerOverflow},
+	}
+	for _, c := range cases {
+		_, err := vm.Call(c.name, c.args...)
+		assert.Equal(t, c.err, err, c.name)
+	}
+	// the instance is usable after trap
+	assert.Equal(t, uint64(3), call(t, vm, "add", 1, 2))
+
+	// the operand stack is bounded
+	vm = newTestInstance(t, Config{MaxStackSize: 100})
+	_, err := vm.Call("fib", 200)
+	assert.Equal(t, ErrCallStackExhausted, err)
+	assert.Equal(t, uint64(3), call(t, vm, "add", 1, 2))
+
+	_, err = vm.Call("not_exist")
+	assert.Error(t, err)
+	_, err = vm.Call("add", 1)
+	assert.Error(t, err)
+}
+
+func TestInstantiate(t *testing.T) {
+	m, err := Decode(testModule{
+		funcs:  []testFunc{{name: "f", body: []byte{opEnd}}},
+		memory: []byte{0x00, 0x03},
+	}.bytes())
+	assert.NoError(t, err)
+	_, err = Instantiate(m, Config{MaxMemoryPages: 2})
+	assert.Error(t, err)
+	vm, err := Instantiate(m, Config{})
+	assert.NoError(t, err)
+	assert.Len(t, vm.Memory(), 3*PageSize)
+	_, err = vm.ReadFloat64s(3*PageSize-8, 2)
+	assert.Equal(t, ErrMemoryOutOfBounds, err)
+	assert.Equal(t, ErrMemoryOutOfBounds, vm.WriteFloat64s(3*PageSize-8, []float64{1, 2}))
+}
+
+func TestNumeric(t *testing.T) {
+	vm := &Instance{config: Config{}.withDefaults()}
+	cases := []struct {
+		op   byte
+		args []uint64
+		want uint64
+	}{
+		{0x45, []uint64{0}, 1},
+		{0x48, []uint64{neg(-1), 1}, 1},
+		{0x49, []uint64{neg(-1), 1}, 0},
+		{0x4e, []uint64{2, 2}, 1},
+		{0x53, []uint64{math.MaxUint64, 1}, 1},
+		{0x54, []uint64{math.MaxUint64, 1}, 0},
+		{0x62, []uint64{fromF64(math.NaN()), fromF64(math.NaN())}, 1},
+		{0x67, []uint64{1}, 31},
+		{0x68, []uint64{8}, 3},
+		{0x69, []uint64{0xff}, 8},
+		{0x6c, []uint64{neg(-2), 3}, neg(-6)},
+		{0x6f, []uint64{neg(-7), 2}, neg(-1)},
+		{0x6f, []uint64{neg(math.MinInt32), neg(-1)}, 0},
+		{0x74, []uint64{1, 33}, 2},
+		{0x75, []uint64{neg(-8), 1}, neg(-4)},
+		{0x76, []uint64{neg(-8), 28}, 0xf},
+		{0x77, []uint64{0x80000000, 1}, 1},
+		{0x78, []uint64{1, 1}, 0x80000000},
+		{0x79, []uint64{1}, 63},
+		{0x7f, []uint64{uint64(math.MaxUint64 - 6), 2}, uint64(math.MaxUint64 - 2)},
+		{0x87, []uint64{1 << 63, 63}, math.MaxUint64},
+		{0x8a, []uint64{1, 1}, 1 << 63},
+		{0x9a, []uint64{fromF64(1)}, fromF64(-1)},
+		{0x9c, []uint64{fromF64(-1.5)}, fromF64(-2)},
+		{0xa5, []uint64{fromF64(1), fromF64(2)}, fromF64(2)},
+		{0xa6, []uint64{fromF64(-1), fromF64(2)}, fromF64(1)},
+		{0xa7, []uint64{1<<32 + 1}, 1},
+		{0xac, []uint64{neg(-1)}, math.MaxUint64},
+		{0xad, []uint64{neg(-1)}, math.MaxUint32},
+		{0xb0, []uint64{fromF64(-2.5)}, uint64(math.MaxUint64 - 1)},
+		{0xb1, []uint64{fromF64(1e19)}, 1e19},
+		{0xb9, []uint64{math.MaxUint64}, fromF64(-1)},
+	}
+	for _, c := range cases {
+		vm.stack = append(vm.stack[:0], c.args...)
+		vm.numeric(c.op)
+		assert.Equal(t, []uint64{c.want}, vm.stack, "op 0x%x", c.op)
+	}
+}
@@ -0,0 +1,183 @@
+package wasm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+)
+
+// PageSize is the size(bytes) of memory page
+const PageSize = 64 * 1024
+
+// Defines the default resource limits of instance
+const (
+	defaultMaxMemoryPages  = 256 // 16MiB
+	defaultMaxInstructions = 100 * 1000 * 1000
+	defaultMaxCallDepth    = 1000
+	defaultMaxStackSize    = 1024 * 1024
+)
+
+// Defines the traps of execution
+var (
+	ErrOutOfFuel          = errors.New("instruction limit exceeded")
+	ErrCallStackExhausted = errors.New("call stack exhausted")
+	ErrUnreachable        = errors.New("unreachable executed")
+	ErrMemoryOutOfBounds  = errors.New("out of bounds memory access")
+	ErrDivideByZero       = errors.New("integer divide by zero")
+	ErrIntegerOverflow    = errors.New("integer overflow")
+	ErrInvalidConversion  = errors.New("invalid conversion to integer")
+)
+
+// Config represents the resource limits of instance, zero means the default limit
+type Config struct {
+	// MaxMemoryPages is the max pages(64KiB) of memory, includes the initial pages and grown pages
+	MaxMemoryPages uint32
+	// MaxInstructions is the max number of instructions executed per call
+	MaxInstructions int64
+	// MaxCallDepth is the max depth of nested function calls
+	MaxCallDepth int
+	// MaxStackSize is the max number of values on stack, includes the locals of functions
+	MaxStackSize int
+}
+
+// withDefaults returns the config with the default limits filled
+func (c Config) withDefaults() Config {
+	if c.MaxMemoryPages == 0 {
+		c.MaxMemoryPages = defaultMaxMemoryPages
+	}
+	if c.MaxInstructions == 0 {
+		c.MaxInstructions = defaultMaxInstructions
+	}
+	if c.MaxCallDepth == 0 {
+		c.MaxCallDepth = defaultMaxCallDepth
+	}
+	if c.MaxStackSize == 0 {
+		c.MaxStackSize = defaultMaxStackSize
+	}
+	return c
+}
+
+// Instance represents the instantiated module, which has its own memory and globals.
+// The functions of instance can only access the state of instance, the resources used by calls are bounded
+// by config, the traps and the malformed code stop the call with error instead of crashing the process.
+// NOTICE: instance isn't thread-safe.
+type Instance struct {
+	module   *Module
+	config   Config
+	memory   []byte
+	maxPages uint32
+	globals  []uint64
+	stack    []uint64
+	fuel     int64
+	depth    int
+}
+
+// Instantiate creates the instance of module, initializes the memory by data segments
+func Instantiate(m *Module, cfg Config) (*Instance, error) {
+	cfg = cfg.withDefaults()
+	vm := &Instance{
+		module:  m,
+		config:  cfg,
+		globals: make([]uint64, len(m.Globals)),
+	}
+	for i, g := range m.Globals {
+		vm.globals[i] = g.Init
+	}
+	if m.Memory != nil {
+		if m.Memory.Min > cfg.MaxMemoryPages {
+			return nil, fmt.Errorf("initial memory %d pages exceeds limit %d pages", m.Memory.Min, cfg.MaxMemoryPages)
+		}
+		vm.maxPages = cfg.MaxMemoryPages
+		if m.Memory.HasMax && m.Memory.Max < vm.maxPages {
+			vm.maxPages = m.Memory.Max
+		}
+		vm.memory = make([]byte, int(m.Memory.Min)*PageSize)
+		for _, seg := range m.data {
+			if uint64(seg.offset)+uint64(len(seg.data)) > uint64(len(vm.memory)) {
+				return nil, fmt.Errorf("data segment at %d out of memory", seg.offset)
+			}
+			copy(vm.memory[seg.offset:], seg.data)
+		}
+	}
+	return vm, nil
+}
+
+// Call calls the exported function by name with the raw values of args, returns the raw values of results.
+// The i32/i64 value is the bits of integer, the f64 value is the bits of IEEE 754 float.
+func (vm *Instance) Call(name string, args ...uint64) ([]uint64, error) {
+	export, ok := vm.module.Exports[name]
+	if !ok || export.Kind != ExportFunc {
+		return nil, fmt.Errorf("function[%s] not exported", name)
+	}
+	if n := len(vm.module.Functions[export.Index].Type.Params); n != len(args) {
+		return nil, fmt.Errorf("function[%s] expects %d args, but got %d", name, n, len(args))
+	}
+	return vm.invoke(export.Index, args)
+}
+
+// Memory returns the memory of instance, the slice is changed if memory grows
+func (vm *Instance) Memory() []byte {
+	return vm.memory
+}
+
+// ReadFloat64s reads the float64 values from memory at offset
+func (vm *Instance) ReadFloat64s(offset uint32, n int) ([]float64, error) {
+	if n < 0 || uint64(offset)+uint64(n)*8 > uint64(len(vm.memory)) {
+		return nil, ErrMemoryOutOfBounds
+	}
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = math.Float64frombits(binary.LittleEndian.Uint64(vm.memory[int(offset)+i*8:]))
+	}
+	return values, nil
+}
+
+// WriteFloat64s writes the float64 values into memory at offset
+func (vm *Instance) WriteFloat64s(offset uint32, values []float64) error {
+	if uint64(offset)+uint64(len(values))*8 > uint64(len(vm.memory)) {
+		return ErrMemoryOutOfBounds
+	}
+	for i, v := range values {
+		binary.LittleEndian.PutUint64(vm.memory[int(offset)+i*8:], math.Float64bits(v))
+	}
+	return nil
+}
+
+// invoke calls the function with fresh fuel, recovers the traps
+func (vm *Instance) invoke(idx uint32, args []uint64) (results []uint64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch e := r.(type) {
+			case trap:
+				err = e.err
+			case runtime.Error:
+				// the code is validated when decoding, the runtime error means the bug of interpreter,
+				// which stops the call instead of crashing the process
+				err = fmt.Errorf("invalid code:%s", e)
+			default:
+				panic(r)
+			}
+		}
+		vm.stack = vm.stack[:0]
+		vm.depth = 0
+	}()
+	vm.fuel = vm.config.MaxInstructions
+	vm.stack = append(vm.stack[:0], args...)
+	vm.call(idx)
+	n := len(vm.module.Functions[idx].Type.Results)
+	results = make([]uint64, n)
+	copy(results, vm.stack[len(vm.stack)-n:])
+	return results, nil
+}
+
+// trap represents the error which stops the execution
+type trap struct {
+	err error
+}
+
+// throw stops the execution with error
+func throw(err error) {
+	panic(trap{err: err})
+}
@@ -0,0 +1,591 @@
+package wasm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ValueType represents the type of value in WebAssembly
+type ValueType byte
+
+// Defines the value types supported, f32 of WebAssembly MVP isn't supported
+const (
+	I32 ValueType = 0x7f
+	I64 ValueType = 0x7e
+	F64 ValueType = 0x7c
+)
+
+// Defines the kinds of export supported, table isn't supported
+const (
+	ExportFunc   byte = 0x00
+	ExportMemory byte = 0x02
+	ExportGlobal byte = 0x03
+)
+
+const (
+	magic   = 0x6d736100 // \0asm
+	version = 1
+	// maxLocals is the max number of locals of function, which bounds the memory allocated per call
+	maxLocals = 50000
+)
+
+// errUnexpectedEOF represents the module binary is truncated
+var errUnexpectedEOF = errors.New("unexpected end of module")
+
+// FuncType represents the signature of function
+type FuncType struct {
+	Params  []ValueType
+	Results []ValueType
+}
+
+// Equal checks if the signatures are the same
+func (t FuncType) Equal(o FuncType) bool {
+	if len(t.Params) != len(o.Params) || len(t.Results) != len(o.Results) {
+		return false
+	}
+	for i := range t.Params {
+		if t.Params[i] != o.Params[i] {
+			return false
+		}
+	}
+	for i := range t.Results {
+		if t.Results[i] != o.Results[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Limits represents the min/max size of memory(pages)
+type Limits struct {
+	Min    uint32
+	Max    uint32
+	HasMax bool
+}
+
+// Global represents the global variable with the initial value
+type Global struct {
+	Type    ValueType
+	Mutable bool
+	Init    uint64
+}
+
+// Export represents the exported item of module
+type Export struct {
+	Kind  byte
+	Index uint32
+}
+
+// Function represents the function defined in module, the code is validated and compiled into instructions
+// when decoding
+type Function struct {
+	Type   FuncType
+	Locals []ValueType
+	code   []instruction
+	// maxStack is the max height of operand stack of function, excludes the locals
+	maxStack int
+}
+
+// dataSegment represents the bytes initialized into memory at offset
+type dataSegment struct {
+	offset uint32
+	data   []byte
+}
+
+// Module represents the decoded WebAssembly module. The module is self-contained, imports are not supported,
+// so that the functions of module can only access the memory/globals of its own instance.
+type Module struct {
+	Types     []FuncType
+	Functions []Function
+	Memory    *Limits
+	Globals   []Global
+	Exports   map[string]Export
+	data      []dataSegment
+}
+
+// Decode decodes the WebAssembly module from binary format, then compiles the code of functions,
+// returns error if the module is malformed or uses the features which aren't supported.
+// Only the subset of WebAssembly MVP which the numeric functions need is supported: i32/i64/f64 values,
+// the structured control flow with direct calls, locals/globals, the full width load/store of memory and
+// the numeric instructions of the values(see compile.go). The imports, tables, start function, f32 and
+// the post-MVP proposals(e.g. bulk memory, sign extension, non-trapping conversion) aren't supported,
+// e.g. the module compiled by clang/rustc needs the target features of these proposals disabled.
+func Decode(data []byte) (*Module, error) {
+	r := &reader{buf: data}
+	m, err := decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("decode wasm module error at offset %d:%s", r.pos, err)
+	}
+	return m, nil
+}
+
+// ExportedFunc returns the signature of exported function by name, returns false if not exist
+func (m *Module) ExportedFunc(name string) (FuncType, bool) {
+	export, ok := m.Exports[name]
+	if !ok || export.Kind != ExportFunc {
+		return FuncType{}, false
+	}
+	return m.Functions[export.Index].Type, true
+}
+
+func decode(r *reader) (*Module, error) {
+	head, err := r.bytes(8)
+	if err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint32(head) != magic {
+		return nil, errors.New("invalid magic number")
+	}
+	if v := binary.LittleEndian.Uint32(head[4:]); v != version {
+		return nil, fmt.Errorf("unsupported version %d", v)
+	}
+	m := &Module{Exports: make(map[string]Export)}
+	var funcTypes []uint32
+	var codes [][]byte
+	for !r.eof() {
+		id, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		size, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		payload, err := r.bytes(int(size))
+		if err != nil {
+			return nil, err
+		}
+		s := &reader{buf: payload, base: r.pos - int(size)}
+		switch id {
+		case 0: // custom section is ignored
+		case 1:
+			err = decodeTypes(s, m)
+		case 2:
+			err = errors.New("imports are not supported")
+		case 3:
+			funcTypes, err = decodeFunctions(s, m)
+		case 4:
+			err = errors.New("tables are not supported")
+		case 5:
+			err = decodeMemory(s, m)
+		case 6:
+			err = decodeGlobals(s, m)
+		case 7:
+			err = decodeExports(s, m)
+		case 8:
+			err = errors.New("start function is not supported")
+		case 9:
+			err = errors.New("element segments are not supported")
+		case 10:
+			codes, err = decodeCodes(s)
+		case 11:
+			err = decodeData(s, m)
+		default:
+			err = fmt.Errorf("unknown section %d", id)
+		}
+		if err != nil {
+			r.pos = s.base + s.pos
+			return nil, err
+		}
+	}
+	if len(funcTypes) != len(codes) {
+		return nil, fmt.Errorf("function count %d mismatches code count %d", len(funcTypes), len(codes))
+	}
+	m.Functions = make([]Function, len(funcTypes))
+	for i, typeIdx := range funcTypes {
+		m.Functions[i].Type = m.Types[typeIdx]
+	}
+	for i, body := range codes {
+		if err := compileFunction(m, &m.Functions[i], body); err != nil {
+			return nil, fmt.Errorf("function[%d]:%s", i, err)
+		}
+	}
+	return m, validateModule(m)
+}
+
+// validateModule checks the indexes referenced by module
+func validateModule(m *Module) error {
+	for name, export := range m.Exports {
+		var count int
+		switch export.Kind {
+		case ExportFunc:
+			count = len(m.Functions)
+		case ExportGlobal:
+			count = len(m.Globals)
+		case ExportMemory:
+			if m.Memory != nil {
+				count = 1
+			}
+		default:
+			return fmt.Errorf("unsupported kind of export[%s]", name)
+		}
+		if int(export.Index) >= count {
+			return fmt.Errorf("export[%s] references index %d out of range", name, export.Index)
+		}
+	}
+	if len(m.data) > 0 && m.Memory == nil {
+		return errors.New("data segment without memory")
+	}
+	return nil
+}
+
+func decodeTypes(r *reader, m *Module) error {
+	count, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < count; i++ {
+		form, err := r.byte()
+		if err != nil {
+			return err
+		}
+		if form != 0x60 {
+			return fmt.Errorf("invalid form of function type 0x%x", form)
+		}
+		params, err := r.valueTypes()
+		if err != nil {
+			return err
+		}
+		results, err := r.valueTypes()
+		if err != nil {
+			return err
+		}
+		m.Types = append(m.Types, FuncType{Params: params, Results: results})
+	}
+	return nil
+}
+
+func decodeFunctions(r *reader, m *Module) ([]uint32, error) {
+	count, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	var funcTypes []uint32
+	for i := uint32(0); i < count; i++ {
+		idx, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		if int(idx) >= len(m.Types) {
+			return nil, fmt.Errorf("type index %d out of range", idx)
+		}
+		funcTypes = append(funcTypes, idx)
+	}
+	return funcTypes, nil
+}
+
+func decodeMemory(r *reader, m *Module) error {
+	count, err := r.u32()
+	if err != nil {
+		return err
+	}
+	if count > 1 {
+		return errors.New("multiple memories are not supported")
+	}
+	if count == 0 {
+		return nil
+	}
+	limits, err := r.limits()
+	if err != nil {
+		return err
+	}
+	m.Memory = &limits
+	return nil
+}
+
+func decodeGlobals(r *reader, m *Module) error {
+	count, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < count; i++ {
+		t, err := r.valueType()
+		if err != nil {
+			return err
+		}
+		mutable, err := r.byte()
+		if err != nil {
+			return err
+		}
+		init, err := r.constExpr(m)
+		if err != nil {
+			return err
+		}
+		m.Globals = append(m.Globals, Global{Type: t, Mutable: mutable == 1, Init: init})
+	}
+	return nil
+}
+
+func decodeExports(r *reader, m *Module) error {
+	count, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < count; i++ {
+		name, err := r.name()
+		if err != nil {
+			return err
+		}
+		kind, err := r.byte()
+		if err != nil {
+			return err
+		}
+		idx, err := r.u32()
+		if err != nil {
+			return err
+		}
+		if _, ok := m.Exports[name]; ok {
+			return fmt.Errorf("duplicate export[%s]", name)
+		}
+		m.Exports[name] = Export{Kind: kind, Index: idx}
+	}
+	return nil
+}
+
+func decodeCodes(r *reader) ([][]byte, error) {
+	count, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	var codes [][]byte
+	for i := uint32(0); i < count; i++ {
+		size, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		body, err := r.bytes(int(size))
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, body)
+	}
+	return codes, nil
+}
+
+func decodeData(r *reader, m *Module) error {
+	count, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < count; i++ {
+		flag, err := r.u32()
+		if err != nil {
+			return err
+		}
+		if flag != 0 {
+			return fmt.Errorf("unsupported data segment flag %d", flag)
+		}
+		offset, err := r.constExpr(m)
+		if err != nil {
+			return err
+		}
+		n, err := r.u32()
+		if err != nil {
+			return err
+		}
+		data, err := r.bytes(int(n))
+		if err != nil {
+			return err
+		}
+		m.data = append(m.data, dataSegment{offset: uint32(offset), data: data})
+	}
+	return nil
+}
+
+// reader reads the module binary
+type reader struct {
+	buf  []byte
+	pos  int
+	base int
+}
+
+func (r *reader) eof() bool {
+	return r.pos >= len(r.buf)
+}
+
+func (r *reader) byte() (byte, error) {
+	if r.pos >= len(r.buf) {
+		return 0, errUnexpectedEOF
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *reader) bytes(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.buf) {
+		return nil, errUnexpectedEOF
+	}
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+// u32 reads the unsigned LEB128 integer of 32 bits
+func (r *reader) u32() (uint32, error) {
+	var result uint32
+	for shift := uint(0); shift < 35; shift += 7 {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		result |= uint32(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return result, nil
+		}
+	}
+	return 0, errors.New("invalid LEB128 of u32")
+}
+
+// signed reads the signed LEB128 integer of bits
+func (r *reader) signed(bits uint) (int64, error) {
+	var result int64
+	var shift uint
+	for {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		result |= int64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			if shift < 64 && b&0x40 != 0 {
+				result |= -1 << shift
+			}
+			return result, nil
+		}
+		if shift >= bits+7 {
+			return 0, fmt.Errorf("invalid LEB128 of s%d", bits)
+		}
+	}
+}
+
+func (r *reader) f64() (uint64, error) {
+	b, err := r.bytes(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+func (r *reader) name() (string, error) {
+	n, err := r.u32()
+	if err != nil {
+		return "", err
+	}
+	b, err := r.bytes(int(n))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (r *reader) valueType() (ValueType, error) {
+	b, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+	switch t := ValueType(b); t {
+	case I32, I64, F64:
+		return t, nil
+	default:
+		return 0, fmt.Errorf("unsupported value type 0x%x", b)
+	}
+}
+
+func (r *reader) valueTypes() ([]ValueType, error) {
+	count, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	if int(count) > len(r.buf)-r.pos {
+		return nil, errUnexpectedEOF
+	}
+	types := make([]ValueType, count)
+	for i := range types {
+		if types[i], err = r.valueType(); err != nil {
+			return nil, err
+		}
+	}
+	return types, nil
+}
+
+func (r *reader) limits() (Limits, error) {
+	flag, err := r.byte()
+	if err != nil {
+		return Limits{}, err
+	}
+	min, err := r.u32()
+	if err != nil {
+		return Limits{}, err
+	}
+	limits := Limits{Min: min}
+	switch flag {
+	case 0x00:
+	case 0x01:
+		if limits.Max, err = r.u32(); err != nil {
+			return Limits{}, err
+		}
+		if limits.Max < limits.Min {
+			return Limits{}, errors.New("max of limits is less than min")
+		}
+		limits.HasMax = true
+	default:
+		return Limits{}, fmt.Errorf("invalid flag 0x%x of limits", flag)
+	}
+	return limits, nil
+}
+
+// constExpr reads the constant expression of global/element/data, only const instruction and
+// global.get of global defined before are supported
+func (r *reader) constExpr(m *Module) (uint64, error) {
+	op, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+	var value uint64
+	switch op {
+	case opI32Const:
+		v, err := r.signed(32)
+		if err != nil {
+			return 0, err
+		}
+		value = uint64(uint32(v))
+	case opI64Const:
+		v, err := r.signed(64)
+		if err != nil {
+			return 0, err
+		}
+		value = uint64(v)
+	case opF64Const:
+		if value, err = r.f64(); err != nil {
+			return 0, err
+		}
+	case opGlobalGet:
+		idx, err := r.u32()
+		if err != nil {
+			return 0, err
+		}
+		if int(idx) >= len(m.Globals) {
+			return 0, fmt.Errorf("global index %d out of range", idx)
+		}
+		value = m.Globals[idx].Init
+	default:
+		return 0, fmt.Errorf("unsupported instruction 0x%x in constant expression", op)
+	}
+	end, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+	if end != opEnd {
+		return 0, errors.New("constant expression isn't terminated by end")
+	}
+	return value, nil
+}
+
+// f64 helpers convert between the float64 and raw value
+func f64(v uint64) float64 { return math.Float64frombits(v) }
+
+func fromF64(v float64) uint64 { return math.Float64bits(v) }
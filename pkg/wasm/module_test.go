@@ -0,0 +1,193 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testFunc represents the function of test module, the body includes the end of function
+type testFunc struct {
+	name    string
+	params  []ValueType
+	results []ValueType
+	locals  []ValueType
+	// rawLocals replaces the encoded locals if not nil
+	rawLocals []byte
+	body      []byte
+}
+
+// testModule represents the module assembled for test, each function has its own type
+type testModule struct {
+	funcs  []testFunc
+	memory []byte // limits of memory
+	data   []byte
+}
+
+func (m testModule) bytes() []byte {
+	out := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	types := uleb(uint64(len(m.funcs)))
+	funcs := uleb(uint64(len(m.funcs)))
+	codes := uleb(uint64(len(m.funcs)))
+	var exports []byte
+	exportCount := 0
+	for i, f := range m.funcs {
+		types = append(types, 0x60)
+		types = append(types, valueTypes(f.params)...)
+		types = append(types, valueTypes(f.results)...)
+		funcs = append(funcs, uleb(uint64(i))...)
+		body := f.rawLocals
+		if body == nil {
+			body = uleb(uint64(len(f.locals)))
+			for _, t := range f.locals {
+				body = append(body, 0x01, byte(t))
+			}
+		}
+		body = append(body, f.body...)
+		codes = append(codes, uleb(uint64(len(body)))...)
+		codes = append(codes, body...)
+		if f.name != "" {
+			exports = append(exports, name(f.name)...)
+			exports = append(exports, ExportFunc)
+			exports = append(exports, uleb(uint64(i))...)
+			exportCount++
+		}
+	}
+	if m.memory != nil {
+		exports = append(exports, name("memory")...)
+		exports = append(exports, ExportMemory, 0x00)
+		exportCount++
+	}
+	out = append(out, section(1, types)...)
+	out = append(out, section(3, funcs)...)
+	if m.memory != nil {
+		out = append(out, section(5, append([]byte{0x01}, m.memory...))...)
+	}
+	out = append(out, section(7, append(uleb(uint64(exportCount)), exports...))...)
+	out = append(out, section(10, codes)...)
+	if m.data != nil {
+		data := []byte{0x01, 0x00, opI32Const, 0x00, opEnd}
+		data = append(data, uleb(uint64(len(m.data)))...)
+		data = append(data, m.data...)
+		out = append(out, section(11, data)...)
+	}
+	return out
+}
+
+func section(id byte, payload []byte) []byte {
+	return append(append([]byte{id}, uleb(uint64(len(payload)))...), payload...)
+}
+
+func name(s string) []byte {
+	return append(uleb(uint64(len(s))), s...)
+}
+
+func valueTypes(types []ValueType) []byte {
+	out := uleb(uint64(len(types)))
+	for _, t := range types {
+		out = append(out, byte(t))
+	}
+	return out
+}
+
+func uleb(v uint64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if v == 0 {
+			return out
+		}
+	}
+}
+
+func sleb(v int64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	m, err := Decode(testModule{
+		funcs: []testFunc{{name: "add", params: []ValueType{I32, I32}, results: []ValueType{I32},
+			body: []byte{opLocalGet, 0, opLocalGet, 1, 0x6a, opEnd}}},
+		memory: []byte{0x01, 0x01, 0x02},
+		data:   []byte{1, 2, 3},
+	}.bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, &Limits{Min: 1, Max: 2, HasMax: true}, m.Memory)
+	funcType, ok := m.ExportedFunc("add")
+	assert.True(t, ok)
+	assert.Equal(t, FuncType{Params: []ValueType{I32, I32}, Results: []ValueType{I32}}, funcType)
+	_, ok = m.ExportedFunc("memory")
+	assert.False(t, ok)
+	_, ok = m.ExportedFunc("sub")
+	assert.False(t, ok)
+	// the max height of operand stack is computed when validating
+	assert.Equal(t, 2, m.Functions[0].maxStack)
+
+	// the stack of unreachable code is polymorphic
+	_, err = Decode(testModule{funcs: []testFunc{{results: []ValueType{I32},
+		body: []byte{opUnreachable, 0x6a, opEnd}}}}.bytes())
+	assert.NoError(t, err)
+}
+
+func TestDecode_invalid(t *testing.T) {
+	valid := testModule{funcs: []testFunc{{name: "f", body: []byte{opEnd}}}}.bytes()
+	cases := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"magic", append([]byte{0x01}, valid[1:]...)},
+		{"version", append(append([]byte{}, valid[:4]...), 0x02, 0x00, 0x00, 0x00)},
+		{"truncated", valid[:len(valid)-1]},
+		{"imports", append(append([]byte{}, valid[:8]...), section(2, []byte{0x00})...)},
+		{"table", append(append([]byte{}, valid[:8]...), section(4, []byte{0x01, 0x70, 0x00, 0x01})...)},
+		{"start", append(append([]byte{}, valid...), section(8, []byte{0x00})...)},
+		{"elements", append(append([]byte{}, valid...), section(9, []byte{0x00})...)},
+		{"data count", append(append([]byte{}, valid...), section(12, []byte{0x00})...)},
+		{"f32 type", testModule{funcs: []testFunc{{params: []ValueType{0x7d}, body: []byte{opEnd}}}}.bytes()},
+		{"f32 local", testModule{funcs: []testFunc{{locals: []ValueType{0x7d}, body: []byte{opEnd}}}}.bytes()},
+		{"unknown section", append(append([]byte{}, valid...), section(13, nil)...)},
+		{"missing end", testModule{funcs: []testFunc{{body: []byte{opNop}}}}.bytes()},
+		{"code after end", testModule{funcs: []testFunc{{body: []byte{opEnd, opNop}}}}.bytes()},
+		{"unsupported opcode", testModule{funcs: []testFunc{{body: []byte{0x06, opEnd}}}}.bytes()},
+		{"else without if", testModule{funcs: []testFunc{{body: []byte{opElse, opEnd}}}}.bytes()},
+		{"label out of range", testModule{funcs: []testFunc{{body: []byte{opBr, 1, opEnd}}}}.bytes()},
+		{"function out of range", testModule{funcs: []testFunc{{body: []byte{opCall, 1, opEnd}}}}.bytes()},
+		{"local out of range", testModule{funcs: []testFunc{{body: []byte{opLocalGet, 0, opEnd}}}}.bytes()},
+		{"memory not defined", testModule{funcs: []testFunc{{body: []byte{opMemorySize, 0, opEnd}}}}.bytes()},
+		{"data without memory", testModule{funcs: []testFunc{{body: []byte{opEnd}}}, data: []byte{1}}.bytes()},
+		{"too many locals", testModule{funcs: []testFunc{{
+			rawLocals: append(append([]byte{0x01}, uleb(maxLocals+1)...), byte(I32)), body: []byte{opEnd}}}}.bytes()},
+		{"type mismatch", testModule{funcs: []testFunc{{body: []byte{opI64Const, 0, opI32Eqz, opDrop, opEnd}}}}.bytes()},
+		{"stack underflow", testModule{funcs: []testFunc{{body: []byte{opI32Const, 0, 0x6a, opDrop, opEnd}}}}.bytes()},
+		{"missing result", testModule{funcs: []testFunc{{results: []ValueType{I32}, body: []byte{opEnd}}}}.bytes()},
+		{"operands left", testModule{funcs: []testFunc{{body: []byte{opI32Const, 0, opEnd}}}}.bytes()},
+		{"local type mismatch", testModule{funcs: []testFunc{{params: []ValueType{I32},
+			body: []byte{opI64Const, 0, opLocalSet, 0, opEnd}}}}.bytes()},
+		{"select type mismatch", testModule{funcs: []testFunc{{
+			body: []byte{opI32Const, 0, opI64Const, 0, opI32Const, 1, opSelect, opDrop, opEnd}}}}.bytes()},
+		{"if without else", testModule{funcs: []testFunc{{
+			body: []byte{opI32Const, 1, opIf, byte(I32), opI32Const, 1, opEnd, opDrop, opEnd}}}}.bytes()},
+		{"branch type mismatch", testModule{funcs: []testFunc{{
+			body: []byte{opBlock, byte(I32), opI64Const, 0, opBr, 0, opEnd, opDrop, opEnd}}}}.bytes()},
+		{"call args mismatch", testModule{funcs: []testFunc{{params: []ValueType{I32},
+			body: []byte{opI64Const, 0, opCall, 0, opEnd}}}}.bytes()},
+	}
+	for _, c := range cases {
+		_, err := Decode(c.data)
+		assert.Error(t, err, c.name)
+	}
+}
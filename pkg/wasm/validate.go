@@ -0,0 +1,207 @@
+package wasm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// unknownType is the type of operand popped from the stack of unreachable code, which matches any type
+const unknownType ValueType = 0
+
+// ctrlFrame represents the block/loop/if/else or the function body when validating
+type ctrlFrame struct {
+	op      byte
+	params  []ValueType
+	results []ValueType
+	// height is the height of operand stack when entering the block, excludes the params of block
+	height int
+	// unreachable is true after the unconditional branch(e.g. br, return, unreachable),
+	// the stack of unreachable code is polymorphic
+	unreachable bool
+}
+
+// validator checks the types of operands by the abstract operand stack of function(see the validation algorithm
+// of WebAssembly spec), and computes the max height of operand stack which bounds the stack used per call.
+type validator struct {
+	vals      []ValueType
+	ctrls     []ctrlFrame
+	maxHeight int
+}
+
+// push pushes the operand of type
+func (v *validator) push(t ValueType) {
+	v.vals = append(v.vals, t)
+	if len(v.vals) > v.maxHeight {
+		v.maxHeight = len(v.vals)
+	}
+}
+
+// pushes pushes the operands of types in order
+func (v *validator) pushes(types []ValueType) {
+	for _, t := range types {
+		v.push(t)
+	}
+}
+
+// pop pops the operand, returns unknown type if the stack of unreachable code is empty
+func (v *validator) pop() (ValueType, error) {
+	frame := &v.ctrls[len(v.ctrls)-1]
+	if len(v.vals) == frame.height {
+		if frame.unreachable {
+			return unknownType, nil
+		}
+		return 0, errors.New("operand stack underflow")
+	}
+	t := v.vals[len(v.vals)-1]
+	v.vals = v.vals[:len(v.vals)-1]
+	return t, nil
+}
+
+// popExpect pops the operand of expected type, returns error if the type mismatches
+func (v *validator) popExpect(expect ValueType) (ValueType, error) {
+	t, err := v.pop()
+	if err != nil {
+		return 0, err
+	}
+	if t != expect && t != unknownType && expect != unknownType {
+		return 0, fmt.Errorf("type mismatch, expected 0x%x but got 0x%x", expect, t)
+	}
+	return t, nil
+}
+
+// pops pops the operands of types in reverse order
+func (v *validator) pops(types []ValueType) error {
+	for i := len(types) - 1; i >= 0; i-- {
+		if _, err := v.popExpect(types[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// operate pops the params, then pushes the result if it isn't unknown type
+func (v *validator) operate(params []ValueType, result ValueType) error {
+	if err := v.pops(params); err != nil {
+		return err
+	}
+	if result != unknownType {
+		v.push(result)
+	}
+	return nil
+}
+
+// pushCtrl enters the block with params/results, the params are pushed as the operands of block
+func (v *validator) pushCtrl(op byte, params, results []ValueType) {
+	v.ctrls = append(v.ctrls, ctrlFrame{op: op, params: params, results: results, height: len(v.vals)})
+	v.pushes(params)
+}
+
+// popCtrl exits the block, the operands left must be the results of block
+func (v *validator) popCtrl() (ctrlFrame, error) {
+	frame := v.ctrls[len(v.ctrls)-1]
+	if err := v.pops(frame.results); err != nil {
+		return frame, err
+	}
+	if len(v.vals) != frame.height {
+		return frame, errors.New("operands left at the end of block")
+	}
+	v.ctrls = v.ctrls[:len(v.ctrls)-1]
+	return frame, nil
+}
+
+// labelTypes returns the types of operands passed by branch to the label of depth,
+// the params for loop, the results for others
+func (v *validator) labelTypes(depth uint64) []ValueType {
+	frame := v.ctrls[len(v.ctrls)-1-int(depth)]
+	if frame.op == opLoop {
+		return frame.params
+	}
+	return frame.results
+}
+
+// setUnreachable marks the code after unconditional branch as unreachable, drops the operands of block
+func (v *validator) setUnreachable() {
+	frame := &v.ctrls[len(v.ctrls)-1]
+	v.vals = v.vals[:frame.height]
+	frame.unreachable = true
+}
+
+// Defines the operand types of instructions
+var (
+	typesI32   = []ValueType{I32}
+	typesI64   = []ValueType{I64}
+	typesF64   = []ValueType{F64}
+	typesI32x2 = []ValueType{I32, I32}
+	typesI64x2 = []ValueType{I64, I64}
+	typesF64x2 = []ValueType{F64, F64}
+)
+
+// numericSignature returns the params and result of numeric instruction, op is in [i32.eqz, f64.reinterpret_i64],
+// returns false if the instruction operates on f32 which isn't supported
+func numericSignature(op byte) (params []ValueType, result ValueType, ok bool) {
+	switch {
+	case op == 0x45: // i32.eqz
+		return typesI32, I32, true
+	case op <= 0x4f: // i32 comparison
+		return typesI32x2, I32, true
+	case op == 0x50: // i64.eqz
+		return typesI64, I32, true
+	case op <= 0x5a: // i64 comparison
+		return typesI64x2, I32, true
+	case op <= 0x60: // f32 comparison
+		return nil, 0, false
+	case op <= 0x66: // f64 comparison
+		return typesF64x2, I32, true
+	case op <= 0x69: // i32 unary
+		return typesI32, I32, true
+	case op <= 0x78: // i32 binary
+		return typesI32x2, I32, true
+	case op <= 0x7b: // i64 unary
+		return typesI64, I64, true
+	case op <= 0x8a: // i64 binary
+		return typesI64x2, I64, true
+	case op <= 0x98: // f32 unary/binary
+		return nil, 0, false
+	case op <= 0x9f: // f64 unary
+		return typesF64, F64, true
+	case op <= 0xa6: // f64 binary
+		return typesF64x2, F64, true
+	case op == 0xa7: // i32.wrap_i64
+		return typesI64, I32, true
+	case op == 0xaa, op == 0xab: // i32.trunc_f64_s/u
+		return typesF64, I32, true
+	case op == 0xac, op == 0xad: // i64.extend_i32_s/u
+		return typesI32, I64, true
+	case op == 0xb0, op == 0xb1: // i64.trunc_f64_s/u
+		return typesF64, I64, true
+	case op == 0xb7, op == 0xb8: // f64.convert_i32_s/u
+		return typesI32, F64, true
+	case op == 0xb9, op == 0xba: // f64.convert_i64_s/u
+		return typesI64, F64, true
+	case op == 0xbd: // i64.reinterpret_f64
+		return typesF64, I64, true
+	case op == 0xbf: // f64.reinterpret_i64
+		return typesI64, F64, true
+	default: // the conversions from/to f32
+		return nil, 0, false
+	}
+}
+
+// memorySignature returns the params and result of load/store instruction, op is one of the full width
+// load/store of i32/i64/f64
+func memorySignature(op byte) (params []ValueType, result ValueType) {
+	switch op {
+	case opI32Load:
+		return typesI32, I32
+	case opI64Load:
+		return typesI32, I64
+	case opF64Load:
+		return typesI32, F64
+	case opI32Store:
+		return typesI32x2, unknownType
+	case opI64Store:
+		return []ValueType{I32, I64}, unknownType
+	default: // f64.store
+		return []ValueType{I32, F64}, unknownType
+	}
+}
@@ -0,0 +1,254 @@
+package query
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/eleme/lindb/sql"
+)
+
+// Defines all anomaly detection function names, which are evaluated on the merged result series
+const (
+	ZScore           = "zscore"
+	HoltWinters      = "holt_winters"
+	HoltWintersUpper = "holt_winters_upper"
+	HoltWintersLower = "holt_winters_lower"
+	SeasonalBaseline = "seasonal_baseline"
+)
+
+// Defines the smoothing parameters of holt-winters(same as graphite),
+// the deviation is smoothed by gamma too(Brutlag's confidence band)
+const (
+	holtWintersAlpha = 0.1
+	holtWintersBeta  = 0.0035
+	holtWintersGamma = 0.1
+	// holtWintersDelta is the default scaling factor of deviation for forecast bands
+	holtWintersDelta = 3.0
+	// holtWintersBootstrapSeasons is how many seasons before query start time are used to train the model
+	holtWintersBootstrapSeasons = 2
+)
+
+// zscore returns how many standard deviations the value is away from the mean of the window,
+// which contains the last n time slots before the value, the result is NaN if the window has less than 2 values
+// or all values in the window are same, such as abs(zscore(expr, 30)) > 3 means anomaly,
+// usage: zscore(expr, n)
+func zscore(ctx *evalContext, args []sql.Expr) ([]float64, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("function[%s] needs 2 args", ZScore)
+	}
+	n, err := intArg(ZScore, args[1])
+	if err != nil {
+		return nil, err
+	}
+	if n < 2 {
+		return nil, fmt.Errorf("window size of function[%s] must be >= 2", ZScore)
+	}
+	values, err := eval(ctx, args[0])
+	if err != nil {
+		return nil, err
+	}
+	result := ctx.arena.Values(len(values))
+	sum := 0.0
+	sumOfSquares := 0.0
+	count := 0
+	for i, value := range values {
+		if i > 0 {
+			// add the previous value into window, then remove the value which is out of window
+			if prev := values[i-1]; !math.IsNaN(prev) {
+				sum += prev
+				sumOfSquares += prev * prev
+				count++
+			}
+			if i > n {
+				if old := values[i-n-1]; !math.IsNaN(old) {
+					sum -= old
+					sumOfSquares -= old * old
+					count--
+				}
+			}
+		}
+		if math.IsNaN(value) || count < 2 {
+			continue
+		}
+		mean := sum / float64(count)
+		variance := sumOfSquares/float64(count) - mean*mean
+		if variance <= 0 {
+			continue
+		}
+		result[i] = (value - mean) / math.Sqrt(variance)
+	}
+	return result, nil
+}
+
+// zscoreLookback returns the duration of n time slots
+func zscoreLookback(interval int64, args []sql.Expr) int64 {
+	if len(args) != 2 {
+		return 0
+	}
+	n, err := intArg(ZScore, args[1])
+	if err != nil || n <= 0 {
+		return 0
+	}
+	return int64(n) * interval
+}
+
+// holtWinters returns the forecast(direction is 0) or the upper/lower band(direction is 1/-1) of forecast
+// by holt-winters triple exponential smoothing, the band is the forecast +/- delta * smoothed deviation,
+// the value out of bands means anomaly. The model is trained by the data of seasons before query start time.
+// usage: holt_winters(expr, season),
+// holt_winters_upper(expr, season[, delta]), holt_winters_lower(expr, season[, delta])
+func holtWinters(ctx *evalContext, funcName string, args []sql.Expr, direction float64) ([]float64, error) {
+	seasonLength, delta, err := holtWintersArgs(ctx, funcName, args, direction != 0)
+	if err != nil {
+		return nil, err
+	}
+	values, err := eval(ctx, args[0])
+	if err != nil {
+		return nil, err
+	}
+	result := ctx.arena.Values(len(values))
+	seasonals := make([]float64, len(values))
+	deviations := make([]float64, len(values))
+	var intercept, slope, prediction float64
+	initialized := false
+	for i, actual := range values {
+		lastSeasonal, lastDeviation := 0.0, 0.0
+		if i >= seasonLength {
+			lastSeasonal = seasonals[i-seasonLength]
+			lastDeviation = deviations[i-seasonLength]
+		}
+		if math.IsNaN(actual) {
+			// keeps the seasonal components of previous season for the missing value
+			seasonals[i] = lastSeasonal
+			deviations[i] = lastDeviation
+			continue
+		}
+		if !initialized {
+			intercept = actual
+			prediction = actual
+			initialized = true
+		}
+		lastIntercept := intercept
+		intercept = holtWintersAlpha*(actual-lastSeasonal) + (1-holtWintersAlpha)*(lastIntercept+slope)
+		slope = holtWintersBeta*(intercept-lastIntercept) + (1-holtWintersBeta)*slope
+		seasonals[i] = holtWintersGamma*(actual-intercept) + (1-holtWintersGamma)*lastSeasonal
+		deviations[i] = holtWintersGamma*math.Abs(actual-prediction) + (1-holtWintersGamma)*lastDeviation
+		result[i] = prediction + direction*delta*deviations[i]
+		// forecasts the value of next time slot
+		nextSeasonal := 0.0
+		if i+1 >= seasonLength {
+			nextSeasonal = seasonals[i+1-seasonLength]
+		}
+		prediction = intercept + slope + nextSeasonal
+	}
+	return result, nil
+}
+
+// holtWintersArgs returns the time slots of season and the scaling factor of deviation,
+// only the function of forecast bands accepts delta
+func holtWintersArgs(ctx *evalContext, funcName string, args []sql.Expr,
+	withDelta bool) (seasonLength int, delta float64, err error) {
+	maxArgs := 2
+	if withDelta {
+		maxArgs = 3
+	}
+	if len(args) < 2 || len(args) > maxArgs {
+		return 0, 0, fmt.Errorf("function[%s] needs 2~%d args", funcName, maxArgs)
+	}
+	seasonLength, err = seasonArg(funcName, args[1], ctx.interval)
+	if err != nil {
+		return 0, 0, err
+	}
+	delta = holtWintersDelta
+	if len(args) == 3 {
+		number, ok := args[2].(*sql.NumberExpr)
+		if !ok || number.Value <= 0 {
+			return 0, 0, fmt.Errorf("delta of function[%s] must be positive number", funcName)
+		}
+		delta = number.Value
+	}
+	return seasonLength, delta, nil
+}
+
+// holtWintersLookback returns the duration of bootstrap seasons
+func holtWintersLookback(interval int64, args []sql.Expr) int64 {
+	if len(args) < 2 {
+		return 0
+	}
+	season, ok := args[1].(*sql.DurationExpr)
+	if !ok || season.Value <= 0 {
+		return 0
+	}
+	return holtWintersBootstrapSeasons * season.Value
+}
+
+// seasonalBaseline returns the average of the values at the same time slot of last n seasons,
+// which is the baseline for comparing with the current value, such as expr / seasonal_baseline(expr, 1d, 7),
+// usage: seasonal_baseline(expr, season, n)
+func seasonalBaseline(ctx *evalContext, args []sql.Expr) ([]float64, error) {
+	if len(args) != 3 {
+		return nil, fmt.Errorf("function[%s] needs 3 args", SeasonalBaseline)
+	}
+	seasonLength, err := seasonArg(SeasonalBaseline, args[1], ctx.interval)
+	if err != nil {
+		return nil, err
+	}
+	n, err := intArg(SeasonalBaseline, args[2])
+	if err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		return nil, fmt.Errorf("number of seasons of function[%s] must be > 0", SeasonalBaseline)
+	}
+	values, err := eval(ctx, args[0])
+	if err != nil {
+		return nil, err
+	}
+	result := ctx.arena.Values(len(values))
+	for i := range result {
+		sum := 0.0
+		count := 0
+		for j := 1; j <= n; j++ {
+			idx := i - j*seasonLength
+			if idx < 0 {
+				break
+			}
+			if value := values[idx]; !math.IsNaN(value) {
+				sum += value
+				count++
+			}
+		}
+		if count > 0 {
+			result[i] = sum / float64(count)
+		}
+	}
+	return result, nil
+}
+
+// seasonalBaselineLookback returns the duration of n seasons
+func seasonalBaselineLookback(interval int64, args []sql.Expr) int64 {
+	if len(args) != 3 {
+		return 0
+	}
+	season, ok := args[1].(*sql.DurationExpr)
+	if !ok || season.Value <= 0 {
+		return 0
+	}
+	n, err := intArg(SeasonalBaseline, args[2])
+	if err != nil || n <= 0 {
+		return 0
+	}
+	return int64(n) * season.Value
+}
+
+// seasonArg returns how many time slots the season contains, the season must be multiple of interval
+func seasonArg(funcName string, arg sql.Expr, interval int64) (int, error) {
+	season, ok := arg.(*sql.DurationExpr)
+	if !ok || season.Value <= 0 {
+		return 0, fmt.Errorf("season of function[%s] must be positive duration", funcName)
+	}
+	if interval <= 0 || season.Value%interval != 0 || season.Value/interval < 2 {
+		return 0, fmt.Errorf("season of function[%s] must be multiple(>=2) of interval[%dms]", funcName, interval)
+	}
+	return int(season.Value / interval), nil
+}
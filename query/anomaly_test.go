@@ -0,0 +1,131 @@
+package query
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/sql"
+)
+
+func TestZScore(t *testing.T) {
+	ctx := newTestContext(timeutil.OneSecond, map[string][]float64{"f": {1, 2, 3, 4, nan, 100}})
+	values, err := eval(ctx, &sql.CallExpr{Name: ZScore, Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, &sql.NumberExpr{Value: 3}}})
+	assert.Nil(t, err)
+	// the window is the last 3 time slots before the value
+	assertValues(t, []float64{nan, nan, 3, 2 / math.Sqrt(2.0/3), nan, (100 - 3.5) / 0.5}, values)
+
+	// all values in the window are same
+	ctx = newTestContext(timeutil.OneSecond, map[string][]float64{"f": {1, 1, 1, 5}})
+	values, _ = eval(ctx, &sql.CallExpr{Name: ZScore, Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, &sql.NumberExpr{Value: 2}}})
+	assertValues(t, []float64{nan, nan, nan, nan}, values)
+
+	_, err = eval(ctx, &sql.CallExpr{Name: ZScore, Args: []sql.Expr{&sql.FieldExpr{Name: "f"}}})
+	assert.NotNil(t, err)
+	_, err = eval(ctx, &sql.CallExpr{Name: ZScore, Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, &sql.NumberExpr{Value: 1.5}}})
+	assert.NotNil(t, err)
+	_, err = eval(ctx, &sql.CallExpr{Name: ZScore, Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, &sql.NumberExpr{Value: 1}}})
+	assert.NotNil(t, err)
+	_, err = eval(ctx, &sql.CallExpr{Name: ZScore, Args: []sql.Expr{&sql.DurationExpr{Value: 1}, &sql.NumberExpr{Value: 2}}})
+	assert.NotNil(t, err)
+}
+
+func TestHoltWinters(t *testing.T) {
+	// the season contains 4 time slots, the last value is anomaly
+	var series []float64
+	for i := 0; i < 20; i++ {
+		series = append(series, 10, 20, 30, 20)
+	}
+	series = append(series, 100)
+	ctx := newTestContext(timeutil.OneMinute, map[string][]float64{"f": series})
+	season := &sql.DurationExpr{Value: 4 * timeutil.OneMinute}
+	forecast, err := eval(ctx, &sql.CallExpr{Name: HoltWinters, Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, season}})
+	assert.Nil(t, err)
+	upper, err := eval(ctx, &sql.CallExpr{Name: HoltWintersUpper, Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, season}})
+	assert.Nil(t, err)
+	lower, err := eval(ctx, &sql.CallExpr{Name: HoltWintersLower,
+		Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, season, &sql.NumberExpr{Value: 2}}})
+	assert.Nil(t, err)
+	assert.Equal(t, len(series), len(forecast))
+	assert.Equal(t, 10.0, forecast[0])
+	last := len(series) - 1
+	for i := range series {
+		assert.True(t, upper[i] >= forecast[i] && forecast[i] >= lower[i], "slot %d", i)
+	}
+	// the forecast learns the seasonality
+	for i := last - 4; i < last; i++ {
+		assert.True(t, series[i] < upper[i] && series[i] > lower[i], "slot %d", i)
+	}
+	assert.True(t, series[last] > upper[last])
+
+	// the missing values are skipped
+	ctx = newTestContext(timeutil.OneMinute, map[string][]float64{"f": {nan, 1, nan, 1}})
+	forecast, _ = eval(ctx, &sql.CallExpr{Name: HoltWinters, Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, season}})
+	assertValues(t, []float64{nan, 1, nan, 1}, forecast)
+
+	_, err = eval(ctx, &sql.CallExpr{Name: HoltWinters, Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, season, &sql.NumberExpr{Value: 2}}})
+	assert.NotNil(t, err)
+	_, err = eval(ctx, &sql.CallExpr{Name: HoltWintersUpper, Args: []sql.Expr{&sql.FieldExpr{Name: "f"}}})
+	assert.NotNil(t, err)
+	_, err = eval(ctx, &sql.CallExpr{Name: HoltWintersUpper,
+		Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, season, &sql.NumberExpr{Value: -1}}})
+	assert.NotNil(t, err)
+	_, err = eval(ctx, &sql.CallExpr{Name: HoltWinters, Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, &sql.NumberExpr{Value: 4}}})
+	assert.NotNil(t, err)
+	_, err = eval(ctx, &sql.CallExpr{Name: HoltWinters,
+		Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, &sql.DurationExpr{Value: timeutil.OneMinute}}})
+	assert.NotNil(t, err)
+	_, err = eval(ctx, &sql.CallExpr{Name: HoltWinters,
+		Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, &sql.DurationExpr{Value: 90 * timeutil.OneSecond}}})
+	assert.NotNil(t, err)
+	_, err = eval(ctx, &sql.CallExpr{Name: HoltWinters, Args: []sql.Expr{&sql.DurationExpr{Value: 1}, season}})
+	assert.NotNil(t, err)
+}
+
+func TestSeasonalBaseline(t *testing.T) {
+	ctx := newTestContext(timeutil.OneMinute, map[string][]float64{"f": {1, 2, 3, nan, 5, 6}})
+	season := &sql.DurationExpr{Value: 2 * timeutil.OneMinute}
+	values, err := eval(ctx, &sql.CallExpr{Name: SeasonalBaseline,
+		Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, season, &sql.NumberExpr{Value: 2}}})
+	assert.Nil(t, err)
+	assertValues(t, []float64{nan, nan, 1, 2, 2, 2}, values)
+
+	_, err = eval(ctx, &sql.CallExpr{Name: SeasonalBaseline, Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, season}})
+	assert.NotNil(t, err)
+	_, err = eval(ctx, &sql.CallExpr{Name: SeasonalBaseline,
+		Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, season, &sql.NumberExpr{Value: 0}}})
+	assert.NotNil(t, err)
+	_, err = eval(ctx, &sql.CallExpr{Name: SeasonalBaseline,
+		Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, season, &sql.NumberExpr{Value: 1.5}}})
+	assert.NotNil(t, err)
+	_, err = eval(ctx, &sql.CallExpr{Name: SeasonalBaseline,
+		Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, &sql.NumberExpr{Value: 2}, &sql.NumberExpr{Value: 2}}})
+	assert.NotNil(t, err)
+	_, err = eval(ctx, &sql.CallExpr{Name: SeasonalBaseline,
+		Args: []sql.Expr{&sql.DurationExpr{Value: 1}, season, &sql.NumberExpr{Value: 2}}})
+	assert.NotNil(t, err)
+}
+
+func TestAnomaly_Lookback(t *testing.T) {
+	interval := int64(timeutil.OneMinute)
+	field := &sql.FieldExpr{Name: "f"}
+	day := &sql.DurationExpr{Value: timeutil.OneDay}
+	assert.Equal(t, 30*interval, lookback(&sql.CallExpr{Name: ZScore, Args: []sql.Expr{field, &sql.NumberExpr{Value: 30}}}, interval))
+	assert.Equal(t, int64(0), lookback(&sql.CallExpr{Name: ZScore, Args: []sql.Expr{field}}, interval))
+	assert.Equal(t, int64(0), lookback(&sql.CallExpr{Name: ZScore, Args: []sql.Expr{field, &sql.NumberExpr{Value: -1}}}, interval))
+	assert.Equal(t, int64(2*timeutil.OneDay), lookback(&sql.CallExpr{Name: HoltWinters, Args: []sql.Expr{field, day}}, interval))
+	assert.Equal(t, int64(2*timeutil.OneDay),
+		lookback(&sql.CallExpr{Name: HoltWintersUpper, Args: []sql.Expr{field, day, &sql.NumberExpr{Value: 2}}}, interval))
+	assert.Equal(t, int64(0), lookback(&sql.CallExpr{Name: HoltWintersLower, Args: []sql.Expr{field}}, interval))
+	assert.Equal(t, int64(0),
+		lookback(&sql.CallExpr{Name: HoltWinters, Args: []sql.Expr{field, &sql.NumberExpr{Value: 1}}}, interval))
+	assert.Equal(t, int64(7*timeutil.OneDay),
+		lookback(&sql.CallExpr{Name: SeasonalBaseline, Args: []sql.Expr{field, day, &sql.NumberExpr{Value: 7}}}, interval))
+	assert.Equal(t, int64(0), lookback(&sql.CallExpr{Name: SeasonalBaseline, Args: []sql.Expr{field, day}}, interval))
+	assert.Equal(t, int64(0),
+		lookback(&sql.CallExpr{Name: SeasonalBaseline, Args: []sql.Expr{field, &sql.NumberExpr{Value: 1}, &sql.NumberExpr{Value: 7}}}, interval))
+	assert.Equal(t, int64(0),
+		lookback(&sql.CallExpr{Name: SeasonalBaseline, Args: []sql.Expr{field, day, &sql.NumberExpr{Value: 0}}}, interval))
+}
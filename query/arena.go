@@ -0,0 +1,124 @@
+package query
+
+import (
+	"math"
+	"sync"
+
+	"github.com/eleme/lindb/models"
+)
+
+const (
+	// arenaChunkSize is the number of values in one chunk of arena(128KB)
+	arenaChunkSize = 16 * 1024
+	// arenaMaxValues is the max number of values allocated from chunk, the larger one is allocated from heap
+	arenaMaxValues = arenaChunkSize / 4
+	// arenaSeriesChunkSize is the number of series in one series chunk of arena
+	arenaSeriesChunkSize = 256
+)
+
+var valuesChunkPool = sync.Pool{
+	New: func() interface{} {
+		chunk := make([]float64, arenaChunkSize)
+		return &chunk
+	},
+}
+
+var seriesChunkPool = sync.Pool{
+	New: func() interface{} {
+		chunk := make([]models.Series, arenaSeriesChunkSize)
+		return &chunk
+	},
+}
+
+// Arena allocates the intermediate values and series of one query from large chunks, instead of allocating
+// millions of small slices for the aggregates and group maps of large group by query. All the memory is
+// released wholesale by Release at the end of query, the chunks are recycled into pool for other queries,
+// so the values and series allocated from arena cannot be referenced after released.
+// Nil arena allocates from heap, it's safe for concurrent use.
+type Arena struct {
+	mutex        sync.Mutex
+	valuesChunks []*[]float64
+	values       []float64 // the free values of current chunk
+	seriesChunks []*[]models.Series
+	series       []models.Series // the free series of current chunk
+}
+
+// NewArena creates the memory arena of query
+func NewArena() *Arena {
+	return &Arena{}
+}
+
+// Values allocates the values which all time slots are NaN
+func (a *Arena) Values(pointCount int) []float64 {
+	if a == nil || pointCount > arenaMaxValues {
+		return newValues(pointCount)
+	}
+	a.mutex.Lock()
+	if len(a.values) < pointCount {
+		chunk := valuesChunkPool.Get().(*[]float64)
+		a.valuesChunks = append(a.valuesChunks, chunk)
+		a.values = *chunk
+	}
+	// limits the capacity, so that appending values doesn't overwrite others
+	values := a.values[:pointCount:pointCount]
+	a.values = a.values[pointCount:]
+	a.mutex.Unlock()
+
+	for i := range values {
+		values[i] = math.NaN()
+	}
+	return values
+}
+
+// NewSeries allocates a series with given tags
+func (a *Arena) NewSeries(tags map[string]string) *models.Series {
+	if a == nil {
+		return models.NewSeries(tags)
+	}
+	a.mutex.Lock()
+	if len(a.series) == 0 {
+		chunk := seriesChunkPool.Get().(*[]models.Series)
+		a.seriesChunks = append(a.seriesChunks, chunk)
+		a.series = *chunk
+	}
+	series := &a.series[0]
+	a.series = a.series[1:]
+	a.mutex.Unlock()
+
+	series.Tags = tags
+	if series.Fields == nil {
+		series.Fields = make(map[string][]float64)
+	}
+	return series
+}
+
+// Release recycles all chunks of arena into pool, the arena can be reused after released
+func (a *Arena) Release() {
+	if a == nil {
+		return
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for _, chunk := range a.valuesChunks {
+		valuesChunkPool.Put(chunk)
+	}
+	for _, chunk := range a.seriesChunks {
+		// clears the references of tags/values, so that they can be collected,
+		// the fields map is kept for reusing by other queries
+		for i := range *chunk {
+			series := &(*chunk)[i]
+			series.Metric = ""
+			series.Database = ""
+			series.Tags = nil
+			for field := range series.Fields {
+				delete(series.Fields, field)
+			}
+		}
+		seriesChunkPool.Put(chunk)
+	}
+	a.valuesChunks = nil
+	a.values = nil
+	a.seriesChunks = nil
+	a.series = nil
+}
@@ -0,0 +1,91 @@
+package query
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+)
+
+func TestArena_Values(t *testing.T) {
+	arena := NewArena()
+	v1 := arena.Values(10)
+	v2 := arena.Values(10)
+	assert.Len(t, v1, 10)
+	for _, v := range v1 {
+		assert.True(t, math.IsNaN(v))
+	}
+	// appending doesn't overwrite the values allocated after
+	v2[0] = 1
+	v1 = append(v1, 2)
+	assert.Equal(t, float64(1), v2[0])
+	assert.Len(t, v1, 11)
+
+	// allocates new chunk if current chunk is full
+	for i := 0; i < arenaChunkSize/arenaMaxValues+1; i++ {
+		assert.Len(t, arena.Values(arenaMaxValues), arenaMaxValues)
+	}
+	assert.Len(t, arena.valuesChunks, 2)
+	// large values are allocated from heap
+	assert.Len(t, arena.Values(arenaMaxValues+1), arenaMaxValues+1)
+	assert.Len(t, arena.valuesChunks, 2)
+
+	arena.Release()
+	assert.Nil(t, arena.valuesChunks)
+	assert.Nil(t, arena.values)
+	// reuse after released
+	v1 = arena.Values(5)
+	assert.True(t, math.IsNaN(v1[0]))
+	arena.Release()
+
+	// nil arena allocates from heap
+	var nilArena *Arena
+	assert.Len(t, nilArena.Values(5), 5)
+	assert.NotNil(t, nilArena.NewSeries(nil).Fields)
+	nilArena.Release()
+}
+
+func TestArena_NewSeries(t *testing.T) {
+	arena := NewArena()
+	for i := 0; i < arenaSeriesChunkSize+1; i++ {
+		series := arena.NewSeries(map[string]string{"host": "1.1.1.1"})
+		assert.Equal(t, "1.1.1.1", series.Tags["host"])
+		assert.Empty(t, series.Fields)
+		series.Fields["f"] = arena.Values(1)
+	}
+	assert.Len(t, arena.seriesChunks, 2)
+	chunk := arena.seriesChunks[0]
+	arena.Release()
+	assert.Nil(t, arena.seriesChunks)
+	// the references of series are cleared after released
+	assert.Nil(t, (*chunk)[0].Tags)
+	assert.Empty(t, (*chunk)[0].Fields)
+}
+
+func BenchmarkArena_Values(b *testing.B) {
+	b.ReportAllocs()
+	seriesList := make([]*models.Series, 1000)
+	for i := 0; i < b.N; i++ {
+		arena := NewArena()
+		for j := range seriesList {
+			series := arena.NewSeries(nil)
+			series.Fields["f"] = arena.Values(60)
+			seriesList[j] = series
+		}
+		arena.Release()
+	}
+}
+
+func BenchmarkHeap_Values(b *testing.B) {
+	b.ReportAllocs()
+	seriesList := make([]*models.Series, 1000)
+	for i := 0; i < b.N; i++ {
+		for j := range seriesList {
+			series := models.NewSeries(nil)
+			series.Fields["f"] = newValues(60)
+			seriesList[j] = series
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/sql"
+)
+
+// FetchRequest represents the sub query of one metric which is sent to storage nodes
+type FetchRequest struct {
+	Database  string
+	SubQuery  SubQuery
+	GroupBy   []string
+	TimeRange models.TimeRange
+	Interval  int64
+	// OrderLimit is the top n stage pushed down to storage nodes, nil means no push down
+	OrderLimit *OrderLimit
+	// MinSequences are the sequences of shards which the storage nodes wait for before scanning(read-your-writes)
+	MinSequences models.SequenceToken
+	// ShardLocal means the series of each group are on the same shard, so the groups of storage nodes
+	// are concatenated without merging across shards
+	ShardLocal bool
+}
+
+// Fetcher fetches the merged result of sub query from storage nodes
+type Fetcher interface {
+	// Fetch returns the result set of the sub query, field's values are keyed by field name,
+	// the deadline of context is propagated to storage nodes
+	Fetch(ctx context.Context, req *FetchRequest) (*models.ResultSet, error)
+	// IsShardLocal checks if the series of each group are on the same shard of database,
+	// then the top n groups ordered by field can be selected by storage nodes without merging across shards
+	IsShardLocal(database string, groupBy []string) bool
+}
+
+// BrokerExecutor represents the executor of query DSL at broker
+type BrokerExecutor interface {
+	// Execute executes the query request, returns the final result set,
+	// returns error if the query isn't done before the deadline of context
+	Execute(ctx context.Context, req *models.QueryRequest) (*models.ResultSet, error)
+}
+
+// brokerExecutor implements broker executor interface,
+// 1) fans out the sub query of each metric by fetcher
+// 2) aligns the results, then evaluates select expressions
+// 3) fills the missing time slots
+// 4) orders and pages the series, the series are paged by continue token if page size is set
+type brokerExecutor struct {
+	fetcher Fetcher
+	udfs    UDFRegistry
+}
+
+// NewBrokerExecutor creates the broker executor with the fetcher of sub query,
+// the functions which aren't builtin are resolved from the user defined functions of database,
+// nil registry means only builtin functions are supported.
+func NewBrokerExecutor(fetcher Fetcher, udfs UDFRegistry) BrokerExecutor {
+	return &brokerExecutor{
+		fetcher: fetcher,
+		udfs:    udfs,
+	}
+}
+
+// Execute executes the query request, returns the final result set,
+// returns error if the query isn't done before the deadline of context
+func (e *brokerExecutor) Execute(ctx context.Context, req *models.QueryRequest) (*models.ResultSet, error) {
+	if err := validateQueryRequest(req); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("query is canceled before executing:%s", err)
+	}
+	fill, err := ParseFillPolicy(req.Fill)
+	if err != nil {
+		return nil, err
+	}
+	orderLimit, err := newOrderLimit(req)
+	if err != nil {
+		return nil, err
+	}
+	page, err := NewPage(req)
+	if err != nil {
+		return nil, err
+	}
+	minSequences, err := models.ParseSequenceToken(req.MinSequence)
+	if err != nil {
+		return nil, err
+	}
+
+	var rs *models.ResultSet
+	if len(req.From) == 0 {
+		rs, err = e.executeIn(ctx, req, models.QueryMetric{Database: req.Database, Metric: req.Metric},
+			orderLimit, minSequences)
+	} else {
+		rs, err = e.executeAcross(ctx, req, orderLimit, minSequences)
+	}
+	if err != nil {
+		return nil, err
+	}
+	fill.Apply(rs)
+	orderLimit.Apply(rs)
+	page.Apply(rs)
+	return rs, nil
+}
+
+// executeAcross executes the query in the database of each metric in from clause concurrently,
+// then unions the series of results, the series is marked with its database.
+// The series of different databases aren't merged even if the group by tags are same,
+// so that the top n stage is pushed down into each database as the query of one database.
+func (e *brokerExecutor) executeAcross(ctx context.Context, req *models.QueryRequest, orderLimit *OrderLimit,
+	minSequences models.SequenceToken) (*models.ResultSet, error) {
+	rsList := make([]*models.ResultSet, len(req.From))
+	errs := make([]error, len(req.From))
+	var wg sync.WaitGroup
+	for i := range req.From {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			rsList[idx], errs[idx] = e.executeIn(ctx, req, req.From[idx], orderLimit, minSequences)
+		}(i)
+	}
+	wg.Wait()
+	for i, from := range req.From {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("query metric[%s] of database[%s] error:%s", from.Metric, from.Database, errs[i])
+		}
+		for _, series := range rsList[i].Series {
+			series.Database = from.Database
+		}
+	}
+	return unionResults(rsList), nil
+}
+
+// executeIn executes the query in the database with the default metric of select expressions,
+// returns the result set which isn't filled, ordered and paged.
+func (e *brokerExecutor) executeIn(ctx context.Context, req *models.QueryRequest, from models.QueryMetric,
+	orderLimit *OrderLimit, minSequences models.SequenceToken) (*models.ResultSet, error) {
+	var items []SelectItem
+	for _, field := range req.Fields {
+		expr, err := sql.ParseExpr(field.Expr)
+		if err != nil {
+			return nil, err
+		}
+		if len(from.Metric) > 0 {
+			expr = bindMetric(expr, from.Metric)
+		}
+		expr, err = resolveUDF(expr, from.Database, e.udfs)
+		if err != nil {
+			return nil, err
+		}
+		alias := field.Alias
+		if len(alias) == 0 {
+			alias = field.Expr
+		}
+		items = append(items, SelectItem{Alias: alias, Expr: expr})
+	}
+	plan, err := NewCrossMetricPlan(req.GroupBy, items...)
+	if err != nil {
+		return nil, err
+	}
+	// the sequence token is returned by the write into the database of request
+	if from.Database != req.Database {
+		minSequences = nil
+	}
+
+	startTime := req.Start - req.Start%req.Interval
+	timeRange := plan.TimeRange(models.TimeRange{Start: startTime, End: req.End}, req.Interval)
+	shardLocal := len(req.GroupBy) > 0 && e.fetcher.IsShardLocal(from.Database, req.GroupBy)
+	pushDown := pushDownOrderLimit(plan, items, orderLimit, shardLocal)
+	results, err := e.fetch(ctx, req, from.Database, plan, timeRange, pushDown, minSequences, shardLocal)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("query is canceled after fetching:%s", err)
+	}
+	// the aligned result set and intermediate values of expressions are released after evaluated
+	arena := NewArena()
+	defer arena.Release()
+	return plan.Apply(results, startTime, arena)
+}
+
+// fetch fetches the results of all sub queries from the database concurrently, key is metric name
+func (e *brokerExecutor) fetch(ctx context.Context, req *models.QueryRequest, database string, plan *CrossMetricPlan,
+	timeRange models.TimeRange, pushDown *OrderLimit, minSequences models.SequenceToken, shardLocal bool,
+) (map[string]*models.ResultSet, error) {
+	subQueries := plan.SubQueries()
+	rsList := make([]*models.ResultSet, len(subQueries))
+	errs := make([]error, len(subQueries))
+	var wg sync.WaitGroup
+	for i := range subQueries {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			rsList[idx], errs[idx] = e.fetcher.Fetch(ctx, &FetchRequest{
+				Database:     database,
+				SubQuery:     subQueries[idx],
+				GroupBy:      req.GroupBy,
+				TimeRange:    timeRange,
+				Interval:     req.Interval,
+				OrderLimit:   pushDown,
+				MinSequences: minSequences,
+				ShardLocal:   shardLocal,
+			})
+			if rsList[idx] != nil {
+				addScannedPoints(ctx, rsList[idx].Stats)
+			}
+		}(i)
+	}
+	wg.Wait()
+	results := make(map[string]*models.ResultSet)
+	for i, subQuery := range subQueries {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("query metric[%s] error:%s", subQuery.MetricName, errs[i])
+		}
+		results[subQuery.MetricName] = rsList[i]
+	}
+	return results, nil
+}
+
+// unionResults unions the series of result sets which start with the same time,
+// the point count of result is the max point count of result sets, the short values are padded by NaN.
+func unionResults(rsList []*models.ResultSet) *models.ResultSet {
+	result := &models.ResultSet{StartTime: rsList[0].StartTime, Interval: rsList[0].Interval}
+	for _, rs := range rsList {
+		if rs.PointCount > result.PointCount {
+			result.PointCount = rs.PointCount
+		}
+	}
+	for _, rs := range rsList {
+		result.MergeMetadata(rs)
+		for _, column := range rs.Columns {
+			result.AddColumn(column.Name, column.Type)
+		}
+		for _, series := range rs.Series {
+			for field, values := range series.Fields {
+				if len(values) < result.PointCount {
+					padded := newValues(result.PointCount)
+					copy(padded, values)
+					series.Fields[field] = padded
+				}
+			}
+			result.Series = append(result.Series, series)
+		}
+	}
+	return result
+}
+
+// validateQueryRequest checks if the query request is valid
+func validateQueryRequest(req *models.QueryRequest) error {
+	if req == nil {
+		return fmt.Errorf("query request is nil")
+	}
+	if len(req.From) == 0 && len(req.Database) == 0 {
+		return fmt.Errorf("database name cannot be empty")
+	}
+	for _, from := range req.From {
+		if len(from.Database) == 0 || len(from.Metric) == 0 {
+			return fmt.Errorf("database and metric of from clause cannot be empty")
+		}
+	}
+	if len(req.Fields) == 0 {
+		return fmt.Errorf("select fields cannot be empty")
+	}
+	if req.Interval <= 0 {
+		return fmt.Errorf("interval must be > 0")
+	}
+	if req.Start >= req.End {
+		return fmt.Errorf("start time must be < end time")
+	}
+	return nil
+}
+
+// newOrderLimit creates the order/limit stage by the order by/offset/limit of query request
+func newOrderLimit(req *models.QueryRequest) (*OrderLimit, error) {
+	if req.OrderBy == nil {
+		return NewOrderLimit(nil, req.Offset, req.Limit), nil
+	}
+	f, err := ParseOrderFunc(req.OrderBy.Func)
+	if err != nil {
+		return nil, err
+	}
+	return NewOrderLimit(&OrderBy{Field: req.OrderBy.Field, Func: f, Desc: req.OrderBy.Desc}, req.Offset, req.Limit), nil
+}
+
+// pushDownOrderLimit returns the top n stage which can be pushed down to storage nodes,
+// only the query of one metric ordered by tags or a raw field can be pushed down, because the order value
+// of expression cannot be computed before evaluating at broker. Ordering by tags is pushed down always,
+// the top n groups by tags are kept in the top n of each node. Ordering by field is pushed down only if
+// the groups are shard-local, otherwise the partial values of group on each node don't decide the top n,
+// then the limit is applied after merging at broker.
+func pushDownOrderLimit(plan *CrossMetricPlan, items []SelectItem, orderLimit *OrderLimit,
+	shardLocal bool) *OrderLimit {
+	subQueries := plan.SubQueries()
+	if orderLimit.limit <= 0 || len(subQueries) != 1 {
+		return nil
+	}
+	if orderLimit.orderBy == nil {
+		return orderLimit.PushDown()
+	}
+	if !shardLocal {
+		return nil
+	}
+	for _, item := range items {
+		if item.Alias != orderLimit.orderBy.Field {
+			continue
+		}
+		field, ok := item.Expr.(*sql.MetricFieldExpr)
+		if !ok {
+			return nil
+		}
+		orderBy := *orderLimit.orderBy
+		orderBy.Field = field.Field
+		return NewOrderLimit(&orderBy, orderLimit.offset, orderLimit.limit).PushDown()
+	}
+	return nil
+}
+
+// bindMetric binds the field which isn't prefixed by metric name to the default metric
+func bindMetric(expr sql.Expr, metricName string) sql.Expr {
+	switch e := expr.(type) {
+	case *sql.FieldExpr:
+		return &sql.MetricFieldExpr{Metric: metricName, Field: e.Name}
+	case *sql.BinaryExpr:
+		return &sql.BinaryExpr{Op: e.Op, Left: bindMetric(e.Left, metricName), Right: bindMetric(e.Right, metricName)}
+	case *sql.CallExpr:
+		args := make([]sql.Expr, len(e.Args))
+		for i, arg := range e.Args {
+			args[i] = bindMetric(arg, metricName)
+		}
+		return &sql.CallExpr{Name: e.Name, Args: args}
+	default:
+		return expr
+	}
+}
@@ -0,0 +1,317 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/timeutil"
+)
+
+type mockFetcher struct {
+	mutex      sync.Mutex
+	reqs       map[string]*FetchRequest
+	results    map[string]*models.ResultSet
+	shardLocal bool
+}
+
+func (f *mockFetcher) Fetch(ctx context.Context, req *FetchRequest) (*models.ResultSet, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	key := req.Database + "/" + req.SubQuery.MetricName
+	f.reqs[key] = req
+	rs, ok := f.results[key]
+	if ok {
+		return rs, nil
+	}
+	rs, ok = f.results[req.SubQuery.MetricName]
+	if !ok {
+		return nil, fmt.Errorf("not found")
+	}
+	return rs, nil
+}
+
+func (f *mockFetcher) IsShardLocal(database string, groupBy []string) bool {
+	return f.shardLocal
+}
+
+func newMockFetcher() *mockFetcher {
+	interval := int64(timeutil.OneMinute)
+	return &mockFetcher{
+		shardLocal: true,
+		reqs:       make(map[string]*FetchRequest),
+		results: map[string]*models.ResultSet{
+			"cpu": {
+				StartTime:  0,
+				Interval:   interval,
+				PointCount: 4,
+				Series: []*models.Series{
+					{Tags: map[string]string{"host": "a"}, Fields: map[string][]float64{"used": {1, nan, 3, 4}, "total": {10, 10, 10, 10}}},
+					{Tags: map[string]string{"host": "b"}, Fields: map[string][]float64{"used": {5, 5, 5, 5}, "total": {10, 10, 10, 10}}},
+				},
+			},
+			"db2/cpu": {
+				StartTime:  0,
+				Interval:   interval,
+				PointCount: 4,
+				Series: []*models.Series{
+					{Tags: map[string]string{"host": "c"}, Fields: map[string][]float64{"used": {7, 7, 7, 7}}},
+					{Tags: map[string]string{"host": "a"}, Fields: map[string][]float64{"used": {1, 1, 1, 1}}},
+				},
+			},
+			"mem": {
+				StartTime:  interval,
+				Interval:   interval,
+				PointCount: 3,
+				Series: []*models.Series{
+					{Tags: map[string]string{"host": "a"}, Fields: map[string][]float64{"used": {2, 2, 2}}},
+				},
+			},
+		},
+	}
+}
+
+func TestBrokerExecutor_Execute(t *testing.T) {
+	interval := int64(timeutil.OneMinute)
+	fetcher := newMockFetcher()
+	executor := NewBrokerExecutor(fetcher, nil)
+
+	rs, err := executor.Execute(context.TODO(), &models.QueryRequest{
+		Database: "db",
+		Metric:   "cpu",
+		Fields:   []models.QueryField{{Alias: "ratio", Expr: "used/total*100"}, {Expr: "used"}},
+		Start:    interval + 10,
+		End:      4 * interval,
+		Interval: interval,
+		GroupBy:  []string{"host"},
+		Fill:     "previous",
+		OrderBy:  &models.QueryOrderBy{Field: "used", Func: "max", Desc: true},
+		Limit:    1,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, interval, rs.StartTime)
+	assert.Equal(t, 3, rs.PointCount)
+	assert.Equal(t, 1, len(rs.Series))
+	assert.Equal(t, map[string]string{"host": "b"}, rs.Series[0].Tags)
+	assertValues(t, []float64{50, 50, 50}, rs.Series[0].Fields["ratio"])
+	assertValues(t, []float64{5, 5, 5}, rs.Series[0].Fields["used"])
+	// order by raw field, top n is pushed down
+	req := fetcher.reqs["db/cpu"]
+	assert.Equal(t, SubQuery{MetricName: "cpu", Fields: []string{"total", "used"}}, req.SubQuery)
+	assert.Equal(t, models.TimeRange{Start: interval, End: 4 * interval}, req.TimeRange)
+	assert.Equal(t, NewOrderLimit(&OrderBy{Field: "used", Func: OrderByMax, Desc: true}, 0, 1), req.OrderLimit)
+	// the groups of storage nodes are concatenated without merging
+	assert.True(t, req.ShardLocal)
+
+	// the groups aren't shard-local, the top n by field is applied after merging
+	fetcher.shardLocal = false
+	newRequest := func(orderBy *models.QueryOrderBy) *models.QueryRequest {
+		return &models.QueryRequest{
+			Database: "db",
+			Metric:   "cpu",
+			Fields:   []models.QueryField{{Expr: "used"}},
+			Start:    interval,
+			End:      4 * interval,
+			Interval: interval,
+			GroupBy:  []string{"host"},
+			OrderBy:  orderBy,
+			Limit:    1,
+		}
+	}
+	rs, err = executor.Execute(context.TODO(), newRequest(&models.QueryOrderBy{Field: "used", Func: "max", Desc: true}))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(rs.Series))
+	assert.Equal(t, map[string]string{"host": "b"}, rs.Series[0].Tags)
+	assert.Nil(t, fetcher.reqs["db/cpu"].OrderLimit)
+	assert.False(t, fetcher.reqs["db/cpu"].ShardLocal)
+	// order by tags is pushed down always
+	rs, err = executor.Execute(context.TODO(), newRequest(nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(rs.Series))
+	assert.Equal(t, map[string]string{"host": "a"}, rs.Series[0].Tags)
+	assert.Equal(t, NewOrderLimit(nil, 0, 1), fetcher.reqs["db/cpu"].OrderLimit)
+	fetcher.shardLocal = true
+
+	// query across metrics, fill zero
+	rs, err = executor.Execute(context.TODO(), &models.QueryRequest{
+		Database: "db",
+		Fields:   []models.QueryField{{Alias: "sum", Expr: "cpu:used+mem:used"}},
+		Start:    0,
+		End:      4 * interval,
+		Interval: interval,
+		GroupBy:  []string{"host"},
+		Fill:     "zero",
+		OrderBy:  &models.QueryOrderBy{Field: "sum", Func: "sum", Desc: true},
+		Limit:    1,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(rs.Series))
+	assert.Equal(t, map[string]string{"host": "a"}, rs.Series[0].Tags)
+	assertValues(t, []float64{0, 0, 5, 6}, rs.Series[0].Fields["sum"])
+	assert.Nil(t, fetcher.reqs["db/cpu"].OrderLimit)
+	assert.Nil(t, fetcher.reqs["db/mem"].OrderLimit)
+}
+
+func TestBrokerExecutor_Execute_Federation(t *testing.T) {
+	interval := int64(timeutil.OneMinute)
+	fetcher := newMockFetcher()
+	executor := NewBrokerExecutor(fetcher, nil)
+
+	rs, err := executor.Execute(context.TODO(), &models.QueryRequest{
+		From:     []models.QueryMetric{{Database: "db", Metric: "cpu"}, {Database: "db2", Metric: "cpu"}},
+		Fields:   []models.QueryField{{Alias: "used", Expr: "used"}},
+		Start:    0,
+		End:      4 * interval,
+		Interval: interval,
+		GroupBy:  []string{"host"},
+		Fill:     "zero",
+		// read the writes into db
+		Database:    "db",
+		MinSequence: "1:10",
+	})
+	assert.Nil(t, err)
+	// the series of same tags in different databases aren't merged
+	assert.Equal(t, 4, len(rs.Series))
+	assert.Equal(t, "db", rs.Series[0].Database)
+	assert.Equal(t, map[string]string{"host": "a"}, rs.Series[0].Tags)
+	assertValues(t, []float64{1, 0, 3, 4}, rs.Series[0].Fields["used"])
+	assert.Equal(t, "db", rs.Series[1].Database)
+	assert.Equal(t, map[string]string{"host": "b"}, rs.Series[1].Tags)
+	assert.Equal(t, "db2", rs.Series[2].Database)
+	assert.Equal(t, map[string]string{"host": "a"}, rs.Series[2].Tags)
+	assertValues(t, []float64{1, 1, 1, 1}, rs.Series[2].Fields["used"])
+	assert.Equal(t, "db2", rs.Series[3].Database)
+	assert.Equal(t, map[string]string{"host": "c"}, rs.Series[3].Tags)
+	assert.Equal(t, "db", fetcher.reqs["db/cpu"].Database)
+	assert.Equal(t, "db2", fetcher.reqs["db2/cpu"].Database)
+	// the sequence token is of the shards of request's database
+	assert.Equal(t, models.SequenceToken{1: 10}, fetcher.reqs["db/cpu"].MinSequences)
+	assert.Nil(t, fetcher.reqs["db2/cpu"].MinSequences)
+	assert.Equal(t, SubQuery{MetricName: "cpu", Fields: []string{"used"}}, fetcher.reqs["db2/cpu"].SubQuery)
+
+	// the top n is pushed down into each database, then applied on the union
+	rs, err = executor.Execute(context.TODO(), &models.QueryRequest{
+		From:     []models.QueryMetric{{Database: "db", Metric: "cpu"}, {Database: "db2", Metric: "cpu"}},
+		Fields:   []models.QueryField{{Alias: "used", Expr: "used"}},
+		Start:    0,
+		End:      4 * interval,
+		Interval: interval,
+		GroupBy:  []string{"host"},
+		OrderBy:  &models.QueryOrderBy{Field: "used", Func: "sum", Desc: true},
+		Limit:    2,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rs.Series))
+	assert.Equal(t, "db2", rs.Series[0].Database)
+	assert.Equal(t, map[string]string{"host": "c"}, rs.Series[0].Tags)
+	assert.Equal(t, "db", rs.Series[1].Database)
+	assert.Equal(t, map[string]string{"host": "b"}, rs.Series[1].Tags)
+	assert.Equal(t, 2, fetcher.reqs["db2/cpu"].OrderLimit.limit)
+
+	// the metric not exist in one database
+	_, err = executor.Execute(context.TODO(), &models.QueryRequest{
+		From:     []models.QueryMetric{{Database: "db", Metric: "cpu"}, {Database: "db2", Metric: "not-exist"}},
+		Fields:   []models.QueryField{{Alias: "used", Expr: "used"}},
+		Start:    0,
+		End:      4 * interval,
+		Interval: interval,
+	})
+	assert.NotNil(t, err)
+}
+
+func TestUnionResults(t *testing.T) {
+	rs := unionResults([]*models.ResultSet{
+		{StartTime: 10, Interval: 10, PointCount: 2, Columns: []models.Column{{Name: "f"}},
+			Series: []*models.Series{{Fields: map[string][]float64{"f": {1, 2}}}}},
+		{StartTime: 10, Interval: 10, PointCount: 3, Partial: true,
+			Series: []*models.Series{{Fields: map[string][]float64{"f": {3, 4, 5}}}}},
+	})
+	assert.Equal(t, int64(10), rs.StartTime)
+	assert.Equal(t, 3, rs.PointCount)
+	assert.True(t, rs.Partial)
+	assert.Equal(t, []models.Column{{Name: "f"}}, rs.Columns)
+	assert.Equal(t, 2, len(rs.Series))
+	assertValues(t, []float64{1, 2, nan}, rs.Series[0].Fields["f"])
+	assertValues(t, []float64{3, 4, 5}, rs.Series[1].Fields["f"])
+}
+
+func TestBrokerExecutor_Execute_Page(t *testing.T) {
+	interval := int64(timeutil.OneMinute)
+	executor := NewBrokerExecutor(newMockFetcher(), nil)
+	req := &models.QueryRequest{
+		Database: "db",
+		Metric:   "cpu",
+		Fields:   []models.QueryField{{Expr: "used"}},
+		Start:    0,
+		End:      4 * interval,
+		Interval: interval,
+		GroupBy:  []string{"host"},
+		PageSize: 1,
+	}
+	var hosts []string
+	for {
+		rs, err := executor.Execute(context.TODO(), req)
+		assert.Nil(t, err)
+		assert.Len(t, rs.Series, 1)
+		hosts = append(hosts, rs.Series[0].Tags["host"])
+		if len(rs.Continue) == 0 {
+			break
+		}
+		req.Continue = rs.Continue
+	}
+	assert.Equal(t, []string{"a", "b"}, hosts)
+}
+
+func TestBrokerExecutor_Execute_Fail(t *testing.T) {
+	interval := int64(timeutil.OneMinute)
+	executor := NewBrokerExecutor(newMockFetcher(), nil)
+	newRequest := func() *models.QueryRequest {
+		return &models.QueryRequest{
+			Database: "db",
+			Metric:   "cpu",
+			Fields:   []models.QueryField{{Alias: "f", Expr: "used"}},
+			Start:    0,
+			End:      interval,
+			Interval: interval,
+		}
+	}
+	for _, update := range []func(req *models.QueryRequest){
+		func(req *models.QueryRequest) { req.Database = "" },
+		func(req *models.QueryRequest) { req.Fields = nil },
+		func(req *models.QueryRequest) { req.Interval = 0 },
+		func(req *models.QueryRequest) { req.End = 0 },
+		func(req *models.QueryRequest) { req.Fields[0].Expr = "used+" },
+		func(req *models.QueryRequest) { req.Metric = "" },
+		func(req *models.QueryRequest) { req.Fill = "unknown" },
+		func(req *models.QueryRequest) { req.OrderBy = &models.QueryOrderBy{Field: "f", Func: "unknown"} },
+		func(req *models.QueryRequest) { req.Metric = "not-exist" },
+		func(req *models.QueryRequest) { req.MinSequence = "1:a" },
+		func(req *models.QueryRequest) { req.PageSize = -1 },
+		func(req *models.QueryRequest) { req.Continue = "token" },
+		func(req *models.QueryRequest) { req.From = []models.QueryMetric{{Database: "db"}} },
+	} {
+		req := newRequest()
+		update(req)
+		_, err := executor.Execute(context.TODO(), req)
+		assert.NotNil(t, err)
+	}
+	_, err := executor.Execute(context.TODO(), nil)
+	assert.NotNil(t, err)
+
+	// fetch failure
+	_, err = NewBrokerExecutor(&mockFetcher{reqs: make(map[string]*FetchRequest)}, nil).
+		Execute(context.TODO(), newRequest())
+	assert.NotNil(t, err)
+
+	// the deadline of query exceeded
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+	_, err = executor.Execute(ctx, newRequest())
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,213 @@
+package query
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+)
+
+// Blacklist checks if the query matches any blacklist rule of known-pathological queries
+type Blacklist interface {
+	// Check returns ErrQueryBlacklisted if the query matches any blacklist rule
+	Check(req *models.QueryRequest) error
+}
+
+// Authorizer checks if the user of query is allowed to query the database
+type Authorizer interface {
+	// Authorize returns ErrQueryUnauthorized if the user in context isn't allowed to query the database
+	Authorize(ctx context.Context, database string) error
+}
+
+// Tracker tracks the running queries of broker, so that operators can find out the expensive query
+// and kill it. NOTICE: the queries are tracked by each broker, the id is unique in broker.
+type Tracker interface {
+	// Track registers the running query, returns the context which is canceled when the query is killed,
+	// the query must be finished after executing
+	Track(ctx context.Context, req *models.QueryRequest) (context.Context, *TrackedQuery)
+	// List returns the running queries sorted by start time
+	List() []models.RunningQuery
+	// Kill cancels the running query by id, returns false if the query not exist
+	Kill(id string) bool
+}
+
+// TrackedQuery represents the running query tracked by query tracker
+type TrackedQuery struct {
+	id            string
+	req           *models.QueryRequest
+	startTime     time.Time
+	scannedPoints int64
+	cancel        context.CancelFunc
+	tracker       *queryTracker
+	killed        int32
+}
+
+// ID returns the id of query
+func (q *TrackedQuery) ID() string {
+	return q.id
+}
+
+// Killed returns if the query is killed by operator
+func (q *TrackedQuery) Killed() bool {
+	return atomic.LoadInt32(&q.killed) == 1
+}
+
+// Finish unregisters the query from tracker, then releases the context of query
+func (q *TrackedQuery) Finish() {
+	q.tracker.remove(q.id)
+	q.cancel()
+}
+
+// addScannedPoints counts the raw points scanned by the sub query
+func (q *TrackedQuery) addScannedPoints(points int64) {
+	atomic.AddInt64(&q.scannedPoints, points)
+}
+
+// trackedQueryKey is the key of tracked query in context
+type trackedQueryKey struct{}
+
+// addScannedPoints counts the raw points scanned by sub query into the query tracked by context
+func addScannedPoints(ctx context.Context, stats *models.ResultStats) {
+	if stats == nil {
+		return
+	}
+	if q, ok := ctx.Value(trackedQueryKey{}).(*TrackedQuery); ok {
+		q.addScannedPoints(stats.ScannedPoints)
+	}
+}
+
+// queryTracker implements Tracker interface
+type queryTracker struct {
+	seq     int64
+	mutex   sync.RWMutex
+	queries map[string]*TrackedQuery
+}
+
+// NewTracker creates the tracker of running queries
+func NewTracker() Tracker {
+	return &queryTracker{
+		queries: make(map[string]*TrackedQuery),
+	}
+}
+
+// Track registers the running query with an increasing id
+func (t *queryTracker) Track(ctx context.Context, req *models.QueryRequest) (context.Context, *TrackedQuery) {
+	ctx, cancel := context.WithCancel(ctx)
+	q := &TrackedQuery{
+		id:        strconv.FormatInt(atomic.AddInt64(&t.seq, 1), 10),
+		req:       req,
+		startTime: time.Now(),
+		cancel:    cancel,
+		tracker:   t,
+	}
+	t.mutex.Lock()
+	t.queries[q.id] = q
+	t.mutex.Unlock()
+	return context.WithValue(ctx, trackedQueryKey{}, q), q
+}
+
+// List returns the running queries sorted by start time, the scanned bytes are estimated by the points scanned
+func (t *queryTracker) List() []models.RunningQuery {
+	t.mutex.RLock()
+	queries := make([]*TrackedQuery, 0, len(t.queries))
+	for _, q := range t.queries {
+		queries = append(queries, q)
+	}
+	t.mutex.RUnlock()
+	sort.Slice(queries, func(i, j int) bool {
+		return queries[i].startTime.Before(queries[j].startTime)
+	})
+	now := time.Now()
+	result := make([]models.RunningQuery, 0, len(queries))
+	for _, q := range queries {
+		result = append(result, models.RunningQuery{
+			ID:           q.id,
+			Database:     q.req.Database,
+			Query:        q.req,
+			StartTime:    q.startTime.UnixNano() / int64(time.Millisecond),
+			Elapsed:      now.Sub(q.startTime).Nanoseconds() / int64(time.Millisecond),
+			ScannedBytes: atomic.LoadInt64(&q.scannedPoints) * models.PointBytes,
+		})
+	}
+	return result
+}
+
+// Kill marks the query killed, then cancels the context of query
+func (t *queryTracker) Kill(id string) bool {
+	t.mutex.RLock()
+	q, ok := t.queries[id]
+	t.mutex.RUnlock()
+	if !ok {
+		return false
+	}
+	atomic.StoreInt32(&q.killed, 1)
+	q.cancel()
+	return true
+}
+
+// remove unregisters the query by id
+func (t *queryTracker) remove(id string) {
+	t.mutex.Lock()
+	delete(t.queries, id)
+	t.mutex.Unlock()
+}
+
+// controlledExecutor implements BrokerExecutor interface, which rejects the unauthorized or blacklisted query
+// before executing, and tracks the running query, so that the query can be listed and killed.
+type controlledExecutor struct {
+	executor   BrokerExecutor
+	tracker    Tracker
+	blacklist  Blacklist
+	authorizer Authorizer
+}
+
+// NewControlledExecutor creates the executor wrapping the broker executor with query tracker, blacklist and
+// authorizer, the query isn't checked if blacklist is nil, the query isn't authorized if authorizer is nil.
+func NewControlledExecutor(executor BrokerExecutor, tracker Tracker, blacklist Blacklist,
+	authorizer Authorizer) BrokerExecutor {
+	return &controlledExecutor{
+		executor:   executor,
+		tracker:    tracker,
+		blacklist:  blacklist,
+		authorizer: authorizer,
+	}
+}
+
+// Execute authorizes each database of the query, checks the query by blacklist,
+// then executes the query as a tracked query, returns ErrQueryKilled if the query is killed while executing
+func (e *controlledExecutor) Execute(ctx context.Context, req *models.QueryRequest) (*models.ResultSet, error) {
+	if err := Authorize(ctx, e.authorizer, req); err != nil {
+		return nil, err
+	}
+	if e.blacklist != nil {
+		if err := e.blacklist.Check(req); err != nil {
+			return nil, err
+		}
+	}
+	ctx, q := e.tracker.Track(ctx, req)
+	defer q.Finish()
+	rs, err := e.executor.Execute(ctx, req)
+	if q.Killed() {
+		return nil, errors.Wrapf(errors.ErrQueryKilled, "query[%s] is killed", q.ID())
+	}
+	return rs, err
+}
+
+// Authorize checks if the user in context is allowed to query each database of the query request,
+// the query is allowed if authorizer is nil
+func Authorize(ctx context.Context, authorizer Authorizer, req *models.QueryRequest) error {
+	if authorizer == nil || req == nil {
+		return nil
+	}
+	for _, database := range req.Databases() {
+		if err := authorizer.Authorize(ctx, database); err != nil {
+			return err
+		}
+	}
+	return nil
+}
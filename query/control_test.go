@@ -0,0 +1,162 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/timeutil"
+)
+
+// blockingExecutor scans points, then blocks until the query is canceled or released
+type blockingExecutor struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (e *blockingExecutor) Execute(ctx context.Context, req *models.QueryRequest) (*models.ResultSet, error) {
+	addScannedPoints(ctx, &models.ResultStats{ScannedPoints: 10})
+	close(e.started)
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-e.release:
+		return &models.ResultSet{}, nil
+	}
+}
+
+// mockBlacklist rejects the query of database
+type mockBlacklist struct {
+	database string
+}
+
+func (b *mockBlacklist) Check(req *models.QueryRequest) error {
+	if req.Database == b.database {
+		return errors.Wrapf(errors.ErrQueryBlacklisted, "query of database[%s]", b.database)
+	}
+	return nil
+}
+
+type mockAuthorizer struct {
+	database string
+	user     string
+}
+
+func (a *mockAuthorizer) Authorize(ctx context.Context, database string) error {
+	if database == a.database && models.UserFromContext(ctx) != a.user {
+		return errors.Wrapf(errors.ErrQueryUnauthorized, "query of database[%s]", database)
+	}
+	return nil
+}
+
+func TestTracker(t *testing.T) {
+	tracker := NewTracker()
+	assert.Empty(t, tracker.List())
+
+	req1 := &models.QueryRequest{Database: "db1"}
+	ctx1, q1 := tracker.Track(context.TODO(), req1)
+	_, q2 := tracker.Track(context.TODO(), &models.QueryRequest{Database: "db2"})
+	assert.NotEqual(t, q1.ID(), q2.ID())
+	addScannedPoints(ctx1, &models.ResultStats{ScannedPoints: 2})
+	addScannedPoints(ctx1, nil)
+	addScannedPoints(context.TODO(), &models.ResultStats{ScannedPoints: 2})
+
+	// sorted by start time
+	queries := tracker.List()
+	assert.Equal(t, 2, len(queries))
+	assert.Equal(t, q1.ID(), queries[0].ID)
+	assert.Equal(t, "db1", queries[0].Database)
+	assert.Equal(t, req1, queries[0].Query)
+	assert.Equal(t, int64(2*models.PointBytes), queries[0].ScannedBytes)
+	assert.True(t, queries[0].StartTime > 0)
+	assert.Equal(t, q2.ID(), queries[1].ID)
+	assert.Equal(t, int64(0), queries[1].ScannedBytes)
+
+	assert.False(t, tracker.Kill("not_exist"))
+	assert.True(t, tracker.Kill(q1.ID()))
+	assert.True(t, q1.Killed())
+	assert.False(t, q2.Killed())
+	assert.NotNil(t, ctx1.Err())
+
+	q1.Finish()
+	q2.Finish()
+	assert.Empty(t, tracker.List())
+	assert.False(t, tracker.Kill(q1.ID()))
+}
+
+func TestControlledExecutor(t *testing.T) {
+	tracker := NewTracker()
+	blocking := &blockingExecutor{started: make(chan struct{}), release: make(chan struct{})}
+	executor := NewControlledExecutor(blocking, tracker, &mockBlacklist{database: "bad"},
+		&mockAuthorizer{database: "private", user: "admin"})
+
+	// blacklisted query isn't executed
+	_, err := executor.Execute(context.TODO(), &models.QueryRequest{Database: "bad"})
+	assert.True(t, errors.Is(err, errors.ErrQueryBlacklisted))
+	assert.Empty(t, tracker.List())
+	// each database of the query across databases is authorized
+	_, err = executor.Execute(context.TODO(), &models.QueryRequest{
+		From: []models.QueryMetric{{Database: "db", Metric: "cpu"}, {Database: "private", Metric: "cpu"}},
+	})
+	assert.True(t, errors.Is(err, errors.ErrQueryUnauthorized))
+	assert.Empty(t, tracker.List())
+
+	// the running query is killed
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := executor.Execute(context.TODO(), &models.QueryRequest{Database: "db"})
+		errCh <- err
+	}()
+	<-blocking.started
+	queries := tracker.List()
+	assert.Equal(t, 1, len(queries))
+	assert.Equal(t, int64(10*models.PointBytes), queries[0].ScannedBytes)
+	assert.True(t, tracker.Kill(queries[0].ID))
+	select {
+	case err := <-errCh:
+		assert.True(t, errors.Is(err, errors.ErrQueryKilled))
+	case <-time.After(time.Second):
+		t.Fatal("killed query isn't stopped")
+	}
+	assert.Empty(t, tracker.List())
+
+	// the query is done without blacklist
+	blocking = &blockingExecutor{started: make(chan struct{}), release: make(chan struct{})}
+	close(blocking.release)
+	executor = NewControlledExecutor(blocking, tracker, nil, &mockAuthorizer{database: "private", user: "admin"})
+	rs, err := executor.Execute(context.TODO(), &models.QueryRequest{Database: "bad"})
+	assert.Nil(t, err)
+	assert.NotNil(t, rs)
+	// the reader of database is authorized
+	blocking = &blockingExecutor{started: make(chan struct{}), release: make(chan struct{})}
+	close(blocking.release)
+	executor = NewControlledExecutor(blocking, tracker, nil, &mockAuthorizer{database: "private", user: "admin"})
+	rs, err = executor.Execute(models.WithUser(context.TODO(), "admin"), &models.QueryRequest{Database: "private"})
+	assert.Nil(t, err)
+	assert.NotNil(t, rs)
+	assert.Empty(t, tracker.List())
+}
+
+func TestBrokerExecutor_trackScannedPoints(t *testing.T) {
+	interval := int64(timeutil.OneMinute)
+	fetcher := newMockFetcher()
+	fetcher.results["cpu"].Stats = &models.ResultStats{ScannedPoints: 8}
+	tracker := NewTracker()
+	ctx, q := tracker.Track(context.TODO(), &models.QueryRequest{Database: "db"})
+	defer q.Finish()
+
+	_, err := NewBrokerExecutor(fetcher, nil).Execute(ctx, &models.QueryRequest{
+		Database: "db",
+		Metric:   "cpu",
+		Fields:   []models.QueryField{{Expr: "used"}},
+		Start:    0,
+		End:      4 * interval,
+		Interval: interval,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(8*models.PointBytes), tracker.List()[0].ScannedBytes)
+}
@@ -0,0 +1,204 @@
+package query
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/sql"
+)
+
+// SubQuery represents the query of one metric which is fanned out from the query across metrics
+type SubQuery struct {
+	MetricName string
+	Fields     []string
+}
+
+// CrossMetricPlan represents the plan of query which references multi metrics in select expressions,
+// 1) fans out one sub query per metric
+// 2) aligns time slots and group by keys of the sub query results
+// 3) evaluates the select expressions on the aligned result set
+type CrossMetricPlan struct {
+	items      []SelectItem
+	groupBy    []string
+	subQueries []SubQuery
+}
+
+// NewCrossMetricPlan creates the plan of query across metrics by select items and group by tag keys
+func NewCrossMetricPlan(groupBy []string, items ...SelectItem) (*CrossMetricPlan, error) {
+	metricFields := make(map[string]map[string]struct{})
+	for _, item := range items {
+		collectMetricFields(item.Expr, metricFields)
+	}
+	if len(metricFields) == 0 {
+		return nil, fmt.Errorf("there is no metric referenced in select expressions")
+	}
+	var subQueries []SubQuery
+	for metricName, fields := range metricFields {
+		subQuery := SubQuery{MetricName: metricName}
+		for field := range fields {
+			subQuery.Fields = append(subQuery.Fields, field)
+		}
+		sort.Strings(subQuery.Fields)
+		subQueries = append(subQueries, subQuery)
+	}
+	sort.Slice(subQueries, func(i, j int) bool {
+		return subQueries[i].MetricName < subQueries[j].MetricName
+	})
+	return &CrossMetricPlan{
+		items:      items,
+		groupBy:    groupBy,
+		subQueries: subQueries,
+	}, nil
+}
+
+// SubQueries returns the sub queries of each metric, sorted by metric name
+func (p *CrossMetricPlan) SubQueries() []SubQuery {
+	return p.subQueries
+}
+
+// TimeRange returns the time range which need be queried for each sub query
+func (p *CrossMetricPlan) TimeRange(timeRange models.TimeRange, interval int64) models.TimeRange {
+	return NewPostAggregation(p.items...).TimeRange(timeRange, interval)
+}
+
+// Apply aligns the results of sub queries(key is metric name),
+// then evaluates the select expressions, returns the result set which starts with the start time.
+// The aligned result set and intermediate values are allocated from arena(nil means heap).
+func (p *CrossMetricPlan) Apply(results map[string]*models.ResultSet, startTime int64,
+	arena *Arena) (*models.ResultSet, error) {
+	rs, err := p.align(results, arena)
+	if err != nil {
+		return nil, err
+	}
+	return NewPostAggregation(p.items...).Apply(rs, startTime, arena)
+}
+
+// align merges the results of sub queries into one result set,
+// the time slots are aligned by the earliest start time, the series are joined by the group by tags,
+// the field's values of each metric are stored by the key of metric and field,
+// the metadata(e.g. partial flags, stats) of the results of sub queries are merged.
+func (p *CrossMetricPlan) align(results map[string]*models.ResultSet, arena *Arena) (*models.ResultSet, error) {
+	var interval, startTime, endTime int64
+	first := true
+	for _, subQuery := range p.subQueries {
+		rs, ok := results[subQuery.MetricName]
+		if !ok || rs == nil {
+			return nil, fmt.Errorf("result of metric[%s] not found", subQuery.MetricName)
+		}
+		if rs.Interval <= 0 {
+			return nil, fmt.Errorf("interval of metric[%s]'s result must be > 0", subQuery.MetricName)
+		}
+		end := rs.StartTime + int64(rs.PointCount)*rs.Interval
+		if first {
+			interval, startTime, endTime = rs.Interval, rs.StartTime, end
+			first = false
+			continue
+		}
+		if rs.Interval != interval {
+			return nil, fmt.Errorf("interval of metric[%s]'s result is different from others", subQuery.MetricName)
+		}
+		if (rs.StartTime-startTime)%interval != 0 {
+			return nil, fmt.Errorf("time slots of metric[%s]'s result cannot be aligned", subQuery.MetricName)
+		}
+		if rs.StartTime < startTime {
+			startTime = rs.StartTime
+		}
+		if end > endTime {
+			endTime = end
+		}
+	}
+	aligned := &models.ResultSet{
+		StartTime:  startTime,
+		Interval:   interval,
+		PointCount: int((endTime - startTime) / interval),
+	}
+	seriesMap := make(map[string]*models.Series)
+	var groupKeys []string
+	for _, subQuery := range p.subQueries {
+		rs := results[subQuery.MetricName]
+		aligned.MergeMetadata(rs)
+		offset := int((rs.StartTime - startTime) / interval)
+		for _, series := range rs.Series {
+			tags := p.groupTags(series.Tags)
+			groupKey := groupByKey(tags, p.groupBy)
+			target, ok := seriesMap[groupKey]
+			if !ok {
+				target = arena.NewSeries(tags)
+				if len(p.subQueries) == 1 {
+					target.Metric = subQuery.MetricName
+				}
+				seriesMap[groupKey] = target
+				groupKeys = append(groupKeys, groupKey)
+			}
+			for _, field := range subQuery.Fields {
+				values, ok := series.Fields[field]
+				if !ok {
+					continue
+				}
+				key := metricFieldKey(subQuery.MetricName, field)
+				targetValues, ok := target.Fields[key]
+				if !ok {
+					targetValues = arena.Values(aligned.PointCount)
+					target.Fields[key] = targetValues
+				}
+				copy(targetValues[offset:], values)
+			}
+		}
+	}
+	sort.Strings(groupKeys)
+	for _, groupKey := range groupKeys {
+		aligned.Series = append(aligned.Series, seriesMap[groupKey])
+	}
+	return aligned, nil
+}
+
+// groupTags returns the tags which only contain group by tag keys
+func (p *CrossMetricPlan) groupTags(tags map[string]string) map[string]string {
+	if len(p.groupBy) == 0 {
+		return nil
+	}
+	result := make(map[string]string)
+	for _, tagKey := range p.groupBy {
+		result[tagKey] = tags[tagKey]
+	}
+	return result
+}
+
+// groupByKey returns the key of series by the values of group by tags(see TagValuesKey)
+func groupByKey(tags map[string]string, groupBy []string) string {
+	values := make([]string, len(groupBy))
+	for i, tagKey := range groupBy {
+		values[i] = tags[tagKey]
+	}
+	return TagValuesKey(values)
+}
+
+// collectMetricFields collects all metric fields referenced by the expression
+func collectMetricFields(expr sql.Expr, metricFields map[string]map[string]struct{}) {
+	switch e := expr.(type) {
+	case *sql.MetricFieldExpr:
+		fields, ok := metricFields[e.Metric]
+		if !ok {
+			fields = make(map[string]struct{})
+			metricFields[e.Metric] = fields
+		}
+		fields[e.Field] = struct{}{}
+	case *sql.BinaryExpr:
+		collectMetricFields(e.Left, metricFields)
+		collectMetricFields(e.Right, metricFields)
+	case *sql.CallExpr:
+		for _, arg := range e.Args {
+			collectMetricFields(arg, metricFields)
+		}
+	case *UDFExpr:
+		for _, arg := range e.Args {
+			collectMetricFields(arg, metricFields)
+		}
+	}
+}
+
+// metricFieldKey returns the key of metric's field in the aligned result set
+func metricFieldKey(metricName, field string) string {
+	return metricName + ":" + field
+}
@@ -0,0 +1,129 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/sql"
+)
+
+func TestNewCrossMetricPlan(t *testing.T) {
+	expr, _ := sql.ParseExpr("errors:count/requests:count*100 + moving_average(errors:sum, 2)")
+	plan, err := NewCrossMetricPlan(nil, SelectItem{Alias: "ratio", Expr: expr})
+	assert.Nil(t, err)
+	assert.Equal(t, []SubQuery{
+		{MetricName: "errors", Fields: []string{"count", "sum"}},
+		{MetricName: "requests", Fields: []string{"count"}},
+	}, plan.SubQueries())
+	interval := int64(timeutil.OneMinute)
+	assert.Equal(t, models.TimeRange{Start: 9 * interval, End: 20 * interval},
+		plan.TimeRange(models.TimeRange{Start: 10 * interval, End: 20 * interval}, interval))
+
+	expr, _ = sql.ParseExpr("a+1")
+	_, err = NewCrossMetricPlan(nil, SelectItem{Alias: "f", Expr: expr})
+	assert.NotNil(t, err)
+}
+
+func TestCrossMetricPlan_Apply(t *testing.T) {
+	interval := int64(timeutil.OneMinute)
+	expr, _ := sql.ParseExpr("errors:count/requests:count*100")
+	plan, _ := NewCrossMetricPlan([]string{"host"}, SelectItem{Alias: "ratio", Expr: expr})
+
+	results := map[string]*models.ResultSet{
+		"errors": {
+			StartTime:  interval,
+			Interval:   interval,
+			PointCount: 3,
+			Series: []*models.Series{
+				{Tags: map[string]string{"host": "a", "disk": "/"}, Fields: map[string][]float64{"count": {1, 2, 3}}},
+				{Tags: map[string]string{"host": "a", "disk": "/home"}, Fields: map[string][]float64{"other": {1, 1, 1}}},
+				{Tags: map[string]string{"host": "c"}, Fields: map[string][]float64{"count": {1, 1, 1}}},
+			},
+		},
+		"requests": {
+			StartTime:  0,
+			Interval:   interval,
+			PointCount: 3,
+			Series: []*models.Series{
+				{Tags: map[string]string{"host": "a"}, Fields: map[string][]float64{"count": {10, 10, 20}}},
+				{Tags: map[string]string{"host": "b"}, Fields: map[string][]float64{"count": {10, 10, 10}}},
+			},
+		},
+	}
+	rs, err := plan.Apply(results, 0, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), rs.StartTime)
+	assert.Equal(t, 4, rs.PointCount)
+	assert.Equal(t, 3, len(rs.Series))
+	assert.Equal(t, map[string]string{"host": "a"}, rs.Series[0].Tags)
+	assertValues(t, []float64{nan, 10, 10, nan}, rs.Series[0].Fields["ratio"])
+	assert.Equal(t, map[string]string{"host": "b"}, rs.Series[1].Tags)
+	assertValues(t, []float64{nan, nan, nan, nan}, rs.Series[1].Fields["ratio"])
+	assert.Equal(t, map[string]string{"host": "c"}, rs.Series[2].Tags)
+	assertValues(t, []float64{nan, nan, nan, nan}, rs.Series[2].Fields["ratio"])
+
+	// the tag values which contain the separator aren't merged
+	plan, _ = NewCrossMetricPlan([]string{"host", "disk"}, SelectItem{Alias: "ratio", Expr: expr})
+	rs, err = plan.Apply(map[string]*models.ResultSet{
+		"errors": {Interval: interval, PointCount: 1, Series: []*models.Series{
+			{Tags: map[string]string{"host": "a,b", "disk": "c"}, Fields: map[string][]float64{"count": {1}}},
+			{Tags: map[string]string{"host": "a", "disk": "b,c"}, Fields: map[string][]float64{"count": {2}}},
+		}},
+		"requests": {Interval: interval, PointCount: 1, Series: []*models.Series{
+			{Tags: map[string]string{"host": "a,b", "disk": "c"}, Fields: map[string][]float64{"count": {10}}},
+		}},
+	}, 0, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rs.Series))
+
+	// without group by, all series are merged into one series
+	plan, _ = NewCrossMetricPlan(nil, SelectItem{Alias: "ratio", Expr: expr})
+	rs, err = plan.Apply(map[string]*models.ResultSet{
+		"errors":   {Interval: interval, PointCount: 2, Series: []*models.Series{{Fields: map[string][]float64{"count": {1, 2}}}}},
+		"requests": {Interval: interval, PointCount: 2, Series: []*models.Series{{Fields: map[string][]float64{"count": {4, 4}}}}},
+	}, 0, NewArena())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(rs.Series))
+	assertValues(t, []float64{25, 50}, rs.Series[0].Fields["ratio"])
+	assert.False(t, rs.Partial)
+	assert.Equal(t, []models.Column{{Name: "ratio", Type: models.FloatValue}}, rs.Columns)
+	// the fields are evaluated from multiple metrics
+	assert.Empty(t, rs.Series[0].Metric)
+
+	// partial result of sub query, the metadata are merged
+	rs, err = plan.Apply(map[string]*models.ResultSet{
+		"errors": {Interval: interval, PointCount: 2, Partial: true, MissingShards: []int{2},
+			Stats: &models.ResultStats{ScannedSeries: 1, ScannedPoints: 2}},
+		"requests": {Interval: interval, PointCount: 2, MissingShards: []int{1},
+			Stats: &models.ResultStats{ScannedSeries: 3, ScannedPoints: 4}},
+	}, 0, nil)
+	assert.Nil(t, err)
+	assert.True(t, rs.Partial)
+	assert.Equal(t, []int{1, 2}, rs.MissingShards)
+	assert.Equal(t, &models.ResultStats{ScannedSeries: 4, ScannedPoints: 6}, rs.Stats)
+
+	// result not found
+	_, err = plan.Apply(map[string]*models.ResultSet{"errors": {Interval: interval}}, 0, nil)
+	assert.NotNil(t, err)
+	// interval is different
+	_, err = plan.Apply(map[string]*models.ResultSet{"errors": {Interval: interval}, "requests": {Interval: 2 * interval}}, 0, nil)
+	assert.NotNil(t, err)
+	// wrong interval
+	_, err = plan.Apply(map[string]*models.ResultSet{"errors": {}, "requests": {Interval: interval}}, 0, nil)
+	assert.NotNil(t, err)
+	// cannot align
+	_, err = plan.Apply(map[string]*models.ResultSet{"errors": {Interval: interval},
+		"requests": {Interval: interval, StartTime: 1}}, 0, nil)
+	assert.NotNil(t, err)
+	// the series of single metric
+	expr, _ = sql.ParseExpr("requests:count")
+	plan, _ = NewCrossMetricPlan(nil, SelectItem{Alias: "count", Expr: expr})
+	rs, err = plan.Apply(map[string]*models.ResultSet{
+		"requests": {Interval: interval, PointCount: 2, Series: []*models.Series{{Fields: map[string][]float64{"count": {4, 4}}}}},
+	}, 0, NewArena())
+	assert.Nil(t, err)
+	assert.Equal(t, "requests", rs.Series[0].Metric)
+}
@@ -0,0 +1,127 @@
+package query
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/sql"
+)
+
+// evalContext represents the context of evaluating expression on a series
+type evalContext struct {
+	series     *models.Series
+	interval   int64
+	pointCount int
+	// arena allocates the values of evaluating, nil means allocating from heap
+	arena *Arena
+}
+
+// eval evaluates the expression of LinQL on the series of context, returns the values of each time slot.
+// The field which not exists in the series is evaluated as all NaN, the arithmetic is done by each time slot,
+// the function is evaluated with the args.
+func eval(ctx *evalContext, expr sql.Expr) ([]float64, error) {
+	switch e := expr.(type) {
+	case *sql.FieldExpr:
+		return evalField(ctx, e.Name), nil
+	case *sql.MetricFieldExpr:
+		return evalField(ctx, metricFieldKey(e.Metric, e.Field)), nil
+	case *sql.NumberExpr:
+		result := ctx.arena.Values(ctx.pointCount)
+		for i := range result {
+			result[i] = e.Value
+		}
+		return result, nil
+	case *sql.DurationExpr:
+		return nil, fmt.Errorf("duration[%dms] cannot be evaluated as series", e.Value)
+	case *sql.BinaryExpr:
+		left, err := eval(ctx, e.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := eval(ctx, e.Right)
+		if err != nil {
+			return nil, err
+		}
+		for i := range left {
+			left[i] = binaryOp(e.Op, left[i], right[i])
+		}
+		return left, nil
+	case *sql.CallExpr:
+		fn, ok := functions[e.Name]
+		if !ok {
+			return nil, fmt.Errorf("function[%s] not support", e.Name)
+		}
+		return fn.eval(ctx, e.Args)
+	case *UDFExpr:
+		return e.Eval(ctx)
+	default:
+		return nil, fmt.Errorf("expression[%s] cannot be evaluated", expr)
+	}
+}
+
+// evalField returns a copy of the field's values, returns all NaN if field not exist
+func evalField(ctx *evalContext, name string) []float64 {
+	result := ctx.arena.Values(ctx.pointCount)
+	if values, ok := ctx.series.Fields[name]; ok {
+		copy(result, values)
+	}
+	return result
+}
+
+// lookback returns how long(ms) the expression needs the data before query start time,
+// which is the max lookback of args plus the lookback of function.
+func lookback(expr sql.Expr, interval int64) int64 {
+	var args []sql.Expr
+	switch e := expr.(type) {
+	case *sql.BinaryExpr:
+		args = []sql.Expr{e.Left, e.Right}
+	case *sql.CallExpr:
+		args = e.Args
+	case *UDFExpr:
+		return e.Lookback(interval)
+	}
+	var result int64
+	for _, arg := range args {
+		if l := lookback(arg, interval); l > result {
+			result = l
+		}
+	}
+	if call, ok := expr.(*sql.CallExpr); ok {
+		if fn, ok := functions[call.Name]; ok {
+			result += fn.lookback(interval, call.Args)
+		}
+	}
+	return result
+}
+
+// binaryOp does arithmetic for two values
+func binaryOp(op sql.BinaryOperator, left, right float64) float64 {
+	if math.IsNaN(left) || math.IsNaN(right) {
+		return math.NaN()
+	}
+	switch op {
+	case sql.ADD:
+		return left + right
+	case sql.SUB:
+		return left - right
+	case sql.MUL:
+		return left * right
+	case sql.DIV:
+		if right == 0 {
+			return math.NaN()
+		}
+		return left / right
+	default:
+		return math.NaN()
+	}
+}
+
+// newValues returns the values which all time slots are NaN
+func newValues(pointCount int) []float64 {
+	values := make([]float64, pointCount)
+	for i := range values {
+		values[i] = math.NaN()
+	}
+	return values
+}
@@ -0,0 +1,106 @@
+package query
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/eleme/lindb/models"
+)
+
+// FillPolicy represents how to fill the time slot which has no data when materializing the final result
+type FillPolicy int
+
+// Defines all fill policies
+const (
+	// FillNull keeps the missing time slot as null(NaN)
+	FillNull FillPolicy = iota
+	// FillZero fills the missing time slot with 0
+	FillZero
+	// FillPrevious fills the missing time slot with the previous value
+	FillPrevious
+	// FillLinear fills the missing time slot with the linear interpolation of the values around it
+	FillLinear
+)
+
+// String returns the name of fill policy
+func (f FillPolicy) String() string {
+	switch f {
+	case FillZero:
+		return "zero"
+	case FillPrevious:
+		return "previous"
+	case FillLinear:
+		return "linear"
+	default:
+		return "null"
+	}
+}
+
+// ParseFillPolicy returns the fill policy by name, empty name means null
+func ParseFillPolicy(name string) (FillPolicy, error) {
+	switch name {
+	case "", "null":
+		return FillNull, nil
+	case "zero":
+		return FillZero, nil
+	case "previous":
+		return FillPrevious, nil
+	case "linear":
+		return FillLinear, nil
+	default:
+		return FillNull, fmt.Errorf("unknown fill policy:%s", name)
+	}
+}
+
+// Apply fills the missing time slots of all series' fields in the result set
+func (f FillPolicy) Apply(rs *models.ResultSet) {
+	if f == FillNull {
+		return
+	}
+	for _, series := range rs.Series {
+		for _, values := range series.Fields {
+			f.fill(values)
+		}
+	}
+}
+
+// fill fills the missing values in place
+func (f FillPolicy) fill(values []float64) {
+	switch f {
+	case FillZero:
+		for i, value := range values {
+			if math.IsNaN(value) {
+				values[i] = 0
+			}
+		}
+	case FillPrevious:
+		prev := math.NaN()
+		for i, value := range values {
+			if math.IsNaN(value) {
+				values[i] = prev
+			} else {
+				prev = value
+			}
+		}
+	case FillLinear:
+		fillLinear(values)
+	}
+}
+
+// fillLinear fills the missing values between two values with linear interpolation,
+// the missing values before the first value or after the last value are not filled.
+func fillLinear(values []float64) {
+	prev := -1
+	for i, value := range values {
+		if math.IsNaN(value) {
+			continue
+		}
+		if prev >= 0 && i-prev > 1 {
+			step := (value - values[prev]) / float64(i-prev)
+			for j := prev + 1; j < i; j++ {
+				values[j] = values[prev] + step*float64(j-prev)
+			}
+		}
+		prev = i
+	}
+}
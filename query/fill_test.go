@@ -0,0 +1,54 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+)
+
+func TestParseFillPolicy(t *testing.T) {
+	for _, policy := range []FillPolicy{FillNull, FillZero, FillPrevious, FillLinear} {
+		p, err := ParseFillPolicy(policy.String())
+		assert.Nil(t, err)
+		assert.Equal(t, policy, p)
+	}
+	p, err := ParseFillPolicy("")
+	assert.Nil(t, err)
+	assert.Equal(t, FillNull, p)
+	_, err = ParseFillPolicy("unknown")
+	assert.NotNil(t, err)
+	assert.Equal(t, "null", FillPolicy(100).String())
+}
+
+func TestFillPolicy_Apply(t *testing.T) {
+	newResultSet := func() *models.ResultSet {
+		return &models.ResultSet{
+			Series: []*models.Series{
+				{Fields: map[string][]float64{
+					"f1": {nan, 1, nan, nan, 4, nan},
+					"f2": {nan, nan},
+				}},
+			},
+		}
+	}
+	rs := newResultSet()
+	FillNull.Apply(rs)
+	assertValues(t, []float64{nan, 1, nan, nan, 4, nan}, rs.Series[0].Fields["f1"])
+
+	rs = newResultSet()
+	FillZero.Apply(rs)
+	assertValues(t, []float64{0, 1, 0, 0, 4, 0}, rs.Series[0].Fields["f1"])
+	assertValues(t, []float64{0, 0}, rs.Series[0].Fields["f2"])
+
+	rs = newResultSet()
+	FillPrevious.Apply(rs)
+	assertValues(t, []float64{nan, 1, 1, 1, 4, 4}, rs.Series[0].Fields["f1"])
+	assertValues(t, []float64{nan, nan}, rs.Series[0].Fields["f2"])
+
+	rs = newResultSet()
+	FillLinear.Apply(rs)
+	assertValues(t, []float64{nan, 1, 2, 3, 4, nan}, rs.Series[0].Fields["f1"])
+	assertValues(t, []float64{nan, nan}, rs.Series[0].Fields["f2"])
+}
@@ -0,0 +1,229 @@
+package query
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/sql"
+)
+
+// Defines all function names which are evaluated on the merged result series
+const (
+	MovingAverage         = "moving_average"
+	Derivative            = "derivative"
+	NonNegativeDerivative = "non_negative_derivative"
+	TimeShift             = "time_shift"
+)
+
+// function represents the post aggregation function evaluated on the merged result series
+type function struct {
+	// eval evaluates the function with args
+	eval func(ctx *evalContext, args []sql.Expr) ([]float64, error)
+	// lookback returns how long(ms) the function needs the data before query start time
+	lookback func(interval int64, args []sql.Expr) int64
+}
+
+// functions represents all supported post aggregation functions,
+// initialized in init because the functions evaluate the args recursively by eval.
+var functions map[string]function
+
+func init() {
+	functions = map[string]function{
+		MovingAverage: {
+			eval:     movingAverage,
+			lookback: movingAverageLookback,
+		},
+		Derivative: {
+			eval: func(ctx *evalContext, args []sql.Expr) ([]float64, error) {
+				return derivative(ctx, args, false)
+			},
+			lookback: derivativeLookback,
+		},
+		NonNegativeDerivative: {
+			eval: func(ctx *evalContext, args []sql.Expr) ([]float64, error) {
+				return derivative(ctx, args, true)
+			},
+			lookback: derivativeLookback,
+		},
+		TimeShift: {
+			eval:     timeShift,
+			lookback: timeShiftLookback,
+		},
+		ZScore: {
+			eval:     zscore,
+			lookback: zscoreLookback,
+		},
+		HoltWinters: {
+			eval: func(ctx *evalContext, args []sql.Expr) ([]float64, error) {
+				return holtWinters(ctx, HoltWinters, args, 0)
+			},
+			lookback: holtWintersLookback,
+		},
+		HoltWintersUpper: {
+			eval: func(ctx *evalContext, args []sql.Expr) ([]float64, error) {
+				return holtWinters(ctx, HoltWintersUpper, args, 1)
+			},
+			lookback: holtWintersLookback,
+		},
+		HoltWintersLower: {
+			eval: func(ctx *evalContext, args []sql.Expr) ([]float64, error) {
+				return holtWinters(ctx, HoltWintersLower, args, -1)
+			},
+			lookback: holtWintersLookback,
+		},
+		SeasonalBaseline: {
+			eval:     seasonalBaseline,
+			lookback: seasonalBaselineLookback,
+		},
+	}
+}
+
+// IsBuiltinFunction checks if the function is builtin post aggregation function
+func IsBuiltinFunction(name string) bool {
+	_, ok := functions[name]
+	return ok
+}
+
+// movingAverage returns the average of the values in the window which contains the last n time slots,
+// usage: moving_average(expr, n)
+func movingAverage(ctx *evalContext, args []sql.Expr) ([]float64, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("function[%s] needs 2 args", MovingAverage)
+	}
+	n, err := intArg(MovingAverage, args[1])
+	if err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		return nil, fmt.Errorf("window size of function[%s] must be > 0", MovingAverage)
+	}
+	values, err := eval(ctx, args[0])
+	if err != nil {
+		return nil, err
+	}
+	result := ctx.arena.Values(len(values))
+	sum := 0.0
+	count := 0
+	for i, value := range values {
+		if !math.IsNaN(value) {
+			sum += value
+			count++
+		}
+		// remove the value which is out of window
+		if i >= n {
+			if old := values[i-n]; !math.IsNaN(old) {
+				sum -= old
+				count--
+			}
+		}
+		if count > 0 {
+			result[i] = sum / float64(count)
+		}
+	}
+	return result, nil
+}
+
+// movingAverageLookback returns the duration of n-1 time slots
+func movingAverageLookback(interval int64, args []sql.Expr) int64 {
+	if len(args) != 2 {
+		return 0
+	}
+	n, err := intArg(MovingAverage, args[1])
+	if err != nil || n <= 1 {
+		return 0
+	}
+	return int64(n-1) * interval
+}
+
+// derivative returns the rate of change between the value and the previous value per unit(default 1s),
+// if nonNegative is true, negative rate(counter reset) is ignored,
+// usage: derivative(expr[, unit]), non_negative_derivative(expr[, unit])
+func derivative(ctx *evalContext, args []sql.Expr, nonNegative bool) ([]float64, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, fmt.Errorf("function[derivative] needs 1 or 2 args")
+	}
+	unit := int64(timeutil.OneSecond)
+	if len(args) == 2 {
+		duration, ok := args[1].(*sql.DurationExpr)
+		if !ok || duration.Value <= 0 {
+			return nil, fmt.Errorf("unit of function[derivative] must be positive duration")
+		}
+		unit = duration.Value
+	}
+	values, err := eval(ctx, args[0])
+	if err != nil {
+		return nil, err
+	}
+	result := ctx.arena.Values(len(values))
+	prev := -1
+	for i, value := range values {
+		if math.IsNaN(value) {
+			continue
+		}
+		if prev >= 0 {
+			elapsed := float64(int64(i-prev)*ctx.interval) / float64(unit)
+			rate := (value - values[prev]) / elapsed
+			if !nonNegative || rate >= 0 {
+				result[i] = rate
+			}
+		}
+		prev = i
+	}
+	return result, nil
+}
+
+// derivativeLookback returns the duration of one time slot
+func derivativeLookback(interval int64, args []sql.Expr) int64 {
+	return interval
+}
+
+// timeShift returns the values of the time slot which is shifted duration before,
+// such as time_shift(expr, 1w) for week-over-week comparisons,
+// usage: time_shift(expr, duration)
+func timeShift(ctx *evalContext, args []sql.Expr) ([]float64, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("function[%s] needs 2 args", TimeShift)
+	}
+	duration, ok := args[1].(*sql.DurationExpr)
+	if !ok {
+		return nil, fmt.Errorf("shift of function[%s] must be duration", TimeShift)
+	}
+	if ctx.interval <= 0 || duration.Value%ctx.interval != 0 {
+		return nil, fmt.Errorf("shift of function[%s] must be multiple of interval[%dms]", TimeShift, ctx.interval)
+	}
+	values, err := eval(ctx, args[0])
+	if err != nil {
+		return nil, err
+	}
+	shift := int(duration.Value / ctx.interval)
+	result := ctx.arena.Values(len(values))
+	for i := range result {
+		idx := i - shift
+		if idx >= 0 && idx < len(values) {
+			result[i] = values[idx]
+		}
+	}
+	return result, nil
+}
+
+// timeShiftLookback returns the shift duration if shift is positive
+func timeShiftLookback(interval int64, args []sql.Expr) int64 {
+	if len(args) != 2 {
+		return 0
+	}
+	duration, ok := args[1].(*sql.DurationExpr)
+	if !ok || duration.Value < 0 {
+		return 0
+	}
+	return duration.Value
+}
+
+// intArg returns the int value of number arg
+func intArg(funcName string, arg sql.Expr) (int, error) {
+	number, ok := arg.(*sql.NumberExpr)
+	if !ok || number.Value != math.Trunc(number.Value) {
+		return 0, fmt.Errorf("param of function[%s] must be integer", funcName)
+	}
+	return int(number.Value), nil
+}
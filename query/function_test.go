@@ -0,0 +1,119 @@
+package query
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/sql"
+)
+
+var nan = math.NaN()
+
+func newTestContext(interval int64, fields map[string][]float64) *evalContext {
+	series := models.NewSeries(nil)
+	pointCount := 0
+	for name, values := range fields {
+		series.Fields[name] = values
+		pointCount = len(values)
+	}
+	return &evalContext{series: series, interval: interval, pointCount: pointCount}
+}
+
+func assertValues(t *testing.T, expect, actual []float64) {
+	assert.Equal(t, len(expect), len(actual))
+	for i := range expect {
+		if math.IsNaN(expect[i]) {
+			assert.True(t, math.IsNaN(actual[i]), "slot %d expect NaN, actual %f", i, actual[i])
+		} else {
+			assert.InDelta(t, expect[i], actual[i], 1e-9, "slot %d", i)
+		}
+	}
+}
+
+func TestMovingAverage(t *testing.T) {
+	ctx := newTestContext(timeutil.OneSecond, map[string][]float64{"f": {1, 2, 3, nan, 5, 6}})
+	values, err := eval(ctx, &sql.CallExpr{Name: MovingAverage, Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, &sql.NumberExpr{Value: 2}}})
+	assert.Nil(t, err)
+	assertValues(t, []float64{1, 1.5, 2.5, 3, 5, 5.5}, values)
+
+	ctx = newTestContext(timeutil.OneSecond, map[string][]float64{"f": {nan, nan, 1}})
+	values, _ = eval(ctx, &sql.CallExpr{Name: MovingAverage, Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, &sql.NumberExpr{Value: 3}}})
+	assertValues(t, []float64{nan, nan, 1}, values)
+
+	_, err = eval(ctx, &sql.CallExpr{Name: MovingAverage, Args: []sql.Expr{&sql.FieldExpr{Name: "f"}}})
+	assert.NotNil(t, err)
+	_, err = eval(ctx, &sql.CallExpr{Name: MovingAverage, Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, &sql.NumberExpr{Value: 1.5}}})
+	assert.NotNil(t, err)
+	_, err = eval(ctx, &sql.CallExpr{Name: MovingAverage, Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, &sql.NumberExpr{Value: 0}}})
+	assert.NotNil(t, err)
+	_, err = eval(ctx, &sql.CallExpr{Name: MovingAverage, Args: []sql.Expr{&sql.DurationExpr{Value: 10}, &sql.NumberExpr{Value: 2}}})
+	assert.NotNil(t, err)
+}
+
+func TestDerivative(t *testing.T) {
+	ctx := newTestContext(10*timeutil.OneSecond, map[string][]float64{"f": {10, 20, nan, 60, 30}})
+	values, err := eval(ctx, &sql.CallExpr{Name: Derivative, Args: []sql.Expr{&sql.FieldExpr{Name: "f"}}})
+	assert.Nil(t, err)
+	assertValues(t, []float64{nan, 1, nan, 2, -3}, values)
+
+	values, err = eval(ctx, &sql.CallExpr{Name: NonNegativeDerivative, Args: []sql.Expr{&sql.FieldExpr{Name: "f"}}})
+	assert.Nil(t, err)
+	assertValues(t, []float64{nan, 1, nan, 2, nan}, values)
+
+	values, err = eval(ctx, &sql.CallExpr{Name: Derivative,
+		Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, &sql.DurationExpr{Value: timeutil.OneMinute}}})
+	assert.Nil(t, err)
+	assertValues(t, []float64{nan, 60, nan, 120, -180}, values)
+
+	_, err = eval(ctx, &sql.CallExpr{Name: Derivative, Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, &sql.NumberExpr{Value: 1}}})
+	assert.NotNil(t, err)
+	_, err = eval(ctx, &sql.CallExpr{Name: Derivative})
+	assert.NotNil(t, err)
+	_, err = eval(ctx, &sql.CallExpr{Name: Derivative, Args: []sql.Expr{&sql.DurationExpr{Value: 1}}})
+	assert.NotNil(t, err)
+}
+
+func TestTimeShift(t *testing.T) {
+	ctx := newTestContext(timeutil.OneMinute, map[string][]float64{"f": {1, 2, 3, 4}})
+	values, err := eval(ctx, &sql.CallExpr{Name: TimeShift,
+		Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, &sql.DurationExpr{Value: 2 * timeutil.OneMinute}}})
+	assert.Nil(t, err)
+	assertValues(t, []float64{nan, nan, 1, 2}, values)
+
+	values, err = eval(ctx, &sql.CallExpr{Name: TimeShift,
+		Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, &sql.DurationExpr{Value: -timeutil.OneMinute}}})
+	assert.Nil(t, err)
+	assertValues(t, []float64{2, 3, 4, nan}, values)
+
+	_, err = eval(ctx, &sql.CallExpr{Name: TimeShift,
+		Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, &sql.DurationExpr{Value: timeutil.OneSecond}}})
+	assert.NotNil(t, err)
+	_, err = eval(ctx, &sql.CallExpr{Name: TimeShift, Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, &sql.NumberExpr{Value: 1}}})
+	assert.NotNil(t, err)
+	_, err = eval(ctx, &sql.CallExpr{Name: TimeShift, Args: []sql.Expr{&sql.FieldExpr{Name: "f"}}})
+	assert.NotNil(t, err)
+	_, err = eval(ctx, &sql.CallExpr{Name: TimeShift,
+		Args: []sql.Expr{&sql.DurationExpr{Value: 1}, &sql.DurationExpr{Value: timeutil.OneMinute}}})
+	assert.NotNil(t, err)
+}
+
+func TestFunction_Lookback(t *testing.T) {
+	interval := int64(timeutil.OneMinute)
+	field := &sql.FieldExpr{Name: "f"}
+	assert.Equal(t, 2*interval, lookback(&sql.CallExpr{Name: MovingAverage, Args: []sql.Expr{field, &sql.NumberExpr{Value: 3}}}, interval))
+	assert.Equal(t, int64(0), lookback(&sql.CallExpr{Name: MovingAverage, Args: []sql.Expr{field}}, interval))
+	assert.Equal(t, interval, lookback(&sql.CallExpr{Name: Derivative, Args: []sql.Expr{field}}, interval))
+	assert.Equal(t, int64(timeutil.OneWeek),
+		lookback(&sql.CallExpr{Name: TimeShift, Args: []sql.Expr{field, &sql.DurationExpr{Value: timeutil.OneWeek}}}, interval))
+	assert.Equal(t, int64(0),
+		lookback(&sql.CallExpr{Name: TimeShift, Args: []sql.Expr{field, &sql.DurationExpr{Value: -timeutil.OneWeek}}}, interval))
+	assert.Equal(t, int64(0), lookback(&sql.CallExpr{Name: TimeShift, Args: []sql.Expr{field}}, interval))
+	// nested function
+	assert.Equal(t, int64(timeutil.OneWeek)+interval, lookback(&sql.CallExpr{Name: Derivative, Args: []sql.Expr{
+		&sql.CallExpr{Name: TimeShift, Args: []sql.Expr{field, &sql.DurationExpr{Value: timeutil.OneWeek}}}}}, interval))
+	assert.Equal(t, int64(0), lookback(&sql.CallExpr{Name: "not_exist", Args: []sql.Expr{field}}, interval))
+}
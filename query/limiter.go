@@ -0,0 +1,148 @@
+package query
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/models"
+)
+
+// LimitKind represents the kind of query execution limit
+type LimitKind string
+
+// Defines all kinds of query execution limit
+const (
+	// SeriesLimit limits the number of series matched
+	SeriesLimit LimitKind = "series"
+	// PointsLimit limits the number of raw points scanned
+	PointsLimit LimitKind = "points"
+	// BytesLimit limits the bytes of response
+	BytesLimit LimitKind = "bytes"
+)
+
+// LimitExceededError represents the error of query exceeds the execution limit
+type LimitExceededError struct {
+	Kind  LimitKind
+	Limit int64
+}
+
+// Error returns the error message
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("query exceeds the limit of %s[%d], result is partial", e.Kind, e.Limit)
+}
+
+// IsLimitExceeded checks if the error is limit exceeded error
+func IsLimitExceeded(err error) bool {
+	_, ok := err.(*LimitExceededError)
+	return ok
+}
+
+// ResultLimiter counts the series matched, raw points scanned and response bytes of query execution
+// on storage node, it's safe to be used by the shards queried concurrently.
+// After any limit exceeded, all following counting fail, the query should stop and return partial result.
+type ResultLimiter struct {
+	limits config.Query
+
+	mutex    sync.Mutex
+	series   int64
+	points   int64
+	bytes    int64
+	exceeded *LimitExceededError
+}
+
+// NewResultLimiter creates the limiter of query execution
+func NewResultLimiter(limits config.Query) *ResultLimiter {
+	return &ResultLimiter{limits: limits}
+}
+
+// AddSeries counts the series matched, returns LimitExceededError if exceeds the limit
+func (l *ResultLimiter) AddSeries(n int64) error {
+	return l.add(&l.series, n, l.limits.MaxSeries, SeriesLimit)
+}
+
+// AddPoints counts the raw points scanned, returns LimitExceededError if exceeds the limit
+func (l *ResultLimiter) AddPoints(n int64) error {
+	return l.add(&l.points, n, l.limits.MaxPoints, PointsLimit)
+}
+
+// AddBytes counts the bytes of response, returns LimitExceededError if exceeds the limit
+func (l *ResultLimiter) AddBytes(n int64) error {
+	return l.add(&l.bytes, n, l.limits.MaxResponseBytes, BytesLimit)
+}
+
+// Err returns the first limit exceeded error, returns nil if not exceeded
+func (l *ResultLimiter) Err() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.exceeded == nil {
+		return nil
+	}
+	return l.exceeded
+}
+
+// Partial returns if the result is partial because of any limit exceeded
+func (l *ResultLimiter) Partial() bool {
+	return l.Err() != nil
+}
+
+// Stats returns the statistics of series matched and raw points scanned
+func (l *ResultLimiter) Stats() *models.ResultStats {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return &models.ResultStats{ScannedSeries: l.series, ScannedPoints: l.points}
+}
+
+// Apply applies the limits on the result set which is built by storage node,
+// the series are kept in order until any limit exceeded, then the result set is marked partial.
+// Returns the limit exceeded error if the result set is truncated.
+func (l *ResultLimiter) Apply(rs *models.ResultSet) error {
+	for idx, series := range rs.Series {
+		if err := l.AddSeries(1); err != nil {
+			l.truncate(rs, idx)
+			return err
+		}
+		if err := l.AddBytes(seriesSize(series)); err != nil {
+			l.truncate(rs, idx)
+			return err
+		}
+	}
+	if err := l.Err(); err != nil {
+		rs.Partial = true
+		return err
+	}
+	return nil
+}
+
+// truncate keeps the first n series of result set, then marks the result set partial
+func (l *ResultLimiter) truncate(rs *models.ResultSet, n int) {
+	rs.Series = rs.Series[:n]
+	rs.Partial = true
+}
+
+// add counts the value, returns error if exceeds the limit(zero means no limit)
+func (l *ResultLimiter) add(counter *int64, n, limit int64, kind LimitKind) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.exceeded != nil {
+		return l.exceeded
+	}
+	if limit > 0 && *counter+n > limit {
+		l.exceeded = &LimitExceededError{Kind: kind, Limit: limit}
+		return l.exceeded
+	}
+	*counter += n
+	return nil
+}
+
+// seriesSize returns the estimated bytes of series in response
+func seriesSize(series *models.Series) int64 {
+	var size int64
+	for key, value := range series.Tags {
+		size += int64(len(key) + len(value))
+	}
+	for field, values := range series.Fields {
+		size += int64(len(field) + 8*len(values))
+	}
+	return size
+}
@@ -0,0 +1,69 @@
+package query
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/models"
+)
+
+func TestResultLimiter(t *testing.T) {
+	limiter := NewResultLimiter(config.Query{MaxSeries: 2, MaxPoints: 100})
+	assert.Nil(t, limiter.AddSeries(2))
+	assert.Nil(t, limiter.AddPoints(100))
+	assert.Nil(t, limiter.AddBytes(1000000))
+	assert.False(t, limiter.Partial())
+	assert.Nil(t, limiter.Err())
+
+	err := limiter.AddPoints(1)
+	assert.True(t, IsLimitExceeded(err))
+	assert.Equal(t, &LimitExceededError{Kind: PointsLimit, Limit: 100}, err)
+	assert.Equal(t, "query exceeds the limit of points[100], result is partial", err.Error())
+	// keep the first error after exceeded
+	assert.Equal(t, err, limiter.AddSeries(1))
+	assert.Equal(t, err, limiter.Err())
+	assert.True(t, limiter.Partial())
+	assert.False(t, IsLimitExceeded(fmt.Errorf("err")))
+}
+
+func TestResultLimiter_Apply(t *testing.T) {
+	newResultSet := func() *models.ResultSet {
+		rs := &models.ResultSet{PointCount: 2}
+		for i := 0; i < 3; i++ {
+			series := models.NewSeries(map[string]string{"host": fmt.Sprintf("%d", i)})
+			series.Fields["f"] = []float64{1, 2}
+			rs.Series = append(rs.Series, series)
+		}
+		return rs
+	}
+	// no limit
+	rs := newResultSet()
+	assert.Nil(t, NewResultLimiter(config.Query{}).Apply(rs))
+	assert.Equal(t, 3, len(rs.Series))
+	assert.False(t, rs.Partial)
+
+	// series limit
+	rs = newResultSet()
+	err := NewResultLimiter(config.Query{MaxSeries: 2}).Apply(rs)
+	assert.Equal(t, &LimitExceededError{Kind: SeriesLimit, Limit: 2}, err)
+	assert.Equal(t, 2, len(rs.Series))
+	assert.True(t, rs.Partial)
+
+	// bytes limit, each series is 5+16 bytes
+	rs = newResultSet()
+	err = NewResultLimiter(config.Query{MaxResponseBytes: 50}).Apply(rs)
+	assert.Equal(t, &LimitExceededError{Kind: BytesLimit, Limit: 50}, err)
+	assert.Equal(t, 2, len(rs.Series))
+	assert.True(t, rs.Partial)
+
+	// points limit exceeded when scanning
+	rs = newResultSet()
+	limiter := NewResultLimiter(config.Query{MaxPoints: 1})
+	_ = limiter.AddPoints(2)
+	assert.True(t, IsLimitExceeded(limiter.Apply(rs)))
+	assert.Equal(t, 0, len(rs.Series))
+	assert.True(t, rs.Partial)
+}
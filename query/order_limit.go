@@ -0,0 +1,207 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/eleme/lindb/models"
+)
+
+// OrderFunc represents how to reduce the values of field to one value for ordering series
+type OrderFunc int
+
+// Defines all order functions
+const (
+	OrderBySum OrderFunc = iota + 1
+	OrderByAvg
+	OrderByMax
+	OrderByMin
+	OrderByLast
+)
+
+// String returns the name of order function
+func (f OrderFunc) String() string {
+	switch f {
+	case OrderBySum:
+		return "sum"
+	case OrderByAvg:
+		return "avg"
+	case OrderByMax:
+		return "max"
+	case OrderByMin:
+		return "min"
+	case OrderByLast:
+		return "last"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseOrderFunc returns the order function by name
+func ParseOrderFunc(name string) (OrderFunc, error) {
+	for _, f := range []OrderFunc{OrderBySum, OrderByAvg, OrderByMax, OrderByMin, OrderByLast} {
+		if f.String() == name {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown order function:%s", name)
+}
+
+// reduce returns the reduced value of the values, NaN values are ignored,
+// returns NaN if there is no value.
+func (f OrderFunc) reduce(values []float64) float64 {
+	result := math.NaN()
+	count := 0
+	for _, value := range values {
+		if math.IsNaN(value) {
+			continue
+		}
+		count++
+		if count == 1 {
+			result = value
+			continue
+		}
+		switch f {
+		case OrderBySum, OrderByAvg:
+			result += value
+		case OrderByMax:
+			result = math.Max(result, value)
+		case OrderByMin:
+			result = math.Min(result, value)
+		case OrderByLast:
+			result = value
+		}
+	}
+	if f == OrderByAvg && count > 0 {
+		result /= float64(count)
+	}
+	return result
+}
+
+// OrderBy represents ordering series by the reduced value of field
+type OrderBy struct {
+	Field string
+	Func  OrderFunc
+	Desc  bool
+}
+
+// OrderLimit represents the stage which orders series of result set, then keeps the series paged by offset and limit.
+// If order by isn't set, series are ordered by tags for stable paging.
+type OrderLimit struct {
+	orderBy *OrderBy
+	offset  int
+	limit   int
+}
+
+// NewOrderLimit creates the order/limit stage, limit <= 0 means no limit
+func NewOrderLimit(orderBy *OrderBy, offset, limit int) *OrderLimit {
+	if offset < 0 {
+		offset = 0
+	}
+	return &OrderLimit{
+		orderBy: orderBy,
+		offset:  offset,
+		limit:   limit,
+	}
+}
+
+// OrderBy returns the order by of stage, nil means the series are ordered by tags
+func (o *OrderLimit) OrderBy() *OrderBy {
+	return o.orderBy
+}
+
+// Limit returns the max number of series after offset, <= 0 means no limit
+func (o *OrderLimit) Limit() int {
+	return o.limit
+}
+
+// PushDown returns the order/limit stage for storage node, which keeps top offset+limit series per node,
+// so that broker only merges a few series from each node instead of all groups.
+// The final order and paging are done at broker after merging.
+func (o *OrderLimit) PushDown() *OrderLimit {
+	limit := o.limit
+	if limit > 0 {
+		limit += o.offset
+	}
+	return NewOrderLimit(o.orderBy, 0, limit)
+}
+
+// seriesEntry represents the series with its order score and tags key
+type seriesEntry struct {
+	series *models.Series
+	score  float64
+	key    string
+}
+
+// Apply orders the series of result set, then keeps the series paged by offset and limit
+func (o *OrderLimit) Apply(rs *models.ResultSet) {
+	entries := make([]seriesEntry, len(rs.Series))
+	for i, s := range rs.Series {
+		entries[i] = seriesEntry{series: s, key: seriesKey(s)}
+		if o.orderBy != nil {
+			entries[i].score = o.orderBy.Func.reduce(s.Fields[o.orderBy.Field])
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return o.less(&entries[i], &entries[j])
+	})
+	if o.offset >= len(entries) {
+		rs.Series = nil
+		return
+	}
+	end := len(entries)
+	if o.limit > 0 && o.offset+o.limit < end {
+		end = o.offset + o.limit
+	}
+	series := make([]*models.Series, 0, end-o.offset)
+	for _, entry := range entries[o.offset:end] {
+		series = append(series, entry.series)
+	}
+	rs.Series = series
+}
+
+// less compares two series by score, NaN score is always ordered at last,
+// series with same score(or without order by) are ordered by tags.
+func (o *OrderLimit) less(left, right *seriesEntry) bool {
+	if o.orderBy != nil {
+		leftNaN, rightNaN := math.IsNaN(left.score), math.IsNaN(right.score)
+		switch {
+		case leftNaN && rightNaN:
+		case leftNaN:
+			return false
+		case rightNaN:
+			return true
+		case left.score != right.score:
+			if o.orderBy.Desc {
+				return left.score > right.score
+			}
+			return left.score < right.score
+		}
+	}
+	return left.key < right.key
+}
+
+// seriesKey returns the key of series which is the database of series(query across databases) with its tags key,
+// so that the series of same tags in different databases are ordered by database
+func seriesKey(series *models.Series) string {
+	return series.Database + "\x00" + tagsKey(series.Tags)
+}
+
+// tagsKey returns the key of tags which is joined by sorted tag key/value
+func tagsKey(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString(key)
+		b.WriteString("=")
+		b.WriteString(tags[key])
+		b.WriteString(",")
+	}
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+)
+
+func TestOrderFunc(t *testing.T) {
+	values := []float64{nan, 3, 1, nan, 2, nan}
+	assert.Equal(t, 6.0, OrderBySum.reduce(values))
+	assert.Equal(t, 2.0, OrderByAvg.reduce(values))
+	assert.Equal(t, 3.0, OrderByMax.reduce(values))
+	assert.Equal(t, 1.0, OrderByMin.reduce(values))
+	assert.Equal(t, 2.0, OrderByLast.reduce(values))
+	assertValues(t, []float64{nan}, []float64{OrderBySum.reduce([]float64{nan})})
+	assertValues(t, []float64{nan}, []float64{OrderByAvg.reduce(nil)})
+
+	for _, f := range []OrderFunc{OrderBySum, OrderByAvg, OrderByMax, OrderByMin, OrderByLast} {
+		f1, err := ParseOrderFunc(f.String())
+		assert.Nil(t, err)
+		assert.Equal(t, f, f1)
+	}
+	_, err := ParseOrderFunc("unknown")
+	assert.NotNil(t, err)
+}
+
+func newOrderTestResultSet() *models.ResultSet {
+	return &models.ResultSet{
+		Series: []*models.Series{
+			{Tags: map[string]string{"host": "a"}, Fields: map[string][]float64{"f": {1, 2}}},
+			{Tags: map[string]string{"host": "b"}, Fields: map[string][]float64{"f": {5, nan}}},
+			{Tags: map[string]string{"host": "c"}, Fields: map[string][]float64{"f": {nan, nan}}},
+			{Tags: map[string]string{"host": "d"}, Fields: map[string][]float64{"f": {0, 3}}},
+			{Tags: map[string]string{"host": "e"}, Fields: map[string][]float64{"other": {10}}},
+		},
+	}
+}
+
+func hosts(rs *models.ResultSet) []string {
+	var result []string
+	for _, series := range rs.Series {
+		result = append(result, series.Tags["host"])
+	}
+	return result
+}
+
+func TestOrderLimit_Apply(t *testing.T) {
+	rs := newOrderTestResultSet()
+	NewOrderLimit(&OrderBy{Field: "f", Func: OrderBySum, Desc: true}, 0, 0).Apply(rs)
+	assert.Equal(t, []string{"b", "a", "d", "c", "e"}, hosts(rs))
+
+	rs = newOrderTestResultSet()
+	NewOrderLimit(&OrderBy{Field: "f", Func: OrderByMax}, 0, 0).Apply(rs)
+	assert.Equal(t, []string{"a", "d", "b", "c", "e"}, hosts(rs))
+
+	rs = newOrderTestResultSet()
+	NewOrderLimit(&OrderBy{Field: "f", Func: OrderBySum, Desc: true}, 1, 2).Apply(rs)
+	assert.Equal(t, []string{"a", "d"}, hosts(rs))
+
+	rs = newOrderTestResultSet()
+	NewOrderLimit(&OrderBy{Field: "f", Func: OrderBySum, Desc: true}, 10, 2).Apply(rs)
+	assert.Nil(t, rs.Series)
+
+	// order by tags without order by
+	rs = newOrderTestResultSet()
+	rs.Series[0], rs.Series[4] = rs.Series[4], rs.Series[0]
+	NewOrderLimit(nil, -1, 3).Apply(rs)
+	assert.Equal(t, []string{"a", "b", "c"}, hosts(rs))
+}
+
+func TestOrderLimit_PushDown(t *testing.T) {
+	orderBy := &OrderBy{Field: "f", Func: OrderBySum, Desc: true}
+	stage := NewOrderLimit(orderBy, 1, 2)
+	pushDown := stage.PushDown()
+	assert.Equal(t, NewOrderLimit(orderBy, 0, 3), pushDown)
+	assert.Equal(t, NewOrderLimit(orderBy, 0, 0), NewOrderLimit(orderBy, 1, 0).PushDown())
+
+	// top n of each node, then final merge at broker
+	node1 := &models.ResultSet{Series: []*models.Series{
+		{Tags: map[string]string{"host": "a"}, Fields: map[string][]float64{"f": {1}}},
+		{Tags: map[string]string{"host": "b"}, Fields: map[string][]float64{"f": {5}}},
+		{Tags: map[string]string{"host": "c"}, Fields: map[string][]float64{"f": {3}}},
+		{Tags: map[string]string{"host": "d"}, Fields: map[string][]float64{"f": {2}}},
+	}}
+	node2 := &models.ResultSet{Series: []*models.Series{
+		{Tags: map[string]string{"host": "e"}, Fields: map[string][]float64{"f": {4}}},
+		{Tags: map[string]string{"host": "f"}, Fields: map[string][]float64{"f": {0}}},
+	}}
+	pushDown.Apply(node1)
+	pushDown.Apply(node2)
+	assert.Equal(t, []string{"b", "c", "d"}, hosts(node1))
+	assert.Equal(t, []string{"e", "f"}, hosts(node2))
+
+	merged := &models.ResultSet{Series: append(node1.Series, node2.Series...)}
+	stage.Apply(merged)
+	assert.Equal(t, []string{"e", "c"}, hosts(merged))
+}
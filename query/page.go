@@ -0,0 +1,104 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/hashers"
+)
+
+// Page represents the cursor-based pagination of the series of result set, the series are ordered
+// deterministically(see OrderLimit), the continue token records the position of next page and the fingerprint
+// of query, so that the token cannot be used by other query. The query is executed for each page,
+// then the client with limited memory iterates the series of large result set page by page.
+type Page struct {
+	size        int
+	offset      int
+	fingerprint uint64
+}
+
+// NewPage creates the pagination of query request, returns nil if the query isn't paginated,
+// returns err if the page size or continue token is invalid.
+func NewPage(req *models.QueryRequest) (*Page, error) {
+	if req.PageSize < 0 {
+		return nil, fmt.Errorf("page size must be >= 0")
+	}
+	if req.PageSize == 0 {
+		if len(req.Continue) > 0 {
+			return nil, fmt.Errorf("page size must be > 0 when continue token is set")
+		}
+		return nil, nil
+	}
+	page := &Page{size: req.PageSize, fingerprint: queryFingerprint(req)}
+	if len(req.Continue) == 0 {
+		return page, nil
+	}
+	offset, fingerprint, err := parseContinueToken(req.Continue)
+	if err != nil {
+		return nil, err
+	}
+	if fingerprint != page.fingerprint {
+		return nil, fmt.Errorf("continue token[%s] doesn't belong to the query", req.Continue)
+	}
+	page.offset = offset
+	return page, nil
+}
+
+// Apply keeps the series of current page, then sets the continue token of next page if more series remain
+func (p *Page) Apply(rs *models.ResultSet) {
+	if p == nil {
+		return
+	}
+	total := len(rs.Series)
+	if p.offset >= total {
+		rs.Series = nil
+		return
+	}
+	end := p.offset + p.size
+	if end >= total {
+		rs.Series = rs.Series[p.offset:]
+		return
+	}
+	rs.Series = rs.Series[p.offset:end]
+	rs.Continue = encodeContinueToken(end, p.fingerprint)
+}
+
+// queryFingerprint returns the hash of query request excluding the pagination
+func queryFingerprint(req *models.QueryRequest) uint64 {
+	q := *req
+	q.PageSize = 0
+	q.Continue = ""
+	data, _ := json.Marshal(&q)
+	return hashers.XXHash64(string(data))
+}
+
+// encodeContinueToken encodes the offset of next page and the fingerprint of query as url safe token
+func encodeContinueToken(offset int, fingerprint uint64) string {
+	token := strconv.Itoa(offset) + ":" + strconv.FormatUint(fingerprint, 16)
+	return base64.RawURLEncoding.EncodeToString([]byte(token))
+}
+
+// parseContinueToken parses the offset of next page and the fingerprint of query from token
+func parseContinueToken(token string) (offset int, fingerprint uint64, err error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid continue token[%s]", token)
+	}
+	parts := strings.Split(string(data), ":")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid continue token[%s]", token)
+	}
+	offset, err = strconv.Atoi(parts[0])
+	if err != nil || offset < 0 {
+		return 0, 0, fmt.Errorf("invalid offset of continue token[%s]", token)
+	}
+	fingerprint, err = strconv.ParseUint(parts[1], 16, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid fingerprint of continue token[%s]", token)
+	}
+	return offset, fingerprint, nil
+}
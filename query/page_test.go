@@ -0,0 +1,77 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+)
+
+func newPagedResultSet(seriesCount int) *models.ResultSet {
+	rs := &models.ResultSet{}
+	for i := 0; i < seriesCount; i++ {
+		rs.Series = append(rs.Series, models.NewSeries(nil))
+	}
+	return rs
+}
+
+func TestNewPage(t *testing.T) {
+	req := &models.QueryRequest{Database: "db", Metric: "cpu"}
+	page, err := NewPage(req)
+	assert.Nil(t, err)
+	assert.Nil(t, page)
+	// nil page keeps all series
+	rs := newPagedResultSet(3)
+	page.Apply(rs)
+	assert.Len(t, rs.Series, 3)
+
+	req.PageSize = -1
+	_, err = NewPage(req)
+	assert.NotNil(t, err)
+	req.PageSize = 0
+	req.Continue = encodeContinueToken(1, queryFingerprint(req))
+	_, err = NewPage(req)
+	assert.NotNil(t, err)
+
+	req.PageSize = 2
+	for _, token := range []string{"!", "MQ", "YToxMA", "MTph"} {
+		req.Continue = token
+		_, err = NewPage(req)
+		assert.NotNil(t, err)
+	}
+	// the token of other query
+	req.Continue = encodeContinueToken(1, queryFingerprint(&models.QueryRequest{Database: "db", Metric: "mem"}))
+	_, err = NewPage(req)
+	assert.NotNil(t, err)
+}
+
+func TestPage_Apply(t *testing.T) {
+	req := &models.QueryRequest{Database: "db", Metric: "cpu", PageSize: 2}
+	page, err := NewPage(req)
+	assert.Nil(t, err)
+	all := newPagedResultSet(5)
+	rs := &models.ResultSet{Series: all.Series}
+	page.Apply(rs)
+	assert.Equal(t, all.Series[:2], rs.Series)
+	assert.NotEmpty(t, rs.Continue)
+
+	// the token is bound to the query excluding the pagination
+	req.PageSize = 3
+	req.Continue = rs.Continue
+	page, err = NewPage(req)
+	assert.Nil(t, err)
+	rs = &models.ResultSet{Series: all.Series}
+	page.Apply(rs)
+	assert.Equal(t, all.Series[2:], rs.Series)
+	assert.Empty(t, rs.Continue)
+
+	// offset exceeds the series
+	req.Continue = encodeContinueToken(10, queryFingerprint(req))
+	page, err = NewPage(req)
+	assert.Nil(t, err)
+	rs = &models.ResultSet{Series: all.Series}
+	page.Apply(rs)
+	assert.Empty(t, rs.Series)
+	assert.Empty(t, rs.Continue)
+}
@@ -0,0 +1,351 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/eleme/lindb/models"
+	aggregationpb "github.com/eleme/lindb/rpc/proto/aggregation"
+)
+
+// PartialResultVersion is the format version of partial result built by this node,
+// the partial result of newer version is merged as well, because the unknown fields are ignored.
+const PartialResultVersion = 1
+
+// MergePartialResults merges the partial aggregation results of storage nodes into one,
+// the series are joined by the tag values of group by, the values of same time slot are aggregated
+// by the aggregator type of column. The results must be of the same metric, time range, interval and group by.
+func MergePartialResults(results []*aggregationpb.PartialResult) (*aggregationpb.PartialResult, error) {
+	merger := NewPartialResultMerger(false)
+	for _, result := range results {
+		if err := merger.Add(result); err != nil {
+			return nil, err
+		}
+	}
+	return merger.Result(), nil
+}
+
+// MergePartialResultStream merges the partial results as they arrive from storage nodes until the channel is closed,
+// so that broker doesn't wait for all responses and only keeps the merged result in memory.
+// If the groups are shard-local(see NewPartialResultMerger), the series of results are concatenated without joining.
+func MergePartialResultStream(ctx context.Context, results <-chan *aggregationpb.PartialResult,
+	shardLocal bool) (*aggregationpb.PartialResult, error) {
+	merger := NewPartialResultMerger(shardLocal)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case result, ok := <-results:
+			if !ok {
+				return merger.Result(), nil
+			}
+			if err := merger.Add(result); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// PartialResultMerger merges the partial results incrementally, each result is merged into the merged result
+// when it arrives, then it can be released. The columns of series are merged by time slot in order,
+// because the slots of column are sorted.
+type PartialResultMerger struct {
+	// shardLocal means the series of each group are on the same shard, so the group is in only one result
+	shardLocal    bool
+	mutex         sync.Mutex
+	merged        *aggregationpb.PartialResult
+	series        map[string]*aggregationpb.Series
+	missingShards map[int32]struct{}
+}
+
+// NewPartialResultMerger creates the merger of partial results, if the groups are shard-local(see
+// models.DatabaseCluster.IsShardLocal), the series are appended directly instead of being joined by tag values,
+// because the group is returned by only one storage node which owns the shard of group.
+func NewPartialResultMerger(shardLocal bool) *PartialResultMerger {
+	return &PartialResultMerger{
+		shardLocal:    shardLocal,
+		series:        make(map[string]*aggregationpb.Series),
+		missingShards: make(map[int32]struct{}),
+	}
+}
+
+// Add merges the partial result into the merged result, it's safe to be called concurrently
+func (m *PartialResultMerger) Add(result *aggregationpb.PartialResult) error {
+	if result == nil {
+		return nil
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.merged == nil {
+		m.merged = &aggregationpb.PartialResult{
+			Version:    PartialResultVersion,
+			MetricName: result.MetricName,
+			StartTime:  result.StartTime,
+			EndTime:    result.EndTime,
+			Interval:   result.Interval,
+			GroupBy:    result.GroupBy,
+			Complete:   true,
+		}
+	} else if err := checkPartialResult(m.merged, result); err != nil {
+		return err
+	}
+	m.merged.Complete = m.merged.Complete && result.Complete
+	for _, shardID := range result.MissingShards {
+		m.missingShards[shardID] = struct{}{}
+	}
+	if m.shardLocal {
+		m.merged.Series = append(m.merged.Series, result.Series...)
+		return nil
+	}
+	for _, series := range result.Series {
+		key := TagValuesKey(series.TagValues)
+		target, ok := m.series[key]
+		if !ok {
+			target = &aggregationpb.Series{TagValues: series.TagValues}
+			m.series[key] = target
+			m.merged.Series = append(m.merged.Series, target)
+		}
+		if err := mergeSeries(target, series); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Result returns the merged result, returns nil if no result is added
+func (m *PartialResultMerger) Result() *aggregationpb.PartialResult {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.merged == nil {
+		return nil
+	}
+	m.merged.MissingShards = m.merged.MissingShards[:0]
+	for shardID := range m.missingShards {
+		m.merged.MissingShards = append(m.merged.MissingShards, shardID)
+	}
+	sort.Slice(m.merged.MissingShards, func(i, j int) bool {
+		return m.merged.MissingShards[i] < m.merged.MissingShards[j]
+	})
+	return m.merged
+}
+
+// PartialResultToResultSet converts the partial result into the result set, the tags of series are built
+// by the group by tag keys, the values are converted into float, the value types are kept in the columns.
+func PartialResultToResultSet(result *aggregationpb.PartialResult) *models.ResultSet {
+	interval := result.Interval
+	if interval <= 0 {
+		interval = 1
+	}
+	rs := &models.ResultSet{
+		StartTime:  result.StartTime,
+		Interval:   interval,
+		PointCount: int((result.EndTime-result.StartTime)/interval) + 1,
+		Partial:    !result.Complete,
+	}
+	for _, shardID := range result.MissingShards {
+		rs.MissingShards = append(rs.MissingShards, int(shardID))
+	}
+	for _, series := range result.Series {
+		var tags map[string]string
+		if len(result.GroupBy) > 0 {
+			tags = make(map[string]string, len(result.GroupBy))
+			for idx, tagKey := range result.GroupBy {
+				if idx < len(series.TagValues) {
+					tags[tagKey] = series.TagValues[idx]
+				}
+			}
+		}
+		target := models.NewSeries(tags)
+		target.Metric = result.MetricName
+		for _, column := range series.Columns {
+			if column.ValueType == aggregationpb.ValueType_Integer {
+				rs.AddColumn(column.FieldName, models.IntegerValue)
+			} else {
+				rs.AddColumn(column.FieldName, models.FloatValue)
+			}
+			values := newValues(rs.PointCount)
+			for idx, slot := range column.Slots {
+				if slot < 0 || int(slot) >= rs.PointCount {
+					continue
+				}
+				if column.ValueType == aggregationpb.ValueType_Integer {
+					if idx < len(column.IntValues) {
+						values[slot] = float64(column.IntValues[idx])
+					}
+				} else if idx < len(column.FloatValues) {
+					values[slot] = column.FloatValues[idx]
+				}
+			}
+			target.Fields[column.FieldName] = values
+		}
+		rs.Series = append(rs.Series, target)
+	}
+	return rs
+}
+
+// TagValuesKey returns the key of the tag values of group by, each value is prefixed by its length,
+// so that the tag values which contain the separator never share the same key, like [a,b] and [a b].
+func TagValuesKey(tagValues []string) string {
+	var b strings.Builder
+	for _, value := range tagValues {
+		b.WriteString(strconv.Itoa(len(value)))
+		b.WriteByte(':')
+		b.WriteString(value)
+	}
+	return b.String()
+}
+
+// checkPartialResult checks if the partial result can be merged into the merged result
+func checkPartialResult(merged, result *aggregationpb.PartialResult) error {
+	if merged.MetricName != result.MetricName {
+		return fmt.Errorf("cannot merge partial results of metric[%s] and metric[%s]",
+			merged.MetricName, result.MetricName)
+	}
+	if merged.StartTime != result.StartTime || merged.EndTime != result.EndTime || merged.Interval != result.Interval {
+		return fmt.Errorf("cannot merge partial results of metric[%s] with different time range or interval",
+			merged.MetricName)
+	}
+	if TagValuesKey(merged.GroupBy) != TagValuesKey(result.GroupBy) {
+		return fmt.Errorf("cannot merge partial results of metric[%s] with different group by", merged.MetricName)
+	}
+	return nil
+}
+
+// mergeSeries merges the columns of series into the target series
+func mergeSeries(target, series *aggregationpb.Series) error {
+	for _, column := range series.Columns {
+		var targetColumn *aggregationpb.Column
+		for _, c := range target.Columns {
+			if c.FieldName == column.FieldName {
+				targetColumn = c
+				break
+			}
+		}
+		if targetColumn == nil {
+			targetColumn = &aggregationpb.Column{
+				FieldName: column.FieldName,
+				AggType:   column.AggType,
+				ValueType: column.ValueType,
+			}
+			target.Columns = append(target.Columns, targetColumn)
+		}
+		if err := mergeColumn(targetColumn, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeColumn merges the values of column into the target column by time slot in order,
+// the values of same slot are aggregated by the aggregator type.
+func mergeColumn(target, column *aggregationpb.Column) error {
+	if target.AggType != column.AggType || target.ValueType != column.ValueType {
+		return fmt.Errorf("cannot merge column[%s] of different aggregator type or value type", column.FieldName)
+	}
+	isInt := column.ValueType == aggregationpb.ValueType_Integer
+	if (isInt && len(column.IntValues) != len(column.Slots)) ||
+		(!isInt && len(column.FloatValues) != len(column.Slots)) {
+		return fmt.Errorf("the values of column[%s] don't match the slots", column.FieldName)
+	}
+	slots, idx := sortSlots(column.Slots)
+	var (
+		mergedSlots  = make([]int32, 0, len(target.Slots)+len(slots))
+		mergedInts   []int64
+		mergedFloats []float64
+	)
+	appendValue := func(slot int32, from *aggregationpb.Column, i int) {
+		mergedSlots = append(mergedSlots, slot)
+		if isInt {
+			mergedInts = append(mergedInts, from.IntValues[i])
+		} else {
+			mergedFloats = append(mergedFloats, from.FloatValues[i])
+		}
+	}
+	i, j := 0, 0
+	for i < len(target.Slots) || j < len(slots) {
+		switch {
+		case j == len(slots) || (i < len(target.Slots) && target.Slots[i] < slots[j]):
+			appendValue(target.Slots[i], target, i)
+			i++
+		case i == len(target.Slots) || slots[j] < target.Slots[i]:
+			appendValue(slots[j], column, idx[j])
+			j++
+		default:
+			// same time slot, aggregates the values
+			mergedSlots = append(mergedSlots, slots[j])
+			if isInt {
+				mergedInts = append(mergedInts, aggregateInt(target.AggType, target.IntValues[i], column.IntValues[idx[j]]))
+			} else {
+				mergedFloats = append(mergedFloats,
+					aggregateFloat(target.AggType, target.FloatValues[i], column.FloatValues[idx[j]]))
+			}
+			i++
+			j++
+		}
+	}
+	target.Slots = mergedSlots
+	target.IntValues = mergedInts
+	target.FloatValues = mergedFloats
+	return nil
+}
+
+// sortSlots returns the sorted slots with the indexes of values, the slots are sorted by storage node usually,
+// then no copy is made.
+func sortSlots(slots []int32) (sorted []int32, idx []int) {
+	idx = make([]int, len(slots))
+	for i := range idx {
+		idx[i] = i
+	}
+	if sort.SliceIsSorted(slots, func(i, j int) bool { return slots[i] < slots[j] }) {
+		return slots, idx
+	}
+	sort.Slice(idx, func(i, j int) bool {
+		return slots[idx[i]] < slots[idx[j]]
+	})
+	sorted = make([]int32, len(slots))
+	for i, k := range idx {
+		sorted[i] = slots[k]
+	}
+	return sorted, idx
+}
+
+// aggregateInt aggregates two values by aggregator type, the value of later one is kept for unknown type
+func aggregateInt(aggType aggregationpb.AggType, a, b int64) int64 {
+	switch aggType {
+	case aggregationpb.AggType_Sum, aggregationpb.AggType_Count:
+		return a + b
+	case aggregationpb.AggType_Min:
+		if b < a {
+			return b
+		}
+		return a
+	case aggregationpb.AggType_Max:
+		if b > a {
+			return b
+		}
+		return a
+	default:
+		return b
+	}
+}
+
+// aggregateFloat aggregates two values by aggregator type, the value of later one is kept for unknown type
+func aggregateFloat(aggType aggregationpb.AggType, a, b float64) float64 {
+	switch aggType {
+	case aggregationpb.AggType_Sum, aggregationpb.AggType_Count:
+		return a + b
+	case aggregationpb.AggType_Min:
+		return math.Min(a, b)
+	case aggregationpb.AggType_Max:
+		return math.Max(a, b)
+	default:
+		return b
+	}
+}
@@ -0,0 +1,243 @@
+package query
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+	aggregationpb "github.com/eleme/lindb/rpc/proto/aggregation"
+)
+
+func newPartialResult(complete bool, missingShards []int32, series ...*aggregationpb.Series) *aggregationpb.PartialResult {
+	return &aggregationpb.PartialResult{
+		Version:       PartialResultVersion,
+		MetricName:    "cpu",
+		StartTime:     1000,
+		EndTime:       1030,
+		Interval:      10,
+		GroupBy:       []string{"host"},
+		Series:        series,
+		Complete:      complete,
+		MissingShards: missingShards,
+	}
+}
+
+func TestMergePartialResults(t *testing.T) {
+	merged, err := MergePartialResults(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, merged)
+
+	r1 := newPartialResult(true, nil,
+		&aggregationpb.Series{TagValues: []string{"1.1.1.1"}, Columns: []*aggregationpb.Column{
+			{FieldName: "count", AggType: aggregationpb.AggType_Sum, ValueType: aggregationpb.ValueType_Integer,
+				Slots: []int32{0, 2}, IntValues: []int64{1, 2}},
+			{FieldName: "max", AggType: aggregationpb.AggType_Max, ValueType: aggregationpb.ValueType_Float,
+				Slots: []int32{1}, FloatValues: []float64{5}},
+		}})
+	r2 := newPartialResult(false, []int32{3, 1},
+		&aggregationpb.Series{TagValues: []string{"1.1.1.1"}, Columns: []*aggregationpb.Column{
+			{FieldName: "count", AggType: aggregationpb.AggType_Sum, ValueType: aggregationpb.ValueType_Integer,
+				Slots: []int32{2, 1}, IntValues: []int64{10, 20}},
+			{FieldName: "max", AggType: aggregationpb.AggType_Max, ValueType: aggregationpb.ValueType_Float,
+				Slots: []int32{1}, FloatValues: []float64{3}},
+		}},
+		&aggregationpb.Series{TagValues: []string{"2.2.2.2"}, Columns: []*aggregationpb.Column{
+			{FieldName: "min", AggType: aggregationpb.AggType_Min, ValueType: aggregationpb.ValueType_Float,
+				Slots: []int32{0}, FloatValues: []float64{7}},
+		}})
+	merged, err = MergePartialResults([]*aggregationpb.PartialResult{r1, nil, r2})
+	assert.Nil(t, err)
+	assert.False(t, merged.Complete)
+	assert.Equal(t, []int32{1, 3}, merged.MissingShards)
+	assert.Len(t, merged.Series, 2)
+	assert.Equal(t, &aggregationpb.Column{FieldName: "count", AggType: aggregationpb.AggType_Sum,
+		ValueType: aggregationpb.ValueType_Integer, Slots: []int32{0, 1, 2}, IntValues: []int64{1, 20, 12}},
+		merged.Series[0].Columns[0])
+	assert.Equal(t, []float64{5}, merged.Series[0].Columns[1].FloatValues)
+	assert.Equal(t, []float64{7}, merged.Series[1].Columns[0].FloatValues)
+
+	rs := PartialResultToResultSet(merged)
+	assert.True(t, rs.Partial)
+	assert.Equal(t, 4, rs.PointCount)
+	assert.Len(t, rs.Series, 2)
+	assert.Equal(t, map[string]string{"host": "1.1.1.1"}, rs.Series[0].Tags)
+	assert.Equal(t, 20.0, rs.Series[0].Fields["count"][1])
+	assert.True(t, math.IsNaN(rs.Series[0].Fields["count"][3]))
+	assert.Equal(t, 5.0, rs.Series[0].Fields["max"][1])
+	assert.Equal(t, "cpu", rs.Series[0].Metric)
+	assert.Equal(t, []int{1, 3}, rs.MissingShards)
+	assert.Equal(t, []models.Column{
+		{Name: "count", Type: models.IntegerValue},
+		{Name: "max", Type: models.FloatValue},
+		{Name: "min", Type: models.FloatValue},
+	}, rs.Columns)
+}
+
+func TestMergePartialResults_separatorInTagValues(t *testing.T) {
+	newResult := func(groupBy []string, tagValues ...string) *aggregationpb.PartialResult {
+		result := newPartialResult(true, nil, &aggregationpb.Series{TagValues: tagValues, Columns: []*aggregationpb.Column{
+			{FieldName: "count", AggType: aggregationpb.AggType_Sum, ValueType: aggregationpb.ValueType_Integer,
+				Slots: []int32{0}, IntValues: []int64{1}},
+		}})
+		result.GroupBy = groupBy
+		return result
+	}
+	merged, err := MergePartialResults([]*aggregationpb.PartialResult{
+		newResult([]string{"host", "disk"}, "a,b", "c"),
+		newResult([]string{"host", "disk"}, "a", "b,c"),
+	})
+	assert.Nil(t, err)
+	assert.Len(t, merged.Series, 2)
+
+	// the group by which joins into same string
+	_, err = MergePartialResults([]*aggregationpb.PartialResult{
+		newResult([]string{"host,disk"}, "a"),
+		newResult([]string{"host", "disk"}, "a", "b"),
+	})
+	assert.NotNil(t, err)
+}
+
+func TestTagValuesKey(t *testing.T) {
+	assert.Equal(t, "", TagValuesKey(nil))
+	assert.Equal(t, "3:a,b1:c", TagValuesKey([]string{"a,b", "c"}))
+	assert.NotEqual(t, TagValuesKey([]string{"a,b", "c"}), TagValuesKey([]string{"a", "b,c"}))
+	assert.NotEqual(t, TagValuesKey([]string{"", "a"}), TagValuesKey([]string{"a", ""}))
+}
+
+func TestMergePartialResults_mismatch(t *testing.T) {
+	r1 := newPartialResult(true, nil)
+	for _, r2 := range []*aggregationpb.PartialResult{
+		{MetricName: "memory", StartTime: 1000, EndTime: 1030, Interval: 10, GroupBy: []string{"host"}},
+		{MetricName: "cpu", StartTime: 1000, EndTime: 1030, Interval: 20, GroupBy: []string{"host"}},
+		{MetricName: "cpu", StartTime: 1000, EndTime: 1030, Interval: 10},
+	} {
+		_, err := MergePartialResults([]*aggregationpb.PartialResult{r1, r2})
+		assert.NotNil(t, err)
+	}
+
+	// different aggregator type
+	column := &aggregationpb.Column{FieldName: "f", AggType: aggregationpb.AggType_Sum, Slots: []int32{0},
+		FloatValues: []float64{1}}
+	_, err := MergePartialResults([]*aggregationpb.PartialResult{
+		newPartialResult(true, nil, &aggregationpb.Series{Columns: []*aggregationpb.Column{column}}),
+		newPartialResult(true, nil, &aggregationpb.Series{Columns: []*aggregationpb.Column{
+			{FieldName: "f", AggType: aggregationpb.AggType_Max, Slots: []int32{0}, FloatValues: []float64{1}}}}),
+	})
+	assert.NotNil(t, err)
+	// values don't match slots
+	for _, column := range []*aggregationpb.Column{
+		{FieldName: "f", AggType: aggregationpb.AggType_Sum, Slots: []int32{0}},
+		{FieldName: "f", AggType: aggregationpb.AggType_Sum, ValueType: aggregationpb.ValueType_Integer,
+			Slots: []int32{0}},
+	} {
+		_, err = MergePartialResults([]*aggregationpb.PartialResult{
+			newPartialResult(true, nil, &aggregationpb.Series{Columns: []*aggregationpb.Column{column}}),
+		})
+		assert.NotNil(t, err)
+	}
+}
+
+func TestMergePartialResultStream(t *testing.T) {
+	results := make(chan *aggregationpb.PartialResult, 3)
+	for i := 0; i < 3; i++ {
+		results <- newPartialResult(true, []int32{int32(i)},
+			&aggregationpb.Series{TagValues: []string{"1.1.1.1"}, Columns: []*aggregationpb.Column{
+				{FieldName: "count", AggType: aggregationpb.AggType_Sum, ValueType: aggregationpb.ValueType_Integer,
+					Slots: []int32{int32(i), 3}, IntValues: []int64{1, 1}},
+			}})
+	}
+	close(results)
+	merged, err := MergePartialResultStream(context.TODO(), results, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []int32{0, 1, 2}, merged.MissingShards)
+	assert.Equal(t, []int32{0, 1, 2, 3}, merged.Series[0].Columns[0].Slots)
+	assert.Equal(t, []int64{1, 1, 1, 3}, merged.Series[0].Columns[0].IntValues)
+
+	// merge error
+	results = make(chan *aggregationpb.PartialResult, 2)
+	results <- newPartialResult(true, nil)
+	results <- &aggregationpb.PartialResult{MetricName: "memory"}
+	_, err = MergePartialResultStream(context.TODO(), results, false)
+	assert.NotNil(t, err)
+
+	// canceled
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	_, err = MergePartialResultStream(ctx, make(chan *aggregationpb.PartialResult), false)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestPartialResultMerger_shardLocal(t *testing.T) {
+	merger := NewPartialResultMerger(true)
+	for _, host := range []string{"1.1.1.1", "2.2.2.2"} {
+		assert.Nil(t, merger.Add(newPartialResult(true, nil,
+			&aggregationpb.Series{TagValues: []string{host}, Columns: []*aggregationpb.Column{
+				{FieldName: "count", AggType: aggregationpb.AggType_Sum, ValueType: aggregationpb.ValueType_Integer,
+					Slots: []int32{0}, IntValues: []int64{1}},
+			}})))
+	}
+	merged := merger.Result()
+	assert.Len(t, merged.Series, 2)
+	assert.Equal(t, []string{"1.1.1.1"}, merged.Series[0].TagValues)
+	assert.Equal(t, []string{"2.2.2.2"}, merged.Series[1].TagValues)
+	// the results must be of the same metric
+	assert.NotNil(t, merger.Add(&aggregationpb.PartialResult{MetricName: "memory"}))
+}
+
+func TestPartialResultMerger_concurrent(t *testing.T) {
+	merger := NewPartialResultMerger(false)
+	assert.Nil(t, merger.Result())
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(slot int32) {
+			defer wg.Done()
+			err := merger.Add(newPartialResult(true, nil,
+				&aggregationpb.Series{TagValues: []string{"1.1.1.1"}, Columns: []*aggregationpb.Column{
+					{FieldName: "max", AggType: aggregationpb.AggType_Max, ValueType: aggregationpb.ValueType_Float,
+						Slots: []int32{slot % 3}, FloatValues: []float64{float64(slot)}},
+				}}))
+			assert.Nil(t, err)
+		}(int32(i))
+	}
+	wg.Wait()
+	merged := merger.Result()
+	assert.True(t, merged.Complete)
+	assert.Equal(t, []int32{0, 1, 2}, merged.Series[0].Columns[0].Slots)
+	assert.Equal(t, []float64{9, 7, 8}, merged.Series[0].Columns[0].FloatValues)
+}
+
+func TestPartialResult_marshal(t *testing.T) {
+	result := newPartialResult(true, []int32{1},
+		&aggregationpb.Series{TagValues: []string{"1.1.1.1"}, Columns: []*aggregationpb.Column{
+			{FieldName: "count", AggType: aggregationpb.AggType_Sum, ValueType: aggregationpb.ValueType_Integer,
+				Slots: []int32{0, 2}, IntValues: []int64{1, -2}},
+		}})
+	data, err := proto.Marshal(result)
+	assert.Nil(t, err)
+	// unknown fields of newer version are skipped
+	data = append(data, proto.EncodeVarint(uint64(100<<3))...)
+	data = append(data, proto.EncodeVarint(1)...)
+	decoded := &aggregationpb.PartialResult{}
+	assert.Nil(t, proto.Unmarshal(data, decoded))
+	assert.Equal(t, result.Series, decoded.Series)
+	assert.Equal(t, result.MissingShards, decoded.MissingShards)
+}
+
+func TestAggregate(t *testing.T) {
+	assert.Equal(t, int64(3), aggregateInt(aggregationpb.AggType_Count, 1, 2))
+	assert.Equal(t, int64(1), aggregateInt(aggregationpb.AggType_Min, 1, 2))
+	assert.Equal(t, int64(1), aggregateInt(aggregationpb.AggType_Min, 2, 1))
+	assert.Equal(t, int64(2), aggregateInt(aggregationpb.AggType_Max, 1, 2))
+	assert.Equal(t, int64(2), aggregateInt(aggregationpb.AggType_Max, 2, 1))
+	assert.Equal(t, int64(2), aggregateInt(aggregationpb.AggType_UnknownAgg, 1, 2))
+	assert.Equal(t, 3.0, aggregateFloat(aggregationpb.AggType_Sum, 1, 2))
+	assert.Equal(t, 1.0, aggregateFloat(aggregationpb.AggType_Min, 1, 2))
+	assert.Equal(t, 2.0, aggregateFloat(aggregationpb.AggType_Max, 1, 2))
+	assert.Equal(t, 2.0, aggregateFloat(aggregationpb.AggType_UnknownAgg, 1, 2))
+}
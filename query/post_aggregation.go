@@ -0,0 +1,93 @@
+package query
+
+import (
+	"fmt"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/sql"
+)
+
+// SelectItem represents the select field expression with alias in the query
+type SelectItem struct {
+	Alias string
+	Expr  sql.Expr
+}
+
+// PostAggregation represents the function stage of broker executor,
+// which evaluates the select expressions(functions/arithmetic) on the merged result set.
+type PostAggregation struct {
+	items []SelectItem
+}
+
+// NewPostAggregation creates the post aggregation stage with select items
+func NewPostAggregation(items ...SelectItem) *PostAggregation {
+	return &PostAggregation{
+		items: items,
+	}
+}
+
+// TimeRange returns the time range which need be queried from storage,
+// the start time is extended by the max lookback of select expressions, aligned by interval.
+func (p *PostAggregation) TimeRange(timeRange models.TimeRange, interval int64) models.TimeRange {
+	var maxLookback int64
+	for _, item := range p.items {
+		if l := lookback(item.Expr, interval); l > maxLookback {
+			maxLookback = l
+		}
+	}
+	if interval > 0 && maxLookback%interval != 0 {
+		maxLookback = (maxLookback/interval + 1) * interval
+	}
+	return models.TimeRange{Start: timeRange.Start - maxLookback, End: timeRange.End}
+}
+
+// Apply evaluates the select expressions on each series of the merged result set,
+// then returns the new result set which starts with the start time of query,
+// each series of new result set only contains the fields of select items, which are float columns.
+// The intermediate values of evaluating are allocated from arena(nil means heap),
+// the values of new result set are allocated from heap, which can be used after the arena released.
+func (p *PostAggregation) Apply(rs *models.ResultSet, startTime int64, arena *Arena) (*models.ResultSet, error) {
+	if rs.Interval <= 0 {
+		return nil, fmt.Errorf("interval of result set must be > 0")
+	}
+	diff := startTime - rs.StartTime
+	if diff < 0 || diff%rs.Interval != 0 {
+		return nil, fmt.Errorf("start time[%d] isn't aligned with result set", startTime)
+	}
+	skip := int(diff / rs.Interval)
+	if skip > rs.PointCount {
+		skip = rs.PointCount
+	}
+	result := &models.ResultSet{
+		StartTime:  startTime,
+		Interval:   rs.Interval,
+		PointCount: rs.PointCount - skip,
+	}
+	result.MergeMetadata(rs)
+	for _, item := range p.items {
+		result.AddColumn(item.Alias, models.FloatValue)
+	}
+	for _, series := range rs.Series {
+		ctx := &evalContext{
+			series:     series,
+			interval:   rs.Interval,
+			pointCount: rs.PointCount,
+			arena:      arena,
+		}
+		newSeries := models.NewSeries(series.Tags)
+		newSeries.Metric = series.Metric
+		for _, item := range p.items {
+			values, err := eval(ctx, item.Expr)
+			if err != nil {
+				return nil, fmt.Errorf("evaluate expression[%s] error:%s", item.Expr, err)
+			}
+			values = values[skip:]
+			if arena != nil {
+				values = append([]float64(nil), values...)
+			}
+			newSeries.Fields[item.Alias] = values
+		}
+		result.Series = append(result.Series, newSeries)
+	}
+	return result, nil
+}
@@ -0,0 +1,122 @@
+package query
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/sql"
+)
+
+func TestBinaryExpr(t *testing.T) {
+	ctx := newTestContext(timeutil.OneSecond, map[string][]float64{
+		"a": {1, 2, nan, 4},
+		"b": {2, 0, 1, 2},
+	})
+	a := &sql.FieldExpr{Name: "a"}
+	b := &sql.FieldExpr{Name: "b"}
+	values, _ := eval(ctx, &sql.BinaryExpr{Op: sql.ADD, Left: a, Right: b})
+	assertValues(t, []float64{3, 2, nan, 6}, values)
+	values, _ = eval(ctx, &sql.BinaryExpr{Op: sql.SUB, Left: a, Right: b})
+	assertValues(t, []float64{-1, 2, nan, 2}, values)
+	values, _ = eval(ctx, &sql.BinaryExpr{Op: sql.MUL, Left: a, Right: &sql.NumberExpr{Value: 100}})
+	assertValues(t, []float64{100, 200, nan, 400}, values)
+	values, _ = eval(ctx, &sql.BinaryExpr{Op: sql.DIV, Left: a, Right: b})
+	assertValues(t, []float64{0.5, nan, nan, 2}, values)
+	values, _ = eval(ctx, &sql.BinaryExpr{Op: sql.BinaryOperator(100), Left: a, Right: b})
+	assertValues(t, []float64{nan, nan, nan, nan}, values)
+	// field not exist
+	values, _ = eval(ctx, &sql.FieldExpr{Name: "c"})
+	assertValues(t, []float64{nan, nan, nan, nan}, values)
+	// source values cannot be modified
+	assertValues(t, []float64{1, 2, nan, 4}, ctx.series.Fields["a"])
+
+	_, err := eval(ctx, &sql.BinaryExpr{Op: sql.ADD, Left: &sql.DurationExpr{Value: 1}, Right: b})
+	assert.NotNil(t, err)
+	_, err = eval(ctx, &sql.BinaryExpr{Op: sql.ADD, Left: a, Right: &sql.DurationExpr{Value: 1}})
+	assert.NotNil(t, err)
+	_, err = eval(ctx, &sql.CallExpr{Name: "not_exist"})
+	assert.NotNil(t, err)
+
+	expr := &sql.BinaryExpr{Op: sql.MUL, Left: &sql.BinaryExpr{Op: sql.DIV, Left: a, Right: b}, Right: &sql.NumberExpr{Value: 100}}
+	assert.Equal(t, "((a/b)*100)", expr.String())
+	assert.Equal(t, "time_shift(a,60000ms)",
+		(&sql.CallExpr{Name: TimeShift, Args: []sql.Expr{a, &sql.DurationExpr{Value: timeutil.OneMinute}}}).String())
+	assert.Equal(t, "unknown", sql.BinaryOperator(100).String())
+}
+
+func TestPostAggregation_TimeRange(t *testing.T) {
+	interval := int64(timeutil.OneMinute)
+	timeRange := models.TimeRange{Start: 10 * interval, End: 20 * interval}
+	stage := NewPostAggregation(SelectItem{Alias: "f", Expr: &sql.FieldExpr{Name: "f"}})
+	assert.Equal(t, timeRange, stage.TimeRange(timeRange, interval))
+
+	stage = NewPostAggregation(
+		SelectItem{Alias: "f", Expr: &sql.FieldExpr{Name: "f"}},
+		SelectItem{Alias: "avg", Expr: &sql.CallExpr{Name: MovingAverage, Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, &sql.NumberExpr{Value: 3}}}},
+		SelectItem{Alias: "shift", Expr: &sql.CallExpr{Name: TimeShift,
+			Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, &sql.DurationExpr{Value: 2*timeutil.OneMinute + timeutil.OneSecond}}}},
+	)
+	// lookback aligned by interval
+	assert.Equal(t, models.TimeRange{Start: 7 * interval, End: 20 * interval}, stage.TimeRange(timeRange, interval))
+}
+
+func TestPostAggregation_Apply(t *testing.T) {
+	interval := int64(timeutil.OneDay)
+	rs := &models.ResultSet{
+		StartTime:  0,
+		Interval:   interval,
+		PointCount: 10,
+		Series: []*models.Series{
+			{
+				Tags: map[string]string{"host": "1.1.1.1"},
+				Fields: map[string][]float64{
+					"errors":   {1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+					"requests": {10, 10, 10, 10, 10, 10, 10, 10, 10, 0},
+				},
+			},
+		},
+	}
+	stage := NewPostAggregation(
+		SelectItem{Alias: "ratio", Expr: &sql.BinaryExpr{Op: sql.MUL,
+			Left:  &sql.BinaryExpr{Op: sql.DIV, Left: &sql.FieldExpr{Name: "errors"}, Right: &sql.FieldExpr{Name: "requests"}},
+			Right: &sql.NumberExpr{Value: 100}}},
+		SelectItem{Alias: "wow", Expr: &sql.BinaryExpr{Op: sql.SUB,
+			Left:  &sql.FieldExpr{Name: "errors"},
+			Right: &sql.CallExpr{Name: TimeShift, Args: []sql.Expr{&sql.FieldExpr{Name: "errors"}, &sql.DurationExpr{Value: timeutil.OneWeek}}}}},
+	)
+	timeRange := stage.TimeRange(models.TimeRange{Start: 7 * interval, End: 9 * interval}, interval)
+	assert.Equal(t, int64(0), timeRange.Start)
+
+	result, err := stage.Apply(rs, 7*interval, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 7*interval, result.StartTime)
+	assert.Equal(t, interval, result.Interval)
+	assert.Equal(t, 3, result.PointCount)
+	assert.Equal(t, 1, len(result.Series))
+	series := result.Series[0]
+	assert.Equal(t, map[string]string{"host": "1.1.1.1"}, series.Tags)
+	assert.Equal(t, 2, len(series.Fields))
+	assertValues(t, []float64{80, 90, math.NaN()}, series.Fields["ratio"])
+	assertValues(t, []float64{7, 7, 7}, series.Fields["wow"])
+
+	// start time not aligned
+	_, err = stage.Apply(rs, 1, nil)
+	assert.NotNil(t, err)
+	_, err = stage.Apply(rs, -interval, nil)
+	assert.NotNil(t, err)
+	// start time after result set
+	result, err = stage.Apply(rs, 20*interval, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, result.PointCount)
+	// wrong interval
+	_, err = stage.Apply(&models.ResultSet{}, 0, nil)
+	assert.NotNil(t, err)
+	// evaluate error
+	stage = NewPostAggregation(SelectItem{Alias: "f", Expr: &sql.CallExpr{Name: "not_exist"}})
+	_, err = stage.Apply(rs, 0, nil)
+	assert.NotNil(t, err)
+}
@@ -1,38 +1,90 @@
 package query
 
 import (
+	"context"
 	"fmt"
+	"math"
+	"sort"
 
 	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/interval"
 	"github.com/eleme/lindb/tsdb"
+	"github.com/eleme/lindb/tsdb/index"
 )
 
+// TSDBExecutor represents the executor which queries the shards of tsdb engine on storage node
+type TSDBExecutor interface {
+	Executor
+	// Result returns the merged result set of all shards after executed, returns the error of execution
+	Result() (*models.ResultSet, error)
+	// SetMinSequences sets the min sequences of shards which the query requires(read-your-writes),
+	// the execution waits until the writes of sequences are visible in shards before scanning,
+	// fails if ctx done before that
+	SetMinSequences(ctx context.Context, token models.SequenceToken)
+}
+
+// SegmentScanner scans the data of query in the snapshot of segment, returns the result set of segment,
+// the time slots of result set are based on the query interval. It's called by multiple workers concurrently,
+// each segment is scanned by one worker, the raw points scanned should be counted by limiter.
+// The series and values of result set should be allocated from arena, which is released after merged.
+type SegmentScanner interface {
+	Scan(segment tsdb.SegmentSnapshot, query models.Query, limiter *ResultLimiter,
+		arena *Arena) (*models.ResultSet, error)
+}
+
+// scanTask represents the scan of one segment in the snapshot of shard
+type scanTask struct {
+	shardIdx int
+	segment  tsdb.SegmentSnapshot
+}
+
 // tsdbExecute represents execution search logic in tsdb level,
 // does query task async, then merge result, such as map-reduce job
 type tsdbExecute struct {
-	engine   tsdb.Engine
-	query    models.Query
-	shardIDs []int
+	engine       tsdb.Engine
+	query        models.Query
+	shardIDs     []int
+	intervalType interval.Type
+	scanner      SegmentScanner
+	// limiter limits the series matched/points scanned/response bytes of shards' execution
+	limiter *ResultLimiter
+	// pool executes the scan tasks of segments concurrently
+	pool *workerPool
+	// arena allocates the intermediate results of segments, released after merged
+	arena *Arena
+	// minSequences are the sequences of shards which the writes are waited for, until waitCtx done
+	minSequences models.SequenceToken
+	waitCtx      context.Context
 
 	shards []tsdb.Shard
+	// snapshots are the snapshots of shards acquired before scanning, released after merged
+	snapshots []tsdb.Snapshot
+	tasks     []scanTask
 
-	err error
+	result *models.ResultSet
+	err    error
 }
 
-// NewTSDBExecutor creates execution which queries tsdb storage
-func NewTSDBExecutor(engine tsdb.Engine, shardIDs []int, query models.Query) Executor {
+// NewTSDBExecutor creates execution which queries tsdb storage with the limits of execution,
+// the segments of interval type in shards are scanned by scanner with max concurrency(number of cpu cores if <= 0)
+func NewTSDBExecutor(engine tsdb.Engine, shardIDs []int, query models.Query, intervalType interval.Type,
+	scanner SegmentScanner, limiter *ResultLimiter, concurrency int) TSDBExecutor {
 	return &tsdbExecute{
-		engine:   engine,
-		shardIDs: shardIDs,
-		query:    query,
+		engine:       engine,
+		shardIDs:     shardIDs,
+		query:        query,
+		intervalType: intervalType,
+		scanner:      scanner,
+		limiter:      limiter,
+		pool:         newWorkerPool(concurrency),
 	}
 }
 
 // Execute executes search logic in tsdb level,
 // 1) valition input params
-// 2) build execute plan
-// 3) build execute pipeline
-// 4) run pipeline
+// 2) acquire the snapshot of each shard, build scan task for each segment of snapshots
+// 3) scan segments concurrently by bounded workers
+// 4) merge the results of segments in task order, then release the snapshots
 func (e *tsdbExecute) Execute() {
 	// do query validation
 	if err := e.validation(); err != nil {
@@ -54,6 +106,162 @@ func (e *tsdbExecute) Execute() {
 		e.err = err
 		return
 	}
+	if err := e.waitSequences(); err != nil {
+		e.err = err
+		return
+	}
+
+	defer e.closeSnapshots()
+	if err := e.buildScanTasks(); err != nil {
+		e.err = err
+		return
+	}
+	e.arena = NewArena()
+	defer e.arena.Release()
+	results, err := e.pool.Run(len(e.tasks), e.scan)
+	if err != nil {
+		e.err = err
+		return
+	}
+	e.result = e.merge(results)
+	if e.limiter != nil && !e.limiter.Partial() {
+		// the series of merged result set are counted by limiter, truncates the result set if exceeds
+		_ = e.limiter.Apply(e.result)
+	}
+}
+
+// Result returns the merged result set of all shards after executed, returns the error of execution
+func (e *tsdbExecute) Result() (*models.ResultSet, error) {
+	return e.result, e.err
+}
+
+// SetMinSequences sets the min sequences of shards which the query requires
+func (e *tsdbExecute) SetMinSequences(ctx context.Context, token models.SequenceToken) {
+	e.waitCtx = ctx
+	e.minSequences = token
+}
+
+// waitSequences waits until the writes of min sequences are visible in shards
+func (e *tsdbExecute) waitSequences() error {
+	if len(e.minSequences) == 0 {
+		return nil
+	}
+	for idx, shard := range e.shards {
+		shardID := e.shardIDs[idx]
+		sequence, ok := e.minSequences[shardID]
+		if !ok {
+			continue
+		}
+		if err := shard.WaitForSequence(e.waitCtx, sequence); err != nil {
+			return fmt.Errorf("wait for the writes of sequence[%d] visible in shard[%d] error:%s, current sequence:%d",
+				sequence, shardID, err, shard.Sequence())
+		}
+	}
+	return nil
+}
+
+// buildScanTasks acquires the snapshot of each shard, so that the flush or compaction during the query
+// doesn't change the data and index read by scanning, then builds a scan task for each segment of snapshots.
+// The tasks are ordered by the base time of segment and the order of shard,
+// so that the merged result is same as sequential scan.
+func (e *tsdbExecute) buildScanTasks() error {
+	timeRange := e.query.TimeRange()
+	for shardIdx, shardID := range e.shardIDs {
+		snapshot, err := e.engine.GetSnapshot(shardID, e.intervalType, timeRange)
+		if err != nil {
+			return fmt.Errorf("acquire snapshot of shard[%d] error:%s", shardID, err)
+		}
+		e.snapshots = append(e.snapshots, snapshot)
+		for _, segment := range snapshot.Segments() {
+			e.tasks = append(e.tasks, scanTask{shardIdx: shardIdx, segment: segment})
+		}
+	}
+	sort.SliceStable(e.tasks, func(i, j int) bool {
+		left, right := e.tasks[i], e.tasks[j]
+		if left.segment.BaseTime() != right.segment.BaseTime() {
+			return left.segment.BaseTime() < right.segment.BaseTime()
+		}
+		return left.shardIdx < right.shardIdx
+	})
+	return nil
+}
+
+// closeSnapshots releases the snapshots of shards after the results of segments merged
+func (e *tsdbExecute) closeSnapshots() {
+	for _, snapshot := range e.snapshots {
+		snapshot.Close()
+	}
+}
+
+// scan scans the segment of idx-th task, skips the task if the query exceeds the limits already,
+// the limit exceeded error of scanner doesn't fail the query, the result is partial.
+func (e *tsdbExecute) scan(idx int) (*models.ResultSet, error) {
+	if e.limiter != nil && e.limiter.Partial() {
+		return nil, nil
+	}
+	rs, err := e.scanner.Scan(e.tasks[idx].segment, e.query, e.limiter, e.arena)
+	if err != nil && !IsLimitExceeded(err) {
+		return nil, fmt.Errorf("scan segment[%d] of shard[%d] error:%s",
+			e.tasks[idx].segment.BaseTime(), e.shardIDs[e.tasks[idx].shardIdx], err)
+	}
+	return rs, nil
+}
+
+// merge merges the result sets of tasks in task order, the time slots are based on query's time range,
+// the series are joined by tags, the values of later task overwrite the earlier one in same time slot.
+// The stats of merged result set are counted by limiter if set.
+// The merged result set is allocated from heap, which can be used after the arena released.
+func (e *tsdbExecute) merge(results []*models.ResultSet) *models.ResultSet {
+	timeRange := e.query.TimeRange()
+	interval := e.query.Interval().Nanoseconds() / 1e6
+	if interval <= 0 {
+		interval = 1
+	}
+	merged := &models.ResultSet{
+		StartTime:  timeRange.Start,
+		Interval:   interval,
+		PointCount: int((timeRange.End-timeRange.Start)/interval) + 1,
+		Partial:    e.limiter != nil && e.limiter.Partial(),
+	}
+	seriesMap := make(map[string]*models.Series)
+	for _, rs := range results {
+		if rs == nil {
+			continue
+		}
+		merged.MergeMetadata(rs)
+		for _, column := range rs.Columns {
+			merged.AddColumn(column.Name, column.Type)
+		}
+		offset := int((rs.StartTime - merged.StartTime) / interval)
+		for _, series := range rs.Series {
+			key := index.MapToString(series.Tags)
+			target, ok := seriesMap[key]
+			if !ok {
+				target = models.NewSeries(series.Tags)
+				target.Metric = e.query.MetricName()
+				seriesMap[key] = target
+				merged.Series = append(merged.Series, target)
+			}
+			for field, values := range series.Fields {
+				targetValues, ok := target.Fields[field]
+				if !ok {
+					targetValues = newValues(merged.PointCount)
+					target.Fields[field] = targetValues
+				}
+				for i, value := range values {
+					slot := offset + i
+					if slot < 0 || slot >= merged.PointCount || math.IsNaN(value) {
+						continue
+					}
+					targetValues[slot] = value
+				}
+			}
+		}
+	}
+	if e.limiter != nil {
+		merged.Stats = e.limiter.Stats()
+	}
+	return merged
 }
 
 // validation validates query input params and tsdb data are valid
@@ -66,6 +274,10 @@ func (e *tsdbExecute) validation() error {
 	if e.engine.NumOfShards() == 0 {
 		return fmt.Errorf("tsdb engine[%s] hasn't shard", e.engine.Name())
 	}
+	// check segment scanner if set
+	if e.scanner == nil {
+		return fmt.Errorf("there is no segment scanner of tsdb engine[%s]", e.engine.Name())
+	}
 	return nil
 }
 
@@ -0,0 +1,241 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/interval"
+	"github.com/eleme/lindb/tsdb"
+)
+
+// testQuery implements models.Query for testing
+type testQuery struct {
+	timeRange models.TimeRange
+	interval  time.Duration
+}
+
+func (q *testQuery) MetricName() string {
+	return "cpu"
+}
+
+func (q *testQuery) TimeRange() models.TimeRange {
+	return q.timeRange
+}
+
+func (q *testQuery) Interval() time.Duration {
+	return q.interval
+}
+
+// testScanner returns a point of host for each segment at the base time of segment
+type testScanner struct {
+	mutex   sync.Mutex
+	scanned []int64
+	err     error
+}
+
+func (s *testScanner) Scan(segment tsdb.SegmentSnapshot, query models.Query, limiter *ResultLimiter,
+	arena *Arena) (*models.ResultSet, error) {
+	baseTime := segment.BaseTime()
+	// the earlier segment completes later
+	time.Sleep(time.Duration(10-baseTime/10) * time.Millisecond)
+	s.mutex.Lock()
+	s.scanned = append(s.scanned, baseTime)
+	s.mutex.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	if err := limiter.AddPoints(1); err != nil {
+		return nil, err
+	}
+	series := arena.NewSeries(map[string]string{"host": "1.1.1.1"})
+	values := arena.Values(1)
+	values[0] = float64(baseTime)
+	series.Fields["f1"] = values
+	return &models.ResultSet{StartTime: baseTime, Interval: 10, PointCount: 1, Series: []*models.Series{series},
+		Columns: []models.Column{{Name: "f1", Type: models.IntegerValue}}}, nil
+}
+
+func newTestSnapshot(ctrl *gomock.Controller, baseTimes ...int64) *tsdb.MockSnapshot {
+	var segments []tsdb.SegmentSnapshot
+	for _, baseTime := range baseTimes {
+		segment := tsdb.NewMockSegmentSnapshot(ctrl)
+		segment.EXPECT().BaseTime().Return(baseTime).AnyTimes()
+		segments = append(segments, segment)
+	}
+	snapshot := tsdb.NewMockSnapshot(ctrl)
+	snapshot.EXPECT().Segments().Return(segments).AnyTimes()
+	return snapshot
+}
+
+func newTestEngine(ctrl *gomock.Controller) tsdb.Engine {
+	engine := tsdb.NewMockEngine(ctrl)
+	engine.EXPECT().Name().Return("db").AnyTimes()
+	engine.EXPECT().NumOfShards().Return(2).AnyTimes()
+	snapshot1 := newTestSnapshot(ctrl, 40, 0, 20)
+	snapshot1.EXPECT().Close().AnyTimes()
+	engine.EXPECT().GetSnapshot(1, interval.Day, gomock.Any()).Return(snapshot1, nil).AnyTimes()
+	snapshot2 := newTestSnapshot(ctrl, 30, 10)
+	snapshot2.EXPECT().Close().AnyTimes()
+	engine.EXPECT().GetSnapshot(2, interval.Day, gomock.Any()).Return(snapshot2, nil).AnyTimes()
+	shard1 := tsdb.NewMockShard(ctrl)
+	shard2 := tsdb.NewMockShard(ctrl)
+	engine.EXPECT().GetShard(1).Return(shard1).AnyTimes()
+	engine.EXPECT().GetShard(2).Return(shard2).AnyTimes()
+	engine.EXPECT().GetShard(gomock.Any()).Return(nil).AnyTimes()
+	return engine
+}
+
+func TestTSDBExecute_Execute(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	engine := newTestEngine(ctrl)
+	query := &testQuery{timeRange: models.TimeRange{Start: 0, End: 40}, interval: 10 * time.Millisecond}
+	scanner := &testScanner{}
+	exec := NewTSDBExecutor(engine, []int{1, 2}, query, interval.Day, scanner, NewResultLimiter(config.Query{}), 2)
+	exec.Execute()
+	rs, err := exec.Result()
+	assert.Nil(t, err)
+	assert.Len(t, scanner.scanned, 5)
+	assert.Equal(t, int64(0), rs.StartTime)
+	assert.Equal(t, int64(10), rs.Interval)
+	assert.Equal(t, 5, rs.PointCount)
+	assert.False(t, rs.Partial)
+	assert.Len(t, rs.Series, 1)
+	assert.Equal(t, []float64{0, 10, 20, 30, 40}, rs.Series[0].Fields["f1"])
+	assert.Equal(t, "cpu", rs.Series[0].Metric)
+	assert.Equal(t, []models.Column{{Name: "f1", Type: models.IntegerValue}}, rs.Columns)
+	assert.Equal(t, &models.ResultStats{ScannedPoints: 5}, rs.Stats)
+
+	// points limit exceeded, result is partial
+	exec = NewTSDBExecutor(engine, []int{1, 2}, query, interval.Day, &testScanner{},
+		NewResultLimiter(config.Query{MaxPoints: 2}), 1)
+	exec.Execute()
+	rs, err = exec.Result()
+	assert.Nil(t, err)
+	assert.True(t, rs.Partial)
+	values := rs.Series[0].Fields["f1"]
+	assert.Equal(t, []float64{0, 10}, values[:2])
+	assert.True(t, math.IsNaN(values[4]))
+
+	// scan error
+	exec = NewTSDBExecutor(engine, []int{1, 2}, query, interval.Day, &testScanner{err: fmt.Errorf("err")},
+		NewResultLimiter(config.Query{}), 0)
+	exec.Execute()
+	rs, err = exec.Result()
+	assert.NotNil(t, err)
+	assert.Nil(t, rs)
+}
+
+func TestTSDBExecute_SetMinSequences(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	engine := tsdb.NewMockEngine(ctrl)
+	engine.EXPECT().NumOfShards().Return(2).AnyTimes()
+	snapshot := newTestSnapshot(ctrl, 0)
+	snapshot.EXPECT().Close().AnyTimes()
+	engine.EXPECT().GetSnapshot(1, interval.Day, gomock.Any()).Return(snapshot, nil).AnyTimes()
+	shard1 := tsdb.NewMockShard(ctrl)
+	shard2 := tsdb.NewMockShard(ctrl)
+	engine.EXPECT().GetShard(1).Return(shard1).AnyTimes()
+	engine.EXPECT().GetShard(2).Return(shard2).AnyTimes()
+	query := &testQuery{timeRange: models.TimeRange{Start: 0, End: 40}, interval: 10 * time.Millisecond}
+
+	// only the shards in token are waited for
+	ctx := context.TODO()
+	shard1.EXPECT().WaitForSequence(ctx, int64(10)).Return(nil)
+	emptySnapshot := newTestSnapshot(ctrl)
+	emptySnapshot.EXPECT().Close()
+	engine.EXPECT().GetSnapshot(2, interval.Day, gomock.Any()).Return(emptySnapshot, nil)
+	exec := NewTSDBExecutor(engine, []int{1, 2}, query, interval.Day, &testScanner{},
+		NewResultLimiter(config.Query{}), 0)
+	exec.SetMinSequences(ctx, models.SequenceToken{1: 10, 3: 1})
+	exec.Execute()
+	rs, err := exec.Result()
+	assert.Nil(t, err)
+	assert.NotNil(t, rs)
+
+	// the writes of sequence aren't visible before ctx done
+	shard1.EXPECT().WaitForSequence(ctx, int64(10)).Return(context.DeadlineExceeded)
+	shard1.EXPECT().Sequence().Return(int64(8))
+	exec = NewTSDBExecutor(engine, []int{1, 2}, query, interval.Day, &testScanner{},
+		NewResultLimiter(config.Query{}), 0)
+	exec.SetMinSequences(ctx, models.SequenceToken{1: 10})
+	exec.Execute()
+	_, err = exec.Result()
+	assert.NotNil(t, err)
+}
+
+func TestTSDBExecute_Execute_snapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	engine := tsdb.NewMockEngine(ctrl)
+	engine.EXPECT().NumOfShards().Return(2).AnyTimes()
+	engine.EXPECT().GetShard(gomock.Any()).Return(tsdb.NewMockShard(ctrl)).AnyTimes()
+	query := &testQuery{timeRange: models.TimeRange{Start: 0, End: 40}, interval: 10 * time.Millisecond}
+
+	// the snapshots are released after the results merged
+	snapshot1 := newTestSnapshot(ctrl, 0)
+	snapshot2 := newTestSnapshot(ctrl, 10)
+	gomock.InOrder(
+		engine.EXPECT().GetSnapshot(1, interval.Day, query.TimeRange()).Return(snapshot1, nil),
+		engine.EXPECT().GetSnapshot(2, interval.Day, query.TimeRange()).Return(snapshot2, nil),
+		snapshot1.EXPECT().Close(),
+		snapshot2.EXPECT().Close(),
+	)
+	scanner := &testScanner{}
+	exec := NewTSDBExecutor(engine, []int{1, 2}, query, interval.Day, scanner, NewResultLimiter(config.Query{}), 0)
+	exec.Execute()
+	rs, err := exec.Result()
+	assert.Nil(t, err)
+	assert.Len(t, rs.Series, 1)
+	assert.Len(t, scanner.scanned, 2)
+
+	// the snapshots acquired are released if fail to acquire the others
+	snapshot1 = newTestSnapshot(ctrl, 0)
+	gomock.InOrder(
+		engine.EXPECT().GetSnapshot(1, interval.Day, query.TimeRange()).Return(snapshot1, nil),
+		engine.EXPECT().GetSnapshot(2, interval.Day, query.TimeRange()).Return(nil, fmt.Errorf("err")),
+		snapshot1.EXPECT().Close(),
+	)
+	scanner = &testScanner{}
+	exec = NewTSDBExecutor(engine, []int{1, 2}, query, interval.Day, scanner, NewResultLimiter(config.Query{}), 0)
+	exec.Execute()
+	_, err = exec.Result()
+	assert.NotNil(t, err)
+	assert.Empty(t, scanner.scanned)
+}
+
+func TestTSDBExecute_Execute_validation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	engine := newTestEngine(ctrl)
+	query := &testQuery{timeRange: models.TimeRange{Start: 0, End: 40}, interval: 10 * time.Millisecond}
+	cases := []struct {
+		shardIDs []int
+		scanner  SegmentScanner
+	}{
+		{shardIDs: nil, scanner: &testScanner{}},
+		{shardIDs: []int{1}, scanner: nil},
+		{shardIDs: []int{3}, scanner: &testScanner{}},
+		{shardIDs: []int{1, 3}, scanner: &testScanner{}},
+	}
+	for _, c := range cases {
+		exec := NewTSDBExecutor(engine, c.shardIDs, query, interval.Day, c.scanner, nil, 0)
+		exec.Execute()
+		_, err := exec.Result()
+		assert.NotNil(t, err)
+	}
+}
@@ -0,0 +1,155 @@
+package query
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/eleme/lindb/sql"
+)
+
+// UDF represents the user defined function which is evaluated on the merged result series like builtin functions,
+// such as the domain-specific smoothing or anomaly score registered by database.
+type UDF interface {
+	// Eval evaluates the function, series are the values of series args, params are the values of
+	// number/duration(ms) args, both in the order of args, returns the values of each time slot
+	Eval(interval int64, series [][]float64, params []float64) ([]float64, error)
+	// Lookback returns how long(ms) the function needs the data before query start time
+	Lookback(interval int64, params []float64) int64
+}
+
+// UDFFactory creates the user defined function with the params of definition,
+// the Go plugin exports the factory as symbol, so it must be an alias of func type.
+type UDFFactory = func(params map[string]string) (UDF, error)
+
+// UDFRegistry finds the user defined function registered by database
+type UDFRegistry interface {
+	// Lookup returns the user defined function of database by name, returns false if not exist
+	Lookup(database, name string) (UDF, bool)
+}
+
+// UDFExpr represents the call of user defined function, which is resolved from registry before executing
+type UDFExpr struct {
+	Name string
+	Args []sql.Expr
+	UDF  UDF
+}
+
+// Eval evaluates the series args, then evaluates the user defined function with the values of args,
+// returns error if the function panics or returns the values of wrong length.
+func (e *UDFExpr) Eval(ctx *evalContext) ([]float64, error) {
+	var series [][]float64
+	for _, arg := range e.Args {
+		if _, ok := udfParam(arg); ok {
+			continue
+		}
+		values, err := eval(ctx, arg)
+		if err != nil {
+			return nil, err
+		}
+		series = append(series, values)
+	}
+	values, err := e.eval(ctx.interval, series, e.params())
+	if err != nil {
+		return nil, fmt.Errorf("function[%s] error:%s", e.Name, err)
+	}
+	if len(values) != ctx.pointCount {
+		return nil, fmt.Errorf("function[%s] returns %d values, but expect %d", e.Name, len(values), ctx.pointCount)
+	}
+	result := ctx.arena.Values(ctx.pointCount)
+	copy(result, values)
+	return result, nil
+}
+
+// eval evaluates the user defined function, recovers the panic of function, so that it cannot crash broker
+func (e *UDFExpr) eval(interval int64, series [][]float64, params []float64) (values []float64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic:%v, stack:%s", r, debug.Stack())
+		}
+	}()
+	return e.UDF.Eval(interval, series, params)
+}
+
+// Lookback returns the lookback of function with the lookback of args
+func (e *UDFExpr) Lookback(interval int64) (result int64) {
+	for _, arg := range e.Args {
+		if l := lookback(arg, interval); l > result {
+			result = l
+		}
+	}
+	defer func() {
+		// ignore the lookback of function if panic
+		_ = recover()
+	}()
+	if l := e.UDF.Lookback(interval, e.params()); l > 0 {
+		result += l
+	}
+	return result
+}
+
+// String returns the string format of function call
+func (e *UDFExpr) String() string {
+	return (&sql.CallExpr{Name: e.Name, Args: e.Args}).String()
+}
+
+// params returns the values of number/duration args
+func (e *UDFExpr) params() []float64 {
+	var params []float64
+	for _, arg := range e.Args {
+		if param, ok := udfParam(arg); ok {
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// udfParam returns the value of number/duration arg, returns false if the arg is series
+func udfParam(arg sql.Expr) (float64, bool) {
+	switch e := arg.(type) {
+	case *sql.NumberExpr:
+		return e.Value, true
+	case *sql.DurationExpr:
+		return float64(e.Value), true
+	default:
+		return 0, false
+	}
+}
+
+// resolveUDF replaces the call of function which isn't builtin with the user defined function of database,
+// returns error if the function not exist. The expression isn't changed if registry is nil.
+func resolveUDF(expr sql.Expr, database string, registry UDFRegistry) (sql.Expr, error) {
+	if registry == nil {
+		return expr, nil
+	}
+	switch e := expr.(type) {
+	case *sql.BinaryExpr:
+		left, err := resolveUDF(e.Left, database, registry)
+		if err != nil {
+			return nil, err
+		}
+		right, err := resolveUDF(e.Right, database, registry)
+		if err != nil {
+			return nil, err
+		}
+		return &sql.BinaryExpr{Op: e.Op, Left: left, Right: right}, nil
+	case *sql.CallExpr:
+		args := make([]sql.Expr, len(e.Args))
+		for i, arg := range e.Args {
+			resolved, err := resolveUDF(arg, database, registry)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = resolved
+		}
+		if _, ok := functions[e.Name]; ok {
+			return &sql.CallExpr{Name: e.Name, Args: args}, nil
+		}
+		udf, ok := registry.Lookup(database, e.Name)
+		if !ok {
+			return nil, fmt.Errorf("function[%s] not support", e.Name)
+		}
+		return &UDFExpr{Name: e.Name, Args: args, UDF: udf}, nil
+	default:
+		return expr, nil
+	}
+}
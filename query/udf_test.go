@@ -0,0 +1,149 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/sql"
+)
+
+// scaleUDF multiplies the sum of series by the first param, panics if the first param is negative
+type scaleUDF struct {
+	length int
+}
+
+func (f *scaleUDF) Eval(interval int64, series [][]float64, params []float64) ([]float64, error) {
+	if len(params) != 1 {
+		return nil, fmt.Errorf("needs 1 param")
+	}
+	if params[0] < 0 {
+		panic("negative scale")
+	}
+	result := make([]float64, len(series[0])+f.length)
+	for _, values := range series {
+		for i, value := range values {
+			result[i] += value * params[0]
+		}
+	}
+	return result, nil
+}
+
+func (f *scaleUDF) Lookback(interval int64, params []float64) int64 {
+	if len(params) != 1 {
+		panic("needs 1 param")
+	}
+	return interval
+}
+
+type mockUDFRegistry map[string]UDF
+
+func (r mockUDFRegistry) Lookup(database, name string) (UDF, bool) {
+	udf, ok := r[database+"/"+name]
+	return udf, ok
+}
+
+func TestUDFExpr(t *testing.T) {
+	ctx := newTestContext(timeutil.OneSecond, map[string][]float64{"f": {1, 2, 3}})
+	expr := &UDFExpr{
+		Name: "scale",
+		Args: []sql.Expr{&sql.FieldExpr{Name: "f"}, &sql.NumberExpr{Value: 2}, &sql.FieldExpr{Name: "f"}},
+		UDF:  &scaleUDF{},
+	}
+	values, err := expr.Eval(ctx)
+	assert.Nil(t, err)
+	assertValues(t, []float64{4, 8, 12}, values)
+	assert.Equal(t, "scale(f,2,f)", expr.String())
+	assert.Equal(t, int64(timeutil.OneSecond), expr.Lookback(timeutil.OneSecond))
+
+	// the duration is passed as param(ms)
+	expr.Args = []sql.Expr{&sql.FieldExpr{Name: "f"}, &sql.DurationExpr{Value: 10}}
+	values, err = expr.Eval(ctx)
+	assert.Nil(t, err)
+	assertValues(t, []float64{10, 20, 30}, values)
+
+	// error/panic of function
+	expr.Args = []sql.Expr{&sql.FieldExpr{Name: "f"}}
+	_, err = expr.Eval(ctx)
+	assert.NotNil(t, err)
+	assert.Equal(t, int64(0), expr.Lookback(timeutil.OneSecond))
+	expr.Args = []sql.Expr{&sql.FieldExpr{Name: "f"}, &sql.NumberExpr{Value: -1}}
+	_, err = expr.Eval(ctx)
+	assert.NotNil(t, err)
+	// the length of values is wrong
+	expr.Args = []sql.Expr{&sql.FieldExpr{Name: "f"}, &sql.NumberExpr{Value: 1}}
+	expr.UDF = &scaleUDF{length: 1}
+	_, err = expr.Eval(ctx)
+	assert.NotNil(t, err)
+	// the error of args
+	expr.Args = []sql.Expr{&sql.CallExpr{Name: "unknown"}, &sql.NumberExpr{Value: 1}}
+	_, err = expr.Eval(ctx)
+	assert.NotNil(t, err)
+}
+
+func TestResolveUDF(t *testing.T) {
+	expr, _ := sql.ParseExpr("moving_average(scale(f,2),3)+1")
+	// nil registry
+	resolved, err := resolveUDF(expr, "db", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, expr, resolved)
+
+	registry := mockUDFRegistry{"db/scale": &scaleUDF{}}
+	resolved, err = resolveUDF(expr, "db", registry)
+	assert.Nil(t, err)
+	assert.Equal(t, expr.String(), resolved.String())
+	call := resolved.(*sql.BinaryExpr).Left.(*sql.CallExpr)
+	assert.Equal(t, MovingAverage, call.Name)
+	udf := call.Args[0].(*UDFExpr)
+	assert.Equal(t, "scale", udf.Name)
+
+	// the function isn't registered for database
+	_, err = resolveUDF(expr, "db2", registry)
+	assert.NotNil(t, err)
+	expr, _ = sql.ParseExpr("1+scale(f,2)")
+	_, err = resolveUDF(expr, "db2", registry)
+	assert.NotNil(t, err)
+	expr, _ = sql.ParseExpr("moving_average(scale(f,2),3)")
+	_, err = resolveUDF(expr, "db2", registry)
+	assert.NotNil(t, err)
+
+	assert.True(t, IsBuiltinFunction(MovingAverage))
+	assert.False(t, IsBuiltinFunction("scale"))
+}
+
+func TestBrokerExecutor_UDF(t *testing.T) {
+	interval := int64(timeutil.OneMinute)
+	fetcher := newMockFetcher()
+	executor := NewBrokerExecutor(fetcher, mockUDFRegistry{"db/scale": &scaleUDF{}})
+
+	rs, err := executor.Execute(context.TODO(), &models.QueryRequest{
+		Database: "db",
+		Metric:   "cpu",
+		Fields:   []models.QueryField{{Alias: "scaled", Expr: "scale(total,2)"}},
+		Start:    interval,
+		End:      4 * interval,
+		Interval: interval,
+		GroupBy:  []string{"host"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rs.Series))
+	assertValues(t, []float64{20, 20, 20}, rs.Series[0].Fields["scaled"])
+	// the lookback of function is queried
+	req := fetcher.reqs["db/cpu"]
+	assert.Equal(t, SubQuery{MetricName: "cpu", Fields: []string{"total"}}, req.SubQuery)
+	assert.Equal(t, models.TimeRange{Start: 0, End: 4 * interval}, req.TimeRange)
+
+	_, err = executor.Execute(context.TODO(), &models.QueryRequest{
+		Database: "db",
+		Metric:   "cpu",
+		Fields:   []models.QueryField{{Expr: "unknown(total)"}},
+		Start:    interval,
+		End:      4 * interval,
+		Interval: interval,
+	})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,81 @@
+package query
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/eleme/lindb/models"
+)
+
+// scanTaskFunc executes the idx-th scan task of query, returns the result set of task
+type scanTaskFunc func(idx int) (*models.ResultSet, error)
+
+// workerPool executes the scan tasks of one query by a bounded number of workers,
+// the results are kept in the order of tasks whatever the order of completion is.
+type workerPool struct {
+	concurrency int
+}
+
+// newWorkerPool creates the worker pool with max concurrency, uses GOMAXPROCS if concurrency <= 0,
+// which is adjusted by the cpu quota of container
+func newWorkerPool(concurrency int) *workerPool {
+	if concurrency <= 0 {
+		concurrency = runtime.GOMAXPROCS(0)
+	}
+	return &workerPool{concurrency: concurrency}
+}
+
+// Run executes numOfTasks tasks concurrently, blocks until all dispatched tasks are completed,
+// returns the results indexed by task. After any task fails, the remaining tasks aren't dispatched
+// and the error of first failure is returned.
+func (p *workerPool) Run(numOfTasks int, task scanTaskFunc) ([]*models.ResultSet, error) {
+	results := make([]*models.ResultSet, numOfTasks)
+	if numOfTasks == 0 {
+		return results, nil
+	}
+	workers := p.concurrency
+	if workers > numOfTasks {
+		workers = numOfTasks
+	}
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	tasks := make(chan int)
+	failed := make(chan struct{})
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range tasks {
+				rs, err := task(idx)
+				if err != nil {
+					once.Do(func() {
+						firstErr = err
+						close(failed)
+					})
+					continue
+				}
+				results[idx] = rs
+			}
+		}()
+	}
+
+dispatch:
+	for idx := 0; idx < numOfTasks; idx++ {
+		select {
+		case tasks <- idx:
+		case <-failed:
+			break dispatch
+		}
+	}
+	close(tasks)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return results, nil
+}
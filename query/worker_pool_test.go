@@ -0,0 +1,56 @@
+package query
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+)
+
+func TestWorkerPool_Run(t *testing.T) {
+	var running, maxRunning int32
+	pool := newWorkerPool(3)
+	results, err := pool.Run(10, func(idx int) (*models.ResultSet, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		// the earlier task completes later
+		time.Sleep(time.Duration(10-idx) * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return &models.ResultSet{StartTime: int64(idx)}, nil
+	})
+	assert.Nil(t, err)
+	assert.Len(t, results, 10)
+	for idx, rs := range results {
+		assert.Equal(t, int64(idx), rs.StartTime)
+	}
+	assert.True(t, atomic.LoadInt32(&maxRunning) <= 3)
+
+	results, err = newWorkerPool(0).Run(0, nil)
+	assert.Nil(t, err)
+	assert.Empty(t, results)
+}
+
+func TestWorkerPool_Run_err(t *testing.T) {
+	var executed int32
+	pool := newWorkerPool(1)
+	results, err := pool.Run(100, func(idx int) (*models.ResultSet, error) {
+		atomic.AddInt32(&executed, 1)
+		if idx == 2 {
+			return nil, fmt.Errorf("err")
+		}
+		return &models.ResultSet{}, nil
+	})
+	assert.NotNil(t, err)
+	assert.Nil(t, results)
+	// the remaining tasks aren't dispatched after failure
+	assert.True(t, atomic.LoadInt32(&executed) < 100)
+}
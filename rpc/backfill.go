@@ -0,0 +1,26 @@
+package rpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// metaBackfill is the grpc metadata key which marks the write as backfill
+const metaBackfill = "lindb-backfill"
+
+// WithBackfill returns the outgoing context which marks the write as backfill, so that storage node writes
+// the points as the corrected historical data which bypasses the behind window of shard.
+func WithBackfill(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, metaBackfill, "true")
+}
+
+// IsBackfill returns if the write of incoming context is marked as backfill
+func IsBackfill(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+	values := md.Get(metaBackfill)
+	return len(values) > 0 && values[0] == "true"
+}
@@ -0,0 +1,17 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestBackfill(t *testing.T) {
+	assert.False(t, IsBackfill(context.TODO()))
+	assert.False(t, IsBackfill(metadata.NewIncomingContext(context.TODO(), metadata.Pairs("lindb-backfill", "false"))))
+
+	md, _ := metadata.FromOutgoingContext(WithBackfill(context.TODO()))
+	assert.True(t, IsBackfill(metadata.NewIncomingContext(context.TODO(), md)))
+}
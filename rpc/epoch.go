@@ -0,0 +1,41 @@
+package rpc
+
+import (
+	"context"
+	"strconv"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Defines the grpc metadata keys of routing epoch which the write is routed by
+const (
+	metaDatabase     = "lindb-database"
+	metaRoutingEpoch = "lindb-routing-epoch"
+)
+
+// WithRoutingEpoch returns the outgoing context which carries the database and the routing epoch of shard assignment
+// which the write is routed by, so that storage node can reject the write routed by a stale assignment.
+func WithRoutingEpoch(ctx context.Context, database string, epoch int64) context.Context {
+	return metadata.AppendToOutgoingContext(ctx,
+		metaDatabase, database,
+		metaRoutingEpoch, strconv.FormatInt(epoch, 10))
+}
+
+// GetRoutingEpoch returns the database and the routing epoch carried by the incoming context,
+// returns false if the write isn't tagged with routing epoch
+func GetRoutingEpoch(ctx context.Context) (database string, epoch int64, ok bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", 0, false
+	}
+	databases := md.Get(metaDatabase)
+	epochs := md.Get(metaRoutingEpoch)
+	if len(databases) == 0 || len(epochs) == 0 {
+		return "", 0, false
+	}
+	epoch, err := strconv.ParseInt(epochs[0], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return databases[0], epoch, true
+}
@@ -0,0 +1,25 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestRoutingEpoch(t *testing.T) {
+	_, _, ok := GetRoutingEpoch(context.TODO())
+	assert.False(t, ok)
+	_, _, ok = GetRoutingEpoch(metadata.NewIncomingContext(context.TODO(), metadata.Pairs("lindb-database", "db")))
+	assert.False(t, ok)
+	_, _, ok = GetRoutingEpoch(metadata.NewIncomingContext(context.TODO(),
+		metadata.Pairs("lindb-database", "db", "lindb-routing-epoch", "a")))
+	assert.False(t, ok)
+
+	md, _ := metadata.FromOutgoingContext(WithRoutingEpoch(context.TODO(), "db", 10))
+	database, epoch, ok := GetRoutingEpoch(metadata.NewIncomingContext(context.TODO(), md))
+	assert.True(t, ok)
+	assert.Equal(t, "db", database)
+	assert.Equal(t, int64(10), epoch)
+}
@@ -0,0 +1,109 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FaultInjector injects faults into rpc calls by the address of server, such as network partition and delay,
+// which is used by chaos testing of cluster, all the nodes of cluster can run in process with same injector.
+type FaultInjector struct {
+	mutex       sync.RWMutex
+	partitioned map[string]struct{}
+	delays      map[string]time.Duration
+}
+
+// NewFaultInjector creates the fault injector without any fault
+func NewFaultInjector() *FaultInjector {
+	return &FaultInjector{
+		partitioned: make(map[string]struct{}),
+		delays:      make(map[string]time.Duration),
+	}
+}
+
+// Partition isolates the server of address, all calls from/to it fail with unavailable
+func (f *FaultInjector) Partition(address string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.partitioned[address] = struct{}{}
+}
+
+// Delay delays all calls from/to the server of address
+func (f *FaultInjector) Delay(address string, delay time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.delays[address] = delay
+}
+
+// Heal removes all faults of the server of address
+func (f *FaultInjector) Heal(address string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	delete(f.partitioned, address)
+	delete(f.delays, address)
+}
+
+// HealAll removes all faults
+func (f *FaultInjector) HealAll() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.partitioned = make(map[string]struct{})
+	f.delays = make(map[string]time.Duration)
+}
+
+// ServerOption returns the server option which injects faults into the calls of server bound on address
+func (f *FaultInjector) ServerOption(address string) grpc.ServerOption {
+	return grpc.UnaryInterceptor(f.UnaryServerInterceptor(address))
+}
+
+// UnaryServerInterceptor returns the server interceptor which injects faults into the calls of server bound on address
+func (f *FaultInjector) UnaryServerInterceptor(address string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{},
+		info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := f.inject(ctx, address); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// DialOption returns the dial option which injects faults into the calls to target server
+func (f *FaultInjector) DialOption() grpc.DialOption {
+	return grpc.WithUnaryInterceptor(f.UnaryClientInterceptor())
+}
+
+// UnaryClientInterceptor returns the client interceptor which injects faults into the calls to target server
+func (f *FaultInjector) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{},
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if err := f.inject(ctx, cc.Target()); err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// inject returns unavailable error if the server of address is partitioned, else sleeps the delay
+func (f *FaultInjector) inject(ctx context.Context, address string) error {
+	f.mutex.RLock()
+	_, partitioned := f.partitioned[address]
+	delay := f.delays[address]
+	f.mutex.RUnlock()
+
+	if partitioned {
+		return status.Errorf(codes.Unavailable, "server[%s] is partitioned by fault injector", address)
+	}
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	return nil
+}
@@ -9,6 +9,8 @@ import (
 	common "github.com/eleme/lindb/rpc/proto/common"
 	proto "github.com/golang/protobuf/proto"
 	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
 	math "math"
 )
 
@@ -26,15 +28,16 @@ const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 func init() { proto.RegisterFile("broker.proto", fileDescriptor_f209535e190f2bed) }
 
 var fileDescriptor_f209535e190f2bed = []byte{
-	// 129 bytes of a gzipped FileDescriptorProto
+	// 145 bytes of a gzipped FileDescriptorProto
 	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x49, 0x2a, 0xca, 0xcf,
 	0x4e, 0x2d, 0xd2, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0x83, 0xf0, 0xa4, 0x78, 0x92, 0xf3,
-	0x73, 0x73, 0xf3, 0xf3, 0x20, 0xa2, 0x46, 0xce, 0x5c, 0xbc, 0x4e, 0x60, 0xf1, 0xe0, 0xd4, 0xa2,
+	0x73, 0x73, 0xf3, 0xf3, 0x20, 0xa2, 0x46, 0x75, 0x5c, 0xbc, 0x4e, 0x60, 0xf1, 0xe0, 0xd4, 0xa2,
 	0xb2, 0xcc, 0xe4, 0x54, 0x21, 0x23, 0x2e, 0xee, 0xf0, 0xa2, 0xcc, 0x92, 0xd4, 0x80, 0xfc, 0xcc,
 	0xbc, 0x92, 0x62, 0x21, 0x7e, 0x3d, 0xa8, 0xf2, 0xa0, 0xd4, 0xc2, 0xd2, 0xd4, 0xe2, 0x12, 0x29,
-	0x01, 0x84, 0x40, 0x71, 0x41, 0x7e, 0x5e, 0x71, 0xaa, 0x12, 0x83, 0x93, 0xc0, 0x89, 0x47, 0x72,
-	0x8c, 0x17, 0x1e, 0xc9, 0x31, 0x3e, 0x78, 0x24, 0xc7, 0x38, 0xe3, 0xb1, 0x1c, 0x43, 0x12, 0x1b,
-	0xd8, 0x74, 0x63, 0x40, 0x00, 0x00, 0x00, 0xff, 0xff, 0xe6, 0x0c, 0xd3, 0x3d, 0x83, 0x00, 0x00,
+	0x01, 0x84, 0x40, 0x71, 0x41, 0x7e, 0x5e, 0x71, 0xaa, 0x12, 0x83, 0x90, 0x25, 0x97, 0x40, 0x70,
+	0x69, 0x52, 0x71, 0x72, 0x51, 0x66, 0x52, 0xaa, 0x73, 0x46, 0x62, 0x5e, 0x7a, 0x2a, 0x71, 0x1a,
+	0x0d, 0x18, 0x9d, 0x78, 0x4e, 0x3c, 0x92, 0x63, 0xbc, 0xf0, 0x48, 0x8e, 0xf1, 0xc1, 0x23, 0x39,
+	0xc6, 0x24, 0x36, 0xb0, 0xa3, 0x8c, 0x01, 0x03, 0x00, 0x71, 0x88, 0x97, 0x05, 0xba, 0x00, 0x00,
 	0x00,
 }
 
@@ -51,6 +54,7 @@ const _ = grpc.SupportPackageIsVersion4
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type BrokerServiceClient interface {
 	WritePoints(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error)
+	SubscribeChanges(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (BrokerService_SubscribeChangesClient, error)
 }
 
 type brokerServiceClient struct {
@@ -70,9 +74,53 @@ func (c *brokerServiceClient) WritePoints(ctx context.Context, in *common.Reques
 	return out, nil
 }
 
+func (c *brokerServiceClient) SubscribeChanges(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (BrokerService_SubscribeChangesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_BrokerService_serviceDesc.Streams[0], "/broker.BrokerService/SubscribeChanges", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &brokerServiceSubscribeChangesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BrokerService_SubscribeChangesClient interface {
+	Recv() (*common.Response, error)
+	grpc.ClientStream
+}
+
+type brokerServiceSubscribeChangesClient struct {
+	grpc.ClientStream
+}
+
+func (x *brokerServiceSubscribeChangesClient) Recv() (*common.Response, error) {
+	m := new(common.Response)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // BrokerServiceServer is the server API for BrokerService service.
 type BrokerServiceServer interface {
 	WritePoints(context.Context, *common.Request) (*common.Response, error)
+	SubscribeChanges(*common.Request, BrokerService_SubscribeChangesServer) error
+}
+
+// UnimplementedBrokerServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedBrokerServiceServer struct {
+}
+
+func (*UnimplementedBrokerServiceServer) WritePoints(ctx context.Context, req *common.Request) (*common.Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WritePoints not implemented")
+}
+func (*UnimplementedBrokerServiceServer) SubscribeChanges(req *common.Request, srv BrokerService_SubscribeChangesServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeChanges not implemented")
 }
 
 func RegisterBrokerServiceServer(s *grpc.Server, srv BrokerServiceServer) {
@@ -97,6 +145,27 @@ func _BrokerService_WritePoints_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BrokerService_SubscribeChanges_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(common.Request)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BrokerServiceServer).SubscribeChanges(m, &brokerServiceSubscribeChangesServer{stream})
+}
+
+type BrokerService_SubscribeChangesServer interface {
+	Send(*common.Response) error
+	grpc.ServerStream
+}
+
+type brokerServiceSubscribeChangesServer struct {
+	grpc.ServerStream
+}
+
+func (x *brokerServiceSubscribeChangesServer) Send(m *common.Response) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _BrokerService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "broker.BrokerService",
 	HandlerType: (*BrokerServiceServer)(nil),
@@ -106,6 +175,12 @@ var _BrokerService_serviceDesc = grpc.ServiceDesc{
 			Handler:    _BrokerService_WritePoints_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeChanges",
+			Handler:       _BrokerService_SubscribeChanges_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "broker.proto",
 }
@@ -9,6 +9,8 @@ import (
 	common "github.com/eleme/lindb/rpc/proto/common"
 	proto "github.com/golang/protobuf/proto"
 	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
 	math "math"
 )
 
@@ -26,15 +28,26 @@ const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 func init() { proto.RegisterFile("storage.proto", fileDescriptor_0d2c4ccf1453ffdb) }
 
 var fileDescriptor_0d2c4ccf1453ffdb = []byte{
-	// 128 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x2d, 0x2e, 0xc9, 0x2f,
-	0x4a, 0x4c, 0x4f, 0xd5, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0x87, 0x72, 0xa5, 0x78, 0x92,
-	0xf3, 0x73, 0x73, 0xf3, 0xf3, 0x20, 0xc2, 0x46, 0x4e, 0x5c, 0x3c, 0xe1, 0x45, 0x99, 0x25, 0xa9,
-	0xc1, 0xa9, 0x45, 0x65, 0x99, 0xc9, 0xa9, 0x42, 0x46, 0x5c, 0xdc, 0x60, 0x7e, 0x40, 0x7e, 0x66,
-	0x5e, 0x49, 0xb1, 0x10, 0xbf, 0x1e, 0x54, 0x75, 0x50, 0x6a, 0x61, 0x69, 0x6a, 0x71, 0x89, 0x94,
-	0x00, 0x42, 0xa0, 0xb8, 0x20, 0x3f, 0xaf, 0x38, 0x55, 0x89, 0xc1, 0x49, 0xe0, 0xc4, 0x23, 0x39,
-	0xc6, 0x0b, 0x8f, 0xe4, 0x18, 0x1f, 0x3c, 0x92, 0x63, 0x9c, 0xf1, 0x58, 0x8e, 0x21, 0x89, 0x0d,
-	0x6c, 0xb8, 0x31, 0x20, 0x00, 0x00, 0xff, 0xff, 0x94, 0xa0, 0xeb, 0xe1, 0x84, 0x00, 0x00, 0x00,
+	// 304 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x92, 0x31, 0x4f, 0x3a, 0x41,
+	0x10, 0xc5, 0xff, 0x97, 0x7f, 0x84, 0x38, 0x9e, 0xa2, 0x57, 0x52, 0x50, 0x58, 0x2b, 0x31, 0x47,
+	0x34, 0x16, 0x36, 0xa2, 0x68, 0x23, 0x09, 0x72, 0x12, 0xea, 0x91, 0x9b, 0x9c, 0x1b, 0xb8, 0xdd,
+	0x73, 0x76, 0x0e, 0xa4, 0xf5, 0xd3, 0x59, 0xfa, 0x05, 0x4c, 0x0c, 0x9f, 0xc4, 0xb0, 0x72, 0xb1,
+	0x5d, 0xca, 0x99, 0xec, 0x6f, 0xde, 0x7b, 0x79, 0x0b, 0xfb, 0x56, 0x0c, 0x63, 0x46, 0xed, 0x82,
+	0x8d, 0x98, 0xa8, 0xbe, 0x19, 0x9b, 0xe1, 0xc4, 0xe4, 0xb9, 0xd1, 0xbf, 0xeb, 0xb8, 0x0b, 0xe1,
+	0x98, 0x95, 0x50, 0x42, 0x3c, 0x57, 0x13, 0x8a, 0x62, 0xd8, 0x73, 0xf3, 0xc0, 0x28, 0x2d, 0x36,
+	0x6a, 0xb4, 0x37, 0xaf, 0x87, 0xf4, 0x5a, 0x92, 0x95, 0xe6, 0xe1, 0xdf, 0xc2, 0x16, 0x46, 0x5b,
+	0x3a, 0xfe, 0x17, 0x7f, 0x05, 0xd0, 0xe8, 0x93, 0x60, 0x8a, 0x82, 0xd5, 0x9d, 0x36, 0xd4, 0x93,
+	0x32, 0xcb, 0xc8, 0x8a, 0xd7, 0x8d, 0xb5, 0xee, 0x0d, 0x72, 0xaa, 0x34, 0xce, 0x94, 0x2c, 0xbd,
+	0x19, 0xe7, 0xb5, 0x37, 0x27, 0x5f, 0xaf, 0x6b, 0xe6, 0xb1, 0x24, 0x5e, 0x6e, 0xc1, 0xc4, 0xef,
+	0xff, 0x21, 0xbc, 0x4e, 0x73, 0xa5, 0xab, 0x70, 0x17, 0xd0, 0x18, 0x30, 0x15, 0xc8, 0x94, 0xbc,
+	0x94, 0x92, 0x9a, 0x85, 0xf6, 0x13, 0x3f, 0x83, 0xdd, 0x07, 0x65, 0xe5, 0x09, 0xed, 0xd4, 0xd3,
+	0xee, 0x25, 0x1c, 0xf5, 0x51, 0x63, 0x46, 0x7d, 0x12, 0x56, 0x93, 0x44, 0x0c, 0x93, 0x1f, 0x79,
+	0x0e, 0x07, 0x2e, 0xe8, 0x10, 0x17, 0x5b, 0x74, 0x19, 0x75, 0x20, 0xbc, 0x27, 0xb9, 0x55, 0x76,
+	0x3a, 0xb2, 0x98, 0x79, 0x6a, 0x9d, 0x42, 0x6d, 0x8c, 0x9c, 0x8f, 0x0a, 0x6f, 0x8d, 0xde, 0x5b,
+	0x61, 0x58, 0xee, 0x30, 0x57, 0x33, 0xbf, 0xb2, 0xe3, 0x2b, 0x08, 0x5d, 0x9e, 0xaa, 0x83, 0x13,
+	0xd8, 0x71, 0xb3, 0x17, 0xdd, 0x0d, 0x3f, 0x56, 0xad, 0xe0, 0x73, 0xd5, 0x0a, 0xbe, 0x57, 0xad,
+	0xe0, 0xb9, 0xe6, 0xfe, 0x7e, 0xe7, 0x67, 0x00, 0x65, 0x99, 0x53, 0x21, 0x23, 0x03, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -74,6 +87,14 @@ type WriteServiceServer interface {
 	WritePoints(context.Context, *common.Request) (*common.Response, error)
 }
 
+// UnimplementedWriteServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedWriteServiceServer struct {
+}
+
+func (*UnimplementedWriteServiceServer) WritePoints(ctx context.Context, req *common.Request) (*common.Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WritePoints not implemented")
+}
+
 func RegisterWriteServiceServer(s *grpc.Server, srv WriteServiceServer) {
 	s.RegisterService(&_WriteService_serviceDesc, srv)
 }
@@ -108,3 +129,543 @@ var _WriteService_serviceDesc = grpc.ServiceDesc{
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "storage.proto",
 }
+
+// MetadataServiceClient is the client API for MetadataService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type MetadataServiceClient interface {
+	Suggest(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error)
+	Cardinality(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error)
+	WriteEvents(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error)
+	QueryEvents(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error)
+}
+
+type metadataServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewMetadataServiceClient(cc *grpc.ClientConn) MetadataServiceClient {
+	return &metadataServiceClient{cc}
+}
+
+func (c *metadataServiceClient) Suggest(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error) {
+	out := new(common.Response)
+	err := c.cc.Invoke(ctx, "/storage.MetadataService/Suggest", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metadataServiceClient) Cardinality(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error) {
+	out := new(common.Response)
+	err := c.cc.Invoke(ctx, "/storage.MetadataService/Cardinality", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metadataServiceClient) WriteEvents(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error) {
+	out := new(common.Response)
+	err := c.cc.Invoke(ctx, "/storage.MetadataService/WriteEvents", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metadataServiceClient) QueryEvents(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error) {
+	out := new(common.Response)
+	err := c.cc.Invoke(ctx, "/storage.MetadataService/QueryEvents", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MetadataServiceServer is the server API for MetadataService service.
+type MetadataServiceServer interface {
+	Suggest(context.Context, *common.Request) (*common.Response, error)
+	Cardinality(context.Context, *common.Request) (*common.Response, error)
+	WriteEvents(context.Context, *common.Request) (*common.Response, error)
+	QueryEvents(context.Context, *common.Request) (*common.Response, error)
+}
+
+// UnimplementedMetadataServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedMetadataServiceServer struct {
+}
+
+func (*UnimplementedMetadataServiceServer) Suggest(ctx context.Context, req *common.Request) (*common.Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Suggest not implemented")
+}
+func (*UnimplementedMetadataServiceServer) Cardinality(ctx context.Context, req *common.Request) (*common.Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Cardinality not implemented")
+}
+func (*UnimplementedMetadataServiceServer) WriteEvents(ctx context.Context, req *common.Request) (*common.Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WriteEvents not implemented")
+}
+func (*UnimplementedMetadataServiceServer) QueryEvents(ctx context.Context, req *common.Request) (*common.Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryEvents not implemented")
+}
+
+func RegisterMetadataServiceServer(s *grpc.Server, srv MetadataServiceServer) {
+	s.RegisterService(&_MetadataService_serviceDesc, srv)
+}
+
+func _MetadataService_Suggest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(common.Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetadataServiceServer).Suggest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/storage.MetadataService/Suggest",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetadataServiceServer).Suggest(ctx, req.(*common.Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetadataService_Cardinality_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(common.Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetadataServiceServer).Cardinality(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/storage.MetadataService/Cardinality",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetadataServiceServer).Cardinality(ctx, req.(*common.Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetadataService_WriteEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(common.Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetadataServiceServer).WriteEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/storage.MetadataService/WriteEvents",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetadataServiceServer).WriteEvents(ctx, req.(*common.Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetadataService_QueryEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(common.Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetadataServiceServer).QueryEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/storage.MetadataService/QueryEvents",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetadataServiceServer).QueryEvents(ctx, req.(*common.Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _MetadataService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "storage.MetadataService",
+	HandlerType: (*MetadataServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Suggest",
+			Handler:    _MetadataService_Suggest_Handler,
+		},
+		{
+			MethodName: "Cardinality",
+			Handler:    _MetadataService_Cardinality_Handler,
+		},
+		{
+			MethodName: "WriteEvents",
+			Handler:    _MetadataService_WriteEvents_Handler,
+		},
+		{
+			MethodName: "QueryEvents",
+			Handler:    _MetadataService_QueryEvents_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "storage.proto",
+}
+
+// AdminServiceClient is the client API for AdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type AdminServiceClient interface {
+	PrepareShutdown(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error)
+	ListTasks(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error)
+	ManageMetricStore(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error)
+	QueryRawPoints(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error)
+	GetDiskUsage(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error)
+	WarmUp(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error)
+	ExportFamily(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error)
+}
+
+type adminServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewAdminServiceClient(cc *grpc.ClientConn) AdminServiceClient {
+	return &adminServiceClient{cc}
+}
+
+func (c *adminServiceClient) PrepareShutdown(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error) {
+	out := new(common.Response)
+	err := c.cc.Invoke(ctx, "/storage.AdminService/PrepareShutdown", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ListTasks(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error) {
+	out := new(common.Response)
+	err := c.cc.Invoke(ctx, "/storage.AdminService/ListTasks", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ManageMetricStore(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error) {
+	out := new(common.Response)
+	err := c.cc.Invoke(ctx, "/storage.AdminService/ManageMetricStore", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) QueryRawPoints(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error) {
+	out := new(common.Response)
+	err := c.cc.Invoke(ctx, "/storage.AdminService/QueryRawPoints", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) GetDiskUsage(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error) {
+	out := new(common.Response)
+	err := c.cc.Invoke(ctx, "/storage.AdminService/GetDiskUsage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) WarmUp(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error) {
+	out := new(common.Response)
+	err := c.cc.Invoke(ctx, "/storage.AdminService/WarmUp", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ExportFamily(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error) {
+	out := new(common.Response)
+	err := c.cc.Invoke(ctx, "/storage.AdminService/ExportFamily", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServiceServer is the server API for AdminService service.
+type AdminServiceServer interface {
+	PrepareShutdown(context.Context, *common.Request) (*common.Response, error)
+	ListTasks(context.Context, *common.Request) (*common.Response, error)
+	ManageMetricStore(context.Context, *common.Request) (*common.Response, error)
+	QueryRawPoints(context.Context, *common.Request) (*common.Response, error)
+	GetDiskUsage(context.Context, *common.Request) (*common.Response, error)
+	WarmUp(context.Context, *common.Request) (*common.Response, error)
+	ExportFamily(context.Context, *common.Request) (*common.Response, error)
+}
+
+// UnimplementedAdminServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedAdminServiceServer struct {
+}
+
+func (*UnimplementedAdminServiceServer) PrepareShutdown(ctx context.Context, req *common.Request) (*common.Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PrepareShutdown not implemented")
+}
+func (*UnimplementedAdminServiceServer) ListTasks(ctx context.Context, req *common.Request) (*common.Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTasks not implemented")
+}
+func (*UnimplementedAdminServiceServer) ManageMetricStore(ctx context.Context, req *common.Request) (*common.Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ManageMetricStore not implemented")
+}
+func (*UnimplementedAdminServiceServer) QueryRawPoints(ctx context.Context, req *common.Request) (*common.Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryRawPoints not implemented")
+}
+func (*UnimplementedAdminServiceServer) GetDiskUsage(ctx context.Context, req *common.Request) (*common.Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDiskUsage not implemented")
+}
+func (*UnimplementedAdminServiceServer) WarmUp(ctx context.Context, req *common.Request) (*common.Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WarmUp not implemented")
+}
+func (*UnimplementedAdminServiceServer) ExportFamily(ctx context.Context, req *common.Request) (*common.Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportFamily not implemented")
+}
+
+func RegisterAdminServiceServer(s *grpc.Server, srv AdminServiceServer) {
+	s.RegisterService(&_AdminService_serviceDesc, srv)
+}
+
+func _AdminService_PrepareShutdown_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(common.Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).PrepareShutdown(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/storage.AdminService/PrepareShutdown",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).PrepareShutdown(ctx, req.(*common.Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ListTasks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(common.Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ListTasks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/storage.AdminService/ListTasks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ListTasks(ctx, req.(*common.Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ManageMetricStore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(common.Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ManageMetricStore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/storage.AdminService/ManageMetricStore",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ManageMetricStore(ctx, req.(*common.Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_QueryRawPoints_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(common.Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).QueryRawPoints(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/storage.AdminService/QueryRawPoints",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).QueryRawPoints(ctx, req.(*common.Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_GetDiskUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(common.Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).GetDiskUsage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/storage.AdminService/GetDiskUsage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).GetDiskUsage(ctx, req.(*common.Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_WarmUp_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(common.Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).WarmUp(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/storage.AdminService/WarmUp",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).WarmUp(ctx, req.(*common.Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ExportFamily_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(common.Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ExportFamily(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/storage.AdminService/ExportFamily",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ExportFamily(ctx, req.(*common.Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _AdminService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "storage.AdminService",
+	HandlerType: (*AdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PrepareShutdown",
+			Handler:    _AdminService_PrepareShutdown_Handler,
+		},
+		{
+			MethodName: "ListTasks",
+			Handler:    _AdminService_ListTasks_Handler,
+		},
+		{
+			MethodName: "ManageMetricStore",
+			Handler:    _AdminService_ManageMetricStore_Handler,
+		},
+		{
+			MethodName: "QueryRawPoints",
+			Handler:    _AdminService_QueryRawPoints_Handler,
+		},
+		{
+			MethodName: "GetDiskUsage",
+			Handler:    _AdminService_GetDiskUsage_Handler,
+		},
+		{
+			MethodName: "WarmUp",
+			Handler:    _AdminService_WarmUp_Handler,
+		},
+		{
+			MethodName: "ExportFamily",
+			Handler:    _AdminService_ExportFamily_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "storage.proto",
+}
+
+// QueryServiceClient is the client API for QueryService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type QueryServiceClient interface {
+	Query(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error)
+}
+
+type queryServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewQueryServiceClient(cc *grpc.ClientConn) QueryServiceClient {
+	return &queryServiceClient{cc}
+}
+
+func (c *queryServiceClient) Query(ctx context.Context, in *common.Request, opts ...grpc.CallOption) (*common.Response, error) {
+	out := new(common.Response)
+	err := c.cc.Invoke(ctx, "/storage.QueryService/Query", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// QueryServiceServer is the server API for QueryService service.
+type QueryServiceServer interface {
+	Query(context.Context, *common.Request) (*common.Response, error)
+}
+
+// UnimplementedQueryServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedQueryServiceServer struct {
+}
+
+func (*UnimplementedQueryServiceServer) Query(ctx context.Context, req *common.Request) (*common.Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Query not implemented")
+}
+
+func RegisterQueryServiceServer(s *grpc.Server, srv QueryServiceServer) {
+	s.RegisterService(&_QueryService_serviceDesc, srv)
+}
+
+func _QueryService_Query_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(common.Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServiceServer).Query(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/storage.QueryService/Query",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServiceServer).Query(ctx, req.(*common.Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _QueryService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "storage.QueryService",
+	HandlerType: (*QueryServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Query",
+			Handler:    _QueryService_Query_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "storage.proto",
+}
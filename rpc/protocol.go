@@ -0,0 +1,156 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/eleme/lindb/rpc/proto/common"
+)
+
+// Defines the versions of rpc protocol between broker and storage node
+const (
+	// ProtocolV1 is the protocol of the nodes without version negotiation, the code of response is OK/ERR
+	ProtocolV1 int32 = 1
+	// ProtocolV2 carries the typed error code of response, e.g. routing epoch mismatch
+	ProtocolV2 int32 = 2
+
+	// CurrentProtocol is the protocol version of current binary
+	CurrentProtocol = ProtocolV2
+	// MinCompatibleProtocol is the min protocol version which current binary can talk with,
+	// so that the cluster can be upgraded node-by-node across one minor version.
+	MinCompatibleProtocol = ProtocolV1
+)
+
+// metaProtocolVersion is the grpc metadata key of protocol version, which is sent by client in request metadata
+// and by server in response header
+const metaProtocolVersion = "lindb-protocol-version"
+
+// DefaultProtocol is the protocol of current binary
+var DefaultProtocol = NewProtocol(CurrentProtocol, MinCompatibleProtocol)
+
+// protocolKey is the context key of the negotiated protocol version
+type protocolKey struct{}
+
+// Protocol negotiates the protocol version with peer on each rpc call, the negotiated version is the min version
+// of both sides, the call is rejected if the negotiated version is less than the min compatible version.
+// The node without negotiation(before ProtocolV2) is treated as ProtocolV1.
+type Protocol struct {
+	version    int32
+	minVersion int32
+}
+
+// NewProtocol creates the protocol with the version of binary and the min compatible version
+func NewProtocol(version, minVersion int32) *Protocol {
+	return &Protocol{
+		version:    version,
+		minVersion: minVersion,
+	}
+}
+
+// Version returns the protocol version of binary
+func (p *Protocol) Version() int32 {
+	return p.version
+}
+
+// Negotiate returns the protocol version used with the peer, returns error if the peer is incompatible
+func (p *Protocol) Negotiate(peerVersion int32) (int32, error) {
+	if peerVersion <= 0 {
+		peerVersion = ProtocolV1
+	}
+	negotiated := p.version
+	if peerVersion < negotiated {
+		negotiated = peerVersion
+	}
+	if negotiated < p.minVersion {
+		return 0, fmt.Errorf("protocol version[%d] of peer is incompatible with version[%d], min compatible version is %d",
+			peerVersion, p.version, p.minVersion)
+	}
+	return negotiated, nil
+}
+
+// UnaryServerInterceptor returns the server interceptor which negotiates the protocol version with client,
+// the negotiated version is carried by the context of handler, the response is downgraded for old client.
+func (p *Protocol) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{},
+		info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		negotiated, err := p.Negotiate(getProtocolVersion(metadataFromIncoming(ctx)))
+		if err != nil {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		// client reads the version of server from response header
+		_ = grpc.SetHeader(ctx, metadata.Pairs(metaProtocolVersion, strconv.Itoa(int(p.version))))
+		resp, err := handler(context.WithValue(ctx, protocolKey{}, negotiated), req)
+		if err != nil {
+			return resp, err
+		}
+		return downgradeResponse(negotiated, resp), nil
+	}
+}
+
+// UnaryClientInterceptor returns the client interceptor which sends the protocol version to server,
+// then checks if the version of server is compatible
+func (p *Protocol) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{},
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx = metadata.AppendToOutgoingContext(ctx, metaProtocolVersion, strconv.Itoa(int(p.version)))
+		var header metadata.MD
+		if err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header))...); err != nil {
+			return err
+		}
+		if _, err := p.Negotiate(getProtocolVersion(header)); err != nil {
+			return status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil
+	}
+}
+
+// DialOption returns the dial option which negotiates the protocol version with server
+func (p *Protocol) DialOption() grpc.DialOption {
+	return grpc.WithUnaryInterceptor(p.UnaryClientInterceptor())
+}
+
+// GetProtocolVersion returns the protocol version negotiated with client,
+// returns current version if the call isn't intercepted by protocol, e.g. in-process call
+func GetProtocolVersion(ctx context.Context) int32 {
+	if version, ok := ctx.Value(protocolKey{}).(int32); ok {
+		return version
+	}
+	return CurrentProtocol
+}
+
+// downgradeResponse downgrades the typed error code of response to ERR for the client of ProtocolV1,
+// which only knows OK/ERR
+func downgradeResponse(version int32, resp interface{}) interface{} {
+	if version >= ProtocolV2 {
+		return resp
+	}
+	if r, ok := resp.(*common.Response); ok && r != nil && r.Code != OK && r.Code != ERR {
+		return BuildResponse(ERR, r.Msg, r.Data)
+	}
+	return resp
+}
+
+// metadataFromIncoming returns the metadata of incoming context, returns nil if not exist
+func metadataFromIncoming(ctx context.Context) metadata.MD {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return md
+}
+
+// getProtocolVersion returns the protocol version in metadata, returns 0 if not exist or invalid
+func getProtocolVersion(md metadata.MD) int32 {
+	values := md.Get(metaProtocolVersion)
+	if len(values) == 0 {
+		return 0
+	}
+	version, err := strconv.ParseInt(values[0], 10, 32)
+	if err != nil {
+		return 0
+	}
+	return int32(version)
+}
@@ -0,0 +1,83 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/rpc/proto/common"
+)
+
+func TestProtocol_Negotiate(t *testing.T) {
+	p := NewProtocol(ProtocolV2, ProtocolV1)
+	assert.Equal(t, ProtocolV2, p.Version())
+	version, err := p.Negotiate(0)
+	assert.Nil(t, err)
+	assert.Equal(t, ProtocolV1, version)
+	version, _ = p.Negotiate(ProtocolV2)
+	assert.Equal(t, ProtocolV2, version)
+	version, _ = p.Negotiate(3)
+	assert.Equal(t, ProtocolV2, version)
+
+	_, err = NewProtocol(3, ProtocolV2).Negotiate(ProtocolV1)
+	assert.NotNil(t, err)
+}
+
+func TestProtocol_UnaryServerInterceptor(t *testing.T) {
+	typedResp := ResponseErr(errors.ErrEpochMismatch)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		assert.Equal(t, req, GetProtocolVersion(ctx))
+		return typedResp, nil
+	}
+	interceptor := DefaultProtocol.UnaryServerInterceptor()
+
+	// old client without version, typed error code is downgraded
+	resp, err := interceptor(context.TODO(), ProtocolV1, &grpc.UnaryServerInfo{}, handler)
+	assert.Nil(t, err)
+	assert.Equal(t, ERR, resp.(*common.Response).Code)
+	assert.Equal(t, typedResp.Msg, resp.(*common.Response).Msg)
+
+	ctx := metadata.NewIncomingContext(context.TODO(), metadata.Pairs(metaProtocolVersion, "2"))
+	resp, err = interceptor(ctx, ProtocolV2, &grpc.UnaryServerInfo{}, handler)
+	assert.Nil(t, err)
+	assert.Equal(t, typedResp, resp)
+
+	// incompatible client is rejected
+	_, err = NewProtocol(3, 3).UnaryServerInterceptor()(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	assert.Equal(t, CurrentProtocol, GetProtocolVersion(context.TODO()))
+}
+
+func TestProtocol_UnaryClientInterceptor(t *testing.T) {
+	invoker := func(serverVersion string) grpc.UnaryInvoker {
+		return func(ctx context.Context, method string, req, reply interface{},
+			cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			md, _ := metadata.FromOutgoingContext(ctx)
+			assert.Equal(t, []string{"2"}, md.Get(metaProtocolVersion))
+			for _, opt := range opts {
+				if header, ok := opt.(grpc.HeaderCallOption); ok && len(serverVersion) > 0 {
+					*header.HeaderAddr = metadata.Pairs(metaProtocolVersion, serverVersion)
+				}
+			}
+			return nil
+		}
+	}
+	interceptor := DefaultProtocol.UnaryClientInterceptor()
+	assert.Nil(t, interceptor(context.TODO(), "write", nil, nil, nil, invoker("")))
+	assert.Nil(t, interceptor(context.TODO(), "write", nil, nil, nil, invoker("3")))
+
+	// old server is incompatible with client
+	err := NewProtocol(ProtocolV2, ProtocolV2).UnaryClientInterceptor()(context.TODO(), "write",
+		nil, nil, nil, invoker("1"))
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	err = NewProtocol(ProtocolV2, ProtocolV2).UnaryClientInterceptor()(context.TODO(), "write",
+		nil, nil, nil, invoker("a"))
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
@@ -1,6 +1,7 @@
 package rpc
 
 import (
+	"github.com/eleme/lindb/pkg/errors"
 	"github.com/eleme/lindb/rpc/proto/common"
 )
 
@@ -28,3 +29,13 @@ func ResponseOKWithData(data []byte) *common.Response {
 func ResponseError(msg string) *common.Response {
 	return BuildResponse(ERR, msg, nil)
 }
+
+// ResponseErr builds the error response with the code of error, so that the client can check the error by code
+func ResponseErr(err error) *common.Response {
+	return BuildResponse(int32(errors.CodeOf(err)), err.Error(), nil)
+}
+
+// ResponseToError returns the error of response, returns nil if response is ok
+func ResponseToError(resp *common.Response) error {
+	return errors.FromCode(errors.Code(resp.Code), resp.Msg)
+}
@@ -1,6 +1,7 @@
 package rpc
 
 import (
+	"context"
 	"net"
 
 	"google.golang.org/grpc"
@@ -21,10 +22,11 @@ type server struct {
 	logger *logger.Logger
 }
 
-func NewTCPServer(bindAddress string) TCPServer {
+// NewTCPServer creates the grpc server bound on address with server options, e.g. interceptors
+func NewTCPServer(bindAddress string, opts ...grpc.ServerOption) TCPServer {
 	return &server{
 		bindAddress: bindAddress,
-		gs:          grpc.NewServer(),
+		gs:          grpc.NewServer(opts...),
 		logger:      logger.GetLogger("rpc/server"),
 	}
 }
@@ -48,3 +50,19 @@ func (s *server) Stop() {
 		s.gs.Stop()
 	}
 }
+
+// ChainUnaryServer chains the server interceptors into one, the first one is the outermost,
+// because grpc server only accepts one unary interceptor.
+func ChainUnaryServer(interceptors ...grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{},
+		info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		chained := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], chained
+			chained = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, next)
+			}
+		}
+		return chained(ctx, req)
+	}
+}
@@ -0,0 +1,29 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+func TestChainUnaryServer(t *testing.T) {
+	var calls []string
+	newInterceptor := func(name string) grpc.UnaryServerInterceptor {
+		return func(ctx context.Context, req interface{},
+			info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			calls = append(calls, name)
+			return handler(ctx, req)
+		}
+	}
+	chained := ChainUnaryServer(newInterceptor("a"), newInterceptor("b"))
+	resp, err := chained(context.TODO(), "req", &grpc.UnaryServerInfo{},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			calls = append(calls, "handler")
+			return req, nil
+		})
+	assert.Nil(t, err)
+	assert.Equal(t, "req", resp)
+	assert.Equal(t, []string{"a", "b", "handler"}, calls)
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/pathutil"
+	"github.com/eleme/lindb/pkg/state"
+	"github.com/eleme/lindb/pkg/timeutil"
+)
+
+// AuditService defines audit log service interface
+type AuditService interface {
+	// Record records the audit log of administrative operation
+	Record(log models.AuditLog) error
+	// List lists the newest audit logs ordered by time desc, lists all if limit <= 0
+	List(limit int) ([]models.AuditLog, error)
+}
+
+// auditService implements AuditService interface
+type auditService struct {
+	repo    state.Repository
+	maxLogs int
+}
+
+// NewAuditService creates audit log service, only the newest maxLogs logs are kept if maxLogs > 0.
+// NOTICE: the logs are stored in state repository only, because there is no system database for self-monitoring.
+func NewAuditService(repo state.Repository, maxLogs int) AuditService {
+	return &auditService{
+		repo:    repo,
+		maxLogs: maxLogs,
+	}
+}
+
+// Record records the audit log of administrative operation into state's repo, then removes the oldest logs
+// if the number of logs exceeds the max logs
+func (s *auditService) Record(log models.AuditLog) error {
+	if len(log.Operation) == 0 {
+		return fmt.Errorf("operation of audit log cannot be empty")
+	}
+	if log.Timestamp == 0 {
+		log.Timestamp = timeutil.Now()
+	}
+	// the id is ordered by time, the random suffix makes it unique among brokers
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("generate audit log id error:%s", err)
+	}
+	log.ID = fmt.Sprintf("%013d-%s", log.Timestamp, hex.EncodeToString(suffix))
+	data, err := json.Marshal(log)
+	if err != nil {
+		return fmt.Errorf("marshal audit log error:%s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.repo.Put(ctx, getAuditLogPath(log.ID), data); err != nil {
+		return err
+	}
+	if s.maxLogs > 0 {
+		return s.removeOldest(ctx)
+	}
+	return nil
+}
+
+// List lists the newest audit logs ordered by time desc, lists all if limit <= 0
+func (s *auditService) List(limit int) ([]models.AuditLog, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return s.list(ctx, limit)
+}
+
+// list lists the audit logs ordered by time desc
+func (s *auditService) list(ctx context.Context, limit int) ([]models.AuditLog, error) {
+	data, err := s.repo.List(ctx, pathutil.Keys.Cluster.AuditLogs.Prefix())
+	if err != nil {
+		return nil, err
+	}
+	var logs []models.AuditLog
+	for _, val := range data {
+		log := models.AuditLog{}
+		if err := json.Unmarshal(val, &log); err != nil {
+			return nil, fmt.Errorf("unmarshal audit log error:%s", err)
+		}
+		logs = append(logs, log)
+	}
+	sort.Slice(logs, func(i, j int) bool {
+		return logs[i].ID > logs[j].ID
+	})
+	if limit > 0 && len(logs) > limit {
+		logs = logs[:limit]
+	}
+	return logs, nil
+}
+
+// removeOldest removes the oldest logs which exceed the max logs
+func (s *auditService) removeOldest(ctx context.Context) error {
+	logs, err := s.list(ctx, 0)
+	if err != nil {
+		return err
+	}
+	for idx := s.maxLogs; idx < len(logs); idx++ {
+		if err := s.repo.Delete(ctx, getAuditLogPath(logs[idx].ID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getAuditLogPath returns the path which storing audit log
+func getAuditLogPath(id string) string {
+	return pathutil.Keys.Cluster.AuditLogs.Key(id)
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"gopkg.in/check.v1"
+
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/state"
+)
+
+type testAuditSRVSuite struct {
+	mock.RepoTestSuite
+}
+
+func TestAuditSRV(t *testing.T) {
+	check.Suite(&testAuditSRVSuite{})
+	check.TestingT(t)
+}
+
+func (ts *testAuditSRVSuite) TestAuditLog(c *check.C) {
+	repo, _ := state.NewRepo(state.Config{
+		Endpoints: ts.Cluster.Endpoints,
+	})
+	srv := NewAuditService(repo, 2)
+
+	err := srv.Record(models.AuditLog{})
+	c.Assert(err, check.NotNil)
+
+	for idx, target := range []string{"db1", "db2", "db3"} {
+		err = srv.Record(models.AuditLog{
+			Operation: models.AuditCreateDatabase,
+			Target:    target,
+			Initiator: "admin",
+			Timestamp: int64(idx + 1),
+		})
+		c.Assert(err, check.IsNil)
+	}
+	// the oldest log is removed
+	logs, err := srv.List(0)
+	c.Assert(err, check.IsNil)
+	c.Assert(logs, check.HasLen, 2)
+	c.Assert(logs[0].Target, check.Equals, "db3")
+	c.Assert(logs[0].Initiator, check.Equals, "admin")
+	c.Assert(logs[1].Target, check.Equals, "db2")
+
+	logs, _ = srv.List(1)
+	c.Assert(logs, check.HasLen, 1)
+	c.Assert(logs[0].Target, check.Equals, "db3")
+
+	_ = repo.Put(context.TODO(), getAuditLogPath("bad"), []byte("bad"))
+	_, err = srv.List(0)
+	c.Assert(err, check.NotNil)
+}
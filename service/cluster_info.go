@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/pathutil"
+	"github.com/eleme/lindb/pkg/state"
+	"github.com/eleme/lindb/pkg/timeutil"
+)
+
+// schemaMigrations are the migrations of metadata schema, schemaMigrations[v] migrates the metadata
+// in state repository from schema version v to v+1, the migration must be idempotent.
+var schemaMigrations = map[int]func(ctx context.Context, repo state.Repository) error{}
+
+// ClusterInfoService represents the service of cluster identity, which prevents the nodes of different clusters
+// or incompatible versions mingling in one namespace of state repository.
+type ClusterInfoService interface {
+	// Bootstrap creates the cluster info with the id(generated if empty) on the first start of cluster,
+	// or validates the cluster info when node joins the cluster, returns the cluster info.
+	Bootstrap(clusterID string) (*models.ClusterInfo, error)
+	// Get returns the cluster info, returns state.ErrNotExist if the cluster isn't bootstrapped
+	Get() (*models.ClusterInfo, error)
+	// Migrate migrates the metadata from the schema version of cluster to the version supported by node,
+	// returns the cluster info after migrating.
+	Migrate() (*models.ClusterInfo, error)
+}
+
+// clusterInfoService implements ClusterInfoService based on state repository
+type clusterInfoService struct {
+	repo state.Repository
+}
+
+// NewClusterInfoService creates the cluster info service
+func NewClusterInfoService(repo state.Repository) ClusterInfoService {
+	return &clusterInfoService{repo: repo}
+}
+
+// Bootstrap creates the cluster info if not exist, then validates the id and schema version of cluster.
+// The namespace created before cluster info(no cluster info but metadata exists) is adopted as current version,
+// because the schema of metadata isn't changed since then.
+func (s *clusterInfoService) Bootstrap(clusterID string) (*models.ClusterInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	info, _, err := s.get(ctx)
+	if err == state.ErrNotExist {
+		info, err = s.create(ctx, clusterID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := validateClusterInfo(info, clusterID); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Get returns the cluster info
+func (s *clusterInfoService) Get() (*models.ClusterInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	info, _, err := s.get(ctx)
+	return info, err
+}
+
+// Migrate runs the migrations from the schema version of cluster one by one, the schema version is increased
+// after each migration, so that the interrupted migration can be resumed.
+func (s *clusterInfoService) Migrate() (*models.ClusterInfo, error) {
+	ctx := context.Background()
+	info, revision, err := s.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if info.SchemaVersion > models.MetadataSchemaVersion {
+		return nil, fmt.Errorf("metadata schema version[%d] of cluster is newer than version[%d] of node, "+
+			"upgrade the node to migrate", info.SchemaVersion, models.MetadataSchemaVersion)
+	}
+	for info.SchemaVersion < models.MetadataSchemaVersion {
+		migration, ok := schemaMigrations[info.SchemaVersion]
+		if !ok {
+			return nil, fmt.Errorf("migration of metadata schema version[%d] not found", info.SchemaVersion)
+		}
+		if err := migration(ctx, s.repo); err != nil {
+			return nil, fmt.Errorf("migrate metadata schema version[%d] error:%s", info.SchemaVersion, err)
+		}
+		info.SchemaVersion++
+		if revision, err = s.save(ctx, info, revision); err != nil {
+			return nil, err
+		}
+	}
+	return info, nil
+}
+
+// get returns the cluster info with the revision of it
+func (s *clusterInfoService) get(ctx context.Context) (*models.ClusterInfo, int64, error) {
+	data, revision, err := s.repo.GetWithRevision(ctx, pathutil.Keys.Cluster.Info)
+	if err != nil {
+		return nil, 0, err
+	}
+	info := &models.ClusterInfo{}
+	if err := json.Unmarshal(data, info); err != nil {
+		return nil, 0, fmt.Errorf("unmarshal cluster info error:%s", err)
+	}
+	return info, revision, nil
+}
+
+// create creates the cluster info with current schema version if not exist,
+// returns the cluster info created by other node if it's created concurrently.
+func (s *clusterInfoService) create(ctx context.Context, clusterID string) (*models.ClusterInfo, error) {
+	if len(clusterID) == 0 {
+		id := make([]byte, 16)
+		if _, err := rand.Read(id); err != nil {
+			return nil, fmt.Errorf("generate cluster id error:%s", err)
+		}
+		clusterID = hex.EncodeToString(id)
+	}
+	info := &models.ClusterInfo{
+		ID:            clusterID,
+		SchemaVersion: models.MetadataSchemaVersion,
+		CreateTime:    timeutil.Now(),
+	}
+	if _, err := s.save(ctx, info, 0); err != nil {
+		info, _, err = s.get(ctx)
+		return info, err
+	}
+	return info, nil
+}
+
+// save saves the cluster info if it isn't changed since the revision read, returns the new revision
+func (s *clusterInfoService) save(ctx context.Context, info *models.ClusterInfo, revision int64) (int64, error) {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return 0, fmt.Errorf("marshal cluster info error:%s", err)
+	}
+	success, err := s.repo.Txn(ctx, state.Txn{
+		Cmps: []state.Cmp{state.CmpRevision(pathutil.Keys.Cluster.Info, revision)},
+		Ops:  []state.Op{state.OpPut(pathutil.Keys.Cluster.Info, data)},
+	})
+	if err != nil {
+		return 0, err
+	}
+	if !success {
+		return 0, fmt.Errorf("cluster info is changed by others")
+	}
+	_, revision, err = s.repo.GetWithRevision(ctx, pathutil.Keys.Cluster.Info)
+	return revision, err
+}
+
+// validateClusterInfo checks if node can join the cluster, the id must be same as the configured id,
+// and the schema version must be same as the version supported by node.
+func validateClusterInfo(info *models.ClusterInfo, clusterID string) error {
+	if len(info.ID) == 0 || info.SchemaVersion <= 0 {
+		return fmt.Errorf("cluster info[%s] misses id or schema version, fix it with the right id and "+
+			"schema version[%d] of cluster in state repository", pathutil.Keys.Cluster.Info, models.MetadataSchemaVersion)
+	}
+	if len(clusterID) > 0 && clusterID != info.ID {
+		return fmt.Errorf("node belongs to cluster[%s], but the namespace is used by cluster[%s], "+
+			"check the namespace and cluster-id of coordinator config", clusterID, info.ID)
+	}
+	if info.SchemaVersion > models.MetadataSchemaVersion {
+		return fmt.Errorf("metadata schema version[%d] of cluster is newer than version[%d] of node, "+
+			"upgrade the node before joining the cluster", info.SchemaVersion, models.MetadataSchemaVersion)
+	}
+	if info.SchemaVersion < models.MetadataSchemaVersion {
+		return fmt.Errorf("metadata schema version[%d] of cluster is older than version[%d] of node, "+
+			"migrate the metadata by `lind admin migrate-metadata` before upgrading the nodes",
+			info.SchemaVersion, models.MetadataSchemaVersion)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"gopkg.in/check.v1"
+
+	"github.com/eleme/lindb/constants"
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/state"
+)
+
+type testClusterInfoSRVSuite struct {
+	mock.RepoTestSuite
+}
+
+func TestClusterInfoSRV(t *testing.T) {
+	check.Suite(&testClusterInfoSRVSuite{})
+	check.TestingT(t)
+}
+
+func (ts *testClusterInfoSRVSuite) TestBootstrap(c *check.C) {
+	repo, _ := state.NewRepo(state.Config{
+		Namespace: "/test/cluster/bootstrap",
+		Endpoints: ts.Cluster.Endpoints,
+	})
+	srv := NewClusterInfoService(repo)
+	_, err := srv.Get()
+	c.Assert(err, check.Equals, state.ErrNotExist)
+
+	// first start, the id is generated
+	info, err := srv.Bootstrap("")
+	c.Assert(err, check.IsNil)
+	c.Assert(info.ID, check.HasLen, 32)
+	c.Assert(info.SchemaVersion, check.Equals, models.MetadataSchemaVersion)
+	// join the cluster
+	info2, err := srv.Bootstrap(info.ID)
+	c.Assert(err, check.IsNil)
+	c.Assert(*info2, check.DeepEquals, *info)
+	info2, _ = srv.Get()
+	c.Assert(*info2, check.DeepEquals, *info)
+	// the namespace is used by another cluster
+	_, err = srv.Bootstrap("other")
+	c.Assert(err, check.NotNil)
+
+	// configured cluster id
+	repo2, _ := state.NewRepo(state.Config{
+		Namespace: "/test/cluster/bootstrap2",
+		Endpoints: ts.Cluster.Endpoints,
+	})
+	info, err = NewClusterInfoService(repo2).Bootstrap("cluster1")
+	c.Assert(err, check.IsNil)
+	c.Assert(info.ID, check.Equals, "cluster1")
+
+	for _, invalid := range []models.ClusterInfo{
+		{ID: "cluster1"},
+		{SchemaVersion: models.MetadataSchemaVersion},
+		{ID: "cluster1", SchemaVersion: models.MetadataSchemaVersion + 1},
+		{ID: "cluster1", SchemaVersion: models.MetadataSchemaVersion - 1},
+	} {
+		data, _ := json.Marshal(&invalid)
+		_ = repo2.Put(context.TODO(), constants.ClusterInfoPath, data)
+		_, err = NewClusterInfoService(repo2).Bootstrap("cluster1")
+		c.Assert(err, check.NotNil)
+	}
+	_ = repo2.Put(context.TODO(), constants.ClusterInfoPath, []byte("abc"))
+	_, err = NewClusterInfoService(repo2).Bootstrap("cluster1")
+	c.Assert(err, check.NotNil)
+}
+
+func (ts *testClusterInfoSRVSuite) TestMigrate(c *check.C) {
+	repo, _ := state.NewRepo(state.Config{
+		Namespace: "/test/cluster/migrate",
+		Endpoints: ts.Cluster.Endpoints,
+	})
+	srv := NewClusterInfoService(repo)
+	_, err := srv.Migrate()
+	c.Assert(err, check.Equals, state.ErrNotExist)
+
+	data, _ := json.Marshal(&models.ClusterInfo{ID: "cluster1", SchemaVersion: models.MetadataSchemaVersion - 1})
+	_ = repo.Put(context.TODO(), constants.ClusterInfoPath, data)
+	// migration not found
+	_, err = srv.Migrate()
+	c.Assert(err, check.NotNil)
+
+	defer delete(schemaMigrations, models.MetadataSchemaVersion-1)
+	schemaMigrations[models.MetadataSchemaVersion-1] = func(ctx context.Context, repo state.Repository) error {
+		return fmt.Errorf("err")
+	}
+	_, err = srv.Migrate()
+	c.Assert(err, check.NotNil)
+	migrated := false
+	schemaMigrations[models.MetadataSchemaVersion-1] = func(ctx context.Context, repo state.Repository) error {
+		migrated = true
+		return nil
+	}
+	info, err := srv.Migrate()
+	c.Assert(err, check.IsNil)
+	c.Assert(migrated, check.Equals, true)
+	c.Assert(info.SchemaVersion, check.Equals, models.MetadataSchemaVersion)
+	_, err = srv.Bootstrap("cluster1")
+	c.Assert(err, check.IsNil)
+
+	// newer version
+	data, _ = json.Marshal(&models.ClusterInfo{ID: "cluster1", SchemaVersion: models.MetadataSchemaVersion + 1})
+	_ = repo.Put(context.TODO(), constants.ClusterInfoPath, data)
+	_, err = srv.Migrate()
+	c.Assert(err, check.NotNil)
+}
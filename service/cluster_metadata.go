@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/pathutil"
+	"github.com/eleme/lindb/pkg/state"
+	"github.com/eleme/lindb/pkg/timeutil"
+)
+
+// ImportOption represents the options of importing cluster metadata
+type ImportOption struct {
+	// Overwrite overwrites the existing metadata, if false import fails when any metadata exists
+	Overwrite bool
+	// SkipShardAssignment skips importing shard assignments, which reference the nodes of original cluster,
+	// master creates new shard assignments based on active nodes when database config is imported.
+	SkipShardAssignment bool
+}
+
+// ClusterMetadataService represents exporting/importing all metadata of cluster,
+// storage clusters/databases are stored in broker's state repository,
+// shard assignments are stored in related storage cluster's state repository.
+// The metadata under the other roots of keyspace(except the state roots, see pathutil.Keyspace.StateRoots)
+// are exported as key/value entries from broker's and storage clusters' state repositories.
+type ClusterMetadataService interface {
+	// Export exports all metadata of cluster
+	Export() (*models.ClusterMetadata, error)
+	// Import imports the metadata into cluster
+	Import(metadata *models.ClusterMetadata, option ImportOption) error
+}
+
+// clusterMetadataService implements ClusterMetadataService interface
+type clusterMetadataService struct {
+	repo                  state.Repository
+	storageClusterService StorageClusterService
+	storageClusterRepos   StorageClusterRepos
+	databaseService       DatabaseService
+}
+
+// NewClusterMetadataService creates cluster metadata service using broker's state repository,
+// the shard assignments are accessed by the shared state repos of storage clusters
+func NewClusterMetadataService(repo state.Repository, storageClusterRepos StorageClusterRepos) ClusterMetadataService {
+	return &clusterMetadataService{
+		repo:                  repo,
+		storageClusterService: NewStorageClusterService(repo),
+		storageClusterRepos:   storageClusterRepos,
+		databaseService:       NewDatabaseService(repo),
+	}
+}
+
+// Export exports all storage clusters, databases, shard assignments and the other metadata entries
+func (s *clusterMetadataService) Export() (*models.ClusterMetadata, error) {
+	storageClusters, err := s.storageClusterService.List()
+	if err != nil {
+		return nil, fmt.Errorf("list storage clusters error:%s", err)
+	}
+	databases, err := s.databaseService.List()
+	if err != nil {
+		return nil, fmt.Errorf("list databases error:%s", err)
+	}
+	metadata := &models.ClusterMetadata{
+		Version:         models.ClusterMetadataVersion,
+		ExportTime:      timeutil.Now(),
+		StorageClusters: storageClusters,
+		Databases:       databases,
+	}
+	err = s.forEachShardAssign(storageClusters, func(cluster string, shardAssignService ShardAssignService) error {
+		for _, database := range databases {
+			if !containsCluster(database, cluster) {
+				continue
+			}
+			shardAssign, err := shardAssignService.Get(database.Name)
+			if err == state.ErrNotExist {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("get shard assignment of database[%s] error:%s", database.Name, err)
+			}
+			metadata.ShardAssignments = append(metadata.ShardAssignments, models.DatabaseShardAssignment{
+				Cluster:         cluster,
+				Database:        database.Name,
+				ShardAssignment: shardAssign,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if metadata.Entries, err = s.exportEntries(storageClusters); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// Import imports storage clusters, shard assignments, metadata entries, then database configs,
+// shard assignments are imported before database configs, so that master doesn't create new shard assignments.
+func (s *clusterMetadataService) Import(metadata *models.ClusterMetadata, option ImportOption) error {
+	if metadata.Version != models.ClusterMetadataVersion {
+		return fmt.Errorf("not support cluster metadata version:%d", metadata.Version)
+	}
+	shardAssignments := make(map[string][]models.DatabaseShardAssignment)
+	if !option.SkipShardAssignment {
+		for _, shardAssign := range metadata.ShardAssignments {
+			shardAssignments[shardAssign.Cluster] = append(shardAssignments[shardAssign.Cluster], shardAssign)
+		}
+	}
+	if !option.Overwrite {
+		if err := s.checkNotExist(metadata, shardAssignments); err != nil {
+			return err
+		}
+	}
+	for _, storageCluster := range metadata.StorageClusters {
+		if err := s.storageClusterService.Save(storageCluster); err != nil {
+			return fmt.Errorf("save storage cluster[%s] error:%s", storageCluster.Name, err)
+		}
+	}
+	err := s.forEachShardAssign(metadata.StorageClusters, func(cluster string, shardAssignService ShardAssignService) error {
+		for _, shardAssign := range shardAssignments[cluster] {
+			if err := shardAssignService.Save(shardAssign.Database, shardAssign.ShardAssignment); err != nil {
+				return fmt.Errorf("save shard assignment of database[%s] error:%s", shardAssign.Database, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = s.forEachEntry(metadata, func(repo state.Repository, entry models.MetadataEntry) error {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := repo.Put(ctx, entry.Key, entry.Value); err != nil {
+			return fmt.Errorf("save metadata[%s] of cluster[%s] error:%s", entry.Key, entry.Cluster, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, database := range metadata.Databases {
+		if err := s.databaseService.Save(database); err != nil {
+			return fmt.Errorf("save database[%s] error:%s", database.Name, err)
+		}
+	}
+	return nil
+}
+
+// checkNotExist checks the metadata which will be imported doesn't exist
+func (s *clusterMetadataService) checkNotExist(metadata *models.ClusterMetadata,
+	shardAssignments map[string][]models.DatabaseShardAssignment) error {
+	for _, storageCluster := range metadata.StorageClusters {
+		if _, err := s.storageClusterService.Get(storageCluster.Name); err != state.ErrNotExist {
+			return fmt.Errorf("storage cluster[%s] already exist", storageCluster.Name)
+		}
+	}
+	for _, database := range metadata.Databases {
+		if _, err := s.databaseService.Get(database.Name); !errors.Is(err, errors.ErrDatabaseNotFound) {
+			return fmt.Errorf("database[%s] already exist", database.Name)
+		}
+	}
+	err := s.forEachShardAssign(metadata.StorageClusters, func(cluster string, shardAssignService ShardAssignService) error {
+		for _, shardAssign := range shardAssignments[cluster] {
+			if _, err := shardAssignService.Get(shardAssign.Database); err != state.ErrNotExist {
+				return fmt.Errorf("shard assignment of database[%s] already exist in cluster[%s]",
+					shardAssign.Database, cluster)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return s.forEachEntry(metadata, func(repo state.Repository, entry models.MetadataEntry) error {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if _, err := repo.Get(ctx, entry.Key); err != state.ErrNotExist {
+			return fmt.Errorf("metadata[%s] already exist in cluster[%s]", entry.Key, entry.Cluster)
+		}
+		return nil
+	})
+}
+
+// exportEntries exports the metadata entries from broker's state repository and storage clusters' state repositories
+func (s *clusterMetadataService) exportEntries(storageClusters []models.StorageCluster) ([]models.MetadataEntry, error) {
+	entries, err := listEntries(s.repo, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, storageCluster := range storageClusters {
+		repo, err := s.storageClusterRepos.Get(storageCluster)
+		if err != nil {
+			return nil, err
+		}
+		clusterEntries, err := listEntries(repo, storageCluster.Name)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, clusterEntries...)
+	}
+	return entries, nil
+}
+
+// forEachEntry invokes fn with each metadata entry and the state repository which the entry is stored in,
+// the entry of storage cluster which isn't in metadata is invalid.
+func (s *clusterMetadataService) forEachEntry(metadata *models.ClusterMetadata,
+	fn func(repo state.Repository, entry models.MetadataEntry) error) error {
+	storageClusters := make(map[string]models.StorageCluster)
+	for _, storageCluster := range metadata.StorageClusters {
+		storageClusters[storageCluster.Name] = storageCluster
+	}
+	for _, entry := range metadata.Entries {
+		if !isEntryKey(entry.Key) {
+			return fmt.Errorf("metadata[%s] isn't under the metadata roots of keyspace", entry.Key)
+		}
+		repo := s.repo
+		if len(entry.Cluster) > 0 {
+			storageCluster, ok := storageClusters[entry.Cluster]
+			if !ok {
+				return fmt.Errorf("storage cluster[%s] of metadata[%s] not found", entry.Cluster, entry.Key)
+			}
+			var err error
+			if repo, err = s.storageClusterRepos.Get(storageCluster); err != nil {
+				return err
+			}
+		}
+		if err := fn(repo, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forEachShardAssign gets each storage cluster's shared state repository, then invokes fn with shard assign service
+func (s *clusterMetadataService) forEachShardAssign(storageClusters []models.StorageCluster,
+	fn func(cluster string, shardAssignService ShardAssignService) error) error {
+	for _, storageCluster := range storageClusters {
+		repo, err := s.storageClusterRepos.Get(storageCluster)
+		if err != nil {
+			return err
+		}
+		if err := fn(storageCluster.Name, NewShardAssignService(repo)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// containsCluster checks if database is stored in the storage cluster
+func containsCluster(database models.Database, cluster string) bool {
+	for _, c := range database.Clusters {
+		if c.Name == cluster {
+			return true
+		}
+	}
+	return false
+}
+
+// entryRoots returns the roots and keys of keyspace which are exported as metadata entries,
+// except the state roots and the roots of typed metadata(storage clusters/databases/shard assignments).
+func entryRoots() []string {
+	excluded := map[string]bool{
+		string(pathutil.Keys.Cluster.StorageClusters): true,
+		string(pathutil.Keys.Databases.Configs):       true,
+		string(pathutil.Keys.Databases.Assignments):   true,
+	}
+	for _, root := range pathutil.Keys.StateRoots() {
+		excluded[root] = true
+	}
+	var roots []string
+	for _, root := range pathutil.Keys.Roots() {
+		if !excluded[root] {
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+// isEntryKey checks if the key is one of the metadata roots or under them
+func isEntryKey(key string) bool {
+	for _, root := range entryRoots() {
+		if key == root || strings.HasPrefix(key, pathutil.KeyRoot(root).Prefix()) {
+			return true
+		}
+	}
+	return false
+}
+
+// listEntries lists the metadata entries in state repository, the root is listed as a key and as the prefix of keys
+func listEntries(repo state.Repository, cluster string) ([]models.MetadataEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var entries []models.MetadataEntry
+	for _, root := range entryRoots() {
+		value, err := repo.Get(ctx, root)
+		switch {
+		case err == nil:
+			entries = append(entries, models.MetadataEntry{Cluster: cluster, Key: root, Value: value})
+		case err != state.ErrNotExist:
+			return nil, fmt.Errorf("get metadata[%s] of cluster[%s] error:%s", root, cluster, err)
+		}
+		kvs, err := repo.ListKV(ctx, pathutil.KeyRoot(root).Prefix())
+		if err != nil {
+			return nil, fmt.Errorf("list metadata[%s] of cluster[%s] error:%s", root, cluster, err)
+		}
+		for _, kv := range kvs {
+			entries = append(entries, models.MetadataEntry{Cluster: cluster, Key: kv.Key, Value: kv.Value})
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"gopkg.in/check.v1"
+
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/pathutil"
+	"github.com/eleme/lindb/pkg/state"
+)
+
+type testClusterMetadataSRVSuite struct {
+	mock.RepoTestSuite
+}
+
+func TestClusterMetadataSRV(t *testing.T) {
+	check.Suite(&testClusterMetadataSRVSuite{})
+	check.TestingT(t)
+}
+
+func (ts *testClusterMetadataSRVSuite) TestExportImport(c *check.C) {
+	repo, _ := state.NewRepo(state.Config{Namespace: "/metadata/src", Endpoints: ts.Cluster.Endpoints})
+	storageCfg := state.Config{Namespace: "/metadata/src/storage", Endpoints: ts.Cluster.Endpoints}
+	storageRepo, _ := state.NewRepo(storageCfg)
+
+	storageCluster := models.StorageCluster{Name: "test", Config: storageCfg}
+	_ = NewStorageClusterService(repo).Save(storageCluster)
+	database := models.Database{Name: "db", Clusters: []models.DatabaseCluster{{Name: "test", NumOfShard: 1, ReplicaFactor: 1}}}
+	_ = NewDatabaseService(repo).Save(database)
+	_ = NewDatabaseService(repo).Save(models.Database{Name: "db2",
+		Clusters: []models.DatabaseCluster{{Name: "other", NumOfShard: 1, ReplicaFactor: 1}}})
+	shardAssign := models.NewShardAssignment()
+	shardAssign.Nodes[1] = models.Node{IP: "1.1.1.1", Port: 2080}
+	shardAssign.AddReplica(1, 1)
+	_ = NewShardAssignService(storageRepo).Save("db", shardAssign)
+	// the other metadata of broker and storage cluster
+	udfKey := pathutil.KeyRoot(pathutil.Keys.Queries.UDFs.Key("db")).Key("f")
+	_ = repo.Put(context.TODO(), udfKey, []byte("udf"))
+	_ = repo.Put(context.TODO(), pathutil.Keys.Queries.Blacklist.Key("rule"), []byte("rule"))
+	_ = storageRepo.Put(context.TODO(), pathutil.Keys.Databases.Limits.Key("db"), []byte("limits"))
+	_ = storageRepo.Put(context.TODO(), pathutil.Keys.Shards.States.Key("db"), []byte("states"))
+	_ = storageRepo.Put(context.TODO(), pathutil.Keys.Shards.Corruptions.Key("c1"), []byte("corruption"))
+	// the state isn't metadata
+	_ = repo.Put(context.TODO(), pathutil.Keys.Nodes.Active.Key("node"), []byte("node"))
+	_ = storageRepo.Put(context.TODO(), pathutil.Keys.Stats.Nodes.Key("node"), []byte("stats"))
+	entries := []models.MetadataEntry{
+		{Key: pathutil.Keys.Queries.Blacklist.Key("rule"), Value: []byte("rule")},
+		{Key: udfKey, Value: []byte("udf")},
+		{Cluster: "test", Key: pathutil.Keys.Databases.Limits.Key("db"), Value: []byte("limits")},
+		{Cluster: "test", Key: pathutil.Keys.Shards.States.Key("db"), Value: []byte("states")},
+		{Cluster: "test", Key: pathutil.Keys.Shards.Corruptions.Key("c1"), Value: []byte("corruption")},
+	}
+
+	storageClusterRepos := NewStorageClusterRepos()
+	defer func() {
+		_ = storageClusterRepos.Close()
+	}()
+	srv := NewClusterMetadataService(repo, storageClusterRepos)
+	metadata, err := srv.Export()
+	c.Assert(err, check.IsNil)
+	c.Assert(metadata.Version, check.Equals, models.ClusterMetadataVersion)
+	c.Assert(metadata.StorageClusters, check.DeepEquals, []models.StorageCluster{storageCluster})
+	c.Assert(len(metadata.Databases), check.Equals, 2)
+	c.Assert(metadata.ShardAssignments, check.DeepEquals, []models.DatabaseShardAssignment{
+		{Cluster: "test", Database: "db", ShardAssignment: shardAssign},
+	})
+	c.Assert(metadata.Entries, check.DeepEquals, entries)
+
+	// import into fresh cluster, but shard assignment already exist in storage cluster
+	dstRepo, _ := state.NewRepo(state.Config{Namespace: "/metadata/dst", Endpoints: ts.Cluster.Endpoints})
+	dst := NewClusterMetadataService(dstRepo, storageClusterRepos)
+	err = dst.Import(metadata, ImportOption{})
+	c.Assert(err, check.NotNil)
+	// the metadata of storage cluster already exist
+	err = dst.Import(metadata, ImportOption{SkipShardAssignment: true})
+	c.Assert(err, check.NotNil)
+	for _, entry := range entries[2:] {
+		_ = storageRepo.Delete(context.TODO(), entry.Key)
+	}
+	err = dst.Import(metadata, ImportOption{SkipShardAssignment: true})
+	c.Assert(err, check.IsNil)
+	imported, _ := dst.Export()
+	c.Assert(imported.StorageClusters, check.DeepEquals, metadata.StorageClusters)
+	c.Assert(imported.Databases, check.DeepEquals, metadata.Databases)
+	c.Assert(imported.Entries, check.DeepEquals, entries)
+
+	// metadata already exist
+	err = dst.Import(metadata, ImportOption{SkipShardAssignment: true})
+	c.Assert(err, check.NotNil)
+	metadata.StorageClusters = nil
+	err = dst.Import(metadata, ImportOption{SkipShardAssignment: true})
+	c.Assert(err, check.NotNil)
+	metadata.StorageClusters = []models.StorageCluster{storageCluster}
+	err = dst.Import(metadata, ImportOption{Overwrite: true})
+	c.Assert(err, check.IsNil)
+
+	// entry of storage cluster not found or not metadata
+	err = dst.Import(&models.ClusterMetadata{Version: models.ClusterMetadataVersion,
+		Entries: []models.MetadataEntry{{Cluster: "other", Key: udfKey}}}, ImportOption{Overwrite: true})
+	c.Assert(err, check.NotNil)
+	err = dst.Import(&models.ClusterMetadata{Version: models.ClusterMetadataVersion,
+		Entries: []models.MetadataEntry{{Key: pathutil.Keys.Nodes.Active.Key("node")}}}, ImportOption{Overwrite: true})
+	c.Assert(err, check.NotNil)
+
+	// version not support
+	err = dst.Import(&models.ClusterMetadata{}, ImportOption{})
+	c.Assert(err, check.NotNil)
+	// bad database config
+	err = dst.Import(&models.ClusterMetadata{Version: models.ClusterMetadataVersion,
+		Databases: []models.Database{{Name: "bad"}}}, ImportOption{})
+	c.Assert(err, check.NotNil)
+	// bad storage cluster config
+	err = dst.Import(&models.ClusterMetadata{Version: models.ClusterMetadataVersion,
+		StorageClusters: []models.StorageCluster{{}}}, ImportOption{Overwrite: true})
+	c.Assert(err, check.NotNil)
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/eleme/lindb/broker/rpc"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/pathutil"
+	"github.com/eleme/lindb/pkg/state"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/tsdb"
+)
+
+// repairTimeout is the max duration of re-replicating a family from replica
+const repairTimeout = 10 * time.Minute
+
+// CorruptionService handles the corrupted files found in the engines of current storage node,
+// the corruption is recorded into state repository, then the family is re-replicated from a healthy replica,
+// the record is removed after repaired, so the remaining records need to be handled by operators.
+type CorruptionService interface {
+	// Report records the corruption and repairs the family in background, which is used as tsdb.CorruptionHandler
+	Report(corruption models.Corruption)
+}
+
+// corruptionService implements CorruptionService interface
+type corruptionService struct {
+	ctx            context.Context
+	node           models.Node
+	repo           state.Repository
+	storageService StorageService
+	newClient      adminClientFactory
+	// repairing is the ids of corruptions being repaired, the same family is reported by each bad file
+	repairing map[string]struct{}
+	mutex     sync.Mutex
+	logger    *logger.Logger
+}
+
+// NewCorruptionService creates the corruption service of storage node
+func NewCorruptionService(ctx context.Context, node models.Node, repo state.Repository,
+	storageService StorageService) CorruptionService {
+	return &corruptionService{
+		ctx:            ctx,
+		node:           node,
+		repo:           repo,
+		storageService: storageService,
+		newClient: func(node models.Node) rpc.AdminClient {
+			return rpc.NewAdminClient(fmt.Sprintf("%s:%d", node.IP, node.Port))
+		},
+		repairing: make(map[string]struct{}),
+		logger:    logger.GetLogger("service/corruption"),
+	}
+}
+
+// Report records the corruption and repairs the family in background
+func (s *corruptionService) Report(corruption models.Corruption) {
+	corruption.Node = s.node.Key()
+	corruption.ReportTime = timeutil.Now()
+	id := corruption.ID()
+
+	s.mutex.Lock()
+	if _, ok := s.repairing[id]; ok {
+		s.mutex.Unlock()
+		return
+	}
+	s.repairing[id] = struct{}{}
+	s.mutex.Unlock()
+
+	go func() {
+		defer func() {
+			s.mutex.Lock()
+			delete(s.repairing, id)
+			s.mutex.Unlock()
+		}()
+		s.handle(id, corruption)
+	}()
+}
+
+// handle records the corruption, then repairs it, removes the record if repaired
+func (s *corruptionService) handle(id string, corruption models.Corruption) {
+	s.logger.Warn("found corrupted file", logger.Any("corruption", corruption))
+	key := pathutil.Keys.Shards.Corruptions.Key(id)
+	data, _ := json.Marshal(&corruption)
+	if err := s.repo.Put(s.ctx, key, data); err != nil {
+		s.logger.Error("record corruption error", logger.String("key", key), logger.Error(err))
+	}
+	if corruption.Index {
+		s.logger.Error("corrupted index cannot be repaired from replica, the node needs to be rebuilt",
+			logger.String("database", corruption.Database), logger.String("family", corruption.Family))
+		return
+	}
+	if err := s.repair(corruption); err != nil {
+		s.logger.Error("repair corrupted family error", logger.Any("corruption", corruption), logger.Error(err))
+		return
+	}
+	if err := s.repo.Delete(s.ctx, key); err != nil {
+		s.logger.Error("remove repaired corruption error", logger.String("key", key), logger.Error(err))
+	}
+}
+
+// repair re-replicates the family from the other replicas of shard in turn, until one succeeds
+func (s *corruptionService) repair(corruption models.Corruption) error {
+	engine := s.storageService.GetEngine(corruption.Database)
+	if engine == nil {
+		return fmt.Errorf("database[%s] not found", corruption.Database)
+	}
+	assignment, err := NewShardAssignService(s.repo).Get(corruption.Database)
+	if err != nil {
+		return fmt.Errorf("get shard assignment of database[%s] error:%s", corruption.Database, err)
+	}
+	replica, ok := assignment.Shards[corruption.ShardID]
+	if !ok {
+		return fmt.Errorf("shard[%d] of database[%s] not found", corruption.ShardID, corruption.Database)
+	}
+	err = fmt.Errorf("no healthy replica of shard[%d] in database[%s]", corruption.ShardID, corruption.Database)
+	for _, nodeID := range replica.Replicas {
+		node, ok := assignment.Nodes[nodeID]
+		if !ok || node.Key() == s.node.Key() {
+			continue
+		}
+		if err = s.repairFrom(engine, node, corruption); err != nil {
+			s.logger.Warn("repair corrupted family from replica error, try next replica",
+				logger.String("replica", node.Key()), logger.Error(err))
+			continue
+		}
+		return nil
+	}
+	return err
+}
+
+// repairFrom re-replicates the family from the replica in given node
+func (s *corruptionService) repairFrom(engine tsdb.Engine, node models.Node, corruption models.Corruption) error {
+	client := s.newClient(node)
+	if err := client.Init(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	ctx, cancel := context.WithTimeout(s.ctx, repairTimeout)
+	defer cancel()
+	req := models.FamilyExportRequest{
+		Database:     corruption.Database,
+		ShardID:      corruption.ShardID,
+		IntervalType: corruption.IntervalType,
+		Segment:      corruption.Segment,
+		Family:       corruption.Family,
+	}
+	return engine.RepairFamily(ctx, corruption, func(after uint32) (*models.FamilyExport, error) {
+		req.After = after
+		return client.ExportFamily(ctx, &req)
+	})
+}
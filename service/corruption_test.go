@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"gopkg.in/check.v1"
+
+	"github.com/eleme/lindb/broker/rpc"
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/interval"
+	"github.com/eleme/lindb/pkg/pathutil"
+	"github.com/eleme/lindb/pkg/state"
+	"github.com/eleme/lindb/pkg/util"
+	"github.com/eleme/lindb/tsdb"
+)
+
+type testCorruptionSRVSuite struct {
+	mock.RepoTestSuite
+}
+
+func TestCorruptionSRV(t *testing.T) {
+	check.Suite(&testCorruptionSRVSuite{})
+	check.TestingT(t)
+}
+
+func (ts *testCorruptionSRVSuite) TestReport(c *check.C) {
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	repo, _ := state.NewRepo(state.Config{Endpoints: ts.Cluster.Endpoints})
+
+	engine := tsdb.NewMockEngine(ctrl)
+	tsdb.RegisterEngine("corruption_srv_test", func(name string, path string, dataDirs tsdb.DataDirs) (tsdb.Engine, error) {
+		return engine, nil
+	})
+	shardOption := validOption
+	shardOption.EngineType = "corruption_srv_test"
+	engine.EXPECT().GetShard(1).Return(nil)
+	engine.EXPECT().CreateShards(shardOption, 1).Return(nil)
+	storageService := NewStorageService(config.Engine{Path: testPath})
+	_ = storageService.CreateShards("corruption_db", shardOption, 1)
+
+	self := models.Node{IP: "127.0.0.1", Port: 2001}
+	shardAssign := models.NewShardAssignment()
+	shardAssign.Nodes[1] = self
+	shardAssign.Nodes[2] = models.Node{IP: "127.0.0.1", Port: 2002}
+	shardAssign.Nodes[3] = models.Node{IP: "127.0.0.1", Port: 2003}
+	shardAssign.AddReplica(1, 1)
+	shardAssign.AddReplica(1, 2)
+	shardAssign.AddReplica(1, 3)
+	_ = NewShardAssignService(repo).Save("corruption_db", shardAssign)
+
+	srv := NewCorruptionService(context.TODO(), self, repo, storageService)
+	var nodes []uint16
+	srv.(*corruptionService).newClient = func(node models.Node) rpc.AdminClient {
+		nodes = append(nodes, node.Port)
+		client := rpc.NewMockAdminClient(ctrl)
+		if node.Port == 2002 {
+			client.EXPECT().Init().Return(fmt.Errorf("err"))
+			return client
+		}
+		client.EXPECT().Init().Return(nil)
+		client.EXPECT().Close().Return(nil)
+		client.EXPECT().ExportFamily(gomock.Any(), &models.FamilyExportRequest{Database: "corruption_db", ShardID: 1,
+			IntervalType: interval.Day, Segment: "20190702", Family: "10"}).
+			Return(&models.FamilyExport{Done: true}, nil)
+		return client
+	}
+	engine.EXPECT().RepairFamily(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, corruption models.Corruption, exporter tsdb.FamilyExporter) error {
+			_, err := exporter(0)
+			return err
+		})
+
+	// repaired from the healthy replica, the record is removed
+	corruption := models.Corruption{Database: "corruption_db", ShardID: 1, IntervalType: interval.Day,
+		Segment: "20190702", Family: "10"}
+	srv.Report(corruption)
+	waitRepaired(srv)
+	c.Assert(nodes, check.DeepEquals, []uint16{2002, 2003})
+	corruption.Node = self.Key()
+	_, err := repo.Get(context.TODO(), pathutil.Keys.Shards.Corruptions.Key(corruption.ID()))
+	c.Assert(err, check.NotNil)
+
+	// database not found, the record is kept
+	corruption.Database = "not_exist_db"
+	srv.Report(corruption)
+	waitRepaired(srv)
+	_, err = repo.Get(context.TODO(), pathutil.Keys.Shards.Corruptions.Key(corruption.ID()))
+	c.Assert(err, check.IsNil)
+
+	// index isn't repaired
+	corruption = models.Corruption{Database: "corruption_db", Index: true, Family: "metric"}
+	srv.Report(corruption)
+	waitRepaired(srv)
+	corruption.Node = self.Key()
+	_, err = repo.Get(context.TODO(), pathutil.Keys.Shards.Corruptions.Key(corruption.ID()))
+	c.Assert(err, check.IsNil)
+}
+
+// waitRepaired waits until the reported corruptions are handled
+func waitRepaired(srv CorruptionService) {
+	for {
+		s := srv.(*corruptionService)
+		s.mutex.Lock()
+		n := len(s.repairing)
+		s.mutex.Unlock()
+		if n == 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
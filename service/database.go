@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
 	"github.com/eleme/lindb/pkg/pathutil"
 	"github.com/eleme/lindb/pkg/state"
 )
@@ -16,6 +18,8 @@ type DatabaseService interface {
 	Save(database models.Database) error
 	// Get gets database config by name
 	Get(name string) (models.Database, error)
+	// List lists all database configs
+	List() ([]models.Database, error)
 }
 
 // databaseService implements DatabaseService interface
@@ -35,6 +39,9 @@ func (db *databaseService) Save(database models.Database) error {
 	if len(database.Name) == 0 {
 		return fmt.Errorf("name cannot be empty")
 	}
+	if err := pathutil.ValidateName(database.Name); err != nil {
+		return fmt.Errorf("invalid database name:%s", err)
+	}
 	if len(database.Clusters) == 0 {
 		return fmt.Errorf("cluster is empty")
 	}
@@ -42,12 +49,33 @@ func (db *databaseService) Save(database models.Database) error {
 		if len(cluster.Name) == 0 {
 			return fmt.Errorf("cluster name is empty")
 		}
+		if err := pathutil.ValidateName(cluster.Name); err != nil {
+			return fmt.Errorf("invalid cluster name:%s", err)
+		}
 		if cluster.NumOfShard <= 0 {
 			return fmt.Errorf("num. of shard must be > 0")
 		}
 		if cluster.ReplicaFactor <= 0 {
 			return fmt.Errorf("replica factor must be > 0")
 		}
+		if err := cluster.ValidateRoutingTags(); err != nil {
+			return err
+		}
+		if err := cluster.ShardOption.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := db.checkRoutingTags(database); err != nil {
+		return err
+	}
+	if err := database.WriteRules.Validate(); err != nil {
+		return err
+	}
+	if err := database.NamingPolicy.Validate(); err != nil {
+		return err
+	}
+	if err := database.WriteAck.Validate(); err != nil {
+		return err
 	}
 	data, err := json.Marshal(database)
 	if err != nil {
@@ -63,6 +91,9 @@ func (db *databaseService) Get(name string) (models.Database, error) {
 		return database, fmt.Errorf("database name must not be null")
 	}
 	configBytes, err := db.repo.Get(context.TODO(), pathutil.GetDatabaseConfigPath(name))
+	if err == state.ErrNotExist {
+		return database, errors.Wrapf(errors.ErrDatabaseNotFound, "database[%s]", name)
+	}
 	if err != nil {
 		return database, err
 	}
@@ -72,3 +103,44 @@ func (db *databaseService) Get(name string) (models.Database, error) {
 	}
 	return database, nil
 }
+
+// List returns all database configs in the state's repo
+func (db *databaseService) List() ([]models.Database, error) {
+	data, err := db.repo.List(context.TODO(), pathutil.Keys.Databases.Configs.Prefix())
+	if err != nil {
+		return nil, err
+	}
+	var result []models.Database
+	for _, val := range data {
+		database := models.Database{}
+		if err := json.Unmarshal(val, &database); err != nil {
+			return nil, fmt.Errorf("unmarshal database config error:%s", err)
+		}
+		result = append(result, database)
+	}
+	return result, nil
+}
+
+// checkRoutingTags checks if the routing tags of database's clusters are changed,
+// the routing tags cannot be changed after created, because the series written before are routed by them.
+func (db *databaseService) checkRoutingTags(database models.Database) error {
+	existing, err := db.Get(database.Name)
+	if errors.Is(err, errors.ErrDatabaseNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	routingTags := make(map[string][]string)
+	for _, cluster := range existing.Clusters {
+		routingTags[cluster.Name] = cluster.RoutingTags
+	}
+	for _, cluster := range database.Clusters {
+		old, ok := routingTags[cluster.Name]
+		if ok && strings.Join(old, ",") != strings.Join(cluster.RoutingTags, ",") {
+			return fmt.Errorf("routing tags of cluster[%s] cannot be changed from %v to %v",
+				cluster.Name, old, cluster.RoutingTags)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/pathutil"
+	"github.com/eleme/lindb/pkg/state"
+)
+
+// DatabaseLimitsService represents the runtime limits of database, which are stored in the state repository
+// of the storage clusters of database and watched by storage nodes
+type DatabaseLimitsService interface {
+	// Save saves the limits into all storage clusters of database, the limits are removed if empty
+	Save(limits models.DatabaseLimits) error
+	// Get returns the limits of database, returns empty limits if not set
+	Get(databaseName string) (*models.DatabaseLimits, error)
+}
+
+// databaseLimitsService implements DatabaseLimitsService interface
+type databaseLimitsService struct {
+	databaseService       DatabaseService
+	storageClusterService StorageClusterService
+	storageClusterRepos   StorageClusterRepos
+}
+
+// NewDatabaseLimitsService creates the database limits service
+func NewDatabaseLimitsService(databaseService DatabaseService, storageClusterService StorageClusterService,
+	storageClusterRepos StorageClusterRepos) DatabaseLimitsService {
+	return &databaseLimitsService{
+		databaseService:       databaseService,
+		storageClusterService: storageClusterService,
+		storageClusterRepos:   storageClusterRepos,
+	}
+}
+
+// Save saves the limits into the state repository of all storage clusters of database
+func (s *databaseLimitsService) Save(limits models.DatabaseLimits) error {
+	if len(limits.Database) == 0 {
+		return fmt.Errorf("database name cannot be empty")
+	}
+	for metricName, limit := range limits.MaxTagsLimits {
+		if len(metricName) == 0 {
+			return fmt.Errorf("metric name of max tags limit cannot be empty")
+		}
+		if limit == 0 {
+			return fmt.Errorf("max tags limit of metric[%s] cannot be zero", metricName)
+		}
+	}
+	data, err := json.Marshal(limits)
+	if err != nil {
+		return fmt.Errorf("marshal database limits error:%s", err)
+	}
+	database, err := s.databaseService.Get(limits.Database)
+	if err != nil {
+		return errors.Wrapf(err, "get database[%s] config error", limits.Database)
+	}
+	key := pathutil.GetDatabaseLimitsPath(limits.Database)
+	for _, cluster := range database.Clusters {
+		err := withStorageClusterRepo(s.storageClusterService, s.storageClusterRepos, cluster.Name,
+			func(repo state.Repository) error {
+				ctx, cancel := withDefaultTimeout(context.TODO())
+				defer cancel()
+				if len(limits.MaxTagsLimits) == 0 {
+					return repo.Delete(ctx, key)
+				}
+				return repo.Put(ctx, key, data)
+			})
+		if err != nil {
+			return fmt.Errorf("save limits of database[%s] into storage cluster[%s] error:%s",
+				limits.Database, cluster.Name, err)
+		}
+	}
+	return nil
+}
+
+// Get returns the limits of database from the first storage cluster of database
+func (s *databaseLimitsService) Get(databaseName string) (*models.DatabaseLimits, error) {
+	database, err := s.databaseService.Get(databaseName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get database[%s] config error", databaseName)
+	}
+	limits := &models.DatabaseLimits{Database: databaseName}
+	if len(database.Clusters) == 0 {
+		return limits, nil
+	}
+	clusterName := database.Clusters[0].Name
+	err = withStorageClusterRepo(s.storageClusterService, s.storageClusterRepos, clusterName,
+		func(repo state.Repository) error {
+			ctx, cancel := withDefaultTimeout(context.TODO())
+			defer cancel()
+			data, err := repo.Get(ctx, pathutil.GetDatabaseLimitsPath(databaseName))
+			if err == state.ErrNotExist {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			return json.Unmarshal(data, limits)
+		})
+	if err != nil {
+		return nil, fmt.Errorf("get limits of database[%s] from storage cluster[%s] error:%s",
+			databaseName, clusterName, err)
+	}
+	return limits, nil
+}
+
+// withStorageClusterRepo gets the shared state repository of storage cluster, then calls the function with it
+func withStorageClusterRepo(storageClusterService StorageClusterService, storageClusterRepos StorageClusterRepos,
+	clusterName string, fn func(repo state.Repository) error) error {
+	cluster, err := storageClusterService.Get(clusterName)
+	if err != nil {
+		return fmt.Errorf("get storage cluster config error:%s", err)
+	}
+	repo, err := storageClusterRepos.Get(cluster)
+	if err != nil {
+		return err
+	}
+	return fn(repo)
+}
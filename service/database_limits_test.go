@@ -0,0 +1,70 @@
+package service
+
+import (
+	"testing"
+
+	"gopkg.in/check.v1"
+
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/state"
+)
+
+type testDatabaseLimitsSRVSuite struct {
+	mock.RepoTestSuite
+}
+
+func TestDatabaseLimitsSRV(t *testing.T) {
+	check.Suite(&testDatabaseLimitsSRVSuite{})
+	check.TestingT(t)
+}
+
+func (ts *testDatabaseLimitsSRVSuite) TestSaveAndGet(c *check.C) {
+	cfg := state.Config{Endpoints: ts.Cluster.Endpoints}
+	repo, _ := state.NewRepo(cfg)
+	databaseService := NewDatabaseService(repo)
+	storageClusterService := NewStorageClusterService(repo)
+	storageClusterRepos := NewStorageClusterRepos()
+	defer func() {
+		_ = storageClusterRepos.Close()
+	}()
+	srv := NewDatabaseLimitsService(databaseService, storageClusterService, storageClusterRepos)
+
+	limits := models.DatabaseLimits{Database: "limits_db", MaxTagsLimits: map[string]uint32{"cpu": 100}}
+	// invalid limits
+	c.Assert(srv.Save(models.DatabaseLimits{}), check.NotNil)
+	c.Assert(srv.Save(models.DatabaseLimits{Database: "limits_db", MaxTagsLimits: map[string]uint32{"": 1}}),
+		check.NotNil)
+	c.Assert(srv.Save(models.DatabaseLimits{Database: "limits_db", MaxTagsLimits: map[string]uint32{"cpu": 0}}),
+		check.NotNil)
+	// database not exist
+	c.Assert(srv.Save(limits), check.NotNil)
+	_, err := srv.Get("limits_db")
+	c.Assert(err, check.NotNil)
+
+	_ = databaseService.Save(models.Database{
+		Name:     "limits_db",
+		Clusters: []models.DatabaseCluster{{Name: "limits_cluster", NumOfShard: 1, ReplicaFactor: 1}},
+	})
+	// storage cluster not exist
+	c.Assert(srv.Save(limits), check.NotNil)
+	_, err = srv.Get("limits_db")
+	c.Assert(err, check.NotNil)
+
+	_ = storageClusterService.Save(models.StorageCluster{Name: "limits_cluster", Config: cfg})
+	// limits not set
+	result, err := srv.Get("limits_db")
+	c.Assert(err, check.IsNil)
+	c.Assert(result, check.DeepEquals, &models.DatabaseLimits{Database: "limits_db"})
+
+	c.Assert(srv.Save(limits), check.IsNil)
+	result, err = srv.Get("limits_db")
+	c.Assert(err, check.IsNil)
+	c.Assert(result, check.DeepEquals, &limits)
+
+	// empty limits are removed
+	c.Assert(srv.Save(models.DatabaseLimits{Database: "limits_db"}), check.IsNil)
+	result, err = srv.Get("limits_db")
+	c.Assert(err, check.IsNil)
+	c.Assert(result, check.DeepEquals, &models.DatabaseLimits{Database: "limits_db"})
+}
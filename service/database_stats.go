@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/pathutil"
+	"github.com/eleme/lindb/pkg/state"
+	"github.com/eleme/lindb/pkg/timeutil"
+)
+
+// DatabaseStatsRecorder records the statistics of database's writes and queries in current node
+type DatabaseStatsRecorder interface {
+	// RecordWrite records the points and bytes written into database, and the points failed to write
+	RecordWrite(databaseName string, points, bytes, failedPoints int64)
+	// RecordQuery records the query of database with the latency and the bytes of points scanned
+	RecordQuery(databaseName string, latency time.Duration, scanBytes int64)
+	// RecordScan records the bytes of points scanned by query without counting the query,
+	// which is used by storage node because the query is counted by broker
+	RecordScan(databaseName string, scanBytes int64)
+	// Restore restores the statistics reported before, so that the statistics are accumulated after restarting
+	Restore(stats []models.DatabaseStats)
+	// Snapshot returns the statistics of databases sorted by database name
+	Snapshot() []models.DatabaseStats
+}
+
+// databaseStatsRecorder implements DatabaseStatsRecorder interface
+type databaseStatsRecorder struct {
+	databases map[string]*models.DatabaseStats
+	mutex     sync.Mutex
+}
+
+// NewDatabaseStatsRecorder creates the database statistics recorder
+func NewDatabaseStatsRecorder() DatabaseStatsRecorder {
+	return &databaseStatsRecorder{
+		databases: make(map[string]*models.DatabaseStats),
+	}
+}
+
+// RecordWrite records the points and bytes written into database
+func (r *databaseStatsRecorder) RecordWrite(databaseName string, points, bytes, failedPoints int64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	stats := r.getOrCreate(databaseName)
+	stats.WrittenPoints += points
+	stats.WrittenBytes += bytes
+	stats.FailedPoints += failedPoints
+}
+
+// RecordQuery records the query of database with the latency and the bytes of points scanned
+func (r *databaseStatsRecorder) RecordQuery(databaseName string, latency time.Duration, scanBytes int64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	stats := r.getOrCreate(databaseName)
+	stats.Queries++
+	stats.ScanBytes += scanBytes
+	stats.ObserveLatency(latency.Nanoseconds() / int64(time.Millisecond))
+}
+
+// RecordScan records the bytes of points scanned by query
+func (r *databaseStatsRecorder) RecordScan(databaseName string, scanBytes int64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.getOrCreate(databaseName).ScanBytes += scanBytes
+}
+
+// Restore adds the statistics reported before into the statistics recorded
+func (r *databaseStatsRecorder) Restore(stats []models.DatabaseStats) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for idx := range stats {
+		r.getOrCreate(stats[idx].Database).Merge(&stats[idx])
+	}
+}
+
+// Snapshot returns the copy of statistics of databases sorted by database name
+func (r *databaseStatsRecorder) Snapshot() []models.DatabaseStats {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	result := make([]models.DatabaseStats, 0, len(r.databases))
+	for _, stats := range r.databases {
+		snapshot := *stats
+		snapshot.QueryLatency = append([]int64(nil), stats.QueryLatency...)
+		result = append(result, snapshot)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Database < result[j].Database
+	})
+	return result
+}
+
+// getOrCreate returns the statistics of database, creates it if not exist, must be called with lock
+func (r *databaseStatsRecorder) getOrCreate(databaseName string) *models.DatabaseStats {
+	stats, ok := r.databases[databaseName]
+	if !ok {
+		stats = &models.DatabaseStats{Database: databaseName}
+		r.databases[databaseName] = stats
+	}
+	return stats
+}
+
+// DatabaseStatsService represents the database statistics stored in state repository,
+// the statistics are reported by each node, then aggregated by master.
+type DatabaseStatsService interface {
+	// Report saves the statistics of node
+	Report(stats models.NodeStats) error
+	// GetNodeStats returns the statistics of node, returns state.ErrNotExist if not reported
+	GetNodeStats(node string) (*models.NodeStats, error)
+	// ListNodeStats returns the statistics reported by all nodes
+	ListNodeStats() ([]models.NodeStats, error)
+	// SaveClusterStats saves the statistics aggregated by master
+	SaveClusterStats(stats models.ClusterStats) error
+	// GetClusterStats returns the statistics aggregated by master, returns state.ErrNotExist if not aggregated
+	GetClusterStats() (*models.ClusterStats, error)
+}
+
+// databaseStatsService implements DatabaseStatsService interface
+type databaseStatsService struct {
+	repo state.Repository
+}
+
+// NewDatabaseStatsService creates the database statistics service
+func NewDatabaseStatsService(repo state.Repository) DatabaseStatsService {
+	return &databaseStatsService{repo: repo}
+}
+
+// Report saves the statistics of node under the key of node
+func (s *databaseStatsService) Report(stats models.NodeStats) error {
+	if err := pathutil.ValidateName(stats.Node); err != nil {
+		return fmt.Errorf("invalid node of statistics:%s", err)
+	}
+	return s.put(pathutil.Keys.Stats.Nodes.Key(stats.Node), stats)
+}
+
+// GetNodeStats returns the statistics of node
+func (s *databaseStatsService) GetNodeStats(node string) (*models.NodeStats, error) {
+	stats := &models.NodeStats{}
+	if err := s.get(pathutil.Keys.Stats.Nodes.Key(node), stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// ListNodeStats returns the statistics reported by all nodes, the invalid statistics are skipped
+func (s *databaseStatsService) ListNodeStats() ([]models.NodeStats, error) {
+	ctx, cancel := withDefaultTimeout(context.TODO())
+	defer cancel()
+	values, err := s.repo.List(ctx, pathutil.Keys.Stats.Nodes.Prefix())
+	if err != nil {
+		return nil, err
+	}
+	var result []models.NodeStats
+	for _, value := range values {
+		stats := models.NodeStats{}
+		if err := json.Unmarshal(value, &stats); err != nil {
+			continue
+		}
+		result = append(result, stats)
+	}
+	return result, nil
+}
+
+// SaveClusterStats saves the statistics aggregated by master
+func (s *databaseStatsService) SaveClusterStats(stats models.ClusterStats) error {
+	return s.put(pathutil.Keys.Stats.Databases, stats)
+}
+
+// GetClusterStats returns the statistics aggregated by master
+func (s *databaseStatsService) GetClusterStats() (*models.ClusterStats, error) {
+	stats := &models.ClusterStats{}
+	if err := s.get(pathutil.Keys.Stats.Databases, stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// put saves the value as json under the key
+func (s *databaseStatsService) put(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal database statistics error:%s", err)
+	}
+	ctx, cancel := withDefaultTimeout(context.TODO())
+	defer cancel()
+	return s.repo.Put(ctx, key, data)
+}
+
+// get unmarshals the json value of the key
+func (s *databaseStatsService) get(key string, value interface{}) error {
+	ctx, cancel := withDefaultTimeout(context.TODO())
+	defer cancel()
+	data, err := s.repo.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("unmarshal database statistics error:%s", err)
+	}
+	return nil
+}
+
+// ReportDatabaseStats restores the statistics reported by node before, then reports the statistics recorded
+// periodically until the context is done. The statistics of shards are reported by storage node, which are
+// used by master for splitting hot shards, shardStats is nil for broker node.
+func ReportDatabaseStats(ctx context.Context, node string, recorder DatabaseStatsRecorder,
+	statsService DatabaseStatsService, interval time.Duration, shardStats func() []models.ShardStat) {
+	log := logger.GetLogger("service/database/stats")
+	if stats, err := statsService.GetNodeStats(node); err == nil {
+		recorder.Restore(stats.Databases)
+	} else if err != state.ErrNotExist {
+		log.Error("restore database statistics of node error, the statistics are recorded from zero",
+			logger.String("node", node), logger.Error(err))
+	}
+	var lastShards *models.NodeStats
+	report := func() {
+		stats := models.NodeStats{Node: node, Timestamp: timeutil.Now(), Databases: recorder.Snapshot()}
+		if shardStats != nil {
+			stats.Shards = shardStats()
+			computeWriteRates(lastShards, &stats)
+			lastShards = &stats
+		}
+		if err := statsService.Report(stats); err != nil {
+			log.Error("report database statistics of node error", logger.String("node", node), logger.Error(err))
+		}
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			report()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// computeWriteRates computes the write rate(points per second) of shards by the points written since last report,
+// the rate is 0 if the shard isn't reported last time or the shard is reopened
+func computeWriteRates(last, current *models.NodeStats) {
+	if last == nil || current.Timestamp <= last.Timestamp {
+		return
+	}
+	type shardKey struct {
+		database string
+		shardID  int
+	}
+	lastPoints := make(map[shardKey]int64, len(last.Shards))
+	for _, stat := range last.Shards {
+		lastPoints[shardKey{database: stat.Database, shardID: stat.ShardID}] = stat.WrittenPoints
+	}
+	elapsed := current.Timestamp - last.Timestamp
+	for idx := range current.Shards {
+		stat := &current.Shards[idx]
+		points, ok := lastPoints[shardKey{database: stat.Database, shardID: stat.ShardID}]
+		if ok && stat.WrittenPoints >= points {
+			stat.WriteRate = (stat.WrittenPoints - points) * 1000 / elapsed
+		}
+	}
+}
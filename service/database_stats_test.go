@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/check.v1"
+
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/state"
+)
+
+func TestDatabaseStatsRecorder(t *testing.T) {
+	recorder := NewDatabaseStatsRecorder()
+	assert.Empty(t, recorder.Snapshot())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recorder.RecordWrite("db2", 10, 100, 1)
+			recorder.RecordQuery("db1", 20*time.Millisecond, 32)
+			recorder.RecordScan("db1", 16)
+		}()
+	}
+	wg.Wait()
+	snapshot := recorder.Snapshot()
+	assert.Len(t, snapshot, 2)
+	assert.Equal(t, "db1", snapshot[0].Database)
+	assert.Equal(t, int64(10), snapshot[0].Queries)
+	assert.Equal(t, int64(480), snapshot[0].ScanBytes)
+	assert.Equal(t, int64(25), snapshot[0].P99Latency)
+	assert.Equal(t, models.DatabaseStats{Database: "db2", WrittenPoints: 100, WrittenBytes: 1000, FailedPoints: 10},
+		snapshot[1])
+
+	// the snapshot isn't changed by recording
+	recorder.RecordQuery("db1", time.Second, 0)
+	assert.Equal(t, int64(10), snapshot[0].Queries)
+	assert.Equal(t, int64(10), snapshot[0].QueryLatency[2])
+
+	// the statistics reported before are accumulated
+	recorder.Restore([]models.DatabaseStats{{Database: "db2", WrittenPoints: 1}, {Database: "db3", Queries: 1}})
+	snapshot = recorder.Snapshot()
+	assert.Len(t, snapshot, 3)
+	assert.Equal(t, int64(11), snapshot[0].Queries)
+	assert.Equal(t, int64(101), snapshot[1].WrittenPoints)
+	assert.Equal(t, int64(1), snapshot[2].Queries)
+}
+
+type testDatabaseStatsSRVSuite struct {
+	mock.RepoTestSuite
+}
+
+func TestDatabaseStatsSRV(t *testing.T) {
+	check.Suite(&testDatabaseStatsSRVSuite{})
+	check.TestingT(t)
+}
+
+func (ts *testDatabaseStatsSRVSuite) TestReport(c *check.C) {
+	repo, _ := state.NewRepo(state.Config{Namespace: "/stats/test", Endpoints: ts.Cluster.Endpoints})
+	srv := NewDatabaseStatsService(repo)
+
+	_, err := srv.GetClusterStats()
+	c.Assert(err, check.Equals, state.ErrNotExist)
+	_, err = srv.GetNodeStats("1.1.1.1:9000")
+	c.Assert(err, check.Equals, state.ErrNotExist)
+	// invalid node
+	c.Assert(srv.Report(models.NodeStats{Node: "a/b"}), check.NotNil)
+
+	stats := models.NodeStats{Node: "1.1.1.1:9000", Timestamp: 10,
+		Databases: []models.DatabaseStats{{Database: "db", WrittenPoints: 10}}}
+	c.Assert(srv.Report(stats), check.IsNil)
+	c.Assert(srv.Report(models.NodeStats{Node: "node-2"}), check.IsNil)
+	nodeStats, err := srv.GetNodeStats("1.1.1.1:9000")
+	c.Assert(err, check.IsNil)
+	c.Assert(*nodeStats, check.DeepEquals, stats)
+	// the invalid stats are skipped
+	_ = repo.Put(context.TODO(), "/stats/nodes/bad", []byte("bad"))
+	nodes, err := srv.ListNodeStats()
+	c.Assert(err, check.IsNil)
+	c.Assert(nodes, check.HasLen, 2)
+
+	clusterStats := models.ClusterStats{Timestamp: 20, Nodes: 2, Databases: stats.Databases}
+	c.Assert(srv.SaveClusterStats(clusterStats), check.IsNil)
+	result, err := srv.GetClusterStats()
+	c.Assert(err, check.IsNil)
+	c.Assert(*result, check.DeepEquals, clusterStats)
+	_ = repo.Put(context.TODO(), "/stats/databases", []byte("bad"))
+	_, err = srv.GetClusterStats()
+	c.Assert(err, check.NotNil)
+}
+
+func (ts *testDatabaseStatsSRVSuite) TestReportDatabaseStats(c *check.C) {
+	repo, _ := state.NewRepo(state.Config{Namespace: "/stats/report", Endpoints: ts.Cluster.Endpoints})
+	srv := NewDatabaseStatsService(repo)
+	_ = srv.Report(models.NodeStats{Node: "node-1", Databases: []models.DatabaseStats{{Database: "db", Queries: 5}}})
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	recorder := NewDatabaseStatsRecorder()
+	done := make(chan struct{})
+	go func() {
+		ReportDatabaseStats(ctx, "node-1", recorder, srv, 10*time.Millisecond, nil)
+		close(done)
+	}()
+	recorder.RecordQuery("db", time.Millisecond, 0)
+	// the statistics reported before restarting are restored
+	for i := 0; i < 100; i++ {
+		stats, _ := srv.GetNodeStats("node-1")
+		if len(stats.Databases) == 1 && stats.Databases[0].Queries == 6 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	stats, err := srv.GetNodeStats("node-1")
+	c.Assert(err, check.IsNil)
+	c.Assert(stats.Databases[0].Queries, check.Equals, int64(6))
+	cancel()
+	<-done
+}
+
+func TestComputeWriteRates(t *testing.T) {
+	last := &models.NodeStats{Timestamp: 1000, Shards: []models.ShardStat{
+		{Database: "db", ShardID: 1, WrittenPoints: 100},
+		{Database: "db", ShardID: 2, WrittenPoints: 100},
+	}}
+	current := &models.NodeStats{Timestamp: 3000, Shards: []models.ShardStat{
+		{Database: "db", ShardID: 1, WrittenPoints: 300},
+		// the shard is reopened
+		{Database: "db", ShardID: 2, WrittenPoints: 10},
+		{Database: "db", ShardID: 3, WrittenPoints: 10},
+	}}
+	computeWriteRates(nil, current)
+	assert.Equal(t, int64(0), current.Shards[0].WriteRate)
+	computeWriteRates(last, current)
+	assert.Equal(t, int64(100), current.Shards[0].WriteRate)
+	assert.Equal(t, int64(0), current.Shards[1].WriteRate)
+	assert.Equal(t, int64(0), current.Shards[2].WriteRate)
+}
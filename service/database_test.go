@@ -2,11 +2,14 @@ package service
 
 import (
 	"testing"
+	"time"
 
 	"gopkg.in/check.v1"
 
 	"github.com/eleme/lindb/mock"
 	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/interval"
+	"github.com/eleme/lindb/pkg/option"
 	"github.com/eleme/lindb/pkg/state"
 )
 
@@ -42,6 +45,10 @@ func (ts *testDatabaseSRVSuite) TestDatabase(c *check.C) {
 	database2, _ := db.Get("test")
 	c.Assert(database, check.DeepEquals, database2)
 
+	databases, err := db.List()
+	c.Assert(err, check.IsNil)
+	c.Assert(databases, check.DeepEquals, []models.Database{database})
+
 	// test create database error
 	err = db.Save(models.Database{})
 	c.Assert(err, check.NotNil)
@@ -51,6 +58,18 @@ func (ts *testDatabaseSRVSuite) TestDatabase(c *check.C) {
 	})
 	c.Assert(err, check.NotNil)
 
+	// the name collides with the keys of other databases
+	err = db.Save(models.Database{
+		Name:     "test/config",
+		Clusters: []models.DatabaseCluster{{Name: "test", NumOfShard: 12, ReplicaFactor: 3}},
+	})
+	c.Assert(err, check.NotNil)
+	err = db.Save(models.Database{
+		Name:     "test",
+		Clusters: []models.DatabaseCluster{{Name: "..", NumOfShard: 12, ReplicaFactor: 3}},
+	})
+	c.Assert(err, check.NotNil)
+
 	err = db.Save(models.Database{
 		Name: "test",
 		Clusters: []models.DatabaseCluster{
@@ -83,4 +102,77 @@ func (ts *testDatabaseSRVSuite) TestDatabase(c *check.C) {
 		},
 	})
 	c.Assert(err, check.NotNil)
+
+	err = db.Save(models.Database{
+		Name:       "test",
+		Clusters:   database.Clusters,
+		WriteRules: &models.WriteRules{DropMetrics: []string{"("}},
+	})
+	c.Assert(err, check.NotNil)
+
+	err = db.Save(models.Database{
+		Name:         "test",
+		Clusters:     database.Clusters,
+		NamingPolicy: &models.NamingPolicy{MaxDepth: -1},
+	})
+	c.Assert(err, check.NotNil)
+
+	err = db.Save(models.Database{
+		Name:     "test",
+		Clusters: database.Clusters,
+		WriteAck: models.WriteAck("fsync"),
+	})
+	c.Assert(err, check.NotNil)
+
+	err = db.Save(models.Database{
+		Name: "test",
+		Clusters: []models.DatabaseCluster{{
+			Name:          "test",
+			NumOfShard:    3,
+			ReplicaFactor: 3,
+			ShardOption: option.ShardOption{Interval: 10 * time.Second, IntervalType: interval.Day,
+				Rollups: []option.RollupOption{{Interval: 5 * time.Minute, IntervalType: interval.Day}}},
+		}},
+	})
+	c.Assert(err, check.NotNil)
+}
+
+func (ts *testDatabaseSRVSuite) TestDatabase_RoutingTags(c *check.C) {
+	repo, _ := state.NewRepo(state.Config{
+		Endpoints: ts.Cluster.Endpoints,
+	})
+
+	db := NewDatabaseService(repo)
+	database := models.Database{
+		Name: "routing_db",
+		Clusters: []models.DatabaseCluster{
+			{
+				Name:          "test",
+				NumOfShard:    12,
+				ReplicaFactor: 3,
+				RoutingTags:   []string{"region"},
+			},
+		},
+	}
+	c.Assert(db.Save(database), check.IsNil)
+	// update other options
+	database.Clusters[0].NumOfShard = 24
+	c.Assert(db.Save(database), check.IsNil)
+	// routing tags cannot be changed
+	database.Clusters[0].RoutingTags = []string{"region", "zone"}
+	c.Assert(db.Save(database), check.NotNil)
+	// new cluster
+	database.Clusters[0].RoutingTags = []string{"region"}
+	database.Clusters = append(database.Clusters, models.DatabaseCluster{
+		Name:          "test2",
+		NumOfShard:    12,
+		ReplicaFactor: 3,
+		RoutingTags:   []string{"zone"},
+	})
+	c.Assert(db.Save(database), check.IsNil)
+
+	database.Clusters[1].RoutingTags = []string{"zone", "zone"}
+	c.Assert(db.Save(database), check.NotNil)
+	database.Clusters[1].RoutingTags = []string{""}
+	c.Assert(db.Save(database), check.NotNil)
 }
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/eleme/lindb/models"
+)
+
+// indexShardLabel is the shard label of the disk usage of database's metadata index
+const indexShardLabel = "index"
+
+var (
+	diskUsageBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "lindb",
+		Subsystem: "storage",
+		Name:      "disk_usage_bytes",
+		Help:      "The bytes of sst files of database's shards and metadata index(shard=index) in storage node.",
+	}, []string{"db", "shard"})
+)
+
+func init() {
+	prometheus.MustRegister(diskUsageBytes)
+}
+
+// DiskUsageService represents the disk usage of databases in storage clusters,
+// so that operators can find out which database consumes the disk.
+type DiskUsageService interface {
+	// Report returns the disk usage of given database in storage nodes, returns all databases' if database is empty
+	Report(ctx context.Context, database string) (*models.DiskUsageReport, error)
+}
+
+// UpdateDiskUsageMetrics refreshes the disk usage gauges by the engines of storage node,
+// the gauges are reset first, so that the dropped databases/shards aren't reported any more.
+func UpdateDiskUsageMetrics(storageService StorageService) {
+	usages := storageService.DiskUsage("")
+	diskUsageBytes.Reset()
+	for _, usage := range usages {
+		diskUsageBytes.WithLabelValues(usage.Database, indexShardLabel).Set(float64(usage.IndexBytes))
+		for _, shard := range usage.Shards {
+			diskUsageBytes.WithLabelValues(usage.Database, strconv.Itoa(shard.ShardID)).Set(float64(shard.Bytes))
+		}
+	}
+}
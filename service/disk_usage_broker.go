@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/eleme/lindb/broker/rpc"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+)
+
+// brokerDiskUsageService implements DiskUsageService interface for broker,
+// sends the request to the replicas of database's shards, or all active nodes of storage clusters
+// if database isn't specified, the disk usage is computed by each storage node from the metas of its sst files.
+type brokerDiskUsageService struct {
+	storageClusterService StorageClusterService
+	storageClusterRepos   StorageClusterRepos
+	routingCache          RoutingCache
+	circuitBreakers       rpc.CircuitBreakers
+	newClient             adminClientFactory
+}
+
+// NewBrokerDiskUsageService creates disk usage service for broker
+func NewBrokerDiskUsageService(storageClusterService StorageClusterService, storageClusterRepos StorageClusterRepos,
+	routingCache RoutingCache, circuitBreakers rpc.CircuitBreakers, connPool rpc.ConnPool) DiskUsageService {
+	return &brokerDiskUsageService{
+		storageClusterService: storageClusterService,
+		storageClusterRepos:   storageClusterRepos,
+		routingCache:          routingCache,
+		circuitBreakers:       circuitBreakers,
+		newClient: func(node models.Node) rpc.AdminClient {
+			return rpc.NewPooledAdminClient(connPool, fmt.Sprintf("%s:%d", node.IP, node.Port))
+		},
+	}
+}
+
+// Report returns the disk usage of databases in storage nodes, the details are sorted by database and node,
+// returns error if any storage node fails, because the partial usage misleads the capacity planning.
+func (s *brokerDiskUsageService) Report(ctx context.Context, database string) (*models.DiskUsageReport, error) {
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
+	nodes, err := s.storageNodes(database)
+	if err != nil {
+		return nil, err
+	}
+	req := &models.DiskUsageRequest{Database: database}
+	var details []models.DatabaseDiskUsage
+	for _, node := range nodes {
+		usages, err := s.getDiskUsage(ctx, node, req)
+		if err != nil {
+			return nil, err
+		}
+		for idx := range usages {
+			usages[idx].Node = node.String()
+		}
+		details = append(details, usages...)
+	}
+	sort.SliceStable(details, func(i, j int) bool {
+		if details[i].Database != details[j].Database {
+			return details[i].Database < details[j].Database
+		}
+		return details[i].Node < details[j].Node
+	})
+	return models.NewDiskUsageReport(details), nil
+}
+
+// storageNodes returns the replica nodes of database's shards if database isn't empty,
+// otherwise returns the active nodes of all storage clusters
+func (s *brokerDiskUsageService) storageNodes(database string) ([]models.Node, error) {
+	if len(database) > 0 {
+		shards, err := collectDatabaseShards(s.routingCache, database)
+		if err != nil {
+			return nil, err
+		}
+		nodes := allReplicas(shards)
+		if len(nodes) == 0 {
+			return nil, errors.Wrapf(errors.ErrDatabaseNotFound, "no storage nodes of database: %s", database)
+		}
+		return nodes, nil
+	}
+	clusters, err := s.storageClusterService.List()
+	if err != nil {
+		return nil, fmt.Errorf("list storage clusters error:%s", err)
+	}
+	selected := make(map[string]struct{})
+	var nodes []models.Node
+	for _, cluster := range clusters {
+		clusterNodes, err := listClusterNodes(s.storageClusterRepos, cluster)
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range clusterNodes {
+			if _, ok := selected[node.Key()]; ok {
+				continue
+			}
+			selected[node.Key()] = struct{}{}
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
+
+// getDiskUsage sends the disk usage request to the storage node
+func (s *brokerDiskUsageService) getDiskUsage(ctx context.Context, node models.Node,
+	req *models.DiskUsageRequest) ([]models.DatabaseDiskUsage, error) {
+	client := s.newClient(node)
+	if err := client.Init(); err != nil {
+		s.circuitBreakers.Failure(node.Key())
+		return nil, fmt.Errorf("connect storage node[%s:%d] error:%s", node.IP, node.Port, err)
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	usages, err := client.GetDiskUsage(ctx, req)
+	if rpc.IsNodeFailure(err) {
+		s.circuitBreakers.Failure(node.Key())
+	} else {
+		s.circuitBreakers.Success(node.Key())
+	}
+	return usages, err
+}
+
+// listClusterNodes returns the active nodes of storage cluster by the shared state repo of storage cluster
+func listClusterNodes(storageClusterRepos StorageClusterRepos, cluster models.StorageCluster) ([]models.Node, error) {
+	repo, err := storageClusterRepos.Get(cluster)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := listActiveNodes(repo)
+	if err != nil {
+		return nil, fmt.Errorf("list active nodes of storage cluster[%s] error:%s", cluster.Name, err)
+	}
+	return nodes, nil
+}
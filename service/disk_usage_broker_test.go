@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"gopkg.in/check.v1"
+
+	"github.com/eleme/lindb/broker/rpc"
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/pathutil"
+	"github.com/eleme/lindb/pkg/state"
+)
+
+type testBrokerDiskUsageSRVSuite struct {
+	mock.RepoTestSuite
+}
+
+func TestBrokerDiskUsageSRV(t *testing.T) {
+	check.Suite(&testBrokerDiskUsageSRVSuite{})
+	check.TestingT(t)
+}
+
+func (ts *testBrokerDiskUsageSRVSuite) TestReport(c *check.C) {
+	cfg := state.Config{Endpoints: ts.Cluster.Endpoints}
+	repo, _ := state.NewRepo(cfg)
+	storageClusterService := NewStorageClusterService(repo)
+	ctrl := gomock.NewController(c)
+	defer ctrl.Finish()
+	routingCache := NewMockRoutingCache(ctrl)
+
+	storageClusterRepos := NewStorageClusterRepos()
+	defer func() {
+		_ = storageClusterRepos.Close()
+	}()
+	srv := NewBrokerDiskUsageService(storageClusterService, storageClusterRepos, routingCache,
+		rpc.NewCircuitBreakers(config.CircuitBreaker{}), rpc.NewConnPool())
+	var (
+		nodes   []models.Node
+		req     *models.DiskUsageRequest
+		initErr error
+	)
+	srv.(*brokerDiskUsageService).newClient = func(node models.Node) rpc.AdminClient {
+		nodes = append(nodes, node)
+		client := rpc.NewMockAdminClient(ctrl)
+		client.EXPECT().Init().Return(initErr)
+		client.EXPECT().Close().Return(nil).AnyTimes()
+		if initErr == nil {
+			client.EXPECT().GetDiskUsage(gomock.Any(), req).
+				Return([]models.DatabaseDiskUsage{{Database: "db", Bytes: int64(node.Port)}}, nil)
+		}
+		return client
+	}
+
+	// database not exist
+	routingCache.EXPECT().Shards("disk_usage_db").Return(nil, false)
+	_, err := srv.Report(context.TODO(), "disk_usage_db")
+	c.Assert(err, check.NotNil)
+	// no storage clusters
+	report, err := srv.Report(context.TODO(), "")
+	c.Assert(err, check.IsNil)
+	c.Assert(report, check.DeepEquals, &models.DiskUsageReport{
+		Databases: []models.DiskUsageSummary{},
+		Details:   []models.DatabaseDiskUsage{},
+	})
+
+	_ = storageClusterService.Save(models.StorageCluster{Name: "disk_usage_cluster", Config: cfg})
+	shardAssign := models.NewShardAssignment()
+	shardAssign.Nodes[1] = models.Node{IP: "127.0.0.1", Port: 2001}
+	shardAssign.AddReplica(1, 1)
+	routingCache.EXPECT().Shards("disk_usage_db").Return(assignedShards(shardAssign), true)
+	for _, node := range []models.Node{{IP: "127.0.0.1", Port: 2001}, {IP: "127.0.0.1", Port: 2002}} {
+		data, _ := json.Marshal(node)
+		_ = repo.Put(context.TODO(), pathutil.Keys.Nodes.Active.Key(node.Key()), data)
+	}
+
+	// request is sent to the replicas of database's shards
+	req = &models.DiskUsageRequest{Database: "disk_usage_db"}
+	report, err = srv.Report(context.TODO(), "disk_usage_db")
+	c.Assert(err, check.IsNil)
+	c.Assert(nodes, check.HasLen, 1)
+	c.Assert(report.Details, check.DeepEquals, []models.DatabaseDiskUsage{
+		{Database: "db", Node: "127.0.0.1:2001", Bytes: 2001},
+	})
+
+	// request is sent to all active nodes of storage clusters
+	nodes = nil
+	req = &models.DiskUsageRequest{}
+	report, err = srv.Report(context.TODO(), "")
+	c.Assert(err, check.IsNil)
+	c.Assert(nodes, check.HasLen, 2)
+	c.Assert(report, check.DeepEquals, &models.DiskUsageReport{
+		Databases: []models.DiskUsageSummary{{Database: "db", Bytes: 4003, Nodes: 2}},
+		Details: []models.DatabaseDiskUsage{
+			{Database: "db", Node: "127.0.0.1:2001", Bytes: 2001},
+			{Database: "db", Node: "127.0.0.1:2002", Bytes: 2002},
+		},
+	})
+
+	initErr = fmt.Errorf("err")
+	_, err = srv.Report(context.TODO(), "")
+	c.Assert(err, check.NotNil)
+}
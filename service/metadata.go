@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/tsdb/index"
+)
+
+// defaultSuggestLimit represents the default limit of suggesting metric metadata
+const defaultSuggestLimit = 100
+
+// defaultEventLimit represents the default limit of querying annotation events
+const defaultEventLimit = 1000
+
+// MetadataService represents metric metadata query interface, such as metric names, tag keys and tag values
+type MetadataService interface {
+	// Suggest returns sorted metric metadata values based on suggest request,
+	// returns error if the request isn't done before the deadline of context
+	Suggest(ctx context.Context, req *models.SuggestRequest) ([]string, error)
+	// Cardinality returns the HyperLogLog sketches of series and tag values of the metric,
+	// returns nil if metric not exist
+	Cardinality(ctx context.Context, req *models.CardinalityRequest) (*index.MetricCardinality, error)
+	// WriteEvents writes the annotation events(deploys, incidents) of database
+	WriteEvents(ctx context.Context, req *models.EventWriteRequest) error
+	// QueryEvents returns the annotation events of database which overlap the time range of query,
+	// sorted by start time
+	QueryEvents(ctx context.Context, query *models.EventQuery) ([]models.Event, error)
+}
+
+// metadataService implements MetadataService interface based on tsdb engine's index
+type metadataService struct {
+	storageService StorageService
+}
+
+// NewMetadataService creates metadata service instance for querying engine's index
+func NewMetadataService(storageService StorageService) MetadataService {
+	return &metadataService{
+		storageService: storageService,
+	}
+}
+
+// Suggest returns sorted metric metadata values from the index of database's engine,
+// returns empty result if engine not exist in current storage node.
+func (s *metadataService) Suggest(ctx context.Context, req *models.SuggestRequest) ([]string, error) {
+	if err := validateSuggestRequest(req); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	engine := s.storageService.GetEngine(req.Database)
+	if engine == nil {
+		return nil, nil
+	}
+	index := engine.GetIndex()
+	switch req.Type {
+	case models.SuggestMetricNames:
+		return index.SuggestMetrics(req.Prefix, req.Offset, req.Limit), nil
+	case models.SuggestTagKeys:
+		return index.SuggestTagKeys(req.MetricName, req.Limit), nil
+	default:
+		return index.SuggestTagValues(req.MetricName, req.TagKey, req.Prefix, req.Limit), nil
+	}
+}
+
+// Cardinality returns the sketches of series and tag values from the index of database's engine,
+// returns nil if engine or metric not exist in current storage node.
+func (s *metadataService) Cardinality(ctx context.Context, req *models.CardinalityRequest) (*index.MetricCardinality, error) {
+	if err := validateCardinalityRequest(req); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	engine := s.storageService.GetEngine(req.Database)
+	if engine == nil {
+		return nil, nil
+	}
+	return engine.GetIndex().GetCardinality(req.MetricName)
+}
+
+// WriteEvents writes the annotation events into the event store of database's engine,
+// returns error if engine not exist in current storage node.
+func (s *metadataService) WriteEvents(ctx context.Context, req *models.EventWriteRequest) error {
+	if err := validateEventWriteRequest(req); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	engine := s.storageService.GetEngine(req.Database)
+	if engine == nil {
+		return errors.Wrapf(errors.ErrDatabaseNotFound, "database: %s", req.Database)
+	}
+	return engine.GetIndex().GetEventStore().Write(req.Events)
+}
+
+// QueryEvents returns the annotation events from the event store of database's engine,
+// returns empty result if engine not exist in current storage node.
+func (s *metadataService) QueryEvents(ctx context.Context, query *models.EventQuery) ([]models.Event, error) {
+	if err := validateEventQuery(query); err != nil {
+		return nil, err
+	}
+	engine := s.storageService.GetEngine(query.Database)
+	if engine == nil {
+		return nil, nil
+	}
+	events, err := engine.GetIndex().GetEventStore().Query(ctx, query.StartTime, query.EndTime, query.Tags)
+	if err != nil {
+		return nil, err
+	}
+	return limitEvents(events, query.Limit), nil
+}
+
+// EstimateCardinality estimates the num. of series and tag values by the sketches of metric,
+// returns zero if the sketches are nil.
+func EstimateCardinality(req *models.CardinalityRequest, sketches *index.MetricCardinality) *models.CardinalityResult {
+	result := &models.CardinalityResult{
+		MetricName: req.MetricName,
+		TagValues:  make(map[string]uint64),
+	}
+	if sketches == nil {
+		for _, tagKey := range req.TagKeys {
+			result.TagValues[tagKey] = 0
+		}
+		return result
+	}
+	result.Series = sketches.Series.Count()
+	if len(req.TagKeys) == 0 {
+		for tagKey, sketch := range sketches.TagValues {
+			result.TagValues[tagKey] = sketch.Count()
+		}
+		return result
+	}
+	for _, tagKey := range req.TagKeys {
+		if sketch, ok := sketches.TagValues[tagKey]; ok {
+			result.TagValues[tagKey] = sketch.Count()
+		} else {
+			result.TagValues[tagKey] = 0
+		}
+	}
+	return result
+}
+
+// validateCardinalityRequest checks if cardinality request is valid
+func validateCardinalityRequest(req *models.CardinalityRequest) error {
+	if req == nil {
+		return fmt.Errorf("cardinality request cannot be nil")
+	}
+	if len(req.Database) == 0 {
+		return fmt.Errorf("database name cannot be empty")
+	}
+	if len(req.MetricName) == 0 {
+		return fmt.Errorf("metric name cannot be empty")
+	}
+	return nil
+}
+
+// validateEventWriteRequest checks if event write request is valid, the events are validated
+func validateEventWriteRequest(req *models.EventWriteRequest) error {
+	if req == nil {
+		return fmt.Errorf("event write request cannot be nil")
+	}
+	if len(req.Database) == 0 {
+		return fmt.Errorf("database name cannot be empty")
+	}
+	if len(req.Events) == 0 {
+		return fmt.Errorf("events cannot be empty")
+	}
+	for idx := range req.Events {
+		if err := req.Events[idx].Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateEventQuery checks if event query is valid, uses default limit if limit not set
+func validateEventQuery(query *models.EventQuery) error {
+	if query == nil {
+		return fmt.Errorf("event query cannot be nil")
+	}
+	if len(query.Database) == 0 {
+		return fmt.Errorf("database name cannot be empty")
+	}
+	if query.StartTime <= 0 || query.EndTime < query.StartTime {
+		return fmt.Errorf("time range of event query is invalid")
+	}
+	if query.Limit < 0 {
+		return fmt.Errorf("limit must be >= 0")
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultEventLimit
+	}
+	return nil
+}
+
+// validateSuggestRequest checks if suggest request is valid, uses default limit if limit not set
+func validateSuggestRequest(req *models.SuggestRequest) error {
+	if req == nil {
+		return fmt.Errorf("suggest request cannot be nil")
+	}
+	if len(req.Database) == 0 {
+		return fmt.Errorf("database name cannot be empty")
+	}
+	if req.Offset < 0 {
+		return fmt.Errorf("offset must be >= 0")
+	}
+	if req.Limit < 0 {
+		return fmt.Errorf("limit must be >= 0")
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultSuggestLimit
+	}
+	switch req.Type {
+	case models.SuggestMetricNames:
+	case models.SuggestTagKeys:
+		if len(req.MetricName) == 0 {
+			return fmt.Errorf("metric name cannot be empty")
+		}
+	case models.SuggestTagValues:
+		if len(req.MetricName) == 0 {
+			return fmt.Errorf("metric name cannot be empty")
+		}
+		if len(req.TagKey) == 0 {
+			return fmt.Errorf("tag key cannot be empty")
+		}
+	default:
+		return fmt.Errorf("unknown suggest type:%d", req.Type)
+	}
+	return nil
+}
+
+// mergeEvents merges the events from multi storage nodes, removes duplicate events,
+// then returns the first events sorted by start time within limit.
+func mergeEvents(results [][]models.Event, limit int) ([]models.Event, error) {
+	var events []models.Event
+	seen := make(map[string]struct{})
+	for _, result := range results {
+		for _, event := range result {
+			key, err := json.Marshal(&event)
+			if err != nil {
+				return nil, fmt.Errorf("marshal event error:%s", err)
+			}
+			if _, ok := seen[string(key)]; ok {
+				continue
+			}
+			seen[string(key)] = struct{}{}
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].StartTime < events[j].StartTime
+	})
+	return limitEvents(events, limit), nil
+}
+
+// limitEvents returns the first events within limit
+func limitEvents(events []models.Event, limit int) []models.Event {
+	if limit > 0 && len(events) > limit {
+		return events[:limit]
+	}
+	return events
+}
+
+// mergeSuggestions merges the sorted results from multi storage nodes,
+// removes duplicate values, then returns the values paged by offset and limit.
+func mergeSuggestions(results [][]string, offset, limit int) []string {
+	set := make(map[string]struct{})
+	for _, result := range results {
+		for _, value := range result {
+			set[value] = struct{}{}
+		}
+	}
+	if offset >= len(set) || limit <= 0 {
+		return nil
+	}
+	values := make([]string, 0, len(set))
+	for value := range set {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	end := offset + limit
+	if end > len(values) {
+		end = len(values)
+	}
+	return values[offset:end]
+}
@@ -0,0 +1,340 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/eleme/lindb/broker/rpc"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/pathutil"
+	"github.com/eleme/lindb/pkg/state"
+	"github.com/eleme/lindb/tsdb/index"
+)
+
+// defaultMetadataTimeout represents the default timeout of querying metadata from storage nodes,
+// which is used if the context has no deadline
+const defaultMetadataTimeout = 5 * time.Second
+
+// metadataClientFactory creates the metadata client for given storage node
+type metadataClientFactory func(node models.Node) rpc.MetadataClient
+
+// brokerMetadataService implements MetadataService interface for broker,
+// finds the storage nodes which cover all shards of database, then queries metric metadata from them and merges
+// the results. The replica whose circuit is open is skipped, so that the dead node doesn't slow down the queries.
+type brokerMetadataService struct {
+	routingCache    RoutingCache
+	circuitBreakers rpc.CircuitBreakers
+	newClient       metadataClientFactory
+}
+
+// NewBrokerMetadataService creates metadata service for broker
+func NewBrokerMetadataService(routingCache RoutingCache, circuitBreakers rpc.CircuitBreakers,
+	connPool rpc.ConnPool) MetadataService {
+	return &brokerMetadataService{
+		routingCache:    routingCache,
+		circuitBreakers: circuitBreakers,
+		newClient: func(node models.Node) rpc.MetadataClient {
+			return rpc.NewPooledMetadataClient(connPool, fmt.Sprintf("%s:%d", node.IP, node.Port))
+		},
+	}
+}
+
+// Suggest returns sorted metric metadata values merged from all storage nodes of database
+func (s *brokerMetadataService) Suggest(ctx context.Context, req *models.SuggestRequest) ([]string, error) {
+	if err := validateSuggestRequest(req); err != nil {
+		return nil, err
+	}
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
+	nodes, err := s.getDatabaseNodes(req.Database)
+	if err != nil {
+		return nil, err
+	}
+	// each node returns the values from first one, broker does paging after merging
+	nodeReq := *req
+	nodeReq.Offset = 0
+	nodeReq.Limit = req.Offset + req.Limit
+	var results [][]string
+	for _, node := range nodes {
+		values, err := s.suggest(ctx, node, &nodeReq)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, values)
+	}
+	return mergeSuggestions(results, req.Offset, req.Limit), nil
+}
+
+// Cardinality returns the sketches of series and tag values merged from all storage nodes of database,
+// the replicas of same series are counted once because the sketches are built by the hash of tags.
+func (s *brokerMetadataService) Cardinality(ctx context.Context, req *models.CardinalityRequest) (*index.MetricCardinality, error) {
+	if err := validateCardinalityRequest(req); err != nil {
+		return nil, err
+	}
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
+	nodes, err := s.getDatabaseNodes(req.Database)
+	if err != nil {
+		return nil, err
+	}
+	var result *index.MetricCardinality
+	for _, node := range nodes {
+		sketches, err := s.cardinality(ctx, node, req)
+		if err != nil {
+			return nil, err
+		}
+		if sketches == nil {
+			continue
+		}
+		if result == nil {
+			result = sketches
+		} else {
+			result.Merge(sketches)
+		}
+	}
+	return result, nil
+}
+
+// WriteEvents writes the annotation events into all replicas of database's shards,
+// so that any storage nodes covering the shards can answer the event query.
+// The events may be written into part of replicas if returns error, the client can retry,
+// the duplicate events are returned once by query.
+func (s *brokerMetadataService) WriteEvents(ctx context.Context, req *models.EventWriteRequest) error {
+	if err := validateEventWriteRequest(req); err != nil {
+		return err
+	}
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
+	shards, err := s.collectDatabaseShards(req.Database)
+	if err != nil {
+		return err
+	}
+	nodes := allReplicas(shards)
+	if len(nodes) == 0 {
+		return errors.Wrapf(errors.ErrDatabaseNotFound, "no storage nodes of database: %s", req.Database)
+	}
+	for _, node := range nodes {
+		if err := s.writeEvents(ctx, node, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// QueryEvents returns the annotation events merged from the storage nodes of database
+func (s *brokerMetadataService) QueryEvents(ctx context.Context, query *models.EventQuery) ([]models.Event, error) {
+	if err := validateEventQuery(query); err != nil {
+		return nil, err
+	}
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
+	nodes, err := s.getDatabaseNodes(query.Database)
+	if err != nil {
+		return nil, err
+	}
+	var results [][]models.Event
+	for _, node := range nodes {
+		events, err := s.queryEvents(ctx, node, query)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, events)
+	}
+	return mergeEvents(results, query.Limit)
+}
+
+// writeEvents writes the annotation events into the storage node
+func (s *brokerMetadataService) writeEvents(ctx context.Context, node models.Node, req *models.EventWriteRequest) error {
+	client, err := s.connect(node)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	err = client.WriteEvents(ctx, req)
+	s.record(node, err)
+	return err
+}
+
+// queryEvents queries the annotation events from the storage node
+func (s *brokerMetadataService) queryEvents(ctx context.Context, node models.Node,
+	query *models.EventQuery) ([]models.Event, error) {
+	client, err := s.connect(node)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	events, err := client.QueryEvents(ctx, query)
+	s.record(node, err)
+	return events, err
+}
+
+// cardinality queries the sketches of metric from the storage node
+func (s *brokerMetadataService) cardinality(ctx context.Context, node models.Node,
+	req *models.CardinalityRequest) (*index.MetricCardinality, error) {
+	client, err := s.connect(node)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	sketches, err := client.Cardinality(ctx, req)
+	s.record(node, err)
+	return sketches, err
+}
+
+// suggest queries metric metadata values from the storage node
+func (s *brokerMetadataService) suggest(ctx context.Context, node models.Node, req *models.SuggestRequest) ([]string, error) {
+	client, err := s.connect(node)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	values, err := client.Suggest(ctx, req)
+	s.record(node, err)
+	return values, err
+}
+
+// connect creates the client connected to the storage node, the failure of connecting is recorded
+func (s *brokerMetadataService) connect(node models.Node) (rpc.MetadataClient, error) {
+	client := s.newClient(node)
+	if err := client.Init(); err != nil {
+		s.circuitBreakers.Failure(node.Key())
+		return nil, fmt.Errorf("connect storage node[%s:%d] error:%s", node.IP, node.Port, err)
+	}
+	return client, nil
+}
+
+// record records the result of request into the circuit breaker of storage node
+func (s *brokerMetadataService) record(node models.Node, err error) {
+	if rpc.IsNodeFailure(err) {
+		s.circuitBreakers.Failure(node.Key())
+		return
+	}
+	s.circuitBreakers.Success(node.Key())
+}
+
+// withDefaultTimeout returns the context with default timeout if the context has no deadline
+func withDefaultTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, defaultMetadataTimeout)
+}
+
+// getDatabaseNodes returns the storage nodes which cover all shards of database, one replica of each shard is
+// selected, the replica whose circuit is open is skipped, returns error if all replicas of any shard are suspect.
+func (s *brokerMetadataService) getDatabaseNodes(databaseName string) ([]models.Node, error) {
+	shards, err := s.collectDatabaseShards(databaseName)
+	if err != nil {
+		return nil, err
+	}
+	return s.selectReplicas(databaseName, shards)
+}
+
+// collectDatabaseShards collects the replica nodes of each shard of database in all storage clusters
+func (s *brokerMetadataService) collectDatabaseShards(databaseName string) ([][]models.Node, error) {
+	return collectDatabaseShards(s.routingCache, databaseName)
+}
+
+// collectDatabaseShards collects the replica nodes of each shard of database in all storage clusters
+// from the routing cache which is filled by the watches of state repos, so that the state repo isn't
+// requested per request. Returns database not found error if the routing of database isn't cached.
+func collectDatabaseShards(routingCache RoutingCache, databaseName string) ([][]models.Node, error) {
+	shards, ok := routingCache.Shards(databaseName)
+	if !ok {
+		return nil, errors.Wrapf(errors.ErrDatabaseNotFound, "no shard routing of database: %s", databaseName)
+	}
+	return shards, nil
+}
+
+// allReplicas returns the distinct replica nodes of all shards
+func allReplicas(shards [][]models.Node) []models.Node {
+	selected := make(map[string]struct{})
+	var result []models.Node
+	for _, replicas := range shards {
+		for _, node := range replicas {
+			if _, ok := selected[node.Key()]; ok {
+				continue
+			}
+			selected[node.Key()] = struct{}{}
+			result = append(result, node)
+		}
+	}
+	return result
+}
+
+// selectReplicas selects one replica of each shard, the node selected for other shards is preferred,
+// so that the number of nodes fanned out is small.
+func (s *brokerMetadataService) selectReplicas(databaseName string, shards [][]models.Node) ([]models.Node, error) {
+	selected := make(map[string]struct{})
+	var result []models.Node
+	for _, replicas := range shards {
+		covered := false
+		for _, node := range replicas {
+			if _, ok := selected[node.Key()]; ok {
+				covered = true
+				break
+			}
+		}
+		for idx := 0; idx < len(replicas) && !covered; idx++ {
+			node := replicas[idx]
+			if s.circuitBreakers.Allow(node.Key()) {
+				selected[node.Key()] = struct{}{}
+				result = append(result, node)
+				covered = true
+			}
+		}
+		if !covered && len(replicas) > 0 {
+			return nil, fmt.Errorf("all replicas of database[%s]'s shard are suspect, circuits are open", databaseName)
+		}
+	}
+	return result, nil
+}
+
+// assignedShards returns the replica nodes of each shard of the shard assignment, which are sorted by shard id
+func assignedShards(shardAssign *models.ShardAssignment) [][]models.Node {
+	var shardIDs []int
+	for shardID := range shardAssign.Shards {
+		shardIDs = append(shardIDs, shardID)
+	}
+	sort.Ints(shardIDs)
+	var shards [][]models.Node
+	for _, shardID := range shardIDs {
+		var replicas []models.Node
+		for _, nodeID := range shardAssign.Shards[shardID].Replicas {
+			if node, ok := shardAssign.Nodes[nodeID]; ok {
+				replicas = append(replicas, node)
+			}
+		}
+		shards = append(shards, replicas)
+	}
+	return shards
+}
+
+// listActiveNodes returns the active nodes registered in the state repo of storage cluster
+func listActiveNodes(repo state.Repository) ([]models.Node, error) {
+	values, err := repo.List(context.TODO(), pathutil.Keys.Nodes.Active.Prefix())
+	if err != nil {
+		return nil, err
+	}
+	var nodes []models.Node
+	for _, value := range values {
+		node := models.Node{}
+		if err := json.Unmarshal(value, &node); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
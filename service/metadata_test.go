@@ -0,0 +1,430 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/broker/rpc"
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/util"
+	"github.com/eleme/lindb/tsdb/index"
+)
+
+func TestMetadataService_Suggest(t *testing.T) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+
+	storageService := NewStorageService(config.Engine{Path: testPath})
+	srv := NewMetadataService(storageService)
+
+	values, err := srv.Suggest(context.TODO(), &models.SuggestRequest{Database: "metadata_db", Type: models.SuggestMetricNames})
+	assert.Nil(t, err)
+	assert.Nil(t, values)
+
+	err = storageService.CreateShards("metadata_db", validOption, 1)
+	assert.Nil(t, err)
+	idx := storageService.GetEngine("metadata_db").GetIndex()
+	metricID, _ := idx.GetMetricUID().GetOrCreateMetricID("cpu", true)
+	_, _ = idx.GetMetricUID().GetOrCreateMetricID("cpu.load", true)
+	_ = idx.GetMetricUID().Flush()
+	_, _ = idx.GetTagsUID().GetOrCreateTagsID(metricID, index.MapToString(map[string]string{"host": "host-1"}))
+	_, _ = idx.GetTagsUID().GetOrCreateTagsID(metricID, index.MapToString(map[string]string{"host": "host-2"}))
+	_ = idx.GetTagsUID().Flush()
+
+	values, err = srv.Suggest(context.TODO(), &models.SuggestRequest{Database: "metadata_db", Type: models.SuggestMetricNames, Prefix: "cpu"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"cpu", "cpu.load"}, values)
+	values, _ = srv.Suggest(context.TODO(), &models.SuggestRequest{Database: "metadata_db", Type: models.SuggestMetricNames, Offset: 1, Limit: 1})
+	assert.Equal(t, []string{"cpu.load"}, values)
+
+	values, err = srv.Suggest(context.TODO(), &models.SuggestRequest{Database: "metadata_db", Type: models.SuggestTagKeys, MetricName: "cpu"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"host"}, values)
+
+	values, err = srv.Suggest(context.TODO(), &models.SuggestRequest{Database: "metadata_db", Type: models.SuggestTagValues,
+		MetricName: "cpu", TagKey: "host", Prefix: "host"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"host-1", "host-2"}, values)
+
+	_, err = srv.Suggest(context.TODO(), &models.SuggestRequest{Type: models.SuggestMetricNames})
+	assert.NotNil(t, err)
+	_ = storageService.GetEngine("metadata_db").Close()
+}
+
+func TestMetadataService_Cardinality(t *testing.T) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+
+	storageService := NewStorageService(config.Engine{Path: testPath})
+	srv := NewMetadataService(storageService)
+
+	_, err := srv.Cardinality(context.TODO(), &models.CardinalityRequest{Database: "metadata_db"})
+	assert.NotNil(t, err)
+	sketches, err := srv.Cardinality(context.TODO(), &models.CardinalityRequest{Database: "metadata_db", MetricName: "cpu"})
+	assert.Nil(t, err)
+	assert.Nil(t, sketches)
+
+	err = storageService.CreateShards("metadata_db", validOption, 1)
+	assert.Nil(t, err)
+	idx := storageService.GetEngine("metadata_db").GetIndex()
+	metricID, _ := idx.GetMetricUID().GetOrCreateMetricID("cpu", true)
+	_ = idx.GetMetricUID().Flush()
+	_, _ = idx.GetTagsUID().GetOrCreateTagsID(metricID, index.MapToString(map[string]string{"host": "host-1", "ip": "1"}))
+	_, _ = idx.GetTagsUID().GetOrCreateTagsID(metricID, index.MapToString(map[string]string{"host": "host-2", "ip": "1"}))
+	_ = idx.GetTagsUID().Flush()
+
+	req := &models.CardinalityRequest{Database: "metadata_db", MetricName: "cpu"}
+	sketches, err = srv.Cardinality(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Equal(t, &models.CardinalityResult{
+		MetricName: "cpu",
+		Series:     2,
+		TagValues:  map[string]uint64{"host": 2, "ip": 1},
+	}, EstimateCardinality(req, sketches))
+
+	req.TagKeys = []string{"ip", "zone"}
+	assert.Equal(t, &models.CardinalityResult{
+		MetricName: "cpu",
+		Series:     2,
+		TagValues:  map[string]uint64{"ip": 1, "zone": 0},
+	}, EstimateCardinality(req, sketches))
+	assert.Equal(t, &models.CardinalityResult{
+		MetricName: "cpu",
+		TagValues:  map[string]uint64{"ip": 0, "zone": 0},
+	}, EstimateCardinality(req, nil))
+
+	sketches, err = srv.Cardinality(context.TODO(), &models.CardinalityRequest{Database: "metadata_db", MetricName: "memory"})
+	assert.Nil(t, err)
+	assert.Nil(t, sketches)
+	_ = storageService.GetEngine("metadata_db").Close()
+}
+
+func TestMetadataService_Events(t *testing.T) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+
+	storageService := NewStorageService(config.Engine{Path: testPath})
+	srv := NewMetadataService(storageService)
+
+	query := &models.EventQuery{Database: "metadata_db", StartTime: 1000, EndTime: 2000}
+	events, err := srv.QueryEvents(context.TODO(), query)
+	assert.Nil(t, err)
+	assert.Nil(t, events)
+	req := &models.EventWriteRequest{Database: "metadata_db", Events: []models.Event{
+		{Title: "deploy", Tags: map[string]string{"app": "lindb"}, StartTime: 1500},
+		{Title: "incident", StartTime: 500, EndTime: 1200},
+		{Title: "old", StartTime: 100, EndTime: 200},
+	}}
+	// engine not exist
+	assert.NotNil(t, srv.WriteEvents(context.TODO(), req))
+
+	err = storageService.CreateShards("metadata_db", validOption, 1)
+	assert.Nil(t, err)
+	assert.Nil(t, srv.WriteEvents(context.TODO(), req))
+	events, err = srv.QueryEvents(context.TODO(), query)
+	assert.Nil(t, err)
+	assert.Equal(t, []models.Event{
+		{Title: "incident", StartTime: 500, EndTime: 1200},
+		{Title: "deploy", Tags: map[string]string{"app": "lindb"}, StartTime: 1500, EndTime: 1500},
+	}, events)
+	query.Limit = 1
+	query.Tags = map[string]string{"app": "lindb"}
+	events, err = srv.QueryEvents(context.TODO(), query)
+	assert.Nil(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "deploy", events[0].Title)
+
+	assert.NotNil(t, srv.WriteEvents(context.TODO(), &models.EventWriteRequest{Database: "metadata_db"}))
+	_, err = srv.QueryEvents(context.TODO(), &models.EventQuery{Database: "metadata_db"})
+	assert.NotNil(t, err)
+	_ = storageService.GetEngine("metadata_db").Close()
+}
+
+func TestValidateSuggestRequest(t *testing.T) {
+	assert.NotNil(t, validateSuggestRequest(nil))
+	assert.NotNil(t, validateSuggestRequest(&models.SuggestRequest{Type: models.SuggestMetricNames}))
+	assert.NotNil(t, validateSuggestRequest(&models.SuggestRequest{Database: "db"}))
+	assert.NotNil(t, validateSuggestRequest(&models.SuggestRequest{Database: "db", Type: models.SuggestMetricNames, Offset: -1}))
+	assert.NotNil(t, validateSuggestRequest(&models.SuggestRequest{Database: "db", Type: models.SuggestMetricNames, Limit: -1}))
+	assert.NotNil(t, validateSuggestRequest(&models.SuggestRequest{Database: "db", Type: models.SuggestTagKeys}))
+	assert.NotNil(t, validateSuggestRequest(&models.SuggestRequest{Database: "db", Type: models.SuggestTagValues}))
+	assert.NotNil(t, validateSuggestRequest(&models.SuggestRequest{Database: "db", Type: models.SuggestTagValues, MetricName: "cpu"}))
+
+	req := &models.SuggestRequest{Database: "db", Type: models.SuggestTagValues, MetricName: "cpu", TagKey: "host"}
+	assert.Nil(t, validateSuggestRequest(req))
+	assert.Equal(t, defaultSuggestLimit, req.Limit)
+}
+
+func TestValidateEventRequest(t *testing.T) {
+	assert.NotNil(t, validateEventWriteRequest(nil))
+	assert.NotNil(t, validateEventWriteRequest(&models.EventWriteRequest{}))
+	assert.NotNil(t, validateEventWriteRequest(&models.EventWriteRequest{Database: "db"}))
+	assert.NotNil(t, validateEventWriteRequest(&models.EventWriteRequest{Database: "db",
+		Events: []models.Event{{StartTime: 100}}}))
+
+	assert.NotNil(t, validateEventQuery(nil))
+	assert.NotNil(t, validateEventQuery(&models.EventQuery{StartTime: 100, EndTime: 200}))
+	assert.NotNil(t, validateEventQuery(&models.EventQuery{Database: "db", StartTime: 200, EndTime: 100}))
+	assert.NotNil(t, validateEventQuery(&models.EventQuery{Database: "db", StartTime: 100, EndTime: 200, Limit: -1}))
+	query := &models.EventQuery{Database: "db", StartTime: 100, EndTime: 200}
+	assert.Nil(t, validateEventQuery(query))
+	assert.Equal(t, defaultEventLimit, query.Limit)
+}
+
+func TestMergeEvents(t *testing.T) {
+	e1 := models.Event{Title: "e1", StartTime: 100, EndTime: 100}
+	e2 := models.Event{Title: "e2", StartTime: 50, EndTime: 200}
+	e3 := models.Event{Title: "e3", StartTime: 150, EndTime: 150}
+	events, err := mergeEvents([][]models.Event{{e1, e3}, {e2, e1}, nil}, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []models.Event{e2, e1, e3}, events)
+	events, _ = mergeEvents([][]models.Event{{e1, e3}, {e2, e1}}, 2)
+	assert.Equal(t, []models.Event{e2, e1}, events)
+	events, _ = mergeEvents(nil, 10)
+	assert.Nil(t, events)
+}
+
+func TestMergeSuggestions(t *testing.T) {
+	results := [][]string{{"a", "c", "e"}, {"b", "c", "d"}, nil}
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, mergeSuggestions(results, 0, 10))
+	assert.Equal(t, []string{"b", "c"}, mergeSuggestions(results, 1, 2))
+	assert.Equal(t, []string{"e"}, mergeSuggestions(results, 4, 2))
+	assert.Nil(t, mergeSuggestions(results, 5, 2))
+	assert.Nil(t, mergeSuggestions(results, 0, 0))
+	assert.Nil(t, mergeSuggestions(nil, 0, 10))
+}
+
+func TestBrokerMetadataService_selectReplicas(t *testing.T) {
+	breakers := rpc.NewCircuitBreakers(config.CircuitBreaker{FailureThreshold: 1, OpenTimeout: 60 * 1000})
+	srv := NewBrokerMetadataService(nil, breakers, nil).(*brokerMetadataService)
+	node1 := models.Node{IP: "127.0.0.1", Port: 2000}
+	node2 := models.Node{IP: "127.0.0.2", Port: 2000}
+	node3 := models.Node{IP: "127.0.0.3", Port: 2000}
+	shards := [][]models.Node{{node1, node2}, {node2, node3}, {node3, node1}, nil}
+
+	// the selected node is preferred
+	nodes, err := srv.selectReplicas("db", shards)
+	assert.Nil(t, err)
+	assert.Equal(t, []models.Node{node1, node2}, nodes)
+
+	// the suspect node is skipped, traffic ships to other replicas
+	breakers.Failure(node1.Key())
+	nodes, err = srv.selectReplicas("db", shards)
+	assert.Nil(t, err)
+	assert.Equal(t, []models.Node{node2, node3}, nodes)
+
+	// all replicas of shard are suspect
+	breakers.Failure(node2.Key())
+	_, err = srv.selectReplicas("db", shards)
+	assert.NotNil(t, err)
+}
+
+func TestAllReplicas(t *testing.T) {
+	node1 := models.Node{IP: "127.0.0.1", Port: 2000}
+	node2 := models.Node{IP: "127.0.0.2", Port: 2000}
+	node3 := models.Node{IP: "127.0.0.3", Port: 2000}
+	assert.Equal(t, []models.Node{node1, node2, node3}, allReplicas([][]models.Node{{node1, node2}, {node2, node3}, nil}))
+	assert.Nil(t, allReplicas(nil))
+}
+
+type mockMetadataClient struct {
+	node     models.Node
+	initErr  error
+	req      *models.SuggestRequest
+	eventReq *models.EventWriteRequest
+}
+
+func (c *mockMetadataClient) Init() error {
+	return c.initErr
+}
+
+func (c *mockMetadataClient) Suggest(ctx context.Context, req *models.SuggestRequest) ([]string, error) {
+	c.req = req
+	if c.node.Port == 2000 {
+		return []string{"cpu", "disk"}, nil
+	}
+	return []string{"cpu", "memory"}, nil
+}
+
+func (c *mockMetadataClient) Cardinality(ctx context.Context, req *models.CardinalityRequest) (*index.MetricCardinality, error) {
+	if c.node.Port == 2000 {
+		return nil, nil
+	}
+	sketches := index.NewMetricCardinality()
+	sketches.AddSeries(map[string]string{"host": fmt.Sprintf("host-%d", c.node.Port)})
+	return sketches, nil
+}
+
+func (c *mockMetadataClient) WriteEvents(ctx context.Context, req *models.EventWriteRequest) error {
+	c.eventReq = req
+	return nil
+}
+
+func (c *mockMetadataClient) QueryEvents(ctx context.Context, query *models.EventQuery) ([]models.Event, error) {
+	events := []models.Event{{Title: "deploy", StartTime: query.StartTime, EndTime: query.StartTime}}
+	if c.node.Port != 2000 {
+		events = append(events, models.Event{Title: fmt.Sprintf("event-%d", c.node.Port),
+			StartTime: query.StartTime - int64(c.node.Port), EndTime: query.EndTime})
+	}
+	return events, nil
+}
+
+func (c *mockMetadataClient) Close() error {
+	return nil
+}
+
+func TestBrokerMetadataService_Suggest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	routingCache := NewMockRoutingCache(ctrl)
+	srv := NewBrokerMetadataService(routingCache, rpc.NewCircuitBreakers(config.CircuitBreaker{}), rpc.NewConnPool())
+	var clients []*mockMetadataClient
+	var initErr error
+	srv.(*brokerMetadataService).newClient = func(node models.Node) rpc.MetadataClient {
+		client := &mockMetadataClient{node: node, initErr: initErr}
+		clients = append(clients, client)
+		return client
+	}
+
+	// database not exist
+	routingCache.EXPECT().Shards("metadata_db").Return(nil, false)
+	_, err := srv.Suggest(context.TODO(), &models.SuggestRequest{Database: "metadata_db", Type: models.SuggestMetricNames})
+	assert.NotNil(t, err)
+
+	// shard assignment not exist
+	routingCache.EXPECT().Shards("metadata_db").Return(nil, true)
+	values, err := srv.Suggest(context.TODO(), &models.SuggestRequest{Database: "metadata_db", Type: models.SuggestMetricNames})
+	assert.Nil(t, err)
+	assert.Nil(t, values)
+
+	shardAssign := models.NewShardAssignment()
+	shardAssign.Nodes[1] = models.Node{IP: "127.0.0.1", Port: 2000}
+	shardAssign.Nodes[2] = models.Node{IP: "127.0.0.1", Port: 2001}
+	shardAssign.AddReplica(1, 1)
+	shardAssign.AddReplica(2, 2)
+	routingCache.EXPECT().Shards("metadata_db").Return(assignedShards(shardAssign), true).AnyTimes()
+
+	values, err = srv.Suggest(context.TODO(), &models.SuggestRequest{Database: "metadata_db", Type: models.SuggestMetricNames, Offset: 1, Limit: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"disk", "memory"}, values)
+	assert.Len(t, clients, 2)
+	for _, client := range clients {
+		assert.Equal(t, 0, client.req.Offset)
+		assert.Equal(t, 3, client.req.Limit)
+	}
+
+	initErr = fmt.Errorf("err")
+	_, err = srv.Suggest(context.TODO(), &models.SuggestRequest{Database: "metadata_db", Type: models.SuggestMetricNames})
+	assert.NotNil(t, err)
+}
+
+func TestBrokerMetadataService_Cardinality(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	routingCache := NewMockRoutingCache(ctrl)
+	srv := NewBrokerMetadataService(routingCache, rpc.NewCircuitBreakers(config.CircuitBreaker{}), rpc.NewConnPool())
+	var initErr error
+	srv.(*brokerMetadataService).newClient = func(node models.Node) rpc.MetadataClient {
+		return &mockMetadataClient{node: node, initErr: initErr}
+	}
+
+	// invalid request
+	_, err := srv.Cardinality(context.TODO(), &models.CardinalityRequest{Database: "cardinality_db"})
+	assert.NotNil(t, err)
+	// database not exist
+	routingCache.EXPECT().Shards("cardinality_db").Return(nil, false)
+	_, err = srv.Cardinality(context.TODO(), &models.CardinalityRequest{Database: "cardinality_db", MetricName: "cpu"})
+	assert.NotNil(t, err)
+
+	shardAssign := models.NewShardAssignment()
+	shardAssign.Nodes[1] = models.Node{IP: "127.0.0.1", Port: 2000}
+	shardAssign.Nodes[2] = models.Node{IP: "127.0.0.1", Port: 2001}
+	shardAssign.Nodes[3] = models.Node{IP: "127.0.0.1", Port: 2002}
+	shardAssign.AddReplica(1, 1)
+	shardAssign.AddReplica(2, 2)
+	shardAssign.AddReplica(3, 3)
+	routingCache.EXPECT().Shards("cardinality_db").Return(assignedShards(shardAssign), true).AnyTimes()
+
+	// sketches of nodes are merged, the node without metric is skipped
+	sketches, err := srv.Cardinality(context.TODO(), &models.CardinalityRequest{Database: "cardinality_db", MetricName: "cpu"})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), sketches.Series.Count())
+	assert.Equal(t, uint64(2), sketches.TagValues["host"].Count())
+
+	initErr = fmt.Errorf("err")
+	_, err = srv.Cardinality(context.TODO(), &models.CardinalityRequest{Database: "cardinality_db", MetricName: "cpu"})
+	assert.NotNil(t, err)
+}
+
+func TestBrokerMetadataService_Events(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	routingCache := NewMockRoutingCache(ctrl)
+	srv := NewBrokerMetadataService(routingCache, rpc.NewCircuitBreakers(config.CircuitBreaker{}), rpc.NewConnPool())
+	var (
+		clients []*mockMetadataClient
+		initErr error
+	)
+	srv.(*brokerMetadataService).newClient = func(node models.Node) rpc.MetadataClient {
+		client := &mockMetadataClient{node: node, initErr: initErr}
+		clients = append(clients, client)
+		return client
+	}
+
+	req := &models.EventWriteRequest{Database: "event_db", Events: []models.Event{{Title: "deploy", StartTime: 1000}}}
+	query := &models.EventQuery{Database: "event_db", StartTime: 1000, EndTime: 2000}
+	// invalid request
+	assert.NotNil(t, srv.WriteEvents(context.TODO(), &models.EventWriteRequest{Database: "event_db"}))
+	_, err := srv.QueryEvents(context.TODO(), &models.EventQuery{Database: "event_db"})
+	assert.NotNil(t, err)
+	// database not exist
+	routingCache.EXPECT().Shards("event_db").Return(nil, false).Times(2)
+	assert.NotNil(t, srv.WriteEvents(context.TODO(), req))
+	_, err = srv.QueryEvents(context.TODO(), query)
+	assert.NotNil(t, err)
+
+	// shard assignment not exist, no nodes to write
+	routingCache.EXPECT().Shards("event_db").Return(nil, true)
+	assert.NotNil(t, srv.WriteEvents(context.TODO(), req))
+
+	shardAssign := models.NewShardAssignment()
+	shardAssign.Nodes[1] = models.Node{IP: "127.0.0.1", Port: 2000}
+	shardAssign.Nodes[2] = models.Node{IP: "127.0.0.1", Port: 2001}
+	shardAssign.Nodes[3] = models.Node{IP: "127.0.0.1", Port: 2002}
+	shardAssign.AddReplica(1, 1)
+	shardAssign.AddReplica(1, 2)
+	shardAssign.AddReplica(2, 2)
+	shardAssign.AddReplica(2, 3)
+	routingCache.EXPECT().Shards("event_db").Return(assignedShards(shardAssign), true).AnyTimes()
+
+	// events are written into all replicas
+	assert.Nil(t, srv.WriteEvents(context.TODO(), req))
+	assert.Len(t, clients, 3)
+	for _, client := range clients {
+		assert.Equal(t, req, client.eventReq)
+	}
+
+	// events of nodes are merged, the duplicate events are returned once
+	events, err := srv.QueryEvents(context.TODO(), query)
+	assert.Nil(t, err)
+	assert.Equal(t, []models.Event{
+		{Title: "event-2001", StartTime: 1000 - 2001, EndTime: 2000},
+		{Title: "deploy", StartTime: 1000, EndTime: 1000},
+	}, events)
+
+	initErr = fmt.Errorf("err")
+	assert.NotNil(t, srv.WriteEvents(context.TODO(), req))
+	_, err = srv.QueryEvents(context.TODO(), query)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+)
+
+// MetricStoreService represents the administration of metric stores in memory-database of shards,
+// operators reset/drop the metric store for recovering from a cardinality incident without restarting storage nodes
+type MetricStoreService interface {
+	// Manage resets or drops the metric store in memory-database of database's shards,
+	// returns the result of each shard replica
+	Manage(ctx context.Context, req *models.MetricStoreRequest) ([]models.MetricStoreResult, error)
+}
+
+// metricStoreService implements MetricStoreService interface based on the shards of tsdb engine in storage node
+type metricStoreService struct {
+	storageService StorageService
+}
+
+// NewMetricStoreService creates metric store service for managing the memory-database of local shards
+func NewMetricStoreService(storageService StorageService) MetricStoreService {
+	return &metricStoreService{
+		storageService: storageService,
+	}
+}
+
+// Manage resets or drops the metric store of the shards in current storage node, the shard not exist is skipped,
+// the metric not exist in shard is not treated as failure.
+func (s *metricStoreService) Manage(ctx context.Context, req *models.MetricStoreRequest) ([]models.MetricStoreResult, error) {
+	if err := validateMetricStoreRequest(req); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	engine := s.storageService.GetEngine(req.Database)
+	if engine == nil {
+		return nil, nil
+	}
+	shardIDs := req.ShardIDs
+	if len(shardIDs) == 0 {
+		shardIDs = engine.ShardIDs()
+	}
+	var results []models.MetricStoreResult
+	for _, shardID := range shardIDs {
+		shard := engine.GetShard(shardID)
+		if shard == nil {
+			continue
+		}
+		result := models.MetricStoreResult{ShardID: shardID}
+		// the metric stores of rollup intervals are operated too, the first error is returned
+		for _, memDB := range shard.MemoryDatabases() {
+			var err error
+			if req.Action == models.ResetMetricStore {
+				err = memDB.ResetMetricStore(req.MetricName)
+			} else {
+				err = memDB.DropMetricStore(req.MetricName)
+			}
+			if err != nil && !errors.Is(err, errors.ErrMetricNotFound) && len(result.Error) == 0 {
+				result.Error = err.Error()
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// validateMetricStoreRequest checks if metric store request is valid
+func validateMetricStoreRequest(req *models.MetricStoreRequest) error {
+	if req == nil {
+		return fmt.Errorf("metric store request cannot be nil")
+	}
+	if len(req.Database) == 0 {
+		return fmt.Errorf("database name cannot be empty")
+	}
+	if len(req.MetricName) == 0 {
+		return fmt.Errorf("metric name cannot be empty")
+	}
+	switch req.Action {
+	case models.ResetMetricStore, models.DropMetricStore:
+		return nil
+	default:
+		return fmt.Errorf("unknown action[%s] of metric store", req.Action)
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/eleme/lindb/broker/rpc"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+)
+
+// adminClientFactory creates the admin client for given storage node
+type adminClientFactory func(node models.Node) rpc.AdminClient
+
+// brokerMetricStoreService implements MetricStoreService interface for broker,
+// sends the request to all replicas of database's shards, because each replica has its own memory-database.
+// The storage node operates the requested shards which it owns, so the shards of request needn't be resolved.
+type brokerMetricStoreService struct {
+	routingCache    RoutingCache
+	circuitBreakers rpc.CircuitBreakers
+	newClient       adminClientFactory
+}
+
+// NewBrokerMetricStoreService creates metric store service for broker
+func NewBrokerMetricStoreService(routingCache RoutingCache, circuitBreakers rpc.CircuitBreakers,
+	connPool rpc.ConnPool) MetricStoreService {
+	return &brokerMetricStoreService{
+		routingCache:    routingCache,
+		circuitBreakers: circuitBreakers,
+		newClient: func(node models.Node) rpc.AdminClient {
+			return rpc.NewPooledAdminClient(connPool, fmt.Sprintf("%s:%d", node.IP, node.Port))
+		},
+	}
+}
+
+// Manage resets or drops the metric store in all replicas of database's shards,
+// returns the results of shard replicas sorted by shard id, returns error if any storage node fails.
+func (s *brokerMetricStoreService) Manage(ctx context.Context,
+	req *models.MetricStoreRequest) ([]models.MetricStoreResult, error) {
+	if err := validateMetricStoreRequest(req); err != nil {
+		return nil, err
+	}
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
+	shards, err := collectDatabaseShards(s.routingCache, req.Database)
+	if err != nil {
+		return nil, err
+	}
+	nodes := allReplicas(shards)
+	if len(nodes) == 0 {
+		return nil, errors.Wrapf(errors.ErrDatabaseNotFound, "no storage nodes of database: %s", req.Database)
+	}
+	var results []models.MetricStoreResult
+	for _, node := range nodes {
+		nodeResults, err := s.manage(ctx, node, req)
+		if err != nil {
+			return nil, err
+		}
+		for idx := range nodeResults {
+			nodeResults[idx].Node = node.String()
+		}
+		results = append(results, nodeResults...)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].ShardID < results[j].ShardID
+	})
+	return results, nil
+}
+
+// manage sends the request of metric store to the storage node
+func (s *brokerMetricStoreService) manage(ctx context.Context, node models.Node,
+	req *models.MetricStoreRequest) ([]models.MetricStoreResult, error) {
+	client := s.newClient(node)
+	if err := client.Init(); err != nil {
+		s.circuitBreakers.Failure(node.Key())
+		return nil, fmt.Errorf("connect storage node[%s:%d] error:%s", node.IP, node.Port, err)
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	results, err := client.ManageMetricStore(ctx, req)
+	if rpc.IsNodeFailure(err) {
+		s.circuitBreakers.Failure(node.Key())
+	} else {
+		s.circuitBreakers.Success(node.Key())
+	}
+	return results, err
+}
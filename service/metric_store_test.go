@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/broker/rpc"
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/pkg/util"
+)
+
+func TestMetricStoreService_Manage(t *testing.T) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+
+	storageService := NewStorageService(config.Engine{Path: testPath})
+	srv := NewMetricStoreService(storageService)
+
+	req := &models.MetricStoreRequest{Database: "metric_store_db", MetricName: "cpu", Action: models.ResetMetricStore}
+	results, err := srv.Manage(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Nil(t, results)
+
+	option := validOption
+	option.TimeWindow = 32
+	assert.Nil(t, storageService.CreateShards("metric_store_db", option, 1, 2))
+	point := models.NewPoint("cpu", timeutil.Now(), map[string]string{"host": "1.1.1.1"},
+		map[string]models.Field{"f1": models.NewSimpleField(field.SumField, field.Integer, int64(1))})
+	memDB := storageService.GetShard("metric_store_db", 1).MemoryDatabase()
+	assert.Nil(t, memDB.Write(point))
+
+	// metric store is reset too frequently
+	results, err = srv.Manage(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, 1, results[0].ShardID)
+	assert.NotEmpty(t, results[0].Error)
+	assert.Equal(t, models.MetricStoreResult{ShardID: 2}, results[1])
+
+	// the shard not exist is skipped
+	req.Action = models.DropMetricStore
+	req.ShardIDs = []int{1, 3}
+	results, err = srv.Manage(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Equal(t, []models.MetricStoreResult{{ShardID: 1}}, results)
+	assert.Equal(t, 0, memDB.CountMetrics())
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	_, err = srv.Manage(ctx, req)
+	assert.NotNil(t, err)
+	_ = storageService.GetEngine("metric_store_db").Close()
+}
+
+func TestValidateMetricStoreRequest(t *testing.T) {
+	assert.NotNil(t, validateMetricStoreRequest(nil))
+	assert.NotNil(t, validateMetricStoreRequest(&models.MetricStoreRequest{MetricName: "cpu"}))
+	assert.NotNil(t, validateMetricStoreRequest(&models.MetricStoreRequest{Database: "db"}))
+	assert.NotNil(t, validateMetricStoreRequest(&models.MetricStoreRequest{Database: "db", MetricName: "cpu"}))
+	assert.NotNil(t, validateMetricStoreRequest(&models.MetricStoreRequest{Database: "db", MetricName: "cpu", Action: "flush"}))
+	assert.Nil(t, validateMetricStoreRequest(&models.MetricStoreRequest{Database: "db", MetricName: "cpu",
+		Action: models.DropMetricStore}))
+}
+
+func TestBrokerMetricStoreService_Manage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	routingCache := NewMockRoutingCache(ctrl)
+	srv := NewBrokerMetricStoreService(routingCache, rpc.NewCircuitBreakers(config.CircuitBreaker{}), rpc.NewConnPool())
+
+	req := &models.MetricStoreRequest{Database: "metric_store_db", MetricName: "cpu", Action: models.DropMetricStore}
+	var (
+		nodes   []models.Node
+		initErr error
+	)
+	srv.(*brokerMetricStoreService).newClient = func(node models.Node) rpc.AdminClient {
+		nodes = append(nodes, node)
+		client := rpc.NewMockAdminClient(ctrl)
+		client.EXPECT().Init().Return(initErr)
+		client.EXPECT().Close().Return(nil).AnyTimes()
+		if initErr == nil {
+			client.EXPECT().ManageMetricStore(gomock.Any(), req).
+				Return([]models.MetricStoreResult{{ShardID: int(node.Port) - 2000}}, nil)
+		}
+		return client
+	}
+	// invalid request
+	_, err := srv.Manage(context.TODO(), &models.MetricStoreRequest{Database: "metric_store_db"})
+	assert.NotNil(t, err)
+	// database not exist
+	routingCache.EXPECT().Shards("metric_store_db").Return(nil, false)
+	_, err = srv.Manage(context.TODO(), req)
+	assert.NotNil(t, err)
+
+	// shard assignment not exist, no nodes to send
+	routingCache.EXPECT().Shards("metric_store_db").Return(nil, true)
+	_, err = srv.Manage(context.TODO(), req)
+	assert.NotNil(t, err)
+
+	shardAssign := models.NewShardAssignment()
+	shardAssign.Nodes[1] = models.Node{IP: "127.0.0.1", Port: 2002}
+	shardAssign.Nodes[2] = models.Node{IP: "127.0.0.1", Port: 2001}
+	shardAssign.AddReplica(1, 1)
+	shardAssign.AddReplica(1, 2)
+	shardAssign.AddReplica(2, 2)
+	routingCache.EXPECT().Shards("metric_store_db").Return(assignedShards(shardAssign), true).AnyTimes()
+
+	// request is sent to all replicas, the results are sorted by shard id
+	results, err := srv.Manage(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Len(t, nodes, 2)
+	assert.Equal(t, []models.MetricStoreResult{
+		{Node: "127.0.0.1:2001", ShardID: 1},
+		{Node: "127.0.0.1:2002", ShardID: 2},
+	}, results)
+
+	initErr = fmt.Errorf("err")
+	_, err = srv.Manage(context.TODO(), req)
+	assert.NotNil(t, err)
+}
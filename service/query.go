@@ -0,0 +1,443 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/pkg/interval"
+	"github.com/eleme/lindb/query"
+	aggregationpb "github.com/eleme/lindb/rpc/proto/aggregation"
+	"github.com/eleme/lindb/tsdb"
+	"github.com/eleme/lindb/tsdb/index"
+	"github.com/eleme/lindb/tsdb/memdb"
+)
+
+// QueryService represents the query of metric data in the shards of storage node,
+// which is called by broker for each sub query of metric, then the results of storage nodes are merged by broker.
+type QueryService interface {
+	// Query scans the requested shards which the node owns, returns the partial aggregation result
+	// grouped by the tag values of group by, the result is incomplete if any shard missing or any limit exceeded
+	Query(ctx context.Context, req *models.StorageQueryRequest) (*aggregationpb.PartialResult, error)
+}
+
+// queryService implements QueryService interface based on the shards of tsdb engine in storage node
+type queryService struct {
+	storageService StorageService
+	limits         config.Query
+	statsRecorder  DatabaseStatsRecorder
+}
+
+// NewQueryService creates query service of storage node with the limits of query execution,
+// the points scanned are recorded into database statistics if stats recorder isn't nil
+func NewQueryService(storageService StorageService, limits config.Query,
+	statsRecorder DatabaseStatsRecorder) QueryService {
+	return &queryService{
+		storageService: storageService,
+		limits:         limits,
+		statsRecorder:  statsRecorder,
+	}
+}
+
+// queryField represents the field of query which is resolved by the field index of metric
+type queryField struct {
+	name    string
+	fieldID uint32
+	aggFunc field.AggFunc
+	aggType aggregationpb.AggType
+}
+
+// Query scans the segments of the requested shards by tsdb executor, then adds the data in memory-databases
+// which isn't flushed yet, the series are grouped by the tag values of group by.
+func (s *queryService) Query(ctx context.Context, req *models.StorageQueryRequest) (*aggregationpb.PartialResult, error) {
+	if err := validateStorageQueryRequest(req); err != nil {
+		return nil, err
+	}
+	result := &aggregationpb.PartialResult{
+		Version:    query.PartialResultVersion,
+		MetricName: req.MetricName,
+		StartTime:  req.Start,
+		EndTime:    req.End,
+		Interval:   req.Interval,
+		GroupBy:    req.GroupBy,
+	}
+	engine := s.storageService.GetEngine(req.Database)
+	var shardIDs []int
+	for _, shardID := range req.ShardIDs {
+		if engine != nil && engine.GetShard(shardID) != nil {
+			shardIDs = append(shardIDs, shardID)
+		} else {
+			result.MissingShards = append(result.MissingShards, int32(shardID))
+		}
+	}
+	result.Complete = len(result.MissingShards) == 0
+	if len(shardIDs) == 0 {
+		return result, nil
+	}
+	// the ids of metric and fields written recently may be not synced into index yet,
+	// then only the memory-databases are scanned, the aggregators of fields are resolved by memory-database
+	engineIndex := engine.GetIndex()
+	metricID := engineIndex.GetMetricUID().GetMetricID(req.MetricName)
+	fields := resolveQueryFields(engineIndex.GetFieldUID(), metricID, req.Fields)
+
+	intervalType := req.IntervalType
+	if intervalType == 0 {
+		intervalType = interval.Day
+	}
+	limiter := query.NewResultLimiter(s.limits)
+	defer func() {
+		if s.statsRecorder != nil {
+			if points := limiter.Stats().ScannedPoints; points > 0 {
+				s.statsRecorder.RecordScan(req.Database, points*models.PointBytes)
+			}
+		}
+	}()
+	scanner := newSegmentScanner(ctx, metricID, fields, engineIndex.GetTagsUID())
+	executor := query.NewTSDBExecutor(engine, shardIDs, &storageQuery{req: req}, intervalType, scanner,
+		limiter, s.limits.Concurrency)
+	executor.SetMinSequences(ctx, req.MinSequences)
+	executor.Execute()
+	rs, err := executor.Result()
+	if err != nil {
+		return nil, err
+	}
+	// the data in memory-databases isn't included by the snapshots of segments
+	if err := scanMemoryDatabases(ctx, engine, shardIDs, intervalType, req, fields, rs, limiter); err != nil {
+		return nil, err
+	}
+	// the string fields of group by are the dimensions of series by their last values
+	if err := resolveStringDimensions(ctx, engine, shardIDs, intervalType, req, metricID, rs); err != nil {
+		return nil, err
+	}
+	grouped := groupSeries(rs, req.GroupBy, fields)
+	if req.Limit > 0 {
+		orderLimit, err := newStorageOrderLimit(req)
+		if err != nil {
+			return nil, err
+		}
+		orderLimit.Apply(grouped)
+	}
+	result.Series = toPartialSeries(grouped, req.GroupBy, fields)
+	result.Complete = result.Complete && !rs.Partial && !limiter.Partial()
+	return result, nil
+}
+
+// resolveQueryFields returns the fields of query resolved by the field index of metric, the field not found
+// in index has no field id and aggregator, which is resolved by the type of field in memory-database.
+func resolveQueryFields(fieldUID tsdb.FieldUID, metricID uint32, fieldNames []string) []queryField {
+	fields := make([]queryField, len(fieldNames))
+	for idx, fieldName := range fieldNames {
+		fields[idx] = queryField{name: fieldName, fieldID: index.NotFoundFieldID}
+		if metricID == index.NotFoundMetricID {
+			continue
+		}
+		fieldID := fieldUID.GetFieldID(metricID, fieldName)
+		if fieldID == index.NotFoundFieldID {
+			continue
+		}
+		fields[idx].fieldID = fieldID
+		fields[idx].setType(index.GetFieldType(fieldID))
+	}
+	return fields
+}
+
+// resolved returns if the aggregator of field is resolved
+func (f *queryField) resolved() bool {
+	return f.aggFunc != nil
+}
+
+// setType sets the aggregator of field by the field type
+func (f *queryField) setType(fieldType field.Type) {
+	aggType := fieldType.AggType()
+	f.aggFunc = field.GetAggFunc(aggType)
+	f.aggType = toPartialAggType(aggType)
+}
+
+// toPartialAggType returns the aggregator type of partial result column by the aggregator type of field
+func toPartialAggType(aggType field.AggType) aggregationpb.AggType {
+	switch aggType {
+	case field.Min:
+		return aggregationpb.AggType_Min
+	case field.Max:
+		return aggregationpb.AggType_Max
+	default:
+		return aggregationpb.AggType_Sum
+	}
+}
+
+// scanMemoryDatabases adds the points of memory-databases of shards into the result set of segments,
+// the points are down sampled into the time slots by the aggregator of field.
+func scanMemoryDatabases(ctx context.Context, engine tsdb.Engine, shardIDs []int, intervalType interval.Type,
+	req *models.StorageQueryRequest, fields []queryField, rs *models.ResultSet, limiter *query.ResultLimiter) error {
+	fieldNames := make([]string, len(fields))
+	for idx := range fields {
+		fieldNames[idx] = fields[idx].name
+	}
+	maxSeries := math.MaxInt32
+	timeRange := models.TimeRange{Start: req.Start, End: req.End}
+	seriesMap := make(map[string]*models.Series, len(rs.Series))
+	for _, series := range rs.Series {
+		seriesMap[index.MapToString(series.Tags)] = series
+	}
+	for _, shardID := range shardIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		shard := engine.GetShard(shardID)
+		if shard == nil {
+			continue
+		}
+		memDB, ok := shard.MemoryDatabaseOf(intervalType)
+		// the frozen shard has no memory-database, the data is on disk only
+		if !ok || memDB == nil {
+			continue
+		}
+		if err := resolveFieldTypes(memDB, req.MetricName, fields); err != nil {
+			return fmt.Errorf("resolve fields of memory-database of shard[%d] error:%s", shardID, err)
+		}
+		rawSeries, truncated, err := memDB.RawSeries(req.MetricName, fieldNames, timeRange, maxSeries)
+		if err != nil && !errors.Is(err, errors.ErrMetricNotFound) {
+			return fmt.Errorf("query memory-database of shard[%d] error:%s", shardID, err)
+		}
+		if truncated {
+			rs.Partial = true
+		}
+		for _, item := range rawSeries {
+			tags := index.StringToMap(item.Tags)
+			key := index.MapToString(tags)
+			target, ok := seriesMap[key]
+			if !ok {
+				if limiter.AddSeries(1) != nil {
+					rs.Partial = true
+					return nil
+				}
+				target = models.NewSeries(tags)
+				target.Metric = req.MetricName
+				seriesMap[key] = target
+				rs.Series = append(rs.Series, target)
+			}
+			for idx := range fields {
+				points := item.Fields[fields[idx].name]
+				if len(points) == 0 || !fields[idx].resolved() {
+					continue
+				}
+				if limiter.AddPoints(int64(len(points))) != nil {
+					rs.Partial = true
+					return nil
+				}
+				values, ok := target.Fields[fields[idx].name]
+				if !ok {
+					values = newSlotValues(rs.PointCount)
+					target.Fields[fields[idx].name] = values
+					rs.AddColumn(fields[idx].name, models.IntegerValue)
+				}
+				for _, point := range points {
+					downSample(values, rs.StartTime, rs.Interval, point.Timestamp, float64(point.Value), fields[idx].aggFunc)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// resolveFieldTypes resolves the aggregators of the fields not found in index by the field types of memory-database
+func resolveFieldTypes(memDB memdb.MemoryDatabase, metricName string, fields []queryField) error {
+	var fieldNames []string
+	for idx := range fields {
+		if !fields[idx].resolved() {
+			fieldNames = append(fieldNames, fields[idx].name)
+		}
+	}
+	if len(fieldNames) == 0 {
+		return nil
+	}
+	types, err := memDB.FieldTypes(metricName, fieldNames)
+	if err != nil {
+		if errors.Is(err, errors.ErrMetricNotFound) {
+			return nil
+		}
+		return err
+	}
+	for idx := range fields {
+		if fieldType, ok := types[fields[idx].name]; ok && !fields[idx].resolved() {
+			fields[idx].setType(fieldType)
+		}
+	}
+	return nil
+}
+
+// downSample aggregates the value of timestamp into the time slot of values by the aggregator,
+// the value out of the time slots is dropped.
+func downSample(values []float64, startTime, interval, timestamp int64, value float64, aggFunc field.AggFunc) {
+	if timestamp < startTime {
+		return
+	}
+	slot := (timestamp - startTime) / interval
+	if slot >= int64(len(values)) {
+		return
+	}
+	if math.IsNaN(values[slot]) {
+		values[slot] = value
+		return
+	}
+	values[slot] = aggFunc.AggregateFloat(values[slot], value)
+}
+
+// newSlotValues returns the values of time slots which are NaN if no value
+func newSlotValues(pointCount int) []float64 {
+	values := make([]float64, pointCount)
+	for idx := range values {
+		values[idx] = math.NaN()
+	}
+	return values
+}
+
+// groupSeries aggregates the series of result set by the tag values of group by, the tags of grouped series
+// are the group by tags, all series are aggregated into one series if no group by.
+func groupSeries(rs *models.ResultSet, groupBy []string, fields []queryField) *models.ResultSet {
+	grouped := &models.ResultSet{
+		StartTime:  rs.StartTime,
+		Interval:   rs.Interval,
+		PointCount: rs.PointCount,
+		Columns:    rs.Columns,
+	}
+	groups := make(map[string]*models.Series)
+	tagValues := make([]string, len(groupBy))
+	for _, series := range rs.Series {
+		for idx, tagKey := range groupBy {
+			tagValues[idx] = series.Tags[tagKey]
+		}
+		key := query.TagValuesKey(tagValues)
+		target, ok := groups[key]
+		if !ok {
+			tags := make(map[string]string, len(groupBy))
+			for idx, tagKey := range groupBy {
+				tags[tagKey] = tagValues[idx]
+			}
+			target = models.NewSeries(tags)
+			target.Metric = series.Metric
+			groups[key] = target
+			grouped.Series = append(grouped.Series, target)
+		}
+		for idx := range fields {
+			values, ok := series.Fields[fields[idx].name]
+			if !ok || !fields[idx].resolved() {
+				continue
+			}
+			targetValues, ok := target.Fields[fields[idx].name]
+			if !ok {
+				targetValues = newSlotValues(grouped.PointCount)
+				target.Fields[fields[idx].name] = targetValues
+			}
+			for slot, value := range values {
+				if slot >= len(targetValues) || math.IsNaN(value) {
+					continue
+				}
+				if math.IsNaN(targetValues[slot]) {
+					targetValues[slot] = value
+				} else {
+					targetValues[slot] = fields[idx].aggFunc.AggregateFloat(targetValues[slot], value)
+				}
+			}
+		}
+	}
+	return grouped
+}
+
+// newStorageOrderLimit creates the top n stage pushed down by broker, the groups are ordered by tags if no order by
+func newStorageOrderLimit(req *models.StorageQueryRequest) (*query.OrderLimit, error) {
+	if req.OrderBy == nil {
+		return query.NewOrderLimit(nil, 0, req.Limit), nil
+	}
+	f, err := query.ParseOrderFunc(req.OrderBy.Func)
+	if err != nil {
+		return nil, err
+	}
+	return query.NewOrderLimit(&query.OrderBy{Field: req.OrderBy.Field, Func: f, Desc: req.OrderBy.Desc},
+		0, req.Limit), nil
+}
+
+// toPartialSeries converts the grouped series into the series of partial result, only the time slots which
+// have value are kept in columns, the columns are ordered by the fields of query.
+func toPartialSeries(grouped *models.ResultSet, groupBy []string, fields []queryField) []*aggregationpb.Series {
+	result := make([]*aggregationpb.Series, 0, len(grouped.Series))
+	for _, series := range grouped.Series {
+		tagValues := make([]string, len(groupBy))
+		for idx, tagKey := range groupBy {
+			tagValues[idx] = series.Tags[tagKey]
+		}
+		partialSeries := &aggregationpb.Series{TagValues: tagValues}
+		for idx := range fields {
+			values, ok := series.Fields[fields[idx].name]
+			if !ok {
+				continue
+			}
+			column := &aggregationpb.Column{
+				FieldName: fields[idx].name,
+				AggType:   fields[idx].aggType,
+				ValueType: aggregationpb.ValueType_Integer,
+			}
+			for slot, value := range values {
+				if math.IsNaN(value) {
+					continue
+				}
+				column.Slots = append(column.Slots, int32(slot))
+				column.IntValues = append(column.IntValues, int64(value))
+			}
+			partialSeries.Columns = append(partialSeries.Columns, column)
+		}
+		result = append(result, partialSeries)
+	}
+	return result
+}
+
+// storageQuery implements models.Query by the storage query request
+type storageQuery struct {
+	req *models.StorageQueryRequest
+}
+
+// MetricName returns the metric name of query
+func (q *storageQuery) MetricName() string {
+	return q.req.MetricName
+}
+
+// TimeRange returns the time range of query
+func (q *storageQuery) TimeRange() models.TimeRange {
+	return models.TimeRange{Start: q.req.Start, End: q.req.End}
+}
+
+// Interval returns the time interval of result
+func (q *storageQuery) Interval() time.Duration {
+	return time.Duration(q.req.Interval) * time.Millisecond
+}
+
+// validateStorageQueryRequest checks if the storage query request is valid
+func validateStorageQueryRequest(req *models.StorageQueryRequest) error {
+	if req == nil {
+		return fmt.Errorf("query request cannot be nil")
+	}
+	if len(req.Database) == 0 {
+		return fmt.Errorf("database name cannot be empty")
+	}
+	if len(req.ShardIDs) == 0 {
+		return fmt.Errorf("shard ids cannot be empty")
+	}
+	if len(req.MetricName) == 0 {
+		return fmt.Errorf("metric name cannot be empty")
+	}
+	if len(req.Fields) == 0 {
+		return fmt.Errorf("fields cannot be empty")
+	}
+	if req.Interval <= 0 {
+		return fmt.Errorf("interval must be > 0")
+	}
+	if req.Start > req.End {
+		return fmt.Errorf("start time[%d] is after end time[%d]", req.Start, req.End)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/query"
+)
+
+// queryAuthorizer implements query.Authorizer interface by the readers of database config
+type queryAuthorizer struct {
+	databaseService DatabaseService
+}
+
+// NewQueryAuthorizer creates the authorizer which allows the readers of database to query the database,
+// the database without readers can be queried by all users.
+func NewQueryAuthorizer(databaseService DatabaseService) query.Authorizer {
+	return &queryAuthorizer{databaseService: databaseService}
+}
+
+// Authorize returns ErrQueryUnauthorized if the authenticated user in context isn't the reader of database
+func (a *queryAuthorizer) Authorize(ctx context.Context, database string) error {
+	db, err := a.databaseService.Get(database)
+	if err != nil {
+		return err
+	}
+	if len(db.Readers) == 0 {
+		return nil
+	}
+	userName := models.UserFromContext(ctx)
+	for _, reader := range db.Readers {
+		if len(userName) > 0 && reader == userName {
+			return nil
+		}
+	}
+	return errors.Wrapf(errors.ErrQueryUnauthorized, "user[%s] cannot query database[%s]", userName, database)
+}
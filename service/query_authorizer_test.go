@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+)
+
+type fakeDatabaseService struct {
+	databases map[string]models.Database
+}
+
+func (s *fakeDatabaseService) Save(database models.Database) error {
+	s.databases[database.Name] = database
+	return nil
+}
+
+func (s *fakeDatabaseService) Get(name string) (models.Database, error) {
+	database, ok := s.databases[name]
+	if !ok {
+		return database, errors.Wrapf(errors.ErrDatabaseNotFound, "database[%s]", name)
+	}
+	return database, nil
+}
+
+func (s *fakeDatabaseService) List() ([]models.Database, error) {
+	return nil, nil
+}
+
+func TestQueryAuthorizer_Authorize(t *testing.T) {
+	databaseService := &fakeDatabaseService{databases: map[string]models.Database{
+		"public":  {Name: "public"},
+		"private": {Name: "private", Readers: []string{"admin"}},
+	}}
+	authorizer := NewQueryAuthorizer(databaseService)
+	ctx := context.TODO()
+	assert.Nil(t, authorizer.Authorize(ctx, "public"))
+	assert.True(t, errors.Is(authorizer.Authorize(ctx, "private"), errors.ErrQueryUnauthorized))
+	assert.True(t, errors.Is(authorizer.Authorize(ctx, "not_exist"), errors.ErrDatabaseNotFound))
+
+	ctx = models.WithUser(ctx, "guest")
+	assert.Nil(t, authorizer.Authorize(ctx, "public"))
+	assert.True(t, errors.Is(authorizer.Authorize(ctx, "private"), errors.ErrQueryUnauthorized))
+	ctx = models.WithUser(context.TODO(), "admin")
+	assert.Nil(t, authorizer.Authorize(ctx, "private"))
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/pathutil"
+	"github.com/eleme/lindb/pkg/state"
+)
+
+// QueryBlacklistService represents the blacklist rules of queries, which are stored in the state repository
+// of broker cluster and watched by all brokers
+type QueryBlacklistService interface {
+	// Save saves the blacklist rule, the rule of same name is replaced
+	Save(rule models.QueryBlacklistRule) error
+	// Delete deletes the blacklist rule by name
+	Delete(name string) error
+	// List lists all blacklist rules sorted by name
+	List() ([]models.QueryBlacklistRule, error)
+}
+
+// queryBlacklistService implements QueryBlacklistService interface
+type queryBlacklistService struct {
+	repo state.Repository
+}
+
+// NewQueryBlacklistService creates the query blacklist service
+func NewQueryBlacklistService(repo state.Repository) QueryBlacklistService {
+	return &queryBlacklistService{repo: repo}
+}
+
+// Save validates the blacklist rule, then saves it into state repository
+func (s *queryBlacklistService) Save(rule models.QueryBlacklistRule) error {
+	if _, err := compileBlacklistRule(rule); err != nil {
+		return err
+	}
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return fmt.Errorf("marshal query blacklist rule error:%s", err)
+	}
+	ctx, cancel := withDefaultTimeout(context.TODO())
+	defer cancel()
+	return s.repo.Put(ctx, pathutil.Keys.Queries.Blacklist.Key(rule.Name), data)
+}
+
+// Delete deletes the blacklist rule by name
+func (s *queryBlacklistService) Delete(name string) error {
+	if err := pathutil.ValidateName(name); err != nil {
+		return errors.Wrapf(errors.ErrInvalidArgument, "invalid name of query blacklist rule:%s", err)
+	}
+	ctx, cancel := withDefaultTimeout(context.TODO())
+	defer cancel()
+	return s.repo.Delete(ctx, pathutil.Keys.Queries.Blacklist.Key(name))
+}
+
+// List lists all blacklist rules sorted by name, the invalid rules are skipped
+func (s *queryBlacklistService) List() ([]models.QueryBlacklistRule, error) {
+	ctx, cancel := withDefaultTimeout(context.TODO())
+	defer cancel()
+	values, err := s.repo.List(ctx, pathutil.Keys.Queries.Blacklist.Prefix())
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]models.QueryBlacklistRule, 0, len(values))
+	for _, value := range values {
+		rule := models.QueryBlacklistRule{}
+		if err := json.Unmarshal(value, &rule); err != nil {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].Name < rules[j].Name
+	})
+	return rules, nil
+}
+
+// blacklistRule represents the blacklist rule with the compiled regular expressions
+type blacklistRule struct {
+	rule   models.QueryBlacklistRule
+	metric *regexp.Regexp
+	expr   *regexp.Regexp
+}
+
+// compileBlacklistRule validates the blacklist rule, then compiles the regular expressions of it
+func compileBlacklistRule(rule models.QueryBlacklistRule) (*blacklistRule, error) {
+	if err := pathutil.ValidateName(rule.Name); err != nil {
+		return nil, errors.Wrapf(errors.ErrInvalidArgument, "invalid name of query blacklist rule:%s", err)
+	}
+	if len(rule.Database) == 0 && len(rule.Metric) == 0 && len(rule.Expr) == 0 && len(rule.GroupBy) == 0 {
+		return nil, errors.Wrapf(errors.ErrInvalidArgument,
+			"query blacklist rule[%s] without any condition rejects all queries", rule.Name)
+	}
+	compiled := &blacklistRule{rule: rule}
+	var err error
+	if len(rule.Metric) > 0 {
+		if compiled.metric, err = regexp.Compile(rule.Metric); err != nil {
+			return nil, errors.Wrapf(errors.ErrInvalidArgument, "invalid metric pattern of rule[%s]:%s", rule.Name, err)
+		}
+	}
+	if len(rule.Expr) > 0 {
+		if compiled.expr, err = regexp.Compile(rule.Expr); err != nil {
+			return nil, errors.Wrapf(errors.ErrInvalidArgument, "invalid expr pattern of rule[%s]:%s", rule.Name, err)
+		}
+	}
+	return compiled, nil
+}
+
+// match checks if the query matches all non-empty conditions of the rule,
+// the database/metric conditions are matched if any metric of the query across databases matches them
+func (r *blacklistRule) match(req *models.QueryRequest) bool {
+	if !r.matchSources(req.Sources()) {
+		return false
+	}
+	if r.expr != nil {
+		matched := false
+		for _, field := range req.Fields {
+			if r.expr.MatchString(field.Expr) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(r.rule.GroupBy) > 0 {
+		matched := false
+		for _, tagKey := range req.GroupBy {
+			if tagKey == r.rule.GroupBy {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// matchSources checks if any metric qualified by database matches the database/metric conditions of the rule
+func (r *blacklistRule) matchSources(sources []models.QueryMetric) bool {
+	for _, source := range sources {
+		if len(r.rule.Database) > 0 && r.rule.Database != source.Database {
+			continue
+		}
+		if r.metric != nil && !r.metric.MatchString(source.Metric) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// QueryBlacklist keeps the blacklist rules watched from state repository in memory,
+// rejects the query matching any rule before parsing and executing it.
+type QueryBlacklist struct {
+	mutex sync.RWMutex
+	rules map[string]*blacklistRule
+
+	logger *logger.Logger
+}
+
+// NewQueryBlacklist creates the query blacklist without rules
+func NewQueryBlacklist() *QueryBlacklist {
+	return &QueryBlacklist{
+		rules:  make(map[string]*blacklistRule),
+		logger: logger.GetLogger("service/query/blacklist"),
+	}
+}
+
+// Watch watches the blacklist rules until the context is done
+func (b *QueryBlacklist) Watch(ctx context.Context, repo state.Repository) {
+	eventCh := repo.WatchPrefix(ctx, pathutil.Keys.Queries.Blacklist.Prefix())
+	for event := range eventCh {
+		if event.Err != nil {
+			continue
+		}
+		switch event.Type {
+		case state.EventTypeDelete:
+			for _, kv := range event.KeyValues {
+				if name, ok := pathutil.Keys.Queries.Blacklist.Name(kv.Key); ok {
+					b.Remove(name)
+				}
+			}
+		case state.EventTypeAll:
+			b.Cleanup()
+			fallthrough
+		case state.EventTypeModify:
+			for _, kv := range event.KeyValues {
+				rule := models.QueryBlacklistRule{}
+				if err := json.Unmarshal(kv.Value, &rule); err != nil {
+					b.logger.Error("unmarshal query blacklist rule error",
+						logger.String("key", kv.Key), logger.Error(err))
+					continue
+				}
+				if err := b.Set(rule); err != nil {
+					b.logger.Error("set query blacklist rule error",
+						logger.String("key", kv.Key), logger.Error(err))
+				}
+			}
+		}
+	}
+}
+
+// Set compiles the blacklist rule, then adds it into blacklist, the rule of same name is replaced
+func (b *QueryBlacklist) Set(rule models.QueryBlacklistRule) error {
+	compiled, err := compileBlacklistRule(rule)
+	if err != nil {
+		return err
+	}
+	b.mutex.Lock()
+	b.rules[rule.Name] = compiled
+	b.mutex.Unlock()
+	b.logger.Info("query blacklist rule changed", logger.Any("rule", rule))
+	return nil
+}
+
+// Remove removes the blacklist rule by name
+func (b *QueryBlacklist) Remove(name string) {
+	b.mutex.Lock()
+	delete(b.rules, name)
+	b.mutex.Unlock()
+	b.logger.Info("query blacklist rule removed", logger.String("name", name))
+}
+
+// Cleanup removes all blacklist rules
+func (b *QueryBlacklist) Cleanup() {
+	b.mutex.Lock()
+	b.rules = make(map[string]*blacklistRule)
+	b.mutex.Unlock()
+}
+
+// Check returns ErrQueryBlacklisted if the query matches any blacklist rule
+func (b *QueryBlacklist) Check(req *models.QueryRequest) error {
+	if req == nil {
+		return nil
+	}
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	for _, rule := range b.rules {
+		if rule.match(req) {
+			return errors.Wrapf(errors.ErrQueryBlacklisted, "query is rejected by rule[%s] %s",
+				rule.rule.Name, rule.rule.Reason)
+		}
+	}
+	return nil
+}
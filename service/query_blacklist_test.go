@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/check.v1"
+
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/state"
+)
+
+func TestQueryBlacklist_Check(t *testing.T) {
+	blacklist := NewQueryBlacklist()
+	req := &models.QueryRequest{
+		Database: "db",
+		Metric:   "http_requests",
+		Fields:   []models.QueryField{{Expr: "count"}, {Expr: "percentile(latency, 99)"}},
+		GroupBy:  []string{"host", "url"},
+	}
+	assert.Nil(t, blacklist.Check(req))
+	assert.Nil(t, blacklist.Check(nil))
+
+	// invalid rules
+	assert.NotNil(t, blacklist.Set(models.QueryBlacklistRule{Name: "a/b", Database: "db"}))
+	assert.NotNil(t, blacklist.Set(models.QueryBlacklistRule{Name: "all"}))
+	err := blacklist.Set(models.QueryBlacklistRule{Name: "bad", Metric: "("})
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+	assert.NotNil(t, blacklist.Set(models.QueryBlacklistRule{Name: "bad", Expr: "("}))
+
+	// all conditions must match
+	assert.Nil(t, blacklist.Set(models.QueryBlacklistRule{Name: "url", Database: "db2", GroupBy: "url"}))
+	assert.Nil(t, blacklist.Check(req))
+	assert.Nil(t, blacklist.Set(models.QueryBlacklistRule{Name: "url", Database: "db", GroupBy: "path"}))
+	assert.Nil(t, blacklist.Check(req))
+	assert.Nil(t, blacklist.Set(models.QueryBlacklistRule{Name: "url", Metric: "^http_", GroupBy: "url",
+		Reason: "group by url is too expensive"}))
+	err = blacklist.Check(req)
+	assert.True(t, errors.Is(err, errors.ErrQueryBlacklisted))
+	assert.Contains(t, err.Error(), "group by url is too expensive")
+	blacklist.Remove("url")
+	assert.Nil(t, blacklist.Check(req))
+
+	assert.Nil(t, blacklist.Set(models.QueryBlacklistRule{Name: "percentile", Metric: "^cpu$", Expr: "percentile"}))
+	assert.Nil(t, blacklist.Check(req))
+	assert.Nil(t, blacklist.Set(models.QueryBlacklistRule{Name: "percentile", Expr: "percentile"}))
+	assert.NotNil(t, blacklist.Check(req))
+	assert.Nil(t, blacklist.Set(models.QueryBlacklistRule{Name: "percentile", Expr: "^max"}))
+	assert.Nil(t, blacklist.Check(req))
+
+	blacklist.Cleanup()
+	assert.Nil(t, blacklist.Set(models.QueryBlacklistRule{Name: "db", Database: "db"}))
+	assert.NotNil(t, blacklist.Check(req))
+	blacklist.Cleanup()
+	assert.Nil(t, blacklist.Check(req))
+
+	// the query across databases matches if any metric of it matches
+	req = &models.QueryRequest{
+		From:   []models.QueryMetric{{Database: "db1", Metric: "cpu"}, {Database: "db2", Metric: "http_requests"}},
+		Fields: []models.QueryField{{Expr: "count"}},
+	}
+	assert.Nil(t, blacklist.Set(models.QueryBlacklistRule{Name: "http", Database: "db1", Metric: "^http_"}))
+	assert.Nil(t, blacklist.Check(req))
+	assert.Nil(t, blacklist.Set(models.QueryBlacklistRule{Name: "http", Database: "db2", Metric: "^http_"}))
+	assert.NotNil(t, blacklist.Check(req))
+}
+
+type testQueryBlacklistSRVSuite struct {
+	mock.RepoTestSuite
+}
+
+func TestQueryBlacklistSRV(t *testing.T) {
+	check.Suite(&testQueryBlacklistSRVSuite{})
+	check.TestingT(t)
+}
+
+func (ts *testQueryBlacklistSRVSuite) TestSaveAndWatch(c *check.C) {
+	repo, _ := state.NewRepo(state.Config{Namespace: "/query/test", Endpoints: ts.Cluster.Endpoints})
+	srv := NewQueryBlacklistService(repo)
+
+	rules, err := srv.List()
+	c.Assert(err, check.IsNil)
+	c.Assert(rules, check.HasLen, 0)
+	c.Assert(srv.Save(models.QueryBlacklistRule{Name: "all"}), check.NotNil)
+	c.Assert(srv.Delete("a/b"), check.NotNil)
+
+	rule := models.QueryBlacklistRule{Name: "url", GroupBy: "url"}
+	c.Assert(srv.Save(models.QueryBlacklistRule{Name: "db", Database: "db"}), check.IsNil)
+	c.Assert(srv.Save(rule), check.IsNil)
+	// the invalid rules are skipped
+	_ = repo.Put(context.TODO(), "/query/blacklist/bad", []byte("bad"))
+	rules, err = srv.List()
+	c.Assert(err, check.IsNil)
+	c.Assert(rules, check.DeepEquals, []models.QueryBlacklistRule{{Name: "db", Database: "db"}, rule})
+
+	// the rules saved are watched
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	blacklist := NewQueryBlacklist()
+	go blacklist.Watch(ctx, repo)
+	req := &models.QueryRequest{Database: "db2", GroupBy: []string{"url"}}
+	waitForCheck := func(blacklisted bool) {
+		for i := 0; i < 100; i++ {
+			if (blacklist.Check(req) != nil) == blacklisted {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		c.Assert(blacklist.Check(req) != nil, check.Equals, blacklisted)
+	}
+	waitForCheck(true)
+	c.Assert(srv.Delete("url"), check.IsNil)
+	waitForCheck(false)
+	c.Assert(srv.Save(rule), check.IsNil)
+	waitForCheck(true)
+}
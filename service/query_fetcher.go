@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/eleme/lindb/broker/rpc"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/query"
+	aggregationpb "github.com/eleme/lindb/rpc/proto/aggregation"
+)
+
+// queryClientFactory creates the query client for given storage node
+type queryClientFactory func(node models.Node) rpc.QueryClient
+
+// nodeShards represents the shards of database which are queried from the storage node
+type nodeShards struct {
+	node     models.Node
+	shardIDs []int
+}
+
+// storageFetcher implements query.Fetcher interface for broker, fans out the sub query to the storage nodes
+// which own the shards of database, one replica of each shard is queried. The partial results of storage nodes
+// are merged as they arrive(concatenated if the groups are shard-local), the shards of the nodes which cannot serve
+// are reported as missing shards.
+type storageFetcher struct {
+	routingCache    RoutingCache
+	circuitBreakers rpc.CircuitBreakers
+	newClient       queryClientFactory
+}
+
+// NewStorageFetcher creates the fetcher which queries storage nodes by the routing of database
+func NewStorageFetcher(routingCache RoutingCache, circuitBreakers rpc.CircuitBreakers,
+	connPool rpc.ConnPool) query.Fetcher {
+	return &storageFetcher{
+		routingCache:    routingCache,
+		circuitBreakers: circuitBreakers,
+		newClient: func(node models.Node) rpc.QueryClient {
+			return rpc.NewPooledQueryClient(connPool, fmt.Sprintf("%s:%d", node.IP, node.Port))
+		},
+	}
+}
+
+// Fetch queries the storage nodes concurrently, returns the merged result set of sub query,
+// the result set is partial if any shard is missing, returns error if any storage node fails the request.
+func (f *storageFetcher) Fetch(ctx context.Context, req *query.FetchRequest) (*models.ResultSet, error) {
+	shardAssigns, ok := f.routingCache.ShardAssignments(req.Database)
+	if !ok {
+		return nil, errors.Wrapf(errors.ErrDatabaseNotFound, "no shard routing of database: %s", req.Database)
+	}
+	targets, missingShards := f.selectNodes(shardAssigns)
+
+	var (
+		wg       sync.WaitGroup
+		mutex    sync.Mutex
+		fetchErr error
+		results  = make(chan *aggregationpb.PartialResult, len(targets))
+	)
+	for idx := range targets {
+		wg.Add(1)
+		go func(target nodeShards) {
+			defer wg.Done()
+			result, err := f.query(ctx, target.node, newStorageQueryRequest(req, target.shardIDs))
+			if err == nil {
+				results <- result
+				return
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			if rpc.IsNodeFailure(err) {
+				// the shards of the node which cannot serve are missing, the result is partial
+				missingShards = append(missingShards, target.shardIDs...)
+			} else if fetchErr == nil {
+				fetchErr = err
+			}
+		}(targets[idx])
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	merged, err := query.MergePartialResultStream(ctx, results, req.ShardLocal)
+	if err != nil {
+		return nil, err
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+	if merged == nil {
+		merged = &aggregationpb.PartialResult{
+			MetricName: req.SubQuery.MetricName,
+			StartTime:  req.TimeRange.Start,
+			EndTime:    req.TimeRange.End,
+			Interval:   req.Interval,
+			GroupBy:    req.GroupBy,
+			Complete:   true,
+		}
+	}
+	rs := query.PartialResultToResultSet(merged)
+	if len(missingShards) > 0 {
+		rs.Partial = true
+		rs.MissingShards = mergeShardIDs(rs.MissingShards, missingShards)
+	}
+	return rs, nil
+}
+
+// IsShardLocal checks if the series of each group are on the same shard by the routing tags of database cluster,
+// the database of multiple clusters isn't shard-local, because the series of group may be in the shards of each cluster.
+func (f *storageFetcher) IsShardLocal(database string, groupBy []string) bool {
+	shardAssigns, ok := f.routingCache.ShardAssignments(database)
+	if !ok || len(shardAssigns) != 1 {
+		return false
+	}
+	return shardAssigns[0].Config.IsShardLocal(groupBy)
+}
+
+// selectNodes selects one replica of each shard, the node selected for other shards is preferred,
+// so that the number of nodes fanned out is small. The shards whose replicas are all suspect are missing.
+func (f *storageFetcher) selectNodes(shardAssigns []*models.ShardAssignment) (targets []nodeShards, missingShards []int) {
+	selected := make(map[string]int)
+	for _, shardAssign := range shardAssigns {
+		var shardIDs []int
+		for shardID := range shardAssign.Shards {
+			shardIDs = append(shardIDs, shardID)
+		}
+		sort.Ints(shardIDs)
+		for _, shardID := range shardIDs {
+			var replicas []models.Node
+			for _, nodeID := range shardAssign.Shards[shardID].Replicas {
+				if node, ok := shardAssign.Nodes[nodeID]; ok {
+					replicas = append(replicas, node)
+				}
+			}
+			targetIdx := -1
+			for _, node := range replicas {
+				if idx, ok := selected[node.Key()]; ok {
+					targetIdx = idx
+					break
+				}
+			}
+			for idx := 0; idx < len(replicas) && targetIdx < 0; idx++ {
+				node := replicas[idx]
+				if f.circuitBreakers.Allow(node.Key()) {
+					targetIdx = len(targets)
+					selected[node.Key()] = targetIdx
+					targets = append(targets, nodeShards{node: node})
+				}
+			}
+			if targetIdx < 0 {
+				missingShards = append(missingShards, shardID)
+				continue
+			}
+			targets[targetIdx].shardIDs = append(targets[targetIdx].shardIDs, shardID)
+		}
+	}
+	return targets, missingShards
+}
+
+// query sends the storage query request to the storage node
+func (f *storageFetcher) query(ctx context.Context, node models.Node,
+	req *models.StorageQueryRequest) (*aggregationpb.PartialResult, error) {
+	client := f.newClient(node)
+	if err := client.Init(); err != nil {
+		f.circuitBreakers.Failure(node.Key())
+		return nil, fmt.Errorf("connect storage node[%s:%d] error:%s", node.IP, node.Port, err)
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	result, err := client.Query(ctx, req)
+	if rpc.IsNodeFailure(err) {
+		f.circuitBreakers.Failure(node.Key())
+	} else {
+		f.circuitBreakers.Success(node.Key())
+	}
+	return result, err
+}
+
+// newStorageQueryRequest creates the request of storage node for the shards by the fetch request,
+// the top n stage is pushed down if set
+func newStorageQueryRequest(req *query.FetchRequest, shardIDs []int) *models.StorageQueryRequest {
+	storageReq := &models.StorageQueryRequest{
+		Database:     req.Database,
+		ShardIDs:     shardIDs,
+		MetricName:   req.SubQuery.MetricName,
+		Fields:       req.SubQuery.Fields,
+		GroupBy:      req.GroupBy,
+		Start:        req.TimeRange.Start,
+		End:          req.TimeRange.End,
+		Interval:     req.Interval,
+		MinSequences: req.MinSequences,
+	}
+	if req.OrderLimit != nil {
+		storageReq.Limit = req.OrderLimit.Limit()
+		if orderBy := req.OrderLimit.OrderBy(); orderBy != nil {
+			storageReq.OrderBy = &models.QueryOrderBy{
+				Field: orderBy.Field,
+				Func:  orderBy.Func.String(),
+				Desc:  orderBy.Desc,
+			}
+		}
+	}
+	return storageReq
+}
+
+// mergeShardIDs returns the sorted distinct shard ids
+func mergeShardIDs(shardIDs []int, others []int) []int {
+	set := make(map[int]struct{})
+	var result []int
+	for _, shardID := range append(append([]int{}, shardIDs...), others...) {
+		if _, ok := set[shardID]; ok {
+			continue
+		}
+		set[shardID] = struct{}{}
+		result = append(result, shardID)
+	}
+	sort.Ints(result)
+	return result
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/eleme/lindb/broker/rpc"
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/query"
+	aggregationpb "github.com/eleme/lindb/rpc/proto/aggregation"
+)
+
+func TestStorageFetcher_Fetch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	routingCache := NewMockRoutingCache(ctrl)
+	circuitBreakers := rpc.NewCircuitBreakers(config.CircuitBreaker{})
+	fetcher := NewStorageFetcher(routingCache, circuitBreakers, rpc.NewConnPool())
+
+	req := &query.FetchRequest{
+		Database:  "query_db",
+		SubQuery:  query.SubQuery{MetricName: "cpu", Fields: []string{"f1"}},
+		GroupBy:   []string{"host"},
+		TimeRange: models.TimeRange{Start: 0, End: 20},
+		Interval:  10,
+	}
+	var (
+		mutex    sync.Mutex
+		reqs     = make(map[string]*models.StorageQueryRequest)
+		queryErr = make(map[string]error)
+		initErr  error
+	)
+	fetcher.(*storageFetcher).newClient = func(node models.Node) rpc.QueryClient {
+		client := rpc.NewMockQueryClient(ctrl)
+		client.EXPECT().Init().Return(initErr)
+		client.EXPECT().Close().Return(nil).AnyTimes()
+		if initErr != nil {
+			return client
+		}
+		client.EXPECT().Query(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, storageReq *models.StorageQueryRequest) (*aggregationpb.PartialResult, error) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				reqs[node.Key()] = storageReq
+				if err := queryErr[node.Key()]; err != nil {
+					return nil, err
+				}
+				return &aggregationpb.PartialResult{
+					Version: query.PartialResultVersion, MetricName: "cpu", StartTime: 0, EndTime: 20, Interval: 10,
+					GroupBy: []string{"host"}, Complete: true,
+					Series: []*aggregationpb.Series{{TagValues: []string{"a"}, Columns: []*aggregationpb.Column{{
+						FieldName: "f1", AggType: aggregationpb.AggType_Sum, ValueType: aggregationpb.ValueType_Integer,
+						Slots: []int32{0, 2}, IntValues: []int64{1, 2}}}}},
+				}, nil
+			})
+		return client
+	}
+	// database not exist
+	routingCache.EXPECT().ShardAssignments("query_db").Return(nil, false)
+	_, err := fetcher.Fetch(context.TODO(), req)
+	assert.NotNil(t, err)
+
+	shardAssign := models.NewShardAssignment()
+	shardAssign.Nodes[1] = models.Node{IP: "127.0.0.1", Port: 2001}
+	shardAssign.Nodes[2] = models.Node{IP: "127.0.0.1", Port: 2002}
+	shardAssign.AddReplica(1, 1)
+	shardAssign.AddReplica(1, 2)
+	shardAssign.AddReplica(2, 2)
+	shardAssign.AddReplica(3, 1)
+	routingCache.EXPECT().ShardAssignments("query_db").
+		Return([]*models.ShardAssignment{shardAssign}, true).AnyTimes()
+
+	// one replica of each shard is queried, the results of nodes are merged
+	rs, err := fetcher.Fetch(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.False(t, rs.Partial)
+	assert.Len(t, reqs, 2)
+	assert.Equal(t, []int{1, 3}, reqs["127.0.0.1:2001"].ShardIDs)
+	assert.Equal(t, []int{2}, reqs["127.0.0.1:2002"].ShardIDs)
+	assert.Len(t, rs.Series, 1)
+	assert.Equal(t, map[string]string{"host": "a"}, rs.Series[0].Tags)
+	assert.Equal(t, float64(2), rs.Series[0].Fields["f1"][0])
+	assert.Equal(t, float64(4), rs.Series[0].Fields["f1"][2])
+
+	// the shards of unavailable node are missing
+	mutex.Lock()
+	queryErr["127.0.0.1:2001"] = status.Error(codes.Unavailable, "unavailable")
+	mutex.Unlock()
+	rs, err = fetcher.Fetch(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.True(t, rs.Partial)
+	assert.Equal(t, []int{1, 3}, rs.MissingShards)
+	assert.Len(t, rs.Series, 1)
+
+	// the request error fails the fetch
+	mutex.Lock()
+	queryErr["127.0.0.1:2001"] = fmt.Errorf("err")
+	mutex.Unlock()
+	_, err = fetcher.Fetch(context.TODO(), req)
+	assert.NotNil(t, err)
+
+	initErr = fmt.Errorf("err")
+	_, err = fetcher.Fetch(context.TODO(), req)
+	assert.NotNil(t, err)
+}
+
+func TestStorageFetcher_IsShardLocal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	routingCache := NewMockRoutingCache(ctrl)
+	fetcher := NewStorageFetcher(routingCache, rpc.NewCircuitBreakers(config.CircuitBreaker{}), rpc.NewConnPool())
+
+	routingCache.EXPECT().ShardAssignments("query_db").Return(nil, false)
+	assert.False(t, fetcher.IsShardLocal("query_db", []string{"region"}))
+
+	shardAssign := models.NewShardAssignment()
+	shardAssign.Config = models.DatabaseCluster{Name: "c1", RoutingTags: []string{"region"}}
+	routingCache.EXPECT().ShardAssignments("query_db").Return([]*models.ShardAssignment{shardAssign}, true).Times(3)
+	assert.True(t, fetcher.IsShardLocal("query_db", []string{"host", "region"}))
+	assert.False(t, fetcher.IsShardLocal("query_db", []string{"host"}))
+	assert.False(t, fetcher.IsShardLocal("query_db", nil))
+
+	// the series of group may be in the shards of each cluster
+	routingCache.EXPECT().ShardAssignments("query_db").Return([]*models.ShardAssignment{shardAssign, shardAssign}, true)
+	assert.False(t, fetcher.IsShardLocal("query_db", []string{"region"}))
+}
+
+func TestStorageFetcher_selectNodes(t *testing.T) {
+	circuitBreakers := rpc.NewCircuitBreakers(config.CircuitBreaker{FailureThreshold: 1, OpenTimeout: 60 * 1000})
+	fetcher := &storageFetcher{circuitBreakers: circuitBreakers}
+
+	node1 := models.Node{IP: "127.0.0.1", Port: 2001}
+	node2 := models.Node{IP: "127.0.0.1", Port: 2002}
+	shardAssign := models.NewShardAssignment()
+	shardAssign.Nodes[1] = node1
+	shardAssign.Nodes[2] = node2
+	shardAssign.AddReplica(1, 2)
+	shardAssign.AddReplica(2, 1)
+	shardAssign.AddReplica(2, 2)
+	shardAssign.AddReplica(3, 1)
+	// the node selected for other shards is preferred
+	targets, missingShards := fetcher.selectNodes([]*models.ShardAssignment{shardAssign})
+	assert.Empty(t, missingShards)
+	assert.Equal(t, []nodeShards{
+		{node: node2, shardIDs: []int{1, 2}},
+		{node: node1, shardIDs: []int{3}},
+	}, targets)
+
+	// the shards whose replicas are all suspect are missing
+	circuitBreakers.Failure(node1.Key())
+	targets, missingShards = fetcher.selectNodes([]*models.ShardAssignment{shardAssign})
+	assert.Equal(t, []int{3}, missingShards)
+	assert.Equal(t, []nodeShards{{node: node2, shardIDs: []int{1, 2}}}, targets)
+}
+
+func TestNewStorageQueryRequest(t *testing.T) {
+	req := &query.FetchRequest{
+		Database:  "query_db",
+		SubQuery:  query.SubQuery{MetricName: "cpu", Fields: []string{"f1"}},
+		TimeRange: models.TimeRange{Start: 10, End: 20},
+		Interval:  10,
+	}
+	assert.Equal(t, &models.StorageQueryRequest{Database: "query_db", ShardIDs: []int{1}, MetricName: "cpu",
+		Fields: []string{"f1"}, Start: 10, End: 20, Interval: 10}, newStorageQueryRequest(req, []int{1}))
+
+	req.OrderLimit = query.NewOrderLimit(&query.OrderBy{Field: "f1", Func: query.OrderBySum, Desc: true}, 0, 5)
+	storageReq := newStorageQueryRequest(req, []int{1})
+	assert.Equal(t, 5, storageReq.Limit)
+	assert.Equal(t, &models.QueryOrderBy{Field: "f1", Func: query.OrderBySum.String(), Desc: true}, storageReq.OrderBy)
+}
+
+func TestMergeShardIDs(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 3}, mergeShardIDs([]int{3, 1}, []int{2, 1}))
+	assert.Empty(t, mergeShardIDs(nil, nil))
+}
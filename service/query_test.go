@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/pkg/util"
+	"github.com/eleme/lindb/query"
+	aggregationpb "github.com/eleme/lindb/rpc/proto/aggregation"
+)
+
+func TestQueryService_Query(t *testing.T) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+
+	storageService := NewStorageService(config.Engine{Path: testPath})
+	recorder := NewDatabaseStatsRecorder()
+	srv := NewQueryService(storageService, config.Query{}, recorder)
+
+	now := timeutil.Now()
+	now -= now % (10 * 1000)
+	req := &models.StorageQueryRequest{Database: "query_db", ShardIDs: []int{1, 2, 3}, MetricName: "cpu",
+		Fields: []string{"f1"}, GroupBy: []string{"host"}, Start: now - 10*1000, End: now, Interval: 10 * 1000}
+	// database not exist, all shards are missing
+	result, err := srv.Query(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.False(t, result.Complete)
+	assert.Equal(t, []int32{1, 2, 3}, result.MissingShards)
+
+	option := validOption
+	option.TimeWindow = 32
+	assert.Nil(t, storageService.CreateShards("query_db", option, 1, 2))
+	// metric not exist
+	result, err = srv.Query(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Empty(t, result.Series)
+	assert.Equal(t, []int32{3}, result.MissingShards)
+
+	write := func(shardID int, host string, timestamp int64, value int64) {
+		point := models.NewPoint("cpu", timestamp, map[string]string{"host": host, "ip": host},
+			map[string]models.Field{"f1": models.NewSimpleField(field.SumField, field.Integer, value)})
+		assert.Nil(t, storageService.GetShard("query_db", shardID).MemoryDatabase().Write(point))
+	}
+	write(1, "a", now-10*1000, 1)
+	write(1, "a", now, 2)
+	write(2, "a", now, 3)
+	write(2, "b", now, 4)
+	// the point out of time range
+	write(2, "b", now-20*1000, 5)
+
+	req.ShardIDs = []int{1, 2}
+	result, err = srv.Query(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.True(t, result.Complete)
+	assert.EqualValues(t, query.PartialResultVersion, result.Version)
+	rs := query.PartialResultToResultSet(result)
+	assert.Len(t, rs.Series, 2)
+	values := make(map[string][]float64)
+	for _, series := range rs.Series {
+		values[series.Tags["host"]] = series.Fields["f1"]
+	}
+	assert.Equal(t, []float64{1, 5}, values["a"])
+	assert.Equal(t, float64(4), values["b"][1])
+	// the points scanned are recorded into database statistics
+	assert.Equal(t, []models.DatabaseStats{{Database: "query_db", ScanBytes: 4 * models.PointBytes}},
+		recorder.Snapshot())
+
+	// all series are aggregated into one group without group by
+	req.GroupBy = nil
+	result, err = srv.Query(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Len(t, result.Series, 1)
+	assert.Equal(t, []*aggregationpb.Column{{FieldName: "f1", AggType: aggregationpb.AggType_Sum,
+		ValueType: aggregationpb.ValueType_Integer, Slots: []int32{0, 1}, IntValues: []int64{1, 9}}},
+		result.Series[0].Columns)
+	req.GroupBy = []string{"host"}
+
+	// top n pushed down by broker
+	req.OrderBy = &models.QueryOrderBy{Field: "f1", Func: "sum", Desc: true}
+	req.Limit = 1
+	result, err = srv.Query(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Len(t, result.Series, 1)
+	assert.Equal(t, []string{"a"}, result.Series[0].TagValues)
+	req.OrderBy = &models.QueryOrderBy{Field: "f1", Func: "unknown"}
+	_, err = srv.Query(context.TODO(), req)
+	assert.NotNil(t, err)
+	req.OrderBy = nil
+	req.Limit = 0
+
+	// the points flushed into the families of segment are merged with the points of memory-database
+	backfill := models.NewPoint("cpu", now-10*1000, map[string]string{"host": "b", "ip": "b"},
+		map[string]models.Field{"f1": models.NewSimpleField(field.SumField, field.Integer, int64(6))})
+	shard := storageService.GetShard("query_db", 2)
+	assert.Nil(t, shard.WriteBackfill(backfill))
+	assert.Nil(t, shard.FlushBackfill(context.TODO()))
+	result, err = srv.Query(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.True(t, result.Complete)
+	rs = query.PartialResultToResultSet(result)
+	for _, series := range rs.Series {
+		values[series.Tags["host"]] = series.Fields["f1"]
+	}
+	assert.Equal(t, []float64{1, 5}, values["a"])
+	assert.Equal(t, []float64{6, 4}, values["b"])
+
+	// max points exceeded, the result is incomplete
+	srv = NewQueryService(storageService, config.Query{MaxPoints: 1}, nil)
+	result, err = srv.Query(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.False(t, result.Complete)
+
+	// field not exist
+	req.Fields = []string{"f2"}
+	result, err = srv.Query(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Empty(t, result.Series)
+	req.Fields = []string{"f1"}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	_, err = srv.Query(ctx, req)
+	assert.NotNil(t, err)
+	_ = storageService.GetEngine("query_db").Close()
+}
+
+func TestQueryService_Query_stringDimension(t *testing.T) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+
+	storageService := NewStorageService(config.Engine{Path: testPath})
+	assert.Nil(t, storageService.CreateShards("query_db", validOption, 1))
+	now := timeutil.Now()
+	now -= now % (10 * 1000)
+	write := func(host, version string, value int64) {
+		point := models.NewPoint("cpu", now, map[string]string{"host": host},
+			map[string]models.Field{
+				"f1":      models.NewSimpleField(field.SumField, field.Integer, value),
+				"version": models.NewStringField(version),
+			})
+		assert.Nil(t, storageService.GetShard("query_db", 1).MemoryDatabase().Write(point))
+	}
+	write("a", "v1", 1)
+	write("b", "v2", 2)
+	// the memory-database is flushed when closed, the string values are read from the string family after restarted
+	assert.Nil(t, storageService.Close())
+	storageService = NewStorageService(config.Engine{Path: testPath})
+	assert.Nil(t, storageService.Load())
+	srv := NewQueryService(storageService, config.Query{}, nil)
+
+	req := &models.StorageQueryRequest{Database: "query_db", ShardIDs: []int{1}, MetricName: "cpu",
+		Fields: []string{"f1"}, GroupBy: []string{"version"}, Start: now - 10*1000, End: now, Interval: 10 * 1000}
+	groups := func() map[string]float64 {
+		result, err := srv.Query(context.TODO(), req)
+		assert.Nil(t, err)
+		values := make(map[string]float64)
+		for _, series := range query.PartialResultToResultSet(result).Series {
+			values[series.Tags["version"]] = series.Fields["f1"][1]
+		}
+		return values
+	}
+	assert.Equal(t, map[string]float64{"v1": 1, "v2": 2}, groups())
+
+	// the value in memory-database is newer than the flushed value
+	write("a", "v2", 3)
+	assert.Equal(t, map[string]float64{"v2": 6}, groups())
+	assert.Nil(t, storageService.Close())
+}
+
+func TestValidateStorageQueryRequest(t *testing.T) {
+	assert.NotNil(t, validateStorageQueryRequest(nil))
+	req := &models.StorageQueryRequest{}
+	assert.NotNil(t, validateStorageQueryRequest(req))
+	req.Database = "db"
+	assert.NotNil(t, validateStorageQueryRequest(req))
+	req.ShardIDs = []int{1}
+	assert.NotNil(t, validateStorageQueryRequest(req))
+	req.MetricName = "cpu"
+	assert.NotNil(t, validateStorageQueryRequest(req))
+	req.Fields = []string{"f1"}
+	assert.NotNil(t, validateStorageQueryRequest(req))
+	req.Interval = 10
+	req.Start = 20
+	assert.NotNil(t, validateStorageQueryRequest(req))
+	req.End = 20
+	assert.Nil(t, validateStorageQueryRequest(req))
+}
+
+func TestDownSample(t *testing.T) {
+	values := newSlotValues(2)
+	aggFunc := field.GetAggFunc(field.Sum)
+	downSample(values, 10, 10, 5, 1, aggFunc)
+	downSample(values, 10, 10, 30, 1, aggFunc)
+	downSample(values, 10, 10, 10, 1, aggFunc)
+	downSample(values, 10, 10, 15, 2, aggFunc)
+	assert.Equal(t, float64(3), values[0])
+	downSample(values, 10, 10, 20, 4, field.GetAggFunc(field.Max))
+	assert.Equal(t, float64(4), values[1])
+}
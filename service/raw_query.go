@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/tsdb/index"
+	"github.com/eleme/lindb/tsdb/memdb"
+)
+
+// RawQueryService represents the query of raw points in memory-database of shards,
+// which returns the stored values of series without aggregation or down sampling,
+// so that operators can verify the ingestion and export the data.
+type RawQueryService interface {
+	// Query returns the raw points of series of database's shards, the result is truncated by the guards of request
+	Query(ctx context.Context, req *models.RawQueryRequest) (*models.RawQueryResult, error)
+}
+
+// rawQueryService implements RawQueryService interface based on the shards of tsdb engine in storage node
+type rawQueryService struct {
+	storageService StorageService
+	statsRecorder  DatabaseStatsRecorder
+}
+
+// NewRawQueryService creates raw query service for querying the memory-database of local shards,
+// the points scanned are recorded into database statistics if stats recorder isn't nil
+func NewRawQueryService(storageService StorageService, statsRecorder DatabaseStatsRecorder) RawQueryService {
+	return &rawQueryService{
+		storageService: storageService,
+		statsRecorder:  statsRecorder,
+	}
+}
+
+// Query returns the raw points of the shards in current storage node, the shard not exist is skipped,
+// the series are ordered by shard and tags, which are truncated if the max series or max points exceeded.
+func (s *rawQueryService) Query(ctx context.Context, req *models.RawQueryRequest) (*models.RawQueryResult, error) {
+	if err := validateRawQueryRequest(req); err != nil {
+		return nil, err
+	}
+	engine := s.storageService.GetEngine(req.Database)
+	if engine == nil {
+		return &models.RawQueryResult{}, nil
+	}
+	shardIDs := req.ShardIDs
+	if len(shardIDs) == 0 {
+		shardIDs = engine.ShardIDs()
+	}
+	maxSeries := rawQueryMaxSeries(req)
+	timeRange := models.TimeRange{Start: req.Start, End: req.End}
+	result := &models.RawQueryResult{}
+	scannedPoints := 0
+	defer func() {
+		if s.statsRecorder != nil && scannedPoints > 0 {
+			s.statsRecorder.RecordScan(req.Database, int64(scannedPoints)*models.PointBytes)
+		}
+	}()
+	for _, shardID := range shardIDs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		shard := engine.GetShard(shardID)
+		if shard == nil {
+			continue
+		}
+		memDB := shard.MemoryDatabase()
+		if req.IntervalType != 0 {
+			var ok bool
+			if memDB, ok = shard.MemoryDatabaseOf(req.IntervalType); !ok {
+				continue
+			}
+		}
+		// the frozen shard has no memory-database, the data is on disk only
+		if memDB == nil {
+			continue
+		}
+		series, truncated, err := memDB.RawSeries(req.MetricName, req.Fields, timeRange,
+			maxSeries-len(result.Series))
+		if err != nil && !errors.Is(err, errors.ErrMetricNotFound) {
+			return nil, fmt.Errorf("query raw series of shard[%d] error:%s", shardID, err)
+		}
+		fieldTypes, err := fieldTypesOf(memDB, req, len(series))
+		if err != nil {
+			return nil, fmt.Errorf("query field types of shard[%d] error:%s", shardID, err)
+		}
+		stringValues, err := stringValuesOf(memDB, req)
+		if err != nil {
+			return nil, fmt.Errorf("query string values of shard[%d] error:%s", shardID, err)
+		}
+		for _, item := range series {
+			rawSeries := models.RawSeries{
+				ShardID:    shardID,
+				Tags:       index.StringToMap(item.Tags),
+				Fields:     make(map[string][]models.RawPoint, len(item.Fields)),
+				FieldTypes: make(map[string]field.Type, len(item.Fields)),
+				Strings:    stringValues[item.Tags],
+				Sequence:   shard.Sequence(),
+			}
+			for fieldName, points := range item.Fields {
+				scannedPoints += len(points)
+				rawPoints := make([]models.RawPoint, len(points))
+				for idx, point := range points {
+					rawPoints[idx] = models.RawPoint{Timestamp: point.Timestamp, Value: point.Value}
+				}
+				rawSeries.Fields[fieldName] = rawPoints
+				if fieldType, ok := fieldTypes[fieldName]; ok {
+					rawSeries.FieldTypes[fieldName] = fieldType
+				}
+			}
+			result.Series = append(result.Series, rawSeries)
+		}
+		if truncated || truncateRawQueryResult(result, maxSeries) {
+			result.Truncated = true
+			break
+		}
+	}
+	return result, nil
+}
+
+// fieldTypesOf returns the types of the fields of request in memory-database, returns nil if no series
+func fieldTypesOf(memDB memdb.MemoryDatabase, req *models.RawQueryRequest, seriesCount int) (map[string]field.Type, error) {
+	if seriesCount == 0 {
+		return nil, nil
+	}
+	types, err := memDB.FieldTypes(req.MetricName, req.Fields)
+	if err != nil && !errors.Is(err, errors.ErrMetricNotFound) {
+		return nil, err
+	}
+	return types, nil
+}
+
+// stringValuesOf returns the last values of the string fields of request by the tags of series,
+// returns nil if no string fields
+func stringValuesOf(memDB memdb.MemoryDatabase, req *models.RawQueryRequest) (map[string]map[string]string, error) {
+	if len(req.StringFields) == 0 {
+		return nil, nil
+	}
+	values, err := memDB.StringValues(req.MetricName, req.StringFields)
+	if err != nil {
+		if errors.Is(err, errors.ErrMetricNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	result := make(map[string]map[string]string)
+	for _, value := range values {
+		fields, ok := result[value.Tags]
+		if !ok {
+			fields = make(map[string]string)
+			result[value.Tags] = fields
+		}
+		fields[value.Field] = value.Value
+	}
+	return result, nil
+}
+
+// rawQueryMaxSeries returns the max series of raw query
+func rawQueryMaxSeries(req *models.RawQueryRequest) int {
+	if req.MaxSeries <= 0 {
+		return models.DefaultRawQuerySeries
+	}
+	return req.MaxSeries
+}
+
+// truncateRawQueryResult keeps the series of result within the max series and max points,
+// the series which makes the points exceed is dropped, returns true if truncated.
+func truncateRawQueryResult(result *models.RawQueryResult, maxSeries int) bool {
+	points := 0
+	for idx := range result.Series {
+		points += result.Series[idx].PointCount()
+		if idx >= maxSeries || points > models.MaxRawQueryPoints {
+			result.Series = result.Series[:idx]
+			return true
+		}
+	}
+	return false
+}
+
+// validateRawQueryRequest checks if raw query request is valid, the time range and series are guarded
+func validateRawQueryRequest(req *models.RawQueryRequest) error {
+	if req == nil {
+		return fmt.Errorf("raw query request cannot be nil")
+	}
+	if len(req.Database) == 0 {
+		return fmt.Errorf("database name cannot be empty")
+	}
+	if len(req.MetricName) == 0 {
+		return fmt.Errorf("metric name cannot be empty")
+	}
+	if len(req.Fields) == 0 {
+		return fmt.Errorf("fields cannot be empty")
+	}
+	if req.Start > req.End {
+		return fmt.Errorf("start time[%d] is after end time[%d]", req.Start, req.End)
+	}
+	if req.End-req.Start > models.MaxRawQueryTimeRange {
+		return fmt.Errorf("time range of raw query cannot exceed %dms", models.MaxRawQueryTimeRange)
+	}
+	if req.MaxSeries < 0 || req.MaxSeries > models.MaxRawQuerySeries {
+		return fmt.Errorf("max series of raw query should be in [0, %d]", models.MaxRawQuerySeries)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/eleme/lindb/broker/rpc"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/tsdb/index"
+)
+
+// brokerRawQueryService implements RawQueryService interface for broker,
+// sends the request to all replicas of database's shards, so that the points of replicas can be compared.
+// The storage node queries the requested shards which it owns, so the shards of request needn't be resolved.
+type brokerRawQueryService struct {
+	routingCache    RoutingCache
+	circuitBreakers rpc.CircuitBreakers
+	newClient       adminClientFactory
+}
+
+// NewBrokerRawQueryService creates raw query service for broker
+func NewBrokerRawQueryService(routingCache RoutingCache, circuitBreakers rpc.CircuitBreakers,
+	connPool rpc.ConnPool) RawQueryService {
+	return &brokerRawQueryService{
+		routingCache:    routingCache,
+		circuitBreakers: circuitBreakers,
+		newClient: func(node models.Node) rpc.AdminClient {
+			return rpc.NewPooledAdminClient(connPool, fmt.Sprintf("%s:%d", node.IP, node.Port))
+		},
+	}
+}
+
+// Query returns the raw points of all replicas of database's shards, the series are sorted by shard id,
+// the series of replicas are merged if the request requires, the result is truncated if the series or
+// points exceed the limits, returns error if any storage node fails.
+func (s *brokerRawQueryService) Query(ctx context.Context, req *models.RawQueryRequest) (*models.RawQueryResult, error) {
+	if err := validateRawQueryRequest(req); err != nil {
+		return nil, err
+	}
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
+	shards, err := collectDatabaseShards(s.routingCache, req.Database)
+	if err != nil {
+		return nil, err
+	}
+	nodes := allReplicas(shards)
+	if len(nodes) == 0 {
+		return nil, errors.Wrapf(errors.ErrDatabaseNotFound, "no storage nodes of database: %s", req.Database)
+	}
+	result := &models.RawQueryResult{}
+	for _, node := range nodes {
+		nodeResult, err := s.query(ctx, node, req)
+		if err != nil {
+			return nil, err
+		}
+		for idx := range nodeResult.Series {
+			nodeResult.Series[idx].Node = node.String()
+		}
+		result.Series = append(result.Series, nodeResult.Series...)
+		result.Truncated = result.Truncated || nodeResult.Truncated
+	}
+	if req.Merge {
+		result.Series = mergeReplicas(result.Series)
+	}
+	sort.SliceStable(result.Series, func(i, j int) bool {
+		return result.Series[i].ShardID < result.Series[j].ShardID
+	})
+	if truncateRawQueryResult(result, rawQueryMaxSeries(req)) {
+		result.Truncated = true
+	}
+	return result, nil
+}
+
+// query sends the raw query request to the storage node
+func (s *brokerRawQueryService) query(ctx context.Context, node models.Node,
+	req *models.RawQueryRequest) (*models.RawQueryResult, error) {
+	client := s.newClient(node)
+	if err := client.Init(); err != nil {
+		s.circuitBreakers.Failure(node.Key())
+		return nil, fmt.Errorf("connect storage node[%s:%d] error:%s", node.IP, node.Port, err)
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	result, err := client.QueryRawPoints(ctx, req)
+	if rpc.IsNodeFailure(err) {
+		s.circuitBreakers.Failure(node.Key())
+	} else {
+		s.circuitBreakers.Success(node.Key())
+	}
+	return result, err
+}
+
+// mergeReplicas merges the series of the replicas of shard into one series without node, the duplicate points
+// of replicas are merged by the policy of field type(see field.MergePolicyOf), so that the replica lagging
+// behind never overrides the newer points and the counters aren't summed twice.
+func mergeReplicas(series []models.RawSeries) []models.RawSeries {
+	type seriesKey struct {
+		shardID int
+		tags    string
+	}
+	var keys []seriesKey
+	replicas := make(map[seriesKey][]models.RawSeries)
+	for _, item := range series {
+		key := seriesKey{shardID: item.ShardID, tags: index.MapToString(item.Tags)}
+		if _, ok := replicas[key]; !ok {
+			keys = append(keys, key)
+		}
+		replicas[key] = append(replicas[key], item)
+	}
+	result := make([]models.RawSeries, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, mergeSeries(replicas[key]))
+	}
+	return result
+}
+
+// mergeSeries merges the series of replicas in order, the strings of the replica with greatest sequence are kept
+func mergeSeries(replicas []models.RawSeries) models.RawSeries {
+	merged := replicas[0]
+	merged.Node = ""
+	merged.Fields = make(map[string][]models.RawPoint)
+	merged.FieldTypes = nil
+	sources := make(map[string][]field.PointIterator)
+	var fieldNames []string
+	for _, replica := range replicas {
+		if replica.Sequence > merged.Sequence {
+			merged.Sequence = replica.Sequence
+			merged.Strings = replica.Strings
+		}
+		for fieldName, rawPoints := range replica.Fields {
+			if _, ok := sources[fieldName]; !ok {
+				fieldNames = append(fieldNames, fieldName)
+			}
+			points := make([]field.Point, len(rawPoints))
+			for idx, point := range rawPoints {
+				points[idx] = field.Point{Timestamp: point.Timestamp, Value: point.Value, Sequence: replica.Sequence}
+			}
+			sources[fieldName] = append(sources[fieldName], field.NewPointsIterator(points))
+		}
+		for fieldName, fieldType := range replica.FieldTypes {
+			if merged.FieldTypes == nil {
+				merged.FieldTypes = make(map[string]field.Type)
+			}
+			merged.FieldTypes[fieldName] = fieldType
+		}
+	}
+	for _, fieldName := range fieldNames {
+		rawPoints := []models.RawPoint{}
+		it := field.NewMergedIterator(field.MergePolicyOf(merged.FieldTypes[fieldName]), sources[fieldName]...)
+		for it.Next() {
+			point := it.Point()
+			rawPoints = append(rawPoints, models.RawPoint{Timestamp: point.Timestamp, Value: point.Value})
+		}
+		merged.Fields[fieldName] = rawPoints
+	}
+	return merged
+}
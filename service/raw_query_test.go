@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/broker/rpc"
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/pkg/interval"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/pkg/util"
+)
+
+func TestRawQueryService_Query(t *testing.T) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+
+	storageService := NewStorageService(config.Engine{Path: testPath})
+	recorder := NewDatabaseStatsRecorder()
+	srv := NewRawQueryService(storageService, recorder)
+
+	now := timeutil.Now()
+	now -= now % (10 * 1000)
+	req := &models.RawQueryRequest{Database: "raw_query_db", MetricName: "cpu", Fields: []string{"f1"},
+		Start: now - 10*1000, End: now}
+	// database not exist
+	result, err := srv.Query(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Equal(t, &models.RawQueryResult{}, result)
+
+	option := validOption
+	option.TimeWindow = 32
+	assert.Nil(t, storageService.CreateShards("raw_query_db", option, 1, 2))
+	// metric not exist
+	result, err = srv.Query(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Empty(t, result.Series)
+
+	write := func(shardID int, host string, timestamp int64, value int64) {
+		point := models.NewPoint("cpu", timestamp, map[string]string{"host": host},
+			map[string]models.Field{"f1": models.NewSimpleField(field.SumField, field.Integer, value)})
+		assert.Nil(t, storageService.GetShard("raw_query_db", shardID).MemoryDatabase().Write(point))
+	}
+	write(1, "1.1.1.1", now-10*1000, 1)
+	write(1, "1.1.1.1", now, 2)
+	write(2, "2.2.2.2", now, 3)
+	// the point out of time range
+	write(2, "2.2.2.2", now-20*1000, 4)
+
+	req.ShardIDs = []int{1, 2, 3}
+	result, err = srv.Query(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Equal(t, &models.RawQueryResult{Series: []models.RawSeries{
+		{ShardID: 1, Tags: map[string]string{"host": "1.1.1.1"},
+			Fields:     map[string][]models.RawPoint{"f1": {{Timestamp: now - 10*1000, Value: 1}, {Timestamp: now, Value: 2}}},
+			FieldTypes: map[string]field.Type{"f1": field.SumField}},
+		{ShardID: 2, Tags: map[string]string{"host": "2.2.2.2"},
+			Fields:     map[string][]models.RawPoint{"f1": {{Timestamp: now, Value: 3}}},
+			FieldTypes: map[string]field.Type{"f1": field.SumField}},
+	}}, result)
+	// the points scanned are recorded into database statistics
+	assert.Equal(t, []models.DatabaseStats{{Database: "raw_query_db", ScanBytes: 3 * models.PointBytes}},
+		recorder.Snapshot())
+
+	// the shards without the memory-database of rollup interval are skipped
+	req.IntervalType = interval.Month
+	result, err = srv.Query(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Empty(t, result.Series)
+	req.IntervalType = 0
+
+	// max series exceeded
+	req.MaxSeries = 1
+	result, err = srv.Query(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.True(t, result.Truncated)
+	assert.Len(t, result.Series, 1)
+	assert.Equal(t, 1, result.Series[0].ShardID)
+
+	// the last values of string fields are attached to the series as dimensions
+	req.MaxSeries = 0
+	req.StringFields = []string{"version"}
+	writeVersion := func(timestamp int64, version string) {
+		point := models.NewPoint("cpu", timestamp, map[string]string{"host": "1.1.1.1"},
+			map[string]models.Field{"version": models.NewStringField(version)})
+		assert.Nil(t, storageService.GetShard("raw_query_db", 1).MemoryDatabase().Write(point))
+	}
+	writeVersion(now, "v2")
+	writeVersion(now-1000, "v1")
+	result, err = srv.Query(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Len(t, result.Series, 2)
+	assert.Equal(t, map[string]string{"version": "v2"}, result.Series[0].Strings)
+	assert.Empty(t, result.Series[1].Strings)
+	req.StringFields = nil
+
+	// invalid request
+	_, err = srv.Query(context.TODO(), &models.RawQueryRequest{Database: "raw_query_db"})
+	assert.NotNil(t, err)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	_, err = srv.Query(ctx, req)
+	assert.NotNil(t, err)
+	_ = storageService.GetEngine("raw_query_db").Close()
+}
+
+func TestMergeReplicas(t *testing.T) {
+	tags := map[string]string{"host": "1.1.1.1"}
+	series := []models.RawSeries{
+		{Node: "n1", ShardID: 1, Tags: tags, Sequence: 10, Strings: map[string]string{"version": "v1"},
+			Fields: map[string][]models.RawPoint{
+				"f1": {{Timestamp: 10, Value: 1}, {Timestamp: 20, Value: 2}},
+				"f2": {{Timestamp: 10, Value: 5}},
+			}},
+		{Node: "n1", ShardID: 2, Tags: tags, Sequence: 5,
+			Fields: map[string][]models.RawPoint{"f1": {{Timestamp: 10, Value: 7}}}},
+		{Node: "n2", ShardID: 1, Tags: tags, Sequence: 12, Strings: map[string]string{"version": "v2"},
+			Fields: map[string][]models.RawPoint{"f1": {{Timestamp: 20, Value: 3}, {Timestamp: 30, Value: 4}}}},
+		{Node: "n3", ShardID: 1, Tags: tags, Sequence: 12,
+			Fields: map[string][]models.RawPoint{"f1": {{Timestamp: 20, Value: 6}}}},
+		// the max field is merged by max value
+		{Node: "n1", ShardID: 3, Tags: tags, Sequence: 10, FieldTypes: map[string]field.Type{"f3": field.MaxField},
+			Fields: map[string][]models.RawPoint{"f3": {{Timestamp: 10, Value: 9}}}},
+		{Node: "n2", ShardID: 3, Tags: tags, Sequence: 12, FieldTypes: map[string]field.Type{"f3": field.MaxField},
+			Fields: map[string][]models.RawPoint{"f3": {{Timestamp: 10, Value: 8}}}},
+	}
+	// the duplicate points are last-write-wins by the sequence of replica, never summed
+	assert.Equal(t, []models.RawSeries{
+		{ShardID: 1, Tags: tags, Sequence: 12, Strings: map[string]string{"version": "v2"},
+			Fields: map[string][]models.RawPoint{
+				"f1": {{Timestamp: 10, Value: 1}, {Timestamp: 20, Value: 3}, {Timestamp: 30, Value: 4}},
+				"f2": {{Timestamp: 10, Value: 5}},
+			}},
+		{ShardID: 2, Tags: tags, Sequence: 5,
+			Fields: map[string][]models.RawPoint{"f1": {{Timestamp: 10, Value: 7}}}},
+		{ShardID: 3, Tags: tags, Sequence: 12, FieldTypes: map[string]field.Type{"f3": field.MaxField},
+			Fields: map[string][]models.RawPoint{"f3": {{Timestamp: 10, Value: 9}}}},
+	}, mergeReplicas(series))
+	assert.Empty(t, mergeReplicas(nil))
+}
+
+func TestTruncateRawQueryResult(t *testing.T) {
+	newSeries := func(shardID, points int) models.RawSeries {
+		return models.RawSeries{ShardID: shardID, Fields: map[string][]models.RawPoint{"f1": make([]models.RawPoint, points)}}
+	}
+	result := &models.RawQueryResult{Series: []models.RawSeries{newSeries(1, 10), newSeries(2, 10)}}
+	assert.False(t, truncateRawQueryResult(result, 2))
+	assert.Len(t, result.Series, 2)
+	assert.True(t, truncateRawQueryResult(result, 1))
+	assert.Equal(t, []models.RawSeries{newSeries(1, 10)}, result.Series)
+
+	// the series which makes the points exceed is dropped
+	result = &models.RawQueryResult{Series: []models.RawSeries{
+		newSeries(1, models.MaxRawQueryPoints-1), newSeries(2, 2)}}
+	assert.True(t, truncateRawQueryResult(result, 10))
+	assert.Len(t, result.Series, 1)
+	assert.Equal(t, models.MaxRawQueryPoints-1, result.PointCount())
+}
+
+func TestValidateRawQueryRequest(t *testing.T) {
+	assert.NotNil(t, validateRawQueryRequest(nil))
+	assert.NotNil(t, validateRawQueryRequest(&models.RawQueryRequest{MetricName: "cpu", Fields: []string{"f1"}}))
+	assert.NotNil(t, validateRawQueryRequest(&models.RawQueryRequest{Database: "db", Fields: []string{"f1"}}))
+	assert.NotNil(t, validateRawQueryRequest(&models.RawQueryRequest{Database: "db", MetricName: "cpu"}))
+	req := &models.RawQueryRequest{Database: "db", MetricName: "cpu", Fields: []string{"f1"}, Start: 10, End: 0}
+	assert.NotNil(t, validateRawQueryRequest(req))
+	req.End = req.Start + models.MaxRawQueryTimeRange + 1
+	assert.NotNil(t, validateRawQueryRequest(req))
+	req.End = req.Start + models.MaxRawQueryTimeRange
+	assert.Nil(t, validateRawQueryRequest(req))
+	req.MaxSeries = models.MaxRawQuerySeries + 1
+	assert.NotNil(t, validateRawQueryRequest(req))
+	req.MaxSeries = -1
+	assert.NotNil(t, validateRawQueryRequest(req))
+	req.MaxSeries = models.MaxRawQuerySeries
+	assert.Nil(t, validateRawQueryRequest(req))
+}
+
+func TestBrokerRawQueryService_Query(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	routingCache := NewMockRoutingCache(ctrl)
+	srv := NewBrokerRawQueryService(routingCache, rpc.NewCircuitBreakers(config.CircuitBreaker{}), rpc.NewConnPool())
+
+	req := &models.RawQueryRequest{Database: "raw_query_db", MetricName: "cpu", Fields: []string{"f1"}}
+	var (
+		nodes   []models.Node
+		initErr error
+	)
+	srv.(*brokerRawQueryService).newClient = func(node models.Node) rpc.AdminClient {
+		nodes = append(nodes, node)
+		client := rpc.NewMockAdminClient(ctrl)
+		client.EXPECT().Init().Return(initErr)
+		client.EXPECT().Close().Return(nil).AnyTimes()
+		if initErr == nil {
+			client.EXPECT().QueryRawPoints(gomock.Any(), req).Return(&models.RawQueryResult{Series: []models.RawSeries{
+				{ShardID: int(node.Port) - 2000, Fields: map[string][]models.RawPoint{"f1": {{Timestamp: 10, Value: 1}}}},
+			}}, nil)
+		}
+		return client
+	}
+	// invalid request
+	_, err := srv.Query(context.TODO(), &models.RawQueryRequest{Database: "raw_query_db"})
+	assert.NotNil(t, err)
+	// database not exist
+	routingCache.EXPECT().Shards("raw_query_db").Return(nil, false)
+	_, err = srv.Query(context.TODO(), req)
+	assert.NotNil(t, err)
+
+	// shard assignment not exist, no nodes to send
+	routingCache.EXPECT().Shards("raw_query_db").Return(nil, true)
+	_, err = srv.Query(context.TODO(), req)
+	assert.NotNil(t, err)
+
+	shardAssign := models.NewShardAssignment()
+	shardAssign.Nodes[1] = models.Node{IP: "127.0.0.1", Port: 2002}
+	shardAssign.Nodes[2] = models.Node{IP: "127.0.0.1", Port: 2001}
+	shardAssign.AddReplica(1, 1)
+	shardAssign.AddReplica(1, 2)
+	shardAssign.AddReplica(2, 2)
+	routingCache.EXPECT().Shards("raw_query_db").Return(assignedShards(shardAssign), true).AnyTimes()
+
+	// request is sent to all replicas, the series are sorted by shard id
+	points := map[string][]models.RawPoint{"f1": {{Timestamp: 10, Value: 1}}}
+	result, err := srv.Query(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Len(t, nodes, 2)
+	assert.Equal(t, &models.RawQueryResult{Series: []models.RawSeries{
+		{Node: "127.0.0.1:2001", ShardID: 1, Fields: points},
+		{Node: "127.0.0.1:2002", ShardID: 2, Fields: points},
+	}}, result)
+
+	// the series of replicas are merged without node
+	req.Merge = true
+	result, err = srv.Query(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Equal(t, &models.RawQueryResult{Series: []models.RawSeries{
+		{ShardID: 1, Fields: points},
+		{ShardID: 2, Fields: points},
+	}}, result)
+	req.Merge = false
+
+	// the series of all replicas are truncated by max series
+	req.MaxSeries = 1
+	result, err = srv.Query(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Equal(t, &models.RawQueryResult{Series: []models.RawSeries{
+		{Node: "127.0.0.1:2001", ShardID: 1, Fields: points},
+	}, Truncated: true}, result)
+
+	initErr = fmt.Errorf("err")
+	_, err = srv.Query(context.TODO(), req)
+	assert.NotNil(t, err)
+}
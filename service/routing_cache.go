@@ -0,0 +1,461 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/pathutil"
+	"github.com/eleme/lindb/pkg/state"
+)
+
+//go:generate mockgen -source ./routing_cache.go -destination=./routing_cache_mock.go -package service
+
+// RoutingCache represents the local cache of the shard routing of databases in all storage clusters,
+// the shard assignments and active nodes are watched from the state repo of storage clusters and persisted
+// as snapshot file after changed. The broker loads the snapshot at startup, then routes the requests by it
+// while the watches are re-established, so that the requests aren't rejected if etcd is slow.
+type RoutingCache interface {
+	// Load loads the routing of snapshot file, the routing of each storage cluster is replaced
+	// when the watch of storage cluster is established.
+	Load() error
+	// Watch watches the storage clusters in broker's state repo and the routing of them until the context is done
+	Watch(ctx context.Context, repo state.Repository)
+	// Refresh reloads the shard assignment of database from the state repo of watched storage cluster,
+	// so that the write rejected by stale routing epoch is re-routed without waiting for the watch event
+	Refresh(ctx context.Context, clusterName, databaseName string) error
+	// Shards returns the replica nodes of each shard of database, returns false if database isn't cached
+	Shards(databaseName string) ([][]models.Node, bool)
+	// ShardAssignments returns the shard assignments of database in storage clusters, the nodes of assignments
+	// are resolved by the active nodes, returns false if database isn't cached
+	ShardAssignments(databaseName string) ([]*models.ShardAssignment, bool)
+}
+
+// routingSnapshot represents the routing of all storage clusters, which is persisted as json
+type routingSnapshot struct {
+	Clusters map[string]*clusterRouting `json:"clusters"`
+}
+
+// clusterRouting represents the active nodes and the shard assignments of databases in storage cluster
+type clusterRouting struct {
+	// ActiveNodes are the active nodes keyed by the key of state repo
+	ActiveNodes map[string]models.Node      `json:"activeNodes"`
+	Databases   map[string]*databaseRouting `json:"databases"`
+}
+
+// databaseRouting represents the shard assignment of database with the revision of state repo,
+// the revision is used to validate the cached assignment when the watch is established.
+type databaseRouting struct {
+	Revision        int64                   `json:"revision"`
+	ShardAssignment *models.ShardAssignment `json:"shardAssignment"`
+}
+
+// newClusterRouting creates an empty routing of storage cluster
+func newClusterRouting() *clusterRouting {
+	return &clusterRouting{
+		ActiveNodes: make(map[string]models.Node),
+		Databases:   make(map[string]*databaseRouting),
+	}
+}
+
+// routingCache implements RoutingCache interface
+type routingCache struct {
+	path     string
+	snapshot routingSnapshot
+	mutex    sync.RWMutex
+	// persistLock makes the snapshot persisted one by one
+	persistLock sync.Mutex
+	// newRepo creates the state repo of storage cluster
+	newRepo func(cfg state.Config) (state.Repository, error)
+	// repos are the state repos of watched storage clusters keyed by cluster name, guarded by mutex
+	repos map[string]state.Repository
+
+	logger *logger.Logger
+}
+
+// NewRoutingCache creates the routing cache which persists the snapshot into the file of path,
+// empty path means the routing isn't persisted.
+func NewRoutingCache(path string) RoutingCache {
+	return &routingCache{
+		path:     path,
+		snapshot: routingSnapshot{Clusters: make(map[string]*clusterRouting)},
+		newRepo:  state.NewRepo,
+		repos:    make(map[string]state.Repository),
+		logger:   logger.GetLogger("service/routing/cache"),
+	}
+}
+
+// Load loads the routing of snapshot file, it's ok if the snapshot file not exist
+func (c *routingCache) Load() error {
+	if len(c.path) == 0 {
+		return nil
+	}
+	data, err := ioutil.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	snapshot := routingSnapshot{}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+	if snapshot.Clusters == nil {
+		snapshot.Clusters = make(map[string]*clusterRouting)
+	}
+	for _, cluster := range snapshot.Clusters {
+		if cluster.ActiveNodes == nil {
+			cluster.ActiveNodes = make(map[string]models.Node)
+		}
+		if cluster.Databases == nil {
+			cluster.Databases = make(map[string]*databaseRouting)
+		}
+	}
+	c.mutex.Lock()
+	c.snapshot = snapshot
+	c.mutex.Unlock()
+	c.logger.Info("load routing snapshot successfully",
+		logger.String("path", c.path), logger.Any("clusters", len(snapshot.Clusters)))
+	return nil
+}
+
+// Shards returns the replica nodes of each shard of database in all storage clusters which are sorted by name
+func (c *routingCache) Shards(databaseName string) ([][]models.Node, bool) {
+	shardAssigns, ok := c.ShardAssignments(databaseName)
+	if !ok {
+		return nil, false
+	}
+	var shards [][]models.Node
+	for _, shardAssign := range shardAssigns {
+		shards = append(shards, assignedShards(shardAssign)...)
+	}
+	return shards, true
+}
+
+// ShardAssignments returns the copies of the shard assignments of database in all storage clusters
+// which are sorted by cluster name, the addresses of assigned nodes are resolved by the active nodes of storage cluster.
+func (c *routingCache) ShardAssignments(databaseName string) ([]*models.ShardAssignment, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	var clusterNames []string
+	for name, cluster := range c.snapshot.Clusters {
+		if _, ok := cluster.Databases[databaseName]; ok {
+			clusterNames = append(clusterNames, name)
+		}
+	}
+	if len(clusterNames) == 0 {
+		return nil, false
+	}
+	sort.Strings(clusterNames)
+	var shardAssigns []*models.ShardAssignment
+	for _, name := range clusterNames {
+		cluster := c.snapshot.Clusters[name]
+		shardAssign := *cluster.Databases[databaseName].ShardAssignment
+		// copies the nodes, because resolving nodes changes the addresses of assignment
+		shardAssign.Nodes = make(map[int]models.Node, len(shardAssign.Nodes))
+		for nodeID, node := range cluster.Databases[databaseName].ShardAssignment.Nodes {
+			shardAssign.Nodes[nodeID] = node
+		}
+		activeNodes := make([]models.Node, 0, len(cluster.ActiveNodes))
+		for _, node := range cluster.ActiveNodes {
+			activeNodes = append(activeNodes, node)
+		}
+		shardAssign.ResolveNodes(activeNodes)
+		shardAssigns = append(shardAssigns, &shardAssign)
+	}
+	return shardAssigns, true
+}
+
+// Watch watches the storage cluster configs, the routing of each storage cluster is watched in the state repo
+// of storage cluster, the routing of storage cluster is removed after the storage cluster is deleted.
+func (c *routingCache) Watch(ctx context.Context, repo state.Repository) {
+	watching := make(map[string]context.CancelFunc)
+	defer func() {
+		for _, cancel := range watching {
+			cancel()
+		}
+	}()
+	startWatch := func(cluster models.StorageCluster) {
+		if cancel, ok := watching[cluster.Name]; ok {
+			cancel()
+		}
+		clusterCtx, cancel := context.WithCancel(ctx)
+		watching[cluster.Name] = cancel
+		go c.watchCluster(clusterCtx, cluster)
+	}
+	eventCh := repo.WatchPrefix(ctx, pathutil.Keys.Cluster.StorageClusters.Prefix())
+	for event := range eventCh {
+		if event.Err != nil {
+			continue
+		}
+		switch event.Type {
+		case state.EventTypeAll:
+			clusters := make(map[string]models.StorageCluster)
+			for _, kv := range event.KeyValues {
+				if cluster, ok := c.parseStorageCluster(kv); ok {
+					clusters[cluster.Name] = cluster
+				}
+			}
+			for name, cancel := range watching {
+				if _, ok := clusters[name]; !ok {
+					cancel()
+					delete(watching, name)
+				}
+			}
+			c.retainClusters(clusters)
+			for _, cluster := range clusters {
+				startWatch(cluster)
+			}
+		case state.EventTypeModify:
+			for _, kv := range event.KeyValues {
+				if cluster, ok := c.parseStorageCluster(kv); ok {
+					startWatch(cluster)
+				}
+			}
+		case state.EventTypeDelete:
+			for _, kv := range event.KeyValues {
+				name := pathutil.GetName(kv.Key)
+				if cancel, ok := watching[name]; ok {
+					cancel()
+					delete(watching, name)
+				}
+				c.removeCluster(name)
+			}
+		}
+	}
+}
+
+// parseStorageCluster parses the storage cluster config of watch event
+func (c *routingCache) parseStorageCluster(kv state.EventKeyValue) (models.StorageCluster, bool) {
+	cluster := models.StorageCluster{}
+	if err := json.Unmarshal(kv.Value, &cluster); err != nil || len(cluster.Name) == 0 {
+		c.logger.Error("unmarshal storage cluster config error",
+			logger.String("key", kv.Key), logger.Error(err))
+		return cluster, false
+	}
+	return cluster, true
+}
+
+// watchCluster watches the shard assignments and the active nodes in the state repo of storage cluster
+// until the context is done
+func (c *routingCache) watchCluster(ctx context.Context, cluster models.StorageCluster) {
+	repo, err := c.newRepo(cluster.Config)
+	if err != nil {
+		c.logger.Error("connect state repo of storage cluster error",
+			logger.String("cluster", cluster.Name), logger.Error(err))
+		return
+	}
+	c.mutex.Lock()
+	c.repos[cluster.Name] = repo
+	c.mutex.Unlock()
+	defer func() {
+		c.mutex.Lock()
+		if c.repos[cluster.Name] == repo {
+			delete(c.repos, cluster.Name)
+		}
+		c.mutex.Unlock()
+		if err := repo.Close(); err != nil {
+			c.logger.Error("close state repo of storage cluster error",
+				logger.String("cluster", cluster.Name), logger.Error(err))
+		}
+	}()
+	assignCh := repo.WatchPrefix(ctx, pathutil.Keys.Databases.Assignments.Prefix())
+	nodesCh := repo.WatchPrefix(ctx, pathutil.Keys.Nodes.Active.Prefix())
+	for {
+		select {
+		case event, ok := <-assignCh:
+			if !ok {
+				return
+			}
+			c.onShardAssignEvent(cluster.Name, event)
+		case event, ok := <-nodesCh:
+			if !ok {
+				return
+			}
+			c.onActiveNodesEvent(cluster.Name, event)
+		}
+	}
+}
+
+// onShardAssignEvent updates the shard assignments of storage cluster, the cached assignments are replaced
+// by the assignments in state repo after the watch is established, the stale ones are logged.
+func (c *routingCache) onShardAssignEvent(clusterName string, event *state.Event) {
+	if event.Err != nil {
+		return
+	}
+	c.mutex.Lock()
+	cluster := c.getCluster(clusterName)
+	switch event.Type {
+	case state.EventTypeAll:
+		databases := make(map[string]*databaseRouting)
+		stale := 0
+		for _, kv := range event.KeyValues {
+			routing, ok := c.parseShardAssignment(kv)
+			if !ok {
+				continue
+			}
+			name := pathutil.GetName(kv.Key)
+			if cached, ok := cluster.Databases[name]; !ok || cached.Revision != routing.Revision {
+				stale++
+			}
+			databases[name] = routing
+		}
+		for name := range cluster.Databases {
+			if _, ok := databases[name]; !ok {
+				stale++
+			}
+		}
+		cluster.Databases = databases
+		c.logger.Info("validate routing cache of storage cluster by state repo",
+			logger.String("cluster", clusterName), logger.Any("databases", len(databases)), logger.Any("stale", stale))
+	case state.EventTypeModify:
+		for _, kv := range event.KeyValues {
+			if routing, ok := c.parseShardAssignment(kv); ok {
+				cluster.Databases[pathutil.GetName(kv.Key)] = routing
+			}
+		}
+	case state.EventTypeDelete:
+		for _, kv := range event.KeyValues {
+			delete(cluster.Databases, pathutil.GetName(kv.Key))
+		}
+	}
+	c.mutex.Unlock()
+	c.persist()
+}
+
+// Refresh reads the shard assignment of database from the state repo of storage cluster, the cached assignment
+// is replaced if it's older than the one read, because the watch event may be applied before refreshing.
+func (c *routingCache) Refresh(ctx context.Context, clusterName, databaseName string) error {
+	c.mutex.RLock()
+	repo, ok := c.repos[clusterName]
+	c.mutex.RUnlock()
+	if !ok {
+		return fmt.Errorf("state repo of storage cluster[%s] isn't watched", clusterName)
+	}
+	key := pathutil.Keys.Databases.Assignments.Key(databaseName)
+	data, revision, err := repo.GetWithRevision(ctx, key)
+	if err != nil {
+		return fmt.Errorf("get shard assignment of database[%s] in storage cluster[%s] error:%s",
+			databaseName, clusterName, err)
+	}
+	routing, ok := c.parseShardAssignment(state.EventKeyValue{Key: key, Value: data, Rev: revision})
+	if !ok {
+		return fmt.Errorf("unmarshal shard assignment of database[%s] in storage cluster[%s] error",
+			databaseName, clusterName)
+	}
+	c.mutex.Lock()
+	cluster := c.getCluster(clusterName)
+	if cached, ok := cluster.Databases[databaseName]; !ok || cached.Revision < routing.Revision {
+		cluster.Databases[databaseName] = routing
+	}
+	c.mutex.Unlock()
+	c.persist()
+	return nil
+}
+
+// parseShardAssignment parses the shard assignment of watch event
+func (c *routingCache) parseShardAssignment(kv state.EventKeyValue) (*databaseRouting, bool) {
+	shardAssign := &models.ShardAssignment{}
+	if err := json.Unmarshal(kv.Value, shardAssign); err != nil {
+		c.logger.Error("unmarshal shard assignment error",
+			logger.String("key", kv.Key), logger.Error(err))
+		return nil, false
+	}
+	return &databaseRouting{Revision: kv.Rev, ShardAssignment: shardAssign}, true
+}
+
+// onActiveNodesEvent updates the active nodes of storage cluster
+func (c *routingCache) onActiveNodesEvent(clusterName string, event *state.Event) {
+	if event.Err != nil {
+		return
+	}
+	c.mutex.Lock()
+	cluster := c.getCluster(clusterName)
+	switch event.Type {
+	case state.EventTypeAll:
+		cluster.ActiveNodes = make(map[string]models.Node)
+		fallthrough
+	case state.EventTypeModify:
+		for _, kv := range event.KeyValues {
+			node := models.Node{}
+			if err := json.Unmarshal(kv.Value, &node); err != nil {
+				c.logger.Error("unmarshal active node error",
+					logger.String("key", kv.Key), logger.Error(err))
+				continue
+			}
+			cluster.ActiveNodes[kv.Key] = node
+		}
+	case state.EventTypeDelete:
+		for _, kv := range event.KeyValues {
+			delete(cluster.ActiveNodes, kv.Key)
+		}
+	}
+	c.mutex.Unlock()
+	c.persist()
+}
+
+// getCluster returns the routing of storage cluster, creates it if not exist, must be called with lock
+func (c *routingCache) getCluster(clusterName string) *clusterRouting {
+	cluster, ok := c.snapshot.Clusters[clusterName]
+	if !ok {
+		cluster = newClusterRouting()
+		c.snapshot.Clusters[clusterName] = cluster
+	}
+	return cluster
+}
+
+// retainClusters removes the routing of storage clusters which are not retained
+func (c *routingCache) retainClusters(retained map[string]models.StorageCluster) {
+	c.mutex.Lock()
+	for name := range c.snapshot.Clusters {
+		if _, ok := retained[name]; !ok {
+			delete(c.snapshot.Clusters, name)
+		}
+	}
+	c.mutex.Unlock()
+	c.persist()
+}
+
+// removeCluster removes the routing of storage cluster
+func (c *routingCache) removeCluster(clusterName string) {
+	c.mutex.Lock()
+	delete(c.snapshot.Clusters, clusterName)
+	c.mutex.Unlock()
+	c.persist()
+}
+
+// persist writes the snapshot into a temp file then renames it, so that the snapshot file is never partial
+func (c *routingCache) persist() {
+	if len(c.path) == 0 {
+		return
+	}
+	c.persistLock.Lock()
+	defer c.persistLock.Unlock()
+	c.mutex.RLock()
+	data, err := json.Marshal(&c.snapshot)
+	c.mutex.RUnlock()
+	if err != nil {
+		c.logger.Error("marshal routing snapshot error", logger.Error(err))
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), os.ModePerm); err != nil {
+		c.logger.Error("create routing snapshot dir error", logger.String("path", c.path), logger.Error(err))
+		return
+	}
+	tmp := c.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		c.logger.Error("write routing snapshot error", logger.String("path", tmp), logger.Error(err))
+		return
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		c.logger.Error("rename routing snapshot error", logger.String("path", c.path), logger.Error(err))
+	}
+}
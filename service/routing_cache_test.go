@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/check.v1"
+
+	"github.com/eleme/lindb/constants"
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/pathutil"
+	"github.com/eleme/lindb/pkg/state"
+	"github.com/eleme/lindb/pkg/util"
+)
+
+func TestRoutingCache_Load(t *testing.T) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	path := filepath.Join(testPath, "routing.json")
+	// snapshot not exist
+	cache := NewRoutingCache(path)
+	assert.Nil(t, cache.Load())
+	_, ok := cache.Shards("db")
+	assert.False(t, ok)
+	// routing isn't persisted
+	assert.Nil(t, NewRoutingCache("").Load())
+
+	// invalid snapshot
+	assert.Nil(t, util.MkDirIfNotExist(testPath))
+	assert.Nil(t, ioutil.WriteFile(path, []byte("invalid"), 0644))
+	assert.NotNil(t, cache.Load())
+
+	shardAssign := models.NewShardAssignment()
+	shardAssign.Nodes[1] = models.Node{ID: "node-1", IP: "127.0.0.1", Port: 2000}
+	shardAssign.Nodes[2] = models.Node{IP: "127.0.0.1", Port: 2001}
+	shardAssign.AddReplica(1, 1)
+	shardAssign.AddReplica(1, 2)
+	shardAssign.AddReplica(2, 2)
+	snapshot := routingSnapshot{Clusters: map[string]*clusterRouting{
+		"cluster2": {Databases: map[string]*databaseRouting{"db": {Revision: 1, ShardAssignment: shardAssign}}},
+		"cluster1": {
+			ActiveNodes: map[string]models.Node{"node-1": {ID: "node-1", IP: "127.0.0.2", Port: 2000}},
+			Databases:   map[string]*databaseRouting{"db": {Revision: 2, ShardAssignment: shardAssign}},
+		},
+	}}
+	data, _ := json.Marshal(&snapshot)
+	assert.Nil(t, ioutil.WriteFile(path, data, 0644))
+	assert.Nil(t, cache.Load())
+
+	// the shards of clusters are sorted by cluster name, the nodes are resolved by active nodes of cluster
+	shards, ok := cache.Shards("db")
+	assert.True(t, ok)
+	resolved := models.Node{ID: "node-1", IP: "127.0.0.2", Port: 2000}
+	assert.Equal(t, [][]models.Node{
+		{resolved, shardAssign.Nodes[2]}, {shardAssign.Nodes[2]},
+		{shardAssign.Nodes[1], shardAssign.Nodes[2]}, {shardAssign.Nodes[2]},
+	}, shards)
+	// the cached shards are served without requesting the state repo
+	cachedShards, err := collectDatabaseShards(cache, "db")
+	assert.Nil(t, err)
+	assert.Equal(t, shards, cachedShards)
+	// the cached assignment isn't changed
+	assert.Equal(t, "127.0.0.1", shardAssign.Nodes[1].IP)
+	_, ok = cache.Shards("other_db")
+	assert.False(t, ok)
+
+	// the shard assignments keep the shard ids
+	shardAssigns, ok := cache.ShardAssignments("db")
+	assert.True(t, ok)
+	assert.Len(t, shardAssigns, 2)
+	assert.Equal(t, resolved, shardAssigns[0].Nodes[1])
+	assert.Equal(t, shardAssign.Nodes[1], shardAssigns[1].Nodes[1])
+	assert.Equal(t, shardAssign.Shards, shardAssigns[0].Shards)
+	_, ok = cache.ShardAssignments("other_db")
+	assert.False(t, ok)
+}
+
+type testRoutingCacheSuite struct {
+	mock.RepoTestSuite
+}
+
+func TestRoutingCache(t *testing.T) {
+	check.Suite(&testRoutingCacheSuite{})
+	check.TestingT(t)
+}
+
+func (ts *testRoutingCacheSuite) TestWatch(c *check.C) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	cfg := state.Config{Endpoints: ts.Cluster.Endpoints}
+	repo, _ := state.NewRepo(cfg)
+	path := filepath.Join(testPath, "routing.json")
+
+	shardAssign := models.NewShardAssignment()
+	shardAssign.Nodes[1] = models.Node{ID: "node-1", IP: "127.0.0.1", Port: 2000}
+	shardAssign.AddReplica(1, 1)
+	_ = NewShardAssignService(repo).Save("routing_db", shardAssign)
+	activeNode, _ := json.Marshal(models.Node{ID: "node-1", IP: "127.0.0.2", Port: 2000})
+	_ = repo.Put(context.TODO(), pathutil.GetNodePath(constants.ActiveNodesPath, "node-1"), activeNode)
+
+	// the snapshot has the cluster which is deleted
+	staleCache := NewRoutingCache(path).(*routingCache)
+	staleCache.snapshot.Clusters["stale_cluster"] = &clusterRouting{
+		Databases: map[string]*databaseRouting{"stale_db": {ShardAssignment: shardAssign}}}
+	staleCache.persist()
+
+	cache := NewRoutingCache(path)
+	c.Assert(cache.Load(), check.IsNil)
+	_, ok := cache.Shards("stale_db")
+	c.Assert(ok, check.Equals, true)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	go cache.Watch(ctx, repo)
+	storageClusterService := NewStorageClusterService(repo)
+	_ = storageClusterService.Save(models.StorageCluster{Name: "routing_cluster", Config: cfg})
+
+	resolved := [][]models.Node{{{ID: "node-1", IP: "127.0.0.2", Port: 2000}}}
+	waitRouting := func(databaseName string, expected [][]models.Node) {
+		for i := 0; i < 100; i++ {
+			shards, _ := cache.Shards(databaseName)
+			if fmt.Sprint(shards) == fmt.Sprint(expected) {
+				return
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		c.Fatalf("routing of database[%s] isn't synced", databaseName)
+	}
+	waitRouting("routing_db", resolved)
+	waitRouting("stale_db", nil)
+
+	// the routing is persisted, which is served after restarting
+	restarted := NewRoutingCache(path)
+	c.Assert(restarted.Load(), check.IsNil)
+	shards, ok := restarted.Shards("routing_db")
+	c.Assert(ok, check.Equals, true)
+	c.Assert(shards, check.DeepEquals, resolved)
+
+	// the stale assignment is replaced by the one of state repo after refreshed
+	rc := cache.(*routingCache)
+	rc.mutex.Lock()
+	stale := *rc.snapshot.Clusters["routing_cluster"].Databases["routing_db"]
+	rc.snapshot.Clusters["routing_cluster"].Databases["routing_db"] = &databaseRouting{
+		ShardAssignment: models.NewShardAssignment()}
+	rc.mutex.Unlock()
+	waitRouting("routing_db", nil)
+	c.Assert(cache.Refresh(context.TODO(), "routing_cluster", "routing_db"), check.IsNil)
+	waitRouting("routing_db", resolved)
+	c.Assert(rc.snapshot.Clusters["routing_cluster"].Databases["routing_db"].Revision, check.Equals, stale.Revision)
+	c.Assert(cache.Refresh(context.TODO(), "routing_cluster", "not_exist"), check.NotNil)
+	c.Assert(cache.Refresh(context.TODO(), "not_exist", "routing_db"), check.NotNil)
+
+	// the address of node is changed
+	activeNode, _ = json.Marshal(models.Node{ID: "node-1", IP: "127.0.0.3", Port: 2000})
+	_ = repo.Put(context.TODO(), pathutil.GetNodePath(constants.ActiveNodesPath, "node-1"), activeNode)
+	waitRouting("routing_db", [][]models.Node{{{ID: "node-1", IP: "127.0.0.3", Port: 2000}}})
+	// the node is offline, uses the assigned address
+	_ = repo.Delete(context.TODO(), pathutil.GetNodePath(constants.ActiveNodesPath, "node-1"))
+	waitRouting("routing_db", [][]models.Node{{shardAssign.Nodes[1]}})
+
+	// the shard assignment is deleted
+	_ = repo.Delete(context.TODO(), pathutil.GetDatabaseAssignPath("routing_db"))
+	waitRouting("routing_db", nil)
+	_ = NewShardAssignService(repo).Save("routing_db", shardAssign)
+	waitRouting("routing_db", [][]models.Node{{shardAssign.Nodes[1]}})
+
+	// the storage cluster is deleted
+	_ = storageClusterService.Delete("routing_cluster")
+	waitRouting("routing_db", nil)
+}
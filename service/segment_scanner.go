@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+
+	"github.com/RoaringBitmap/roaring"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/encoding"
+	"github.com/eleme/lindb/query"
+	"github.com/eleme/lindb/tsdb"
+	"github.com/eleme/lindb/tsdb/index"
+	"github.com/eleme/lindb/tsdb/metrictbl"
+)
+
+// segmentScanner implements query.SegmentScanner, reads the metric-blocks of the metric in the families of segment,
+// the points of fields are down sampled into the time slots of query by the aggregator of field.
+// It's created for each query, the segments are scanned by the workers of query concurrently.
+type segmentScanner struct {
+	ctx      context.Context
+	metricID uint32
+	fields   map[uint32]*queryField // key is field id
+	tagsUID  tsdb.TagsUID
+}
+
+// newSegmentScanner creates the scanner of the fields of metric
+func newSegmentScanner(ctx context.Context, metricID uint32, fields []queryField,
+	tagsUID tsdb.TagsUID) query.SegmentScanner {
+	// only the fields found in index are stored in the families of segment
+	fieldMap := make(map[uint32]*queryField, len(fields))
+	for idx := range fields {
+		if fields[idx].fieldID != index.NotFoundFieldID {
+			fieldMap[fields[idx].fieldID] = &fields[idx]
+		}
+	}
+	return &segmentScanner{
+		ctx:      ctx,
+		metricID: metricID,
+		fields:   fieldMap,
+		tagsUID:  tagsUID,
+	}
+}
+
+// Scan reads the metric-blocks of the families which start before the end of query in time order,
+// the series are allocated from arena, the tags of series are resolved by the tags index after scanned.
+// Returns the scanned result with limit exceeded error if the points scanned exceed the limit.
+func (s *segmentScanner) Scan(segment tsdb.SegmentSnapshot, q models.Query, limiter *query.ResultLimiter,
+	arena *query.Arena) (*models.ResultSet, error) {
+	timeRange := q.TimeRange()
+	rs := &models.ResultSet{
+		StartTime: timeRange.Start,
+		Interval:  q.Interval().Nanoseconds() / 1e6,
+	}
+	if rs.Interval <= 0 {
+		rs.Interval = 1
+	}
+	rs.PointCount = int((timeRange.End-timeRange.Start)/rs.Interval) + 1
+	if len(s.fields) == 0 {
+		return rs, nil
+	}
+	fieldIDs := make(map[uint32]struct{}, len(s.fields))
+	projection := make([]uint32, 0, len(s.fields))
+	for fieldID, f := range s.fields {
+		fieldIDs[fieldID] = struct{}{}
+		projection = append(projection, fieldID)
+		rs.AddColumn(f.name, models.IntegerValue)
+	}
+	seriesIDs := roaring.New()
+	seriesMap := make(map[uint32]*models.Series)
+	var scanErr error
+	scanBlock := func(familyTime int64) func(block []byte) bool {
+		return func(block []byte) bool {
+			reader, err := metrictbl.NewBlockReader(block)
+			if err != nil {
+				scanErr = err
+				return true
+			}
+			var points int64
+			if err := reader.Scan(fieldIDs, func(tsID, fieldID uint32, data []byte) {
+				series, ok := seriesMap[tsID]
+				if !ok {
+					series = arena.NewSeries(nil)
+					series.Metric = q.MetricName()
+					seriesMap[tsID] = series
+					seriesIDs.Add(tsID)
+					rs.Series = append(rs.Series, series)
+				}
+				f := s.fields[fieldID]
+				values, ok := series.Fields[f.name]
+				if !ok {
+					values = arena.Values(rs.PointCount)
+					series.Fields[f.name] = values
+				}
+				n, err := s.decode(values, rs, familyTime, segment.Interval(), data, f)
+				if err != nil && scanErr == nil {
+					scanErr = err
+				}
+				points += n
+			}); err != nil {
+				scanErr = err
+				return true
+			}
+			if scanErr != nil {
+				return true
+			}
+			if err := limiter.AddPoints(points); err != nil {
+				scanErr = err
+				return true
+			}
+			// go on reading the metric-blocks of other files
+			return false
+		}
+	}
+scan:
+	for _, familyTime := range segment.FamilyTimes() {
+		if familyTime > timeRange.End {
+			break
+		}
+		// only the families of the queried fields are read(projection push down)
+		for _, family := range segment.GetFieldFamilies(familyTime, projection) {
+			if err := family.LookupWithContext(s.ctx, s.metricID, scanBlock(familyTime)); err != nil {
+				return nil, err
+			}
+			if scanErr != nil {
+				break scan
+			}
+		}
+	}
+	if len(rs.Series) > 0 {
+		seriesTags := s.tagsUID.GetSeriesTags(s.metricID, seriesIDs)
+		for tsID, series := range seriesMap {
+			series.Tags = index.StringToMap(seriesTags[tsID])
+		}
+	}
+	if scanErr != nil && !query.IsLimitExceeded(scanErr) {
+		return nil, scanErr
+	}
+	return rs, scanErr
+}
+
+// decode decodes the points of field which are encoded by TSD encoding, the slots of points are relative to
+// the family time, returns the count of points decoded.
+func (s *segmentScanner) decode(values []float64, rs *models.ResultSet, familyTime, interval int64,
+	data []byte, f *queryField) (int64, error) {
+	decoder := encoding.GetBlockDecoder()
+	defer encoding.PutBlockDecoder(decoder)
+	startSlot, slotValues, exists, err := decoder.DecodeTSD(data)
+	if err != nil {
+		return 0, err
+	}
+	var points int64
+	for i, value := range slotValues {
+		if !exists[i] {
+			continue
+		}
+		timestamp := familyTime + int64(startSlot+i)*interval
+		if timestamp > rs.StartTime+int64(rs.PointCount)*rs.Interval {
+			break
+		}
+		points++
+		downSample(values, rs.StartTime, rs.Interval, timestamp, float64(encoding.ZigZagDecode(value)), f.aggFunc)
+	}
+	return points, nil
+}
@@ -12,9 +12,18 @@ import (
 
 type ShardAssignService interface {
 	Get(databaseName string) (*models.ShardAssignment, error)
+	// GetWithRevision returns the shard assignment with the revision of it in repository
+	GetWithRevision(databaseName string) (*models.ShardAssignment, int64, error)
 	Save(databaseName string, shardAssign *models.ShardAssignment) error
+	// Publish saves the shard assignment and the key-value pairs(e.g. coordinator tasks) atomically,
+	// if the revision isn't AnyRevision, fails if the shard assignment is changed since the revision read,
+	// 0 means the shard assignment doesn't exist.
+	Publish(databaseName string, shardAssign *models.ShardAssignment, revision int64, kvs []state.KeyValue) error
 }
 
+// AnyRevision represents publishing shard assignment without comparing the revision
+const AnyRevision int64 = -1
+
 type shardAssignService struct {
 	repo state.Repository
 }
@@ -26,15 +35,20 @@ func NewShardAssignService(repo state.Repository) ShardAssignService {
 }
 
 func (s *shardAssignService) Get(databaseName string) (*models.ShardAssignment, error) {
-	data, err := s.repo.Get(context.TODO(), pathutil.GetDatabaseAssignPath(databaseName))
+	shardAssign, _, err := s.GetWithRevision(databaseName)
+	return shardAssign, err
+}
+
+func (s *shardAssignService) GetWithRevision(databaseName string) (*models.ShardAssignment, int64, error) {
+	data, revision, err := s.repo.GetWithRevision(context.TODO(), pathutil.GetDatabaseAssignPath(databaseName))
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	shardAssign := &models.ShardAssignment{}
 	if err := json.Unmarshal(data, shardAssign); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	return shardAssign, nil
+	return shardAssign, revision, nil
 }
 
 func (s *shardAssignService) Save(databaseName string, shardAssign *models.ShardAssignment) error {
@@ -44,3 +58,27 @@ func (s *shardAssignService) Save(databaseName string, shardAssign *models.Shard
 	}
 	return s.repo.Put(context.TODO(), pathutil.GetDatabaseAssignPath(databaseName), data)
 }
+
+func (s *shardAssignService) Publish(databaseName string, shardAssign *models.ShardAssignment,
+	revision int64, kvs []state.KeyValue) error {
+	data, err := json.Marshal(shardAssign)
+	if err != nil {
+		return fmt.Errorf("marshal shard assignment error:%s", err)
+	}
+	key := pathutil.GetDatabaseAssignPath(databaseName)
+	txn := state.Txn{Ops: []state.Op{state.OpPut(key, data)}}
+	if revision != AnyRevision {
+		txn.Cmps = append(txn.Cmps, state.CmpRevision(key, revision))
+	}
+	for _, kv := range kvs {
+		txn.Ops = append(txn.Ops, state.OpPut(kv.Key, kv.Value))
+	}
+	success, err := s.repo.Txn(context.TODO(), txn)
+	if err != nil {
+		return err
+	}
+	if !success {
+		return fmt.Errorf("shard assignment of database[%s] is changed by others", databaseName)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"testing"
 
 	"gopkg.in/check.v1"
@@ -47,3 +48,41 @@ func (ts *testShardAssignSRVSuite) TestShardAssign(c *check.C) {
 	_, err := srv.Get("not_exist")
 	c.Assert(state.ErrNotExist, check.Equals, err)
 }
+
+func (ts *testShardAssignSRVSuite) TestShardAssign_Publish(c *check.C) {
+	repo, _ := state.NewRepo(state.Config{
+		Namespace: "/test/publish",
+		Endpoints: ts.Cluster.Endpoints,
+	})
+	srv := NewShardAssignService(repo)
+
+	shardAssign := models.NewShardAssignment()
+	shardAssign.AddReplica(1, 1)
+	task := state.KeyValue{Key: "/task/1", Value: []byte("task1")}
+	err := srv.Publish("db", shardAssign, 0, []state.KeyValue{task})
+	c.Assert(err, check.IsNil)
+	shardAssign1, revision, err := srv.GetWithRevision("db")
+	c.Assert(err, check.IsNil)
+	c.Assert(*shardAssign1, check.DeepEquals, *shardAssign)
+	value, _ := repo.Get(context.TODO(), "/task/1")
+	c.Assert(string(value), check.Equals, "task1")
+
+	// already exist
+	err = srv.Publish("db", shardAssign, 0, nil)
+	c.Assert(err, check.NotNil)
+
+	shardAssign1.Epoch++
+	c.Assert(srv.Publish("db", shardAssign1, revision, nil), check.IsNil)
+	// changed since the revision read, the tasks aren't saved
+	err = srv.Publish("db", shardAssign1, revision, []state.KeyValue{{Key: "/task/2", Value: []byte("task2")}})
+	c.Assert(err, check.NotNil)
+	_, err = repo.Get(context.TODO(), "/task/2")
+	c.Assert(err, check.Equals, state.ErrNotExist)
+
+	c.Assert(srv.Publish("db", shardAssign, AnyRevision, nil), check.IsNil)
+	shardAssign2, _ := srv.Get("db")
+	c.Assert(shardAssign2.Epoch, check.Equals, int64(0))
+
+	_, _, err = srv.GetWithRevision("not_exist")
+	c.Assert(err, check.Equals, state.ErrNotExist)
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/pathutil"
+	"github.com/eleme/lindb/pkg/state"
+)
+
+// ShardStateService represents the states(hot/read-only/frozen) of database's shards, which are stored in
+// the state repository of the storage clusters of database and enforced by storage nodes
+type ShardStateService interface {
+	// Save saves the states into all storage clusters of database, the states are removed if empty
+	Save(states models.ShardStates) error
+	// Get returns the states of database's shards, returns empty states if not set(all shards are hot)
+	Get(databaseName string) (*models.ShardStates, error)
+}
+
+// shardStateService implements ShardStateService interface
+type shardStateService struct {
+	databaseService       DatabaseService
+	storageClusterService StorageClusterService
+	storageClusterRepos   StorageClusterRepos
+}
+
+// NewShardStateService creates the shard state service
+func NewShardStateService(databaseService DatabaseService, storageClusterService StorageClusterService,
+	storageClusterRepos StorageClusterRepos) ShardStateService {
+	return &shardStateService{
+		databaseService:       databaseService,
+		storageClusterService: storageClusterService,
+		storageClusterRepos:   storageClusterRepos,
+	}
+}
+
+// Save saves the states into the state repository of all storage clusters of database
+func (s *shardStateService) Save(states models.ShardStates) error {
+	if len(states.Database) == 0 {
+		return fmt.Errorf("database name cannot be empty")
+	}
+	for shardID, shardState := range states.States {
+		if shardID < 0 {
+			return fmt.Errorf("shard id[%d] cannot be negative", shardID)
+		}
+		if !shardState.IsValid() {
+			return fmt.Errorf("unknown state[%s] of shard[%d]", shardState, shardID)
+		}
+	}
+	data, err := json.Marshal(states)
+	if err != nil {
+		return fmt.Errorf("marshal shard states error:%s", err)
+	}
+	database, err := s.databaseService.Get(states.Database)
+	if err != nil {
+		return errors.Wrapf(err, "get database[%s] config error", states.Database)
+	}
+	key := pathutil.GetShardStatePath(states.Database)
+	for _, cluster := range database.Clusters {
+		err := withStorageClusterRepo(s.storageClusterService, s.storageClusterRepos, cluster.Name,
+			func(repo state.Repository) error {
+				ctx, cancel := withDefaultTimeout(context.TODO())
+				defer cancel()
+				if len(states.States) == 0 {
+					return repo.Delete(ctx, key)
+				}
+				return repo.Put(ctx, key, data)
+			})
+		if err != nil {
+			return fmt.Errorf("save shard states of database[%s] into storage cluster[%s] error:%s",
+				states.Database, cluster.Name, err)
+		}
+	}
+	return nil
+}
+
+// Get returns the states of database's shards from the first storage cluster of database
+func (s *shardStateService) Get(databaseName string) (*models.ShardStates, error) {
+	database, err := s.databaseService.Get(databaseName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get database[%s] config error", databaseName)
+	}
+	states := &models.ShardStates{Database: databaseName}
+	if len(database.Clusters) == 0 {
+		return states, nil
+	}
+	clusterName := database.Clusters[0].Name
+	err = withStorageClusterRepo(s.storageClusterService, s.storageClusterRepos, clusterName,
+		func(repo state.Repository) error {
+			ctx, cancel := withDefaultTimeout(context.TODO())
+			defer cancel()
+			data, err := repo.Get(ctx, pathutil.GetShardStatePath(databaseName))
+			if err == state.ErrNotExist {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			return json.Unmarshal(data, states)
+		})
+	if err != nil {
+		return nil, fmt.Errorf("get shard states of database[%s] from storage cluster[%s] error:%s",
+			databaseName, clusterName, err)
+	}
+	return states, nil
+}
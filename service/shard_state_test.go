@@ -0,0 +1,73 @@
+package service
+
+import (
+	"testing"
+
+	"gopkg.in/check.v1"
+
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/state"
+)
+
+type testShardStateSRVSuite struct {
+	mock.RepoTestSuite
+}
+
+func TestShardStateSRV(t *testing.T) {
+	check.Suite(&testShardStateSRVSuite{})
+	check.TestingT(t)
+}
+
+func (ts *testShardStateSRVSuite) TestSaveAndGet(c *check.C) {
+	cfg := state.Config{Endpoints: ts.Cluster.Endpoints}
+	repo, _ := state.NewRepo(cfg)
+	databaseService := NewDatabaseService(repo)
+	storageClusterService := NewStorageClusterService(repo)
+	storageClusterRepos := NewStorageClusterRepos()
+	defer func() {
+		_ = storageClusterRepos.Close()
+	}()
+	srv := NewShardStateService(databaseService, storageClusterService, storageClusterRepos)
+
+	states := models.ShardStates{Database: "state_db", States: map[int]models.ShardState{
+		1: models.ShardReadOnly,
+		2: models.ShardFrozen,
+	}}
+	// invalid states
+	c.Assert(srv.Save(models.ShardStates{}), check.NotNil)
+	c.Assert(srv.Save(models.ShardStates{Database: "state_db", States: map[int]models.ShardState{-1: models.ShardHot}}),
+		check.NotNil)
+	c.Assert(srv.Save(models.ShardStates{Database: "state_db", States: map[int]models.ShardState{1: "archived"}}),
+		check.NotNil)
+	// database not exist
+	c.Assert(srv.Save(states), check.NotNil)
+	_, err := srv.Get("state_db")
+	c.Assert(err, check.NotNil)
+
+	_ = databaseService.Save(models.Database{
+		Name:     "state_db",
+		Clusters: []models.DatabaseCluster{{Name: "state_cluster", NumOfShard: 3, ReplicaFactor: 1}},
+	})
+	// storage cluster not exist
+	c.Assert(srv.Save(states), check.NotNil)
+	_, err = srv.Get("state_db")
+	c.Assert(err, check.NotNil)
+
+	_ = storageClusterService.Save(models.StorageCluster{Name: "state_cluster", Config: cfg})
+	// states not set
+	result, err := srv.Get("state_db")
+	c.Assert(err, check.IsNil)
+	c.Assert(result, check.DeepEquals, &models.ShardStates{Database: "state_db"})
+
+	c.Assert(srv.Save(states), check.IsNil)
+	result, err = srv.Get("state_db")
+	c.Assert(err, check.IsNil)
+	c.Assert(result, check.DeepEquals, &states)
+
+	// empty states are removed
+	c.Assert(srv.Save(models.ShardStates{Database: "state_db"}), check.IsNil)
+	result, err = srv.Get("state_db")
+	c.Assert(err, check.IsNil)
+	c.Assert(result, check.DeepEquals, &models.ShardStates{Database: "state_db"})
+}
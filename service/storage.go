@@ -1,12 +1,17 @@
 package service
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
+	"sort"
 	"sync"
 
 	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/models"
 
 	"github.com/eleme/lindb/pkg/option"
+	"github.com/eleme/lindb/pkg/util"
 	"github.com/eleme/lindb/tsdb"
 )
 
@@ -18,21 +23,51 @@ type StorageService interface {
 	GetEngine(db string) tsdb.Engine
 	// GetShard returns shard by given db and shard id, if not exist return nil
 	GetShard(db string, shardID int) tsdb.Shard
+	// FlushAll flushes the in-memory data of all engines into disk
+	FlushAll() error
+	// CheckShards checks the data directories of all engines' shards, marks the shards on failed directory offline
+	CheckShards()
+	// DiskUsage returns the disk usage of given db's engine sorted by database, returns all engines' if db is empty
+	DiskUsage(db string) []models.DatabaseDiskUsage
+	// SetCorruptionHandler sets the handler which is invoked when a file of engines is found corrupted,
+	// the handler is also set to the engines created later
+	SetCorruptionHandler(handler tsdb.CorruptionHandler)
+	// SetFlushCoordinator registers the shards of engines into the coordinator, which decides when the
+	// memory-databases of shards are flushed, the shards created or opened later are also registered
+	SetFlushCoordinator(coordinator tsdb.FlushCoordinator)
+	// ShardStats returns the statistics of all engines' online shards sorted by database and shard id
+	ShardStats() []models.ShardStat
+	// Load opens the engines existing under the engine path, which is called after restarted,
+	// so that the shards created before are written and queried without creating them again
+	Load() error
+	// Close flushes the memory-databases of shards and the index of all engines, then closes the engines,
+	// the engines are removed from the service
+	Close() error
 }
 
 // NewStorageService creates storage service instance for managing tsdb engine
 func NewStorageService(config config.Engine) StorageService {
-	return &storageService{
+	s := &storageService{
 		config: config,
 	}
+	if len(config.DataPaths) > 0 {
+		s.dataDirs = tsdb.NewDataDirs(config.DataPaths)
+	}
+	return s
 }
 
 // storageService implements StorageService interface
 type storageService struct {
 	engines sync.Map
 
-	config config.Engine
-	mutex  sync.Mutex
+	config   config.Engine
+	dataDirs tsdb.DataDirs
+	// corruptionHandler is set to the engines created later, protected by mutex
+	corruptionHandler tsdb.CorruptionHandler
+	mutex             sync.Mutex
+	// flushCoordinator is nil if the shards are only flushed when closed
+	flushCoordinator tsdb.FlushCoordinator
+	flushMutex       sync.RWMutex
 }
 
 // CreateShards creates shards for data partition by given options
@@ -43,19 +78,32 @@ func (s *storageService) CreateShards(db string, option option.ShardOption, shar
 		return fmt.Errorf("cannot create empty shard for db[%s]", db)
 	}
 	engine := s.GetEngine(db)
+	newEngine := engine == nil
 	if engine == nil {
 		s.mutex.Lock()
 		defer s.mutex.Unlock()
 		// double check
 		engine = s.GetEngine(db)
 		if engine == nil {
-			// create tsdb engine
+			// create tsdb engine by the engine type of database
 			var err error
-			engine, err = tsdb.NewEngine(db, s.config.Path)
+			engine, err = tsdb.NewEngineByType(option.EngineType, db, s.config.Path, s.dataDirs)
 			if err != nil {
 				return err
 			}
+			if s.corruptionHandler != nil {
+				engine.SetCorruptionHandler(s.corruptionHandler)
+			}
 			s.engines.Store(db, engine)
+		} else {
+			newEngine = false
+		}
+	}
+	// the shards opened by this call, which are warmed after created
+	var newShardIDs []int
+	for _, shardID := range shardIDs {
+		if engine.GetShard(shardID) == nil {
+			newShardIDs = append(newShardIDs, shardID)
 		}
 	}
 
@@ -63,7 +111,10 @@ func (s *storageService) CreateShards(db string, option option.ShardOption, shar
 	if err := engine.CreateShards(option, shardIDs...); err != nil {
 		return err
 	}
-
+	s.registerFlushables(engine, newShardIDs)
+	if s.config.WarmUpSegments > 0 && (newEngine || len(newShardIDs) > 0) {
+		go autoWarmUp(engine, newEngine, newShardIDs, s.config.WarmUpSegments)
+	}
 	return nil
 }
 
@@ -85,3 +136,225 @@ func (s *storageService) GetEngine(db string) tsdb.Engine {
 	}
 	return nil
 }
+
+// FlushAll flushes the in-memory data of all engines into disk, continues flushing the others
+// if one engine fails, returns the first error.
+func (s *storageService) FlushAll() error {
+	var firstErr error
+	s.engines.Range(func(key, value interface{}) bool {
+		engine, ok := value.(tsdb.Engine)
+		if !ok {
+			return true
+		}
+		if err := engine.Flush(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return true
+	})
+	return firstErr
+}
+
+// CheckShards checks the data directories of all engines' shards, marks the shards on failed directory offline
+func (s *storageService) CheckShards() {
+	s.engines.Range(func(key, value interface{}) bool {
+		if engine, ok := value.(tsdb.Engine); ok {
+			engine.CheckShards()
+		}
+		return true
+	})
+}
+
+// DiskUsage returns the disk usage of given db's engine sorted by database, returns all engines' if db is empty
+func (s *storageService) DiskUsage(db string) []models.DatabaseDiskUsage {
+	var usages []models.DatabaseDiskUsage
+	s.engines.Range(func(key, value interface{}) bool {
+		engine, ok := value.(tsdb.Engine)
+		if ok && (db == "" || engine.Name() == db) {
+			usages = append(usages, engine.DiskUsage())
+		}
+		return true
+	})
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].Database < usages[j].Database
+	})
+	return usages
+}
+
+// SetCorruptionHandler sets the handler which is invoked when a file of engines is found corrupted
+func (s *storageService) SetCorruptionHandler(handler tsdb.CorruptionHandler) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.corruptionHandler = handler
+	s.engines.Range(func(key, value interface{}) bool {
+		if engine, ok := value.(tsdb.Engine); ok {
+			engine.SetCorruptionHandler(handler)
+		}
+		return true
+	})
+}
+
+// SetFlushCoordinator registers the shards of engines into the coordinator which flushes the memory-databases
+func (s *storageService) SetFlushCoordinator(coordinator tsdb.FlushCoordinator) {
+	s.flushMutex.Lock()
+	s.flushCoordinator = coordinator
+	s.flushMutex.Unlock()
+	s.engines.Range(func(key, value interface{}) bool {
+		if engine, ok := value.(tsdb.Engine); ok {
+			s.registerFlushables(engine, engine.ShardIDs())
+		}
+		return true
+	})
+}
+
+// registerFlushables registers the shards of engine into the flush coordinator if set
+func (s *storageService) registerFlushables(engine tsdb.Engine, shardIDs []int) {
+	s.flushMutex.RLock()
+	defer s.flushMutex.RUnlock()
+	if s.flushCoordinator == nil {
+		return
+	}
+	for _, shardID := range shardIDs {
+		s.flushCoordinator.Register(flushableName(engine.Name(), shardID),
+			&shardFlushable{engine: engine, shardID: shardID})
+	}
+}
+
+// unregisterFlushables removes the shards of engine from the flush coordinator if set
+func (s *storageService) unregisterFlushables(engine tsdb.Engine) {
+	s.flushMutex.RLock()
+	defer s.flushMutex.RUnlock()
+	if s.flushCoordinator == nil {
+		return
+	}
+	for _, shardID := range engine.ShardIDs() {
+		s.flushCoordinator.Unregister(flushableName(engine.Name(), shardID))
+	}
+}
+
+// flushableName returns the unique name of shard registered into flush coordinator
+func flushableName(db string, shardID int) string {
+	return fmt.Sprintf("%s/%d", db, shardID)
+}
+
+// shardFlushable adapts the shard of engine to tsdb.Flushable, the shard is resolved when the coordinator checks,
+// so that the shard offline or frozen is skipped
+type shardFlushable struct {
+	engine  tsdb.Engine
+	shardID int
+}
+
+// MemSize returns the estimated bytes of the memory-databases of shard, returns 0 if the shard isn't online
+func (f *shardFlushable) MemSize() int64 {
+	shard := f.engine.GetShard(f.shardID)
+	if shard == nil {
+		return 0
+	}
+	var size int64
+	for _, memDB := range shard.MemoryDatabases() {
+		size += int64(memDB.Stats().EstimatedBytes)
+	}
+	return size
+}
+
+// Flush flushes the memory-databases of shard, the bytes written into disk are estimated by the memory size
+func (f *shardFlushable) Flush() (int64, error) {
+	shard := f.engine.GetShard(f.shardID)
+	if shard == nil {
+		return 0, nil
+	}
+	size := f.MemSize()
+	if err := shard.Flush(context.TODO()); err != nil {
+		return 0, fmt.Errorf("flush shard[%d] of engine[%s] error:%s", f.shardID, f.engine.Name(), err)
+	}
+	return size, nil
+}
+
+// ShardStats returns the statistics of all engines' online shards sorted by database and shard id
+func (s *storageService) ShardStats() []models.ShardStat {
+	var stats []models.ShardStat
+	s.engines.Range(func(key, value interface{}) bool {
+		engine, ok := value.(tsdb.Engine)
+		if !ok {
+			return true
+		}
+		for _, shardID := range engine.ShardIDs() {
+			if shard := engine.GetShard(shardID); shard != nil {
+				stats = append(stats, shard.Stat())
+			}
+		}
+		return true
+	})
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Database != stats[j].Database {
+			return stats[i].Database < stats[j].Database
+		}
+		return stats[i].ShardID < stats[j].ShardID
+	})
+	return stats
+}
+
+// Load opens the engines existing under the engine path, the directory which isn't engine is skipped
+func (s *storageService) Load() error {
+	if !util.Exist(s.config.Path) {
+		return nil
+	}
+	files, err := ioutil.ReadDir(s.config.Path)
+	if err != nil {
+		return fmt.Errorf("list engines under path[%s] error:%s", s.config.Path, err)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, file := range files {
+		if !file.IsDir() || s.GetEngine(file.Name()) != nil {
+			continue
+		}
+		engine, ok, err := tsdb.OpenEngine(file.Name(), s.config.Path, s.dataDirs)
+		if err != nil {
+			return fmt.Errorf("open engine[%s] error:%s", file.Name(), err)
+		}
+		if !ok {
+			continue
+		}
+		if s.corruptionHandler != nil {
+			engine.SetCorruptionHandler(s.corruptionHandler)
+		}
+		s.engines.Store(file.Name(), engine)
+		s.registerFlushables(engine, engine.ShardIDs())
+	}
+	return nil
+}
+
+// Close flushes the in-memory data of all engines, so that the points written are recovered after restarted,
+// then closes the engines, continues closing the others if one engine fails, returns the first error.
+func (s *storageService) Close() error {
+	var firstErr error
+	s.engines.Range(func(key, value interface{}) bool {
+		if engine, ok := value.(tsdb.Engine); ok {
+			s.unregisterFlushables(engine)
+			if err := flushEngine(engine); err != nil && firstErr == nil {
+				firstErr = err
+			}
+			if err := engine.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		s.engines.Delete(key)
+		return true
+	})
+	return firstErr
+}
+
+// flushEngine flushes the memory-databases of engine's online shards into the families of segments,
+// then flushes the index of engine
+func flushEngine(engine tsdb.Engine) error {
+	for _, shardID := range engine.ShardIDs() {
+		shard := engine.GetShard(shardID)
+		if shard == nil {
+			continue
+		}
+		if err := shard.Flush(context.TODO()); err != nil {
+			return fmt.Errorf("flush shard[%d] of engine[%s] error:%s", shardID, engine.Name(), err)
+		}
+	}
+	return engine.Flush()
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/eleme/lindb/constants"
 	"github.com/eleme/lindb/models"
 	"github.com/eleme/lindb/pkg/logger"
 	"github.com/eleme/lindb/pkg/pathutil"
@@ -40,6 +39,9 @@ func (s *storageClusterService) Save(storageCluster models.StorageCluster) error
 	if storageCluster.Name == "" {
 		return fmt.Errorf("storage cluster name cannot be empty")
 	}
+	if err := pathutil.ValidateName(storageCluster.Name); err != nil {
+		return fmt.Errorf("invalid storage cluster name:%s", err)
+	}
 	data, err := json.Marshal(storageCluster)
 	if err != nil {
 		return fmt.Errorf("marshal storage cluster error:%s", err)
@@ -85,7 +87,7 @@ func (s *storageClusterService) List() ([]models.StorageCluster, error) {
 	defer cancel()
 
 	var result []models.StorageCluster
-	data, err := s.repo.List(ctx, constants.StorageClusterConfigPath)
+	data, err := s.repo.List(ctx, pathutil.Keys.Cluster.StorageClusters.Prefix())
 	if err != nil {
 		return result, err
 	}
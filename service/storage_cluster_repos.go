@@ -0,0 +1,93 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/state"
+)
+
+// StorageClusterRepos manages the state repos of storage clusters, the repo of each storage cluster is connected
+// once then shared by the requests of broker, instead of connecting the state repo per request.
+type StorageClusterRepos interface {
+	// Get returns the shared state repo of storage cluster, the repo is reconnected if the config is changed
+	Get(cluster models.StorageCluster) (state.Repository, error)
+	// Remove closes the state repo of storage cluster after the storage cluster is deleted
+	Remove(clusterName string)
+	// Close closes the state repos of all storage clusters
+	Close() error
+}
+
+// clusterRepo represents the state repo of storage cluster with the config connected by
+type clusterRepo struct {
+	config state.Config
+	repo   state.Repository
+}
+
+// storageClusterRepos implements StorageClusterRepos interface
+type storageClusterRepos struct {
+	repos map[string]*clusterRepo
+	mutex sync.Mutex
+	// newRepo creates the state repo of storage cluster
+	newRepo func(cfg state.Config) (state.Repository, error)
+
+	logger *logger.Logger
+}
+
+// NewStorageClusterRepos creates the manager of storage clusters' state repos
+func NewStorageClusterRepos() StorageClusterRepos {
+	return &storageClusterRepos{
+		repos:   make(map[string]*clusterRepo),
+		newRepo: state.NewRepo,
+		logger:  logger.GetLogger("service/storage/repos"),
+	}
+}
+
+// Get returns the shared state repo of storage cluster, connects it if not exist or the config is changed
+func (r *storageClusterRepos) Get(cluster models.StorageCluster) (state.Repository, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if cached, ok := r.repos[cluster.Name]; ok {
+		if reflect.DeepEqual(cached.config, cluster.Config) {
+			return cached.repo, nil
+		}
+		r.close(cluster.Name, cached)
+	}
+	repo, err := r.newRepo(cluster.Config)
+	if err != nil {
+		return nil, fmt.Errorf("connect state repo of storage cluster[%s] error:%s", cluster.Name, err)
+	}
+	r.repos[cluster.Name] = &clusterRepo{config: cluster.Config, repo: repo}
+	return repo, nil
+}
+
+// Remove closes the state repo of storage cluster if exist
+func (r *storageClusterRepos) Remove(clusterName string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if cached, ok := r.repos[clusterName]; ok {
+		r.close(clusterName, cached)
+	}
+}
+
+// Close closes the state repos of all storage clusters
+func (r *storageClusterRepos) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for name, cached := range r.repos {
+		r.close(name, cached)
+	}
+	return nil
+}
+
+// close closes the state repo of storage cluster, then removes it, must be called with lock
+func (r *storageClusterRepos) close(clusterName string, cached *clusterRepo) {
+	delete(r.repos, clusterName)
+	if err := cached.repo.Close(); err != nil {
+		r.logger.Error("close state repo of storage cluster error",
+			logger.String("cluster", clusterName), logger.Error(err))
+	}
+}
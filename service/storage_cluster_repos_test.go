@@ -0,0 +1,61 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/state"
+)
+
+type mockClusterRepo struct {
+	state.Repository
+	closed bool
+}
+
+func (r *mockClusterRepo) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestStorageClusterRepos(t *testing.T) {
+	repos := NewStorageClusterRepos().(*storageClusterRepos)
+	var created []*mockClusterRepo
+	repos.newRepo = func(cfg state.Config) (state.Repository, error) {
+		if cfg.Namespace == "err" {
+			return nil, fmt.Errorf("err")
+		}
+		repo := &mockClusterRepo{}
+		created = append(created, repo)
+		return repo, nil
+	}
+	cluster := models.StorageCluster{Name: "cluster", Config: state.Config{Endpoints: []string{"a"}}}
+	repo1, err := repos.Get(cluster)
+	assert.Nil(t, err)
+	// repo is shared
+	repo2, err := repos.Get(cluster)
+	assert.Nil(t, err)
+	assert.True(t, repo1 == repo2)
+	assert.Len(t, created, 1)
+
+	// reconnect after config changed
+	cluster.Config.Endpoints = []string{"b"}
+	repo3, err := repos.Get(cluster)
+	assert.Nil(t, err)
+	assert.False(t, repo1 == repo3)
+	assert.True(t, created[0].closed)
+
+	_, err = repos.Get(models.StorageCluster{Name: "bad", Config: state.Config{Namespace: "err"}})
+	assert.NotNil(t, err)
+
+	repos.Remove("cluster")
+	assert.True(t, created[1].closed)
+	repos.Remove("cluster")
+
+	_, _ = repos.Get(cluster)
+	assert.Nil(t, repos.Close())
+	assert.True(t, created[2].closed)
+	assert.Empty(t, repos.repos)
+}
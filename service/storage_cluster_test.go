@@ -34,6 +34,8 @@ func (ts *testStorageClusterSRVSuite) TestStorageCluster(c *check.C) {
 	}
 	err = srv.Save(models.StorageCluster{})
 	c.Assert(err, check.NotNil)
+	err = srv.Save(models.StorageCluster{Name: "../test1"})
+	c.Assert(err, check.NotNil)
 
 	cluster2, _ := srv.Get("test1")
 	c.Assert(cluster, check.DeepEquals, cluster2)
@@ -1,15 +1,23 @@
 package service
 
 import (
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/field"
 	"github.com/eleme/lindb/pkg/interval"
 	"github.com/eleme/lindb/pkg/option"
+	"github.com/eleme/lindb/pkg/timeutil"
 	"github.com/eleme/lindb/pkg/util"
+	"github.com/eleme/lindb/tsdb"
 )
 
 var testPath = "test_data"
@@ -36,3 +44,207 @@ func TestCreateShards(t *testing.T) {
 	assert.Nil(t, service.GetShard("test_db", 10))
 	assert.Nil(t, service.GetShard("test_db2", 2))
 }
+
+func TestFlushAll(t *testing.T) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+
+	service := NewStorageService(config.Engine{Path: testPath})
+	// no engines
+	assert.Nil(t, service.FlushAll())
+
+	assert.Nil(t, service.CreateShards("test_db", validOption, 1))
+	assert.Nil(t, service.CreateShards("test_db2", validOption, 1))
+	assert.Nil(t, service.FlushAll())
+}
+
+func TestCheckShards(t *testing.T) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+
+	dataPath := filepath.Join(testPath, "disk1")
+	service := NewStorageService(config.Engine{Path: testPath, DataPaths: []string{dataPath}})
+	assert.Nil(t, service.CreateShards("test_db", validOption, 1))
+	assert.True(t, util.Exist(filepath.Join(dataPath, "test_db", "shard", "1")))
+	service.CheckShards()
+	assert.NotNil(t, service.GetShard("test_db", 1))
+
+	// the disk of data path fails
+	assert.Nil(t, util.RemoveDir(dataPath))
+	service.CheckShards()
+	assert.Nil(t, service.GetShard("test_db", 1))
+	assert.Len(t, service.GetEngine("test_db").OfflineShards(), 1)
+}
+
+func TestDiskUsage(t *testing.T) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+
+	service := NewStorageService(config.Engine{Path: testPath})
+	assert.Empty(t, service.DiskUsage(""))
+
+	assert.Nil(t, service.CreateShards("test_db2", validOption, 2))
+	assert.Nil(t, service.CreateShards("test_db", validOption, 1))
+	// sorted by database
+	usages := service.DiskUsage("")
+	assert.Equal(t, 2, len(usages))
+	assert.Equal(t, "test_db", usages[0].Database)
+	assert.Equal(t, "test_db2", usages[1].Database)
+	assert.Equal(t, service.GetEngine("test_db2").DiskUsage(), usages[1])
+
+	usages = service.DiskUsage("test_db2")
+	assert.Equal(t, 1, len(usages))
+	assert.Equal(t, []models.ShardDiskUsage{{ShardID: 2}}, usages[0].Shards)
+	assert.Empty(t, service.DiskUsage("not_exist"))
+
+	// the gauges of dropped database are removed
+	UpdateDiskUsageMetrics(service)
+	metric := &dto.Metric{}
+	assert.Nil(t, diskUsageBytes.WithLabelValues("test_db2", "2").Write(metric))
+	assert.Equal(t, float64(0), metric.GetGauge().GetValue())
+	UpdateDiskUsageMetrics(NewStorageService(config.Engine{Path: testPath}))
+	count := 0
+	ch := make(chan prometheus.Metric, 10)
+	diskUsageBytes.Collect(ch)
+	close(ch)
+	for range ch {
+		count++
+	}
+	assert.Equal(t, 0, count)
+}
+
+func TestStorageService_ShardStats(t *testing.T) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+
+	service := NewStorageService(config.Engine{Path: testPath})
+	assert.Empty(t, service.ShardStats())
+	assert.Nil(t, service.CreateShards("test_db2", validOption, 2))
+	assert.Nil(t, service.CreateShards("test_db", validOption, 3, 1))
+	// sorted by database and shard id
+	assert.Equal(t, []models.ShardStat{
+		{Database: "test_db", ShardID: 1},
+		{Database: "test_db", ShardID: 3},
+		{Database: "test_db2", ShardID: 2},
+	}, service.ShardStats())
+}
+
+func TestStorageService_SetCorruptionHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+
+	engine1 := tsdb.NewMockEngine(ctrl)
+	engine2 := tsdb.NewMockEngine(ctrl)
+	engines := map[string]tsdb.Engine{"db1": engine1, "db2": engine2}
+	tsdb.RegisterEngine("corruption_test", func(name string, path string, dataDirs tsdb.DataDirs) (tsdb.Engine, error) {
+		return engines[name], nil
+	})
+	shardOption := validOption
+	shardOption.EngineType = "corruption_test"
+	for _, engine := range engines {
+		engine.(*tsdb.MockEngine).EXPECT().GetShard(1).Return(nil)
+		engine.(*tsdb.MockEngine).EXPECT().CreateShards(shardOption, 1).Return(nil)
+	}
+
+	service := NewStorageService(config.Engine{Path: testPath})
+	assert.Nil(t, service.CreateShards("db1", shardOption, 1))
+	// set to existing engine
+	engine1.EXPECT().SetCorruptionHandler(gomock.Any())
+	service.SetCorruptionHandler(func(corruption models.Corruption) {})
+	// set to the engine created later
+	engine2.EXPECT().SetCorruptionHandler(gomock.Any())
+	assert.Nil(t, service.CreateShards("db2", shardOption, 1))
+}
+
+// flushables records the flushables registered into flush coordinator
+type flushables map[string]tsdb.Flushable
+
+func (f flushables) Register(name string, flushable tsdb.Flushable) { f[name] = flushable }
+func (f flushables) Unregister(name string)                         { delete(f, name) }
+func (f flushables) Start()                                         {}
+func (f flushables) Stop()                                          {}
+
+func TestStorageService_SetFlushCoordinator(t *testing.T) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+
+	service := NewStorageService(config.Engine{Path: testPath})
+	assert.Nil(t, service.CreateShards("test_db", validOption, 1))
+	// register the existing shards
+	coordinator := make(flushables)
+	service.SetFlushCoordinator(coordinator)
+	assert.Len(t, coordinator, 1)
+	// register the shards created later
+	assert.Nil(t, service.CreateShards("test_db", validOption, 1, 2))
+	assert.Len(t, coordinator, 2)
+
+	flushable := coordinator["test_db/1"]
+	assert.Zero(t, flushable.MemSize())
+	point := models.NewPoint("cpu", timeutil.Now(), map[string]string{"host": "1.1.1.1"},
+		map[string]models.Field{"usage": models.NewSimpleField(field.SumField, field.Integer, int64(1))})
+	assert.Nil(t, service.GetEngine("test_db").Write(1, point))
+	assert.True(t, flushable.MemSize() > 0)
+	// the memory-database is flushed by coordinator
+	written, err := flushable.Flush()
+	assert.Nil(t, err)
+	assert.True(t, written > 0)
+	assert.NotEmpty(t, service.GetShard("test_db", 1).DiskUsage().Families)
+
+	// the shards are unregistered after closed
+	assert.Nil(t, service.Close())
+	assert.Empty(t, coordinator)
+	// the shards opened after restarted are registered
+	service = NewStorageService(config.Engine{Path: testPath})
+	service.SetFlushCoordinator(coordinator)
+	assert.Nil(t, service.Load())
+	assert.Len(t, coordinator, 2)
+	// the shard not online is skipped
+	written, err = (&shardFlushable{engine: service.GetEngine("test_db"), shardID: 3}).Flush()
+	assert.Nil(t, err)
+	assert.Zero(t, written)
+	assert.Nil(t, service.Close())
+}
+
+func TestStorageService_Load_Close(t *testing.T) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+
+	service := NewStorageService(config.Engine{Path: testPath})
+	// engine path not exist
+	assert.Nil(t, service.Load())
+	assert.Nil(t, service.CreateShards("test_db", validOption, 1, 2))
+	point := models.NewPoint("cpu", timeutil.Now(), map[string]string{"host": "1.1.1.1"},
+		map[string]models.Field{"usage": models.NewSimpleField(field.SumField, field.Integer, int64(1))})
+	assert.Nil(t, service.GetEngine("test_db").Write(1, point))
+	assert.Nil(t, service.Close())
+	assert.Nil(t, service.GetEngine("test_db"))
+
+	// the engines are opened after restarted, the directory which isn't engine is skipped
+	assert.Nil(t, util.MkDirIfNotExist(filepath.Join(testPath, "not_engine")))
+	service = NewStorageService(config.Engine{Path: testPath})
+	assert.Nil(t, service.Load())
+	assert.NotNil(t, service.GetShard("test_db", 1))
+	assert.NotNil(t, service.GetShard("test_db", 2))
+	// the memory-database is flushed when closed
+	assert.NotEmpty(t, service.GetShard("test_db", 1).DiskUsage().Families)
+	assert.Empty(t, service.GetShard("test_db", 2).DiskUsage().Families)
+	assert.Nil(t, service.GetEngine("not_engine"))
+	// loaded already
+	assert.Nil(t, service.Load())
+	assert.Nil(t, service.Close())
+
+	// the engine type isn't registered
+	assert.Nil(t, util.EncodeToml(filepath.Join(testPath, "test_db", "OPTIONS"),
+		map[string]interface{}{"shardOption": map[string]interface{}{"engineType": "archive"}}))
+	service = NewStorageService(config.Engine{Path: testPath})
+	assert.NotNil(t, service.Load())
+}
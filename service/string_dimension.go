@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/RoaringBitmap/roaring"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/pkg/interval"
+	"github.com/eleme/lindb/tsdb"
+	"github.com/eleme/lindb/tsdb/index"
+	"github.com/eleme/lindb/tsdb/metrictbl"
+)
+
+// stringValue is the last value of string field of series
+type stringValue struct {
+	timestamp int64
+	value     string
+}
+
+// stringValues is the last values of string fields keyed by field name
+type stringValues map[string]stringValue
+
+// set keeps the value of later timestamp
+func (v stringValues) set(fieldName string, timestamp int64, value string) {
+	if last, ok := v[fieldName]; ok && last.timestamp > timestamp {
+		return
+	}
+	v[fieldName] = stringValue{timestamp: timestamp, value: value}
+}
+
+// resolveStringDimensions sets the last values of the string fields of group by as the tags of series,
+// so that the series are grouped by the last values like tags. The values flushed into the string families
+// of segments are merged with the values in memory-databases, the value of later timestamp wins.
+func resolveStringDimensions(ctx context.Context, engine tsdb.Engine, shardIDs []int, intervalType interval.Type,
+	req *models.StorageQueryRequest, metricID uint32, rs *models.ResultSet) error {
+	if len(req.GroupBy) == 0 || len(rs.Series) == 0 {
+		return nil
+	}
+	// the values of series keyed by the sorted tags
+	values := make(map[string]stringValues)
+	valuesOf := func(tags string) stringValues {
+		key := index.MapToString(index.StringToMap(tags))
+		v, ok := values[key]
+		if !ok {
+			v = make(stringValues)
+			values[key] = v
+		}
+		return v
+	}
+	if fieldIDs := stringFieldIDs(engine.GetIndex().GetFieldUID(), metricID, req.GroupBy); len(fieldIDs) > 0 {
+		timeRange := models.TimeRange{Start: req.Start, End: req.End}
+		for _, shardID := range shardIDs {
+			snapshot, err := engine.GetSnapshot(shardID, intervalType, timeRange)
+			if err != nil {
+				return fmt.Errorf("get snapshot of shard[%d] error:%s", shardID, err)
+			}
+			seriesValues, err := scanStringFamilies(ctx, snapshot, metricID, fieldIDs)
+			snapshot.Close()
+			if err != nil {
+				return fmt.Errorf("scan string families of shard[%d] error:%s", shardID, err)
+			}
+			if len(seriesValues) == 0 {
+				continue
+			}
+			seriesIDs := roaring.New()
+			for tsID := range seriesValues {
+				seriesIDs.Add(tsID)
+			}
+			seriesTags := engine.GetIndex().GetTagsUID().GetSeriesTags(metricID, seriesIDs)
+			for tsID, v := range seriesValues {
+				tags, ok := seriesTags[tsID]
+				if !ok {
+					continue
+				}
+				target := valuesOf(tags)
+				for fieldName, last := range v {
+					target.set(fieldName, last.timestamp, last.value)
+				}
+			}
+		}
+	}
+	// the values in memory-databases may be newer than the flushed values
+	for _, shardID := range shardIDs {
+		shard := engine.GetShard(shardID)
+		if shard == nil {
+			continue
+		}
+		memDB, ok := shard.MemoryDatabaseOf(intervalType)
+		if !ok || memDB == nil {
+			continue
+		}
+		memValues, err := memDB.StringValues(req.MetricName, req.GroupBy)
+		if err != nil {
+			if errors.Is(err, errors.ErrMetricNotFound) {
+				continue
+			}
+			return fmt.Errorf("query string values of memory-database of shard[%d] error:%s", shardID, err)
+		}
+		for _, v := range memValues {
+			valuesOf(v.Tags).set(v.Field, v.Timestamp, v.Value)
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	for _, series := range rs.Series {
+		v, ok := values[index.MapToString(series.Tags)]
+		if !ok {
+			continue
+		}
+		// the tags of series may be shared, so the dimensions are set into a copy
+		tags := make(map[string]string, len(series.Tags)+len(v))
+		for tagKey, tagValue := range series.Tags {
+			tags[tagKey] = tagValue
+		}
+		for fieldName, last := range v {
+			// the tag takes precedence over the string field with the same name
+			if _, ok := tags[fieldName]; !ok {
+				tags[fieldName] = last.value
+			}
+		}
+		series.Tags = tags
+	}
+	return nil
+}
+
+// stringFieldIDs returns the names of the string fields of group by keyed by field id
+func stringFieldIDs(fieldUID tsdb.FieldUID, metricID uint32, groupBy []string) map[uint32]string {
+	if metricID == index.NotFoundMetricID {
+		return nil
+	}
+	fieldIDs := make(map[uint32]string)
+	for _, name := range groupBy {
+		fieldID := fieldUID.GetFieldID(metricID, name)
+		if fieldID != index.NotFoundFieldID && index.GetFieldType(fieldID) == field.StringField {
+			fieldIDs[fieldID] = name
+		}
+	}
+	return fieldIDs
+}
+
+// scanStringFamilies returns the last values of the string fields of each series keyed by series id,
+// which are decoded from the string blocks of metric in the string families of segments.
+func scanStringFamilies(ctx context.Context, snapshot tsdb.Snapshot, metricID uint32,
+	fieldIDs map[uint32]string) (map[uint32]stringValues, error) {
+	result := make(map[uint32]stringValues)
+	for _, segment := range snapshot.Segments() {
+		family := segment.GetFamilyByName(metrictbl.StringFamilyName)
+		if family == nil {
+			continue
+		}
+		var decodeErr error
+		if err := family.LookupWithContext(ctx, metricID, func(data []byte) bool {
+			block, err := metrictbl.DecodeStringBlock(data)
+			if err != nil {
+				decodeErr = err
+				return true
+			}
+			for _, entry := range block.Entries {
+				fieldName, ok := fieldIDs[entry.FieldID]
+				if !ok {
+					continue
+				}
+				value, ok := block.Value(entry)
+				if !ok {
+					continue
+				}
+				v, ok := result[entry.TSID]
+				if !ok {
+					v = make(stringValues)
+					result[entry.TSID] = v
+				}
+				v.set(fieldName, entry.Timestamp, value)
+			}
+			return false
+		}); err != nil {
+			return nil, err
+		}
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"plugin"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/pathutil"
+	"github.com/eleme/lindb/pkg/state"
+	"github.com/eleme/lindb/query"
+)
+
+// defaultUDFSymbol is the default name of factory exported by the plugin of user defined function
+const defaultUDFSymbol = "NewUDF"
+
+// udfNameRegexp is the pattern of function name, which can be parsed in the select expression
+var udfNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// UDFService represents the user defined functions of databases, which are stored in the state repository
+// of broker cluster and loaded by all brokers
+type UDFService interface {
+	// Save saves the user defined function, the function of same database and name is replaced
+	Save(definition models.UDFDefinition) error
+	// Delete deletes the user defined function of database by name
+	Delete(database, name string) error
+	// List lists the user defined functions of database sorted by name, empty database means all databases
+	List(database string) ([]models.UDFDefinition, error)
+}
+
+// udfService implements UDFService interface
+type udfService struct {
+	repo state.Repository
+}
+
+// NewUDFService creates the user defined function service
+func NewUDFService(repo state.Repository) UDFService {
+	return &udfService{repo: repo}
+}
+
+// Save validates the user defined function, then saves it into state repository
+func (s *udfService) Save(definition models.UDFDefinition) error {
+	if err := validateUDFDefinition(definition); err != nil {
+		return err
+	}
+	data, err := json.Marshal(definition)
+	if err != nil {
+		return fmt.Errorf("marshal user defined function error:%s", err)
+	}
+	ctx, cancel := withDefaultTimeout(context.TODO())
+	defer cancel()
+	return s.repo.Put(ctx, udfKey(definition.Database, definition.Name), data)
+}
+
+// Delete deletes the user defined function of database by name
+func (s *udfService) Delete(database, name string) error {
+	if err := pathutil.ValidateName(database); err != nil {
+		return errors.Wrapf(errors.ErrInvalidArgument, "invalid database of user defined function:%s", err)
+	}
+	if err := pathutil.ValidateName(name); err != nil {
+		return errors.Wrapf(errors.ErrInvalidArgument, "invalid name of user defined function:%s", err)
+	}
+	ctx, cancel := withDefaultTimeout(context.TODO())
+	defer cancel()
+	return s.repo.Delete(ctx, udfKey(database, name))
+}
+
+// List lists the user defined functions sorted by database and name, the invalid functions are skipped
+func (s *udfService) List(database string) ([]models.UDFDefinition, error) {
+	prefix := pathutil.Keys.Queries.UDFs.Prefix()
+	if len(database) > 0 {
+		if err := pathutil.ValidateName(database); err != nil {
+			return nil, errors.Wrapf(errors.ErrInvalidArgument, "invalid database of user defined function:%s", err)
+		}
+		prefix = pathutil.KeyRoot(pathutil.Keys.Queries.UDFs.Key(database)).Prefix()
+	}
+	ctx, cancel := withDefaultTimeout(context.TODO())
+	defer cancel()
+	values, err := s.repo.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	definitions := make([]models.UDFDefinition, 0, len(values))
+	for _, value := range values {
+		definition := models.UDFDefinition{}
+		if err := json.Unmarshal(value, &definition); err != nil {
+			continue
+		}
+		definitions = append(definitions, definition)
+	}
+	sort.Slice(definitions, func(i, j int) bool {
+		if definitions[i].Database != definitions[j].Database {
+			return definitions[i].Database < definitions[j].Database
+		}
+		return definitions[i].Name < definitions[j].Name
+	})
+	return definitions, nil
+}
+
+// validateUDFDefinition checks if the user defined function is valid, the builtin function cannot be replaced
+func validateUDFDefinition(definition models.UDFDefinition) error {
+	if err := pathutil.ValidateName(definition.Database); err != nil {
+		return errors.Wrapf(errors.ErrInvalidArgument, "invalid database of user defined function:%s", err)
+	}
+	if !udfNameRegexp.MatchString(definition.Name) {
+		return errors.Wrapf(errors.ErrInvalidArgument, "invalid name of user defined function[%s]", definition.Name)
+	}
+	if query.IsBuiltinFunction(definition.Name) {
+		return errors.Wrapf(errors.ErrInvalidArgument, "function[%s] is builtin", definition.Name)
+	}
+	if (len(definition.Plugin) == 0) == (len(definition.WASM) == 0) {
+		return errors.Wrapf(errors.ErrInvalidArgument, "either plugin or wasm of function[%s] must be set",
+			definition.Name)
+	}
+	return nil
+}
+
+// udfKey returns the key of user defined function, which is grouped by database
+func udfKey(database, name string) string {
+	return pathutil.KeyRoot(pathutil.Keys.Queries.UDFs.Key(database)).Key(name)
+}
+
+// parseUDFKey returns the database and name of user defined function by key
+func parseUDFKey(key string) (database, name string, ok bool) {
+	path := strings.TrimPrefix(key, pathutil.Keys.Queries.UDFs.Prefix())
+	if len(path) == len(key) {
+		return "", "", false
+	}
+	idx := strings.Index(path, "/")
+	if idx < 0 {
+		return "", "", false
+	}
+	database, name = path[:idx], path[idx+1:]
+	if pathutil.ValidateName(database) != nil || pathutil.ValidateName(name) != nil {
+		return "", "", false
+	}
+	return database, name, true
+}
+
+// UDFLoader loads the user defined function by definition
+type UDFLoader func(definition models.UDFDefinition) (query.UDF, error)
+
+// NewUDFLoader creates the loader which loads the user defined function from WebAssembly module if wasm is set,
+// otherwise from Go plugin if plugin is allowed, the function of Go plugin is rejected if not allowed.
+func NewUDFLoader(allowPlugin bool) UDFLoader {
+	return func(definition models.UDFDefinition) (query.UDF, error) {
+		if len(definition.WASM) > 0 {
+			return LoadWASMUDF(definition)
+		}
+		if !allowPlugin {
+			return nil, fmt.Errorf("load function[%s] of database[%s] from go plugin isn't allowed",
+				definition.Name, definition.Database)
+		}
+		return LoadPluginUDF(definition)
+	}
+}
+
+// LoadPluginUDF loads the user defined function from Go plugin, the plugin exports the factory(query.UDFFactory)
+// by symbol, then the function is created by the factory with the params of definition.
+// NOTICE: Go plugin cannot be unloaded and is cached by path, so the new version of plugin must use a new path,
+// and the plugin runs in the process of broker, only the trusted plugin can be loaded.
+func LoadPluginUDF(definition models.UDFDefinition) (query.UDF, error) {
+	p, err := plugin.Open(definition.Plugin)
+	if err != nil {
+		return nil, fmt.Errorf("open plugin[%s] error:%s", definition.Plugin, err)
+	}
+	symbol := definition.Symbol
+	if len(symbol) == 0 {
+		symbol = defaultUDFSymbol
+	}
+	s, err := p.Lookup(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("lookup symbol[%s] of plugin[%s] error:%s", symbol, definition.Plugin, err)
+	}
+	var factory query.UDFFactory
+	switch f := s.(type) {
+	case query.UDFFactory:
+		factory = f
+	case *query.UDFFactory:
+		factory = *f
+	default:
+		return nil, fmt.Errorf("symbol[%s] of plugin[%s] isn't the factory of user defined function, type:%T",
+			symbol, definition.Plugin, s)
+	}
+	return factory(definition.Params)
+}
+
+// UDFRegistry keeps the user defined functions of databases watched from state repository in memory,
+// which are resolved from registry when the query uses the function which isn't builtin.
+type UDFRegistry struct {
+	load UDFLoader
+
+	mutex     sync.RWMutex
+	databases map[string]map[string]query.UDF
+
+	logger *logger.Logger
+}
+
+// NewUDFRegistry creates the user defined function registry, the functions are loaded by loader
+func NewUDFRegistry(load UDFLoader) *UDFRegistry {
+	return &UDFRegistry{
+		load:      load,
+		databases: make(map[string]map[string]query.UDF),
+		logger:    logger.GetLogger("service/query/udf"),
+	}
+}
+
+// Watch watches the user defined functions until the context is done
+func (r *UDFRegistry) Watch(ctx context.Context, repo state.Repository) {
+	eventCh := repo.WatchPrefix(ctx, pathutil.Keys.Queries.UDFs.Prefix())
+	for event := range eventCh {
+		if event.Err != nil {
+			continue
+		}
+		switch event.Type {
+		case state.EventTypeDelete:
+			for _, kv := range event.KeyValues {
+				if database, name, ok := parseUDFKey(kv.Key); ok {
+					r.Remove(database, name)
+				}
+			}
+		case state.EventTypeAll:
+			r.Cleanup()
+			fallthrough
+		case state.EventTypeModify:
+			for _, kv := range event.KeyValues {
+				definition := models.UDFDefinition{}
+				if err := json.Unmarshal(kv.Value, &definition); err != nil {
+					r.logger.Error("unmarshal user defined function error",
+						logger.String("key", kv.Key), logger.Error(err))
+					continue
+				}
+				if err := r.Set(definition); err != nil {
+					r.logger.Error("load user defined function error",
+						logger.String("key", kv.Key), logger.Error(err))
+				}
+			}
+		}
+	}
+}
+
+// Set loads the user defined function, then registers it for database, the function of same name is replaced
+func (r *UDFRegistry) Set(definition models.UDFDefinition) error {
+	if err := validateUDFDefinition(definition); err != nil {
+		return err
+	}
+	udf, err := r.load(definition)
+	if err != nil {
+		return err
+	}
+	if udf == nil {
+		return fmt.Errorf("user defined function[%s] is nil", definition.Name)
+	}
+	r.mutex.Lock()
+	functions, ok := r.databases[definition.Database]
+	if !ok {
+		functions = make(map[string]query.UDF)
+		r.databases[definition.Database] = functions
+	}
+	functions[definition.Name] = udf
+	r.mutex.Unlock()
+	r.logger.Info("user defined function changed", logger.Any("function", definition))
+	return nil
+}
+
+// Remove unregisters the user defined function of database by name
+func (r *UDFRegistry) Remove(database, name string) {
+	r.mutex.Lock()
+	if functions, ok := r.databases[database]; ok {
+		delete(functions, name)
+		if len(functions) == 0 {
+			delete(r.databases, database)
+		}
+	}
+	r.mutex.Unlock()
+	r.logger.Info("user defined function removed", logger.String("database", database), logger.String("name", name))
+}
+
+// Cleanup unregisters all user defined functions
+func (r *UDFRegistry) Cleanup() {
+	r.mutex.Lock()
+	r.databases = make(map[string]map[string]query.UDF)
+	r.mutex.Unlock()
+}
+
+// Lookup returns the user defined function of database by name, returns false if not exist
+func (r *UDFRegistry) Lookup(database, name string) (query.UDF, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	udf, ok := r.databases[database][name]
+	return udf, ok
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/check.v1"
+
+	"github.com/eleme/lindb/mock"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/state"
+	"github.com/eleme/lindb/query"
+)
+
+type mockUDF struct {
+	params map[string]string
+}
+
+func (f *mockUDF) Eval(interval int64, series [][]float64, params []float64) ([]float64, error) {
+	return series[0], nil
+}
+
+func (f *mockUDF) Lookback(interval int64, params []float64) int64 {
+	return 0
+}
+
+func mockUDFLoader(definition models.UDFDefinition) (query.UDF, error) {
+	switch definition.Plugin {
+	case "bad.so":
+		return nil, fmt.Errorf("bad plugin")
+	case "nil.so":
+		return nil, nil
+	default:
+		return &mockUDF{params: definition.Params}, nil
+	}
+}
+
+func TestUDFRegistry(t *testing.T) {
+	registry := NewUDFRegistry(mockUDFLoader)
+	_, ok := registry.Lookup("db", "smooth")
+	assert.False(t, ok)
+
+	// invalid functions
+	err := registry.Set(models.UDFDefinition{Database: "db", Name: "smooth-1", Plugin: "smooth.so"})
+	assert.True(t, errors.Is(err, errors.ErrInvalidArgument))
+	assert.NotNil(t, registry.Set(models.UDFDefinition{Database: "a/b", Name: "smooth", Plugin: "smooth.so"}))
+	assert.NotNil(t, registry.Set(models.UDFDefinition{Database: "db", Name: query.MovingAverage, Plugin: "smooth.so"}))
+	assert.NotNil(t, registry.Set(models.UDFDefinition{Database: "db", Name: "smooth"}))
+	assert.NotNil(t, registry.Set(models.UDFDefinition{Database: "db", Name: "smooth", Plugin: "smooth.so",
+		WASM: "smooth.wasm"}))
+	assert.NotNil(t, registry.Set(models.UDFDefinition{Database: "db", Name: "smooth", Plugin: "bad.so"}))
+	assert.NotNil(t, registry.Set(models.UDFDefinition{Database: "db", Name: "smooth", Plugin: "nil.so"}))
+
+	assert.Nil(t, registry.Set(models.UDFDefinition{Database: "db", Name: "smooth", Plugin: "smooth.so",
+		Params: map[string]string{"alpha": "0.5"}}))
+	udf, ok := registry.Lookup("db", "smooth")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"alpha": "0.5"}, udf.(*mockUDF).params)
+	// the function is registered by database
+	_, ok = registry.Lookup("db2", "smooth")
+	assert.False(t, ok)
+	assert.Nil(t, registry.Set(models.UDFDefinition{Database: "db", Name: "score", Plugin: "score.so"}))
+	registry.Remove("db", "smooth")
+	_, ok = registry.Lookup("db", "smooth")
+	assert.False(t, ok)
+	_, ok = registry.Lookup("db", "score")
+	assert.True(t, ok)
+	registry.Remove("db2", "score")
+	registry.Cleanup()
+	_, ok = registry.Lookup("db", "score")
+	assert.False(t, ok)
+}
+
+func TestLoadPluginUDF(t *testing.T) {
+	definition := models.UDFDefinition{Database: "db", Name: "smooth", Plugin: "not_exist.so"}
+	_, err := LoadPluginUDF(definition)
+	assert.NotNil(t, err)
+	// the go plugin is rejected unless allowed
+	_, err = NewUDFLoader(false)(definition)
+	assert.Contains(t, err.Error(), "isn't allowed")
+	_, err = NewUDFLoader(true)(definition)
+	assert.Contains(t, err.Error(), "open plugin")
+}
+
+func TestParseUDFKey(t *testing.T) {
+	database, name, ok := parseUDFKey(udfKey("db", "smooth"))
+	assert.True(t, ok)
+	assert.Equal(t, "db", database)
+	assert.Equal(t, "smooth", name)
+	_, _, ok = parseUDFKey("/query/blacklist/db/smooth")
+	assert.False(t, ok)
+	_, _, ok = parseUDFKey("/query/udf/db")
+	assert.False(t, ok)
+	_, _, ok = parseUDFKey("/query/udf/db/smooth/1")
+	assert.False(t, ok)
+}
+
+type testUDFSRVSuite struct {
+	mock.RepoTestSuite
+}
+
+func TestUDFSRV(t *testing.T) {
+	check.Suite(&testUDFSRVSuite{})
+	check.TestingT(t)
+}
+
+func (ts *testUDFSRVSuite) TestSaveAndWatch(c *check.C) {
+	repo, _ := state.NewRepo(state.Config{Namespace: "/udf/test", Endpoints: ts.Cluster.Endpoints})
+	srv := NewUDFService(repo)
+
+	definitions, err := srv.List("")
+	c.Assert(err, check.IsNil)
+	c.Assert(definitions, check.HasLen, 0)
+	c.Assert(srv.Save(models.UDFDefinition{Database: "db", Name: "smooth"}), check.NotNil)
+	c.Assert(srv.Delete("a/b", "smooth"), check.NotNil)
+	c.Assert(srv.Delete("db", "a/b"), check.NotNil)
+	_, err = srv.List("a/b")
+	c.Assert(err, check.NotNil)
+
+	smooth := models.UDFDefinition{Database: "db", Name: "smooth", Plugin: "smooth.so"}
+	score := models.UDFDefinition{Database: "db2", Name: "score", Plugin: "score.so"}
+	c.Assert(srv.Save(score), check.IsNil)
+	c.Assert(srv.Save(smooth), check.IsNil)
+	// the invalid functions are skipped
+	_ = repo.Put(context.TODO(), "/query/udf/db/bad", []byte("bad"))
+	definitions, err = srv.List("")
+	c.Assert(err, check.IsNil)
+	c.Assert(definitions, check.DeepEquals, []models.UDFDefinition{smooth, score})
+	definitions, err = srv.List("db2")
+	c.Assert(err, check.IsNil)
+	c.Assert(definitions, check.DeepEquals, []models.UDFDefinition{score})
+
+	// the functions saved are watched
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	registry := NewUDFRegistry(mockUDFLoader)
+	go registry.Watch(ctx, repo)
+	waitForLookup := func(exist bool) {
+		for i := 0; i < 100; i++ {
+			if _, ok := registry.Lookup("db", "smooth"); ok == exist {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		_, ok := registry.Lookup("db", "smooth")
+		c.Assert(ok, check.Equals, exist)
+	}
+	waitForLookup(true)
+	c.Assert(srv.Delete("db", "smooth"), check.IsNil)
+	waitForLookup(false)
+	c.Assert(srv.Save(smooth), check.IsNil)
+	waitForLookup(true)
+}
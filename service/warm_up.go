@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/tsdb"
+)
+
+// WarmUpService represents the pre-warming of table readers and index blocks of database's shards after
+// storage nodes restarted, so that the first queries(like the dashboards after a deploy) don't read them from disk.
+type WarmUpService interface {
+	// WarmUp warms the metadata index and the most recent segments of database's shards,
+	// returns the result of index and each shard replica
+	WarmUp(ctx context.Context, req *models.WarmUpRequest) ([]models.WarmUpResult, error)
+}
+
+// warmUpService implements WarmUpService interface based on the engines in storage node
+type warmUpService struct {
+	storageService StorageService
+}
+
+// NewWarmUpService creates warm-up service for the engines of storage node
+func NewWarmUpService(storageService StorageService) WarmUpService {
+	return &warmUpService{
+		storageService: storageService,
+	}
+}
+
+// WarmUp warms the metadata index and the shards of database in current storage node, the shard not exist is skipped,
+// the failure of index or shard is returned in its result, the others are warmed continually.
+func (s *warmUpService) WarmUp(ctx context.Context, req *models.WarmUpRequest) ([]models.WarmUpResult, error) {
+	if err := validateWarmUpRequest(req); err != nil {
+		return nil, err
+	}
+	engine := s.storageService.GetEngine(req.Database)
+	if engine == nil {
+		return nil, nil
+	}
+	shardIDs := req.ShardIDs
+	if len(shardIDs) == 0 {
+		shardIDs = engine.ShardIDs()
+	}
+	return warmUpEngine(ctx, engine, true, shardIDs, req.Segments)
+}
+
+// validateWarmUpRequest checks if warm-up request is valid
+func validateWarmUpRequest(req *models.WarmUpRequest) error {
+	if req == nil {
+		return fmt.Errorf("warm up request cannot be nil")
+	}
+	if len(req.Database) == 0 {
+		return fmt.Errorf("database name cannot be empty")
+	}
+	return nil
+}
+
+// warmUpEngine warms the metadata index if index is true and the shards of engine,
+// stops if ctx is done before warming a shard.
+func warmUpEngine(ctx context.Context, engine tsdb.Engine, index bool,
+	shardIDs []int, numOfSegments int) ([]models.WarmUpResult, error) {
+	var results []models.WarmUpResult
+	if index {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		stats, err := engine.GetIndex().WarmUp()
+		results = append(results, newWarmUpResult(models.WarmUpResult{Index: true, WarmUpStats: stats}, err))
+	}
+	for _, shardID := range shardIDs {
+		shard := engine.GetShard(shardID)
+		if shard == nil {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		stats, err := shard.WarmUp(numOfSegments)
+		results = append(results, newWarmUpResult(models.WarmUpResult{ShardID: shardID, WarmUpStats: stats}, err))
+	}
+	return results, nil
+}
+
+// newWarmUpResult sets the error of warm-up result if fail
+func newWarmUpResult(result models.WarmUpResult, err error) models.WarmUpResult {
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// autoWarmUp warms the most recent segments of the shards opened by storage node in background,
+// the index is warmed if the engine is opened too, the failures are logged.
+func autoWarmUp(engine tsdb.Engine, index bool, shardIDs []int, numOfSegments int) {
+	log := logger.GetLogger("service/warm/up")
+	results, _ := warmUpEngine(context.Background(), engine, index, shardIDs, numOfSegments)
+	for _, result := range results {
+		if len(result.Error) > 0 {
+			log.Error("warm up error", logger.String("db", engine.Name()), logger.Any("index", result.Index),
+				logger.Any("shardID", result.ShardID), logger.String("error", result.Error))
+			continue
+		}
+		log.Info("warm up successfully", logger.String("db", engine.Name()), logger.Any("index", result.Index),
+			logger.Any("shardID", result.ShardID), logger.Any("files", result.Files),
+			logger.Any("blocks", result.Blocks))
+	}
+}
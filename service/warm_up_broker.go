@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/eleme/lindb/broker/rpc"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+)
+
+// brokerWarmUpService implements WarmUpService interface for broker,
+// sends the request to all replicas of database's shards, because each replica has its own readers and block cache.
+// The storage node warms the requested shards which it owns, so the shards of request needn't be resolved.
+type brokerWarmUpService struct {
+	routingCache    RoutingCache
+	circuitBreakers rpc.CircuitBreakers
+	newClient       adminClientFactory
+}
+
+// NewBrokerWarmUpService creates warm-up service for broker
+func NewBrokerWarmUpService(routingCache RoutingCache, circuitBreakers rpc.CircuitBreakers,
+	connPool rpc.ConnPool) WarmUpService {
+	return &brokerWarmUpService{
+		routingCache:    routingCache,
+		circuitBreakers: circuitBreakers,
+		newClient: func(node models.Node) rpc.AdminClient {
+			return rpc.NewPooledAdminClient(connPool, fmt.Sprintf("%s:%d", node.IP, node.Port))
+		},
+	}
+}
+
+// WarmUp warms the index and shards in all replicas of database, returns the results sorted by node,
+// the index's result is the first of each node, then the shards' sorted by shard id.
+// The failure of storage node is returned in the result of node, so that the others are warmed continually,
+// because the warm-up is usually requested when some nodes are restarting.
+func (s *brokerWarmUpService) WarmUp(ctx context.Context, req *models.WarmUpRequest) ([]models.WarmUpResult, error) {
+	if err := validateWarmUpRequest(req); err != nil {
+		return nil, err
+	}
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
+	shards, err := collectDatabaseShards(s.routingCache, req.Database)
+	if err != nil {
+		return nil, err
+	}
+	nodes := allReplicas(shards)
+	if len(nodes) == 0 {
+		return nil, errors.Wrapf(errors.ErrDatabaseNotFound, "no storage nodes of database: %s", req.Database)
+	}
+	var results []models.WarmUpResult
+	for _, node := range nodes {
+		nodeResults, err := s.warmUp(ctx, node, req)
+		if err != nil {
+			nodeResults = []models.WarmUpResult{{Error: err.Error()}}
+		}
+		for idx := range nodeResults {
+			nodeResults[idx].Node = node.String()
+		}
+		results = append(results, nodeResults...)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Node != results[j].Node {
+			return results[i].Node < results[j].Node
+		}
+		if results[i].Index != results[j].Index {
+			return results[i].Index
+		}
+		return results[i].ShardID < results[j].ShardID
+	})
+	return results, nil
+}
+
+// warmUp sends the warm-up request to the storage node
+func (s *brokerWarmUpService) warmUp(ctx context.Context, node models.Node,
+	req *models.WarmUpRequest) ([]models.WarmUpResult, error) {
+	client := s.newClient(node)
+	if err := client.Init(); err != nil {
+		s.circuitBreakers.Failure(node.Key())
+		return nil, fmt.Errorf("connect storage node[%s:%d] error:%s", node.IP, node.Port, err)
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	results, err := client.WarmUp(ctx, req)
+	if rpc.IsNodeFailure(err) {
+		s.circuitBreakers.Failure(node.Key())
+	} else {
+		s.circuitBreakers.Success(node.Key())
+	}
+	return results, err
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/broker/rpc"
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/models"
+)
+
+func TestBrokerWarmUpService_WarmUp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	routingCache := NewMockRoutingCache(ctrl)
+	srv := NewBrokerWarmUpService(routingCache, rpc.NewCircuitBreakers(config.CircuitBreaker{}), rpc.NewConnPool())
+
+	req := &models.WarmUpRequest{Database: "warm_up_db", Segments: 2}
+	var (
+		nodes   []models.Node
+		initErr error
+	)
+	srv.(*brokerWarmUpService).newClient = func(node models.Node) rpc.AdminClient {
+		nodes = append(nodes, node)
+		client := rpc.NewMockAdminClient(ctrl)
+		client.EXPECT().Init().Return(initErr)
+		client.EXPECT().Close().Return(nil).AnyTimes()
+		if initErr == nil {
+			client.EXPECT().WarmUp(gomock.Any(), req).
+				Return([]models.WarmUpResult{{ShardID: int(node.Port) - 2000}, {Index: true}}, nil)
+		}
+		return client
+	}
+	// invalid request
+	_, err := srv.WarmUp(context.TODO(), &models.WarmUpRequest{})
+	assert.NotNil(t, err)
+	// database not exist
+	routingCache.EXPECT().Shards("warm_up_db").Return(nil, false)
+	_, err = srv.WarmUp(context.TODO(), req)
+	assert.NotNil(t, err)
+
+	// shard assignment not exist, no nodes to send
+	routingCache.EXPECT().Shards("warm_up_db").Return(nil, true)
+	_, err = srv.WarmUp(context.TODO(), req)
+	assert.NotNil(t, err)
+
+	shardAssign := models.NewShardAssignment()
+	shardAssign.Nodes[1] = models.Node{IP: "127.0.0.1", Port: 2002}
+	shardAssign.Nodes[2] = models.Node{IP: "127.0.0.1", Port: 2001}
+	shardAssign.AddReplica(1, 1)
+	shardAssign.AddReplica(1, 2)
+	shardAssign.AddReplica(2, 2)
+	routingCache.EXPECT().Shards("warm_up_db").Return(assignedShards(shardAssign), true).AnyTimes()
+
+	// request is sent to all replicas, the results are sorted by node, the index's is the first
+	results, err := srv.WarmUp(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Len(t, nodes, 2)
+	assert.Equal(t, []models.WarmUpResult{
+		{Node: "127.0.0.1:2001", Index: true},
+		{Node: "127.0.0.1:2001", ShardID: 1},
+		{Node: "127.0.0.1:2002", Index: true},
+		{Node: "127.0.0.1:2002", ShardID: 2},
+	}, results)
+
+	// the failure of node is returned in its result
+	initErr = fmt.Errorf("err")
+	results, err = srv.WarmUp(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Len(t, results, 2)
+	for _, result := range results {
+		assert.NotEqual(t, "", result.Error)
+	}
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/util"
+	"github.com/eleme/lindb/tsdb"
+)
+
+func TestWarmUpService_WarmUp(t *testing.T) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+
+	storageService := NewStorageService(config.Engine{Path: testPath})
+	srv := NewWarmUpService(storageService)
+
+	// invalid request
+	_, err := srv.WarmUp(context.TODO(), nil)
+	assert.NotNil(t, err)
+	_, err = srv.WarmUp(context.TODO(), &models.WarmUpRequest{})
+	assert.NotNil(t, err)
+	// database not exist
+	req := &models.WarmUpRequest{Database: "warm_up_db"}
+	results, err := srv.WarmUp(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Nil(t, results)
+
+	assert.Nil(t, storageService.CreateShards("warm_up_db", validOption, 1, 2))
+	defer func() {
+		_ = storageService.GetEngine("warm_up_db").Close()
+	}()
+	results, err = srv.WarmUp(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Equal(t, []models.WarmUpResult{{Index: true}, {ShardID: 1}, {ShardID: 2}}, results)
+
+	// the shard not exist is skipped
+	req.ShardIDs = []int{2, 3}
+	results, err = srv.WarmUp(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Equal(t, []models.WarmUpResult{{Index: true}, {ShardID: 2}}, results)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	_, err = srv.WarmUp(ctx, req)
+	assert.NotNil(t, err)
+}
+
+func TestWarmUpEngine(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	engine := tsdb.NewMockEngine(ctrl)
+	index := tsdb.NewMockIndex(ctrl)
+	shard := tsdb.NewMockShard(ctrl)
+	engine.EXPECT().Name().Return("db").AnyTimes()
+	engine.EXPECT().GetIndex().Return(index).AnyTimes()
+	engine.EXPECT().GetShard(1).Return(shard).AnyTimes()
+	index.EXPECT().WarmUp().Return(models.WarmUpStats{Files: 2, Blocks: 3}, nil).AnyTimes()
+	shard.EXPECT().WarmUp(2).Return(models.WarmUpStats{Files: 1}, fmt.Errorf("err")).AnyTimes()
+
+	// the failure of shard is returned in its result
+	results, err := warmUpEngine(context.TODO(), engine, true, []int{1}, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []models.WarmUpResult{
+		{Index: true, WarmUpStats: models.WarmUpStats{Files: 2, Blocks: 3}},
+		{ShardID: 1, WarmUpStats: models.WarmUpStats{Files: 1}, Error: "err"},
+	}, results)
+
+	// the index isn't warmed if the engine has been opened
+	results, err = warmUpEngine(context.TODO(), engine, false, []int{1}, 2)
+	assert.Nil(t, err)
+	assert.Len(t, results, 1)
+
+	autoWarmUp(engine, true, []int{1}, 2)
+}
+
+func TestStorageService_CreateShards_warmUp(t *testing.T) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+
+	storageService := NewStorageService(config.Engine{Path: testPath, WarmUpSegments: 1})
+	assert.Nil(t, storageService.CreateShards("warm_up_db", validOption, 1))
+	// the shard opened is warmed in background
+	assert.Nil(t, storageService.CreateShards("warm_up_db", validOption, 1, 2))
+	assert.NotNil(t, storageService.GetShard("warm_up_db", 2))
+	time.Sleep(100 * time.Millisecond)
+	_ = storageService.GetEngine("warm_up_db").Close()
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/wasm"
+	"github.com/eleme/lindb/query"
+)
+
+// Defines the functions exported by the wasm module of user defined function
+const (
+	// defaultWASMEvalSymbol is the default name of eval function,
+	// eval(interval i64, series i32, seriesCount i32, pointCount i32, params i32, paramCount i32) i32,
+	// series are the values of series args in order, params are the values of number/duration args,
+	// returns the offset of pointCount values in memory.
+	defaultWASMEvalSymbol = "eval"
+	// wasmAllocSymbol is the name of alloc function, alloc(size i32) i32, returns the offset of memory allocated
+	wasmAllocSymbol = "alloc"
+	// wasmLookbackSymbol is the name of optional lookback function,
+	// lookback(interval i64, params i32, paramCount i32) i64, returns how long(ms) the function needs before start.
+	wasmLookbackSymbol = "lookback"
+)
+
+// wasmUDFConfig is the resource limits of each call of wasm function, the instructions are limited by default
+var wasmUDFConfig = wasm.Config{
+	MaxMemoryPages: 1024, // 64MiB
+}
+
+// function signatures of wasm module
+var (
+	wasmAllocType = wasm.FuncType{Params: []wasm.ValueType{wasm.I32}, Results: []wasm.ValueType{wasm.I32}}
+	wasmEvalType  = wasm.FuncType{
+		Params:  []wasm.ValueType{wasm.I64, wasm.I32, wasm.I32, wasm.I32, wasm.I32, wasm.I32},
+		Results: []wasm.ValueType{wasm.I32},
+	}
+	wasmLookbackType = wasm.FuncType{
+		Params:  []wasm.ValueType{wasm.I64, wasm.I32, wasm.I32},
+		Results: []wasm.ValueType{wasm.I64},
+	}
+)
+
+// LoadWASMUDF loads the user defined function from WebAssembly module, the module must define the memory
+// and export the alloc/eval functions, the values of float64 are passed by memory in little endian.
+// The module cannot import anything, each call runs in a new instance whose memory and instructions are
+// limited, so that the function cannot access the broker and the state of calls isn't shared.
+func LoadWASMUDF(definition models.UDFDefinition) (query.UDF, error) {
+	data, err := ioutil.ReadFile(definition.WASM)
+	if err != nil {
+		return nil, fmt.Errorf("read wasm module[%s] error:%s", definition.WASM, err)
+	}
+	module, err := wasm.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("load wasm module[%s] error:%s", definition.WASM, err)
+	}
+	if module.Memory == nil {
+		return nil, fmt.Errorf("wasm module[%s] doesn't define memory", definition.WASM)
+	}
+	eval := definition.Symbol
+	if len(eval) == 0 {
+		eval = defaultWASMEvalSymbol
+	}
+	checkFunc := func(name string, expect wasm.FuncType) error {
+		funcType, ok := module.ExportedFunc(name)
+		if !ok {
+			return fmt.Errorf("wasm module[%s] doesn't export function[%s]", definition.WASM, name)
+		}
+		if !funcType.Equal(expect) {
+			return fmt.Errorf("function[%s] of wasm module[%s] has wrong signature", name, definition.WASM)
+		}
+		return nil
+	}
+	if err := checkFunc(wasmAllocSymbol, wasmAllocType); err != nil {
+		return nil, err
+	}
+	if err := checkFunc(eval, wasmEvalType); err != nil {
+		return nil, err
+	}
+	udf := &wasmUDF{module: module, eval: eval}
+	if _, ok := module.ExportedFunc(wasmLookbackSymbol); ok {
+		if err := checkFunc(wasmLookbackSymbol, wasmLookbackType); err != nil {
+			return nil, err
+		}
+		udf.lookback = true
+	}
+	// instantiates the module once, so that the module exceeding limits fails fast
+	if _, err := wasm.Instantiate(module, wasmUDFConfig); err != nil {
+		return nil, fmt.Errorf("instantiate wasm module[%s] error:%s", definition.WASM, err)
+	}
+	return udf, nil
+}
+
+// wasmUDF implements query.UDF interface, which evaluates the function of wasm module
+type wasmUDF struct {
+	module   *wasm.Module
+	eval     string
+	lookback bool
+}
+
+// Eval writes the values of series and params into the memory of new instance, then calls the eval function,
+// returns the values in memory at the offset returned.
+func (f *wasmUDF) Eval(interval int64, series [][]float64, params []float64) ([]float64, error) {
+	if len(series) == 0 {
+		return nil, errors.New("wasm function requires series args")
+	}
+	pointCount := len(series[0])
+	vm, err := wasm.Instantiate(f.module, wasmUDFConfig)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]float64, 0, len(series)*pointCount+len(params))
+	for _, s := range series {
+		if len(s) != pointCount {
+			return nil, errors.New("series args have different point count")
+		}
+		values = append(values, s...)
+	}
+	values = append(values, params...)
+	ptr, err := f.write(vm, values)
+	if err != nil {
+		return nil, err
+	}
+	paramsPtr := ptr + uint32(len(series)*pointCount*8)
+	results, err := vm.Call(f.eval, uint64(interval), uint64(ptr), uint64(len(series)), uint64(pointCount),
+		uint64(paramsPtr), uint64(len(params)))
+	if err != nil {
+		return nil, err
+	}
+	return vm.ReadFloat64s(uint32(results[0]), pointCount)
+}
+
+// Lookback calls the lookback function with params if exported, returns 0 if not exported or error
+func (f *wasmUDF) Lookback(interval int64, params []float64) int64 {
+	if !f.lookback {
+		return 0
+	}
+	vm, err := wasm.Instantiate(f.module, wasmUDFConfig)
+	if err != nil {
+		return 0
+	}
+	ptr, err := f.write(vm, params)
+	if err != nil {
+		return 0
+	}
+	results, err := vm.Call(wasmLookbackSymbol, uint64(interval), uint64(ptr), uint64(len(params)))
+	if err != nil {
+		return 0
+	}
+	return int64(results[0])
+}
+
+// write allocates the memory by alloc function of module, then writes the values, returns the offset
+func (f *wasmUDF) write(vm *wasm.Instance, values []float64) (uint32, error) {
+	size := len(values) * 8
+	if size > math.MaxInt32 {
+		return 0, errors.New("args of wasm function are too large")
+	}
+	results, err := vm.Call(wasmAllocSymbol, uint64(size))
+	if err != nil {
+		return 0, err
+	}
+	ptr := uint32(results[0])
+	if err := vm.WriteFloat64s(ptr, values); err != nil {
+		return 0, err
+	}
+	return ptr, nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+)
+
+func wasmSection(id byte, payload ...byte) []byte {
+	// the payloads of test module are less than 128 bytes
+	return append([]byte{id, byte(len(payload))}, payload...)
+}
+
+func wasmCode(body ...byte) []byte {
+	return append([]byte{byte(len(body))}, body...)
+}
+
+// testWASMModule returns the module whose eval adds params[0] to the first series,
+// spin loops forever, lookback returns 2 intervals.
+func testWASMModule() []byte {
+	var module []byte
+	module = append(module, 0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00)
+	// types: alloc, eval, lookback
+	module = append(module, wasmSection(1,
+		0x03,
+		0x60, 0x01, 0x7f, 0x01, 0x7f,
+		0x60, 0x06, 0x7e, 0x7f, 0x7f, 0x7f, 0x7f, 0x7f, 0x01, 0x7f,
+		0x60, 0x03, 0x7e, 0x7f, 0x7f, 0x01, 0x7e)...)
+	// functions: alloc, eval, lookback, spin
+	module = append(module, wasmSection(3, 0x04, 0x00, 0x01, 0x02, 0x01)...)
+	// memory: 1 page
+	module = append(module, wasmSection(5, 0x01, 0x00, 0x01)...)
+	// global: heap = 1024
+	module = append(module, wasmSection(6, 0x01, 0x7f, 0x01, 0x41, 0x80, 0x08, 0x0b)...)
+	module = append(module, wasmSection(7,
+		0x05,
+		0x06, 'm', 'e', 'm', 'o', 'r', 'y', 0x02, 0x00,
+		0x05, 'a', 'l', 'l', 'o', 'c', 0x00, 0x00,
+		0x04, 'e', 'v', 'a', 'l', 0x00, 0x01,
+		0x08, 'l', 'o', 'o', 'k', 'b', 'a', 'c', 'k', 0x00, 0x02,
+		0x04, 's', 'p', 'i', 'n', 0x00, 0x03)...)
+	var codes []byte
+	codes = append(codes, 0x04)
+	// alloc: returns the heap, then moves the heap by size
+	codes = append(codes, wasmCode(0x00, 0x23, 0x00, 0x23, 0x00, 0x20, 0x00, 0x6a, 0x24, 0x00, 0x0b)...)
+	// eval: adds params[0] to the values of first series in place
+	codes = append(codes, wasmCode(0x01, 0x01, 0x7f,
+		0x20, 0x01, 0x21, 0x06,
+		0x02, 0x40, 0x03, 0x40,
+		0x20, 0x03, 0x45, 0x0d, 0x01,
+		0x20, 0x06, 0x20, 0x06, 0x2b, 0x03, 0x00, 0x20, 0x04, 0x2b, 0x03, 0x00, 0xa0, 0x39, 0x03, 0x00,
+		0x20, 0x06, 0x41, 0x08, 0x6a, 0x21, 0x06,
+		0x20, 0x03, 0x41, 0x01, 0x6b, 0x21, 0x03,
+		0x0c, 0x00, 0x0b, 0x0b,
+		0x20, 0x01, 0x0b)...)
+	// lookback: interval * 2
+	codes = append(codes, wasmCode(0x00, 0x20, 0x00, 0x42, 0x02, 0x7e, 0x0b)...)
+	// spin: loops forever
+	codes = append(codes, wasmCode(0x00, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x41, 0x00, 0x0b)...)
+	return append(module, wasmSection(10, codes...)...)
+}
+
+func TestLoadWASMUDF(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wasm_udf")
+	assert.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	path := filepath.Join(dir, "smooth.wasm")
+	assert.NoError(t, ioutil.WriteFile(path, testWASMModule(), 0644))
+	invalid := filepath.Join(dir, "invalid.wasm")
+	assert.NoError(t, ioutil.WriteFile(invalid, []byte("invalid"), 0644))
+
+	definition := models.UDFDefinition{Database: "db", Name: "smooth", WASM: path}
+	udf, err := NewUDFLoader(false)(definition)
+	assert.NoError(t, err)
+	values, err := udf.Eval(10, [][]float64{{1, 2, math.NaN()}, {4, 5, 6}}, []float64{0.5})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1.5, 2.5}, values[:2])
+	assert.True(t, math.IsNaN(values[2]))
+	// each call runs in new instance
+	values, err = udf.Eval(10, [][]float64{{1}}, []float64{1})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{2}, values)
+	assert.Equal(t, int64(20), udf.Lookback(10, nil))
+
+	_, err = udf.Eval(10, nil, nil)
+	assert.Error(t, err)
+	_, err = udf.Eval(10, [][]float64{{1}, {1, 2}}, nil)
+	assert.Error(t, err)
+
+	// the function is stopped by the limit of instructions
+	cfg := wasmUDFConfig
+	defer func() {
+		wasmUDFConfig = cfg
+	}()
+	wasmUDFConfig.MaxInstructions = 10000
+	definition.Symbol = "spin"
+	udf, err = LoadWASMUDF(definition)
+	assert.NoError(t, err)
+	_, err = udf.Eval(10, [][]float64{{1}}, nil)
+	assert.Error(t, err)
+
+	for _, d := range []models.UDFDefinition{
+		{WASM: filepath.Join(dir, "not_exist.wasm")},
+		{WASM: invalid},
+		{WASM: path, Symbol: "not_exist"},
+		{WASM: path, Symbol: "alloc"},
+	} {
+		_, err = LoadWASMUDF(d)
+		assert.Error(t, err)
+	}
+}
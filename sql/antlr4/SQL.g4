@@ -140,10 +140,9 @@ boolExprBinaryOperator:
                          ;
 
 expr                     :
-                           expr T_MUL expr
-                         | expr T_DIV expr
-                         | expr T_ADD expr
-                         | expr T_SUB expr
+                           T_SUB expr
+                         | expr (T_MUL | T_DIV) expr
+                         | expr (T_ADD | T_SUB) expr
                          | T_OPEN_P expr T_CLOSE_P
                          | exprFunc
                          | exprAtom
@@ -168,6 +167,7 @@ funcParam               :
                          ;
 exprAtom                :
                            ident identFilter?
+                         | metricFieldRef
                          | decNumber
                          | intNumber
                          ;
@@ -241,6 +241,9 @@ nonReservedWords      :
                         | T_YEAR
                         ;
 
+// Field of metric, the metric can be qualified by database, like db::metric:field
+metricFieldRef          : (databaseName T_COLON T_COLON)? metricName T_COLON ident ;
+
 // Lexer rules
 T_CREATE             : C R E A T E                      ;
 T_UPDATE             : U P D A T E                      ;
@@ -351,7 +354,7 @@ fragment L_DIGIT     : [0-9] ;
 fragment L_ID_PART   :
                       [a-zA-Z] ([a-zA-Z] | L_DIGIT | '_' | '.')*                                            // Identifier part
                       | '$' '{' .*? '}'
-                      | ('_' | '@' | ':' | '#' | '$') ([a-zA-Z] | L_DIGIT | '_' | '@' | ':' | '#' | '$')+     // (at least one char must follow special char)
+                      | ('_' | '@' | '#' | '$') ([a-zA-Z] | L_DIGIT | '_' | '@' | '#' | '$')+                 // (at least one char must follow special char)
                       | '"' .*? '"'                                                                           // Quoted identifiers
                       | '`' .*? '`'                                                                           // Quoted identifiers
                       | '\'' .*? '\''                                                                           // Quoted identifiers
@@ -0,0 +1,265 @@
+package sql
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/antlr/antlr4/runtime/Go/antlr"
+
+	parser "github.com/eleme/lindb/sql/grammar"
+	"github.com/eleme/lindb/sql/util"
+)
+
+// BinaryOperator represents the arithmetic operator between two expressions
+type BinaryOperator int
+
+// Defines all arithmetic operators
+const (
+	ADD BinaryOperator = iota + 1
+	SUB
+	MUL
+	DIV
+)
+
+// String returns the symbol of operator
+func (op BinaryOperator) String() string {
+	switch op {
+	case ADD:
+		return "+"
+	case SUB:
+		return "-"
+	case MUL:
+		return "*"
+	case DIV:
+		return "/"
+	default:
+		return "unknown"
+	}
+}
+
+// Expr represents the expression of select field, which is parsed by the expr rule of LinQL grammar
+type Expr interface {
+	// String returns the string format of expression
+	String() string
+}
+
+// FieldExpr represents the reference of field
+type FieldExpr struct {
+	Name string
+}
+
+// String returns the field name
+func (e *FieldExpr) String() string {
+	return e.Name
+}
+
+// MetricFieldExpr represents the reference of metric's field in the query across metrics
+type MetricFieldExpr struct {
+	Metric string
+	Field  string
+}
+
+// String returns the metric field reference, like metric:field
+func (e *MetricFieldExpr) String() string {
+	return e.Metric + ":" + e.Field
+}
+
+// NumberExpr represents the number literal
+type NumberExpr struct {
+	Value float64
+}
+
+// String returns the number literal
+func (e *NumberExpr) String() string {
+	return fmt.Sprintf("%g", e.Value)
+}
+
+// DurationExpr represents the duration literal(ms), such as 1w/1d
+type DurationExpr struct {
+	Value int64
+}
+
+// String returns the duration literal
+func (e *DurationExpr) String() string {
+	return fmt.Sprintf("%dms", e.Value)
+}
+
+// BinaryExpr represents the arithmetic between two expressions
+type BinaryExpr struct {
+	Op    BinaryOperator
+	Left  Expr
+	Right Expr
+}
+
+// String returns the string format of binary expression
+func (e *BinaryExpr) String() string {
+	return fmt.Sprintf("(%s%s%s)", e.Left, e.Op, e.Right)
+}
+
+// CallExpr represents the function call
+type CallExpr struct {
+	Name string
+	Args []Expr
+}
+
+// String returns the string format of function call
+func (e *CallExpr) String() string {
+	result := e.Name + "("
+	for i, arg := range e.Args {
+		if i > 0 {
+			result += ","
+		}
+		result += arg.String()
+	}
+	return result + ")"
+}
+
+// exprErrorListener records the first syntax error of parsing expression
+type exprErrorListener struct {
+	*antlr.DefaultErrorListener
+	err error
+}
+
+// SyntaxError records the syntax error if no error recorded
+func (l *exprErrorListener) SyntaxError(recognizer antlr.Recognizer, offendingSymbol interface{},
+	line, column int, msg string, e antlr.RecognitionException) {
+	if l.err == nil {
+		l.err = fmt.Errorf("parse expression error at %d: %s", column, msg)
+	}
+}
+
+// ParseExpr parses the expression by the expr rule of LinQL grammar, supports number/duration(such as 1w, 5m)
+// literal, arithmetic(+ - * /), parentheses and function call.
+// The field reference is written as field for single metric or metric:field across metrics,
+// e.g. errors:count/requests:count*100.
+// The metric qualified by database(database::metric:field) isn't supported in expression,
+// the query across databases unions the series of each database by the from clause of query.
+func ParseExpr(expr string) (result Expr, err error) {
+	errorListener := &exprErrorListener{DefaultErrorListener: antlr.NewDefaultErrorListener()}
+	lexer := parser.NewSQLLexer(antlr.NewInputStream(expr))
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(errorListener)
+	tokens := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+	p := parser.NewSQLParser(tokens)
+	p.RemoveErrorListeners()
+	p.AddErrorListener(errorListener)
+
+	ctx := p.Expr().(*parser.ExprContext)
+	if errorListener.err != nil {
+		return nil, fmt.Errorf("parse expression[%s] error: %s", expr, errorListener.err)
+	}
+	if token := tokens.LT(1); token.GetTokenType() != antlr.TokenEOF {
+		return nil, fmt.Errorf("parse expression[%s] error: unexpected '%s'", expr, token.GetText())
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("parse expression[%s] error: %v", expr, r)
+		}
+	}()
+	return buildExpr(ctx), nil
+}
+
+// buildExpr builds the expression from the parse tree of expr rule
+func buildExpr(ctx *parser.ExprContext) Expr {
+	switch {
+	case ctx.ExprAtom() != nil:
+		return buildExprAtom(ctx.ExprAtom().(*parser.ExprAtomContext))
+	case ctx.ExprFunc() != nil:
+		return buildCall(ctx.ExprFunc().(*parser.ExprFuncContext))
+	case ctx.DurationLit() != nil:
+		return &DurationExpr{Value: parseDuration(ctx.DurationLit().(*parser.DurationLitContext))}
+	}
+	exprs := ctx.AllExpr()
+	switch len(exprs) {
+	case 1:
+		expr := buildExpr(exprs[0].(*parser.ExprContext))
+		if ctx.T_SUB() == nil {
+			// parentheses
+			return expr
+		}
+		// negative sign
+		switch e := expr.(type) {
+		case *NumberExpr:
+			e.Value = -e.Value
+			return e
+		case *DurationExpr:
+			e.Value = -e.Value
+			return e
+		default:
+			return &BinaryExpr{Op: SUB, Left: &NumberExpr{Value: 0}, Right: expr}
+		}
+	case 2:
+		var op BinaryOperator
+		switch {
+		case ctx.T_MUL() != nil:
+			op = MUL
+		case ctx.T_DIV() != nil:
+			op = DIV
+		case ctx.T_ADD() != nil:
+			op = ADD
+		case ctx.T_SUB() != nil:
+			op = SUB
+		default:
+			panic(fmt.Sprintf("unknown operator of expr[%s]", ctx.GetText()))
+		}
+		return &BinaryExpr{
+			Op:    op,
+			Left:  buildExpr(exprs[0].(*parser.ExprContext)),
+			Right: buildExpr(exprs[1].(*parser.ExprContext)),
+		}
+	default:
+		panic(fmt.Sprintf("unknown expr[%s]", ctx.GetText()))
+	}
+}
+
+// buildExprAtom builds the field reference or number literal
+func buildExprAtom(ctx *parser.ExprAtomContext) Expr {
+	switch {
+	case ctx.Ident() != nil:
+		if ctx.IdentFilter() != nil {
+			panic(fmt.Sprintf("filter of field[%s] is not supported in expression", ctx.Ident().GetText()))
+		}
+		return &FieldExpr{Name: util.GetStringValue(ctx.Ident().GetText())}
+	case ctx.MetricFieldRef() != nil:
+		ref := ctx.MetricFieldRef().(*parser.MetricFieldRefContext)
+		if ref.DatabaseName() != nil {
+			panic(fmt.Sprintf("database of metric field[%s] is not supported in expression", ref.GetText()))
+		}
+		return &MetricFieldExpr{
+			Metric: util.GetStringValue(ref.MetricName().GetText()),
+			Field:  util.GetStringValue(ref.Ident().GetText()),
+		}
+	case ctx.IntNumber() != nil:
+		return &NumberExpr{Value: parseNumber(ctx.IntNumber().GetText())}
+	case ctx.DecNumber() != nil:
+		return &NumberExpr{Value: parseNumber(ctx.DecNumber().GetText())}
+	default:
+		panic(fmt.Sprintf("unknown expr atom[%s]", ctx.GetText()))
+	}
+}
+
+// buildCall builds the function call with the args
+func buildCall(ctx *parser.ExprFuncContext) Expr {
+	call := &CallExpr{Name: util.GetStringValue(ctx.Ident().GetText())}
+	if ctx.ExprFuncParams() == nil {
+		return call
+	}
+	params := ctx.ExprFuncParams().(*parser.ExprFuncParamsContext)
+	for _, p := range params.AllFuncParam() {
+		param := p.(*parser.FuncParamContext)
+		if param.Expr() == nil {
+			panic(fmt.Sprintf("param[%s] of function[%s] is not expression", param.GetText(), call.Name))
+		}
+		call.Args = append(call.Args, buildExpr(param.Expr().(*parser.ExprContext)))
+	}
+	return call
+}
+
+// parseNumber parses the number literal
+func parseNumber(text string) float64 {
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		panic(fmt.Sprintf("invalid number[%s]", text))
+	}
+	return value
+}
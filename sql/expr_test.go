@@ -0,0 +1,57 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/sql/util"
+)
+
+func TestParseExpr(t *testing.T) {
+	cases := map[string]string{
+		"f":                                   "f",
+		"1.5":                                 "1.5",
+		"a+b*c":                               "(a+(b*c))",
+		"a*b+c":                               "((a*b)+c)",
+		"(a+b)*c":                             "((a+b)*c)",
+		"a-b-c":                               "((a-b)-c)",
+		"a/b*c":                               "((a/b)*c)",
+		"a/b/2":                               "((a/b)/2)",
+		"-a":                                  "(0-a)",
+		"-a+b":                                "((0-a)+b)",
+		"-2*a":                                "(-2*a)",
+		"a-1":                                 "(a-1)",
+		"errors:count / requests:count * 100": "((errors:count/requests:count)*100)",
+		"cpu.load:f1 + cpu.load:f2":           "(cpu.load:f1+cpu.load:f2)",
+		"moving_average(f, 5)":                "moving_average(f,5)",
+		"time_shift(f, 1w)":                   "time_shift(f,604800000ms)",
+		"time_shift(f, -1d)":                  "time_shift(f,-86400000ms)",
+		"f - time_shift(f,1h)":                "(f-time_shift(f,3600000ms))",
+		"rate()":                              "rate()",
+		"derivative(moving_average(a:b,2))":   "derivative(moving_average(a:b,2))",
+		"cpu.load :used":                      "cpu.load:used",
+	}
+	for input, expect := range cases {
+		expr, err := ParseExpr(input)
+		assert.Nil(t, err, input)
+		if err == nil {
+			assert.Equal(t, expect, expr.String(), input)
+		}
+	}
+
+	expr, _ := ParseExpr("errors:count/requests:count")
+	binary, ok := expr.(*BinaryExpr)
+	assert.True(t, ok)
+	assert.Equal(t, &MetricFieldExpr{Metric: "errors", Field: "count"}, binary.Left)
+	expr, _ = ParseExpr("5m")
+	assert.Equal(t, &DurationExpr{Value: 5 * util.OneMinute}, expr)
+
+	errCases := []string{"", "a+", "(a+b", "a b", "f(a,", "f(a b)", "1.2.3", "1.5w", "a:", "a:1", "#", "a)",
+		"db::", "db::cpu", "db::1:f", "db::cpu+1", "f[host='a']", "f(host='a')",
+		"db1::cpu:used", "db1::cpu:used + db2::cpu.load :used"}
+	for _, input := range errCases {
+		_, err := ParseExpr(input)
+		assert.NotNil(t, err, input)
+	}
+}
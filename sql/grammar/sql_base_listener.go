@@ -506,3 +506,9 @@ func (s *BaseSQLListener) EnterNonReservedWords(ctx *NonReservedWordsContext) {}
 
 // ExitNonReservedWords is called when production nonReservedWords is exited.
 func (s *BaseSQLListener) ExitNonReservedWords(ctx *NonReservedWordsContext) {}
+
+// EnterMetricFieldRef is called when production metricFieldRef is entered.
+func (s *BaseSQLListener) EnterMetricFieldRef(ctx *MetricFieldRefContext) {}
+
+// ExitMetricFieldRef is called when production metricFieldRef is exited.
+func (s *BaseSQLListener) ExitMetricFieldRef(ctx *MetricFieldRefContext) {}
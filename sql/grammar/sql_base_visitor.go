@@ -331,3 +331,7 @@ func (v *BaseSQLVisitor) VisitIdent(ctx *IdentContext) interface{} {
 func (v *BaseSQLVisitor) VisitNonReservedWords(ctx *NonReservedWordsContext) interface{} {
 	return v.VisitChildren(ctx)
 }
+
+func (v *BaseSQLVisitor) VisitMetricFieldRef(ctx *MetricFieldRefContext) interface{} {
+	return v.VisitChildren(ctx)
+}
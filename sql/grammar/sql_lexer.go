@@ -110,167 +110,167 @@ var serializedLexerAtn = []uint16{
 	2, 209, 2, 211, 2, 213, 2, 215, 2, 217, 2, 219, 2, 221, 2, 223, 2, 225,
 	2, 227, 2, 229, 2, 231, 2, 233, 2, 235, 2, 237, 2, 239, 2, 241, 2, 243,
 	2, 3, 2, 34, 3, 2, 48, 48, 5, 2, 11, 12, 15, 15, 34, 34, 3, 2, 50, 59,
-	4, 2, 67, 92, 99, 124, 4, 2, 48, 48, 97, 97, 6, 2, 37, 38, 60, 60, 66,
-	66, 97, 97, 4, 2, 67, 67, 99, 99, 4, 2, 68, 68, 100, 100, 4, 2, 69, 69,
-	101, 101, 4, 2, 70, 70, 102, 102, 4, 2, 71, 71, 103, 103, 4, 2, 72, 72,
-	104, 104, 4, 2, 73, 73, 105, 105, 4, 2, 74, 74, 106, 106, 4, 2, 75, 75,
-	107, 107, 4, 2, 76, 76, 108, 108, 4, 2, 77, 77, 109, 109, 4, 2, 78, 78,
-	110, 110, 4, 2, 79, 79, 111, 111, 4, 2, 80, 80, 112, 112, 4, 2, 81, 81,
-	113, 113, 4, 2, 82, 82, 114, 114, 4, 2, 83, 83, 115, 115, 4, 2, 84, 84,
-	116, 116, 4, 2, 85, 85, 117, 117, 4, 2, 86, 86, 118, 118, 4, 2, 87, 87,
-	119, 119, 4, 2, 88, 88, 120, 120, 4, 2, 89, 89, 121, 121, 4, 2, 90, 90,
-	122, 122, 4, 2, 91, 91, 123, 123, 4, 2, 92, 92, 124, 124, 2, 801, 2, 3,
-	3, 2, 2, 2, 2, 5, 3, 2, 2, 2, 2, 7, 3, 2, 2, 2, 2, 9, 3, 2, 2, 2, 2, 11,
-	3, 2, 2, 2, 2, 13, 3, 2, 2, 2, 2, 15, 3, 2, 2, 2, 2, 17, 3, 2, 2, 2, 2,
-	19, 3, 2, 2, 2, 2, 21, 3, 2, 2, 2, 2, 23, 3, 2, 2, 2, 2, 25, 3, 2, 2, 2,
-	2, 27, 3, 2, 2, 2, 2, 29, 3, 2, 2, 2, 2, 31, 3, 2, 2, 2, 2, 33, 3, 2, 2,
-	2, 2, 35, 3, 2, 2, 2, 2, 37, 3, 2, 2, 2, 2, 39, 3, 2, 2, 2, 2, 41, 3, 2,
-	2, 2, 2, 43, 3, 2, 2, 2, 2, 45, 3, 2, 2, 2, 2, 47, 3, 2, 2, 2, 2, 49, 3,
-	2, 2, 2, 2, 51, 3, 2, 2, 2, 2, 53, 3, 2, 2, 2, 2, 55, 3, 2, 2, 2, 2, 57,
-	3, 2, 2, 2, 2, 59, 3, 2, 2, 2, 2, 61, 3, 2, 2, 2, 2, 63, 3, 2, 2, 2, 2,
-	65, 3, 2, 2, 2, 2, 67, 3, 2, 2, 2, 2, 69, 3, 2, 2, 2, 2, 71, 3, 2, 2, 2,
-	2, 73, 3, 2, 2, 2, 2, 75, 3, 2, 2, 2, 2, 77, 3, 2, 2, 2, 2, 79, 3, 2, 2,
-	2, 2, 81, 3, 2, 2, 2, 2, 83, 3, 2, 2, 2, 2, 85, 3, 2, 2, 2, 2, 87, 3, 2,
-	2, 2, 2, 89, 3, 2, 2, 2, 2, 91, 3, 2, 2, 2, 2, 93, 3, 2, 2, 2, 2, 95, 3,
-	2, 2, 2, 2, 97, 3, 2, 2, 2, 2, 99, 3, 2, 2, 2, 2, 101, 3, 2, 2, 2, 2, 103,
-	3, 2, 2, 2, 2, 105, 3, 2, 2, 2, 2, 107, 3, 2, 2, 2, 2, 109, 3, 2, 2, 2,
-	2, 111, 3, 2, 2, 2, 2, 113, 3, 2, 2, 2, 2, 115, 3, 2, 2, 2, 2, 117, 3,
-	2, 2, 2, 2, 119, 3, 2, 2, 2, 2, 121, 3, 2, 2, 2, 2, 123, 3, 2, 2, 2, 2,
-	125, 3, 2, 2, 2, 2, 127, 3, 2, 2, 2, 2, 129, 3, 2, 2, 2, 2, 131, 3, 2,
-	2, 2, 2, 133, 3, 2, 2, 2, 2, 135, 3, 2, 2, 2, 2, 137, 3, 2, 2, 2, 2, 139,
-	3, 2, 2, 2, 2, 141, 3, 2, 2, 2, 2, 143, 3, 2, 2, 2, 2, 145, 3, 2, 2, 2,
-	2, 147, 3, 2, 2, 2, 2, 149, 3, 2, 2, 2, 2, 151, 3, 2, 2, 2, 2, 153, 3,
-	2, 2, 2, 2, 155, 3, 2, 2, 2, 2, 157, 3, 2, 2, 2, 2, 159, 3, 2, 2, 2, 2,
-	161, 3, 2, 2, 2, 2, 163, 3, 2, 2, 2, 2, 165, 3, 2, 2, 2, 2, 167, 3, 2,
-	2, 2, 2, 169, 3, 2, 2, 2, 2, 171, 3, 2, 2, 2, 2, 173, 3, 2, 2, 2, 2, 175,
-	3, 2, 2, 2, 2, 177, 3, 2, 2, 2, 2, 179, 3, 2, 2, 2, 2, 181, 3, 2, 2, 2,
-	2, 183, 3, 2, 2, 2, 2, 185, 3, 2, 2, 2, 3, 245, 3, 2, 2, 2, 5, 252, 3,
-	2, 2, 2, 7, 259, 3, 2, 2, 2, 9, 263, 3, 2, 2, 2, 11, 268, 3, 2, 2, 2, 13,
-	277, 3, 2, 2, 2, 15, 282, 3, 2, 2, 2, 17, 288, 3, 2, 2, 2, 19, 300, 3,
-	2, 2, 2, 21, 304, 3, 2, 2, 2, 23, 312, 3, 2, 2, 2, 25, 320, 3, 2, 2, 2,
-	27, 330, 3, 2, 2, 2, 29, 335, 3, 2, 2, 2, 31, 338, 3, 2, 2, 2, 33, 343,
-	3, 2, 2, 2, 35, 352, 3, 2, 2, 2, 37, 362, 3, 2, 2, 2, 39, 367, 3, 2, 2,
-	2, 41, 380, 3, 2, 2, 2, 43, 392, 3, 2, 2, 2, 45, 398, 3, 2, 2, 2, 47, 402,
-	3, 2, 2, 2, 49, 407, 3, 2, 2, 2, 51, 412, 3, 2, 2, 2, 53, 416, 3, 2, 2,
-	2, 55, 421, 3, 2, 2, 2, 57, 428, 3, 2, 2, 2, 59, 434, 3, 2, 2, 2, 61, 439,
-	3, 2, 2, 2, 63, 445, 3, 2, 2, 2, 65, 451, 3, 2, 2, 2, 67, 459, 3, 2, 2,
-	2, 69, 465, 3, 2, 2, 2, 71, 473, 3, 2, 2, 2, 73, 483, 3, 2, 2, 2, 75, 490,
-	3, 2, 2, 2, 77, 493, 3, 2, 2, 2, 79, 497, 3, 2, 2, 2, 81, 500, 3, 2, 2,
-	2, 83, 505, 3, 2, 2, 2, 85, 510, 3, 2, 2, 2, 87, 519, 3, 2, 2, 2, 89, 525,
-	3, 2, 2, 2, 91, 529, 3, 2, 2, 2, 93, 534, 3, 2, 2, 2, 95, 539, 3, 2, 2,
-	2, 97, 543, 3, 2, 2, 2, 99, 551, 3, 2, 2, 2, 101, 554, 3, 2, 2, 2, 103,
-	560, 3, 2, 2, 2, 105, 567, 3, 2, 2, 2, 107, 570, 3, 2, 2, 2, 109, 574,
-	3, 2, 2, 2, 111, 580, 3, 2, 2, 2, 113, 585, 3, 2, 2, 2, 115, 589, 3, 2,
-	2, 2, 117, 592, 3, 2, 2, 2, 119, 596, 3, 2, 2, 2, 121, 604, 3, 2, 2, 2,
-	123, 606, 3, 2, 2, 2, 125, 608, 3, 2, 2, 2, 127, 610, 3, 2, 2, 2, 129,
-	612, 3, 2, 2, 2, 131, 614, 3, 2, 2, 2, 133, 616, 3, 2, 2, 2, 135, 618,
-	3, 2, 2, 2, 137, 620, 3, 2, 2, 2, 139, 622, 3, 2, 2, 2, 141, 624, 3, 2,
-	2, 2, 143, 627, 3, 2, 2, 2, 145, 630, 3, 2, 2, 2, 147, 632, 3, 2, 2, 2,
-	149, 635, 3, 2, 2, 2, 151, 637, 3, 2, 2, 2, 153, 640, 3, 2, 2, 2, 155,
-	643, 3, 2, 2, 2, 157, 645, 3, 2, 2, 2, 159, 647, 3, 2, 2, 2, 161, 649,
-	3, 2, 2, 2, 163, 651, 3, 2, 2, 2, 165, 653, 3, 2, 2, 2, 167, 655, 3, 2,
-	2, 2, 169, 657, 3, 2, 2, 2, 171, 659, 3, 2, 2, 2, 173, 661, 3, 2, 2, 2,
-	175, 663, 3, 2, 2, 2, 177, 665, 3, 2, 2, 2, 179, 667, 3, 2, 2, 2, 181,
-	670, 3, 2, 2, 2, 183, 693, 3, 2, 2, 2, 185, 696, 3, 2, 2, 2, 187, 702,
-	3, 2, 2, 2, 189, 704, 3, 2, 2, 2, 191, 756, 3, 2, 2, 2, 193, 758, 3, 2,
-	2, 2, 195, 760, 3, 2, 2, 2, 197, 762, 3, 2, 2, 2, 199, 764, 3, 2, 2, 2,
-	201, 766, 3, 2, 2, 2, 203, 768, 3, 2, 2, 2, 205, 770, 3, 2, 2, 2, 207,
-	772, 3, 2, 2, 2, 209, 774, 3, 2, 2, 2, 211, 776, 3, 2, 2, 2, 213, 778,
-	3, 2, 2, 2, 215, 780, 3, 2, 2, 2, 217, 782, 3, 2, 2, 2, 219, 784, 3, 2,
-	2, 2, 221, 786, 3, 2, 2, 2, 223, 788, 3, 2, 2, 2, 225, 790, 3, 2, 2, 2,
-	227, 792, 3, 2, 2, 2, 229, 794, 3, 2, 2, 2, 231, 796, 3, 2, 2, 2, 233,
-	798, 3, 2, 2, 2, 235, 800, 3, 2, 2, 2, 237, 802, 3, 2, 2, 2, 239, 804,
-	3, 2, 2, 2, 241, 806, 3, 2, 2, 2, 243, 808, 3, 2, 2, 2, 245, 246, 5, 197,
-	99, 2, 246, 247, 5, 227, 114, 2, 247, 248, 5, 201, 101, 2, 248, 249, 5,
-	193, 97, 2, 249, 250, 5, 231, 116, 2, 250, 251, 5, 201, 101, 2, 251, 4,
-	3, 2, 2, 2, 252, 253, 5, 233, 117, 2, 253, 254, 5, 223, 112, 2, 254, 255,
-	5, 199, 100, 2, 255, 256, 5, 193, 97, 2, 256, 257, 5, 231, 116, 2, 257,
-	258, 5, 201, 101, 2, 258, 6, 3, 2, 2, 2, 259, 260, 5, 229, 115, 2, 260,
-	261, 5, 201, 101, 2, 261, 262, 5, 231, 116, 2, 262, 8, 3, 2, 2, 2, 263,
-	264, 5, 199, 100, 2, 264, 265, 5, 227, 114, 2, 265, 266, 5, 221, 111, 2,
-	266, 267, 5, 223, 112, 2, 267, 10, 3, 2, 2, 2, 268, 269, 5, 209, 105, 2,
-	269, 270, 5, 219, 110, 2, 270, 271, 5, 231, 116, 2, 271, 272, 5, 201, 101,
-	2, 272, 273, 5, 227, 114, 2, 273, 274, 5, 235, 118, 2, 274, 275, 5, 193,
-	97, 2, 275, 276, 5, 215, 108, 2, 276, 12, 3, 2, 2, 2, 277, 278, 5, 219,
-	110, 2, 278, 279, 5, 193, 97, 2, 279, 280, 5, 217, 109, 2, 280, 281, 5,
-	201, 101, 2, 281, 14, 3, 2, 2, 2, 282, 283, 5, 229, 115, 2, 283, 284, 5,
-	207, 104, 2, 284, 285, 5, 193, 97, 2, 285, 286, 5, 227, 114, 2, 286, 287,
-	5, 199, 100, 2, 287, 16, 3, 2, 2, 2, 288, 289, 5, 227, 114, 2, 289, 290,
-	5, 201, 101, 2, 290, 291, 5, 223, 112, 2, 291, 292, 5, 215, 108, 2, 292,
-	293, 5, 209, 105, 2, 293, 294, 5, 197, 99, 2, 294, 295, 5, 193, 97, 2,
-	295, 296, 5, 231, 116, 2, 296, 297, 5, 209, 105, 2, 297, 298, 5, 221, 111,
-	2, 298, 299, 5, 219, 110, 2, 299, 18, 3, 2, 2, 2, 300, 301, 5, 231, 116,
-	2, 301, 302, 5, 231, 116, 2, 302, 303, 5, 215, 108, 2, 303, 20, 3, 2, 2,
-	2, 304, 305, 5, 217, 109, 2, 305, 306, 5, 201, 101, 2, 306, 307, 5, 231,
-	116, 2, 307, 308, 5, 193, 97, 2, 308, 309, 5, 231, 116, 2, 309, 310, 5,
-	231, 116, 2, 310, 311, 5, 215, 108, 2, 311, 22, 3, 2, 2, 2, 312, 313, 5,
-	223, 112, 2, 313, 314, 5, 193, 97, 2, 314, 315, 5, 229, 115, 2, 315, 316,
-	5, 231, 116, 2, 316, 317, 5, 231, 116, 2, 317, 318, 5, 231, 116, 2, 318,
-	319, 5, 215, 108, 2, 319, 24, 3, 2, 2, 2, 320, 321, 5, 203, 102, 2, 321,
-	322, 5, 233, 117, 2, 322, 323, 5, 231, 116, 2, 323, 324, 5, 233, 117, 2,
-	324, 325, 5, 227, 114, 2, 325, 326, 5, 201, 101, 2, 326, 327, 5, 231, 116,
-	2, 327, 328, 5, 231, 116, 2, 328, 329, 5, 215, 108, 2, 329, 26, 3, 2, 2,
-	2, 330, 331, 5, 213, 107, 2, 331, 332, 5, 209, 105, 2, 332, 333, 5, 215,
-	108, 2, 333, 334, 5, 215, 108, 2, 334, 28, 3, 2, 2, 2, 335, 336, 5, 221,
-	111, 2, 336, 337, 5, 219, 110, 2, 337, 30, 3, 2, 2, 2, 338, 339, 5, 229,
-	115, 2, 339, 340, 5, 207, 104, 2, 340, 341, 5, 221, 111, 2, 341, 342, 5,
-	237, 119, 2, 342, 32, 3, 2, 2, 2, 343, 344, 5, 199, 100, 2, 344, 345, 5,
-	193, 97, 2, 345, 346, 5, 231, 116, 2, 346, 347, 5, 193, 97, 2, 347, 348,
-	5, 195, 98, 2, 348, 349, 5, 193, 97, 2, 349, 350, 5, 229, 115, 2, 350,
-	351, 5, 201, 101, 2, 351, 34, 3, 2, 2, 2, 352, 353, 5, 199, 100, 2, 353,
-	354, 5, 193, 97, 2, 354, 355, 5, 231, 116, 2, 355, 356, 5, 193, 97, 2,
-	356, 357, 5, 195, 98, 2, 357, 358, 5, 193, 97, 2, 358, 359, 5, 229, 115,
-	2, 359, 360, 5, 201, 101, 2, 360, 361, 5, 229, 115, 2, 361, 36, 3, 2, 2,
-	2, 362, 363, 5, 219, 110, 2, 363, 364, 5, 221, 111, 2, 364, 365, 5, 199,
-	100, 2, 365, 366, 5, 201, 101, 2, 366, 38, 3, 2, 2, 2, 367, 368, 5, 217,
-	109, 2, 368, 369, 5, 201, 101, 2, 369, 370, 5, 193, 97, 2, 370, 371, 5,
-	229, 115, 2, 371, 372, 5, 233, 117, 2, 372, 373, 5, 227, 114, 2, 373, 374,
-	5, 201, 101, 2, 374, 375, 5, 217, 109, 2, 375, 376, 5, 201, 101, 2, 376,
-	377, 5, 219, 110, 2, 377, 378, 5, 231, 116, 2, 378, 379, 5, 229, 115, 2,
-	379, 40, 3, 2, 2, 2, 380, 381, 5, 217, 109, 2, 381, 382, 5, 201, 101, 2,
-	382, 383, 5, 193, 97, 2, 383, 384, 5, 229, 115, 2, 384, 385, 5, 233, 117,
-	2, 385, 386, 5, 227, 114, 2, 386, 387, 5, 201, 101, 2, 387, 388, 5, 217,
-	109, 2, 388, 389, 5, 201, 101, 2, 389, 390, 5, 219, 110, 2, 390, 391, 5,
-	231, 116, 2, 391, 42, 3, 2, 2, 2, 392, 393, 5, 203, 102, 2, 393, 394, 5,
-	209, 105, 2, 394, 395, 5, 201, 101, 2, 395, 396, 5, 215, 108, 2, 396, 397,
-	5, 199, 100, 2, 397, 44, 3, 2, 2, 2, 398, 399, 5, 231, 116, 2, 399, 400,
-	5, 193, 97, 2, 400, 401, 5, 205, 103, 2, 401, 46, 3, 2, 2, 2, 402, 403,
-	5, 209, 105, 2, 403, 404, 5, 219, 110, 2, 404, 405, 5, 203, 102, 2, 405,
-	406, 5, 221, 111, 2, 406, 48, 3, 2, 2, 2, 407, 408, 5, 213, 107, 2, 408,
-	409, 5, 201, 101, 2, 409, 410, 5, 241, 121, 2, 410, 411, 5, 229, 115, 2,
-	411, 50, 3, 2, 2, 2, 412, 413, 5, 213, 107, 2, 413, 414, 5, 201, 101, 2,
-	414, 415, 5, 241, 121, 2, 415, 52, 3, 2, 2, 2, 416, 417, 5, 237, 119, 2,
-	417, 418, 5, 209, 105, 2, 418, 419, 5, 231, 116, 2, 419, 420, 5, 207, 104,
-	2, 420, 54, 3, 2, 2, 2, 421, 422, 5, 235, 118, 2, 422, 423, 5, 193, 97,
-	2, 423, 424, 5, 215, 108, 2, 424, 425, 5, 233, 117, 2, 425, 426, 5, 201,
-	101, 2, 426, 427, 5, 229, 115, 2, 427, 56, 3, 2, 2, 2, 428, 429, 5, 235,
-	118, 2, 429, 430, 5, 193, 97, 2, 430, 431, 5, 215, 108, 2, 431, 432, 5,
-	233, 117, 2, 432, 433, 5, 201, 101, 2, 433, 58, 3, 2, 2, 2, 434, 435, 5,
-	203, 102, 2, 435, 436, 5, 227, 114, 2, 436, 437, 5, 221, 111, 2, 437, 438,
-	5, 217, 109, 2, 438, 60, 3, 2, 2, 2, 439, 440, 5, 237, 119, 2, 440, 441,
-	5, 207, 104, 2, 441, 442, 5, 201, 101, 2, 442, 443, 5, 227, 114, 2, 443,
-	444, 5, 201, 101, 2, 444, 62, 3, 2, 2, 2, 445, 446, 5, 215, 108, 2, 446,
-	447, 5, 209, 105, 2, 447, 448, 5, 217, 109, 2, 448, 449, 5, 209, 105, 2,
-	449, 450, 5, 231, 116, 2, 450, 64, 3, 2, 2, 2, 451, 452, 5, 225, 113, 2,
-	452, 453, 5, 233, 117, 2, 453, 454, 5, 201, 101, 2, 454, 455, 5, 227, 114,
-	2, 455, 456, 5, 209, 105, 2, 456, 457, 5, 201, 101, 2, 457, 458, 5, 229,
-	115, 2, 458, 66, 3, 2, 2, 2, 459, 460, 5, 225, 113, 2, 460, 461, 5, 233,
-	117, 2, 461, 462, 5, 201, 101, 2, 462, 463, 5, 227, 114, 2, 463, 464, 5,
-	241, 121, 2, 464, 68, 3, 2, 2, 2, 465, 466, 5, 201, 101, 2, 466, 467, 5,
-	239, 120, 2, 467, 468, 5, 223, 112, 2, 468, 469, 5, 215, 108, 2, 469, 470,
-	5, 193, 97, 2, 470, 471, 5, 209, 105, 2, 471, 472, 5, 219, 110, 2, 472,
-	70, 3, 2, 2, 2, 473, 474, 5, 237, 119, 2, 474, 475, 5, 209, 105, 2, 475,
-	476, 5, 231, 116, 2, 476, 477, 5, 207, 104, 2, 477, 478, 5, 235, 118, 2,
-	478, 479, 5, 193, 97, 2, 479, 480, 5, 215, 108, 2, 480, 481, 5, 233, 117,
-	2, 481, 482, 5, 201, 101, 2, 482, 72, 3, 2, 2, 2, 483, 484, 5, 229, 115,
-	2, 484, 485, 5, 201, 101, 2, 485, 486, 5, 215, 108, 2, 486, 487, 5, 201,
-	101, 2, 487, 488, 5, 197, 99, 2, 488, 489, 5, 231, 116, 2, 489, 74, 3,
-	2, 2, 2, 490, 491, 5, 193, 97, 2, 491, 492, 5, 229, 115, 2, 492, 76, 3,
-	2, 2, 2, 493, 494, 5, 193, 97, 2, 494, 495, 5, 219, 110, 2, 495, 496, 5,
-	199, 100, 2, 496, 78, 3, 2, 2, 2, 497, 498, 5, 221, 111, 2, 498, 499, 5,
-	227, 114, 2, 499, 80, 3, 2, 2, 2, 500, 501, 5, 203, 102, 2, 501, 502, 5,
-	209, 105, 2, 502, 503, 5, 215, 108, 2, 503, 504, 5, 215, 108, 2, 504, 82,
-	3, 2, 2, 2, 505, 506, 5, 219, 110, 2, 506, 507, 5, 233, 117, 2, 507, 508,
+	4, 2, 67, 92, 99, 124, 4, 2, 48, 48, 97, 97, 5, 2, 37, 38, 66, 66, 97,
+	97, 4, 2, 67, 67, 99, 99, 4, 2, 68, 68, 100, 100, 4, 2, 69, 69, 101, 101,
+	4, 2, 70, 70, 102, 102, 4, 2, 71, 71, 103, 103, 4, 2, 72, 72, 104, 104,
+	4, 2, 73, 73, 105, 105, 4, 2, 74, 74, 106, 106, 4, 2, 75, 75, 107, 107,
+	4, 2, 76, 76, 108, 108, 4, 2, 77, 77, 109, 109, 4, 2, 78, 78, 110, 110,
+	4, 2, 79, 79, 111, 111, 4, 2, 80, 80, 112, 112, 4, 2, 81, 81, 113, 113,
+	4, 2, 82, 82, 114, 114, 4, 2, 83, 83, 115, 115, 4, 2, 84, 84, 116, 116,
+	4, 2, 85, 85, 117, 117, 4, 2, 86, 86, 118, 118, 4, 2, 87, 87, 119, 119,
+	4, 2, 88, 88, 120, 120, 4, 2, 89, 89, 121, 121, 4, 2, 90, 90, 122, 122,
+	4, 2, 91, 91, 123, 123, 4, 2, 92, 92, 124, 124, 2, 801, 2, 3, 3, 2, 2,
+	2, 2, 5, 3, 2, 2, 2, 2, 7, 3, 2, 2, 2, 2, 9, 3, 2, 2, 2, 2, 11, 3, 2, 2,
+	2, 2, 13, 3, 2, 2, 2, 2, 15, 3, 2, 2, 2, 2, 17, 3, 2, 2, 2, 2, 19, 3, 2,
+	2, 2, 2, 21, 3, 2, 2, 2, 2, 23, 3, 2, 2, 2, 2, 25, 3, 2, 2, 2, 2, 27, 3,
+	2, 2, 2, 2, 29, 3, 2, 2, 2, 2, 31, 3, 2, 2, 2, 2, 33, 3, 2, 2, 2, 2, 35,
+	3, 2, 2, 2, 2, 37, 3, 2, 2, 2, 2, 39, 3, 2, 2, 2, 2, 41, 3, 2, 2, 2, 2,
+	43, 3, 2, 2, 2, 2, 45, 3, 2, 2, 2, 2, 47, 3, 2, 2, 2, 2, 49, 3, 2, 2, 2,
+	2, 51, 3, 2, 2, 2, 2, 53, 3, 2, 2, 2, 2, 55, 3, 2, 2, 2, 2, 57, 3, 2, 2,
+	2, 2, 59, 3, 2, 2, 2, 2, 61, 3, 2, 2, 2, 2, 63, 3, 2, 2, 2, 2, 65, 3, 2,
+	2, 2, 2, 67, 3, 2, 2, 2, 2, 69, 3, 2, 2, 2, 2, 71, 3, 2, 2, 2, 2, 73, 3,
+	2, 2, 2, 2, 75, 3, 2, 2, 2, 2, 77, 3, 2, 2, 2, 2, 79, 3, 2, 2, 2, 2, 81,
+	3, 2, 2, 2, 2, 83, 3, 2, 2, 2, 2, 85, 3, 2, 2, 2, 2, 87, 3, 2, 2, 2, 2,
+	89, 3, 2, 2, 2, 2, 91, 3, 2, 2, 2, 2, 93, 3, 2, 2, 2, 2, 95, 3, 2, 2, 2,
+	2, 97, 3, 2, 2, 2, 2, 99, 3, 2, 2, 2, 2, 101, 3, 2, 2, 2, 2, 103, 3, 2,
+	2, 2, 2, 105, 3, 2, 2, 2, 2, 107, 3, 2, 2, 2, 2, 109, 3, 2, 2, 2, 2, 111,
+	3, 2, 2, 2, 2, 113, 3, 2, 2, 2, 2, 115, 3, 2, 2, 2, 2, 117, 3, 2, 2, 2,
+	2, 119, 3, 2, 2, 2, 2, 121, 3, 2, 2, 2, 2, 123, 3, 2, 2, 2, 2, 125, 3,
+	2, 2, 2, 2, 127, 3, 2, 2, 2, 2, 129, 3, 2, 2, 2, 2, 131, 3, 2, 2, 2, 2,
+	133, 3, 2, 2, 2, 2, 135, 3, 2, 2, 2, 2, 137, 3, 2, 2, 2, 2, 139, 3, 2,
+	2, 2, 2, 141, 3, 2, 2, 2, 2, 143, 3, 2, 2, 2, 2, 145, 3, 2, 2, 2, 2, 147,
+	3, 2, 2, 2, 2, 149, 3, 2, 2, 2, 2, 151, 3, 2, 2, 2, 2, 153, 3, 2, 2, 2,
+	2, 155, 3, 2, 2, 2, 2, 157, 3, 2, 2, 2, 2, 159, 3, 2, 2, 2, 2, 161, 3,
+	2, 2, 2, 2, 163, 3, 2, 2, 2, 2, 165, 3, 2, 2, 2, 2, 167, 3, 2, 2, 2, 2,
+	169, 3, 2, 2, 2, 2, 171, 3, 2, 2, 2, 2, 173, 3, 2, 2, 2, 2, 175, 3, 2,
+	2, 2, 2, 177, 3, 2, 2, 2, 2, 179, 3, 2, 2, 2, 2, 181, 3, 2, 2, 2, 2, 183,
+	3, 2, 2, 2, 2, 185, 3, 2, 2, 2, 3, 245, 3, 2, 2, 2, 5, 252, 3, 2, 2, 2,
+	7, 259, 3, 2, 2, 2, 9, 263, 3, 2, 2, 2, 11, 268, 3, 2, 2, 2, 13, 277, 3,
+	2, 2, 2, 15, 282, 3, 2, 2, 2, 17, 288, 3, 2, 2, 2, 19, 300, 3, 2, 2, 2,
+	21, 304, 3, 2, 2, 2, 23, 312, 3, 2, 2, 2, 25, 320, 3, 2, 2, 2, 27, 330,
+	3, 2, 2, 2, 29, 335, 3, 2, 2, 2, 31, 338, 3, 2, 2, 2, 33, 343, 3, 2, 2,
+	2, 35, 352, 3, 2, 2, 2, 37, 362, 3, 2, 2, 2, 39, 367, 3, 2, 2, 2, 41, 380,
+	3, 2, 2, 2, 43, 392, 3, 2, 2, 2, 45, 398, 3, 2, 2, 2, 47, 402, 3, 2, 2,
+	2, 49, 407, 3, 2, 2, 2, 51, 412, 3, 2, 2, 2, 53, 416, 3, 2, 2, 2, 55, 421,
+	3, 2, 2, 2, 57, 428, 3, 2, 2, 2, 59, 434, 3, 2, 2, 2, 61, 439, 3, 2, 2,
+	2, 63, 445, 3, 2, 2, 2, 65, 451, 3, 2, 2, 2, 67, 459, 3, 2, 2, 2, 69, 465,
+	3, 2, 2, 2, 71, 473, 3, 2, 2, 2, 73, 483, 3, 2, 2, 2, 75, 490, 3, 2, 2,
+	2, 77, 493, 3, 2, 2, 2, 79, 497, 3, 2, 2, 2, 81, 500, 3, 2, 2, 2, 83, 505,
+	3, 2, 2, 2, 85, 510, 3, 2, 2, 2, 87, 519, 3, 2, 2, 2, 89, 525, 3, 2, 2,
+	2, 91, 529, 3, 2, 2, 2, 93, 534, 3, 2, 2, 2, 95, 539, 3, 2, 2, 2, 97, 543,
+	3, 2, 2, 2, 99, 551, 3, 2, 2, 2, 101, 554, 3, 2, 2, 2, 103, 560, 3, 2,
+	2, 2, 105, 567, 3, 2, 2, 2, 107, 570, 3, 2, 2, 2, 109, 574, 3, 2, 2, 2,
+	111, 580, 3, 2, 2, 2, 113, 585, 3, 2, 2, 2, 115, 589, 3, 2, 2, 2, 117,
+	592, 3, 2, 2, 2, 119, 596, 3, 2, 2, 2, 121, 604, 3, 2, 2, 2, 123, 606,
+	3, 2, 2, 2, 125, 608, 3, 2, 2, 2, 127, 610, 3, 2, 2, 2, 129, 612, 3, 2,
+	2, 2, 131, 614, 3, 2, 2, 2, 133, 616, 3, 2, 2, 2, 135, 618, 3, 2, 2, 2,
+	137, 620, 3, 2, 2, 2, 139, 622, 3, 2, 2, 2, 141, 624, 3, 2, 2, 2, 143,
+	627, 3, 2, 2, 2, 145, 630, 3, 2, 2, 2, 147, 632, 3, 2, 2, 2, 149, 635,
+	3, 2, 2, 2, 151, 637, 3, 2, 2, 2, 153, 640, 3, 2, 2, 2, 155, 643, 3, 2,
+	2, 2, 157, 645, 3, 2, 2, 2, 159, 647, 3, 2, 2, 2, 161, 649, 3, 2, 2, 2,
+	163, 651, 3, 2, 2, 2, 165, 653, 3, 2, 2, 2, 167, 655, 3, 2, 2, 2, 169,
+	657, 3, 2, 2, 2, 171, 659, 3, 2, 2, 2, 173, 661, 3, 2, 2, 2, 175, 663,
+	3, 2, 2, 2, 177, 665, 3, 2, 2, 2, 179, 667, 3, 2, 2, 2, 181, 670, 3, 2,
+	2, 2, 183, 693, 3, 2, 2, 2, 185, 696, 3, 2, 2, 2, 187, 702, 3, 2, 2, 2,
+	189, 704, 3, 2, 2, 2, 191, 756, 3, 2, 2, 2, 193, 758, 3, 2, 2, 2, 195,
+	760, 3, 2, 2, 2, 197, 762, 3, 2, 2, 2, 199, 764, 3, 2, 2, 2, 201, 766,
+	3, 2, 2, 2, 203, 768, 3, 2, 2, 2, 205, 770, 3, 2, 2, 2, 207, 772, 3, 2,
+	2, 2, 209, 774, 3, 2, 2, 2, 211, 776, 3, 2, 2, 2, 213, 778, 3, 2, 2, 2,
+	215, 780, 3, 2, 2, 2, 217, 782, 3, 2, 2, 2, 219, 784, 3, 2, 2, 2, 221,
+	786, 3, 2, 2, 2, 223, 788, 3, 2, 2, 2, 225, 790, 3, 2, 2, 2, 227, 792,
+	3, 2, 2, 2, 229, 794, 3, 2, 2, 2, 231, 796, 3, 2, 2, 2, 233, 798, 3, 2,
+	2, 2, 235, 800, 3, 2, 2, 2, 237, 802, 3, 2, 2, 2, 239, 804, 3, 2, 2, 2,
+	241, 806, 3, 2, 2, 2, 243, 808, 3, 2, 2, 2, 245, 246, 5, 197, 99, 2, 246,
+	247, 5, 227, 114, 2, 247, 248, 5, 201, 101, 2, 248, 249, 5, 193, 97, 2,
+	249, 250, 5, 231, 116, 2, 250, 251, 5, 201, 101, 2, 251, 4, 3, 2, 2, 2,
+	252, 253, 5, 233, 117, 2, 253, 254, 5, 223, 112, 2, 254, 255, 5, 199, 100,
+	2, 255, 256, 5, 193, 97, 2, 256, 257, 5, 231, 116, 2, 257, 258, 5, 201,
+	101, 2, 258, 6, 3, 2, 2, 2, 259, 260, 5, 229, 115, 2, 260, 261, 5, 201,
+	101, 2, 261, 262, 5, 231, 116, 2, 262, 8, 3, 2, 2, 2, 263, 264, 5, 199,
+	100, 2, 264, 265, 5, 227, 114, 2, 265, 266, 5, 221, 111, 2, 266, 267, 5,
+	223, 112, 2, 267, 10, 3, 2, 2, 2, 268, 269, 5, 209, 105, 2, 269, 270, 5,
+	219, 110, 2, 270, 271, 5, 231, 116, 2, 271, 272, 5, 201, 101, 2, 272, 273,
+	5, 227, 114, 2, 273, 274, 5, 235, 118, 2, 274, 275, 5, 193, 97, 2, 275,
+	276, 5, 215, 108, 2, 276, 12, 3, 2, 2, 2, 277, 278, 5, 219, 110, 2, 278,
+	279, 5, 193, 97, 2, 279, 280, 5, 217, 109, 2, 280, 281, 5, 201, 101, 2,
+	281, 14, 3, 2, 2, 2, 282, 283, 5, 229, 115, 2, 283, 284, 5, 207, 104, 2,
+	284, 285, 5, 193, 97, 2, 285, 286, 5, 227, 114, 2, 286, 287, 5, 199, 100,
+	2, 287, 16, 3, 2, 2, 2, 288, 289, 5, 227, 114, 2, 289, 290, 5, 201, 101,
+	2, 290, 291, 5, 223, 112, 2, 291, 292, 5, 215, 108, 2, 292, 293, 5, 209,
+	105, 2, 293, 294, 5, 197, 99, 2, 294, 295, 5, 193, 97, 2, 295, 296, 5,
+	231, 116, 2, 296, 297, 5, 209, 105, 2, 297, 298, 5, 221, 111, 2, 298, 299,
+	5, 219, 110, 2, 299, 18, 3, 2, 2, 2, 300, 301, 5, 231, 116, 2, 301, 302,
+	5, 231, 116, 2, 302, 303, 5, 215, 108, 2, 303, 20, 3, 2, 2, 2, 304, 305,
+	5, 217, 109, 2, 305, 306, 5, 201, 101, 2, 306, 307, 5, 231, 116, 2, 307,
+	308, 5, 193, 97, 2, 308, 309, 5, 231, 116, 2, 309, 310, 5, 231, 116, 2,
+	310, 311, 5, 215, 108, 2, 311, 22, 3, 2, 2, 2, 312, 313, 5, 223, 112, 2,
+	313, 314, 5, 193, 97, 2, 314, 315, 5, 229, 115, 2, 315, 316, 5, 231, 116,
+	2, 316, 317, 5, 231, 116, 2, 317, 318, 5, 231, 116, 2, 318, 319, 5, 215,
+	108, 2, 319, 24, 3, 2, 2, 2, 320, 321, 5, 203, 102, 2, 321, 322, 5, 233,
+	117, 2, 322, 323, 5, 231, 116, 2, 323, 324, 5, 233, 117, 2, 324, 325, 5,
+	227, 114, 2, 325, 326, 5, 201, 101, 2, 326, 327, 5, 231, 116, 2, 327, 328,
+	5, 231, 116, 2, 328, 329, 5, 215, 108, 2, 329, 26, 3, 2, 2, 2, 330, 331,
+	5, 213, 107, 2, 331, 332, 5, 209, 105, 2, 332, 333, 5, 215, 108, 2, 333,
+	334, 5, 215, 108, 2, 334, 28, 3, 2, 2, 2, 335, 336, 5, 221, 111, 2, 336,
+	337, 5, 219, 110, 2, 337, 30, 3, 2, 2, 2, 338, 339, 5, 229, 115, 2, 339,
+	340, 5, 207, 104, 2, 340, 341, 5, 221, 111, 2, 341, 342, 5, 237, 119, 2,
+	342, 32, 3, 2, 2, 2, 343, 344, 5, 199, 100, 2, 344, 345, 5, 193, 97, 2,
+	345, 346, 5, 231, 116, 2, 346, 347, 5, 193, 97, 2, 347, 348, 5, 195, 98,
+	2, 348, 349, 5, 193, 97, 2, 349, 350, 5, 229, 115, 2, 350, 351, 5, 201,
+	101, 2, 351, 34, 3, 2, 2, 2, 352, 353, 5, 199, 100, 2, 353, 354, 5, 193,
+	97, 2, 354, 355, 5, 231, 116, 2, 355, 356, 5, 193, 97, 2, 356, 357, 5,
+	195, 98, 2, 357, 358, 5, 193, 97, 2, 358, 359, 5, 229, 115, 2, 359, 360,
+	5, 201, 101, 2, 360, 361, 5, 229, 115, 2, 361, 36, 3, 2, 2, 2, 362, 363,
+	5, 219, 110, 2, 363, 364, 5, 221, 111, 2, 364, 365, 5, 199, 100, 2, 365,
+	366, 5, 201, 101, 2, 366, 38, 3, 2, 2, 2, 367, 368, 5, 217, 109, 2, 368,
+	369, 5, 201, 101, 2, 369, 370, 5, 193, 97, 2, 370, 371, 5, 229, 115, 2,
+	371, 372, 5, 233, 117, 2, 372, 373, 5, 227, 114, 2, 373, 374, 5, 201, 101,
+	2, 374, 375, 5, 217, 109, 2, 375, 376, 5, 201, 101, 2, 376, 377, 5, 219,
+	110, 2, 377, 378, 5, 231, 116, 2, 378, 379, 5, 229, 115, 2, 379, 40, 3,
+	2, 2, 2, 380, 381, 5, 217, 109, 2, 381, 382, 5, 201, 101, 2, 382, 383,
+	5, 193, 97, 2, 383, 384, 5, 229, 115, 2, 384, 385, 5, 233, 117, 2, 385,
+	386, 5, 227, 114, 2, 386, 387, 5, 201, 101, 2, 387, 388, 5, 217, 109, 2,
+	388, 389, 5, 201, 101, 2, 389, 390, 5, 219, 110, 2, 390, 391, 5, 231, 116,
+	2, 391, 42, 3, 2, 2, 2, 392, 393, 5, 203, 102, 2, 393, 394, 5, 209, 105,
+	2, 394, 395, 5, 201, 101, 2, 395, 396, 5, 215, 108, 2, 396, 397, 5, 199,
+	100, 2, 397, 44, 3, 2, 2, 2, 398, 399, 5, 231, 116, 2, 399, 400, 5, 193,
+	97, 2, 400, 401, 5, 205, 103, 2, 401, 46, 3, 2, 2, 2, 402, 403, 5, 209,
+	105, 2, 403, 404, 5, 219, 110, 2, 404, 405, 5, 203, 102, 2, 405, 406, 5,
+	221, 111, 2, 406, 48, 3, 2, 2, 2, 407, 408, 5, 213, 107, 2, 408, 409, 5,
+	201, 101, 2, 409, 410, 5, 241, 121, 2, 410, 411, 5, 229, 115, 2, 411, 50,
+	3, 2, 2, 2, 412, 413, 5, 213, 107, 2, 413, 414, 5, 201, 101, 2, 414, 415,
+	5, 241, 121, 2, 415, 52, 3, 2, 2, 2, 416, 417, 5, 237, 119, 2, 417, 418,
+	5, 209, 105, 2, 418, 419, 5, 231, 116, 2, 419, 420, 5, 207, 104, 2, 420,
+	54, 3, 2, 2, 2, 421, 422, 5, 235, 118, 2, 422, 423, 5, 193, 97, 2, 423,
+	424, 5, 215, 108, 2, 424, 425, 5, 233, 117, 2, 425, 426, 5, 201, 101, 2,
+	426, 427, 5, 229, 115, 2, 427, 56, 3, 2, 2, 2, 428, 429, 5, 235, 118, 2,
+	429, 430, 5, 193, 97, 2, 430, 431, 5, 215, 108, 2, 431, 432, 5, 233, 117,
+	2, 432, 433, 5, 201, 101, 2, 433, 58, 3, 2, 2, 2, 434, 435, 5, 203, 102,
+	2, 435, 436, 5, 227, 114, 2, 436, 437, 5, 221, 111, 2, 437, 438, 5, 217,
+	109, 2, 438, 60, 3, 2, 2, 2, 439, 440, 5, 237, 119, 2, 440, 441, 5, 207,
+	104, 2, 441, 442, 5, 201, 101, 2, 442, 443, 5, 227, 114, 2, 443, 444, 5,
+	201, 101, 2, 444, 62, 3, 2, 2, 2, 445, 446, 5, 215, 108, 2, 446, 447, 5,
+	209, 105, 2, 447, 448, 5, 217, 109, 2, 448, 449, 5, 209, 105, 2, 449, 450,
+	5, 231, 116, 2, 450, 64, 3, 2, 2, 2, 451, 452, 5, 225, 113, 2, 452, 453,
+	5, 233, 117, 2, 453, 454, 5, 201, 101, 2, 454, 455, 5, 227, 114, 2, 455,
+	456, 5, 209, 105, 2, 456, 457, 5, 201, 101, 2, 457, 458, 5, 229, 115, 2,
+	458, 66, 3, 2, 2, 2, 459, 460, 5, 225, 113, 2, 460, 461, 5, 233, 117, 2,
+	461, 462, 5, 201, 101, 2, 462, 463, 5, 227, 114, 2, 463, 464, 5, 241, 121,
+	2, 464, 68, 3, 2, 2, 2, 465, 466, 5, 201, 101, 2, 466, 467, 5, 239, 120,
+	2, 467, 468, 5, 223, 112, 2, 468, 469, 5, 215, 108, 2, 469, 470, 5, 193,
+	97, 2, 470, 471, 5, 209, 105, 2, 471, 472, 5, 219, 110, 2, 472, 70, 3,
+	2, 2, 2, 473, 474, 5, 237, 119, 2, 474, 475, 5, 209, 105, 2, 475, 476,
+	5, 231, 116, 2, 476, 477, 5, 207, 104, 2, 477, 478, 5, 235, 118, 2, 478,
+	479, 5, 193, 97, 2, 479, 480, 5, 215, 108, 2, 480, 481, 5, 233, 117, 2,
+	481, 482, 5, 201, 101, 2, 482, 72, 3, 2, 2, 2, 483, 484, 5, 229, 115, 2,
+	484, 485, 5, 201, 101, 2, 485, 486, 5, 215, 108, 2, 486, 487, 5, 201, 101,
+	2, 487, 488, 5, 197, 99, 2, 488, 489, 5, 231, 116, 2, 489, 74, 3, 2, 2,
+	2, 490, 491, 5, 193, 97, 2, 491, 492, 5, 229, 115, 2, 492, 76, 3, 2, 2,
+	2, 493, 494, 5, 193, 97, 2, 494, 495, 5, 219, 110, 2, 495, 496, 5, 199,
+	100, 2, 496, 78, 3, 2, 2, 2, 497, 498, 5, 221, 111, 2, 498, 499, 5, 227,
+	114, 2, 499, 80, 3, 2, 2, 2, 500, 501, 5, 203, 102, 2, 501, 502, 5, 209,
+	105, 2, 502, 503, 5, 215, 108, 2, 503, 504, 5, 215, 108, 2, 504, 82, 3,
+	2, 2, 2, 505, 506, 5, 219, 110, 2, 506, 507, 5, 233, 117, 2, 507, 508,
 	5, 215, 108, 2, 508, 509, 5, 215, 108, 2, 509, 84, 3, 2, 2, 2, 510, 511,
 	5, 223, 112, 2, 511, 512, 5, 227, 114, 2, 512, 513, 5, 201, 101, 2, 513,
 	514, 5, 235, 118, 2, 514, 515, 5, 209, 105, 2, 515, 516, 5, 221, 111, 2,
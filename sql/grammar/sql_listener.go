@@ -251,6 +251,9 @@ type SQLListener interface {
 	// EnterNonReservedWords is called when entering the nonReservedWords production.
 	EnterNonReservedWords(c *NonReservedWordsContext)
 
+	// EnterMetricFieldRef is called when entering the metricFieldRef production.
+	EnterMetricFieldRef(c *MetricFieldRefContext)
+
 	// ExitStatement is called when exiting the statement production.
 	ExitStatement(c *StatementContext)
 
@@ -493,4 +496,7 @@ type SQLListener interface {
 
 	// ExitNonReservedWords is called when exiting the nonReservedWords production.
 	ExitNonReservedWords(c *NonReservedWordsContext)
+
+	// ExitMetricFieldRef is called when exiting the metricFieldRef production.
+	ExitMetricFieldRef(c *MetricFieldRefContext)
 }
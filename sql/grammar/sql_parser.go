@@ -16,7 +16,7 @@ var _ = reflect.Copy
 var _ = strconv.Itoa
 
 var parserATN = []uint16{
-	3, 24715, 42794, 33075, 47597, 16764, 15335, 30598, 22884, 3, 94, 694,
+	3, 24715, 42794, 33075, 47597, 16764, 15335, 30598, 22884, 3, 94, 703,
 	4, 2, 9, 2, 4, 3, 9, 3, 4, 4, 9, 4, 4, 5, 9, 5, 4, 6, 9, 6, 4, 7, 9, 7,
 	4, 8, 9, 8, 4, 9, 9, 9, 4, 10, 9, 10, 4, 11, 9, 11, 4, 12, 9, 12, 4, 13,
 	9, 13, 4, 14, 9, 14, 4, 15, 9, 15, 4, 16, 9, 16, 4, 17, 9, 17, 4, 18, 9,
@@ -32,295 +32,299 @@ var parserATN = []uint16{
 	4, 66, 9, 66, 4, 67, 9, 67, 4, 68, 9, 68, 4, 69, 9, 69, 4, 70, 9, 70, 4,
 	71, 9, 71, 4, 72, 9, 72, 4, 73, 9, 73, 4, 74, 9, 74, 4, 75, 9, 75, 4, 76,
 	9, 76, 4, 77, 9, 77, 4, 78, 9, 78, 4, 79, 9, 79, 4, 80, 9, 80, 4, 81, 9,
-	81, 4, 82, 9, 82, 3, 2, 3, 2, 3, 2, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 5, 3, 183, 10, 3,
-	3, 4, 3, 4, 3, 4, 3, 4, 3, 4, 5, 4, 190, 10, 4, 3, 4, 3, 4, 5, 4, 194,
-	10, 4, 3, 5, 3, 5, 3, 5, 7, 5, 199, 10, 5, 12, 5, 14, 5, 202, 11, 5, 3,
-	6, 3, 6, 3, 6, 3, 6, 3, 6, 3, 6, 3, 6, 3, 6, 3, 6, 3, 6, 3, 6, 3, 6, 3,
-	6, 3, 6, 5, 6, 218, 10, 6, 3, 7, 3, 7, 3, 7, 7, 7, 223, 10, 7, 12, 7, 14,
-	7, 226, 11, 7, 3, 8, 3, 8, 3, 8, 3, 8, 3, 8, 3, 8, 3, 8, 3, 8, 3, 8, 3,
-	8, 3, 8, 3, 9, 3, 9, 3, 10, 3, 10, 3, 11, 3, 11, 3, 12, 3, 12, 3, 13, 3,
-	13, 3, 14, 3, 14, 3, 15, 3, 15, 3, 16, 3, 16, 3, 17, 3, 17, 3, 17, 3, 17,
-	3, 17, 5, 17, 260, 10, 17, 3, 17, 3, 17, 5, 17, 264, 10, 17, 3, 18, 3,
-	18, 3, 18, 3, 18, 3, 19, 3, 19, 3, 19, 3, 20, 3, 20, 3, 20, 3, 21, 3, 21,
-	3, 21, 5, 21, 279, 10, 21, 3, 21, 5, 21, 282, 10, 21, 3, 22, 3, 22, 3,
-	22, 3, 22, 3, 22, 3, 22, 5, 22, 290, 10, 22, 3, 23, 3, 23, 3, 23, 3, 23,
-	3, 23, 3, 24, 3, 24, 3, 24, 3, 24, 3, 24, 3, 24, 3, 24, 5, 24, 304, 10,
-	24, 3, 24, 5, 24, 307, 10, 24, 3, 25, 3, 25, 3, 25, 3, 25, 3, 25, 3, 25,
-	3, 25, 3, 25, 3, 25, 3, 26, 3, 26, 3, 26, 3, 26, 3, 26, 3, 26, 5, 26, 324,
-	10, 26, 3, 27, 3, 27, 3, 27, 5, 27, 329, 10, 27, 3, 28, 3, 28, 3, 28, 3,
-	28, 5, 28, 335, 10, 28, 3, 28, 3, 28, 5, 28, 339, 10, 28, 3, 29, 3, 29,
-	3, 29, 3, 29, 3, 29, 3, 29, 5, 29, 347, 10, 29, 3, 30, 3, 30, 3, 30, 3,
-	30, 3, 30, 3, 31, 3, 31, 3, 31, 3, 32, 3, 32, 3, 32, 3, 32, 3, 32, 5, 32,
-	362, 10, 32, 3, 33, 3, 33, 3, 34, 3, 34, 3, 35, 3, 35, 3, 36, 3, 36, 3,
-	37, 5, 37, 373, 10, 37, 3, 37, 3, 37, 3, 37, 3, 37, 5, 37, 379, 10, 37,
-	3, 37, 5, 37, 382, 10, 37, 3, 37, 5, 37, 385, 10, 37, 3, 37, 5, 37, 388,
-	10, 37, 3, 37, 5, 37, 391, 10, 37, 3, 37, 5, 37, 394, 10, 37, 3, 38, 3,
-	38, 3, 38, 7, 38, 399, 10, 38, 12, 38, 14, 38, 402, 11, 38, 3, 39, 3, 39,
-	5, 39, 406, 10, 39, 3, 40, 3, 40, 3, 40, 3, 41, 3, 41, 3, 41, 3, 42, 3,
-	42, 3, 42, 3, 43, 3, 43, 3, 43, 5, 43, 420, 10, 43, 3, 43, 3, 43, 3, 43,
-	7, 43, 425, 10, 43, 12, 43, 14, 43, 428, 11, 43, 3, 44, 3, 44, 3, 44, 3,
-	44, 3, 44, 5, 44, 435, 10, 44, 5, 44, 437, 10, 44, 3, 45, 3, 45, 3, 45,
-	3, 45, 3, 46, 3, 46, 3, 46, 3, 46, 3, 46, 3, 46, 3, 46, 3, 46, 3, 46, 3,
-	46, 3, 46, 3, 46, 3, 46, 5, 46, 456, 10, 46, 3, 46, 3, 46, 3, 46, 3, 46,
-	5, 46, 462, 10, 46, 3, 46, 3, 46, 3, 46, 7, 46, 467, 10, 46, 12, 46, 14,
-	46, 470, 11, 46, 3, 47, 3, 47, 3, 47, 7, 47, 475, 10, 47, 12, 47, 14, 47,
-	478, 11, 47, 3, 48, 3, 48, 3, 48, 5, 48, 483, 10, 48, 3, 49, 3, 49, 3,
-	49, 3, 49, 5, 49, 489, 10, 49, 3, 50, 3, 50, 5, 50, 493, 10, 50, 3, 51,
-	3, 51, 3, 51, 5, 51, 498, 10, 51, 3, 51, 3, 51, 3, 52, 3, 52, 3, 52, 3,
-	52, 3, 52, 3, 52, 3, 52, 3, 52, 5, 52, 510, 10, 52, 3, 52, 5, 52, 513,
-	10, 52, 3, 53, 3, 53, 3, 53, 7, 53, 518, 10, 53, 12, 53, 14, 53, 521, 11,
-	53, 3, 54, 3, 54, 3, 54, 3, 54, 3, 54, 3, 54, 5, 54, 529, 10, 54, 3, 55,
-	3, 55, 3, 56, 3, 56, 3, 56, 3, 56, 3, 57, 3, 57, 3, 57, 3, 57, 3, 58, 3,
-	58, 7, 58, 543, 10, 58, 12, 58, 14, 58, 546, 11, 58, 3, 59, 3, 59, 3, 59,
-	7, 59, 551, 10, 59, 12, 59, 14, 59, 554, 11, 59, 3, 60, 3, 60, 3, 60, 3,
-	61, 3, 61, 3, 61, 3, 61, 3, 61, 3, 61, 5, 61, 565, 10, 61, 3, 61, 3, 61,
-	3, 61, 3, 61, 7, 61, 571, 10, 61, 12, 61, 14, 61, 574, 11, 61, 3, 62, 3,
-	62, 3, 63, 3, 63, 3, 64, 3, 64, 3, 64, 3, 64, 3, 65, 3, 65, 3, 65, 3, 65,
-	3, 65, 3, 65, 3, 65, 3, 65, 5, 65, 592, 10, 65, 3, 66, 3, 66, 3, 66, 3,
-	66, 3, 66, 3, 66, 3, 66, 3, 66, 5, 66, 602, 10, 66, 3, 66, 3, 66, 3, 66,
-	3, 66, 3, 66, 3, 66, 3, 66, 3, 66, 3, 66, 3, 66, 3, 66, 3, 66, 7, 66, 616,
-	10, 66, 12, 66, 14, 66, 619, 11, 66, 3, 67, 3, 67, 3, 67, 3, 68, 3, 68,
-	3, 69, 3, 69, 3, 69, 5, 69, 629, 10, 69, 3, 69, 3, 69, 3, 70, 3, 70, 3,
-	70, 7, 70, 636, 10, 70, 12, 70, 14, 70, 639, 11, 70, 3, 71, 3, 71, 5, 71,
-	643, 10, 71, 3, 72, 3, 72, 5, 72, 647, 10, 72, 3, 72, 3, 72, 5, 72, 651,
-	10, 72, 3, 73, 3, 73, 3, 73, 3, 73, 3, 74, 5, 74, 658, 10, 74, 3, 74, 3,
-	74, 3, 75, 5, 75, 663, 10, 75, 3, 75, 3, 75, 3, 76, 3, 76, 3, 76, 3, 77,
-	3, 77, 3, 78, 3, 78, 3, 79, 3, 79, 3, 80, 3, 80, 3, 81, 3, 81, 5, 81, 680,
-	10, 81, 3, 81, 3, 81, 3, 81, 5, 81, 685, 10, 81, 7, 81, 687, 10, 81, 12,
-	81, 14, 81, 690, 11, 81, 3, 82, 3, 82, 3, 82, 2, 6, 84, 90, 120, 130, 83,
-	2, 4, 6, 8, 10, 12, 14, 16, 18, 20, 22, 24, 26, 28, 30, 32, 34, 36, 38,
-	40, 42, 44, 46, 48, 50, 52, 54, 56, 58, 60, 62, 64, 66, 68, 70, 72, 74,
-	76, 78, 80, 82, 84, 86, 88, 90, 92, 94, 96, 98, 100, 102, 104, 106, 108,
-	110, 112, 114, 116, 118, 120, 122, 124, 126, 128, 130, 132, 134, 136, 138,
-	140, 142, 144, 146, 148, 150, 152, 154, 156, 158, 160, 162, 2, 10, 5, 2,
-	48, 48, 71, 73, 78, 78, 3, 2, 40, 41, 4, 2, 43, 44, 92, 93, 3, 2, 46, 47,
-	4, 2, 48, 48, 78, 78, 3, 2, 62, 68, 3, 2, 86, 87, 11, 2, 3, 3, 7, 7, 9,
-	11, 15, 24, 26, 29, 31, 35, 38, 52, 54, 57, 61, 68, 2, 705, 2, 164, 3,
-	2, 2, 2, 4, 182, 3, 2, 2, 2, 6, 184, 3, 2, 2, 2, 8, 195, 3, 2, 2, 2, 10,
-	217, 3, 2, 2, 2, 12, 219, 3, 2, 2, 2, 14, 227, 3, 2, 2, 2, 16, 238, 3,
-	2, 2, 2, 18, 240, 3, 2, 2, 2, 20, 242, 3, 2, 2, 2, 22, 244, 3, 2, 2, 2,
-	24, 246, 3, 2, 2, 2, 26, 248, 3, 2, 2, 2, 28, 250, 3, 2, 2, 2, 30, 252,
-	3, 2, 2, 2, 32, 254, 3, 2, 2, 2, 34, 265, 3, 2, 2, 2, 36, 269, 3, 2, 2,
-	2, 38, 272, 3, 2, 2, 2, 40, 275, 3, 2, 2, 2, 42, 283, 3, 2, 2, 2, 44, 291,
-	3, 2, 2, 2, 46, 296, 3, 2, 2, 2, 48, 308, 3, 2, 2, 2, 50, 317, 3, 2, 2,
-	2, 52, 325, 3, 2, 2, 2, 54, 330, 3, 2, 2, 2, 56, 340, 3, 2, 2, 2, 58, 348,
-	3, 2, 2, 2, 60, 353, 3, 2, 2, 2, 62, 356, 3, 2, 2, 2, 64, 363, 3, 2, 2,
-	2, 66, 365, 3, 2, 2, 2, 68, 367, 3, 2, 2, 2, 70, 369, 3, 2, 2, 2, 72, 372,
-	3, 2, 2, 2, 74, 395, 3, 2, 2, 2, 76, 403, 3, 2, 2, 2, 78, 407, 3, 2, 2,
-	2, 80, 410, 3, 2, 2, 2, 82, 413, 3, 2, 2, 2, 84, 419, 3, 2, 2, 2, 86, 436,
-	3, 2, 2, 2, 88, 438, 3, 2, 2, 2, 90, 461, 3, 2, 2, 2, 92, 471, 3, 2, 2,
-	2, 94, 479, 3, 2, 2, 2, 96, 484, 3, 2, 2, 2, 98, 490, 3, 2, 2, 2, 100,
-	494, 3, 2, 2, 2, 102, 501, 3, 2, 2, 2, 104, 514, 3, 2, 2, 2, 106, 528,
-	3, 2, 2, 2, 108, 530, 3, 2, 2, 2, 110, 532, 3, 2, 2, 2, 112, 536, 3, 2,
-	2, 2, 114, 540, 3, 2, 2, 2, 116, 547, 3, 2, 2, 2, 118, 555, 3, 2, 2, 2,
-	120, 564, 3, 2, 2, 2, 122, 575, 3, 2, 2, 2, 124, 577, 3, 2, 2, 2, 126,
-	579, 3, 2, 2, 2, 128, 591, 3, 2, 2, 2, 130, 601, 3, 2, 2, 2, 132, 620,
-	3, 2, 2, 2, 134, 623, 3, 2, 2, 2, 136, 625, 3, 2, 2, 2, 138, 632, 3, 2,
-	2, 2, 140, 642, 3, 2, 2, 2, 142, 650, 3, 2, 2, 2, 144, 652, 3, 2, 2, 2,
-	146, 657, 3, 2, 2, 2, 148, 662, 3, 2, 2, 2, 150, 666, 3, 2, 2, 2, 152,
-	669, 3, 2, 2, 2, 154, 671, 3, 2, 2, 2, 156, 673, 3, 2, 2, 2, 158, 675,
-	3, 2, 2, 2, 160, 679, 3, 2, 2, 2, 162, 691, 3, 2, 2, 2, 164, 165, 5, 4,
-	3, 2, 165, 166, 7, 2, 2, 3, 166, 3, 3, 2, 2, 2, 167, 183, 5, 6, 4, 2, 168,
-	183, 5, 32, 17, 2, 169, 183, 5, 34, 18, 2, 170, 183, 5, 36, 19, 2, 171,
-	183, 5, 38, 20, 2, 172, 183, 5, 40, 21, 2, 173, 183, 5, 44, 23, 2, 174,
-	183, 5, 42, 22, 2, 175, 183, 5, 52, 27, 2, 176, 183, 5, 46, 24, 2, 177,
-	183, 5, 48, 25, 2, 178, 183, 5, 50, 26, 2, 179, 183, 5, 54, 28, 2, 180,
-	183, 5, 62, 32, 2, 181, 183, 5, 72, 37, 2, 182, 167, 3, 2, 2, 2, 182, 168,
-	3, 2, 2, 2, 182, 169, 3, 2, 2, 2, 182, 170, 3, 2, 2, 2, 182, 171, 3, 2,
-	2, 2, 182, 172, 3, 2, 2, 2, 182, 173, 3, 2, 2, 2, 182, 174, 3, 2, 2, 2,
-	182, 175, 3, 2, 2, 2, 182, 176, 3, 2, 2, 2, 182, 177, 3, 2, 2, 2, 182,
-	178, 3, 2, 2, 2, 182, 179, 3, 2, 2, 2, 182, 180, 3, 2, 2, 2, 182, 181,
-	3, 2, 2, 2, 183, 5, 3, 2, 2, 2, 184, 185, 7, 3, 2, 2, 185, 186, 7, 18,
-	2, 2, 186, 189, 5, 30, 16, 2, 187, 188, 7, 28, 2, 2, 188, 190, 5, 8, 5,
-	2, 189, 187, 3, 2, 2, 2, 189, 190, 3, 2, 2, 2, 190, 193, 3, 2, 2, 2, 191,
-	192, 7, 79, 2, 2, 192, 194, 5, 12, 7, 2, 193, 191, 3, 2, 2, 2, 193, 194,
-	3, 2, 2, 2, 194, 7, 3, 2, 2, 2, 195, 200, 5, 10, 6, 2, 196, 197, 7, 79,
-	2, 2, 197, 199, 5, 10, 6, 2, 198, 196, 3, 2, 2, 2, 199, 202, 3, 2, 2, 2,
-	200, 198, 3, 2, 2, 2, 200, 201, 3, 2, 2, 2, 201, 9, 3, 2, 2, 2, 202, 200,
-	3, 2, 2, 2, 203, 204, 7, 7, 2, 2, 204, 218, 5, 132, 67, 2, 205, 206, 7,
-	9, 2, 2, 206, 218, 5, 16, 9, 2, 207, 208, 7, 10, 2, 2, 208, 218, 5, 28,
-	15, 2, 209, 210, 7, 11, 2, 2, 210, 218, 5, 18, 10, 2, 211, 212, 7, 12,
-	2, 2, 212, 218, 5, 20, 11, 2, 213, 214, 7, 13, 2, 2, 214, 218, 5, 22, 12,
-	2, 215, 216, 7, 14, 2, 2, 216, 218, 5, 24, 13, 2, 217, 203, 3, 2, 2, 2,
-	217, 205, 3, 2, 2, 2, 217, 207, 3, 2, 2, 2, 217, 209, 3, 2, 2, 2, 217,
-	211, 3, 2, 2, 2, 217, 213, 3, 2, 2, 2, 217, 215, 3, 2, 2, 2, 218, 11, 3,
-	2, 2, 2, 219, 224, 5, 14, 8, 2, 220, 221, 7, 79, 2, 2, 221, 223, 5, 14,
-	8, 2, 222, 220, 3, 2, 2, 2, 223, 226, 3, 2, 2, 2, 224, 222, 3, 2, 2, 2,
-	224, 225, 3, 2, 2, 2, 225, 13, 3, 2, 2, 2, 226, 224, 3, 2, 2, 2, 227, 228,
-	7, 84, 2, 2, 228, 229, 7, 8, 2, 2, 229, 230, 5, 26, 14, 2, 230, 231, 7,
-	79, 2, 2, 231, 232, 7, 11, 2, 2, 232, 233, 5, 18, 10, 2, 233, 234, 7, 79,
-	2, 2, 234, 235, 7, 7, 2, 2, 235, 236, 5, 132, 67, 2, 236, 237, 7, 85, 2,
-	2, 237, 15, 3, 2, 2, 2, 238, 239, 5, 146, 74, 2, 239, 17, 3, 2, 2, 2, 240,
-	241, 5, 132, 67, 2, 241, 19, 3, 2, 2, 2, 242, 243, 5, 132, 67, 2, 243,
-	21, 3, 2, 2, 2, 244, 245, 5, 132, 67, 2, 245, 23, 3, 2, 2, 2, 246, 247,
-	5, 132, 67, 2, 247, 25, 3, 2, 2, 2, 248, 249, 5, 160, 81, 2, 249, 27, 3,
-	2, 2, 2, 250, 251, 5, 146, 74, 2, 251, 29, 3, 2, 2, 2, 252, 253, 5, 160,
-	81, 2, 253, 31, 3, 2, 2, 2, 254, 255, 7, 4, 2, 2, 255, 256, 7, 18, 2, 2,
-	256, 259, 5, 30, 16, 2, 257, 258, 7, 28, 2, 2, 258, 260, 5, 8, 5, 2, 259,
-	257, 3, 2, 2, 2, 259, 260, 3, 2, 2, 2, 260, 263, 3, 2, 2, 2, 261, 262,
-	7, 79, 2, 2, 262, 264, 5, 12, 7, 2, 263, 261, 3, 2, 2, 2, 263, 264, 3,
-	2, 2, 2, 264, 33, 3, 2, 2, 2, 265, 266, 7, 6, 2, 2, 266, 267, 7, 18, 2,
-	2, 267, 268, 5, 30, 16, 2, 268, 35, 3, 2, 2, 2, 269, 270, 7, 17, 2, 2,
-	270, 271, 7, 19, 2, 2, 271, 37, 3, 2, 2, 2, 272, 273, 7, 17, 2, 2, 273,
-	274, 7, 20, 2, 2, 274, 39, 3, 2, 2, 2, 275, 276, 7, 17, 2, 2, 276, 278,
-	7, 21, 2, 2, 277, 279, 5, 56, 29, 2, 278, 277, 3, 2, 2, 2, 278, 279, 3,
-	2, 2, 2, 279, 281, 3, 2, 2, 2, 280, 282, 5, 150, 76, 2, 281, 280, 3, 2,
-	2, 2, 281, 282, 3, 2, 2, 2, 282, 41, 3, 2, 2, 2, 283, 284, 7, 17, 2, 2,
-	284, 285, 7, 24, 2, 2, 285, 286, 7, 26, 2, 2, 286, 287, 7, 31, 2, 2, 287,
-	289, 5, 152, 77, 2, 288, 290, 5, 150, 76, 2, 289, 288, 3, 2, 2, 2, 289,
-	290, 3, 2, 2, 2, 290, 43, 3, 2, 2, 2, 291, 292, 7, 17, 2, 2, 292, 293,
-	7, 25, 2, 2, 293, 294, 7, 31, 2, 2, 294, 295, 5, 152, 77, 2, 295, 45, 3,
-	2, 2, 2, 296, 297, 7, 17, 2, 2, 297, 298, 7, 24, 2, 2, 298, 299, 7, 29,
-	2, 2, 299, 300, 7, 31, 2, 2, 300, 301, 5, 152, 77, 2, 301, 303, 5, 58,
-	30, 2, 302, 304, 5, 60, 31, 2, 303, 302, 3, 2, 2, 2, 303, 304, 3, 2, 2,
-	2, 304, 306, 3, 2, 2, 2, 305, 307, 5, 150, 76, 2, 306, 305, 3, 2, 2, 2,
-	306, 307, 3, 2, 2, 2, 307, 47, 3, 2, 2, 2, 308, 309, 7, 17, 2, 2, 309,
-	310, 7, 24, 2, 2, 310, 311, 7, 29, 2, 2, 311, 312, 7, 25, 2, 2, 312, 313,
-	7, 31, 2, 2, 313, 314, 5, 152, 77, 2, 314, 315, 5, 58, 30, 2, 315, 316,
-	5, 60, 31, 2, 316, 49, 3, 2, 2, 2, 317, 318, 7, 17, 2, 2, 318, 319, 7,
-	23, 2, 2, 319, 320, 7, 26, 2, 2, 320, 321, 7, 31, 2, 2, 321, 323, 5, 152,
-	77, 2, 322, 324, 5, 150, 76, 2, 323, 322, 3, 2, 2, 2, 323, 324, 3, 2, 2,
-	2, 324, 51, 3, 2, 2, 2, 325, 326, 7, 17, 2, 2, 326, 328, 7, 34, 2, 2, 327,
-	329, 5, 150, 76, 2, 328, 327, 3, 2, 2, 2, 328, 329, 3, 2, 2, 2, 329, 53,
-	3, 2, 2, 2, 330, 331, 7, 17, 2, 2, 331, 334, 7, 56, 2, 2, 332, 333, 7,
-	55, 2, 2, 333, 335, 5, 68, 35, 2, 334, 332, 3, 2, 2, 2, 334, 335, 3, 2,
-	2, 2, 335, 338, 3, 2, 2, 2, 336, 337, 7, 28, 2, 2, 337, 339, 5, 70, 36,
-	2, 338, 336, 3, 2, 2, 2, 338, 339, 3, 2, 2, 2, 339, 55, 3, 2, 2, 2, 340,
-	341, 7, 28, 2, 2, 341, 346, 7, 22, 2, 2, 342, 343, 7, 71, 2, 2, 343, 347,
-	5, 152, 77, 2, 344, 345, 7, 78, 2, 2, 345, 347, 5, 152, 77, 2, 346, 342,
-	3, 2, 2, 2, 346, 344, 3, 2, 2, 2, 347, 57, 3, 2, 2, 2, 348, 349, 7, 28,
-	2, 2, 349, 350, 7, 27, 2, 2, 350, 351, 7, 71, 2, 2, 351, 352, 5, 154, 78,
-	2, 352, 59, 3, 2, 2, 2, 353, 354, 7, 32, 2, 2, 354, 355, 5, 86, 44, 2,
-	355, 61, 3, 2, 2, 2, 356, 357, 7, 15, 2, 2, 357, 358, 7, 35, 2, 2, 358,
-	361, 5, 64, 33, 2, 359, 360, 7, 16, 2, 2, 360, 362, 5, 66, 34, 2, 361,
-	359, 3, 2, 2, 2, 361, 362, 3, 2, 2, 2, 362, 63, 3, 2, 2, 2, 363, 364, 7,
-	92, 2, 2, 364, 65, 3, 2, 2, 2, 365, 366, 7, 92, 2, 2, 366, 67, 3, 2, 2,
-	2, 367, 368, 5, 160, 81, 2, 368, 69, 3, 2, 2, 2, 369, 370, 5, 160, 81,
-	2, 370, 71, 3, 2, 2, 2, 371, 373, 7, 36, 2, 2, 372, 371, 3, 2, 2, 2, 372,
-	373, 3, 2, 2, 2, 373, 374, 3, 2, 2, 2, 374, 375, 7, 38, 2, 2, 375, 376,
-	5, 74, 38, 2, 376, 378, 5, 80, 41, 2, 377, 379, 5, 82, 42, 2, 378, 377,
-	3, 2, 2, 2, 378, 379, 3, 2, 2, 2, 379, 381, 3, 2, 2, 2, 380, 382, 5, 102,
-	52, 2, 381, 380, 3, 2, 2, 2, 381, 382, 3, 2, 2, 2, 382, 384, 3, 2, 2, 2,
-	383, 385, 5, 112, 57, 2, 384, 383, 3, 2, 2, 2, 384, 385, 3, 2, 2, 2, 385,
-	387, 3, 2, 2, 2, 386, 388, 5, 110, 56, 2, 387, 386, 3, 2, 2, 2, 387, 388,
-	3, 2, 2, 2, 388, 390, 3, 2, 2, 2, 389, 391, 5, 150, 76, 2, 390, 389, 3,
-	2, 2, 2, 390, 391, 3, 2, 2, 2, 391, 393, 3, 2, 2, 2, 392, 394, 7, 37, 2,
-	2, 393, 392, 3, 2, 2, 2, 393, 394, 3, 2, 2, 2, 394, 73, 3, 2, 2, 2, 395,
-	400, 5, 76, 39, 2, 396, 397, 7, 79, 2, 2, 397, 399, 5, 76, 39, 2, 398,
-	396, 3, 2, 2, 2, 399, 402, 3, 2, 2, 2, 400, 398, 3, 2, 2, 2, 400, 401,
-	3, 2, 2, 2, 401, 75, 3, 2, 2, 2, 402, 400, 3, 2, 2, 2, 403, 405, 5, 130,
-	66, 2, 404, 406, 5, 78, 40, 2, 405, 404, 3, 2, 2, 2, 405, 406, 3, 2, 2,
-	2, 406, 77, 3, 2, 2, 2, 407, 408, 7, 39, 2, 2, 408, 409, 5, 160, 81, 2,
-	409, 79, 3, 2, 2, 2, 410, 411, 7, 31, 2, 2, 411, 412, 5, 152, 77, 2, 412,
-	81, 3, 2, 2, 2, 413, 414, 7, 32, 2, 2, 414, 415, 5, 84, 43, 2, 415, 83,
-	3, 2, 2, 2, 416, 417, 8, 43, 1, 2, 417, 420, 5, 90, 46, 2, 418, 420, 5,
-	94, 48, 2, 419, 416, 3, 2, 2, 2, 419, 418, 3, 2, 2, 2, 420, 426, 3, 2,
-	2, 2, 421, 422, 12, 3, 2, 2, 422, 423, 7, 40, 2, 2, 423, 425, 5, 84, 43,
-	4, 424, 421, 3, 2, 2, 2, 425, 428, 3, 2, 2, 2, 426, 424, 3, 2, 2, 2, 426,
-	427, 3, 2, 2, 2, 427, 85, 3, 2, 2, 2, 428, 426, 3, 2, 2, 2, 429, 437, 5,
-	88, 45, 2, 430, 437, 5, 90, 46, 2, 431, 434, 5, 88, 45, 2, 432, 433, 7,
-	40, 2, 2, 433, 435, 5, 90, 46, 2, 434, 432, 3, 2, 2, 2, 434, 435, 3, 2,
-	2, 2, 435, 437, 3, 2, 2, 2, 436, 429, 3, 2, 2, 2, 436, 430, 3, 2, 2, 2,
-	436, 431, 3, 2, 2, 2, 437, 87, 3, 2, 2, 2, 438, 439, 7, 30, 2, 2, 439,
-	440, 7, 71, 2, 2, 440, 441, 5, 158, 80, 2, 441, 89, 3, 2, 2, 2, 442, 443,
-	8, 46, 1, 2, 443, 444, 7, 84, 2, 2, 444, 445, 5, 90, 46, 2, 445, 446, 7,
-	85, 2, 2, 446, 462, 3, 2, 2, 2, 447, 448, 5, 154, 78, 2, 448, 449, 9, 2,
-	2, 2, 449, 450, 5, 156, 79, 2, 450, 462, 3, 2, 2, 2, 451, 455, 5, 154,
-	78, 2, 452, 456, 7, 59, 2, 2, 453, 454, 7, 49, 2, 2, 454, 456, 7, 59, 2,
-	2, 455, 452, 3, 2, 2, 2, 455, 453, 3, 2, 2, 2, 456, 457, 3, 2, 2, 2, 457,
-	458, 7, 84, 2, 2, 458, 459, 5, 92, 47, 2, 459, 460, 7, 85, 2, 2, 460, 462,
-	3, 2, 2, 2, 461, 442, 3, 2, 2, 2, 461, 447, 3, 2, 2, 2, 461, 451, 3, 2,
-	2, 2, 462, 468, 3, 2, 2, 2, 463, 464, 12, 3, 2, 2, 464, 465, 9, 3, 2, 2,
-	465, 467, 5, 90, 46, 4, 466, 463, 3, 2, 2, 2, 467, 470, 3, 2, 2, 2, 468,
-	466, 3, 2, 2, 2, 468, 469, 3, 2, 2, 2, 469, 91, 3, 2, 2, 2, 470, 468, 3,
-	2, 2, 2, 471, 476, 5, 156, 79, 2, 472, 473, 7, 79, 2, 2, 473, 475, 5, 156,
-	79, 2, 474, 472, 3, 2, 2, 2, 475, 478, 3, 2, 2, 2, 476, 474, 3, 2, 2, 2,
-	476, 477, 3, 2, 2, 2, 477, 93, 3, 2, 2, 2, 478, 476, 3, 2, 2, 2, 479, 482,
-	5, 96, 49, 2, 480, 481, 7, 40, 2, 2, 481, 483, 5, 96, 49, 2, 482, 480,
-	3, 2, 2, 2, 482, 483, 3, 2, 2, 2, 483, 95, 3, 2, 2, 2, 484, 485, 7, 57,
-	2, 2, 485, 488, 5, 128, 65, 2, 486, 489, 5, 98, 50, 2, 487, 489, 5, 160,
-	81, 2, 488, 486, 3, 2, 2, 2, 488, 487, 3, 2, 2, 2, 489, 97, 3, 2, 2, 2,
-	490, 492, 5, 100, 51, 2, 491, 493, 5, 132, 67, 2, 492, 491, 3, 2, 2, 2,
-	492, 493, 3, 2, 2, 2, 493, 99, 3, 2, 2, 2, 494, 495, 7, 58, 2, 2, 495,
-	497, 7, 84, 2, 2, 496, 498, 5, 138, 70, 2, 497, 496, 3, 2, 2, 2, 497, 498,
-	3, 2, 2, 2, 498, 499, 3, 2, 2, 2, 499, 500, 7, 85, 2, 2, 500, 101, 3, 2,
-	2, 2, 501, 502, 7, 52, 2, 2, 502, 503, 7, 54, 2, 2, 503, 509, 5, 104, 53,
-	2, 504, 505, 7, 42, 2, 2, 505, 506, 7, 84, 2, 2, 506, 507, 5, 108, 55,
-	2, 507, 508, 7, 85, 2, 2, 508, 510, 3, 2, 2, 2, 509, 504, 3, 2, 2, 2, 509,
-	510, 3, 2, 2, 2, 510, 512, 3, 2, 2, 2, 511, 513, 5, 118, 60, 2, 512, 511,
-	3, 2, 2, 2, 512, 513, 3, 2, 2, 2, 513, 103, 3, 2, 2, 2, 514, 519, 5, 106,
-	54, 2, 515, 516, 7, 79, 2, 2, 516, 518, 5, 106, 54, 2, 517, 515, 3, 2,
-	2, 2, 518, 521, 3, 2, 2, 2, 519, 517, 3, 2, 2, 2, 519, 520, 3, 2, 2, 2,
-	520, 105, 3, 2, 2, 2, 521, 519, 3, 2, 2, 2, 522, 529, 5, 160, 81, 2, 523,
-	524, 7, 57, 2, 2, 524, 525, 7, 84, 2, 2, 525, 526, 5, 132, 67, 2, 526,
-	527, 7, 85, 2, 2, 527, 529, 3, 2, 2, 2, 528, 522, 3, 2, 2, 2, 528, 523,
-	3, 2, 2, 2, 529, 107, 3, 2, 2, 2, 530, 531, 9, 4, 2, 2, 531, 109, 3, 2,
-	2, 2, 532, 533, 7, 45, 2, 2, 533, 534, 7, 54, 2, 2, 534, 535, 5, 116, 59,
-	2, 535, 111, 3, 2, 2, 2, 536, 537, 7, 7, 2, 2, 537, 538, 7, 54, 2, 2, 538,
-	539, 5, 26, 14, 2, 539, 113, 3, 2, 2, 2, 540, 544, 5, 130, 66, 2, 541,
-	543, 9, 5, 2, 2, 542, 541, 3, 2, 2, 2, 543, 546, 3, 2, 2, 2, 544, 542,
-	3, 2, 2, 2, 544, 545, 3, 2, 2, 2, 545, 115, 3, 2, 2, 2, 546, 544, 3, 2,
-	2, 2, 547, 552, 5, 114, 58, 2, 548, 549, 7, 79, 2, 2, 549, 551, 5, 114,
-	58, 2, 550, 548, 3, 2, 2, 2, 551, 554, 3, 2, 2, 2, 552, 550, 3, 2, 2, 2,
-	552, 553, 3, 2, 2, 2, 553, 117, 3, 2, 2, 2, 554, 552, 3, 2, 2, 2, 555,
-	556, 7, 53, 2, 2, 556, 557, 5, 120, 61, 2, 557, 119, 3, 2, 2, 2, 558, 559,
-	8, 61, 1, 2, 559, 560, 7, 84, 2, 2, 560, 561, 5, 120, 61, 2, 561, 562,
-	7, 85, 2, 2, 562, 565, 3, 2, 2, 2, 563, 565, 5, 124, 63, 2, 564, 558, 3,
-	2, 2, 2, 564, 563, 3, 2, 2, 2, 565, 572, 3, 2, 2, 2, 566, 567, 12, 4, 2,
-	2, 567, 568, 5, 122, 62, 2, 568, 569, 5, 120, 61, 5, 569, 571, 3, 2, 2,
-	2, 570, 566, 3, 2, 2, 2, 571, 574, 3, 2, 2, 2, 572, 570, 3, 2, 2, 2, 572,
-	573, 3, 2, 2, 2, 573, 121, 3, 2, 2, 2, 574, 572, 3, 2, 2, 2, 575, 576,
-	9, 3, 2, 2, 576, 123, 3, 2, 2, 2, 577, 578, 5, 126, 64, 2, 578, 125, 3,
-	2, 2, 2, 579, 580, 5, 130, 66, 2, 580, 581, 5, 128, 65, 2, 581, 582, 5,
-	130, 66, 2, 582, 127, 3, 2, 2, 2, 583, 592, 7, 71, 2, 2, 584, 592, 7, 72,
-	2, 2, 585, 592, 7, 73, 2, 2, 586, 592, 7, 76, 2, 2, 587, 592, 7, 77, 2,
-	2, 588, 592, 7, 74, 2, 2, 589, 592, 7, 75, 2, 2, 590, 592, 9, 6, 2, 2,
-	591, 583, 3, 2, 2, 2, 591, 584, 3, 2, 2, 2, 591, 585, 3, 2, 2, 2, 591,
-	586, 3, 2, 2, 2, 591, 587, 3, 2, 2, 2, 591, 588, 3, 2, 2, 2, 591, 589,
-	3, 2, 2, 2, 591, 590, 3, 2, 2, 2, 592, 129, 3, 2, 2, 2, 593, 594, 8, 66,
-	1, 2, 594, 595, 7, 84, 2, 2, 595, 596, 5, 130, 66, 2, 596, 597, 7, 85,
-	2, 2, 597, 602, 3, 2, 2, 2, 598, 602, 5, 136, 69, 2, 599, 602, 5, 142,
-	72, 2, 600, 602, 5, 132, 67, 2, 601, 593, 3, 2, 2, 2, 601, 598, 3, 2, 2,
-	2, 601, 599, 3, 2, 2, 2, 601, 600, 3, 2, 2, 2, 602, 617, 3, 2, 2, 2, 603,
-	604, 12, 10, 2, 2, 604, 605, 7, 89, 2, 2, 605, 616, 5, 130, 66, 11, 606,
-	607, 12, 9, 2, 2, 607, 608, 7, 88, 2, 2, 608, 616, 5, 130, 66, 10, 609,
-	610, 12, 8, 2, 2, 610, 611, 7, 86, 2, 2, 611, 616, 5, 130, 66, 9, 612,
-	613, 12, 7, 2, 2, 613, 614, 7, 87, 2, 2, 614, 616, 5, 130, 66, 8, 615,
-	603, 3, 2, 2, 2, 615, 606, 3, 2, 2, 2, 615, 609, 3, 2, 2, 2, 615, 612,
-	3, 2, 2, 2, 616, 619, 3, 2, 2, 2, 617, 615, 3, 2, 2, 2, 617, 618, 3, 2,
-	2, 2, 618, 131, 3, 2, 2, 2, 619, 617, 3, 2, 2, 2, 620, 621, 5, 146, 74,
-	2, 621, 622, 5, 134, 68, 2, 622, 133, 3, 2, 2, 2, 623, 624, 9, 7, 2, 2,
-	624, 135, 3, 2, 2, 2, 625, 626, 5, 160, 81, 2, 626, 628, 7, 84, 2, 2, 627,
-	629, 5, 138, 70, 2, 628, 627, 3, 2, 2, 2, 628, 629, 3, 2, 2, 2, 629, 630,
-	3, 2, 2, 2, 630, 631, 7, 85, 2, 2, 631, 137, 3, 2, 2, 2, 632, 637, 5, 140,
-	71, 2, 633, 634, 7, 79, 2, 2, 634, 636, 5, 140, 71, 2, 635, 633, 3, 2,
-	2, 2, 636, 639, 3, 2, 2, 2, 637, 635, 3, 2, 2, 2, 637, 638, 3, 2, 2, 2,
-	638, 139, 3, 2, 2, 2, 639, 637, 3, 2, 2, 2, 640, 643, 5, 130, 66, 2, 641,
-	643, 5, 90, 46, 2, 642, 640, 3, 2, 2, 2, 642, 641, 3, 2, 2, 2, 643, 141,
-	3, 2, 2, 2, 644, 646, 5, 160, 81, 2, 645, 647, 5, 144, 73, 2, 646, 645,
-	3, 2, 2, 2, 646, 647, 3, 2, 2, 2, 647, 651, 3, 2, 2, 2, 648, 651, 5, 148,
-	75, 2, 649, 651, 5, 146, 74, 2, 650, 644, 3, 2, 2, 2, 650, 648, 3, 2, 2,
-	2, 650, 649, 3, 2, 2, 2, 651, 143, 3, 2, 2, 2, 652, 653, 7, 82, 2, 2, 653,
-	654, 5, 90, 46, 2, 654, 655, 7, 83, 2, 2, 655, 145, 3, 2, 2, 2, 656, 658,
-	9, 8, 2, 2, 657, 656, 3, 2, 2, 2, 657, 658, 3, 2, 2, 2, 658, 659, 3, 2,
-	2, 2, 659, 660, 7, 92, 2, 2, 660, 147, 3, 2, 2, 2, 661, 663, 9, 8, 2, 2,
-	662, 661, 3, 2, 2, 2, 662, 663, 3, 2, 2, 2, 663, 664, 3, 2, 2, 2, 664,
-	665, 7, 93, 2, 2, 665, 149, 3, 2, 2, 2, 666, 667, 7, 33, 2, 2, 667, 668,
-	7, 92, 2, 2, 668, 151, 3, 2, 2, 2, 669, 670, 5, 160, 81, 2, 670, 153, 3,
-	2, 2, 2, 671, 672, 5, 160, 81, 2, 672, 155, 3, 2, 2, 2, 673, 674, 5, 160,
-	81, 2, 674, 157, 3, 2, 2, 2, 675, 676, 5, 160, 81, 2, 676, 159, 3, 2, 2,
-	2, 677, 680, 7, 91, 2, 2, 678, 680, 5, 162, 82, 2, 679, 677, 3, 2, 2, 2,
-	679, 678, 3, 2, 2, 2, 680, 688, 3, 2, 2, 2, 681, 684, 7, 69, 2, 2, 682,
-	685, 7, 91, 2, 2, 683, 685, 5, 162, 82, 2, 684, 682, 3, 2, 2, 2, 684, 683,
-	3, 2, 2, 2, 685, 687, 3, 2, 2, 2, 686, 681, 3, 2, 2, 2, 687, 690, 3, 2,
-	2, 2, 688, 686, 3, 2, 2, 2, 688, 689, 3, 2, 2, 2, 689, 161, 3, 2, 2, 2,
-	690, 688, 3, 2, 2, 2, 691, 692, 9, 9, 2, 2, 692, 163, 3, 2, 2, 2, 64, 182,
-	189, 193, 200, 217, 224, 259, 263, 278, 281, 289, 303, 306, 323, 328, 334,
-	338, 346, 361, 372, 378, 381, 384, 387, 390, 393, 400, 405, 419, 426, 434,
-	436, 455, 461, 468, 476, 482, 488, 492, 497, 509, 512, 519, 528, 544, 552,
-	564, 572, 591, 601, 615, 617, 628, 637, 642, 646, 650, 657, 662, 679, 684,
-	688,
+	81, 4, 82, 9, 82, 4, 83, 9, 83, 3, 2, 3, 2, 3, 2, 3, 3, 3, 3, 3, 3, 3,
+	3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 5,
+	3, 185, 10, 3, 3, 4, 3, 4, 3, 4, 3, 4, 3, 4, 5, 4, 192, 10, 4, 3, 4, 3,
+	4, 5, 4, 196, 10, 4, 3, 5, 3, 5, 3, 5, 7, 5, 201, 10, 5, 12, 5, 14, 5,
+	204, 11, 5, 3, 6, 3, 6, 3, 6, 3, 6, 3, 6, 3, 6, 3, 6, 3, 6, 3, 6, 3, 6,
+	3, 6, 3, 6, 3, 6, 3, 6, 5, 6, 220, 10, 6, 3, 7, 3, 7, 3, 7, 7, 7, 225,
+	10, 7, 12, 7, 14, 7, 228, 11, 7, 3, 8, 3, 8, 3, 8, 3, 8, 3, 8, 3, 8, 3,
+	8, 3, 8, 3, 8, 3, 8, 3, 8, 3, 9, 3, 9, 3, 10, 3, 10, 3, 11, 3, 11, 3, 12,
+	3, 12, 3, 13, 3, 13, 3, 14, 3, 14, 3, 15, 3, 15, 3, 16, 3, 16, 3, 17, 3,
+	17, 3, 17, 3, 17, 3, 17, 5, 17, 262, 10, 17, 3, 17, 3, 17, 5, 17, 266,
+	10, 17, 3, 18, 3, 18, 3, 18, 3, 18, 3, 19, 3, 19, 3, 19, 3, 20, 3, 20,
+	3, 20, 3, 21, 3, 21, 3, 21, 5, 21, 281, 10, 21, 3, 21, 5, 21, 284, 10,
+	21, 3, 22, 3, 22, 3, 22, 3, 22, 3, 22, 3, 22, 5, 22, 292, 10, 22, 3, 23,
+	3, 23, 3, 23, 3, 23, 3, 23, 3, 24, 3, 24, 3, 24, 3, 24, 3, 24, 3, 24, 3,
+	24, 5, 24, 306, 10, 24, 3, 24, 5, 24, 309, 10, 24, 3, 25, 3, 25, 3, 25,
+	3, 25, 3, 25, 3, 25, 3, 25, 3, 25, 3, 25, 3, 26, 3, 26, 3, 26, 3, 26, 3,
+	26, 3, 26, 5, 26, 326, 10, 26, 3, 27, 3, 27, 3, 27, 5, 27, 331, 10, 27,
+	3, 28, 3, 28, 3, 28, 3, 28, 5, 28, 337, 10, 28, 3, 28, 3, 28, 5, 28, 341,
+	10, 28, 3, 29, 3, 29, 3, 29, 3, 29, 3, 29, 3, 29, 5, 29, 349, 10, 29, 3,
+	30, 3, 30, 3, 30, 3, 30, 3, 30, 3, 31, 3, 31, 3, 31, 3, 32, 3, 32, 3, 32,
+	3, 32, 3, 32, 5, 32, 364, 10, 32, 3, 33, 3, 33, 3, 34, 3, 34, 3, 35, 3,
+	35, 3, 36, 3, 36, 3, 37, 5, 37, 375, 10, 37, 3, 37, 3, 37, 3, 37, 3, 37,
+	5, 37, 381, 10, 37, 3, 37, 5, 37, 384, 10, 37, 3, 37, 5, 37, 387, 10, 37,
+	3, 37, 5, 37, 390, 10, 37, 3, 37, 5, 37, 393, 10, 37, 3, 37, 5, 37, 396,
+	10, 37, 3, 38, 3, 38, 3, 38, 7, 38, 401, 10, 38, 12, 38, 14, 38, 404, 11,
+	38, 3, 39, 3, 39, 5, 39, 408, 10, 39, 3, 40, 3, 40, 3, 40, 3, 41, 3, 41,
+	3, 41, 3, 42, 3, 42, 3, 42, 3, 43, 3, 43, 3, 43, 5, 43, 422, 10, 43, 3,
+	43, 3, 43, 3, 43, 7, 43, 427, 10, 43, 12, 43, 14, 43, 430, 11, 43, 3, 44,
+	3, 44, 3, 44, 3, 44, 3, 44, 5, 44, 437, 10, 44, 5, 44, 439, 10, 44, 3,
+	45, 3, 45, 3, 45, 3, 45, 3, 46, 3, 46, 3, 46, 3, 46, 3, 46, 3, 46, 3, 46,
+	3, 46, 3, 46, 3, 46, 3, 46, 3, 46, 3, 46, 5, 46, 458, 10, 46, 3, 46, 3,
+	46, 3, 46, 3, 46, 5, 46, 464, 10, 46, 3, 46, 3, 46, 3, 46, 7, 46, 469,
+	10, 46, 12, 46, 14, 46, 472, 11, 46, 3, 47, 3, 47, 3, 47, 7, 47, 477, 10,
+	47, 12, 47, 14, 47, 480, 11, 47, 3, 48, 3, 48, 3, 48, 5, 48, 485, 10, 48,
+	3, 49, 3, 49, 3, 49, 3, 49, 5, 49, 491, 10, 49, 3, 50, 3, 50, 5, 50, 495,
+	10, 50, 3, 51, 3, 51, 3, 51, 5, 51, 500, 10, 51, 3, 51, 3, 51, 3, 52, 3,
+	52, 3, 52, 3, 52, 3, 52, 3, 52, 3, 52, 3, 52, 5, 52, 512, 10, 52, 3, 52,
+	5, 52, 515, 10, 52, 3, 53, 3, 53, 3, 53, 7, 53, 520, 10, 53, 12, 53, 14,
+	53, 523, 11, 53, 3, 54, 3, 54, 3, 54, 3, 54, 3, 54, 3, 54, 5, 54, 531,
+	10, 54, 3, 55, 3, 55, 3, 56, 3, 56, 3, 56, 3, 56, 3, 57, 3, 57, 3, 57,
+	3, 57, 3, 58, 3, 58, 7, 58, 545, 10, 58, 12, 58, 14, 58, 548, 11, 58, 3,
+	59, 3, 59, 3, 59, 7, 59, 553, 10, 59, 12, 59, 14, 59, 556, 11, 59, 3, 60,
+	3, 60, 3, 60, 3, 61, 3, 61, 3, 61, 3, 61, 3, 61, 3, 61, 5, 61, 567, 10,
+	61, 3, 61, 3, 61, 3, 61, 3, 61, 7, 61, 573, 10, 61, 12, 61, 14, 61, 576,
+	11, 61, 3, 62, 3, 62, 3, 63, 3, 63, 3, 64, 3, 64, 3, 64, 3, 64, 3, 65,
+	3, 65, 3, 65, 3, 65, 3, 65, 3, 65, 3, 65, 3, 65, 5, 65, 594, 10, 65, 3,
+	66, 3, 66, 3, 66, 3, 66, 3, 66, 3, 66, 3, 66, 3, 66, 3, 66, 3, 66, 5, 66,
+	606, 10, 66, 3, 66, 3, 66, 3, 66, 3, 66, 3, 66, 3, 66, 7, 66, 614, 10,
+	66, 12, 66, 14, 66, 617, 11, 66, 3, 67, 3, 67, 3, 67, 3, 68, 3, 68, 3,
+	69, 3, 69, 3, 69, 5, 69, 627, 10, 69, 3, 69, 3, 69, 3, 70, 3, 70, 3, 70,
+	7, 70, 634, 10, 70, 12, 70, 14, 70, 637, 11, 70, 3, 71, 3, 71, 5, 71, 641,
+	10, 71, 3, 72, 3, 72, 5, 72, 645, 10, 72, 3, 72, 3, 72, 3, 72, 5, 72, 650,
+	10, 72, 3, 73, 3, 73, 3, 73, 3, 73, 3, 74, 5, 74, 657, 10, 74, 3, 74, 3,
+	74, 3, 75, 5, 75, 662, 10, 75, 3, 75, 3, 75, 3, 76, 3, 76, 3, 76, 3, 77,
+	3, 77, 3, 78, 3, 78, 3, 79, 3, 79, 3, 80, 3, 80, 3, 81, 3, 81, 5, 81, 679,
+	10, 81, 3, 81, 3, 81, 3, 81, 5, 81, 684, 10, 81, 7, 81, 686, 10, 81, 12,
+	81, 14, 81, 689, 11, 81, 3, 82, 3, 82, 3, 82, 3, 83, 3, 83, 3, 83, 3, 83,
+	5, 83, 698, 10, 83, 3, 83, 3, 83, 3, 83, 3, 83, 2, 6, 84, 90, 120, 130,
+	84, 2, 4, 6, 8, 10, 12, 14, 16, 18, 20, 22, 24, 26, 28, 30, 32, 34, 36,
+	38, 40, 42, 44, 46, 48, 50, 52, 54, 56, 58, 60, 62, 64, 66, 68, 70, 72,
+	74, 76, 78, 80, 82, 84, 86, 88, 90, 92, 94, 96, 98, 100, 102, 104, 106,
+	108, 110, 112, 114, 116, 118, 120, 122, 124, 126, 128, 130, 132, 134, 136,
+	138, 140, 142, 144, 146, 148, 150, 152, 154, 156, 158, 160, 162, 164, 2,
+	11, 5, 2, 48, 48, 71, 73, 78, 78, 3, 2, 40, 41, 4, 2, 43, 44, 92, 93, 3,
+	2, 46, 47, 4, 2, 48, 48, 78, 78, 3, 2, 88, 89, 3, 2, 86, 87, 3, 2, 62,
+	68, 11, 2, 3, 3, 7, 7, 9, 11, 15, 24, 26, 29, 31, 35, 38, 52, 54, 57, 61,
+	68, 2, 714, 2, 166, 3, 2, 2, 2, 4, 184, 3, 2, 2, 2, 6, 186, 3, 2, 2, 2,
+	8, 197, 3, 2, 2, 2, 10, 219, 3, 2, 2, 2, 12, 221, 3, 2, 2, 2, 14, 229,
+	3, 2, 2, 2, 16, 240, 3, 2, 2, 2, 18, 242, 3, 2, 2, 2, 20, 244, 3, 2, 2,
+	2, 22, 246, 3, 2, 2, 2, 24, 248, 3, 2, 2, 2, 26, 250, 3, 2, 2, 2, 28, 252,
+	3, 2, 2, 2, 30, 254, 3, 2, 2, 2, 32, 256, 3, 2, 2, 2, 34, 267, 3, 2, 2,
+	2, 36, 271, 3, 2, 2, 2, 38, 274, 3, 2, 2, 2, 40, 277, 3, 2, 2, 2, 42, 285,
+	3, 2, 2, 2, 44, 293, 3, 2, 2, 2, 46, 298, 3, 2, 2, 2, 48, 310, 3, 2, 2,
+	2, 50, 319, 3, 2, 2, 2, 52, 327, 3, 2, 2, 2, 54, 332, 3, 2, 2, 2, 56, 342,
+	3, 2, 2, 2, 58, 350, 3, 2, 2, 2, 60, 355, 3, 2, 2, 2, 62, 358, 3, 2, 2,
+	2, 64, 365, 3, 2, 2, 2, 66, 367, 3, 2, 2, 2, 68, 369, 3, 2, 2, 2, 70, 371,
+	3, 2, 2, 2, 72, 374, 3, 2, 2, 2, 74, 397, 3, 2, 2, 2, 76, 405, 3, 2, 2,
+	2, 78, 409, 3, 2, 2, 2, 80, 412, 3, 2, 2, 2, 82, 415, 3, 2, 2, 2, 84, 421,
+	3, 2, 2, 2, 86, 438, 3, 2, 2, 2, 88, 440, 3, 2, 2, 2, 90, 463, 3, 2, 2,
+	2, 92, 473, 3, 2, 2, 2, 94, 481, 3, 2, 2, 2, 96, 486, 3, 2, 2, 2, 98, 492,
+	3, 2, 2, 2, 100, 496, 3, 2, 2, 2, 102, 503, 3, 2, 2, 2, 104, 516, 3, 2,
+	2, 2, 106, 530, 3, 2, 2, 2, 108, 532, 3, 2, 2, 2, 110, 534, 3, 2, 2, 2,
+	112, 538, 3, 2, 2, 2, 114, 542, 3, 2, 2, 2, 116, 549, 3, 2, 2, 2, 118,
+	557, 3, 2, 2, 2, 120, 566, 3, 2, 2, 2, 122, 577, 3, 2, 2, 2, 124, 579,
+	3, 2, 2, 2, 126, 581, 3, 2, 2, 2, 128, 593, 3, 2, 2, 2, 130, 605, 3, 2,
+	2, 2, 132, 618, 3, 2, 2, 2, 134, 621, 3, 2, 2, 2, 136, 623, 3, 2, 2, 2,
+	138, 630, 3, 2, 2, 2, 140, 640, 3, 2, 2, 2, 142, 649, 3, 2, 2, 2, 144,
+	651, 3, 2, 2, 2, 146, 656, 3, 2, 2, 2, 148, 661, 3, 2, 2, 2, 150, 665,
+	3, 2, 2, 2, 152, 668, 3, 2, 2, 2, 154, 670, 3, 2, 2, 2, 156, 672, 3, 2,
+	2, 2, 158, 674, 3, 2, 2, 2, 160, 678, 3, 2, 2, 2, 162, 690, 3, 2, 2, 2,
+	164, 697, 3, 2, 2, 2, 166, 167, 5, 4, 3, 2, 167, 168, 7, 2, 2, 3, 168,
+	3, 3, 2, 2, 2, 169, 185, 5, 6, 4, 2, 170, 185, 5, 32, 17, 2, 171, 185,
+	5, 34, 18, 2, 172, 185, 5, 36, 19, 2, 173, 185, 5, 38, 20, 2, 174, 185,
+	5, 40, 21, 2, 175, 185, 5, 44, 23, 2, 176, 185, 5, 42, 22, 2, 177, 185,
+	5, 52, 27, 2, 178, 185, 5, 46, 24, 2, 179, 185, 5, 48, 25, 2, 180, 185,
+	5, 50, 26, 2, 181, 185, 5, 54, 28, 2, 182, 185, 5, 62, 32, 2, 183, 185,
+	5, 72, 37, 2, 184, 169, 3, 2, 2, 2, 184, 170, 3, 2, 2, 2, 184, 171, 3,
+	2, 2, 2, 184, 172, 3, 2, 2, 2, 184, 173, 3, 2, 2, 2, 184, 174, 3, 2, 2,
+	2, 184, 175, 3, 2, 2, 2, 184, 176, 3, 2, 2, 2, 184, 177, 3, 2, 2, 2, 184,
+	178, 3, 2, 2, 2, 184, 179, 3, 2, 2, 2, 184, 180, 3, 2, 2, 2, 184, 181,
+	3, 2, 2, 2, 184, 182, 3, 2, 2, 2, 184, 183, 3, 2, 2, 2, 185, 5, 3, 2, 2,
+	2, 186, 187, 7, 3, 2, 2, 187, 188, 7, 18, 2, 2, 188, 191, 5, 30, 16, 2,
+	189, 190, 7, 28, 2, 2, 190, 192, 5, 8, 5, 2, 191, 189, 3, 2, 2, 2, 191,
+	192, 3, 2, 2, 2, 192, 195, 3, 2, 2, 2, 193, 194, 7, 79, 2, 2, 194, 196,
+	5, 12, 7, 2, 195, 193, 3, 2, 2, 2, 195, 196, 3, 2, 2, 2, 196, 7, 3, 2,
+	2, 2, 197, 202, 5, 10, 6, 2, 198, 199, 7, 79, 2, 2, 199, 201, 5, 10, 6,
+	2, 200, 198, 3, 2, 2, 2, 201, 204, 3, 2, 2, 2, 202, 200, 3, 2, 2, 2, 202,
+	203, 3, 2, 2, 2, 203, 9, 3, 2, 2, 2, 204, 202, 3, 2, 2, 2, 205, 206, 7,
+	7, 2, 2, 206, 220, 5, 132, 67, 2, 207, 208, 7, 9, 2, 2, 208, 220, 5, 16,
+	9, 2, 209, 210, 7, 10, 2, 2, 210, 220, 5, 28, 15, 2, 211, 212, 7, 11, 2,
+	2, 212, 220, 5, 18, 10, 2, 213, 214, 7, 12, 2, 2, 214, 220, 5, 20, 11,
+	2, 215, 216, 7, 13, 2, 2, 216, 220, 5, 22, 12, 2, 217, 218, 7, 14, 2, 2,
+	218, 220, 5, 24, 13, 2, 219, 205, 3, 2, 2, 2, 219, 207, 3, 2, 2, 2, 219,
+	209, 3, 2, 2, 2, 219, 211, 3, 2, 2, 2, 219, 213, 3, 2, 2, 2, 219, 215,
+	3, 2, 2, 2, 219, 217, 3, 2, 2, 2, 220, 11, 3, 2, 2, 2, 221, 226, 5, 14,
+	8, 2, 222, 223, 7, 79, 2, 2, 223, 225, 5, 14, 8, 2, 224, 222, 3, 2, 2,
+	2, 225, 228, 3, 2, 2, 2, 226, 224, 3, 2, 2, 2, 226, 227, 3, 2, 2, 2, 227,
+	13, 3, 2, 2, 2, 228, 226, 3, 2, 2, 2, 229, 230, 7, 84, 2, 2, 230, 231,
+	7, 8, 2, 2, 231, 232, 5, 26, 14, 2, 232, 233, 7, 79, 2, 2, 233, 234, 7,
+	11, 2, 2, 234, 235, 5, 18, 10, 2, 235, 236, 7, 79, 2, 2, 236, 237, 7, 7,
+	2, 2, 237, 238, 5, 132, 67, 2, 238, 239, 7, 85, 2, 2, 239, 15, 3, 2, 2,
+	2, 240, 241, 5, 146, 74, 2, 241, 17, 3, 2, 2, 2, 242, 243, 5, 132, 67,
+	2, 243, 19, 3, 2, 2, 2, 244, 245, 5, 132, 67, 2, 245, 21, 3, 2, 2, 2, 246,
+	247, 5, 132, 67, 2, 247, 23, 3, 2, 2, 2, 248, 249, 5, 132, 67, 2, 249,
+	25, 3, 2, 2, 2, 250, 251, 5, 160, 81, 2, 251, 27, 3, 2, 2, 2, 252, 253,
+	5, 146, 74, 2, 253, 29, 3, 2, 2, 2, 254, 255, 5, 160, 81, 2, 255, 31, 3,
+	2, 2, 2, 256, 257, 7, 4, 2, 2, 257, 258, 7, 18, 2, 2, 258, 261, 5, 30,
+	16, 2, 259, 260, 7, 28, 2, 2, 260, 262, 5, 8, 5, 2, 261, 259, 3, 2, 2,
+	2, 261, 262, 3, 2, 2, 2, 262, 265, 3, 2, 2, 2, 263, 264, 7, 79, 2, 2, 264,
+	266, 5, 12, 7, 2, 265, 263, 3, 2, 2, 2, 265, 266, 3, 2, 2, 2, 266, 33,
+	3, 2, 2, 2, 267, 268, 7, 6, 2, 2, 268, 269, 7, 18, 2, 2, 269, 270, 5, 30,
+	16, 2, 270, 35, 3, 2, 2, 2, 271, 272, 7, 17, 2, 2, 272, 273, 7, 19, 2,
+	2, 273, 37, 3, 2, 2, 2, 274, 275, 7, 17, 2, 2, 275, 276, 7, 20, 2, 2, 276,
+	39, 3, 2, 2, 2, 277, 278, 7, 17, 2, 2, 278, 280, 7, 21, 2, 2, 279, 281,
+	5, 56, 29, 2, 280, 279, 3, 2, 2, 2, 280, 281, 3, 2, 2, 2, 281, 283, 3,
+	2, 2, 2, 282, 284, 5, 150, 76, 2, 283, 282, 3, 2, 2, 2, 283, 284, 3, 2,
+	2, 2, 284, 41, 3, 2, 2, 2, 285, 286, 7, 17, 2, 2, 286, 287, 7, 24, 2, 2,
+	287, 288, 7, 26, 2, 2, 288, 289, 7, 31, 2, 2, 289, 291, 5, 152, 77, 2,
+	290, 292, 5, 150, 76, 2, 291, 290, 3, 2, 2, 2, 291, 292, 3, 2, 2, 2, 292,
+	43, 3, 2, 2, 2, 293, 294, 7, 17, 2, 2, 294, 295, 7, 25, 2, 2, 295, 296,
+	7, 31, 2, 2, 296, 297, 5, 152, 77, 2, 297, 45, 3, 2, 2, 2, 298, 299, 7,
+	17, 2, 2, 299, 300, 7, 24, 2, 2, 300, 301, 7, 29, 2, 2, 301, 302, 7, 31,
+	2, 2, 302, 303, 5, 152, 77, 2, 303, 305, 5, 58, 30, 2, 304, 306, 5, 60,
+	31, 2, 305, 304, 3, 2, 2, 2, 305, 306, 3, 2, 2, 2, 306, 308, 3, 2, 2, 2,
+	307, 309, 5, 150, 76, 2, 308, 307, 3, 2, 2, 2, 308, 309, 3, 2, 2, 2, 309,
+	47, 3, 2, 2, 2, 310, 311, 7, 17, 2, 2, 311, 312, 7, 24, 2, 2, 312, 313,
+	7, 29, 2, 2, 313, 314, 7, 25, 2, 2, 314, 315, 7, 31, 2, 2, 315, 316, 5,
+	152, 77, 2, 316, 317, 5, 58, 30, 2, 317, 318, 5, 60, 31, 2, 318, 49, 3,
+	2, 2, 2, 319, 320, 7, 17, 2, 2, 320, 321, 7, 23, 2, 2, 321, 322, 7, 26,
+	2, 2, 322, 323, 7, 31, 2, 2, 323, 325, 5, 152, 77, 2, 324, 326, 5, 150,
+	76, 2, 325, 324, 3, 2, 2, 2, 325, 326, 3, 2, 2, 2, 326, 51, 3, 2, 2, 2,
+	327, 328, 7, 17, 2, 2, 328, 330, 7, 34, 2, 2, 329, 331, 5, 150, 76, 2,
+	330, 329, 3, 2, 2, 2, 330, 331, 3, 2, 2, 2, 331, 53, 3, 2, 2, 2, 332, 333,
+	7, 17, 2, 2, 333, 336, 7, 56, 2, 2, 334, 335, 7, 55, 2, 2, 335, 337, 5,
+	68, 35, 2, 336, 334, 3, 2, 2, 2, 336, 337, 3, 2, 2, 2, 337, 340, 3, 2,
+	2, 2, 338, 339, 7, 28, 2, 2, 339, 341, 5, 70, 36, 2, 340, 338, 3, 2, 2,
+	2, 340, 341, 3, 2, 2, 2, 341, 55, 3, 2, 2, 2, 342, 343, 7, 28, 2, 2, 343,
+	348, 7, 22, 2, 2, 344, 345, 7, 71, 2, 2, 345, 349, 5, 152, 77, 2, 346,
+	347, 7, 78, 2, 2, 347, 349, 5, 152, 77, 2, 348, 344, 3, 2, 2, 2, 348, 346,
+	3, 2, 2, 2, 349, 57, 3, 2, 2, 2, 350, 351, 7, 28, 2, 2, 351, 352, 7, 27,
+	2, 2, 352, 353, 7, 71, 2, 2, 353, 354, 5, 154, 78, 2, 354, 59, 3, 2, 2,
+	2, 355, 356, 7, 32, 2, 2, 356, 357, 5, 86, 44, 2, 357, 61, 3, 2, 2, 2,
+	358, 359, 7, 15, 2, 2, 359, 360, 7, 35, 2, 2, 360, 363, 5, 64, 33, 2, 361,
+	362, 7, 16, 2, 2, 362, 364, 5, 66, 34, 2, 363, 361, 3, 2, 2, 2, 363, 364,
+	3, 2, 2, 2, 364, 63, 3, 2, 2, 2, 365, 366, 7, 92, 2, 2, 366, 65, 3, 2,
+	2, 2, 367, 368, 7, 92, 2, 2, 368, 67, 3, 2, 2, 2, 369, 370, 5, 160, 81,
+	2, 370, 69, 3, 2, 2, 2, 371, 372, 5, 160, 81, 2, 372, 71, 3, 2, 2, 2, 373,
+	375, 7, 36, 2, 2, 374, 373, 3, 2, 2, 2, 374, 375, 3, 2, 2, 2, 375, 376,
+	3, 2, 2, 2, 376, 377, 7, 38, 2, 2, 377, 378, 5, 74, 38, 2, 378, 380, 5,
+	80, 41, 2, 379, 381, 5, 82, 42, 2, 380, 379, 3, 2, 2, 2, 380, 381, 3, 2,
+	2, 2, 381, 383, 3, 2, 2, 2, 382, 384, 5, 102, 52, 2, 383, 382, 3, 2, 2,
+	2, 383, 384, 3, 2, 2, 2, 384, 386, 3, 2, 2, 2, 385, 387, 5, 112, 57, 2,
+	386, 385, 3, 2, 2, 2, 386, 387, 3, 2, 2, 2, 387, 389, 3, 2, 2, 2, 388,
+	390, 5, 110, 56, 2, 389, 388, 3, 2, 2, 2, 389, 390, 3, 2, 2, 2, 390, 392,
+	3, 2, 2, 2, 391, 393, 5, 150, 76, 2, 392, 391, 3, 2, 2, 2, 392, 393, 3,
+	2, 2, 2, 393, 395, 3, 2, 2, 2, 394, 396, 7, 37, 2, 2, 395, 394, 3, 2, 2,
+	2, 395, 396, 3, 2, 2, 2, 396, 73, 3, 2, 2, 2, 397, 402, 5, 76, 39, 2, 398,
+	399, 7, 79, 2, 2, 399, 401, 5, 76, 39, 2, 400, 398, 3, 2, 2, 2, 401, 404,
+	3, 2, 2, 2, 402, 400, 3, 2, 2, 2, 402, 403, 3, 2, 2, 2, 403, 75, 3, 2,
+	2, 2, 404, 402, 3, 2, 2, 2, 405, 407, 5, 130, 66, 2, 406, 408, 5, 78, 40,
+	2, 407, 406, 3, 2, 2, 2, 407, 408, 3, 2, 2, 2, 408, 77, 3, 2, 2, 2, 409,
+	410, 7, 39, 2, 2, 410, 411, 5, 160, 81, 2, 411, 79, 3, 2, 2, 2, 412, 413,
+	7, 31, 2, 2, 413, 414, 5, 152, 77, 2, 414, 81, 3, 2, 2, 2, 415, 416, 7,
+	32, 2, 2, 416, 417, 5, 84, 43, 2, 417, 83, 3, 2, 2, 2, 418, 419, 8, 43,
+	1, 2, 419, 422, 5, 90, 46, 2, 420, 422, 5, 94, 48, 2, 421, 418, 3, 2, 2,
+	2, 421, 420, 3, 2, 2, 2, 422, 428, 3, 2, 2, 2, 423, 424, 12, 3, 2, 2, 424,
+	425, 7, 40, 2, 2, 425, 427, 5, 84, 43, 4, 426, 423, 3, 2, 2, 2, 427, 430,
+	3, 2, 2, 2, 428, 426, 3, 2, 2, 2, 428, 429, 3, 2, 2, 2, 429, 85, 3, 2,
+	2, 2, 430, 428, 3, 2, 2, 2, 431, 439, 5, 88, 45, 2, 432, 439, 5, 90, 46,
+	2, 433, 436, 5, 88, 45, 2, 434, 435, 7, 40, 2, 2, 435, 437, 5, 90, 46,
+	2, 436, 434, 3, 2, 2, 2, 436, 437, 3, 2, 2, 2, 437, 439, 3, 2, 2, 2, 438,
+	431, 3, 2, 2, 2, 438, 432, 3, 2, 2, 2, 438, 433, 3, 2, 2, 2, 439, 87, 3,
+	2, 2, 2, 440, 441, 7, 30, 2, 2, 441, 442, 7, 71, 2, 2, 442, 443, 5, 158,
+	80, 2, 443, 89, 3, 2, 2, 2, 444, 445, 8, 46, 1, 2, 445, 446, 7, 84, 2,
+	2, 446, 447, 5, 90, 46, 2, 447, 448, 7, 85, 2, 2, 448, 464, 3, 2, 2, 2,
+	449, 450, 5, 154, 78, 2, 450, 451, 9, 2, 2, 2, 451, 452, 5, 156, 79, 2,
+	452, 464, 3, 2, 2, 2, 453, 457, 5, 154, 78, 2, 454, 458, 7, 59, 2, 2, 455,
+	456, 7, 49, 2, 2, 456, 458, 7, 59, 2, 2, 457, 454, 3, 2, 2, 2, 457, 455,
+	3, 2, 2, 2, 458, 459, 3, 2, 2, 2, 459, 460, 7, 84, 2, 2, 460, 461, 5, 92,
+	47, 2, 461, 462, 7, 85, 2, 2, 462, 464, 3, 2, 2, 2, 463, 444, 3, 2, 2,
+	2, 463, 449, 3, 2, 2, 2, 463, 453, 3, 2, 2, 2, 464, 470, 3, 2, 2, 2, 465,
+	466, 12, 3, 2, 2, 466, 467, 9, 3, 2, 2, 467, 469, 5, 90, 46, 4, 468, 465,
+	3, 2, 2, 2, 469, 472, 3, 2, 2, 2, 470, 468, 3, 2, 2, 2, 470, 471, 3, 2,
+	2, 2, 471, 91, 3, 2, 2, 2, 472, 470, 3, 2, 2, 2, 473, 478, 5, 156, 79,
+	2, 474, 475, 7, 79, 2, 2, 475, 477, 5, 156, 79, 2, 476, 474, 3, 2, 2, 2,
+	477, 480, 3, 2, 2, 2, 478, 476, 3, 2, 2, 2, 478, 479, 3, 2, 2, 2, 479,
+	93, 3, 2, 2, 2, 480, 478, 3, 2, 2, 2, 481, 484, 5, 96, 49, 2, 482, 483,
+	7, 40, 2, 2, 483, 485, 5, 96, 49, 2, 484, 482, 3, 2, 2, 2, 484, 485, 3,
+	2, 2, 2, 485, 95, 3, 2, 2, 2, 486, 487, 7, 57, 2, 2, 487, 490, 5, 128,
+	65, 2, 488, 491, 5, 98, 50, 2, 489, 491, 5, 160, 81, 2, 490, 488, 3, 2,
+	2, 2, 490, 489, 3, 2, 2, 2, 491, 97, 3, 2, 2, 2, 492, 494, 5, 100, 51,
+	2, 493, 495, 5, 132, 67, 2, 494, 493, 3, 2, 2, 2, 494, 495, 3, 2, 2, 2,
+	495, 99, 3, 2, 2, 2, 496, 497, 7, 58, 2, 2, 497, 499, 7, 84, 2, 2, 498,
+	500, 5, 138, 70, 2, 499, 498, 3, 2, 2, 2, 499, 500, 3, 2, 2, 2, 500, 501,
+	3, 2, 2, 2, 501, 502, 7, 85, 2, 2, 502, 101, 3, 2, 2, 2, 503, 504, 7, 52,
+	2, 2, 504, 505, 7, 54, 2, 2, 505, 511, 5, 104, 53, 2, 506, 507, 7, 42,
+	2, 2, 507, 508, 7, 84, 2, 2, 508, 509, 5, 108, 55, 2, 509, 510, 7, 85,
+	2, 2, 510, 512, 3, 2, 2, 2, 511, 506, 3, 2, 2, 2, 511, 512, 3, 2, 2, 2,
+	512, 514, 3, 2, 2, 2, 513, 515, 5, 118, 60, 2, 514, 513, 3, 2, 2, 2, 514,
+	515, 3, 2, 2, 2, 515, 103, 3, 2, 2, 2, 516, 521, 5, 106, 54, 2, 517, 518,
+	7, 79, 2, 2, 518, 520, 5, 106, 54, 2, 519, 517, 3, 2, 2, 2, 520, 523, 3,
+	2, 2, 2, 521, 519, 3, 2, 2, 2, 521, 522, 3, 2, 2, 2, 522, 105, 3, 2, 2,
+	2, 523, 521, 3, 2, 2, 2, 524, 531, 5, 160, 81, 2, 525, 526, 7, 57, 2, 2,
+	526, 527, 7, 84, 2, 2, 527, 528, 5, 132, 67, 2, 528, 529, 7, 85, 2, 2,
+	529, 531, 3, 2, 2, 2, 530, 524, 3, 2, 2, 2, 530, 525, 3, 2, 2, 2, 531,
+	107, 3, 2, 2, 2, 532, 533, 9, 4, 2, 2, 533, 109, 3, 2, 2, 2, 534, 535,
+	7, 45, 2, 2, 535, 536, 7, 54, 2, 2, 536, 537, 5, 116, 59, 2, 537, 111,
+	3, 2, 2, 2, 538, 539, 7, 7, 2, 2, 539, 540, 7, 54, 2, 2, 540, 541, 5, 26,
+	14, 2, 541, 113, 3, 2, 2, 2, 542, 546, 5, 130, 66, 2, 543, 545, 9, 5, 2,
+	2, 544, 543, 3, 2, 2, 2, 545, 548, 3, 2, 2, 2, 546, 544, 3, 2, 2, 2, 546,
+	547, 3, 2, 2, 2, 547, 115, 3, 2, 2, 2, 548, 546, 3, 2, 2, 2, 549, 554,
+	5, 114, 58, 2, 550, 551, 7, 79, 2, 2, 551, 553, 5, 114, 58, 2, 552, 550,
+	3, 2, 2, 2, 553, 556, 3, 2, 2, 2, 554, 552, 3, 2, 2, 2, 554, 555, 3, 2,
+	2, 2, 555, 117, 3, 2, 2, 2, 556, 554, 3, 2, 2, 2, 557, 558, 7, 53, 2, 2,
+	558, 559, 5, 120, 61, 2, 559, 119, 3, 2, 2, 2, 560, 561, 8, 61, 1, 2, 561,
+	562, 7, 84, 2, 2, 562, 563, 5, 120, 61, 2, 563, 564, 7, 85, 2, 2, 564,
+	567, 3, 2, 2, 2, 565, 567, 5, 124, 63, 2, 566, 560, 3, 2, 2, 2, 566, 565,
+	3, 2, 2, 2, 567, 574, 3, 2, 2, 2, 568, 569, 12, 4, 2, 2, 569, 570, 5, 122,
+	62, 2, 570, 571, 5, 120, 61, 5, 571, 573, 3, 2, 2, 2, 572, 568, 3, 2, 2,
+	2, 573, 576, 3, 2, 2, 2, 574, 572, 3, 2, 2, 2, 574, 575, 3, 2, 2, 2, 575,
+	121, 3, 2, 2, 2, 576, 574, 3, 2, 2, 2, 577, 578, 9, 3, 2, 2, 578, 123,
+	3, 2, 2, 2, 579, 580, 5, 126, 64, 2, 580, 125, 3, 2, 2, 2, 581, 582, 5,
+	130, 66, 2, 582, 583, 5, 128, 65, 2, 583, 584, 5, 130, 66, 2, 584, 127,
+	3, 2, 2, 2, 585, 594, 7, 71, 2, 2, 586, 594, 7, 72, 2, 2, 587, 594, 7,
+	73, 2, 2, 588, 594, 7, 76, 2, 2, 589, 594, 7, 77, 2, 2, 590, 594, 7, 74,
+	2, 2, 591, 594, 7, 75, 2, 2, 592, 594, 9, 6, 2, 2, 593, 585, 3, 2, 2, 2,
+	593, 586, 3, 2, 2, 2, 593, 587, 3, 2, 2, 2, 593, 588, 3, 2, 2, 2, 593,
+	589, 3, 2, 2, 2, 593, 590, 3, 2, 2, 2, 593, 591, 3, 2, 2, 2, 593, 592,
+	3, 2, 2, 2, 594, 129, 3, 2, 2, 2, 595, 596, 8, 66, 1, 2, 596, 597, 7, 87,
+	2, 2, 597, 606, 5, 130, 66, 9, 598, 599, 7, 84, 2, 2, 599, 600, 5, 130,
+	66, 2, 600, 601, 7, 85, 2, 2, 601, 606, 3, 2, 2, 2, 602, 606, 5, 136, 69,
+	2, 603, 606, 5, 142, 72, 2, 604, 606, 5, 132, 67, 2, 605, 595, 3, 2, 2,
+	2, 605, 598, 3, 2, 2, 2, 605, 602, 3, 2, 2, 2, 605, 603, 3, 2, 2, 2, 605,
+	604, 3, 2, 2, 2, 606, 615, 3, 2, 2, 2, 607, 608, 12, 8, 2, 2, 608, 609,
+	9, 7, 2, 2, 609, 614, 5, 130, 66, 9, 610, 611, 12, 7, 2, 2, 611, 612, 9,
+	8, 2, 2, 612, 614, 5, 130, 66, 8, 613, 607, 3, 2, 2, 2, 613, 610, 3, 2,
+	2, 2, 614, 617, 3, 2, 2, 2, 615, 613, 3, 2, 2, 2, 615, 616, 3, 2, 2, 2,
+	616, 131, 3, 2, 2, 2, 617, 615, 3, 2, 2, 2, 618, 619, 5, 146, 74, 2, 619,
+	620, 5, 134, 68, 2, 620, 133, 3, 2, 2, 2, 621, 622, 9, 9, 2, 2, 622, 135,
+	3, 2, 2, 2, 623, 624, 5, 160, 81, 2, 624, 626, 7, 84, 2, 2, 625, 627, 5,
+	138, 70, 2, 626, 625, 3, 2, 2, 2, 626, 627, 3, 2, 2, 2, 627, 628, 3, 2,
+	2, 2, 628, 629, 7, 85, 2, 2, 629, 137, 3, 2, 2, 2, 630, 635, 5, 140, 71,
+	2, 631, 632, 7, 79, 2, 2, 632, 634, 5, 140, 71, 2, 633, 631, 3, 2, 2, 2,
+	634, 637, 3, 2, 2, 2, 635, 633, 3, 2, 2, 2, 635, 636, 3, 2, 2, 2, 636,
+	139, 3, 2, 2, 2, 637, 635, 3, 2, 2, 2, 638, 641, 5, 130, 66, 2, 639, 641,
+	5, 90, 46, 2, 640, 638, 3, 2, 2, 2, 640, 639, 3, 2, 2, 2, 641, 141, 3,
+	2, 2, 2, 642, 644, 5, 160, 81, 2, 643, 645, 5, 144, 73, 2, 644, 643, 3,
+	2, 2, 2, 644, 645, 3, 2, 2, 2, 645, 650, 3, 2, 2, 2, 646, 650, 5, 164,
+	83, 2, 647, 650, 5, 148, 75, 2, 648, 650, 5, 146, 74, 2, 649, 642, 3, 2,
+	2, 2, 649, 646, 3, 2, 2, 2, 649, 647, 3, 2, 2, 2, 649, 648, 3, 2, 2, 2,
+	650, 143, 3, 2, 2, 2, 651, 652, 7, 82, 2, 2, 652, 653, 5, 90, 46, 2, 653,
+	654, 7, 83, 2, 2, 654, 145, 3, 2, 2, 2, 655, 657, 9, 8, 2, 2, 656, 655,
+	3, 2, 2, 2, 656, 657, 3, 2, 2, 2, 657, 658, 3, 2, 2, 2, 658, 659, 7, 92,
+	2, 2, 659, 147, 3, 2, 2, 2, 660, 662, 9, 8, 2, 2, 661, 660, 3, 2, 2, 2,
+	661, 662, 3, 2, 2, 2, 662, 663, 3, 2, 2, 2, 663, 664, 7, 93, 2, 2, 664,
+	149, 3, 2, 2, 2, 665, 666, 7, 33, 2, 2, 666, 667, 7, 92, 2, 2, 667, 151,
+	3, 2, 2, 2, 668, 669, 5, 160, 81, 2, 669, 153, 3, 2, 2, 2, 670, 671, 5,
+	160, 81, 2, 671, 155, 3, 2, 2, 2, 672, 673, 5, 160, 81, 2, 673, 157, 3,
+	2, 2, 2, 674, 675, 5, 160, 81, 2, 675, 159, 3, 2, 2, 2, 676, 679, 7, 91,
+	2, 2, 677, 679, 5, 162, 82, 2, 678, 676, 3, 2, 2, 2, 678, 677, 3, 2, 2,
+	2, 679, 687, 3, 2, 2, 2, 680, 683, 7, 69, 2, 2, 681, 684, 7, 91, 2, 2,
+	682, 684, 5, 162, 82, 2, 683, 681, 3, 2, 2, 2, 683, 682, 3, 2, 2, 2, 684,
+	686, 3, 2, 2, 2, 685, 680, 3, 2, 2, 2, 686, 689, 3, 2, 2, 2, 687, 685,
+	3, 2, 2, 2, 687, 688, 3, 2, 2, 2, 688, 161, 3, 2, 2, 2, 689, 687, 3, 2,
+	2, 2, 690, 691, 9, 10, 2, 2, 691, 163, 3, 2, 2, 2, 693, 694, 5, 30, 16,
+	2, 694, 695, 7, 70, 2, 2, 695, 696, 7, 70, 2, 2, 696, 698, 3, 2, 2, 2,
+	697, 693, 3, 2, 2, 2, 697, 698, 3, 2, 2, 2, 698, 699, 3, 2, 2, 2, 699,
+	700, 5, 152, 77, 2, 700, 701, 7, 70, 2, 2, 701, 702, 5, 160, 81, 2, 702,
+	165, 3, 2, 2, 2, 65, 184, 191, 195, 202, 219, 226, 261, 265, 280, 283,
+	291, 305, 308, 325, 330, 336, 340, 348, 363, 374, 380, 383, 386, 389, 392,
+	395, 402, 407, 421, 428, 436, 438, 457, 463, 470, 478, 484, 490, 494, 499,
+	511, 514, 521, 530, 546, 554, 566, 574, 593, 605, 613, 615, 626, 635, 640,
+	644, 649, 656, 661, 678, 683, 687, 697,
 }
 var deserializer = antlr.NewATNDeserializer(nil)
 var deserializedATN = deserializer.DeserializeFromUInt16(parserATN)
@@ -367,6 +371,7 @@ var ruleNames = []string{
 	"expr", "durationLit", "intervalItem", "exprFunc", "exprFuncParams", "funcParam",
 	"exprAtom", "identFilter", "intNumber", "decNumber", "limitClause", "metricName",
 	"tagKey", "tagValue", "tagValuePattern", "ident", "nonReservedWords",
+	"metricFieldRef",
 }
 var decisionToDFA = make([]*antlr.DFA, len(deserializedATN.DecisionToState))
 
@@ -574,6 +579,7 @@ const (
 	SQLParserRULE_tagValuePattern        = 78
 	SQLParserRULE_ident                  = 79
 	SQLParserRULE_nonReservedWords       = 80
+	SQLParserRULE_metricFieldRef         = 81
 )
 
 // IStatementContext is an interface to support dynamic dispatch.
@@ -680,11 +686,11 @@ func (p *SQLParser) Statement() (localctx IStatementContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(162)
+		p.SetState(164)
 		p.StatementList()
 	}
 	{
-		p.SetState(163)
+		p.SetState(165)
 		p.Match(SQLParserEOF)
 	}
 
@@ -929,111 +935,111 @@ func (p *SQLParser) StatementList() (localctx IStatementListContext) {
 		}
 	}()
 
-	p.SetState(180)
+	p.SetState(182)
 	p.GetErrorHandler().Sync(p)
 	switch p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 0, p.GetParserRuleContext()) {
 	case 1:
 		p.EnterOuterAlt(localctx, 1)
 		{
-			p.SetState(165)
+			p.SetState(167)
 			p.CreateDatabaseStmt()
 		}
 
 	case 2:
 		p.EnterOuterAlt(localctx, 2)
 		{
-			p.SetState(166)
+			p.SetState(168)
 			p.UpdateDatabaseStmt()
 		}
 
 	case 3:
 		p.EnterOuterAlt(localctx, 3)
 		{
-			p.SetState(167)
+			p.SetState(169)
 			p.DropDatabaseStmt()
 		}
 
 	case 4:
 		p.EnterOuterAlt(localctx, 4)
 		{
-			p.SetState(168)
+			p.SetState(170)
 			p.ShowDatabasesStmt()
 		}
 
 	case 5:
 		p.EnterOuterAlt(localctx, 5)
 		{
-			p.SetState(169)
+			p.SetState(171)
 			p.ShowNodeStmt()
 		}
 
 	case 6:
 		p.EnterOuterAlt(localctx, 6)
 		{
-			p.SetState(170)
+			p.SetState(172)
 			p.ShowMeasurementsStmt()
 		}
 
 	case 7:
 		p.EnterOuterAlt(localctx, 7)
 		{
-			p.SetState(171)
+			p.SetState(173)
 			p.ShowInfoStmt()
 		}
 
 	case 8:
 		p.EnterOuterAlt(localctx, 8)
 		{
-			p.SetState(172)
+			p.SetState(174)
 			p.ShowTagKeysStmt()
 		}
 
 	case 9:
 		p.EnterOuterAlt(localctx, 9)
 		{
-			p.SetState(173)
+			p.SetState(175)
 			p.ShowQueriesStmt()
 		}
 
 	case 10:
 		p.EnterOuterAlt(localctx, 10)
 		{
-			p.SetState(174)
+			p.SetState(176)
 			p.ShowTagValuesStmt()
 		}
 
 	case 11:
 		p.EnterOuterAlt(localctx, 11)
 		{
-			p.SetState(175)
+			p.SetState(177)
 			p.ShowTagValuesInfoStmt()
 		}
 
 	case 12:
 		p.EnterOuterAlt(localctx, 12)
 		{
-			p.SetState(176)
+			p.SetState(178)
 			p.ShowFieldKeysStmt()
 		}
 
 	case 13:
 		p.EnterOuterAlt(localctx, 13)
 		{
-			p.SetState(177)
+			p.SetState(179)
 			p.ShowStatsStmt()
 		}
 
 	case 14:
 		p.EnterOuterAlt(localctx, 14)
 		{
-			p.SetState(178)
+			p.SetState(180)
 			p.KillQueryStmt()
 		}
 
 	case 15:
 		p.EnterOuterAlt(localctx, 15)
 		{
-			p.SetState(179)
+			p.SetState(181)
 			p.QueryStmt()
 		}
 
@@ -1179,43 +1185,43 @@ func (p *SQLParser) CreateDatabaseStmt() (localctx ICreateDatabaseStmtContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(182)
+		p.SetState(184)
 		p.Match(SQLParserT_CREATE)
 	}
 	{
-		p.SetState(183)
+		p.SetState(185)
 		p.Match(SQLParserT_DATASBAE)
 	}
 	{
-		p.SetState(184)
+		p.SetState(186)
 		p.DatabaseName()
 	}
-	p.SetState(187)
+	p.SetState(189)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_WITH {
 		{
-			p.SetState(185)
+			p.SetState(187)
 			p.Match(SQLParserT_WITH)
 		}
 		{
-			p.SetState(186)
+			p.SetState(188)
 			p.WithClauseList()
 		}
 
 	}
-	p.SetState(191)
+	p.SetState(193)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_COMMA {
 		{
-			p.SetState(189)
+			p.SetState(191)
 			p.Match(SQLParserT_COMMA)
 		}
 		{
-			p.SetState(190)
+			p.SetState(192)
 			p.IntervalDefineList()
 		}
 
@@ -1347,26 +1353,26 @@ func (p *SQLParser) WithClauseList() (localctx IWithClauseListContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(193)
+		p.SetState(195)
 		p.WithClause()
 	}
-	p.SetState(198)
+	p.SetState(200)
 	p.GetErrorHandler().Sync(p)
 	_alt = p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 3, p.GetParserRuleContext())
 
 	for _alt != 2 && _alt != antlr.ATNInvalidAltNumber {
 		if _alt == 1 {
 			{
-				p.SetState(194)
+				p.SetState(196)
 				p.Match(SQLParserT_COMMA)
 			}
 			{
-				p.SetState(195)
+				p.SetState(197)
 				p.WithClause()
 			}
 
 		}
-		p.SetState(200)
+		p.SetState(202)
 		p.GetErrorHandler().Sync(p)
 		_alt = p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 3, p.GetParserRuleContext())
 	}
@@ -1560,84 +1566,84 @@ func (p *SQLParser) WithClause() (localctx IWithClauseContext) {
 		}
 	}()
 
-	p.SetState(215)
+	p.SetState(217)
 	p.GetErrorHandler().Sync(p)
 
 	switch p.GetTokenStream().LA(1) {
 	case SQLParserT_INTERVAL:
 		p.EnterOuterAlt(localctx, 1)
 		{
-			p.SetState(201)
+			p.SetState(203)
 			p.Match(SQLParserT_INTERVAL)
 		}
 		{
-			p.SetState(202)
+			p.SetState(204)
 			p.DurationLit()
 		}
 
 	case SQLParserT_SHARD:
 		p.EnterOuterAlt(localctx, 2)
 		{
-			p.SetState(203)
+			p.SetState(205)
 			p.Match(SQLParserT_SHARD)
 		}
 		{
-			p.SetState(204)
+			p.SetState(206)
 			p.ShardNum()
 		}
 
 	case SQLParserT_REPLICATION:
 		p.EnterOuterAlt(localctx, 3)
 		{
-			p.SetState(205)
+			p.SetState(207)
 			p.Match(SQLParserT_REPLICATION)
 		}
 		{
-			p.SetState(206)
+			p.SetState(208)
 			p.ReplicaFactor()
 		}
 
 	case SQLParserT_TTL:
 		p.EnterOuterAlt(localctx, 4)
 		{
-			p.SetState(207)
+			p.SetState(209)
 			p.Match(SQLParserT_TTL)
 		}
 		{
-			p.SetState(208)
+			p.SetState(210)
 			p.TtlVal()
 		}
 
 	case SQLParserT_META_TTL:
 		p.EnterOuterAlt(localctx, 5)
 		{
-			p.SetState(209)
+			p.SetState(211)
 			p.Match(SQLParserT_META_TTL)
 		}
 		{
-			p.SetState(210)
+			p.SetState(212)
 			p.MetattlVal()
 		}
 
 	case SQLParserT_PAST_TTL:
 		p.EnterOuterAlt(localctx, 6)
 		{
-			p.SetState(211)
+			p.SetState(213)
 			p.Match(SQLParserT_PAST_TTL)
 		}
 		{
-			p.SetState(212)
+			p.SetState(214)
 			p.PastVal()
 		}
 
 	case SQLParserT_FUTURE_TTL:
 		p.EnterOuterAlt(localctx, 7)
 		{
-			p.SetState(213)
+			p.SetState(215)
 			p.Match(SQLParserT_FUTURE_TTL)
 		}
 		{
-			p.SetState(214)
+			p.SetState(216)
 			p.FutureVal()
 		}
 
@@ -1770,24 +1776,24 @@ func (p *SQLParser) IntervalDefineList() (localctx IIntervalDefineListContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(217)
+		p.SetState(219)
 		p.IntervalDefine()
 	}
-	p.SetState(222)
+	p.SetState(224)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	for _la == SQLParserT_COMMA {
 		{
-			p.SetState(218)
+			p.SetState(220)
 			p.Match(SQLParserT_COMMA)
 		}
 		{
-			p.SetState(219)
+			p.SetState(221)
 			p.IntervalDefine()
 		}
 
-		p.SetState(224)
+		p.SetState(226)
 		p.GetErrorHandler().Sync(p)
 		_la = p.GetTokenStream().LA(1)
 	}
@@ -1943,43 +1949,43 @@ func (p *SQLParser) IntervalDefine() (localctx IIntervalDefineContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(225)
+		p.SetState(227)
 		p.Match(SQLParserT_OPEN_P)
 	}
 	{
-		p.SetState(226)
+		p.SetState(228)
 		p.Match(SQLParserT_INTERVAL_NAME)
 	}
 	{
-		p.SetState(227)
+		p.SetState(229)
 		p.IntervalNameVal()
 	}
 	{
-		p.SetState(228)
+		p.SetState(230)
 		p.Match(SQLParserT_COMMA)
 	}
 	{
-		p.SetState(229)
+		p.SetState(231)
 		p.Match(SQLParserT_TTL)
 	}
 	{
-		p.SetState(230)
+		p.SetState(232)
 		p.TtlVal()
 	}
 	{
-		p.SetState(231)
+		p.SetState(233)
 		p.Match(SQLParserT_COMMA)
 	}
 	{
-		p.SetState(232)
+		p.SetState(234)
 		p.Match(SQLParserT_INTERVAL)
 	}
 	{
-		p.SetState(233)
+		p.SetState(235)
 		p.DurationLit()
 	}
 	{
-		p.SetState(234)
+		p.SetState(236)
 		p.Match(SQLParserT_CLOSE_P)
 	}
 
@@ -2086,7 +2092,7 @@ func (p *SQLParser) ShardNum() (localctx IShardNumContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(236)
+		p.SetState(238)
 		p.IntNumber()
 	}
 
@@ -2193,7 +2199,7 @@ func (p *SQLParser) TtlVal() (localctx ITtlValContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(238)
+		p.SetState(240)
 		p.DurationLit()
 	}
 
@@ -2300,7 +2306,7 @@ func (p *SQLParser) MetattlVal() (localctx IMetattlValContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(240)
+		p.SetState(242)
 		p.DurationLit()
 	}
 
@@ -2407,7 +2413,7 @@ func (p *SQLParser) PastVal() (localctx IPastValContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(242)
+		p.SetState(244)
 		p.DurationLit()
 	}
 
@@ -2514,7 +2520,7 @@ func (p *SQLParser) FutureVal() (localctx IFutureValContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(244)
+		p.SetState(246)
 		p.DurationLit()
 	}
 
@@ -2621,7 +2627,7 @@ func (p *SQLParser) IntervalNameVal() (localctx IIntervalNameValContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(246)
+		p.SetState(248)
 		p.Ident()
 	}
 
@@ -2728,7 +2734,7 @@ func (p *SQLParser) ReplicaFactor() (localctx IReplicaFactorContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(248)
+		p.SetState(250)
 		p.IntNumber()
 	}
 
@@ -2835,7 +2841,7 @@ func (p *SQLParser) DatabaseName() (localctx IDatabaseNameContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(250)
+		p.SetState(252)
 		p.Ident()
 	}
 
@@ -2979,43 +2985,43 @@ func (p *SQLParser) UpdateDatabaseStmt() (localctx IUpdateDatabaseStmtContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(252)
+		p.SetState(254)
 		p.Match(SQLParserT_UPDATE)
 	}
 	{
-		p.SetState(253)
+		p.SetState(255)
 		p.Match(SQLParserT_DATASBAE)
 	}
 	{
-		p.SetState(254)
+		p.SetState(256)
 		p.DatabaseName()
 	}
-	p.SetState(257)
+	p.SetState(259)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_WITH {
 		{
-			p.SetState(255)
+			p.SetState(257)
 			p.Match(SQLParserT_WITH)
 		}
 		{
-			p.SetState(256)
+			p.SetState(258)
 			p.WithClauseList()
 		}
 
 	}
-	p.SetState(261)
+	p.SetState(263)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_COMMA {
 		{
-			p.SetState(259)
+			p.SetState(261)
 			p.Match(SQLParserT_COMMA)
 		}
 		{
-			p.SetState(260)
+			p.SetState(262)
 			p.IntervalDefineList()
 		}
 
@@ -3132,15 +3138,15 @@ func (p *SQLParser) DropDatabaseStmt() (localctx IDropDatabaseStmtContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(263)
+		p.SetState(265)
 		p.Match(SQLParserT_DROP)
 	}
 	{
-		p.SetState(264)
+		p.SetState(266)
 		p.Match(SQLParserT_DATASBAE)
 	}
 	{
-		p.SetState(265)
+		p.SetState(267)
 		p.DatabaseName()
 	}
 
@@ -3245,11 +3251,11 @@ func (p *SQLParser) ShowDatabasesStmt() (localctx IShowDatabasesStmtContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(267)
+		p.SetState(269)
 		p.Match(SQLParserT_SHOW)
 	}
 	{
-		p.SetState(268)
+		p.SetState(270)
 		p.Match(SQLParserT_DATASBAES)
 	}
 
@@ -3354,11 +3360,11 @@ func (p *SQLParser) ShowNodeStmt() (localctx IShowNodeStmtContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(270)
+		p.SetState(272)
 		p.Match(SQLParserT_SHOW)
 	}
 	{
-		p.SetState(271)
+		p.SetState(273)
 		p.Match(SQLParserT_NODE)
 	}
 
@@ -3484,31 +3490,31 @@ func (p *SQLParser) ShowMeasurementsStmt() (localctx IShowMeasurementsStmtContex
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(273)
+		p.SetState(275)
 		p.Match(SQLParserT_SHOW)
 	}
 	{
-		p.SetState(274)
+		p.SetState(276)
 		p.Match(SQLParserT_MEASUREMENTS)
 	}
-	p.SetState(276)
+	p.SetState(278)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_WITH {
 		{
-			p.SetState(275)
+			p.SetState(277)
 			p.WithMeasurementClause()
 		}
 
 	}
-	p.SetState(279)
+	p.SetState(281)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_LIMIT {
 		{
-			p.SetState(278)
+			p.SetState(280)
 			p.LimitClause()
 		}
 
@@ -3644,32 +3650,32 @@ func (p *SQLParser) ShowTagKeysStmt() (localctx IShowTagKeysStmtContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(281)
+		p.SetState(283)
 		p.Match(SQLParserT_SHOW)
 	}
 	{
-		p.SetState(282)
+		p.SetState(284)
 		p.Match(SQLParserT_TAG)
 	}
 	{
-		p.SetState(283)
+		p.SetState(285)
 		p.Match(SQLParserT_KEYS)
 	}
 	{
-		p.SetState(284)
+		p.SetState(286)
 		p.Match(SQLParserT_FROM)
 	}
 	{
-		p.SetState(285)
+		p.SetState(287)
 		p.MetricName()
 	}
-	p.SetState(287)
+	p.SetState(289)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_LIMIT {
 		{
-			p.SetState(286)
+			p.SetState(288)
 			p.LimitClause()
 		}
 
@@ -3790,19 +3796,19 @@ func (p *SQLParser) ShowInfoStmt() (localctx IShowInfoStmtContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(289)
+		p.SetState(291)
 		p.Match(SQLParserT_SHOW)
 	}
 	{
-		p.SetState(290)
+		p.SetState(292)
 		p.Match(SQLParserT_INFO)
 	}
 	{
-		p.SetState(291)
+		p.SetState(293)
 		p.Match(SQLParserT_FROM)
 	}
 	{
-		p.SetState(292)
+		p.SetState(294)
 		p.MetricName()
 	}
 
@@ -3956,47 +3962,47 @@ func (p *SQLParser) ShowTagValuesStmt() (localctx IShowTagValuesStmtContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(294)
+		p.SetState(296)
 		p.Match(SQLParserT_SHOW)
 	}
 	{
-		p.SetState(295)
+		p.SetState(297)
 		p.Match(SQLParserT_TAG)
 	}
 	{
-		p.SetState(296)
+		p.SetState(298)
 		p.Match(SQLParserT_VALUES)
 	}
 	{
-		p.SetState(297)
+		p.SetState(299)
 		p.Match(SQLParserT_FROM)
 	}
 	{
-		p.SetState(298)
+		p.SetState(300)
 		p.MetricName()
 	}
 	{
-		p.SetState(299)
+		p.SetState(301)
 		p.WithTagClause()
 	}
-	p.SetState(301)
+	p.SetState(303)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_WHERE {
 		{
-			p.SetState(300)
+			p.SetState(302)
 			p.WhereTagCascade()
 		}
 
 	}
-	p.SetState(304)
+	p.SetState(306)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_LIMIT {
 		{
-			p.SetState(303)
+			p.SetState(305)
 			p.LimitClause()
 		}
 
@@ -4145,35 +4151,35 @@ func (p *SQLParser) ShowTagValuesInfoStmt() (localctx IShowTagValuesInfoStmtCont
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(306)
+		p.SetState(308)
 		p.Match(SQLParserT_SHOW)
 	}
 	{
-		p.SetState(307)
+		p.SetState(309)
 		p.Match(SQLParserT_TAG)
 	}
 	{
-		p.SetState(308)
+		p.SetState(310)
 		p.Match(SQLParserT_VALUES)
 	}
 	{
-		p.SetState(309)
+		p.SetState(311)
 		p.Match(SQLParserT_INFO)
 	}
 	{
-		p.SetState(310)
+		p.SetState(312)
 		p.Match(SQLParserT_FROM)
 	}
 	{
-		p.SetState(311)
+		p.SetState(313)
 		p.MetricName()
 	}
 	{
-		p.SetState(312)
+		p.SetState(314)
 		p.WithTagClause()
 	}
 	{
-		p.SetState(313)
+		p.SetState(315)
 		p.WhereTagCascade()
 	}
 
@@ -4307,32 +4313,32 @@ func (p *SQLParser) ShowFieldKeysStmt() (localctx IShowFieldKeysStmtContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(315)
+		p.SetState(317)
 		p.Match(SQLParserT_SHOW)
 	}
 	{
-		p.SetState(316)
+		p.SetState(318)
 		p.Match(SQLParserT_FIELD)
 	}
 	{
-		p.SetState(317)
+		p.SetState(319)
 		p.Match(SQLParserT_KEYS)
 	}
 	{
-		p.SetState(318)
+		p.SetState(320)
 		p.Match(SQLParserT_FROM)
 	}
 	{
-		p.SetState(319)
+		p.SetState(321)
 		p.MetricName()
 	}
-	p.SetState(321)
+	p.SetState(323)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_LIMIT {
 		{
-			p.SetState(320)
+			p.SetState(322)
 			p.LimitClause()
 		}
 
@@ -4450,20 +4456,20 @@ func (p *SQLParser) ShowQueriesStmt() (localctx IShowQueriesStmtContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(323)
+		p.SetState(325)
 		p.Match(SQLParserT_SHOW)
 	}
 	{
-		p.SetState(324)
+		p.SetState(326)
 		p.Match(SQLParserT_QUERIES)
 	}
-	p.SetState(326)
+	p.SetState(328)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_LIMIT {
 		{
-			p.SetState(325)
+			p.SetState(327)
 			p.LimitClause()
 		}
 
@@ -4599,39 +4605,39 @@ func (p *SQLParser) ShowStatsStmt() (localctx IShowStatsStmtContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(328)
+		p.SetState(330)
 		p.Match(SQLParserT_SHOW)
 	}
 	{
-		p.SetState(329)
+		p.SetState(331)
 		p.Match(SQLParserT_STATS)
 	}
-	p.SetState(332)
+	p.SetState(334)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_FOR {
 		{
-			p.SetState(330)
+			p.SetState(332)
 			p.Match(SQLParserT_FOR)
 		}
 		{
-			p.SetState(331)
+			p.SetState(333)
 			p.Module()
 		}
 
 	}
-	p.SetState(336)
+	p.SetState(338)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_WITH {
 		{
-			p.SetState(334)
+			p.SetState(336)
 			p.Match(SQLParserT_WITH)
 		}
 		{
-			p.SetState(335)
+			p.SetState(337)
 			p.Component()
 		}
 
@@ -4756,34 +4762,34 @@ func (p *SQLParser) WithMeasurementClause() (localctx IWithMeasurementClauseCont
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(338)
+		p.SetState(340)
 		p.Match(SQLParserT_WITH)
 	}
 	{
-		p.SetState(339)
+		p.SetState(341)
 		p.Match(SQLParserT_MEASUREMENT)
 	}
-	p.SetState(344)
+	p.SetState(346)
 	p.GetErrorHandler().Sync(p)
 
 	switch p.GetTokenStream().LA(1) {
 	case SQLParserT_EQUAL:
 		{
-			p.SetState(340)
+			p.SetState(342)
 			p.Match(SQLParserT_EQUAL)
 		}
 		{
-			p.SetState(341)
+			p.SetState(343)
 			p.MetricName()
 		}
 
 	case SQLParserT_REGEXP:
 		{
-			p.SetState(342)
+			p.SetState(344)
 			p.Match(SQLParserT_REGEXP)
 		}
 		{
-			p.SetState(343)
+			p.SetState(345)
 			p.MetricName()
 		}
 
@@ -4906,19 +4912,19 @@ func (p *SQLParser) WithTagClause() (localctx IWithTagClauseContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(346)
+		p.SetState(348)
 		p.Match(SQLParserT_WITH)
 	}
 	{
-		p.SetState(347)
+		p.SetState(349)
 		p.Match(SQLParserT_KEY)
 	}
 	{
-		p.SetState(348)
+		p.SetState(350)
 		p.Match(SQLParserT_EQUAL)
 	}
 	{
-		p.SetState(349)
+		p.SetState(351)
 		p.TagKey()
 	}
 
@@ -5029,11 +5035,11 @@ func (p *SQLParser) WhereTagCascade() (localctx IWhereTagCascadeContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(351)
+		p.SetState(353)
 		p.Match(SQLParserT_WHERE)
 	}
 	{
-		p.SetState(352)
+		p.SetState(354)
 		p.TagCascadeExpr()
 	}
 
@@ -5163,28 +5169,28 @@ func (p *SQLParser) KillQueryStmt() (localctx IKillQueryStmtContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(354)
+		p.SetState(356)
 		p.Match(SQLParserT_KILL)
 	}
 	{
-		p.SetState(355)
+		p.SetState(357)
 		p.Match(SQLParserT_QUERY)
 	}
 	{
-		p.SetState(356)
+		p.SetState(358)
 		p.QueryId()
 	}
-	p.SetState(359)
+	p.SetState(361)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_ON {
 		{
-			p.SetState(357)
+			p.SetState(359)
 			p.Match(SQLParserT_ON)
 		}
 		{
-			p.SetState(358)
+			p.SetState(360)
 			p.ServerId()
 		}
 
@@ -5287,7 +5293,7 @@ func (p *SQLParser) QueryId() (localctx IQueryIdContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(361)
+		p.SetState(363)
 		p.Match(SQLParserL_INT)
 	}
 
@@ -5388,7 +5394,7 @@ func (p *SQLParser) ServerId() (localctx IServerIdContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(363)
+		p.SetState(365)
 		p.Match(SQLParserL_INT)
 	}
 
@@ -5495,7 +5501,7 @@ func (p *SQLParser) Module() (localctx IModuleContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(365)
+		p.SetState(367)
 		p.Ident()
 	}
 
@@ -5602,7 +5608,7 @@ func (p *SQLParser) Component() (localctx IComponentContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(367)
+		p.SetState(369)
 		p.Ident()
 	}
 
@@ -5781,91 +5787,91 @@ func (p *SQLParser) QueryStmt() (localctx IQueryStmtContext) {
 	}()
 
 	p.EnterOuterAlt(localctx, 1)
-	p.SetState(370)
+	p.SetState(372)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_EXPLAIN {
 		{
-			p.SetState(369)
+			p.SetState(371)
 			p.Match(SQLParserT_EXPLAIN)
 		}
 
 	}
 	{
-		p.SetState(372)
+		p.SetState(374)
 		p.Match(SQLParserT_SELECT)
 	}
 	{
-		p.SetState(373)
+		p.SetState(375)
 		p.Fields()
 	}
 	{
-		p.SetState(374)
+		p.SetState(376)
 		p.FromClause()
 	}
-	p.SetState(376)
+	p.SetState(378)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_WHERE {
 		{
-			p.SetState(375)
+			p.SetState(377)
 			p.WhereClause()
 		}
 
 	}
-	p.SetState(379)
+	p.SetState(381)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_GROUP {
 		{
-			p.SetState(378)
+			p.SetState(380)
 			p.GroupByClause()
 		}
 
 	}
-	p.SetState(382)
+	p.SetState(384)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_INTERVAL {
 		{
-			p.SetState(381)
+			p.SetState(383)
 			p.IntervalByClause()
 		}
 
 	}
-	p.SetState(385)
+	p.SetState(387)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_ORDER {
 		{
-			p.SetState(384)
+			p.SetState(386)
 			p.OrderByClause()
 		}
 
 	}
-	p.SetState(388)
+	p.SetState(390)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_LIMIT {
 		{
-			p.SetState(387)
+			p.SetState(389)
 			p.LimitClause()
 		}
 
 	}
-	p.SetState(391)
+	p.SetState(393)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_WITH_VALUE {
 		{
-			p.SetState(390)
+			p.SetState(392)
 			p.Match(SQLParserT_WITH_VALUE)
 		}
 
@@ -5996,24 +6002,24 @@ func (p *SQLParser) Fields() (localctx IFieldsContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(393)
+		p.SetState(395)
 		p.Field()
 	}
-	p.SetState(398)
+	p.SetState(400)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	for _la == SQLParserT_COMMA {
 		{
-			p.SetState(394)
+			p.SetState(396)
 			p.Match(SQLParserT_COMMA)
 		}
 		{
-			p.SetState(395)
+			p.SetState(397)
 			p.Field()
 		}
 
-		p.SetState(400)
+		p.SetState(402)
 		p.GetErrorHandler().Sync(p)
 		_la = p.GetTokenStream().LA(1)
 	}
@@ -6132,16 +6138,16 @@ func (p *SQLParser) Field() (localctx IFieldContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(401)
+		p.SetState(403)
 		p.expr(0)
 	}
-	p.SetState(403)
+	p.SetState(405)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_AS {
 		{
-			p.SetState(402)
+			p.SetState(404)
 			p.Alias()
 		}
 
@@ -6254,11 +6260,11 @@ func (p *SQLParser) Alias() (localctx IAliasContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(405)
+		p.SetState(407)
 		p.Match(SQLParserT_AS)
 	}
 	{
-		p.SetState(406)
+		p.SetState(408)
 		p.Ident()
 	}
 
@@ -6369,11 +6375,11 @@ func (p *SQLParser) FromClause() (localctx IFromClauseContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(408)
+		p.SetState(410)
 		p.Match(SQLParserT_FROM)
 	}
 	{
-		p.SetState(409)
+		p.SetState(411)
 		p.MetricName()
 	}
 
@@ -6484,11 +6490,11 @@ func (p *SQLParser) WhereClause() (localctx IWhereClauseContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(411)
+		p.SetState(413)
 		p.Match(SQLParserT_WHERE)
 	}
 	{
-		p.SetState(412)
+		p.SetState(414)
 		p.clauseBooleanExpr(0)
 	}
 
@@ -6642,24 +6648,24 @@ func (p *SQLParser) clauseBooleanExpr(_p int) (localctx IClauseBooleanExprContex
 	var _alt int
 
 	p.EnterOuterAlt(localctx, 1)
-	p.SetState(417)
+	p.SetState(419)
 	p.GetErrorHandler().Sync(p)
 	switch p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 28, p.GetParserRuleContext()) {
 	case 1:
 		{
-			p.SetState(415)
+			p.SetState(417)
 			p.tagBooleanExpr(0)
 		}
 
 	case 2:
 		{
-			p.SetState(416)
+			p.SetState(418)
 			p.TimeExpr()
 		}
 
 	}
 	p.GetParserRuleContext().SetStop(p.GetTokenStream().LT(-1))
-	p.SetState(424)
+	p.SetState(426)
 	p.GetErrorHandler().Sync(p)
 	_alt = p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 29, p.GetParserRuleContext())
 
@@ -6671,22 +6677,22 @@ func (p *SQLParser) clauseBooleanExpr(_p int) (localctx IClauseBooleanExprContex
 			_prevctx = localctx
 			localctx = NewClauseBooleanExprContext(p, _parentctx, _parentState)
 			p.PushNewRecursionContext(localctx, _startState, SQLParserRULE_clauseBooleanExpr)
-			p.SetState(419)
+			p.SetState(421)
 
 			if !(p.Precpred(p.GetParserRuleContext(), 1)) {
 				panic(antlr.NewFailedPredicateException(p, "p.Precpred(p.GetParserRuleContext(), 1)", ""))
 			}
 			{
-				p.SetState(420)
+				p.SetState(422)
 				p.Match(SQLParserT_AND)
 			}
 			{
-				p.SetState(421)
+				p.SetState(423)
 				p.clauseBooleanExpr(2)
 			}
 
 		}
-		p.SetState(426)
+		p.SetState(428)
 		p.GetErrorHandler().Sync(p)
 		_alt = p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 29, p.GetParserRuleContext())
 	}
@@ -6807,40 +6813,40 @@ func (p *SQLParser) TagCascadeExpr() (localctx ITagCascadeExprContext) {
 		}
 	}()
 
-	p.SetState(434)
+	p.SetState(436)
 	p.GetErrorHandler().Sync(p)
 	switch p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 31, p.GetParserRuleContext()) {
 	case 1:
 		p.EnterOuterAlt(localctx, 1)
 		{
-			p.SetState(427)
+			p.SetState(429)
 			p.TagEqualExpr()
 		}
 
 	case 2:
 		p.EnterOuterAlt(localctx, 2)
 		{
-			p.SetState(428)
+			p.SetState(430)
 			p.tagBooleanExpr(0)
 		}
 
 	case 3:
 		p.EnterOuterAlt(localctx, 3)
 		{
-			p.SetState(429)
+			p.SetState(431)
 			p.TagEqualExpr()
 		}
-		p.SetState(432)
+		p.SetState(434)
 		p.GetErrorHandler().Sync(p)
 		_la = p.GetTokenStream().LA(1)
 
 		if _la == SQLParserT_AND {
 			{
-				p.SetState(430)
+				p.SetState(432)
 				p.Match(SQLParserT_AND)
 			}
 			{
-				p.SetState(431)
+				p.SetState(433)
 				p.tagBooleanExpr(0)
 			}
 
@@ -6959,15 +6965,15 @@ func (p *SQLParser) TagEqualExpr() (localctx ITagEqualExprContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(436)
+		p.SetState(438)
 		p.Match(SQLParserT_VALUE)
 	}
 	{
-		p.SetState(437)
+		p.SetState(439)
 		p.Match(SQLParserT_EQUAL)
 	}
 	{
-		p.SetState(438)
+		p.SetState(440)
 		p.TagValuePattern()
 	}
 
@@ -7172,30 +7178,30 @@ func (p *SQLParser) tagBooleanExpr(_p int) (localctx ITagBooleanExprContext) {
 	var _alt int
 
 	p.EnterOuterAlt(localctx, 1)
-	p.SetState(459)
+	p.SetState(461)
 	p.GetErrorHandler().Sync(p)
 	switch p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 33, p.GetParserRuleContext()) {
 	case 1:
 		{
-			p.SetState(441)
+			p.SetState(443)
 			p.Match(SQLParserT_OPEN_P)
 		}
 		{
-			p.SetState(442)
+			p.SetState(444)
 			p.tagBooleanExpr(0)
 		}
 		{
-			p.SetState(443)
+			p.SetState(445)
 			p.Match(SQLParserT_CLOSE_P)
 		}
 
 	case 2:
 		{
-			p.SetState(445)
+			p.SetState(447)
 			p.TagKey()
 		}
 		{
-			p.SetState(446)
+			p.SetState(448)
 			_la = p.GetTokenStream().LA(1)
 
 			if !(((_la-46)&-(0x1f+1)) == 0 && ((1<<uint((_la-46)))&((1<<(SQLParserT_LIKE-46))|(1<<(SQLParserT_EQUAL-46))|(1<<(SQLParserT_NOTEQUAL-46))|(1<<(SQLParserT_NOTEQUAL2-46))|(1<<(SQLParserT_REGEXP-46)))) != 0) {
@@ -7206,32 +7212,32 @@ func (p *SQLParser) tagBooleanExpr(_p int) (localctx ITagBooleanExprContext) {
 			}
 		}
 		{
-			p.SetState(447)
+			p.SetState(449)
 			p.TagValue()
 		}
 
 	case 3:
 		{
-			p.SetState(449)
+			p.SetState(451)
 			p.TagKey()
 		}
-		p.SetState(453)
+		p.SetState(455)
 		p.GetErrorHandler().Sync(p)
 
 		switch p.GetTokenStream().LA(1) {
 		case SQLParserT_IN:
 			{
-				p.SetState(450)
+				p.SetState(452)
 				p.Match(SQLParserT_IN)
 			}
 
 		case SQLParserT_NOT:
 			{
-				p.SetState(451)
+				p.SetState(453)
 				p.Match(SQLParserT_NOT)
 			}
 			{
-				p.SetState(452)
+				p.SetState(454)
 				p.Match(SQLParserT_IN)
 			}
 
@@ -7239,21 +7245,21 @@ func (p *SQLParser) tagBooleanExpr(_p int) (localctx ITagBooleanExprContext) {
 			panic(antlr.NewNoViableAltException(p, nil, nil, nil, nil, nil))
 		}
 		{
-			p.SetState(455)
+			p.SetState(457)
 			p.Match(SQLParserT_OPEN_P)
 		}
 		{
-			p.SetState(456)
+			p.SetState(458)
 			p.TagValueList()
 		}
 		{
-			p.SetState(457)
+			p.SetState(459)
 			p.Match(SQLParserT_CLOSE_P)
 		}
 
 	}
 	p.GetParserRuleContext().SetStop(p.GetTokenStream().LT(-1))
-	p.SetState(466)
+	p.SetState(468)
 	p.GetErrorHandler().Sync(p)
 	_alt = p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 34, p.GetParserRuleContext())
 
@@ -7265,13 +7271,13 @@ func (p *SQLParser) tagBooleanExpr(_p int) (localctx ITagBooleanExprContext) {
 			_prevctx = localctx
 			localctx = NewTagBooleanExprContext(p, _parentctx, _parentState)
 			p.PushNewRecursionContext(localctx, _startState, SQLParserRULE_tagBooleanExpr)
-			p.SetState(461)
+			p.SetState(463)
 
 			if !(p.Precpred(p.GetParserRuleContext(), 1)) {
 				panic(antlr.NewFailedPredicateException(p, "p.Precpred(p.GetParserRuleContext(), 1)", ""))
 			}
 			{
-				p.SetState(462)
+				p.SetState(464)
 				_la = p.GetTokenStream().LA(1)
 
 				if !(_la == SQLParserT_AND || _la == SQLParserT_OR) {
@@ -7282,12 +7288,12 @@ func (p *SQLParser) tagBooleanExpr(_p int) (localctx ITagBooleanExprContext) {
 				}
 			}
 			{
-				p.SetState(463)
+				p.SetState(465)
 				p.tagBooleanExpr(2)
 			}
 
 		}
-		p.SetState(468)
+		p.SetState(470)
 		p.GetErrorHandler().Sync(p)
 		_alt = p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 34, p.GetParserRuleContext())
 	}
@@ -7417,24 +7423,24 @@ func (p *SQLParser) TagValueList() (localctx ITagValueListContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(469)
+		p.SetState(471)
 		p.TagValue()
 	}
-	p.SetState(474)
+	p.SetState(476)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	for _la == SQLParserT_COMMA {
 		{
-			p.SetState(470)
+			p.SetState(472)
 			p.Match(SQLParserT_COMMA)
 		}
 		{
-			p.SetState(471)
+			p.SetState(473)
 			p.TagValue()
 		}
 
-		p.SetState(476)
+		p.SetState(478)
 		p.GetErrorHandler().Sync(p)
 		_la = p.GetTokenStream().LA(1)
 	}
@@ -7559,19 +7565,19 @@ func (p *SQLParser) TimeExpr() (localctx ITimeExprContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(477)
+		p.SetState(479)
 		p.TimeBooleanExpr()
 	}
-	p.SetState(480)
+	p.SetState(482)
 	p.GetErrorHandler().Sync(p)
 
 	if p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 36, p.GetParserRuleContext()) == 1 {
 		{
-			p.SetState(478)
+			p.SetState(480)
 			p.Match(SQLParserT_AND)
 		}
 		{
-			p.SetState(479)
+			p.SetState(481)
 			p.TimeBooleanExpr()
 		}
 
@@ -7704,26 +7710,26 @@ func (p *SQLParser) TimeBooleanExpr() (localctx ITimeBooleanExprContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(482)
+		p.SetState(484)
 		p.Match(SQLParserT_TIME)
 	}
 	{
-		p.SetState(483)
+		p.SetState(485)
 		p.BoolExprBinaryOperator()
 	}
-	p.SetState(486)
+	p.SetState(488)
 	p.GetErrorHandler().Sync(p)
 
 	switch p.GetTokenStream().LA(1) {
 	case SQLParserT_NOW:
 		{
-			p.SetState(484)
+			p.SetState(486)
 			p.NowExpr()
 		}
 
 	case SQLParserT_CREATE, SQLParserT_INTERVAL, SQLParserT_SHARD, SQLParserT_REPLICATION, SQLParserT_TTL, SQLParserT_KILL, SQLParserT_ON, SQLParserT_SHOW, SQLParserT_DATASBAE, SQLParserT_DATASBAES, SQLParserT_NODE, SQLParserT_MEASUREMENTS, SQLParserT_MEASUREMENT, SQLParserT_FIELD, SQLParserT_TAG, SQLParserT_KEYS, SQLParserT_KEY, SQLParserT_WITH, SQLParserT_VALUES, SQLParserT_FROM, SQLParserT_WHERE, SQLParserT_LIMIT, SQLParserT_QUERIES, SQLParserT_QUERY, SQLParserT_SELECT, SQLParserT_AS, SQLParserT_AND, SQLParserT_OR, SQLParserT_FILL, SQLParserT_NULL, SQLParserT_PREVIOUS, SQLParserT_ORDER, SQLParserT_ASC, SQLParserT_DESC, SQLParserT_LIKE, SQLParserT_NOT, SQLParserT_BETWEEN, SQLParserT_IS, SQLParserT_GROUP, SQLParserT_BY, SQLParserT_FOR, SQLParserT_STATS, SQLParserT_TIME, SQLParserT_PROFILE, SQLParserT_SECOND, SQLParserT_MINUTE, SQLParserT_HOUR, SQLParserT_DAY, SQLParserT_WEEK, SQLParserT_MONTH, SQLParserT_YEAR, SQLParserL_ID:
 		{
-			p.SetState(485)
+			p.SetState(487)
 			p.Ident()
 		}
 
@@ -7844,15 +7850,15 @@ func (p *SQLParser) NowExpr() (localctx INowExprContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(488)
+		p.SetState(490)
 		p.NowFunc()
 	}
-	p.SetState(490)
+	p.SetState(492)
 	p.GetErrorHandler().Sync(p)
 
 	if p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 38, p.GetParserRuleContext()) == 1 {
 		{
-			p.SetState(489)
+			p.SetState(491)
 			p.DurationLit()
 		}
 
@@ -7974,26 +7980,26 @@ func (p *SQLParser) NowFunc() (localctx INowFuncContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(492)
+		p.SetState(494)
 		p.Match(SQLParserT_NOW)
 	}
 	{
-		p.SetState(493)
+		p.SetState(495)
 		p.Match(SQLParserT_OPEN_P)
 	}
-	p.SetState(495)
+	p.SetState(497)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if (((_la)&-(0x1f+1)) == 0 && ((1<<uint(_la))&((1<<SQLParserT_CREATE)|(1<<SQLParserT_INTERVAL)|(1<<SQLParserT_SHARD)|(1<<SQLParserT_REPLICATION)|(1<<SQLParserT_TTL)|(1<<SQLParserT_KILL)|(1<<SQLParserT_ON)|(1<<SQLParserT_SHOW)|(1<<SQLParserT_DATASBAE)|(1<<SQLParserT_DATASBAES)|(1<<SQLParserT_NODE)|(1<<SQLParserT_MEASUREMENTS)|(1<<SQLParserT_MEASUREMENT)|(1<<SQLParserT_FIELD)|(1<<SQLParserT_TAG)|(1<<SQLParserT_KEYS)|(1<<SQLParserT_KEY)|(1<<SQLParserT_WITH)|(1<<SQLParserT_VALUES)|(1<<SQLParserT_FROM)|(1<<SQLParserT_WHERE)|(1<<SQLParserT_LIMIT))) != 0) || (((_la-32)&-(0x1f+1)) == 0 && ((1<<uint((_la-32)))&((1<<(SQLParserT_QUERIES-32))|(1<<(SQLParserT_QUERY-32))|(1<<(SQLParserT_SELECT-32))|(1<<(SQLParserT_AS-32))|(1<<(SQLParserT_AND-32))|(1<<(SQLParserT_OR-32))|(1<<(SQLParserT_FILL-32))|(1<<(SQLParserT_NULL-32))|(1<<(SQLParserT_PREVIOUS-32))|(1<<(SQLParserT_ORDER-32))|(1<<(SQLParserT_ASC-32))|(1<<(SQLParserT_DESC-32))|(1<<(SQLParserT_LIKE-32))|(1<<(SQLParserT_NOT-32))|(1<<(SQLParserT_BETWEEN-32))|(1<<(SQLParserT_IS-32))|(1<<(SQLParserT_GROUP-32))|(1<<(SQLParserT_BY-32))|(1<<(SQLParserT_FOR-32))|(1<<(SQLParserT_STATS-32))|(1<<(SQLParserT_TIME-32))|(1<<(SQLParserT_PROFILE-32))|(1<<(SQLParserT_SECOND-32))|(1<<(SQLParserT_MINUTE-32))|(1<<(SQLParserT_HOUR-32))|(1<<(SQLParserT_DAY-32)))) != 0) || (((_la-64)&-(0x1f+1)) == 0 && ((1<<uint((_la-64)))&((1<<(SQLParserT_WEEK-64))|(1<<(SQLParserT_MONTH-64))|(1<<(SQLParserT_YEAR-64))|(1<<(SQLParserT_OPEN_P-64))|(1<<(SQLParserT_ADD-64))|(1<<(SQLParserT_SUB-64))|(1<<(SQLParserL_ID-64))|(1<<(SQLParserL_INT-64))|(1<<(SQLParserL_DEC-64)))) != 0) {
 		{
-			p.SetState(494)
+			p.SetState(496)
 			p.ExprFuncParams()
 		}
 
 	}
 	{
-		p.SetState(497)
+		p.SetState(499)
 		p.Match(SQLParserT_CLOSE_P)
 	}
 
@@ -8141,47 +8147,47 @@ func (p *SQLParser) GroupByClause() (localctx IGroupByClauseContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(499)
+		p.SetState(501)
 		p.Match(SQLParserT_GROUP)
 	}
 	{
-		p.SetState(500)
+		p.SetState(502)
 		p.Match(SQLParserT_BY)
 	}
 	{
-		p.SetState(501)
+		p.SetState(503)
 		p.Dimensions()
 	}
-	p.SetState(507)
+	p.SetState(509)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_FILL {
 		{
-			p.SetState(502)
+			p.SetState(504)
 			p.Match(SQLParserT_FILL)
 		}
 		{
-			p.SetState(503)
+			p.SetState(505)
 			p.Match(SQLParserT_OPEN_P)
 		}
 		{
-			p.SetState(504)
+			p.SetState(506)
 			p.FillOption()
 		}
 		{
-			p.SetState(505)
+			p.SetState(507)
 			p.Match(SQLParserT_CLOSE_P)
 		}
 
 	}
-	p.SetState(510)
+	p.SetState(512)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_HAVING {
 		{
-			p.SetState(509)
+			p.SetState(511)
 			p.HavingClause()
 		}
 
@@ -8312,24 +8318,24 @@ func (p *SQLParser) Dimensions() (localctx IDimensionsContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(512)
+		p.SetState(514)
 		p.Dimension()
 	}
-	p.SetState(517)
+	p.SetState(519)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	for _la == SQLParserT_COMMA {
 		{
-			p.SetState(513)
+			p.SetState(515)
 			p.Match(SQLParserT_COMMA)
 		}
 		{
-			p.SetState(514)
+			p.SetState(516)
 			p.Dimension()
 		}
 
-		p.SetState(519)
+		p.SetState(521)
 		p.GetErrorHandler().Sync(p)
 		_la = p.GetTokenStream().LA(1)
 	}
@@ -8457,32 +8463,32 @@ func (p *SQLParser) Dimension() (localctx IDimensionContext) {
 		}
 	}()
 
-	p.SetState(526)
+	p.SetState(528)
 	p.GetErrorHandler().Sync(p)
 	switch p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 43, p.GetParserRuleContext()) {
 	case 1:
 		p.EnterOuterAlt(localctx, 1)
 		{
-			p.SetState(520)
+			p.SetState(522)
 			p.Ident()
 		}
 
 	case 2:
 		p.EnterOuterAlt(localctx, 2)
 		{
-			p.SetState(521)
+			p.SetState(523)
 			p.Match(SQLParserT_TIME)
 		}
 		{
-			p.SetState(522)
+			p.SetState(524)
 			p.Match(SQLParserT_OPEN_P)
 		}
 		{
-			p.SetState(523)
+			p.SetState(525)
 			p.DurationLit()
 		}
 		{
-			p.SetState(524)
+			p.SetState(526)
 			p.Match(SQLParserT_CLOSE_P)
 		}
 
@@ -8598,7 +8604,7 @@ func (p *SQLParser) FillOption() (localctx IFillOptionContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(528)
+		p.SetState(530)
 		_la = p.GetTokenStream().LA(1)
 
 		if !(_la == SQLParserT_NULL || _la == SQLParserT_PREVIOUS || _la == SQLParserL_INT || _la == SQLParserL_DEC) {
@@ -8720,15 +8726,15 @@ func (p *SQLParser) OrderByClause() (localctx IOrderByClauseContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(530)
+		p.SetState(532)
 		p.Match(SQLParserT_ORDER)
 	}
 	{
-		p.SetState(531)
+		p.SetState(533)
 		p.Match(SQLParserT_BY)
 	}
 	{
-		p.SetState(532)
+		p.SetState(534)
 		p.SortFields()
 	}
 
@@ -8843,15 +8849,15 @@ func (p *SQLParser) IntervalByClause() (localctx IIntervalByClauseContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(534)
+		p.SetState(536)
 		p.Match(SQLParserT_INTERVAL)
 	}
 	{
-		p.SetState(535)
+		p.SetState(537)
 		p.Match(SQLParserT_BY)
 	}
 	{
-		p.SetState(536)
+		p.SetState(538)
 		p.IntervalNameVal()
 	}
 
@@ -8975,16 +8981,16 @@ func (p *SQLParser) SortField() (localctx ISortFieldContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(538)
+		p.SetState(540)
 		p.expr(0)
 	}
-	p.SetState(542)
+	p.SetState(544)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	for _la == SQLParserT_ASC || _la == SQLParserT_DESC {
 		{
-			p.SetState(539)
+			p.SetState(541)
 			_la = p.GetTokenStream().LA(1)
 
 			if !(_la == SQLParserT_ASC || _la == SQLParserT_DESC) {
@@ -8995,7 +9001,7 @@ func (p *SQLParser) SortField() (localctx ISortFieldContext) {
 			}
 		}
 
-		p.SetState(544)
+		p.SetState(546)
 		p.GetErrorHandler().Sync(p)
 		_la = p.GetTokenStream().LA(1)
 	}
@@ -9125,24 +9131,24 @@ func (p *SQLParser) SortFields() (localctx ISortFieldsContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(545)
+		p.SetState(547)
 		p.SortField()
 	}
-	p.SetState(550)
+	p.SetState(552)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	for _la == SQLParserT_COMMA {
 		{
-			p.SetState(546)
+			p.SetState(548)
 			p.Match(SQLParserT_COMMA)
 		}
 		{
-			p.SetState(547)
+			p.SetState(549)
 			p.SortField()
 		}
 
-		p.SetState(552)
+		p.SetState(554)
 		p.GetErrorHandler().Sync(p)
 		_la = p.GetTokenStream().LA(1)
 	}
@@ -9254,11 +9260,11 @@ func (p *SQLParser) HavingClause() (localctx IHavingClauseContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(553)
+		p.SetState(555)
 		p.Match(SQLParserT_HAVING)
 	}
 	{
-		p.SetState(554)
+		p.SetState(556)
 		p.boolExpr(0)
 	}
 
@@ -9416,32 +9422,32 @@ func (p *SQLParser) boolExpr(_p int) (localctx IBoolExprContext) {
 	var _alt int
 
 	p.EnterOuterAlt(localctx, 1)
-	p.SetState(562)
+	p.SetState(564)
 	p.GetErrorHandler().Sync(p)
 	switch p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 46, p.GetParserRuleContext()) {
 	case 1:
 		{
-			p.SetState(557)
+			p.SetState(559)
 			p.Match(SQLParserT_OPEN_P)
 		}
 		{
-			p.SetState(558)
+			p.SetState(560)
 			p.boolExpr(0)
 		}
 		{
-			p.SetState(559)
+			p.SetState(561)
 			p.Match(SQLParserT_CLOSE_P)
 		}
 
 	case 2:
 		{
-			p.SetState(561)
+			p.SetState(563)
 			p.BoolExprAtom()
 		}
 
 	}
 	p.GetParserRuleContext().SetStop(p.GetTokenStream().LT(-1))
-	p.SetState(570)
+	p.SetState(572)
 	p.GetErrorHandler().Sync(p)
 	_alt = p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 47, p.GetParserRuleContext())
 
@@ -9453,22 +9459,22 @@ func (p *SQLParser) boolExpr(_p int) (localctx IBoolExprContext) {
 			_prevctx = localctx
 			localctx = NewBoolExprContext(p, _parentctx, _parentState)
 			p.PushNewRecursionContext(localctx, _startState, SQLParserRULE_boolExpr)
-			p.SetState(564)
+			p.SetState(566)
 
 			if !(p.Precpred(p.GetParserRuleContext(), 2)) {
 				panic(antlr.NewFailedPredicateException(p, "p.Precpred(p.GetParserRuleContext(), 2)", ""))
 			}
 			{
-				p.SetState(565)
+				p.SetState(567)
 				p.BoolExprLogicalOp()
 			}
 			{
-				p.SetState(566)
+				p.SetState(568)
 				p.boolExpr(3)
 			}
 
 		}
-		p.SetState(572)
+		p.SetState(574)
 		p.GetErrorHandler().Sync(p)
 		_alt = p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 47, p.GetParserRuleContext())
 	}
@@ -9575,7 +9581,7 @@ func (p *SQLParser) BoolExprLogicalOp() (localctx IBoolExprLogicalOpContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(573)
+		p.SetState(575)
 		_la = p.GetTokenStream().LA(1)
 
 		if !(_la == SQLParserT_AND || _la == SQLParserT_OR) {
@@ -9689,7 +9695,7 @@ func (p *SQLParser) BoolExprAtom() (localctx IBoolExprAtomContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(575)
+		p.SetState(577)
 		p.BoolExprBinary()
 	}
 
@@ -9819,15 +9825,15 @@ func (p *SQLParser) BoolExprBinary() (localctx IBoolExprBinaryContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(577)
+		p.SetState(579)
 		p.expr(0)
 	}
 	{
-		p.SetState(578)
+		p.SetState(580)
 		p.BoolExprBinaryOperator()
 	}
 	{
-		p.SetState(579)
+		p.SetState(581)
 		p.expr(0)
 	}
 
@@ -9959,63 +9965,63 @@ func (p *SQLParser) BoolExprBinaryOperator() (localctx IBoolExprBinaryOperatorCo
 		}
 	}()
 
-	p.SetState(589)
+	p.SetState(591)
 	p.GetErrorHandler().Sync(p)
 
 	switch p.GetTokenStream().LA(1) {
 	case SQLParserT_EQUAL:
 		p.EnterOuterAlt(localctx, 1)
 		{
-			p.SetState(581)
+			p.SetState(583)
 			p.Match(SQLParserT_EQUAL)
 		}
 
 	case SQLParserT_NOTEQUAL:
 		p.EnterOuterAlt(localctx, 2)
 		{
-			p.SetState(582)
+			p.SetState(584)
 			p.Match(SQLParserT_NOTEQUAL)
 		}
 
 	case SQLParserT_NOTEQUAL2:
 		p.EnterOuterAlt(localctx, 3)
 		{
-			p.SetState(583)
+			p.SetState(585)
 			p.Match(SQLParserT_NOTEQUAL2)
 		}
 
 	case SQLParserT_LESS:
 		p.EnterOuterAlt(localctx, 4)
 		{
-			p.SetState(584)
+			p.SetState(586)
 			p.Match(SQLParserT_LESS)
 		}
 
 	case SQLParserT_LESSEQUAL:
 		p.EnterOuterAlt(localctx, 5)
 		{
-			p.SetState(585)
+			p.SetState(587)
 			p.Match(SQLParserT_LESSEQUAL)
 		}
 
 	case SQLParserT_GREATER:
 		p.EnterOuterAlt(localctx, 6)
 		{
-			p.SetState(586)
+			p.SetState(588)
 			p.Match(SQLParserT_GREATER)
 		}
 
 	case SQLParserT_GREATEREQUAL:
 		p.EnterOuterAlt(localctx, 7)
 		{
-			p.SetState(587)
+			p.SetState(589)
 			p.Match(SQLParserT_GREATEREQUAL)
 		}
 
 	case SQLParserT_LIKE, SQLParserT_REGEXP:
 		p.EnterOuterAlt(localctx, 8)
 		{
-			p.SetState(588)
+			p.SetState(590)
 			_la = p.GetTokenStream().LA(1)
 
 			if !(_la == SQLParserT_LIKE || _la == SQLParserT_REGEXP) {
@@ -10071,6 +10077,10 @@ func NewExprContext(parser antlr.Parser, parent antlr.ParserRuleContext, invokin
 
 func (s *ExprContext) GetParser() antlr.Parser { return s.parser }
 
+func (s *ExprContext) T_SUB() antlr.TerminalNode {
+	return s.GetToken(SQLParserT_SUB, 0)
+}
+
 func (s *ExprContext) T_OPEN_P() antlr.TerminalNode {
 	return s.GetToken(SQLParserT_OPEN_P, 0)
 }
@@ -10144,10 +10154,6 @@ func (s *ExprContext) T_ADD() antlr.TerminalNode {
 	return s.GetToken(SQLParserT_ADD, 0)
 }
 
-func (s *ExprContext) T_SUB() antlr.TerminalNode {
-	return s.GetToken(SQLParserT_SUB, 0)
-}
-
 func (s *ExprContext) GetRuleContext() antlr.RuleContext {
 	return s
 }
@@ -10190,6 +10196,7 @@ func (p *SQLParser) expr(_p int) (localctx IExprContext) {
 	var _ antlr.ParserRuleContext = _prevctx // TODO: To prevent unused variable warning.
 	_startState := 128
 	p.EnterRecursionRule(localctx, 128, SQLParserRULE_expr, _p)
+	var _la int
 
 	defer func() {
 		p.UnrollRecursionContexts(_parentctx)
@@ -10210,44 +10217,54 @@ func (p *SQLParser) expr(_p int) (localctx IExprContext) {
 	var _alt int
 
 	p.EnterOuterAlt(localctx, 1)
-	p.SetState(599)
+	p.SetState(603)
 	p.GetErrorHandler().Sync(p)
 	switch p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 49, p.GetParserRuleContext()) {
 	case 1:
 		{
-			p.SetState(592)
+			p.SetState(594)
+			p.Match(SQLParserT_SUB)
+		}
+		{
+			p.SetState(595)
+			p.expr(7)
+		}
+
+	case 2:
+		{
+			p.SetState(596)
 			p.Match(SQLParserT_OPEN_P)
 		}
 		{
-			p.SetState(593)
+			p.SetState(597)
 			p.expr(0)
 		}
 		{
-			p.SetState(594)
+			p.SetState(598)
 			p.Match(SQLParserT_CLOSE_P)
 		}
 
-	case 2:
+	case 3:
 		{
-			p.SetState(596)
+			p.SetState(600)
 			p.ExprFunc()
 		}
 
-	case 3:
+	case 4:
 		{
-			p.SetState(597)
+			p.SetState(601)
 			p.ExprAtom()
 		}
 
-	case 4:
+	case 5:
 		{
-			p.SetState(598)
+			p.SetState(602)
 			p.DurationLit()
 		}
 
 	}
 	p.GetParserRuleContext().SetStop(p.GetTokenStream().LT(-1))
-	p.SetState(615)
+	p.SetState(613)
 	p.GetErrorHandler().Sync(p)
 	_alt = p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 51, p.GetParserRuleContext())
 
@@ -10257,81 +10274,61 @@ func (p *SQLParser) expr(_p int) (localctx IExprContext) {
 				p.TriggerExitRuleEvent()
 			}
 			_prevctx = localctx
-			p.SetState(613)
+			p.SetState(611)
 			p.GetErrorHandler().Sync(p)
 			switch p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 50, p.GetParserRuleContext()) {
 			case 1:
 				localctx = NewExprContext(p, _parentctx, _parentState)
 				p.PushNewRecursionContext(localctx, _startState, SQLParserRULE_expr)
-				p.SetState(601)
-
-				if !(p.Precpred(p.GetParserRuleContext(), 8)) {
-					panic(antlr.NewFailedPredicateException(p, "p.Precpred(p.GetParserRuleContext(), 8)", ""))
-				}
-				{
-					p.SetState(602)
-					p.Match(SQLParserT_MUL)
-				}
-				{
-					p.SetState(603)
-					p.expr(9)
-				}
-
-			case 2:
-				localctx = NewExprContext(p, _parentctx, _parentState)
-				p.PushNewRecursionContext(localctx, _startState, SQLParserRULE_expr)
-				p.SetState(604)
-
-				if !(p.Precpred(p.GetParserRuleContext(), 7)) {
-					panic(antlr.NewFailedPredicateException(p, "p.Precpred(p.GetParserRuleContext(), 7)", ""))
-				}
-				{
-					p.SetState(605)
-					p.Match(SQLParserT_DIV)
-				}
-				{
-					p.SetState(606)
-					p.expr(8)
-				}
-
-			case 3:
-				localctx = NewExprContext(p, _parentctx, _parentState)
-				p.PushNewRecursionContext(localctx, _startState, SQLParserRULE_expr)
-				p.SetState(607)
+				p.SetState(605)
 
 				if !(p.Precpred(p.GetParserRuleContext(), 6)) {
 					panic(antlr.NewFailedPredicateException(p, "p.Precpred(p.GetParserRuleContext(), 6)", ""))
 				}
 				{
-					p.SetState(608)
-					p.Match(SQLParserT_ADD)
+					p.SetState(606)
+					_la = p.GetTokenStream().LA(1)
+
+					if !(_la == SQLParserT_DIV || _la == SQLParserT_MUL) {
+						p.GetErrorHandler().RecoverInline(p)
+					} else {
+						p.GetErrorHandler().ReportMatch(p)
+						p.Consume()
+					}
 				}
 				{
-					p.SetState(609)
+					p.SetState(607)
 					p.expr(7)
 				}
 
-			case 4:
+			case 2:
 				localctx = NewExprContext(p, _parentctx, _parentState)
 				p.PushNewRecursionContext(localctx, _startState, SQLParserRULE_expr)
-				p.SetState(610)
+				p.SetState(608)
 
 				if !(p.Precpred(p.GetParserRuleContext(), 5)) {
 					panic(antlr.NewFailedPredicateException(p, "p.Precpred(p.GetParserRuleContext(), 5)", ""))
 				}
 				{
-					p.SetState(611)
-					p.Match(SQLParserT_SUB)
+					p.SetState(609)
+					_la = p.GetTokenStream().LA(1)
+
+					if !(_la == SQLParserT_ADD || _la == SQLParserT_SUB) {
+						p.GetErrorHandler().RecoverInline(p)
+					} else {
+						p.GetErrorHandler().ReportMatch(p)
+						p.Consume()
+					}
 				}
 				{
-					p.SetState(612)
+					p.SetState(610)
 					p.expr(6)
 				}
 
 			}
 
 		}
-		p.SetState(617)
+		p.SetState(615)
 		p.GetErrorHandler().Sync(p)
 		_alt = p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 51, p.GetParserRuleContext())
 	}
@@ -10449,11 +10446,11 @@ func (p *SQLParser) DurationLit() (localctx IDurationLitContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(618)
+		p.SetState(616)
 		p.IntNumber()
 	}
 	{
-		p.SetState(619)
+		p.SetState(617)
 		p.IntervalItem()
 	}
 
@@ -10579,7 +10576,7 @@ func (p *SQLParser) IntervalItem() (localctx IIntervalItemContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(621)
+		p.SetState(619)
 		_la = p.GetTokenStream().LA(1)
 
 		if !(((_la-60)&-(0x1f+1)) == 0 && ((1<<uint((_la-60)))&((1<<(SQLParserT_SECOND-60))|(1<<(SQLParserT_MINUTE-60))|(1<<(SQLParserT_HOUR-60))|(1<<(SQLParserT_DAY-60))|(1<<(SQLParserT_WEEK-60))|(1<<(SQLParserT_MONTH-60))|(1<<(SQLParserT_YEAR-60)))) != 0) {
@@ -10712,26 +10709,26 @@ func (p *SQLParser) ExprFunc() (localctx IExprFuncContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(623)
+		p.SetState(621)
 		p.Ident()
 	}
 	{
-		p.SetState(624)
+		p.SetState(622)
 		p.Match(SQLParserT_OPEN_P)
 	}
-	p.SetState(626)
+	p.SetState(624)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if (((_la)&-(0x1f+1)) == 0 && ((1<<uint(_la))&((1<<SQLParserT_CREATE)|(1<<SQLParserT_INTERVAL)|(1<<SQLParserT_SHARD)|(1<<SQLParserT_REPLICATION)|(1<<SQLParserT_TTL)|(1<<SQLParserT_KILL)|(1<<SQLParserT_ON)|(1<<SQLParserT_SHOW)|(1<<SQLParserT_DATASBAE)|(1<<SQLParserT_DATASBAES)|(1<<SQLParserT_NODE)|(1<<SQLParserT_MEASUREMENTS)|(1<<SQLParserT_MEASUREMENT)|(1<<SQLParserT_FIELD)|(1<<SQLParserT_TAG)|(1<<SQLParserT_KEYS)|(1<<SQLParserT_KEY)|(1<<SQLParserT_WITH)|(1<<SQLParserT_VALUES)|(1<<SQLParserT_FROM)|(1<<SQLParserT_WHERE)|(1<<SQLParserT_LIMIT))) != 0) || (((_la-32)&-(0x1f+1)) == 0 && ((1<<uint((_la-32)))&((1<<(SQLParserT_QUERIES-32))|(1<<(SQLParserT_QUERY-32))|(1<<(SQLParserT_SELECT-32))|(1<<(SQLParserT_AS-32))|(1<<(SQLParserT_AND-32))|(1<<(SQLParserT_OR-32))|(1<<(SQLParserT_FILL-32))|(1<<(SQLParserT_NULL-32))|(1<<(SQLParserT_PREVIOUS-32))|(1<<(SQLParserT_ORDER-32))|(1<<(SQLParserT_ASC-32))|(1<<(SQLParserT_DESC-32))|(1<<(SQLParserT_LIKE-32))|(1<<(SQLParserT_NOT-32))|(1<<(SQLParserT_BETWEEN-32))|(1<<(SQLParserT_IS-32))|(1<<(SQLParserT_GROUP-32))|(1<<(SQLParserT_BY-32))|(1<<(SQLParserT_FOR-32))|(1<<(SQLParserT_STATS-32))|(1<<(SQLParserT_TIME-32))|(1<<(SQLParserT_PROFILE-32))|(1<<(SQLParserT_SECOND-32))|(1<<(SQLParserT_MINUTE-32))|(1<<(SQLParserT_HOUR-32))|(1<<(SQLParserT_DAY-32)))) != 0) || (((_la-64)&-(0x1f+1)) == 0 && ((1<<uint((_la-64)))&((1<<(SQLParserT_WEEK-64))|(1<<(SQLParserT_MONTH-64))|(1<<(SQLParserT_YEAR-64))|(1<<(SQLParserT_OPEN_P-64))|(1<<(SQLParserT_ADD-64))|(1<<(SQLParserT_SUB-64))|(1<<(SQLParserL_ID-64))|(1<<(SQLParserL_INT-64))|(1<<(SQLParserL_DEC-64)))) != 0) {
 		{
-			p.SetState(625)
+			p.SetState(623)
 			p.ExprFuncParams()
 		}
 
 	}
 	{
-		p.SetState(628)
+		p.SetState(626)
 		p.Match(SQLParserT_CLOSE_P)
 	}
 
@@ -10860,24 +10857,24 @@ func (p *SQLParser) ExprFuncParams() (localctx IExprFuncParamsContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(630)
+		p.SetState(628)
 		p.FuncParam()
 	}
-	p.SetState(635)
+	p.SetState(633)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	for _la == SQLParserT_COMMA {
 		{
-			p.SetState(631)
+			p.SetState(629)
 			p.Match(SQLParserT_COMMA)
 		}
 		{
-			p.SetState(632)
+			p.SetState(630)
 			p.FuncParam()
 		}
 
-		p.SetState(637)
+		p.SetState(635)
 		p.GetErrorHandler().Sync(p)
 		_la = p.GetTokenStream().LA(1)
 	}
@@ -10993,20 +10990,20 @@ func (p *SQLParser) FuncParam() (localctx IFuncParamContext) {
 		}
 	}()
 
-	p.SetState(640)
+	p.SetState(638)
 	p.GetErrorHandler().Sync(p)
 	switch p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 54, p.GetParserRuleContext()) {
 	case 1:
 		p.EnterOuterAlt(localctx, 1)
 		{
-			p.SetState(638)
+			p.SetState(636)
 			p.expr(0)
 		}
 
 	case 2:
 		p.EnterOuterAlt(localctx, 2)
 		{
-			p.SetState(639)
+			p.SetState(637)
 			p.tagBooleanExpr(0)
 		}
 
@@ -11073,6 +11070,16 @@ func (s *ExprAtomContext) IdentFilter() IIdentFilterContext {
 	return t.(IIdentFilterContext)
 }
 
+func (s *ExprAtomContext) MetricFieldRef() IMetricFieldRefContext {
+	var t = s.GetTypedRuleContext(reflect.TypeOf((*IMetricFieldRefContext)(nil)).Elem(), 0)
+
+	if t == nil {
+		return nil
+	}
+
+	return t.(IMetricFieldRefContext)
+}
+
 func (s *ExprAtomContext) DecNumber() IDecNumberContext {
 	var t = s.GetTypedRuleContext(reflect.TypeOf((*IDecNumberContext)(nil)).Elem(), 0)
 
@@ -11143,21 +11150,21 @@ func (p *SQLParser) ExprAtom() (localctx IExprAtomContext) {
 		}
 	}()
 
-	p.SetState(648)
+	p.SetState(647)
 	p.GetErrorHandler().Sync(p)
 	switch p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 56, p.GetParserRuleContext()) {
 	case 1:
 		p.EnterOuterAlt(localctx, 1)
 		{
-			p.SetState(642)
+			p.SetState(640)
 			p.Ident()
 		}
-		p.SetState(644)
+		p.SetState(642)
 		p.GetErrorHandler().Sync(p)
 
 		if p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 55, p.GetParserRuleContext()) == 1 {
 			{
-				p.SetState(643)
+				p.SetState(641)
 				p.IdentFilter()
 			}
 
@@ -11166,14 +11173,21 @@ func (p *SQLParser) ExprAtom() (localctx IExprAtomContext) {
 	case 2:
 		p.EnterOuterAlt(localctx, 2)
 		{
-			p.SetState(646)
-			p.DecNumber()
+			p.SetState(644)
+			p.MetricFieldRef()
 		}
 
 	case 3:
 		p.EnterOuterAlt(localctx, 3)
 		{
-			p.SetState(647)
+			p.SetState(645)
+			p.DecNumber()
+		}
+
+	case 4:
+		p.EnterOuterAlt(localctx, 4)
+		{
+			p.SetState(646)
 			p.IntNumber()
 		}
 
@@ -11290,15 +11304,15 @@ func (p *SQLParser) IdentFilter() (localctx IIdentFilterContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(650)
+		p.SetState(649)
 		p.Match(SQLParserT_OPEN_SB)
 	}
 	{
-		p.SetState(651)
+		p.SetState(650)
 		p.tagBooleanExpr(0)
 	}
 	{
-		p.SetState(652)
+		p.SetState(651)
 		p.Match(SQLParserT_CLOSE_SB)
 	}
 
@@ -11407,13 +11421,13 @@ func (p *SQLParser) IntNumber() (localctx IIntNumberContext) {
 	}()
 
 	p.EnterOuterAlt(localctx, 1)
-	p.SetState(655)
+	p.SetState(654)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_ADD || _la == SQLParserT_SUB {
 		{
-			p.SetState(654)
+			p.SetState(653)
 			_la = p.GetTokenStream().LA(1)
 
 			if !(_la == SQLParserT_ADD || _la == SQLParserT_SUB) {
@@ -11426,7 +11440,7 @@ func (p *SQLParser) IntNumber() (localctx IIntNumberContext) {
 
 	}
 	{
-		p.SetState(657)
+		p.SetState(656)
 		p.Match(SQLParserL_INT)
 	}
 
@@ -11535,13 +11549,13 @@ func (p *SQLParser) DecNumber() (localctx IDecNumberContext) {
 	}()
 
 	p.EnterOuterAlt(localctx, 1)
-	p.SetState(660)
+	p.SetState(659)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == SQLParserT_ADD || _la == SQLParserT_SUB {
 		{
-			p.SetState(659)
+			p.SetState(658)
 			_la = p.GetTokenStream().LA(1)
 
 			if !(_la == SQLParserT_ADD || _la == SQLParserT_SUB) {
@@ -11554,7 +11568,7 @@ func (p *SQLParser) DecNumber() (localctx IDecNumberContext) {
 
 	}
 	{
-		p.SetState(662)
+		p.SetState(661)
 		p.Match(SQLParserL_DEC)
 	}
 
@@ -11659,11 +11673,11 @@ func (p *SQLParser) LimitClause() (localctx ILimitClauseContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(664)
+		p.SetState(663)
 		p.Match(SQLParserT_LIMIT)
 	}
 	{
-		p.SetState(665)
+		p.SetState(664)
 		p.Match(SQLParserL_INT)
 	}
 
@@ -11770,7 +11784,7 @@ func (p *SQLParser) MetricName() (localctx IMetricNameContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(667)
+		p.SetState(666)
 		p.Ident()
 	}
 
@@ -11877,7 +11891,7 @@ func (p *SQLParser) TagKey() (localctx ITagKeyContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(669)
+		p.SetState(668)
 		p.Ident()
 	}
 
@@ -11984,7 +11998,7 @@ func (p *SQLParser) TagValue() (localctx ITagValueContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(671)
+		p.SetState(670)
 		p.Ident()
 	}
 
@@ -12091,7 +12105,7 @@ func (p *SQLParser) TagValuePattern() (localctx ITagValuePatternContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(673)
+		p.SetState(672)
 		p.Ident()
 	}
 
@@ -12228,48 +12242,48 @@ func (p *SQLParser) Ident() (localctx IIdentContext) {
 	var _alt int
 
 	p.EnterOuterAlt(localctx, 1)
-	p.SetState(677)
+	p.SetState(676)
 	p.GetErrorHandler().Sync(p)
 
 	switch p.GetTokenStream().LA(1) {
 	case SQLParserL_ID:
 		{
-			p.SetState(675)
+			p.SetState(674)
 			p.Match(SQLParserL_ID)
 		}
 
 	case SQLParserT_CREATE, SQLParserT_INTERVAL, SQLParserT_SHARD, SQLParserT_REPLICATION, SQLParserT_TTL, SQLParserT_KILL, SQLParserT_ON, SQLParserT_SHOW, SQLParserT_DATASBAE, SQLParserT_DATASBAES, SQLParserT_NODE, SQLParserT_MEASUREMENTS, SQLParserT_MEASUREMENT, SQLParserT_FIELD, SQLParserT_TAG, SQLParserT_KEYS, SQLParserT_KEY, SQLParserT_WITH, SQLParserT_VALUES, SQLParserT_FROM, SQLParserT_WHERE, SQLParserT_LIMIT, SQLParserT_QUERIES, SQLParserT_QUERY, SQLParserT_SELECT, SQLParserT_AS, SQLParserT_AND, SQLParserT_OR, SQLParserT_FILL, SQLParserT_NULL, SQLParserT_PREVIOUS, SQLParserT_ORDER, SQLParserT_ASC, SQLParserT_DESC, SQLParserT_LIKE, SQLParserT_NOT, SQLParserT_BETWEEN, SQLParserT_IS, SQLParserT_GROUP, SQLParserT_BY, SQLParserT_FOR, SQLParserT_STATS, SQLParserT_TIME, SQLParserT_PROFILE, SQLParserT_SECOND, SQLParserT_MINUTE, SQLParserT_HOUR, SQLParserT_DAY, SQLParserT_WEEK, SQLParserT_MONTH, SQLParserT_YEAR:
 		{
-			p.SetState(676)
+			p.SetState(675)
 			p.NonReservedWords()
 		}
 
 	default:
 		panic(antlr.NewNoViableAltException(p, nil, nil, nil, nil, nil))
 	}
-	p.SetState(686)
+	p.SetState(685)
 	p.GetErrorHandler().Sync(p)
 	_alt = p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 61, p.GetParserRuleContext())
 
 	for _alt != 2 && _alt != antlr.ATNInvalidAltNumber {
 		if _alt == 1 {
 			{
-				p.SetState(679)
+				p.SetState(678)
 				p.Match(SQLParserT_DOT)
 			}
-			p.SetState(682)
+			p.SetState(681)
 			p.GetErrorHandler().Sync(p)
 
 			switch p.GetTokenStream().LA(1) {
 			case SQLParserL_ID:
 				{
-					p.SetState(680)
+					p.SetState(679)
 					p.Match(SQLParserL_ID)
 				}
 
 			case SQLParserT_CREATE, SQLParserT_INTERVAL, SQLParserT_SHARD, SQLParserT_REPLICATION, SQLParserT_TTL, SQLParserT_KILL, SQLParserT_ON, SQLParserT_SHOW, SQLParserT_DATASBAE, SQLParserT_DATASBAES, SQLParserT_NODE, SQLParserT_MEASUREMENTS, SQLParserT_MEASUREMENT, SQLParserT_FIELD, SQLParserT_TAG, SQLParserT_KEYS, SQLParserT_KEY, SQLParserT_WITH, SQLParserT_VALUES, SQLParserT_FROM, SQLParserT_WHERE, SQLParserT_LIMIT, SQLParserT_QUERIES, SQLParserT_QUERY, SQLParserT_SELECT, SQLParserT_AS, SQLParserT_AND, SQLParserT_OR, SQLParserT_FILL, SQLParserT_NULL, SQLParserT_PREVIOUS, SQLParserT_ORDER, SQLParserT_ASC, SQLParserT_DESC, SQLParserT_LIKE, SQLParserT_NOT, SQLParserT_BETWEEN, SQLParserT_IS, SQLParserT_GROUP, SQLParserT_BY, SQLParserT_FOR, SQLParserT_STATS, SQLParserT_TIME, SQLParserT_PROFILE, SQLParserT_SECOND, SQLParserT_MINUTE, SQLParserT_HOUR, SQLParserT_DAY, SQLParserT_WEEK, SQLParserT_MONTH, SQLParserT_YEAR:
 				{
-					p.SetState(681)
+					p.SetState(680)
 					p.NonReservedWords()
 				}
 
@@ -12278,7 +12292,7 @@ func (p *SQLParser) Ident() (localctx IIdentContext) {
 			}
 
 		}
-		p.SetState(688)
+		p.SetState(687)
 		p.GetErrorHandler().Sync(p)
 		_alt = p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 61, p.GetParserRuleContext())
 	}
@@ -12581,7 +12595,7 @@ func (p *SQLParser) NonReservedWords() (localctx INonReservedWordsContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(689)
+		p.SetState(688)
 		_la = p.GetTokenStream().LA(1)
 
 		if !((((_la)&-(0x1f+1)) == 0 && ((1<<uint(_la))&((1<<SQLParserT_CREATE)|(1<<SQLParserT_INTERVAL)|(1<<SQLParserT_SHARD)|(1<<SQLParserT_REPLICATION)|(1<<SQLParserT_TTL)|(1<<SQLParserT_KILL)|(1<<SQLParserT_ON)|(1<<SQLParserT_SHOW)|(1<<SQLParserT_DATASBAE)|(1<<SQLParserT_DATASBAES)|(1<<SQLParserT_NODE)|(1<<SQLParserT_MEASUREMENTS)|(1<<SQLParserT_MEASUREMENT)|(1<<SQLParserT_FIELD)|(1<<SQLParserT_TAG)|(1<<SQLParserT_KEYS)|(1<<SQLParserT_KEY)|(1<<SQLParserT_WITH)|(1<<SQLParserT_VALUES)|(1<<SQLParserT_FROM)|(1<<SQLParserT_WHERE)|(1<<SQLParserT_LIMIT))) != 0) || (((_la-32)&-(0x1f+1)) == 0 && ((1<<uint((_la-32)))&((1<<(SQLParserT_QUERIES-32))|(1<<(SQLParserT_QUERY-32))|(1<<(SQLParserT_SELECT-32))|(1<<(SQLParserT_AS-32))|(1<<(SQLParserT_AND-32))|(1<<(SQLParserT_OR-32))|(1<<(SQLParserT_FILL-32))|(1<<(SQLParserT_NULL-32))|(1<<(SQLParserT_PREVIOUS-32))|(1<<(SQLParserT_ORDER-32))|(1<<(SQLParserT_ASC-32))|(1<<(SQLParserT_DESC-32))|(1<<(SQLParserT_LIKE-32))|(1<<(SQLParserT_NOT-32))|(1<<(SQLParserT_BETWEEN-32))|(1<<(SQLParserT_IS-32))|(1<<(SQLParserT_GROUP-32))|(1<<(SQLParserT_BY-32))|(1<<(SQLParserT_FOR-32))|(1<<(SQLParserT_STATS-32))|(1<<(SQLParserT_TIME-32))|(1<<(SQLParserT_PROFILE-32))|(1<<(SQLParserT_SECOND-32))|(1<<(SQLParserT_MINUTE-32))|(1<<(SQLParserT_HOUR-32))|(1<<(SQLParserT_DAY-32)))) != 0) || (((_la-64)&-(0x1f+1)) == 0 && ((1<<uint((_la-64)))&((1<<(SQLParserT_WEEK-64))|(1<<(SQLParserT_MONTH-64))|(1<<(SQLParserT_YEAR-64)))) != 0)) {
@@ -12595,6 +12609,167 @@ func (p *SQLParser) NonReservedWords() (localctx INonReservedWordsContext) {
 	return localctx
 }
 
+// IMetricFieldRefContext is an interface to support dynamic dispatch.
+type IMetricFieldRefContext interface {
+	antlr.ParserRuleContext
+
+	// GetParser returns the parser.
+	GetParser() antlr.Parser
+
+	// IsMetricFieldRefContext differentiates from other interfaces.
+	IsMetricFieldRefContext()
+}
+
+type MetricFieldRefContext struct {
+	*antlr.BaseParserRuleContext
+	parser antlr.Parser
+}
+
+func NewEmptyMetricFieldRefContext() *MetricFieldRefContext {
+	var p = new(MetricFieldRefContext)
+	p.BaseParserRuleContext = antlr.NewBaseParserRuleContext(nil, -1)
+	p.RuleIndex = SQLParserRULE_metricFieldRef
+	return p
+}
+
+func (*MetricFieldRefContext) IsMetricFieldRefContext() {}
+
+func NewMetricFieldRefContext(parser antlr.Parser, parent antlr.ParserRuleContext, invokingState int) *MetricFieldRefContext {
+	var p = new(MetricFieldRefContext)
+
+	p.BaseParserRuleContext = antlr.NewBaseParserRuleContext(parent, invokingState)
+
+	p.parser = parser
+	p.RuleIndex = SQLParserRULE_metricFieldRef
+
+	return p
+}
+
+func (s *MetricFieldRefContext) GetParser() antlr.Parser { return s.parser }
+
+func (s *MetricFieldRefContext) DatabaseName() IDatabaseNameContext {
+	var t = s.GetTypedRuleContext(reflect.TypeOf((*IDatabaseNameContext)(nil)).Elem(), 0)
+
+	if t == nil {
+		return nil
+	}
+
+	return t.(IDatabaseNameContext)
+}
+
+func (s *MetricFieldRefContext) AllT_COLON() []antlr.TerminalNode {
+	return s.GetTokens(SQLParserT_COLON)
+}
+
+func (s *MetricFieldRefContext) T_COLON(i int) antlr.TerminalNode {
+	return s.GetToken(SQLParserT_COLON, i)
+}
+
+func (s *MetricFieldRefContext) MetricName() IMetricNameContext {
+	var t = s.GetTypedRuleContext(reflect.TypeOf((*IMetricNameContext)(nil)).Elem(), 0)
+
+	if t == nil {
+		return nil
+	}
+
+	return t.(IMetricNameContext)
+}
+
+func (s *MetricFieldRefContext) Ident() IIdentContext {
+	var t = s.GetTypedRuleContext(reflect.TypeOf((*IIdentContext)(nil)).Elem(), 0)
+
+	if t == nil {
+		return nil
+	}
+
+	return t.(IIdentContext)
+}
+
+func (s *MetricFieldRefContext) GetRuleContext() antlr.RuleContext {
+	return s
+}
+
+func (s *MetricFieldRefContext) ToStringTree(ruleNames []string, recog antlr.Recognizer) string {
+	return antlr.TreesStringTree(s, ruleNames, recog)
+}
+
+func (s *MetricFieldRefContext) EnterRule(listener antlr.ParseTreeListener) {
+	if listenerT, ok := listener.(SQLListener); ok {
+		listenerT.EnterMetricFieldRef(s)
+	}
+}
+
+func (s *MetricFieldRefContext) ExitRule(listener antlr.ParseTreeListener) {
+	if listenerT, ok := listener.(SQLListener); ok {
+		listenerT.ExitMetricFieldRef(s)
+	}
+}
+
+func (s *MetricFieldRefContext) Accept(visitor antlr.ParseTreeVisitor) interface{} {
+	switch t := visitor.(type) {
+	case SQLVisitor:
+		return t.VisitMetricFieldRef(s)
+
+	default:
+		return t.VisitChildren(s)
+	}
+}
+
+func (p *SQLParser) MetricFieldRef() (localctx IMetricFieldRefContext) {
+	localctx = NewMetricFieldRefContext(p, p.GetParserRuleContext(), p.GetState())
+	p.EnterRule(localctx, 162, SQLParserRULE_metricFieldRef)
+
+	defer func() {
+		p.ExitRule()
+	}()
+
+	defer func() {
+		if err := recover(); err != nil {
+			if v, ok := err.(antlr.RecognitionException); ok {
+				localctx.SetException(v)
+				p.GetErrorHandler().ReportError(p, v)
+				p.GetErrorHandler().Recover(p, v)
+			} else {
+				panic(err)
+			}
+		}
+	}()
+
+	p.EnterOuterAlt(localctx, 1)
+	p.SetState(695)
+	p.GetErrorHandler().Sync(p)
+
+	if p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 62, p.GetParserRuleContext()) == 1 {
+		{
+			p.SetState(691)
+			p.DatabaseName()
+		}
+		{
+			p.SetState(692)
+			p.Match(SQLParserT_COLON)
+		}
+		{
+			p.SetState(693)
+			p.Match(SQLParserT_COLON)
+		}
+
+	}
+	{
+		p.SetState(697)
+		p.MetricName()
+	}
+	{
+		p.SetState(698)
+		p.Match(SQLParserT_COLON)
+	}
+	{
+		p.SetState(699)
+		p.Ident()
+	}
+
+	return localctx
+}
+
 func (p *SQLParser) Sempred(localctx antlr.RuleContext, ruleIndex, predIndex int) bool {
 	switch ruleIndex {
 	case 41:
@@ -12663,15 +12838,9 @@ func (p *SQLParser) BoolExpr_Sempred(localctx antlr.RuleContext, predIndex int)
 func (p *SQLParser) Expr_Sempred(localctx antlr.RuleContext, predIndex int) bool {
 	switch predIndex {
 	case 3:
-		return p.Precpred(p.GetParserRuleContext(), 8)
-
-	case 4:
-		return p.Precpred(p.GetParserRuleContext(), 7)
-
-	case 5:
 		return p.Precpred(p.GetParserRuleContext(), 6)
 
-	case 6:
+	case 4:
 		return p.Precpred(p.GetParserRuleContext(), 5)
 
 	default:
@@ -250,4 +250,7 @@ type SQLVisitor interface {
 
 	// Visit a parse tree produced by SQLParser#nonReservedWords.
 	VisitNonReservedWords(ctx *NonReservedWordsContext) interface{}
+
+	// Visit a parse tree produced by SQLParser#metricFieldRef.
+	VisitMetricFieldRef(ctx *MetricFieldRefContext) interface{}
 }
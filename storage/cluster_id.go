@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/eleme/lindb/pkg/util"
+)
+
+// clusterIDFile is the file name of cluster id under the data directory of storage node
+const clusterIDFile = "CLUSTER_ID"
+
+// checkClusterID checks if the data directory belongs to the cluster, the cluster id is stored when the node
+// joins the cluster first time, so that the data of one cluster is never served in another cluster
+// (e.g. the node is configured with the namespace of another cluster by mistake).
+func checkClusterID(dir, clusterID string) error {
+	path := filepath.Join(dir, clusterIDFile)
+	if util.Exist(path) {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read cluster id file[%s] error:%s", path, err)
+		}
+		if id := strings.TrimSpace(string(data)); id != clusterID {
+			return fmt.Errorf("data directory[%s] belongs to cluster[%s], cannot join cluster[%s], "+
+				"check the namespace and cluster-id of coordinator config", dir, id, clusterID)
+		}
+		return nil
+	}
+	if err := util.MkDirIfNotExist(dir); err != nil {
+		return fmt.Errorf("create data directory[%s] error:%s", dir, err)
+	}
+	if err := ioutil.WriteFile(path, []byte(clusterID), 0644); err != nil {
+		return fmt.Errorf("write cluster id file[%s] error:%s", path, err)
+	}
+	return nil
+}
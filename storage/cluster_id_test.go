@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/pkg/util"
+)
+
+func TestCheckClusterID(t *testing.T) {
+	dir := "cluster_id_test"
+	defer func() {
+		_ = util.RemoveDir(dir)
+	}()
+	assert.Nil(t, checkClusterID(dir, "cluster1"))
+	assert.Nil(t, checkClusterID(dir, "cluster1"))
+	// the data directory belongs to another cluster
+	assert.NotNil(t, checkClusterID(dir, "cluster2"))
+
+	// the id file edited by hand
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, clusterIDFile), []byte("cluster2\n"), 0644))
+	assert.Nil(t, checkClusterID(dir, "cluster2"))
+	// read id file error
+	_ = util.RemoveDir(filepath.Join(dir, clusterIDFile))
+	assert.Nil(t, util.MkDirIfNotExist(filepath.Join(dir, clusterIDFile)))
+	assert.NotNil(t, checkClusterID(dir, "cluster1"))
+}
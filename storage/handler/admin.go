@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/eleme/lindb/kv"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/rpc"
+	"github.com/eleme/lindb/rpc/proto/common"
+	"github.com/eleme/lindb/service"
+)
+
+// Admin represents the rpc handler for the administration of storage node
+type Admin struct {
+	storageService     service.StorageService
+	metricStoreService service.MetricStoreService
+	rawQueryService    service.RawQueryService
+	warmUpService      service.WarmUpService
+	writer             *Writer
+	taskManager        kv.TaskManager
+
+	mutex  sync.Mutex
+	logger *logger.Logger
+}
+
+// NewAdmin creates the administration rpc handler, the points scanned by raw query are recorded
+// into database statistics if stats recorder isn't nil
+func NewAdmin(storageService service.StorageService, writer *Writer,
+	statsRecorder service.DatabaseStatsRecorder) *Admin {
+	return &Admin{
+		storageService:     storageService,
+		metricStoreService: service.NewMetricStoreService(storageService),
+		rawQueryService:    service.NewRawQueryService(storageService, statsRecorder),
+		warmUpService:      service.NewWarmUpService(storageService),
+		writer:             writer,
+		taskManager:        kv.DefaultTaskManager,
+		logger:             logger.GetLogger("storage/handler/admin"),
+	}
+}
+
+// PrepareShutdown prepares the storage node for a planned restart(like the preStop hook of Kubernetes):
+// 1) rejects the following writes;
+// 2) forces flushing the in-memory data of all shards into disk.
+// The response is ok when the node is ready for shutdown, so that the restart has no replay cost or data risk,
+// it can be called repeatedly, the in-memory data is flushed again if written before draining.
+// NOTICE: the storage node doesn't consume replica log in this version, so there is no consumption state to sync.
+func (a *Admin) PrepareShutdown(ctx context.Context, request *common.Request) (*common.Response, error) {
+	// serialize the concurrent calls from orchestrators
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.writer.Drain()
+	if err := a.storageService.FlushAll(); err != nil {
+		a.logger.Error("flush data for shutdown error", logger.Error(err))
+		return rpc.ResponseError("flush data for shutdown error:" + err.Error()), nil
+	}
+	a.logger.Info("storage node is ready for shutdown")
+	return rpc.ResponseOK(), nil
+}
+
+// ListTasks returns the progress of running flush/compaction tasks(family, bytes processed, estimated remaining time,
+// start time), so that operators can see why disk IO is high and whether a compaction is stuck.
+func (a *Admin) ListTasks(ctx context.Context, request *common.Request) (*common.Response, error) {
+	data, err := json.Marshal(a.taskManager.Running())
+	if err != nil {
+		return rpc.ResponseError("marshal running tasks error:" + err.Error()), nil
+	}
+	return rpc.ResponseOKWithData(data), nil
+}
+
+// ManageMetricStore resets or drops the metric store in memory-database of database's shards in current storage node,
+// so that operators can recover from a cardinality incident without restarting.
+func (a *Admin) ManageMetricStore(ctx context.Context, request *common.Request) (*common.Response, error) {
+	req := &models.MetricStoreRequest{}
+	if err := json.Unmarshal(request.Data, req); err != nil {
+		return rpc.ResponseError("unmarshal metric store request error:" + err.Error()), nil
+	}
+	results, err := a.metricStoreService.Manage(ctx, req)
+	if err != nil {
+		return rpc.ResponseError(err.Error()), nil
+	}
+	a.logger.Info("manage metric store", logger.String("db", req.Database),
+		logger.String("metric", req.MetricName), logger.String("action", string(req.Action)))
+	data, err := json.Marshal(results)
+	if err != nil {
+		return rpc.ResponseError("marshal metric store results error:" + err.Error()), nil
+	}
+	return rpc.ResponseOKWithData(data), nil
+}
+
+// QueryRawPoints returns the raw points of series in memory-database of database's shards in current storage node,
+// which aren't aggregated or down sampled, so that operators can verify the ingestion of shard replicas.
+func (a *Admin) QueryRawPoints(ctx context.Context, request *common.Request) (*common.Response, error) {
+	req := &models.RawQueryRequest{}
+	if err := json.Unmarshal(request.Data, req); err != nil {
+		return rpc.ResponseError("unmarshal raw query request error:" + err.Error()), nil
+	}
+	result, err := a.rawQueryService.Query(ctx, req)
+	if err != nil {
+		return rpc.ResponseError(err.Error()), nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return rpc.ResponseError("marshal raw query result error:" + err.Error()), nil
+	}
+	return rpc.ResponseOKWithData(data), nil
+}
+
+// GetDiskUsage returns the disk usage of the database's engine in current storage node,
+// returns all databases' if the database of request is empty.
+func (a *Admin) GetDiskUsage(ctx context.Context, request *common.Request) (*common.Response, error) {
+	req := &models.DiskUsageRequest{}
+	if len(request.Data) > 0 {
+		if err := json.Unmarshal(request.Data, req); err != nil {
+			return rpc.ResponseError("unmarshal disk usage request error:" + err.Error()), nil
+		}
+	}
+	usages := a.storageService.DiskUsage(req.Database)
+	if usages == nil {
+		usages = []models.DatabaseDiskUsage{}
+	}
+	data, err := json.Marshal(usages)
+	if err != nil {
+		return rpc.ResponseError("marshal disk usage error:" + err.Error()), nil
+	}
+	return rpc.ResponseOKWithData(data), nil
+}
+
+// WarmUp opens the table readers and loads the index blocks of database's metadata index and shards
+// in current storage node into block cache, so that the first queries after restarted don't read them from disk.
+func (a *Admin) WarmUp(ctx context.Context, request *common.Request) (*common.Response, error) {
+	req := &models.WarmUpRequest{}
+	if err := json.Unmarshal(request.Data, req); err != nil {
+		return rpc.ResponseError("unmarshal warm up request error:" + err.Error()), nil
+	}
+	results, err := a.warmUpService.WarmUp(ctx, req)
+	if err != nil {
+		return rpc.ResponseError(err.Error()), nil
+	}
+	a.logger.Info("warm up database", logger.String("db", req.Database), logger.Any("segments", req.Segments))
+	if results == nil {
+		results = []models.WarmUpResult{}
+	}
+	data, err := json.Marshal(results)
+	if err != nil {
+		return rpc.ResponseError("marshal warm up results error:" + err.Error()), nil
+	}
+	return rpc.ResponseOKWithData(data), nil
+}
+
+// ExportFamily exports a page of the data family of database's shard in current storage node, which is called by
+// the replica whose file of the family is corrupted, so that the family is re-replicated from current node.
+func (a *Admin) ExportFamily(ctx context.Context, request *common.Request) (*common.Response, error) {
+	req := &models.FamilyExportRequest{}
+	if err := json.Unmarshal(request.Data, req); err != nil {
+		return rpc.ResponseError("unmarshal family export request error:" + err.Error()), nil
+	}
+	engine := a.storageService.GetEngine(req.Database)
+	if engine == nil {
+		return rpc.ResponseError(fmt.Sprintf("database[%s] not found", req.Database)), nil
+	}
+	result, err := engine.ExportFamily(*req)
+	if err != nil {
+		return rpc.ResponseError(err.Error()), nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return rpc.ResponseError("marshal exported family error:" + err.Error()), nil
+	}
+	return rpc.ResponseOKWithData(data), nil
+}
@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/kv"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/pkg/interval"
+	"github.com/eleme/lindb/pkg/option"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/pkg/util"
+	"github.com/eleme/lindb/rpc"
+	"github.com/eleme/lindb/rpc/proto/common"
+	"github.com/eleme/lindb/service"
+)
+
+// flushErrStorageService fails flushing engines
+type flushErrStorageService struct {
+	service.StorageService
+}
+
+func (s *flushErrStorageService) FlushAll() error {
+	return fmt.Errorf("flush error")
+}
+
+func TestAdmin_PrepareShutdown(t *testing.T) {
+	testPath := "test_data"
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	storageService := service.NewStorageService(config.Engine{Path: testPath})
+	assert.Nil(t, storageService.CreateShards("db", option.ShardOption{Interval: time.Second * 10, IntervalType: interval.Day}, 1))
+	writer := NewWriter(storageService, NewRoutingEpochs())
+	admin := NewAdmin(storageService, writer, nil)
+
+	write := newWriteRequest(t, "db", 1, newCPUPoint("a", timeutil.Now(), 1))
+	resp, _ := writer.WritePoints(context.TODO(), write)
+	assert.Nil(t, rpc.ResponseToError(resp))
+
+	resp, _ = admin.PrepareShutdown(context.TODO(), &common.Request{})
+	assert.Nil(t, rpc.ResponseToError(resp))
+	assert.True(t, writer.IsDraining())
+	resp, _ = writer.WritePoints(context.TODO(), write)
+	assert.NotNil(t, rpc.ResponseToError(resp))
+	// prepare again
+	resp, _ = admin.PrepareShutdown(context.TODO(), &common.Request{})
+	assert.Nil(t, rpc.ResponseToError(resp))
+
+	admin = NewAdmin(&flushErrStorageService{StorageService: storageService}, writer, nil)
+	resp, _ = admin.PrepareShutdown(context.TODO(), &common.Request{})
+	assert.NotNil(t, rpc.ResponseToError(resp))
+}
+
+func TestAdmin_ListTasks(t *testing.T) {
+	admin := NewAdmin(nil, nil, nil)
+	admin.taskManager = kv.NewTaskManager()
+	task := admin.taskManager.Start(kv.TaskCompaction, "f", 100)
+	defer task.Done()
+	task.Add(10)
+
+	resp, _ := admin.ListTasks(context.TODO(), &common.Request{})
+	assert.Nil(t, rpc.ResponseToError(resp))
+	var tasks []kv.TaskProgress
+	assert.Nil(t, json.Unmarshal(resp.Data, &tasks))
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, kv.TaskCompaction, tasks[0].Type)
+	assert.Equal(t, "f", tasks[0].Family)
+	assert.Equal(t, int64(10), tasks[0].BytesProcessed)
+	assert.Equal(t, int64(100), tasks[0].BytesTotal)
+}
+
+func TestAdmin_ManageMetricStore(t *testing.T) {
+	testPath := "test_data"
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	storageService := service.NewStorageService(config.Engine{Path: testPath})
+	assert.Nil(t, storageService.CreateShards("db", option.ShardOption{Interval: time.Second * 10, IntervalType: interval.Day, TimeWindow: 32}, 1, 2))
+	memDB := storageService.GetShard("db", 1).MemoryDatabase()
+	assert.Nil(t, memDB.Write(models.NewPoint("cpu", timeutil.Now(), map[string]string{"host": "1.1.1.1"},
+		map[string]models.Field{"f1": models.NewSimpleField(field.SumField, field.Integer, int64(1))})))
+	admin := NewAdmin(storageService, nil, nil)
+
+	resp, _ := admin.ManageMetricStore(context.TODO(), &common.Request{Data: []byte("err")})
+	assert.NotNil(t, rpc.ResponseToError(resp))
+	data, _ := json.Marshal(&models.MetricStoreRequest{Database: "db", MetricName: "cpu"})
+	resp, _ = admin.ManageMetricStore(context.TODO(), &common.Request{Data: data})
+	assert.NotNil(t, rpc.ResponseToError(resp))
+
+	data, _ = json.Marshal(&models.MetricStoreRequest{Database: "db", MetricName: "cpu", Action: models.DropMetricStore})
+	resp, _ = admin.ManageMetricStore(context.TODO(), &common.Request{Data: data})
+	assert.Nil(t, rpc.ResponseToError(resp))
+	var results []models.MetricStoreResult
+	assert.Nil(t, json.Unmarshal(resp.Data, &results))
+	assert.Equal(t, []models.MetricStoreResult{{ShardID: 1}, {ShardID: 2}}, results)
+	assert.Equal(t, 0, memDB.CountMetrics())
+}
+
+func TestAdmin_QueryRawPoints(t *testing.T) {
+	testPath := "test_data"
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	storageService := service.NewStorageService(config.Engine{Path: testPath})
+	assert.Nil(t, storageService.CreateShards("db", option.ShardOption{Interval: time.Second * 10, IntervalType: interval.Day, TimeWindow: 32}, 1))
+	now := timeutil.Now()
+	now -= now % (10 * 1000)
+	memDB := storageService.GetShard("db", 1).MemoryDatabase()
+	assert.Nil(t, memDB.Write(models.NewPoint("cpu", now, map[string]string{"host": "1.1.1.1"},
+		map[string]models.Field{"f1": models.NewSimpleField(field.SumField, field.Integer, int64(1))})))
+	admin := NewAdmin(storageService, nil, nil)
+
+	resp, _ := admin.QueryRawPoints(context.TODO(), &common.Request{Data: []byte("err")})
+	assert.NotNil(t, rpc.ResponseToError(resp))
+	data, _ := json.Marshal(&models.RawQueryRequest{Database: "db", MetricName: "cpu"})
+	resp, _ = admin.QueryRawPoints(context.TODO(), &common.Request{Data: data})
+	assert.NotNil(t, rpc.ResponseToError(resp))
+
+	data, _ = json.Marshal(&models.RawQueryRequest{Database: "db", MetricName: "cpu", Fields: []string{"f1"},
+		Start: now - 1000, End: now})
+	resp, _ = admin.QueryRawPoints(context.TODO(), &common.Request{Data: data})
+	assert.Nil(t, rpc.ResponseToError(resp))
+	result := &models.RawQueryResult{}
+	assert.Nil(t, json.Unmarshal(resp.Data, result))
+	assert.Equal(t, &models.RawQueryResult{Series: []models.RawSeries{{
+		ShardID:    1,
+		Tags:       map[string]string{"host": "1.1.1.1"},
+		Fields:     map[string][]models.RawPoint{"f1": {{Timestamp: now, Value: 1}}},
+		FieldTypes: map[string]field.Type{"f1": field.SumField},
+	}}}, result)
+}
+
+func TestAdmin_GetDiskUsage(t *testing.T) {
+	testPath := "test_data"
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	storageService := service.NewStorageService(config.Engine{Path: testPath})
+	shardOption := option.ShardOption{Interval: time.Second * 10, IntervalType: interval.Day}
+	assert.Nil(t, storageService.CreateShards("db1", shardOption, 1))
+	assert.Nil(t, storageService.CreateShards("db2", shardOption, 2))
+	admin := NewAdmin(storageService, nil, nil)
+
+	resp, _ := admin.GetDiskUsage(context.TODO(), &common.Request{Data: []byte("err")})
+	assert.NotNil(t, rpc.ResponseToError(resp))
+
+	// all databases
+	resp, _ = admin.GetDiskUsage(context.TODO(), &common.Request{})
+	assert.Nil(t, rpc.ResponseToError(resp))
+	var usages []models.DatabaseDiskUsage
+	assert.Nil(t, json.Unmarshal(resp.Data, &usages))
+	assert.Equal(t, storageService.DiskUsage(""), usages)
+	assert.Equal(t, 2, len(usages))
+
+	data, _ := json.Marshal(&models.DiskUsageRequest{Database: "db2"})
+	resp, _ = admin.GetDiskUsage(context.TODO(), &common.Request{Data: data})
+	assert.Nil(t, rpc.ResponseToError(resp))
+	usages = nil
+	assert.Nil(t, json.Unmarshal(resp.Data, &usages))
+	assert.Equal(t, 1, len(usages))
+	assert.Equal(t, "db2", usages[0].Database)
+	assert.Equal(t, []models.ShardDiskUsage{{ShardID: 2}}, usages[0].Shards)
+
+	data, _ = json.Marshal(&models.DiskUsageRequest{Database: "not_exist"})
+	resp, _ = admin.GetDiskUsage(context.TODO(), &common.Request{Data: data})
+	assert.Nil(t, rpc.ResponseToError(resp))
+	assert.Equal(t, "[]", string(resp.Data))
+}
+
+func TestAdmin_WarmUp(t *testing.T) {
+	testPath := "test_data"
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	storageService := service.NewStorageService(config.Engine{Path: testPath})
+	shardOption := option.ShardOption{Interval: time.Second * 10, IntervalType: interval.Day}
+	assert.Nil(t, storageService.CreateShards("db", shardOption, 1))
+	admin := NewAdmin(storageService, nil, nil)
+
+	resp, _ := admin.WarmUp(context.TODO(), &common.Request{Data: []byte("err")})
+	assert.NotNil(t, rpc.ResponseToError(resp))
+	data, _ := json.Marshal(&models.WarmUpRequest{})
+	resp, _ = admin.WarmUp(context.TODO(), &common.Request{Data: data})
+	assert.NotNil(t, rpc.ResponseToError(resp))
+
+	data, _ = json.Marshal(&models.WarmUpRequest{Database: "db"})
+	resp, _ = admin.WarmUp(context.TODO(), &common.Request{Data: data})
+	assert.Nil(t, rpc.ResponseToError(resp))
+	var results []models.WarmUpResult
+	assert.Nil(t, json.Unmarshal(resp.Data, &results))
+	assert.Equal(t, []models.WarmUpResult{{Index: true}, {ShardID: 1}}, results)
+
+	data, _ = json.Marshal(&models.WarmUpRequest{Database: "not_exist"})
+	resp, _ = admin.WarmUp(context.TODO(), &common.Request{Data: data})
+	assert.Nil(t, rpc.ResponseToError(resp))
+	assert.Equal(t, "[]", string(resp.Data))
+}
+
+func TestAdmin_ExportFamily(t *testing.T) {
+	testPath := "test_data"
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	storageService := service.NewStorageService(config.Engine{Path: testPath})
+	shardOption := option.ShardOption{Interval: time.Second * 10, IntervalType: interval.Day}
+	assert.Nil(t, storageService.CreateShards("db", shardOption, 1))
+	admin := NewAdmin(storageService, nil, nil)
+
+	resp, _ := admin.ExportFamily(context.TODO(), &common.Request{Data: []byte("err")})
+	assert.NotNil(t, rpc.ResponseToError(resp))
+	data, _ := json.Marshal(&models.FamilyExportRequest{Database: "not_exist"})
+	resp, _ = admin.ExportFamily(context.TODO(), &common.Request{Data: data})
+	assert.NotNil(t, rpc.ResponseToError(resp))
+	// family not found
+	req := models.FamilyExportRequest{Database: "db", ShardID: 1, IntervalType: interval.Day,
+		Segment: "20190702", Family: "10"}
+	data, _ = json.Marshal(&req)
+	resp, _ = admin.ExportFamily(context.TODO(), &common.Request{Data: data})
+	assert.NotNil(t, rpc.ResponseToError(resp))
+
+	intervalSegment, _ := storageService.GetEngine("db").GetShard(1).GetIntervalSegment(interval.Day)
+	segment, _ := intervalSegment.GetOrCreateSegment("20190702")
+	familyTime, _ := segment.FamilyTime("10")
+	_, _ = segment.GetOrCreateFamily(familyTime)
+	resp, _ = admin.ExportFamily(context.TODO(), &common.Request{Data: data})
+	assert.Nil(t, rpc.ResponseToError(resp))
+	result := &models.FamilyExport{}
+	assert.Nil(t, json.Unmarshal(resp.Data, result))
+	assert.Equal(t, &models.FamilyExport{Done: true}, result)
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/pathutil"
+	"github.com/eleme/lindb/pkg/state"
+	"github.com/eleme/lindb/service"
+)
+
+// limitsApplyInterval is the interval of applying the limits to shards,
+// so that the shards created after the limits changed also use the limits
+const limitsApplyInterval = 30 * time.Second
+
+// DatabaseLimits tracks the runtime limits of databases stored in state repository,
+// applies the max tags limits of metrics into the memory-database of database's shards,
+// so that the limits are adjusted cluster-wide by the admin api of broker.
+type DatabaseLimits struct {
+	storageService service.StorageService
+	applyInterval  time.Duration
+
+	mutex  sync.RWMutex
+	limits map[string]map[string]uint32
+
+	logger *logger.Logger
+}
+
+// NewDatabaseLimits creates the database limits tracker
+func NewDatabaseLimits(storageService service.StorageService) *DatabaseLimits {
+	return &DatabaseLimits{
+		storageService: storageService,
+		applyInterval:  limitsApplyInterval,
+		limits:         make(map[string]map[string]uint32),
+		logger:         logger.GetLogger("storage/handler/limits"),
+	}
+}
+
+// Watch watches the limits of databases until the context is done, applies the limits periodically
+func (l *DatabaseLimits) Watch(ctx context.Context, repo state.Repository) {
+	eventCh := repo.WatchPrefix(ctx, pathutil.Keys.Databases.Limits.Prefix())
+	ticker := time.NewTicker(l.applyInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.ApplyAll()
+		case event, ok := <-eventCh:
+			if !ok {
+				return
+			}
+			if event.Err != nil {
+				continue
+			}
+			switch event.Type {
+			case state.EventTypeDelete:
+				for _, kv := range event.KeyValues {
+					l.OnDelete(kv.Key)
+				}
+			case state.EventTypeAll:
+				l.Cleanup()
+				fallthrough
+			case state.EventTypeModify:
+				for _, kv := range event.KeyValues {
+					l.OnCreate(kv.Key, kv.Value)
+				}
+			}
+		}
+	}
+}
+
+// OnCreate updates the limits of database, then applies the limits to the shards of database
+func (l *DatabaseLimits) OnCreate(key string, resource []byte) {
+	limits := models.DatabaseLimits{}
+	if err := json.Unmarshal(resource, &limits); err != nil {
+		l.logger.Error("unmarshal database limits error",
+			logger.String("key", key), logger.Error(err))
+		return
+	}
+	l.Set(pathutil.GetName(key), limits.MaxTagsLimits)
+}
+
+// OnDelete removes the limits of database, the shards of database use the default limits
+func (l *DatabaseLimits) OnDelete(key string) {
+	l.Set(pathutil.GetName(key), nil)
+}
+
+// Cleanup removes the limits of all databases, the shards use the default limits
+func (l *DatabaseLimits) Cleanup() {
+	l.mutex.Lock()
+	databases := make([]string, 0, len(l.limits))
+	for database := range l.limits {
+		databases = append(databases, database)
+	}
+	l.mutex.Unlock()
+	for _, database := range databases {
+		l.Set(database, nil)
+	}
+}
+
+// Set sets the max tags limits of database, then applies the limits to the shards of database,
+// nil limits means using the default limits.
+func (l *DatabaseLimits) Set(database string, maxTagsLimits map[string]uint32) {
+	l.mutex.Lock()
+	if maxTagsLimits == nil {
+		delete(l.limits, database)
+	} else {
+		l.limits[database] = maxTagsLimits
+	}
+	l.mutex.Unlock()
+	l.logger.Info("limits of database changed",
+		logger.String("database", database), logger.Any("maxTagsLimits", maxTagsLimits))
+	l.apply(database, maxTagsLimits)
+}
+
+// Get returns the max tags limits of database, returns false if not set
+func (l *DatabaseLimits) Get(database string) (map[string]uint32, bool) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	maxTagsLimits, ok := l.limits[database]
+	return maxTagsLimits, ok
+}
+
+// ApplyAll applies the limits to the shards of all databases which have limits
+func (l *DatabaseLimits) ApplyAll() {
+	l.mutex.RLock()
+	limits := make(map[string]map[string]uint32, len(l.limits))
+	for database, maxTagsLimits := range l.limits {
+		limits[database] = maxTagsLimits
+	}
+	l.mutex.RUnlock()
+	for database, maxTagsLimits := range limits {
+		l.apply(database, maxTagsLimits)
+	}
+}
+
+// apply applies the max tags limits to the memory-databases(including rollup intervals') of the shards of database
+// in current storage node
+func (l *DatabaseLimits) apply(database string, maxTagsLimits map[string]uint32) {
+	engine := l.storageService.GetEngine(database)
+	if engine == nil {
+		return
+	}
+	for _, shardID := range engine.ShardIDs() {
+		shard := engine.GetShard(shardID)
+		if shard == nil {
+			continue
+		}
+		for _, memDB := range shard.MemoryDatabases() {
+			memDB.SetMaxTagsLimits(maxTagsLimits)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/pkg/interval"
+	"github.com/eleme/lindb/pkg/option"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/pkg/util"
+	"github.com/eleme/lindb/service"
+)
+
+func TestDatabaseLimits(t *testing.T) {
+	testPath := "test_data"
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	storageService := service.NewStorageService(config.Engine{Path: testPath})
+	shardOption := option.ShardOption{Interval: time.Second * 10, IntervalType: interval.Day, TimeWindow: 32}
+	assert.Nil(t, storageService.CreateShards("db", shardOption, 1))
+	write := func(shardID int, host string) error {
+		return storageService.GetShard("db", shardID).MemoryDatabase().Write(
+			models.NewPoint("cpu", timeutil.Now(), map[string]string{"host": host},
+				map[string]models.Field{"f1": models.NewSimpleField(field.SumField, field.Integer, int64(1))}))
+	}
+	limits := NewDatabaseLimits(storageService)
+
+	// limits of database whose engine not exist
+	limits.Set("not_exist", map[string]uint32{"cpu": 1})
+
+	data, _ := json.Marshal(models.DatabaseLimits{Database: "db", MaxTagsLimits: map[string]uint32{"cpu": 1}})
+	limits.OnCreate("/database/limits/db", data)
+	maxTagsLimits, ok := limits.Get("db")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]uint32{"cpu": 1}, maxTagsLimits)
+	assert.Nil(t, write(1, "1.1.1.1"))
+	assert.NotNil(t, write(1, "1.1.1.2"))
+
+	// wrong data
+	limits.OnCreate("/database/limits/db", []byte("err"))
+	_, ok = limits.Get("db")
+	assert.True(t, ok)
+
+	// the shard created later uses the limits after applying
+	assert.Nil(t, storageService.CreateShards("db", shardOption, 2))
+	limits.ApplyAll()
+	assert.Nil(t, write(2, "1.1.1.1"))
+	assert.NotNil(t, write(2, "1.1.1.2"))
+
+	// the default limits are used after removing
+	limits.OnDelete("/database/limits/db")
+	_, ok = limits.Get("db")
+	assert.False(t, ok)
+	assert.Nil(t, write(1, "1.1.1.2"))
+
+	limits.OnCreate("/database/limits/db", data)
+	limits.Cleanup()
+	_, ok = limits.Get("db")
+	assert.False(t, ok)
+	_, ok = limits.Get("not_exist")
+	assert.False(t, ok)
+	assert.Nil(t, write(2, "1.1.1.2"))
+	_ = storageService.GetEngine("db").Close()
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/rpc"
+	"github.com/eleme/lindb/rpc/proto/common"
+	"github.com/eleme/lindb/service"
+)
+
+// Metadata represents the rpc handler for querying metric metadata of storage node
+type Metadata struct {
+	metadataService service.MetadataService
+}
+
+// NewMetadata creates metric metadata rpc handler
+func NewMetadata(metadataService service.MetadataService) *Metadata {
+	return &Metadata{
+		metadataService: metadataService,
+	}
+}
+
+// Suggest suggests metric names/tag keys/tag values based on the suggest request of request data,
+// returns the suggest result as response data
+func (m *Metadata) Suggest(ctx context.Context, request *common.Request) (*common.Response, error) {
+	req := &models.SuggestRequest{}
+	if err := json.Unmarshal(request.Data, req); err != nil {
+		return rpc.ResponseError("unmarshal suggest request error:" + err.Error()), nil
+	}
+	values, err := m.metadataService.Suggest(ctx, req)
+	if err != nil {
+		return rpc.ResponseErr(err), nil
+	}
+	data, err := json.Marshal(&models.SuggestResult{Values: values})
+	if err != nil {
+		return rpc.ResponseError("marshal suggest result error:" + err.Error()), nil
+	}
+	return rpc.ResponseOKWithData(data), nil
+}
+
+// Cardinality returns the HyperLogLog sketches of metric's series and tag values as response data,
+// the response data is empty if metric not exist
+func (m *Metadata) Cardinality(ctx context.Context, request *common.Request) (*common.Response, error) {
+	req := &models.CardinalityRequest{}
+	if err := json.Unmarshal(request.Data, req); err != nil {
+		return rpc.ResponseError("unmarshal cardinality request error:" + err.Error()), nil
+	}
+	sketches, err := m.metadataService.Cardinality(ctx, req)
+	if err != nil {
+		return rpc.ResponseErr(err), nil
+	}
+	if sketches == nil {
+		return rpc.ResponseOK(), nil
+	}
+	data, err := sketches.MarshalBinary()
+	if err != nil {
+		return rpc.ResponseError("marshal cardinality sketches error:" + err.Error()), nil
+	}
+	return rpc.ResponseOKWithData(data), nil
+}
+
+// WriteEvents writes the annotation events of the event write request of request data
+func (m *Metadata) WriteEvents(ctx context.Context, request *common.Request) (*common.Response, error) {
+	req := &models.EventWriteRequest{}
+	if err := json.Unmarshal(request.Data, req); err != nil {
+		return rpc.ResponseError("unmarshal event write request error:" + err.Error()), nil
+	}
+	if err := m.metadataService.WriteEvents(ctx, req); err != nil {
+		return rpc.ResponseErr(err), nil
+	}
+	return rpc.ResponseOK(), nil
+}
+
+// QueryEvents returns the annotation events of the event query of request data as response data
+func (m *Metadata) QueryEvents(ctx context.Context, request *common.Request) (*common.Response, error) {
+	query := &models.EventQuery{}
+	if err := json.Unmarshal(request.Data, query); err != nil {
+		return rpc.ResponseError("unmarshal event query error:" + err.Error()), nil
+	}
+	events, err := m.metadataService.QueryEvents(ctx, query)
+	if err != nil {
+		return rpc.ResponseErr(err), nil
+	}
+	data, err := json.Marshal(&models.EventResult{Events: events})
+	if err != nil {
+		return rpc.ResponseError("marshal event result error:" + err.Error()), nil
+	}
+	return rpc.ResponseOKWithData(data), nil
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/rpc"
+	"github.com/eleme/lindb/rpc/proto/common"
+	"github.com/eleme/lindb/service"
+)
+
+// Query represents the rpc handler for querying metric data of storage node
+type Query struct {
+	queryService service.QueryService
+}
+
+// NewQuery creates query rpc handler
+func NewQuery(queryService service.QueryService) *Query {
+	return &Query{
+		queryService: queryService,
+	}
+}
+
+// Query scans the shards of storage query request in current storage node,
+// returns the partial aggregation result which is marshaled by protobuf as response data
+func (q *Query) Query(ctx context.Context, request *common.Request) (*common.Response, error) {
+	req := &models.StorageQueryRequest{}
+	if err := json.Unmarshal(request.Data, req); err != nil {
+		return rpc.ResponseError("unmarshal query request error:" + err.Error()), nil
+	}
+	result, err := q.queryService.Query(ctx, req)
+	if err != nil {
+		return rpc.ResponseErr(err), nil
+	}
+	data, err := proto.Marshal(result)
+	if err != nil {
+		return rpc.ResponseError("marshal partial result error:" + err.Error()), nil
+	}
+	return rpc.ResponseOKWithData(data), nil
+}
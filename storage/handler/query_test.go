@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/pkg/interval"
+	"github.com/eleme/lindb/pkg/option"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/pkg/util"
+	"github.com/eleme/lindb/rpc"
+	aggregationpb "github.com/eleme/lindb/rpc/proto/aggregation"
+	"github.com/eleme/lindb/rpc/proto/common"
+	"github.com/eleme/lindb/service"
+)
+
+func TestQuery_Query(t *testing.T) {
+	testPath := "test_data"
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	storageService := service.NewStorageService(config.Engine{Path: testPath})
+	assert.Nil(t, storageService.CreateShards("db", option.ShardOption{Interval: time.Second * 10, IntervalType: interval.Day, TimeWindow: 32}, 1))
+	now := timeutil.Now()
+	now -= now % (10 * 1000)
+	memDB := storageService.GetShard("db", 1).MemoryDatabase()
+	assert.Nil(t, memDB.Write(models.NewPoint("cpu", now, map[string]string{"host": "1.1.1.1"},
+		map[string]models.Field{"f1": models.NewSimpleField(field.SumField, field.Integer, int64(1))})))
+	handler := NewQuery(service.NewQueryService(storageService, config.Query{}, nil))
+
+	resp, _ := handler.Query(context.TODO(), &common.Request{Data: []byte("err")})
+	assert.NotNil(t, rpc.ResponseToError(resp))
+	data, _ := json.Marshal(&models.StorageQueryRequest{Database: "db", MetricName: "cpu"})
+	resp, _ = handler.Query(context.TODO(), &common.Request{Data: data})
+	assert.NotNil(t, rpc.ResponseToError(resp))
+
+	data, _ = json.Marshal(&models.StorageQueryRequest{Database: "db", ShardIDs: []int{1, 2}, MetricName: "cpu",
+		Fields: []string{"f1"}, GroupBy: []string{"host"}, Start: now, End: now, Interval: 10 * 1000})
+	resp, _ = handler.Query(context.TODO(), &common.Request{Data: data})
+	assert.Nil(t, rpc.ResponseToError(resp))
+	result := &aggregationpb.PartialResult{}
+	assert.Nil(t, proto.Unmarshal(resp.Data, result))
+	assert.False(t, result.Complete)
+	assert.Equal(t, []int32{2}, result.MissingShards)
+	assert.Len(t, result.Series, 1)
+	assert.Equal(t, []string{"1.1.1.1"}, result.Series[0].TagValues)
+	assert.Equal(t, []int64{1}, result.Series[0].Columns[0].IntValues)
+	_ = storageService.GetEngine("db").Close()
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/pathutil"
+	"github.com/eleme/lindb/pkg/state"
+)
+
+// RoutingEpochs tracks the routing epoch of each database's shard assignment stored in state repository.
+// The shard assignment increases the epoch when the shards are split or reassigned, storage node rejects
+// the writes routed by other epoch, so that brokers refresh the routing instead of writing into the shard
+// which is moved, then the reassignment is rolled out consistently without losing writes.
+type RoutingEpochs struct {
+	mutex  sync.RWMutex
+	epochs map[string]int64
+
+	logger *logger.Logger
+}
+
+// NewRoutingEpochs creates the routing epochs tracker
+func NewRoutingEpochs() *RoutingEpochs {
+	return &RoutingEpochs{
+		epochs: make(map[string]int64),
+		logger: logger.GetLogger("storage/handler/routing"),
+	}
+}
+
+// Watch watches the shard assignments of databases until the context is done
+func (e *RoutingEpochs) Watch(ctx context.Context, repo state.Repository) {
+	eventCh := repo.WatchPrefix(ctx, pathutil.Keys.Databases.Assignments.Prefix())
+	for event := range eventCh {
+		if event.Err != nil {
+			continue
+		}
+		switch event.Type {
+		case state.EventTypeDelete:
+			for _, kv := range event.KeyValues {
+				e.OnDelete(kv.Key)
+			}
+		case state.EventTypeAll:
+			e.Cleanup()
+			fallthrough
+		case state.EventTypeModify:
+			for _, kv := range event.KeyValues {
+				e.OnCreate(kv.Key, kv.Value)
+			}
+		}
+	}
+}
+
+// OnCreate updates the routing epoch of database by the shard assignment
+func (e *RoutingEpochs) OnCreate(key string, resource []byte) {
+	shardAssign := models.ShardAssignment{}
+	if err := json.Unmarshal(resource, &shardAssign); err != nil {
+		e.logger.Error("unmarshal shard assignment error",
+			logger.String("key", key), logger.Error(err))
+		return
+	}
+	e.Set(pathutil.GetName(key), shardAssign.Epoch)
+}
+
+// OnDelete removes the routing epoch of database
+func (e *RoutingEpochs) OnDelete(key string) {
+	e.mutex.Lock()
+	delete(e.epochs, pathutil.GetName(key))
+	e.mutex.Unlock()
+}
+
+// Cleanup removes all routing epochs
+func (e *RoutingEpochs) Cleanup() {
+	e.mutex.Lock()
+	e.epochs = make(map[string]int64)
+	e.mutex.Unlock()
+}
+
+// Set sets the routing epoch of database
+func (e *RoutingEpochs) Set(database string, epoch int64) {
+	e.mutex.Lock()
+	e.epochs[database] = epoch
+	e.mutex.Unlock()
+	e.logger.Info("routing epoch of database changed",
+		logger.String("database", database), logger.Any("epoch", epoch))
+}
+
+// Get returns the routing epoch of database, returns false if the shard assignment isn't received
+func (e *RoutingEpochs) Get(database string) (int64, bool) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	epoch, ok := e.epochs[database]
+	return epoch, ok
+}
+
+// Check checks if the write is routed by the current epoch of database, returns ErrEpochMismatch if not.
+// The write is accepted if the shard assignment of database isn't received yet.
+func (e *RoutingEpochs) Check(database string, epoch int64) error {
+	current, ok := e.Get(database)
+	if !ok || current == epoch {
+		return nil
+	}
+	return errors.Wrapf(errors.ErrEpochMismatch,
+		"write of database[%s] is routed by epoch[%d], current epoch is %d", database, epoch, current)
+}
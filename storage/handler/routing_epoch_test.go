@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/rpc"
+	"github.com/eleme/lindb/rpc/proto/common"
+)
+
+func TestRoutingEpochs(t *testing.T) {
+	epochs := NewRoutingEpochs()
+	// accepts the write before receiving shard assignment
+	assert.Nil(t, epochs.Check("db", 1))
+
+	data, _ := json.Marshal(models.ShardAssignment{Epoch: 2})
+	epochs.OnCreate("/database/assign/db", data)
+	epoch, ok := epochs.Get("db")
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), epoch)
+	assert.Nil(t, epochs.Check("db", 2))
+	assert.True(t, errors.Is(epochs.Check("db", 1), errors.ErrEpochMismatch))
+
+	// wrong data
+	epochs.OnCreate("/database/assign/db", []byte("err"))
+	epoch, _ = epochs.Get("db")
+	assert.Equal(t, int64(2), epoch)
+
+	epochs.OnDelete("/database/assign/db")
+	_, ok = epochs.Get("db")
+	assert.False(t, ok)
+
+	epochs.Set("db", 3)
+	epochs.Cleanup()
+	_, ok = epochs.Get("db")
+	assert.False(t, ok)
+}
+
+func TestWriter_WritePoints_Epoch(t *testing.T) {
+	epochs := NewRoutingEpochs()
+	epochs.Set("db", 2)
+	writer := NewWriter(nil, epochs)
+
+	// the write isn't tagged with epoch, which is rejected after the epoch checked because of the empty data
+	resp, err := writer.WritePoints(context.TODO(), &common.Request{})
+	assert.Nil(t, err)
+	assert.True(t, errors.Is(rpc.ResponseToError(resp), errors.ErrInvalidArgument))
+
+	incoming := func(database string, epoch int64) context.Context {
+		md, _ := metadata.FromOutgoingContext(rpc.WithRoutingEpoch(context.TODO(), database, epoch))
+		return metadata.NewIncomingContext(context.TODO(), md)
+	}
+	resp, _ = writer.WritePoints(incoming("db", 2), &common.Request{})
+	assert.True(t, errors.Is(rpc.ResponseToError(resp), errors.ErrInvalidArgument))
+	resp, _ = writer.WritePoints(incoming("db", 1), &common.Request{})
+	assert.True(t, errors.Is(rpc.ResponseToError(resp), errors.ErrEpochMismatch))
+}
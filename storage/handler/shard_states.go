@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/pathutil"
+	"github.com/eleme/lindb/pkg/state"
+	"github.com/eleme/lindb/service"
+)
+
+// statesApplyInterval is the interval of applying the states to shards,
+// so that the shards created after the states changed also use the states,
+// and the shard failed to change state(e.g. flushing error when frozen) is retried.
+const statesApplyInterval = 30 * time.Second
+
+// ShardStates tracks the states(hot/read-only/frozen) of database's shards stored in state repository,
+// applies the states to the shards of database in current storage node, so that the old shards stop
+// accepting writes or release the memory-databases by the admin api of broker.
+type ShardStates struct {
+	storageService service.StorageService
+	applyInterval  time.Duration
+
+	mutex  sync.RWMutex
+	states map[string]*models.ShardStates
+
+	logger *logger.Logger
+}
+
+// NewShardStates creates the shard states tracker
+func NewShardStates(storageService service.StorageService) *ShardStates {
+	return &ShardStates{
+		storageService: storageService,
+		applyInterval:  statesApplyInterval,
+		states:         make(map[string]*models.ShardStates),
+		logger:         logger.GetLogger("storage/handler/states"),
+	}
+}
+
+// Watch watches the states of database's shards until the context is done, applies the states periodically
+func (s *ShardStates) Watch(ctx context.Context, repo state.Repository) {
+	eventCh := repo.WatchPrefix(ctx, pathutil.Keys.Shards.States.Prefix())
+	ticker := time.NewTicker(s.applyInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.ApplyAll()
+		case event, ok := <-eventCh:
+			if !ok {
+				return
+			}
+			if event.Err != nil {
+				continue
+			}
+			switch event.Type {
+			case state.EventTypeDelete:
+				for _, kv := range event.KeyValues {
+					s.OnDelete(kv.Key)
+				}
+			case state.EventTypeAll:
+				s.Cleanup()
+				fallthrough
+			case state.EventTypeModify:
+				for _, kv := range event.KeyValues {
+					s.OnCreate(kv.Key, kv.Value)
+				}
+			}
+		}
+	}
+}
+
+// OnCreate updates the states of database's shards, then applies the states to the shards of database
+func (s *ShardStates) OnCreate(key string, resource []byte) {
+	states := &models.ShardStates{}
+	if err := json.Unmarshal(resource, states); err != nil {
+		s.logger.Error("unmarshal shard states error",
+			logger.String("key", key), logger.Error(err))
+		return
+	}
+	states.Database = pathutil.GetName(key)
+	s.Set(states)
+}
+
+// OnDelete removes the states of database, the shards of database become hot
+func (s *ShardStates) OnDelete(key string) {
+	s.Set(&models.ShardStates{Database: pathutil.GetName(key)})
+}
+
+// Cleanup removes the states of all databases, all shards become hot
+func (s *ShardStates) Cleanup() {
+	s.mutex.Lock()
+	databases := make([]string, 0, len(s.states))
+	for database := range s.states {
+		databases = append(databases, database)
+	}
+	s.mutex.Unlock()
+	for _, database := range databases {
+		s.Set(&models.ShardStates{Database: database})
+	}
+}
+
+// Set sets the states of database's shards, then applies the states to the shards of database,
+// the states without any shard are removed.
+func (s *ShardStates) Set(states *models.ShardStates) {
+	s.mutex.Lock()
+	if len(states.States) == 0 {
+		delete(s.states, states.Database)
+	} else {
+		s.states[states.Database] = states
+	}
+	s.mutex.Unlock()
+	s.logger.Info("states of shards changed",
+		logger.String("database", states.Database), logger.Any("states", states.States))
+	s.apply(states)
+}
+
+// Get returns the states of database's shards, returns false if not set
+func (s *ShardStates) Get(database string) (*models.ShardStates, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	states, ok := s.states[database]
+	return states, ok
+}
+
+// ApplyAll applies the states to the shards of all databases which have states
+func (s *ShardStates) ApplyAll() {
+	s.mutex.RLock()
+	states := make([]*models.ShardStates, 0, len(s.states))
+	for _, item := range s.states {
+		states = append(states, item)
+	}
+	s.mutex.RUnlock()
+	for _, item := range states {
+		s.apply(item)
+	}
+}
+
+// apply applies the states to the shards of database in current storage node, the shard not in states is hot.
+// The flushing of frozen shard is aborted after the shard closed, so no timeout is needed.
+func (s *ShardStates) apply(states *models.ShardStates) {
+	engine := s.storageService.GetEngine(states.Database)
+	if engine == nil {
+		return
+	}
+	for _, shardID := range engine.ShardIDs() {
+		shard := engine.GetShard(shardID)
+		if shard == nil {
+			continue
+		}
+		if err := shard.SetState(context.TODO(), states.StateOf(shardID)); err != nil {
+			s.logger.Error("change state of shard error", logger.String("database", states.Database),
+				logger.Any("shardID", shardID), logger.Error(err))
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/pkg/interval"
+	"github.com/eleme/lindb/pkg/option"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/pkg/util"
+	"github.com/eleme/lindb/service"
+)
+
+func TestShardStates(t *testing.T) {
+	testPath := "test_data"
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	storageService := service.NewStorageService(config.Engine{Path: testPath})
+	shardOption := option.ShardOption{Interval: time.Second * 10, IntervalType: interval.Day, TimeWindow: 32,
+		Behind: timeutil.OneHour, Ahead: timeutil.OneHour}
+	assert.Nil(t, storageService.CreateShards("db", shardOption, 1, 2))
+	write := func(shardID int) error {
+		return storageService.GetShard("db", shardID).Write(models.NewPoint("cpu", timeutil.Now(), nil,
+			map[string]models.Field{"f1": models.NewSimpleField(field.SumField, field.Integer, int64(1))}))
+	}
+	stateOf := func(shardID int) models.ShardState {
+		return storageService.GetShard("db", shardID).State()
+	}
+	states := NewShardStates(storageService)
+
+	// states of database whose engine not exist
+	states.Set(&models.ShardStates{Database: "not_exist", States: map[int]models.ShardState{1: models.ShardFrozen}})
+
+	data, _ := json.Marshal(models.ShardStates{States: map[int]models.ShardState{1: models.ShardReadOnly}})
+	states.OnCreate("/shard/state/db", data)
+	result, ok := states.Get("db")
+	assert.True(t, ok)
+	assert.Equal(t, models.ShardReadOnly, result.StateOf(1))
+	assert.Equal(t, models.ShardReadOnly, stateOf(1))
+	assert.NotNil(t, write(1))
+	assert.Nil(t, write(2))
+
+	// wrong data
+	states.OnCreate("/shard/state/db", []byte("err"))
+	_, ok = states.Get("db")
+	assert.True(t, ok)
+
+	// the shard created later uses the states after applying
+	data, _ = json.Marshal(models.ShardStates{States: map[int]models.ShardState{3: models.ShardFrozen}})
+	states.OnCreate("/shard/state/db", data)
+	assert.Equal(t, models.ShardHot, stateOf(1))
+	assert.Nil(t, storageService.CreateShards("db", shardOption, 3))
+	assert.Equal(t, models.ShardHot, stateOf(3))
+	states.ApplyAll()
+	assert.Equal(t, models.ShardFrozen, stateOf(3))
+	assert.Nil(t, storageService.GetShard("db", 3).MemoryDatabase())
+
+	// all shards are hot after removing
+	states.OnDelete("/shard/state/db")
+	_, ok = states.Get("db")
+	assert.False(t, ok)
+	assert.Equal(t, models.ShardHot, stateOf(3))
+	assert.Nil(t, write(3))
+
+	states.OnCreate("/shard/state/db", data)
+	states.Cleanup()
+	_, ok = states.Get("db")
+	assert.False(t, ok)
+	_, ok = states.Get("not_exist")
+	assert.False(t, ok)
+	assert.Equal(t, models.ShardHot, stateOf(3))
+	_ = storageService.GetEngine("db").Close()
+}
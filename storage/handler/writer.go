@@ -2,24 +2,99 @@ package handler
 
 import (
 	"context"
+	"sync/atomic"
+	"time"
 
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/tracing"
 	"github.com/eleme/lindb/rpc"
 	"github.com/eleme/lindb/rpc/proto/common"
 	"github.com/eleme/lindb/service"
+	"github.com/eleme/lindb/tsdb"
 )
 
+// Writer represents the rpc handler which applies the writes of shards replicated by brokers
 type Writer struct {
 	storageService service.StorageService
+	routingEpochs  *RoutingEpochs
+	// draining is 1 after the node is prepared for shutdown, the writes are rejected
+	draining int32
 }
 
-func NewWriter(storageService service.StorageService) *Writer {
+// NewWriter creates the write rpc handler, the writes routed by stale routing epoch are rejected
+func NewWriter(storageService service.StorageService, routingEpochs *RoutingEpochs) *Writer {
 	return &Writer{
 		storageService: storageService,
+		routingEpochs:  routingEpochs,
 	}
 }
 
+// WritePoints applies the write of shard into the shard of database's engine, the response is ok only after
+// all points of write are applied, the data of response is the sequence token of shard for read-your-writes.
+// The write marked as backfill is staged then flushed into the historical families of shard before the response.
+// The write is rejected before applying if the node is draining or the write is routed by stale routing,
+// the error of applying(e.g. the shard is read-only) is returned to broker.
 func (w *Writer) WritePoints(ctx context.Context, request *common.Request) (*common.Response, error) {
-	// todo: @XiaTianliang
-	//bs.logger.Info(string(request.Data))
-	return rpc.ResponseOK(), nil
+	if w.IsDraining() {
+		return rpc.ResponseError("storage node is shutting down, write is rejected"), nil
+	}
+	// rejects the write routed by stale shard assignment, broker refreshes the routing then retries
+	if database, epoch, ok := rpc.GetRoutingEpoch(ctx); ok {
+		if err := w.routingEpochs.Check(database, epoch); err != nil {
+			return rpc.ResponseErr(err), nil
+		}
+	}
+	write, err := models.UnmarshalShardWrite(request.Data)
+	if err != nil {
+		return rpc.ResponseErr(errors.Wrapf(errors.ErrInvalidArgument, "%s", err)), nil
+	}
+	engine := w.storageService.GetEngine(write.Database)
+	if engine == nil {
+		return rpc.ResponseErr(errors.Wrapf(errors.ErrDatabaseNotFound, "database[%s]", write.Database)), nil
+	}
+	if err := w.apply(ctx, engine, write, rpc.IsBackfill(ctx)); err != nil {
+		return rpc.ResponseErr(err), nil
+	}
+	token := engine.SequenceToken(write.ShardID)
+	return rpc.ResponseOKWithData([]byte(token.String())), nil
+}
+
+// apply decodes the points of writes, then writes them into the shard of engine, returns the first error,
+// the points of backfill are flushed into the historical families after staged.
+func (w *Writer) apply(ctx context.Context, engine tsdb.Engine, write *models.ShardWrite, backfill bool) error {
+	defer tracing.ObserveStage(tracing.StageStorageApply, time.Now())
+	writePoint := engine.Write
+	if backfill {
+		writePoint = engine.WriteBackfill
+	}
+	for _, data := range write.Writes {
+		points, err := models.DecodePoints(data)
+		if err != nil {
+			return errors.Wrapf(errors.ErrInvalidArgument, "decode points of shard[%d] error:%s", write.ShardID, err)
+		}
+		for _, point := range points {
+			if err := writePoint(write.ShardID, point); err != nil {
+				return errors.Wrapf(err, "write point of metric[%s] into shard[%d] of database[%s] error",
+					point.Name(), write.ShardID, write.Database)
+			}
+		}
+	}
+	if !backfill {
+		return nil
+	}
+	if err := engine.FlushBackfill(ctx, write.ShardID); err != nil {
+		return errors.Wrapf(err, "flush backfill of shard[%d] of database[%s] error", write.ShardID, write.Database)
+	}
+	return nil
+}
+
+// Drain rejects the following writes, so that no more data is written into memory database before shutdown
+func (w *Writer) Drain() {
+	atomic.StoreInt32(&w.draining, 1)
+}
+
+// IsDraining returns if the writes are rejected because of shutdown
+func (w *Writer) IsDraining() bool {
+	return atomic.LoadInt32(&w.draining) == 1
 }
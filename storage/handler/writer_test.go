@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/pkg/interval"
+	"github.com/eleme/lindb/pkg/option"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/pkg/tracing"
+	"github.com/eleme/lindb/pkg/util"
+	"github.com/eleme/lindb/rpc"
+	aggregationpb "github.com/eleme/lindb/rpc/proto/aggregation"
+	"github.com/eleme/lindb/rpc/proto/common"
+	"github.com/eleme/lindb/service"
+)
+
+// newWriteRequest builds the request of shard write which has the points encoded as one write
+func newWriteRequest(t *testing.T, database string, shardID int, points ...models.Point) *common.Request {
+	data, err := models.EncodePoints(points)
+	assert.Nil(t, err)
+	write := &models.ShardWrite{Database: database, ShardID: shardID, Writes: [][]byte{data}}
+	data, err = write.Marshal()
+	assert.Nil(t, err)
+	return &common.Request{Data: data}
+}
+
+// newCPUPoint creates the point of metric cpu with host tag and sum field f1
+func newCPUPoint(host string, timestamp, value int64) models.Point {
+	return models.NewPoint("cpu", timestamp, map[string]string{"host": host},
+		map[string]models.Field{"f1": models.NewSimpleField(field.SumField, field.Integer, value)})
+}
+
+func TestWriter_WritePoints(t *testing.T) {
+	testPath := "test_data"
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	storageService := service.NewStorageService(config.Engine{Path: testPath})
+	shardOption := option.ShardOption{Interval: time.Second * 10, IntervalType: interval.Day, TimeWindow: 32,
+		Behind: timeutil.OneHour, Ahead: timeutil.OneHour}
+	assert.Nil(t, storageService.CreateShards("db", shardOption, 1))
+	defer func() {
+		_ = storageService.GetEngine("db").Close()
+	}()
+	writer := NewWriter(storageService, NewRoutingEpochs())
+	now := timeutil.Now()
+
+	// the points are applied into the memory-database of shard before the response
+	resp, err := writer.WritePoints(context.TODO(), newWriteRequest(t, "db", 1,
+		newCPUPoint("a", now, 1), newCPUPoint("b", now, 2)))
+	assert.Nil(t, err)
+	assert.Nil(t, rpc.ResponseToError(resp))
+	shard := storageService.GetShard("db", 1)
+	assert.Equal(t, int64(2), shard.Sequence())
+	assert.Equal(t, 2, shard.MemoryDatabase().Stats().MutableSeries)
+	token, err := models.ParseSequenceToken(string(resp.Data))
+	assert.Nil(t, err)
+	assert.Equal(t, models.SequenceToken{1: 2}, token)
+
+	// corrupted write
+	resp, _ = writer.WritePoints(context.TODO(), &common.Request{Data: []byte("err")})
+	assert.True(t, errors.Is(rpc.ResponseToError(resp), errors.ErrInvalidArgument))
+	data, _ := (&models.ShardWrite{Database: "db", ShardID: 1, Writes: [][]byte{[]byte("err")}}).Marshal()
+	resp, _ = writer.WritePoints(context.TODO(), &common.Request{Data: data})
+	assert.True(t, errors.Is(rpc.ResponseToError(resp), errors.ErrInvalidArgument))
+	// database or shard not exist
+	resp, _ = writer.WritePoints(context.TODO(), newWriteRequest(t, "not_exist", 1, newCPUPoint("a", now, 1)))
+	assert.True(t, errors.Is(rpc.ResponseToError(resp), errors.ErrDatabaseNotFound))
+	resp, _ = writer.WritePoints(context.TODO(), newWriteRequest(t, "db", 2, newCPUPoint("a", now, 1)))
+	assert.True(t, errors.Is(rpc.ResponseToError(resp), errors.ErrShardNotFound))
+	// the error of applying is returned, e.g. the type of field is changed
+	resp, _ = writer.WritePoints(context.TODO(), newWriteRequest(t, "db", 1,
+		models.NewPoint("cpu", now, map[string]string{"host": "a"},
+			map[string]models.Field{"f1": models.NewSimpleField(field.MaxField, field.Integer, int64(1))})))
+	assert.NotNil(t, rpc.ResponseToError(resp))
+	assert.Equal(t, int64(2), shard.Sequence())
+}
+
+func TestWriter_WritePoints_ReadOnly(t *testing.T) {
+	testPath := "test_data"
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	storageService := service.NewStorageService(config.Engine{Path: testPath})
+	shardOption := option.ShardOption{Interval: time.Second * 10, IntervalType: interval.Day, TimeWindow: 32,
+		Behind: timeutil.OneHour, Ahead: timeutil.OneHour}
+	assert.Nil(t, storageService.CreateShards("db", shardOption, 1))
+	defer func() {
+		_ = storageService.GetEngine("db").Close()
+	}()
+	writer := NewWriter(storageService, NewRoutingEpochs())
+	shard := storageService.GetShard("db", 1)
+	write := newWriteRequest(t, "db", 1, newCPUPoint("a", timeutil.Now(), 1))
+
+	// the writes of read-only or frozen shard are rejected instead of acked
+	for _, state := range []models.ShardState{models.ShardReadOnly, models.ShardFrozen} {
+		assert.Nil(t, shard.SetState(context.TODO(), state))
+		resp, _ := writer.WritePoints(context.TODO(), write)
+		assert.True(t, errors.Is(rpc.ResponseToError(resp), errors.ErrShardReadOnly))
+		assert.Equal(t, int64(0), shard.Sequence())
+	}
+	assert.Nil(t, shard.SetState(context.TODO(), models.ShardHot))
+	resp, _ := writer.WritePoints(context.TODO(), write)
+	assert.Nil(t, rpc.ResponseToError(resp))
+	assert.Equal(t, int64(1), shard.Sequence())
+}
+
+func TestWriter_WritePoints_Backfill(t *testing.T) {
+	testPath := "test_data"
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	storageService := service.NewStorageService(config.Engine{Path: testPath})
+	shardOption := option.ShardOption{Interval: time.Second * 10, IntervalType: interval.Day, TimeWindow: 32,
+		Behind: timeutil.OneHour, Ahead: timeutil.OneHour}
+	assert.Nil(t, storageService.CreateShards("db", shardOption, 1))
+	defer func() {
+		_ = storageService.GetEngine("db").Close()
+	}()
+	writer := NewWriter(storageService, NewRoutingEpochs())
+	query := NewQuery(service.NewQueryService(storageService, config.Query{}, nil))
+	timestamp := timeutil.Now() - 3*timeutil.OneDay
+	timestamp -= timestamp % (10 * 1000)
+	write := newWriteRequest(t, "db", 1, newCPUPoint("a", timestamp, 5))
+	queryValues := func() []int64 {
+		data, _ := json.Marshal(&models.StorageQueryRequest{Database: "db", ShardIDs: []int{1}, MetricName: "cpu",
+			Fields: []string{"f1"}, GroupBy: []string{"host"}, Start: timestamp, End: timestamp, Interval: 10 * 1000})
+		resp, _ := query.Query(context.TODO(), &common.Request{Data: data})
+		assert.Nil(t, rpc.ResponseToError(resp))
+		result := &aggregationpb.PartialResult{}
+		assert.Nil(t, proto.Unmarshal(resp.Data, result))
+		if len(result.Series) == 0 {
+			return nil
+		}
+		return result.Series[0].Columns[0].IntValues
+	}
+
+	// the historical point is dropped by the live write
+	resp, _ := writer.WritePoints(context.TODO(), write)
+	assert.Nil(t, rpc.ResponseToError(resp))
+	assert.Empty(t, queryValues())
+	// the backfill is flushed into the historical family of shard, then is visible to queries
+	md, _ := metadata.FromOutgoingContext(rpc.WithBackfill(context.TODO()))
+	ctx := metadata.NewIncomingContext(context.TODO(), md)
+	resp, _ = writer.WritePoints(ctx, write)
+	assert.Nil(t, rpc.ResponseToError(resp))
+	assert.Equal(t, []int64{5}, queryValues())
+	assert.Equal(t, int64(0), storageService.GetShard("db", 1).Sequence())
+	// the backfill of read-only shard is rejected
+	assert.Nil(t, storageService.GetShard("db", 1).SetState(context.TODO(), models.ShardReadOnly))
+	resp, _ = writer.WritePoints(ctx, write)
+	assert.True(t, errors.Is(rpc.ResponseToError(resp), errors.ErrShardReadOnly))
+	// the backfill of shard not exist
+	resp, _ = writer.WritePoints(ctx, newWriteRequest(t, "db", 2, newCPUPoint("a", timestamp, 5)))
+	assert.True(t, errors.Is(rpc.ResponseToError(resp), errors.ErrShardNotFound))
+}
+
+// stageHistogram returns the sample count and sum of the write stage histogram
+func stageHistogram(t *testing.T, stage string) (count uint64, sum float64) {
+	families, err := prometheus.DefaultGatherer.Gather()
+	assert.Nil(t, err)
+	for _, family := range families {
+		if family.GetName() != "lindb_write_stage_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "stage" && label.GetValue() == stage {
+					return metric.GetHistogram().GetSampleCount(), metric.GetHistogram().GetSampleSum()
+				}
+			}
+		}
+	}
+	return 0, 0
+}
+
+func TestWriter_WritePoints_StageTiming(t *testing.T) {
+	testPath := "test_data"
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	storageService := service.NewStorageService(config.Engine{Path: testPath})
+	shardOption := option.ShardOption{Interval: time.Second * 10, IntervalType: interval.Day, TimeWindow: 32,
+		Behind: timeutil.OneHour, Ahead: timeutil.OneHour}
+	assert.Nil(t, storageService.CreateShards("db", shardOption, 1))
+	defer func() {
+		_ = storageService.GetEngine("db").Close()
+	}()
+	writer := NewWriter(storageService, NewRoutingEpochs())
+
+	applyCount, applySum := stageHistogram(t, tracing.StageStorageApply)
+	memDBCount, memDBSum := stageHistogram(t, tracing.StageMemDBWrite)
+	now := timeutil.Now()
+	var points []models.Point
+	for i := 0; i < 100; i++ {
+		points = append(points, newCPUPoint(strconv.Itoa(i), now, int64(i)))
+	}
+	resp, _ := writer.WritePoints(context.TODO(), newWriteRequest(t, "db", 1, points...))
+	assert.Nil(t, rpc.ResponseToError(resp))
+
+	// the storage apply stage is observed once per write, which includes the memory-database writes of all points
+	count, sum := stageHistogram(t, tracing.StageStorageApply)
+	assert.Equal(t, applyCount+1, count)
+	count, memDBElapsed := stageHistogram(t, tracing.StageMemDBWrite)
+	assert.Equal(t, memDBCount+100, count)
+	assert.True(t, sum-applySum >= memDBElapsed-memDBSum)
+
+	// the write rejected before applying isn't observed
+	resp, _ = writer.WritePoints(context.TODO(), newWriteRequest(t, "not_exist", 1, points...))
+	assert.NotNil(t, rpc.ResponseToError(resp))
+	count, _ = stageHistogram(t, tracing.StageStorageApply)
+	assert.Equal(t, applyCount+1, count)
+}
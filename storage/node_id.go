@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/eleme/lindb/pkg/util"
+)
+
+// nodeIDFile is the file name of node id under the data directory of storage node
+const nodeIDFile = "NODE_ID"
+
+// loadOrCreateNodeID returns the persistent id of storage node stored in the data directory,
+// the id is generated and stored when the node starts first time, so that the node keeps the same id
+// after the ip/port changes(e.g. pod restart), and the shard assignments of node are kept.
+func loadOrCreateNodeID(dir string) (string, error) {
+	path := filepath.Join(dir, nodeIDFile)
+	if util.Exist(path) {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("read node id file[%s] error:%s", path, err)
+		}
+		id := strings.TrimSpace(string(data))
+		if len(id) == 0 {
+			return "", fmt.Errorf("node id file[%s] is empty", path)
+		}
+		return id, nil
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate node id error:%s", err)
+	}
+	id := hex.EncodeToString(b)
+	if err := util.MkDirIfNotExist(dir); err != nil {
+		return "", fmt.Errorf("create data directory[%s] error:%s", dir, err)
+	}
+	if err := ioutil.WriteFile(path, []byte(id), 0644); err != nil {
+		return "", fmt.Errorf("write node id file[%s] error:%s", path, err)
+	}
+	return id, nil
+}
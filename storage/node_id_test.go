@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/pkg/util"
+)
+
+func TestLoadOrCreateNodeID(t *testing.T) {
+	dir := "node_id_test"
+	defer func() {
+		_ = util.RemoveDir(dir)
+	}()
+	id, err := loadOrCreateNodeID(dir)
+	assert.Nil(t, err)
+	assert.Len(t, id, 32)
+	// load the same id after restarting
+	id2, err := loadOrCreateNodeID(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, id, id2)
+
+	// empty id file
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, nodeIDFile), []byte(" \n"), 0644))
+	_, err = loadOrCreateNodeID(dir)
+	assert.NotNil(t, err)
+}
@@ -3,13 +3,19 @@ package storage
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
 
 	"github.com/eleme/lindb/config"
-	"github.com/eleme/lindb/constants"
 	"github.com/eleme/lindb/coordinator/discovery"
 	task "github.com/eleme/lindb/coordinator/storage"
+	"github.com/eleme/lindb/kv"
 	"github.com/eleme/lindb/models"
 	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/pathutil"
+	"github.com/eleme/lindb/pkg/resource"
 	"github.com/eleme/lindb/pkg/server"
 	"github.com/eleme/lindb/pkg/state"
 	"github.com/eleme/lindb/pkg/util"
@@ -17,22 +23,40 @@ import (
 	"github.com/eleme/lindb/rpc/proto/storage"
 	"github.com/eleme/lindb/service"
 	"github.com/eleme/lindb/storage/handler"
+	"github.com/eleme/lindb/tsdb"
 )
 
 const (
 	storageCfgName = "storage.toml"
 	// DefaultStorageCfgFile defines storage default config file path
 	DefaultStorageCfgFile = "./" + storageCfgName
+	// healthCheckTimeout is the timeout of checking the connection of state repository
+	healthCheckTimeout = 2 * time.Second
+	// shardCheckInterval is the interval of checking the data directories of shards
+	shardCheckInterval = 30 * time.Second
+	// diskUsageInterval is the interval of refreshing the disk usage metrics of databases
+	diskUsageInterval = time.Minute
+	// indexFlushInterval is the interval of flushing the new metric, tags and field ids of databases into disk
+	indexFlushInterval = 30 * time.Second
 )
 
 // srv represents all dependency services
 type srv struct {
-	storageService service.StorageService
+	storageService  service.StorageService
+	metadataService service.MetadataService
+	routingEpochs   *handler.RoutingEpochs
+	databaseLimits  *handler.DatabaseLimits
+	shardStates     *handler.ShardStates
+	// statsRecorder is nil if database stats is disabled
+	statsRecorder service.DatabaseStatsRecorder
 }
 
 // rpcHandler represents all dependency rpc handlers
 type rpcHandler struct {
-	writer *handler.Writer
+	writer   *handler.Writer
+	metadata *handler.Metadata
+	admin    *handler.Admin
+	query    *handler.Query
 }
 
 // runtime represents storage runtime dependency
@@ -47,21 +71,34 @@ type runtime struct {
 	node         models.Node
 	server       rpc.TCPServer
 	repo         state.Repository
+	degraded     *state.Degraded
 	registry     discovery.Registry
 	taskExecutor *task.TaskExecutor
+	pprof        *server.PProfServer
+	health       *server.HealthServer
 	srv          srv
+	// flushCoordinator decides when the memory-databases of shards are flushed
+	flushCoordinator tsdb.FlushCoordinator
+	// ready is 1 after the storage node completes startup(recovery/registry), 0 when stopping
+	ready int32
+
+	protocol     *rpc.Protocol
+	interceptors []grpc.UnaryServerInterceptor
 
 	log *logger.Logger
 }
 
-// NewStorageRuntime creates storage runtime
-func NewStorageRuntime(cfgPath string) server.Service {
+// NewStorageRuntime creates storage runtime with the rpc protocol of binary, the interceptors are used by
+// the rpc server of storage, e.g. the interceptors of fault injection for chaos testing
+func NewStorageRuntime(cfgPath string, protocol *rpc.Protocol, interceptors ...grpc.UnaryServerInterceptor) server.Service {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &runtime{
-		state:   server.New,
-		cfgPath: cfgPath,
-		ctx:     ctx,
-		cancel:  cancel,
+		state:        server.New,
+		cfgPath:      cfgPath,
+		ctx:          ctx,
+		cancel:       cancel,
+		protocol:     protocol,
+		interceptors: interceptors,
 
 		log: logger.GetLogger("storage/runtime"),
 	}
@@ -81,6 +118,15 @@ func (r *runtime) Run() error {
 		r.state = server.Failed
 		return fmt.Errorf("decode config file error:%s", err)
 	}
+	if err := logger.InitLogger(r.config.Logging); err != nil {
+		r.state = server.Failed
+		return fmt.Errorf("init logger error:%s", err)
+	}
+	// apply the resource limits of container before building the services which use the budgets
+	r.applyResourceLimits(resource.DetectLimits())
+
+	// start health server first, so that the liveness probe succeeds during recovery
+	r.startHealthServer()
 
 	ip, err := util.GetHostIP()
 	if err != nil {
@@ -90,11 +136,29 @@ func (r *runtime) Run() error {
 
 	// build service dependency for storage server
 	r.buildServiceDependency()
+	// open the engines created before restarted, so that the writes of their shards are applied
+	if err := r.srv.storageService.Load(); err != nil {
+		r.state = server.Failed
+		return fmt.Errorf("load engines error:%s", err)
+	}
+	// the memory-databases of shards are flushed by the coordinator instead of flushing independently,
+	// so that the flushes are staggered by the total memory and disk bandwidth
+	r.flushCoordinator = tsdb.NewFlushCoordinator(r.config.Flush)
+	r.srv.storageService.SetFlushCoordinator(r.flushCoordinator)
+	r.flushCoordinator.Start()
 
-	r.node = models.Node{IP: ip, Port: r.config.Server.Port}
+	nodeID, err := loadOrCreateNodeID(r.config.Engine.Path)
+	if err != nil {
+		r.state = server.Failed
+		return err
+	}
+	r.node = models.Node{ID: nodeID, IP: ip, Port: r.config.Server.Port, Labels: r.config.Labels}
 	// start tcp server
 	r.startTCPServer()
 
+	// start pprof server if enabled
+	r.startPProfServer()
+
 	// start state repo
 	if err := r.startStateRepo(); err != nil {
 		r.state = server.Failed
@@ -103,18 +167,60 @@ func (r *runtime) Run() error {
 
 	// register storage node info
 	//TODO TTL default value???
-	r.registry = discovery.NewRegistry(r.repo, constants.ActiveNodesPath, r.config.Server.TTL)
+	r.registry = discovery.NewRegistry(r.repo, string(pathutil.Keys.Nodes.Active), r.config.Server.TTL)
 	if err := r.registry.Register(r.node); err != nil {
 		return fmt.Errorf("register storage node error:%s", err)
 	}
 
+	// watch the routing epochs of shard assignments, the writes routed by stale epoch are rejected
+	go r.srv.routingEpochs.Watch(r.ctx, r.repo)
+	// watch the runtime limits of databases, e.g. the max tags limits of metrics in memory-database
+	go r.srv.databaseLimits.Watch(r.ctx, r.repo)
+	// watch the states of shards, e.g. the frozen shards release the memory-databases
+	go r.srv.shardStates.Watch(r.ctx, r.repo)
+	// report the database and shard statistics of node, which are aggregated by master of broker,
+	// the hot shards are split by master according to the shard statistics
+	if r.srv.statsRecorder != nil {
+		go service.ReportDatabaseStats(r.ctx, r.node.Key(), r.srv.statsRecorder,
+			service.NewDatabaseStatsService(r.repo), time.Duration(r.config.DatabaseStats.ReportInterval)*time.Millisecond,
+			r.srv.storageService.ShardStats)
+	}
+
+	// report the corrupted files found in engines, then re-replicate the families from healthy replicas
+	r.srv.storageService.SetCorruptionHandler(
+		service.NewCorruptionService(r.ctx, r.node, r.repo, r.srv.storageService).Report)
+
 	r.taskExecutor = task.NewTaskExecutor(r.ctx, &r.node, r.repo, r.srv.storageService)
 	r.taskExecutor.Run()
 
+	// check the data directories of shards in background, the shards on failed disk are marked offline
+	go r.checkShards(shardCheckInterval)
+	// refresh the disk usage metrics of databases in background
+	go r.refreshDiskUsage(diskUsageInterval)
+	// persist the index of databases in background, so that the ids are found after restarted
+	go r.flushIndexes(indexFlushInterval)
+
 	r.state = server.Running
+	atomic.StoreInt32(&r.ready, 1)
 	return nil
 }
 
+// applyResourceLimits adjusts GOMAXPROCS by the cpu quota, and resolves the memory budgets of flush and block cache
+// by the memory limit, so that the containers don't need the hand-tuned absolute settings
+func (r *runtime) applyResourceLimits(limits resource.Limits) {
+	procs := resource.AdjustMaxProcs(limits)
+	flush := &r.config.Flush
+	flush.HighWaterMark = limits.ResolveBytes(flush.HighWaterMark, flush.HighWaterMarkRatio)
+	flush.LowWaterMark = limits.ResolveBytes(flush.LowWaterMark, flush.LowWaterMarkRatio)
+	blockCacheSize := limits.ResolveBytes(r.config.Engine.BlockCacheSize, r.config.Engine.BlockCacheRatio)
+	if blockCacheSize > 0 {
+		kv.SetSharedBlockCacheSize(int(blockCacheSize))
+	}
+	r.log.Info("apply resource limits", logger.Any("cpu", limits.CPU), logger.Any("memory", limits.Memory),
+		logger.Any("gomaxprocs", procs), logger.Any("flushHighWaterMark", flush.HighWaterMark),
+		logger.Any("flushLowWaterMark", flush.LowWaterMark), logger.Any("blockCacheSize", blockCacheSize))
+}
+
 // State returns current storage server state
 func (r *runtime) State() server.State {
 	return r.state
@@ -127,13 +233,25 @@ func (r *runtime) startStateRepo() error {
 		return fmt.Errorf("start storage state repository error:%s", err)
 	}
 	r.repo = repo
+	r.degraded = state.NewDegraded(repo, time.Duration(r.config.Coordinator.DegradedTolerance)*time.Second)
 	r.log.Info("start storage state repository successfully")
+	// join the cluster, the data directory must belong to the cluster
+	clusterInfo, err := service.NewClusterInfoService(repo).Bootstrap(r.config.Coordinator.ClusterID)
+	if err != nil {
+		return fmt.Errorf("bootstrap cluster error:%s", err)
+	}
+	if err := checkClusterID(r.config.Engine.Path, clusterInfo.ID); err != nil {
+		return err
+	}
+	r.log.Info("join cluster successfully", logger.Any("cluster", clusterInfo))
 	return nil
 }
 
 // Stop stops storage server
 func (r *runtime) Stop() error {
 	defer r.cancel()
+	// not ready first, so that orchestrators stop routing requests to this node
+	atomic.StoreInt32(&r.ready, 0)
 
 	if r.taskExecutor != nil {
 		if err := r.taskExecutor.Close(); err != nil {
@@ -148,6 +266,13 @@ func (r *runtime) Stop() error {
 		}
 	}
 
+	if r.pprof != nil {
+		r.log.Info("stopping pprof server")
+		if err := r.pprof.Stop(); err != nil {
+			r.log.Error("stop pprof server error", logger.Error(err))
+		}
+	}
+
 	// close state repo if exist
 	if r.repo != nil {
 		r.log.Info("closing state repo")
@@ -161,6 +286,27 @@ func (r *runtime) Stop() error {
 		r.log.Info("stopping grpc server")
 		r.server.Stop()
 	}
+
+	// stop the flushes of coordinator, the memory-databases are flushed when the engines closed
+	if r.flushCoordinator != nil {
+		r.log.Info("stopping flush coordinator")
+		r.flushCoordinator.Stop()
+	}
+
+	// close the engines after no more writes/queries, so that the engines can be opened after restarted
+	if r.srv.storageService != nil {
+		r.log.Info("closing engines")
+		if err := r.srv.storageService.Close(); err != nil {
+			r.log.Error("close engines error", logger.Error(err))
+		}
+	}
+
+	if r.health != nil {
+		r.log.Info("stopping health server")
+		if err := r.health.Stop(); err != nil {
+			r.log.Error("stop health server error", logger.Error(err))
+		}
+	}
 	r.log.Info("storage server stop complete")
 	r.state = server.Terminated
 	return nil
@@ -168,15 +314,26 @@ func (r *runtime) Stop() error {
 
 // buildServiceDependency builds broker service dependency
 func (r *runtime) buildServiceDependency() {
+	storageService := service.NewStorageService(r.config.Engine)
 	srv := srv{
-		storageService: service.NewStorageService(r.config.Engine),
+		storageService:  storageService,
+		metadataService: service.NewMetadataService(storageService),
+		routingEpochs:   handler.NewRoutingEpochs(),
+		databaseLimits:  handler.NewDatabaseLimits(storageService),
+		shardStates:     handler.NewShardStates(storageService),
+	}
+	if r.config.DatabaseStats.ReportInterval > 0 {
+		srv.statsRecorder = service.NewDatabaseStatsRecorder()
 	}
 	r.srv = srv
 }
 
 // startTCPServer starts tcp server
 func (r *runtime) startTCPServer() {
-	r.server = rpc.NewTCPServer(fmt.Sprintf("%s:%d", r.node.IP, r.node.Port))
+	// the protocol version is negotiated after the other interceptors, e.g. fault injection
+	interceptors := append(r.interceptors, r.protocol.UnaryServerInterceptor())
+	r.server = rpc.NewTCPServer(fmt.Sprintf("%s:%d", r.node.IP, r.node.Port),
+		grpc.UnaryInterceptor(rpc.ChainUnaryServer(interceptors...)))
 
 	// bind rpc handlers
 	r.bindRPCHandlers()
@@ -190,9 +347,104 @@ func (r *runtime) startTCPServer() {
 
 // bindRPCHandlers binds rpc handlers, registers handler into grpc server
 func (r *runtime) bindRPCHandlers() {
+	writer := handler.NewWriter(r.srv.storageService, r.srv.routingEpochs)
 	handlers := rpcHandler{
-		writer: handler.NewWriter(r.srv.storageService),
+		writer:   writer,
+		metadata: handler.NewMetadata(r.srv.metadataService),
+		admin:    handler.NewAdmin(r.srv.storageService, writer, r.srv.statsRecorder),
+		query: handler.NewQuery(service.NewQueryService(r.srv.storageService, r.config.Query,
+			r.srv.statsRecorder)),
 	}
 
 	storage.RegisterWriteServiceServer(r.server.GetServer(), handlers.writer)
+	storage.RegisterMetadataServiceServer(r.server.GetServer(), handlers.metadata)
+	storage.RegisterAdminServiceServer(r.server.GetServer(), handlers.admin)
+	storage.RegisterQueryServiceServer(r.server.GetServer(), handlers.query)
+}
+
+// startPProfServer starts pprof http server for production profiling if enabled
+func (r *runtime) startPProfServer() {
+	if !r.config.PProf.Enabled {
+		return
+	}
+	r.pprof = server.NewPProfServer(fmt.Sprintf(":%d", r.config.PProf.Port))
+	r.pprof.Start()
+}
+
+// startHealthServer starts http server exposing the health of storage node if http port is configured,
+// the storage node is ready after startup completes and state repository is reachable,
+// the storage node keeps ready if state repository is unavailable within the degraded tolerance.
+// NOTICE: the storage node doesn't consume replica log in this version, so the replica lag isn't checked.
+func (r *runtime) startHealthServer() {
+	if r.config.HTTP.Port == 0 {
+		return
+	}
+	health := server.NewHealth()
+	health.AddReadinessCheck("startup", r.checkStartup)
+	health.AddReadinessCheck("etcd", r.checkStateRepo)
+	r.health = server.NewHealthServer(fmt.Sprintf(":%d", r.config.HTTP.Port), health)
+	r.health.Start()
+}
+
+// checkStartup checks whether the storage node completes startup
+func (r *runtime) checkStartup() error {
+	if atomic.LoadInt32(&r.ready) == 0 {
+		return fmt.Errorf("storage node is starting or stopping")
+	}
+	return nil
+}
+
+// checkStateRepo checks whether the state repository is reachable or unavailable within the degraded tolerance
+func (r *runtime) checkStateRepo() error {
+	// state repository is created during startup
+	if err := r.checkStartup(); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(r.ctx, healthCheckTimeout)
+	defer cancel()
+	return r.degraded.Check(ctx)
+}
+
+// refreshDiskUsage refreshes the disk usage metrics of databases periodically until storage stops
+func (r *runtime) refreshDiskUsage(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			service.UpdateDiskUsageMetrics(r.srv.storageService)
+		case <-r.ctx.Done():
+			return
+		}
+	}
+}
+
+// flushIndexes flushes the index of databases periodically until storage stops
+func (r *runtime) flushIndexes(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.srv.storageService.FlushAll(); err != nil {
+				r.log.Error("flush index of databases error", logger.Error(err))
+			}
+		case <-r.ctx.Done():
+			return
+		}
+	}
+}
+
+// checkShards checks the data directories of shards periodically until storage stops
+func (r *runtime) checkShards(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.srv.storageService.CheckShards()
+		case <-r.ctx.Done():
+			return
+		}
+	}
 }
@@ -3,6 +3,9 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"net/http"
+	"os"
+	goruntime "runtime"
 	"testing"
 	"time"
 
@@ -10,15 +13,19 @@ import (
 
 	"github.com/eleme/lindb/config"
 	"github.com/eleme/lindb/constants"
+	"github.com/eleme/lindb/kv"
 	"github.com/eleme/lindb/mock"
 	"github.com/eleme/lindb/models"
 	"github.com/eleme/lindb/pkg/pathutil"
+	"github.com/eleme/lindb/pkg/resource"
 	"github.com/eleme/lindb/pkg/server"
 	"github.com/eleme/lindb/pkg/state"
 	"github.com/eleme/lindb/pkg/util"
+	"github.com/eleme/lindb/rpc"
 )
 
 var storageCfgPath = "./storage.toml"
+var storageDataPath = "./test_data"
 
 type testStorageRuntimeSuite struct {
 	mock.RepoTestSuite
@@ -32,9 +39,10 @@ func TestStorageRuntime(t *testing.T) {
 func (ts *testStorageRuntimeSuite) TestStorageRun(c *check.C) {
 	defer func() {
 		_ = util.RemoveDir(storageCfgPath)
+		_ = util.RemoveDir(storageDataPath)
 	}()
 	// test run fail
-	storage := NewStorageRuntime(storageCfgPath)
+	storage := NewStorageRuntime(storageCfgPath, rpc.DefaultProtocol)
 	err := storage.Run()
 	if err == nil {
 		c.Fail()
@@ -47,13 +55,19 @@ func (ts *testStorageRuntimeSuite) TestStorageRun(c *check.C) {
 			Port: 9999,
 			TTL:  1,
 		},
+		HTTP: config.HTTP{
+			Port: 9998,
+		},
+		Engine: config.Engine{
+			Path: storageDataPath,
+		},
 		Coordinator: state.Config{
 			Namespace: "/test/storage",
 			Endpoints: ts.Cluster.Endpoints,
 		},
 	}
 	_ = util.EncodeToml(storageCfgPath, &cfg)
-	storage = NewStorageRuntime(storageCfgPath)
+	storage = NewStorageRuntime(storageCfgPath, rpc.DefaultProtocol)
 	err = storage.Run()
 	if err != nil {
 		c.Fatal(err)
@@ -63,7 +77,7 @@ func (ts *testStorageRuntimeSuite) TestStorageRun(c *check.C) {
 	time.Sleep(200 * time.Millisecond)
 
 	runtime, _ := storage.(*runtime)
-	nodePath := pathutil.GetNodePath(constants.ActiveNodesPath, runtime.node.String())
+	nodePath := pathutil.GetNodePath(constants.ActiveNodesPath, runtime.node.Key())
 	nodeBytes, err := runtime.repo.Get(context.TODO(), nodePath)
 	if err != nil {
 		c.Fatal(err)
@@ -71,8 +85,46 @@ func (ts *testStorageRuntimeSuite) TestStorageRun(c *check.C) {
 	nodeInfo := models.Node{}
 	_ = json.Unmarshal(nodeBytes, &nodeInfo)
 
-	c.Assert(runtime.node, check.Equals, nodeInfo)
+	c.Assert(runtime.node, check.DeepEquals, nodeInfo)
+	// storage node registers by the persistent id
+	c.Assert(len(nodeInfo.ID) > 0, check.Equals, true)
+
+	// storage node is ready after startup
+	resp, err := http.Get("http://127.0.0.1:9998/health/ready")
+	if err != nil {
+		c.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	c.Assert(resp.StatusCode, check.Equals, http.StatusOK)
+	c.Assert(runtime.checkStartup(), check.IsNil)
 
 	_ = storage.Stop()
 	c.Assert(server.Terminated, check.Equals, storage.State())
+	c.Assert(runtime.checkStartup(), check.NotNil)
+	c.Assert(runtime.checkStateRepo(), check.NotNil)
+
+	// keep the node id after restarting
+	nodeID, err := loadOrCreateNodeID(storageDataPath)
+	c.Assert(err, check.IsNil)
+	c.Assert(nodeID, check.Equals, nodeInfo.ID)
+}
+
+func (ts *testStorageRuntimeSuite) TestApplyResourceLimits(c *check.C) {
+	defer goruntime.GOMAXPROCS(goruntime.GOMAXPROCS(0))
+	defer kv.SetSharedBlockCacheSize(0)
+
+	r := NewStorageRuntime(storageCfgPath, rpc.DefaultProtocol).(*runtime)
+	r.config = config.NewDefaultStorageCfg()
+	// the absolute water marks are used if no memory limit
+	r.applyResourceLimits(resource.Limits{})
+	c.Assert(r.config.Flush.HighWaterMark, check.Equals, int64(2*1024*1024*1024))
+	c.Assert(r.config.Flush.LowWaterMark, check.Equals, int64(1536*1024*1024))
+
+	// the budgets are the ratios of memory limit
+	r.applyResourceLimits(resource.Limits{CPU: 1, Memory: 1024 * 1024 * 1024})
+	c.Assert(r.config.Flush.HighWaterMark, check.Equals, int64(512*1024*1024))
+	c.Assert(r.config.Flush.LowWaterMark, check.Equals, int64(384*1024*1024))
+	if _, ok := os.LookupEnv("GOMAXPROCS"); !ok {
+		c.Assert(goruntime.GOMAXPROCS(0), check.Equals, 1)
+	}
 }
@@ -0,0 +1,20 @@
+package tsdb
+
+import (
+	"context"
+
+	"github.com/eleme/lindb/tsdb/memdb"
+)
+
+// backfillStage stages the historical points of backfill for an interval, the points bypass the behind window
+// of writes and are kept apart from the memory-database of live writes, then flushed into the historical
+// families of interval segment directly.
+type backfillStage struct {
+	memDB   memdb.MemoryDatabase
+	segment IntervalSegment
+}
+
+// flush flushes all families staged into the historical families of interval segment
+func (s *backfillStage) flush(ctx context.Context) error {
+	return flushMemoryDatabase(ctx, s.memDB, s.segment)
+}
@@ -0,0 +1,312 @@
+package tsdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/eleme/lindb/kv/version"
+	"github.com/eleme/lindb/pkg/backup"
+	"github.com/eleme/lindb/pkg/util"
+)
+
+// backupFile represents a file which is uploaded into backup target, the name is the path relative to engine's
+// path, which is the name of backup file, the path is where the file is read from.
+type backupFile struct {
+	name string
+	path string
+}
+
+// storeCheckpoint represents the checkpoint of kv store with the path of store relative to engine's path
+type storeCheckpoint struct {
+	rel        string
+	checkpoint *version.Checkpoint
+}
+
+// Backup uploads the files of engine's metadata(options/index) and given shards into backup target under prefix.
+// The versions of the families of index store and shards' segment stores are pinned atomically in the snapshot
+// barrier, then exactly the sst files of pinned versions are uploaded with the manifest which builds the pinned
+// versions(instead of the manifest of store), so that the backup is a consistent snapshot of flushed data even if
+// the families are flushed or compacted during uploading. The data in memory database isn't included.
+// For incremental backup, base is the files of previous backup, the files of each store in base backup are built
+// from the version edit logs of the manifest in base backup, the sst file of pinned versions which is in base
+// files with the same meta isn't uploaded again, returns the file info of base backup instead.
+func (e *engine) Backup(target backup.Target, prefix string, base backup.PartFiles,
+	shardIDs ...int) ([]backup.FileInfo, error) {
+	checkpoints, err := e.checkpoint(shardIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, c := range checkpoints {
+			c.checkpoint.Release()
+		}
+	}()
+	tmpDir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = util.RemoveDir(tmpDir)
+	}()
+
+	shards := make(map[string]bool)
+	for _, shardID := range shardIDs {
+		shards[strconv.Itoa(shardID)] = true
+	}
+	var metaFiles, dataFiles []backupFile
+	baseFiles := make(map[string]backup.FileInfo)
+	walkFn := func(root string) filepath.WalkFunc {
+		return func(path string, f os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			if f.IsDir() {
+				switch {
+				// skip the shards which not need backup
+				case filepath.Dir(rel) == shardPath && !shards[filepath.Base(rel)]:
+					return filepath.SkipDir
+				// the files of kv stores are uploaded by checkpoints
+				case rel == indexPath || (filepath.Base(rel) == segmentPath && filepath.Dir(filepath.Dir(rel)) == shardPath):
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			name := f.Name()
+			if name != version.Lock && !strings.HasSuffix(name, version.TmpSuffix) {
+				metaFiles = append(metaFiles, backupFile{name: rel, path: path})
+			}
+			return nil
+		}
+	}
+	if err := filepath.Walk(e.path, walkFn(e.path)); err != nil {
+		return nil, fmt.Errorf("list files of engine[%s] error:%s", e.name, err)
+	}
+	// the shards on data directories have the same relative path as under engine's path
+	for _, shardID := range shardIDs {
+		dir, ok := e.info.ShardDirs[strconv.Itoa(shardID)]
+		if !ok {
+			continue
+		}
+		root := filepath.Join(dir, e.name)
+		if err := filepath.Walk(filepath.Join(root, shardPath, strconv.Itoa(shardID)), walkFn(root)); err != nil {
+			return nil, fmt.Errorf("list files of shard[%d] of engine[%s] error:%s", shardID, e.name, err)
+		}
+	}
+	for idx, c := range checkpoints {
+		storePath := c.checkpoint.StorePath()
+		// the OPTIONS of store is read after pinned, which includes all families of checkpoint
+		metaFiles = append(metaFiles, backupFile{
+			name: filepath.Join(c.rel, version.Options),
+			path: filepath.Join(storePath, version.Options),
+		})
+		manifestDir := filepath.Join(tmpDir, strconv.Itoa(idx))
+		if err := util.MkDir(manifestDir); err != nil {
+			return nil, err
+		}
+		names, err := c.checkpoint.WriteManifest(manifestDir)
+		if err != nil {
+			return nil, fmt.Errorf("write manifest of store[%s] of engine[%s] error:%s", c.rel, e.name, err)
+		}
+		for _, name := range names {
+			metaFiles = append(metaFiles, backupFile{
+				name: filepath.Join(c.rel, name),
+				path: filepath.Join(manifestDir, name),
+			})
+		}
+		unchanged, err := e.unchangedFiles(base, c, filepath.Join(manifestDir, "base"))
+		if err != nil {
+			return nil, err
+		}
+		for name, f := range unchanged {
+			baseFiles[name] = f
+		}
+		for _, fileName := range checkpointFiles(c.checkpoint) {
+			dataFiles = append(dataFiles, backupFile{
+				name: filepath.Join(c.rel, fileName),
+				path: filepath.Join(storePath, fileName),
+			})
+		}
+	}
+
+	var files []backup.FileInfo
+	for _, file := range metaFiles {
+		f, err := e.backupFile(target, prefix, file)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	for _, file := range dataFiles {
+		if baseFile, ok := baseFiles[filepath.ToSlash(file.name)]; ok {
+			files = append(files, baseFile)
+			continue
+		}
+		f, err := e.backupFile(target, prefix, file)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// checkpoint pins the versions of index store and the segment stores of shards atomically in the snapshot barrier,
+// the segments are listed in the barrier, so that the segment created by a flush isn't missed.
+func (e *engine) checkpoint(shardIDs []int) ([]storeCheckpoint, error) {
+	shards := make([]Shard, len(shardIDs))
+	for idx, shardID := range shardIDs {
+		shard, err := e.getOnlineShard(shardID)
+		if err != nil {
+			return nil, err
+		}
+		shards[idx] = shard
+	}
+	var checkpoints []storeCheckpoint
+	var err error
+	e.barrier.Acquire(func() {
+		checkpoints = append(checkpoints, storeCheckpoint{rel: indexPath, checkpoint: e.index.Checkpoint()})
+		for idx, shard := range shards {
+			// the segment stores have the same path relative to engine's path under the data directory of shard
+			shardRoot := e.shardPath(shardIDs[idx])
+			shardRel := filepath.Join(shardPath, strconv.Itoa(shardIDs[idx]))
+			for _, intervalType := range shard.IntervalTypes() {
+				intervalSegment, ok := shard.GetIntervalSegment(intervalType)
+				if !ok {
+					continue
+				}
+				for _, segment := range intervalSegment.GetAllSegments() {
+					checkpoint := segment.Checkpoint()
+					checkpoints = append(checkpoints, storeCheckpoint{checkpoint: checkpoint})
+					rel, relErr := filepath.Rel(shardRoot, checkpoint.StorePath())
+					if relErr != nil && err == nil {
+						err = relErr
+					}
+					checkpoints[len(checkpoints)-1].rel = filepath.Join(shardRel, rel)
+				}
+			}
+		}
+	})
+	if err != nil {
+		for _, c := range checkpoints {
+			c.checkpoint.Release()
+		}
+		return nil, err
+	}
+	return checkpoints, nil
+}
+
+// unchangedFiles returns the base file infos of the sst files of checkpoint which are unchanged since base backup
+// keyed by backup file name, the manifest of store in base backup is downloaded into dir for reading the files
+// of base versions. Returns nil if the store doesn't exist in base backup.
+func (e *engine) unchangedFiles(base backup.PartFiles, c storeCheckpoint, dir string) (map[string]backup.FileInfo, error) {
+	if base == nil {
+		return nil, nil
+	}
+	baseFiles := make(map[string]backup.FileInfo)
+	for _, f := range base.Files() {
+		baseFiles[f.Name] = f
+	}
+	currentFile, ok := baseFiles[filepath.ToSlash(filepath.Join(c.rel, version.Current))]
+	if !ok {
+		return nil, nil
+	}
+	manifestName, err := readBaseFile(base, currentFile)
+	if err != nil {
+		return nil, fmt.Errorf("read base backup file[%s] of engine[%s] error:%s", currentFile.Name, e.name, err)
+	}
+	manifestFile, ok := baseFiles[filepath.ToSlash(filepath.Join(c.rel, string(manifestName)))]
+	if !ok {
+		return nil, fmt.Errorf("manifest of store[%s] not exist in base backup of engine[%s]", c.rel, e.name)
+	}
+	manifest, err := readBaseFile(base, manifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("read base backup file[%s] of engine[%s] error:%s", manifestFile.Name, e.name, err)
+	}
+	if err := util.MkDirIfNotExist(dir); err != nil {
+		return nil, err
+	}
+	manifestPath := filepath.Join(dir, string(manifestName))
+	if err := ioutil.WriteFile(manifestPath, manifest, 0644); err != nil {
+		return nil, err
+	}
+	baseVersions, err := version.ReadManifestFiles(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("read base manifest of store[%s] of engine[%s] error:%s", c.rel, e.name, err)
+	}
+	result := make(map[string]backup.FileInfo)
+	for familyName, fileMetas := range c.checkpoint.UnchangedFiles(baseVersions) {
+		for _, fileMeta := range fileMetas {
+			name := filepath.ToSlash(filepath.Join(c.rel, familyName, version.Table(fileMeta.GetFileNumber())))
+			if f, ok := baseFiles[name]; ok {
+				result[name] = f
+			}
+		}
+	}
+	return result, nil
+}
+
+// readBaseFile reads the content of file in base backup
+func readBaseFile(base backup.PartFiles, file backup.FileInfo) ([]byte, error) {
+	reader, err := base.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	return ioutil.ReadAll(reader)
+}
+
+// checkpointFiles returns the paths of sst files of checkpoint relative to the path of store,
+// sorted by family name and file number
+func checkpointFiles(checkpoint *version.Checkpoint) []string {
+	families := checkpoint.Files()
+	familyNames := make([]string, 0, len(families))
+	for familyName := range families {
+		familyNames = append(familyNames, familyName)
+	}
+	sort.Strings(familyNames)
+	var files []string
+	for _, familyName := range familyNames {
+		fileMetas := families[familyName]
+		sort.Slice(fileMetas, func(i, j int) bool {
+			return fileMetas[i].GetFileNumber() < fileMetas[j].GetFileNumber()
+		})
+		for _, fileMeta := range fileMetas {
+			files = append(files, filepath.Join(familyName, version.Table(fileMeta.GetFileNumber())))
+		}
+	}
+	return files
+}
+
+// backupFile uploads the file into backup target
+func (e *engine) backupFile(target backup.Target, prefix string, file backupFile) (backup.FileInfo, error) {
+	name := filepath.ToSlash(file.name)
+	if len(prefix) > 0 {
+		name = prefix + "/" + name
+	}
+	f, err := os.Open(file.path)
+	if err != nil {
+		return backup.FileInfo{}, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	stat, err := f.Stat()
+	if err != nil {
+		return backup.FileInfo{}, err
+	}
+	if err := target.Put(name, f, stat.Size()); err != nil {
+		return backup.FileInfo{}, fmt.Errorf("backup file[%s] of engine[%s] error:%s", file.name, e.name, err)
+	}
+	return backup.FileInfo{Name: filepath.ToSlash(file.name), Size: stat.Size()}, nil
+}
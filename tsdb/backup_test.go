@@ -0,0 +1,195 @@
+package tsdb
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/backup"
+	"github.com/eleme/lindb/pkg/interval"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/pkg/util"
+)
+
+func TestEngine_Backup(t *testing.T) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	e, _ := NewEngine("test_db", filepath.Join(testPath, "data"), nil)
+	_ = e.CreateShards(validOption, 1, 2)
+	defer func() {
+		_ = e.Close()
+	}()
+	timestamp, _ := timeutil.ParseTimestamp("20190702 10:00:00", "20060102 15:04:05")
+	family, familyName := getTestFamily(t, e, timestamp)
+	flushTestBlocks(t, e, family, map[string][]string{"cpu": {"host=a"}, "mem": {"host=a", "host=b"}})
+	assert.Nil(t, e.GetIndex().Flush())
+	sstFile := filepath.ToSlash(filepath.Join("shard", "1", "segment", "day", testSegment, familyName, "000002.sst"))
+
+	target, _ := backup.NewTarget(backup.Config{URL: filepath.Join(testPath, "backup")})
+	files, err := e.Backup(target, "set/test_db", nil, 1)
+	assert.Nil(t, err)
+	assert.True(t, len(files) > 0)
+	// sst files are uploaded after meta files
+	assert.Equal(t, sstFile, files[len(files)-1].Name)
+	for _, f := range files {
+		assert.False(t, strings.HasPrefix(f.Name, "shard/2"))
+		assert.NotEqual(t, "LOCK", filepath.Base(f.Name))
+		assert.True(t, util.Exist(filepath.Join(testPath, "backup", "set", "test_db", f.Name)))
+	}
+	assert.True(t, util.Exist(filepath.Join(testPath, "backup", "set", "test_db", "OPTIONS")))
+	req := models.FamilyExportRequest{Database: "test_db", ShardID: 1, IntervalType: interval.Day,
+		Segment: testSegment, Family: familyName}
+	expect, err := e.ExportFamily(req)
+	assert.Nil(t, err)
+
+	_, err = e.Backup(target, "set/test_db", nil, 10)
+	assert.NotNil(t, err)
+
+	// incremental backup, only uploads the sst files which are changed since base backup
+	sstInfo := files[len(files)-1]
+	catalog := backup.NewCatalog(target)
+	part := backup.Part{Database: "test_db", ShardIDs: []int{1}}
+	assert.Nil(t, catalog.SavePart("set", &backup.PartManifest{Part: part, Files: files}))
+	base, err := catalog.GetPartFiles("set", part)
+	assert.Nil(t, err)
+	flushTestBlocks(t, e, family, map[string][]string{"cpu": {"host=b"}})
+	files, err = e.Backup(target, "set2/test_db", base, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, backup.FileInfo{Name: sstFile, Size: sstInfo.Size, BackupID: "set"}, files[len(files)-2])
+	assert.Empty(t, files[len(files)-1].BackupID)
+	for _, f := range files {
+		// the manifest is uploaded in each backup
+		if strings.HasPrefix(filepath.Base(f.Name), "MANIFEST") {
+			assert.Empty(t, f.BackupID)
+		}
+	}
+	assert.False(t, util.Exist(filepath.Join(testPath, "backup", "set2", "test_db", sstFile)))
+	assert.True(t, util.Exist(filepath.Join(testPath, "backup", "set2", "test_db", files[len(files)-1].Name)))
+	assert.True(t, util.Exist(filepath.Join(testPath, "backup", "set2", "test_db", "OPTIONS")))
+
+	// the backup based on incremental backup reads the manifest of base backup
+	assert.Nil(t, catalog.SavePart("set2", &backup.PartManifest{Part: part, Files: files}))
+	base, err = catalog.GetPartFiles("set2", part)
+	assert.Nil(t, err)
+	files, err = e.Backup(target, "set3/test_db", base, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "set", files[len(files)-2].BackupID)
+	assert.Equal(t, "set2", files[len(files)-1].BackupID)
+
+	// backup fails if the manifest of store in base backup cannot be read
+	_ = target.Delete(filepath.ToSlash(filepath.Join("set3", "test_db", "shard", "1", "segment", "day",
+		testSegment, "MANIFEST-000001")))
+	assert.Nil(t, catalog.SavePart("set3", &backup.PartManifest{Part: part, Files: files}))
+	base, err = catalog.GetPartFiles("set3", part)
+	assert.Nil(t, err)
+	_, err = e.Backup(target, "set4/test_db", base, 1)
+	assert.NotNil(t, err)
+
+	// the engine opened from backup has the data of pinned versions, which changes the manifest of backup
+	restored, err := NewEngine("test_db", filepath.Join(testPath, "backup", "set"), nil)
+	assert.Nil(t, err)
+	result, err := restored.ExportFamily(req)
+	assert.Nil(t, err)
+	assert.Equal(t, expect.Blocks, result.Blocks)
+	_ = restored.Close()
+}
+
+// failTarget fails the upload of sst files
+type failTarget struct {
+	backup.Target
+}
+
+func (t *failTarget) Put(name string, reader io.Reader, size int64) error {
+	if strings.HasSuffix(name, ".sst") {
+		return fmt.Errorf("upload timeout")
+	}
+	return t.Target.Put(name, reader, size)
+}
+
+func TestEngine_Backup_uploadFailure(t *testing.T) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	e, _ := NewEngine("test_db", filepath.Join(testPath, "data"), nil)
+	_ = e.CreateShards(validOption, 1)
+	defer func() {
+		_ = e.Close()
+	}()
+	timestamp, _ := timeutil.ParseTimestamp("20190702 10:00:00", "20060102 15:04:05")
+	family, familyName := getTestFamily(t, e, timestamp)
+	flushTestBlocks(t, e, family, map[string][]string{"cpu": {"host=a"}})
+
+	target, _ := backup.NewTarget(backup.Config{URL: filepath.Join(testPath, "backup")})
+	_, err := e.Backup(&failTarget{Target: target}, "set/test_db", nil, 1)
+	assert.NotNil(t, err)
+	// the versions pinned by backup are released after the upload failed, so that the versions are removed
+	// after new versions installed
+	flushTestBlocks(t, e, family, map[string][]string{"cpu": {"host=b"}})
+	assert.Nil(t, e.GetIndex().Flush())
+	intervalSegment, _ := e.GetShard(1).GetIntervalSegment(interval.Day)
+	seg, _ := intervalSegment.GetOrCreateSegment(testSegment)
+	assert.Equal(t, map[string]int{familyName: 1}, seg.(*segment).kvStore.ActiveVersions())
+	for _, versions := range e.GetIndex().(*engineIndex).store.ActiveVersions() {
+		assert.Equal(t, 1, versions)
+	}
+}
+
+func TestEngine_checkpoint(t *testing.T) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	e, _ := NewEngine("test_db", filepath.Join(testPath, "data"), nil)
+	_ = e.CreateShards(validOption, 1)
+	defer func() {
+		_ = e.Close()
+	}()
+	timestamp, _ := timeutil.ParseTimestamp("20190702 10:00:00", "20060102 15:04:05")
+	family, familyName := getTestFamily(t, e, timestamp)
+	flushTestBlocks(t, e, family, map[string][]string{"cpu": {"host=a"}})
+
+	engine := e.(*engine)
+	checkpoints, err := engine.checkpoint([]int{1})
+	assert.Nil(t, err)
+	assert.Len(t, checkpoints, 2)
+	assert.Equal(t, indexPath, checkpoints[0].rel)
+	assert.Equal(t, filepath.Join("shard", "1", "segment", "day", testSegment), checkpoints[1].rel)
+	// the files flushed after pinned aren't included
+	flushTestBlocks(t, e, family, map[string][]string{"cpu": {"host=b"}})
+	assert.Equal(t, []string{filepath.Join(familyName, "000002.sst")}, checkpointFiles(checkpoints[1].checkpoint))
+	for _, c := range checkpoints {
+		c.checkpoint.Release()
+	}
+
+	_, err = engine.checkpoint([]int{2})
+	assert.NotNil(t, err)
+}
+
+func TestEngine_Backup_dataDirs(t *testing.T) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	dataDir := filepath.Join(testPath, "disk1")
+	e, _ := NewEngine("test_db", filepath.Join(testPath, "data"), NewDataDirs([]string{dataDir}))
+	_ = e.CreateShards(validOption, 1)
+	defer func() {
+		_ = e.Close()
+	}()
+	timestamp, _ := timeutil.ParseTimestamp("20190702 10:00:00", "20060102 15:04:05")
+	family, familyName := getTestFamily(t, e, timestamp)
+	flushTestBlocks(t, e, family, map[string][]string{"cpu": {"host=a"}})
+
+	target, _ := backup.NewTarget(backup.Config{URL: filepath.Join(testPath, "backup")})
+	files, err := e.Backup(target, "set/test_db", nil, 1)
+	assert.Nil(t, err)
+	// the files of shard on data directory have the same name as under engine's path
+	sstFile := filepath.Join("shard", "1", "segment", "day", testSegment, familyName, "000002.sst")
+	assert.Equal(t, filepath.ToSlash(sstFile), files[len(files)-1].Name)
+	assert.True(t, util.Exist(filepath.Join(testPath, "backup", "set", "test_db", sstFile)))
+	assert.True(t, util.Exist(filepath.Join(testPath, "backup", "set", "test_db", "OPTIONS")))
+}
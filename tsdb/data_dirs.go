@@ -0,0 +1,93 @@
+package tsdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/eleme/lindb/pkg/util"
+)
+
+// probeFile is the file written for checking whether the directory is healthy
+const probeFile = ".probe"
+
+// DataDirs represents the data directories of storage node which store the shards(JBOD),
+// each directory is usually on a separate disk, the new shard is placed on the directory with most available space.
+// The failure of a disk only makes the shards on it offline, instead of the whole node.
+type DataDirs interface {
+	// Dirs returns all data directories
+	Dirs() []string
+	// Pick returns the healthy directory with most available space for new shard
+	Pick() (string, error)
+}
+
+// dataDirs implements DataDirs
+type dataDirs struct {
+	dirs []string
+	// availableSpace returns the available space(bytes) of directory's disk
+	availableSpace func(dir string) (uint64, error)
+}
+
+// NewDataDirs creates the data directories
+func NewDataDirs(dirs []string) DataDirs {
+	return &dataDirs{
+		dirs:           dirs,
+		availableSpace: availableSpace,
+	}
+}
+
+// Dirs returns all data directories
+func (d *dataDirs) Dirs() []string {
+	return d.dirs
+}
+
+// Pick returns the healthy directory with most available space for new shard
+func (d *dataDirs) Pick() (string, error) {
+	var (
+		picked string
+		max    uint64
+	)
+	for _, dir := range d.dirs {
+		if err := util.MkDirIfNotExist(dir); err != nil {
+			continue
+		}
+		if err := checkDir(dir); err != nil {
+			continue
+		}
+		space, err := d.availableSpace(dir)
+		if err != nil {
+			continue
+		}
+		if len(picked) == 0 || space > max {
+			picked = dir
+			max = space
+		}
+	}
+	if len(picked) == 0 {
+		return "", fmt.Errorf("there is no healthy data directory in %v", d.dirs)
+	}
+	return picked, nil
+}
+
+// checkDir checks whether the directory is healthy by writing and removing a probe file
+func checkDir(dir string) error {
+	path := filepath.Join(dir, probeFile)
+	if err := ioutil.WriteFile(path, []byte(probeFile), 0644); err != nil {
+		return fmt.Errorf("write probe file of directory[%s] error:%s", dir, err)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("remove probe file of directory[%s] error:%s", dir, err)
+	}
+	return nil
+}
+
+// availableSpace returns the available space(bytes) of directory's disk
+func availableSpace(dir string) (uint64, error) {
+	stat := syscall.Statfs_t{}
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
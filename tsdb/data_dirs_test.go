@@ -0,0 +1,51 @@
+package tsdb
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/pkg/util"
+)
+
+func TestDataDirs_Pick(t *testing.T) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	dir1 := filepath.Join(testPath, "disk1")
+	dir2 := filepath.Join(testPath, "disk2")
+	dirs := NewDataDirs([]string{dir1, dir2})
+	assert.Equal(t, []string{dir1, dir2}, dirs.Dirs())
+	dir, err := dirs.Pick()
+	assert.Nil(t, err)
+	assert.Equal(t, dir1, dir)
+
+	space := map[string]uint64{dir1: 10, dir2: 20}
+	dirs.(*dataDirs).availableSpace = func(dir string) (uint64, error) {
+		if s, ok := space[dir]; ok {
+			return s, nil
+		}
+		return 0, fmt.Errorf("err")
+	}
+	// the directory with most available space
+	dir, err = dirs.Pick()
+	assert.Nil(t, err)
+	assert.Equal(t, dir2, dir)
+	delete(space, dir2)
+	dir, err = dirs.Pick()
+	assert.Nil(t, err)
+	assert.Equal(t, dir1, dir)
+
+	// no healthy directory
+	delete(space, dir1)
+	_, err = dirs.Pick()
+	assert.NotNil(t, err)
+	_, err = NewDataDirs([]string{filepath.Join("/proc", "lindb")}).Pick()
+	assert.NotNil(t, err)
+
+	available, err := availableSpace(testPath)
+	assert.Nil(t, err)
+	assert.True(t, available > 0)
+}
@@ -1,10 +1,19 @@
 package tsdb
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"sync"
 
+	"github.com/eleme/lindb/kv/version"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/backup"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/interval"
+	"github.com/eleme/lindb/pkg/logger"
 	"github.com/eleme/lindb/pkg/option"
 	"github.com/eleme/lindb/pkg/util"
 )
@@ -14,7 +23,13 @@ const shardPath = "shard"
 
 //go:generate mockgen -source ./engine.go -destination=./engine_mock.go -package tsdb
 
-// Engine represents a time series storage engine
+// CorruptionHandler handles the corrupted file found in the kv families of engine, the file has been marked bad
+// in family version, so that it's skipped by reads.
+type CorruptionHandler func(corruption models.Corruption)
+
+// Engine represents a time series storage engine of database.
+// The default engine is based on memory database and kv store, the alternative engine can be plugged by
+// registering the engine factory with engine type, which is chosen by the shard option of database.
 type Engine interface {
 	// Name returns tsdb engine's name, engine's name is database's name for user
 	Name() string
@@ -24,6 +39,49 @@ type Engine interface {
 	CreateShards(option option.ShardOption, shardIDs ...int) error
 	// GetShard returns shard by given shard id, if not exist returns nil
 	GetShard(shardID int) Shard
+	// ShardIDs returns the sorted ids of online shards
+	ShardIDs() []int
+	// GetIndex returns the metadata index of engine
+	GetIndex() Index
+	// Write writes the metric-point into the shard
+	Write(shardID int, point models.Point) error
+	// WriteBackfill writes the historical metric-point into the backfill staging of the shard
+	WriteBackfill(shardID int, point models.Point) error
+	// FlushBackfill flushes the points staged by backfill of the shard into the historical families
+	FlushBackfill(ctx context.Context, shardID int) error
+	// SequenceToken returns the sequences of shards after the writes applied, which is returned to the client
+	// for read-your-writes, the shard not exist is skipped
+	SequenceToken(shardIDs ...int) models.SequenceToken
+	// Scan returns the segments of shard which store the data of interval type in time range
+	Scan(shardID int, intervalType interval.Type, timeRange models.TimeRange) ([]Segment, error)
+	// GetSnapshot acquires the snapshot of the data families of shard's segments which store the data of interval type
+	// in time range, with the index families of engine atomically, the snapshot must be closed after the query
+	GetSnapshot(shardID int, intervalType interval.Type, timeRange models.TimeRange) (Snapshot, error)
+	// Flush flushes the in-memory data of engine into disk
+	Flush() error
+	// CheckShards checks the data directories of shards, marks the shards on failed directory offline
+	CheckShards()
+	// OfflineShards returns the offline shards with the errors, e.g. the disk of shard's data directory fails
+	OfflineShards() map[int]error
+	// DiskUsage returns the disk usage of engine's metadata index and online shards
+	DiskUsage() models.DatabaseDiskUsage
+	// Drop closes engine, then removes all data of engine
+	Drop() error
+	// Backup uploads the files of engine's metadata and given shards into backup target under prefix,
+	// the sst files which are unchanged since base backup aren't uploaded again
+	Backup(target backup.Target, prefix string, base backup.PartFiles, shardIDs ...int) ([]backup.FileInfo, error)
+	// ExportFamily exports a page of the metric blocks in the data family of shard's segment, the metrics, series
+	// and fields are referenced by name, so that the data can be imported into the replica with different ids
+	ExportFamily(req models.FamilyExportRequest) (*models.FamilyExport, error)
+	// RepairFamily replaces the files of the data family which has corrupted file by the data exported from
+	// healthy replica, the metrics, series and fields are mapped into the ids of engine's index
+	RepairFamily(ctx context.Context, corruption models.Corruption, exporter FamilyExporter) error
+	// MigrateSeries moves the data of the series whose hash is in the range from the shard into new shard,
+	// which is invoked after the shard split, the hash of series is computed by the routing tags
+	MigrateSeries(ctx context.Context, shardID, newShardID int, hashRange models.HashRange, routingTags []string) error
+	// SetCorruptionHandler sets the handler which is invoked when a file of the index or shards is found corrupted,
+	// the handler is also set to the shards created later
+	SetCorruptionHandler(handler CorruptionHandler)
 	// Close closed engine then release resource
 	Close() error
 }
@@ -32,22 +90,34 @@ type Engine interface {
 type info struct {
 	ShardIDs    []int              `toml:"shardIds"`
 	ShardOption option.ShardOption `toml:"shardOption"`
+	// ShardDirs are the data directories of shards keyed by shard id, the shard not in it is under engine's path
+	ShardDirs map[string]string `toml:"shardDirs"`
 }
 
 // engine implements Engine for storing shards, each shard represents a time series storage
 type engine struct {
-	name   string
-	path   string
-	shards sync.Map
-	info   *info
+	name     string
+	path     string
+	dataDirs DataDirs
+	shards   sync.Map
+	// offlineShards are the shards which cannot be accessed, keyed by shard id with the error
+	offlineShards sync.Map
+	info          *info
+	index         Index
+	// barrier is shared by the stores of index and segments, so that the snapshots of them are acquired atomically
+	barrier *version.Barrier
+	// corruptionHandler is set to the shards created later, protected by mutex
+	corruptionHandler CorruptionHandler
 
 	numOfShards int
 
-	mutex sync.Mutex
+	mutex  sync.Mutex
+	logger *logger.Logger
 }
 
-// NewEngine creates engine instance if create engine's path successfully
-func NewEngine(name string, path string) (Engine, error) {
+// NewEngine creates engine instance if create engine's path successfully,
+// the new shards are placed on data directories if not nil, otherwise under engine's path.
+func NewEngine(name string, path string, dataDirs DataDirs) (Engine, error) {
 	enginePath := filepath.Join(path, name)
 	// create engine path
 	if err := util.MkDirIfNotExist(enginePath); err != nil {
@@ -60,16 +130,38 @@ func NewEngine(name string, path string) (Engine, error) {
 			return nil, fmt.Errorf("load engine option from file[%s] error:%s", infoPath, err)
 		}
 	}
+	barrier := version.NewBarrier()
+	idx, err := newIndex(enginePath, barrier)
+	if err != nil {
+		return nil, fmt.Errorf("create index for engine[%s] error:%s", name, err)
+	}
 	e := &engine{
-		name: name,
-		path: enginePath,
-		info: info,
+		name:     name,
+		path:     enginePath,
+		dataDirs: dataDirs,
+		info:     info,
+		index:    idx,
+		barrier:  barrier,
+		logger:   logger.GetLogger("tsdb/engine"),
 	}
 	// load shards if engine is exist
 	if len(e.info.ShardIDs) > 0 {
 		for _, shardID := range e.info.ShardIDs {
-			shard, err := newShard(shardID, filepath.Join(enginePath, shardPath, string(shardID)), info.ShardOption)
+			dir, onDataDir := e.info.ShardDirs[strconv.Itoa(shardID)]
+			if onDataDir {
+				// the failure of data directory only makes the shards on it offline
+				if err := checkDir(dir); err != nil {
+					e.markOffline(shardID, err)
+					continue
+				}
+			}
+			shard, err := newShard(name, shardID, e.shardPath(shardID), info.ShardOption, idx.GetIDGenerator(), barrier)
 			if err != nil {
+				if onDataDir {
+					e.markOffline(shardID, err)
+					continue
+				}
+				_ = idx.Close()
 				return nil, fmt.Errorf("cannot create shard[%d] for engine[%s] error:%s", shardID, name, err)
 			}
 			e.shards.Store(shardID, shard)
@@ -103,14 +195,31 @@ func (e *engine) CreateShards(option option.ShardOption, shardIDs ...int) error
 			// double check
 			shard = e.GetShard(shardID)
 			if shard == nil {
+				// using new shard option
+				newInfo := &info{ShardOption: option, ShardIDs: e.info.ShardIDs, ShardDirs: e.info.ShardDirs}
+				if e.dataDirs != nil {
+					// place new shard on the data directory with most available space
+					dir, err := e.dataDirs.Pick()
+					if err != nil {
+						e.mutex.Unlock()
+						return fmt.Errorf("pick data directory of shard[%d] for engine[%s] error:%s", shardID, e.name, err)
+					}
+					newInfo.ShardDirs = make(map[string]string, len(e.info.ShardDirs)+1)
+					for id, shardDir := range e.info.ShardDirs {
+						newInfo.ShardDirs[id] = shardDir
+					}
+					newInfo.ShardDirs[strconv.Itoa(shardID)] = dir
+				}
 				// new shard
-				shard, err := newShard(shardID, filepath.Join(e.path, shardPath, fmt.Sprintf("%d", shardID)), option)
+				shard, err := newShard(e.name, shardID, shardPathOf(e.path, e.name, shardID, newInfo),
+					option, e.index.GetIDGenerator(), e.barrier)
 				if err != nil {
 					e.mutex.Unlock()
 					return fmt.Errorf("cannot create shard[%d] for engine[%s] error:%s", shardID, e.name, err)
 				}
-				// using new shard option
-				newInfo := &info{ShardOption: option, ShardIDs: e.info.ShardIDs}
+				if e.corruptionHandler != nil {
+					shard.SetCorruptionHandler(e.corruptionHandler)
+				}
 				// add new shard id
 				newInfo.ShardIDs = append(newInfo.ShardIDs, shardID)
 				if err := e.dumpEningeInfo(newInfo); err != nil {
@@ -136,12 +245,230 @@ func (e *engine) GetShard(shardID int) Shard {
 	return nil
 }
 
-// Close closed engine then release resource
-func (e *engine) Close() error {
-	//TODO impl close logic
+// ShardIDs returns the sorted ids of online shards
+func (e *engine) ShardIDs() []int {
+	var shardIDs []int
+	e.shards.Range(func(key, value interface{}) bool {
+		shardIDs = append(shardIDs, key.(int))
+		return true
+	})
+	sort.Ints(shardIDs)
+	return shardIDs
+}
+
+// GetIndex returns the metadata index of engine
+func (e *engine) GetIndex() Index {
+	return e.index
+}
+
+// Write writes the metric-point into the shard
+func (e *engine) Write(shardID int, point models.Point) error {
+	shard, err := e.getOnlineShard(shardID)
+	if err != nil {
+		return err
+	}
+	return shard.Write(point)
+}
+
+// WriteBackfill writes the historical metric-point into the backfill staging of the shard
+func (e *engine) WriteBackfill(shardID int, point models.Point) error {
+	shard, err := e.getOnlineShard(shardID)
+	if err != nil {
+		return err
+	}
+	return shard.WriteBackfill(point)
+}
+
+// FlushBackfill flushes the points staged by backfill of the shard into the historical families
+func (e *engine) FlushBackfill(ctx context.Context, shardID int) error {
+	shard, err := e.getOnlineShard(shardID)
+	if err != nil {
+		return err
+	}
+	return shard.FlushBackfill(ctx)
+}
+
+// SequenceToken returns the sequences of shards after the writes applied, the shard not exist is skipped
+func (e *engine) SequenceToken(shardIDs ...int) models.SequenceToken {
+	token := make(models.SequenceToken)
+	for _, shardID := range shardIDs {
+		if shard := e.GetShard(shardID); shard != nil {
+			token[shardID] = shard.Sequence()
+		}
+	}
+	return token
+}
+
+// Scan returns the segments of shard which store the data of interval type in time range
+func (e *engine) Scan(shardID int, intervalType interval.Type, timeRange models.TimeRange) ([]Segment, error) {
+	shard, err := e.getOnlineShard(shardID)
+	if err != nil {
+		return nil, err
+	}
+	return shard.GetSegments(intervalType, timeRange), nil
+}
+
+// GetSnapshot acquires the snapshot of the data families of shard's segments with the index families atomically
+func (e *engine) GetSnapshot(shardID int, intervalType interval.Type, timeRange models.TimeRange) (Snapshot, error) {
+	shard, err := e.getOnlineShard(shardID)
+	if err != nil {
+		return nil, err
+	}
+	return newSnapshot(e.barrier, e.index, shard.GetSegments(intervalType, timeRange)), nil
+}
+
+// Flush flushes the metadata index into kv store, then compacts the tags index.
+// NOTICE: the data of memory database isn't flushed into the families of segment in this version.
+func (e *engine) Flush() error {
+	if err := e.index.Flush(); err != nil {
+		return fmt.Errorf("flush index of engine[%s] error:%s", e.name, err)
+	}
+	if err := e.index.Compact(); err != nil {
+		return fmt.Errorf("compact index of engine[%s] error:%s", e.name, err)
+	}
+	return nil
+}
+
+// DiskUsage returns the disk usage of engine's metadata index and online shards
+func (e *engine) DiskUsage() models.DatabaseDiskUsage {
+	usage := models.DatabaseDiskUsage{
+		Database: e.name,
+		Index:    e.index.DiskUsage(),
+	}
+	for _, family := range usage.Index {
+		usage.IndexBytes += family.Bytes
+	}
+	usage.Bytes = usage.IndexBytes
+	for _, shardID := range e.ShardIDs() {
+		shard := e.GetShard(shardID)
+		if shard == nil {
+			continue
+		}
+		shardUsage := shard.DiskUsage()
+		usage.Bytes += shardUsage.Bytes
+		usage.Shards = append(usage.Shards, shardUsage)
+	}
+	return usage
+}
+
+// Drop closes engine, then removes all data of engine
+func (e *engine) Drop() error {
+	if err := e.Close(); err != nil {
+		return err
+	}
+	if err := util.RemoveDir(e.path); err != nil {
+		return fmt.Errorf("remove path of engine[%s] error:%s", e.name, err)
+	}
+	for _, dir := range e.info.ShardDirs {
+		if err := util.RemoveDir(filepath.Join(dir, e.name)); err != nil {
+			return fmt.Errorf("remove data directory[%s] of engine[%s] error:%s", dir, e.name, err)
+		}
+	}
 	return nil
 }
 
+// SetCorruptionHandler sets the handler which is invoked when a file of the index or shards is found corrupted,
+// the database is filled into the corruption
+func (e *engine) SetCorruptionHandler(handler CorruptionHandler) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.corruptionHandler = func(corruption models.Corruption) {
+		corruption.Database = e.name
+		handler(corruption)
+	}
+	e.index.SetCorruptionHandler(e.corruptionHandler)
+	e.shards.Range(func(key, value interface{}) bool {
+		shard, ok := value.(Shard)
+		if ok {
+			shard.SetCorruptionHandler(e.corruptionHandler)
+		}
+		return true
+	})
+}
+
+// Close closed engine then release resource, closes all shards and index
+func (e *engine) Close() error {
+	e.shards.Range(func(key, value interface{}) bool {
+		if shard, ok := value.(Shard); ok {
+			shard.Close()
+		}
+		return true
+	})
+	return e.index.Close()
+}
+
+// CheckShards checks the data directories of shards, marks the shards on failed directory offline,
+// the offline shards are closed, which cannot be written or queried.
+func (e *engine) CheckShards() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	failedDirs := make(map[string]error)
+	for id, dir := range e.info.ShardDirs {
+		shardID, err := strconv.Atoi(id)
+		if err != nil || e.GetShard(shardID) == nil {
+			continue
+		}
+		checkErr, checked := failedDirs[dir]
+		if !checked {
+			checkErr = checkDir(dir)
+			failedDirs[dir] = checkErr
+		}
+		if checkErr == nil {
+			continue
+		}
+		if shard, ok := e.shards.Load(shardID); ok {
+			e.shards.Delete(shardID)
+			e.numOfShards--
+			shard.(Shard).Close()
+		}
+		e.markOffline(shardID, checkErr)
+	}
+}
+
+// OfflineShards returns the offline shards with the errors, e.g. the disk of shard's data directory fails
+func (e *engine) OfflineShards() map[int]error {
+	result := make(map[int]error)
+	e.offlineShards.Range(func(key, value interface{}) bool {
+		result[key.(int)] = value.(error)
+		return true
+	})
+	return result
+}
+
+// markOffline marks the shard offline because of the error
+func (e *engine) markOffline(shardID int, err error) {
+	e.offlineShards.Store(shardID, err)
+	e.logger.Error("shard is offline", logger.String("engine", e.name),
+		logger.Any("shard", shardID), logger.Error(err))
+}
+
+// getOnlineShard returns the shard, returns the error if shard is offline or not exist
+func (e *engine) getOnlineShard(shardID int) (Shard, error) {
+	if shard := e.GetShard(shardID); shard != nil {
+		return shard, nil
+	}
+	if err, ok := e.offlineShards.Load(shardID); ok {
+		return nil, errors.Wrapf(errors.ErrShardOffline, "shard[%d] of engine[%s]:%s", shardID, e.name, err)
+	}
+	return nil, errors.Wrapf(errors.ErrShardNotFound, "shard[%d] of engine[%s]", shardID, e.name)
+}
+
+// shardPath returns the path of shard
+func (e *engine) shardPath(shardID int) string {
+	return shardPathOf(e.path, e.name, shardID, e.info)
+}
+
+// shardPathOf returns the path of shard, which is under the data directory of shard if exists,
+// otherwise under engine's path.
+func shardPathOf(enginePath, name string, shardID int, info *info) string {
+	id := strconv.Itoa(shardID)
+	if dir, ok := info.ShardDirs[id]; ok {
+		return filepath.Join(dir, name, shardPath, id)
+	}
+	return filepath.Join(enginePath, shardPath, id)
+}
+
 // dumpEningeInfo persists option info to OPTIONS file
 func (e *engine) dumpEningeInfo(newInfo *info) error {
 	infoPath := infoPath(e.path)
@@ -0,0 +1,61 @@
+package tsdb
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/eleme/lindb/pkg/util"
+)
+
+// DefaultEngineType is the type of default engine based on memory database and kv store
+const DefaultEngineType = "tsdb"
+
+// EngineFactory creates the engine of database under the path, the shards are placed on data directories if not nil
+type EngineFactory func(name string, path string, dataDirs DataDirs) (Engine, error)
+
+var (
+	engineFactories = map[string]EngineFactory{DefaultEngineType: NewEngine}
+	factoryMutex    sync.RWMutex
+)
+
+// RegisterEngine registers the factory of engine type, so that the engine can be chosen by database,
+// e.g. a read-only archive engine over object storage. The factory of same engine type is replaced.
+func RegisterEngine(engineType string, factory EngineFactory) {
+	factoryMutex.Lock()
+	defer factoryMutex.Unlock()
+	engineFactories[engineType] = factory
+}
+
+// NewEngineByType creates the engine of database by the factory of engine type,
+// creates the default engine if engine type is empty.
+func NewEngineByType(engineType, name, path string, dataDirs DataDirs) (Engine, error) {
+	if len(engineType) == 0 {
+		engineType = DefaultEngineType
+	}
+	factoryMutex.RLock()
+	factory, ok := engineFactories[engineType]
+	factoryMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("engine type[%s] of database[%s] not register", engineType, name)
+	}
+	return factory(name, path, dataDirs)
+}
+
+// OpenEngine opens the existing engine of database under the path by the factory of engine type
+// which is recorded in the engine's info, returns false if the engine doesn't exist.
+func OpenEngine(name, path string, dataDirs DataDirs) (Engine, bool, error) {
+	infoPath := infoPath(filepath.Join(path, name))
+	if !util.Exist(infoPath) {
+		return nil, false, nil
+	}
+	info := &info{}
+	if err := util.DecodeToml(infoPath, info); err != nil {
+		return nil, true, fmt.Errorf("load engine option from file[%s] error:%s", infoPath, err)
+	}
+	engine, err := NewEngineByType(info.ShardOption.EngineType, name, path, dataDirs)
+	if err != nil {
+		return nil, true, err
+	}
+	return engine, true, nil
+}
@@ -0,0 +1,77 @@
+package tsdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/pkg/option"
+	"github.com/eleme/lindb/pkg/util"
+)
+
+func TestNewEngineByType(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	defer util.RemoveDir(testPath)
+
+	// default engine
+	engine, err := NewEngineByType("", "test_db", testPath, nil)
+	assert.Nil(t, err)
+	assert.True(t, util.Exist(filepath.Join(testPath, "test_db")))
+	assert.Nil(t, engine.Close())
+
+	_, err = NewEngineByType("archive", "test_db", testPath, nil)
+	assert.NotNil(t, err)
+
+	archive := NewMockEngine(ctrl)
+	RegisterEngine("archive", func(name string, path string, dataDirs DataDirs) (Engine, error) {
+		if name == "err_db" {
+			return nil, fmt.Errorf("err")
+		}
+		return archive, nil
+	})
+	defer func() {
+		factoryMutex.Lock()
+		delete(engineFactories, "archive")
+		factoryMutex.Unlock()
+	}()
+	engine, err = NewEngineByType("archive", "test_db", testPath, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, archive, engine)
+	_, err = NewEngineByType("archive", "err_db", testPath, nil)
+	assert.NotNil(t, err)
+}
+
+func TestOpenEngine(t *testing.T) {
+	defer util.RemoveDir(testPath)
+
+	_, ok, err := OpenEngine("test_db", testPath, nil)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	engine, err := NewEngine("test_db", testPath, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, engine.CreateShards(validOption, 1))
+	assert.Nil(t, engine.Close())
+	engine, ok, err = OpenEngine("test_db", testPath, nil)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []int{1}, engine.ShardIDs())
+	assert.Nil(t, engine.Close())
+
+	// engine type not register
+	assert.Nil(t, util.EncodeToml(infoPath(filepath.Join(testPath, "test_db")),
+		&info{ShardIDs: []int{1}, ShardOption: option.ShardOption{EngineType: "archive"}}))
+	_, ok, err = OpenEngine("test_db", testPath, nil)
+	assert.NotNil(t, err)
+	assert.True(t, ok)
+	// info corrupted
+	assert.Nil(t, ioutil.WriteFile(infoPath(filepath.Join(testPath, "test_db")), []byte("err"), 0644))
+	_, ok, err = OpenEngine("test_db", testPath, nil)
+	assert.NotNil(t, err)
+	assert.True(t, ok)
+}
@@ -1,14 +1,22 @@
 package tsdb
 
 import (
+	"context"
+	"io/ioutil"
 	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/eleme/lindb/kv"
+	"github.com/eleme/lindb/kv/version"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
 	"github.com/eleme/lindb/pkg/interval"
 	"github.com/eleme/lindb/pkg/option"
+	"github.com/eleme/lindb/pkg/timeutil"
 	"github.com/eleme/lindb/pkg/util"
 )
 
@@ -17,11 +25,12 @@ var validOption = option.ShardOption{Interval: time.Second * 10, IntervalType: i
 
 func TestNew(t *testing.T) {
 	defer util.RemoveDir(testPath)
-	engine, _ := NewEngine("test_db", testPath)
+	engine, _ := NewEngine("test_db", testPath, nil)
 	assert.NotNil(t, engine)
 	assert.True(t, util.Exist(filepath.Join(testPath, "test_db")))
 
 	assert.Equal(t, 0, engine.NumOfShards())
+	assert.NotNil(t, engine.GetIndex())
 
 	err := engine.CreateShards(option.ShardOption{})
 	assert.NotNil(t, err)
@@ -41,7 +50,7 @@ func TestNew(t *testing.T) {
 	engine.Close()
 
 	// re-open engine test load exist data
-	engine, _ = NewEngine("test_db", testPath)
+	engine, _ = NewEngine("test_db", testPath, nil)
 	assert.True(t, util.Exist(filepath.Join(testPath, "test_db")))
 	assert.True(t, util.Exist(filepath.Join(testPath, "test_db", "OPTIONS")))
 
@@ -52,3 +61,172 @@ func TestNew(t *testing.T) {
 	assert.Equal(t, 3, engine.NumOfShards())
 	engine.Close()
 }
+
+func TestEngine_Write_Scan_Drop(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	defer util.RemoveDir(testPath)
+
+	engine, _ := NewEngine("test_db", testPath, nil)
+	assert.Nil(t, engine.CreateShards(validOption, 3, 1))
+	assert.Equal(t, []int{1, 3}, engine.ShardIDs())
+	assert.NotNil(t, engine.GetShard(1).MemoryDatabase())
+
+	point := models.NewMockPoint(ctrl)
+	point.EXPECT().Timestamp().Return(int64(0))
+	assert.Nil(t, engine.Write(1, point))
+	assert.True(t, errors.Is(engine.Write(2, point), errors.ErrShardNotFound))
+	// the point out of time range is dropped, the shard not exist is skipped
+	assert.Equal(t, models.SequenceToken{1: 0}, engine.SequenceToken(1, 2))
+	// the backfill point ahead of now is dropped by the shard
+	point.EXPECT().Timestamp().Return(timeutil.Now() + timeutil.OneDay)
+	assert.Nil(t, engine.WriteBackfill(1, point))
+	assert.Nil(t, engine.FlushBackfill(context.TODO(), 1))
+	assert.True(t, errors.Is(engine.WriteBackfill(2, point), errors.ErrShardNotFound))
+	assert.True(t, errors.Is(engine.FlushBackfill(context.TODO(), 2), errors.ErrShardNotFound))
+
+	segments, err := engine.Scan(1, interval.Day, models.TimeRange{Start: 0, End: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(segments))
+	_, err = engine.Scan(2, interval.Day, models.TimeRange{Start: 0, End: 10})
+	assert.True(t, errors.Is(err, errors.ErrShardNotFound))
+
+	assert.Nil(t, engine.Flush())
+	assert.Nil(t, engine.Drop())
+	assert.False(t, util.Exist(filepath.Join(testPath, "test_db")))
+}
+
+func TestEngine_DataDirs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	defer util.RemoveDir(testPath)
+
+	dir1 := filepath.Join(testPath, "disk1")
+	dir2 := filepath.Join(testPath, "disk2")
+	dirs := NewDataDirs([]string{dir1, dir2})
+	space := map[string]uint64{dir1: 10, dir2: 20}
+	dirs.(*dataDirs).availableSpace = func(dir string) (uint64, error) {
+		return space[dir], nil
+	}
+	engine, _ := NewEngine("test_db", filepath.Join(testPath, "meta"), dirs)
+	assert.Nil(t, engine.CreateShards(validOption, 1))
+	space[dir1] = 30
+	assert.Nil(t, engine.CreateShards(validOption, 2))
+	// shards are placed on the directory with most available space
+	assert.True(t, util.Exist(filepath.Join(dir2, "test_db", "shard", "1")))
+	assert.True(t, util.Exist(filepath.Join(dir1, "test_db", "shard", "2")))
+	engine.CheckShards()
+	assert.Empty(t, engine.OfflineShards())
+	assert.Nil(t, engine.Close())
+
+	// re-open engine, the shards are loaded from data directories
+	engine, _ = NewEngine("test_db", filepath.Join(testPath, "meta"), dirs)
+	assert.Equal(t, 2, engine.NumOfShards())
+	assert.NotNil(t, engine.GetShard(1))
+	assert.NotNil(t, engine.GetShard(2))
+
+	// disk2 fails, only the shard on it is offline
+	assert.Nil(t, util.RemoveDir(dir2))
+	engine.CheckShards()
+	assert.Equal(t, 1, engine.NumOfShards())
+	assert.Nil(t, engine.GetShard(1))
+	assert.NotNil(t, engine.GetShard(2))
+	assert.Len(t, engine.OfflineShards(), 1)
+	point := models.NewMockPoint(ctrl)
+	assert.True(t, errors.Is(engine.Write(1, point), errors.ErrShardOffline))
+	_, err := engine.Scan(1, interval.Day, models.TimeRange{Start: 0, End: 10})
+	assert.True(t, errors.Is(err, errors.ErrShardOffline))
+	point.EXPECT().Timestamp().Return(int64(0))
+	assert.Nil(t, engine.Write(2, point))
+	assert.Nil(t, engine.Close())
+
+	// re-open engine with the failed disk
+	engine, err = NewEngine("test_db", filepath.Join(testPath, "meta"), dirs)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, engine.NumOfShards())
+	assert.Contains(t, engine.OfflineShards(), 1)
+
+	assert.Nil(t, engine.Drop())
+	assert.False(t, util.Exist(filepath.Join(dir1, "test_db")))
+}
+
+func TestEngine_DiskUsage(t *testing.T) {
+	defer util.RemoveDir(testPath)
+
+	engine, _ := NewEngine("test_db", testPath, nil)
+	defer engine.Close()
+	assert.Nil(t, engine.CreateShards(validOption, 1))
+
+	usage := engine.DiskUsage()
+	assert.Equal(t, "test_db", usage.Database)
+	assert.NotEmpty(t, usage.Index)
+	assert.Equal(t, []models.ShardDiskUsage{{ShardID: 1}}, usage.Shards)
+
+	// flush a file into the family of segment
+	seg, err := engine.GetShard(1).(*shard).segments[interval.Day].GetOrCreateSegment("20190702")
+	assert.Nil(t, err)
+	family, err := seg.(*segment).kvStore.CreateFamily("f", kv.FamilyOption{})
+	assert.Nil(t, err)
+	flusher := family.NewFlusher()
+	_ = flusher.Add(1, []byte("test"))
+	assert.Nil(t, flusher.Commit())
+
+	usage = engine.DiskUsage()
+	assert.Equal(t, 1, len(usage.Shards))
+	shardUsage := usage.Shards[0]
+	assert.Equal(t, 1, len(shardUsage.Families))
+	assert.Equal(t, "day/20190702/f", shardUsage.Families[0].Name)
+	assert.Equal(t, family.DiskUsage().Bytes, shardUsage.Families[0].Bytes)
+	assert.True(t, shardUsage.Bytes > 0)
+	assert.Equal(t, shardUsage.Families[0].Bytes, shardUsage.Bytes)
+	assert.Equal(t, usage.IndexBytes+shardUsage.Bytes, usage.Bytes)
+}
+
+func TestEngine_SetCorruptionHandler(t *testing.T) {
+	defer util.RemoveDir(testPath)
+
+	engine, _ := NewEngine("test_db", testPath, nil)
+	defer engine.Close()
+	var corruptions []models.Corruption
+	engine.SetCorruptionHandler(func(corruption models.Corruption) {
+		corruptions = append(corruptions, corruption)
+	})
+	// the handler is set to the shard created later
+	assert.Nil(t, engine.CreateShards(validOption, 1))
+
+	seg, err := engine.GetShard(1).(*shard).segments[interval.Day].GetOrCreateSegment("20190702")
+	assert.Nil(t, err)
+	family, err := seg.(*segment).kvStore.CreateFamily("f", kv.FamilyOption{})
+	assert.Nil(t, err)
+	for _, value := range []string{"a", "b"} {
+		flusher := family.NewFlusher()
+		_ = flusher.Add(1, []byte(value))
+		assert.Nil(t, flusher.Commit())
+	}
+	// corrupt the value of first file, skip the length of value
+	familyPath := filepath.Join(testPath, "test_db", "shard", "1", segmentPath, "day", "20190702", "f")
+	files, err := ioutil.ReadDir(familyPath)
+	assert.Nil(t, err)
+	var fileName string
+	for _, file := range files {
+		if filepath.Ext(file.Name()) == ".sst" {
+			fileName = filepath.Join(familyPath, file.Name())
+			break
+		}
+	}
+	data, err := ioutil.ReadFile(fileName)
+	assert.Nil(t, err)
+	data[1] ^= 0xff
+	assert.Nil(t, ioutil.WriteFile(fileName, data, 0644))
+
+	assert.NotNil(t, family.Compact(&eventMerger{}))
+	assert.Equal(t, 1, len(corruptions))
+	corruption := corruptions[0]
+	assert.Equal(t, "test_db", corruption.Database)
+	assert.Equal(t, 1, corruption.ShardID)
+	assert.Equal(t, interval.Day, corruption.IntervalType)
+	assert.Equal(t, "20190702", corruption.Segment)
+	assert.Equal(t, "f", corruption.Family)
+	assert.Equal(t, fileName, filepath.Join(familyPath, version.Table(corruption.FileNumber)))
+	assert.NotEmpty(t, corruption.Error)
+}
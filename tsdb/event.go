@@ -0,0 +1,152 @@
+package tsdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/eleme/lindb/kv"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/timeutil"
+)
+
+//go:generate mockgen -source ./event.go -destination=./event_mock.go -package tsdb
+
+// EventStore represents the store of annotation events(deploys, incidents) of the engine,
+// the events are bucketed by the day of start time, each bucket is a key of event family.
+type EventStore interface {
+	// Write writes the events into event family, the events must be valid
+	Write(events []models.Event) error
+	// Query returns the events which overlap [startTime, endTime] and have all the tags, sorted by start time,
+	// the duplicate events are returned once.
+	Query(ctx context.Context, startTime, endTime int64, tags map[string]string) ([]models.Event, error)
+	// Compact merges the buckets of events written into different files
+	Compact() error
+}
+
+// eventStore implements EventStore based on kv family, the events of bucket are stored as json lines
+type eventStore struct {
+	family kv.Family
+}
+
+// newEventStore creates the event store based on kv family
+func newEventStore(family kv.Family) EventStore {
+	return &eventStore{family: family}
+}
+
+// Write writes the events of each day bucket as json lines into a new file of event family
+func (s *eventStore) Write(events []models.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	buckets := make(map[uint32][]byte)
+	for idx := range events {
+		data, err := json.Marshal(&events[idx])
+		if err != nil {
+			return fmt.Errorf("marshal event error:%s", err)
+		}
+		bucket := eventBucket(events[idx].StartTime)
+		buckets[bucket] = append(append(buckets[bucket], data...), '\n')
+	}
+	keys := make([]uint32, 0, len(buckets))
+	for key := range buckets {
+		keys = append(keys, key)
+	}
+	// the keys of flusher must be added in order
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j]
+	})
+	flusher := s.family.NewFlusher()
+	for _, key := range keys {
+		if err := flusher.Add(key, buckets[key]); err != nil {
+			return fmt.Errorf("add events into event family error:%s", err)
+		}
+	}
+	if err := flusher.Commit(); err != nil {
+		return fmt.Errorf("commit events into event family error:%s", err)
+	}
+	return nil
+}
+
+// Query reads the day buckets from the start time minus max event duration, so that the events started
+// before the start time are found, then filters the events by time range and tags.
+func (s *eventStore) Query(ctx context.Context, startTime, endTime int64,
+	tags map[string]string) ([]models.Event, error) {
+	var (
+		events []models.Event
+		seen   = make(map[string]struct{})
+		err    error
+	)
+	for bucket := eventBucket(startTime - models.MaxEventDuration); bucket <= eventBucket(endTime); bucket++ {
+		lookupErr := s.family.LookupWithContext(ctx, bucket, func(data []byte) bool {
+			for _, line := range bytes.Split(data, []byte{'\n'}) {
+				if len(line) == 0 {
+					continue
+				}
+				if _, ok := seen[string(line)]; ok {
+					continue
+				}
+				seen[string(line)] = struct{}{}
+				event := models.Event{}
+				if err = json.Unmarshal(line, &event); err != nil {
+					err = fmt.Errorf("unmarshal event error:%s", err)
+					return true
+				}
+				if event.Overlaps(startTime, endTime) && event.MatchTags(tags) {
+					events = append(events, event)
+				}
+			}
+			return false
+		})
+		if lookupErr != nil {
+			return nil, lookupErr
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].StartTime < events[j].StartTime
+	})
+	return events, nil
+}
+
+// Compact merges the buckets of events written into different files into one file
+func (s *eventStore) Compact() error {
+	return s.family.Compact(&eventMerger{})
+}
+
+// eventMerger implements kv.Merger, concatenates the json lines of events in different files
+type eventMerger struct {
+}
+
+// Merge concatenates the json lines of events, the duplicate events(e.g. written by retry) are removed
+func (m *eventMerger) Merge(key uint32, values [][]byte) ([]byte, error) {
+	var (
+		merged []byte
+		seen   = make(map[string]struct{})
+	)
+	for _, value := range values {
+		for _, line := range bytes.Split(value, []byte{'\n'}) {
+			if len(line) == 0 {
+				continue
+			}
+			if _, ok := seen[string(line)]; ok {
+				continue
+			}
+			seen[string(line)] = struct{}{}
+			merged = append(append(merged, line...), '\n')
+		}
+	}
+	return merged, nil
+}
+
+// eventBucket returns the day bucket of timestamp, the timestamp before epoch is in first bucket
+func eventBucket(timestamp int64) uint32 {
+	if timestamp < 0 {
+		return 0
+	}
+	return uint32(timestamp / timeutil.OneDay)
+}
@@ -0,0 +1,66 @@
+package tsdb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/pkg/util"
+)
+
+func TestEventStore_Query(t *testing.T) {
+	defer util.RemoveDir(testPath)
+	idx, err := newIndex(testPath, nil)
+	assert.Nil(t, err)
+	defer func() {
+		_ = idx.Close()
+	}()
+	events := idx.GetEventStore()
+	assert.Nil(t, events.Write(nil))
+
+	now := timeutil.Now()
+	deploy := models.Event{Title: "deploy", Tags: map[string]string{"app": "lindb"}, StartTime: now, EndTime: now}
+	// long incident started days before
+	incident := models.Event{Title: "incident", StartTime: now - 3*timeutil.OneDay, EndTime: now - timeutil.OneHour}
+	old := models.Event{Title: "old", StartTime: now - 10*timeutil.OneDay, EndTime: now - 9*timeutil.OneDay}
+	assert.Nil(t, events.Write([]models.Event{deploy, incident, old}))
+	// duplicate event written by retry
+	assert.Nil(t, events.Write([]models.Event{deploy}))
+
+	result, err := events.Query(context.TODO(), now-2*timeutil.OneHour, now, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []models.Event{incident, deploy}, result)
+	result, err = events.Query(context.TODO(), now-2*timeutil.OneHour, now, map[string]string{"app": "lindb"})
+	assert.Nil(t, err)
+	assert.Equal(t, []models.Event{deploy}, result)
+	result, err = events.Query(context.TODO(), now-2*timeutil.OneHour, now, map[string]string{"app": "other"})
+	assert.Nil(t, err)
+	assert.Nil(t, result)
+
+	// events are still queried after compacting
+	assert.Nil(t, idx.Compact())
+	result, err = events.Query(context.TODO(), now-20*timeutil.OneDay, now, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []models.Event{old, incident, deploy}, result)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	_, err = events.Query(ctx, now-2*timeutil.OneHour, now, nil)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestEventMerger_Merge(t *testing.T) {
+	merger := &eventMerger{}
+	merged, err := merger.Merge(1, [][]byte{[]byte("a\nb\n"), []byte("b\n\nc\n")})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("a\nb\nc\n"), merged)
+}
+
+func TestEventBucket(t *testing.T) {
+	assert.Equal(t, uint32(0), eventBucket(-1))
+	assert.Equal(t, uint32(0), eventBucket(timeutil.OneDay-1))
+	assert.Equal(t, uint32(1), eventBucket(timeutil.OneDay))
+}
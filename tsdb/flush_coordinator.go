@@ -0,0 +1,221 @@
+package tsdb
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/pkg/timeutil"
+)
+
+// Flushable represents the in-memory data which can be flushed into disk, like the memory database of shard
+type Flushable interface {
+	// MemSize returns the size(bytes) of in-memory data waiting for flushing
+	MemSize() int64
+	// Flush flushes the in-memory data into disk, returns the size(bytes) written into disk
+	Flush() (int64, error)
+}
+
+// FlushCoordinator coordinates the flushes of all flushables in storage node, avoids the synchronized
+// flush storms when each shard flushes independently:
+// 1) when the total memory exceeds high water mark, the largest flushables are flushed until below low water mark;
+// 2) otherwise the flushables are flushed after max interval, the first flush time is spread randomly;
+// 3) at most concurrency flushables are flushed at the same time, the next flushes are delayed
+// until the written bytes are paid by the disk bandwidth budget.
+type FlushCoordinator interface {
+	// Register registers the flushable with unique name, like database/shard
+	Register(name string, flushable Flushable)
+	// Unregister removes the flushable by name
+	Unregister(name string)
+	// Start checks and flushes the flushables in background
+	Start()
+	// Stop stops checking, waits for the running flushes completed
+	Stop()
+}
+
+// flushState represents the flush state of flushable
+type flushState struct {
+	name      string
+	flushable Flushable
+	memSize   int64
+	// nextFlush is the time which the flushable must be flushed before because of max interval
+	nextFlush int64
+}
+
+// flushCoordinator implements FlushCoordinator
+type flushCoordinator struct {
+	cfg config.Flush
+
+	mutex      sync.Mutex
+	flushables map[string]*flushState
+	// throttleUntil is the time which the next flushes are delayed until because of disk bandwidth
+	throttleUntil int64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	logger *logger.Logger
+}
+
+// NewFlushCoordinator creates the flush coordinator
+func NewFlushCoordinator(cfg config.Flush) FlushCoordinator {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = 1000
+	}
+	if cfg.LowWaterMark <= 0 || cfg.LowWaterMark > cfg.HighWaterMark {
+		cfg.LowWaterMark = cfg.HighWaterMark
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &flushCoordinator{
+		cfg:        cfg,
+		flushables: make(map[string]*flushState),
+		ctx:        ctx,
+		cancel:     cancel,
+		logger:     logger.GetLogger("tsdb/flush/coordinator"),
+	}
+}
+
+// Register registers the flushable with unique name, like database/shard
+func (c *flushCoordinator) Register(name string, flushable Flushable) {
+	state := &flushState{name: name, flushable: flushable}
+	if c.cfg.MaxInterval > 0 {
+		// spread the first flush time, so that the flushables registered together won't be flushed together
+		state.nextFlush = timeutil.Now() + rand.Int63n(c.cfg.MaxInterval)
+	}
+	c.mutex.Lock()
+	c.flushables[name] = state
+	c.mutex.Unlock()
+}
+
+// Unregister removes the flushable by name
+func (c *flushCoordinator) Unregister(name string) {
+	c.mutex.Lock()
+	delete(c.flushables, name)
+	c.mutex.Unlock()
+}
+
+// Start checks and flushes the flushables in background
+func (c *flushCoordinator) Start() {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		ticker := time.NewTicker(time.Duration(c.cfg.CheckInterval) * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.check(timeutil.Now())
+			case <-c.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops checking, waits for the running flushes completed
+func (c *flushCoordinator) Stop() {
+	c.cancel()
+	c.wg.Wait()
+}
+
+// check picks the flushables which need flush, then flushes them
+func (c *flushCoordinator) check(now int64) {
+	c.mutex.Lock()
+	if now < c.throttleUntil {
+		c.mutex.Unlock()
+		return
+	}
+	candidates := c.pickCandidates(now)
+	c.mutex.Unlock()
+	if len(candidates) == 0 {
+		return
+	}
+
+	written := c.flush(candidates)
+
+	c.mutex.Lock()
+	finished := timeutil.Now()
+	for _, state := range candidates {
+		if c.cfg.MaxInterval > 0 {
+			state.nextFlush = finished + c.cfg.MaxInterval
+		}
+	}
+	if c.cfg.DiskBandwidth > 0 {
+		c.throttleUntil = finished + written*1000/c.cfg.DiskBandwidth
+	}
+	c.mutex.Unlock()
+}
+
+// pickCandidates returns at most concurrency flushables which need flush, the largest ones are picked
+// if memory exceeds high water mark, otherwise the ones exceeding max interval, must hold the lock.
+func (c *flushCoordinator) pickCandidates(now int64) []*flushState {
+	var (
+		total  int64
+		states []*flushState
+	)
+	for _, state := range c.flushables {
+		state.memSize = state.flushable.MemSize()
+		if state.memSize <= 0 {
+			continue
+		}
+		total += state.memSize
+		states = append(states, state)
+	}
+	var candidates []*flushState
+	if c.cfg.HighWaterMark > 0 && total >= c.cfg.HighWaterMark {
+		sort.Slice(states, func(i, j int) bool {
+			return states[i].memSize > states[j].memSize
+		})
+		for _, state := range states {
+			if total <= c.cfg.LowWaterMark || len(candidates) >= c.cfg.Concurrency {
+				break
+			}
+			candidates = append(candidates, state)
+			total -= state.memSize
+		}
+		return candidates
+	}
+	if c.cfg.MaxInterval <= 0 {
+		return nil
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].nextFlush < states[j].nextFlush
+	})
+	for _, state := range states {
+		if state.nextFlush > now || len(candidates) >= c.cfg.Concurrency {
+			break
+		}
+		candidates = append(candidates, state)
+	}
+	return candidates
+}
+
+// flush flushes the flushables concurrently, returns the total bytes written into disk
+func (c *flushCoordinator) flush(candidates []*flushState) int64 {
+	var (
+		written int64
+		wg      sync.WaitGroup
+	)
+	for _, state := range candidates {
+		wg.Add(1)
+		go func(state *flushState) {
+			defer wg.Done()
+			n, err := state.flushable.Flush()
+			if err != nil {
+				c.logger.Error("flush error", logger.String("name", state.name), logger.Error(err))
+			}
+			atomic.AddInt64(&written, n)
+		}(state)
+	}
+	wg.Wait()
+	return written
+}
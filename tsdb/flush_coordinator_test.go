@@ -0,0 +1,144 @@
+package tsdb
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/config"
+	"github.com/eleme/lindb/pkg/timeutil"
+)
+
+// mockFlushable flushes all in-memory data into disk
+type mockFlushable struct {
+	mutex   sync.Mutex
+	memSize int64
+	flushes int
+	err     error
+}
+
+func (f *mockFlushable) MemSize() int64 {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.memSize
+}
+
+func (f *mockFlushable) Flush() (int64, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.flushes++
+	if f.err != nil {
+		return 0, f.err
+	}
+	n := f.memSize
+	f.memSize = 0
+	return n, nil
+}
+
+func (f *mockFlushable) numOfFlushes() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.flushes
+}
+
+func TestFlushCoordinator_memory(t *testing.T) {
+	c := NewFlushCoordinator(config.Flush{HighWaterMark: 100, LowWaterMark: 50, Concurrency: 2}).(*flushCoordinator)
+	flushables := []*mockFlushable{{memSize: 10}, {memSize: 40}, {memSize: 30}, {memSize: 15}, {}}
+	for i, f := range flushables {
+		c.Register(fmt.Sprintf("db/%d", i), f)
+	}
+	// below high water mark
+	c.check(timeutil.Now())
+	for _, f := range flushables {
+		assert.Equal(t, 0, f.numOfFlushes())
+	}
+
+	// 10+40+30+15+10=105, the largest two are flushed
+	flushables[4].memSize = 10
+	c.check(timeutil.Now())
+	assert.Equal(t, []int{0, 1, 1, 0, 0}, []int{flushables[0].numOfFlushes(), flushables[1].numOfFlushes(),
+		flushables[2].numOfFlushes(), flushables[3].numOfFlushes(), flushables[4].numOfFlushes()})
+
+	// stop picking when below low water mark
+	flushables[1].memSize = 70
+	c.check(timeutil.Now())
+	assert.Equal(t, 2, flushables[1].numOfFlushes())
+	assert.Equal(t, 0, flushables[3].numOfFlushes())
+
+	c.Unregister("db/1")
+	assert.Len(t, c.flushables, 4)
+}
+
+func TestFlushCoordinator_maxInterval(t *testing.T) {
+	c := NewFlushCoordinator(config.Flush{MaxInterval: 1000, Concurrency: 1}).(*flushCoordinator)
+	f1 := &mockFlushable{memSize: 10}
+	f2 := &mockFlushable{memSize: 10}
+	empty := &mockFlushable{}
+	c.Register("db/1", f1)
+	c.Register("db/2", f2)
+	c.Register("db/3", empty)
+	// the first flush time is spread in max interval
+	now := timeutil.Now()
+	for _, state := range c.flushables {
+		assert.True(t, state.nextFlush >= now-1000 && state.nextFlush < now+1000)
+	}
+	c.flushables["db/1"].nextFlush = now - 10
+	c.flushables["db/2"].nextFlush = now - 20
+	c.flushables["db/3"].nextFlush = now - 30
+
+	// one flush at a time, the oldest one first
+	c.check(now)
+	assert.Equal(t, 0, f1.numOfFlushes())
+	assert.Equal(t, 1, f2.numOfFlushes())
+	assert.True(t, c.flushables["db/2"].nextFlush >= now+1000)
+	c.check(now)
+	assert.Equal(t, 1, f1.numOfFlushes())
+	c.check(now)
+	assert.Equal(t, 1, f1.numOfFlushes())
+	assert.Equal(t, 1, f2.numOfFlushes())
+	// the empty one isn't flushed
+	assert.Equal(t, 0, empty.numOfFlushes())
+}
+
+func TestFlushCoordinator_diskBandwidth(t *testing.T) {
+	c := NewFlushCoordinator(config.Flush{HighWaterMark: 100, DiskBandwidth: 1000}).(*flushCoordinator)
+	f := &mockFlushable{memSize: 2000}
+	c.Register("db/1", f)
+	now := timeutil.Now()
+	c.check(now)
+	assert.Equal(t, 1, f.numOfFlushes())
+	// 2000 bytes written, the next flushes are delayed 2s
+	assert.True(t, c.throttleUntil >= now+2000)
+	f.memSize = 2000
+	c.check(now + 1000)
+	assert.Equal(t, 1, f.numOfFlushes())
+	c.check(c.throttleUntil)
+	assert.Equal(t, 2, f.numOfFlushes())
+
+	// flush failure
+	f.err = fmt.Errorf("err")
+	f.memSize = 2000
+	c.check(c.throttleUntil)
+	assert.Equal(t, 3, f.numOfFlushes())
+}
+
+func TestFlushCoordinator_Start(t *testing.T) {
+	c := NewFlushCoordinator(config.Flush{CheckInterval: 10, HighWaterMark: 100})
+	f := &mockFlushable{memSize: 200}
+	c.Register("db/1", f)
+	c.Start()
+	deadline := time.Now().Add(5 * time.Second)
+	for f.numOfFlushes() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Stop()
+	assert.Equal(t, 1, f.numOfFlushes())
+
+	// default config
+	c = NewFlushCoordinator(config.Flush{HighWaterMark: 100, LowWaterMark: 200})
+	assert.Equal(t, config.Flush{CheckInterval: 1000, HighWaterMark: 100, LowWaterMark: 100, Concurrency: 1},
+		c.(*flushCoordinator).cfg)
+}
@@ -1,6 +1,270 @@
 package tsdb
 
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/eleme/lindb/kv"
+	"github.com/eleme/lindb/kv/version"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/tsdb/index"
+)
+
 //go:generate mockgen -source ./index.go -destination=./index_mock.go -package tsdb
 
+const (
+	indexPath         = "index"
+	metricIndexFamily = "metric"
+	tagsIndexFamily   = "tags"
+	seriesIndexFamily = "series"
+	fieldIndexFamily  = "field"
+	sequenceFamily    = "sequence"
+	eventFamily       = "event"
+
+	cardinalityIndexFamily = "cardinality"
+)
+
+// Index represents the metadata index of the engine, includes metric name and tags
 type Index interface {
+	// GetMetricUID returns the metric name unique id under the database
+	GetMetricUID() MetricUID
+	// GetTagsUID returns the tags unique id under the database
+	GetTagsUID() TagsUID
+	// GetFieldUID returns the field unique id under the database
+	GetFieldUID() FieldUID
+	// GetIDGenerator returns the generator of metric, series and field ID under the database
+	GetIDGenerator() index.IDGenerator
+	// SuggestMetrics returns sorted metric names given a search prefix, paged by offset and limit
+	SuggestMetrics(prefix string, offset, limit int) []string
+	// SuggestTagKeys returns sorted tag keys of the metric
+	SuggestTagKeys(metricName string, limit int) []string
+	// SuggestTagValues returns sorted tag values of the metric's tag key given a search prefix
+	SuggestTagValues(metricName, tagKey, tagValuePrefix string, limit int) []string
+	// GetCardinality returns the sketches of series and tag values of the metric, returns nil if metric not exist
+	GetCardinality(metricName string) (*index.MetricCardinality, error)
+	// GetEventStore returns the store of annotation events under the database
+	GetEventStore() EventStore
+	// IndexFamilies returns the kv families of metric and tags index, which are read by queries in snapshot
+	IndexFamilies() (metricFamily, tagsFamily kv.Family)
+	// Flush flushes the in-memory metric name, field and tags unique ids into kv store
+	Flush() error
+	// Compact merges the tags index flushed into different files, reduces the files read by tag value lookup
+	Compact() error
+	// DiskUsage returns the disk usage of index's kv families sorted by name
+	DiskUsage() []models.FamilyDiskUsage
+	// WarmUp opens the table readers and loads the index blocks of index's kv families into block cache
+	WarmUp() (models.WarmUpStats, error)
+	// SetCorruptionHandler sets the handler which is invoked when a file of index's kv families is found corrupted
+	SetCorruptionHandler(handler CorruptionHandler)
+	// Checkpoint pins the versions of index's kv families for copying the files of index store, e.g. backup
+	Checkpoint() *version.Checkpoint
+	// Close closes index's kv store then release resource
+	Close() error
+}
+
+// engineIndex implements Index based on kv store
+type engineIndex struct {
+	store        kv.Store
+	metricFamily kv.Family
+	tagsFamily   kv.Family
+	metricUID    MetricUID
+	tagsUID      TagsUID
+	fieldUID     FieldUID
+	generator    index.IDGenerator
+	events       EventStore
+}
+
+// newIndex creates the index of engine under the engine's path, the store shares the snapshot barrier with
+// the stores of engine's segments
+func newIndex(enginePath string, barrier *version.Barrier) (Index, error) {
+	store, err := kv.NewStore(indexPath, kv.DefaultStoreOption(filepath.Join(enginePath, indexPath)))
+	if err != nil {
+		return nil, fmt.Errorf("create index store for engine[%s] error:%s", enginePath, err)
+	}
+	store.SetSnapshotBarrier(barrier)
+	metricFamily, err := createFamily(store, metricIndexFamily)
+	if err != nil {
+		_ = store.Close()
+		return nil, err
+	}
+	tagsFamily, err := createFamily(store, tagsIndexFamily)
+	if err != nil {
+		_ = store.Close()
+		return nil, err
+	}
+	cardinalityFamily, err := createFamily(store, cardinalityIndexFamily)
+	if err != nil {
+		_ = store.Close()
+		return nil, err
+	}
+	seriesFamily, err := createFamily(store, seriesIndexFamily)
+	if err != nil {
+		_ = store.Close()
+		return nil, err
+	}
+	fieldFamily, err := createFamily(store, fieldIndexFamily)
+	if err != nil {
+		_ = store.Close()
+		return nil, err
+	}
+	seqFamily, err := createFamily(store, sequenceFamily)
+	if err != nil {
+		_ = store.Close()
+		return nil, err
+	}
+	evtFamily, err := createFamily(store, eventFamily)
+	if err != nil {
+		_ = store.Close()
+		return nil, err
+	}
+	seqStore := index.NewKVSequenceStore(seqFamily)
+	metricSeq, err := index.NewSequence(index.MetricSequenceKey, seqStore, index.DefaultSequenceBlockSize)
+	if err != nil {
+		_ = store.Close()
+		return nil, fmt.Errorf("create metric sequence for engine[%s] error:%s", enginePath, err)
+	}
+	seriesSeq, err := index.NewSequence(index.SeriesSequenceKey, seqStore, index.DefaultSequenceBlockSize)
+	if err != nil {
+		_ = store.Close()
+		return nil, fmt.Errorf("create series sequence for engine[%s] error:%s", enginePath, err)
+	}
+	metricUID := index.NewMetricUID(metricFamily, metricSeq)
+	tagsUID := index.NewTagsUID(tagsFamily, cardinalityFamily, seriesFamily, seriesSeq)
+	fieldUID := index.NewFieldUID(fieldFamily)
+	return &engineIndex{
+		store:        store,
+		metricFamily: metricFamily,
+		tagsFamily:   tagsFamily,
+		metricUID:    metricUID,
+		tagsUID:      tagsUID,
+		fieldUID:     fieldUID,
+		generator:    index.NewIDGenerator(metricUID, tagsUID, fieldUID),
+		events:       newEventStore(evtFamily),
+	}, nil
+}
+
+// GetMetricUID returns the metric name unique id under the database
+func (i *engineIndex) GetMetricUID() MetricUID {
+	return i.metricUID
+}
+
+// GetTagsUID returns the tags unique id under the database
+func (i *engineIndex) GetTagsUID() TagsUID {
+	return i.tagsUID
+}
+
+// GetFieldUID returns the field unique id under the database
+func (i *engineIndex) GetFieldUID() FieldUID {
+	return i.fieldUID
+}
+
+// GetIDGenerator returns the generator of metric, series and field ID under the database
+func (i *engineIndex) GetIDGenerator() index.IDGenerator {
+	return i.generator
+}
+
+// GetEventStore returns the store of annotation events under the database
+func (i *engineIndex) GetEventStore() EventStore {
+	return i.events
+}
+
+// IndexFamilies returns the kv families of metric and tags index
+func (i *engineIndex) IndexFamilies() (metricFamily, tagsFamily kv.Family) {
+	return i.metricFamily, i.tagsFamily
+}
+
+// SuggestMetrics returns sorted metric names given a search prefix, paged by offset and limit
+func (i *engineIndex) SuggestMetrics(prefix string, offset, limit int) []string {
+	return i.metricUID.SuggestMetrics(prefix, offset, limit)
+}
+
+// SuggestTagKeys returns sorted tag keys of the metric
+func (i *engineIndex) SuggestTagKeys(metricName string, limit int) []string {
+	metricID := i.metricUID.GetMetricID(metricName)
+	if metricID == index.NotFoundMetricID {
+		return nil
+	}
+	return i.tagsUID.GetTagNames(metricID, limit)
+}
+
+// SuggestTagValues returns sorted tag values of the metric's tag key given a search prefix
+func (i *engineIndex) SuggestTagValues(metricName, tagKey, tagValuePrefix string, limit int) []string {
+	metricID := i.metricUID.GetMetricID(metricName)
+	if metricID == index.NotFoundMetricID {
+		return nil
+	}
+	return i.tagsUID.SuggestTagValues(metricID, tagKey, tagValuePrefix, limit)
+}
+
+// GetCardinality returns the sketches of series and tag values of the metric, returns nil if metric not exist
+func (i *engineIndex) GetCardinality(metricName string) (*index.MetricCardinality, error) {
+	metricID := i.metricUID.GetMetricID(metricName)
+	if metricID == index.NotFoundMetricID {
+		return nil, nil
+	}
+	return i.tagsUID.GetCardinality(metricID)
+}
+
+// Flush flushes the in-memory metric name, field and tags unique ids into kv store
+func (i *engineIndex) Flush() error {
+	if err := i.metricUID.Flush(); err != nil {
+		return fmt.Errorf("flush metric uid error:%s", err)
+	}
+	if err := i.fieldUID.Flush(); err != nil {
+		return fmt.Errorf("flush field uid error:%s", err)
+	}
+	if err := i.tagsUID.Flush(); err != nil {
+		return fmt.Errorf("flush tags uid error:%s", err)
+	}
+	return nil
+}
+
+// Compact merges the tags index flushed into different files, reduces the files read by tag value lookup
+func (i *engineIndex) Compact() error {
+	if err := i.tagsUID.Compact(); err != nil {
+		return fmt.Errorf("compact tags uid error:%s", err)
+	}
+	if err := i.events.Compact(); err != nil {
+		return fmt.Errorf("compact events error:%s", err)
+	}
+	return nil
+}
+
+// DiskUsage returns the disk usage of index's kv families sorted by name
+func (i *engineIndex) DiskUsage() []models.FamilyDiskUsage {
+	return i.store.DiskUsage()
+}
+
+// WarmUp opens the table readers and loads the index blocks of index's kv families into block cache
+func (i *engineIndex) WarmUp() (models.WarmUpStats, error) {
+	return i.store.WarmUp()
+}
+
+// SetCorruptionHandler sets the handler which is invoked when a file of index's kv families is found corrupted
+func (i *engineIndex) SetCorruptionHandler(handler CorruptionHandler) {
+	i.store.SetCorruptionHandler(func(family string, fileNumber int64, err error) {
+		handler(models.Corruption{Index: true, Family: family, FileNumber: fileNumber, Error: err.Error()})
+	})
+}
+
+// Checkpoint pins the versions of index's kv families
+func (i *engineIndex) Checkpoint() *version.Checkpoint {
+	return i.store.Checkpoint()
+}
+
+// Close closes index's kv store then release resource
+func (i *engineIndex) Close() error {
+	return i.store.Close()
+}
+
+// createFamily returns the family of store if exist, else creates it
+func createFamily(store kv.Store, familyName string) (kv.Family, error) {
+	if family := store.GetFamily(familyName); family != nil {
+		return family, nil
+	}
+	family, err := store.CreateFamily(familyName, kv.FamilyOption{})
+	if err != nil {
+		return nil, fmt.Errorf("create index family[%s] error:%s", familyName, err)
+	}
+	return family, nil
 }
@@ -0,0 +1,120 @@
+package index
+
+import (
+	"fmt"
+
+	"github.com/eleme/lindb/kv"
+	"github.com/eleme/lindb/pkg/hll"
+	"github.com/eleme/lindb/pkg/stream"
+)
+
+// MetricCardinality represents the HyperLogLog sketches of the series and the values of each tag key
+// under the metric, sketches of different flushes or storage nodes are merged by union.
+type MetricCardinality struct {
+	Series    *hll.HyperLogLog
+	TagValues map[string]*hll.HyperLogLog
+}
+
+// NewMetricCardinality creates empty sketches of metric
+func NewMetricCardinality() *MetricCardinality {
+	return &MetricCardinality{
+		Series:    hll.New(),
+		TagValues: make(map[string]*hll.HyperLogLog),
+	}
+}
+
+// AddSeries adds the series and its tag values into sketches
+func (c *MetricCardinality) AddSeries(tags map[string]string) {
+	// the tags string is sorted by tag key, so that the same series has same hash in all storage nodes
+	c.Series.AddString(MapToString(tags))
+	for tagKey, tagValue := range tags {
+		sketch, ok := c.TagValues[tagKey]
+		if !ok {
+			sketch = hll.New()
+			c.TagValues[tagKey] = sketch
+		}
+		sketch.AddString(tagValue)
+	}
+}
+
+// Merge merges other sketches into this
+func (c *MetricCardinality) Merge(other *MetricCardinality) {
+	c.Series.Merge(other.Series)
+	for tagKey, otherSketch := range other.TagValues {
+		if sketch, ok := c.TagValues[tagKey]; ok {
+			sketch.Merge(otherSketch)
+		} else {
+			c.TagValues[tagKey] = otherSketch
+		}
+	}
+}
+
+// MarshalBinary encodes the sketches, format: series sketch, num. of tag keys, [tag key, tag values sketch]
+func (c *MetricCardinality) MarshalBinary() ([]byte, error) {
+	writer := stream.BinaryWriter()
+	by, err := c.Series.MarshalBinary()
+	if nil != err {
+		return nil, err
+	}
+	writer.PutKey(by)
+	tagKeys := make([]string, 0, len(c.TagValues))
+	for tagKey := range c.TagValues {
+		tagKeys = append(tagKeys, tagKey)
+	}
+	writer.PutUvarint64(uint64(len(tagKeys)))
+	for _, tagKey := range tagKeys {
+		by, err := c.TagValues[tagKey].MarshalBinary()
+		if nil != err {
+			return nil, err
+		}
+		writer.PutKey([]byte(tagKey))
+		writer.PutKey(by)
+	}
+	return writer.Bytes()
+}
+
+// UnmarshalBinary decodes the sketches encoded by MarshalBinary
+func (c *MetricCardinality) UnmarshalBinary(data []byte) error {
+	reader := stream.NewBufReader(data)
+	_, by := reader.ReadKey()
+	series := hll.New()
+	if err := series.UnmarshalBinary(by); nil != err {
+		return fmt.Errorf("decode series sketch error:%s", err)
+	}
+	tagKeys := int(reader.ReadInt())
+	tagValues := make(map[string]*hll.HyperLogLog, tagKeys)
+	for i := 0; i < tagKeys; i++ {
+		_, tagKey := reader.ReadKey()
+		_, by := reader.ReadKey()
+		sketch := hll.New()
+		if err := sketch.UnmarshalBinary(by); nil != err {
+			return fmt.Errorf("decode tag[%s] values sketch error:%s", string(tagKey), err)
+		}
+		tagValues[string(tagKey)] = sketch
+	}
+	c.Series = series
+	c.TagValues = tagValues
+	return nil
+}
+
+// cardinalityMerger implements kv.Merger, unions the sketches of the metric in different files
+type cardinalityMerger struct {
+}
+
+// NewCardinalityMerger returns the merger of metric cardinality sketches
+func NewCardinalityMerger() kv.Merger {
+	return &cardinalityMerger{}
+}
+
+// Merge unions the sketches of the metric
+func (m *cardinalityMerger) Merge(metricID uint32, values [][]byte) ([]byte, error) {
+	result := NewMetricCardinality()
+	for _, value := range values {
+		cardinality := NewMetricCardinality()
+		if err := cardinality.UnmarshalBinary(value); nil != err {
+			return nil, fmt.Errorf("decode cardinality of metric[%d] error:%s", metricID, err)
+		}
+		result.Merge(cardinality)
+	}
+	return result.MarshalBinary()
+}
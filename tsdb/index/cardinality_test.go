@@ -0,0 +1,60 @@
+package index
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/pkg/hll"
+	"github.com/eleme/lindb/pkg/stream"
+)
+
+func TestMetricCardinality_Marshal(t *testing.T) {
+	c := NewMetricCardinality()
+	for i := 0; i < 100; i++ {
+		c.AddSeries(map[string]string{"host": fmt.Sprintf("host-%d", i), "zone": fmt.Sprintf("zone-%d", i%5)})
+	}
+	data, err := c.MarshalBinary()
+	assert.Nil(t, err)
+	c2 := NewMetricCardinality()
+	assert.Nil(t, c2.UnmarshalBinary(data))
+	assert.Equal(t, c.Series.Count(), c2.Series.Count())
+	assert.Equal(t, uint64(5), c2.TagValues["zone"].Count())
+	assert.Equal(t, c.TagValues["host"].Count(), c2.TagValues["host"].Count())
+
+	assert.NotNil(t, c2.UnmarshalBinary([]byte{0}))
+	// invalid sketch of tag values
+	writer := stream.BinaryWriter()
+	series, _ := hll.New().MarshalBinary()
+	writer.PutKey(series)
+	writer.PutUvarint64(1)
+	writer.PutKey([]byte("host"))
+	writer.PutKey([]byte{0})
+	data, _ = writer.Bytes()
+	assert.NotNil(t, c2.UnmarshalBinary(data))
+}
+
+func TestCardinalityMerger_Merge(t *testing.T) {
+	c1 := NewMetricCardinality()
+	c1.AddSeries(map[string]string{"host": "host-1", "zone": "sh"})
+	c1.AddSeries(map[string]string{"host": "host-2", "zone": "sh"})
+	c2 := NewMetricCardinality()
+	// same series of replica is counted once
+	c2.AddSeries(map[string]string{"zone": "sh", "host": "host-1"})
+	c2.AddSeries(map[string]string{"host": "host-3", "ip": "1.1.1.1"})
+	v1, _ := c1.MarshalBinary()
+	v2, _ := c2.MarshalBinary()
+
+	merged, err := NewCardinalityMerger().Merge(1, [][]byte{v1, v2})
+	assert.Nil(t, err)
+	result := NewMetricCardinality()
+	assert.Nil(t, result.UnmarshalBinary(merged))
+	assert.Equal(t, uint64(3), result.Series.Count())
+	assert.Equal(t, uint64(3), result.TagValues["host"].Count())
+	assert.Equal(t, uint64(1), result.TagValues["zone"].Count())
+	assert.Equal(t, uint64(1), result.TagValues["ip"].Count())
+
+	_, err = NewCardinalityMerger().Merge(1, [][]byte{v1, {0}})
+	assert.NotNil(t, err)
+}
@@ -3,6 +3,9 @@ package index
 import (
 	"encoding/json"
 	"math"
+	"sort"
+
+	"github.com/eleme/lindb/pkg/tree"
 )
 
 const (
@@ -25,3 +28,39 @@ func MapToString(tagsMap map[string]string) string {
 	b, _ := json.Marshal(tagsMap)
 	return string(b)
 }
+
+//collectTreeKeys collects all keys of the tree which match the prefix, if prefix is empty collects all keys
+func collectTreeKeys(reader *tree.Reader, prefix []byte, keys map[string]struct{}) {
+	var it tree.Iterator
+	if len(prefix) == 0 {
+		it = reader.SeekToFirst()
+	} else {
+		it = reader.Seek(prefix)
+	}
+	if it == nil {
+		return
+	}
+	for it.Next() {
+		key := it.GetKey()
+		if len(key) > 0 {
+			keys[string(key)] = struct{}{}
+		}
+	}
+}
+
+//pageStrings returns the sorted strings of the set, paged by offset and limit
+func pageStrings(set map[string]struct{}, offset, limit int) []string {
+	if offset >= len(set) || limit <= 0 {
+		return nil
+	}
+	result := make([]string, 0, len(set))
+	for s := range set {
+		result = append(result, s)
+	}
+	sort.Strings(result)
+	end := offset + limit
+	if end > len(result) {
+		end = len(result)
+	}
+	return result[offset:end]
+}
@@ -3,7 +3,9 @@ package index
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"sort"
+	"sync"
 
 	"go.uber.org/zap"
 
@@ -14,13 +16,21 @@ import (
 	"github.com/eleme/lindb/pkg/util"
 )
 
-//FieldUID represents field unique under the metric name.
+//FieldUID represents field unique under the metric name,
+//the field id is composed of field type(high 16 bits) and the field sequence of metric(low 16 bits),
+//so that the field type is decoded from the field id of metric table.
+//The new fields are kept in memory by metric until flushed into kv store.
 type FieldUID struct {
-	metricID   uint32
+	mutex   sync.RWMutex
+	metrics map[uint32]*metricFields //key is metric id, the fields which aren't flushed
+	family  kv.Family
+	dbField zap.Field
+}
+
+//metricFields represents the in-memory fields of a metric
+type metricFields struct {
+	sequenceID uint16            //field sequence id
 	fieldMap   map[string]uint32 //key is field name
-	sequenceID uint16            // sequence id
-	family     kv.Family
-	dbField    zap.Field
 }
 
 //FieldReader represents parses byte arrays for reading
@@ -48,117 +58,147 @@ func newFieldReader(byteArray []byte) *FieldReader {
 //NewFieldUID creation requires kvFamily
 func NewFieldUID(f kv.Family) *FieldUID {
 	return &FieldUID{
-		fieldMap: make(map[string]uint32),
-		family:   f,
-		dbField:  zap.String("db", "db"),
+		metrics: make(map[uint32]*metricFields),
+		family:  f,
+		dbField: zap.String("db", "db"),
 	}
 }
 
 //GetOrCreateFieldID  returns find the ID associated with a given field name and field type or create it.
 func (f *FieldUID) GetOrCreateFieldID(metricID uint32, fieldName string, fieldType field.Type) (uint32, error) {
-	//get fieldID from disk
-	fieldID := f.GetFieldID(metricID, fieldName)
-	if NotFoundFieldID == fieldID {
-		if f.metricID != metricID {
-			err := f.Flush()
-			if nil != err {
-				logger.GetLogger("tsdb/index").Error("flush metric field error!", f.dbField, logger.Error(err))
-				return NotFoundFieldID, err
-			}
-			f.metricID = metricID
-			//clear all
-			for k := range f.fieldMap {
-				delete(f.fieldMap, k)
-			}
-			f.sequenceID = f.getLastFieldSequenceID(metricID)
-		}
+	if fieldID := f.GetFieldID(metricID, fieldName); fieldID != NotFoundFieldID {
+		return fieldID, nil
+	}
 
-		f.sequenceID++
-		f.fieldMap[fieldName] = util.ShortToInt(uint16(fieldType), f.sequenceID)
-		return f.fieldMap[fieldName], nil
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	mf, ok := f.metrics[metricID]
+	if !ok {
+		mf = &metricFields{
+			sequenceID: f.getLastFieldSequenceID(metricID),
+			fieldMap:   make(map[string]uint32),
+		}
+		f.metrics[metricID] = mf
+	}
+	// double check, the field maybe created by the other writer
+	if fieldID, ok := mf.fieldMap[fieldName]; ok {
+		return fieldID, nil
+	}
+	if mf.sequenceID == math.MaxUint16 {
+		return NotFoundFieldID, fmt.Errorf("too many fields of metric[%d]", metricID)
 	}
+	mf.sequenceID++
+	fieldID := util.ShortToInt(uint16(fieldType), mf.sequenceID)
+	mf.fieldMap[fieldName] = fieldID
 	return fieldID, nil
 }
 
-//GetFields returns get all fields within the metric name
+//GetFields returns get the field names within the metric name, at most limit fields
 func (f *FieldUID) GetFields(metricID uint32, limit int16) map[string]struct{} {
+	fields := make(map[string]struct{})
+	f.mutex.RLock()
+	if mf, ok := f.metrics[metricID]; ok {
+		for fieldName := range mf.fieldMap {
+			fields[fieldName] = struct{}{}
+		}
+	}
+	f.mutex.RUnlock()
 	f.family.Lookup(metricID, func(bytes []byte) bool {
 		fieldReader := newFieldReader(bytes)
 		for i := 0; i < fieldReader.fieldCount; i++ {
 			_, key := fieldReader.reader.ReadKey()
-			id := fieldReader.reader.ReadInt()
-			//todo
-			fmt.Println("fieldName", key, " id:", id)
+			_ = fieldReader.reader.ReadInt()
+			fields[string(key)] = struct{}{}
 		}
-		return true
+		// go on reading the fields of other files
+		return false
 	})
-	return nil
+	if len(fields) > int(limit) {
+		names := pageStrings(fields, 0, int(limit))
+		fields = make(map[string]struct{}, len(names))
+		for _, name := range names {
+			fields[name] = struct{}{}
+		}
+	}
+	return fields
 }
 
-//Flush represents forces a flush of in-memory data, and clear it
+//Flush represents forces a flush of in-memory data, and clear it,
+//the lock is held until committed, so that the flushing field is found in memory or kv store.
 func (f *FieldUID) Flush() error {
-	//flush fieldId to kv-store
-	if len(f.fieldMap) > 0 {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if len(f.metrics) == 0 {
+		return nil
+	}
+	metricIDs := make([]uint32, 0, len(f.metrics))
+	for metricID := range f.metrics {
+		metricIDs = append(metricIDs, metricID)
+	}
+	// the keys of flusher must be in ascending order
+	sort.Slice(metricIDs, func(i, j int) bool {
+		return metricIDs[i] < metricIDs[j]
+	})
+	flusher := f.family.NewFlusher()
+	for _, metricID := range metricIDs {
+		mf := f.metrics[metricID]
 		writer := stream.BinaryWriter()
-		writer.PutUvarint64(uint64(f.sequenceID))
-		writer.PutUvarint64(uint64(len(f.fieldMap)))
-
-		fieldNames := f.getSortFieldNames()
-		for _, fieldName := range fieldNames {
+		writer.PutUvarint64(uint64(mf.sequenceID))
+		writer.PutUvarint64(uint64(len(mf.fieldMap)))
+		for _, fieldName := range sortedFieldNames(mf.fieldMap) {
 			writer.PutKey([]byte(fieldName))
-			writer.PutUvarint64(uint64(f.fieldMap[fieldName]))
+			writer.PutUvarint64(uint64(mf.fieldMap[fieldName]))
 		}
-
 		by, err := writer.Bytes()
 		if nil != err {
 			logger.GetLogger("tsdb/index").Error("encode metric field error:", f.dbField, logger.Error(err))
 			return err
 		}
-
-		//flusher
-		flusher := f.family.NewFlusher()
-		addError := flusher.Add(f.metricID, by)
-		if nil != addError {
+		if err := flusher.Add(metricID, by); nil != err {
 			logger.GetLogger("tsdb/index").Error("write metric field error!",
-				f.dbField, zap.String("metricID", string(f.metricID)), logger.Error(addError))
-			return addError
-		}
-		//commit
-		commitError := flusher.Commit()
-		if nil != commitError {
-			logger.GetLogger("tsdb/index").Error("flush metric fieldId error!", f.dbField, logger.Error(commitError))
-			return commitError
-		}
-
-		//clear in-memory data
-		for k := range f.fieldMap {
-			delete(f.fieldMap, k)
+				f.dbField, zap.Uint32("metricID", metricID), logger.Error(err))
+			return err
 		}
 	}
+	if err := flusher.Commit(); nil != err {
+		logger.GetLogger("tsdb/index").Error("flush metric fieldId error!", f.dbField, logger.Error(err))
+		return err
+	}
+	f.metrics = make(map[uint32]*metricFields)
 	return nil
 }
 
-//getLastFieldSequenceID returns last field sequence by metricID
+//getLastFieldSequenceID returns the max field sequence of metric in all files
 func (f *FieldUID) getLastFieldSequenceID(metricID uint32) uint16 {
 	seq := uint16(0)
 	f.family.Lookup(metricID, func(bytes []byte) bool {
-		fieldReader := newFieldReader(bytes)
-		seq = fieldReader.seq
-		return true
+		if fieldReader := newFieldReader(bytes); fieldReader.seq > seq {
+			seq = fieldReader.seq
+		}
+		// go on reading the sequence of other files
+		return false
 	})
 	return seq
 }
 
-//GetFieldID returns get fieldID by fieldName within the metric name
-func (f *FieldUID) GetFieldID(metricID uint32, field string) uint32 {
-	var fieldID = NotFoundFieldID
+//GetFieldID returns get fieldID by fieldName within the metric name from memory or kv store
+func (f *FieldUID) GetFieldID(metricID uint32, fieldName string) uint32 {
+	f.mutex.RLock()
+	if mf, ok := f.metrics[metricID]; ok {
+		if fieldID, ok := mf.fieldMap[fieldName]; ok {
+			f.mutex.RUnlock()
+			return fieldID
+		}
+	}
+	f.mutex.RUnlock()
 
+	var fieldID = NotFoundFieldID
 	f.family.Lookup(metricID, func(byteArray []byte) bool {
 		fieldReader := newFieldReader(byteArray)
 		for i := 0; i < fieldReader.fieldCount; i++ {
 			_, key := fieldReader.reader.ReadKey()
 			id := fieldReader.reader.ReadInt()
-			if bytes.Equal(key, []byte(field)) {
+			if bytes.Equal(key, []byte(fieldName)) {
 				fieldID = uint32(id)
 				return true
 			}
@@ -168,14 +208,40 @@ func (f *FieldUID) GetFieldID(metricID uint32, field string) uint32 {
 	return fieldID
 }
 
-//getSortFieldNames returns get the sorted field names
-func (f *FieldUID) getSortFieldNames() []string {
-	fieldNames := make([]string, len(f.fieldMap))
-	var idx int
-	for fieldName := range f.fieldMap {
-		fieldNames[idx] = fieldName
-		idx++
+//GetFieldNames returns the names of all fields within the metric name keyed by field id from memory or kv store
+func (f *FieldUID) GetFieldNames(metricID uint32) map[uint32]string {
+	names := make(map[uint32]string)
+	f.mutex.RLock()
+	if mf, ok := f.metrics[metricID]; ok {
+		for fieldName, fieldID := range mf.fieldMap {
+			names[fieldID] = fieldName
+		}
+	}
+	f.mutex.RUnlock()
+	f.family.Lookup(metricID, func(byteArray []byte) bool {
+		fieldReader := newFieldReader(byteArray)
+		for i := 0; i < fieldReader.fieldCount; i++ {
+			_, key := fieldReader.reader.ReadKey()
+			names[uint32(fieldReader.reader.ReadInt())] = string(key)
+		}
+		// go on reading the fields of other files
+		return false
+	})
+	return names
+}
+
+//sortedFieldNames returns get the sorted field names
+func sortedFieldNames(fieldMap map[string]uint32) []string {
+	fieldNames := make([]string, 0, len(fieldMap))
+	for fieldName := range fieldMap {
+		fieldNames = append(fieldNames, fieldName)
 	}
 	sort.Strings(fieldNames)
 	return fieldNames
 }
+
+//GetFieldType returns the field type encoded in the high 16 bits of field id
+func GetFieldType(fieldID uint32) field.Type {
+	high, _ := util.IntToShort(fieldID)
+	return field.Type(high)
+}
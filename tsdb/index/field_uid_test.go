@@ -37,6 +37,13 @@ func TestFieldUid_GetOrCreateFieldId(t *testing.T) {
 			assert.Equal(t, j, int(low))
 		}
 	}
+	// the names of fields in memory and disk
+	newID, _ := fieldUID.GetOrCreateFieldID(1, "new-field", field.Type(1))
+	names := fieldUID.GetFieldNames(1)
+	assert.Equal(t, 100, len(names))
+	assert.Equal(t, "field-1", names[fieldUID.GetFieldID(1, "field-1")])
+	assert.Equal(t, "new-field", names[newID])
+	assert.Empty(t, fieldUID.GetFieldNames(100))
 	fmt.Println("success")
 }
 
@@ -0,0 +1,59 @@
+package index
+
+import (
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/pkg/logger"
+)
+
+// idGenerator implements IDGenerator based on the persistent unique ids of metric, tags and field,
+// the ids are looked up from the uids, new ids are allocated from the persistent sequences of database
+// only if not found, so that the same name gets the same id after restart, and ids are never reused.
+// If fails to allocate id, returns 0 which means unset id, so that the caller retries later.
+type idGenerator struct {
+	metricUID *MetricUID
+	tagsUID   *TagsUID
+	fieldUID  *FieldUID
+
+	logger *logger.Logger
+}
+
+// NewIDGenerator creates the id generator of database which generates ids through the uids
+func NewIDGenerator(metricUID *MetricUID, tagsUID *TagsUID, fieldUID *FieldUID) IDGenerator {
+	return &idGenerator{
+		metricUID: metricUID,
+		tagsUID:   tagsUID,
+		fieldUID:  fieldUID,
+		logger:    logger.GetLogger("tsdb/index"),
+	}
+}
+
+// GenMetricID generates ID(uint32) from metricName
+func (g *idGenerator) GenMetricID(metricName string) uint32 {
+	id, ok := g.metricUID.GetOrCreateMetricID(metricName, true)
+	if !ok {
+		g.logger.Error("generate metric id error", logger.String("metric", metricName))
+		return 0
+	}
+	return id
+}
+
+// GenTSID generates ID(uint32) from metricID and sortedTags
+func (g *idGenerator) GenTSID(metricID uint32, sortedTags string) uint32 {
+	id, err := g.tagsUID.GetOrCreateTagsID(metricID, sortedTags)
+	if err != nil {
+		g.logger.Error("generate series id error", logger.Uint32("metricID", metricID), logger.Error(err))
+		return 0
+	}
+	return id
+}
+
+// GenFieldID generates ID(uint32) from metricID and fieldName
+func (g *idGenerator) GenFieldID(metricID uint32, fieldName string, fieldType field.Type) uint32 {
+	id, err := g.fieldUID.GetOrCreateFieldID(metricID, fieldName, fieldType)
+	if err != nil {
+		g.logger.Error("generate field id error", logger.Uint32("metricID", metricID),
+			logger.String("field", fieldName), logger.Error(err))
+		return 0
+	}
+	return id
+}
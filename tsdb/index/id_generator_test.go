@@ -0,0 +1,84 @@
+package index
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/kv"
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/pkg/util"
+)
+
+// newTestIDGenerator creates the id generator based on the families of kv store under test path
+func newTestIDGenerator(t *testing.T, seqStore SequenceStore) (IDGenerator, kv.Store) {
+	store, err := kv.NewStore("index", kv.DefaultStoreOption(testKVPath))
+	assert.Nil(t, err)
+	families := make(map[string]kv.Family)
+	for _, name := range []string{"metric", "tags", "cardinality", "series", "field"} {
+		family := store.GetFamily(name)
+		if family == nil {
+			family, err = store.CreateFamily(name, kv.FamilyOption{})
+			assert.Nil(t, err)
+		}
+		families[name] = family
+	}
+	metricSeq, err := NewSequence(MetricSequenceKey, seqStore, 10)
+	assert.Nil(t, err)
+	seriesSeq, err := NewSequence(SeriesSequenceKey, seqStore, 10)
+	assert.Nil(t, err)
+	metricUID := NewMetricUID(families["metric"], metricSeq)
+	tagsUID := NewTagsUID(families["tags"], families["cardinality"], families["series"], seriesSeq)
+	return NewIDGenerator(metricUID, tagsUID, NewFieldUID(families["field"])), store
+}
+
+func TestIDGenerator(t *testing.T) {
+	util.RemoveDir(testKVPath)
+	defer util.RemoveDir(testKVPath)
+	seqStore := &memorySequenceStore{watermarks: make(map[uint32]uint32)}
+	generator, store := newTestIDGenerator(t, seqStore)
+
+	assert.Equal(t, uint32(1), generator.GenMetricID("cpu"))
+	assert.Equal(t, uint32(2), generator.GenMetricID("mem"))
+	assert.Equal(t, uint32(1), generator.GenMetricID("cpu"))
+
+	assert.Equal(t, uint32(1), generator.GenTSID(1, `{"host":"a"}`))
+	assert.Equal(t, uint32(2), generator.GenTSID(2, `{"host":"a"}`))
+	assert.Equal(t, uint32(1), generator.GenTSID(1, `{"host":"a"}`))
+	// the subset of tags is another series
+	assert.Equal(t, uint32(3), generator.GenTSID(1, `{"host":"a","ip":"1"}`))
+
+	idleID := generator.GenFieldID(1, "idle", field.SumField)
+	assert.Equal(t, idleID, generator.GenFieldID(1, "idle", field.SumField))
+	assert.Equal(t, field.SumField, GetFieldType(idleID))
+	assert.Equal(t, field.MaxField, GetFieldType(generator.GenFieldID(1, "usage", field.MaxField)))
+
+	// flush then restart, the same names get the same ids, the new ids are never reused
+	g := generator.(*idGenerator)
+	assert.Nil(t, g.metricUID.Flush())
+	assert.Nil(t, g.tagsUID.Flush())
+	assert.Nil(t, g.fieldUID.Flush())
+	assert.Nil(t, store.Close())
+	generator, store = newTestIDGenerator(t, seqStore)
+	assert.Equal(t, uint32(1), generator.GenMetricID("cpu"))
+	assert.Equal(t, uint32(11), generator.GenMetricID("disk"))
+	assert.Equal(t, uint32(3), generator.GenTSID(1, `{"host":"a","ip":"1"}`))
+	assert.Equal(t, uint32(11), generator.GenTSID(1, `{"host":"b"}`))
+	assert.Equal(t, idleID, generator.GenFieldID(1, "idle", field.SumField))
+
+	// returns unset id if allocate error
+	seqStore.err = fmt.Errorf("err")
+	g = generator.(*idGenerator)
+	g.metricUID.seq = &Sequence{store: seqStore, blockSize: 10, next: 1}
+	g.tagsUID.seq = &Sequence{store: seqStore, blockSize: 10, next: 1}
+	assert.Equal(t, uint32(0), generator.GenMetricID("load"))
+	assert.Equal(t, uint32(0), generator.GenTSID(1, `{"host":"c"}`))
+	// the existing ids are found
+	assert.Equal(t, uint32(1), generator.GenMetricID("cpu"))
+
+	// retry after store recovered
+	seqStore.err = nil
+	assert.Equal(t, uint32(1), generator.GenMetricID("load"))
+	assert.Nil(t, store.Close())
+}
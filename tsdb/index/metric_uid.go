@@ -1,124 +1,214 @@
 package index
 
 import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/RoaringBitmap/roaring"
 	"go.uber.org/zap"
 
 	"github.com/eleme/lindb/kv"
 	"github.com/eleme/lindb/pkg/logger"
 	"github.com/eleme/lindb/pkg/tree"
-	"github.com/eleme/lindb/pkg/util"
 )
 
-//MetricUid represents metric name unique id under the database
+//familyReader reads the values of key from the files of kv family, which is the family or the snapshot of family
+type familyReader interface {
+	Lookup(key uint32, extractorFunc func([]byte) bool)
+}
+
+//MetricUid represents metric name unique id under the database,
+//the metric ids are allocated from the persistent sequence of database on the first write of metric name,
+//the new metric names are kept in memory by partition until flushed into kv store.
 type MetricUID struct {
-	partition  uint32
-	metrics    *tree.BTree //Key is the ascii of the first letter of metric
-	sequenceID uint32      //unique sequence id
-	family     kv.Family
-	dbField    zap.Field
+	seq     *Sequence
+	family  kv.Family
+	mutex   sync.RWMutex
+	metrics map[uint32]*tree.BTree //key is the ascii of the first letter of metric, which aren't flushed
+	dbField zap.Field
 }
 
-//NewMetricUID creation requires kvFamily
-func NewMetricUID(metricFamily kv.Family) *MetricUID {
-	//Get the last written sequenceID, otherwise start from 0
-	seq := uint32(0)
-	metricFamily.Lookup(MetricSequenceIDKey, func(bytes []byte) bool {
-		seq = util.BytesToUint32(bytes)
-		return true
-	})
+//NewMetricUID creation requires kvFamily and the sequence of metric id
+func NewMetricUID(metricFamily kv.Family, seq *Sequence) *MetricUID {
 	return &MetricUID{
-		metrics:    tree.NewBTree(),
-		sequenceID: seq,
-		family:     metricFamily,
-		dbField:    zap.String("db", "db"),
+		seq:     seq,
+		family:  metricFamily,
+		metrics: make(map[uint32]*tree.BTree),
+		dbField: zap.String("db", "db"),
 	}
 }
 
-//GetOrCreateMetricID returns find the metric ID associated with a given name or create it.
+//GetOrCreateMetricID returns find the metric ID associated with a given name or create it,
+//the id is allocated from sequence only if the name isn't found in memory or kv store.
 func (m *MetricUID) GetOrCreateMetricID(metricName string, create bool) (uint32, bool) {
-	if len(metricName) > 0 {
-		nameBytes := []byte(metricName)
-		partition := getPartition(nameBytes)
-
-		id := m.getMetricIDFromDisk(partition, nameBytes)
-		if id == NotFoundMetricID {
-			// if not exists
-			if partition != m.partition {
-				err := m.Flush()
-				if nil != err {
-					logger.GetLogger("tsdb/index").Error("flush metric tree error!", m.dbField)
-				}
-				m.partition = partition
-				m.metrics.Clear()
-			}
-			if create {
-				m.sequenceID++
-				m.metrics.Put(nameBytes, int(m.sequenceID))
-				return m.sequenceID, true
-			}
-			return NotFoundMetricID, false
-		}
+	if len(metricName) == 0 {
+		return NotFoundMetricID, false
+	}
+	nameBytes := []byte(metricName)
+	partition := getPartition(nameBytes)
+	if id, ok := m.getMetricIDFromMemory(partition, nameBytes); ok {
+		return id, true
+	}
+	if id := getMetricIDFromDisk(m.family, partition, nameBytes); id != NotFoundMetricID {
 		return id, true
 	}
-	return NotFoundMetricID, false
+	if !create {
+		return NotFoundMetricID, false
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	metrics, ok := m.metrics[partition]
+	if !ok {
+		metrics = tree.NewBTree()
+		m.metrics[partition] = metrics
+	}
+	// double check, the metric maybe created by the other writer
+	if id, ok := metrics.Get(nameBytes); ok {
+		return uint32(id), true
+	}
+	id, err := m.seq.Next()
+	if err != nil {
+		logger.GetLogger("tsdb/index").Error("allocate metric id error!", m.dbField,
+			logger.String("metric", metricName), logger.Error(err))
+		return NotFoundMetricID, false
+	}
+	metrics.Put(nameBytes, int(id))
+	return id, true
 }
 
-//SuggestMetrics returns suggestions of metric names given a search prefix.
-func (m *MetricUID) SuggestMetrics(prefix string, limit int16) map[string]struct{} {
-	if len(prefix) > 0 {
-		nameBytes := []byte(prefix)
-		partition := getPartition(nameBytes)
+//getMetricIDFromMemory returns the metric ID which isn't flushed
+func (m *MetricUID) getMetricIDFromMemory(partition uint32, name []byte) (uint32, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	metrics, ok := m.metrics[partition]
+	if !ok {
+		return NotFoundMetricID, false
+	}
+	id, ok := metrics.Get(name)
+	return uint32(id), ok
+}
+
+//SuggestMetrics returns suggestions of metric names given a search prefix,
+//the names are sorted in ascending order, then paged by offset and limit.
+func (m *MetricUID) SuggestMetrics(prefix string, offset, limit int) []string {
+	if offset < 0 || limit <= 0 {
+		return nil
+	}
+	nameBytes := []byte(prefix)
+	names := make(map[string]struct{})
+	if len(nameBytes) > 0 {
+		m.collectMetricNames(getPartition(nameBytes), nameBytes, names)
+	} else {
+		// partition 0 is reserved for metric sequence id
+		for partition := uint32(1); partition <= math.MaxUint8; partition++ {
+			m.collectMetricNames(partition, nil, names)
+		}
+	}
+	return pageStrings(names, offset, limit)
+}
+
+//GetMetricID returns the metric ID associated with a given name from memory or disk,
+//returns NotFoundMetricID if not exist, it does not create the metric.
+func (m *MetricUID) GetMetricID(metricName string) uint32 {
+	id, _ := m.GetOrCreateMetricID(metricName, false)
+	return id
+}
 
+//GetMetricNames returns the names of the metric ids from memory or disk, the id not found is skipped,
+//all metric names are scanned, so it's only used by the cold path, e.g. re-replicating data from the replica.
+func (m *MetricUID) GetMetricNames(metricIDs *roaring.Bitmap) map[uint32]string {
+	names := make(map[uint32]string)
+	collect := func(name []byte, id int) bool {
+		if metricIDs.Contains(uint32(id)) {
+			names[uint32(id)] = string(name)
+		}
+		return uint64(len(names)) < metricIDs.GetCardinality()
+	}
+	m.mutex.RLock()
+	for _, metrics := range m.metrics {
+		metrics.Range(collect)
+	}
+	m.mutex.RUnlock()
+	// partition 0 is reserved for metric sequence id
+	for partition := uint32(1); partition <= math.MaxUint8; partition++ {
+		if uint64(len(names)) == metricIDs.GetCardinality() {
+			break
+		}
 		m.family.Lookup(partition, func(bytes []byte) bool {
-			treeReader := tree.NewReader(bytes)
-			it := treeReader.Seek(nameBytes)
-			if nil != it {
-				//todo
-				logger.GetLogger("tsdb/index").Error("", m.dbField)
+			it := tree.NewReader(bytes).SeekToFirst()
+			for it != nil && it.Next() {
+				if !collect(it.GetKey(), it.GetValue()) {
+					return true
+				}
 			}
-			return true
+			// go on reading the partition of other files
+			return false
 		})
-	} else {
-		//todo
-		logger.GetLogger("tsdb/index").Warn("", m.dbField)
-		//partitions := m.getSortPartition()
 	}
-	return nil
+	return names
+}
+
+//GetMetricIDInSnapshot returns the metric ID associated with a given name from the snapshot of metric family,
+//returns NotFoundMetricID if not exist, the metrics flushed after the snapshot acquired aren't found.
+func (m *MetricUID) GetMetricIDInSnapshot(snapshot kv.FamilySnapshot, metricName string) uint32 {
+	if len(metricName) == 0 {
+		return NotFoundMetricID
+	}
+	nameBytes := []byte(metricName)
+	return getMetricIDFromDisk(snapshot, getPartition(nameBytes), nameBytes)
+}
+
+//collectMetricNames collects metric names under the partition which match the prefix
+func (m *MetricUID) collectMetricNames(partition uint32, prefix []byte, names map[string]struct{}) {
+	m.family.Lookup(partition, func(bytes []byte) bool {
+		collectTreeKeys(tree.NewReader(bytes), prefix, names)
+		// go on reading the partition of other files
+		return false
+	})
 }
 
-//Flush represents forces a flush of in-memory data, and clear it
+//Flush represents forces a flush of in-memory data, and clear it,
+//the lock is held until committed, so that the flushing metric is found in memory or kv store.
 func (m *MetricUID) Flush() error {
-	if m.metrics.Len() == 0 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if len(m.metrics) == 0 {
 		return nil
 	}
-	flusher := m.family.NewFlusher()
-	bs := util.Uint32ToBytes(m.sequenceID)
-	err := flusher.Add(MetricSequenceIDKey, bs)
-	if nil != err {
-		logger.GetLogger("tsdb/index").Error("write metric sequenceID error!", m.dbField, logger.Error(err))
-		return err
+	partitions := make([]uint32, 0, len(m.metrics))
+	for partition := range m.metrics {
+		partitions = append(partitions, partition)
 	}
-
-	writer := tree.NewWriter(m.metrics)
-	byteArray, err := writer.Encode()
-	if nil != err {
-		logger.GetLogger("tsdb/index").Error(" metricTree encode error!", m.dbField, logger.Error(err))
+	// the keys of flusher must be in ascending order
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i] < partitions[j]
+	})
+	flusher := m.family.NewFlusher()
+	for _, partition := range partitions {
+		byteArray, err := tree.NewWriter(m.metrics[partition]).Encode()
+		if nil != err {
+			logger.GetLogger("tsdb/index").Error("metricTree encode error!", m.dbField, logger.Error(err))
+			return err
+		}
+		if err := flusher.Add(partition, byteArray); nil != err {
+			logger.GetLogger("tsdb/index").Error("write metric tree error!",
+				m.dbField, zap.Uint32("partition", partition), logger.Error(err))
+			return err
+		}
 	}
-	err = flusher.Add(m.partition, byteArray)
-	if nil != err {
-		logger.GetLogger("tsdb/index").Error("write metric tree error!",
-			m.dbField, zap.String("partition", string(m.partition)))
+	if err := flusher.Commit(); nil != err {
 		return err
 	}
-	m.metrics.Clear()
-
-	return flusher.Commit()
+	m.metrics = make(map[uint32]*tree.BTree)
+	return nil
 }
 
-// getMetricIdFromDisk return unique int32 id, return -1 if not found
-func (m *MetricUID) getMetricIDFromDisk(partition uint32, metric []byte) uint32 {
+// getMetricIdFromDisk return unique int32 id read by reader, return -1 if not found
+func getMetricIDFromDisk(reader familyReader, partition uint32, metric []byte) uint32 {
 	var metricID = NotFoundMetricID
-	m.family.Lookup(partition, func(bytes []byte) bool {
+	reader.Lookup(partition, func(bytes []byte) bool {
 		treeReader := tree.NewReader(bytes)
 		v, ok := treeReader.Get(metric)
 		if ok {
@@ -1,10 +1,10 @@
 package index
 
 import (
-	"encoding/binary"
 	"fmt"
 	"testing"
 
+	"github.com/RoaringBitmap/roaring"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/eleme/lindb/kv"
@@ -20,7 +20,8 @@ func Test_MeasurementAdd(t *testing.T) {
 	var indexStore, _ = kv.NewStore("index", option)
 	family, _ := indexStore.CreateFamily("measurement", kv.FamilyOption{})
 
-	measurementUID := NewMetricUID(family)
+	seq, _ := NewSequence(MetricSequenceKey, &memorySequenceStore{watermarks: make(map[uint32]uint32)}, 1000)
+	measurementUID := NewMetricUID(family, seq)
 	for i := 0; i < 100; i++ {
 		measurementUID.GetOrCreateMetricID(fmt.Sprintf("%s%d", "key-", i), true)
 	}
@@ -30,11 +31,10 @@ func Test_MeasurementAdd(t *testing.T) {
 		measurementUID.GetOrCreateMetricID(fmt.Sprintf("%s%d", "key-", i), true)
 	}
 
-	for i := 0; i < 100; i++ {
+	for i := 0; i < 200; i++ {
 		id, ok := measurementUID.GetOrCreateMetricID(fmt.Sprintf("%s%d", "key-", i), false)
-		if ok {
-			assert.Equal(t, uint32(i+1), id)
-		}
+		assert.True(t, ok)
+		assert.Equal(t, uint32(i+1), id)
 	}
 
 	_ = indexStore.Close()
@@ -42,15 +42,23 @@ func Test_MeasurementAdd(t *testing.T) {
 	indexStore, _ = kv.NewStore("index", option)
 	family, _ = indexStore.CreateFamily("measurement", kv.FamilyOption{})
 
-	snapshot, _ := family.GetSnapshot(MetricSequenceIDKey)
-	readers := snapshot.Readers()
-	if nil != readers {
-		for _, reader := range readers {
-			byteArray := reader.Get(MetricSequenceIDKey)
-			fmt.Println("re-open:", binary.BigEndian.Uint32(byteArray))
-		}
-	}
+	measurementUID = NewMetricUID(family, seq)
+	assert.Equal(t, 100, len(measurementUID.SuggestMetrics("key-", 0, 1000)))
+	assert.Equal(t, []string{"key-0", "key-1", "key-10"}, measurementUID.SuggestMetrics("key-", 0, 3))
+	assert.Equal(t, []string{"key-11", "key-12"}, measurementUID.SuggestMetrics("key-", 3, 2))
+	assert.Equal(t, []string{"key-0"}, measurementUID.SuggestMetrics("", 0, 1))
+	assert.Nil(t, measurementUID.SuggestMetrics("key-", 1000, 10))
+	assert.Nil(t, measurementUID.SuggestMetrics("not-exist", 0, 10))
+	assert.Nil(t, measurementUID.SuggestMetrics("key-", 0, 0))
 
-	measurementUID.SuggestMetrics("key-", 100)
+	assert.Equal(t, uint32(1), measurementUID.GetMetricID("key-0"))
+	assert.Equal(t, NotFoundMetricID, measurementUID.GetMetricID("key-199"))
+	assert.Equal(t, NotFoundMetricID, measurementUID.GetMetricID(""))
 
+	// the names of metrics in memory and disk
+	measurementUID.GetOrCreateMetricID("new-metric", true)
+	newID := measurementUID.GetMetricID("new-metric")
+	assert.Equal(t, map[uint32]string{1: "key-0", 100: "key-99", newID: "new-metric"},
+		measurementUID.GetMetricNames(roaring.BitmapOf(1, 100, newID, 10000)))
+	_ = indexStore.Close()
 }
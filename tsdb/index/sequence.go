@@ -0,0 +1,116 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/eleme/lindb/kv"
+	"github.com/eleme/lindb/pkg/util"
+)
+
+// defines the keys of sequences in sequence family
+const (
+	MetricSequenceKey uint32 = iota + 1
+	SeriesSequenceKey
+	FieldSequenceKey
+)
+
+// DefaultSequenceBlockSize is the default num. of ids allocated at a time
+const DefaultSequenceBlockSize = 1000
+
+// SequenceStore persists the high watermark of sequences
+type SequenceStore interface {
+	// Load returns the persisted high watermark of sequence, returns 0 if not exist
+	Load(key uint32) (uint32, error)
+	// Save persists the high watermark of sequence durably
+	Save(key uint32, watermark uint32) error
+}
+
+// kvSequenceStore implements SequenceStore based on kv family
+type kvSequenceStore struct {
+	family kv.Family
+}
+
+// NewKVSequenceStore creates the sequence store based on kv family
+func NewKVSequenceStore(family kv.Family) SequenceStore {
+	return &kvSequenceStore{family: family}
+}
+
+// Load returns the max watermark in all the files of family,
+// because the watermark is increasing, the order of files doesn't matter.
+func (s *kvSequenceStore) Load(key uint32) (uint32, error) {
+	watermark := uint32(0)
+	err := s.family.LookupWithContext(context.TODO(), key, func(value []byte) bool {
+		if v := util.BytesToUint32(value); v > watermark {
+			watermark = v
+		}
+		// go on reading the other files
+		return false
+	})
+	if err != nil {
+		return 0, err
+	}
+	return watermark, nil
+}
+
+// Save writes the watermark into a new file of family, returns after the edit log committed
+func (s *kvSequenceStore) Save(key uint32, watermark uint32) error {
+	flusher := s.family.NewFlusher()
+	if err := flusher.Add(key, util.Uint32ToBytes(watermark)); err != nil {
+		return err
+	}
+	return flusher.Commit()
+}
+
+// Sequence allocates the unique ids of a type under the database, the ids are allocated in blocks,
+// the high watermark of block is persisted before any id of the block is returned,
+// so that the ids are never reused after crash, the cached ids which aren't used are skipped.
+type Sequence struct {
+	key       uint32
+	store     SequenceStore
+	blockSize uint32
+
+	mutex sync.Mutex
+	next  uint32 // next id to return
+	limit uint32 // high watermark of allocated block, inclusive
+}
+
+// NewSequence creates the sequence which starts from the persisted high watermark
+func NewSequence(key uint32, store SequenceStore, blockSize uint32) (*Sequence, error) {
+	if blockSize == 0 {
+		blockSize = DefaultSequenceBlockSize
+	}
+	watermark, err := store.Load(key)
+	if err != nil {
+		return nil, fmt.Errorf("load sequence[%d] error:%s", key, err)
+	}
+	return &Sequence{
+		key:       key,
+		store:     store,
+		blockSize: blockSize,
+		next:      watermark + 1,
+		limit:     watermark,
+	}, nil
+}
+
+// Next returns the next id(starts from 1), allocates a new block if cached ids are used up
+func (s *Sequence) Next() (uint32, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.next > s.limit {
+		if s.limit > math.MaxUint32-s.blockSize {
+			return 0, fmt.Errorf("sequence[%d] is exhausted", s.key)
+		}
+		limit := s.limit + s.blockSize
+		if err := s.store.Save(s.key, limit); err != nil {
+			return 0, fmt.Errorf("allocate block of sequence[%d] error:%s", s.key, err)
+		}
+		s.limit = limit
+	}
+	id := s.next
+	s.next++
+	return id, nil
+}
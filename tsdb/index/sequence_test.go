@@ -0,0 +1,107 @@
+package index
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/kv"
+	"github.com/eleme/lindb/pkg/util"
+)
+
+// memorySequenceStore stores the watermark in memory for testing
+type memorySequenceStore struct {
+	watermarks map[uint32]uint32
+	saves      int
+	err        error
+}
+
+func (s *memorySequenceStore) Load(key uint32) (uint32, error) {
+	return s.watermarks[key], s.err
+}
+
+func (s *memorySequenceStore) Save(key uint32, watermark uint32) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.saves++
+	s.watermarks[key] = watermark
+	return nil
+}
+
+func TestSequence_Next(t *testing.T) {
+	store := &memorySequenceStore{watermarks: make(map[uint32]uint32)}
+	seq, err := NewSequence(MetricSequenceKey, store, 10)
+	assert.Nil(t, err)
+	for i := 1; i <= 25; i++ {
+		id, err := seq.Next()
+		assert.Nil(t, err)
+		assert.Equal(t, uint32(i), id)
+	}
+	// allocates a block for each 10 ids
+	assert.Equal(t, 3, store.saves)
+	assert.Equal(t, uint32(30), store.watermarks[MetricSequenceKey])
+
+	// restart after crash, the cached ids are skipped
+	seq, err = NewSequence(MetricSequenceKey, store, 10)
+	assert.Nil(t, err)
+	id, err := seq.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(31), id)
+
+	// allocate block error
+	store.err = fmt.Errorf("err")
+	seq, err = NewSequence(MetricSequenceKey, store, 0)
+	assert.NotNil(t, err)
+	assert.Nil(t, seq)
+	seq = &Sequence{key: MetricSequenceKey, store: store, blockSize: 10, next: 1}
+	_, err = seq.Next()
+	assert.NotNil(t, err)
+
+	// exhausted
+	store.err = nil
+	seq = &Sequence{key: MetricSequenceKey, store: store, blockSize: 10, next: 1<<32 - 5, limit: 1<<32 - 6}
+	_, err = seq.Next()
+	assert.NotNil(t, err)
+}
+
+func TestKVSequenceStore(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "sequence")
+	defer func() {
+		_ = util.RemoveDir(dir)
+	}()
+	option := kv.DefaultStoreOption(filepath.Join(dir, "index"))
+	store, err := kv.NewStore("index", option)
+	assert.Nil(t, err)
+	family, _ := store.CreateFamily("sequence", kv.FamilyOption{})
+
+	seqStore := NewKVSequenceStore(family)
+	watermark, err := seqStore.Load(MetricSequenceKey)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(0), watermark)
+
+	seq, _ := NewSequence(MetricSequenceKey, seqStore, 2)
+	for i := 0; i < 5; i++ {
+		_, _ = seq.Next()
+	}
+	seq, _ = NewSequence(SeriesSequenceKey, seqStore, 2)
+	_, _ = seq.Next()
+	_ = store.Close()
+
+	// reopen store, loads max watermark of all files
+	store, err = kv.NewStore("index", option)
+	assert.Nil(t, err)
+	defer func() {
+		_ = store.Close()
+	}()
+	seqStore = NewKVSequenceStore(store.GetFamily("sequence"))
+	watermark, err = seqStore.Load(MetricSequenceKey)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(6), watermark)
+	watermark, err = seqStore.Load(SeriesSequenceKey)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(2), watermark)
+}
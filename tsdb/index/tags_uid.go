@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"sort"
+	"sync"
 
 	"github.com/eleme/lindb/kv"
 	"github.com/eleme/lindb/pkg/logger"
@@ -14,17 +15,57 @@ import (
 	"go.uber.org/zap"
 )
 
-//TagsUID represents tags unique id under the metric name.
+//TagsUID represents tags unique id under the metric name,
+//the tags ids(series ids) are allocated from the persistent sequence of database on the first write of series,
+//the mappings of tags to id are stored in series family, which are looked up by the exact tags,
+//the tag value bitmaps are stored in tags family for filtering and suggestion.
+//The new series are kept in memory by metric until flushed into kv store.
 type TagsUID struct {
-	metricID  uint32
-	tagsIDMap map[uint32]uint32
+	seq     *Sequence
+	mutex   sync.RWMutex
+	metrics map[uint32]*metricTags //key is metric id, the tags which aren't flushed
+
+	family            kv.Family
+	cardinalityFamily kv.Family
+	seriesFamily      kv.Family
+	dbField           zap.Field
+}
+
+//metricTags represents the in-memory tags index of a metric
+type metricTags struct {
+	series      map[string]uint32 //tags => tags id
+	tagsMap     map[string]*tree.BTree
+	bitmaps     []*roaring.Bitmap
+	cardinality *MetricCardinality //sketches of series and tag values
+}
 
-	tagsMap   map[string]*tree.BTree
-	bitmaps   []*roaring.Bitmap
-	bitmapSeq uint32 //bitmap sequence Id
+//newMetricTags returns the empty tags index of metric
+func newMetricTags() *metricTags {
+	return &metricTags{
+		series:      make(map[string]uint32),
+		tagsMap:     make(map[string]*tree.BTree),
+		cardinality: NewMetricCardinality(),
+	}
+}
 
-	family  kv.Family
-	dbField zap.Field
+//add adds the tags id into the bitmaps of tag values
+func (mt *metricTags) add(tags string, tagsMap map[string]string, tagsID uint32) {
+	mt.series[tags] = tagsID
+	for tagName, tagValue := range tagsMap {
+		tagTree, ok := mt.tagsMap[tagName]
+		if !ok {
+			tagTree = tree.NewBTree()
+			mt.tagsMap[tagName] = tagTree
+		}
+		bitmapIdx, ok := tagTree.Get([]byte(tagValue))
+		if !ok {
+			bitmapIdx = len(mt.bitmaps)
+			mt.bitmaps = append(mt.bitmaps, roaring.New())
+			tagTree.Put([]byte(tagValue), bitmapIdx)
+		}
+		mt.bitmaps[bitmapIdx].Add(tagsID)
+	}
+	mt.cardinality.AddSeries(tagsMap)
 }
 
 //TagsReader represents parses tags byte arrays for reading
@@ -36,13 +77,14 @@ type TagsReader struct {
 	bitmapPosition       int
 }
 
-//NewTagsUID creation requires kvFamily
-func NewTagsUID(f kv.Family) *TagsUID {
+//NewTagsUID creation requires kvFamily of tags, cardinality sketches and series, and the sequence of tags id
+func NewTagsUID(f kv.Family, cardinalityFamily kv.Family, seriesFamily kv.Family, seq *Sequence) *TagsUID {
 	return &TagsUID{
-		bitmaps:   make([]*roaring.Bitmap, 8),
-		tagsMap:   make(map[string]*tree.BTree),
-		tagsIDMap: make(map[uint32]uint32),
-		family:    f,
+		seq:               seq,
+		metrics:           make(map[uint32]*metricTags),
+		family:            f,
+		cardinalityFamily: cardinalityFamily,
+		seriesFamily:      seriesFamily,
 	}
 }
 
@@ -81,21 +123,11 @@ func newTagsReader(byteArray []byte) *TagsReader {
 
 //getTagValueBitmap returns tag value bitmap from disk
 func (tr *TagsReader) getTagValueBitmap(tagName, tagValue string) *roaring.Bitmap {
-	offset, ok := tr.tagNameOffset[tagName]
-	if ok {
-		tr.reader.NewPosition(tr.tagTreePosition + offset)
-		treeLen := int(tr.reader.ReadInt())
-		treeBytes := tr.reader.ReadBytes(treeLen)
-		treeReader := tree.NewReader(treeBytes)
+	treeReader := tr.tagTreeReader(tagName)
+	if treeReader != nil {
 		bitmapIdx, ok := treeReader.Get([]byte(tagValue))
 		if ok {
-			tr.reader.NewPosition(tr.bitmapOffsetPosition + bitmapIdx*4)
-			bitmapPos := int(tr.reader.ReadUInt32())
-			tr.reader.NewPosition(tr.bitmapPosition + bitmapPos)
-			pos := tr.reader.GetPosition()
-
-			bitmap := roaring.New()
-			_, err := bitmap.ReadFrom(bytes.NewBuffer(tr.reader.SubArray(pos)))
+			bitmap, err := tr.readBitmap(bitmapIdx)
 			if nil != err {
 				logger.GetLogger("tsdb/index").Error("decode bitmap error:", zap.String(tagName, tagValue), logger.Error(err))
 				return nil
@@ -106,24 +138,37 @@ func (tr *TagsReader) getTagValueBitmap(tagName, tagValue string) *roaring.Bitma
 	return nil
 }
 
-//seek returns prefix tag value Iterator
-func (tr *TagsReader) seek(tagName string, prefix []byte) tree.Iterator {
+//readBitmap returns the bitmap of the index from disk
+func (tr *TagsReader) readBitmap(bitmapIdx int) (*roaring.Bitmap, error) {
+	tr.reader.NewPosition(tr.bitmapOffsetPosition + bitmapIdx*4)
+	bitmapPos := int(tr.reader.ReadUInt32())
+	tr.reader.NewPosition(tr.bitmapPosition + bitmapPos)
+	pos := tr.reader.GetPosition()
+
+	bitmap := roaring.New()
+	if _, err := bitmap.ReadFrom(bytes.NewBuffer(tr.reader.SubArray(pos))); nil != err {
+		return nil, err
+	}
+	return bitmap, nil
+}
+
+//tagTreeReader returns the tag value tree reader of the tag name, returns nil if not exist
+func (tr *TagsReader) tagTreeReader(tagName string) *tree.Reader {
 	offset, ok := tr.tagNameOffset[tagName]
-	if ok {
-		tr.reader.NewPosition(tr.tagTreePosition + offset)
-		treeLen := int(tr.reader.ReadInt())
-		treeBytes := tr.reader.ReadBytes(treeLen)
-		treeReader := tree.NewReader(treeBytes)
-		return treeReader.Seek(prefix)
+	if !ok {
+		return nil
 	}
-	return nil
+	tr.reader.NewPosition(tr.tagTreePosition + offset)
+	treeLen := int(tr.reader.ReadInt())
+	treeBytes := tr.reader.ReadBytes(treeLen)
+	return tree.NewReader(treeBytes)
 }
 
-//getSortTagNames returns get the sorted tag names
-func (t *TagsUID) getSortTagNames() []string {
-	tagNames := make([]string, len(t.tagsMap))
+//sortedTagNames returns get the sorted tag names
+func sortedTagNames(tagsMap map[string]*tree.BTree) []string {
+	tagNames := make([]string, len(tagsMap))
 	var idx int
-	for tagName := range t.tagsMap {
+	for tagName := range tagsMap {
 		tagNames[idx] = tagName
 		idx++
 	}
@@ -131,229 +176,388 @@ func (t *TagsUID) getSortTagNames() []string {
 	return tagNames
 }
 
-//GetOrCreateTagsID returns find the tags ID associated with given tags or create it.
+//GetOrCreateTagsID returns find the tags ID associated with given tags or create it,
+//the id is allocated from sequence only if the exact tags aren't found in memory or kv store.
 func (t *TagsUID) GetOrCreateTagsID(metricID uint32, tags string) (uint32, error) {
-	tagsMap := StringToMap(tags)
-	//load from kv-store
-	tagsID := t.getTagsIDFromDisk(metricID, tagsMap)
-	if tagsID == NotFoundTagsID {
-		if t.metricID != metricID {
-			err := t.Flush()
-			if nil != err {
-				logger.GetLogger("tsdb/index").Error("flush metric field error!", t.dbField, logger.Error(err))
-				return NotFoundTagsID, err
-			}
-			t.metricID = metricID
-			t.clear()
-		}
+	if tagsID, ok := t.getTagsIDFromMemory(metricID, tags); ok {
+		return tagsID, nil
+	}
+	if tagsID := getTagsIDFromDisk(t.seriesFamily, metricID, tags); tagsID != NotFoundTagsID {
+		return tagsID, nil
+	}
 
-		tagsID = t.tagsIDMap[metricID] + 1
-		//tagsMap -> tagsID
-		for tagName, tagValue := range tagsMap {
-			tagTree, ok := t.tagsMap[tagName]
-			if !ok {
-				tagTree = tree.NewBTree()
-				t.tagsMap[tagName] = tagTree
-			}
-			var bitmapIdx uint32
-			v, ok := tagTree.Get([]byte(tagValue))
-			if !ok {
-				bitmapIdx = t.bitmapSeq
-				if bitmapIdx >= uint32(len(t.bitmaps)) {
-					target := make([]*roaring.Bitmap, len(t.bitmaps)*2)
-					copy(target, t.bitmaps)
-					t.bitmaps = target
-				}
-				t.bitmaps[bitmapIdx] = roaring.New()
-				tagTree.Put([]byte(tagValue), int(bitmapIdx))
-				t.bitmapSeq++
-			} else {
-				bitmapIdx = uint32(v)
-			}
-			bitmap := t.bitmaps[bitmapIdx]
-			bitmap.Add(tagsID)
-		}
-		t.tagsIDMap[metricID]++
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	mt, ok := t.metrics[metricID]
+	if !ok {
+		mt = newMetricTags()
+		t.metrics[metricID] = mt
+	}
+	// double check, the series maybe created by the other writer
+	if tagsID, ok := mt.series[tags]; ok {
 		return tagsID, nil
 	}
+	tagsID, err := t.seq.Next()
+	if err != nil {
+		return NotFoundTagsID, fmt.Errorf("allocate tags id of metric[%d] error:%s", metricID, err)
+	}
+	mt.add(tags, StringToMap(tags), tagsID)
 	return tagsID, nil
 }
 
-func (t *TagsUID) clear() {
-	//clear all
-	for k := range t.tagsMap {
-		delete(t.tagsMap, k)
+//getTagsIDFromMemory returns the tags ID which isn't flushed
+func (t *TagsUID) getTagsIDFromMemory(metricID uint32, tags string) (uint32, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	mt, ok := t.metrics[metricID]
+	if !ok {
+		return NotFoundTagsID, false
 	}
-	t.bitmapSeq = 0
-	t.bitmaps = make([]*roaring.Bitmap, 8)
+	tagsID, ok := mt.series[tags]
+	return tagsID, ok
 }
 
-//getTagsIDFromDisk returns find the tags ID associated with given tags
-func (t *TagsUID) getTagsIDFromDisk(metricID uint32, tags map[string]string) uint32 {
-	var result *roaring.Bitmap
-	t.family.Lookup(metricID, func(byteArray []byte) bool {
-		tagsReader := newTagsReader(byteArray)
-		for tagName, tagValue := range tags {
-			bitmap := tagsReader.getTagValueBitmap(tagName, tagValue)
-			if nil == bitmap {
-				return true
-			}
-			if nil == result {
-				result = bitmap
-			} else {
-				result.And(bitmap)
-			}
-		}
-		if nil != result && !result.IsEmpty() {
+//getTagsIDFromDisk returns find the tags ID associated with the exact tags in the series family
+func getTagsIDFromDisk(reader familyReader, metricID uint32, tags string) uint32 {
+	var tagsID = NotFoundTagsID
+	reader.Lookup(metricID, func(byteArray []byte) bool {
+		if v, ok := tree.NewReader(byteArray).Get([]byte(tags)); ok {
+			tagsID = uint32(v)
 			return true
 		}
+		// go on reading the series of other files
 		return false
 	})
-	if nil != result && !result.IsEmpty() {
-		//first tags ID
-		return result.Iterator().Next()
+	return tagsID
+}
+
+//GetSeriesTags returns the tags of the series ids under the metric, which are found in memory or kv store
+func (t *TagsUID) GetSeriesTags(metricID uint32, seriesIDs *roaring.Bitmap) map[uint32]string {
+	result := make(map[uint32]string)
+	t.mutex.RLock()
+	if mt, ok := t.metrics[metricID]; ok {
+		for tags, tagsID := range mt.series {
+			if seriesIDs.Contains(tagsID) {
+				result[tagsID] = tags
+			}
+		}
+	}
+	t.mutex.RUnlock()
+	if uint64(len(result)) == seriesIDs.GetCardinality() {
+		return result
 	}
-	return NotFoundTagsID
+	t.seriesFamily.Lookup(metricID, func(byteArray []byte) bool {
+		it := tree.NewReader(byteArray).SeekToFirst()
+		for it.Next() {
+			tagsID := uint32(it.GetValue())
+			if seriesIDs.Contains(tagsID) {
+				result[tagsID] = string(it.GetKey())
+			}
+		}
+		// go on reading the series of other files
+		return uint64(len(result)) == seriesIDs.GetCardinality()
+	})
+	return result
 }
 
 //GetTagValueBitmap returns find bitmap associated with a given tag value
 func (t *TagsUID) GetTagValueBitmap(metricID uint32, tagName string, tagValue string) *roaring.Bitmap {
+	return getTagValueBitmap(t.family, metricID, tagName, tagValue)
+}
+
+//GetTagValueBitmapInSnapshot returns find bitmap associated with a given tag value from the snapshot of tags family,
+//the tags flushed after the snapshot acquired aren't included.
+func (t *TagsUID) GetTagValueBitmapInSnapshot(snapshot kv.FamilySnapshot, metricID uint32,
+	tagName string, tagValue string) *roaring.Bitmap {
+	return getTagValueBitmap(snapshot, metricID, tagName, tagValue)
+}
+
+//getTagValueBitmap returns the union of bitmaps associated with a given tag value in the files read by reader
+func getTagValueBitmap(reader familyReader, metricID uint32, tagName string, tagValue string) *roaring.Bitmap {
 	var result *roaring.Bitmap
-	t.family.Lookup(metricID, func(byteArray []byte) bool {
+	reader.Lookup(metricID, func(byteArray []byte) bool {
 		tagsReader := newTagsReader(byteArray)
 		bitmap := tagsReader.getTagValueBitmap(tagName, tagValue)
 		if nil != bitmap {
-			result = bitmap
-			return true
+			if nil == result {
+				result = bitmap
+			} else {
+				result.Or(bitmap)
+			}
 		}
+		// go on reading the bitmaps of other files which aren't compacted
 		return false
 	})
 	return result
 }
 
-//GetTagNames return get all tag names within the metric name
-func (t *TagsUID) GetTagNames(metricID uint32, limit int16) map[string]struct{} {
-	//todo
+//GetTagNames returns the sorted tag names within the metric name, at most limit names
+func (t *TagsUID) GetTagNames(metricID uint32, limit int) []string {
+	tagNames := make(map[string]struct{})
 	t.family.Lookup(metricID, func(byteArray []byte) bool {
 		tagsReader := newTagsReader(byteArray)
 		for tagName := range tagsReader.tagNameOffset {
-			fmt.Println("xx====", tagName)
+			tagNames[tagName] = struct{}{}
 		}
+		// go on reading tag names of other files
 		return false
 	})
-	return nil
+	return pageStrings(tagNames, 0, limit)
 }
 
-//SuggestTagValues returns suggestions of tag values given a search prefix
-func (t *TagsUID) SuggestTagValues(metricID uint32, tagName string, tagValuePrefix string,
-	limit uint16) map[string]struct{} {
-	//todo
+//SuggestTagValues returns the sorted suggestions of tag values given a search prefix, at most limit values
+func (t *TagsUID) SuggestTagValues(metricID uint32, tagName string, tagValuePrefix string, limit int) []string {
+	tagValues := make(map[string]struct{})
+	prefix := []byte(tagValuePrefix)
 	t.family.Lookup(metricID, func(byteArray []byte) bool {
 		tagsReader := newTagsReader(byteArray)
-		it := tagsReader.seek(tagName, []byte(tagValuePrefix))
-		if nil != it {
-			for it.Next() {
-				fmt.Println("seek:", string(it.GetKey()))
-			}
+		if treeReader := tagsReader.tagTreeReader(tagName); treeReader != nil {
+			collectTreeKeys(treeReader, prefix, tagValues)
 		}
+		// go on reading tag values of other files
 		return false
 	})
-	return nil
+	return pageStrings(tagValues, 0, limit)
 }
 
-//Flush represents forces a flush of in-memory data, and clear it
+//Flush represents forces a flush of in-memory data, and clear it,
+//the lock is held until committed, so that the flushing series is found in memory or kv store.
 func (t *TagsUID) Flush() error {
-	if len(t.tagsIDMap) > 0 {
-		writer := stream.BinaryWriter()
-		tagNameOffset := stream.BinaryWriter()
-		tagTreeWriter := stream.BinaryWriter()
-		bitmapOffset := stream.BinaryWriter()
-		bitmapWriter := stream.BinaryWriter()
-
-		//sort tag names
-		tagNames := t.getSortTagNames()
-		for _, tagName := range tagNames {
-			tagTree := t.tagsMap[tagName]
-
-			tagNameOffset.PutKey([]byte(tagName))
-			tagNameOffset.PutUvarint64(uint64(tagTreeWriter.Len()))
-
-			by, err := tree.NewWriter(tagTree).Encode()
-			if nil != err {
-				logger.GetLogger("tsdb/index").Error("encode tag tree error:", t.dbField, logger.Error(err))
-				return err
-			}
-			tagTreeWriter.PutUvarint64(uint64(len(by)))
-			tagTreeWriter.PutBytes(by)
-		}
-
-		for _, bitmap := range t.bitmaps {
-			if nil != bitmap {
-				bitmapOffset.PutUInt32(uint32(bitmapWriter.Len()))
-
-				bitmap.RunOptimize()
-				bitmapBytes, err := bitmap.ToBytes()
-				if nil != err {
-					logger.GetLogger("tsdb/index").Error("encode tags bitmap error:", t.dbField, logger.Error(err))
-					return err
-				}
-				bitmapWriter.PutBytes(bitmapBytes)
-			}
-		}
-		//
-		writer.PutUvarint64(uint64(len(tagNames)))
-		by, err := tagNameOffset.Bytes()
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if len(t.metrics) == 0 {
+		return nil
+	}
+	metricIDs := make([]uint32, 0, len(t.metrics))
+	for metricID := range t.metrics {
+		metricIDs = append(metricIDs, metricID)
+	}
+	// the keys of flusher must be in ascending order
+	sort.Slice(metricIDs, func(i, j int) bool {
+		return metricIDs[i] < metricIDs[j]
+	})
+	tagsFlusher := t.family.NewFlusher()
+	cardinalityFlusher := t.cardinalityFamily.NewFlusher()
+	seriesFlusher := t.seriesFamily.NewFlusher()
+	for _, metricID := range metricIDs {
+		mt := t.metrics[metricID]
+		by, err := encodeTags(mt.tagsMap, mt.bitmaps)
 		if nil != err {
-			logger.GetLogger("tsdb/index").Error("encode tag names error: ", t.dbField, logger.Error(err))
+			logger.GetLogger("tsdb/index").Error("encode tags data error:", t.dbField, logger.Error(err))
 			return err
 		}
-		writer.PutBytes(by)
-
-		//write tagTree
-		writer.PutUvarint64(uint64(tagTreeWriter.Len()))
-		by, err = tagTreeWriter.Bytes()
-		if nil != err {
-			logger.GetLogger("tsdb/index").Error("encode tag tree writer error:", t.dbField, logger.Error(err))
+		if err := tagsFlusher.Add(metricID, by); nil != err {
+			logger.GetLogger("tsdb/index").Error("write metric tags error!",
+				t.dbField, zap.Uint32("metricID", metricID), logger.Error(err))
 			return err
 		}
-		writer.PutBytes(by)
-
-		//write bitmap offset
-		writer.PutUvarint64(uint64(bitmapOffset.Len()))
-		by, err = bitmapOffset.Bytes()
-		if nil != err {
-			logger.GetLogger("tsdb/index").Error("encode bitmap offset error:", t.dbField, logger.Error(err))
+		if by, err = mt.cardinality.MarshalBinary(); nil != err {
 			return err
 		}
-		writer.PutBytes(by)
-
-		//write bitmap
-		by, err = bitmapWriter.Bytes()
-		if nil != err {
-			logger.GetLogger("tsdb/index").Error("encode bitmap writer error:", t.dbField, logger.Error(err))
+		if err := cardinalityFlusher.Add(metricID, by); nil != err {
 			return err
 		}
-		writer.PutBytes(by)
-
-		//flush tagsID to kv-store
-		flusher := t.family.NewFlusher()
-		by, err = writer.Bytes()
-		if nil != err {
-			logger.GetLogger("tsdb/index").Error("encode tags data error:", t.dbField, logger.Error(err))
+		if by, err = encodeSeries(mt.series); nil != err {
 			return err
 		}
-		err = flusher.Add(t.metricID, by)
-		if nil != err {
-			logger.GetLogger("tsdb/index").Error("write metric tags error!",
-				t.dbField, zap.String("metricID", string(t.metricID)), logger.Error(err))
+		if err := seriesFlusher.Add(metricID, by); nil != err {
 			return err
 		}
-		err = flusher.Commit()
+	}
+	if err := tagsFlusher.Commit(); nil != err {
+		logger.GetLogger("tsdb/index").Error("flush metric tags error!", t.dbField, logger.Error(err))
+		return err
+	}
+	if err := cardinalityFlusher.Commit(); nil != err {
+		logger.GetLogger("tsdb/index").Error("flush metric cardinality error!", t.dbField, logger.Error(err))
+		return err
+	}
+	// the series are committed at last, so that the tags id is found after the bitmaps committed
+	if err := seriesFlusher.Commit(); nil != err {
+		logger.GetLogger("tsdb/index").Error("flush metric series error!", t.dbField, logger.Error(err))
+		return err
+	}
+	t.metrics = make(map[uint32]*metricTags)
+	return nil
+}
+
+//encodeSeries encodes the mappings of tags to tags id as B+Tree
+func encodeSeries(series map[string]uint32) ([]byte, error) {
+	seriesTree := tree.NewBTree()
+	for tags, tagsID := range series {
+		seriesTree.Put([]byte(tags), int(tagsID))
+	}
+	by, err := tree.NewWriter(seriesTree).Encode()
+	if nil != err {
+		return nil, fmt.Errorf("encode series tree error:%s", err)
+	}
+	return by, nil
+}
+
+//GetCardinality returns the sketches of series and tag values under the metric merged from all flushes,
+//returns nil if the metric hasn't been flushed.
+func (t *TagsUID) GetCardinality(metricID uint32) (*MetricCardinality, error) {
+	var result *MetricCardinality
+	var err error
+	t.cardinalityFamily.Lookup(metricID, func(byteArray []byte) bool {
+		cardinality := NewMetricCardinality()
+		if err = cardinality.UnmarshalBinary(byteArray); nil != err {
+			return true
+		}
+		if nil == result {
+			result = cardinality
+		} else {
+			result.Merge(cardinality)
+		}
+		// go on reading the sketches of other files which aren't compacted
+		return false
+	})
+	if nil != err {
+		return nil, fmt.Errorf("read cardinality of metric[%d] error:%s", metricID, err)
+	}
+	return result, nil
+}
+
+//Compact merges the tags data, sketches and series of each metric flushed into different files,
+//so that the bitmap of tag value is read from one file.
+func (t *TagsUID) Compact() error {
+	if err := t.family.Compact(NewTagsMerger()); nil != err {
+		return err
+	}
+	if err := t.seriesFamily.Compact(NewSeriesMerger()); nil != err {
+		return err
+	}
+	return t.cardinalityFamily.Compact(NewCardinalityMerger())
+}
+
+//seriesMerger implements kv.Merger, merges the series of the metric in different files
+type seriesMerger struct {
+}
+
+//NewSeriesMerger returns the merger of series, the mappings of tags to tags id are unioned
+func NewSeriesMerger() kv.Merger {
+	return &seriesMerger{}
+}
+
+//Merge merges the series trees of metric
+func (m *seriesMerger) Merge(metricID uint32, values [][]byte) ([]byte, error) {
+	series := make(map[string]uint32)
+	for _, value := range values {
+		it := tree.NewReader(value).SeekToFirst()
+		for it.Next() {
+			series[string(it.GetKey())] = uint32(it.GetValue())
+		}
+	}
+	return encodeSeries(series)
+}
+
+//tagsMerger implements kv.Merger, merges the tags data of the metric in different files
+type tagsMerger struct {
+}
+
+//NewTagsMerger returns the merger of tags data, the bitmaps of same tag value are unioned into one bitmap
+func NewTagsMerger() kv.Merger {
+	return &tagsMerger{}
+}
+
+//Merge merges the tag value trees and unions the bitmaps of same tag value
+func (m *tagsMerger) Merge(metricID uint32, values [][]byte) ([]byte, error) {
+	tagsMap := make(map[string]*tree.BTree)
+	var bitmaps []*roaring.Bitmap
+	for _, value := range values {
+		tagsReader := newTagsReader(value)
+		for tagName := range tagsReader.tagNameOffset {
+			tagTree, ok := tagsMap[tagName]
+			if !ok {
+				tagTree = tree.NewBTree()
+				tagsMap[tagName] = tagTree
+			}
+			it := tagsReader.tagTreeReader(tagName).SeekToFirst()
+			for it.Next() {
+				bitmap, err := tagsReader.readBitmap(it.GetValue())
+				if nil != err {
+					return nil, fmt.Errorf("decode bitmap of metric[%d] tag[%s] error:%s", metricID, tagName, err)
+				}
+				tagValue := it.GetKey()
+				if bitmapIdx, ok := tagTree.Get(tagValue); ok {
+					bitmaps[bitmapIdx].Or(bitmap)
+					continue
+				}
+				// copy tag value, because the value bytes maybe mapped from file
+				tagTree.Put(append([]byte(nil), tagValue...), len(bitmaps))
+				bitmaps = append(bitmaps, bitmap)
+			}
+		}
+	}
+	return encodeTags(tagsMap, bitmaps)
+}
+
+//encodeTags encodes the tag value trees and bitmaps,
+//format: tag names offset, tag value trees, bitmap offsets, bitmaps
+func encodeTags(tagsMap map[string]*tree.BTree, bitmaps []*roaring.Bitmap) ([]byte, error) {
+	writer := stream.BinaryWriter()
+	tagNameOffset := stream.BinaryWriter()
+	tagTreeWriter := stream.BinaryWriter()
+	bitmapOffset := stream.BinaryWriter()
+	bitmapWriter := stream.BinaryWriter()
+
+	//sort tag names
+	tagNames := sortedTagNames(tagsMap)
+	for _, tagName := range tagNames {
+		tagTree := tagsMap[tagName]
+
+		tagNameOffset.PutKey([]byte(tagName))
+		tagNameOffset.PutUvarint64(uint64(tagTreeWriter.Len()))
+
+		by, err := tree.NewWriter(tagTree).Encode()
 		if nil != err {
-			logger.GetLogger("tsdb/index").Error("flush metric tags error!", t.dbField, logger.Error(err))
-			return err
+			return nil, fmt.Errorf("encode tag tree error:%s", err)
 		}
-		t.clear()
+		tagTreeWriter.PutUvarint64(uint64(len(by)))
+		tagTreeWriter.PutBytes(by)
 	}
-	return nil
+
+	for _, bitmap := range bitmaps {
+		if nil != bitmap {
+			bitmapOffset.PutUInt32(uint32(bitmapWriter.Len()))
+
+			bitmap.RunOptimize()
+			bitmapBytes, err := bitmap.ToBytes()
+			if nil != err {
+				return nil, fmt.Errorf("encode tags bitmap error:%s", err)
+			}
+			bitmapWriter.PutBytes(bitmapBytes)
+		}
+	}
+	//
+	writer.PutUvarint64(uint64(len(tagNames)))
+	by, err := tagNameOffset.Bytes()
+	if nil != err {
+		return nil, fmt.Errorf("encode tag names error:%s", err)
+	}
+	writer.PutBytes(by)
+
+	//write tagTree
+	writer.PutUvarint64(uint64(tagTreeWriter.Len()))
+	by, err = tagTreeWriter.Bytes()
+	if nil != err {
+		return nil, fmt.Errorf("encode tag tree writer error:%s", err)
+	}
+	writer.PutBytes(by)
+
+	//write bitmap offset
+	writer.PutUvarint64(uint64(bitmapOffset.Len()))
+	by, err = bitmapOffset.Bytes()
+	if nil != err {
+		return nil, fmt.Errorf("encode bitmap offset error:%s", err)
+	}
+	writer.PutBytes(by)
+
+	//write bitmap
+	by, err = bitmapWriter.Bytes()
+	if nil != err {
+		return nil, fmt.Errorf("encode bitmap writer error:%s", err)
+	}
+	writer.PutBytes(by)
+	return writer.Bytes()
 }
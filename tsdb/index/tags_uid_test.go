@@ -7,6 +7,7 @@ import (
 
 	"github.com/eleme/lindb/kv"
 	"github.com/eleme/lindb/pkg/stream"
+	"github.com/eleme/lindb/pkg/tree"
 	"github.com/eleme/lindb/pkg/util"
 
 	"github.com/RoaringBitmap/roaring"
@@ -75,29 +76,150 @@ func TestTagsUid_GetTagNames(t *testing.T) {
 	defer util.RemoveDir("../test")
 	tagsUID := initTags()
 	for i := 1; i < 10; i++ {
-		tagsUID.GetTagNames(uint32(i), 100)
-		fmt.Println("xxxxx")
+		assert.Equal(t, []string{"a", "b"}, tagsUID.GetTagNames(uint32(i), 100))
+		assert.Equal(t, []string{"a"}, tagsUID.GetTagNames(uint32(i), 1))
 	}
+	assert.Nil(t, tagsUID.GetTagNames(uint32(100), 100))
 }
 
 func TestTagsUid_SuggestTagValues(t *testing.T) {
 	defer util.RemoveDir("../test")
 	tagsUID := initTags()
 	for i := 1; i < 10; i++ {
-		tagsUID.SuggestTagValues(uint32(i), "a", "value-1-", 100)
-		tagsUID.SuggestTagValues(uint32(i), "a", "v", 100)
-		tagsUID.SuggestTagValues(uint32(i), "b", "value-2-", 100)
-		tagsUID.SuggestTagValues(uint32(i), "b", "v", 100)
+		assert.Equal(t, count-1, len(tagsUID.SuggestTagValues(uint32(i), "a", "value-1-", 100)))
+		assert.Equal(t, count-1, len(tagsUID.SuggestTagValues(uint32(i), "a", "v", 100)))
+		assert.Equal(t, count-1, len(tagsUID.SuggestTagValues(uint32(i), "b", "", 100)))
+		assert.Equal(t, []string{"value-2-1", "value-2-10"}, tagsUID.SuggestTagValues(uint32(i), "b", "value-2-1", 100))
+		assert.Equal(t, []string{"value-2-1"}, tagsUID.SuggestTagValues(uint32(i), "b", "v", 1))
+		assert.Nil(t, tagsUID.SuggestTagValues(uint32(i), "b", "x", 100))
+		assert.Nil(t, tagsUID.SuggestTagValues(uint32(i), "c", "v", 100))
+	}
+}
+
+func TestTagsUid_Compact(t *testing.T) {
+	defer util.RemoveDir("../test")
+	util.RemoveDir("../test")
+	tagsUID := initTagsUID()
+	// flush the tags of same metric twice
+	_, _ = tagsUID.GetOrCreateTagsID(1, MapToString(map[string]string{"host": "host-1", "ip": "1.1.1.1"}))
+	_, _ = tagsUID.GetOrCreateTagsID(1, MapToString(map[string]string{"host": "host-2", "ip": "1.1.1.2"}))
+	assert.Nil(t, tagsUID.Flush())
+	tagsUID.seq.next = 11
+	_, _ = tagsUID.GetOrCreateTagsID(1, MapToString(map[string]string{"host": "host-1", "zone": "sh"}))
+	assert.Nil(t, tagsUID.Flush())
 
-		fmt.Println("xxxxx")
+	assertTags := func() {
+		assert.Equal(t, []uint32{1, 11}, tagsUID.GetTagValueBitmap(1, "host", "host-1").ToArray())
+		assert.Equal(t, []uint32{2}, tagsUID.GetTagValueBitmap(1, "host", "host-2").ToArray())
+		assert.Equal(t, []uint32{11}, tagsUID.GetTagValueBitmap(1, "zone", "sh").ToArray())
+		assert.Nil(t, tagsUID.GetTagValueBitmap(1, "zone", "bj"))
+		assert.Equal(t, []string{"host", "ip", "zone"}, tagsUID.GetTagNames(1, 10))
+		assert.Equal(t, []string{"host-1", "host-2"}, tagsUID.SuggestTagValues(1, "host", "", 10))
 	}
+	assertTags()
+	snapshot, _ := tagsUID.family.GetSnapshot(1)
+	assert.Equal(t, 2, len(snapshot.Readers()))
+	snapshot.Close()
+
+	assert.Nil(t, tagsUID.Compact())
+	assertTags()
+	snapshot, _ = tagsUID.family.GetSnapshot(1)
+	assert.Equal(t, 1, len(snapshot.Readers()))
+	snapshot.Close()
+	snapshot, _ = tagsUID.cardinalityFamily.GetSnapshot(1)
+	assert.Equal(t, 1, len(snapshot.Readers()))
+	snapshot.Close()
+	cardinality, err := tagsUID.GetCardinality(1)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), cardinality.Series.Count())
+}
+
+func TestTagsUid_GetSeriesTags(t *testing.T) {
+	defer util.RemoveDir("../test")
+	util.RemoveDir("../test")
+	tagsUID := initTagsUID()
+	hostTags := MapToString(map[string]string{"host": "host-1"})
+	ipTags := MapToString(map[string]string{"host": "host-1", "ip": "1.1.1.1"})
+	hostID, err := tagsUID.GetOrCreateTagsID(1, hostTags)
+	assert.Nil(t, err)
+	assert.Nil(t, tagsUID.Flush())
+	// the superset of flushed tags is a new series
+	ipID, err := tagsUID.GetOrCreateTagsID(1, ipTags)
+	assert.Nil(t, err)
+	assert.NotEqual(t, hostID, ipID)
+	ids := roaring.BitmapOf(hostID, ipID)
+	assert.Equal(t, map[uint32]string{hostID: hostTags, ipID: ipTags}, tagsUID.GetSeriesTags(1, ids))
+	assert.Nil(t, tagsUID.Flush())
+	assert.Equal(t, map[uint32]string{hostID: hostTags, ipID: ipTags}, tagsUID.GetSeriesTags(1, ids))
+
+	// series of different files are merged
+	assert.Nil(t, tagsUID.Compact())
+	snapshot, _ := tagsUID.seriesFamily.GetSnapshot(1)
+	assert.Equal(t, 1, len(snapshot.Readers()))
+	snapshot.Close()
+	id, _ := tagsUID.GetOrCreateTagsID(1, ipTags)
+	assert.Equal(t, ipID, id)
+	assert.Equal(t, map[uint32]string{hostID: hostTags}, tagsUID.GetSeriesTags(1, roaring.BitmapOf(hostID)))
+}
+
+func TestTagsUid_GetCardinality(t *testing.T) {
+	defer util.RemoveDir("../test")
+	tagsUID := initTags()
+	for i := 1; i < 10; i++ {
+		cardinality, err := tagsUID.GetCardinality(uint32(i))
+		assert.Nil(t, err)
+		// the estimation of sketch is approximate
+		assert.InDelta(t, (count-1)*(count-1), cardinality.Series.Count(), 3)
+		assert.Equal(t, uint64(count-1), cardinality.TagValues["a"].Count())
+		assert.Equal(t, uint64(count-1), cardinality.TagValues["b"].Count())
+	}
+	cardinality, err := tagsUID.GetCardinality(100)
+	assert.Nil(t, err)
+	assert.Nil(t, cardinality)
+
+	// sketches of different flushes are merged
+	_, _ = tagsUID.GetOrCreateTagsID(1, MapToString(map[string]string{"a": "value-1-100", "c": "c"}))
+	assert.Nil(t, tagsUID.Flush())
+	cardinality, _ = tagsUID.GetCardinality(1)
+	assert.InDelta(t, (count-1)*(count-1)+1, cardinality.Series.Count(), 3)
+	assert.Equal(t, uint64(count), cardinality.TagValues["a"].Count())
+	assert.Equal(t, uint64(1), cardinality.TagValues["c"].Count())
+
+	// bad data
+	flusher := tagsUID.cardinalityFamily.NewFlusher()
+	_ = flusher.Add(1, []byte{0})
+	_ = flusher.Commit()
+	_, err = tagsUID.GetCardinality(1)
+	assert.NotNil(t, err)
+}
+
+func TestTagsMerger_Merge(t *testing.T) {
+	bitmap := roaring.New()
+	bitmap.Add(1)
+	tagTree := tree.NewBTree()
+	tagTree.Put([]byte("host-1"), 0)
+	value, err := encodeTags(map[string]*tree.BTree{"host": tagTree}, []*roaring.Bitmap{bitmap})
+	assert.Nil(t, err)
+
+	bitmap = roaring.New()
+	bitmap.Add(2)
+	tagTree = tree.NewBTree()
+	tagTree.Put([]byte("host-1"), 0)
+	value2, err := encodeTags(map[string]*tree.BTree{"host": tagTree}, []*roaring.Bitmap{bitmap})
+	assert.Nil(t, err)
+
+	merged, err := NewTagsMerger().Merge(1, [][]byte{value, value2})
+	assert.Nil(t, err)
+	reader := newTagsReader(merged)
+	assert.Equal(t, []uint32{1, 2}, reader.getTagValueBitmap("host", "host-1").ToArray())
+	assert.Nil(t, reader.getTagValueBitmap("host", "host-2"))
 }
 
 var count = 11
 
 func initTags() *TagsUID {
 	util.RemoveDir("../test")
-	tagsUID := NewTagsUID(initTagsFamily())
+	tagsUID := initTagsUID()
 	for i := 1; i < 10; i++ {
 		for j := 1; j < count; j++ {
 			for k := 1; k < count; k++ {
@@ -112,11 +234,14 @@ func initTags() *TagsUID {
 	return tagsUID
 }
 
-func initTagsFamily() kv.Family {
+func initTagsUID() *TagsUID {
 	option := kv.DefaultStoreOption("../test")
 	var indexStore, _ = kv.NewStore("index", option)
 	family, _ := indexStore.CreateFamily("tags", kv.FamilyOption{})
-	return family
+	cardinalityFamily, _ := indexStore.CreateFamily("cardinality", kv.FamilyOption{})
+	seriesFamily, _ := indexStore.CreateFamily("series", kv.FamilyOption{})
+	seq, _ := NewSequence(SeriesSequenceKey, &memorySequenceStore{watermarks: make(map[uint32]uint32)}, 1000)
+	return NewTagsUID(family, cardinalityFamily, seriesFamily, seq)
 }
 
 func TestTagsMapping(t *testing.T) {
@@ -0,0 +1,147 @@
+package tsdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/pkg/util"
+	"github.com/eleme/lindb/tsdb/index"
+)
+
+func TestIndex_Suggest(t *testing.T) {
+	defer util.RemoveDir(testPath)
+	idx, err := newIndex(testPath, nil)
+	assert.Nil(t, err)
+	assert.NotNil(t, idx)
+
+	metricUID := idx.GetMetricUID()
+	cpuID, _ := metricUID.GetOrCreateMetricID("cpu", true)
+	_, _ = metricUID.GetOrCreateMetricID("cpu.load", true)
+	_, _ = metricUID.GetOrCreateMetricID("memory", true)
+	assert.Nil(t, metricUID.Flush())
+
+	tagsUID := idx.GetTagsUID()
+	_, _ = tagsUID.GetOrCreateTagsID(cpuID, index.MapToString(map[string]string{"host": "host-1", "ip": "1.1.1.1"}))
+	_, _ = tagsUID.GetOrCreateTagsID(cpuID, index.MapToString(map[string]string{"host": "host-2", "ip": "1.1.1.2"}))
+	_, _ = tagsUID.GetOrCreateTagsID(cpuID, index.MapToString(map[string]string{"host": "server-1", "ip": "1.1.1.3"}))
+	assert.Nil(t, tagsUID.Flush())
+
+	assert.Equal(t, []string{"cpu", "cpu.load"}, idx.SuggestMetrics("cpu", 0, 10))
+	assert.Equal(t, []string{"cpu.load"}, idx.SuggestMetrics("cpu", 1, 10))
+	assert.Equal(t, []string{"cpu", "cpu.load", "memory"}, idx.SuggestMetrics("", 0, 10))
+
+	assert.Equal(t, []string{"host", "ip"}, idx.SuggestTagKeys("cpu", 10))
+	assert.Nil(t, idx.SuggestTagKeys("memory", 10))
+	assert.Nil(t, idx.SuggestTagKeys("not-exist", 10))
+
+	assert.Equal(t, []string{"host-1", "host-2"}, idx.SuggestTagValues("cpu", "host", "host", 10))
+	assert.Equal(t, []string{"host-1", "host-2", "server-1"}, idx.SuggestTagValues("cpu", "host", "", 10))
+	assert.Nil(t, idx.SuggestTagValues("cpu", "zone", "", 10))
+	assert.Nil(t, idx.SuggestTagValues("not-exist", "host", "", 10))
+	assert.Nil(t, idx.Close())
+
+	// re-open index test load exist data
+	idx, err = newIndex(testPath, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"cpu", "cpu.load", "memory"}, idx.SuggestMetrics("", 0, 10))
+	assert.Equal(t, []string{"host", "ip"}, idx.SuggestTagKeys("cpu", 10))
+	assert.Nil(t, idx.Close())
+}
+
+func TestIndex_Compact(t *testing.T) {
+	defer util.RemoveDir(testPath)
+	idx, err := newIndex(testPath, nil)
+	assert.Nil(t, err)
+	metricUID := idx.GetMetricUID()
+	cpuID, _ := metricUID.GetOrCreateMetricID("cpu", true)
+	assert.Nil(t, metricUID.Flush())
+
+	tagsUID := idx.GetTagsUID()
+	_, _ = tagsUID.GetOrCreateTagsID(cpuID, index.MapToString(map[string]string{"host": "host-1"}))
+	assert.Nil(t, tagsUID.Flush())
+	_, _ = tagsUID.GetOrCreateTagsID(cpuID, index.MapToString(map[string]string{"ip": "1.1.1.1"}))
+	assert.Nil(t, tagsUID.Flush())
+
+	assert.Nil(t, idx.Compact())
+	assert.Equal(t, []string{"host", "ip"}, idx.SuggestTagKeys("cpu", 10))
+	assert.Equal(t, []string{"host-1"}, idx.SuggestTagValues("cpu", "host", "", 10))
+	assert.Nil(t, idx.Close())
+}
+
+func TestIndex_GetCardinality(t *testing.T) {
+	defer util.RemoveDir(testPath)
+	idx, err := newIndex(testPath, nil)
+	assert.Nil(t, err)
+	cardinality, err := idx.GetCardinality("cpu")
+	assert.Nil(t, err)
+	assert.Nil(t, cardinality)
+
+	metricUID := idx.GetMetricUID()
+	cpuID, _ := metricUID.GetOrCreateMetricID("cpu", true)
+	assert.Nil(t, metricUID.Flush())
+	tagsUID := idx.GetTagsUID()
+	_, _ = tagsUID.GetOrCreateTagsID(cpuID, index.MapToString(map[string]string{"host": "host-1"}))
+	_, _ = tagsUID.GetOrCreateTagsID(cpuID, index.MapToString(map[string]string{"host": "host-2"}))
+	assert.Nil(t, tagsUID.Flush())
+
+	cardinality, err = idx.GetCardinality("cpu")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), cardinality.Series.Count())
+	assert.Equal(t, uint64(2), cardinality.TagValues["host"].Count())
+	assert.Nil(t, idx.Close())
+}
+
+func TestIndex_GetIDGenerator(t *testing.T) {
+	defer util.RemoveDir(testPath)
+	idx, err := newIndex(testPath, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(1), idx.GetIDGenerator().GenMetricID("cpu"))
+	seriesID := idx.GetIDGenerator().GenTSID(1, index.MapToString(map[string]string{"host": "host-1"}))
+	fieldID := idx.GetIDGenerator().GenFieldID(1, "load", field.SumField)
+	assert.Nil(t, idx.Flush())
+	assert.Nil(t, idx.Close())
+
+	// re-open index, the ids generated before are persisted, the ids allocated before aren't reused
+	idx, err = newIndex(testPath, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(1), idx.GetIDGenerator().GenMetricID("cpu"))
+	assert.Equal(t, seriesID, idx.GetIDGenerator().GenTSID(1, index.MapToString(map[string]string{"host": "host-1"})))
+	assert.Equal(t, fieldID, idx.GetFieldUID().GetFieldID(1, "load"))
+	assert.Equal(t, []string{"cpu"}, idx.SuggestMetrics("c", 0, 10))
+	assert.Equal(t, []string{"host"}, idx.SuggestTagKeys("cpu", 10))
+	assert.Equal(t, uint32(index.DefaultSequenceBlockSize+1), idx.GetIDGenerator().GenMetricID("memory"))
+	assert.Nil(t, idx.Close())
+}
+
+func TestIndex_WarmUp(t *testing.T) {
+	defer util.RemoveDir(testPath)
+	idx, err := newIndex(testPath, nil)
+	assert.Nil(t, err)
+	defer func() {
+		_ = idx.Close()
+	}()
+	stats, err := idx.WarmUp()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, stats.Files)
+
+	metricUID := idx.GetMetricUID()
+	cpuID, _ := metricUID.GetOrCreateMetricID("cpu", true)
+	assert.Nil(t, metricUID.Flush())
+	tagsUID := idx.GetTagsUID()
+	_, _ = tagsUID.GetOrCreateTagsID(cpuID, index.MapToString(map[string]string{"host": "host-1"}))
+	assert.Nil(t, tagsUID.Flush())
+
+	// the files of metric and tags index families are warmed
+	stats, err = idx.WarmUp()
+	assert.Nil(t, err)
+	var files int
+	for _, usage := range idx.DiskUsage() {
+		for _, level := range usage.Levels {
+			files += level.Files
+		}
+	}
+	assert.True(t, files >= 2)
+	assert.Equal(t, files, stats.Files)
+}
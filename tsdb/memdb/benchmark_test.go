@@ -0,0 +1,153 @@
+package memdb
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/pkg/interval"
+	"github.com/eleme/lindb/pkg/timeutil"
+)
+
+///////////////////////////////////////////////////
+//           write path micro-benchmarks
+///////////////////////////////////////////////////
+
+// benchSimpleField is a sum field without mock overhead
+type benchSimpleField struct {
+	value int64
+}
+
+func (f *benchSimpleField) Type() field.Type           { return field.SumField }
+func (f *benchSimpleField) IsComplex() bool            { return false }
+func (f *benchSimpleField) ValueType() field.ValueType { return field.Integer }
+func (f *benchSimpleField) AggType() field.AggType     { return field.Sum }
+func (f *benchSimpleField) Value() interface{}         { return f.value }
+
+// benchPoint is a point without mock overhead
+type benchPoint struct {
+	name      string
+	tags      string
+	timestamp int64
+	fields    map[string]models.Field
+}
+
+func (p *benchPoint) Name() string                    { return p.name }
+func (p *benchPoint) Timestamp() int64                { return p.timestamp }
+func (p *benchPoint) Tags() string                    { return p.tags }
+func (p *benchPoint) Fields() map[string]models.Field { return p.fields }
+func (p *benchPoint) TagsMap() map[string]string      { return nil }
+
+// nopIDGenerator generates fixed ID
+type nopIDGenerator struct{}
+
+func (g *nopIDGenerator) GenMetricID(metricName string) uint32              { return 1 }
+func (g *nopIDGenerator) GenTSID(metricID uint32, sortedTags string) uint32 { return 1 }
+func (g *nopIDGenerator) GenFieldID(metricID uint32, fieldName string, fieldType field.Type) uint32 {
+	return 1
+}
+
+// nopTableWriter discards all data
+type nopTableWriter struct{}
+
+func (w *nopTableWriter) WriteField(fieldID uint32, data []byte, startSlot, endSlot int) {}
+func (w *nopTableWriter) WriteTSEntry(tsID uint32)                                       {}
+func (w *nopTableWriter) WriteMetricBlock(metricID uint32) error                         { return nil }
+func (w *nopTableWriter) Close() error                                                   { return nil }
+
+// makeBenchPoints makes points of metrics * series, each point has 2 fields
+func makeBenchPoints(metrics, series int, timestamp int64) []models.Point {
+	var points []models.Point
+	for m := 0; m < metrics; m++ {
+		for s := 0; s < series; s++ {
+			points = append(points, &benchPoint{
+				name:      "system.cpu.load." + strconv.Itoa(m),
+				tags:      "host=alpha-" + strconv.Itoa(s) + ",idc=sh,role=web",
+				timestamp: timestamp,
+				fields: map[string]models.Field{
+					"load":  &benchSimpleField{value: 1},
+					"count": &benchSimpleField{value: 2},
+				},
+			})
+		}
+	}
+	return points
+}
+
+func newBenchMemoryDatabase(b *testing.B) (*memoryDatabase, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	md, err := newMemoryDatabase(ctx, 32, 10*1000, interval.Day)
+	if err != nil {
+		b.Fatal(err)
+	}
+	md.generator = &nopIDGenerator{}
+	return md, cancel
+}
+
+func Benchmark_memoryDatabase_Write(b *testing.B) {
+	md, cancel := newBenchMemoryDatabase(b)
+	defer cancel()
+	points := makeBenchPoints(10, 1000, timeutil.Now())
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = md.Write(points[i%len(points)])
+	}
+}
+
+func Benchmark_memoryDatabase_Write_parallel(b *testing.B) {
+	md, cancel := newBenchMemoryDatabase(b)
+	defer cancel()
+	points := makeBenchPoints(10, 1000, timeutil.Now())
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			_ = md.Write(points[i%len(points)])
+			i++
+		}
+	})
+}
+
+func Benchmark_memoryDatabase_WriteBatch(b *testing.B) {
+	md, cancel := newBenchMemoryDatabase(b)
+	defer cancel()
+	// one batch of write request
+	batch := makeBenchPoints(1, 100, timeutil.Now())
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, point := range batch {
+			_ = md.Write(point)
+		}
+	}
+}
+
+func Benchmark_memoryDatabase_flushFamilyTo(b *testing.B) {
+	points := makeBenchPoints(10, 100, timeutil.Now())
+	writer := &nopTableWriter{}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		md, cancel := newBenchMemoryDatabase(b)
+		for _, point := range points {
+			_ = md.Write(point)
+		}
+		families := md.Families()
+		b.StartTimer()
+		for _, familyTime := range families {
+			_ = md.flushFamilyTo(context.Background(), familyTime, writer)
+		}
+		b.StopTimer()
+		cancel()
+		b.StartTimer()
+	}
+}
@@ -12,6 +12,19 @@ import (
 // the longest length of basic-variable on x64 platform
 const maxTimeWindow = 64
 
+// largeFamilySlots is the number of time slots per family, above which the block uses the max time window,
+// such as 36000 slots of 100ms interval, because compacting block merges the whole compressed data of family.
+const largeFamilySlots = 3600
+
+// blockTimeWindow returns the time window of block based on the configured time window and the slots per family,
+// the max time window is used if the time window isn't in (0, maxTimeWindow] or the family has large slots.
+func blockTimeWindow(timeWindow, slotsPerFamily int) int {
+	if timeWindow <= 0 || timeWindow > maxTimeWindow || slotsPerFamily > largeFamilySlots {
+		return maxTimeWindow
+	}
+	return timeWindow
+}
+
 // blockStore represents a pool of block for reuse
 type blockStore struct {
 	timeWindow     int
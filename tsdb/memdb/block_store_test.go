@@ -65,6 +65,13 @@ func TestTimeWindowRange(t *testing.T) {
 	assert.Equal(t, 40, b2.getEndTime())
 }
 
+func TestBlockTimeWindow(t *testing.T) {
+	assert.Equal(t, 32, blockTimeWindow(32, 360))
+	assert.Equal(t, maxTimeWindow, blockTimeWindow(0, 360))
+	assert.Equal(t, maxTimeWindow, blockTimeWindow(128, 360))
+	assert.Equal(t, maxTimeWindow, blockTimeWindow(32, 36000))
+}
+
 func TestReset(t *testing.T) {
 	bs := newBlockStore(30)
 
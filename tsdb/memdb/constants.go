@@ -1,6 +1,9 @@
 package memdb
 
-import "sync/atomic"
+import (
+	"sync/atomic"
+	"time"
+)
 
 const (
 	// buckets count for sharding metric-stores, 32
@@ -11,8 +14,12 @@ const (
 	defaultMaxTagsLimit = 100000
 	// max fields limitation of a tsStore.
 	maxFieldsLimit = 1024
+	// max length of the value of string field
+	maxStringValueLength = 1024
 	// unit: millisecond, used to prevent resetting metric-store too frequently.
 	minIntervalForResetMetricStore = 10 * 1000
+	// interval for syncing the metricID, tsID and fieldID from id generator
+	idSyncInterval = 10 * time.Second
 )
 
 // use var for mocking
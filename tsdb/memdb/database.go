@@ -2,13 +2,15 @@ package memdb
 
 import (
 	"context"
-	"fmt"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/eleme/lindb/kv/table"
 	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/field"
 	"github.com/eleme/lindb/pkg/hashers"
 	"github.com/eleme/lindb/pkg/interval"
 	"github.com/eleme/lindb/pkg/logger"
@@ -26,46 +28,249 @@ type MemoryDatabase interface {
 	// The producer shall send the config periodically
 	// key: metric-name, value: max-limit
 	WithMaxTagsLimit(<-chan map[string]uint32)
+	// SetMaxTagsLimits replaces the max-count limitation of tags of metrics,
+	// the metric not in limitations uses the default limit, key: metric-name, value: max-limit
+	SetMaxTagsLimits(limitations map[string]uint32)
+	// SetBannedTags sets the high-cardinality tags which are removed from the points at write time,
+	// key: metric-name, value: tag keys, the series colliding after removing are aggregated by field type.
+	SetBannedTags(bannedTags map[string][]string)
 	// Write writes metrics to the memory-database,
 	// return error on exceeding max count of tagsIdentifier or writing failure
 	Write(point models.Point) error
 	// ResetMetricStore reassigns a new version to metricStore
 	// This method provides the ability to reset the tsStore in memory for skipping the tsID-limitation
 	ResetMetricStore(metricName string) error
+	// DropMetricStore removes the metricStore from memory, the data not flushed yet is discarded,
+	// returns ErrMetricNotFound if metric not exist
+	DropMetricStore(metricName string) error
 	// CountMetrics returns the metrics-count of the memory-database
 	CountMetrics() int
 	// CountTags returns the tags-count of the metricName, return -1 if not exist
 	CountTags(metricName string) int
 	// Families returns the families in memory which has not been flushed yet
 	Families() []int64
+	// EndedFamilies returns the families in memory which end before the time(ms), the time window of family
+	// is based on the family option of shard, the caller passes now - behind for allowing late writes.
+	EndedFamilies(timestamp int64) []int64
 	// FlushFamilyTo flushes the corresponded family data to builder.
 	// Close is not in the flushing process.
-	FlushFamilyTo(familyTime int64, tableBuilder table.Builder) error
+	// Flushing stops with the error of context if the context or memory-database is canceled.
+	FlushFamilyTo(ctx context.Context, familyTime int64, tableBuilder table.Builder) error
+	// FlushFamilyFieldsTo flushes the corresponded family data into the builders of field groups,
+	// so that each field group is stored in separate kv family.
+	FlushFamilyFieldsTo(ctx context.Context, familyTime int64,
+		groupOf metrictbl.FieldGroupFunc, newBuilder metrictbl.BuilderFactory) error
+	// LatestValues returns the latest value of the fields of each series of the metric,
+	// which is answered from the head of field stores without scanning the families,
+	// the values are sorted by tags and field, returns ErrMetricNotFound if metric not exist.
+	LatestValues(metricName string, fieldNames []string) ([]LatestValue, error)
+	// StringValues returns the last value of the string fields of each series of the metric,
+	// the values are sorted by tags and field, returns ErrMetricNotFound if metric not exist.
+	StringValues(metricName string, fieldNames []string) ([]StringValue, error)
+	// FlushStringsTo flushes the last values of string fields of all metrics into the builders of
+	// the dedicated kv family(see metrictbl.StringFamilyName) of the segments which the timestamps of values
+	// belong to, the string block of metric is keyed by metric id, no builder is created if no string field.
+	// The values are kept in memory after flushing, because only the last value of series is stored.
+	FlushStringsTo(ctx context.Context, newBuilder metrictbl.SegmentBuilderFactory) error
+	// RawSeries returns the raw points of the fields of each series of the metric within the time range,
+	// which aren't aggregated across series or down sampled. The series are sorted by tags,
+	// at most maxSeries series are returned, truncated is true if there are more series.
+	// Returns ErrMetricNotFound if metric not exist.
+	RawSeries(metricName string, fieldNames []string, timeRange models.TimeRange,
+		maxSeries int) (series []RawSeries, truncated bool, err error)
+	// FieldTypes returns the types of the fields of metric which are written into memory-database,
+	// the fields not written are absent, returns ErrMetricNotFound if metric not exist.
+	// It's used for resolving the aggregator of field whose id isn't synced into index yet.
+	FieldTypes(metricName string, fieldNames []string) (map[string]field.Type, error)
+	// Stats returns the statistics of memory-database, like the count of series and the estimated bytes
+	Stats() Stats
 	// todo: @codingcrush, query
 }
 
-// mStoresBucket is a simple rwMutex locked map of metricStore.
+// LatestValue represents the latest value of a field of series in memory-database
+type LatestValue struct {
+	Tags      string // sorted tags of series
+	Field     string // field name
+	Timestamp int64  // timestamp of the slot
+	Value     int64  // value of the slot
+}
+
+// StringValue represents the last value of a string field of series in memory-database
+type StringValue struct {
+	Tags      string // sorted tags of series
+	Field     string // field name
+	Timestamp int64  // timestamp of the value
+	Value     string // last value
+}
+
+// RawSeries represents the raw points of the fields of a series in memory-database
+type RawSeries struct {
+	Tags   string                // sorted tags of series
+	Fields map[string][]RawPoint // points of field sorted by timestamp
+}
+
+// RawPoint represents the value of a time slot
+type RawPoint struct {
+	Timestamp int64
+	Value     int64
+}
+
+// latestKey is the key of latest value of series field.
+type latestKey struct {
+	tags  string
+	field string
+}
+
+// mStoresSnapshot is the immutable snapshot of metric stores in bucket.
+// metric stores are keyed by the hash of metric name, the metric name is compared after hashing,
+// the metric store whose hash collides with another metric is chained in the collisions map.
+type mStoresSnapshot struct {
+	m          map[uint64]*metricStore // key: XXHash64(metric-name)
+	collisions map[string]*metricStore // key: metric-name, hash collides with the metric in m
+}
+
+// get returns the metricStore by metric-hash and metric-name.
+func (ss *mStoresSnapshot) get(metricHash uint64, metricName string) (mStore *metricStore, ok bool) {
+	mStore, ok = ss.m[metricHash]
+	if ok && mStore.name == metricName {
+		return mStore, true
+	}
+	if len(ss.collisions) == 0 {
+		return nil, false
+	}
+	mStore, ok = ss.collisions[metricName]
+	return
+}
+
+// clone returns a copy of the snapshot for modifying.
+func (ss *mStoresSnapshot) clone() *mStoresSnapshot {
+	newSS := &mStoresSnapshot{
+		m:          make(map[uint64]*metricStore, len(ss.m)+1),
+		collisions: make(map[string]*metricStore, len(ss.collisions))}
+	for metricHash, mStore := range ss.m {
+		newSS.m[metricHash] = mStore
+	}
+	for metricName, mStore := range ss.collisions {
+		newSS.collisions[metricName] = mStore
+	}
+	return newSS
+}
+
+// mStoresBucket is a copy-on-write map of metricStore.
+// Readers(write-path lookup, flush, evict scan) load the immutable snapshot without lock,
+// writers(create or remove metricStore) are serialized by mutex, and replace the snapshot by a modified copy,
+// so that the concurrent flush/scan operations never block ingestion.
 type mStoresBucket struct {
-	rwLock sync.RWMutex
-	m      map[uint32]*metricStore // key: FNV32a(metric-name)
+	mutex    sync.Mutex   // mutex for writers
+	snapshot atomic.Value // *mStoresSnapshot
+}
+
+// newMStoresBucket returns a new empty bucket.
+func newMStoresBucket() *mStoresBucket {
+	bkt := &mStoresBucket{}
+	bkt.snapshot.Store(&mStoresSnapshot{
+		m:          make(map[uint64]*metricStore),
+		collisions: make(map[string]*metricStore)})
+	return bkt
+}
+
+// load returns the current snapshot.
+func (bkt *mStoresBucket) load() *mStoresSnapshot {
+	return bkt.snapshot.Load().(*mStoresSnapshot)
+}
+
+// get returns the metricStore by metric-hash and metric-name without lock.
+func (bkt *mStoresBucket) get(metricHash uint64, metricName string) (mStore *metricStore, ok bool) {
+	return bkt.load().get(metricHash, metricName)
+}
+
+// getOrCreate returns the metricStore by metric-hash and metric-name,
+// creates it with the max-count limitation of tags if not exist.
+func (bkt *mStoresBucket) getOrCreate(metricHash uint64, metricName string, maxTagsLimit uint32) *metricStore {
+	mStore, ok := bkt.get(metricHash, metricName)
+	if ok {
+		return mStore
+	}
+	bkt.mutex.Lock()
+	defer bkt.mutex.Unlock()
+	// double check
+	mStore, ok = bkt.get(metricHash, metricName)
+	if ok {
+		return mStore
+	}
+	mStore = newMetricStore(metricName)
+	mStore.setMaxTagsLimit(maxTagsLimit)
+	bkt.putLocked(metricHash, mStore)
+	return mStore
+}
+
+// put puts the metricStore into bucket, chains it if the hash collides.
+func (bkt *mStoresBucket) put(metricHash uint64, mStore *metricStore) {
+	bkt.mutex.Lock()
+	bkt.putLocked(metricHash, mStore)
+	bkt.mutex.Unlock()
+}
+
+// putLocked copies the snapshot then puts the metricStore, mutex is held by caller.
+func (bkt *mStoresBucket) putLocked(metricHash uint64, mStore *metricStore) {
+	newSS := bkt.load().clone()
+	if _, ok := newSS.m[metricHash]; ok {
+		newSS.collisions[mStore.name] = mStore
+	} else {
+		newSS.m[metricHash] = mStore
+	}
+	bkt.snapshot.Store(newSS)
+}
+
+// delete removes the metricStore from bucket.
+func (bkt *mStoresBucket) delete(metricHash uint64, mStore *metricStore) {
+	bkt.deleteIf(metricHash, mStore, func() bool { return true })
+}
+
+// deleteIf removes the metricStore from bucket if the condition is still true under the writer's lock.
+func (bkt *mStoresBucket) deleteIf(metricHash uint64, mStore *metricStore, condition func() bool) {
+	bkt.mutex.Lock()
+	defer bkt.mutex.Unlock()
+
+	ss := bkt.load()
+	inM := ss.m[metricHash] == mStore
+	inCollisions := ss.collisions[mStore.name] == mStore
+	if (!inM && !inCollisions) || !condition() {
+		return
+	}
+	newSS := ss.clone()
+	if inM {
+		delete(newSS.m, metricHash)
+	} else {
+		delete(newSS.collisions, mStore.name)
+	}
+	bkt.snapshot.Store(newSS)
+}
+
+// size returns the count of metricStore in bucket.
+func (bkt *mStoresBucket) size() int {
+	ss := bkt.load()
+	return len(ss.m) + len(ss.collisions)
 }
 
 // allMetricStores returns pointers to metricStore in bucket.
 func (bkt *mStoresBucket) allMetricStores() (stores *[]*metricStore, release func()) {
+	ss := bkt.load()
 	// get all mStores
-	stores = metricStoresListPool.get(len(bkt.m))
+	stores = metricStoresListPool.get(len(ss.m) + len(ss.collisions))
 	release = func() {
 		metricStoresListPool.put(stores)
 	}
 
-	bkt.rwLock.RLock()
 	idx := 0
-	for _, mStore := range bkt.m {
-		// delete tag of tStore which has not been used for a while
+	for _, mStore := range ss.m {
+		(*stores)[idx] = mStore
+		idx++
+	}
+	for _, mStore := range ss.collisions {
 		(*stores)[idx] = mStore
 		idx++
 	}
-	bkt.rwLock.RUnlock()
 	return stores, release
 }
 
@@ -73,7 +278,6 @@ func (bkt *mStoresBucket) allMetricStores() (stores *[]*metricStore, release fun
 type memoryDatabase struct {
 	timeWindow    int                                    // rollup window of memory-database
 	interval      int64                                  // time interval of rollup
-	intervalType  interval.Type                          // month, day, hour
 	intervalCalc  interval.Calculator                    // helper function for calculating interval
 	blockStore    *blockStore                            // reusable pool
 	ctx           context.Context                        // used for exiting goroutines
@@ -81,69 +285,80 @@ type memoryDatabase struct {
 	once4Syncer   sync.Once                              // once for tags-limitation syncer
 	mStoresList   [shardingCountOfMStores]*mStoresBucket // metric-name -> *metricStore
 	generator     index.IDGenerator                      // the generator for generating ID of metric, field
+	bannedTags    atomic.Value                           // metric-name -> banned tag keys
+	maxTagsLimits atomic.Value                           // metric-name -> max-limit of tags
+	metrics       *memoryDBMetrics                       // self-monitoring metrics
 }
 
-// NewMemoryDatabase returns a new memoryDatabase.
-func NewMemoryDatabase(ctx context.Context, timeWindow int,
-	interval int64, intervalType interval.Type) (MemoryDatabase, error) {
-	return newMemoryDatabase(ctx, timeWindow, interval, intervalType)
+// NewMemoryDatabase returns a new memoryDatabase of the shard of database,
+// the families are calculated by the interval calculator(see option.ShardOption.Calculator),
+// the ID of metric, series and field are synced from generator periodically,
+// the self-monitoring metrics are labeled by database and shard.
+func NewMemoryDatabase(ctx context.Context, database string, shardID int, timeWindow int,
+	interval int64, intervalCalc interval.Calculator, generator index.IDGenerator) (MemoryDatabase, error) {
+	md, err := newMemoryDatabaseWithCalculator(ctx, timeWindow, interval, intervalCalc)
+	if err != nil {
+		return nil, err
+	}
+	md.generator = generator
+	md.metrics = newMemoryDBMetrics(database, shardID)
+	go md.IDSyncer(ctx, idSyncInterval)
+	go md.statsCollector(ctx, statsInterval)
+	return md, nil
 }
 
-// newMemoryDatabase is the new method.
+// newMemoryDatabase is the new method with the default calculator of interval type.
 func newMemoryDatabase(ctx context.Context, timeWindow int,
 	intervalValue int64, intervalType interval.Type) (*memoryDatabase, error) {
 	timeCalc, err := interval.GetCalculator(intervalType)
 	if err != nil {
 		return nil, err
 	}
+	return newMemoryDatabaseWithCalculator(ctx, timeWindow, intervalValue, timeCalc)
+}
+
+// newMemoryDatabaseWithCalculator is the new method with the interval calculator.
+func newMemoryDatabaseWithCalculator(ctx context.Context, timeWindow int,
+	intervalValue int64, timeCalc interval.Calculator) (*memoryDatabase, error) {
+	if intervalValue <= 0 {
+		return nil, errors.Wrapf(errors.ErrInvalidArgument, "interval[%d] must be > 0", intervalValue)
+	}
+	// the block of sub-second interval covers more slots, so that it's compacted less frequently
+	timeWindow = blockTimeWindow(timeWindow, timeCalc.CalSlotsPerFamily(intervalValue))
 	md := memoryDatabase{
 		timeWindow:    timeWindow,
 		interval:      intervalValue,
-		intervalType:  intervalType,
 		intervalCalc:  timeCalc,
 		blockStore:    newBlockStore(timeWindow),
 		ctx:           ctx,
-		evictNotifier: make(chan struct{})}
+		evictNotifier: make(chan struct{}),
+		metrics:       newMemoryDBMetrics("", 0)}
 	for i := range md.mStoresList {
-		md.mStoresList[i] = &mStoresBucket{
-			m: make(map[uint32]*metricStore)}
+		md.mStoresList[i] = newMStoresBucket()
 	}
 	go md.evictor(ctx)
-	// todo: go md.IDSyncer(), initialize it by calling NewMemoryDatabase?
 	return &md, nil
 }
 
 // getBucket returns the mStoresBucket by metric-hash.
-func (md *memoryDatabase) getBucket(metricHash uint32) *mStoresBucket {
+func (md *memoryDatabase) getBucket(metricHash uint64) *mStoresBucket {
 	return md.mStoresList[shardingCountMask&metricHash]
 }
 
-// getMStore returns the mStore by metric-hash.
-func (md *memoryDatabase) getMStore(metricHash uint32) (mStore *metricStore, ok bool) {
-	bkt := md.getBucket(metricHash)
-	bkt.rwLock.RLock()
-	mStore, ok = bkt.m[metricHash]
-	bkt.rwLock.RUnlock()
-	return
+// getMStore returns the mStore by metric-name.
+func (md *memoryDatabase) getMStore(metricName string) (mStore *metricStore, ok bool) {
+	metricHash := hashers.XXHash64(metricName)
+	return md.getBucket(metricHash).get(metricHash, metricName)
 }
 
 // getOrCreateMStore returns a TimeSeriesStore by metric + tags.
 func (md *memoryDatabase) getOrCreateMStore(metricName string) *metricStore {
-	metricHash := hashers.Fnv32a(metricName)
-
-	bucket := md.getBucket(metricHash)
-	var mStore *metricStore
-	mStore, ok := md.getMStore(metricHash)
-	if !ok {
-		bucket.rwLock.Lock()
-		mStore, ok = bucket.m[metricHash]
-		if !ok {
-			mStore = newMetricStore(metricName)
-			bucket.m[metricHash] = mStore
-		}
-		bucket.rwLock.Unlock()
+	metricHash := hashers.XXHash64(metricName)
+	bkt := md.getBucket(metricHash)
+	if mStore, ok := bkt.get(metricHash, metricName); ok {
+		return mStore
 	}
-	return mStore
+	return bkt.getOrCreate(metricHash, metricName, md.maxTagsLimitOf(metricName))
 }
 
 // WithMaxTagsLimit syncs the limitation for different metrics.
@@ -161,33 +376,61 @@ func (md *memoryDatabase) WithMaxTagsLimit(limitationCh <-chan map[string]uint32
 					if limitations == nil {
 						continue
 					}
-					md.setLimitations(limitations)
+					md.SetMaxTagsLimits(limitations)
 				}
 			}
 		}()
 	})
 }
 
-// setLimitations set max-count limitation of tagID.
-func (md *memoryDatabase) setLimitations(limitations map[string]uint32) {
+// SetMaxTagsLimits replaces the max-count limitation of tagID, the limitations are kept for the metric-stores
+// created later, the metric-store not in limitations is reset to the default limit.
+func (md *memoryDatabase) SetMaxTagsLimits(limitations map[string]uint32) {
+	limits := make(map[string]uint32, len(limitations))
 	for metricName, limit := range limitations {
-		mStore, ok := md.getMStore(hashers.Fnv32a(metricName))
-		if !ok {
-			continue
+		limits[metricName] = limit
+	}
+	md.maxTagsLimits.Store(limits)
+	for _, bkt := range md.mStoresList {
+		ss := bkt.load()
+		for _, mStore := range ss.m {
+			mStore.setMaxTagsLimit(md.maxTagsLimitOf(mStore.name))
 		}
-		mStore.setMaxTagsLimit(limit)
+		for _, mStore := range ss.collisions {
+			mStore.setMaxTagsLimit(md.maxTagsLimitOf(mStore.name))
+		}
+	}
+}
+
+// maxTagsLimitOf returns the max-count limitation of tagID of the metric, returns the default limit if not set.
+func (md *memoryDatabase) maxTagsLimitOf(metricName string) uint32 {
+	limits, _ := md.maxTagsLimits.Load().(map[string]uint32)
+	if limit, ok := limits[metricName]; ok {
+		return limit
 	}
+	return defaultMaxTagsLimit
 }
 
-// Write writes metric-point to database.
+// Write writes metric-point to database, counts the written or rejected points.
 func (md *memoryDatabase) Write(point models.Point) error {
+	if err := md.write(point); err != nil {
+		md.metrics.reject(err)
+		return err
+	}
+	md.metrics.writtenPoints.Inc()
+	return nil
+}
+
+// write is the real write method.
+func (md *memoryDatabase) write(point models.Point) error {
 	if point == nil {
-		return fmt.Errorf("point is nil")
+		return errors.Wrapf(errors.ErrInvalidArgument, "point is nil")
 	}
 	if point.Fields() == nil {
-		return fmt.Errorf("fields is nil")
+		return errors.Wrapf(errors.ErrInvalidArgument, "fields is nil")
 	}
 
+	point = md.removeBannedTags(point)
 	mStore := md.getOrCreateMStore(point.Name())
 	if mStore.isFull() {
 		return models.ErrTooManyTags
@@ -204,19 +447,106 @@ func (md *memoryDatabase) Write(point models.Point) error {
 		return models.ErrTooManyFields
 	}
 
+	numeric := false
 	for fieldName, f := range point.Fields() {
-		fieldStore, err := tsStore.getOrCreateFStore(fieldName, f.Type())
-		// field type do not match before
-		if err != nil {
+		if sf, ok := f.(models.StringField); ok {
+			if err := md.writeString(mStore, tsStore, fieldName, timestamp, sf.Value()); err != nil {
+				return err
+			}
+			continue
+		}
+		if sf, ok := f.(models.SimpleField); ok && f.Type() == field.SummaryField {
+			// the raw sample of summary field is aggregated into the min/max/sum/count fields of time slot
+			for name, expanded := range models.ExpandSummaryField(fieldName, sf) {
+				if err := md.writeField(tsStore, name, familyStartTime, slotIndex, expanded); err != nil {
+					return err
+				}
+			}
+			numeric = true
+			continue
+		}
+		if err := md.writeField(tsStore, fieldName, familyStartTime, slotIndex, f); err != nil {
 			return err
 		}
-		// write data
-		fieldStore.write(md.blockStore, familyStartTime, slotIndex, f)
+		numeric = true
+	}
+	// the string fields aren't stored in family
+	if numeric {
+		mStore.addFamilyTime(familyStartTime)
 	}
-	mStore.addFamilyTime(familyStartTime)
 	return nil
 }
 
+// writeField writes the value of numeric field into the slot of family.
+func (md *memoryDatabase) writeField(tsStore *timeSeriesStore, fieldName string,
+	familyStartTime int64, slotIndex int, f models.Field) error {
+	fieldStore, err := tsStore.getOrCreateFStore(fieldName, f.Type())
+	// field type do not match before
+	if err != nil {
+		return err
+	}
+	// write data
+	fieldStore.write(md.blockStore, familyStartTime, slotIndex, f)
+	return nil
+}
+
+// writeString writes the value of string field into series, the value is encoded by the dictionary of metric.
+func (md *memoryDatabase) writeString(mStore *metricStore, tsStore *timeSeriesStore,
+	fieldName string, timestamp int64, value string) error {
+	if len(value) > maxStringValueLength {
+		return errors.Wrapf(errors.ErrInvalidArgument, "length of string field[%s] exceeds %d",
+			fieldName, maxStringValueLength)
+	}
+	return tsStore.writeString(fieldName, timestamp, mStore.dict.getOrAdd(value))
+}
+
+// SetBannedTags sets the high-cardinality tags which are removed from the points at write time.
+func (md *memoryDatabase) SetBannedTags(bannedTags map[string][]string) {
+	banned := make(map[string]map[string]struct{}, len(bannedTags))
+	for metricName, tagKeys := range bannedTags {
+		if len(tagKeys) == 0 {
+			continue
+		}
+		keys := make(map[string]struct{}, len(tagKeys))
+		for _, tagKey := range tagKeys {
+			keys[tagKey] = struct{}{}
+		}
+		banned[metricName] = keys
+	}
+	md.bannedTags.Store(banned)
+}
+
+// removeBannedTags returns the point without banned tags, so that the colliding series are written
+// into the same tsStore, then the values in the same slot are aggregated by the agg func of field.
+func (md *memoryDatabase) removeBannedTags(point models.Point) models.Point {
+	banned, _ := md.bannedTags.Load().(map[string]map[string]struct{})
+	if len(banned) == 0 {
+		return point
+	}
+	keys, ok := banned[point.Name()]
+	if !ok {
+		return point
+	}
+	tags := point.TagsMap()
+	hasBanned := false
+	for tagKey := range keys {
+		if _, ok := tags[tagKey]; ok {
+			hasBanned = true
+			break
+		}
+	}
+	if !hasBanned {
+		return point
+	}
+	newTags := make(map[string]string, len(tags))
+	for tagKey, tagValue := range tags {
+		if _, ok := keys[tagKey]; !ok {
+			newTags[tagKey] = tagValue
+		}
+	}
+	return models.NewPoint(point.Name(), point.Timestamp(), newTags, point.Fields())
+}
+
 // evictor do evict periodically.
 func (md *memoryDatabase) evictor(ctx context.Context) {
 	for {
@@ -240,41 +570,209 @@ func (md *memoryDatabase) evict(bucket *mStoresBucket) {
 
 	for _, mStore := range *allMStores {
 		// delete tag of tStore which has not been used for a while
-		mStore.evict()
+		md.metrics.evictedSeries.Add(float64(mStore.evict()))
 		// delete mStore whose tags is empty now.
 		if mStore.isEmpty() {
-			bucket.rwLock.Lock()
-			if mStore.isEmpty() {
-				delete(bucket.m, hashers.Fnv32a(mStore.name))
-			}
-			bucket.rwLock.Unlock()
+			bucket.deleteIf(hashers.XXHash64(mStore.name), mStore, mStore.isEmpty)
 		}
 	}
 }
 
 // ResetMetricStore flushes the specified metricStore, then a new version will be assigned.
 func (md *memoryDatabase) ResetMetricStore(metricName string) error {
-	mStore, ok := md.getMStore(hashers.Fnv32a(metricName))
+	mStore, ok := md.getMStore(metricName)
 	if !ok {
-		return fmt.Errorf("metric: %s doesn't exist", metricName)
+		return errors.Wrapf(errors.ErrMetricNotFound, "metric: %s", metricName)
 	}
 	return mStore.assignNewVersion()
 }
 
+// DropMetricStore removes the specified metricStore with all series from buckets.
+func (md *memoryDatabase) DropMetricStore(metricName string) error {
+	metricHash := hashers.XXHash64(metricName)
+	bucket := md.getBucket(metricHash)
+	mStore, ok := bucket.get(metricHash, metricName)
+	if !ok {
+		return errors.Wrapf(errors.ErrMetricNotFound, "metric: %s", metricName)
+	}
+	bucket.delete(metricHash, mStore)
+	return nil
+}
+
+// LatestValues returns the latest value of the fields of each series of the metric from the head of field stores.
+func (md *memoryDatabase) LatestValues(metricName string, fieldNames []string) ([]LatestValue, error) {
+	mStore, ok := md.getMStore(metricName)
+	if !ok {
+		return nil, errors.Wrapf(errors.ErrMetricNotFound, "metric: %s", metricName)
+	}
+	values := make(map[latestKey]LatestValue)
+	mStore.latestValues(fieldNames, func(familyTime int64, slot int) int64 {
+		return familyTime + int64(slot)*md.interval
+	}, values)
+	result := make([]LatestValue, 0, len(values))
+	for _, value := range values {
+		result = append(result, value)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Tags != result[j].Tags {
+			return result[i].Tags < result[j].Tags
+		}
+		return result[i].Field < result[j].Field
+	})
+	return result, nil
+}
+
+// StringValues returns the last value of the string fields of each series of the metric.
+func (md *memoryDatabase) StringValues(metricName string, fieldNames []string) ([]StringValue, error) {
+	mStore, ok := md.getMStore(metricName)
+	if !ok {
+		return nil, errors.Wrapf(errors.ErrMetricNotFound, "metric: %s", metricName)
+	}
+	values := make(map[latestKey]StringValue)
+	mStore.stringValues(fieldNames, values)
+	result := make([]StringValue, 0, len(values))
+	for _, value := range values {
+		result = append(result, value)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Tags != result[j].Tags {
+			return result[i].Tags < result[j].Tags
+		}
+		return result[i].Field < result[j].Field
+	})
+	return result, nil
+}
+
+// FlushStringsTo flushes the string blocks of all metrics into the builders of segments in the order of metric id,
+// the builders are closed after flushing.
+func (md *memoryDatabase) FlushStringsTo(ctx context.Context, newBuilder metrictbl.SegmentBuilderFactory) error {
+	segments, err := md.stringBlocks(ctx)
+	if err != nil {
+		return err
+	}
+	segmentTimes := make([]int64, 0, len(segments))
+	for segmentTime := range segments {
+		segmentTimes = append(segmentTimes, segmentTime)
+	}
+	sort.Slice(segmentTimes, func(i, j int) bool {
+		return segmentTimes[i] < segmentTimes[j]
+	})
+	for _, segmentTime := range segmentTimes {
+		tableBuilder, err := newBuilder(segmentTime)
+		if err != nil {
+			return err
+		}
+		if err := addStringBlocks(tableBuilder, segments[segmentTime]); err != nil {
+			_ = tableBuilder.Close()
+			return err
+		}
+		if err := tableBuilder.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stringBlocks builds the string blocks of metrics, then splits them by the segments which the timestamps of
+// values belong to, returns the blocks keyed by segment time and metric id.
+func (md *memoryDatabase) stringBlocks(ctx context.Context) (map[int64]map[uint32]*metrictbl.StringBlock, error) {
+	segments := make(map[int64]map[uint32]*metrictbl.StringBlock)
+	for bucketIndex := 0; bucketIndex < shardingCountOfMStores; bucketIndex++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		allMetricStores, release := md.mStoresList[bucketIndex].allMetricStores()
+		for _, mStore := range *allMetricStores {
+			metricID := mStore.mustGetMetricID(md.generator)
+			block := mStore.stringBlock(metricID, md.generator)
+			if block == nil {
+				continue
+			}
+			for segmentTime, segmentBlock := range block.Split(func(entry metrictbl.StringEntry) int64 {
+				return md.intervalCalc.CalSegmentTime(entry.Timestamp)
+			}) {
+				blocks, ok := segments[segmentTime]
+				if !ok {
+					blocks = make(map[uint32]*metrictbl.StringBlock)
+					segments[segmentTime] = blocks
+				}
+				blocks[metricID] = segmentBlock
+			}
+		}
+		release()
+	}
+	return segments, nil
+}
+
+// addStringBlocks adds the string blocks into builder in the order of metric id
+func addStringBlocks(tableBuilder table.Builder, blocks map[uint32]*metrictbl.StringBlock) error {
+	metricIDs := make([]uint32, 0, len(blocks))
+	for metricID := range blocks {
+		metricIDs = append(metricIDs, metricID)
+	}
+	sort.Slice(metricIDs, func(i, j int) bool {
+		return metricIDs[i] < metricIDs[j]
+	})
+	for _, metricID := range metricIDs {
+		data, err := metrictbl.EncodeStringBlock(blocks[metricID])
+		if err != nil {
+			return err
+		}
+		if err = tableBuilder.Add(metricID, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RawSeries returns the raw points of the fields of each series of the metric within the time range.
+func (md *memoryDatabase) RawSeries(metricName string, fieldNames []string, timeRange models.TimeRange,
+	maxSeries int) (series []RawSeries, truncated bool, err error) {
+	mStore, ok := md.getMStore(metricName)
+	if !ok {
+		return nil, false, errors.Wrapf(errors.ErrMetricNotFound, "metric: %s", metricName)
+	}
+	series, truncated = mStore.rawSeries(fieldNames, func(familyTime int64, slot int) int64 {
+		return familyTime + int64(slot)*md.interval
+	}, timeRange, maxSeries)
+	return series, truncated, nil
+}
+
+// FieldTypes returns the types of the fields of metric which are written into memory-database.
+func (md *memoryDatabase) FieldTypes(metricName string, fieldNames []string) (map[string]field.Type, error) {
+	mStore, ok := md.getMStore(metricName)
+	if !ok {
+		return nil, errors.Wrapf(errors.ErrMetricNotFound, "metric: %s", metricName)
+	}
+	return mStore.fieldTypes(fieldNames), nil
+}
+
 // CountMetrics returns count of metrics in all buckets.
 func (md *memoryDatabase) CountMetrics() int {
 	var counter = 0
 	for bucketIndex := 0; bucketIndex < shardingCountOfMStores; bucketIndex++ {
-		md.mStoresList[bucketIndex].rwLock.RLock()
-		counter += len(md.mStoresList[bucketIndex].m)
-		md.mStoresList[bucketIndex].rwLock.RUnlock()
+		counter += md.mStoresList[bucketIndex].size()
 	}
 	return counter
 }
 
+// Stats returns the statistics of all metric stores in buckets.
+func (md *memoryDatabase) Stats() Stats {
+	stats := Stats{}
+	for bucketIndex := 0; bucketIndex < shardingCountOfMStores; bucketIndex++ {
+		allMetricStores, release := md.mStoresList[bucketIndex].allMetricStores()
+		for _, mStore := range *allMetricStores {
+			mStore.collectStats(&stats)
+		}
+		stats.Metrics += len(*allMetricStores)
+		release()
+	}
+	return stats
+}
+
 // CountTags returns count of tags of a specified metricName, return -1 when metric not exist.
 func (md *memoryDatabase) CountTags(metricName string) int {
-	mStore, ok := md.getMStore(hashers.Fnv32a(metricName))
+	mStore, ok := md.getMStore(metricName)
 	if !ok {
 		return -1
 	}
@@ -285,12 +783,11 @@ func (md *memoryDatabase) CountTags(metricName string) int {
 func (md *memoryDatabase) Families() []int64 {
 	families := make(map[int64]struct{})
 	for bucketIndex := 0; bucketIndex < shardingCountOfMStores; bucketIndex++ {
-		bkt := md.mStoresList[bucketIndex]
-		bkt.rwLock.RLock()
-		for _, mStore := range bkt.m {
+		allMetricStores, release := md.mStoresList[bucketIndex].allMetricStores()
+		for _, mStore := range *allMetricStores {
 			mStore.unionFamilyTimesTo(families)
 		}
-		bkt.rwLock.RUnlock()
+		release()
 	}
 	var list []int64
 	for familyTime := range families {
@@ -302,16 +799,43 @@ func (md *memoryDatabase) Families() []int64 {
 	return list
 }
 
+// EndedFamilies returns the families in memory which end before the time, so that they can be flushed
+// in the granularity of family.
+func (md *memoryDatabase) EndedFamilies(timestamp int64) []int64 {
+	var list []int64
+	for _, familyTime := range md.Families() {
+		if md.intervalCalc.CalFamilyEndTime(familyTime) <= timestamp {
+			list = append(list, familyTime)
+		}
+	}
+	return list
+}
+
 // FlushFamilyTo flushes all data related to the family from metric-stores to builder,
 // this method must be called before the cancellation.
-func (md *memoryDatabase) FlushFamilyTo(familyTime int64, tblBuilder table.Builder) error {
+func (md *memoryDatabase) FlushFamilyTo(ctx context.Context, familyTime int64, tblBuilder table.Builder) error {
 	writer := metrictbl.NewTableWriter(tblBuilder, md.interval)
-	return md.flushFamilyTo(familyTime, writer)
+	return md.flushFamilyTo(ctx, familyTime, writer)
+}
+
+// FlushFamilyFieldsTo flushes all data related to the family from metric-stores to the builders of field groups,
+// the builders are closed after flushing.
+func (md *memoryDatabase) FlushFamilyFieldsTo(ctx context.Context, familyTime int64, groupOf metrictbl.FieldGroupFunc,
+	newBuilder metrictbl.BuilderFactory) error {
+	writer := metrictbl.NewFieldGroupWriter(groupOf, newBuilder, md.interval)
+	if err := md.flushFamilyTo(ctx, familyTime, writer); err != nil {
+		_ = writer.Close()
+		return err
+	}
+	return writer.Close()
 }
 
-// flushFamilyTo is the real flush method, used for mock-test
-func (md *memoryDatabase) flushFamilyTo(familyTime int64, writer metrictbl.TableWriter) error {
+// flushFamilyTo is the real flush method, used for mock-test.
+// Checks the cancellation of context and memory-database before flushing each metric store.
+func (md *memoryDatabase) flushFamilyTo(ctx context.Context, familyTime int64, writer metrictbl.TableWriter) error {
+	startTime := time.Now()
 	defer func() {
+		md.metrics.flushDuration.Observe(time.Since(startTime).Seconds())
 		// non-block notifying evictor
 		select {
 		case md.evictNotifier <- struct{}{}:
@@ -319,18 +843,40 @@ func (md *memoryDatabase) flushFamilyTo(familyTime int64, writer metrictbl.Table
 		}
 	}()
 
-	var err error
+	// the metric-blocks are flushed in ascending order of metric id, because the keys of table must be in order
+	for _, mStore := range md.sortedMetricStores() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := md.ctx.Err(); err != nil {
+			return err
+		}
+		if err := mStore.flushMetricBlocksTo(writer, familyTime, md.generator); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedMetricStores returns the metric stores of all buckets sorted by metric id
+func (md *memoryDatabase) sortedMetricStores() []*metricStore {
+	var (
+		mStores   []*metricStore
+		metricIDs = make(map[*metricStore]uint32)
+	)
 	for bucketIndex := 0; bucketIndex < shardingCountOfMStores; bucketIndex++ {
 		allMetricStores, release := md.mStoresList[bucketIndex].allMetricStores()
 		for _, mStore := range *allMetricStores {
-			if err = mStore.flushMetricBlocksTo(writer, familyTime, md.generator); err != nil {
-				return err
-			}
+			mStores = append(mStores, mStore)
+			metricIDs[mStore] = mStore.mustGetMetricID(md.generator)
 		}
 		// put back to pool
 		release()
 	}
-	return nil
+	sort.Slice(mStores, func(i, j int) bool {
+		return metricIDs[mStores[i]] < metricIDs[mStores[j]]
+	})
+	return mStores
 }
 
 // IDSyncer updates the metricID, tsID and fieldID periodically.
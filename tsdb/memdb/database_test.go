@@ -2,15 +2,22 @@ package memdb
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/eleme/lindb/kv/table"
 	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
 	"github.com/eleme/lindb/pkg/field"
 	"github.com/eleme/lindb/pkg/hashers"
 	"github.com/eleme/lindb/pkg/interval"
 	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/tsdb/index"
+	"github.com/eleme/lindb/tsdb/metrictbl"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -19,9 +26,13 @@ import (
 func Test_NewMemoryDatabase(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	md, _ := NewMemoryDatabase(ctx, 32, 10*1000, interval.Day)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	calc, _ := interval.GetCalculator(interval.Day)
+	md, _ := NewMemoryDatabase(ctx, "db", 1, 32, 10*1000, calc, index.NewMockIDGenerator(ctrl))
 
 	assert.NotNil(t, md)
+	assert.NotNil(t, md.(*memoryDatabase).generator)
 }
 
 func Test_getBucket(t *testing.T) {
@@ -30,10 +41,42 @@ func Test_getBucket(t *testing.T) {
 	md, _ := newMemoryDatabase(ctx, 32, 10*1000, interval.Day)
 
 	for i := 0; i < 1000; i++ {
-		assert.NotNil(t, md.getBucket(hashers.Fnv32a(strconv.Itoa(i))))
+		assert.NotNil(t, md.getBucket(hashers.XXHash64(strconv.Itoa(i))))
 	}
 }
 
+func Test_mStoresBucket_hashCollision(t *testing.T) {
+	bucket := newMStoresBucket()
+	mStore1 := newMetricStore("cpu")
+	mStore2 := newMetricStore("memory")
+	// mock the same hash of different metrics
+	bucket.put(1, mStore1)
+	bucket.put(1, mStore2)
+	assert.Equal(t, 2, bucket.size())
+
+	mStore, ok := bucket.get(1, "cpu")
+	assert.True(t, ok)
+	assert.Equal(t, mStore1, mStore)
+	mStore, ok = bucket.get(1, "memory")
+	assert.True(t, ok)
+	assert.Equal(t, mStore2, mStore)
+	_, ok = bucket.get(1, "disk")
+	assert.False(t, ok)
+	allMStores, release := bucket.allMetricStores()
+	assert.Len(t, *allMStores, 2)
+	release()
+
+	// remove the first one, the chained one still can be found
+	bucket.delete(1, mStore1)
+	_, ok = bucket.get(1, "cpu")
+	assert.False(t, ok)
+	mStore, ok = bucket.get(1, "memory")
+	assert.True(t, ok)
+	assert.Equal(t, mStore2, mStore)
+	bucket.delete(1, mStore2)
+	assert.Equal(t, 0, bucket.size())
+}
+
 func Test_getOrCreateMStore(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -44,7 +87,7 @@ func Test_getOrCreateMStore(t *testing.T) {
 	}
 }
 
-func Test_setLimitations(t *testing.T) {
+func Test_SetMaxTagsLimits(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	md, _ := newMemoryDatabase(ctx, 32, 10*1000, interval.Day)
@@ -53,9 +96,16 @@ func Test_setLimitations(t *testing.T) {
 	md.getOrCreateMStore("cpu.load")
 	md.getOrCreateMStore("loadavg")
 
-	md.setLimitations(limitations)
+	md.SetMaxTagsLimits(limitations)
 	assert.Equal(t, uint32(10), md.getOrCreateMStore("cpu.load").getMaxTagsLimit())
 	assert.NotEqual(t, uint32(10), md.getOrCreateMStore("loadavg").getMaxTagsLimit())
+	// metric store created later uses the limitation
+	assert.Equal(t, uint32(100), md.getOrCreateMStore("memory").getMaxTagsLimit())
+
+	// limitation removed is reset to default
+	md.SetMaxTagsLimits(map[string]uint32{"memory": 50})
+	assert.Equal(t, uint32(defaultMaxTagsLimit), md.getOrCreateMStore("cpu.load").getMaxTagsLimit())
+	assert.Equal(t, uint32(50), md.getOrCreateMStore("memory").getMaxTagsLimit())
 }
 
 func Test_WithMaxTagsLimit(t *testing.T) {
@@ -109,6 +159,49 @@ func Test_Write(t *testing.T) {
 	assert.Equal(t, models.ErrTooManyTags, md.Write(p))
 }
 
+func Test_SetBannedTags(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	md, _ := newMemoryDatabase(ctx, 32, 10*1000, interval.Day)
+	md.SetBannedTags(map[string][]string{"cpu": {"pod", "pid"}, "memory": nil})
+
+	now := timeutil.Now()
+	for i, pod := range []string{"a", "b", "c"} {
+		p := models.NewPoint("cpu", now, map[string]string{"host": "1.1.1.1", "pod": pod}, map[string]models.Field{
+			"count": models.NewSimpleField(field.SumField, field.Integer, int64(i+1)),
+			"max":   models.NewSimpleField(field.MaxField, field.Integer, int64(i+1)),
+		})
+		assert.Nil(t, md.Write(p))
+	}
+	// the points without banned tags are not changed
+	p := models.NewPoint("memory", now, map[string]string{"pod": "a"}, map[string]models.Field{
+		"used": models.NewSimpleField(field.SumField, field.Integer, int64(1)),
+	})
+	assert.Equal(t, p, md.removeBannedTags(p))
+	p = models.NewPoint("cpu", now, map[string]string{"host": "1.1.1.1"}, nil)
+	assert.Equal(t, p, md.removeBannedTags(p))
+
+	// the colliding series are aggregated by field type
+	assert.Equal(t, 1, md.CountTags("cpu"))
+	mStore, _ := md.getMStore("cpu")
+	tsStore := mStore.getOrCreateTSStore(`{"host":"1.1.1.1"}`)
+	assertValue := func(fieldName string, fieldType field.Type, expect int64) {
+		fStore, err := tsStore.getOrCreateFStore(fieldName, fieldType)
+		assert.Nil(t, err)
+		assert.Len(t, fStore.segments, 1)
+		for _, store := range fStore.segments {
+			assert.Equal(t, expect, store.(*simpleFieldStore).block.getValue(0))
+		}
+	}
+	assertValue("count", field.SumField, 6)
+	assertValue("max", field.MaxField, 3)
+
+	// remove all banned tags
+	md.SetBannedTags(nil)
+	p = models.NewPoint("cpu", now, map[string]string{"pod": "a"}, nil)
+	assert.Equal(t, p, md.removeBannedTags(p))
+}
+
 func Test_evict(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -122,7 +215,7 @@ func Test_evict(t *testing.T) {
 		md.evict(store)
 	}
 	// purges all
-	assert.Equal(t, 0, len(md.mStoresList[0].m))
+	assert.Equal(t, 0, md.mStoresList[0].size())
 }
 
 func Test_evictor(t *testing.T) {
@@ -154,8 +247,26 @@ func Test_flushFamilyTo(t *testing.T) {
 		mStore.immutable = append(mStore.immutable, newVersionedTSMap())
 		return mStore
 	}
-	md.mStoresList[0].m[hashers.Fnv32a("cpu")] = getMStore()
-	assert.Nil(t, md.flushFamilyTo(1, tw))
+	md.mStoresList[0].put(hashers.XXHash64("cpu"), getMStore())
+	assert.Nil(t, md.flushFamilyTo(context.Background(), 1, tw))
+
+	// flush canceled
+	flushCtx, flushCancel := context.WithCancel(context.Background())
+	flushCancel()
+	assert.Equal(t, context.Canceled, md.flushFamilyTo(flushCtx, 1, tw))
+	// memory database closed
+	cancel()
+	assert.Equal(t, context.Canceled, md.flushFamilyTo(context.Background(), 1, tw))
+}
+
+func Test_FlushFamilyFieldsTo(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	md, _ := newMemoryDatabase(ctx, 32, 10*1000, interval.Day)
+	// no data, no builder created
+	assert.Nil(t, md.FlushFamilyFieldsTo(context.Background(), 1, metrictbl.PerField, func(group uint32) (table.Builder, error) {
+		return nil, fmt.Errorf("should not create builder")
+	}))
 }
 
 func Test_ResetMetricStore(t *testing.T) {
@@ -170,6 +281,349 @@ func Test_ResetMetricStore(t *testing.T) {
 	assert.Nil(t, md.ResetMetricStore("cpu"))
 }
 
+func Test_DropMetricStore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	md, _ := newMemoryDatabase(ctx, 32, 10*1000, interval.Day)
+
+	assert.True(t, errors.Is(md.DropMetricStore("cpu"), errors.ErrMetricNotFound))
+	md.getOrCreateMStore("cpu").getOrCreateTSStore("host=1.1.1.1")
+	md.getOrCreateMStore("memory")
+	assert.Nil(t, md.DropMetricStore("cpu"))
+	assert.Equal(t, 1, md.CountMetrics())
+	assert.Equal(t, -1, md.CountTags("cpu"))
+	// new series can be written after dropping
+	assert.Equal(t, 0, md.getOrCreateMStore("cpu").getTagsCount())
+}
+
+func Test_LatestValues(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	md, _ := newMemoryDatabase(ctx, 32, 10*1000, interval.Day)
+
+	_, err := md.LatestValues("cpu", []string{"f1"})
+	assert.NotNil(t, err)
+
+	now := timeutil.Now()
+	now -= now % (10 * 1000)
+	write := func(tags string, timestamp int64, value int64) {
+		assert.Nil(t, md.Write(models.NewPoint("cpu", timestamp, map[string]string{"host": tags},
+			map[string]models.Field{"f1": models.NewSimpleField(field.SumField, field.Integer, value)})))
+	}
+	write("1.1.1.1", now-10*1000, 1)
+	write("1.1.1.1", now, 2)
+	write("2.2.2.2", now-20*1000, 3)
+
+	values, err := md.LatestValues("cpu", []string{"f1", "f2"})
+	assert.Nil(t, err)
+	assert.Equal(t, []LatestValue{
+		{Tags: `{"host":"1.1.1.1"}`, Field: "f1", Timestamp: now, Value: 2},
+		{Tags: `{"host":"2.2.2.2"}`, Field: "f1", Timestamp: now - 20*1000, Value: 3},
+	}, values)
+
+	// the series in immutable tsMap is older than the one in mutable tsMap
+	mStore, _ := md.getMStore("cpu")
+	mStore.mutable.version -= int64(time.Hour)
+	assert.Nil(t, md.ResetMetricStore("cpu"))
+	write("2.2.2.2", now, 4)
+	values, err = md.LatestValues("cpu", []string{"f1"})
+	assert.Nil(t, err)
+	assert.Equal(t, []LatestValue{
+		{Tags: `{"host":"1.1.1.1"}`, Field: "f1", Timestamp: now, Value: 2},
+		{Tags: `{"host":"2.2.2.2"}`, Field: "f1", Timestamp: now, Value: 4},
+	}, values)
+}
+
+func Test_StringValues(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	md, _ := newMemoryDatabase(ctx, 32, 10*1000, interval.Day)
+
+	_, err := md.StringValues("cpu", []string{"version"})
+	assert.NotNil(t, err)
+
+	now := timeutil.Now()
+	write := func(tags string, timestamp int64, version string) error {
+		return md.Write(models.NewPoint("cpu", timestamp, map[string]string{"host": tags},
+			map[string]models.Field{"version": models.NewStringField(version)}))
+	}
+	assert.Nil(t, write("1.1.1.1", now, "v2"))
+	// the older value is ignored
+	assert.Nil(t, write("1.1.1.1", now-1000, "v1"))
+	assert.Nil(t, write("2.2.2.2", now, "v1"))
+	// the later write wins on same timestamp
+	assert.Nil(t, write("2.2.2.2", now, "v2"))
+	assert.Nil(t, write("3.3.3.3", now, "v1"))
+	// the string fields aren't stored in family
+	assert.Empty(t, md.Families())
+
+	values, err := md.StringValues("cpu", []string{"version", "status"})
+	assert.Nil(t, err)
+	assert.Equal(t, []StringValue{
+		{Tags: `{"host":"1.1.1.1"}`, Field: "version", Timestamp: now, Value: "v2"},
+		{Tags: `{"host":"2.2.2.2"}`, Field: "version", Timestamp: now, Value: "v2"},
+		{Tags: `{"host":"3.3.3.3"}`, Field: "version", Timestamp: now, Value: "v1"},
+	}, values)
+	// the values are dictionary encoded
+	mStore, _ := md.getMStore("cpu")
+	assert.Equal(t, []string{"v2", "v1"}, mStore.dict.values)
+
+	// the field type cannot be changed
+	assert.True(t, errors.Is(md.Write(models.NewPoint("cpu", now, map[string]string{"host": "1.1.1.1"},
+		map[string]models.Field{"version": models.NewSimpleField(field.SumField, field.Integer, int64(1))})),
+		errors.ErrWrongFieldType))
+	assert.Nil(t, md.Write(models.NewPoint("cpu", now, map[string]string{"host": "1.1.1.1"},
+		map[string]models.Field{"count": models.NewSimpleField(field.SumField, field.Integer, int64(1))})))
+	assert.True(t, errors.Is(md.Write(models.NewPoint("cpu", now, map[string]string{"host": "1.1.1.1"},
+		map[string]models.Field{"count": models.NewStringField("1")})), errors.ErrWrongFieldType))
+	// value too long
+	assert.True(t, errors.Is(write("1.1.1.1", now, strings.Repeat("a", maxStringValueLength+1)),
+		errors.ErrInvalidArgument))
+
+	// the value of series in immutable tsMap is older than the one in mutable tsMap
+	mStore.mutable.version -= int64(time.Hour)
+	assert.Nil(t, md.ResetMetricStore("cpu"))
+	assert.Nil(t, write("1.1.1.1", now+1000, "v3"))
+	values, _ = md.StringValues("cpu", []string{"version"})
+	assert.Len(t, values, 3)
+	assert.Equal(t, StringValue{Tags: `{"host":"1.1.1.1"}`, Field: "version", Timestamp: now + 1000, Value: "v3"},
+		values[0])
+	assert.True(t, md.Stats().EstimatedBytes > 0)
+}
+
+func Test_FlushStringsTo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	md, _ := newMemoryDatabase(ctx, 32, 10*1000, interval.Day)
+	gen := index.NewMockIDGenerator(ctrl)
+	gen.EXPECT().GenMetricID(gomock.Any()).DoAndReturn(func(metricName string) uint32 {
+		return map[string]uint32{"cpu": 2, "memory": 1, "disk": 3}[metricName]
+	}).AnyTimes()
+	gen.EXPECT().GenTSID(gomock.Any(), gomock.Any()).DoAndReturn(func(metricID uint32, tags string) uint32 {
+		return map[string]uint32{`{"host":"1.1.1.1"}`: 1, `{"host":"2.2.2.2"}`: 2}[tags]
+	}).AnyTimes()
+	gen.EXPECT().GenFieldID(gomock.Any(), gomock.Any(), field.StringField).Return(uint32(10)).AnyTimes()
+	md.generator = gen
+
+	now := timeutil.Now()
+	write := func(metricName, tags string, timestamp int64, version string) {
+		assert.Nil(t, md.Write(models.NewPoint(metricName, timestamp, map[string]string{"host": tags},
+			map[string]models.Field{"version": models.NewStringField(version)})))
+	}
+	write("cpu", "1.1.1.1", now, "v1")
+	write("cpu", "2.2.2.2", now, "v2")
+	write("memory", "1.1.1.1", now, "v1")
+	write("memory", "1.1.1.1", now+1, "v3")
+	// the metric without string fields is skipped
+	assert.Nil(t, md.Write(models.NewPoint("disk", now, map[string]string{"host": "1.1.1.1"},
+		map[string]models.Field{"f1": models.NewSimpleField(field.SumField, field.Integer, int64(1))})))
+
+	// the value of yesterday is flushed into the segment of yesterday
+	yesterday := now - timeutil.OneDay
+	write("memory", "2.2.2.2", yesterday, "v0")
+
+	builder := table.NewMockBuilder(ctrl)
+	blocks := make(map[uint32]*metrictbl.StringBlock)
+	var keys []uint32
+	builder.EXPECT().Add(gomock.Any(), gomock.Any()).DoAndReturn(func(key uint32, value []byte) error {
+		block, err := metrictbl.DecodeStringBlock(value)
+		assert.Nil(t, err)
+		blocks[key] = block
+		keys = append(keys, key)
+		return nil
+	}).Times(2)
+	builder.EXPECT().Close().Return(nil)
+	yesterdayBuilder := table.NewMockBuilder(ctrl)
+	yesterdayBuilder.EXPECT().Add(uint32(1), gomock.Any()).DoAndReturn(func(key uint32, value []byte) error {
+		block, err := metrictbl.DecodeStringBlock(value)
+		assert.Nil(t, err)
+		assert.Equal(t, &metrictbl.StringBlock{
+			Dict:    []string{"v0"},
+			Entries: []metrictbl.StringEntry{{TSID: 2, FieldID: 10, Timestamp: yesterday, ValueID: 0}},
+		}, block)
+		return nil
+	})
+	yesterdayBuilder.EXPECT().Close().Return(nil)
+	var segmentTimes []int64
+	newBuilder := func(segmentTime int64) (table.Builder, error) {
+		segmentTimes = append(segmentTimes, segmentTime)
+		if segmentTime == md.intervalCalc.CalSegmentTime(now) {
+			return builder, nil
+		}
+		return yesterdayBuilder, nil
+	}
+	assert.Nil(t, md.FlushStringsTo(context.Background(), newBuilder))
+	assert.Equal(t, []int64{md.intervalCalc.CalSegmentTime(yesterday), md.intervalCalc.CalSegmentTime(now)},
+		segmentTimes)
+	// the blocks are added in the order of metric id
+	assert.Equal(t, []uint32{1, 2}, keys)
+	assert.Equal(t, &metrictbl.StringBlock{
+		Dict: []string{"v1", "v2"},
+		Entries: []metrictbl.StringEntry{
+			{TSID: 1, FieldID: 10, Timestamp: now, ValueID: 0},
+			{TSID: 2, FieldID: 10, Timestamp: now, ValueID: 1},
+		},
+	}, blocks[2])
+	// only the referenced values are in the dictionary of block
+	assert.Equal(t, &metrictbl.StringBlock{
+		Dict:    []string{"v3"},
+		Entries: []metrictbl.StringEntry{{TSID: 1, FieldID: 10, Timestamp: now + 1, ValueID: 0}},
+	}, blocks[1])
+
+	// the values are kept after flushing
+	values, _ := md.StringValues("cpu", []string{"version"})
+	assert.Len(t, values, 2)
+
+	// add failure
+	yesterdayBuilder.EXPECT().Add(gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
+	yesterdayBuilder.EXPECT().Close().Return(nil)
+	assert.NotNil(t, md.FlushStringsTo(context.Background(), newBuilder))
+	// create builder failure
+	assert.NotNil(t, md.FlushStringsTo(context.Background(), func(segmentTime int64) (table.Builder, error) {
+		return nil, fmt.Errorf("err")
+	}))
+	// flush canceled
+	flushCtx, flushCancel := context.WithCancel(context.Background())
+	flushCancel()
+	assert.Equal(t, context.Canceled, md.FlushStringsTo(flushCtx, newBuilder))
+}
+
+func Test_RawSeries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	md, _ := newMemoryDatabase(ctx, 32, 10*1000, interval.Day)
+
+	timeRange := models.TimeRange{Start: 0, End: math.MaxInt64}
+	_, _, err := md.RawSeries("cpu", []string{"f1"}, timeRange, 10)
+	assert.NotNil(t, err)
+
+	now := timeutil.Now()
+	now -= now % (10 * 1000)
+	write := func(tags string, timestamp int64, value int64) {
+		assert.Nil(t, md.Write(models.NewPoint("cpu", timestamp, map[string]string{"host": tags},
+			map[string]models.Field{"f1": models.NewSimpleField(field.SumField, field.Integer, value)})))
+	}
+	write("1.1.1.1", now-10*1000, 1)
+	write("1.1.1.1", now, 2)
+	write("2.2.2.2", now-20*1000, 3)
+	write("3.3.3.3", now, 5)
+
+	series, truncated, err := md.RawSeries("cpu", []string{"f1", "f2"}, timeRange, 10)
+	assert.Nil(t, err)
+	assert.False(t, truncated)
+	assert.Equal(t, []RawSeries{
+		{Tags: `{"host":"1.1.1.1"}`, Fields: map[string][]RawPoint{"f1": {{now - 10*1000, 1}, {now, 2}}}},
+		{Tags: `{"host":"2.2.2.2"}`, Fields: map[string][]RawPoint{"f1": {{now - 20*1000, 3}}}},
+		{Tags: `{"host":"3.3.3.3"}`, Fields: map[string][]RawPoint{"f1": {{now, 5}}}},
+	}, series)
+
+	// the series without points in time range is skipped
+	series, truncated, err = md.RawSeries("cpu", []string{"f1"}, models.TimeRange{Start: now - 20*1000, End: now - 10*1000}, 2)
+	assert.Nil(t, err)
+	assert.False(t, truncated)
+	assert.Equal(t, []RawSeries{
+		{Tags: `{"host":"1.1.1.1"}`, Fields: map[string][]RawPoint{"f1": {{now - 10*1000, 1}}}},
+		{Tags: `{"host":"2.2.2.2"}`, Fields: map[string][]RawPoint{"f1": {{now - 20*1000, 3}}}},
+	}, series)
+
+	// max series exceeded
+	series, truncated, err = md.RawSeries("cpu", []string{"f1"}, timeRange, 1)
+	assert.Nil(t, err)
+	assert.True(t, truncated)
+	assert.Len(t, series, 1)
+
+	// the series in both immutable and mutable tsMap is merged, the newer point is kept
+	mStore, _ := md.getMStore("cpu")
+	mStore.mutable.version -= int64(time.Hour)
+	assert.Nil(t, md.ResetMetricStore("cpu"))
+	write("2.2.2.2", now-20*1000, 4)
+	write("2.2.2.2", now, 6)
+	series, _, err = md.RawSeries("cpu", []string{"f1"}, timeRange, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, RawSeries{Tags: `{"host":"2.2.2.2"}`,
+		Fields: map[string][]RawPoint{"f1": {{now - 20*1000, 4}, {now, 6}}}}, series[1])
+}
+
+func Test_FieldTypes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	md, _ := newMemoryDatabase(ctx, 32, 10*1000, interval.Day)
+
+	_, err := md.FieldTypes("cpu", []string{"f1"})
+	assert.NotNil(t, err)
+
+	now := timeutil.Now()
+	assert.Nil(t, md.Write(models.NewPoint("cpu", now, map[string]string{"host": "1.1.1.1"},
+		map[string]models.Field{"f1": models.NewSimpleField(field.SumField, field.Integer, int64(1))})))
+	assert.Nil(t, md.Write(models.NewPoint("cpu", now, map[string]string{"host": "2.2.2.2"},
+		map[string]models.Field{"f2": models.NewSimpleField(field.MaxField, field.Integer, int64(1))})))
+	types, err := md.FieldTypes("cpu", []string{"f1", "f2", "f3"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]field.Type{"f1": field.SumField, "f2": field.MaxField}, types)
+}
+
+func Test_WriteSummary(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	md, _ := newMemoryDatabase(ctx, 32, 10*1000, interval.Day)
+
+	now := timeutil.Now()
+	now -= now % (10 * 1000)
+	write := func(timestamp int64, value int64) error {
+		return md.Write(models.NewPoint("rpc", timestamp, map[string]string{"host": "1.1.1.1"},
+			map[string]models.Field{"latency": models.NewSimpleField(field.SummaryField, field.Integer, value)}))
+	}
+	// the raw samples in same time slot are aggregated
+	assert.Nil(t, write(now, 20))
+	assert.Nil(t, write(now+1000, 5))
+	assert.Nil(t, write(now+2000, 50))
+	assert.Nil(t, write(now+10*1000, 8))
+
+	fields := []string{"latency", "latency_min", "latency_max", "latency_sum", "latency_count"}
+	series, _, err := md.RawSeries("rpc", fields, models.TimeRange{Start: 0, End: math.MaxInt64}, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []RawSeries{{Tags: `{"host":"1.1.1.1"}`, Fields: map[string][]RawPoint{
+		"latency_min":   {{now, 5}, {now + 10*1000, 8}},
+		"latency_max":   {{now, 50}, {now + 10*1000, 8}},
+		"latency_sum":   {{now, 75}, {now + 10*1000, 8}},
+		"latency_count": {{now, 3}, {now + 10*1000, 1}},
+	}}}, series)
+
+	// the field type conflicts with the summary field
+	assert.Nil(t, md.Write(models.NewPoint("rpc", now, map[string]string{"host": "1.1.1.1"},
+		map[string]models.Field{"size_max": models.NewSimpleField(field.SumField, field.Integer, int64(1))})))
+	err = md.Write(models.NewPoint("rpc", now, map[string]string{"host": "1.1.1.1"},
+		map[string]models.Field{"size": models.NewSimpleField(field.SummaryField, field.Integer, int64(1))}))
+	assert.NotNil(t, err)
+}
+
+func Test_SubSecondInterval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err := newMemoryDatabase(ctx, 32, 0, interval.Day)
+	assert.NotNil(t, err)
+	md, err := newMemoryDatabase(ctx, 32, 100, interval.Day)
+	assert.Nil(t, err)
+	assert.Equal(t, maxTimeWindow, md.timeWindow)
+
+	now := timeutil.Now()
+	now -= now % timeutil.OneHour
+	var expect []RawPoint
+	// the points of 100ms interval cross the time windows of block in the family of hour
+	for i := int64(0); i < 200; i++ {
+		timestamp := now + i*100 + 50
+		assert.Nil(t, md.Write(models.NewPoint("cpu", timestamp, map[string]string{"host": "1.1.1.1"},
+			map[string]models.Field{"f1": models.NewSimpleField(field.SumField, field.Integer, i)})))
+		expect = append(expect, RawPoint{Timestamp: now + i*100, Value: i})
+	}
+	series, _, err := md.RawSeries("cpu", []string{"f1"}, models.TimeRange{Start: 0, End: math.MaxInt64}, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, expect, series[0].Fields["f1"])
+}
+
 func Test_CountMetrics(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -208,6 +662,28 @@ func Test_Families(t *testing.T) {
 	assert.Len(t, md.Families(), 2)
 }
 
+func Test_EndedFamilies(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calc, _ := interval.NewCalculator(interval.Day, 2*timeutil.OneHour, interval.UTCAlignment)
+	md, _ := newMemoryDatabaseWithCalculator(ctx, 32, 10*1000, calc)
+
+	now := timeutil.Now()
+	segmentTime := now - now%timeutil.OneDay
+	write := func(timestamp int64) {
+		assert.Nil(t, md.Write(models.NewPoint("cpu", timestamp, map[string]string{"host": "1.1.1.1"},
+			map[string]models.Field{"f1": models.NewSimpleField(field.SumField, field.Integer, int64(1))})))
+	}
+	// the families of 2 hours aligned with UTC midnight
+	write(segmentTime + timeutil.OneHour)
+	write(segmentTime + 3*timeutil.OneHour)
+	write(segmentTime + 3*timeutil.OneHour + 30*timeutil.OneMinute)
+	assert.Equal(t, []int64{segmentTime, segmentTime + 2*timeutil.OneHour}, md.Families())
+	assert.Empty(t, md.EndedFamilies(segmentTime+2*timeutil.OneHour-1))
+	assert.Equal(t, []int64{segmentTime}, md.EndedFamilies(segmentTime+2*timeutil.OneHour))
+	assert.Len(t, md.EndedFamilies(segmentTime+4*timeutil.OneHour), 2)
+}
+
 func Test_IDSyner(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	mockGen := makeMockIDGenerator(ctrl)
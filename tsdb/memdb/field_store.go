@@ -1,6 +1,7 @@
 package memdb
 
 import (
+	"sort"
 	"sync/atomic"
 
 	"github.com/eleme/lindb/models"
@@ -93,6 +94,56 @@ func (fs *fieldStore) write(blockStore *blockStore, familyStartTime int64, slot
 	fs.sl.Unlock()
 }
 
+// latest returns the family time, slot and value of the latest point in the field store,
+// ok is false if the field store has no value.
+func (fs *fieldStore) latest() (familyTime int64, slot int, value int64, ok bool) {
+	fs.sl.Lock()
+	defer fs.sl.Unlock()
+
+	for theFamilyTime, store := range fs.segments {
+		if ok && theFamilyTime < familyTime {
+			continue
+		}
+		if theSlot, theValue, exist := store.latest(); exist {
+			familyTime, slot, value, ok = theFamilyTime, theSlot, theValue, true
+		}
+	}
+	return
+}
+
+// rawPoints returns the points of segment stores within the time range sorted by timestamp.
+func (fs *fieldStore) rawPoints(toTimestamp func(familyTime int64, slot int) int64,
+	timeRange models.TimeRange) []RawPoint {
+	fs.sl.Lock()
+	defer fs.sl.Unlock()
+
+	var points []RawPoint
+	for familyTime, store := range fs.segments {
+		store.points(func(slot int, value int64) {
+			timestamp := toTimestamp(familyTime, slot)
+			if timestamp < timeRange.Start || timestamp > timeRange.End {
+				return
+			}
+			points = append(points, RawPoint{Timestamp: timestamp, Value: value})
+		})
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Timestamp < points[j].Timestamp
+	})
+	return points
+}
+
+// memSize returns the estimated bytes of segment stores.
+func (fs *fieldStore) memSize() int {
+	fs.sl.Lock()
+	defer fs.sl.Unlock()
+	size := 0
+	for _, store := range fs.segments {
+		size += store.memSize()
+	}
+	return size
+}
+
 // flushFieldTo flushes segments' data to writer and reset the segments-map.
 func (fs *fieldStore) flushFieldTo(writer metrictbl.TableWriter, metricID uint32,
 	familyTime int64, generator index.IDGenerator) {
@@ -3,12 +3,16 @@ package memdb
 import (
 	"math/rand"
 	"runtime"
+	"sort"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/eleme/lindb/models"
 	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/pkg/hashers"
 	"github.com/eleme/lindb/pkg/lockers"
 	"github.com/eleme/lindb/pkg/timeutil"
 	"github.com/eleme/lindb/tsdb/index"
@@ -207,3 +211,86 @@ func Benchmark_spinLockMap(b *testing.B) {
 	wg.Wait()
 
 }
+
+// benchmarkGetUnderLoad measures the latency of lookup on write path,
+// while flush/scan operations iterate all entries and new entries are inserted concurrently.
+func benchmarkGetUnderLoad(b *testing.B, get func(key int), scan func(), insert func(key int)) {
+	var stopped int32
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for atomic.LoadInt32(&stopped) == 0 {
+			scan()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for key := 10000; atomic.LoadInt32(&stopped) == 0; key++ {
+			insert(key)
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	latencies := make([]int64, b.N)
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		start := time.Now()
+		get(r.Intn(10000))
+		latencies[i] = time.Since(start).Nanoseconds()
+	}
+	b.StopTimer()
+	atomic.StoreInt32(&stopped, 1)
+	wg.Wait()
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	b.Logf("N: %d, p99 latency: %dns", b.N, latencies[b.N*99/100])
+}
+
+// visitEntry simulates the work of flush/scan on each entry
+func visitEntry(v int) {
+	_ = hashers.XXHash64(strconv.Itoa(v))
+}
+
+func Benchmark_rwLockedBucket_getUnderLoad(b *testing.B) {
+	m := rwLockedMap{m: make(map[int]int)}
+	for i := 0; i < 10000; i++ {
+		m.m[i] = i
+	}
+	benchmarkGetUnderLoad(b,
+		func(key int) { m.Get(key) },
+		func() {
+			// like flush/scan, visits all entries under read lock
+			m.mu.RLock()
+			for _, v := range m.m {
+				visitEntry(v)
+			}
+			m.mu.RUnlock()
+		},
+		func(key int) {
+			m.mu.Lock()
+			m.m[key] = key
+			m.mu.Unlock()
+		})
+}
+
+func Benchmark_mStoresBucket_getUnderLoad(b *testing.B) {
+	bkt := newMStoresBucket()
+	names := make([]string, 10000)
+	for i := range names {
+		names[i] = strconv.Itoa(i)
+		bkt.getOrCreate(uint64(i), names[i], defaultMaxTagsLimit)
+	}
+	benchmarkGetUnderLoad(b,
+		func(key int) { bkt.get(uint64(key), names[key]) },
+		func() {
+			// like flush/scan, visits all entries of snapshot without lock
+			all, release := bkt.allMetricStores()
+			for _, mStore := range *all {
+				visitEntry(len(mStore.name))
+			}
+			release()
+		},
+		func(key int) { bkt.getOrCreate(uint64(key), strconv.Itoa(key), defaultMaxTagsLimit) })
+}
@@ -2,10 +2,13 @@ package memdb
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/field"
 	"github.com/eleme/lindb/pkg/hashers"
 	"github.com/eleme/lindb/pkg/lockers"
 	"github.com/eleme/lindb/tsdb/index"
@@ -14,25 +17,81 @@ import (
 
 // versionedTSMap holds a mapping relation of tags and TsStore.
 // a version is assigned since newed,
+// the tags are compared after hashing, the tsStore whose hash collides with another tags is chained in collisions.
 type versionedTSMap struct {
-	tsMap       map[uint32]*timeSeriesStore // map-key: FNV32a(sortedTag)
+	tsMap       map[uint64]*timeSeriesStore // map-key: XXHash64(sortedTag)
+	collisions  map[string]*timeSeriesStore // map-key: sortedTag, hash collides with the tags in tsMap
 	familyTimes map[int64]struct{}          // all segments
 	version     int64                       // uptime in nanoseconds
 }
 
+// get returns the tsStore by tags-hash and sortedTags.
+func (vm *versionedTSMap) get(tagsHash uint64, sortedTags string) (tsStore *timeSeriesStore, ok bool) {
+	tsStore, ok = vm.tsMap[tagsHash]
+	if ok && tsStore.tags == sortedTags {
+		return tsStore, true
+	}
+	if len(vm.collisions) == 0 {
+		return nil, false
+	}
+	tsStore, ok = vm.collisions[sortedTags]
+	return
+}
+
+// put puts the tsStore into map, chains it if the hash collides.
+func (vm *versionedTSMap) put(tagsHash uint64, tsStore *timeSeriesStore) {
+	if _, ok := vm.tsMap[tagsHash]; ok {
+		vm.collisions[tsStore.tags] = tsStore
+		return
+	}
+	vm.tsMap[tagsHash] = tsStore
+}
+
+// delete removes the tsStore from map.
+func (vm *versionedTSMap) delete(tagsHash uint64, tsStore *timeSeriesStore) {
+	if vm.tsMap[tagsHash] == tsStore {
+		delete(vm.tsMap, tagsHash)
+		return
+	}
+	if vm.collisions[tsStore.tags] == tsStore {
+		delete(vm.collisions, tsStore.tags)
+	}
+}
+
+// size returns the count of tsStore in map.
+func (vm *versionedTSMap) size() int {
+	return len(vm.tsMap) + len(vm.collisions)
+}
+
 // allTSStores returns a tsStore list in order of tsID.
 func (vm *versionedTSMap) allTSStores() (buf *[]*timeSeriesStore, release func()) {
-	buf = tsStoresListPool.get(len(vm.tsMap))
+	buf = tsStoresListPool.get(vm.size())
 	var count = 0
 	for _, tsStore := range vm.tsMap {
 		(*buf)[count] = tsStore
 		count++
 	}
+	for _, tsStore := range vm.collisions {
+		(*buf)[count] = tsStore
+		count++
+	}
 	return buf, func() {
 		tsStoresListPool.put(buf)
 	}
 }
 
+// memSize returns the estimated bytes of all tsStores in map.
+func (vm *versionedTSMap) memSize() int {
+	size := 0
+	for _, tsStore := range vm.tsMap {
+		size += tsStore.memSize()
+	}
+	for _, tsStore := range vm.collisions {
+		size += tsStore.memSize()
+	}
+	return size
+}
+
 // unionFamilyTimesTo update familyTimes to the input map.
 func (vm *versionedTSMap) unionFamilyTimesTo(segments map[int64]struct{}) {
 	for familyTime := range vm.familyTimes {
@@ -40,27 +99,22 @@ func (vm *versionedTSMap) unionFamilyTimesTo(segments map[int64]struct{}) {
 	}
 }
 
-// flushMetricBlockTo flushes metric-block of mStore to the writer.
-func (vm *versionedTSMap) flushMetricBlocksTo(
-	writer metrictbl.TableWriter, metricID uint32, familyTime int64, generator index.IDGenerator) error {
-
+// collectTSStoresTo appends the tsStores which have data of the family to the list.
+func (vm *versionedTSMap) collectTSStoresTo(familyTime int64, tsStores []*timeSeriesStore) []*timeSeriesStore {
 	// this familyTime doesn't exist
 	if _, ok := vm.familyTimes[familyTime]; !ok {
-		return nil
+		return tsStores
 	}
 	all, release := vm.allTSStores()
 	defer release()
-
-	for _, tsStore := range *all {
-		tsStore.flushTSEntryTo(writer, metricID, familyTime, generator)
-	}
-	return writer.WriteMetricBlock(metricID)
+	return append(tsStores, *all...)
 }
 
 // newVersionedTSMap returns a new versionedTSMap.
 func newVersionedTSMap() *versionedTSMap {
 	return &versionedTSMap{
-		tsMap:       make(map[uint32]*timeSeriesStore),
+		tsMap:       make(map[uint64]*timeSeriesStore),
+		collisions:  make(map[string]*timeSeriesStore),
 		familyTimes: make(map[int64]struct{}),
 		version:     time.Now().UnixNano()}
 }
@@ -76,6 +130,7 @@ type metricStore struct {
 	name         string            // metric name
 	maxTagsLimit uint32            // maximum number of combinations of tags
 	metricID     uint32            // default 0, unset
+	dict         *stringDict       // dictionary of the values of string fields
 }
 
 // newMetricStore returns a new metricStore from name.
@@ -83,7 +138,8 @@ func newMetricStore(name string) *metricStore {
 	ms := metricStore{
 		name:         name,
 		mutable:      newVersionedTSMap(),
-		maxTagsLimit: defaultMaxTagsLimit}
+		maxTagsLimit: defaultMaxTagsLimit,
+		dict:         newStringDict()}
 	return &ms
 }
 
@@ -121,24 +177,24 @@ func (ms *metricStore) addFamilyTime(familyTime int64) {
 }
 
 // getTSStore returns timeSeriesStore, return false when not exist.
-func (ms *metricStore) getTSStore(tagsHash uint32) (tsStore *timeSeriesStore, ok bool) {
+func (ms *metricStore) getTSStore(tagsHash uint64, sortedTags string) (tsStore *timeSeriesStore, ok bool) {
 	ms.mu4Mutable.RLock()
-	tsStore, ok = ms.mutable.tsMap[tagsHash]
+	tsStore, ok = ms.mutable.get(tagsHash, sortedTags)
 	ms.mu4Mutable.RUnlock()
 	return
 }
 
 // getOrCreateTSStore returns timeSeriesStore by sortedTags.
 func (ms *metricStore) getOrCreateTSStore(sortedTags string) *timeSeriesStore {
-	tagsHash := hashers.Fnv32a(sortedTags)
+	tagsHash := hashers.XXHash64(sortedTags)
 
-	tsStore, ok := ms.getTSStore(tagsHash)
+	tsStore, ok := ms.getTSStore(tagsHash, sortedTags)
 	if !ok {
 		ms.mu4Mutable.Lock()
-		tsStore, ok = ms.mutable.tsMap[tagsHash]
+		tsStore, ok = ms.mutable.get(tagsHash, sortedTags)
 		if !ok {
 			tsStore = newTimeSeriesStore(sortedTags)
-			ms.mutable.tsMap[tagsHash] = tsStore
+			ms.mutable.put(tagsHash, tsStore)
 		}
 		ms.mu4Mutable.Unlock()
 	}
@@ -148,7 +204,7 @@ func (ms *metricStore) getOrCreateTSStore(sortedTags string) *timeSeriesStore {
 // getTagsCount return the map's length.
 func (ms *metricStore) getTagsCount() int {
 	ms.mu4Mutable.RLock()
-	length := len(ms.mutable.tsMap)
+	length := ms.mutable.size()
 	ms.mu4Mutable.RUnlock()
 	return length
 }
@@ -163,28 +219,47 @@ func (ms *metricStore) isEmpty() bool {
 	return ms.getTagsCount() == 0
 }
 
-// evict scans all metric-stores and removes which are not in use for a while.
-func (ms *metricStore) evict() {
-	var evictList []uint32
+// evict scans all metric-stores and removes which are not in use for a while, returns the count of evicted.
+func (ms *metricStore) evict() (evicted int) {
+	var evictList []*timeSeriesStore
 	ms.mu4Mutable.RLock()
-	for tagsHash, tStore := range ms.mutable.tsMap {
+	all, release := ms.mutable.allTSStores()
+	ms.mu4Mutable.RUnlock()
+	for _, tStore := range *all {
 		if tStore.shouldBeEvicted() {
-			evictList = append(evictList, tagsHash)
+			evictList = append(evictList, tStore)
 		}
 	}
-	ms.mu4Mutable.RUnlock()
+	release()
 
 	ms.mu4Mutable.Lock()
-	for _, tagsHash := range evictList {
-		tsStore, ok := ms.mutable.tsMap[tagsHash]
-		if !ok {
-			continue
-		}
+	for _, tsStore := range evictList {
 		if tsStore.shouldBeEvicted() {
-			delete(ms.mutable.tsMap, tagsHash)
+			ms.mutable.delete(hashers.XXHash64(tsStore.tags), tsStore)
+			evicted++
 		}
 	}
 	ms.mu4Mutable.Unlock()
+	return evicted
+}
+
+// collectStats adds the count of series and the estimated bytes of mutable and immutable tsMap to stats.
+func (ms *metricStore) collectStats(stats *Stats) {
+	ms.mu4Mutable.RLock()
+	stats.MutableSeries += ms.mutable.size()
+	stats.EstimatedBytes += ms.mutable.memSize()
+	ms.mu4Mutable.RUnlock()
+	stats.EstimatedBytes += ms.dict.memSize()
+
+	ms.sl4immutable.Lock()
+	for _, vm := range ms.immutable {
+		if vm == nil {
+			continue
+		}
+		stats.ImmutableSeries += vm.size()
+		stats.EstimatedBytes += vm.memSize()
+	}
+	ms.sl4immutable.Unlock()
 }
 
 // unionFamilyTimesTo updates familyTimes of mutable and immutable to the input map.
@@ -200,6 +275,256 @@ func (ms *metricStore) unionFamilyTimesTo(families map[int64]struct{}) {
 	ms.sl4immutable.Unlock()
 }
 
+// latestValues collects the latest value of fields of each series in mutable and immutable tsMap into values,
+// the latest one is kept if the series exists in both of them.
+func (ms *metricStore) latestValues(fieldNames []string, toTimestamp func(familyTime int64, slot int) int64,
+	values map[latestKey]LatestValue) {
+	collect := func(vm *versionedTSMap) {
+		all, release := vm.allTSStores()
+		defer release()
+		for _, tsStore := range *all {
+			for _, fieldName := range fieldNames {
+				fStore, ok := tsStore.getFStore(fieldName)
+				if !ok {
+					continue
+				}
+				familyTime, slot, value, ok := fStore.latest()
+				if !ok {
+					continue
+				}
+				timestamp := toTimestamp(familyTime, slot)
+				key := latestKey{tags: tsStore.tags, field: fieldName}
+				if exist, ok := values[key]; ok && exist.Timestamp >= timestamp {
+					continue
+				}
+				values[key] = LatestValue{Tags: tsStore.tags, Field: fieldName, Timestamp: timestamp, Value: value}
+			}
+		}
+	}
+	ms.mu4Mutable.RLock()
+	collect(ms.mutable)
+	ms.mu4Mutable.RUnlock()
+
+	ms.sl4immutable.Lock()
+	for _, vm := range ms.immutable {
+		if vm != nil {
+			collect(vm)
+		}
+	}
+	ms.sl4immutable.Unlock()
+}
+
+// stringValues collects the last value of string fields of each series in mutable and immutable tsMap into values,
+// the value of later timestamp is kept if the series exists in both of them.
+func (ms *metricStore) stringValues(fieldNames []string, values map[latestKey]StringValue) {
+	collect := func(vm *versionedTSMap) {
+		all, release := vm.allTSStores()
+		defer release()
+		for _, tsStore := range *all {
+			for _, fieldName := range fieldNames {
+				timestamp, valueID, ok := tsStore.getString(fieldName)
+				if !ok {
+					continue
+				}
+				key := latestKey{tags: tsStore.tags, field: fieldName}
+				if exist, ok := values[key]; ok && exist.Timestamp > timestamp {
+					continue
+				}
+				values[key] = StringValue{Tags: tsStore.tags, Field: fieldName, Timestamp: timestamp,
+					Value: ms.dict.get(valueID)}
+			}
+		}
+	}
+	ms.sl4immutable.Lock()
+	for _, vm := range ms.immutable {
+		if vm != nil {
+			collect(vm)
+		}
+	}
+	ms.sl4immutable.Unlock()
+
+	ms.mu4Mutable.RLock()
+	collect(ms.mutable)
+	ms.mu4Mutable.RUnlock()
+}
+
+// stringBlock builds the string block of the last values of string fields in mutable and immutable tsMap,
+// the value of later timestamp is kept if the series exists in both of them,
+// the dictionary of block only contains the values referenced, returns nil if no string field.
+func (ms *metricStore) stringBlock(metricID uint32, generator index.IDGenerator) *metrictbl.StringBlock {
+	var entries []metrictbl.StringEntry
+	collect := func(vm *versionedTSMap) {
+		all, release := vm.allTSStores()
+		defer release()
+		for _, tsStore := range *all {
+			entries = tsStore.stringEntriesTo(entries, metricID, generator)
+		}
+	}
+	ms.sl4immutable.Lock()
+	for _, vm := range ms.immutable {
+		if vm != nil {
+			collect(vm)
+		}
+	}
+	ms.sl4immutable.Unlock()
+
+	ms.mu4Mutable.RLock()
+	collect(ms.mutable)
+	ms.mu4Mutable.RUnlock()
+
+	if len(entries) == 0 {
+		return nil
+	}
+	block := &metrictbl.StringBlock{}
+	// index of entry in block, key: tsID<<32 | fieldID
+	entryIdx := make(map[uint64]int)
+	for _, entry := range entries {
+		key := uint64(entry.TSID)<<32 | uint64(entry.FieldID)
+		idx, ok := entryIdx[key]
+		if !ok {
+			entryIdx[key] = len(block.Entries)
+			block.Entries = append(block.Entries, entry)
+		} else if block.Entries[idx].Timestamp <= entry.Timestamp {
+			block.Entries[idx] = entry
+		}
+	}
+	sort.Slice(block.Entries, func(i, j int) bool {
+		if block.Entries[i].TSID != block.Entries[j].TSID {
+			return block.Entries[i].TSID < block.Entries[j].TSID
+		}
+		return block.Entries[i].FieldID < block.Entries[j].FieldID
+	})
+	// compacts the dictionary in the order of entries, id of block dictionary, key: id of metric dictionary
+	ids := make(map[uint32]uint32)
+	for i := range block.Entries {
+		entry := &block.Entries[i]
+		id, exist := ids[entry.ValueID]
+		if !exist {
+			id = uint32(len(block.Dict))
+			ids[entry.ValueID] = id
+			block.Dict = append(block.Dict, ms.dict.get(entry.ValueID))
+		}
+		entry.ValueID = id
+	}
+	return block
+}
+
+// rawSeries returns the raw points of fields of the series within the time range, the series are sorted by tags,
+// at most maxSeries series which have points are returned, truncated is true if there are more series.
+// The series exists in both mutable and immutable tsMap is merged, the point of later version is kept on same timestamp.
+func (ms *metricStore) rawSeries(fieldNames []string, toTimestamp func(familyTime int64, slot int) int64,
+	timeRange models.TimeRange, maxSeries int) (series []RawSeries, truncated bool) {
+	// the tsStores of same tags are ordered from older version to newer one
+	tsStores := make(map[string][]*timeSeriesStore)
+	collect := func(vm *versionedTSMap) {
+		all, release := vm.allTSStores()
+		defer release()
+		for _, tsStore := range *all {
+			tsStores[tsStore.tags] = append(tsStores[tsStore.tags], tsStore)
+		}
+	}
+	ms.sl4immutable.Lock()
+	for _, vm := range ms.immutable {
+		if vm != nil {
+			collect(vm)
+		}
+	}
+	ms.sl4immutable.Unlock()
+
+	ms.mu4Mutable.RLock()
+	collect(ms.mutable)
+	ms.mu4Mutable.RUnlock()
+
+	tagsList := make([]string, 0, len(tsStores))
+	for tags := range tsStores {
+		tagsList = append(tagsList, tags)
+	}
+	sort.Strings(tagsList)
+	for _, tags := range tagsList {
+		fields := make(map[string][]RawPoint)
+		for _, fieldName := range fieldNames {
+			if points := mergeRawPoints(tsStores[tags], fieldName, toTimestamp, timeRange); len(points) > 0 {
+				fields[fieldName] = points
+			}
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		if len(series) == maxSeries {
+			return series, true
+		}
+		series = append(series, RawSeries{Tags: tags, Fields: fields})
+	}
+	return series, false
+}
+
+// fieldTypes returns the types of fields which are found in the tsStores of mutable and immutable tsMap,
+// the type of field is same in all series, because the field store of other type is rejected when writing.
+func (ms *metricStore) fieldTypes(fieldNames []string) map[string]field.Type {
+	types := make(map[string]field.Type, len(fieldNames))
+	collect := func(vm *versionedTSMap) {
+		all, release := vm.allTSStores()
+		defer release()
+		for _, tsStore := range *all {
+			if len(types) == len(fieldNames) {
+				return
+			}
+			for _, fieldName := range fieldNames {
+				if _, ok := types[fieldName]; ok {
+					continue
+				}
+				if fStore, ok := tsStore.getFStore(fieldName); ok {
+					types[fieldName] = fStore.getFieldType()
+				}
+			}
+		}
+	}
+	ms.mu4Mutable.RLock()
+	collect(ms.mutable)
+	ms.mu4Mutable.RUnlock()
+
+	ms.sl4immutable.Lock()
+	for _, vm := range ms.immutable {
+		if vm != nil {
+			collect(vm)
+		}
+	}
+	ms.sl4immutable.Unlock()
+	return types
+}
+
+// mergeRawPoints merges the raw points of field in the tsStores of same series, the later one is kept on same timestamp.
+func mergeRawPoints(tsStores []*timeSeriesStore, fieldName string, toTimestamp func(familyTime int64, slot int) int64,
+	timeRange models.TimeRange) []RawPoint {
+	var merged []RawPoint
+	for _, tsStore := range tsStores {
+		fStore, ok := tsStore.getFStore(fieldName)
+		if !ok {
+			continue
+		}
+		points := fStore.rawPoints(toTimestamp, timeRange)
+		if len(merged) == 0 {
+			merged = points
+			continue
+		}
+		values := make(map[int64]int64, len(merged)+len(points))
+		for _, point := range merged {
+			values[point.Timestamp] = point.Value
+		}
+		for _, point := range points {
+			values[point.Timestamp] = point.Value
+		}
+		merged = merged[:0]
+		for timestamp, value := range values {
+			merged = append(merged, RawPoint{Timestamp: timestamp, Value: value})
+		}
+		sort.Slice(merged, func(i, j int) bool {
+			return merged[i].Timestamp < merged[j].Timestamp
+		})
+	}
+	return merged
+}
+
 // assignNewVersion moves the mutable TSMap to immutable list, then creates a new mutable map.
 func (ms *metricStore) assignNewVersion() error {
 	ms.mu4Mutable.Lock()
@@ -231,39 +556,51 @@ func (ms *metricStore) updateTSIDAndFieldID(metricID uint32, generator index.IDG
 	}
 }
 
-// flushMetricBlocksTo writes metric-blocks to the writer.
+// flushMetricBlocksTo writes the metric-block of the family to the writer, the TSEntries of immutable and mutable
+// parts are written in ascending order of tsID, so that the entries can be located by the keys of metric-block.
+// The entries of the same series in different parts are merged into one, the newer part wins for the same field.
 func (ms *metricStore) flushMetricBlocksTo(
 	writer metrictbl.TableWriter, familyTime int64, generator index.IDGenerator) error {
 
-	var err error
 	metricID := ms.mustGetMetricID(generator)
+	var tsStores []*timeSeriesStore
 
 	// pick immutable
 	ms.sl4immutable.Lock()
-	// build immutable metric-blocks
 	for _, vm := range ms.immutable {
 		if vm == nil {
 			continue
 		}
-		err = vm.flushMetricBlocksTo(writer, metricID, familyTime, generator)
+		tsStores = vm.collectTSStoresTo(familyTime, tsStores)
 		delete(vm.familyTimes, familyTime)
-		if err != nil {
-			ms.sl4immutable.Unlock()
-			return err
-		}
 	}
 	ms.sl4immutable.Unlock()
 
-	ms.mu4Mutable.RLock()
-	err = ms.mutable.flushMetricBlocksTo(writer, metricID, familyTime, generator)
-	ms.mu4Mutable.RUnlock()
-
 	ms.mu4Mutable.Lock()
+	tsStores = ms.mutable.collectTSStoresTo(familyTime, tsStores)
 	delete(ms.mutable.familyTimes, familyTime)
 	ms.mu4Mutable.Unlock()
 
-	if err != nil {
-		return err
+	if len(tsStores) == 0 {
+		return nil
 	}
-	return nil
+	tsIDs := make([]uint32, len(tsStores))
+	for idx, tsStore := range tsStores {
+		tsIDs[idx] = tsStore.mustGetTSID(metricID, generator)
+	}
+	order := make([]int, len(tsStores))
+	for idx := range order {
+		order[idx] = idx
+	}
+	// stable sort keeps the older part before the newer one for the same series
+	sort.SliceStable(order, func(i, j int) bool {
+		return tsIDs[order[i]] < tsIDs[order[j]]
+	})
+	for idx, pos := range order {
+		tsStores[pos].flushFieldsTo(writer, metricID, familyTime, generator)
+		if idx == len(order)-1 || tsIDs[order[idx+1]] != tsIDs[pos] {
+			writer.WriteTSEntry(tsIDs[pos])
+		}
+	}
+	return writer.WriteMetricBlock(metricID)
 }
@@ -35,13 +35,13 @@ func Test_metricStore_isEmpty_isFull(t *testing.T) {
 	assert.True(t, mStore.isEmpty())
 	assert.False(t, mStore.isFull())
 
-	for i := uint32(0); i < 100; i++ {
+	for i := uint64(0); i < 100; i++ {
 		mStore.mutable.tsMap[i] = nil
 	}
 	assert.False(t, mStore.isFull())
 	assert.False(t, mStore.isEmpty())
 
-	for i := uint32(0); i < defaultMaxTagsLimit; i++ {
+	for i := uint64(0); i < defaultMaxTagsLimit; i++ {
 		mStore.mutable.tsMap[i] = nil
 	}
 	assert.True(t, mStore.isFull())
@@ -56,6 +56,40 @@ func Test_metricStore_getTimeSeries(t *testing.T) {
 	assert.Equal(t, mStore.getTagsCount(), 2)
 }
 
+func Test_versionedTSMap_hashCollision(t *testing.T) {
+	vm := newVersionedTSMap()
+	tsStore1 := newTimeSeriesStore("host=alpha-1")
+	tsStore2 := newTimeSeriesStore("host=alpha-2")
+	// mock the same hash of different tags
+	vm.put(1, tsStore1)
+	vm.put(1, tsStore2)
+	assert.Equal(t, 2, vm.size())
+
+	tsStore, ok := vm.get(1, "host=alpha-1")
+	assert.True(t, ok)
+	assert.Equal(t, tsStore1, tsStore)
+	tsStore, ok = vm.get(1, "host=alpha-2")
+	assert.True(t, ok)
+	assert.Equal(t, tsStore2, tsStore)
+	_, ok = vm.get(1, "host=alpha-3")
+	assert.False(t, ok)
+	_, ok = vm.get(2, "host=alpha-1")
+	assert.False(t, ok)
+
+	all, release := vm.allTSStores()
+	assert.Len(t, *all, 2)
+	release()
+
+	vm.delete(1, tsStore1)
+	_, ok = vm.get(1, "host=alpha-1")
+	assert.False(t, ok)
+	tsStore, ok = vm.get(1, "host=alpha-2")
+	assert.True(t, ok)
+	assert.Equal(t, tsStore2, tsStore)
+	vm.delete(1, tsStore2)
+	assert.Equal(t, 0, vm.size())
+}
+
 func Test_metricStore_evict(t *testing.T) {
 	mStore := newMetricStore("cpu.load")
 	mStore.evict()
@@ -75,12 +109,12 @@ func Test_metricStore_evict(t *testing.T) {
 	for i := 0; i < 1000; i++ {
 		mStore.getOrCreateTSStore(strconv.Itoa(i)).getOrCreateFStore("t", field.MaxField)
 	}
-	mStore.evict()
+	assert.Equal(t, 1000, mStore.evict())
 	assert.Equal(t, 1000, mStore.getTagsCount())
 	// purge all
 	time.Sleep(time.Millisecond * 20)
 	setTagsIDTTL(20) // 20 ms
-	mStore.evict()
+	assert.Equal(t, 1000, mStore.evict())
 	assert.True(t, mStore.isEmpty())
 }
 
@@ -0,0 +1,169 @@
+package memdb
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/eleme/lindb/models"
+)
+
+// Defines the reasons of rejecting points by memory-database
+const (
+	rejectByInvalid        = "invalid"
+	rejectByTooManyTags    = "too_many_tags"
+	rejectByTooManyFields  = "too_many_fields"
+	rejectByWrongFieldType = "wrong_field_type"
+)
+
+// Defines the states of series in memory-database
+const (
+	seriesMutable   = "mutable"
+	seriesImmutable = "immutable"
+)
+
+// interval for refreshing the gauges of memory-database, like the count of series and the estimated bytes
+var statsInterval = 15 * time.Second
+
+var (
+	memDBMetricCount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "lindb",
+		Subsystem: "memdb",
+		Name:      "metrics",
+		Help:      "The number of metrics in memory-database of shard.",
+	}, []string{"db", "shard"})
+	memDBSeries = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "lindb",
+		Subsystem: "memdb",
+		Name:      "series",
+		Help:      "The number of series in memory-database of shard, by the state(mutable/immutable) of series.",
+	}, []string{"db", "shard", "state"})
+	memDBBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "lindb",
+		Subsystem: "memdb",
+		Name:      "estimated_bytes",
+		Help:      "The estimated bytes of the data in memory-database of shard.",
+	}, []string{"db", "shard"})
+	memDBWrittenPoints = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "lindb",
+		Subsystem: "memdb",
+		Name:      "written_points_total",
+		Help:      "The number of points written into memory-database of shard.",
+	}, []string{"db", "shard"})
+	memDBRejectedPoints = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "lindb",
+		Subsystem: "memdb",
+		Name:      "rejected_points_total",
+		Help:      "The number of points rejected by memory-database of shard, by the reason of rejecting.",
+	}, []string{"db", "shard", "reason"})
+	memDBEvictedSeries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "lindb",
+		Subsystem: "memdb",
+		Name:      "evicted_series_total",
+		Help:      "The number of series evicted from memory-database of shard, which are not written for a while.",
+	}, []string{"db", "shard"})
+	memDBFlushDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "lindb",
+		Subsystem: "memdb",
+		Name:      "flush_duration_seconds",
+		Help:      "The duration of flushing a family of memory-database of shard.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 16),
+	}, []string{"db", "shard"})
+)
+
+func init() {
+	prometheus.MustRegister(memDBMetricCount, memDBSeries, memDBBytes,
+		memDBWrittenPoints, memDBRejectedPoints, memDBEvictedSeries, memDBFlushDuration)
+}
+
+// memoryDBMetrics holds the self-monitoring metrics of memory-database, which are labeled by database and shard.
+type memoryDBMetrics struct {
+	db    string
+	shard string
+
+	metrics         prometheus.Gauge
+	mutableSeries   prometheus.Gauge
+	immutableSeries prometheus.Gauge
+	bytes           prometheus.Gauge
+	writtenPoints   prometheus.Counter
+	evictedSeries   prometheus.Counter
+	flushDuration   prometheus.Observer
+}
+
+// newMemoryDBMetrics returns the metrics of memory-database for the shard of database.
+func newMemoryDBMetrics(db string, shardID int) *memoryDBMetrics {
+	shard := strconv.Itoa(shardID)
+	return &memoryDBMetrics{
+		db:              db,
+		shard:           shard,
+		metrics:         memDBMetricCount.WithLabelValues(db, shard),
+		mutableSeries:   memDBSeries.WithLabelValues(db, shard, seriesMutable),
+		immutableSeries: memDBSeries.WithLabelValues(db, shard, seriesImmutable),
+		bytes:           memDBBytes.WithLabelValues(db, shard),
+		writtenPoints:   memDBWrittenPoints.WithLabelValues(db, shard),
+		evictedSeries:   memDBEvictedSeries.WithLabelValues(db, shard),
+		flushDuration:   memDBFlushDuration.WithLabelValues(db, shard),
+	}
+}
+
+// reject increases the count of rejected points by the reason of error.
+func (m *memoryDBMetrics) reject(err error) {
+	reason := rejectByInvalid
+	switch err {
+	case models.ErrTooManyTags:
+		reason = rejectByTooManyTags
+	case models.ErrTooManyFields:
+		reason = rejectByTooManyFields
+	case models.ErrWrongFieldType:
+		reason = rejectByWrongFieldType
+	}
+	memDBRejectedPoints.WithLabelValues(m.db, m.shard, reason).Inc()
+}
+
+// delete removes the metrics of the shard, after the memory-database is closed.
+func (m *memoryDBMetrics) delete() {
+	memDBMetricCount.DeleteLabelValues(m.db, m.shard)
+	memDBSeries.DeleteLabelValues(m.db, m.shard, seriesMutable)
+	memDBSeries.DeleteLabelValues(m.db, m.shard, seriesImmutable)
+	memDBBytes.DeleteLabelValues(m.db, m.shard)
+	memDBWrittenPoints.DeleteLabelValues(m.db, m.shard)
+	for _, reason := range []string{rejectByInvalid, rejectByTooManyTags, rejectByTooManyFields, rejectByWrongFieldType} {
+		memDBRejectedPoints.DeleteLabelValues(m.db, m.shard, reason)
+	}
+	memDBEvictedSeries.DeleteLabelValues(m.db, m.shard)
+	memDBFlushDuration.DeleteLabelValues(m.db, m.shard)
+}
+
+// Stats represents the statistics of memory-database.
+type Stats struct {
+	Metrics         int // count of metric stores
+	MutableSeries   int // count of series in mutable tsMap
+	ImmutableSeries int // count of series in immutable tsMap not flushed
+	EstimatedBytes  int // estimated bytes of tags and field data
+}
+
+// statsCollector refreshes the gauges of memory-database periodically, removes the metrics after context done.
+func (md *memoryDatabase) statsCollector(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			md.metrics.delete()
+			return
+		case <-ticker.C:
+			md.refreshStats()
+		}
+	}
+}
+
+// refreshStats sets the gauges of memory-database by current statistics.
+func (md *memoryDatabase) refreshStats() {
+	stats := md.Stats()
+	md.metrics.metrics.Set(float64(stats.Metrics))
+	md.metrics.mutableSeries.Set(float64(stats.MutableSeries))
+	md.metrics.immutableSeries.Set(float64(stats.ImmutableSeries))
+	md.metrics.bytes.Set(float64(stats.EstimatedBytes))
+}
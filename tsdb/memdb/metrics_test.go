@@ -0,0 +1,86 @@
+package memdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/pkg/interval"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/tsdb/index"
+	"github.com/eleme/lindb/tsdb/metrictbl"
+)
+
+func TestMemoryDatabase_metrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	md, _ := newMemoryDatabase(ctx, 32, 10*1000, interval.Day)
+	md.metrics = newMemoryDBMetrics("metrics-db", 1)
+
+	now := timeutil.Now()
+	point := func(fieldType field.Type) models.Point {
+		return models.NewPoint("cpu", now, map[string]string{"host": "1.1.1.1"}, map[string]models.Field{
+			"f1": models.NewSimpleField(fieldType, field.Integer, int64(1)),
+		})
+	}
+	assert.Nil(t, md.Write(point(field.SumField)))
+	assert.Nil(t, md.Write(point(field.SumField)))
+	assert.Equal(t, models.ErrWrongFieldType, md.Write(point(field.MaxField)))
+	assert.NotNil(t, md.Write(nil))
+
+	metric := &dto.Metric{}
+	assert.Nil(t, memDBWrittenPoints.WithLabelValues("metrics-db", "1").Write(metric))
+	assert.Equal(t, 2.0, metric.GetCounter().GetValue())
+	assert.Nil(t, memDBRejectedPoints.WithLabelValues("metrics-db", "1", rejectByWrongFieldType).Write(metric))
+	assert.Equal(t, 1.0, metric.GetCounter().GetValue())
+	assert.Nil(t, memDBRejectedPoints.WithLabelValues("metrics-db", "1", rejectByInvalid).Write(metric))
+	assert.Equal(t, 1.0, metric.GetCounter().GetValue())
+
+	// stats of mutable and immutable series
+	stats := md.Stats()
+	assert.Equal(t, 1, stats.Metrics)
+	assert.Equal(t, 1, stats.MutableSeries)
+	assert.Equal(t, 0, stats.ImmutableSeries)
+	assert.True(t, stats.EstimatedBytes > 0)
+	mStore, _ := md.getMStore("cpu")
+	mStore.mutable.version -= minIntervalForResetMetricStore * int64(time.Millisecond)
+	assert.Nil(t, md.ResetMetricStore("cpu"))
+	md.refreshStats()
+	assert.Nil(t, memDBSeries.WithLabelValues("metrics-db", "1", seriesImmutable).Write(metric))
+	assert.Equal(t, 1.0, metric.GetGauge().GetValue())
+	assert.Nil(t, memDBSeries.WithLabelValues("metrics-db", "1", seriesMutable).Write(metric))
+	assert.Equal(t, 0.0, metric.GetGauge().GetValue())
+	assert.Nil(t, memDBBytes.WithLabelValues("metrics-db", "1").Write(metric))
+	assert.Equal(t, float64(stats.EstimatedBytes), metric.GetGauge().GetValue())
+
+	// flush duration
+	generator := index.NewMockIDGenerator(ctrl)
+	generator.EXPECT().GenMetricID(gomock.Any()).Return(uint32(1)).AnyTimes()
+	generator.EXPECT().GenTSID(gomock.Any(), gomock.Any()).Return(uint32(1)).AnyTimes()
+	generator.EXPECT().GenFieldID(gomock.Any(), gomock.Any(), gomock.Any()).Return(uint32(1)).AnyTimes()
+	md.generator = generator
+	writer := metrictbl.NewMockTableWriter(ctrl)
+	writer.EXPECT().WriteField(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	writer.EXPECT().WriteTSEntry(gomock.Any()).AnyTimes()
+	writer.EXPECT().WriteMetricBlock(gomock.Any()).Return(nil).AnyTimes()
+	assert.Nil(t, md.flushFamilyTo(ctx, md.Families()[0], writer))
+	assert.Nil(t, md.metrics.flushDuration.(prometheus.Histogram).Write(metric))
+	assert.Equal(t, uint64(1), metric.GetHistogram().GetSampleCount())
+
+	// the metrics of shard are removed after memory-database is closed
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	go md.statsCollector(ctx2, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	cancel2()
+	time.Sleep(10 * time.Millisecond)
+	assert.False(t, memDBWrittenPoints.DeleteLabelValues("metrics-db", "1"))
+}
@@ -2,6 +2,7 @@ package memdb
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/eleme/lindb/pkg/encoding"
 	"github.com/eleme/lindb/pkg/field"
@@ -12,6 +13,12 @@ import (
 type segmentStore interface {
 	bytes() (data []byte, startSlot, endSlot int, err error)
 	writeInt(blockStore *blockStore, slotTime int, value int64)
+	// latest returns the latest slot with value, ok is false if store has no value
+	latest() (slot int, value int64, ok bool)
+	// points calls fn with the slot and value of each point in the store, in the order of slot
+	points(fn func(slot int, value int64))
+	// memSize returns the estimated bytes of block and compressed data
+	memSize() int
 }
 
 // singleFieldStore stores single field
@@ -78,3 +85,90 @@ func (fs *simpleFieldStore) bytes() (data []byte, startSlot, endSlot int, err er
 	startSlot, endSlot = encoding.DecodeTSDTime(data)
 	return
 }
+
+// memSize returns the bytes of values in block and the compressed data.
+func (fs *simpleFieldStore) memSize() int {
+	if fs.block == nil {
+		return 0
+	}
+	return len(fs.block.values)*8 + cap(fs.block.compress)
+}
+
+// latest returns the latest slot and value, which is read from the head block,
+// the compressed data is decoded only if it has later slot than the head block(written out of order).
+func (fs *simpleFieldStore) latest() (slot int, value int64, ok bool) {
+	head := fs.block
+	if head == nil {
+		return 0, 0, false
+	}
+	if head.container.container != 0 {
+		slot = head.getEndTime()
+		value = head.getValue(slot - head.getStartTime())
+		ok = true
+	}
+	compress := head.bytes()
+	if len(compress) == 0 {
+		return
+	}
+	if _, endTime := encoding.DecodeTSDTime(compress); ok && endTime <= slot {
+		return
+	}
+	decodeTSD(compress, func(s int, v int64) {
+		if !ok || s > slot {
+			slot, value, ok = s, v, true
+		}
+	})
+	return
+}
+
+// points calls fn with each point of the compressed data and the head block in the order of slot,
+// the slot written out of order exists in both of them, which is aggregated by agg func like compaction does.
+func (fs *simpleFieldStore) points(fn func(slot int, value int64)) {
+	head := fs.block
+	if head == nil {
+		return
+	}
+	values := make(map[int]int64)
+	if compress := head.bytes(); len(compress) > 0 {
+		decodeTSD(compress, func(slot int, value int64) {
+			values[slot] = value
+		})
+	}
+	if head.container.container != 0 {
+		startTime := head.getStartTime()
+		for pos := 0; pos <= head.getEndTime()-startTime; pos++ {
+			if !head.hasValue(pos) {
+				continue
+			}
+			value := head.getValue(pos)
+			if exist, ok := values[startTime+pos]; ok {
+				value = fs.aggFunc.AggregateInt(exist, value)
+			}
+			values[startTime+pos] = value
+		}
+	}
+	slots := make([]int, 0, len(values))
+	for slot := range values {
+		slots = append(slots, slot)
+	}
+	sort.Ints(slots)
+	for _, slot := range slots {
+		fn(slot, values[slot])
+	}
+}
+
+// decodeTSD calls fn with each point of the compressed data in the order of slot, which is decoded
+// by the pooled block decoder in one call, the corrupted data is skipped.
+func decodeTSD(compress []byte, fn func(slot int, value int64)) {
+	decoder := encoding.GetBlockDecoder()
+	defer encoding.PutBlockDecoder(decoder)
+	startTime, values, exists, err := decoder.DecodeTSD(compress)
+	if err != nil {
+		return
+	}
+	for i, value := range values {
+		if exists[i] {
+			fn(startTime+i, encoding.ZigZagDecode(value))
+		}
+	}
+}
@@ -1,10 +1,12 @@
 package memdb
 
 import (
+	"sort"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/eleme/lindb/pkg/bit"
 	"github.com/eleme/lindb/pkg/encoding"
 	"github.com/eleme/lindb/pkg/field"
 )
@@ -57,6 +59,81 @@ func TestSimpleSegmentStore(t *testing.T) {
 	assert.Equal(t, int64(50), encoding.ZigZagDecode(tsd.Value()))
 }
 
+func TestSimpleSegmentStore_latest(t *testing.T) {
+	store := newSimpleFieldStore(field.GetAggFunc(field.Sum))
+	_, _, ok := store.latest()
+	assert.False(t, ok)
+
+	bs := newBlockStore(30)
+	store.writeInt(bs, 10, int64(100))
+	store.writeInt(bs, 12, int64(120))
+	store.writeInt(bs, 12, int64(1))
+	slot, value, ok := store.latest()
+	assert.True(t, ok)
+	assert.Equal(t, 12, slot)
+	assert.Equal(t, int64(121), value)
+
+	// latest slot is in compressed data, because older slot is written out of order
+	store.writeInt(bs, 45, int64(450))
+	store.writeInt(bs, 5, int64(50))
+	slot, value, ok = store.latest()
+	assert.True(t, ok)
+	assert.Equal(t, 45, slot)
+	assert.Equal(t, int64(450), value)
+
+	store.writeInt(bs, 50, int64(500))
+	slot, value, ok = store.latest()
+	assert.True(t, ok)
+	assert.Equal(t, 50, slot)
+	assert.Equal(t, int64(500), value)
+}
+
+func TestSimpleSegmentStore_points(t *testing.T) {
+	store := newSimpleFieldStore(field.GetAggFunc(field.Sum))
+	collect := func() map[int]int64 {
+		points := make(map[int]int64)
+		var slots []int
+		store.points(func(slot int, value int64) {
+			slots = append(slots, slot)
+			points[slot] = value
+		})
+		assert.True(t, sort.IntsAreSorted(slots))
+		return points
+	}
+	assert.Empty(t, collect())
+
+	bs := newBlockStore(30)
+	store.writeInt(bs, 10, int64(100))
+	store.writeInt(bs, 12, int64(120))
+	assert.Equal(t, map[int]int64{10: 100, 12: 120}, collect())
+
+	// slot 10 is written out of order, which exists in both compressed data and head block
+	store.writeInt(bs, 45, int64(450))
+	store.writeInt(bs, 10, int64(1))
+	assert.Equal(t, map[int]int64{10: 101, 12: 120, 45: 450}, collect())
+}
+
+func Test_decodeTSD(t *testing.T) {
+	encoder := encoding.NewTSDEncoder(5)
+	encoder.AppendTime(bit.One)
+	encoder.AppendValue(encoding.ZigZagEncode(-1))
+	encoder.AppendTime(bit.Zero)
+	encoder.AppendTime(bit.One)
+	encoder.AppendValue(encoding.ZigZagEncode(7))
+	data, err := encoder.Bytes()
+	assert.Nil(t, err)
+	points := make(map[int]int64)
+	decodeTSD(data, func(slot int, value int64) {
+		points[slot] = value
+	})
+	assert.Equal(t, map[int]int64{5: -1, 7: 7}, points)
+
+	// the corrupted data is skipped
+	decodeTSD(data[:2], func(slot int, value int64) {
+		assert.Fail(t, "corrupted data is decoded")
+	})
+}
+
 func BenchmarkSimpleSegmentStore(b *testing.B) {
 	aggFunc := field.GetAggFunc(field.Sum)
 	store := newSimpleFieldStore(aggFunc)
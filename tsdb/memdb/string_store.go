@@ -0,0 +1,99 @@
+package memdb
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/tsdb/index"
+)
+
+// stringDict is the dictionary of string values of metric, the value of string field is encoded as the id of
+// dictionary, so that the same value(like build version) shared by many series is stored once.
+// The values are never removed until the metric store is dropped.
+type stringDict struct {
+	mutex  sync.RWMutex
+	ids    map[string]uint32
+	values []string
+	bytes  int
+}
+
+// newStringDict returns a new string dictionary.
+func newStringDict() *stringDict {
+	return &stringDict{ids: make(map[string]uint32)}
+}
+
+// getOrAdd returns the id of value, adds the value into dictionary if not exist.
+func (d *stringDict) getOrAdd(value string) uint32 {
+	d.mutex.RLock()
+	id, ok := d.ids[value]
+	d.mutex.RUnlock()
+	if ok {
+		return id
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if id, ok = d.ids[value]; ok {
+		return id
+	}
+	id = uint32(len(d.values))
+	d.ids[value] = id
+	d.values = append(d.values, value)
+	d.bytes += len(value)
+	return id
+}
+
+// get returns the value of id.
+func (d *stringDict) get(id uint32) string {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	if int(id) >= len(d.values) {
+		return ""
+	}
+	return d.values[id]
+}
+
+// memSize returns the estimated bytes of values in dictionary.
+func (d *stringDict) memSize() int {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	// the value is referenced by both of the map and the slice
+	return d.bytes + len(d.values)*8
+}
+
+// stringFieldStore holds the last value of the string field of series, the value of later timestamp wins,
+// the later write wins on same timestamp(last-value semantics).
+type stringFieldStore struct {
+	fieldName string
+	fieldID   uint32 // default 0
+	timestamp int64
+	valueID   uint32
+}
+
+// mustGetFieldID returns fieldID, if unset, generate a new one.
+func (fs *stringFieldStore) mustGetFieldID(metricID uint32, generator index.IDGenerator) uint32 {
+	fieldID := atomic.LoadUint32(&fs.fieldID)
+	if fieldID > 0 {
+		return fieldID
+	}
+	atomic.CompareAndSwapUint32(&fs.fieldID, 0, generator.GenFieldID(metricID, fs.fieldName, field.StringField))
+	return atomic.LoadUint32(&fs.fieldID)
+}
+
+// newStringFieldStore returns a new stringFieldStore with the first value.
+func newStringFieldStore(fieldName string, timestamp int64, valueID uint32) *stringFieldStore {
+	return &stringFieldStore{
+		fieldName: fieldName,
+		timestamp: timestamp,
+		valueID:   valueID,
+	}
+}
+
+// write keeps the value if its timestamp isn't before the last value.
+func (fs *stringFieldStore) write(timestamp int64, valueID uint32) {
+	if timestamp < fs.timestamp {
+		return
+	}
+	fs.timestamp = timestamp
+	fs.valueID = valueID
+}
@@ -14,19 +14,20 @@ import (
 
 // timeSeriesStore holds a mapping relation of field and fieldStore.
 type timeSeriesStore struct {
-	tsID           uint32                 // tsId identifier
-	fields         map[uint32]*fieldStore // key: Fnv32a(fieldName)
-	lastAccessedAt int64                  // nanoseconds
-	sl             lockers.SpinLock       // spin-lock
-	tags           *string                // tags identifier, nil after tsID is generated
+	tsID           uint32                       // tsId identifier
+	fields         map[uint64]*fieldStore       // key: XXHash64(fieldName)
+	strings        map[uint64]*stringFieldStore // key: XXHash64(fieldName), nil if no string field
+	lastAccessedAt int64                        // nanoseconds
+	sl             lockers.SpinLock             // spin-lock
+	tags           string                       // tags identifier, used for verifying the series identity after hashing
 }
 
 // newTimeSeriesStore returns a new timeSeriesStore from tags.
 func newTimeSeriesStore(tags string) *timeSeriesStore {
 	return &timeSeriesStore{
-		tags:           &tags,
+		tags:           tags,
 		lastAccessedAt: time.Now().UnixNano(),
-		fields:         make(map[uint32]*fieldStore)}
+		fields:         make(map[uint64]*fieldStore)}
 }
 
 // mustGetTSID returns tsID, if unset, generate a new one.
@@ -35,11 +36,7 @@ func (ts *timeSeriesStore) mustGetTSID(metricID uint32, generator index.IDGenera
 	if tsID > 0 {
 		return tsID
 	}
-	theTags := ts.tags
-	if theTags != nil {
-		atomic.CompareAndSwapUint32(&ts.tsID, 0, generator.GenTSID(metricID, *theTags))
-		ts.tags = nil
-	}
+	atomic.CompareAndSwapUint32(&ts.tsID, 0, generator.GenTSID(metricID, ts.tags))
 	return atomic.LoadUint32(&ts.tsID)
 }
 
@@ -49,15 +46,22 @@ func (ts *timeSeriesStore) generateFieldsID(metricID uint32, generator index.IDG
 	for _, fStore := range ts.fields {
 		fStore.mustGetFieldID(metricID, generator)
 	}
+	for _, sStore := range ts.strings {
+		sStore.mustGetFieldID(metricID, generator)
+	}
 	ts.sl.Unlock()
 }
 
 // getOrCreateFStore mustGet a fieldStore by fieldName.
 func (ts *timeSeriesStore) getOrCreateFStore(fieldName string, fieldType field.Type) (*fieldStore, error) {
 	atomic.StoreInt64(&ts.lastAccessedAt, time.Now().UnixNano())
-	fieldHash := hashers.Fnv32a(fieldName)
+	fieldHash := hashers.XXHash64(fieldName)
 
 	ts.sl.Lock()
+	if _, ok := ts.strings[fieldHash]; ok {
+		ts.sl.Unlock()
+		return nil, models.ErrWrongFieldType
+	}
 	store, exist := ts.fields[fieldHash]
 	if exist {
 		if store.getFieldType() != fieldType {
@@ -72,6 +76,48 @@ func (ts *timeSeriesStore) getOrCreateFStore(fieldName string, fieldType field.T
 	return store, nil
 }
 
+// writeString writes the value id of string field, the value of later timestamp is kept.
+func (ts *timeSeriesStore) writeString(fieldName string, timestamp int64, valueID uint32) error {
+	atomic.StoreInt64(&ts.lastAccessedAt, time.Now().UnixNano())
+	fieldHash := hashers.XXHash64(fieldName)
+
+	ts.sl.Lock()
+	defer ts.sl.Unlock()
+	if _, ok := ts.fields[fieldHash]; ok {
+		return models.ErrWrongFieldType
+	}
+	if store, ok := ts.strings[fieldHash]; ok {
+		store.write(timestamp, valueID)
+		return nil
+	}
+	if ts.strings == nil {
+		ts.strings = make(map[uint64]*stringFieldStore)
+	}
+	ts.strings[fieldHash] = newStringFieldStore(fieldName, timestamp, valueID)
+	return nil
+}
+
+// getString returns the timestamp and value id of the last value of string field, return false when not exist.
+func (ts *timeSeriesStore) getString(fieldName string) (timestamp int64, valueID uint32, ok bool) {
+	fieldHash := hashers.XXHash64(fieldName)
+	ts.sl.Lock()
+	defer ts.sl.Unlock()
+	store, ok := ts.strings[fieldHash]
+	if !ok {
+		return 0, 0, false
+	}
+	return store.timestamp, store.valueID, true
+}
+
+// getFStore returns the fieldStore by fieldName, return false when not exist.
+func (ts *timeSeriesStore) getFStore(fieldName string) (*fieldStore, bool) {
+	fieldHash := hashers.XXHash64(fieldName)
+	ts.sl.Lock()
+	store, ok := ts.fields[fieldHash]
+	ts.sl.Unlock()
+	return store, ok
+}
+
 // shouldBeEvicted detects if thisStore has not been accessed for tagsIDTTL.
 func (ts *timeSeriesStore) shouldBeEvicted() bool {
 	// validate ttl
@@ -99,20 +145,61 @@ func (ts *timeSeriesStore) isFull() bool {
 // getFieldsCount returns the count of fields thread-safely.
 func (ts *timeSeriesStore) getFieldsCount() int {
 	ts.sl.Lock()
-	length := len(ts.fields)
+	length := len(ts.fields) + len(ts.strings)
 	ts.sl.Unlock()
 	return length
 }
 
+// memSize returns the estimated bytes of tags and field stores.
+func (ts *timeSeriesStore) memSize() int {
+	ts.sl.Lock()
+	defer ts.sl.Unlock()
+	size := len(ts.tags)
+	for _, fStore := range ts.fields {
+		size += fStore.memSize()
+	}
+	for _, sStore := range ts.strings {
+		// field name, timestamp, field id and value id
+		size += len(sStore.fieldName) + 16
+	}
+	return size
+}
+
+// stringEntriesTo appends the last values of string fields into the entries of string block,
+// the value id of entry is the id of metric dictionary.
+func (ts *timeSeriesStore) stringEntriesTo(entries []metrictbl.StringEntry, metricID uint32,
+	generator index.IDGenerator) []metrictbl.StringEntry {
+	ts.sl.Lock()
+	defer ts.sl.Unlock()
+	if len(ts.strings) == 0 {
+		return entries
+	}
+	tsID := ts.mustGetTSID(metricID, generator)
+	for _, sStore := range ts.strings {
+		entries = append(entries, metrictbl.StringEntry{
+			TSID:      tsID,
+			FieldID:   sStore.mustGetFieldID(metricID, generator),
+			Timestamp: sStore.timestamp,
+			ValueID:   sStore.valueID,
+		})
+	}
+	return entries
+}
+
 // flushTSEntryTo flushes the tsEntry data segment.
 func (ts *timeSeriesStore) flushTSEntryTo(writer metrictbl.TableWriter, metricID uint32,
 	familyTime int64, generator index.IDGenerator) {
 	tsID := ts.mustGetTSID(metricID, generator)
+	ts.flushFieldsTo(writer, metricID, familyTime, generator)
+	writer.WriteTSEntry(tsID)
+}
 
+// flushFieldsTo flushes the data of fields in the family, the tsEntry is written by caller.
+func (ts *timeSeriesStore) flushFieldsTo(writer metrictbl.TableWriter, metricID uint32,
+	familyTime int64, generator index.IDGenerator) {
 	ts.sl.Lock()
 	for _, fStore := range ts.fields {
 		fStore.flushFieldTo(writer, metricID, familyTime, generator)
 	}
-	writer.WriteTSEntry(tsID)
 	ts.sl.Unlock()
 }
@@ -0,0 +1,158 @@
+package tsdb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/eleme/lindb/kv"
+	"github.com/eleme/lindb/kv/table"
+	"github.com/eleme/lindb/tsdb/memdb"
+	"github.com/eleme/lindb/tsdb/metrictbl"
+)
+
+// flushMemoryDatabase flushes all families of memory-database into the kv families of field groups of the segments
+// which the families belong to, then flushes the last values of string fields into the string families of segments,
+// the segments and families are created if not exist
+func flushMemoryDatabase(ctx context.Context, memDB memdb.MemoryDatabase, segment IntervalSegment) error {
+	for _, familyTime := range memDB.Families() {
+		if err := flushFamily(ctx, memDB, segment, familyTime); err != nil {
+			return err
+		}
+	}
+	return flushStrings(ctx, memDB, segment)
+}
+
+// flushStrings flushes the last values of string fields into the string family of the segments
+// which the timestamps of values belong to as new files
+func flushStrings(ctx context.Context, memDB memdb.MemoryDatabase, segment IntervalSegment) error {
+	err := memDB.FlushStringsTo(ctx, func(segmentTime int64) (table.Builder, error) {
+		seg, err := segment.GetOrCreateSegmentOf(segmentTime)
+		if err != nil {
+			return nil, err
+		}
+		family, err := seg.GetOrCreateFamilyByName(metrictbl.StringFamilyName)
+		if err != nil {
+			return nil, err
+		}
+		return newFlusherBuilder(family.NewFlusherWithContext(ctx)), nil
+	})
+	if err != nil {
+		return fmt.Errorf("flush string values error:%s", err)
+	}
+	return nil
+}
+
+// fieldGroupOf groups the fields flushed into the families of field groups, each field has its own family,
+// so that the query of a field never reads the bytes of other fields
+var fieldGroupOf metrictbl.FieldGroupFunc = metrictbl.PerField
+
+// flushFamily flushes the family of memory-database into the kv families of field groups(see metrictbl.FieldFamilyName)
+// of segment as new files
+func flushFamily(ctx context.Context, memDB memdb.MemoryDatabase, segment IntervalSegment, familyTime int64) error {
+	seg, err := segment.GetOrCreateSegmentOf(familyTime)
+	if err != nil {
+		return err
+	}
+	familyName := seg.FamilyName(familyTime)
+	err = memDB.FlushFamilyFieldsTo(ctx, familyTime, fieldGroupOf, func(group uint32) (table.Builder, error) {
+		family, err := seg.GetOrCreateFamilyByName(metrictbl.FieldFamilyName(familyName, group))
+		if err != nil {
+			return nil, err
+		}
+		return newFlusherBuilder(family.NewFlusherWithContext(ctx)), nil
+	})
+	if err != nil {
+		return fmt.Errorf("flush family[%d] error:%s", familyTime, err)
+	}
+	return nil
+}
+
+// flusherBuilder adapts the flusher of kv family to table builder, so that the family of memory-database
+// is flushed into kv family as a new file, the flusher is committed after the builder closed.
+// NOTICE: the time range isn't recorded in the file meta, so the file is read by the queries of any time range.
+type flusherBuilder struct {
+	flusher kv.Flusher
+
+	minKey, maxKey   uint32
+	count            uint64
+	size             int32
+	minTime, maxTime int64
+	hasTimeRange     bool
+	digest           *table.Digest
+}
+
+// newFlusherBuilder creates the table builder which adds the k/v pairs by the flusher of kv family
+func newFlusherBuilder(flusher kv.Flusher) table.Builder {
+	return &flusherBuilder{flusher: flusher, digest: table.NewDigest()}
+}
+
+// FileNumber returns 0, the file number is assigned by the flusher
+func (b *flusherBuilder) FileNumber() int64 {
+	return 0
+}
+
+// Add puts k/v pair by the flusher
+func (b *flusherBuilder) Add(key uint32, value []byte) error {
+	if err := b.flusher.Add(key, value); err != nil {
+		return err
+	}
+	if b.count == 0 || key < b.minKey {
+		b.minKey = key
+	}
+	if b.count == 0 || key > b.maxKey {
+		b.maxKey = key
+	}
+	b.count++
+	b.size += int32(len(value))
+	b.digest.Add(key, value)
+	return nil
+}
+
+// MinKey returns min key added
+func (b *flusherBuilder) MinKey() uint32 {
+	return b.minKey
+}
+
+// MaxKey returns max key added
+func (b *flusherBuilder) MaxKey() uint32 {
+	return b.maxKey
+}
+
+// Size returns the length of values added
+func (b *flusherBuilder) Size() int32 {
+	return b.size
+}
+
+// Count returns the number of k/v pairs added
+func (b *flusherBuilder) Count() uint64 {
+	return b.count
+}
+
+// UpdateTimeRange extends the time range of data added
+func (b *flusherBuilder) UpdateTimeRange(minTime, maxTime int64) {
+	if !b.hasTimeRange || minTime < b.minTime {
+		b.minTime = minTime
+	}
+	if !b.hasTimeRange || maxTime > b.maxTime {
+		b.maxTime = maxTime
+	}
+	b.hasTimeRange = true
+}
+
+// TimeRange returns the time range of data added
+func (b *flusherBuilder) TimeRange() (minTime, maxTime int64, ok bool) {
+	return b.minTime, b.maxTime, b.hasTimeRange
+}
+
+// Digest returns the content digest of k/v pairs added, which is same as the digest of file committed by the flusher
+func (b *flusherBuilder) Digest() uint64 {
+	return b.digest.Sum()
+}
+
+// Close commits the flusher, nothing is committed if no k/v pair added
+func (b *flusherBuilder) Close() error {
+	if b.count == 0 {
+		return nil
+	}
+	return b.flusher.Commit()
+}
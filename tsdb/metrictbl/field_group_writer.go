@@ -0,0 +1,143 @@
+package metrictbl
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/eleme/lindb/kv/table"
+)
+
+// FieldGroupFunc returns the group of field, the fields in same group are flushed into the same kv family
+type FieldGroupFunc func(fieldID uint32) uint32
+
+// PerField puts each field into its own group
+func PerField(fieldID uint32) uint32 {
+	return fieldID
+}
+
+// BuilderFactory creates the table builder for the kv family of field group
+type BuilderFactory func(group uint32) (table.Builder, error)
+
+// FieldFamilyName returns the kv family name of field group in the family
+func FieldFamilyName(familyName string, group uint32) string {
+	return fmt.Sprintf("%s_%d", familyName, group)
+}
+
+// ParseFieldFamilyName returns the family name and the field group of the kv family name of field group,
+// returns false if the name isn't named by FieldFamilyName.
+func ParseFieldFamilyName(name string) (familyName string, group uint32, ok bool) {
+	idx := strings.LastIndexByte(name, '_')
+	if idx <= 0 {
+		return "", 0, false
+	}
+	g, err := strconv.ParseUint(name[idx+1:], 10, 32)
+	if err != nil {
+		return "", 0, false
+	}
+	return name[:idx], uint32(g), true
+}
+
+// ProjectFieldFamilies returns the kv family names which need be read for querying the fields,
+// the families of other field groups are never read(projection push down).
+func ProjectFieldFamilies(familyName string, fieldIDs []uint32, groupOf FieldGroupFunc) []string {
+	groups := make(map[uint32]struct{})
+	var sortedGroups []uint32
+	for _, fieldID := range fieldIDs {
+		group := groupOf(fieldID)
+		if _, ok := groups[group]; ok {
+			continue
+		}
+		groups[group] = struct{}{}
+		sortedGroups = append(sortedGroups, group)
+	}
+	sort.Slice(sortedGroups, func(i, j int) bool {
+		return sortedGroups[i] < sortedGroups[j]
+	})
+	families := make([]string, len(sortedGroups))
+	for idx, group := range sortedGroups {
+		families[idx] = FieldFamilyName(familyName, group)
+	}
+	return families
+}
+
+// fieldGroupWriter implements TableWriter, splits the fields of metric-block into field groups,
+// each field group is written into its own metric-table by the table builder created by factory.
+// The metric-block/TSEntry only exists in the metric-table of field group which has data of it.
+type fieldGroupWriter struct {
+	interval   int64
+	groupOf    FieldGroupFunc
+	newBuilder BuilderFactory
+
+	writers     map[uint32]*tableWriter
+	entryGroups map[uint32]struct{} // the groups which have fields in current TSEntry
+	blockGroups map[uint32]struct{} // the groups which have TSEntry in current metric-block
+	err         error
+}
+
+// NewFieldGroupWriter returns a TableWriter which writes each field group into separate metric-table
+func NewFieldGroupWriter(groupOf FieldGroupFunc, newBuilder BuilderFactory, interval int64) TableWriter {
+	return &fieldGroupWriter{
+		interval:    interval,
+		groupOf:     groupOf,
+		newBuilder:  newBuilder,
+		writers:     make(map[uint32]*tableWriter),
+		entryGroups: make(map[uint32]struct{}),
+		blockGroups: make(map[uint32]struct{}),
+	}
+}
+
+// WriteField writes a compressed field data to the writer of field group.
+func (w *fieldGroupWriter) WriteField(fieldID uint32, data []byte, startSlot, endSlot int) {
+	group := w.groupOf(fieldID)
+	writer, ok := w.writers[group]
+	if !ok {
+		builder, err := w.newBuilder(group)
+		if err != nil {
+			// error is returned when writing metric-block
+			if w.err == nil {
+				w.err = fmt.Errorf("create table builder of field group[%d] error:%s", group, err)
+			}
+			return
+		}
+		writer = newTableWriter(builder, w.interval)
+		w.writers[group] = writer
+	}
+	writer.WriteField(fieldID, data, startSlot, endSlot)
+	w.entryGroups[group] = struct{}{}
+}
+
+// WriteTSEntry writes a full tsEntry into the writers of field groups which have fields of this entry.
+func (w *fieldGroupWriter) WriteTSEntry(tsID uint32) {
+	for group := range w.entryGroups {
+		w.writers[group].WriteTSEntry(tsID)
+		w.blockGroups[group] = struct{}{}
+	}
+	w.entryGroups = make(map[uint32]struct{})
+}
+
+// WriteMetricBlock writes a full metric-block into the writers of field groups which have entries of this metric.
+func (w *fieldGroupWriter) WriteMetricBlock(metricID uint32) error {
+	if w.err != nil {
+		return w.err
+	}
+	for group := range w.blockGroups {
+		if err := w.writers[group].WriteMetricBlock(metricID); err != nil {
+			return err
+		}
+	}
+	w.blockGroups = make(map[uint32]struct{})
+	return nil
+}
+
+// Close closes the writers of all field groups, returns the first error.
+func (w *fieldGroupWriter) Close() error {
+	var err error
+	for _, writer := range w.writers {
+		if e := writer.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,83 @@
+package metrictbl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/kv/table"
+)
+
+func Test_FieldGroupWriter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	builders := make(map[uint32]*table.MockBuilder)
+	newBuilder := func(group uint32) (table.Builder, error) {
+		if group == 100 {
+			return nil, fmt.Errorf("create builder error")
+		}
+		builder := table.NewMockBuilder(ctrl)
+		builder.EXPECT().UpdateTimeRange(gomock.Any(), gomock.Any()).AnyTimes()
+		builders[group] = builder
+		return builder, nil
+	}
+	// field 1,2 in group 0, field 10 in group 1
+	groupOf := func(fieldID uint32) uint32 { return fieldID / 10 }
+	w := NewFieldGroupWriter(groupOf, newBuilder, 10)
+
+	// metric 1 has field 1/2/10
+	w.WriteField(1, []byte("f1"), 1, 2)
+	w.WriteField(2, []byte("f2"), 1, 2)
+	w.WriteTSEntry(1)
+	w.WriteField(10, []byte("f10"), 1, 2)
+	w.WriteTSEntry(2)
+	assert.Equal(t, 2, len(builders))
+	builders[0].EXPECT().Add(uint32(1), gomock.Any()).Return(nil)
+	builders[1].EXPECT().Add(uint32(1), gomock.Any()).Return(nil)
+	assert.Nil(t, w.WriteMetricBlock(1))
+
+	// metric 2 only has field 1, only written into group 0
+	w.WriteField(1, []byte("f1"), 1, 2)
+	w.WriteTSEntry(1)
+	builders[0].EXPECT().Add(uint32(2), gomock.Any()).Return(nil)
+	assert.Nil(t, w.WriteMetricBlock(2))
+
+	// add error
+	w.WriteField(10, []byte("f10"), 1, 2)
+	w.WriteTSEntry(1)
+	builders[1].EXPECT().Add(uint32(3), gomock.Any()).Return(fmt.Errorf("add error"))
+	assert.NotNil(t, w.WriteMetricBlock(3))
+
+	// close
+	builders[0].EXPECT().Close().Return(nil)
+	builders[1].EXPECT().Close().Return(fmt.Errorf("close error"))
+	assert.NotNil(t, w.Close())
+
+	// create builder error
+	w = NewFieldGroupWriter(PerField, newBuilder, 10)
+	w.WriteField(100, []byte("f100"), 1, 2)
+	w.WriteField(101, []byte("f101"), 1, 2)
+	w.WriteTSEntry(1)
+	assert.NotNil(t, w.WriteMetricBlock(1))
+}
+
+func Test_ProjectFieldFamilies(t *testing.T) {
+	assert.Equal(t, []string{"1000_1", "1000_3"}, ProjectFieldFamilies("1000", []uint32{3, 1, 3}, PerField))
+	groupOf := func(fieldID uint32) uint32 { return fieldID / 10 }
+	assert.Equal(t, []string{"1000_0", "1000_2"}, ProjectFieldFamilies("1000", []uint32{1, 2, 25}, groupOf))
+	assert.Empty(t, ProjectFieldFamilies("1000", nil, PerField))
+}
+
+func Test_ParseFieldFamilyName(t *testing.T) {
+	familyName, group, ok := ParseFieldFamilyName(FieldFamilyName("10", 3))
+	assert.True(t, ok)
+	assert.Equal(t, "10", familyName)
+	assert.Equal(t, uint32(3), group)
+	for _, name := range []string{"10", "_3", "10_", "10_a", StringFamilyName} {
+		_, _, ok = ParseFieldFamilyName(name)
+		assert.False(t, ok, name)
+	}
+}
@@ -0,0 +1,193 @@
+package metrictbl
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/RoaringBitmap/roaring"
+
+	"github.com/eleme/lindb/pkg/encoding"
+)
+
+// blockFooterSize is the length of footer(pos of offset, pos of keys, pos of fields-meta) and crc32 of metric-block
+const blockFooterSize = 16
+
+// BlockReader reads the TSEntries of a metric-block which is built by TableWriter,
+// the TSEntries must be written in ascending order of tsID, so that the offsets are aligned with the keys.
+type BlockReader struct {
+	data      []byte
+	fieldIDs  []uint32
+	keys      *roaring.Bitmap
+	offsets   []int
+	posOfMeta int
+	startTime int64
+	endTime   int64
+}
+
+// NewBlockReader parses the footer, keys, offsets and fields-meta of metric-block, returns error if corrupted
+func NewBlockReader(block []byte) (*BlockReader, error) {
+	if len(block) < blockFooterSize {
+		return nil, fmt.Errorf("metric-block is too short, length:%d", len(block))
+	}
+	footer := block[len(block)-blockFooterSize:]
+	if crc32.ChecksumIEEE(block[:len(block)-4]) != binary.BigEndian.Uint32(footer[12:16]) {
+		return nil, fmt.Errorf("checksum of metric-block mismatch")
+	}
+	posOfOffset := int(binary.BigEndian.Uint32(footer[:4]))
+	posOfKeys := int(binary.BigEndian.Uint32(footer[4:8]))
+	posOfMeta := int(binary.BigEndian.Uint32(footer[8:12]))
+	if posOfOffset > posOfKeys || posOfKeys > posOfMeta || posOfMeta > len(block)-blockFooterSize {
+		return nil, fmt.Errorf("footer of metric-block is invalid")
+	}
+	r := &BlockReader{data: block, keys: roaring.New(), posOfMeta: posOfMeta}
+	if err := r.keys.UnmarshalBinary(block[posOfKeys:posOfMeta]); err != nil {
+		return nil, fmt.Errorf("unmarshal keys of metric-block error:%s", err)
+	}
+	if err := r.readMeta(block[posOfMeta : len(block)-blockFooterSize]); err != nil {
+		return nil, err
+	}
+	count := int(r.keys.GetCardinality())
+	if count > 0 {
+		offsetBlock := block[posOfOffset:posOfKeys]
+		decoder := encoding.NewDeltaBitPackingDecoder(&offsetBlock)
+		for decoder.HasNext() {
+			r.offsets = append(r.offsets, int(decoder.Next()))
+		}
+	}
+	if len(r.offsets) != count {
+		return nil, fmt.Errorf("offsets of metric-block don't match keys, offsets:%d, keys:%d",
+			len(r.offsets), count)
+	}
+	for idx, offset := range r.offsets {
+		if offset < 0 || offset > posOfOffset || (idx > 0 && offset < r.offsets[idx-1]) {
+			return nil, fmt.Errorf("offset of TSEntry[%d] in metric-block is invalid", idx)
+		}
+	}
+	// the end of last entry is the position of offsets
+	r.offsets = append(r.offsets, posOfOffset)
+	return r, nil
+}
+
+// readMeta reads the time range and field-id list of fields-meta
+func (r *BlockReader) readMeta(meta []byte) error {
+	pos := 0
+	for i := 0; i < 3; i++ {
+		value, n := binary.Uvarint(meta[pos:])
+		if n <= 0 {
+			return fmt.Errorf("read fields-meta of metric-block error")
+		}
+		pos += n
+		switch i {
+		case 0:
+			r.startTime = int64(value)
+		case 1:
+			r.endTime = int64(value)
+		case 2:
+			if len(meta)-pos < int(value)*4 {
+				return fmt.Errorf("field-id list of metric-block is too short")
+			}
+			r.fieldIDs = make([]uint32, value)
+		}
+	}
+	for idx := range r.fieldIDs {
+		r.fieldIDs[idx] = binary.BigEndian.Uint32(meta[pos:])
+		pos += 4
+	}
+	return nil
+}
+
+// FieldIDs returns the field-id list of fields-meta
+func (r *BlockReader) FieldIDs() []uint32 {
+	return r.fieldIDs
+}
+
+// SeriesIDs returns the tsIDs of the TSEntries in metric-block
+func (r *BlockReader) SeriesIDs() *roaring.Bitmap {
+	return r.keys
+}
+
+// TimeRange returns the min start-time and max end-time of fields-meta, which are relative to the family time
+func (r *BlockReader) TimeRange() (startTime, endTime int64) {
+	return r.startTime, r.endTime
+}
+
+// Entries calls fn with the encoded TSEntry of each series in order of tsID, the fields of entry are referenced
+// by the position in field-id list, so the entry can be copied into the metric-block with same field-id list
+// (see EncodeBlock), even if the ids are translated.
+func (r *BlockReader) Entries(fn func(tsID uint32, entry []byte)) {
+	it := r.keys.Iterator()
+	idx := 0
+	for it.HasNext() {
+		fn(it.Next(), r.data[r.offsets[idx]:r.offsets[idx+1]])
+		idx++
+	}
+}
+
+// Scan calls fn with the compressed data of each field in TSEntries in order of tsID,
+// only the fields in the field-id set are read if set isn't nil.
+func (r *BlockReader) Scan(fieldIDs map[uint32]struct{}, fn func(tsID, fieldID uint32, data []byte)) error {
+	it := r.keys.Iterator()
+	idx := 0
+	for it.HasNext() {
+		tsID := it.Next()
+		if err := r.scanEntry(tsID, r.data[r.offsets[idx]:r.offsets[idx+1]], fieldIDs, fn); err != nil {
+			return err
+		}
+		idx++
+	}
+	return nil
+}
+
+// scanEntry reads the fields of TSEntry, the layout is start-time, end-time, bit-array of fields-meta,
+// the lengths of existed fields, then the data of existed fields.
+func (r *BlockReader) scanEntry(tsID uint32, entry []byte, fieldIDs map[uint32]struct{},
+	fn func(tsID, fieldID uint32, data []byte)) error {
+	if len(entry) == 0 {
+		return nil
+	}
+	pos := 0
+	var bitArrayLen uint64
+	for i := 0; i < 3; i++ {
+		value, n := binary.Uvarint(entry[pos:])
+		if n <= 0 {
+			return fmt.Errorf("read TSEntry[%d] of metric-block error", tsID)
+		}
+		pos += n
+		bitArrayLen = value
+	}
+	if len(entry)-pos < int(bitArrayLen) {
+		return fmt.Errorf("bit-array of TSEntry[%d] is too short", tsID)
+	}
+	bits, err := newBitArray(entry[pos : pos+int(bitArrayLen)])
+	if err != nil {
+		return err
+	}
+	pos += int(bitArrayLen)
+	var (
+		existed []uint32
+		lengths []int
+	)
+	for idx, fieldID := range r.fieldIDs {
+		if !bits.getBit(uint16(idx)) {
+			continue
+		}
+		length, n := binary.Uvarint(entry[pos:])
+		if n <= 0 {
+			return fmt.Errorf("read field length of TSEntry[%d] error", tsID)
+		}
+		pos += n
+		existed = append(existed, fieldID)
+		lengths = append(lengths, int(length))
+	}
+	for idx, fieldID := range existed {
+		if len(entry)-pos < lengths[idx] {
+			return fmt.Errorf("field data of TSEntry[%d] is too short", tsID)
+		}
+		if _, ok := fieldIDs[fieldID]; ok || fieldIDs == nil {
+			fn(tsID, fieldID, entry[pos:pos+lengths[idx]])
+		}
+		pos += lengths[idx]
+	}
+	return nil
+}
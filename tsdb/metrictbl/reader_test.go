@@ -0,0 +1,103 @@
+package metrictbl
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/kv/table"
+)
+
+func buildTestBlock(t *testing.T) []byte {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var block []byte
+	mockBuilder := table.NewMockBuilder(ctrl)
+	mockBuilder.EXPECT().Add(uint32(10), gomock.Any()).DoAndReturn(func(key uint32, value []byte) error {
+		block = append([]byte{}, value...)
+		return nil
+	})
+	mockBuilder.EXPECT().UpdateTimeRange(gomock.Any(), gomock.Any())
+	writer := NewTableWriter(mockBuilder, 10)
+	writer.WriteField(1, []byte("a"), 1, 2)
+	writer.WriteField(2, []byte("bc"), 1, 3)
+	writer.WriteTSEntry(3)
+	// entry without fields
+	writer.WriteTSEntry(5)
+	writer.WriteField(2, []byte("def"), 2, 3)
+	writer.WriteField(4, []byte("g"), 2, 3)
+	writer.WriteTSEntry(7)
+	assert.Nil(t, writer.WriteMetricBlock(10))
+	return block
+}
+
+func TestBlockReader_Scan(t *testing.T) {
+	block := buildTestBlock(t)
+	reader, err := NewBlockReader(block)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{1, 2, 4}, reader.FieldIDs())
+	assert.Equal(t, []uint32{3, 5, 7}, reader.SeriesIDs().ToArray())
+
+	type fieldData struct {
+		tsID, fieldID uint32
+		data          string
+	}
+	var result []fieldData
+	assert.Nil(t, reader.Scan(nil, func(tsID, fieldID uint32, data []byte) {
+		result = append(result, fieldData{tsID: tsID, fieldID: fieldID, data: string(data)})
+	}))
+	assert.Equal(t, []fieldData{{3, 1, "a"}, {3, 2, "bc"}, {7, 2, "def"}, {7, 4, "g"}}, result)
+
+	// projection of fields
+	result = nil
+	assert.Nil(t, reader.Scan(map[uint32]struct{}{2: {}}, func(tsID, fieldID uint32, data []byte) {
+		result = append(result, fieldData{tsID: tsID, fieldID: fieldID, data: string(data)})
+	}))
+	assert.Equal(t, []fieldData{{3, 2, "bc"}, {7, 2, "def"}}, result)
+}
+
+func TestNewBlockReader_corrupted(t *testing.T) {
+	_, err := NewBlockReader([]byte("abc"))
+	assert.NotNil(t, err)
+
+	block := buildTestBlock(t)
+	block[0]++
+	_, err = NewBlockReader(block)
+	assert.NotNil(t, err)
+}
+
+func TestEncodeBlock(t *testing.T) {
+	reader, err := NewBlockReader(buildTestBlock(t))
+	assert.Nil(t, err)
+	startTime, endTime := reader.TimeRange()
+	assert.Equal(t, int64(10), startTime)
+	assert.Equal(t, int64(30), endTime)
+
+	// translate the ids of series and fields, the order of series is changed
+	seriesIDs := map[uint32]uint32{3: 30, 5: 20, 7: 10}
+	entries := make(map[uint32][]byte)
+	reader.Entries(func(tsID uint32, entry []byte) {
+		entries[seriesIDs[tsID]] = entry
+	})
+	block, err := EncodeBlock([]uint32{11, 12, 14}, startTime, endTime, entries)
+	assert.Nil(t, err)
+
+	reader, err = NewBlockReader(block)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{11, 12, 14}, reader.FieldIDs())
+	assert.Equal(t, []uint32{10, 20, 30}, reader.SeriesIDs().ToArray())
+	startTime, endTime = reader.TimeRange()
+	assert.Equal(t, int64(10), startTime)
+	assert.Equal(t, int64(30), endTime)
+	type fieldData struct {
+		tsID, fieldID uint32
+		data          string
+	}
+	var result []fieldData
+	assert.Nil(t, reader.Scan(nil, func(tsID, fieldID uint32, data []byte) {
+		result = append(result, fieldData{tsID: tsID, fieldID: fieldID, data: string(data)})
+	}))
+	assert.Equal(t, []fieldData{{10, 12, "def"}, {10, 14, "g"}, {30, 11, "a"}, {30, 12, "bc"}}, result)
+}
@@ -0,0 +1,118 @@
+package metrictbl
+
+import (
+	"fmt"
+
+	"github.com/eleme/lindb/kv/table"
+	"github.com/eleme/lindb/pkg/stream"
+)
+
+// StringFamilyName is the name of kv family which stores the last values of string fields,
+// the string fields are stored separately from the metric-blocks of numeric fields.
+const StringFamilyName = "string"
+
+// SegmentBuilderFactory creates the table builder for the string family of the segment which starts at segment time
+type SegmentBuilderFactory func(segmentTime int64) (table.Builder, error)
+
+// StringEntry represents the last value of the string field of series,
+// the value is the index of dictionary of string block.
+type StringEntry struct {
+	TSID      uint32
+	FieldID   uint32
+	Timestamp int64
+	ValueID   uint32
+}
+
+// StringBlock represents the last values of string fields of a metric, the values are dictionary encoded,
+// so that the same value(like build version) shared by many series is stored once.
+type StringBlock struct {
+	Dict    []string
+	Entries []StringEntry
+}
+
+// Value returns the string value of entry, returns false if the value id is out of dictionary
+func (b *StringBlock) Value(entry StringEntry) (string, bool) {
+	if int(entry.ValueID) >= len(b.Dict) {
+		return "", false
+	}
+	return b.Dict[entry.ValueID], true
+}
+
+// Split splits the entries of block into the blocks by the key of entry, the order of entries is kept,
+// the dictionary of each block only contains the values referenced by its entries.
+func (b *StringBlock) Split(keyOf func(entry StringEntry) int64) map[int64]*StringBlock {
+	blocks := make(map[int64]*StringBlock)
+	// id of the dictionary of split block, key: split key => id of the dictionary of block
+	ids := make(map[int64]map[uint32]uint32)
+	for _, entry := range b.Entries {
+		key := keyOf(entry)
+		block, ok := blocks[key]
+		if !ok {
+			block = &StringBlock{}
+			blocks[key] = block
+			ids[key] = make(map[uint32]uint32)
+		}
+		id, ok := ids[key][entry.ValueID]
+		if !ok {
+			id = uint32(len(block.Dict))
+			ids[key][entry.ValueID] = id
+			value, _ := b.Value(entry)
+			block.Dict = append(block.Dict, value)
+		}
+		entry.ValueID = id
+		block.Entries = append(block.Entries, entry)
+	}
+	return blocks
+}
+
+// EncodeStringBlock encodes the string block:
+// dict count, [value length, value]..., entry count, [tsID, fieldID, timestamp, value id]...
+func EncodeStringBlock(block *StringBlock) ([]byte, error) {
+	writer := stream.BinaryWriter()
+	writer.PutUvarint64(uint64(len(block.Dict)))
+	for _, value := range block.Dict {
+		writer.PutKey([]byte(value))
+	}
+	writer.PutUvarint64(uint64(len(block.Entries)))
+	for _, entry := range block.Entries {
+		writer.PutUvarint32(entry.TSID)
+		writer.PutUvarint32(entry.FieldID)
+		writer.PutInt64(entry.Timestamp)
+		writer.PutUvarint32(entry.ValueID)
+	}
+	return writer.Bytes()
+}
+
+// DecodeStringBlock decodes the string block encoded by EncodeStringBlock
+func DecodeStringBlock(data []byte) (*StringBlock, error) {
+	reader := stream.BinaryReader(data)
+	dictCount := reader.ReadUvarint64()
+	if err := reader.Error(); err != nil || dictCount > uint64(len(data)) {
+		return nil, fmt.Errorf("decode dictionary of string block error")
+	}
+	block := &StringBlock{Dict: make([]string, dictCount)}
+	for i := range block.Dict {
+		length := reader.ReadUvarint64()
+		if reader.Error() != nil || length > uint64(reader.Len()) {
+			return nil, fmt.Errorf("decode value[%d] of string block error", i)
+		}
+		block.Dict[i] = string(reader.ReadBytes(int(length)))
+	}
+	entryCount := reader.ReadUvarint64()
+	if err := reader.Error(); err != nil || entryCount > uint64(reader.Len()) {
+		return nil, fmt.Errorf("decode entries of string block error")
+	}
+	block.Entries = make([]StringEntry, entryCount)
+	for i := range block.Entries {
+		block.Entries[i] = StringEntry{
+			TSID:      reader.ReadUvarint32(),
+			FieldID:   reader.ReadUvarint32(),
+			Timestamp: reader.ReadInt64(),
+			ValueID:   reader.ReadUvarint32(),
+		}
+		if err := reader.Error(); err != nil {
+			return nil, fmt.Errorf("decode entry[%d] of string block error:%s", i, err)
+		}
+	}
+	return block, nil
+}
@@ -0,0 +1,67 @@
+package metrictbl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringBlock_codec(t *testing.T) {
+	block := &StringBlock{
+		Dict: []string{"v1.0.0", "", "v1.1.0"},
+		Entries: []StringEntry{
+			{TSID: 1, FieldID: 2, Timestamp: 1000, ValueID: 0},
+			{TSID: 2, FieldID: 2, Timestamp: 2000, ValueID: 2},
+			{TSID: 3, FieldID: 3, Timestamp: 3000, ValueID: 1},
+		},
+	}
+	data, err := EncodeStringBlock(block)
+	assert.Nil(t, err)
+	decoded, err := DecodeStringBlock(data)
+	assert.Nil(t, err)
+	assert.Equal(t, block, decoded)
+	value, ok := decoded.Value(decoded.Entries[1])
+	assert.True(t, ok)
+	assert.Equal(t, "v1.1.0", value)
+	_, ok = decoded.Value(StringEntry{ValueID: 3})
+	assert.False(t, ok)
+
+	// empty block
+	data, err = EncodeStringBlock(&StringBlock{})
+	assert.Nil(t, err)
+	decoded, err = DecodeStringBlock(data)
+	assert.Nil(t, err)
+	assert.Empty(t, decoded.Dict)
+	assert.Empty(t, decoded.Entries)
+
+	// corrupted block
+	data, _ = EncodeStringBlock(block)
+	for _, size := range []int{0, 3, len(data) - 1} {
+		_, err = DecodeStringBlock(data[:size])
+		assert.NotNil(t, err, "size %d", size)
+	}
+	_, err = DecodeStringBlock([]byte{0xff, 0xff, 0x01})
+	assert.NotNil(t, err)
+}
+
+func TestStringBlock_Split(t *testing.T) {
+	block := &StringBlock{
+		Dict: []string{"v1", "v2", "v3"},
+		Entries: []StringEntry{
+			{TSID: 1, FieldID: 2, Timestamp: 1000, ValueID: 2},
+			{TSID: 2, FieldID: 2, Timestamp: 2000, ValueID: 0},
+			{TSID: 3, FieldID: 2, Timestamp: 3000, ValueID: 2},
+		},
+	}
+	blocks := block.Split(func(entry StringEntry) int64 {
+		return int64(entry.TSID % 2)
+	})
+	assert.Equal(t, map[int64]*StringBlock{
+		1: {Dict: []string{"v3"}, Entries: []StringEntry{
+			{TSID: 1, FieldID: 2, Timestamp: 1000, ValueID: 0},
+			{TSID: 3, FieldID: 2, Timestamp: 3000, ValueID: 0},
+		}},
+		0: {Dict: []string{"v1"}, Entries: []StringEntry{{TSID: 2, FieldID: 2, Timestamp: 2000, ValueID: 0}}},
+	}, blocks)
+	assert.Empty(t, (&StringBlock{}).Split(func(entry StringEntry) int64 { return 0 }))
+}
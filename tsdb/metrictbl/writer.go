@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"hash/crc32"
+	"sort"
 	"sync/atomic"
 
 	"github.com/eleme/lindb/kv/table"
@@ -43,6 +44,32 @@ func newTableWriter(builder table.Builder, interval int64) *tableWriter {
 		entryBuilder: newTSEntryBuilder()}
 }
 
+// EncodeBlock encodes the metric-block of the TSEntries keyed by tsID, which are read by BlockReader.Entries.
+// The fields of entry are referenced by the position in field-id list, so the field-id list must keep the order
+// of the block which the entries are read from, e.g. the ids of fields are translated into the ids of other index.
+// The time range is the min start-time and max end-time of fields-meta.
+func EncodeBlock(fieldIDs []uint32, startTime, endTime int64, entries map[uint32][]byte) ([]byte, error) {
+	builder := newBlockBuilder()
+	builder.minStartTime = startTime
+	builder.maxEndTime = endTime
+	builder.metaFieldsID = fieldIDs
+	// the TSEntries must be written in ascending order of tsID, so that the offsets are aligned with the keys
+	tsIDs := make([]uint32, 0, len(entries))
+	for tsID := range entries {
+		tsIDs = append(tsIDs, tsID)
+	}
+	sort.Slice(tsIDs, func(i, j int) bool {
+		return tsIDs[i] < tsIDs[j]
+	})
+	for _, tsID := range tsIDs {
+		builder.addTSEntry(tsID, entries[tsID])
+	}
+	if err := builder.finish(); err != nil {
+		return nil, err
+	}
+	return builder.bytes(), nil
+}
+
 // tableWriter implements TableWriter.
 type tableWriter struct {
 	interval     int64
@@ -74,6 +101,8 @@ func (w *tableWriter) WriteMetricBlock(metricID uint32) error {
 	if err := w.tableBuilder.Add(metricID, w.blockBuilder.bytes()); err != nil {
 		return err
 	}
+	// time range of metric-block is relative to the family time
+	w.tableBuilder.UpdateTimeRange(w.blockBuilder.minStartTime, w.blockBuilder.maxEndTime)
 	w.blockBuilder.reset()
 	return nil
 }
@@ -129,7 +129,10 @@ func Test_TableWriter(t *testing.T) {
 	tw.WriteTSEntry(uint32(2))
 
 	mockBuilder.EXPECT().Add(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	// time range of metric-block is slot*interval
+	mockBuilder.EXPECT().UpdateTimeRange(int64(10), int64(10))
 	tw.WriteMetricBlock(uint32(3))
+	mockBuilder.EXPECT().UpdateTimeRange(int64(10), int64(20)).Times(100)
 
 	for x := 0; x < 100; x++ {
 		for y := 0; y < 100; y++ {
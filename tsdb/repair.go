@@ -0,0 +1,293 @@
+package tsdb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/RoaringBitmap/roaring"
+
+	"github.com/eleme/lindb/kv"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/interval"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/tsdb/index"
+	"github.com/eleme/lindb/tsdb/metrictbl"
+)
+
+// defaultExportLimit is the max bytes of metric blocks in a page of exported family
+const defaultExportLimit = 4 * 1024 * 1024
+
+// FamilyExporter exports the page of family from the healthy replica, the page starts after the metric id
+// of the replica, see Engine.ExportFamily.
+type FamilyExporter func(after uint32) (*models.FamilyExport, error)
+
+// ExportFamily exports a page of the metric blocks in the data family of shard's segment, the bad files are skipped.
+// The pages are in order of metric id, the page has one metric at least even if its blocks exceed the limit.
+func (e *engine) ExportFamily(req models.FamilyExportRequest) (*models.FamilyExport, error) {
+	family, err := e.getFamily(req)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := family.GetSnapshotOfAllFiles()
+	if err != nil {
+		return nil, fmt.Errorf("get snapshot of family[%s] error:%s", family.Name(), err)
+	}
+	defer snapshot.Close()
+	// the blocks of metric are in the files flushed at different time, which aren't merged
+	blocks := make(map[uint32][][]byte)
+	for _, reader := range snapshot.Readers() {
+		it := reader.Iterator()
+		for it.Next() {
+			if it.Key() > req.After {
+				blocks[it.Key()] = append(blocks[it.Key()], it.Value())
+			}
+		}
+		if err := it.Err(); err != nil {
+			return nil, fmt.Errorf("read file of family[%s] error:%s", family.Name(), err)
+		}
+	}
+	metricIDs := make([]uint32, 0, len(blocks))
+	for metricID := range blocks {
+		metricIDs = append(metricIDs, metricID)
+	}
+	sort.Slice(metricIDs, func(i, j int) bool {
+		return metricIDs[i] < metricIDs[j]
+	})
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultExportLimit
+	}
+	page := roaring.New()
+	size := 0
+	for _, metricID := range metricIDs {
+		if size >= limit {
+			break
+		}
+		page.Add(metricID)
+		for _, block := range blocks[metricID] {
+			size += len(block)
+		}
+	}
+	result := &models.FamilyExport{Done: int(page.GetCardinality()) == len(metricIDs)}
+	if page.IsEmpty() {
+		return result, nil
+	}
+	result.Next = page.Maximum()
+
+	metricNames := e.index.GetMetricUID().GetMetricNames(page)
+	it := page.Iterator()
+	for it.HasNext() {
+		metricID := it.Next()
+		metricName, ok := metricNames[metricID]
+		if !ok {
+			return nil, fmt.Errorf("name of metric[%d] not found in index of engine[%s]", metricID, e.name)
+		}
+		fieldNames := e.index.GetFieldUID().GetFieldNames(metricID)
+		for _, block := range blocks[metricID] {
+			exported, err := e.exportBlock(metricID, metricName, fieldNames, block)
+			if err != nil {
+				return nil, err
+			}
+			result.Blocks = append(result.Blocks, *exported)
+		}
+	}
+	return result, nil
+}
+
+// exportBlock translates the ids of fields and series in metric block into names
+func (e *engine) exportBlock(metricID uint32, metricName string, fieldNames map[uint32]string,
+	block []byte) (*models.ExportedBlock, error) {
+	reader, err := metrictbl.NewBlockReader(block)
+	if err != nil {
+		return nil, fmt.Errorf("read block of metric[%s] error:%s", metricName, err)
+	}
+	startTime, endTime := reader.TimeRange()
+	exported := &models.ExportedBlock{Metric: metricName, StartTime: startTime, EndTime: endTime}
+	for _, fieldID := range reader.FieldIDs() {
+		fieldName, ok := fieldNames[fieldID]
+		if !ok {
+			return nil, fmt.Errorf("name of field[%d] of metric[%s] not found", fieldID, metricName)
+		}
+		exported.Fields = append(exported.Fields, models.ExportedField{Name: fieldName, Type: index.GetFieldType(fieldID)})
+	}
+	seriesTags := e.index.GetTagsUID().GetSeriesTags(metricID, reader.SeriesIDs())
+	var notFound []uint32
+	reader.Entries(func(tsID uint32, entry []byte) {
+		tags, ok := seriesTags[tsID]
+		if !ok {
+			notFound = append(notFound, tsID)
+			return
+		}
+		// copies the entry, the data of file is released after the snapshot closed
+		exported.Series = append(exported.Series, models.ExportedEntry{Tags: tags, Data: append([]byte(nil), entry...)})
+	})
+	if len(notFound) > 0 {
+		return nil, fmt.Errorf("tags of series%v of metric[%s] not found", notFound, metricName)
+	}
+	return exported, nil
+}
+
+// RepairFamily replaces the files of the family which has corrupted file by the data exported from healthy replica,
+// the metrics, series and fields are mapped into the ids of engine's index. The current files are captured before
+// exporting, so the data flushed into the family during repairing is kept. The blocks are kept in memory until
+// all pages exported, because the order of metric ids of replica isn't same as engine's.
+func (e *engine) RepairFamily(ctx context.Context, corruption models.Corruption, exporter FamilyExporter) error {
+	if corruption.Index {
+		return fmt.Errorf("index family[%s] of engine[%s] cannot be repaired from replica, "+
+			"because the ids are allocated by each node", corruption.Family, e.name)
+	}
+	if corruption.Family == metrictbl.StringFamilyName {
+		return fmt.Errorf("string family of segment[%s] in engine[%s] cannot be repaired from replica, "+
+			"because the blocks aren't metric blocks", corruption.Segment, e.name)
+	}
+	family, err := e.getOrCreateFamily(corruption)
+	if err != nil {
+		return err
+	}
+	flusher := family.NewReplaceFlusher(ctx)
+	blocks, err := e.importFamily(exporter)
+	if err != nil {
+		return fmt.Errorf("import family[%s] from replica error:%s", family.Name(), err)
+	}
+	metricIDs := make([]uint32, 0, len(blocks))
+	numOfFiles := 0
+	for metricID, metricBlocks := range blocks {
+		metricIDs = append(metricIDs, metricID)
+		if len(metricBlocks) > numOfFiles {
+			numOfFiles = len(metricBlocks)
+		}
+	}
+	sort.Slice(metricIDs, func(i, j int) bool {
+		return metricIDs[i] < metricIDs[j]
+	})
+	// the blocks of same metric are flushed into different files like replica, the nth block into nth file
+	for fileIdx := 0; fileIdx < numOfFiles; fileIdx++ {
+		if fileIdx > 0 {
+			if err := flusher.NextFile(); err != nil {
+				return err
+			}
+		}
+		for _, metricID := range metricIDs {
+			if fileIdx >= len(blocks[metricID]) {
+				continue
+			}
+			if err := flusher.Add(metricID, blocks[metricID][fileIdx]); err != nil {
+				flusher.Abort()
+				return err
+			}
+		}
+	}
+	if err := flusher.Commit(); err != nil {
+		return fmt.Errorf("commit repaired family[%s] error:%s", family.Name(), err)
+	}
+	e.logger.Info("repair family from replica successfully", logger.String("engine", e.name),
+		logger.Any("shard", corruption.ShardID), logger.String("segment", corruption.Segment),
+		logger.String("family", corruption.Family), logger.Any("metrics", len(metricIDs)))
+	return nil
+}
+
+// importFamily imports the exported pages of family, returns the metric blocks keyed by the metric id of engine
+func (e *engine) importFamily(exporter FamilyExporter) (map[uint32][][]byte, error) {
+	blocks := make(map[uint32][][]byte)
+	after := uint32(0)
+	for {
+		page, err := exporter(after)
+		if err != nil {
+			return nil, err
+		}
+		for idx := range page.Blocks {
+			metricID, block, err := e.importBlock(&page.Blocks[idx])
+			if err != nil {
+				return nil, err
+			}
+			blocks[metricID] = append(blocks[metricID], block)
+		}
+		if page.Done {
+			return blocks, nil
+		}
+		after = page.Next
+	}
+}
+
+// importBlock maps the names of metric, fields and series in exported block into the ids of engine's index,
+// then encodes the metric block with the ids
+func (e *engine) importBlock(exported *models.ExportedBlock) (uint32, []byte, error) {
+	metricID, ok := e.index.GetMetricUID().GetOrCreateMetricID(exported.Metric, true)
+	if !ok {
+		return 0, nil, fmt.Errorf("create id of metric[%s] error", exported.Metric)
+	}
+	fieldIDs := make([]uint32, len(exported.Fields))
+	for idx, f := range exported.Fields {
+		fieldID, err := e.index.GetFieldUID().GetOrCreateFieldID(metricID, f.Name, f.Type)
+		if err != nil {
+			return 0, nil, fmt.Errorf("create id of field[%s] of metric[%s] error:%s", f.Name, exported.Metric, err)
+		}
+		fieldIDs[idx] = fieldID
+	}
+	entries := make(map[uint32][]byte, len(exported.Series))
+	for _, series := range exported.Series {
+		seriesID, err := e.index.GetTagsUID().GetOrCreateTagsID(metricID, series.Tags)
+		if err != nil {
+			return 0, nil, fmt.Errorf("create id of series[%s] of metric[%s] error:%s", series.Tags, exported.Metric, err)
+		}
+		entries[seriesID] = series.Data
+	}
+	block, err := metrictbl.EncodeBlock(fieldIDs, exported.StartTime, exported.EndTime, entries)
+	if err != nil {
+		return 0, nil, fmt.Errorf("encode block of metric[%s] error:%s", exported.Metric, err)
+	}
+	return metricID, block, nil
+}
+
+// getFamily returns the existing data family of shard's segment
+func (e *engine) getFamily(req models.FamilyExportRequest) (kv.Family, error) {
+	segment, err := e.getSegment(req.ShardID, req.IntervalType, req.Segment, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, family := range segment.GetFamilies() {
+		if family.Name() == req.Family {
+			return family, nil
+		}
+	}
+	return nil, fmt.Errorf("family[%s] of segment[%s] in shard[%d] of engine[%s] not found",
+		req.Family, req.Segment, req.ShardID, e.name)
+}
+
+// getOrCreateFamily returns the data family of corruption, creates it if not exist
+func (e *engine) getOrCreateFamily(corruption models.Corruption) (kv.Family, error) {
+	segment, err := e.getSegment(corruption.ShardID, corruption.IntervalType, corruption.Segment, true)
+	if err != nil {
+		return nil, err
+	}
+	// the family of field group(see metrictbl.FieldFamilyName) belongs to the family of time
+	familyName := corruption.Family
+	if name, _, ok := metrictbl.ParseFieldFamilyName(familyName); ok {
+		familyName = name
+	}
+	if _, err := segment.FamilyTime(familyName); err != nil {
+		return nil, err
+	}
+	return segment.GetOrCreateFamilyByName(corruption.Family)
+}
+
+// getSegment returns the segment of shard by interval type and segment name, creates it if not exist and create
+func (e *engine) getSegment(shardID int, intervalType interval.Type, segmentName string, create bool) (Segment, error) {
+	shard, err := e.getOnlineShard(shardID)
+	if err != nil {
+		return nil, err
+	}
+	intervalSegment, ok := shard.GetIntervalSegment(intervalType)
+	if !ok {
+		return nil, fmt.Errorf("interval[%s] of shard[%d] in engine[%s] not found", intervalType, shardID, e.name)
+	}
+	if create {
+		return intervalSegment.GetOrCreateSegment(segmentName)
+	}
+	segment := intervalSegment.GetSegment(segmentName)
+	if segment == nil {
+		return nil, fmt.Errorf("segment[%s] of shard[%d] in engine[%s] not found", segmentName, shardID, e.name)
+	}
+	return segment, nil
+}
@@ -0,0 +1,131 @@
+package tsdb
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/kv"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/pkg/interval"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/pkg/util"
+	"github.com/eleme/lindb/tsdb/metrictbl"
+)
+
+const testSegment = "20190702"
+
+func TestEngine_ExportFamily_RepairFamily(t *testing.T) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	healthy, _ := NewEngine("test_db", filepath.Join(testPath, "healthy"), nil)
+	_ = healthy.CreateShards(validOption, 1)
+	defer func() {
+		_ = healthy.Close()
+	}()
+	repaired, _ := NewEngine("test_db", filepath.Join(testPath, "repaired"), nil)
+	_ = repaired.CreateShards(validOption, 1)
+	defer func() {
+		_ = repaired.Close()
+	}()
+
+	timestamp, _ := timeutil.ParseTimestamp("20190702 10:00:00", "20060102 15:04:05")
+	family, familyName := getTestFamily(t, healthy, timestamp)
+	// the blocks of same metric are in different files
+	flushTestBlocks(t, healthy, family, map[string][]string{"cpu": {"host=a"}, "mem": {"host=a", "host=b"}})
+	flushTestBlocks(t, healthy, family, map[string][]string{"cpu": {"host=b"}})
+
+	// the ids of repaired engine are different from healthy engine
+	_, _ = repaired.GetIndex().GetMetricUID().GetOrCreateMetricID("other", true)
+	brokenFamily, _ := getTestFamily(t, repaired, timestamp)
+	flusher := brokenFamily.NewFlusher()
+	_ = flusher.Add(1, []byte("corrupted"))
+	assert.Nil(t, flusher.Commit())
+
+	req := models.FamilyExportRequest{Database: "test_db", ShardID: 1, IntervalType: interval.Day,
+		Segment: testSegment, Family: familyName}
+	all, err := healthy.ExportFamily(req)
+	assert.Nil(t, err)
+	assert.True(t, all.Done)
+	assert.Equal(t, 3, len(all.Blocks))
+
+	corruption := models.Corruption{Database: "test_db", ShardID: 1, IntervalType: interval.Day,
+		Segment: testSegment, Family: familyName}
+	pages := 0
+	err = repaired.RepairFamily(context.TODO(), corruption, func(after uint32) (*models.FamilyExport, error) {
+		pages++
+		pageReq := req
+		pageReq.After = after
+		pageReq.Limit = 1
+		return healthy.ExportFamily(pageReq)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, pages)
+	// the corrupted file is replaced by the data of healthy engine
+	result, err := repaired.ExportFamily(req)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, all.Blocks, result.Blocks)
+	assert.Equal(t, 2, brokenFamily.DiskUsage().Levels[0].Files)
+
+	// fails if export fails
+	err = repaired.RepairFamily(context.TODO(), corruption, func(after uint32) (*models.FamilyExport, error) {
+		return nil, fmt.Errorf("err")
+	})
+	assert.NotNil(t, err)
+	// index cannot be repaired
+	assert.NotNil(t, repaired.RepairFamily(context.TODO(), models.Corruption{Index: true}, nil))
+	// the string family isn't repaired from replica
+	assert.NotNil(t, repaired.RepairFamily(context.TODO(), models.Corruption{Family: metrictbl.StringFamilyName}, nil))
+	// family not found
+	req.Family = "100"
+	_, err = healthy.ExportFamily(req)
+	assert.NotNil(t, err)
+	req.Segment = "20190703"
+	_, err = healthy.ExportFamily(req)
+	assert.NotNil(t, err)
+	req.ShardID = 10
+	_, err = healthy.ExportFamily(req)
+	assert.NotNil(t, err)
+}
+
+func getTestFamily(t *testing.T, e Engine, timestamp int64) (kv.Family, string) {
+	intervalSegment, ok := e.GetShard(1).GetIntervalSegment(interval.Day)
+	assert.True(t, ok)
+	segment, err := intervalSegment.GetOrCreateSegment(testSegment)
+	assert.Nil(t, err)
+	// the family of field group which is flushed by memory-database
+	familyName := metrictbl.FieldFamilyName(segment.FamilyName(timestamp), 1)
+	family, err := segment.GetOrCreateFamilyByName(familyName)
+	assert.Nil(t, err)
+	return family, familyName
+}
+
+func flushTestBlocks(t *testing.T, e Engine, family kv.Family, metrics map[string][]string) {
+	idx := e.GetIndex()
+	blocks := make(map[uint32][]byte)
+	for metricName, seriesList := range metrics {
+		metricID, _ := idx.GetMetricUID().GetOrCreateMetricID(metricName, true)
+		fieldID, _ := idx.GetFieldUID().GetOrCreateFieldID(metricID, "f1", field.SumField)
+		entries := make(map[uint32][]byte)
+		for _, tags := range seriesList {
+			seriesID, _ := idx.GetTagsUID().GetOrCreateTagsID(metricID, tags)
+			entries[seriesID] = []byte(metricName + tags)
+		}
+		block, err := metrictbl.EncodeBlock([]uint32{fieldID}, 0, 10, entries)
+		assert.Nil(t, err)
+		blocks[metricID] = block
+	}
+	flusher := family.NewFlusher()
+	for metricID := uint32(0); len(blocks) > 0; metricID++ {
+		if block, ok := blocks[metricID]; ok {
+			assert.Nil(t, flusher.Add(metricID, block))
+			delete(blocks, metricID)
+		}
+	}
+	assert.Nil(t, flusher.Commit())
+}
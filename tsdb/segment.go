@@ -3,10 +3,13 @@ package tsdb
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/eleme/lindb/kv"
+	"github.com/eleme/lindb/kv/version"
 	"github.com/eleme/lindb/models"
 	"github.com/eleme/lindb/pkg/interval"
 	"github.com/eleme/lindb/pkg/logger"
@@ -19,8 +22,23 @@ import (
 type IntervalSegment interface {
 	// GetOrCreateSegment creates new segment if not exist, if exist return it
 	GetOrCreateSegment(segmentName string) (Segment, error)
+	// GetOrCreateSegmentOf returns the segment which the timestamp(ms) belongs to, creates it if not exist
+	GetOrCreateSegmentOf(timestamp int64) (Segment, error)
+	// GetSegment returns the segment by name, returns nil if not exist
+	GetSegment(segmentName string) Segment
 	// GetSegments returns segment list by time range, return nil if not match
 	GetSegments(timeRange models.TimeRange) []Segment
+	// GetAllSegments returns all segments sorted by base time
+	GetAllSegments() []Segment
+	// DiskUsage returns the disk usage of segments' families sorted by name,
+	// the family is named as interval type/segment/family
+	DiskUsage() []models.FamilyDiskUsage
+	// WarmUp opens the table readers and loads the index blocks of the most recent segments into block cache,
+	// warms all segments if number of segments <= 0
+	WarmUp(numOfSegments int) (models.WarmUpStats, error)
+	// SetCorruptionHandler sets the handler which is invoked when a file of segments' families is found corrupted,
+	// the handler is also set to the segments created later
+	SetCorruptionHandler(handler CorruptionHandler)
 	// Close closes interval segment, release resource
 	Close()
 }
@@ -30,14 +48,20 @@ type intervalSegment struct {
 	path         string
 	interval     time.Duration
 	intervalType interval.Type
+	calc         interval.Calculator
+	// barrier is the snapshot barrier shared by the stores of segments and engine's index
+	barrier *version.Barrier
 
 	segments sync.Map
+	// corruptionHandler is set to the segments created later, protected by mutex
+	corruptionHandler CorruptionHandler
 
 	mutex sync.Mutex
 }
 
-// newIntervalSegment create interval segment based on interval/type/path etc.
-func newIntervalSegment(interval time.Duration, intervalType interval.Type, path string) (IntervalSegment, error) {
+// newIntervalSegment create interval segment based on interval/type/calculator/path etc.
+func newIntervalSegment(interval time.Duration, intervalType interval.Type, calc interval.Calculator,
+	path string, barrier *version.Barrier) (IntervalSegment, error) {
 	if err := util.MkDirIfNotExist(path); err != nil {
 		return nil, err
 	}
@@ -45,6 +69,8 @@ func newIntervalSegment(interval time.Duration, intervalType interval.Type, path
 		path:         path,
 		interval:     interval,
 		intervalType: intervalType,
+		calc:         calc,
+		barrier:      barrier,
 	}
 
 	// load segments if exist
@@ -55,7 +81,8 @@ func newIntervalSegment(interval time.Duration, intervalType interval.Type, path
 		return nil, err
 	}
 	for _, segmentName := range segmentNames {
-		seg, err := newSegment(segmentName, intervalType, filepath.Join(path, segmentName))
+		seg, err := newSegment(segmentName, calc, intervalSegment.intervalMillis(),
+			filepath.Join(path, segmentName), barrier)
 		if err != nil {
 			return nil, fmt.Errorf("create segmenet error:%s", err)
 		}
@@ -74,10 +101,13 @@ func (s *intervalSegment) GetOrCreateSegment(segmentName string) (Segment, error
 		// double check, make sure only create segment once
 		segment = s.getSegment(segmentName)
 		if segment == nil {
-			seg, err := newSegment(segmentName, s.intervalType, filepath.Join(s.path, segmentName))
+			seg, err := newSegment(segmentName, s.calc, s.intervalMillis(), filepath.Join(s.path, segmentName), s.barrier)
 			if err != nil {
 				return nil, fmt.Errorf("create segmenet error:%s", err)
 			}
+			if s.corruptionHandler != nil {
+				seg.SetCorruptionHandler(s.corruptionHandler)
+			}
 			s.segments.Store(segmentName, seg)
 			return seg, nil
 		}
@@ -85,16 +115,26 @@ func (s *intervalSegment) GetOrCreateSegment(segmentName string) (Segment, error
 	return segment, nil
 }
 
+// GetSegment returns the segment by name, returns nil if not exist
+func (s *intervalSegment) GetSegment(segmentName string) Segment {
+	return s.getSegment(segmentName)
+}
+
+// GetOrCreateSegmentOf returns the segment which the timestamp belongs to, creates it if not exist
+func (s *intervalSegment) GetOrCreateSegmentOf(timestamp int64) (Segment, error) {
+	return s.GetOrCreateSegment(s.calc.GetSegment(timestamp))
+}
+
+// intervalMillis returns the interval of segments in milliseconds as the timestamp of points
+func (s *intervalSegment) intervalMillis() int64 {
+	return int64(s.interval / time.Millisecond)
+}
+
 // GetSegments returns segment list by time range, return nil if not match
 func (s *intervalSegment) GetSegments(timeRange models.TimeRange) []Segment {
-	calc, err := interval.GetCalculator(s.intervalType)
-	if err != nil {
-		return nil
-	}
-
 	var segments []Segment
-	start := calc.CalSegmentTime(timeRange.Start)
-	end := calc.CalSegmentTime(timeRange.End)
+	start := s.calc.CalSegmentTime(timeRange.Start)
+	end := s.calc.CalSegmentTime(timeRange.End)
 	s.segments.Range(func(k, v interface{}) bool {
 		segment, ok := v.(Segment)
 		if ok {
@@ -108,6 +148,81 @@ func (s *intervalSegment) GetSegments(timeRange models.TimeRange) []Segment {
 	return segments
 }
 
+// DiskUsage returns the disk usage of segments' families sorted by name
+func (s *intervalSegment) DiskUsage() []models.FamilyDiskUsage {
+	var usages []models.FamilyDiskUsage
+	s.segments.Range(func(k, v interface{}) bool {
+		seg, ok := v.(Segment)
+		if ok {
+			for _, usage := range seg.DiskUsage() {
+				usage.Name = s.intervalType.String() + "/" + usage.Name
+				usages = append(usages, usage)
+			}
+		}
+		return true
+	})
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].Name < usages[j].Name
+	})
+	return usages
+}
+
+// GetAllSegments returns all segments sorted by base time
+func (s *intervalSegment) GetAllSegments() []Segment {
+	var segments []Segment
+	s.segments.Range(func(k, v interface{}) bool {
+		seg, ok := v.(Segment)
+		if ok {
+			segments = append(segments, seg)
+		}
+		return true
+	})
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].BaseTime() < segments[j].BaseTime()
+	})
+	return segments
+}
+
+// WarmUp opens the table readers and loads the index blocks of the most recent segments into block cache,
+// stops at the first segment which fails
+func (s *intervalSegment) WarmUp(numOfSegments int) (models.WarmUpStats, error) {
+	segments := s.GetAllSegments()
+	// the most recent segments first
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].BaseTime() > segments[j].BaseTime()
+	})
+	if numOfSegments > 0 && len(segments) > numOfSegments {
+		segments = segments[:numOfSegments]
+	}
+	var stats models.WarmUpStats
+	for _, seg := range segments {
+		segmentStats, err := seg.WarmUp()
+		stats.Add(segmentStats)
+		if err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// SetCorruptionHandler sets the handler which is invoked when a file of segments' families is found corrupted,
+// the interval type is filled into the corruption
+func (s *intervalSegment) SetCorruptionHandler(handler CorruptionHandler) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.corruptionHandler = func(corruption models.Corruption) {
+		corruption.IntervalType = s.intervalType
+		handler(corruption)
+	}
+	s.segments.Range(func(k, v interface{}) bool {
+		seg, ok := v.(Segment)
+		if ok {
+			seg.SetCorruptionHandler(s.corruptionHandler)
+		}
+		return true
+	})
+}
+
 // Close closes interval segment, release resource
 func (s *intervalSegment) Close() {
 	s.segments.Range(func(k, v interface{}) bool {
@@ -134,42 +249,67 @@ func (s *intervalSegment) getSegment(segmentName string) Segment {
 type Segment interface {
 	// BaseTime returns segment base time
 	BaseTime() int64
+	// DiskUsage returns the disk usage of segment's families, the family is named as segment/family
+	DiskUsage() []models.FamilyDiskUsage
+	// GetOrCreateFamily returns the kv family of the family time(ms), creates it if not exist,
+	// the family is named by the family index in segment, like the hour of day
+	GetOrCreateFamily(familyTime int64) (kv.Family, error)
+	// GetOrCreateFamilyByName returns the kv family by name, creates it if not exist,
+	// it's used for the families which aren't families of time, like the string family(see metrictbl.StringFamilyName)
+	GetOrCreateFamilyByName(familyName string) (kv.Family, error)
+	// GetFamilies returns the kv families of segment sorted by name
+	GetFamilies() []kv.Family
+	// FamilyName returns the name of the family which the family time(ms) belongs to
+	FamilyName(familyTime int64) string
+	// FamilyTime returns the start time(ms) of the family by family name
+	FamilyTime(familyName string) (int64, error)
+	// Interval returns the interval(ms) of the data stored in segment
+	Interval() int64
+	// WarmUp opens the table readers and loads the index blocks of segment's families into block cache
+	WarmUp() (models.WarmUpStats, error)
+	// SetCorruptionHandler sets the handler which is invoked when a file of segment's families is found corrupted
+	SetCorruptionHandler(handler CorruptionHandler)
+	// Checkpoint pins the versions of segment's families for copying the files of segment's kv store, e.g. backup
+	Checkpoint() *version.Checkpoint
 	// Close closes segment, include kv store
 	Close()
 }
 
 // segment implements Segment interface
 type segment struct {
-	baseTime     int64
-	kvStore      kv.Store
-	intervalType interval.Type
+	name     string
+	baseTime int64
+	calc     interval.Calculator
+	interval int64
+	kvStore  kv.Store
 	//TODO
 	// families     map[int64]kv.Family
 
 	logger *logger.Logger
 }
 
-// newSegment returns segment, segment is wrapper of kv store
-func newSegment(segmentName string, intervalType interval.Type, path string) (Segment, error) {
-	kvStore, err := kv.NewStore(segmentName, kv.StoreOption{Path: path})
+// newSegment returns segment, segment is wrapper of kv store, the base time is parsed by calculator,
+// the kv store shares the snapshot barrier with other stores of engine
+func newSegment(segmentName string, calc interval.Calculator, interval int64, path string,
+	barrier *version.Barrier) (Segment, error) {
+	kvStore, err := kv.NewStore(segmentName, kv.DefaultStoreOption(path))
 	if err != nil {
 		return nil, fmt.Errorf("create  kv store for segment error:%s", err)
 	}
+	kvStore.SetSnapshotBarrier(barrier)
 	// parse base time from segment name
-	calc, err := interval.GetCalculator(intervalType)
-	if err != nil {
-		return nil, err
-	}
 	baseTime, err := calc.ParseSegmentTime(segmentName)
 	if err != nil {
 		return nil, fmt.Errorf("parse segment[%s] base time error", path)
 	}
 
 	return &segment{
-		baseTime:     baseTime,
-		kvStore:      kvStore,
-		intervalType: intervalType,
-		logger:       logger.GetLogger("tsdb/segment"),
+		name:     segmentName,
+		baseTime: baseTime,
+		calc:     calc,
+		interval: interval,
+		kvStore:  kvStore,
+		logger:   logger.GetLogger("tsdb/segment"),
 	}, nil
 }
 
@@ -178,6 +318,78 @@ func (s *segment) BaseTime() int64 {
 	return s.baseTime
 }
 
+// DiskUsage returns the disk usage of segment's families
+func (s *segment) DiskUsage() []models.FamilyDiskUsage {
+	usages := s.kvStore.DiskUsage()
+	for idx := range usages {
+		usages[idx].Name = s.name + "/" + usages[idx].Name
+	}
+	return usages
+}
+
+// GetOrCreateFamily returns the kv family of the family time(ms), creates it if not exist
+func (s *segment) GetOrCreateFamily(familyTime int64) (kv.Family, error) {
+	return s.GetOrCreateFamilyByName(s.FamilyName(familyTime))
+}
+
+// GetOrCreateFamilyByName returns the kv family by name, creates it if not exist
+func (s *segment) GetOrCreateFamilyByName(familyName string) (kv.Family, error) {
+	if family := s.kvStore.GetFamily(familyName); family != nil {
+		return family, nil
+	}
+	family, err := s.kvStore.CreateFamily(familyName, kv.FamilyOption{})
+	if err != nil {
+		return nil, fmt.Errorf("create family[%s] of segment[%s] error:%s", familyName, s.name, err)
+	}
+	return family, nil
+}
+
+// GetFamilies returns the kv families of segment sorted by name
+func (s *segment) GetFamilies() []kv.Family {
+	return s.kvStore.GetFamilies()
+}
+
+// FamilyName returns the name of the family which the family time(ms) belongs to, like the hour of day
+func (s *segment) FamilyName(familyTime int64) string {
+	return strconv.Itoa(s.calc.CalFamily(familyTime, s.baseTime))
+}
+
+// FamilyTime returns the start time(ms) of the family by family name, the name is the family index in segment
+func (s *segment) FamilyTime(familyName string) (int64, error) {
+	family, err := strconv.Atoi(familyName)
+	if err != nil {
+		return 0, fmt.Errorf("parse family[%s] of segment[%s] error:%s", familyName, s.name, err)
+	}
+	return s.calc.CalFamilyStartTime(s.baseTime, family), nil
+}
+
+// Interval returns the interval(ms) of the data stored in segment
+func (s *segment) Interval() int64 {
+	return s.interval
+}
+
+// WarmUp opens the table readers and loads the index blocks of segment's families into block cache
+func (s *segment) WarmUp() (models.WarmUpStats, error) {
+	stats, err := s.kvStore.WarmUp()
+	if err != nil {
+		return stats, fmt.Errorf("warm up segment[%s] error:%s", s.name, err)
+	}
+	return stats, nil
+}
+
+// SetCorruptionHandler sets the handler which is invoked when a file of segment's families is found corrupted,
+// the segment name is filled into the corruption
+func (s *segment) SetCorruptionHandler(handler CorruptionHandler) {
+	s.kvStore.SetCorruptionHandler(func(family string, fileNumber int64, err error) {
+		handler(models.Corruption{Segment: s.name, Family: family, FileNumber: fileNumber, Error: err.Error()})
+	})
+}
+
+// Checkpoint pins the versions of segment's families
+func (s *segment) Checkpoint() *version.Checkpoint {
+	return s.kvStore.Checkpoint()
+}
+
 // Close closes segment, include kv store
 func (s *segment) Close() {
 	if err := s.kvStore.Close(); err != nil {
@@ -15,9 +15,11 @@ import (
 
 var segPath = filepath.Join(testPath, shardPath, "1", segmentPath, interval.Day.String())
 
+var dayCalc, _ = interval.GetCalculator(interval.Day)
+
 func TestNewIntervalSegment(t *testing.T) {
 	defer util.RemoveDir(testPath)
-	s, err := newIntervalSegment(time.Second*10, interval.Day, segPath)
+	s, err := newIntervalSegment(time.Second*10, interval.Day, dayCalc, segPath, nil)
 	assert.Nil(t, err)
 	assert.NotNil(t, s)
 	assert.True(t, util.Exist(segPath))
@@ -25,7 +27,7 @@ func TestNewIntervalSegment(t *testing.T) {
 
 func TestNewSegment(t *testing.T) {
 	defer util.RemoveDir(testPath)
-	s, _ := newIntervalSegment(time.Second*10, interval.Day, segPath)
+	s, _ := newIntervalSegment(time.Second*10, interval.Day, dayCalc, segPath, nil)
 
 	seg, err := s.GetOrCreateSegment("20190702")
 	assert.Nil(t, err)
@@ -34,7 +36,7 @@ func TestNewSegment(t *testing.T) {
 
 	s.Close()
 
-	s, _ = newIntervalSegment(time.Second*10, interval.Day, segPath)
+	s, _ = newIntervalSegment(time.Second*10, interval.Day, dayCalc, segPath, nil)
 
 	seg1, ok := s.(*intervalSegment)
 	if ok {
@@ -49,7 +51,7 @@ func TestNewSegment(t *testing.T) {
 
 func TestGetSegmentsByTimeRange(t *testing.T) {
 	defer util.RemoveDir(testPath)
-	s, _ := newIntervalSegment(time.Second*10, interval.Day, segPath)
+	s, _ := newIntervalSegment(time.Second*10, interval.Day, dayCalc, segPath, nil)
 	s.GetOrCreateSegment("20190702")
 	t2, _ := timeutil.ParseTimestamp("20190702", "20060102")
 	segments := s.GetSegments(models.TimeRange{Start: t2, End: t2 + 60*60*1000})
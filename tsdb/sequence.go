@@ -0,0 +1,64 @@
+package tsdb
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// writeSequence represents the sequence of writes applied into shard, which increases after each write is
+// visible to queries, so that the query can wait for the writes of given sequence(read-your-writes).
+// The replicas of shard applying the same replica log get the same sequence.
+type writeSequence struct {
+	sequence int64
+	waiters  int32
+
+	mutex sync.Mutex
+	// advanced is closed after the sequence advanced if there are waiters
+	advanced chan struct{}
+}
+
+// newWriteSequence creates the write sequence starting from 0
+func newWriteSequence() *writeSequence {
+	return &writeSequence{advanced: make(chan struct{})}
+}
+
+// Current returns the sequence of latest write which is visible
+func (s *writeSequence) Current() int64 {
+	return atomic.LoadInt64(&s.sequence)
+}
+
+// Next advances the sequence after a write applied, then notifies the waiters, returns the new sequence
+func (s *writeSequence) Next() int64 {
+	sequence := atomic.AddInt64(&s.sequence, 1)
+	// the channel is only replaced if there are waiters, so that the write path has no lock in most cases
+	if atomic.LoadInt32(&s.waiters) > 0 {
+		s.mutex.Lock()
+		close(s.advanced)
+		s.advanced = make(chan struct{})
+		s.mutex.Unlock()
+	}
+	return sequence
+}
+
+// Wait waits until the sequence >= given sequence, returns the error of ctx if done before that
+func (s *writeSequence) Wait(ctx context.Context, sequence int64) error {
+	if s.Current() >= sequence {
+		return nil
+	}
+	atomic.AddInt32(&s.waiters, 1)
+	defer atomic.AddInt32(&s.waiters, -1)
+	for {
+		s.mutex.Lock()
+		advanced := s.advanced
+		s.mutex.Unlock()
+		if s.Current() >= sequence {
+			return nil
+		}
+		select {
+		case <-advanced:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
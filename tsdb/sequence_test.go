@@ -0,0 +1,33 @@
+package tsdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteSequence(t *testing.T) {
+	s := newWriteSequence()
+	assert.Equal(t, int64(0), s.Current())
+	assert.Equal(t, int64(1), s.Next())
+	assert.Nil(t, s.Wait(context.TODO(), 1))
+
+	// wait for the following writes
+	done := make(chan error)
+	go func() {
+		done <- s.Wait(context.TODO(), 3)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	s.Next()
+	s.Next()
+	assert.Nil(t, <-done)
+	assert.Equal(t, int64(3), s.Current())
+
+	// ctx done before the write
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, s.Wait(ctx, 4))
+	assert.Equal(t, int32(0), s.waiters)
+}
@@ -4,13 +4,20 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"sort"
+	"sync"
+	"time"
 
+	"github.com/eleme/lindb/kv/version"
+	"github.com/eleme/lindb/tsdb/index"
 	"github.com/eleme/lindb/tsdb/memdb"
 
 	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
 	"github.com/eleme/lindb/pkg/interval"
 	"github.com/eleme/lindb/pkg/option"
 	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/pkg/tracing"
 	"github.com/eleme/lindb/pkg/util"
 )
 
@@ -22,69 +29,232 @@ const segmentPath = "segment"
 type Shard interface {
 	// GetSegments returns segment list by interval type and time range, return nil if not match
 	GetSegments(intervalType interval.Type, timeRange models.TimeRange) []Segment
-	// Write writes the metric-point into memory-database.
+	// GetIntervalSegment returns the interval segment by interval type, returns false if not exist
+	GetIntervalSegment(intervalType interval.Type) (IntervalSegment, bool)
+	// IntervalTypes returns the interval type and the rollup interval types of shard
+	IntervalTypes() []interval.Type
+	// Write writes the metric-point into memory-database, returns ErrShardReadOnly if the shard isn't hot.
 	Write(point models.Point) error
+	// WriteBackfill writes the historical point for re-ingesting corrected data, which bypasses the behind window
+	// of Write, the point is staged apart from the memory-database and isn't visible to queries until flushed
+	// into the historical family by FlushBackfill.
+	WriteBackfill(point models.Point) error
+	// FlushBackfill flushes the points staged by backfill into the families of segments which they belong to
+	FlushBackfill(ctx context.Context) error
+	// MemoryDatabase returns the memory-database of shard, which holds the data not flushed,
+	// returns nil if the shard is frozen
+	MemoryDatabase() memdb.MemoryDatabase
+	// MemoryDatabaseOf returns the memory-database of interval type, which is the interval or one of rollup intervals,
+	// returns false if the shard is frozen
+	MemoryDatabaseOf(intervalType interval.Type) (memdb.MemoryDatabase, bool)
+	// MemoryDatabases returns the memory-databases of the interval and rollup intervals, the interval's is the first,
+	// returns nil if the shard is frozen
+	MemoryDatabases() []memdb.MemoryDatabase
+	// State returns the state of shard, the shard is hot after created
+	State() models.ShardState
+	// SetState changes the state of shard, the writes are rejected if not hot. The memory-databases are flushed
+	// into the families of segments then released after frozen, they are recreated after unfrozen.
+	SetState(ctx context.Context, state models.ShardState) error
+	// Flush flushes the memory-databases of the interval and rollup intervals into the families of segments,
+	// the memory-databases are kept for writing
+	Flush(ctx context.Context) error
+	// Stat returns the statistics of shard, which is used for deciding to split shard
+	Stat() models.ShardStat
+	// Sequence returns the sequence of latest write which is visible to queries
+	Sequence() int64
+	// WaitForSequence waits until the writes of sequence are visible to queries(read-your-writes),
+	// returns the error of ctx if done before that
+	WaitForSequence(ctx context.Context, sequence int64) error
+	// DiskUsage returns the disk usage of shard's segments in all intervals
+	DiskUsage() models.ShardDiskUsage
+	// WarmUp opens the table readers and loads the index blocks of the most recent segments in all intervals
+	// into block cache, so that the first queries after restarted don't read them from disk,
+	// warms all segments if number of segments <= 0
+	WarmUp(numOfSegments int) (models.WarmUpStats, error)
+	// SetCorruptionHandler sets the handler which is invoked when a file of the segments in all intervals
+	// is found corrupted
+	SetCorruptionHandler(handler CorruptionHandler)
 	// Close releases shard's resource, such as flush data, spawned goroutines etc.
 	Close()
 }
 
 // shard implements Shard interface
 type shard struct {
-	id     int
-	path   string
-	option option.ShardOption
-	memDB  memdb.MemoryDatabase
+	database  string
+	id        int
+	path      string
+	option    option.ShardOption
+	generator index.IDGenerator
+
+	// stateMutex protects the state and the memory-databases which are released after frozen
+	stateMutex sync.RWMutex
+	state      models.ShardState
+	memDB      memdb.MemoryDatabase
+	// rollupMemDBs aggregate the points at write time by rollup intervals, key: interval type
+	rollupMemDBs map[interval.Type]memdb.MemoryDatabase
+	// memCtx is the context of memory-databases, which is canceled after frozen
+	memCtx    context.Context
+	memCancel context.CancelFunc
 
 	segment IntervalSegment // smallest interval for writing data
 
 	// segments keeps all interval segments,
 	// includes one smallest interval segment for writing data, and rollup interval segments
 	segments map[interval.Type]IntervalSegment
-	cancel   context.CancelFunc
+	sequence *writeSequence
+
+	// backfillStages stage the points of backfill for the interval and rollup intervals,
+	// which are created at the first backfill write
+	backfillMutex  sync.Mutex
+	backfillStages []*backfillStage
+
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
-// newShard creates shard instance, if shard path exist then load shard data for init.
-// return error if fail.
-func newShard(shardID int, path string, option option.ShardOption) (Shard, error) {
+// newShard creates shard instance of database, if shard path exist then load shard data for init.
+// The stores of segments share the snapshot barrier with engine's index. return error if fail.
+func newShard(database string, shardID int, path string, option option.ShardOption, generator index.IDGenerator,
+	barrier *version.Barrier) (Shard, error) {
 	if option.Interval <= 0 {
 		return nil, fmt.Errorf("interval cannot be negative")
 	}
-	if _, err := interval.GetCalculator(option.IntervalType); err != nil {
-		return nil, fmt.Errorf("interval type[%d] not define", option.IntervalType)
+	if err := option.Validate(); err != nil {
+		return nil, err
 	}
 	if err := util.MkDirIfNotExist(path); err != nil {
 		return nil, err
 	}
 
-	// new segment for writing
-	segment, err := newIntervalSegment(option.Interval,
-		option.IntervalType,
-		filepath.Join(path, segmentPath, option.IntervalType.String()))
+	// the segments and families are calculated with the family option
+	calc, err := option.Calculator()
 	if err != nil {
 		return nil, err
 	}
-	var memDB memdb.MemoryDatabase
-	ctx, cancel := context.WithCancel(context.Background())
-	memDB, err = memdb.NewMemoryDatabase(ctx, option.TimeWindow, int64(option.Interval), option.IntervalType)
+	// new segment for writing
+	segment, err := newIntervalSegment(option.Interval,
+		option.IntervalType, calc,
+		filepath.Join(path, segmentPath, option.IntervalType.String()), barrier)
 	if err != nil {
-		//if create memory database error, cancel background context
-		cancel()
 		return nil, err
 	}
+	ctx, cancel := context.WithCancel(context.Background())
 	shard := &shard{
-		id:       shardID,
-		path:     path,
-		option:   option,
-		memDB:    memDB,
-		segment:  segment,
-		segments: make(map[interval.Type]IntervalSegment),
-		cancel:   cancel,
+		database:  database,
+		id:        shardID,
+		path:      path,
+		option:    option,
+		generator: generator,
+		state:     models.ShardHot,
+		segment:   segment,
+		segments:  make(map[interval.Type]IntervalSegment),
+		sequence:  newWriteSequence(),
+		ctx:       ctx,
+		cancel:    cancel,
 	}
 	// add writing segment into segment list
 	shard.segments[option.IntervalType] = segment
+	// the segments of rollup intervals, the points are aggregated into them at write time
+	for _, rollup := range option.Rollups {
+		rollupCalc, err := option.RollupCalculator(rollup)
+		if err != nil {
+			shard.Close()
+			return nil, err
+		}
+		rollupSegment, err := newIntervalSegment(rollup.Interval,
+			rollup.IntervalType, rollupCalc,
+			filepath.Join(path, segmentPath, rollup.IntervalType.String()), barrier)
+		if err != nil {
+			shard.Close()
+			return nil, err
+		}
+		shard.segments[rollup.IntervalType] = rollupSegment
+	}
+	if err := shard.openMemoryDatabases(); err != nil {
+		//if create memory database error, cancel background context and close segments
+		shard.Close()
+		return nil, err
+	}
 	return shard, nil
 }
 
+// openMemoryDatabases creates the memory-databases of the interval and rollup intervals for writing,
+// which are released by canceling the context of memory-databases after frozen
+func (s *shard) openMemoryDatabases() error {
+	memCtx, memCancel := context.WithCancel(s.ctx)
+	memDBs, err := s.newMemoryDatabases(memCtx)
+	if err != nil {
+		memCancel()
+		return err
+	}
+	s.memDB = memDBs[0]
+	s.rollupMemDBs = make(map[interval.Type]memdb.MemoryDatabase)
+	for idx, rollup := range s.option.Rollups {
+		s.rollupMemDBs[rollup.IntervalType] = memDBs[idx+1]
+	}
+	s.memCtx = memCtx
+	s.memCancel = memCancel
+	return nil
+}
+
+// newMemoryDatabases creates the memory-databases of the interval and rollup intervals in the order of
+// intervalTypes, which share the id generator of shard, so that the ids of metrics/fields are same.
+func (s *shard) newMemoryDatabases(ctx context.Context) ([]memdb.MemoryDatabase, error) {
+	// the families are calculated with the family option
+	calc, err := s.option.Calculator()
+	if err != nil {
+		return nil, err
+	}
+	memDB, err := newShardMemoryDatabase(ctx, s.database, s.id, s.option, s.option.Interval, calc, s.generator)
+	if err != nil {
+		return nil, err
+	}
+	memDBs := []memdb.MemoryDatabase{memDB}
+	for _, rollup := range s.option.Rollups {
+		rollupCalc, err := s.option.RollupCalculator(rollup)
+		if err != nil {
+			return nil, err
+		}
+		rollupMemDB, err := newShardMemoryDatabase(ctx, s.database, s.id, s.option, rollup.Interval,
+			rollupCalc, s.generator)
+		if err != nil {
+			return nil, err
+		}
+		memDBs = append(memDBs, rollupMemDB)
+	}
+	return memDBs, nil
+}
+
+// IntervalTypes returns the interval type and the rollup interval types in the order of option
+func (s *shard) IntervalTypes() []interval.Type {
+	intervalTypes := []interval.Type{s.option.IntervalType}
+	for _, rollup := range s.option.Rollups {
+		intervalTypes = append(intervalTypes, rollup.IntervalType)
+	}
+	return intervalTypes
+}
+
+// newShardMemoryDatabase creates the memory-database of interval for shard
+func newShardMemoryDatabase(ctx context.Context, database string, shardID int, option option.ShardOption,
+	intervalValue time.Duration, calc interval.Calculator, generator index.IDGenerator) (memdb.MemoryDatabase, error) {
+	// the interval of memory-database is in milliseconds as the timestamp of points
+	memDB, err := memdb.NewMemoryDatabase(ctx, database, shardID,
+		option.TimeWindow, int64(intervalValue/time.Millisecond), calc, generator)
+	if err != nil {
+		return nil, err
+	}
+	if len(option.BannedTags) > 0 {
+		memDB.SetBannedTags(option.BannedTags)
+	}
+	return memDB, nil
+}
+
+// GetIntervalSegment returns the interval segment by interval type, returns false if not exist
+func (s *shard) GetIntervalSegment(intervalType interval.Type) (IntervalSegment, bool) {
+	segment, ok := s.segments[intervalType]
+	return segment, ok
+}
+
 // GetSegments returns segment list by interval type and time range, return nil if not match
 func (s *shard) GetSegments(intervalType interval.Type, timeRange models.TimeRange) []Segment {
 	segment, ok := s.segments[intervalType]
@@ -96,18 +266,277 @@ func (s *shard) GetSegments(intervalType interval.Type, timeRange models.TimeRan
 
 // Write writes the metric-point into memory-database.
 func (s *shard) Write(point models.Point) error {
+	s.stateMutex.RLock()
+	defer s.stateMutex.RUnlock()
+	if !s.state.Writable() {
+		return s.readOnlyError()
+	}
 	timestamp := point.Timestamp()
 	now := timeutil.Now()
 
 	if timestamp < now-s.option.Behind || timestamp > now+s.option.Ahead {
 		return nil
 	}
+	defer tracing.ObserveStage(tracing.StageMemDBWrite, time.Now())
 
-	// write metric point into memory db
-	return s.memDB.Write(point)
+	// write metric point into memory db, the point is visible after written
+	if err := s.memDB.Write(point); err != nil {
+		return err
+	}
+	// aggregate the point into the memory-databases of rollup intervals
+	var rollupErr error
+	for _, rollup := range s.option.Rollups {
+		if err := s.rollupMemDBs[rollup.IntervalType].Write(point); err != nil && rollupErr == nil {
+			rollupErr = fmt.Errorf("write point into rollup interval[%s] error:%s", rollup.Interval, err)
+		}
+	}
+	s.sequence.Next()
+	return rollupErr
 }
 
-// Close closes the memDatabase and spawned goroutines.
+// WriteBackfill writes the historical point into the staging memory-databases of the interval and
+// rollup intervals, only the point ahead of now is dropped. The backfill writes are serialized with flushing.
+func (s *shard) WriteBackfill(point models.Point) error {
+	s.stateMutex.RLock()
+	defer s.stateMutex.RUnlock()
+	if !s.state.Writable() {
+		return s.readOnlyError()
+	}
+	if point.Timestamp() > timeutil.Now()+s.option.Ahead {
+		return nil
+	}
+	s.backfillMutex.Lock()
+	defer s.backfillMutex.Unlock()
+
+	if s.backfillStages == nil {
+		stages, err := s.newBackfillStages()
+		if err != nil {
+			return err
+		}
+		s.backfillStages = stages
+	}
+	for _, stage := range s.backfillStages {
+		if err := stage.memDB.Write(point); err != nil {
+			return fmt.Errorf("write backfill point error:%s", err)
+		}
+	}
+	return nil
+}
+
+// FlushBackfill flushes the points staged by backfill into the historical families of segments
+func (s *shard) FlushBackfill(ctx context.Context) error {
+	s.backfillMutex.Lock()
+	defer s.backfillMutex.Unlock()
+
+	for _, stage := range s.backfillStages {
+		if err := stage.flush(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newBackfillStages creates the staging memory-databases of the interval and rollup intervals,
+// which are released with the memory-databases of live writes after frozen
+func (s *shard) newBackfillStages() ([]*backfillStage, error) {
+	memDBs, err := s.newMemoryDatabases(s.memCtx)
+	if err != nil {
+		return nil, err
+	}
+	stages := make([]*backfillStage, len(memDBs))
+	for idx, intervalType := range s.IntervalTypes() {
+		stages[idx] = &backfillStage{memDB: memDBs[idx], segment: s.segments[intervalType]}
+	}
+	return stages, nil
+}
+
+// readOnlyError returns the error of writing into the shard which isn't hot
+func (s *shard) readOnlyError() error {
+	return errors.Wrapf(errors.ErrShardReadOnly, "shard[%d] of database[%s] is %s", s.id, s.database, s.state)
+}
+
+// MemoryDatabase returns the memory-database of shard
+func (s *shard) MemoryDatabase() memdb.MemoryDatabase {
+	s.stateMutex.RLock()
+	defer s.stateMutex.RUnlock()
+	return s.memDB
+}
+
+// MemoryDatabaseOf returns the memory-database of interval type
+func (s *shard) MemoryDatabaseOf(intervalType interval.Type) (memdb.MemoryDatabase, bool) {
+	s.stateMutex.RLock()
+	defer s.stateMutex.RUnlock()
+	if s.memDB == nil {
+		return nil, false
+	}
+	if intervalType == s.option.IntervalType {
+		return s.memDB, true
+	}
+	memDB, ok := s.rollupMemDBs[intervalType]
+	return memDB, ok
+}
+
+// MemoryDatabases returns the memory-databases of the interval and rollup intervals in the order of option
+func (s *shard) MemoryDatabases() []memdb.MemoryDatabase {
+	s.stateMutex.RLock()
+	defer s.stateMutex.RUnlock()
+	if s.memDB == nil {
+		return nil
+	}
+	memDBs := []memdb.MemoryDatabase{s.memDB}
+	for _, rollup := range s.option.Rollups {
+		memDBs = append(memDBs, s.rollupMemDBs[rollup.IntervalType])
+	}
+	return memDBs
+}
+
+// State returns the state of shard
+func (s *shard) State() models.ShardState {
+	s.stateMutex.RLock()
+	defer s.stateMutex.RUnlock()
+	return s.state
+}
+
+// SetState changes the state of shard, flushes then releases the memory-databases if frozen,
+// recreates the memory-databases if unfrozen. The state isn't changed if flushing fails.
+func (s *shard) SetState(ctx context.Context, state models.ShardState) error {
+	if !state.IsValid() {
+		return errors.Wrapf(errors.ErrInvalidArgument, "unknown shard state[%s]", state)
+	}
+	s.stateMutex.Lock()
+	defer s.stateMutex.Unlock()
+	if state == s.state {
+		return nil
+	}
+	switch {
+	case state == models.ShardFrozen:
+		if err := s.freeze(ctx); err != nil {
+			return err
+		}
+	case s.state == models.ShardFrozen:
+		if err := s.openMemoryDatabases(); err != nil {
+			return err
+		}
+	}
+	s.state = state
+	return nil
+}
+
+// freeze flushes the memory-databases of live writes and backfill into the families of segments,
+// then releases them, must be called under the lock of state
+func (s *shard) freeze(ctx context.Context) error {
+	s.backfillMutex.Lock()
+	defer s.backfillMutex.Unlock()
+
+	for _, stage := range s.backfillStages {
+		if err := stage.flush(ctx); err != nil {
+			return err
+		}
+	}
+	if err := s.flushMemoryDatabases(ctx); err != nil {
+		return err
+	}
+	s.memCancel()
+	s.memDB = nil
+	s.rollupMemDBs = nil
+	s.backfillStages = nil
+	return nil
+}
+
+// Flush flushes the memory-databases of the interval and rollup intervals into the families of segments,
+// does nothing if the shard is frozen
+func (s *shard) Flush(ctx context.Context) error {
+	s.stateMutex.RLock()
+	defer s.stateMutex.RUnlock()
+	if s.memDB == nil {
+		return nil
+	}
+	return s.flushMemoryDatabases(ctx)
+}
+
+// flushMemoryDatabases flushes the memory-databases of the interval and rollup intervals,
+// must be called under the lock of state
+func (s *shard) flushMemoryDatabases(ctx context.Context) error {
+	for _, intervalType := range s.IntervalTypes() {
+		memDB := s.memDB
+		if intervalType != s.option.IntervalType {
+			memDB = s.rollupMemDBs[intervalType]
+		}
+		if err := flushMemoryDatabase(ctx, memDB, s.segments[intervalType]); err != nil {
+			return fmt.Errorf("flush memory database of interval[%s] error:%s", intervalType, err)
+		}
+	}
+	return nil
+}
+
+// Stat returns the number of series in memory-database and the number of points written since opened,
+// the number of series is 0 if the shard is frozen
+func (s *shard) Stat() models.ShardStat {
+	stat := models.ShardStat{Database: s.database, ShardID: s.id, WrittenPoints: s.sequence.Current()}
+	s.stateMutex.RLock()
+	defer s.stateMutex.RUnlock()
+	if s.memDB != nil {
+		memStats := s.memDB.Stats()
+		stat.NumOfSeries = int64(memStats.MutableSeries + memStats.ImmutableSeries)
+	}
+	return stat
+}
+
+// Sequence returns the sequence of latest write which is visible to queries
+func (s *shard) Sequence() int64 {
+	return s.sequence.Current()
+}
+
+// WaitForSequence waits until the writes of sequence are visible to queries
+func (s *shard) WaitForSequence(ctx context.Context, sequence int64) error {
+	return s.sequence.Wait(ctx, sequence)
+}
+
+// DiskUsage returns the disk usage of shard's segments in all intervals, sorted by family name
+func (s *shard) DiskUsage() models.ShardDiskUsage {
+	usage := models.ShardDiskUsage{ShardID: s.id}
+	for _, segment := range s.segments {
+		usage.Families = append(usage.Families, segment.DiskUsage()...)
+	}
+	sort.Slice(usage.Families, func(i, j int) bool {
+		return usage.Families[i].Name < usage.Families[j].Name
+	})
+	for _, family := range usage.Families {
+		usage.Bytes += family.Bytes
+	}
+	return usage
+}
+
+// WarmUp opens the table readers and loads the index blocks of the most recent segments in all intervals
+// into block cache, stops at the first interval which fails
+func (s *shard) WarmUp(numOfSegments int) (models.WarmUpStats, error) {
+	var stats models.WarmUpStats
+	for _, segment := range s.segments {
+		segmentStats, err := segment.WarmUp(numOfSegments)
+		stats.Add(segmentStats)
+		if err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// SetCorruptionHandler sets the handler which is invoked when a file of the segments in all intervals
+// is found corrupted, the database and shard id are filled into the corruption
+func (s *shard) SetCorruptionHandler(handler CorruptionHandler) {
+	for _, segment := range s.segments {
+		segment.SetCorruptionHandler(func(corruption models.Corruption) {
+			corruption.Database = s.database
+			corruption.ShardID = s.id
+			handler(corruption)
+		})
+	}
+}
+
+// Close closes the memDatabase and spawned goroutines, then closes the kv stores of segments.
 func (s *shard) Close() {
 	s.cancel()
+	for _, segment := range s.segments {
+		segment.Close()
+	}
 }
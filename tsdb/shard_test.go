@@ -1,41 +1,351 @@
 package tsdb
 
 import (
+	"context"
+	"fmt"
 	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/eleme/lindb/kv"
 	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/errors"
+	"github.com/eleme/lindb/pkg/field"
 	"github.com/eleme/lindb/pkg/interval"
 	"github.com/eleme/lindb/pkg/option"
+	"github.com/eleme/lindb/pkg/timeutil"
 	"github.com/eleme/lindb/pkg/util"
+	"github.com/eleme/lindb/tsdb/index"
+	"github.com/eleme/lindb/tsdb/memdb"
 )
 
 var path = filepath.Join(testPath, shardPath, "1")
 
 func TestNewShard(t *testing.T) {
 	defer util.RemoveDir(testPath)
-	shard, err := newShard(1, path, option.ShardOption{})
+	shard, err := newShard("db", 1, path, option.ShardOption{}, nil, nil)
 	assert.NotNil(t, err)
 	assert.Nil(t, shard)
 
-	shard, err = newShard(1, path, option.ShardOption{Interval: time.Second * 10})
+	shard, err = newShard("db", 1, path, option.ShardOption{Interval: time.Second * 10}, nil, nil)
 	assert.NotNil(t, err)
 	assert.Nil(t, shard)
 
-	shard, err = newShard(1, path, option.ShardOption{Interval: time.Second * 10, IntervalType: interval.Day})
+	shard, err = newShard("db", 1, path,
+		option.ShardOption{Interval: time.Second * 10, IntervalType: interval.Day}, nil, nil)
 	assert.Nil(t, err)
 	assert.NotNil(t, shard)
 
 	assert.True(t, util.Exist(path))
+
+	// sub-second interval
+	shard, err = newShard("db", 2, filepath.Join(testPath, shardPath, "2"),
+		option.ShardOption{Interval: 100 * time.Millisecond, IntervalType: interval.Day}, nil, nil)
+	assert.Nil(t, err)
+	assert.NotNil(t, shard)
+	_, err = newShard("db", 3, filepath.Join(testPath, shardPath, "3"),
+		option.ShardOption{Interval: 10 * time.Millisecond, IntervalType: interval.Day}, nil, nil)
+	assert.NotNil(t, err)
+}
+
+func TestShard_Write_bannedTags(t *testing.T) {
+	defer util.RemoveDir(testPath)
+	s, err := newShard("db", 1, path, option.ShardOption{
+		TimeWindow:   32,
+		Interval:     time.Second * 10,
+		IntervalType: interval.Day,
+		Behind:       timeutil.OneHour,
+		Ahead:        timeutil.OneHour,
+		BannedTags:   map[string][]string{"cpu": {"pod"}},
+	}, nil, nil)
+	assert.Nil(t, err)
+	for _, pod := range []string{"a", "b"} {
+		err = s.Write(models.NewPoint("cpu", timeutil.Now(), map[string]string{"pod": pod}, map[string]models.Field{
+			"count": models.NewSimpleField(field.SumField, field.Integer, int64(1)),
+		}))
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 1, s.(*shard).memDB.CountTags("cpu"))
+	assert.Equal(t, int64(2), s.Sequence())
+	assert.Nil(t, s.WaitForSequence(context.TODO(), 2))
+	// the point out of time range is dropped, sequence isn't advanced
+	assert.Nil(t, s.Write(models.NewPoint("cpu", 0, nil, nil)))
+	assert.Equal(t, int64(2), s.Sequence())
+}
+
+func TestShard_family(t *testing.T) {
+	defer util.RemoveDir(testPath)
+	shardOption := option.ShardOption{
+		TimeWindow:   32,
+		Interval:     time.Second * 10,
+		IntervalType: interval.Day,
+		Behind:       timeutil.OneDay,
+		Ahead:        timeutil.OneHour,
+		Family:       option.FamilyOption{Duration: 6 * time.Hour, Alignment: interval.UTCAlignment},
+	}
+	s, err := newShard("db", 1, path, shardOption, nil, nil)
+	assert.Nil(t, err)
+	now := timeutil.Now()
+	assert.Nil(t, s.Write(models.NewPoint("cpu", now, nil, map[string]models.Field{
+		"count": models.NewSimpleField(field.SumField, field.Integer, int64(1)),
+	})))
+	// the family of 6 hours is aligned with UTC midnight
+	assert.Equal(t, []int64{now - now%(6*timeutil.OneHour)}, s.MemoryDatabase().Families())
+
+	shardOption.Family.Duration = 5 * time.Hour
+	_, err = newShard("db", 2, filepath.Join(testPath, shardPath, "2"), shardOption, nil, nil)
+	assert.NotNil(t, err)
+}
+
+func TestShard_Write_rollups(t *testing.T) {
+	defer util.RemoveDir(testPath)
+	shardOption := option.ShardOption{
+		TimeWindow:   32,
+		Interval:     time.Second * 10,
+		IntervalType: interval.Day,
+		Behind:       timeutil.OneHour,
+		Ahead:        timeutil.OneHour,
+		Rollups:      []option.RollupOption{{Interval: 5 * time.Minute, IntervalType: interval.Month}},
+	}
+	// rollup interval isn't multiple of interval
+	_, err := newShard("db", 1, path, option.ShardOption{Interval: time.Second * 10, IntervalType: interval.Day,
+		Rollups: []option.RollupOption{{Interval: 15 * time.Second, IntervalType: interval.Month}}}, nil, nil)
+	assert.NotNil(t, err)
+
+	s, err := newShard("db", 1, path, shardOption, nil, nil)
+	assert.Nil(t, err)
+	assert.True(t, util.Exist(filepath.Join(path, segmentPath, interval.Month.String())))
+	assert.Len(t, s.MemoryDatabases(), 2)
+	memDB, ok := s.MemoryDatabaseOf(interval.Day)
+	assert.True(t, ok)
+	assert.Equal(t, s.MemoryDatabase(), memDB)
+	_, ok = s.MemoryDatabaseOf(interval.Year)
+	assert.False(t, ok)
+	rollupMemDB, ok := s.MemoryDatabaseOf(interval.Month)
+	assert.True(t, ok)
+	assert.Equal(t, s.MemoryDatabases()[1], rollupMemDB)
+
+	now := timeutil.Now()
+	now -= now % (5 * 60 * 1000)
+	for _, timestamp := range []int64{now, now + 10*1000} {
+		assert.Nil(t, s.Write(models.NewPoint("cpu", timestamp, map[string]string{"host": "1.1.1.1"},
+			map[string]models.Field{"count": models.NewSimpleField(field.SumField, field.Integer, int64(1))})))
+	}
+	timeRange := models.TimeRange{Start: now, End: now + 10*1000}
+	series, _, err := memDB.RawSeries("cpu", []string{"count"}, timeRange, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []memdb.RawPoint{{Timestamp: now, Value: 1}, {Timestamp: now + 10*1000, Value: 1}},
+		series[0].Fields["count"])
+	// the points are aggregated into the slot of rollup interval at write time
+	series, _, err = rollupMemDB.RawSeries("cpu", []string{"count"}, timeRange, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []memdb.RawPoint{{Timestamp: now, Value: 2}}, series[0].Fields["count"])
+	s.Close()
+}
+
+func TestShard_WriteBackfill(t *testing.T) {
+	defer util.RemoveDir(testPath)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	generator := newMockIDGenerator(ctrl)
+	s, err := newShard("db", 1, path, option.ShardOption{
+		TimeWindow:   32,
+		Interval:     time.Second * 10,
+		IntervalType: interval.Day,
+		Behind:       timeutil.OneHour,
+		Ahead:        timeutil.OneHour,
+		Rollups:      []option.RollupOption{{Interval: 5 * time.Minute, IntervalType: interval.Month}},
+	}, generator, nil)
+	assert.Nil(t, err)
+	// nothing staged
+	assert.Nil(t, s.FlushBackfill(context.TODO()))
+
+	timestamp := timeutil.Now() - 3*timeutil.OneDay
+	point := models.NewPoint("cpu", timestamp, nil, map[string]models.Field{
+		"count": models.NewSimpleField(field.SumField, field.Integer, int64(1)),
+	})
+	// the historical point is dropped by write, but staged by backfill
+	assert.Nil(t, s.Write(point))
+	assert.Nil(t, s.WriteBackfill(point))
+	assert.Empty(t, s.MemoryDatabase().Families())
+	assert.Equal(t, int64(0), s.Sequence())
+	stages := s.(*shard).backfillStages
+	assert.Len(t, stages, 2)
+	assert.Len(t, stages[0].memDB.Families(), 1)
+	// the point ahead of now is dropped
+	assert.Nil(t, s.WriteBackfill(models.NewPoint("cpu", timeutil.Now()+timeutil.OneDay, nil, nil)))
+	assert.Len(t, stages[0].memDB.Families(), 1)
+
+	// the staged families are flushed into the historical segments of interval and rollup interval
+	assert.Nil(t, s.FlushBackfill(context.TODO()))
+	assert.Empty(t, stages[0].memDB.Families())
+	assert.Empty(t, stages[1].memDB.Families())
+	dayCalc, _ := interval.GetCalculator(interval.Day)
+	monthCalc, _ := interval.GetCalculator(interval.Month)
+	timeRange := models.TimeRange{Start: timestamp, End: timestamp}
+	assert.Len(t, s.GetSegments(interval.Day, timeRange), 1)
+	assert.Len(t, s.GetSegments(interval.Month, timeRange), 1)
+	usage := s.DiskUsage()
+	assert.True(t, usage.Bytes > 0)
+	var names []string
+	for _, family := range usage.Families {
+		names = append(names, family.Name)
+	}
+	// the field of id 1 is flushed into the family of its field group
+	assert.Contains(t, names, fmt.Sprintf("%s/%s/%d_1", interval.Day, dayCalc.GetSegment(timestamp),
+		dayCalc.CalFamily(timestamp, dayCalc.CalSegmentTime(timestamp))))
+	assert.Contains(t, names, fmt.Sprintf("%s/%s/%d_1", interval.Month, monthCalc.GetSegment(timestamp),
+		monthCalc.CalFamily(timestamp, monthCalc.CalSegmentTime(timestamp))))
+	s.Close()
+}
+
+func TestShard_SetState(t *testing.T) {
+	defer util.RemoveDir(testPath)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s, err := newShard("db", 1, path, option.ShardOption{
+		TimeWindow:   32,
+		Interval:     time.Second * 10,
+		IntervalType: interval.Day,
+		Behind:       timeutil.OneHour,
+		Ahead:        timeutil.OneHour,
+		Rollups:      []option.RollupOption{{Interval: 5 * time.Minute, IntervalType: interval.Month}},
+	}, newMockIDGenerator(ctrl), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, models.ShardHot, s.State())
+	now := timeutil.Now()
+	point := models.NewPoint("cpu", now, nil, map[string]models.Field{
+		"count": models.NewSimpleField(field.SumField, field.Integer, int64(1)),
+	})
+	assert.Nil(t, s.Write(point))
+	assert.Nil(t, s.WriteBackfill(models.NewPoint("cpu", now-3*timeutil.OneDay, nil, point.Fields())))
+	assert.True(t, errors.Is(s.SetState(context.TODO(), "archived"), errors.ErrInvalidArgument))
+
+	// the writes are rejected, the data in memory is still queryable
+	assert.Nil(t, s.SetState(context.TODO(), models.ShardReadOnly))
+	assert.Equal(t, models.ShardReadOnly, s.State())
+	assert.True(t, errors.Is(s.Write(point), errors.ErrShardReadOnly))
+	assert.True(t, errors.Is(s.WriteBackfill(point), errors.ErrShardReadOnly))
+	assert.Len(t, s.MemoryDatabase().Families(), 1)
+	assert.Len(t, s.MemoryDatabases(), 2)
+
+	// the memory-databases are flushed then released
+	assert.Nil(t, s.SetState(context.TODO(), models.ShardFrozen))
+	assert.Equal(t, models.ShardFrozen, s.State())
+	assert.True(t, errors.Is(s.Write(point), errors.ErrShardReadOnly))
+	assert.Nil(t, s.MemoryDatabase())
+	assert.Nil(t, s.MemoryDatabases())
+	_, ok := s.MemoryDatabaseOf(interval.Day)
+	assert.False(t, ok)
+	assert.Nil(t, s.FlushBackfill(context.TODO()))
+	assert.Len(t, s.GetSegments(interval.Day, models.TimeRange{Start: now, End: now}), 1)
+	assert.Len(t, s.GetSegments(interval.Day,
+		models.TimeRange{Start: now - 3*timeutil.OneDay, End: now - 3*timeutil.OneDay}), 1)
+	assert.Len(t, s.GetSegments(interval.Month, models.TimeRange{Start: now, End: now}), 1)
+	assert.True(t, s.DiskUsage().Bytes > 0)
+
+	// the memory-databases are recreated after unfrozen
+	assert.Nil(t, s.SetState(context.TODO(), models.ShardHot))
+	assert.Empty(t, s.MemoryDatabase().Families())
+	memDB, ok := s.MemoryDatabaseOf(interval.Month)
+	assert.True(t, ok)
+	assert.Empty(t, memDB.Families())
+	assert.Nil(t, s.Write(point))
+	assert.Len(t, s.MemoryDatabase().Families(), 1)
+	s.Close()
+}
+
+func TestShard_Flush_Stat(t *testing.T) {
+	defer util.RemoveDir(testPath)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s, err := newShard("db", 1, path, option.ShardOption{
+		TimeWindow:   32,
+		Interval:     time.Second * 10,
+		IntervalType: interval.Day,
+		Behind:       timeutil.OneHour,
+		Ahead:        timeutil.OneHour,
+		Rollups:      []option.RollupOption{{Interval: 5 * time.Minute, IntervalType: interval.Month}},
+	}, newMockIDGenerator(ctrl), nil)
+	assert.Nil(t, err)
+	defer s.Close()
+	assert.Equal(t, []interval.Type{interval.Day, interval.Month}, s.IntervalTypes())
+	now := timeutil.Now()
+	assert.Nil(t, s.Write(models.NewPoint("cpu", now, map[string]string{"host": "1.1.1.1"}, map[string]models.Field{
+		"count": models.NewSimpleField(field.SumField, field.Integer, int64(1)),
+	})))
+	assert.Equal(t, models.ShardStat{Database: "db", ShardID: 1, NumOfSeries: 1, WrittenPoints: 1}, s.Stat())
+
+	// the memory-databases are flushed into the families of segments, and kept for writing
+	assert.Nil(t, s.Flush(context.TODO()))
+	assert.Len(t, s.GetSegments(interval.Day, models.TimeRange{Start: now, End: now}), 1)
+	assert.Len(t, s.GetSegments(interval.Month, models.TimeRange{Start: now, End: now}), 1)
+	assert.NotNil(t, s.MemoryDatabase())
+
+	// nothing flushed if frozen
+	assert.Nil(t, s.SetState(context.TODO(), models.ShardFrozen))
+	assert.Nil(t, s.Flush(context.TODO()))
+	assert.Equal(t, int64(0), s.Stat().NumOfSeries)
 }
 
 func TestGetSegments(t *testing.T) {
 	defer util.RemoveDir(testPath)
-	shard, _ := newShard(1, path, option.ShardOption{Interval: time.Second * 10, IntervalType: interval.Day})
+	shard, _ := newShard("db", 1, path,
+		option.ShardOption{Interval: time.Second * 10, IntervalType: interval.Day}, nil, nil)
 	assert.Nil(t, shard.GetSegments(interval.Month, models.TimeRange{}))
 	assert.Nil(t, shard.GetSegments(interval.Day, models.TimeRange{}))
 	assert.Equal(t, 0, len(shard.GetSegments(interval.Day, models.TimeRange{})))
 }
+
+// newMockIDGenerator returns the id generator which generates fixed ids
+func newMockIDGenerator(ctrl *gomock.Controller) index.IDGenerator {
+	generator := index.NewMockIDGenerator(ctrl)
+	generator.EXPECT().GenMetricID(gomock.Any()).Return(uint32(1)).AnyTimes()
+	generator.EXPECT().GenTSID(gomock.Any(), gomock.Any()).Return(uint32(1)).AnyTimes()
+	generator.EXPECT().GenFieldID(gomock.Any(), gomock.Any(), gomock.Any()).Return(uint32(1)).AnyTimes()
+	return generator
+}
+
+func TestShard_WarmUp(t *testing.T) {
+	defer util.RemoveDir(testPath)
+	s, err := newShard("db", 1, path, validOption, nil, nil)
+	assert.Nil(t, err)
+	defer s.Close()
+	stats, err := s.WarmUp(0)
+	assert.Nil(t, err)
+	assert.Equal(t, models.WarmUpStats{}, stats)
+
+	// flush a file into the family of each segment
+	for _, segmentName := range []string{"20190701", "20190702"} {
+		seg, err := s.(*shard).segments[interval.Day].GetOrCreateSegment(segmentName)
+		assert.Nil(t, err)
+		family, err := seg.(*segment).kvStore.CreateFamily("f", kv.FamilyOption{})
+		assert.Nil(t, err)
+		flusher := family.NewFlusher()
+		_ = flusher.Add(1, []byte("test"))
+		assert.Nil(t, flusher.Commit())
+	}
+	// the most recent segment
+	stats, err = s.WarmUp(1)
+	assert.Nil(t, err)
+	assert.Equal(t, models.WarmUpStats{Files: 1}, stats)
+	// all segments
+	stats, err = s.WarmUp(0)
+	assert.Nil(t, err)
+	assert.Equal(t, models.WarmUpStats{Files: 2}, stats)
+
+	// stops at the segment which fails
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	seg := NewMockSegment(ctrl)
+	seg.EXPECT().BaseTime().Return(int64(1)).AnyTimes()
+	seg.EXPECT().WarmUp().Return(models.WarmUpStats{Files: 1}, fmt.Errorf("err"))
+	seg.EXPECT().Close().AnyTimes()
+	s.(*shard).segments[interval.Day].(*intervalSegment).segments.Store("bad", seg)
+	stats, err = s.WarmUp(0)
+	assert.NotNil(t, err)
+	assert.Equal(t, models.WarmUpStats{Files: 3}, stats)
+}
@@ -0,0 +1,168 @@
+package tsdb
+
+import (
+	"sort"
+
+	"github.com/RoaringBitmap/roaring"
+
+	"github.com/eleme/lindb/kv"
+	"github.com/eleme/lindb/kv/version"
+	"github.com/eleme/lindb/tsdb/metrictbl"
+)
+
+//go:generate mockgen -source ./snapshot.go -destination=./snapshot_mock.go -package tsdb
+
+// Snapshot is the consistent view of shard for the long-running queries, the versions of the data families of
+// shard's segments and the index families of engine are acquired atomically, so that a flush or compaction
+// committed during the query never makes the series appear twice or vanish from the results.
+// NOTICE: the data in memory-database isn't included.
+type Snapshot interface {
+	// Segments returns the snapshots of segments which store the data of interval type in time range
+	Segments() []SegmentSnapshot
+	// GetMetricID returns the metric ID associated with a given name in the snapshot of metric index family,
+	// returns NotFoundMetricID if not exist
+	GetMetricID(metricName string) uint32
+	// GetTagValueBitmap returns the bitmap associated with a given tag value in the snapshot of tags index family
+	GetTagValueBitmap(metricID uint32, tagName string, tagValue string) *roaring.Bitmap
+	// Close releases the versions of families, must be called after the query completed
+	Close()
+}
+
+// SegmentSnapshot is the view of the data families of segment pinned by snapshot
+type SegmentSnapshot interface {
+	// BaseTime returns segment base time
+	BaseTime() int64
+	// Interval returns the interval(ms) of the data stored in segment
+	Interval() int64
+	// FamilyTimes returns the start times(ms) of the families pinned by snapshot in ascending order
+	FamilyTimes() []int64
+	// GetFamily returns the snapshot of the family which the family time(ms) belongs to, returns nil if not exist
+	GetFamily(familyTime int64) kv.FamilySnapshot
+	// GetFieldFamilies returns the snapshots of the families which store the fields in the family of time(ms),
+	// the families of other field groups aren't returned(projection push down), the family flushed before
+	// the fields are grouped is returned too
+	GetFieldFamilies(familyTime int64, fieldIDs []uint32) []kv.FamilySnapshot
+	// GetFamilyByName returns the snapshot of the family by name, returns nil if not exist,
+	// it's used for the families which aren't families of time, like the string family(see metrictbl.StringFamilyName)
+	GetFamilyByName(familyName string) kv.FamilySnapshot
+}
+
+// snapshot implements Snapshot interface
+type snapshot struct {
+	index    Index
+	metric   kv.FamilySnapshot
+	tags     kv.FamilySnapshot
+	segments []SegmentSnapshot
+	// families are all snapshots of families, released after closed
+	families []kv.FamilySnapshot
+}
+
+// newSnapshot acquires the snapshots of the index families and the data families of segments atomically,
+// the families of segments are listed in the barrier, so that the families created by a flush aren't missed.
+func newSnapshot(barrier *version.Barrier, index Index, segments []Segment) Snapshot {
+	s := &snapshot{index: index}
+	metricFamily, tagsFamily := index.IndexFamilies()
+	barrier.Acquire(func() {
+		s.metric = s.acquire(metricFamily)
+		s.tags = s.acquire(tagsFamily)
+		for _, segment := range segments {
+			segmentSnapshot := &segmentSnapshot{segment: segment, families: make(map[string]kv.FamilySnapshot)}
+			familyTimes := make(map[int64]bool)
+			for _, family := range segment.GetFamilies() {
+				segmentSnapshot.families[family.Name()] = s.acquire(family)
+				// the families of field groups(see metrictbl.FieldFamilyName) belong to the family of time,
+				// the string family isn't family of time
+				familyName := family.Name()
+				if name, _, ok := metrictbl.ParseFieldFamilyName(familyName); ok {
+					familyName = name
+				}
+				if familyTime, err := segment.FamilyTime(familyName); err == nil && !familyTimes[familyTime] {
+					familyTimes[familyTime] = true
+					segmentSnapshot.familyTimes = append(segmentSnapshot.familyTimes, familyTime)
+				}
+			}
+			sort.Slice(segmentSnapshot.familyTimes, func(i, j int) bool {
+				return segmentSnapshot.familyTimes[i] < segmentSnapshot.familyTimes[j]
+			})
+			s.segments = append(s.segments, segmentSnapshot)
+		}
+	})
+	return s
+}
+
+// acquire acquires the snapshot of family, which is released after closed
+func (s *snapshot) acquire(family kv.Family) kv.FamilySnapshot {
+	familySnapshot := family.GetFamilySnapshot()
+	s.families = append(s.families, familySnapshot)
+	return familySnapshot
+}
+
+// Segments returns the snapshots of segments
+func (s *snapshot) Segments() []SegmentSnapshot {
+	return s.segments
+}
+
+// GetMetricID returns the metric ID associated with a given name in the snapshot of metric index family
+func (s *snapshot) GetMetricID(metricName string) uint32 {
+	return s.index.GetMetricUID().GetMetricIDInSnapshot(s.metric, metricName)
+}
+
+// GetTagValueBitmap returns the bitmap associated with a given tag value in the snapshot of tags index family
+func (s *snapshot) GetTagValueBitmap(metricID uint32, tagName string, tagValue string) *roaring.Bitmap {
+	return s.index.GetTagsUID().GetTagValueBitmapInSnapshot(s.tags, metricID, tagName, tagValue)
+}
+
+// Close releases the versions of families
+func (s *snapshot) Close() {
+	for _, family := range s.families {
+		family.Close()
+	}
+}
+
+// segmentSnapshot implements SegmentSnapshot interface
+type segmentSnapshot struct {
+	segment Segment
+	// families are the snapshots of families keyed by family name
+	families    map[string]kv.FamilySnapshot
+	familyTimes []int64
+}
+
+// BaseTime returns segment base time
+func (s *segmentSnapshot) BaseTime() int64 {
+	return s.segment.BaseTime()
+}
+
+// Interval returns the interval(ms) of the data stored in segment
+func (s *segmentSnapshot) Interval() int64 {
+	return s.segment.Interval()
+}
+
+// FamilyTimes returns the start times(ms) of the families pinned by snapshot in ascending order
+func (s *segmentSnapshot) FamilyTimes() []int64 {
+	return s.familyTimes
+}
+
+// GetFamily returns the snapshot of the family which the family time(ms) belongs to
+func (s *segmentSnapshot) GetFamily(familyTime int64) kv.FamilySnapshot {
+	return s.families[s.segment.FamilyName(familyTime)]
+}
+
+// GetFieldFamilies returns the snapshots of the families which store the fields in the family of time(ms)
+func (s *segmentSnapshot) GetFieldFamilies(familyTime int64, fieldIDs []uint32) []kv.FamilySnapshot {
+	familyName := s.segment.FamilyName(familyTime)
+	var families []kv.FamilySnapshot
+	if family, ok := s.families[familyName]; ok {
+		families = append(families, family)
+	}
+	for _, name := range metrictbl.ProjectFieldFamilies(familyName, fieldIDs, fieldGroupOf) {
+		if family, ok := s.families[name]; ok {
+			families = append(families, family)
+		}
+	}
+	return families
+}
+
+// GetFamilyByName returns the snapshot of the family by name
+func (s *segmentSnapshot) GetFamilyByName(familyName string) kv.FamilySnapshot {
+	return s.families[familyName]
+}
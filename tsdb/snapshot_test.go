@@ -0,0 +1,113 @@
+package tsdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/kv"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/interval"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/pkg/util"
+	"github.com/eleme/lindb/tsdb/index"
+	"github.com/eleme/lindb/tsdb/metrictbl"
+)
+
+func TestEngine_GetSnapshot(t *testing.T) {
+	defer util.RemoveDir(testPath)
+
+	engine, _ := NewEngine("test_db", testPath, nil)
+	defer engine.Close()
+	assert.Nil(t, engine.CreateShards(validOption, 1))
+	idx := engine.GetIndex()
+	segment := engine.GetShard(1).(*shard).segments[interval.Day]
+	flush := func(familyTime int64, value string) {
+		seg, err := segment.GetOrCreateSegmentOf(familyTime)
+		assert.Nil(t, err)
+		family, err := seg.GetOrCreateFamily(familyTime)
+		assert.Nil(t, err)
+		flusher := family.NewFlusher()
+		assert.Nil(t, flusher.Add(1, []byte(value)))
+		assert.Nil(t, flusher.Commit())
+	}
+	lookup := func(family kv.FamilySnapshot) []string {
+		var values []string
+		family.Lookup(1, func(byteArray []byte) bool {
+			values = append(values, string(byteArray))
+			return false
+		})
+		return values
+	}
+	cpuID, _ := idx.GetMetricUID().GetOrCreateMetricID("cpu", true)
+	_, err := idx.GetTagsUID().GetOrCreateTagsID(cpuID, index.MapToString(map[string]string{"host": "1.1.1.1"}))
+	assert.Nil(t, err)
+	assert.Nil(t, idx.Flush())
+	flush(0, "a")
+
+	timeRange := models.TimeRange{Start: 0, End: 2 * timeutil.OneHour}
+	snapshot, err := engine.GetSnapshot(1, interval.Day, timeRange)
+	assert.Nil(t, err)
+	// the index and data flushed after acquired aren't visible to the snapshot
+	memID, _ := idx.GetMetricUID().GetOrCreateMetricID("mem", true)
+	_, err = idx.GetTagsUID().GetOrCreateTagsID(cpuID, index.MapToString(map[string]string{"host": "1.1.1.2"}))
+	assert.Nil(t, err)
+	assert.Nil(t, idx.Flush())
+	flush(0, "b")
+	flush(timeutil.OneHour, "c")
+
+	assert.Equal(t, cpuID, snapshot.GetMetricID("cpu"))
+	assert.Equal(t, index.NotFoundMetricID, snapshot.GetMetricID("mem"))
+	assert.Equal(t, memID, idx.GetMetricUID().GetMetricID("mem"))
+	assert.Equal(t, uint64(1), snapshot.GetTagValueBitmap(cpuID, "host", "1.1.1.1").GetCardinality())
+	assert.Nil(t, snapshot.GetTagValueBitmap(cpuID, "host", "1.1.1.2"))
+	assert.NotNil(t, idx.GetTagsUID().GetTagValueBitmap(cpuID, "host", "1.1.1.2"))
+	segments := snapshot.Segments()
+	assert.Len(t, segments, 1)
+	assert.Equal(t, int64(0), segments[0].BaseTime())
+	assert.Equal(t, int64(validOption.Interval/time.Millisecond), segments[0].Interval())
+	assert.Equal(t, []int64{0}, segments[0].FamilyTimes())
+	assert.Equal(t, []string{"a"}, lookup(segments[0].GetFamily(0)))
+	assert.Nil(t, segments[0].GetFamily(timeutil.OneHour))
+	snapshot.Close()
+
+	snapshot, err = engine.GetSnapshot(1, interval.Day, timeRange)
+	assert.Nil(t, err)
+	assert.Equal(t, memID, snapshot.GetMetricID("mem"))
+	segments = snapshot.Segments()
+	assert.Equal(t, []int64{0, timeutil.OneHour}, segments[0].FamilyTimes())
+	assert.ElementsMatch(t, []string{"a", "b"}, lookup(segments[0].GetFamily(0)))
+	assert.Equal(t, []string{"c"}, lookup(segments[0].GetFamily(timeutil.OneHour)))
+	snapshot.Close()
+
+	// the families of field groups belong to the family of time, only the families of fields are returned
+	seg, err := segment.GetOrCreateSegmentOf(2 * timeutil.OneHour)
+	assert.Nil(t, err)
+	for group, value := range map[uint32]string{1: "d", 2: "e"} {
+		family, err := seg.GetOrCreateFamilyByName(metrictbl.FieldFamilyName(seg.FamilyName(2*timeutil.OneHour), group))
+		assert.Nil(t, err)
+		flusher := family.NewFlusher()
+		assert.Nil(t, flusher.Add(1, []byte(value)))
+		assert.Nil(t, flusher.Commit())
+	}
+	snapshot, err = engine.GetSnapshot(1, interval.Day, timeRange)
+	assert.Nil(t, err)
+	segments = snapshot.Segments()
+	assert.Equal(t, []int64{0, timeutil.OneHour, 2 * timeutil.OneHour}, segments[0].FamilyTimes())
+	lookupAll := func(families []kv.FamilySnapshot) []string {
+		var values []string
+		for _, family := range families {
+			values = append(values, lookup(family)...)
+		}
+		return values
+	}
+	assert.Equal(t, []string{"d"}, lookupAll(segments[0].GetFieldFamilies(2*timeutil.OneHour, []uint32{1})))
+	assert.Equal(t, []string{"d", "e"}, lookupAll(segments[0].GetFieldFamilies(2*timeutil.OneHour, []uint32{2, 1})))
+	// the family flushed before the fields are grouped
+	assert.ElementsMatch(t, []string{"a", "b"}, lookupAll(segments[0].GetFieldFamilies(0, []uint32{1})))
+	snapshot.Close()
+
+	_, err = engine.GetSnapshot(2, interval.Day, timeRange)
+	assert.NotNil(t, err)
+}
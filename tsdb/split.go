@@ -0,0 +1,303 @@
+package tsdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/RoaringBitmap/roaring"
+
+	"github.com/eleme/lindb/kv"
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/logger"
+	"github.com/eleme/lindb/tsdb/metrictbl"
+)
+
+// seriesMigration moves the series whose hash is in the hash range from the families of split shard
+// into the families of new shard, the ids of metrics and series are shared by the shards of engine.
+type seriesMigration struct {
+	engine      *engine
+	hashRange   models.HashRange
+	routingTags []string
+	// metricNames caches the names of metrics for computing the hash of series
+	metricNames map[uint32]string
+}
+
+// migratedFile represents the metric blocks of a file of family, which are split into the kept and moved parts
+type migratedFile struct {
+	metricIDs   []uint32
+	kept, moved map[uint32][]byte
+	movedBlocks int
+}
+
+// MigrateSeries moves the data of the series whose hash is in the range from the shard into new shard after
+// the shard split. The memory-databases of shard are flushed first, because the series of new shard are written
+// into the shard before the routing epoch. For each family, the moved series are committed into new shard,
+// then the files of family are replaced by the kept series, so a retry after failure may duplicate the moved
+// data of the family which failed, but never loses it.
+func (e *engine) MigrateSeries(ctx context.Context, shardID, newShardID int,
+	hashRange models.HashRange, routingTags []string) error {
+	from, err := e.getOnlineShard(shardID)
+	if err != nil {
+		return err
+	}
+	to, err := e.getOnlineShard(newShardID)
+	if err != nil {
+		return err
+	}
+	if err := from.Flush(ctx); err != nil {
+		return fmt.Errorf("flush shard[%d] of engine[%s] error:%s", shardID, e.name, err)
+	}
+	m := &seriesMigration{
+		engine:      e,
+		hashRange:   hashRange,
+		routingTags: routingTags,
+		metricNames: make(map[uint32]string),
+	}
+	movedFamilies := 0
+	for _, intervalType := range from.IntervalTypes() {
+		fromSegment, _ := from.GetIntervalSegment(intervalType)
+		toSegment, ok := to.GetIntervalSegment(intervalType)
+		if !ok {
+			return fmt.Errorf("interval[%s] of shard[%d] in engine[%s] not found", intervalType, newShardID, e.name)
+		}
+		for _, segment := range fromSegment.GetAllSegments() {
+			for _, family := range segment.GetFamilies() {
+				moved, err := m.migrateFamily(ctx, segment, family, toSegment)
+				if err != nil {
+					return fmt.Errorf("migrate series of family[%s] in shard[%d] of engine[%s] error:%s",
+						family.Name(), shardID, e.name, err)
+				}
+				if moved {
+					movedFamilies++
+				}
+			}
+		}
+	}
+	e.logger.Info("migrate series into new shard successfully", logger.String("engine", e.name),
+		logger.Any("shard", shardID), logger.Any("newShard", newShardID), logger.Any("families", movedFamilies))
+	return nil
+}
+
+// migrateFamily moves the series of family into the family of new shard, returns false if no series moved
+func (m *seriesMigration) migrateFamily(ctx context.Context, segment Segment, family kv.Family,
+	toSegment IntervalSegment) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	// captures the files of family before reading them, so that the files flushed later are kept
+	replacer := family.NewReplaceFlusher(ctx)
+	snapshot, err := family.GetSnapshotOfAllFiles()
+	if err != nil {
+		return false, err
+	}
+	defer snapshot.Close()
+
+	// the string blocks of the string family are split by the entries of series
+	split := m.splitBlock
+	if family.Name() == metrictbl.StringFamilyName {
+		split = m.splitStringBlock
+	}
+	// the blocks of metric in different files aren't merged, so the files are split one by one
+	var files []*migratedFile
+	movedBlocks := 0
+	for _, reader := range snapshot.Readers() {
+		file := &migratedFile{kept: make(map[uint32][]byte), moved: make(map[uint32][]byte)}
+		it := reader.Iterator()
+		for it.Next() {
+			metricID := it.Key()
+			kept, moved, err := split(metricID, it.Value())
+			if err != nil {
+				return false, err
+			}
+			file.metricIDs = append(file.metricIDs, metricID)
+			if kept != nil {
+				file.kept[metricID] = kept
+			}
+			if moved != nil {
+				file.moved[metricID] = moved
+				file.movedBlocks++
+			}
+		}
+		if err := it.Err(); err != nil {
+			return false, err
+		}
+		movedBlocks += file.movedBlocks
+		files = append(files, file)
+	}
+	if movedBlocks == 0 {
+		replacer.Abort()
+		return false, nil
+	}
+	if err := m.commitMoved(ctx, segment, family, toSegment, files); err != nil {
+		replacer.Abort()
+		return false, err
+	}
+	for idx, file := range files {
+		if idx > 0 {
+			if err := replacer.NextFile(); err != nil {
+				return false, err
+			}
+		}
+		// the keys are added in ascending order as the iterator of file
+		for _, metricID := range file.metricIDs {
+			if block, ok := file.kept[metricID]; ok {
+				if err := replacer.Add(metricID, block); err != nil {
+					replacer.Abort()
+					return false, err
+				}
+			}
+		}
+	}
+	if err := replacer.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// commitMoved flushes the moved blocks of each file into a new file of the family in new shard
+func (m *seriesMigration) commitMoved(ctx context.Context, segment Segment, family kv.Family,
+	toSegment IntervalSegment, files []*migratedFile) error {
+	seg, err := toSegment.GetOrCreateSegmentOf(segment.BaseTime())
+	if err != nil {
+		return err
+	}
+	// the segments of shards have same base time, so the family of new shard has same name
+	toFamily, err := seg.GetOrCreateFamilyByName(family.Name())
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if file.movedBlocks == 0 {
+			continue
+		}
+		flusher := toFamily.NewFlusherWithContext(ctx)
+		for _, metricID := range file.metricIDs {
+			if block, ok := file.moved[metricID]; ok {
+				if err := flusher.Add(metricID, block); err != nil {
+					return err
+				}
+			}
+		}
+		if err := flusher.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitBlock splits the series of metric block by the hash range, returns the blocks of the kept and moved series,
+// the block is nil if no series. The block is returned as kept if no series moved.
+func (m *seriesMigration) splitBlock(metricID uint32, block []byte) (kept, moved []byte, err error) {
+	metricName, err := m.getMetricName(metricID)
+	if err != nil {
+		return nil, nil, err
+	}
+	reader, err := metrictbl.NewBlockReader(block)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read block of metric[%s] error:%s", metricName, err)
+	}
+	seriesTags := m.engine.index.GetTagsUID().GetSeriesTags(metricID, reader.SeriesIDs())
+	keptEntries := make(map[uint32][]byte)
+	movedEntries := make(map[uint32][]byte)
+	reader.Entries(func(seriesID uint32, entry []byte) {
+		if err != nil {
+			return
+		}
+		var isMoved bool
+		if isMoved, err = m.isMoved(metricName, seriesID, seriesTags); err != nil {
+			return
+		}
+		if isMoved {
+			movedEntries[seriesID] = entry
+		} else {
+			keptEntries[seriesID] = entry
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(movedEntries) == 0 {
+		return block, nil, nil
+	}
+	startTime, endTime := reader.TimeRange()
+	fieldIDs := reader.FieldIDs()
+	if moved, err = metrictbl.EncodeBlock(fieldIDs, startTime, endTime, movedEntries); err != nil {
+		return nil, nil, err
+	}
+	if len(keptEntries) > 0 {
+		if kept, err = metrictbl.EncodeBlock(fieldIDs, startTime, endTime, keptEntries); err != nil {
+			return nil, nil, err
+		}
+	}
+	return kept, moved, nil
+}
+
+// splitStringBlock splits the entries of string block by the hash range like splitBlock
+func (m *seriesMigration) splitStringBlock(metricID uint32, data []byte) (kept, moved []byte, err error) {
+	metricName, err := m.getMetricName(metricID)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, err := metrictbl.DecodeStringBlock(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read string block of metric[%s] error:%s", metricName, err)
+	}
+	seriesIDs := roaring.New()
+	for _, entry := range block.Entries {
+		seriesIDs.Add(entry.TSID)
+	}
+	seriesTags := m.engine.index.GetTagsUID().GetSeriesTags(metricID, seriesIDs)
+	blocks := block.Split(func(entry metrictbl.StringEntry) int64 {
+		if err != nil {
+			return 0
+		}
+		var isMoved bool
+		if isMoved, err = m.isMoved(metricName, entry.TSID, seriesTags); isMoved {
+			return 1
+		}
+		return 0
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if blocks[1] == nil {
+		return data, nil, nil
+	}
+	if moved, err = metrictbl.EncodeStringBlock(blocks[1]); err != nil {
+		return nil, nil, err
+	}
+	if blocks[0] != nil {
+		if kept, err = metrictbl.EncodeStringBlock(blocks[0]); err != nil {
+			return nil, nil, err
+		}
+	}
+	return kept, moved, nil
+}
+
+// isMoved returns if the hash of series is in the hash range, the tags of series are resolved from seriesTags
+func (m *seriesMigration) isMoved(metricName string, seriesID uint32, seriesTags map[uint32]string) (bool, error) {
+	tagsString, ok := seriesTags[seriesID]
+	if !ok {
+		return false, fmt.Errorf("tags of series[%d] of metric[%s] not found", seriesID, metricName)
+	}
+	// the tags of series in index are the json of tags, see models.Point
+	tags := make(map[string]string)
+	if err := json.Unmarshal([]byte(tagsString), &tags); err != nil {
+		return false, fmt.Errorf("unmarshal tags[%s] of metric[%s] error:%s", tagsString, metricName, err)
+	}
+	return m.hashRange.Contains(models.SeriesHash(metricName, tags, m.routingTags)), nil
+}
+
+// getMetricName returns the name of metric by id from index, the names are cached during migration
+func (m *seriesMigration) getMetricName(metricID uint32) (string, error) {
+	if metricName, ok := m.metricNames[metricID]; ok {
+		return metricName, nil
+	}
+	metricName, ok := m.engine.index.GetMetricUID().GetMetricNames(roaring.BitmapOf(metricID))[metricID]
+	if !ok {
+		return "", fmt.Errorf("name of metric[%d] not found in index of engine[%s]", metricID, m.engine.name)
+	}
+	m.metricNames[metricID] = metricName
+	return metricName, nil
+}
@@ -0,0 +1,133 @@
+package tsdb
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/RoaringBitmap/roaring"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eleme/lindb/models"
+	"github.com/eleme/lindb/pkg/interval"
+	"github.com/eleme/lindb/pkg/timeutil"
+	"github.com/eleme/lindb/pkg/util"
+	"github.com/eleme/lindb/tsdb/metrictbl"
+)
+
+func TestEngine_MigrateSeries(t *testing.T) {
+	defer func() {
+		_ = util.RemoveDir(testPath)
+	}()
+	e, _ := NewEngine("test_db", testPath, nil)
+	_ = e.CreateShards(validOption, 1, 2)
+	defer func() {
+		_ = e.Close()
+	}()
+
+	var hosts []string
+	for _, host := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		tags, _ := json.Marshal(map[string]string{"host": host})
+		hosts = append(hosts, string(tags))
+	}
+	timestamp, _ := timeutil.ParseTimestamp("20190702 10:00:00", "20060102 15:04:05")
+	family, familyName := getTestFamily(t, e, timestamp)
+	// the blocks of same metric are in different files
+	flushTestBlocks(t, e, family, map[string][]string{"cpu": hosts[:4], "mem": hosts})
+	flushTestBlocks(t, e, family, map[string][]string{"cpu": hosts[4:]})
+	// the last values of string fields are flushed before migrating
+	for _, metricName := range []string{"cpu", "mem"} {
+		for _, host := range []string{"a", "b"} {
+			assert.Nil(t, e.GetShard(1).MemoryDatabase().Write(models.NewPoint(metricName, timestamp,
+				map[string]string{"host": host}, map[string]models.Field{"version": models.NewStringField("v-" + host)})))
+		}
+	}
+
+	req := models.FamilyExportRequest{Database: "test_db", ShardID: 1, IntervalType: interval.Day,
+		Segment: testSegment, Family: familyName}
+	before, err := e.ExportFamily(req)
+	assert.Nil(t, err)
+
+	hashRange := models.HashRange{Start: math.MaxUint32 / 2, End: math.MaxUint32}
+	assert.Nil(t, e.MigrateSeries(context.TODO(), 1, 2, hashRange, nil))
+
+	kept, err := e.ExportFamily(req)
+	assert.Nil(t, err)
+	req.ShardID = 2
+	moved, err := e.ExportFamily(req)
+	assert.Nil(t, err)
+	// the series are moved by the hash range, the blocks of different files are kept apart
+	var series, keptSeries, movedSeries []string
+	for _, block := range before.Blocks {
+		for _, entry := range block.Series {
+			series = append(series, block.Metric+entry.Tags)
+		}
+	}
+	for _, block := range kept.Blocks {
+		for _, entry := range block.Series {
+			assert.False(t, hashRange.Contains(seriesHashOf(t, block.Metric, entry.Tags)))
+			keptSeries = append(keptSeries, block.Metric+entry.Tags)
+		}
+	}
+	for _, block := range moved.Blocks {
+		for _, entry := range block.Series {
+			assert.True(t, hashRange.Contains(seriesHashOf(t, block.Metric, entry.Tags)))
+			movedSeries = append(movedSeries, block.Metric+entry.Tags)
+		}
+	}
+	assert.NotEmpty(t, keptSeries)
+	assert.NotEmpty(t, movedSeries)
+	assert.ElementsMatch(t, series, append(keptSeries, movedSeries...))
+	// the entries of string blocks are moved by the hash range too
+	assert.Equal(t, map[string]string{`cpu{"host":"a"}`: "v-a", `cpu{"host":"b"}`: "v-b"}, stringValuesOf(t, e, 1))
+	assert.Equal(t, map[string]string{`mem{"host":"a"}`: "v-a", `mem{"host":"b"}`: "v-b"}, stringValuesOf(t, e, 2))
+
+	// nothing moved if migrated again
+	assert.Nil(t, e.MigrateSeries(context.TODO(), 1, 2, hashRange, nil))
+	req.ShardID = 1
+	result, err := e.ExportFamily(req)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, kept.Blocks, result.Blocks)
+
+	// shard not found
+	assert.NotNil(t, e.MigrateSeries(context.TODO(), 1, 3, hashRange, nil))
+	assert.NotNil(t, e.MigrateSeries(context.TODO(), 3, 2, hashRange, nil))
+}
+
+func seriesHashOf(t *testing.T, metricName, tagsString string) uint32 {
+	tags := make(map[string]string)
+	assert.Nil(t, json.Unmarshal([]byte(tagsString), &tags))
+	return models.SeriesHash(metricName, tags, nil)
+}
+
+// stringValuesOf returns the last values of string fields in the string family of shard, key: metric name + tags
+func stringValuesOf(t *testing.T, e Engine, shardID int) map[string]string {
+	intervalSegment, _ := e.GetShard(shardID).GetIntervalSegment(interval.Day)
+	segment, err := intervalSegment.GetOrCreateSegment(testSegment)
+	assert.Nil(t, err)
+	family, err := segment.GetOrCreateFamilyByName(metrictbl.StringFamilyName)
+	assert.Nil(t, err)
+	snapshot, err := family.GetSnapshotOfAllFiles()
+	assert.Nil(t, err)
+	defer snapshot.Close()
+	values := make(map[string]string)
+	for _, reader := range snapshot.Readers() {
+		it := reader.Iterator()
+		for it.Next() {
+			metricID := it.Key()
+			metricName := e.GetIndex().GetMetricUID().GetMetricNames(roaring.BitmapOf(metricID))[metricID]
+			block, err := metrictbl.DecodeStringBlock(it.Value())
+			assert.Nil(t, err)
+			seriesIDs := roaring.New()
+			for _, entry := range block.Entries {
+				seriesIDs.Add(entry.TSID)
+			}
+			seriesTags := e.GetIndex().GetTagsUID().GetSeriesTags(metricID, seriesIDs)
+			for _, entry := range block.Entries {
+				values[metricName+seriesTags[entry.TSID]], _ = block.Value(entry)
+			}
+		}
+	}
+	return values
+}
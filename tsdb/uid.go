@@ -3,15 +3,23 @@ package tsdb
 import (
 	"github.com/RoaringBitmap/roaring"
 
+	"github.com/eleme/lindb/kv"
 	"github.com/eleme/lindb/pkg/field"
+	"github.com/eleme/lindb/tsdb/index"
 )
 
 //MetricUid represents metric name unique id under the database
 type MetricUID interface {
 	//GetOrCreateMetricID returns find the metric ID associated with a given name or create it.
 	GetOrCreateMetricID(metricName string, create bool) (uint32, bool)
-	//SuggestMetrics returns suggestions of metric names given a search prefix.
-	SuggestMetrics(prefix string, limit int16) map[string]struct{}
+	//GetMetricID returns find the metric ID associated with a given name, if not exist returns NotFoundMetricID.
+	GetMetricID(metricName string) uint32
+	//GetMetricIDInSnapshot returns find the metric ID associated with a given name in the snapshot of metric family.
+	GetMetricIDInSnapshot(snapshot kv.FamilySnapshot, metricName string) uint32
+	//SuggestMetrics returns sorted suggestions of metric names given a search prefix, paged by offset and limit.
+	SuggestMetrics(prefix string, offset, limit int) []string
+	//GetMetricNames returns the names of the metric ids, the id not found is skipped.
+	GetMetricNames(metricIDs *roaring.Bitmap) map[uint32]string
 	//Flush represents forces a flush of in-memory data, and clear it
 	Flush() error
 }
@@ -21,14 +29,23 @@ type MetricUID interface {
 type TagsUID interface {
 	//GetOrCreateTagsID returns find the tags ID associated with given tags or create it.
 	GetOrCreateTagsID(metricID uint32, tags string) (uint32, error)
-	//GetTagNames return get all tag names within the metric name
-	GetTagNames(metricID uint32, limit int16) map[string]struct{}
+	//GetSeriesTags returns the tags of the series ids under the metric
+	GetSeriesTags(metricID uint32, seriesIDs *roaring.Bitmap) map[uint32]string
+	//GetTagNames return get sorted tag names within the metric name
+	GetTagNames(metricID uint32, limit int) []string
 	//GetTagValueBitmap returns find bitmap associated with a given tag value
 	GetTagValueBitmap(metricID uint32, tagName string, tagValue string) *roaring.Bitmap
-	//SuggestTagValues returns  suggestions of tag values given a search prefix
-	SuggestTagValues(metricID uint32, tagName string, tagValuePrefix string, limit uint16) map[string]struct{}
+	//GetTagValueBitmapInSnapshot returns find bitmap associated with a given tag value in the snapshot of tags family
+	GetTagValueBitmapInSnapshot(snapshot kv.FamilySnapshot, metricID uint32, tagName string,
+		tagValue string) *roaring.Bitmap
+	//SuggestTagValues returns sorted suggestions of tag values given a search prefix
+	SuggestTagValues(metricID uint32, tagName string, tagValuePrefix string, limit int) []string
 	//Flush represents forces a flush of in-memory data, and clear it
 	Flush() error
+	//GetCardinality returns the sketches of series and tag values under the metric
+	GetCardinality(metricID uint32) (*index.MetricCardinality, error)
+	//Compact merges the tags data flushed into different files
+	Compact() error
 }
 
 //FieldUID represents field unique under the metric name.
@@ -40,6 +57,8 @@ type FieldUID interface {
 	GetFields(metricID uint32, limit int16) map[string]struct{}
 	//GetFieldID returns get fieldID by fieldName within the metric name
 	GetFieldID(metricID uint32, fieldName string) uint32
+	//GetFieldNames returns the names of all fields within the metric name keyed by field id
+	GetFieldNames(metricID uint32) map[uint32]string
 	//Flush represents forces a flush of in-memory data, and clear it
 	Flush() error
 }